@@ -0,0 +1,45 @@
+package app
+
+import (
+	"sync"
+)
+
+// DiagnosticsHandler collects the diagnostics data when a command fails
+// (it's called after the command cleanup handlers, so the partial command reports are already saved)
+type DiagnosticsHandler func(xc *ExecutionContext, exitCode int)
+
+var diagnostics struct {
+	mu      sync.Mutex
+	handler DiagnosticsHandler
+	once    sync.Once
+}
+
+// SetDiagnosticsHandler enables the diagnostics data collection for the failed commands
+func SetDiagnosticsHandler(handler DiagnosticsHandler) {
+	diagnostics.mu.Lock()
+	defer diagnostics.mu.Unlock()
+	diagnostics.handler = handler
+}
+
+// DiagnosticsEnabled returns true if the diagnostics data is collected when a command fails
+// (the commands can use it to keep the extra troubleshooting data, like the temporary container logs)
+func DiagnosticsEnabled() bool {
+	diagnostics.mu.Lock()
+	defer diagnostics.mu.Unlock()
+	return diagnostics.handler != nil
+}
+
+// collectDiagnostics calls the diagnostics handler (only once per app run)
+func (ref *ExecutionContext) collectDiagnostics(exitCode int) {
+	diagnostics.mu.Lock()
+	handler := diagnostics.handler
+	diagnostics.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+
+	diagnostics.once.Do(func() {
+		handler(ref, exitCode)
+	})
+}
@@ -0,0 +1,207 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// Error categories
+const (
+	//Docker connection and Docker API errors
+	ErrorCategoryDocker = "docker"
+	//target image errors (missing image, image pull or image build failures)
+	ErrorCategoryImage = "image"
+	//temporary container errors (container start, monitoring or artifact collection failures)
+	ErrorCategoryContainer = "container"
+	//container registry and network errors
+	ErrorCategoryNetwork = "network"
+	//bad command parameters or input files
+	ErrorCategoryInput = "input"
+	//local file system errors
+	ErrorCategoryFilesystem = "filesystem"
+	//unexpected errors
+	ErrorCategoryInternal = "internal"
+)
+
+// Default error exit codes (by error category)
+const (
+	ExitCodeInternal   = 1
+	ExitCodeDocker     = 10
+	ExitCodeImage      = 11
+	ExitCodeContainer  = 12
+	ExitCodeNetwork    = 13
+	ExitCodeInput      = 14
+	ExitCodeFilesystem = 15
+)
+
+var errorCategoryExitCodes = map[string]int{
+	ErrorCategoryDocker:     ExitCodeDocker,
+	ErrorCategoryImage:      ExitCodeImage,
+	ErrorCategoryContainer:  ExitCodeContainer,
+	ErrorCategoryNetwork:    ExitCodeNetwork,
+	ErrorCategoryInput:      ExitCodeInput,
+	ErrorCategoryFilesystem: ExitCodeFilesystem,
+	ErrorCategoryInternal:   ExitCodeInternal,
+}
+
+// Error is a structured command error
+type Error struct {
+	Category string
+	//error type (e.g., 'image.pull')
+	Type    string
+	Message string
+	//remediation hint (optional)
+	Hint     string
+	ExitCode int
+	Err      error
+}
+
+// NewError creates a new command error (the exit code is based on the error category)
+func NewError(category, errType, message, hint string) *Error {
+	return &Error{
+		Category: category,
+		Type:     errType,
+		Message:  message,
+		Hint:     hint,
+		ExitCode: categoryExitCode(category),
+	}
+}
+
+// WrapError wraps the error into a command error (returns nil if there's no error)
+// (the category, the type and the hint are not changed if the error is already a command error)
+func WrapError(err error, category, errType, hint string) error {
+	if err == nil {
+		return nil
+	}
+
+	var xerr *Error
+	if errors.As(err, &xerr) {
+		return err
+	}
+
+	xerr = NewError(category, errType, err.Error(), hint)
+	xerr.Err = err
+	return xerr
+}
+
+func (e *Error) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("%s: %s", e.Type, e.Message)
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitError is the command exit with a command specific exit code
+// (the command already showed the exit info, so it's not shown as a structured error)
+type ExitError struct {
+	ExitCode int
+}
+
+// NewExitError creates a new command exit error
+func NewExitError(exitCode int) *ExitError {
+	return &ExitError{ExitCode: exitCode}
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.ExitCode)
+}
+
+// ReportInfo returns the error info for the command report
+func (e *Error) ReportInfo() *report.ErrorInfo {
+	return &report.ErrorInfo{
+		Category: e.Category,
+		Type:     e.Type,
+		Message:  e.Message,
+		Hint:     e.Hint,
+		ExitCode: e.ExitCode,
+	}
+}
+
+// AsError returns the command error for the error
+// (the known Docker, network and file system errors are categorized, the other errors are internal errors)
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var xerr *Error
+	if errors.As(err, &xerr) {
+		if xerr.ExitCode == 0 {
+			xerr.ExitCode = categoryExitCode(xerr.Category)
+		}
+
+		return xerr
+	}
+
+	category := ErrorCategoryInternal
+	errType := "internal"
+	var hint string
+
+	var apiErr *dockerapi.Error
+	var noContainerErr *dockerapi.NoSuchContainer
+	var pathErr *os.PathError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, dockerapi.ErrConnectionRefused):
+		category = ErrorCategoryDocker
+		errType = "docker.connect"
+		hint = "make sure Docker is running and the Docker connection settings (--host or DOCKER_HOST) are correct"
+	case errors.Is(err, dockerapi.ErrNoSuchImage):
+		category = ErrorCategoryImage
+		errType = "image.not.found"
+		hint = "make sure the target image exists locally (or use --pull to download it from the registry)"
+	case errors.As(err, &noContainerErr):
+		category = ErrorCategoryContainer
+		errType = "container.not.found"
+		hint = "the temporary container exited or it was removed (use --show-clogs to see the container logs)"
+	case errors.As(err, &apiErr):
+		category = ErrorCategoryDocker
+		errType = "docker.api"
+		if apiErr.Status == 401 || apiErr.Status == 403 {
+			hint = "check the registry credentials (--docker-config-path or --registry-account/--registry-secret)"
+		}
+	case errors.As(err, &pathErr):
+		category = ErrorCategoryFilesystem
+		errType = "filesystem"
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			hint = fmt.Sprintf("make sure '%s' exists", pathErr.Path)
+		case errors.Is(err, os.ErrPermission):
+			hint = fmt.Sprintf("make sure you have the permissions to access '%s' (or use --state-path to select a writable state directory)", pathErr.Path)
+		}
+	case errors.As(err, &netErr):
+		category = ErrorCategoryNetwork
+		errType = "network"
+		hint = "check the network connection and the registry address"
+	}
+
+	xerr = NewError(category, errType, err.Error(), hint)
+	xerr.Err = err
+	return xerr
+}
+
+// logFailure logs the original error with the stack trace (the stack is useful in the debug logs)
+func logFailure(err error) {
+	log.WithError(err).WithField("stack", string(debug.Stack())).Debug("docker-slim: failure")
+}
+
+func categoryExitCode(category string) int {
+	if code, ok := errorCategoryExitCodes[category]; ok {
+		return code
+	}
+
+	return ExitCodeInternal
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// Output formats
+const (
+	//the command event stream
+	OutputFormatJSONL = "jsonl"
+	//the JSON console output (including the structured command errors)
+	OutputFormatJSON = "json"
+)
+
+var ErrUnknownOutputFormat = errors.New("unknown output format")
+
+// eventStream writes the command output events (one JSON object per line) to the event sinks
+type eventStream struct {
+	mu    sync.Mutex
+	sinks []eventSink
+	seq   uint64
+	//true if the regular console output is disabled (the events go to stdout)
+	noConsole bool
+}
+
+var events *eventStream
+
+// StartEventStream enables the command output event stream
+// (to the output file or to stdout replacing the regular console output)
+func StartEventStream(format, filePath string) error {
+	if format != OutputFormatJSONL {
+		return fmt.Errorf("%w - '%s'", ErrUnknownOutputFormat, format)
+	}
+
+	stream := eventStreamInstance()
+	if filePath == "" || filePath == "-" {
+		stream.addSink(&writerSink{w: os.Stdout})
+		stream.noConsole = true
+		return nil
+	}
+
+	sink, err := newFileSink(filePath)
+	if err != nil {
+		return err
+	}
+
+	stream.addSink(sink)
+	return nil
+}
+
+// StopEventStream disables the event stream (closing the event sinks)
+func StopEventStream() {
+	stream := events
+	events = nil
+	if stream == nil {
+		return
+	}
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	for _, sink := range stream.sinks {
+		sink.close()
+	}
+
+	stream.sinks = nil
+}
+
+func eventStreamInstance() *eventStream {
+	if events == nil {
+		events = &eventStream{}
+	}
+
+	return events
+}
+
+func (ref *eventStream) addSink(sink eventSink) {
+	ref.mu.Lock()
+	defer ref.mu.Unlock()
+	ref.sinks = append(ref.sinks, sink)
+}
+
+// consoleEnabled returns false if the event stream replaces the regular console output
+func consoleEnabled() bool {
+	return events == nil || !events.noConsole
+}
+
+func emitEvent(cmdName, eventType, name, message string, params []OutVars) {
+	stream := events
+	if stream == nil {
+		return
+	}
+
+	event := &report.Event{
+		SchemaVersion: report.EventSchemaVersion,
+		Time:          time.Now().UTC(),
+		Command:       cmdName,
+		Type:          eventType,
+		Name:          name,
+		Message:       message,
+	}
+
+	if len(params) > 0 && len(params[0]) > 0 {
+		event.Data = map[string]interface{}{}
+		for k, v := range params[0] {
+			event.Data[k] = eventValue(v)
+		}
+	}
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	stream.seq++
+	event.Seq = stream.seq
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	for _, sink := range stream.sinks {
+		//a broken sink must not fail the command
+		sink.write(event, data)
+	}
+}
+
+// eventValue keeps the JSON compatible values and converts the other values to strings
+func eventValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return v
+	case error:
+		return v.Error()
+	}
+
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return value
+}
@@ -1,16 +1,20 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 
 	"github.com/docker-slim/docker-slim/pkg/consts"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/report"
 )
 
 const (
@@ -19,53 +23,190 @@ const (
 )
 
 type ExecutionContext struct {
-	Out             *Output
+	Out *Output
+	//Context is canceled when the command is interrupted
+	//(use it for the Docker API calls and the other long running operations)
+	Context         context.Context
+	mu              sync.Mutex
 	cleanupHandlers []func()
+	err             *Error
+	//cancel is set for the partial execution contexts (see NewPartialExecutionContext)
+	cancel   context.CancelFunc
+	abortErr error
 }
 
 func (ref *ExecutionContext) Exit(exitCode int) {
 	ref.doCleanup()
+	if exitCode != 0 && !IsInterrupted() {
+		ref.collectDiagnostics(exitCode)
+	}
+
 	ref.exit(exitCode)
 }
 
 func (ref *ExecutionContext) AddCleanupHandler(handler func()) {
 	if handler != nil {
+		ref.mu.Lock()
+		defer ref.mu.Unlock()
 		ref.cleanupHandlers = append(ref.cleanupHandlers, handler)
 	}
 }
 
+// doCleanup calls the cleanup handlers (only once; the command can be interrupted while it's exiting)
 func (ref *ExecutionContext) doCleanup() {
-	if len(ref.cleanupHandlers) == 0 {
+	ref.mu.Lock()
+	handlers := ref.cleanupHandlers
+	ref.cleanupHandlers = nil
+	ref.mu.Unlock()
+
+	if len(handlers) == 0 {
 		return
 	}
 
 	//call cleanup handlers in reverse order
-	for i := len(ref.cleanupHandlers) - 1; i >= 0; i-- {
-		cleanup := ref.cleanupHandlers[i]
+	for i := len(handlers) - 1; i >= 0; i-- {
+		cleanup := handlers[i]
 		if cleanup != nil {
 			cleanup()
 		}
 	}
 }
 
+// FailOn terminates the command if there's an error
+// (the error is shown as a structured error; see Fail)
 func (ref *ExecutionContext) FailOn(err error) {
 	if err != nil {
-		ref.doCleanup()
+		ref.Fail(err)
+	}
+}
+
+// Fail shows the structured command error, runs the cleanup handlers
+// (they can use Err to save the error in the command report)
+// and terminates the command with the error exit code
+// (the command exit errors only terminate the command with their exit code)
+func (ref *ExecutionContext) Fail(err error) {
+	if IsInterrupted() {
+		//the error is caused by the interrupted command
+		runtime.Goexit()
 	}
 
-	errutil.FailOn(err)
+	ref.Exit(ref.showError(err))
+}
+
+// Finish ends the partial command execution: it shows the structured error (if there's one),
+// runs the cleanup handlers and returns the exit code (the app is not terminated)
+func (ref *ExecutionContext) Finish(err error) int {
+	var exitCode int
+	if err != nil && !IsInterrupted() {
+		exitCode = ref.showError(err)
+	}
+
+	ref.doCleanup()
+	return exitCode
+}
+
+// Abort stops the command execution from the background goroutines:
+// the partial execution contexts save the error and cancel their context
+// (the command handler returns and Aborted returns the error),
+// the other execution contexts fail the command
+func (ref *ExecutionContext) Abort(err error) {
+	if ref.cancel == nil {
+		ref.Fail(err)
+		return
+	}
+
+	ref.mu.Lock()
+	if ref.abortErr == nil {
+		ref.abortErr = err
+	}
+	ref.mu.Unlock()
+
+	ref.cancel()
+}
+
+// Aborted returns the error the partial command execution was aborted with (nil if it wasn't aborted)
+func (ref *ExecutionContext) Aborted() error {
+	ref.mu.Lock()
+	defer ref.mu.Unlock()
+	return ref.abortErr
+}
+
+// Cancel cancels the partial execution context (it does nothing for the other contexts)
+func (ref *ExecutionContext) Cancel() {
+	if ref.cancel != nil {
+		ref.cancel()
+	}
+}
+
+// showError shows the structured command error and returns its exit code
+// (the command exit errors are not shown: the commands show their exit info)
+func (ref *ExecutionContext) showError(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode
+	}
+
+	xerr := AsError(err)
+	logFailure(err)
+
+	ref.mu.Lock()
+	ref.err = xerr
+	ref.mu.Unlock()
+
+	ref.Out.Failure(xerr)
+	ref.Out.State("exited",
+		OutVars{
+			"exit.code": xerr.ExitCode,
+		})
+
+	return xerr.ExitCode
+}
+
+// Err returns the structured error the command failed with (nil if the command didn't fail)
+func (ref *ExecutionContext) Err() *Error {
+	ref.mu.Lock()
+	defer ref.mu.Unlock()
+	return ref.err
 }
 
 func (ref *ExecutionContext) exit(exitCode int) {
+	if IsInterrupted() {
+		//the interrupt handler exits the app when its cleanup is done
+		runtime.Goexit()
+	}
+
 	ShowCommunityInfo(ref.Out.JSONFlag)
-	os.Exit(exitCode)
+	os.Exit(ExitStatus(exitCode))
+}
+
+// ExitStatus maps the command exit codes to the process exit statuses
+// (the command exit code registry sets it; the default exit status is the low byte of the exit code)
+var ExitStatus = func(exitCode int) int {
+	return exitCode & 0xff
 }
 
 func NewExecutionContext(cmdName, jsonFlag string) *ExecutionContext {
 	ref := &ExecutionContext{
-		Out: NewOutput(cmdName, jsonFlag),
+		Out:     NewOutput(cmdName, jsonFlag),
+		Context: AppContext(),
 	}
 
+	addActiveContext(ref)
+	return ref
+}
+
+// NewPartialExecutionContext creates the execution context for a part of the command execution
+// (e.g., one of the targets in the multi-target mode): its context is derived from the parent context,
+// and the failures are returned to the caller (see Finish and Abort) instead of terminating the app
+func NewPartialExecutionContext(parent context.Context, cmdName, jsonFlag string) *ExecutionContext {
+	ctx, cancel := context.WithCancel(parent)
+	ref := &ExecutionContext{
+		Out:     NewOutput(cmdName, jsonFlag),
+		Context: ctx,
+		cancel:  cancel,
+	}
+
+	addActiveContext(ref)
 	return ref
 }
 
@@ -90,6 +231,13 @@ func NoColor() {
 type OutVars map[string]interface{}
 
 func (ref *Output) LogDump(logType, data string, params ...OutVars) {
+	emitEvent(ref.CmdName, report.EventTypeLog, logType, data, params)
+	if !consoleEnabled() || IsQuiet() {
+		return
+	}
+
+	clearProgressLine()
+
 	var info string
 	msg := map[string]string{}
 	var jsonData []byte
@@ -128,6 +276,13 @@ func (ref *Output) LogDump(logType, data string, params ...OutVars) {
 }
 
 func (ref *Output) Prompt(data string) {
+	emitEvent(ref.CmdName, report.EventTypePrompt, "", data, nil)
+	if !consoleEnabled() {
+		return
+	}
+
+	clearProgressLine()
+
 	switch ref.JSONFlag {
 	case cfJSON:
 		//marshal data to json
@@ -152,6 +307,13 @@ func (ref *Output) Prompt(data string) {
 }
 
 func (ref *Output) Error(errType string, data string) {
+	emitEvent(ref.CmdName, report.EventTypeError, errType, data, nil)
+	if !consoleEnabled() {
+		return
+	}
+
+	clearProgressLine()
+
 	switch ref.JSONFlag {
 	case cfJSON:
 		//marshal data to json
@@ -176,7 +338,57 @@ func (ref *Output) Error(errType string, data string) {
 
 }
 
+// Failure shows the structured command error
+func (ref *Output) Failure(xerr *Error) {
+	emitEvent(ref.CmdName, report.EventTypeError, xerr.Type, xerr.Message,
+		[]OutVars{
+			{
+				"category":  xerr.Category,
+				"hint":      xerr.Hint,
+				"exit.code": xerr.ExitCode,
+			},
+		})
+	if !consoleEnabled() {
+		return
+	}
+
+	clearProgressLine()
+
+	switch ref.JSONFlag {
+	case cfJSON:
+		msg := struct {
+			Cmd string `json:"cmd"`
+			*report.ErrorInfo
+		}{
+			Cmd:       ref.CmdName,
+			ErrorInfo: xerr.ReportInfo(),
+		}
+
+		jsonData, _ := json.Marshal(msg)
+		fmt.Println(string(jsonData))
+	case cfText:
+		color.Set(color.FgHiRed)
+		defer color.Unset()
+
+		var hint string
+		if xerr.Hint != "" {
+			hint = fmt.Sprintf(" hint='%s'", xerr.Hint)
+		}
+
+		fmt.Printf("cmd=%s error=%s category=%s message='%s'%s\n", ref.CmdName, xerr.Type, xerr.Category, xerr.Message, hint)
+	default:
+		log.Fatalf("Unknown console output flag: %s\n. It should be either 'text' or 'json", ref.JSONFlag)
+	}
+}
+
 func (ref *Output) Message(data string) {
+	emitEvent(ref.CmdName, report.EventTypeMessage, "", data, nil)
+	if !consoleEnabled() || IsQuiet() {
+		return
+	}
+
+	clearProgressLine()
+
 	switch ref.JSONFlag {
 	case cfJSON:
 		//marshal data to json
@@ -201,6 +413,13 @@ func (ref *Output) Message(data string) {
 }
 
 func (ref *Output) State(state string, params ...OutVars) {
+	emitEvent(ref.CmdName, report.EventTypeState, state, "", params)
+	if !consoleEnabled() || (IsQuiet() && !isFinalState(state)) {
+		return
+	}
+
+	clearProgressLine()
+
 	var exitInfo string
 	var info string
 	var sep string
@@ -268,6 +487,13 @@ var (
 )
 
 func (ref *Output) Info(infoType string, params ...OutVars) {
+	emitEvent(ref.CmdName, report.EventTypeInfo, infoType, "", params)
+	if !consoleEnabled() || IsQuiet() {
+		return
+	}
+
+	clearProgressLine()
+
 	var data string
 	var sep string
 	msg := map[string]string{}
@@ -307,6 +533,12 @@ func (ref *Output) Info(infoType string, params ...OutVars) {
 }
 
 func ShowCommunityInfo(consoleFormat string) {
+	if !consoleEnabled() || IsQuiet() {
+		return
+	}
+
+	clearProgressLine()
+
 	lines := []struct {
 		App     string `json:"app"`
 		Message string `json:"message"`
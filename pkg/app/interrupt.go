@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ExitCodeInterrupted is the command exit code when the command is interrupted (SIGINT or SIGTERM)
+// (128 + SIGINT, the same code the shells use)
+const ExitCodeInterrupted = 130
+
+// interruptState tracks the app interruption and the active execution contexts
+var interruptState struct {
+	mu          sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+	interrupted bool
+	active      []*ExecutionContext
+}
+
+func init() {
+	interruptState.ctx, interruptState.cancel = context.WithCancel(context.Background())
+}
+
+// AppContext returns the app context (it's canceled when the app is interrupted)
+func AppContext() context.Context {
+	return interruptState.ctx
+}
+
+// IsInterrupted returns true if the app is interrupted
+func IsInterrupted() bool {
+	interruptState.mu.Lock()
+	defer interruptState.mu.Unlock()
+	return interruptState.interrupted
+}
+
+func addActiveContext(xc *ExecutionContext) {
+	interruptState.mu.Lock()
+	defer interruptState.mu.Unlock()
+	interruptState.active = append(interruptState.active, xc)
+}
+
+// HandleInterrupts starts handling SIGINT and SIGTERM.
+// The first signal cancels the app context, runs the cleanup handlers for the active execution contexts
+// (stopping and removing the temporary containers) and exits with the ExitCodeInterrupted exit code.
+// The second signal exits right away.
+func HandleInterrupts() {
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	//the command goroutines failing because of the canceled context
+	//must not terminate the app before the interrupt cleanup is done
+	log.StandardLogger().ExitFunc = func(code int) {
+		if IsInterrupted() {
+			runtime.Goexit()
+		}
+
+		os.Exit(code)
+	}
+
+	go func() {
+		sig := <-sigChan
+		go func() {
+			<-sigChan
+			log.Debug("docker-slim: second interrupt signal, exiting now...")
+			os.Exit(ExitCodeInterrupted)
+		}()
+
+		interrupt(sig)
+	}()
+}
+
+func interrupt(sig os.Signal) {
+	interruptState.mu.Lock()
+	interruptState.interrupted = true
+	active := make([]*ExecutionContext, len(interruptState.active))
+	copy(active, interruptState.active)
+	interruptState.mu.Unlock()
+
+	log.Debugf("docker-slim: interrupt signal (%v), cleaning up...", sig)
+	interruptState.cancel()
+
+	//the cleanup goroutine can be terminated by a fatal error in one of the cleanup handlers
+	cleanupDone := make(chan struct{})
+	go func() {
+		defer close(cleanupDone)
+		for i := len(active) - 1; i >= 0; i-- {
+			active[i].doCleanup()
+		}
+	}()
+
+	<-cleanupDone
+
+	if len(active) > 0 {
+		xc := active[0]
+		xc.Out.State("interrupted",
+			OutVars{
+				"signal": sig.String(),
+			})
+		xc.Out.State("exited",
+			OutVars{
+				"exit.code": ExitCodeInterrupted,
+			})
+	}
+
+	os.Exit(ExitCodeInterrupted)
+}
@@ -5,12 +5,21 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/dockerclipm"
 	"github.com/docker-slim/docker-slim/pkg/app/master/signals"
 )
 
 // Run starts the master app
 func Run() {
+	if dockerclipm.IsPluginInvocation(os.Args) {
+		os.Args = dockerclipm.PluginArgs(os.Args)
+		commands.CLIName = dockerclipm.PluginCommandName
+	}
+
 	signals.InitHandlers()
+	app.HandleInterrupts()
 	cli := newCLI()
 	if err := cli.Run(os.Args); err != nil {
 		log.Fatal(err)
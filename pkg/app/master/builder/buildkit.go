@@ -0,0 +1,411 @@
+package builder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+var (
+	ErrUnknownBuildEngine       = errors.New("unknown image build engine")
+	ErrUnsupportedBuildPlatform = errors.New("image build platform is not the target image platform")
+)
+
+const (
+	dockerExeName       = "docker"
+	buildctlExeName     = "buildctl"
+	dockerfileFrontend  = "dockerfile.v0"
+	provenanceModeMin   = "min"
+	provenanceModeMax   = "max"
+	buildctlOutputLocal = "type=docker"
+)
+
+// the builds with multiple outputs (exporters) need buildx and BuildKit v0.13+
+const (
+	multiOutputMinVersion = "v0.13"
+	multiOutputMinMajor   = 0
+	multiOutputMinMinor   = 13
+)
+
+var buildkitVersionPattern = regexp.MustCompile(`\bv(\d+)\.(\d+)`)
+
+// CheckBuildPlatforms checks that the image build platforms match the target image platform
+// (the minified image files come from the target image, so they can't be used for other platforms)
+func CheckBuildPlatforms(imagePlatform string, platforms []string) error {
+	for _, platform := range platforms {
+		//the platform variant is optional (e.g., 'linux/arm64/v8' for the 'linux/arm64' image)
+		if imagePlatform == "" ||
+			platform == imagePlatform ||
+			strings.HasPrefix(platform, imagePlatform+"/") {
+			continue
+		}
+
+		return fmt.Errorf("%w - %s (target image platform - %s)", ErrUnsupportedBuildPlatform, platform, imagePlatform)
+	}
+
+	return nil
+}
+
+// buildWithBuildKit assembles the optimized image with BuildKit
+// (the image is always loaded into the Docker engine; the configured output and the attestations
+// are exported by the same build if the BuildKit version supports multiple outputs,
+// otherwise the image is built again (using the build cache) for the configured output)
+func (b *ImageBuilder) buildWithBuildKit() error {
+	opts := b.BuildEngine
+	if err := CheckBuildPlatforms(b.BuildOptions.Platform, opts.Platforms); err != nil {
+		return err
+	}
+
+	tags := b.imageTags()
+	platforms := opts.Platforms
+	if len(platforms) == 0 && b.BuildOptions.Platform != "" {
+		platforms = []string{b.BuildOptions.Platform}
+	}
+
+	switch opts.Engine {
+	case config.ImageBuildEngineBuildx:
+		return b.runBuildx(tags, platforms)
+	case config.ImageBuildEngineBuildkitd:
+		return b.runBuildctl(tags, platforms)
+	default:
+		return ErrUnknownBuildEngine
+	}
+}
+
+func (b *ImageBuilder) imageTags() []string {
+	tags := []string{b.BuildOptions.Name}
+	for _, tag := range b.AdditionalTags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func provenanceMode(val string) string {
+	switch val {
+	case "true", provenanceModeMin:
+		return provenanceModeMin
+	case provenanceModeMax:
+		return provenanceModeMax
+	}
+
+	return val
+}
+
+// buildkitVersionAtLeast returns true if the version in the version info is at least the selected version
+// (the BuildKit lines are used if the info has them, e.g., in the 'buildx inspect' and 'buildctl debug info' output)
+func buildkitVersionAtLeast(info string, major, minor int) bool {
+	version := info
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "buildkit") {
+			version = line
+			break
+		}
+	}
+
+	match := buildkitVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return false
+	}
+
+	vmajor, _ := strconv.Atoi(match[1])
+	vminor, _ := strconv.Atoi(match[2])
+	return vmajor > major || (vmajor == major && vminor >= minor)
+}
+
+// buildxMultiOutput returns true if buildx and its builder instance support the builds with multiple outputs
+// (the version detection errors are treated as no support)
+func (b *ImageBuilder) buildxMultiOutput() bool {
+	clientInfo, err := exec.Command(dockerExeName, "buildx", "version").Output()
+	if err != nil || !buildkitVersionAtLeast(string(clientInfo), multiOutputMinMajor, multiOutputMinMinor) {
+		log.Debugf("ImageBuilder.buildxMultiOutput: buildx version - '%s' (%v)", strings.TrimSpace(string(clientInfo)), err)
+		return false
+	}
+
+	args := []string{"buildx", "inspect"}
+	if b.BuildEngine.Endpoint != "" {
+		args = append(args, b.BuildEngine.Endpoint)
+	}
+
+	builderInfo, err := exec.Command(dockerExeName, args...).Output()
+	if err != nil || !buildkitVersionAtLeast(string(builderInfo), multiOutputMinMajor, multiOutputMinMinor) {
+		log.Debugf("ImageBuilder.buildxMultiOutput: builder BuildKit version is too old or unknown (%v)", err)
+		return false
+	}
+
+	return true
+}
+
+// buildctlMultiOutput returns true if the BuildKit daemon supports the builds with multiple outputs
+func (b *ImageBuilder) buildctlMultiOutput() bool {
+	var args []string
+	if b.BuildEngine.Endpoint != "" {
+		args = append(args, "--addr", b.BuildEngine.Endpoint)
+	}
+
+	args = append(args, "debug", "info")
+	daemonInfo, err := exec.Command(buildctlExeName, args...).Output()
+	if err != nil || !buildkitVersionAtLeast(string(daemonInfo), multiOutputMinMajor, multiOutputMinMinor) {
+		log.Debugf("ImageBuilder.buildctlMultiOutput: BuildKit daemon version is too old or unknown (%v)", err)
+		return false
+	}
+
+	return true
+}
+
+// runBuildx runs the build with buildx
+func (b *ImageBuilder) runBuildx(tags, platforms []string) error {
+	output := b.BuildEngine.Output
+	if output == "" || b.buildxMultiOutput() {
+		return b.execBuildx(b.buildxArgs(tags, platforms, true, output, true))
+	}
+
+	log.Infof("ImageBuilder.runBuildx: buildx doesn't support multiple outputs (needs %s), building the image for each output", multiOutputMinVersion)
+	if err := b.execBuildx(b.buildxArgs(tags, platforms, true, "", true)); err != nil {
+		return err
+	}
+
+	return b.execBuildx(b.buildxArgs(tags, platforms, false, output, false))
+}
+
+// buildxArgs creates the buildx command args
+// (load and output are the build outputs; the cache is exported only once when the image is built again)
+func (b *ImageBuilder) buildxArgs(tags, platforms []string, load bool, output string, exportCache bool) []string {
+	opts := b.BuildEngine
+	contextDir := b.BuildOptions.ContextDir
+	args := []string{"buildx", "build"}
+	if opts.Endpoint != "" {
+		args = append(args, "--builder", opts.Endpoint)
+	}
+
+	args = append(args, "--file", filepath.Join(contextDir, b.BuildOptions.Dockerfile))
+	for _, tag := range tags {
+		args = append(args, "--tag", tag)
+	}
+
+	if len(platforms) > 0 {
+		args = append(args, "--platform", strings.Join(platforms, ","))
+	}
+
+	for _, cacheFrom := range opts.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+
+	if exportCache {
+		for _, cacheTo := range opts.CacheTo {
+			args = append(args, "--cache-to", cacheTo)
+		}
+	}
+
+	if opts.Provenance != "" {
+		args = append(args, "--provenance", "mode="+provenanceMode(opts.Provenance))
+	}
+
+	if load {
+		args = append(args, "--load")
+	}
+
+	if output != "" {
+		args = append(args, "--output", output)
+	}
+
+	return append(args, contextDir)
+}
+
+func (b *ImageBuilder) execBuildx(args []string) error {
+	log.Debugf("ImageBuilder.execBuildx: %s %s", dockerExeName, strings.Join(args, " "))
+	cmd := exec.Command(dockerExeName, args...)
+	cmd.Stdout = &b.BuildLog
+	cmd.Stderr = &b.BuildLog
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker buildx error - %v", err)
+	}
+
+	return nil
+}
+
+// runBuildctl runs the build with the buildkitd instance
+// (the image is exported as a Docker image archive and loaded into the Docker engine)
+func (b *ImageBuilder) runBuildctl(tags, platforms []string) error {
+	output := b.BuildEngine.Output
+	if output == "" || b.buildctlMultiOutput() {
+		return b.loadBuildctl(b.buildctlArgs(tags, platforms, true, output, true))
+	}
+
+	log.Infof("ImageBuilder.runBuildctl: BuildKit doesn't support multiple outputs (needs %s), building the image for each output", multiOutputMinVersion)
+	if err := b.loadBuildctl(b.buildctlArgs(tags, platforms, true, "", true)); err != nil {
+		return err
+	}
+
+	args := b.buildctlArgs(tags, platforms, false, output, false)
+	log.Debugf("ImageBuilder.runBuildctl: %s %s", buildctlExeName, strings.Join(args, " "))
+	cmd := exec.Command(buildctlExeName, args...)
+	cmd.Stdout = &b.BuildLog
+	cmd.Stderr = &b.BuildLog
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("buildctl error - %v", err)
+	}
+
+	return nil
+}
+
+// buildctlArgs creates the buildctl command args
+// (load adds the Docker image archive output written to stdout)
+func (b *ImageBuilder) buildctlArgs(tags, platforms []string, load bool, output string, exportCache bool) []string {
+	opts := b.BuildEngine
+	contextDir := b.BuildOptions.ContextDir
+	var args []string
+	if opts.Endpoint != "" {
+		args = append(args, "--addr", opts.Endpoint)
+	}
+
+	args = append(args,
+		"build",
+		"--frontend", dockerfileFrontend,
+		"--local", "context="+contextDir,
+		"--local", "dockerfile="+contextDir,
+		"--opt", "filename="+b.BuildOptions.Dockerfile)
+
+	if len(platforms) > 0 {
+		args = append(args, "--opt", "platform="+strings.Join(platforms, ","))
+	}
+
+	for _, cacheFrom := range opts.CacheFrom {
+		args = append(args, "--import-cache", cacheFrom)
+	}
+
+	if exportCache {
+		for _, cacheTo := range opts.CacheTo {
+			args = append(args, "--export-cache", cacheTo)
+		}
+	}
+
+	if opts.Provenance != "" {
+		args = append(args, "--opt", "attest:provenance=mode="+provenanceMode(opts.Provenance))
+	}
+
+	if load {
+		//the quoted name field can have multiple comma separated names
+		args = append(args, "--output", fmt.Sprintf(`%s,"name=%s"`, buildctlOutputLocal, strings.Join(tags, ",")))
+	}
+
+	if output != "" {
+		args = append(args, "--output", output)
+	}
+
+	return args
+}
+
+// loadBuildctl runs buildctl and loads the image archive it writes to stdout into the Docker engine
+func (b *ImageBuilder) loadBuildctl(args []string) error {
+	log.Debugf("ImageBuilder.loadBuildctl: %s %s", buildctlExeName, strings.Join(args, " "))
+	cmd := exec.Command(buildctlExeName, args...)
+	cmd.Stderr = &b.BuildLog
+	imageArchive, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("buildctl error - %v", err)
+	}
+
+	//buildctl writes its log while the image is loaded, so the load output is added to the build log later
+	var loadLog bytes.Buffer
+	loadErr := b.APIClient.LoadImage(docker.LoadImageOptions{
+		InputStream:  imageArchive,
+		OutputStream: &loadLog,
+	})
+	if loadErr != nil {
+		//draining the output, so buildctl can exit
+		io.Copy(ioutil.Discard, imageArchive)
+	}
+
+	err = cmd.Wait()
+	b.BuildLog.Write(loadLog.Bytes())
+	if err != nil {
+		return fmt.Errorf("buildctl error - %v", err)
+	}
+
+	return loadErr
+}
+
+// buildWithBuildx builds the image with buildx (used for the build options the classic build API doesn't support:
+// named build contexts and the BuildKit cache import/export)
+func (b *BasicImageBuilder) buildWithBuildx() error {
+	opts := b.BuildOptions
+	args := []string{"buildx", "build", "--load", "--tag", opts.Name}
+	if opts.Dockerfile != "" {
+		dockerfilePath := opts.Dockerfile
+		if opts.ContextDir != "" {
+			dockerfilePath = filepath.Join(opts.ContextDir, opts.Dockerfile)
+		}
+
+		args = append(args, "--file", dockerfilePath)
+	}
+
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+
+	if opts.NetworkMode != "" {
+		args = append(args, "--network", opts.NetworkMode)
+	}
+
+	if opts.ExtraHosts != "" {
+		for _, host := range strings.Split(opts.ExtraHosts, ",") {
+			args = append(args, "--add-host", host)
+		}
+	}
+
+	for _, ba := range opts.BuildArgs {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", ba.Name, ba.Value))
+	}
+
+	for name, value := range opts.Labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", name, value))
+	}
+
+	for _, cacheFrom := range opts.CacheFrom {
+		args = append(args, "--cache-from", cacheFrom)
+	}
+
+	for _, cacheTo := range b.CacheTo {
+		args = append(args, "--cache-to", cacheTo)
+	}
+
+	for name, value := range b.NamedContexts {
+		args = append(args, "--build-context", fmt.Sprintf("%s=%s", name, value))
+	}
+
+	args = append(args, b.BuildContext)
+
+	log.Debugf("BasicImageBuilder.buildWithBuildx: %s %s", dockerExeName, strings.Join(args, " "))
+	cmd := exec.Command(dockerExeName, args...)
+	if b.BuildContext == StdinContext {
+		cmd.Stdin = os.Stdin
+	}
+
+	cmd.Stdout = &b.BuildLog
+	cmd.Stderr = &b.BuildLog
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker buildx error - %v", err)
+	}
+
+	return nil
+}
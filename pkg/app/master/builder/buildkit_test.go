@@ -0,0 +1,151 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func newTestBuildKitBuilder(opts *config.ImageBuildEngineOptions) *ImageBuilder {
+	return &ImageBuilder{
+		BasicImageBuilder: BasicImageBuilder{
+			BuildOptions: docker.BuildImageOptions{
+				Name:       "app.slim",
+				Dockerfile: "Dockerfile",
+				ContextDir: "/tmp/artifacts",
+				Platform:   "linux/arm64",
+			},
+		},
+		BuildEngine: opts,
+	}
+}
+
+func TestBuildxArgs(t *testing.T) {
+	b := newTestBuildKitBuilder(&config.ImageBuildEngineOptions{
+		Engine:     config.ImageBuildEngineBuildx,
+		Endpoint:   "slim-builder",
+		CacheFrom:  []string{"type=local,src=/tmp/cache"},
+		CacheTo:    []string{"type=local,dest=/tmp/cache"},
+		Provenance: "true",
+		Output:     "type=oci,dest=/tmp/app.tar",
+	})
+
+	tags := []string{"app.slim", "app:latest"}
+	platforms := []string{"linux/arm64/v8"}
+
+	expected := []string{
+		"buildx", "build",
+		"--builder", "slim-builder",
+		"--file", "/tmp/artifacts/Dockerfile",
+		"--tag", "app.slim",
+		"--tag", "app:latest",
+		"--platform", "linux/arm64/v8",
+		"--cache-from", "type=local,src=/tmp/cache",
+		"--cache-to", "type=local,dest=/tmp/cache",
+		"--provenance", "mode=min",
+		"--load",
+		"--output", "type=oci,dest=/tmp/app.tar",
+		"/tmp/artifacts",
+	}
+
+	if actual := b.buildxArgs(tags, platforms, true, b.BuildEngine.Output, true); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("multi output - got %q expected %q", actual, expected)
+	}
+
+	//the second build (without multi output support) only exports the configured output
+	expected = []string{
+		"buildx", "build",
+		"--builder", "slim-builder",
+		"--file", "/tmp/artifacts/Dockerfile",
+		"--tag", "app.slim",
+		"--tag", "app:latest",
+		"--platform", "linux/arm64/v8",
+		"--cache-from", "type=local,src=/tmp/cache",
+		"--provenance", "mode=min",
+		"--output", "type=oci,dest=/tmp/app.tar",
+		"/tmp/artifacts",
+	}
+
+	if actual := b.buildxArgs(tags, platforms, false, b.BuildEngine.Output, false); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("output only - got %q expected %q", actual, expected)
+	}
+}
+
+func TestBuildctlArgs(t *testing.T) {
+	b := newTestBuildKitBuilder(&config.ImageBuildEngineOptions{
+		Engine:     config.ImageBuildEngineBuildkitd,
+		Endpoint:   "tcp://buildkitd:1234",
+		CacheTo:    []string{"type=inline"},
+		Provenance: "max",
+	})
+
+	expected := []string{
+		"--addr", "tcp://buildkitd:1234",
+		"build",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=/tmp/artifacts",
+		"--local", "dockerfile=/tmp/artifacts",
+		"--opt", "filename=Dockerfile",
+		"--opt", "platform=linux/arm64,linux/arm64/v8",
+		"--export-cache", "type=inline",
+		"--opt", "attest:provenance=mode=max",
+		"--output", `type=docker,"name=app.slim,app:latest"`,
+	}
+
+	actual := b.buildctlArgs([]string{"app.slim", "app:latest"}, []string{"linux/arm64", "linux/arm64/v8"}, true, "", true)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %q expected %q", actual, expected)
+	}
+}
+
+func TestBuildWithBuildKitPlatforms(t *testing.T) {
+	b := newTestBuildKitBuilder(&config.ImageBuildEngineOptions{
+		Engine:    "unknown",
+		Platforms: []string{"linux/amd64"},
+	})
+
+	//the platforms for other architectures are rejected before the build
+	if err := b.buildWithBuildKit(); err == nil || err == ErrUnknownBuildEngine {
+		t.Errorf("other platform - got %v", err)
+	}
+
+	b.BuildEngine.Platforms = []string{"linux/arm64/v8"}
+	if err := b.buildWithBuildKit(); err != ErrUnknownBuildEngine {
+		t.Errorf("target platform - got %v expected %v", err, ErrUnknownBuildEngine)
+	}
+}
+
+func TestBuildkitVersionAtLeast(t *testing.T) {
+	tt := []struct {
+		info     string
+		expected bool
+	}{
+		{info: "github.com/docker/buildx v0.13.1 788433953af10f2a698f5c07611dddce2e08c7a0", expected: true},
+		{info: "github.com/docker/buildx v0.12.1-desktop.4 6996841df2f61988c2794d84d33205368f96c317", expected: false},
+		{info: "github.com/docker/buildx v1.0.0 abc", expected: true},
+		{
+			//the 'buildx inspect' output
+			info: "Name:          slim-builder\nDriver:        docker-container\n\nNodes:\nName:      slim-builder0\nEndpoint:  unix:///var/run/docker.sock\nStatus:    running\nBuildkit:  v0.12.5\nPlatforms: linux/amd64",
+		},
+		{
+			info:     "Name:          default\nDriver:        docker\n\nNodes:\nName:             default\nBuildKit version: v0.13.2\nPlatforms:        linux/amd64",
+			expected: true,
+		},
+		{
+			//the 'buildctl debug info' output
+			info:     "BuildKit: github.com/moby/buildkit v0.14.1 de56a3c5056341667b5bad71f414ece70b50724f",
+			expected: true,
+		},
+		{info: "unknown"},
+		{info: ""},
+	}
+
+	for _, test := range tt {
+		if actual := buildkitVersionAtLeast(test.info, multiOutputMinMajor, multiOutputMinMinor); actual != test.expected {
+			t.Errorf("buildkitVersionAtLeast(%q) - got %v expected %v", test.info, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// StdinContext is the build context value to read the build context (or the Dockerfile) from stdin
+const StdinContext = "-"
+
+const (
+	defaultDockerfileName = "Dockerfile"
+	tarMagicOffset        = 257
+	tarMagic              = "ustar"
+)
+
+// the compressed archive formats supported by the Docker engine for the build context
+var compressedArchiveMagic = [][]byte{
+	{0x1f, 0x8b},                         //gzip
+	{0x42, 0x5a, 0x68},                   //bzip2
+	{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}, //xz
+}
+
+// IsRemoteContext returns true if the build context is a git repository or a URL
+// (the remote contexts are fetched by the Docker engine)
+func IsRemoteContext(buildContext string) bool {
+	for _, prefix := range []string{"http://", "https://", "git://", "git@", "github.com/"} {
+		if strings.HasPrefix(buildContext, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contextFromStream returns the build context archive from the stream
+// (if the stream is not an archive it's used as the Dockerfile in an empty build context)
+func contextFromStream(input io.Reader, dockerfileName string) (io.Reader, error) {
+	reader := bufio.NewReaderSize(input, tarMagicOffset+len(tarMagic))
+	header, err := reader.Peek(tarMagicOffset + len(tarMagic))
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	if isArchive(header) {
+		return reader, nil
+	}
+
+	dockerfileData, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if dockerfileName == "" {
+		dockerfileName = defaultDockerfileName
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	hdr := &tar.Header{
+		Name:     dockerfileName,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(dockerfileData)),
+		ModTime:  time.Now(),
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	if _, err := tw.Write(dockerfileData); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &archive, nil
+}
+
+func isArchive(header []byte) bool {
+	for _, magic := range compressedArchiveMagic {
+		if bytes.HasPrefix(header, magic) {
+			return true
+		}
+	}
+
+	return len(header) >= tarMagicOffset+len(tarMagic) &&
+		string(header[tarMagicOffset:tarMagicOffset+len(tarMagic)]) == tarMagic
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -26,6 +27,10 @@ type BasicImageBuilder struct {
 	BuildOptions  docker.BuildImageOptions
 	APIClient     *docker.Client
 	BuildLog      bytes.Buffer
+	//the build context value (directory, URL or stdin)
+	BuildContext  string
+	NamedContexts map[string]string
+	CacheTo       []string
 }
 
 // ImageBuilder creates new optimized container images
@@ -45,6 +50,7 @@ type ImageBuilder struct {
 	User           string
 	HasData        bool
 	TarData        bool
+	BuildEngine    *config.ImageBuildEngineOptions
 }
 
 const (
@@ -102,12 +108,26 @@ func NewBasicImageBuilder(client *docker.Client,
 			BuildArgs:      buildArgs,
 			RmTmpContainer: true,
 		},
-		APIClient: client,
+		APIClient:     client,
+		BuildContext:  buildContext,
+		NamedContexts: cbOpts.NamedContexts,
+		CacheTo:       cbOpts.CacheTo,
 	}
 
-	if strings.HasPrefix(buildContext, "http://") || strings.HasPrefix(buildContext, "https://") {
+	switch {
+	case IsRemoteContext(buildContext):
 		builder.BuildOptions.Remote = buildContext
-	} else {
+	case buildContext == StdinContext:
+		//buildx reads the build context from stdin itself
+		if !builder.NeedsBuildKit() {
+			input, err := contextFromStream(os.Stdin, cbOpts.Dockerfile)
+			if err != nil {
+				return nil, err
+			}
+
+			builder.BuildOptions.InputStream = input
+		}
+	default:
 		if exists := fsutil.DirExists(buildContext); exists {
 			builder.BuildOptions.ContextDir = buildContext
 			fullDockerfileName := filepath.Join(buildContext, cbOpts.Dockerfile)
@@ -125,9 +145,29 @@ func NewBasicImageBuilder(client *docker.Client,
 
 // Build creates a new container image
 func (b *BasicImageBuilder) Build() error {
+	if b.NeedsBuildKit() {
+		return b.buildWithBuildx()
+	}
+
 	return b.APIClient.BuildImage(b.BuildOptions)
 }
 
+// NeedsBuildKit returns true if the build options are supported only by BuildKit
+// (named build contexts, cache export or cache import specs)
+func (b *BasicImageBuilder) NeedsBuildKit() bool {
+	if len(b.NamedContexts) > 0 || len(b.CacheTo) > 0 {
+		return true
+	}
+
+	for _, cacheFrom := range b.BuildOptions.CacheFrom {
+		if strings.Contains(cacheFrom, "type=") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Remove deletes the configured container image
 func (b *BasicImageBuilder) Remove() error {
 	return nil
@@ -296,6 +336,10 @@ func NewImageBuilder(client *docker.Client,
 			builder.Cmd = instructions.Cmd
 		}
 
+		if instructions.User != "" {
+			builder.User = instructions.User
+		}
+
 		if len(builder.ExposedPorts) > 0 &&
 			len(instructions.RemoveExposedPorts) > 0 {
 			for k := range instructions.RemoveExposedPorts {
@@ -359,6 +403,11 @@ func (b *ImageBuilder) Build() error {
 		return err
 	}
 
+	if b.BuildEngine.UseBuildKit() {
+		//the additional tags are added by BuildKit
+		return b.buildWithBuildKit()
+	}
+
 	err := b.APIClient.BuildImage(b.BuildOptions)
 	if err != nil {
 		return err
@@ -0,0 +1,279 @@
+// Package cache implements the analysis result cache.
+// The cached results are keyed by the image ID (content digest) and by a hash of the parameters used to produce them.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+// Cached result kinds
+const (
+	KindReverse  = "reverse"  //reverse engineered Dockerfile info
+	KindAnalysis = "analysis" //static image data analysis results
+	KindSensor   = "sensor"   //sensor (container analysis) artifacts
+)
+
+const (
+	entryInfoName = "entry.json"
+	entryDataName = "data.json"
+	entryDirName  = "data"
+	keyLen        = 16
+	dirPerms      = 0755
+)
+
+var (
+	ErrNoImageID = errors.New("no image ID")
+)
+
+// Store is the analysis result cache in the state directory
+type Store struct {
+	Location string
+}
+
+// EntryInfo describes a cached result
+type EntryInfo struct {
+	ImageID  string    `json:"image_id"`
+	Kind     string    `json:"kind"`
+	Key      string    `json:"key"`
+	Created  time.Time `json:"created"`
+	Size     int64     `json:"size,omitempty"`
+	Location string    `json:"-"`
+}
+
+// NewStore creates a new cache store for the state path
+func NewStore(statePrefix string) *Store {
+	return &Store{
+		Location: fsutil.CacheStateDir(statePrefix),
+	}
+}
+
+// ParamsKey returns the cache key for the parameters used to produce the cached result
+func ParamsKey(params interface{}) (string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])[:keyLen], nil
+}
+
+func imageKey(imageID string) string {
+	if idx := strings.Index(imageID, ":"); idx != -1 {
+		imageID = imageID[idx+1:]
+	}
+
+	return imageID
+}
+
+func (s *Store) entryPath(imageID, kind, key string) string {
+	return filepath.Join(s.Location, imageKey(imageID), fmt.Sprintf("%s.%s", kind, key))
+}
+
+// Load loads the cached result data (returns false if there's no cached result)
+func (s *Store) Load(imageID, kind, key string, data interface{}) (bool, error) {
+	if imageID == "" {
+		return false, ErrNoImageID
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(s.entryPath(imageID, kind, key), entryDataName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if err := json.Unmarshal(raw, data); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Save saves the result data in the cache
+func (s *Store) Save(imageID, kind, key string, data interface{}) error {
+	return s.save(imageID, kind, key, func(tmpPath string) error {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(filepath.Join(tmpPath, entryDataName), raw, 0644)
+	})
+}
+
+// LoadDir copies the cached result files to the target directory (returns false if there's no cached result)
+func (s *Store) LoadDir(imageID, kind, key, targetDir string) (bool, error) {
+	if imageID == "" {
+		return false, ErrNoImageID
+	}
+
+	dataDir := filepath.Join(s.entryPath(imageID, kind, key), entryDirName)
+	if !fsutil.DirExists(dataDir) {
+		return false, nil
+	}
+
+	if err := copyDir(dataDir, targetDir); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SaveDir saves the result files from the source directory in the cache
+func (s *Store) SaveDir(imageID, kind, key, sourceDir string) error {
+	return s.save(imageID, kind, key, func(tmpPath string) error {
+		return copyDir(sourceDir, filepath.Join(tmpPath, entryDirName))
+	})
+}
+
+func (s *Store) save(imageID, kind, key string, writeData func(tmpPath string) error) error {
+	if imageID == "" {
+		return ErrNoImageID
+	}
+
+	entryPath := s.entryPath(imageID, kind, key)
+	if err := os.MkdirAll(filepath.Dir(entryPath), dirPerms); err != nil {
+		return err
+	}
+
+	//the new entry is prepared in a temporary directory,
+	//so the concurrent runs never see incomplete entries
+	tmpPath, err := ioutil.TempDir(filepath.Dir(entryPath), ".tmp.")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpPath)
+
+	if err := writeData(tmpPath); err != nil {
+		return err
+	}
+
+	info := &EntryInfo{
+		ImageID: imageID,
+		Kind:    kind,
+		Key:     key,
+		Created: time.Now().UTC(),
+	}
+
+	raw, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmpPath, entryInfoName), raw, 0644); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(entryPath); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, entryPath)
+}
+
+// List returns the cached results (all results if the image ID is empty)
+func (s *Store) List(imageID string) ([]*EntryInfo, error) {
+	pattern := filepath.Join(s.Location, "*", "*", entryInfoName)
+	if imageID != "" {
+		pattern = filepath.Join(s.Location, imageKey(imageID), "*", entryInfoName)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*EntryInfo
+	for _, infoPath := range matches {
+		raw, err := ioutil.ReadFile(infoPath)
+		if err != nil {
+			log.Debugf("cache.Store.List: error reading entry info (%s) - %v", infoPath, err)
+			continue
+		}
+
+		var info EntryInfo
+		if err := json.Unmarshal(raw, &info); err != nil {
+			log.Debugf("cache.Store.List: error decoding entry info (%s) - %v", infoPath, err)
+			continue
+		}
+
+		info.Location = filepath.Dir(infoPath)
+		info.Size = dirSize(info.Location)
+		entries = append(entries, &info)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.Before(entries[j].Created)
+	})
+
+	return entries, nil
+}
+
+// Remove deletes the cached results for the image (all cached results if the image ID is empty)
+// and the results older than maxAge (if it's not zero)
+func (s *Store) Remove(imageID string, maxAge time.Duration) (int, error) {
+	entries, err := s.List(imageID)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, entry := range entries {
+		if maxAge > 0 && time.Since(entry.Created) < maxAge {
+			continue
+		}
+
+		if err := os.RemoveAll(entry.Location); err != nil {
+			return count, err
+		}
+
+		//removing the image directory if it's empty
+		os.Remove(filepath.Dir(entry.Location))
+		count++
+	}
+
+	return count, nil
+}
+
+func copyDir(src, dst string) error {
+	err, errs := fsutil.CopyDir(true, src, dst, true, false, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+func dirSize(location string) int64 {
+	var size int64
+	filepath.Walk(location, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
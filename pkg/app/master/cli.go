@@ -11,23 +11,35 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/build"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/cache"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/containerize"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/convert"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/debug"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/dockerclipm"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/doctor"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/edit"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/extract"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/help"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/history"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/install"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/instrument"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/lint"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/plugins"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/policy"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/probe"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/profile"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/registry"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/run"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/server"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/state"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/update"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/validatereport"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/verify"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/version"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/wizard"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands/xray"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/docker/registryconfig"
 	"github.com/docker-slim/docker-slim/pkg/system"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
@@ -46,6 +58,7 @@ func registerCommands() {
 	xray.RegisterCommand()
 	lint.RegisterCommand()
 	build.RegisterCommand()
+	wizard.RegisterCommand()
 	registry.RegisterCommand()
 	profile.RegisterCommand()
 	version.RegisterCommand()
@@ -54,12 +67,24 @@ func registerCommands() {
 	install.RegisterCommand()
 	edit.RegisterCommand()
 	probe.RegisterCommand()
+	verify.RegisterCommand()
+	doctor.RegisterCommand()
+	extract.RegisterCommand()
+	history.RegisterCommand()
+	cache.RegisterCommand()
+	state.RegisterCommand()
+	validatereport.RegisterCommand()
+	policy.RegisterCommand()
 	convert.RegisterCommand()
 	run.RegisterCommand()
 	server.RegisterCommand()
 	debug.RegisterCommand()
 	containerize.RegisterCommand()
+	instrument.RegisterCommand()
 	dockerclipm.RegisterCommand()
+
+	//the 'docker-slim-<cmd>' executables on PATH (can't replace the built-in commands)
+	plugins.RegisterCommands()
 }
 
 func newCLI() *cli.App {
@@ -68,15 +93,30 @@ func newCLI() *cli.App {
 	cliApp := cli.NewApp()
 	cliApp.Version = v.Current()
 	cliApp.Name = AppName
+	//'docker slim' in the help output when running as a Docker CLI plugin
+	cliApp.HelpName = commands.CLIName
 	cliApp.Usage = AppUsage
 	cliApp.CommandNotFound = func(ctx *cli.Context, command string) {
 		fmt.Printf("unknown command - %v \n\n", command)
 		cli.ShowAppHelp(ctx)
 	}
 
+	//'-v' is used for '--verbose'
+	cli.VersionFlag = &cli.BoolFlag{
+		Name:  "version",
+		Usage: "print the version",
+	}
+
 	cliApp.Flags = commands.GlobalFlags()
+	commands.SetupFlagEnvVars(cliApp.Flags)
 
 	cliApp.Before = func(ctx *cli.Context) error {
+		//the flag config file values are used for the flags that are not set explicitly
+		if err := commands.ApplyGlobalFlagConfig(ctx, cliApp.Flags); err != nil {
+			log.Errorf("commands.ApplyGlobalFlagConfig error - %v", err)
+			return err
+		}
+
 		gparams, err := commands.GlobalFlagValues(ctx)
 		if err != nil {
 			log.Errorf("commands.GlobalFlagValues error - %v", err)
@@ -91,6 +131,29 @@ func newCLI() *cli.App {
 
 		gparams = commands.UpdateGlobalFlagValues(appParams, gparams)
 
+		switch gparams.Output {
+		case "":
+		case app.OutputFormatJSON:
+			//same as '--console-format json' (the commands get the console format from the flag)
+			if err := ctx.Set(commands.FlagConsoleFormat, app.OutputFormatJSON); err != nil {
+				return err
+			}
+
+			gparams.ConsoleOutput = app.OutputFormatJSON
+		default:
+			if err := app.StartEventStream(gparams.Output, gparams.OutputFile); err != nil {
+				log.Errorf("app.StartEventStream error - %v", err)
+				return err
+			}
+		}
+
+		for _, sink := range gparams.OutputSinks {
+			if err := app.AddOutputSink(sink); err != nil {
+				log.Errorf("app.AddOutputSink error - %v", err)
+				return err
+			}
+		}
+
 		ctx.Context = commands.CLIContextSave(ctx.Context, commands.GlobalParams, gparams)
 		ctx.Context = commands.CLIContextSave(ctx.Context, commands.AppParams, appParams)
 
@@ -98,6 +161,15 @@ func newCLI() *cli.App {
 			app.NoColor()
 		}
 
+		switch {
+		case gparams.Quiet:
+			app.SetOutputLevel(app.OutputQuiet)
+		case gparams.Debug:
+			app.SetOutputLevel(app.OutputDebug)
+		case gparams.Verbose:
+			app.SetOutputLevel(app.OutputVerbose)
+		}
+
 		if gparams.Debug {
 			log.SetLevel(log.DebugLevel)
 		} else {
@@ -124,6 +196,11 @@ func newCLI() *cli.App {
 					log.Fatalf("unknown log-level %q", gparams.LogLevel)
 				}
 
+				//the quiet mode shows only the errors (unless the log level is set explicitly)
+				if gparams.Quiet && !ctx.IsSet(commands.FlagLogLevel) && logLevel > log.ErrorLevel {
+					logLevel = log.ErrorLevel
+				}
+
 				log.SetLevel(logLevel)
 			}
 		}
@@ -145,18 +222,24 @@ func newCLI() *cli.App {
 			log.Fatalf("unknown log-format %q", gparams.LogFormat)
 		}
 
+		commands.EnableDiagBundle(gparams.DiagBundle, gparams)
+		//used by the registry operations (the registry commands and the remote image inspection)
+		registryconfig.SetDefault(gparams.RegistryConfig)
+
 		log.Debugf("sysinfo => %#v", system.GetSystemInfo())
 
 		//tmp hack
-		if !strings.Contains(strings.Join(os.Args, " "), " docker-cli-plugin-metadata") {
+		if !hasRawOutput() {
 			app.ShowCommunityInfo(gparams.ConsoleOutput)
 		}
 		return nil
 	}
 
 	cliApp.After = func(ctx *cli.Context) error {
+		defer app.StopEventStream()
+
 		//tmp hack
-		if !strings.Contains(strings.Join(os.Args, " "), " docker-cli-plugin-metadata") {
+		if !hasRawOutput() {
 			app.ShowCommunityInfo(ctx.String(commands.FlagConsoleFormat))
 		}
 		return nil
@@ -173,6 +256,20 @@ func newCLI() *cli.App {
 		return nil
 	}
 
+	commands.SetupCommandFlagEnvVars(commands.CLI)
+	commands.SetupFlagConfig(commands.CLI, "")
 	cliApp.Commands = commands.CLI
 	return cliApp
 }
+
+// hasRawOutput returns true if the command prints raw machine readable data to stdout
+// (the community info would break it; tmp hack: the command is not known when the global flags are processed)
+func hasRawOutput() bool {
+	args := strings.Join(os.Args, " ")
+	return strings.Contains(args, " docker-cli-plugin-metadata") ||
+		(strings.Contains(args, " exit-codes") && strings.Contains(args, " --json")) ||
+		(strings.Contains(args, " version") && strings.Contains(args, " --json")) ||
+		(strings.Contains(args, " history") && strings.Contains(args, " --json")) ||
+		(strings.Contains(args, " registry") && strings.Contains(args, " inspect")) ||
+		(strings.Contains(args, " registry") && strings.Contains(args, " tags") && strings.Contains(args, " --json"))
+}
@@ -0,0 +1,335 @@
+package build
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// targetHandler optimizes one of the targets in the multi-target mode
+type targetHandler func(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	client *dockerapi.Client,
+	targetRef string,
+	targetIndex int) error
+
+// GetTargetImages returns the target images from the target flag, the command args and the targets file
+func GetTargetImages(ctx *cli.Context) ([]string, error) {
+	var targets []string
+	if target := ctx.String(commands.FlagTarget); target != "" {
+		targets = append(targets, target)
+	}
+
+	targets = append(targets, ctx.Args().Slice()...)
+
+	if fileName := ctx.String(FlagTargetsFile); fileName != "" {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			targets = append(targets, line)
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var uniqueTargets []string
+	known := map[string]struct{}{}
+	for _, target := range targets {
+		if _, ok := known[target]; ok {
+			continue
+		}
+
+		known[target] = struct{}{}
+		uniqueTargets = append(uniqueTargets, target)
+	}
+
+	return uniqueTargets, nil
+}
+
+// batchTargetPath returns the target specific output location in the multi-target mode
+func batchTargetPath(location string, targetIndex int) string {
+	if location == "" {
+		return ""
+	}
+
+	return filepath.Join(location, fmt.Sprintf("target.%d", targetIndex+1))
+}
+
+// runBatch optimizes multiple target images concurrently
+// (the targets share the Docker client and the results are saved in one command report)
+func runBatch(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	targets []string,
+	workers int,
+	handler targetHandler,
+) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+
+	cmdReport := report.NewBatchBuildCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.Workers = workers
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err == dockerclient.ErrNoDockerInfo {
+		exitMsg := "missing Docker connection info"
+		if gparams.InContainer && gparams.IsDSImage {
+			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+		}
+
+		xc.Out.Error("docker.connect.error", exitMsg)
+
+		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+
+		xc.Exit(exitCode)
+	}
+	xc.FailOn(err)
+
+	reportDir, err := ioutil.TempDir("", "docker-slim-batch-")
+	xc.FailOn(err)
+	xc.AddCleanupHandler(func() { os.RemoveAll(reportDir) })
+	defer os.RemoveAll(reportDir)
+
+	xc.Out.State("batch.started",
+		ovars{
+			"targets": len(targets),
+			"workers": workers,
+		})
+
+	results := make([]*report.BatchBuildTarget, len(targets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = runBatchTarget(xc, gparams, client, targets[idx], idx, reportDir, handler, logger)
+			}
+		}()
+	}
+
+	for idx := range targets {
+		jobs <- idx
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	cmdReport.Targets = results
+	for idx, result := range results {
+		status := "done"
+		if result.ExitCode != 0 {
+			status = "failed"
+			cmdReport.Failed++
+		}
+
+		xc.Out.Info("batch.target.result",
+			ovars{
+				"index":     idx + 1,
+				"target":    result.TargetReference,
+				"status":    status,
+				"exit.code": result.ExitCode,
+			})
+	}
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Failed > 0 {
+		cmdReport.State = command.StateError
+		cmdReport.Error = "batch.target.failure"
+	}
+
+	xc.Out.State("batch.done",
+		ovars{
+			"targets": len(targets),
+			"failed":  cmdReport.Failed,
+		})
+
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	if cmdReport.Failed > 0 {
+		exitCode := commands.ECTBuild | ecbBatchTargetFailure
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		xc.Exit(exitCode)
+	}
+}
+
+func runBatchTarget(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	client *dockerapi.Client,
+	targetRef string,
+	targetIndex int,
+	reportDir string,
+	handler targetHandler,
+	logger *log.Entry,
+) (result *report.BatchBuildTarget) {
+	result = &report.BatchBuildTarget{
+		TargetReference: targetRef,
+	}
+
+	xc.Out.Info("batch.target",
+		ovars{
+			"index":  targetIndex + 1,
+			"target": targetRef,
+		})
+
+	//the target failures stop only the target:
+	//the target runs with its own execution context (canceled when the target fails or it's aborted)
+	//and its handler returns the target error
+	txc := app.NewPartialExecutionContext(xc.Context, Name, xc.Out.JSONFlag)
+	defer txc.Cancel()
+
+	tparams := *gparams
+	tparams.CheckVersion = false
+	tparams.ReportLocation = filepath.Join(reportDir, fmt.Sprintf("%d.json", targetIndex))
+
+	err := runBatchTargetHandler(txc, &tparams, client, targetRef, targetIndex, handler)
+	if abortErr := txc.Aborted(); abortErr != nil {
+		//the background target failures (e.g., the crashed container) cancel the target context
+		//and the handler usually fails with the context error
+		err = abortErr
+	}
+
+	if err != nil {
+		//stop the target goroutines before its cleanup (the temporary containers are removed)
+		txc.Cancel()
+	}
+
+	result.ExitCode = txc.Finish(err)
+	if xerr := txc.Err(); xerr != nil {
+		result.Error = xerr.Error()
+	}
+
+	result.Report = loadBatchTargetReport(tparams.ReportLocation, logger)
+	if result.Error == "" && result.Report != nil {
+		result.Error = result.Report.Error
+	}
+
+	return result
+}
+
+// runBatchTargetHandler calls the target handler
+// (the target handler panics are returned as the target errors)
+func runBatchTargetHandler(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	client *dockerapi.Client,
+	targetRef string,
+	targetIndex int,
+	handler targetHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("target handler panic - %v", r)
+		}
+	}()
+
+	return handler(xc, gparams, client, targetRef, targetIndex)
+}
+
+func loadBatchTargetReport(location string, logger *log.Entry) *report.BuildCommand {
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Debugf("loadBatchTargetReport: error reading target report - %v", err)
+		}
+
+		return nil
+	}
+
+	var targetReport report.BuildCommand
+	if err := json.Unmarshal(data, &targetReport); err != nil {
+		logger.Debugf("loadBatchTargetReport: error decoding target report - %v", err)
+		return nil
+	}
+
+	return &targetReport
+}
+
+// checkBatchOptions checks if the command options can be used to optimize multiple targets concurrently
+func checkBatchOptions(
+	outputTags []string,
+	portBindings map[dockerapi.Port][]dockerapi.PortBinding,
+	doPublishExposedPorts bool,
+	continueAfter *config.ContinueAfter,
+	composeFiles []string,
+) error {
+	//the compose dependency services are shared by the targets and managed by the background goroutines
+	if len(composeFiles) > 0 {
+		return fmt.Errorf("--%s can't be used with multiple targets", commands.FlagComposeFile)
+	}
+
+	if len(outputTags) > 0 {
+		return fmt.Errorf("--%s can't be used with multiple targets", FlagTag)
+	}
+
+	if len(portBindings) > 0 {
+		return fmt.Errorf("--%s can't be used with multiple targets", commands.FlagPublishPort)
+	}
+
+	if doPublishExposedPorts {
+		return fmt.Errorf("--%s can't be used with multiple targets", commands.FlagPublishExposedPorts)
+	}
+
+	if continueAfter != nil &&
+		(hasContinueAfterMode(continueAfter.Mode, config.CAMEnter) ||
+			hasContinueAfterMode(continueAfter.Mode, config.CAMSignal)) {
+		return fmt.Errorf("--%s mode '%s' can't be used with multiple targets", commands.FlagContinueAfter, continueAfter.Mode)
+	}
+
+	return nil
+}
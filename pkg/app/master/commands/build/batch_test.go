@@ -0,0 +1,102 @@
+package build
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+)
+
+func TestRunBatchTarget(t *testing.T) {
+	reportDir, err := ioutil.TempDir("", "batch-target")
+	require.NoError(t, err)
+	defer os.RemoveAll(reportDir)
+
+	xc := app.NewExecutionContext(Name, "text")
+	logger := log.WithField("test", t.Name())
+
+	tt := []struct {
+		name     string
+		handler  targetHandler
+		exitCode int
+		err      string
+	}{
+		{
+			name: "done",
+			handler: func(*app.ExecutionContext, *commands.GenericParams, *dockerapi.Client, string, int) error {
+				return nil
+			},
+		},
+		{
+			name: "exit",
+			handler: func(*app.ExecutionContext, *commands.GenericParams, *dockerapi.Client, string, int) error {
+				return app.NewExitError(commands.ECTBuild | ecbImageBuildError)
+			},
+			exitCode: commands.ECTBuild | ecbImageBuildError,
+		},
+		{
+			name: "error",
+			handler: func(*app.ExecutionContext, *commands.GenericParams, *dockerapi.Client, string, int) error {
+				return app.NewError(app.ErrorCategoryContainer, "container.run", "no container", "")
+			},
+			exitCode: app.ExitCodeContainer,
+			err:      "container.run: no container",
+		},
+		{
+			name: "panic",
+			handler: func(*app.ExecutionContext, *commands.GenericParams, *dockerapi.Client, string, int) error {
+				panic("bad target")
+			},
+			exitCode: app.ExitCodeInternal,
+			err:      "internal: target handler panic - bad target",
+		},
+		{
+			//the background failures cancel the target context (the handler returns the context error)
+			name: "abort",
+			handler: func(xc *app.ExecutionContext, _ *commands.GenericParams, _ *dockerapi.Client, _ string, _ int) error {
+				go xc.Abort(app.NewExitError(-123))
+				<-xc.Context.Done()
+				return xc.Context.Err()
+			},
+			exitCode: -123,
+		},
+	}
+
+	for idx, test := range tt {
+		cleanedUp := false
+		handler := test.handler
+		result := runBatchTarget(xc, &commands.GenericParams{}, nil, test.name, idx, reportDir,
+			func(txc *app.ExecutionContext, gparams *commands.GenericParams, client *dockerapi.Client, targetRef string, targetIndex int) error {
+				txc.AddCleanupHandler(func() { cleanedUp = true })
+				return handler(txc, gparams, client, targetRef, targetIndex)
+			}, logger)
+
+		assert.Equal(t, test.exitCode, result.ExitCode, test.name)
+		assert.Equal(t, test.err, result.Error, test.name)
+		assert.True(t, cleanedUp, test.name)
+	}
+}
+
+func TestRunBatchTargetCanceled(t *testing.T) {
+	xc := app.NewExecutionContext(Name, "text")
+
+	var targetCtx *app.ExecutionContext
+	result := runBatchTarget(xc, &commands.GenericParams{}, nil, "app", 0, "", func(txc *app.ExecutionContext, _ *commands.GenericParams, _ *dockerapi.Client, _ string, _ int) error {
+		targetCtx = txc
+		return errors.New("target failed")
+	}, log.WithField("test", t.Name()))
+
+	assert.Equal(t, app.ExitCodeInternal, result.ExitCode)
+	assert.Equal(t, "internal: target failed", result.Error)
+	//the failed target context is canceled (the command context is not)
+	assert.Error(t, targetCtx.Context.Err())
+	assert.NoError(t, xc.Context.Err())
+}
@@ -0,0 +1,149 @@
+package build
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/cache"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// sensorCacheParams includes the build parameters that affect the collected sensor data
+type sensorCacheParams struct {
+	Version              string
+	RunOpts              *config.ContainerRunOptions
+	Overrides            *config.ContainerOverrides
+	HTTPProbeOpts        config.HTTPProbeOptions
+	HostExecProbes       []string
+	ExecHooks            []config.ExecHook
+	ContinueAfterMode    string
+	ContinueAfterTimeout time.Duration
+	SessionReplayFile    string
+	ExecCmd              string
+	ExecFileCmd          string
+	RunTargetAsUser      bool
+	VolumeMounts         map[string]config.VolumeMount
+	KeepPerms            bool
+	PathPerms            map[string]*fsutil.AccessInfo
+	ExcludePatterns      map[string]*fsutil.AccessInfo
+	PreservePaths        map[string]*fsutil.AccessInfo
+	IncludePaths         map[string]*fsutil.AccessInfo
+	IncludeBins          map[string]*fsutil.AccessInfo
+	IncludeExes          map[string]*fsutil.AccessInfo
+	IncludePkgs          []string
+	IncludePkgDeps       bool
+	IncludeShell         bool
+	IncludeCertAll       bool
+	IncludeCertBundles   bool
+	IncludeCertDirs      bool
+	IncludeCertPKAll     bool
+	IncludeCertPKDirs    bool
+	IncludeNew           bool
+	IncludeEssentials    bool
+	RunAsUser            *config.RunAsUser
+	RtaSourcePT          bool
+	AppNodejsInspectOpts config.AppNodejsInspectOptions
+	//content digests for the files referenced by the probe parameters
+	//(the probe command file is already parsed into the probe commands)
+	FileDigests map[string]string
+}
+
+// sensorResultsKey returns the cache key for the sensor results
+// (an empty key means the sensor results can't be cached)
+func sensorResultsKey(params *sensorCacheParams, logger *log.Entry) string {
+	params.Version = v.Current()
+
+	var files []string
+	for _, cmd := range params.HTTPProbeOpts.Cmds {
+		if cmd.BodyFile != "" {
+			files = append(files, cmd.BodyFile)
+		}
+	}
+
+	files = append(files, params.HTTPProbeOpts.APISpecFiles...)
+	if params.SessionReplayFile != "" {
+		files = append(files, params.SessionReplayFile)
+	}
+
+	var err error
+	params.FileDigests, err = fileDigests(files)
+	if err != nil {
+		logger.Debugf("sensorResultsKey: error getting file digests - %v", err)
+		return ""
+	}
+
+	key, err := cache.ParamsKey(params)
+	if err != nil {
+		logger.Debugf("sensorResultsKey: error creating cache key - %v", err)
+		return ""
+	}
+
+	return key
+}
+
+// fileDigests returns the content digests for the files
+func fileDigests(paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	digests := map[string]string{}
+	for _, fpath := range paths {
+		if _, found := digests[fpath]; found {
+			continue
+		}
+
+		digest, _, err := fileDigest(fpath)
+		if err != nil {
+			return nil, err
+		}
+
+		digests[fpath] = digest
+	}
+
+	return digests, nil
+}
+
+// loadCachedSensorResults restores the cached sensor results in the artifact location
+func loadCachedSensorResults(
+	xc *app.ExecutionContext,
+	resultCache *cache.Store,
+	key string,
+	imageInspector *image.Inspector,
+	logger *log.Entry) bool {
+	found, err := resultCache.LoadDir(imageInspector.ImageInfo.ID, cache.KindSensor, key, imageInspector.ArtifactLocation)
+	if err != nil {
+		logger.Debugf("loadCachedSensorResults: error loading cached results - %v", err)
+		return false
+	}
+
+	status := "miss"
+	if found {
+		status = "hit"
+	}
+
+	xc.Out.Info("sensor.cache",
+		ovars{
+			"status": status,
+			"key":    key,
+		})
+
+	return found
+}
+
+// saveCachedSensorResults saves the collected sensor results in the cache
+func saveCachedSensorResults(
+	resultCache *cache.Store,
+	key string,
+	imageInspector *image.Inspector,
+	logger *log.Entry) {
+	err := resultCache.SaveDir(imageInspector.ImageInfo.ID, cache.KindSensor, key, imageInspector.ArtifactLocation)
+	if err != nil {
+		logger.Debugf("saveCachedSensorResults: error saving results in cache - %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestSensorResultsKeyFileContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sensor-cache-key")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bodyFile := filepath.Join(dir, "body.json")
+	specFile := filepath.Join(dir, "openapi.yaml")
+	require.NoError(t, ioutil.WriteFile(bodyFile, []byte(`{"v":1}`), 0644))
+	require.NoError(t, ioutil.WriteFile(specFile, []byte("openapi: 3.0.0"), 0644))
+
+	logger := log.WithField("test", t.Name())
+	newParams := func() *sensorCacheParams {
+		return &sensorCacheParams{
+			HTTPProbeOpts: config.HTTPProbeOptions{
+				Cmds:         []config.HTTPProbeCmd{{Method: "POST", Resource: "/", BodyFile: bodyFile}},
+				APISpecFiles: []string{specFile},
+			},
+		}
+	}
+
+	key := sensorResultsKey(newParams(), logger)
+	require.NotEmpty(t, key)
+	assert.Equal(t, key, sensorResultsKey(newParams(), logger))
+
+	require.NoError(t, ioutil.WriteFile(bodyFile, []byte(`{"v":2}`), 0644))
+	bodyKey := sensorResultsKey(newParams(), logger)
+	assert.NotEqual(t, key, bodyKey)
+
+	require.NoError(t, ioutil.WriteFile(specFile, []byte("openapi: 3.1.0"), 0644))
+	assert.NotEqual(t, bodyKey, sensorResultsKey(newParams(), logger))
+
+	//no caching if the referenced files can't be read
+	require.NoError(t, os.Remove(specFile))
+	assert.Empty(t, sensorResultsKey(newParams(), logger))
+}
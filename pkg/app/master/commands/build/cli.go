@@ -3,15 +3,22 @@ package build
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 
+	dockerapi "github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/compose"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/sbom"
+	"github.com/docker-slim/docker-slim/pkg/trace"
+	"github.com/docker-slim/docker-slim/pkg/upload"
+	"github.com/docker-slim/docker-slim/pkg/vulnscan"
 )
 
 const (
@@ -26,6 +33,8 @@ var CLI = &cli.Command{
 	Usage:   Usage,
 	Flags: append([]cli.Flag{
 		commands.Cflag(commands.FlagTarget),
+		cflag(FlagTargetsFile),
+		cflag(FlagBatchWorkers),
 		commands.Cflag(commands.FlagPull),
 		commands.Cflag(commands.FlagDockerConfigPath),
 		commands.Cflag(commands.FlagRegistryAccount),
@@ -33,6 +42,9 @@ var CLI = &cli.Command{
 		commands.Cflag(commands.FlagShowPullLogs),
 
 		commands.Cflag(commands.FlagComposeFile),
+		commands.Cflag(commands.FlagDepService),
+		commands.Cflag(commands.FlagDepComposeFile),
+		commands.Cflag(commands.FlagDepHealthyTimeout),
 		commands.Cflag(commands.FlagTargetComposeSvc),
 		commands.Cflag(commands.FlagTargetComposeSvcImage),
 		commands.Cflag(commands.FlagComposeSvcStartWait),
@@ -50,6 +62,9 @@ var CLI = &cli.Command{
 		commands.Cflag(commands.FlagContainerProbeComposeSvc),
 		commands.Cflag(commands.FlagHostExec),
 		commands.Cflag(commands.FlagHostExecFile),
+		commands.Cflag(commands.FlagExecHook),
+		commands.Cflag(commands.FlagSessionRecord),
+		commands.Cflag(commands.FlagSessionReplay),
 
 		commands.Cflag(commands.FlagTargetKubeWorkload),
 		commands.Cflag(commands.FlagTargetKubeWorkloadNamespace),
@@ -65,6 +80,13 @@ var CLI = &cli.Command{
 		cflag(FlagShowBuildLogs),
 		commands.Cflag(commands.FlagCopyMetaArtifacts),
 		commands.Cflag(commands.FlagRemoveFileArtifacts),
+		commands.Cflag(commands.FlagSBOM),
+		commands.Cflag(commands.FlagSBOMOutput),
+		commands.Cflag(commands.FlagReportHTML),
+		commands.Cflag(commands.FlagReportUpload),
+		commands.Cflag(commands.FlagReportUploadArtifacts),
+		commands.Cflag(commands.FlagTraceOutput),
+		commands.Cflag(commands.FlagTraceFormat),
 		commands.Cflag(commands.FlagExec),
 		commands.Cflag(commands.FlagExecFile),
 		//
@@ -97,10 +119,39 @@ var CLI = &cli.Command{
 		cflag(FlagCBOAddHost),
 		cflag(FlagCBOBuildArg),
 		cflag(FlagCBOCacheFrom),
+		cflag(FlagCBOCacheTo),
+		cflag(FlagCBOBuildContext),
 		cflag(FlagCBOLabel),
 		cflag(FlagCBOTarget),
 		cflag(FlagCBONetwork),
 		cflag(FlagDeleteFatImage),
+		cflag(FlagDryRun),
+		cflag(FlagMaxSlimSize),
+		cflag(FlagMinReductionPercent),
+		cflag(FlagFailOnNoReduction),
+		cflag(FlagVerifySlim),
+		cflag(FlagVerifyRetries),
+		cflag(FlagPreserveLayers),
+		cflag(FlagPreserveLayersMinKept),
+		cflag(FlagReproducible),
+		cflag(FlagCacheSensor),
+		cflag(FlagImportSensorData),
+		cflag(FlagOCIOutput),
+		cflag(FlagOCILayerCompression),
+		cflag(FlagScanVulns),
+		cflag(FlagScanVulnsExe),
+		cflag(FlagAuditELF),
+		cflag(FlagStaticScratch),
+		cflag(FlagIncludeDebugImage),
+		cflag(FlagDebugImageTag),
+		cflag(FlagDebugImageToolbox),
+		cflag(FlagImageBuildEngine),
+		cflag(FlagImageBuildEngineEndpoint),
+		cflag(FlagImageBuildCacheFrom),
+		cflag(FlagImageBuildCacheTo),
+		cflag(FlagImageBuildProvenance),
+		cflag(FlagImageBuildPlatform),
+		cflag(FlagImageBuildOutput),
 		//New/Optimized Build Options
 		cflag(FlagNewEntrypoint),
 		cflag(FlagNewCmd),
@@ -123,6 +174,8 @@ var CLI = &cli.Command{
 		cflag(FlagIncludeBinFile),
 		cflag(FlagIncludeExeFile),
 		cflag(FlagIncludeExe),
+		cflag(FlagIncludePkg),
+		cflag(FlagIncludePkgDeps),
 		cflag(FlagIncludeShell),
 		cflag(FlagIncludeCertAll),
 		cflag(FlagIncludeCertBundles),
@@ -130,6 +183,8 @@ var CLI = &cli.Command{
 		cflag(FlagIncludeCertPKAll),
 		cflag(FlagIncludeCertPKDirs),
 		cflag(FlagIncludeNew),
+		cflag(FlagIncludeRuntimeEssentials),
+		cflag(FlagRunAsUser),
 		cflag(FlagKeepTmpArtifacts),
 		cflag(FlagIncludeAppNuxtDir),
 		cflag(FlagIncludeAppNuxtBuildDir),
@@ -142,10 +197,18 @@ var CLI = &cli.Command{
 		cflag(FlagIncludeAppNextStaticDir),
 		cflag(FlagIncludeAppNextNodeModulesDir),
 		cflag(FlagIncludeNodePackage),
+		cflag(FlagNodePrunePackages),
+		cflag(FlagNodeKeepDeclaredDeps),
+		cflag(FlagJavaJLink),
+		cflag(FlagJavaJLinkImage),
+		cflag(FlagJavaAddModules),
+		cflag(FlagPythonPrunePackages),
+		cflag(FlagPythonKeepPackage),
 		cflag(FlagKeepPerms),
 		cflag(FlagPathPerms),
 		cflag(FlagPathPermsFile),
 		commands.Cflag(commands.FlagContinueAfter),
+		commands.Cflag(commands.FlagPreset),
 		commands.Cflag(commands.FlagUseLocalMounts),
 		commands.Cflag(commands.FlagUseSensorVolume),
 		commands.Cflag(commands.FlagRTAOnbuildBaseImage),
@@ -162,9 +225,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.container.build.options", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		deleteFatImage := ctx.Bool(commands.FlagDeleteFatImage)
@@ -187,15 +250,52 @@ var CLI = &cli.Command{
 
 		composeSvcStartWait := ctx.Int(commands.FlagComposeSvcStartWait)
 
+		var composeSvcHealthyTimeout int
+		depComposeFiles := ctx.StringSlice(commands.FlagDepComposeFile)
+		depServices, err := commands.ParseDepServices(ctx.StringSlice(commands.FlagDepService))
+		if err != nil {
+			xc.Out.Error("param.error.dep.service", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		if len(depServices) > 0 {
+			depServicesFile, err := ioutil.TempFile("", "docker-slim-deps-*.yaml")
+			xc.FailOn(err)
+			depServicesFile.Close()
+			xc.AddCleanupHandler(func() { os.Remove(depServicesFile.Name()) })
+			defer os.Remove(depServicesFile.Name())
+
+			err = compose.WriteDepServicesFile(depServicesFile.Name(), depServices)
+			if err != nil {
+				xc.Out.Error("param.error.dep.service", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
+
+			depComposeFiles = append(depComposeFiles, depServicesFile.Name())
+		}
+
+		if len(depComposeFiles) > 0 {
+			composeFiles = append(composeFiles, depComposeFiles...)
+			composeSvcHealthyTimeout = ctx.Int(commands.FlagDepHealthyTimeout)
+		}
+
 		composeEnvNoHost := ctx.Bool(commands.FlagComposeEnvNoHost)
 		composeEnvVars, err := commands.ParseEnvFile(ctx.String(commands.FlagComposeEnvFile))
 		if err != nil {
 			xc.Out.Error("param.error.compose.env.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		composeProjectName := ctx.String(commands.FlagComposeProjectName)
@@ -207,34 +307,124 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.kubernetes.options", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		sizePolicy, err := GetSizePolicy(ctx)
+		if err != nil {
+			xc.Out.Error("param.error.size.policy", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		preserveLayersMinKept := ctx.Int(FlagPreserveLayersMinKept)
+		if preserveLayersMinKept < 0 || preserveLayersMinKept > 100 {
+			xc.Out.Error("param.error.preserve.layers.min.kept", "value must be between 0 and 100")
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		ociLayerCompression := ctx.String(FlagOCILayerCompression)
+		if !IsOCILayerCompression(ociLayerCompression) {
+			xc.Out.Error("param.error.oci.layer.compression", fmt.Sprintf("unsupported value - '%s'", ociLayerCompression))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		sbomFormat := ctx.String(commands.FlagSBOM)
+		if sbomFormat != "" && !sbom.IsFormat(sbomFormat) {
+			xc.Out.Error("param.error.sbom", fmt.Sprintf("unsupported SBOM format - '%s'", sbomFormat))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		reportUpload := ctx.String(commands.FlagReportUpload)
+		if reportUpload != "" && !upload.IsLocation(reportUpload) {
+			xc.Out.Error("param.error.report.upload", fmt.Sprintf("unsupported report upload location - '%s'", reportUpload))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		traceFormat := ctx.String(commands.FlagTraceFormat)
+		if !trace.IsFormat(traceFormat) {
+			xc.Out.Error("param.error.trace.format", fmt.Sprintf("unsupported trace format - '%s'", traceFormat))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		vulnScanner := ctx.String(FlagScanVulns)
+		if vulnScanner != "" && !vulnscan.IsScanner(vulnScanner) {
+			xc.Out.Error("param.error.scan.vulns", fmt.Sprintf("unsupported vulnerability scanner - '%s'", vulnScanner))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		buildEngineOpts, err := GetImageBuildEngineOptions(ctx)
+		if err != nil {
+			xc.Out.Error("param.error.image.build.engine", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		var targetRef string
+		var batchTargets []string
 
 		if kubeOpts.HasTargetSet() {
 			targetRef = kubeOpts.Target.Workload
 		} else if len(composeFiles) > 0 && targetComposeSvc != "" {
 			targetRef = targetComposeSvc
 		} else if cbOpts.Dockerfile == "" {
-			targetRef = ctx.String(commands.FlagTarget)
+			targets, err := GetTargetImages(ctx)
+			if err != nil {
+				xc.Out.Error("param.error.targets.file", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
 
-			if targetRef == "" {
-				if ctx.Args().Len() < 1 {
-					xc.Out.Error("param.target", "missing image ID/name")
-					cli.ShowCommandHelp(ctx, Name)
-					return nil
-				} else {
-					targetRef = ctx.Args().First()
-				}
+			if len(targets) < 1 {
+				xc.Out.Error("param.target", "missing image ID/name")
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			}
+
+			targetRef = targets[0]
+			if len(targets) > 1 {
+				batchTargets = targets
 			}
 		} else {
 			targetRef = cbOpts.DockerfileContext
 			if targetRef == "" {
 				if ctx.Args().Len() < 1 {
-					xc.Out.Error("param.target", "missing Dockerfile build context directory")
+					xc.Out.Error("param.target", "missing Dockerfile build context (directory, URL or '-' for stdin)")
 					cli.ShowCommandHelp(ctx, Name)
 					return nil
 				} else {
@@ -254,9 +444,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.global", "missing params")
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		appOpts, ok := commands.CLIContextGet(ctx.Context, commands.AppParams).(*config.AppOptions)
@@ -269,9 +459,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.container.run.options", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		doPull := ctx.Bool(commands.FlagPull)
@@ -288,9 +478,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.publish.port", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		doPublishExposedPorts := ctx.Bool(commands.FlagPublishExposedPorts)
@@ -302,9 +492,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.continue.after", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		if continueAfter.Mode == config.CAMProbe && !httpProbeOpts.Do {
@@ -331,14 +521,14 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.exec", "fatal: cannot use both --exec and --exec-file")
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 		var execFileCmd []byte
 		if len(execFile) > 0 {
 			execFileCmd, err = ioutil.ReadFile(execFile)
-			errutil.FailOn(err)
+			xc.FailOn(err)
 
 			if !strings.Contains(continueAfter.Mode, config.CAMExec) {
 				if continueAfter.Mode == "" {
@@ -397,15 +587,53 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.host.exec.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		if len(moreHostExecProbes) > 0 {
 			hostExecProbes = append(hostExecProbes, moreHostExecProbes...)
 		}
 
+		sessionRecordFile, sessionReplayFile, err := commands.GetExecSessionFiles(ctx)
+		if err != nil {
+			xc.Out.Error("param.session", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		continueAfter.SessionRecordFile = sessionRecordFile
+		continueAfter.SessionReplayFile = sessionReplayFile
+		if sessionRecordFile != "" || sessionReplayFile != "" {
+			if !strings.Contains(continueAfter.Mode, config.CAMSession) {
+				if continueAfter.Mode == "" || continueAfter.Mode == config.CAMEnter {
+					//the interactive session replaces the 'enter' mode
+					continueAfter.Mode = config.CAMSession
+				} else {
+					continueAfter.Mode = fmt.Sprintf("%s&%s", continueAfter.Mode, config.CAMSession)
+				}
+
+				xc.Out.Info("session",
+					ovars{
+						"message": fmt.Sprintf("updating continue-after mode to %s", continueAfter.Mode),
+					})
+			}
+		}
+
+		execHooks, err := commands.ParseExecHooks(ctx.StringSlice(commands.FlagExecHook))
+		if err != nil {
+			xc.Out.Error("param.exec.hook", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
 		if strings.Contains(continueAfter.Mode, config.CAMHostExec) &&
 			len(hostExecProbes) == 0 {
 			if continueAfter.Mode == config.CAMHostExec {
@@ -452,9 +680,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.image.overrides", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		instructions, err := GetImageInstructions(ctx)
@@ -462,9 +690,23 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.image.instructions", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		runAsUser, err := GetRunAsUser(ctx)
+		if err != nil {
+			xc.Out.Error("param.error.run.as.user", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		if runAsUser != nil {
+			instructions.User = runAsUser.String()
 		}
 
 		volumeMounts, err := commands.ParseVolumeMounts(ctx.StringSlice(commands.FlagMount))
@@ -472,9 +714,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.mount", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		excludePatterns := commands.ParsePaths(ctx.StringSlice(commands.FlagExcludePattern))
@@ -485,9 +727,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.preserve.path.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		} else {
 			for k, v := range morePreservePaths {
 				preservePaths[k] = v
@@ -500,9 +742,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.include.path.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		} else {
 			for k, v := range moreIncludePaths {
 				includePaths[k] = v
@@ -515,9 +757,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.path.perms.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		} else {
 			for k, v := range morePathPerms {
 				pathPerms[k] = v
@@ -530,9 +772,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.include.bin.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		} else {
 			for k, v := range moreIncludeBins {
 				includeBins[k] = v
@@ -545,15 +787,25 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.include.exe.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		} else {
 			for k, v := range moreIncludeExes {
 				includeExes[k] = v
 			}
 		}
 
+		var includePkgs []string
+		for _, name := range ctx.StringSlice(FlagIncludePkg) {
+			//each value can be a comma separated list of packages
+			for _, pkgName := range strings.Split(name, ",") {
+				if pkgName = strings.TrimSpace(pkgName); pkgName != "" {
+					includePkgs = append(includePkgs, pkgName)
+				}
+			}
+		}
+
 		doIncludeShell := ctx.Bool(FlagIncludeShell)
 
 		doIncludeCertAll := ctx.Bool(FlagIncludeCertAll)
@@ -563,6 +815,7 @@ var CLI = &cli.Command{
 		doIncludeCertPKDirs := ctx.Bool(FlagIncludeCertPKDirs)
 
 		doIncludeNew := ctx.Bool(FlagIncludeNew)
+		doIncludeEssentials := ctx.Bool(FlagIncludeRuntimeEssentials)
 
 		doUseLocalMounts := ctx.Bool(commands.FlagUseLocalMounts)
 		doUseSensorVolume := ctx.String(commands.FlagUseSensorVolume)
@@ -586,78 +839,176 @@ var CLI = &cli.Command{
 		rtaOnbuildBaseImage := ctx.Bool(commands.FlagRTAOnbuildBaseImage)
 		rtaSourcePT := ctx.Bool(commands.FlagRTASourcePT)
 
-		OnCommand(
-			xc,
-			gparams,
-			targetRef,
-			doPull,
-			dockerConfigPath,
-			registryAccount,
-			registrySecret,
-			doShowPullLogs,
-			composeFiles,
-			targetComposeSvc,
-			targetComposeSvcImage,
-			composeSvcStartWait,
-			composeSvcNoPorts,
-			depExcludeComposeSvcAll,
-			depIncludeComposeSvcDeps,
-			depIncludeTargetComposeSvcDeps,
-			depIncludeComposeSvcs,
-			depExcludeComposeSvcs,
-			composeNets,
-			composeEnvVars,
-			composeEnvNoHost,
-			composeWorkdir,
-			composeProjectName,
-			containerProbeComposeSvc,
-			cbOpts,
-			crOpts,
-			outputTags,
-			httpProbeOpts,
-			portBindings,
-			doPublishExposedPorts,
-			hostExecProbes,
-			doRmFileArtifacts,
-			doCopyMetaArtifacts,
-			doRunTargetAsUser,
-			doShowContainerLogs,
-			doShowBuildLogs,
-			commands.ParseImageOverrides(doImageOverrides),
-			overrides,
-			instructions,
-			ctx.StringSlice(commands.FlagLink),
-			ctx.StringSlice(commands.FlagEtcHostsMap),
-			ctx.StringSlice(commands.FlagContainerDNS),
-			ctx.StringSlice(commands.FlagContainerDNSSearch),
-			volumeMounts,
-			doKeepPerms,
-			pathPerms,
-			excludePatterns,
-			preservePaths,
-			includePaths,
-			includeBins,
-			includeExes,
-			doIncludeShell,
-			doIncludeCertAll,
-			doIncludeCertBundles,
-			doIncludeCertDirs,
-			doIncludeCertPKAll,
-			doIncludeCertPKDirs,
-			doIncludeNew,
-			doUseLocalMounts,
-			doUseSensorVolume,
-			doKeepTmpArtifacts,
-			continueAfter,
-			execCmd,
-			string(execFileCmd),
-			deleteFatImage,
-			rtaOnbuildBaseImage,
-			rtaSourcePT,
-			ctx.String(commands.FlagSensorIPCEndpoint),
-			ctx.String(commands.FlagSensorIPCMode),
-			kubeOpts,
-			GetAppNodejsInspectOptions(ctx))
+		if len(batchTargets) > 0 {
+			if err := checkBatchOptions(outputTags, portBindings, doPublishExposedPorts, continueAfter, composeFiles); err != nil {
+				xc.Out.Error("param.error.batch", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
+		}
+
+		ociOutput := ctx.String(FlagOCIOutput)
+		sbomOutput := ctx.String(commands.FlagSBOMOutput)
+		reportHTML := ctx.String(commands.FlagReportHTML)
+		traceOutput := ctx.String(commands.FlagTraceOutput)
+
+		slimTarget := func(
+			xc *app.ExecutionContext,
+			gparams *commands.GenericParams,
+			client *dockerapi.Client,
+			targetRef string,
+			targetIndex int) error {
+			copyMetaArtifactsLocation := doCopyMetaArtifacts
+			ociOutputLocation := ociOutput
+			sbomOutputLocation := sbomOutput
+			reportHTMLLocation := reportHTML
+			reportUploadLocation := reportUpload
+			traceOutputLocation := traceOutput
+			if len(batchTargets) > 0 {
+				copyMetaArtifactsLocation = batchTargetPath(doCopyMetaArtifacts, targetIndex)
+				ociOutputLocation = batchTargetPath(ociOutput, targetIndex)
+				if sbomOutput != "" {
+					sbomOutputLocation = filepath.Join(
+						batchTargetPath(filepath.Dir(sbomOutput), targetIndex),
+						filepath.Base(sbomOutput))
+				}
+
+				if reportHTML != "" {
+					reportHTMLLocation = filepath.Join(
+						batchTargetPath(filepath.Dir(reportHTML), targetIndex),
+						filepath.Base(reportHTML))
+				}
+
+				if reportUpload != "" {
+					reportUploadLocation = upload.JoinLocation(reportUpload, fmt.Sprintf("target.%d", targetIndex+1))
+				}
+
+				if traceOutput != "" {
+					traceOutputLocation = filepath.Join(
+						batchTargetPath(filepath.Dir(traceOutput), targetIndex),
+						filepath.Base(traceOutput))
+				}
+			}
+
+			return OnCommand(
+				xc,
+				gparams,
+				client,
+				targetRef,
+				doPull,
+				dockerConfigPath,
+				registryAccount,
+				registrySecret,
+				doShowPullLogs,
+				composeFiles,
+				targetComposeSvc,
+				targetComposeSvcImage,
+				composeSvcStartWait,
+				composeSvcHealthyTimeout,
+				composeSvcNoPorts,
+				depExcludeComposeSvcAll,
+				depIncludeComposeSvcDeps,
+				depIncludeTargetComposeSvcDeps,
+				depIncludeComposeSvcs,
+				depExcludeComposeSvcs,
+				composeNets,
+				composeEnvVars,
+				composeEnvNoHost,
+				composeWorkdir,
+				composeProjectName,
+				containerProbeComposeSvc,
+				cbOpts,
+				crOpts,
+				outputTags,
+				httpProbeOpts,
+				portBindings,
+				doPublishExposedPorts,
+				hostExecProbes,
+				execHooks,
+				doRmFileArtifacts,
+				copyMetaArtifactsLocation,
+				doRunTargetAsUser,
+				doShowContainerLogs,
+				doShowBuildLogs,
+				commands.ParseImageOverrides(doImageOverrides),
+				overrides,
+				instructions,
+				ctx.StringSlice(commands.FlagLink),
+				ctx.StringSlice(commands.FlagEtcHostsMap),
+				ctx.StringSlice(commands.FlagContainerDNS),
+				ctx.StringSlice(commands.FlagContainerDNSSearch),
+				volumeMounts,
+				doKeepPerms,
+				pathPerms,
+				excludePatterns,
+				preservePaths,
+				includePaths,
+				includeBins,
+				includeExes,
+				includePkgs,
+				ctx.Bool(FlagIncludePkgDeps),
+				doIncludeShell,
+				doIncludeCertAll,
+				doIncludeCertBundles,
+				doIncludeCertDirs,
+				doIncludeCertPKAll,
+				doIncludeCertPKDirs,
+				doIncludeNew,
+				doIncludeEssentials,
+				runAsUser,
+				doUseLocalMounts,
+				doUseSensorVolume,
+				doKeepTmpArtifacts,
+				continueAfter,
+				execCmd,
+				string(execFileCmd),
+				deleteFatImage,
+				ctx.Bool(FlagDryRun),
+				sizePolicy,
+				ctx.Bool(FlagVerifySlim),
+				ctx.Int(FlagVerifyRetries),
+				ctx.Bool(FlagPreserveLayers),
+				preserveLayersMinKept,
+				ctx.Bool(FlagReproducible),
+				ociOutputLocation,
+				ociLayerCompression,
+				sbomFormat,
+				sbomOutputLocation,
+				vulnScanner,
+				ctx.String(FlagScanVulnsExe),
+				ctx.Bool(FlagAuditELF),
+				ctx.Bool(FlagStaticScratch),
+				ctx.Bool(FlagIncludeDebugImage),
+				ctx.String(FlagDebugImageTag),
+				ctx.String(FlagDebugImageToolbox),
+				reportHTMLLocation,
+				reportUploadLocation,
+				ctx.Bool(commands.FlagReportUploadArtifacts),
+				traceOutputLocation,
+				traceFormat,
+				buildEngineOpts,
+				rtaOnbuildBaseImage,
+				rtaSourcePT,
+				ctx.String(commands.FlagSensorIPCEndpoint),
+				ctx.String(commands.FlagSensorIPCMode),
+				ctx.Bool(FlagCacheSensor),
+				ctx.String(FlagImportSensorData),
+				kubeOpts,
+				GetAppNodejsInspectOptions(ctx),
+				GetAppNodejsPackageOptions(ctx),
+				GetAppJavaRuntimeOptions(ctx),
+				GetAppPythonPackageOptions(ctx))
+		}
+
+		if len(batchTargets) > 0 {
+			runBatch(xc, gparams, batchTargets, ctx.Int(FlagBatchWorkers), slimTarget)
+			return nil
+		}
+
+		xc.FailOn(slimTarget(xc, gparams, nil, targetRef, 0))
 
 		return nil
 	},
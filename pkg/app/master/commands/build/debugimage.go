@@ -0,0 +1,196 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const (
+	//static busybox build (it works in the optimized images without the libc)
+	DefaultDebugImageToolbox = "busybox:musl"
+
+	debugImageDirName     = "debug-image"
+	debugImageNameSuffix  = ".debug"
+	debugImageToolsPath   = "/.dslim-debug/bin"
+	debugImageToolboxExe  = "/bin/busybox"
+	debugImageDefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	//the optimized image used to build the debug image
+	debugImageLabelName = "docker-slim.debug.source"
+)
+
+type debugImageInfo struct {
+	Toolbox     string
+	ToolboxExe  string
+	BaseImage   string
+	ToolsPath   string
+	Path        string
+	User        string
+	Label       string
+	ImageSource string
+}
+
+// the toolbox binary is static, so the RUN instruction uses the exec form
+// (the optimized image might not have a shell)
+var debugDockerfileTemplate = template.Must(template.New("dockerfile").Parse(`FROM {{.Toolbox}} AS toolbox
+
+FROM {{.BaseImage}}
+USER root
+COPY --from=toolbox {{.ToolboxExe}} {{.ToolsPath}}/busybox
+RUN ["{{.ToolsPath}}/busybox", "--install", "-s", "{{.ToolsPath}}"]
+ENV PATH="{{.Path}}:{{.ToolsPath}}"
+LABEL {{.Label}}="{{.ImageSource}}"
+{{- if .User}}
+USER {{.User}}
+{{- end}}
+`))
+
+// debugImageName returns the debug image name for the optimized image
+// (<repo>.debug:<tag> - the same naming scheme as the one used for the fat images)
+func debugImageName(minifiedImageName string) string {
+	name := imageTagName(minifiedImageName)
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		return name[:idx] + debugImageNameSuffix + name[idx:]
+	}
+
+	return name + debugImageNameSuffix
+}
+
+// buildDebugImage builds the debug variant of the optimized image
+// (the optimized image with the static toolbox tools added in a separate directory on PATH)
+func buildDebugImage(
+	xc *app.ExecutionContext,
+	minifiedImageName string,
+	debugImageTag string,
+	toolbox string,
+	doShowBuildLogs bool,
+	artifactLocation string,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	if debugImageTag == "" {
+		debugImageTag = debugImageName(minifiedImageName)
+	}
+
+	if toolbox == "" {
+		toolbox = DefaultDebugImageToolbox
+	}
+
+	xc.Out.State("building",
+		ovars{
+			"message": "building debug image",
+		})
+
+	workDir := filepath.Join(artifactLocation, debugImageDirName)
+	defer os.RemoveAll(workDir)
+
+	var buildLog bytes.Buffer
+	imageInfo, err := createDebugImage(xc, client, minifiedImageName, debugImageTag, toolbox, workDir, &buildLog)
+	if doShowBuildLogs || err != nil {
+		xc.Out.LogDump("debug.image.build", buildLog.String(),
+			ovars{
+				"tag": debugImageTag,
+			})
+	}
+
+	if err != nil {
+		logger.Errorf("buildDebugImage: error - %v", err)
+		xc.Out.Info("debug.image",
+			ovars{
+				"status": "error",
+				"name":   debugImageTag,
+				"error":  err.Error(),
+			})
+		return
+	}
+
+	cmdReport.DebugImage = &report.DebugImageInfo{
+		Name:      debugImageTag,
+		ID:        imageInfo.ID,
+		Size:      imageInfo.Size,
+		Toolbox:   toolbox,
+		ToolsPath: debugImageToolsPath,
+	}
+
+	xc.Out.Info("debug.image",
+		ovars{
+			"name":       debugImageTag,
+			"id":         imageInfo.ID,
+			"size.human": humanize.Bytes(uint64(imageInfo.Size)),
+			"toolbox":    toolbox,
+			"tools":      debugImageToolsPath,
+		})
+}
+
+func createDebugImage(
+	xc *app.ExecutionContext,
+	client *dockerapi.Client,
+	baseImage string,
+	debugImageTag string,
+	toolbox string,
+	workDir string,
+	buildLog *bytes.Buffer) (*dockerapi.Image, error) {
+	baseInfo, err := client.InspectImage(baseImage)
+	if err != nil {
+		return nil, err
+	}
+
+	info := debugImageInfo{
+		Toolbox:     toolbox,
+		ToolboxExe:  debugImageToolboxExe,
+		BaseImage:   baseImage,
+		ToolsPath:   debugImageToolsPath,
+		Path:        debugImageDefaultPath,
+		Label:       debugImageLabelName,
+		ImageSource: baseImage,
+	}
+
+	if baseInfo.Config != nil {
+		info.User = baseInfo.Config.User
+		for _, envVar := range baseInfo.Config.Env {
+			if strings.HasPrefix(envVar, "PATH=") {
+				info.Path = strings.TrimPrefix(envVar, "PATH=")
+			}
+		}
+	}
+
+	var dockerfile bytes.Buffer
+	if err := debugDockerfileTemplate.Execute(&dockerfile, &info); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(workDir, "Dockerfile"), dockerfile.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+
+	err = client.BuildImage(dockerapi.BuildImageOptions{
+		Context:             xc.Context,
+		Name:                debugImageTag,
+		ContextDir:          workDir,
+		Dockerfile:          "Dockerfile",
+		RmTmpContainer:      true,
+		ForceRmTmpContainer: true,
+		OutputStream:        buildLog,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("debug image build error - %w", err)
+	}
+
+	return client.InspectImage(debugImageTag)
+}
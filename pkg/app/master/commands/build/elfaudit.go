@@ -0,0 +1,105 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/elfaudit"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+// auditSlimImageELF resolves the library dependencies for the dynamic ELF binaries kept in the optimized image
+// (using the optimized image file artifacts) to catch the missing libraries before the image is used
+func auditSlimImageELF(
+	xc *app.ExecutionContext,
+	minifiedImageName string,
+	artifactLocation string,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	tree, err := slimFilesTree(artifactLocation)
+	if err != nil {
+		logger.Errorf("auditSlimImageELF: error loading file artifacts - %v", err)
+		xc.Out.Info("slim.elf.deps",
+			ovars{
+				"error": err.Error(),
+			})
+		return
+	}
+
+	var env []string
+	if imageInfo, err := client.InspectImage(minifiedImageName); err == nil && imageInfo.Config != nil {
+		env = imageInfo.Config.Env
+	} else {
+		logger.Debugf("auditSlimImageELF: error inspecting optimized image - %v", err)
+	}
+
+	elfDeps := elfaudit.Audit(tree, dockerimage.LibraryPathDirs(env))
+	cmdReport.ELFDeps = elfDeps
+
+	status := "ok"
+	if elfDeps.BrokenExecutableCount > 0 || elfDeps.BrokenLibraryCount > 0 {
+		status = "missing.dependencies"
+	}
+
+	xc.Out.Info("slim.elf.deps",
+		ovars{
+			"status":             status,
+			"executables":        elfDeps.ExecutableCount,
+			"libraries":          elfDeps.LibraryCount,
+			"broken.executables": elfDeps.BrokenExecutableCount,
+			"broken.libraries":   elfDeps.BrokenLibraryCount,
+		})
+
+	for _, info := range elfDeps.Executables {
+		if info.Broken() {
+			printMissingELFDeps(xc, info)
+		}
+	}
+
+	for _, info := range elfDeps.BrokenLibraries {
+		printMissingELFDeps(xc, info)
+	}
+}
+
+func printMissingELFDeps(xc *app.ExecutionContext, info *elfaudit.ObjectDeps) {
+	objectInfo := ovars{
+		"type": info.Type,
+		"path": info.Path,
+	}
+
+	if info.MissingInterpreter {
+		objectInfo["missing.interpreter"] = info.Interpreter
+	}
+
+	if len(info.Missing) > 0 {
+		objectInfo["missing.libraries"] = strings.Join(info.Missing, ",")
+	}
+
+	xc.Out.Info("slim.elf.deps.missing", objectInfo)
+}
+
+// slimFilesTree loads the optimized image filesystem tree
+// (from the file artifacts archive or from the file artifacts directory)
+func slimFilesTree(artifactLocation string) (*elfaudit.Tree, error) {
+	tarPath := filepath.Join(artifactLocation, fileArtifactsTar)
+	if !fsutil.IsRegularFile(tarPath) {
+		return elfaudit.NewTreeFromDir(filepath.Join(artifactLocation, fileArtifactsDir))
+	}
+
+	tfile, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tfile.Close()
+	return elfaudit.NewTreeFromTar(tfile)
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/dustin/go-humanize"
+
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 
@@ -19,6 +22,49 @@ const (
 
 	FlagShowBuildLogs = "show-blogs"
 
+	FlagDryRun = "dry-run"
+
+	FlagVerifySlim    = "verify-slim"
+	FlagVerifyRetries = "verify-retries"
+
+	FlagPreserveLayers        = "preserve-layers"
+	FlagPreserveLayersMinKept = "preserve-layers-min-kept"
+
+	FlagReproducible = "reproducible"
+
+	FlagCacheSensor = "cache-sensor"
+
+	FlagImportSensorData = "import-sensor-data"
+
+	FlagOCIOutput           = "oci-output"
+	FlagOCILayerCompression = "oci-layer-compression"
+
+	FlagScanVulns    = "scan-vulns"
+	FlagScanVulnsExe = "scan-vulns-exe"
+
+	FlagAuditELF = "audit-elf"
+
+	FlagStaticScratch = "static-scratch"
+
+	FlagIncludeDebugImage = "include-debug-image"
+	FlagDebugImageTag     = "debug-image-tag"
+	FlagDebugImageToolbox = "debug-image-toolbox"
+
+	FlagImageBuildEngine         = "image-build-engine"
+	FlagImageBuildEngineEndpoint = "image-build-engine-endpoint"
+	FlagImageBuildCacheFrom      = "image-build-cache-from"
+	FlagImageBuildCacheTo        = "image-build-cache-to"
+	FlagImageBuildProvenance     = "image-build-provenance"
+	FlagImageBuildPlatform       = "image-build-platform"
+	FlagImageBuildOutput         = "image-build-output"
+
+	FlagTargetsFile  = "targets-file"
+	FlagBatchWorkers = "batch-workers"
+
+	FlagMaxSlimSize         = "max-slim-size"
+	FlagMinReductionPercent = "min-reduction-percent"
+	FlagFailOnNoReduction   = "fail-on-no-reduction"
+
 	FlagPathPerms        = "path-perms"
 	FlagPathPermsFile    = "path-perms-file"
 	FlagPreservePath     = "preserve-path"
@@ -29,6 +75,8 @@ const (
 	FlagIncludeBinFile   = "include-bin-file"
 	FlagIncludeExe       = "include-exe"
 	FlagIncludeExeFile   = "include-exe-file"
+	FlagIncludePkg       = "include-pkg"
+	FlagIncludePkgDeps   = "include-pkg-deps"
 	FlagIncludeShell     = "include-shell"
 
 	FlagIncludeCertAll     = "include-cert-all"
@@ -39,6 +87,10 @@ const (
 
 	FlagIncludeNew = "include-new"
 
+	FlagIncludeRuntimeEssentials = "include-runtime-essentials"
+
+	FlagRunAsUser = "run-as-user"
+
 	//FlagIncludeLicenses  = "include-licenses"
 
 	FlagKeepTmpArtifacts = "keep-tmp-artifacts"
@@ -57,6 +109,16 @@ const (
 
 	FlagIncludeNodePackage = "include-node-package"
 
+	FlagNodePrunePackages    = "node-prune-packages"
+	FlagNodeKeepDeclaredDeps = "node-keep-declared-deps"
+
+	FlagJavaJLink      = "java-jlink"
+	FlagJavaJLinkImage = "java-jlink-image"
+	FlagJavaAddModules = "java-add-modules"
+
+	FlagPythonPrunePackages = "python-prune-packages"
+	FlagPythonKeepPackage   = "python-keep-package"
+
 	FlagKeepPerms = "keep-perms"
 
 	//Flags to edit (modify, add and remove) image metadata
@@ -86,6 +148,8 @@ const (
 	FlagCBOTarget           = "cbo-target"
 	FlagCBONetwork          = "cbo-network"
 	FlagCBOCacheFrom        = "cbo-cache-from"
+	FlagCBOBuildContext     = "cbo-build-context"
+	FlagCBOCacheTo          = "cbo-cache-to"
 )
 
 // Build command flag usage info
@@ -94,6 +158,49 @@ const (
 
 	FlagShowBuildLogsUsage = "Show image build logs"
 
+	FlagTargetsFileUsage  = "File with the target images to optimize (one per line; the targets are optimized concurrently when there's more than one)"
+	FlagBatchWorkersUsage = "Number of target images optimized concurrently (when there are multiple targets)"
+
+	FlagMaxSlimSizeUsage         = "Fail the build if the optimized image is bigger than the provided size (e.g., 50MB)"
+	FlagMinReductionPercentUsage = "Fail the build if the image size is reduced by less than the provided percentage"
+	FlagFailOnNoReductionUsage   = "Fail the build if the optimized image is not smaller than the original image"
+
+	FlagVerifySlimUsage    = "Verify the optimized image running the HTTP probes against it"
+	FlagVerifyRetriesUsage = "Max number of optimized image rebuilds (with expanded includes) when it fails verification"
+
+	FlagPreserveLayersUsage        = "Reuse the original image layers where most of the files are kept (the rest of the kept files are added in a new layer)"
+	FlagPreserveLayersMinKeptUsage = "Min percentage of the layer data (by size) that needs to be kept to reuse the original layer"
+
+	FlagReproducibleUsage = "Build the same optimized image (with the same image ID) from the same input (fixed timestamps and stable file ordering)"
+
+	FlagCacheSensorUsage = "Reuse the cached sensor results from a previous build of the same image with the same parameters (skips the instrumented container run)"
+
+	FlagImportSensorDataUsage = "Use the sensor data collected by an instrumented image ('instrument' command) instead of running the instrumented container (the artifact directory or its tar archive copied from the container)"
+
+	FlagOCIOutputUsage           = "Save the optimized image in the OCI image layout directory"
+	FlagOCILayerCompressionUsage = "Layer compression for the OCI image layout output (gzip, zstd or estargz)"
+
+	FlagScanVulnsUsage    = "Scan the original and the optimized images for vulnerabilities with the selected scanner (trivy or grype) and report the difference"
+	FlagScanVulnsExeUsage = "Vulnerability scanner executable path (defaults to the scanner name in PATH)"
+
+	FlagAuditELFUsage = "Check the library dependencies for the dynamic ELF binaries in the optimized image and report the missing libraries"
+
+	FlagStaticScratchUsage = "Keep only the app binary, the files it uses, the CA certificates and the tmp directories if the app is a single static binary"
+
+	FlagIncludeDebugImageUsage = "Also build the debug variant of the optimized image (the optimized image with the static busybox tools)"
+	FlagDebugImageTagUsage     = "Debug image tag (default: <optimized image repo>.debug:<optimized image tag>)"
+	FlagDebugImageToolboxUsage = "Image with the static busybox binary (/bin/busybox) added to the debug image"
+
+	FlagImageBuildEngineUsage         = "Engine used to assemble the optimized image (internal - classic build API, buildx or buildkitd)"
+	FlagImageBuildEngineEndpointUsage = "buildkitd address (for 'buildkitd') or builder instance name (for 'buildx')"
+	FlagImageBuildCacheFromUsage      = "BuildKit cache import spec (e.g., 'type=registry,ref=repo/cache')"
+	FlagImageBuildCacheToUsage        = "BuildKit cache export spec (e.g., 'type=registry,ref=repo/cache,mode=max')"
+	FlagImageBuildProvenanceUsage     = "BuildKit provenance attestation mode for the image build output (min or max)"
+	FlagImageBuildPlatformUsage       = "Target platform for the image build (must be the target image platform, e.g., 'linux/amd64')"
+	FlagImageBuildOutputUsage         = "BuildKit output spec (e.g., 'type=registry' or 'type=oci,dest=slim.tar') used in addition to loading the image into the Docker engine"
+
+	FlagDryRunUsage = "Analyze the target and create a build plan (files to keep and drop, metadata changes) without building the optimized image"
+
 	FlagPathPermsUsage        = "Set path permissions in optimized image"
 	FlagPathPermsFileUsage    = "File with path permissions to set"
 	FlagPreservePathUsage     = "Keep path from orignal image in its initial state (changes to the selected container image files when it runs will be discarded)"
@@ -102,6 +209,8 @@ const (
 	FlagIncludePathFileUsage  = "File with paths to keep from original image"
 	FlagIncludeBinUsage       = "Keep binary from original image (executable or shared object using its absolute path)"
 	FlagIncludeExeUsage       = "Keep executable from original image (by executable name)"
+	FlagIncludePkgUsage       = "Keep all files owned by the OS package from original image (using the apk, dpkg or rpm package database)"
+	FlagIncludePkgDepsUsage   = "Keep the files owned by the dependencies of the packages selected with --include-pkg too"
 	FlagIncludeShellUsage     = "Keep basic shell functionality"
 
 	FlagIncludeCertAllUsage     = "Keep all discovered cert files"
@@ -112,6 +221,10 @@ const (
 
 	FlagIncludeNewUsage = "Keep new files created by target during dynamic analysis"
 
+	FlagIncludeRuntimeEssentialsUsage = "Keep CA certificates, timezone data and minimal passwd/group files when the target uses TLS, timezone or user lookups"
+
+	FlagRunAsUserUsage = "Create the non-root user (name:uid[:gid]) in optimized image, make it own the app paths and use it to run the app"
+
 	FlagKeepTmpArtifactsUsage = "Keep temporary artifacts when command is done"
 
 	FlagIncludeAppNuxtDirUsage            = "Keep the root Nuxt.js app directory"
@@ -128,6 +241,16 @@ const (
 
 	FlagIncludeNodePackageUsage = "Keep node.js package by name"
 
+	FlagNodePrunePackagesUsage    = "Remove the node.js packages (node_modules) the app didn't require or import and the dev dependencies (the packages kept with --include-node-package are not removed)"
+	FlagNodeKeepDeclaredDepsUsage = "Keep the declared dependencies of the app and the used packages when the node.js packages are removed (for the packages loaded with dynamic require or import calls)"
+
+	FlagJavaJLinkUsage      = "Replace the Java runtime with a minimal runtime created with jlink (the JDK modules are detected with jdeps from the jar and class files used by the app)"
+	FlagJavaJLinkImageUsage = "JDK image with jdeps and jlink used to create the minimal Java runtime (the target image is used by default)"
+	FlagJavaAddModulesUsage = "Extra JDK modules for the minimal Java runtime (for the modules loaded with reflection or service loaders)"
+
+	FlagPythonPrunePackagesUsage = "Remove the Python packages (site-packages distributions) the app didn't import, the __pycache__ directories and the package test directories"
+	FlagPythonKeepPackageUsage   = "Keep Python package (distribution) by name when the unused Python packages are removed"
+
 	FlagKeepPermsUsage = "Keep artifact permissions as-is"
 
 	FlagNewEntrypointUsage = "New ENTRYPOINT instruction for the optimized image"
@@ -151,13 +274,15 @@ const (
 
 	FlagTagFatUsage              = "Custom tag for the fat image built from Dockerfile"
 	FlagBuildFromDockerfileUsage = "The source Dockerfile name to build the fat image before it's optimized"
-	FlagDockerfileContextUsage   = "The build context directory when building source Dockerfile"
+	FlagDockerfileContextUsage   = "The build context when building source Dockerfile (directory, git repository URL, tarball URL or '-' for stdin)"
 	FlagCBOAddHostUsage          = "Add an extra host-to-IP mapping in /etc/hosts to use when building an image"
 	FlagCBOBuildArgUsage         = "Add a build-time variable"
 	FlagCBOLabelUsage            = "Add a label when building from Dockerfiles"
 	FlagCBOTargetUsage           = "Target stage to build for multi-stage Dockerfiles"
 	FlagCBONetworkUsage          = "Networking mode to use for the RUN instructions at build-time"
-	FlagCBOCacheFromUsage        = "Add an image to the build cache"
+	FlagCBOCacheFromUsage        = "Add an image to the build cache (or a BuildKit cache import spec, e.g., 'type=registry,ref=repo/cache')"
+	FlagCBOCacheToUsage          = "BuildKit cache export spec for the fat image build (e.g., 'type=registry,ref=repo/cache,mode=max')"
+	FlagCBOBuildContextUsage     = "Add a named build context ('name=value', the value is a directory, a URL or a 'docker-image://' reference)"
 )
 
 var Flags = map[string]cli.Flag{
@@ -214,6 +339,17 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagIncludeExeUsage,
 		EnvVars: []string{"DSLIM_INCLUDE_EXE"},
 	},
+	FlagIncludePkg: &cli.StringSliceFlag{
+		Name:    FlagIncludePkg,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagIncludePkgUsage,
+		EnvVars: []string{"DSLIM_INCLUDE_PKG"},
+	},
+	FlagIncludePkgDeps: &cli.BoolFlag{
+		Name:    FlagIncludePkgDeps,
+		Usage:   FlagIncludePkgDepsUsage,
+		EnvVars: []string{"DSLIM_INCLUDE_PKG_DEPS"},
+	},
 	FlagIncludeShell: &cli.BoolFlag{
 		Name:    FlagIncludeShell,
 		Usage:   FlagIncludeShellUsage,
@@ -252,6 +388,17 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagIncludeNewUsage,
 		EnvVars: []string{"DSLIM_INCLUDE_NEW"},
 	},
+	FlagIncludeRuntimeEssentials: &cli.BoolFlag{
+		Name:    FlagIncludeRuntimeEssentials,
+		Usage:   FlagIncludeRuntimeEssentialsUsage,
+		EnvVars: []string{"DSLIM_INCLUDE_RUNTIME_ESSENTIALS"},
+	},
+	FlagRunAsUser: &cli.StringFlag{
+		Name:    FlagRunAsUser,
+		Value:   "",
+		Usage:   FlagRunAsUserUsage,
+		EnvVars: []string{"DSLIM_RUN_AS_USER"},
+	},
 	////
 	FlagKeepTmpArtifacts: &cli.BoolFlag{
 		Name:    FlagKeepTmpArtifacts,
@@ -314,6 +461,45 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagIncludeNodePackageUsage,
 		EnvVars: []string{"DSLIM_INCLUDE_NODE_PKG"},
 	},
+	FlagNodePrunePackages: &cli.BoolFlag{
+		Name:    FlagNodePrunePackages,
+		Usage:   FlagNodePrunePackagesUsage,
+		EnvVars: []string{"DSLIM_NODE_PRUNE_PKGS"},
+	},
+	FlagNodeKeepDeclaredDeps: &cli.BoolFlag{
+		Name:    FlagNodeKeepDeclaredDeps,
+		Value:   true, //enabled by default
+		Usage:   FlagNodeKeepDeclaredDepsUsage,
+		EnvVars: []string{"DSLIM_NODE_KEEP_DECLARED_DEPS"},
+	},
+	FlagJavaJLink: &cli.BoolFlag{
+		Name:    FlagJavaJLink,
+		Usage:   FlagJavaJLinkUsage,
+		EnvVars: []string{"DSLIM_JAVA_JLINK"},
+	},
+	FlagJavaJLinkImage: &cli.StringFlag{
+		Name:    FlagJavaJLinkImage,
+		Value:   "",
+		Usage:   FlagJavaJLinkImageUsage,
+		EnvVars: []string{"DSLIM_JAVA_JLINK_IMAGE"},
+	},
+	FlagJavaAddModules: &cli.StringSliceFlag{
+		Name:    FlagJavaAddModules,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagJavaAddModulesUsage,
+		EnvVars: []string{"DSLIM_JAVA_ADD_MODULES"},
+	},
+	FlagPythonPrunePackages: &cli.BoolFlag{
+		Name:    FlagPythonPrunePackages,
+		Usage:   FlagPythonPrunePackagesUsage,
+		EnvVars: []string{"DSLIM_PYTHON_PRUNE_PKGS"},
+	},
+	FlagPythonKeepPackage: &cli.StringSliceFlag{
+		Name:    FlagPythonKeepPackage,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagPythonKeepPackageUsage,
+		EnvVars: []string{"DSLIM_PYTHON_KEEP_PKG"},
+	},
 	FlagKeepPerms: &cli.BoolFlag{
 		Name:    FlagKeepPerms,
 		Value:   true, //enabled by default
@@ -411,6 +597,18 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagCBOCacheFromUsage,
 		EnvVars: []string{"DSLIM_CBO_CACHE_FROM"},
 	},
+	FlagCBOCacheTo: &cli.StringSliceFlag{
+		Name:    FlagCBOCacheTo,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagCBOCacheToUsage,
+		EnvVars: []string{"DSLIM_CBO_CACHE_TO"},
+	},
+	FlagCBOBuildContext: &cli.StringSliceFlag{
+		Name:    FlagCBOBuildContext,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagCBOBuildContextUsage,
+		EnvVars: []string{"DSLIM_CBO_BUILD_CONTEXT"},
+	},
 	FlagCBOLabel: &cli.StringSliceFlag{
 		Name:    FlagCBOLabel,
 		Value:   cli.NewStringSlice(),
@@ -434,6 +632,172 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagDeleteFatImageUsage,
 		EnvVars: []string{"DSLIM_DELETE_FAT"},
 	},
+	FlagDryRun: &cli.BoolFlag{
+		Name:    FlagDryRun,
+		Usage:   FlagDryRunUsage,
+		EnvVars: []string{"DSLIM_DRY_RUN"},
+	},
+	FlagMaxSlimSize: &cli.StringFlag{
+		Name:    FlagMaxSlimSize,
+		Value:   "",
+		Usage:   FlagMaxSlimSizeUsage,
+		EnvVars: []string{"DSLIM_MAX_SLIM_SIZE"},
+	},
+	FlagMinReductionPercent: &cli.IntFlag{
+		Name:    FlagMinReductionPercent,
+		Value:   0,
+		Usage:   FlagMinReductionPercentUsage,
+		EnvVars: []string{"DSLIM_MIN_REDUCTION_PERCENT"},
+	},
+	FlagFailOnNoReduction: &cli.BoolFlag{
+		Name:    FlagFailOnNoReduction,
+		Usage:   FlagFailOnNoReductionUsage,
+		EnvVars: []string{"DSLIM_FAIL_ON_NO_REDUCTION"},
+	},
+	FlagVerifySlim: &cli.BoolFlag{
+		Name:    FlagVerifySlim,
+		Usage:   FlagVerifySlimUsage,
+		EnvVars: []string{"DSLIM_VERIFY_SLIM"},
+	},
+	FlagVerifyRetries: &cli.IntFlag{
+		Name:    FlagVerifyRetries,
+		Value:   0,
+		Usage:   FlagVerifyRetriesUsage,
+		EnvVars: []string{"DSLIM_VERIFY_RETRIES"},
+	},
+	FlagPreserveLayers: &cli.BoolFlag{
+		Name:    FlagPreserveLayers,
+		Usage:   FlagPreserveLayersUsage,
+		EnvVars: []string{"DSLIM_PRESERVE_LAYERS"},
+	},
+	FlagPreserveLayersMinKept: &cli.IntFlag{
+		Name:    FlagPreserveLayersMinKept,
+		Value:   100,
+		Usage:   FlagPreserveLayersMinKeptUsage,
+		EnvVars: []string{"DSLIM_PRESERVE_LAYERS_MIN_KEPT"},
+	},
+	FlagReproducible: &cli.BoolFlag{
+		Name:    FlagReproducible,
+		Usage:   FlagReproducibleUsage,
+		EnvVars: []string{"DSLIM_REPRODUCIBLE"},
+	},
+	FlagCacheSensor: &cli.BoolFlag{
+		Name:    FlagCacheSensor,
+		Usage:   FlagCacheSensorUsage,
+		EnvVars: []string{"DSLIM_CACHE_SENSOR"},
+	},
+	FlagImportSensorData: &cli.StringFlag{
+		Name:    FlagImportSensorData,
+		Value:   "",
+		Usage:   FlagImportSensorDataUsage,
+		EnvVars: []string{"DSLIM_IMPORT_SENSOR_DATA"},
+	},
+	FlagOCIOutput: &cli.StringFlag{
+		Name:    FlagOCIOutput,
+		Value:   "",
+		Usage:   FlagOCIOutputUsage,
+		EnvVars: []string{"DSLIM_OCI_OUTPUT"},
+	},
+	FlagOCILayerCompression: &cli.StringFlag{
+		Name:    FlagOCILayerCompression,
+		Value:   OCILayerGzip,
+		Usage:   FlagOCILayerCompressionUsage,
+		EnvVars: []string{"DSLIM_OCI_LAYER_COMPRESSION"},
+	},
+	FlagScanVulns: &cli.StringFlag{
+		Name:    FlagScanVulns,
+		Value:   "",
+		Usage:   FlagScanVulnsUsage,
+		EnvVars: []string{"DSLIM_SCAN_VULNS"},
+	},
+	FlagScanVulnsExe: &cli.StringFlag{
+		Name:    FlagScanVulnsExe,
+		Value:   "",
+		Usage:   FlagScanVulnsExeUsage,
+		EnvVars: []string{"DSLIM_SCAN_VULNS_EXE"},
+	},
+	FlagAuditELF: &cli.BoolFlag{
+		Name:    FlagAuditELF,
+		Value:   true, //enabled by default
+		Usage:   FlagAuditELFUsage,
+		EnvVars: []string{"DSLIM_AUDIT_ELF"},
+	},
+	FlagStaticScratch: &cli.BoolFlag{
+		Name:    FlagStaticScratch,
+		Usage:   FlagStaticScratchUsage,
+		EnvVars: []string{"DSLIM_STATIC_SCRATCH"},
+	},
+	FlagIncludeDebugImage: &cli.BoolFlag{
+		Name:    FlagIncludeDebugImage,
+		Usage:   FlagIncludeDebugImageUsage,
+		EnvVars: []string{"DSLIM_INCLUDE_DEBUG_IMAGE"},
+	},
+	FlagDebugImageTag: &cli.StringFlag{
+		Name:    FlagDebugImageTag,
+		Value:   "",
+		Usage:   FlagDebugImageTagUsage,
+		EnvVars: []string{"DSLIM_DEBUG_IMAGE_TAG"},
+	},
+	FlagDebugImageToolbox: &cli.StringFlag{
+		Name:    FlagDebugImageToolbox,
+		Value:   DefaultDebugImageToolbox,
+		Usage:   FlagDebugImageToolboxUsage,
+		EnvVars: []string{"DSLIM_DEBUG_IMAGE_TOOLBOX"},
+	},
+	FlagImageBuildEngine: &cli.StringFlag{
+		Name:    FlagImageBuildEngine,
+		Value:   config.ImageBuildEngineInternal,
+		Usage:   FlagImageBuildEngineUsage,
+		EnvVars: []string{"DSLIM_IMAGE_BUILD_ENGINE"},
+	},
+	FlagImageBuildEngineEndpoint: &cli.StringFlag{
+		Name:    FlagImageBuildEngineEndpoint,
+		Value:   "",
+		Usage:   FlagImageBuildEngineEndpointUsage,
+		EnvVars: []string{"DSLIM_IMAGE_BUILD_ENGINE_ENDPOINT"},
+	},
+	FlagImageBuildCacheFrom: &cli.StringSliceFlag{
+		Name:    FlagImageBuildCacheFrom,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagImageBuildCacheFromUsage,
+		EnvVars: []string{"DSLIM_IMAGE_BUILD_CACHE_FROM"},
+	},
+	FlagImageBuildCacheTo: &cli.StringSliceFlag{
+		Name:    FlagImageBuildCacheTo,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagImageBuildCacheToUsage,
+		EnvVars: []string{"DSLIM_IMAGE_BUILD_CACHE_TO"},
+	},
+	FlagImageBuildProvenance: &cli.StringFlag{
+		Name:    FlagImageBuildProvenance,
+		Value:   "",
+		Usage:   FlagImageBuildProvenanceUsage,
+		EnvVars: []string{"DSLIM_IMAGE_BUILD_PROVENANCE"},
+	},
+	FlagImageBuildPlatform: &cli.StringSliceFlag{
+		Name:    FlagImageBuildPlatform,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagImageBuildPlatformUsage,
+		EnvVars: []string{"DSLIM_IMAGE_BUILD_PLATFORM"},
+	},
+	FlagImageBuildOutput: &cli.StringFlag{
+		Name:    FlagImageBuildOutput,
+		Value:   "",
+		Usage:   FlagImageBuildOutputUsage,
+		EnvVars: []string{"DSLIM_IMAGE_BUILD_OUTPUT"},
+	},
+	FlagTargetsFile: &cli.StringFlag{
+		Name:    FlagTargetsFile,
+		Value:   "",
+		Usage:   FlagTargetsFileUsage,
+		EnvVars: []string{"DSLIM_TARGETS_FILE"},
+	},
+	FlagBatchWorkers: &cli.IntFlag{
+		Name:    FlagBatchWorkers,
+		Value:   2,
+		Usage:   FlagBatchWorkersUsage,
+		EnvVars: []string{"DSLIM_BATCH_WORKERS"},
+	},
 	FlagRemoveExpose: &cli.StringSliceFlag{
 		Name:    FlagRemoveExpose,
 		Value:   cli.NewStringSlice(),
@@ -492,6 +856,7 @@ func GetContainerBuildOptions(ctx *cli.Context) (*config.ContainerBuildOptions,
 	cbo.Target = ctx.String(FlagCBOTarget)
 	cbo.NetworkMode = ctx.String(FlagCBONetwork)
 	cbo.CacheFrom = ctx.StringSlice(FlagCBOCacheFrom)
+	cbo.CacheTo = ctx.StringSlice(FlagCBOCacheTo)
 
 	hosts := ctx.StringSlice(FlagCBOAddHost)
 	//TODO: figure out how to encode multiple host entries to a string (docs are not helpful)
@@ -533,6 +898,19 @@ func GetContainerBuildOptions(ctx *cli.Context) (*config.ContainerBuildOptions,
 		}
 	}
 
+	for _, rbc := range ctx.StringSlice(FlagCBOBuildContext) {
+		parts := strings.SplitN(rbc, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("bad --%s value (%s) - expected 'name=value'", FlagCBOBuildContext, rbc)
+		}
+
+		if cbo.NamedContexts == nil {
+			cbo.NamedContexts = map[string]string{}
+		}
+
+		cbo.NamedContexts[parts[0]] = parts[1]
+	}
+
 	rawLabels := ctx.StringSlice(FlagCBOLabel)
 	for _, rlabel := range rawLabels {
 		parts := strings.SplitN(rlabel, "=", 2)
@@ -659,6 +1037,38 @@ func GetAppNodejsInspectOptions(ctx *cli.Context) config.AppNodejsInspectOptions
 	}
 }
 
+func GetAppNodejsPackageOptions(ctx *cli.Context) config.AppNodejsPackageOptions {
+	return config.AppNodejsPackageOptions{
+		Prune:            ctx.Bool(FlagNodePrunePackages),
+		KeepDeclaredDeps: ctx.Bool(FlagNodeKeepDeclaredDeps),
+		KeepPackages:     ctx.StringSlice(FlagIncludeNodePackage),
+	}
+}
+
+func GetAppJavaRuntimeOptions(ctx *cli.Context) config.AppJavaRuntimeOptions {
+	var modules []string
+	for _, value := range ctx.StringSlice(FlagJavaAddModules) {
+		for _, module := range strings.Split(value, ",") {
+			if module = strings.TrimSpace(module); module != "" {
+				modules = append(modules, module)
+			}
+		}
+	}
+
+	return config.AppJavaRuntimeOptions{
+		JLink:      ctx.Bool(FlagJavaJLink),
+		JLinkImage: ctx.String(FlagJavaJLinkImage),
+		AddModules: modules,
+	}
+}
+
+func GetAppPythonPackageOptions(ctx *cli.Context) config.AppPythonPackageOptions {
+	return config.AppPythonPackageOptions{
+		Prune:        ctx.Bool(FlagPythonPrunePackages),
+		KeepPackages: ctx.StringSlice(FlagPythonKeepPackage),
+	}
+}
+
 func getAppNextInspectOptions(ctx *cli.Context) config.NodejsWebFrameworkInspectOptions {
 	return config.NodejsWebFrameworkInspectOptions{
 		IncludeAppDir:         ctx.Bool(FlagIncludeAppNextDir),
@@ -679,6 +1089,60 @@ func getAppNuxtInspectOptions(ctx *cli.Context) config.NodejsWebFrameworkInspect
 	}
 }
 
+func GetSizePolicy(ctx *cli.Context) (*config.SizePolicy, error) {
+	policy := &config.SizePolicy{
+		MinReductionPercent: ctx.Int(FlagMinReductionPercent),
+		FailOnNoReduction:   ctx.Bool(FlagFailOnNoReduction),
+	}
+
+	if policy.MinReductionPercent < 0 || policy.MinReductionPercent > 100 {
+		return nil, fmt.Errorf("bad --%s value (%d) - must be between 0 and 100",
+			FlagMinReductionPercent, policy.MinReductionPercent)
+	}
+
+	if maxSize := ctx.String(FlagMaxSlimSize); maxSize != "" {
+		val, err := humanize.ParseBytes(maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("bad --%s value (%s) - %v", FlagMaxSlimSize, maxSize, err)
+		}
+
+		policy.MaxSize = val
+	}
+
+	return policy, nil
+}
+
+// GetRunAsUser parses the non-root user spec ('name:uid' or 'name:uid:gid')
+func GetRunAsUser(ctx *cli.Context) (*config.RunAsUser, error) {
+	spec := strings.TrimSpace(ctx.String(FlagRunAsUser))
+	if spec == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+		return nil, fmt.Errorf("bad --%s value (%s) - expected 'name:uid[:gid]'", FlagRunAsUser, spec)
+	}
+
+	user := &config.RunAsUser{
+		Name: parts[0],
+	}
+
+	var err error
+	if user.UID, err = strconv.Atoi(parts[1]); err != nil || user.UID <= 0 {
+		return nil, fmt.Errorf("bad --%s value (%s) - uid must be a non-root user ID", FlagRunAsUser, spec)
+	}
+
+	user.GID = user.UID
+	if len(parts) == 3 {
+		if user.GID, err = strconv.Atoi(parts[2]); err != nil || user.GID < 0 {
+			return nil, fmt.Errorf("bad --%s value (%s) - invalid gid", FlagRunAsUser, spec)
+		}
+	}
+
+	return user, nil
+}
+
 func GetKubernetesOptions(ctx *cli.Context) (config.KubernetesOptions, error) {
 	cfg := config.KubernetesOptions{
 		Target: config.KubernetesTarget{
@@ -699,3 +1163,42 @@ func GetKubernetesOptions(ctx *cli.Context) (config.KubernetesOptions, error) {
 
 	return cfg, nil
 }
+
+// GetImageBuildEngineOptions returns the image build engine options
+// (nil if the optimized image is assembled with the classic build API)
+func GetImageBuildEngineOptions(ctx *cli.Context) (*config.ImageBuildEngineOptions, error) {
+	opts := &config.ImageBuildEngineOptions{
+		Engine:     ctx.String(FlagImageBuildEngine),
+		Endpoint:   ctx.String(FlagImageBuildEngineEndpoint),
+		CacheFrom:  ctx.StringSlice(FlagImageBuildCacheFrom),
+		CacheTo:    ctx.StringSlice(FlagImageBuildCacheTo),
+		Provenance: ctx.String(FlagImageBuildProvenance),
+		Output:     ctx.String(FlagImageBuildOutput),
+	}
+
+	for _, val := range ctx.StringSlice(FlagImageBuildPlatform) {
+		for _, platform := range strings.Split(val, ",") {
+			if platform = strings.TrimSpace(platform); platform != "" {
+				opts.Platforms = append(opts.Platforms, platform)
+			}
+		}
+	}
+
+	switch opts.Engine {
+	case "", config.ImageBuildEngineInternal:
+		return nil, nil
+	case config.ImageBuildEngineBuildx, config.ImageBuildEngineBuildkitd:
+	default:
+		return nil, fmt.Errorf("bad --%s value (%s) - expected '%s', '%s' or '%s'",
+			FlagImageBuildEngine, opts.Engine,
+			config.ImageBuildEngineInternal, config.ImageBuildEngineBuildx, config.ImageBuildEngineBuildkitd)
+	}
+
+	switch opts.Provenance {
+	case "", "min", "max":
+	default:
+		return nil, fmt.Errorf("bad --%s value (%s) - expected 'min' or 'max'", FlagImageBuildProvenance, opts.Provenance)
+	}
+
+	return opts, nil
+}
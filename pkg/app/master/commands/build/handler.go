@@ -28,6 +28,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
 	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/trace"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	"github.com/docker-slim/docker-slim/pkg/util/printbuffer"
@@ -41,6 +42,12 @@ import (
 const appName = commands.AppName
 const composeProjectNamePat = "dsbuild_%v_%v"
 
+// File artifacts collected from the instrumented container (in the artifact location)
+const (
+	fileArtifactsTar = "files.tar"
+	fileArtifactsDir = "files"
+)
+
 // Build command exit codes
 const (
 	ecbOther = iota + 1
@@ -55,14 +62,57 @@ const (
 	ecbKubernetesNoWorkload
 	ecbKubernetesNoWorkloadContainer
 	ecbNotImplementedYet
+	ecbProbeFailureThreshold
+	ecbComposeSvcNotHealthy
+	ecbDryRunPlanError
+	ecbPolicyMaxSlimSize
+	ecbPolicyMinReduction
+	ecbPolicyNoReduction
+	ecbSlimImageVerifyFailure
+	ecbBatchTargetFailure
+	ecbNoSuccessfulProbes
+	ecbBadSensorData
+	ecbBadImageBuildPlatform
+	ecbContainerProbeSvcNotFound
+	ecbContainerProbeFailure
 )
 
+// exitCodes documents the build command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTBuild | ecbOther, Name: "build.other", Description: "other build command error"},
+	{Code: commands.ECTBuild | ecbBadCustomImageTag, Name: "build.bad.custom.image.tag", Description: "invalid custom image tag (--tag)"},
+	{Code: commands.ECTBuild | ecbImageBuildError, Name: "build.image.build.error", Description: "error building the fat or the optimized image"},
+	{Code: commands.ECTBuild | ecbImageAlreadyOptimized, Name: "build.image.already.optimized", Description: "target image is already optimized"},
+	{Code: commands.ECTBuild | ecbOnbuildBaseImage, Name: "build.onbuild.base.image", Description: "target image has ONBUILD instructions"},
+	{Code: commands.ECTBuild | ecbNoEntrypoint, Name: "build.no.entrypoint", Description: "target image has no entrypoint or cmd (use --entrypoint or --cmd)"},
+	{Code: commands.ECTBuild | ecbBadTargetComposeSvc, Name: "build.bad.target.compose.svc", Description: "unknown target compose service"},
+	{Code: commands.ECTBuild | ecbComposeSvcNoImage, Name: "build.compose.svc.no.image", Description: "target compose service has no image"},
+	{Code: commands.ECTBuild | ecbComposeSvcUnknownImage, Name: "build.compose.svc.unknown.image", Description: "target compose service image not found"},
+	{Code: commands.ECTBuild | ecbKubernetesNoWorkload, Name: "build.kubernetes.no.workload", Description: "target Kubernetes workload not found"},
+	{Code: commands.ECTBuild | ecbKubernetesNoWorkloadContainer, Name: "build.kubernetes.no.workload.container", Description: "target Kubernetes workload container not found"},
+	{Code: commands.ECTBuild | ecbNotImplementedYet, Name: "build.not.implemented.yet", Description: "unsupported build mode"},
+	{Code: commands.ECTBuild | ecbProbeFailureThreshold, Name: "build.probe.failure.threshold", Description: "HTTP probe failure threshold exceeded (--http-probe-fail-threshold)"},
+	{Code: commands.ECTBuild | ecbComposeSvcNotHealthy, Name: "build.compose.svc.not.healthy", Description: "compose service is not healthy"},
+	{Code: commands.ECTBuild | ecbDryRunPlanError, Name: "build.dry.run.plan.error", Description: "error creating the dry run build plan"},
+	{Code: commands.ECTBuild | ecbPolicyMaxSlimSize, Name: "build.policy.max.slim.size", Description: "optimized image is bigger than the max size policy"},
+	{Code: commands.ECTBuild | ecbPolicyMinReduction, Name: "build.policy.min.reduction", Description: "image size reduction is less than the min reduction policy"},
+	{Code: commands.ECTBuild | ecbPolicyNoReduction, Name: "build.policy.no.reduction", Description: "optimized image is not smaller than the original image"},
+	{Code: commands.ECTBuild | ecbSlimImageVerifyFailure, Name: "build.slim.image.verify.failure", Description: "optimized image verification failed"},
+	{Code: commands.ECTBuild | ecbBatchTargetFailure, Name: "build.batch.target.failure", Description: "one or more batch build targets failed"},
+	{Code: commands.ECTBuild | ecbNoSuccessfulProbes, Name: "build.no.successful.probes", Description: "no successful HTTP probe calls (--http-probe-exit-on-failure)"},
+	{Code: commands.ECTBuild | ecbBadSensorData, Name: "build.bad.sensor.data", Description: "error importing the sensor data (--import-sensor-data)"},
+	{Code: commands.ECTBuild | ecbBadImageBuildPlatform, Name: "build.bad.image.build.platform", Description: "image build platform is not the target image platform (--image-build-platform)"},
+	{Code: commands.ECTBuild | ecbContainerProbeSvcNotFound, Name: "build.container.probe.svc.not.found", Description: "container probe compose service is not running (--container-probe-compose-svc)"},
+	{Code: commands.ECTBuild | ecbContainerProbeFailure, Name: "build.container.probe.failure", Description: "container probe compose service exited with an error (--container-probe-compose-svc)"},
+}
+
 type ovars = app.OutVars
 
 // OnCommand implements the 'build' docker-slim command
 func OnCommand(
 	xc *app.ExecutionContext,
 	gparams *commands.GenericParams,
+	client *dockerapi.Client,
 	targetRef string,
 	doPull bool,
 	dockerConfigPath string,
@@ -74,6 +124,7 @@ func OnCommand(
 	targetComposeSvc string,
 	targetComposeSvcImage string,
 	composeSvcStartWait int,
+	composeSvcHealthyTimeout int,
 	composeSvcNoPorts bool,
 	depExcludeComposeSvcAll bool,
 	depIncludeComposeSvcDeps string,
@@ -96,6 +147,7 @@ func OnCommand(
 	portBindings map[dockerapi.Port][]dockerapi.PortBinding,
 	doPublishExposedPorts bool,
 	hostExecProbes []string,
+	execHooks []config.ExecHook,
 	doRmFileArtifacts bool,
 	copyMetaArtifactsLocation string,
 	doRunTargetAsUser bool,
@@ -117,6 +169,8 @@ func OnCommand(
 	includePaths map[string]*fsutil.AccessInfo,
 	includeBins map[string]*fsutil.AccessInfo,
 	includeExes map[string]*fsutil.AccessInfo,
+	includePkgs []string,
+	doIncludePkgDeps bool,
 	doIncludeShell bool,
 	doIncludeCertAll bool,
 	doIncludeCertBundles bool,
@@ -124,6 +178,8 @@ func OnCommand(
 	doIncludeCertPKAll bool,
 	doIncludeCertPKDirs bool,
 	doIncludeNew bool,
+	doIncludeEssentials bool,
+	runAsUser *config.RunAsUser,
 
 	doUseLocalMounts bool,
 	doUseSensorVolume string,
@@ -132,14 +188,43 @@ func OnCommand(
 	execCmd string,
 	execFileCmd string,
 	doDeleteFatImage bool,
+	doDryRun bool,
+	sizePolicy *config.SizePolicy,
+	doVerifySlim bool,
+	verifyRetries int,
+	doPreserveLayers bool,
+	preserveLayersMinKept int,
+	doReproducible bool,
+	ociOutput string,
+	ociLayerCompression string,
+	sbomFormat string,
+	sbomOutput string,
+	vulnScanner string,
+	vulnScannerExe string,
+	doAuditELF bool,
+	doStaticScratch bool,
+	doIncludeDebugImage bool,
+	debugImageTag string,
+	debugImageToolbox string,
+	reportHTML string,
+	reportUpload string,
+	doUploadArtifacts bool,
+	traceOutput string,
+	traceFormat string,
+	buildEngineOpts *config.ImageBuildEngineOptions,
 	rtaOnbuildBaseImage bool,
 	rtaSourcePT bool,
 	sensorIPCEndpoint string,
 	sensorIPCMode string,
+	doCacheSensor bool,
+	sensorDataImport string,
 
 	kubeOpts config.KubernetesOptions,
 	appNodejsInspectOpts config.AppNodejsInspectOptions,
-) {
+	appNodejsPackageOpts config.AppNodejsPackageOptions,
+	appJavaRuntimeOpts config.AppJavaRuntimeOptions,
+	appPythonPackageOpts config.AppPythonPackageOptions,
+) error {
 	printState := true
 	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
 
@@ -149,17 +234,9 @@ func OnCommand(
 	cmdReport.State = command.StateStarted
 	cmdReport.TargetReference = targetRef
 
-	cmdReportOnExit := func() {
-		cmdReport.State = command.StateError
-		if cmdReport.Save() {
-			xc.Out.Info("report",
-				ovars{
-					"file": cmdReport.ReportLocation(),
-				})
-		}
-	}
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
 
-	xc.AddCleanupHandler(cmdReportOnExit)
+	telemetry := commands.NewTelemetry(xc, gparams, Name, targetRef, traceOutput != "", logger)
 
 	var customImageTag string
 	var additionalTags []string
@@ -174,27 +251,33 @@ func OnCommand(
 
 	logger.Debugf("customImageTag='%s', additionalTags=%#v", customImageTag, additionalTags)
 
-	client, err := dockerclient.New(gparams.ClientConfig)
-	if err == dockerclient.ErrNoDockerInfo {
-		exitMsg := "missing Docker connection info"
-		if gparams.InContainer && gparams.IsDSImage {
-			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
-		}
+	var err error
+	if client == nil {
+		client, err = dockerclient.New(gparams.ClientConfig)
+		if err == dockerclient.ErrNoDockerInfo {
+			exitMsg := "missing Docker connection info"
+			if gparams.InContainer && gparams.IsDSImage {
+				exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+			}
 
-		xc.Out.Error("docker.connect.error", exitMsg)
+			xc.Out.Error("docker.connect.error", exitMsg)
 
-		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
-		xc.Out.State("exited",
-			ovars{
-				"exit.code": exitCode,
-				"version":   v.Current(),
-				"location":  fsutil.ExeDir(),
-			})
+			exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+					"version":   v.Current(),
+					"location":  fsutil.ExeDir(),
+				})
 
-		cmdReport.Error = "docker.connect.error"
-		xc.Exit(exitCode)
+			cmdReport.Error = "docker.connect.error"
+			return app.NewExitError(exitCode)
+		}
+
+		if err != nil {
+			return err
+		}
 	}
-	xc.FailOn(err)
 
 	xc.Out.State("started")
 
@@ -210,7 +293,9 @@ func OnCommand(
 			})
 
 		kubeClient, err := kubernetes.NewClient(kubeOpts)
-		xc.FailOn(err)
+		if err != nil {
+			return err
+		}
 
 		var manifests *kubernetes.Manifests
 		if len(kubeOpts.Manifests) > 0 {
@@ -218,7 +303,9 @@ func OnCommand(
 				kubeOpts,
 				kubeClient,
 				kubernetes.NewResourceBuilderFunc(kubeOpts))
-			xc.FailOn(err)
+			if err != nil {
+				return err
+			}
 		}
 
 		h := newKubeHandler(
@@ -230,7 +317,7 @@ func OnCommand(
 			kubeClient,
 			kubernetes.NewKubectl(kubeOpts),
 			kubernetes.NewWorkloadFinder(manifests, kubernetes.NewResourceBuilderFunc(kubeOpts)))
-		h.Handle(
+		err = h.Handle(
 			kubeOpts.Target,
 			kubeOpts.TargetOverride,
 			manifests,
@@ -240,8 +327,12 @@ func OnCommand(
 				DoShowBuildLogs:           doShowBuildLogs,
 				DoShowContainerLogs:       doShowContainerLogs,
 				DoDeleteFatImage:          doDeleteFatImage,
+				DoDryRun:                  doDryRun,
+				SizePolicy:                sizePolicy,
 				DoRmFileArtifacts:         doRmFileArtifacts,
 				CBOpts:                    cbOpts,
+				BuildEngineOpts:           buildEngineOpts,
+				Telemetry:                 telemetry,
 				RtaOnbuildBaseImage:       rtaOnbuildBaseImage,
 				RtaSourcePT:               rtaSourcePT,
 				DockerConfigPath:          dockerConfigPath,
@@ -251,6 +342,7 @@ func OnCommand(
 				PathPerms:                 pathPerms,
 				ArchiveState:              gparams.ArchiveState,
 				StatePath:                 gparams.StatePath,
+				ResultCache:               commands.ResultCache(gparams),
 				CopyMetaArtifactsLocation: copyMetaArtifactsLocation,
 				Debug:                     gparams.Debug,
 				LogLevel:                  gparams.LogLevel,
@@ -262,10 +354,13 @@ func OnCommand(
 				continueAfter:             continueAfter,
 				execCmd:                   execCmd,
 			})
+		if err != nil {
+			return err
+		}
 
 		vinfo := <-viChan
 		version.PrintCheckVersion(xc, "", vinfo)
-		return
+		return nil
 	}
 
 	if len(composeFiles) > 0 && targetComposeSvc != "" {
@@ -301,7 +396,10 @@ func OnCommand(
 	}
 
 	if cbOpts.Dockerfile != "" {
-		targetRef = buildFatImage(xc, targetRef, customImageTag, cbOpts, doShowBuildLogs, client, cmdReport)
+		targetRef, err = buildFatImage(xc, targetRef, customImageTag, cbOpts, doShowBuildLogs, client, cmdReport)
+		if err != nil {
+			return err
+		}
 	}
 
 	var serviceAliases []string
@@ -335,7 +433,8 @@ func OnCommand(
 
 		//todo: move compose flags to options
 		options := &compose.ExecutionOptions{
-			SvcStartWait: composeSvcStartWait,
+			SvcStartWait:      composeSvcStartWait,
+			SvcHealthyTimeout: composeSvcHealthyTimeout,
 		}
 
 		logger.Debugf("compose: file(s)='%s' selectors='%+v'\n",
@@ -362,8 +461,9 @@ func OnCommand(
 			options,
 			nil, //eventCh
 			printState)
-
-		xc.FailOn(err)
+		if err != nil {
+			return err
+		}
 
 		if !depExcludeComposeSvcAll && !exe.SelectedHaveImages() {
 			xc.Out.Info("compose.file.error",
@@ -380,7 +480,7 @@ func OnCommand(
 					"location":  fsutil.ExeDir(),
 				})
 
-			xc.Exit(exitCode)
+			return app.NewExitError(exitCode)
 		}
 
 		if targetComposeSvc != "" {
@@ -401,7 +501,7 @@ func OnCommand(
 						"location":  fsutil.ExeDir(),
 					})
 
-				xc.Exit(exitCode)
+				return app.NewExitError(exitCode)
 			}
 
 			serviceAliases = append(serviceAliases, targetSvcInfo.Config.Name)
@@ -411,7 +511,7 @@ func OnCommand(
 				targetRef = commands.UpdateImageRef(logger, targetRef, targetComposeSvcImage)
 				//shouldn't need to
 				targetSvcInfo.Config.Image = targetRef
-				logger.Debug("using target service override '%s' -> '%s' ", targetComposeSvcImage, targetRef)
+				logger.Debugf("using target service override '%s' -> '%s' ", targetComposeSvcImage, targetRef)
 			}
 
 			if len(targetSvcInfo.Config.Entrypoint) > 0 {
@@ -535,7 +635,9 @@ func OnCommand(
 				targetSvcInfo.Config.Volumes,
 				targetSvcInfo.Config.Tmpfs,
 				exe.ActiveVolumes)
-			xc.FailOn(err)
+			if err != nil {
+				return err
+			}
 
 			logger.Debugf("compose targetSvcInfo - baseMounts(%d)", len(baseMounts))
 
@@ -575,7 +677,7 @@ func OnCommand(
 				"location":  fsutil.ExeDir(),
 			})
 
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
 	if !commands.ConfirmNetwork(logger, client, overrides.Network) {
@@ -593,10 +695,11 @@ func OnCommand(
 				"location":  fsutil.ExeDir(),
 			})
 
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
-	imageInspector, localVolumePath, statePath, stateKey := inspectFatImage(
+	resultCache := commands.ResultCache(gparams)
+	imageInspector, localVolumePath, statePath, stateKey, err := inspectFatImage(
 		xc,
 		targetRef,
 		doPull,
@@ -606,392 +709,759 @@ func OnCommand(
 		registryAccount,
 		registrySecret,
 		gparams.StatePath,
+		resultCache,
+		telemetry,
 		client,
 		logger,
 		cmdReport)
+	if err != nil {
+		return err
+	}
+
+	if err := checkImageBuildPlatforms(xc, buildEngineOpts, imageInspector, cmdReport); err != nil {
+		return err
+	}
 
 	//refresh the target refs
 	targetRef = imageInspector.ImageRef
 
-	//validate links (check if target container exists, ignore&log if not)
-	svcLinkMap := map[string]struct{}{}
-	for _, linkInfo := range links {
-		svcLinkMap[linkInfo] = struct{}{}
+	var sensorCacheKey string
+	if doCacheSensor && resultCache != nil {
+		if len(composeFiles) > 0 {
+			//the dependency services are not a part of the cache key
+			logger.Debug("sensor result caching is not supported with compose dependencies")
+		} else {
+			sensorCacheKey = sensorResultsKey(&sensorCacheParams{
+				RunOpts:              crOpts,
+				Overrides:            overrides,
+				HTTPProbeOpts:        httpProbeOpts,
+				HostExecProbes:       hostExecProbes,
+				ExecHooks:            execHooks,
+				ContinueAfterMode:    continueAfter.Mode,
+				ContinueAfterTimeout: continueAfter.Timeout,
+				SessionReplayFile:    continueAfter.SessionReplayFile,
+				ExecCmd:              execCmd,
+				ExecFileCmd:          execFileCmd,
+				RunTargetAsUser:      doRunTargetAsUser,
+				VolumeMounts:         explicitVolumeMounts,
+				KeepPerms:            doKeepPerms,
+				PathPerms:            pathPerms,
+				ExcludePatterns:      excludePatterns,
+				PreservePaths:        preservePaths,
+				IncludePaths:         includePaths,
+				IncludeBins:          includeBins,
+				IncludeExes:          includeExes,
+				IncludePkgs:          includePkgs,
+				IncludePkgDeps:       doIncludePkgDeps,
+				IncludeShell:         doIncludeShell,
+				IncludeCertAll:       doIncludeCertAll,
+				IncludeCertBundles:   doIncludeCertBundles,
+				IncludeCertDirs:      doIncludeCertDirs,
+				IncludeCertPKAll:     doIncludeCertPKAll,
+				IncludeCertPKDirs:    doIncludeCertPKDirs,
+				IncludeNew:           doIncludeNew,
+				IncludeEssentials:    doIncludeEssentials,
+				RunAsUser:            runAsUser,
+				RtaSourcePT:          rtaSourcePT,
+				AppNodejsInspectOpts: appNodejsInspectOpts,
+			}, logger)
+		}
 	}
 
-	selectedNetNames := map[string]compose.NetNameInfo{}
-	if depServicesExe != nil {
-		xc.Out.State("container.dependencies.init.start")
-		err = depServicesExe.Prepare()
-		if err != nil {
-			var svcErr *compose.ServiceError
-			if errors.As(err, &svcErr) {
-				xc.Out.Info("compose.file.error",
-					ovars{
-						"status":       "deps.unknown.image",
-						"files":        strings.Join(composeFiles, ","),
-						"service":      svcErr.Service,
-						"pull.enabled": doPull,
-						"message":      "Unknown dependency image (make sure to pull or build the images for your dependencies in compose)",
-					})
+	//reusing the cached sensor results or importing the sensor data collected by an instrumented image
+	//skips the instrumented container run
+	if sensorDataImport != "" {
+		if err := importSensorData(xc, sensorDataImport, imageInspector, logger, cmdReport); err != nil {
+			return err
+		}
+	} else if sensorCacheKey == "" ||
+		!loadCachedSensorResults(xc, resultCache, sensorCacheKey, imageInspector, logger) {
+		//validate links (check if target container exists, ignore&log if not)
+		svcLinkMap := map[string]struct{}{}
+		for _, linkInfo := range links {
+			svcLinkMap[linkInfo] = struct{}{}
+		}
 
-				exitCode := commands.ECTBuild | ecbComposeSvcUnknownImage
-				xc.Out.State("exited",
-					ovars{
-						"exit.code": exitCode,
-						"version":   v.Current(),
-						"location":  fsutil.ExeDir(),
-					})
+		selectedNetNames := map[string]compose.NetNameInfo{}
+		if depServicesExe != nil {
+			xc.Out.State("container.dependencies.init.start")
+			err = depServicesExe.Prepare()
+			if err != nil {
+				var svcErr *compose.ServiceError
+				if errors.As(err, &svcErr) {
+					xc.Out.Info("compose.file.error",
+						ovars{
+							"status":       "deps.unknown.image",
+							"files":        strings.Join(composeFiles, ","),
+							"service":      svcErr.Service,
+							"pull.enabled": doPull,
+							"message":      "Unknown dependency image (make sure to pull or build the images for your dependencies in compose)",
+						})
+
+					exitCode := commands.ECTBuild | ecbComposeSvcUnknownImage
+					xc.Out.State("exited",
+						ovars{
+							"exit.code": exitCode,
+							"version":   v.Current(),
+							"location":  fsutil.ExeDir(),
+						})
 
-				xc.Exit(exitCode)
+					return app.NewExitError(exitCode)
+				}
+
+				return err
 			}
 
-			xc.FailOn(err)
-		}
+			err = depServicesExe.Start()
+			if err != nil {
+				depServicesExe.Stop()
+				depServicesExe.Cleanup()
+				return err
+			}
 
-		err = depServicesExe.Start()
-		if err != nil {
-			depServicesExe.Stop()
-			depServicesExe.Cleanup()
-		}
-		xc.FailOn(err)
-
-		exeCleanup := func() {
-			if depServicesExe != nil {
-				xc.Out.State("container.dependencies.shutdown.start")
-				err = depServicesExe.Stop()
-				errutil.WarnOn(err)
-				err = depServicesExe.Cleanup()
-				errutil.WarnOn(err)
-				xc.Out.State("container.dependencies.shutdown.done")
+			exeCleanup := func() {
+				if depServicesExe != nil {
+					xc.Out.State("container.dependencies.shutdown.start")
+					err = depServicesExe.Stop()
+					errutil.WarnOn(err)
+					err = depServicesExe.Cleanup()
+					errutil.WarnOn(err)
+					xc.Out.State("container.dependencies.shutdown.done")
+				}
 			}
-		}
 
-		xc.AddCleanupHandler(exeCleanup)
+			xc.AddCleanupHandler(exeCleanup)
 
-		//todo:
-		//need a better way to make sure the dependencies are ready
-		//monitor docker events
-		//use basic application level checks (probing)
-		time.Sleep(3 * time.Second)
-		xc.Out.State("container.dependencies.init.done")
+			if composeSvcHealthyTimeout > 0 {
+				err = depServicesExe.WaitForHealthyServices()
+				if err != nil {
+					xc.Out.Info("compose.service.error",
+						ovars{
+							"status":  "deps.not.healthy",
+							"message": err.Error(),
+						})
 
-		//might need more info (including alias info) when targeting compose services
-		allNetNames := depServicesExe.ActiveNetworkNames()
+					exitCode := commands.ECTBuild | ecbComposeSvcNotHealthy
+					xc.Out.State("exited",
+						ovars{
+							"exit.code": exitCode,
+							"version":   v.Current(),
+							"location":  fsutil.ExeDir(),
+						})
 
-		if targetComposeSvc != "" {
-			//if we are targeting a compose service, and we
-			//have explicitly selected compose networks (composeNets)
-			//we use the selected subset of the configured networks for the target service
-			composeNetsSet := map[string]struct{}{}
-			for _, key := range composeNets {
-				composeNetsSet[key] = struct{}{}
+					cmdReport.Error = "deps.not.healthy"
+					return app.NewExitError(exitCode)
+				}
+			} else {
+				//todo:
+				//need a better way to make sure the dependencies are ready
+				//monitor docker events
+				//use basic application level checks (probing)
+				time.Sleep(3 * time.Second)
 			}
 
-			svcNets := depServicesExe.ActiveServiceNetworks(targetComposeSvc)
-			for key, netNameInfo := range svcNets {
-				if len(composeNets) > 0 {
-					if _, found := composeNetsSet[key]; !found {
-						continue
-					}
-				}
+			xc.Out.State("container.dependencies.init.done")
 
-				selectedNetNames[key] = netNameInfo
-			}
-		} else {
-			//we are not targeting a compose service,
-			//but we do want to connect to the networks in compose
-			if len(composeNets) > 0 {
+			//might need more info (including alias info) when targeting compose services
+			allNetNames := depServicesExe.ActiveNetworkNames()
+
+			if targetComposeSvc != "" {
+				//if we are targeting a compose service, and we
+				//have explicitly selected compose networks (composeNets)
+				//we use the selected subset of the configured networks for the target service
+				composeNetsSet := map[string]struct{}{}
 				for _, key := range composeNets {
-					if net, found := allNetNames[key]; found {
-						selectedNetNames[key] = compose.NetNameInfo{
-							FullName: net,
-							//Aliases: serviceAliases, - we merge serviceAliases later
+					composeNetsSet[key] = struct{}{}
+				}
+
+				svcNets := depServicesExe.ActiveServiceNetworks(targetComposeSvc)
+				for key, netNameInfo := range svcNets {
+					if len(composeNets) > 0 {
+						if _, found := composeNetsSet[key]; !found {
+							continue
 						}
 					}
+
+					selectedNetNames[key] = netNameInfo
 				}
 			} else {
-				//select/use all networks if specific networks are not selected
-				for key, fullName := range allNetNames {
-					selectedNetNames[key] = compose.NetNameInfo{
-						FullName: fullName,
-						//Aliases: serviceAliases, - we merge serviceAliases later
+				//we are not targeting a compose service,
+				//but we do want to connect to the networks in compose
+				if len(composeNets) > 0 {
+					for _, key := range composeNets {
+						if net, found := allNetNames[key]; found {
+							selectedNetNames[key] = compose.NetNameInfo{
+								FullName: net,
+								//Aliases: serviceAliases, - we merge serviceAliases later
+							}
+						}
+					}
+				} else {
+					//select/use all networks if specific networks are not selected
+					for key, fullName := range allNetNames {
+						selectedNetNames[key] = compose.NetNameInfo{
+							FullName: fullName,
+							//Aliases: serviceAliases, - we merge serviceAliases later
+						}
 					}
 				}
 			}
 		}
-	}
 
-	links = []string{} //reset&reuse
-	if targetComposeSvc != "" && depServicesExe != nil {
-		targetSvcInfo := depServicesExe.Service(targetComposeSvc)
-		//convert service links to container links (after deps are started)
-		targetSvcLinkMap := map[string]struct{}{}
-		for _, linkInfo := range targetSvcInfo.Config.Links {
-			var linkTarget string
-			var linkName string
-			parts := strings.Split(linkInfo, ":")
-			switch len(parts) {
-			case 1:
-				linkTarget = parts[0]
-				linkName = parts[0]
-			case 2:
-				linkTarget = parts[0]
-				linkName = parts[1]
-			default:
-				logger.Debugf("targetSvcInfo.Config.Links: malformed link - %s", linkInfo)
-				continue
-			}
+		links = []string{} //reset&reuse
+		if targetComposeSvc != "" && depServicesExe != nil {
+			targetSvcInfo := depServicesExe.Service(targetComposeSvc)
+			//convert service links to container links (after deps are started)
+			targetSvcLinkMap := map[string]struct{}{}
+			for _, linkInfo := range targetSvcInfo.Config.Links {
+				var linkTarget string
+				var linkName string
+				parts := strings.Split(linkInfo, ":")
+				switch len(parts) {
+				case 1:
+					linkTarget = parts[0]
+					linkName = parts[0]
+				case 2:
+					linkTarget = parts[0]
+					linkName = parts[1]
+				default:
+					logger.Debugf("targetSvcInfo.Config.Links: malformed link - %s", linkInfo)
+					continue
+				}
 
-			linkSvcInfo := depServicesExe.Service(linkTarget)
-			if linkSvcInfo == nil {
-				logger.Debugf("targetSvcInfo.Config.Links: unknown service in link - %s", linkInfo)
-				continue
-			}
+				linkSvcInfo := depServicesExe.Service(linkTarget)
+				if linkSvcInfo == nil {
+					logger.Debugf("targetSvcInfo.Config.Links: unknown service in link - %s", linkInfo)
+					continue
+				}
+
+				logger.Debugf("targetSvcInfo.Config.Links: linkInfo=%s linkSvcInfo=%#v", linkInfo, linkSvcInfo)
+				if linkSvcInfo.ContainerName == "" {
+					logger.Debugf("targetSvcInfo.Config.Links: no container name - linkInfo=%s", linkInfo)
+					continue
+				}
 
-			logger.Debugf("targetSvcInfo.Config.Links: linkInfo=%s linkSvcInfo=%#v", linkInfo, linkSvcInfo)
-			if linkSvcInfo.ContainerName == "" {
-				logger.Debugf("targetSvcInfo.Config.Links: no container name - linkInfo=%s", linkInfo)
-				continue
+				clink := fmt.Sprintf("%s:%s", linkSvcInfo.ContainerName, linkSvcInfo.ContainerName)
+				targetSvcLinkMap[clink] = struct{}{}
+				clink = fmt.Sprintf("%s:%s", linkSvcInfo.ContainerName, linkName)
+				targetSvcLinkMap[clink] = struct{}{}
 			}
 
-			clink := fmt.Sprintf("%s:%s", linkSvcInfo.ContainerName, linkSvcInfo.ContainerName)
-			targetSvcLinkMap[clink] = struct{}{}
-			clink = fmt.Sprintf("%s:%s", linkSvcInfo.ContainerName, linkName)
-			targetSvcLinkMap[clink] = struct{}{}
+			for k := range targetSvcLinkMap {
+				links = append(links, k)
+			}
 		}
 
-		for k := range targetSvcLinkMap {
+		for k := range svcLinkMap {
 			links = append(links, k)
 		}
-	}
 
-	for k := range svcLinkMap {
-		links = append(links, k)
-	}
+		selectedNetworks := map[string]container.NetNameInfo{}
+		for key, info := range selectedNetNames {
+			aset := map[string]struct{}{}
+			for _, a := range info.Aliases {
+				aset[a] = struct{}{}
+			}
+
+			//merge serviceAliases with the main set of aliases
+			for _, a := range serviceAliases {
+				aset[a] = struct{}{}
+			}
+			var alist []string
+			for a := range aset {
+				alist = append(alist, a)
+			}
 
-	selectedNetworks := map[string]container.NetNameInfo{}
-	for key, info := range selectedNetNames {
-		aset := map[string]struct{}{}
-		for _, a := range info.Aliases {
-			aset[a] = struct{}{}
+			selectedNetworks[key] = container.NetNameInfo{
+				Name:     key,
+				FullName: info.FullName,
+				Aliases:  alist,
+			}
 		}
 
-		//merge serviceAliases with the main set of aliases
-		for _, a := range serviceAliases {
-			aset[a] = struct{}{}
+		xc.Out.State("container.inspection.start")
+
+		hasClassicLinks := true
+		if targetComposeSvc != "" ||
+			len(composeNets) > 0 ||
+			overrides.Network != "" {
+			hasClassicLinks = false
 		}
-		var alist []string
-		for a := range aset {
-			alist = append(alist, a)
+
+		containerInspector, err := container.NewInspector(
+			xc,
+			crOpts,
+			logger,
+			client,
+			statePath,
+			imageInspector,
+			localVolumePath,
+			doUseLocalMounts,
+			doUseSensorVolume,
+			doKeepTmpArtifacts,
+			overrides,
+			explicitVolumeMounts,
+			baseMounts,
+			baseVolumesFrom,
+			portBindings,
+			doPublishExposedPorts,
+			hasClassicLinks,
+			links,
+			etcHostsMaps,
+			dnsServers,
+			dnsSearchDomains,
+			doShowContainerLogs,
+			doKeepPerms,
+			pathPerms,
+			excludePatterns,
+			preservePaths,
+			includePaths,
+			includeBins,
+			includeExes,
+			includePkgs,
+			doIncludePkgDeps,
+			doIncludeShell,
+			doIncludeCertAll,
+			doIncludeCertBundles,
+			doIncludeCertDirs,
+			doIncludeCertPKAll,
+			doIncludeCertPKDirs,
+			doIncludeNew,
+			doIncludeEssentials,
+			runAsUser,
+			selectedNetworks,
+			gparams.Debug,
+			gparams.LogLevel,
+			gparams.LogFormat,
+			gparams.InContainer,
+			rtaSourcePT,
+			sensorIPCEndpoint,
+			sensorIPCMode,
+			printState,
+			appNodejsInspectOpts)
+		if err != nil {
+			return err
 		}
 
-		selectedNetworks[key] = container.NetNameInfo{
-			Name:     key,
-			FullName: info.FullName,
-			Aliases:  alist,
+		traceRecorder := telemetry.Recorder()
+		containerInspector.Trace = traceRecorder
+		profileDone := telemetry.Phase(commands.PhaseProfile)
+
+		if len(containerInspector.FatContainerCmd) == 0 {
+			xc.Out.Info("target.image.error",
+				ovars{
+					"status":  "no.entrypoint.cmd",
+					"image":   targetRef,
+					"message": "no ENTRYPOINT/CMD",
+				})
+
+			exitCode := commands.ECTBuild | ecbNoEntrypoint
+			xc.Out.State("exited", ovars{"exit.code": exitCode})
+
+			cmdReport.Error = "no.entrypoint.cmd"
+			return app.NewExitError(exitCode)
 		}
-	}
 
-	xc.Out.State("container.inspection.start")
+		logger.Info("starting instrumented 'fat' container...")
+		err = containerInspector.RunContainer()
+		if err != nil && containerInspector.DoShowContainerLogs {
+			containerInspector.ShowContainerLogs()
+		}
 
-	hasClassicLinks := true
-	if targetComposeSvc != "" ||
-		len(composeNets) > 0 ||
-		overrides.Network != "" {
-		hasClassicLinks = false
-	}
+		if err != nil {
+			return app.WrapError(err, app.ErrorCategoryContainer, "container.run", commands.HintContainerLogs)
+		}
 
-	containerInspector, err := container.NewInspector(
-		xc,
-		crOpts,
-		logger,
-		client,
-		statePath,
-		imageInspector,
-		localVolumePath,
-		doUseLocalMounts,
-		doUseSensorVolume,
-		doKeepTmpArtifacts,
-		overrides,
-		explicitVolumeMounts,
-		baseMounts,
-		baseVolumesFrom,
-		portBindings,
-		doPublishExposedPorts,
-		hasClassicLinks,
-		links,
-		etcHostsMaps,
-		dnsServers,
-		dnsSearchDomains,
-		doShowContainerLogs,
-		doKeepPerms,
-		pathPerms,
-		excludePatterns,
-		preservePaths,
-		includePaths,
-		includeBins,
-		includeExes,
-		doIncludeShell,
-		doIncludeCertAll,
-		doIncludeCertBundles,
-		doIncludeCertDirs,
-		doIncludeCertPKAll,
-		doIncludeCertPKDirs,
-		doIncludeNew,
-		selectedNetworks,
-		gparams.Debug,
-		gparams.LogLevel,
-		gparams.LogFormat,
-		gparams.InContainer,
-		rtaSourcePT,
-		sensorIPCEndpoint,
-		sensorIPCMode,
-		printState,
-		appNodejsInspectOpts)
-	xc.FailOn(err)
-
-	if len(containerInspector.FatContainerCmd) == 0 {
-		xc.Out.Info("target.image.error",
+		containerName := containerInspector.ContainerName
+		containerID := containerInspector.ContainerID
+		inspectorCleanup := func() {
+			xc.Out.Info("container.inspector.cleanup",
+				ovars{
+					"name": containerName,
+					"id":   containerID,
+				})
+
+			if containerInspector != nil {
+				xc.Out.State("container.target.shutdown.start")
+				containerInspector.FinishMonitoring()
+				_ = containerInspector.ShutdownContainer()
+				xc.Out.State("container.target.shutdown.done")
+			}
+		}
+
+		xc.AddCleanupHandler(inspectorCleanup)
+
+		xc.Out.Info("container",
 			ovars{
-				"status":  "no.entrypoint.cmd",
-				"image":   targetRef,
-				"message": "no ENTRYPOINT/CMD",
+				"name":             containerInspector.ContainerName,
+				"id":               containerInspector.ContainerID,
+				"target.port.list": containerInspector.ContainerPortList,
+				"target.port.info": containerInspector.ContainerPortsInfo,
+				"message":          "YOU CAN USE THESE PORTS TO INTERACT WITH THE CONTAINER",
 			})
 
-		exitCode := commands.ECTBuild | ecbNoEntrypoint
-		xc.Out.State("exited", ovars{"exit.code": exitCode})
+		logger.Info("watching container monitor...")
+
+		traceMonitorDone := traceRecorder.Begin(trace.TrackCommand, "command", "container.monitor")
+		err = monitorContainer(
+			xc,
+			targetRef,
+			continueAfter,
+			execCmd,
+			execFileCmd,
+			httpProbeOpts,
+			hostExecProbes,
+			execHooks,
+			depServicesExe,
+			containerProbeComposeSvc,
+			containerInspector,
+			telemetry,
+			client,
+			cmdReport,
+			printState)
+		traceMonitorDone(nil)
+		if err != nil {
+			return err
+		}
+
+		xc.Out.State("container.inspection.finishing")
+
+		containerInspector.FinishMonitoring()
+
+		logger.Info("shutting down 'fat' container...")
+		shutdownErr := containerInspector.ShutdownContainer()
+
+		if depServicesExe != nil {
+			xc.Out.State("container.dependencies.shutdown.start")
+			err = depServicesExe.Stop()
+			errutil.WarnOn(err)
+			err = depServicesExe.Cleanup()
+			errutil.WarnOn(err)
+			xc.Out.State("container.dependencies.shutdown.done")
+		}
+
+		if shutdownErr != nil {
+			return app.WrapError(shutdownErr, app.ErrorCategoryContainer, "container.artifacts.copy", commands.HintContainerLogs)
+		}
+
+		xc.Out.State("container.inspection.artifact.processing")
+
+		if !containerInspector.HasCollectedData() {
+			imageInspector.ShowFatImageDockerInstructions()
+			xc.Out.Info("results",
+				ovars{
+					"status":   "no data collected (no minified image generated)",
+					"version":  v.Current(),
+					"location": fsutil.ExeDir(),
+				})
+
+			exitCode := commands.ECTBuild | ecbImageBuildError
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+
+			cmdReport.Error = "no.data.collected"
+			return app.NewExitError(exitCode)
+		}
+
+		logger.Info("processing instrumented 'fat' container info...")
+		err = containerInspector.ProcessCollectedData()
+		if err != nil {
+			return err
+		}
+
+		profileDone()
+		if traceOutput != "" {
+			commands.AddSensorTraceEvents(traceRecorder, imageInspector.ArtifactLocation, logger)
+			commands.SaveTrace(xc, traceRecorder, traceOutput, traceFormat, logger)
+		}
+
+		if sensorCacheKey != "" {
+			saveCachedSensorResults(resultCache, sensorCacheKey, imageInspector, logger)
+		}
+	}
+
+	xc.Out.State("container.inspection.done")
+
+	if doDryRun {
+		//the artifacts need to be uploaded before they are removed in the post processing step
+		if reportUpload != "" && doUploadArtifacts {
+			commands.UploadReportFiles(xc, reportUpload, commands.ArtifactFiles(imageInspector.ArtifactLocation), logger)
+		}
+
+		err := dryRunPostProcess(
+			xc,
+			customImageTag,
+			overrides,
+			imageOverrideSelectors,
+			instructions,
+			"",
+			doRmFileArtifacts,
+			imageInspector,
+			client,
+			logger,
+			cmdReport)
+		if err != nil {
+			return err
+		}
+
+		telemetry.Finish(cmdReport.State)
+
+		if reportHTML != "" {
+			saveHTMLReport(xc, reportHTML, cmdReport.Plan, logger, cmdReport)
+		}
+
+		if reportUpload != "" {
+			commands.UploadReportFiles(xc, reportUpload, []string{cmdReport.ReportLocation(), reportHTML}, logger)
+		}
 
-		cmdReport.Error = "no.entrypoint.cmd"
-		xc.Exit(exitCode)
+		vinfo := <-viChan
+		version.PrintCheckVersion(xc, "", vinfo)
+		return nil
 	}
 
-	logger.Info("starting instrumented 'fat' container...")
-	err = containerInspector.RunContainer()
-	if err != nil && containerInspector.DoShowContainerLogs {
-		containerInspector.ShowContainerLogs()
+	//the preserved original image layers have the original Java runtime
+	if appJavaRuntimeOpts.JLink && !doPreserveLayers {
+		minimizeJavaRuntime(
+			xc,
+			appJavaRuntimeOpts,
+			imageInspector,
+			client,
+			logger,
+			cmdReport)
 	}
-	xc.FailOn(err)
 
-	containerName := containerInspector.ContainerName
-	containerID := containerInspector.ContainerID
-	inspectorCleanup := func() {
-		xc.Out.Info("container.inspector.cleanup",
-			ovars{
-				"name": containerName,
-				"id":   containerID,
-			})
+	//the preserved original image layers have all original Python packages
+	if appPythonPackageOpts.Prune && !doPreserveLayers {
+		prunePythonPackages(
+			xc,
+			appPythonPackageOpts,
+			imageInspector.ArtifactLocation,
+			logger,
+			cmdReport)
+	}
+
+	//the preserved original image layers have all original Node.js packages
+	if appNodejsPackageOpts.Prune && !doPreserveLayers {
+		pruneNodePackages(
+			xc,
+			appNodejsPackageOpts,
+			imageInspector.ArtifactLocation,
+			logger,
+			cmdReport)
+	}
 
-		if containerInspector != nil {
-			xc.Out.State("container.target.shutdown.start")
-			containerInspector.FinishMonitoring()
-			_ = containerInspector.ShutdownContainer()
-			xc.Out.State("container.target.shutdown.done")
+	//the preserved original image layers have all original files
+	if !doPreserveLayers {
+		var keptPaths []string
+		for _, paths := range []map[string]*fsutil.AccessInfo{includePaths, includeBins, includeExes} {
+			for name := range paths {
+				keptPaths = append(keptPaths, strings.Trim(name, "/"))
+			}
 		}
+
+		processStaticBinary(
+			xc,
+			doStaticScratch,
+			keptPaths,
+			imageInspector.ArtifactLocation,
+			logger,
+			cmdReport)
 	}
 
-	xc.AddCleanupHandler(inspectorCleanup)
+	var reproducibleModTime time.Time
+	if doReproducible {
+		reproducibleModTime = reproducibleTime()
+	}
 
-	xc.Out.Info("container",
-		ovars{
-			"name":             containerInspector.ContainerName,
-			"id":               containerInspector.ContainerID,
-			"target.port.list": containerInspector.ContainerPortList,
-			"target.port.info": containerInspector.ContainerPortsInfo,
-			"message":          "YOU CAN USE THESE PORTS TO INTERACT WITH THE CONTAINER",
-		})
+	buildImage := func(deleteFatImage bool) (string, error) {
+		if doReproducible {
+			//the file artifacts can be updated when the verification fails
+			err := normalizeFileArtifacts(imageInspector.ArtifactLocation, reproducibleModTime)
+			errutil.WarnOn(err)
+		}
 
-	logger.Info("watching container monitor...")
+		buildDone := telemetry.Phase(commands.PhaseBuild)
+		defer buildDone()
 
-	monitorContainer(
-		xc,
-		targetRef,
-		continueAfter,
-		execCmd,
-		execFileCmd,
-		httpProbeOpts,
-		hostExecProbes,
-		depServicesExe,
-		containerProbeComposeSvc,
-		containerInspector,
-		client,
-		cmdReport,
-		printState)
+		return buildSlimImage(
+			xc,
+			customImageTag,
+			additionalTags,
+			cbOpts,
+			overrides,
+			imageOverrideSelectors,
+			instructions,
+			deleteFatImage,
+			doShowBuildLogs,
+			buildEngineOpts,
+			imageInspector,
+			client,
+			logger,
+			cmdReport)
+	}
 
-	xc.Out.State("container.inspection.finishing")
+	//the fat image is needed to expand the included paths if the verification fails
+	//and to reuse its layers when the original image layers are preserved
+	keepFatImage := (doVerifySlim && verifyRetries > 0) || doPreserveLayers
+	minifiedImageName, err := buildImage(doDeleteFatImage && !keepFatImage)
+	if err != nil {
+		return err
+	}
 
-	containerInspector.FinishMonitoring()
+	if doVerifySlim {
+		minifiedImageName, err = verifySlimImage(
+			xc,
+			minifiedImageName,
+			verifyRetries,
+			func() (string, error) { return buildImage(false) },
+			overrides,
+			httpProbeOpts,
+			imageInspector,
+			client,
+			logger,
+			cmdReport)
+		if err != nil {
+			return err
+		}
+	}
 
-	logger.Info("shutting down 'fat' container...")
-	err = containerInspector.ShutdownContainer()
-	errutil.WarnOn(err)
+	//the preserved original image layers have more files than the file artifacts
+	if doAuditELF && !doPreserveLayers {
+		auditSlimImageELF(
+			xc,
+			minifiedImageName,
+			imageInspector.ArtifactLocation,
+			client,
+			logger,
+			cmdReport)
+	}
 
-	if depServicesExe != nil {
-		xc.Out.State("container.dependencies.shutdown.start")
-		err = depServicesExe.Stop()
-		errutil.WarnOn(err)
-		err = depServicesExe.Cleanup()
-		errutil.WarnOn(err)
-		xc.Out.State("container.dependencies.shutdown.done")
+	if doPreserveLayers {
+		preserveImageLayers(
+			xc,
+			minifiedImageName,
+			additionalTags,
+			preserveLayersMinKept,
+			imageInspector.ImageInfo.ID,
+			imageInspector.ArtifactLocation,
+			client,
+			logger,
+			cmdReport)
 	}
 
-	xc.Out.State("container.inspection.artifact.processing")
+	if doReproducible {
+		makeImageReproducible(
+			xc,
+			minifiedImageName,
+			additionalTags,
+			reproducibleModTime,
+			imageInspector.ArtifactLocation,
+			client,
+			logger,
+			cmdReport)
+	}
 
-	if !containerInspector.HasCollectedData() {
-		imageInspector.ShowFatImageDockerInstructions()
-		xc.Out.Info("results",
-			ovars{
-				"status":   "no data collected (no minified image generated)",
-				"version":  v.Current(),
-				"location": fsutil.ExeDir(),
-			})
+	if ociOutput != "" {
+		saveOCIImage(
+			xc,
+			minifiedImageName,
+			ociOutput,
+			ociLayerCompression,
+			imageInspector.ArtifactLocation,
+			client,
+			logger,
+			cmdReport)
+	}
 
-		exitCode := commands.ECTBuild | ecbImageBuildError
-		xc.Out.State("exited",
-			ovars{
-				"exit.code": exitCode,
-			})
+	if sbomFormat != "" {
+		saveSlimImageSBOM(
+			xc,
+			sbomFormat,
+			sbomOutput,
+			minifiedImageName,
+			imageInspector.ArtifactLocation,
+			client,
+			logger,
+			cmdReport)
+	}
 
-		cmdReport.Error = "no.data.collected"
-		xc.Exit(exitCode)
+	if vulnScanner != "" {
+		scanImageVulnerabilities(
+			xc,
+			vulnScanner,
+			vulnScannerExe,
+			imageInspector.ImageRef,
+			minifiedImageName,
+			logger,
+			cmdReport)
 	}
 
-	logger.Info("processing instrumented 'fat' container info...")
-	err = containerInspector.ProcessCollectedData()
-	xc.FailOn(err)
+	if doIncludeDebugImage {
+		buildDebugImage(
+			xc,
+			minifiedImageName,
+			debugImageTag,
+			debugImageToolbox,
+			doShowBuildLogs,
+			imageInspector.ArtifactLocation,
+			client,
+			logger,
+			cmdReport)
+	}
 
-	xc.Out.State("container.inspection.done")
+	//the file lists need the target image and the file artifacts
+	var reportFiles *report.BuildPlan
+	if reportHTML != "" {
+		files, err := createFilePlan(imageInspector, client)
+		if err == nil {
+			reportFiles = files
+		} else {
+			logger.Debugf("error listing kept and removed files - %v", err)
+		}
+	}
 
-	minifiedImageName := buildSlimImage(
-		xc,
-		customImageTag,
-		additionalTags,
-		cbOpts,
-		overrides,
-		imageOverrideSelectors,
-		instructions,
-		doDeleteFatImage,
-		doShowBuildLogs,
-		imageInspector,
-		client,
-		logger,
-		cmdReport)
+	if doDeleteFatImage && keepFatImage && cbOpts.Dockerfile != "" {
+		err := client.RemoveImage(cbOpts.Tag)
+		errutil.WarnOn(err)
+	}
+
+	//the artifacts need to be uploaded before they are removed in the post processing step
+	if reportUpload != "" && doUploadArtifacts {
+		commands.UploadReportFiles(xc, reportUpload, commands.ArtifactFiles(imageInspector.ArtifactLocation), logger)
+	}
 
 	// (Re)Name me please!
-	slimmingPostProcess(
+	err = slimmingPostProcess(
 		xc,
 		minifiedImageName,
 		copyMetaArtifactsLocation,
 		doRmFileArtifacts,
 		gparams.ArchiveState,
 		stateKey,
+		sizePolicy,
 		imageInspector,
 		client,
 		logger,
 		cmdReport)
+	if err != nil {
+		return err
+	}
+
+	telemetry.Finish(cmdReport.State)
+
+	if reportHTML != "" {
+		saveHTMLReport(xc, reportHTML, reportFiles, logger, cmdReport)
+	}
+
+	if reportUpload != "" {
+		commands.UploadReportFiles(xc, reportUpload, []string{cmdReport.ReportLocation(), reportHTML}, logger)
+	}
 
 	vinfo := <-viChan
 	version.PrintCheckVersion(xc, "", vinfo)
+	return nil
 }
 
 func monitorContainer(
@@ -1002,13 +1472,18 @@ func monitorContainer(
 	execFileCmd string,
 	httpProbeOpts config.HTTPProbeOptions,
 	hostExecProbes []string,
+	execHooks []config.ExecHook,
 	depServicesExe *compose.Execution,
 	containerProbeComposeSvc string,
 	containerInspector *container.Inspector,
+	telemetry *commands.Telemetry,
 	client *dockerapi.Client,
 	cmdReport *report.BuildCommand,
 	printState bool,
-) {
+) error {
+	commands.RunExecHooks(xc, printState, execHooks, config.ExecHookAfterStart,
+		containerInspector.APIClient, containerInspector.ContainerID)
+
 	if hasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
 		httpProbeOpts.Do = true
 	}
@@ -1017,9 +1492,11 @@ func monitorContainer(
 	if httpProbeOpts.Do {
 		var err error
 		probe, err = http.NewContainerProbe(xc, containerInspector, httpProbeOpts, printState)
-		xc.FailOn(err)
+		if err != nil {
+			return err
+		}
 
-		if len(probe.Ports()) == 0 {
+		if len(probe.Ports()) == 0 && len(probe.UDPPorts()) == 0 {
 			xc.Out.State("http.probe.error",
 				ovars{
 					"error":   "NO EXPOSED PORTS",
@@ -1038,11 +1515,24 @@ func monitorContainer(
 				})
 
 			cmdReport.Error = "no.exposed.ports"
-			xc.Exit(exitCode)
+			return app.NewExitError(exitCode)
 		}
 
+		probe.SetTrace(telemetry.Recorder())
+		probe.SetRoundHook(func(round int) {
+			commands.RunExecHooks(xc, printState, execHooks, config.ExecHookProbeRound,
+				containerInspector.APIClient, containerInspector.ContainerID)
+		})
+
+		probeDone := telemetry.Phase(commands.PhaseProbe)
+		probeProgress := xc.Out.NewSpinner("http.probe")
 		probe.Start()
 		continueAfter.ContinueChan = probe.DoneChan()
+		go func(done <-chan struct{}) {
+			<-done
+			probeProgress.Done()
+			probeDone()
+		}(probe.DoneChan())
 	}
 
 	continueAfterMsg := "provide the expected input to allow the container inspector to continue its execution"
@@ -1089,26 +1579,39 @@ func monitorContainer(
 						Stdout:       true,
 						Stderr:       true,
 					})
-					xc.FailOn(err)
+					//the log streaming errors don't stop the container probe
+					errutil.WarnOn(err)
 				}()
 			}
 
 			svc, ok := depServicesExe.RunningServices[containerProbeComposeSvc]
 			if !ok {
 				xc.Out.State("error", ovars{"message": "container-prove-compose-svc not found in running services"})
-				xc.Exit(1)
+				exitCode := commands.ECTBuild | ecbContainerProbeSvcNotFound
+				xc.Out.State("exited", ovars{"exit.code": exitCode})
+				cmdReport.Error = "container.probe.svc.not.found"
+				return app.NewExitError(exitCode)
 			}
 			for {
 				c, err := client.InspectContainerWithOptions(dockerapi.InspectContainerOptions{
 					ID: svc.ID,
 				})
-				xc.FailOn(err)
+				if err != nil {
+					return err
+				}
+
 				if c.State.Running {
 					xc.Out.Info("wait for container.probe to finish")
 				} else {
 					if c.State.ExitCode != 0 {
-						xc.Out.State("exited", ovars{"container.probe exit.code": c.State.ExitCode})
-						xc.Exit(1)
+						exitCode := commands.ECTBuild | ecbContainerProbeFailure
+						xc.Out.State("exited",
+							ovars{
+								"exit.code":                 exitCode,
+								"container.probe.exit.code": c.State.ExitCode,
+							})
+						cmdReport.Error = "container.probe.failure"
+						return app.NewExitError(exitCode)
 					}
 					break
 				}
@@ -1149,18 +1652,29 @@ func monitorContainer(
 				AttachStdout: true,
 				AttachStderr: true,
 			})
-			xc.FailOn(err)
+			if err != nil {
+				return err
+			}
 
 			buffer := &printbuffer.PrintBuffer{Prefix: fmt.Sprintf("%s[%s][exec]: output:", appName, Name)}
-			xc.FailOn(containerInspector.APIClient.StartExec(exec.ID, dockerapi.StartExecOptions{
+			err = containerInspector.APIClient.StartExec(exec.ID, dockerapi.StartExecOptions{
 				InputStream:  input,
 				OutputStream: buffer,
 				ErrorStream:  buffer,
-			}))
+			})
+			if err != nil {
+				return err
+			}
 
 			inspect, err := containerInspector.APIClient.InspectExec(exec.ID)
-			xc.FailOn(err)
-			errutil.FailWhen(inspect.Running, "still running")
+			if err != nil {
+				return err
+			}
+
+			if inspect.Running {
+				return commands.ExecStillRunningError()
+			}
+
 			if inspect.ExitCode != 0 {
 				execFail = true
 			}
@@ -1181,7 +1695,12 @@ func monitorContainer(
 
 		case config.CAMTimeout:
 			xc.Out.Prompt(fmt.Sprintf("waiting for the target container (%v seconds)", int(continueAfter.Timeout)))
-			<-time.After(time.Second * continueAfter.Timeout)
+			select {
+			case <-time.After(time.Second * continueAfter.Timeout):
+			case <-xc.Context.Done():
+				//the command is interrupted or the target is aborted (the target container crashed)
+				return xc.Context.Err()
+			}
 			xc.Out.Info("event",
 				ovars{
 					"message": "done waiting for the target container",
@@ -1189,29 +1708,77 @@ func monitorContainer(
 
 		case config.CAMProbe:
 			xc.Out.Prompt("waiting for the HTTP probe to finish")
-			<-continueAfter.ContinueChan
+			select {
+			case <-continueAfter.ContinueChan:
+			case <-xc.Context.Done():
+				return xc.Context.Err()
+			}
 			xc.Out.Info("event",
 				ovars{
 					"message": "HTTP probe is done",
 				})
 
+			//the probe results are saved in the report even if the command exits on the probe failures
+			if probe != nil {
+				cmdReport.HTTPProbe = probe.Report()
+			}
+
 			if probe != nil && probe.CallCount > 0 && probe.OkCount == 0 && httpProbeOpts.ExitOnFailure {
 				xc.Out.Error("probe.error", "no.successful.calls")
 
 				containerInspector.ShowContainerLogs()
-				xc.Out.State("exited", ovars{"exit.code": -1})
-				xc.Exit(-1)
+				saveProbeFailureReport(xc, cmdReport, "probe.no.successful.calls")
+				xc.Out.State("exited", ovars{"exit.code": commands.ECTBuild | ecbNoSuccessfulProbes})
+				return app.NewExitError(commands.ECTBuild | ecbNoSuccessfulProbes)
+			}
+
+			if probe != nil && probe.FailureThresholdExceeded() {
+				xc.Out.Error("probe.error", "failure.threshold.exceeded")
+				xc.Out.Info("probe.failures",
+					ovars{
+						"commands":        probe.CmdCount,
+						"failed.commands": probe.CmdFailCount,
+						"threshold":       httpProbeOpts.FailThreshold,
+					})
+
+				containerInspector.ShowContainerLogs()
+				saveProbeFailureReport(xc, cmdReport, "probe.failure.threshold.exceeded")
+				exitCode := commands.ECTBuild | ecbProbeFailureThreshold
+				xc.Out.State("exited", ovars{"exit.code": exitCode})
+				return app.NewExitError(exitCode)
 			}
 		case config.CAMHostExec:
 			commands.RunHostExecProbes(printState, xc, hostExecProbes)
+		case config.CAMSession:
+			failCount := commands.RunExecSession(xc, continueAfter, targetRef,
+				func(command string) (int, error) {
+					return commands.ContainerExec(containerInspector.APIClient, containerInspector.ContainerID, command, "session")
+				})
+			xc.Out.Info("continue.after",
+				ovars{
+					"mode":     config.CAMSession,
+					"failures": failCount,
+				})
+
+			if failCount > 0 && continueAfter.SessionReplayFile != "" {
+				//replayed sessions need to be deterministic
+				execFail = true
+			}
 		case config.CAMAppExit:
 			xc.Out.Prompt("waiting for the target app to exit")
 			//TBD
 		default:
-			errutil.Fail("unknown continue-after mode")
+			return commands.UnknownContinueAfterModeError(mode)
 		}
 	}
 
+	commands.RunExecHooks(xc, printState, execHooks, config.ExecHookBeforeCommit,
+		containerInspector.APIClient, containerInspector.ContainerID)
+
+	if probe != nil {
+		cmdReport.HTTPProbe = probe.Report()
+	}
+
 	if execFail {
 		xc.Out.Info("continue.after",
 			ovars{
@@ -1226,8 +1793,10 @@ func monitorContainer(
 			})
 
 		cmdReport.Error = "exec.cmd.failure"
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
+
+	return nil
 }
 
 func slimmingPostProcess(
@@ -1237,13 +1806,16 @@ func slimmingPostProcess(
 	doRmFileArtifacts bool,
 	archiveState string,
 	stateKey string,
+	sizePolicy *config.SizePolicy,
 	imageInspector *image.Inspector,
 	client *dockerapi.Client,
 	logger *log.Entry,
 	cmdReport *report.BuildCommand,
-) {
+) error {
 	newImageInspector, err := image.NewInspector(client, minifiedImageName)
-	xc.FailOn(err)
+	if err != nil {
+		return err
+	}
 
 	if newImageInspector.NoImage() {
 		xc.Out.Info("results",
@@ -1259,7 +1831,7 @@ func slimmingPostProcess(
 			})
 
 		cmdReport.Error = "minified.image.not.found"
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
 	err = newImageInspector.Inspect()
@@ -1316,6 +1888,11 @@ func slimmingPostProcess(
 	cmdReport.ContainerReportName = report.DefaultContainerReportFileName
 	cmdReport.SeccompProfileName = imageInspector.SeccompProfileName
 	cmdReport.AppArmorProfileName = imageInspector.AppArmorProfileName
+	//the capabilities report is not created without the syscall info
+	hasCapsReport := fsutil.Exists(filepath.Join(imageInspector.ArtifactLocation, imageInspector.CapabilitiesName))
+	if hasCapsReport {
+		cmdReport.CapabilitiesReportName = imageInspector.CapabilitiesName
+	}
 
 	xc.Out.Info("results",
 		ovars{
@@ -1354,6 +1931,13 @@ func slimmingPostProcess(
 			"artifacts.apparmor": cmdReport.AppArmorProfileName,
 		})
 
+	if hasCapsReport {
+		xc.Out.Info("results",
+			ovars{
+				"artifacts.capabilities": cmdReport.CapabilitiesReportName,
+			})
+	}
+
 	if cmdReport.ArtifactLocation != "" {
 		creportPath := filepath.Join(cmdReport.ArtifactLocation, cmdReport.ContainerReportName)
 		if creportData, err := ioutil.ReadFile(creportPath); err == nil {
@@ -1364,6 +1948,35 @@ func slimmingPostProcess(
 					Release: creport.System.Release,
 					Distro:  creport.System.Distro,
 				}
+
+				for _, info := range creport.Image.IncludeDeps {
+					xc.Out.Info("include.deps",
+						ovars{
+							"target":      info.Target,
+							"interpreter": info.Interpreter,
+							"libraries":   len(info.Libraries),
+							"dlopen":      len(info.DlopenLibraries),
+							"files":       len(info.Files),
+						})
+				}
+
+				if info := creport.Image.RuntimeEssentials; info != nil {
+					xc.Out.Info("runtime.essentials",
+						ovars{
+							"tls":      info.TLS,
+							"timezone": info.Timezone,
+							"users":    info.Users,
+						})
+				}
+
+				for _, info := range creport.Image.XattrIssues {
+					xc.Out.Info("xattr.not.preserved",
+						ovars{
+							"path":  info.Path,
+							"name":  info.Name,
+							"error": info.Error,
+						})
+				}
 			} else {
 				logger.Infof("could not read container report - json parsing error - %v", err)
 			}
@@ -1379,6 +1992,10 @@ func slimmingPostProcess(
 			imageInspector.SeccompProfileName,
 			imageInspector.AppArmorProfileName,
 		}
+
+		if hasCapsReport {
+			toCopy = append(toCopy, imageInspector.CapabilitiesName)
+		}
 		if !commands.CopyMetaArtifacts(logger,
 			toCopy,
 			imageInspector.ArtifactLocation, copyMetaArtifactsLocation) {
@@ -1404,6 +2021,10 @@ func slimmingPostProcess(
 		errutil.WarnOn(err)
 	}
 
+	if err := checkSizePolicy(xc, sizePolicy, cmdReport); err != nil {
+		return err
+	}
+
 	xc.Out.State("done")
 
 	xc.Out.Info("commands",
@@ -1418,6 +2039,8 @@ func slimmingPostProcess(
 				"file": cmdReport.ReportLocation(),
 			})
 	}
+
+	return nil
 }
 
 func hasContinueAfterMode(modeSet, mode string) bool {
@@ -1456,3 +2079,16 @@ type chanWriter struct {
 func (w *chanWriter) Write(p []byte) (n int, err error) {
 	return w.w.Write(p)
 }
+
+// saveProbeFailureReport saves the command report (with the HTTP probe results)
+// when the command exits because of the HTTP probe failures
+func saveProbeFailureReport(xc *app.ExecutionContext, cmdReport *report.BuildCommand, errName string) {
+	cmdReport.State = command.StateError
+	cmdReport.Error = errName
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+}
@@ -0,0 +1,32 @@
+package build
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// saveHTMLReport saves the command report as a standalone HTML page
+// (the kept and removed files are listed if they are available)
+func saveHTMLReport(
+	xc *app.ExecutionContext,
+	location string,
+	files *report.BuildPlan,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	if err := cmdReport.SaveHTML(location, files); err != nil {
+		logger.Errorf("saveHTMLReport: error saving HTML report - %v", err)
+		xc.Out.Info("report.html",
+			ovars{
+				"error": err.Error(),
+			})
+		return
+	}
+
+	xc.Out.Info("report.html",
+		ovars{
+			"file": location,
+		})
+}
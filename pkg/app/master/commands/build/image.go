@@ -12,6 +12,7 @@ import (
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/builder"
+	"github.com/docker-slim/docker-slim/pkg/app/master/cache"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
@@ -33,12 +34,18 @@ func inspectFatImage(
 	registryAccount string,
 	registrySecret string,
 	paramsStatePath string,
+	resultCache *cache.Store,
+	telemetry *commands.Telemetry,
 	client *dockerapi.Client,
 	logger *log.Entry,
 	cmdReport *report.BuildCommand,
-) (*image.Inspector, string, string, string) {
+) (*image.Inspector, string, string, string, error) {
 	imageInspector, err := image.NewInspector(client, targetRef)
-	xc.FailOn(err)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	imageInspector.ResultCache = resultCache
 
 	if imageInspector.NoImage() {
 		if doPull {
@@ -49,8 +56,16 @@ func inspectFatImage(
 					"message": "trying to pull target image",
 				})
 
-			err := imageInspector.Pull(doShowPullLogs, dockerConfigPath, registryAccount, registrySecret)
-			xc.FailOn(err)
+			pullDone := telemetry.Phase(commands.PhasePull)
+			pullProgress := xc.Out.NewProgress("image.pull", 0, app.ProgressUnitBytes)
+			imageInspector.PullProgress = pullProgress.Update
+			err := imageInspector.Pull(xc.Context, doShowPullLogs, dockerConfigPath, registryAccount, registrySecret)
+			pullProgress.Done()
+			if err != nil {
+				return nil, "", "", "", commands.ImagePullError(err)
+			}
+
+			pullDone()
 		} else {
 			xc.Out.Info("target.image.error",
 				ovars{
@@ -65,7 +80,7 @@ func inspectFatImage(
 					"exit.code": exitCode,
 				})
 
-			xc.Exit(exitCode)
+			return nil, "", "", "", app.NewExitError(exitCode)
 		}
 	}
 
@@ -76,9 +91,15 @@ func inspectFatImage(
 
 	logger.Info("inspecting 'fat' image metadata...")
 	err = imageInspector.Inspect()
-	xc.FailOn(err)
+	if err != nil {
+		return nil, "", "", "", app.WrapError(err, app.ErrorCategoryImage, "image.inspect", "")
+	}
+
+	localVolumePath, artifactLocation, statePath, stateKey, err := fsutil.PrepareImageStateDirs(paramsStatePath, imageInspector.ImageInfo.ID)
+	if err != nil {
+		return nil, "", "", "", commands.StateDirError(err)
+	}
 
-	localVolumePath, artifactLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(paramsStatePath, imageInspector.ImageInfo.ID)
 	imageInspector.ArtifactLocation = artifactLocation
 	logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
 
@@ -107,14 +128,19 @@ func inspectFatImage(
 					})
 
 				cmdReport.Error = "image.already.optimized"
-				xc.Exit(exitCode)
+				return nil, "", "", "", app.NewExitError(exitCode)
 			}
 		}
 	}
 
 	logger.Info("processing 'fat' image info...")
+	reverseDone := telemetry.Phase(commands.PhaseReverse)
 	err = imageInspector.ProcessCollectedData()
-	xc.FailOn(err)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	reverseDone()
 
 	if imageInspector.DockerfileInfo != nil {
 		if imageInspector.DockerfileInfo.ExeUser != "" {
@@ -160,12 +186,49 @@ func inspectFatImage(
 				})
 
 			cmdReport.Error = "onbuild.base.image"
-			xc.Exit(exitCode)
+			return nil, "", "", "", app.NewExitError(exitCode)
 		}
 	}
 
 	xc.Out.State("image.inspection.done")
-	return imageInspector, localVolumePath, statePath, stateKey
+	return imageInspector, localVolumePath, statePath, stateKey, nil
+}
+
+// checkImageBuildPlatforms fails early if the image build platforms (--image-build-platform)
+// don't match the target image platform
+func checkImageBuildPlatforms(
+	xc *app.ExecutionContext,
+	buildEngineOpts *config.ImageBuildEngineOptions,
+	imageInspector *image.Inspector,
+	cmdReport *report.BuildCommand) error {
+	if buildEngineOpts == nil || len(buildEngineOpts.Platforms) == 0 {
+		return nil
+	}
+
+	var imagePlatform string
+	if imageInspector.ImageInfo.OS != "" && imageInspector.ImageInfo.Architecture != "" {
+		imagePlatform = fmt.Sprintf("%s/%s", imageInspector.ImageInfo.OS, imageInspector.ImageInfo.Architecture)
+	}
+
+	if err := builder.CheckBuildPlatforms(imagePlatform, buildEngineOpts.Platforms); err != nil {
+		xc.Out.Info("param.error",
+			ovars{
+				"status":  "bad.image.build.platform",
+				"value":   strings.Join(buildEngineOpts.Platforms, ","),
+				"message": err.Error(),
+			})
+
+		exitCode := commands.ECTBuild | ecbBadImageBuildPlatform
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "bad.image.build.platform"
+		return app.NewExitError(exitCode)
+	}
+
+	return nil
 }
 
 func buildFatImage(
@@ -176,7 +239,8 @@ func buildFatImage(
 	doShowBuildLogs bool,
 	client *dockerapi.Client,
 	cmdReport *report.BuildCommand,
-) (fatImageRepoNameTag string) {
+) (string, error) {
+	var fatImageRepoNameTag string
 	xc.Out.State("building",
 		ovars{
 			"message": "building basic image",
@@ -211,7 +275,7 @@ func buildFatImage(
 				})
 
 			cmdReport.Error = "malformed.custom.image.tag"
-			xc.Exit(exitCode)
+			return "", app.NewExitError(exitCode)
 		}
 	} else {
 		fatImageRepoNameTag = fmt.Sprintf("docker-slim-tmp-fat-image.%v.%v",
@@ -232,8 +296,11 @@ func buildFatImage(
 		cbOpts,
 		targetRef,
 		doShowBuildLogs)
-	xc.FailOn(err)
+	if err != nil {
+		return "", err
+	}
 
+	fatBuilder.BuildOptions.Context = xc.Context
 	err = fatBuilder.Build()
 
 	if doShowBuildLogs || err != nil {
@@ -244,26 +311,19 @@ func buildFatImage(
 	}
 
 	if err != nil {
-		xc.Out.Info("build.error",
-			ovars{
-				"status": "standard.image.build.error",
-				"value":  err,
-			})
-
-		exitCode := commands.ECTBuild | ecbImageBuildError
-		xc.Out.State("exited",
-			ovars{
-				"exit.code": exitCode,
-				"version":   v.Current(),
-				"location":  fsutil.ExeDir(),
-			})
-
-		xc.Exit(exitCode)
+		return "", &app.Error{
+			Category: app.ErrorCategoryImage,
+			Type:     "standard.image.build.error",
+			Message:  err.Error(),
+			Hint:     "check the Dockerfile and the build context (the build logs are shown above)",
+			ExitCode: commands.ECTBuild | ecbImageBuildError,
+			Err:      err,
+		}
 	}
 
 	xc.Out.State("basic.image.build.completed")
 
-	return fatImageRepoNameTag
+	return fatImageRepoNameTag, nil
 }
 
 func buildSlimImage(
@@ -276,11 +336,12 @@ func buildSlimImage(
 	instructions *config.ImageNewInstructions,
 	doDeleteFatImage bool,
 	doShowBuildLogs bool,
+	buildEngineOpts *config.ImageBuildEngineOptions,
 	imageInspector *image.Inspector,
 	client *dockerapi.Client,
 	logger *log.Entry,
 	cmdReport *report.BuildCommand,
-) string {
+) (string, error) {
 	xc.Out.State("building",
 		ovars{
 			"message": "building optimized image",
@@ -300,7 +361,12 @@ func buildSlimImage(
 		overrides,
 		instructions,
 		imageInspector.ImageRef)
-	xc.FailOn(err)
+	if err != nil {
+		return "", err
+	}
+
+	builder.BuildEngine = buildEngineOpts
+	builder.BuildOptions.Context = xc.Context
 
 	if !builder.HasData {
 		logger.Info("WARNING - no data artifacts")
@@ -316,22 +382,14 @@ func buildSlimImage(
 	}
 
 	if err != nil {
-		xc.Out.Info("build.error",
-			ovars{
-				"status": "optimized.image.build.error",
-				"error":  err,
-			})
-
-		exitCode := commands.ECTBuild | ecbImageBuildError
-		xc.Out.State("exited",
-			ovars{
-				"exit.code": exitCode,
-				"version":   v.Current(),
-				"location":  fsutil.ExeDir(),
-			})
-
-		cmdReport.Error = "optimized.image.build.error"
-		xc.Exit(exitCode)
+		return "", &app.Error{
+			Category: app.ErrorCategoryImage,
+			Type:     "optimized.image.build.error",
+			Message:  err.Error(),
+			Hint:     fmt.Sprintf("the build logs are shown above (use --%s to keep the temporary build artifacts)", FlagKeepTmpArtifacts),
+			ExitCode: commands.ECTBuild | ecbImageBuildError,
+			Err:      err,
+		}
 	}
 
 	xc.Out.State("completed")
@@ -356,5 +414,5 @@ func buildSlimImage(
 	cmdReport.MinifiedImage = builder.RepoName
 	cmdReport.MinifiedImageHasData = builder.HasData
 
-	return builder.RepoName
+	return builder.RepoName, nil
 }
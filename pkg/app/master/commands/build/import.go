@@ -0,0 +1,218 @@
+package build
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/container"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/sensor"
+	"github.com/docker-slim/docker-slim/pkg/app/master/security/apparmor"
+	"github.com/docker-slim/docker-slim/pkg/app/master/security/capabilities"
+	"github.com/docker-slim/docker-slim/pkg/app/master/security/seccomp"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+var ErrNoSensorReport = errors.New("no container report (creport.json) in the sensor data")
+
+// importSensorData prepares the sensor data collected by an instrumented image ('instrument' command)
+// the same way the instrumented container artifacts are prepared (the container report, the file artifacts archive and the security profiles).
+// The sensor data is the sensor artifact directory copied from the container ('docker cp <container>:/opt/dockerslim/artifacts <dir>')
+// or its tar archive ('docker cp <container>:/opt/dockerslim/artifacts - > <file>'), which also keeps the file owners.
+func importSensorData(
+	xc *app.ExecutionContext,
+	dataPath string,
+	imageInspector *image.Inspector,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand) error {
+	fail := func(err error) error {
+		logger.Debugf("importSensorData(%s): error - %v", dataPath, err)
+		xc.Out.Info("sensor.data.import.error",
+			ovars{
+				"path":  dataPath,
+				"error": err.Error(),
+			})
+
+		exitCode := commands.ECTBuild | ecbBadSensorData
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "bad.sensor.data"
+		return app.NewExitError(exitCode)
+	}
+
+	artifactLocation := imageInspector.ArtifactLocation
+	creportPath := filepath.Join(artifactLocation, report.DefaultContainerReportFileName)
+	filesOutPath := filepath.Join(artifactLocation, container.FileArtifactsOutTar)
+
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	if info.IsDir() {
+		err = importSensorDataDir(dataPath, creportPath, filesOutPath)
+	} else {
+		err = importSensorDataArchive(dataPath, creportPath, filesOutPath)
+	}
+
+	if err != nil {
+		return fail(err)
+	}
+
+	creport, err := loadContainerReport(creportPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = dockerutil.PrepareContainerDataArchive(filesOutPath,
+		fileArtifactsTar,
+		sensor.FileArtifactsPrefix,
+		true,
+		creport.Image.Xattrs)
+	if err != nil {
+		return fail(err)
+	}
+
+	logger.Info("processing imported sensor data...")
+	if err := apparmor.GenProfile(artifactLocation, imageInspector.AppArmorProfileName); err != nil {
+		return err
+	}
+
+	if err := seccomp.GenProfile(artifactLocation, imageInspector.SeccompProfileName); err != nil {
+		return err
+	}
+
+	if err := capabilities.GenProfile(artifactLocation, imageInspector.CapabilitiesName); err != nil {
+		return err
+	}
+
+	cmdReport.SensorDataImport = dataPath
+	xc.Out.Info("sensor.data.import",
+		ovars{
+			"path":  dataPath,
+			"files": len(creport.Image.Files),
+		})
+
+	return nil
+}
+
+// importSensorDataDir copies the container report and archives the file artifacts
+// from the sensor artifact directory
+func importSensorDataDir(dirPath, creportPath, filesOutPath string) error {
+	srcReportPath := filepath.Join(dirPath, report.DefaultContainerReportFileName)
+	if !fsutil.IsRegularFile(srcReportPath) {
+		return ErrNoSensorReport
+	}
+
+	if err := fsutil.CopyRegularFile(false, srcReportPath, creportPath, true); err != nil {
+		return err
+	}
+
+	//the archived file artifacts have the same 'files/' prefix the files copied from the container have
+	return fsutil.ArchiveDir(filesOutPath,
+		filepath.Join(dirPath, sensor.FileArtifactsDirName),
+		filepath.Clean(dirPath)+"/",
+		"")
+}
+
+// importSensorDataArchive extracts the container report and the file artifacts
+// from the sensor artifact directory tar archive
+func importSensorDataArchive(archivePath, creportPath, filesOutPath string) error {
+	inFile, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer inFile.Close()
+
+	outFile, err := os.Create(filesOutPath)
+	if err != nil {
+		return err
+	}
+
+	defer outFile.Close()
+
+	hasReport := false
+	tw := tar.NewWriter(outFile)
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		name := sensorDataArchiveName(hdr.Name)
+		switch {
+		case name == report.DefaultContainerReportFileName && hdr.Typeflag == tar.TypeReg:
+			reportFile, err := os.Create(creportPath)
+			if err != nil {
+				return err
+			}
+
+			_, err = fsutil.CopyStream(reportFile, tr)
+			reportFile.Close()
+			if err != nil {
+				return err
+			}
+
+			hasReport = true
+		case strings.HasPrefix(name, sensor.FileArtifactsPrefix):
+			hdr.Name = name
+			if hdr.Typeflag == tar.TypeLink {
+				hdr.Linkname = sensorDataArchiveName(hdr.Linkname)
+			}
+
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+
+			if _, err := fsutil.CopyStream(tw, tr); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if !hasReport {
+		return ErrNoSensorReport
+	}
+
+	return nil
+}
+
+// sensorDataArchiveName returns the archive path relative to the sensor artifact directory
+// ('docker cp' archives have the artifact directory name as the top level directory)
+func sensorDataArchiveName(name string) string {
+	name = strings.TrimPrefix(name, "./")
+	if name == report.DefaultContainerReportFileName ||
+		name == sensor.FileArtifactsDirName ||
+		strings.HasPrefix(name, sensor.FileArtifactsPrefix) {
+		return name
+	}
+
+	if idx := strings.Index(name, "/"); idx >= 0 {
+		return name[idx+1:]
+	}
+
+	return name
+}
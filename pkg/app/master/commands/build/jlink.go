@@ -0,0 +1,565 @@
+package build
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	jlinkWorkDir        = "tmp/docker-slim-jlink"
+	jlinkScriptName     = "jlink.sh"
+	jlinkFilesDirName   = "files"
+	jlinkModulesName    = "modules"
+	jlinkRuntimeDirName = "jre"
+	jlinkAddModulesEnv  = "DSLIM_JLINK_ADD_MODULES"
+
+	//the JDK 9+ runtime image has the modules in one jimage file (file level slimming always keeps all of them)
+	javaModulesImagePath = "lib/modules"
+	javaLauncherPath     = "bin/java"
+)
+
+// the modules jdeps can't detect (they are loaded by the JDK service providers)
+var defaultJLinkModules = []string{"jdk.crypto.ec"}
+
+var ErrNoJavaRuntime = errors.New("no Java runtime (JDK 9+) in the file artifacts")
+
+// jlinkScript detects the app modules with jdeps and creates the minimal runtime with jlink.
+// The Spring Boot and WAR archives are extracted, so jdeps sees the nested jars and classes.
+const jlinkScript = `set -e
+W=/` + jlinkWorkDir + `
+cd "$W"
+
+JLINK=$(command -v jlink || true)
+if [ -z "$JLINK" ] && [ -n "$JAVA_HOME" ]; then
+  JLINK="$JAVA_HOME/bin/jlink"
+fi
+
+if [ -z "$JLINK" ] || [ ! -x "$JLINK" ]; then
+  echo "jlink not found (use a JDK 9+ image with jlink)" >&2
+  exit 2
+fi
+
+JLINK=$(readlink -f "$JLINK" 2>/dev/null || echo "$JLINK")
+JDK_BIN=$(dirname "$JLINK")
+
+: > inputs.list
+n=0
+for jar in $(find ` + jlinkFilesDirName + ` -type f -name '*.jar'); do
+  n=$((n+1))
+  if "$JDK_BIN/jar" tf "$jar" 2>/dev/null | grep -q -E '^(BOOT-INF|WEB-INF)/'; then
+    mkdir -p "nested/$n"
+    (cd "nested/$n" && "$JDK_BIN/jar" xf "$W/$jar") || true
+  else
+    echo "$jar" >> inputs.list
+  fi
+done
+
+if [ -d nested ]; then
+  find nested -type f -name '*.jar' >> inputs.list
+  find nested -type d \( -path '*/BOOT-INF/classes' -o -path '*/WEB-INF/classes' \) >> inputs.list
+fi
+
+find ` + jlinkFilesDirName + ` -type f -name '*.class' >> inputs.list
+
+VERSION=$("$JDK_BIN/java" -XshowSettings:properties -version 2>&1 | sed -n 's/^ *java.specification.version = //p')
+MODULES=""
+if [ -s inputs.list ]; then
+  MODULES=$("$JDK_BIN/jdeps" -q --ignore-missing-deps --multi-release "$VERSION" --print-module-deps $(cat inputs.list))
+fi
+
+AVAILABLE=$("$JDK_BIN/java" --list-modules | sed 's/@.*//')
+for module in $(echo "$` + jlinkAddModulesEnv + `" | tr ',' ' '); do
+  if echo "$AVAILABLE" | grep -q -x "$module"; then
+    MODULES="$MODULES,$module"
+  else
+    echo "skipping unknown module: $module" >&2
+  fi
+done
+
+MODULES=$(echo "$MODULES" | sed 's/^,*//')
+if [ -z "$MODULES" ]; then
+  MODULES=java.base
+fi
+
+echo "modules: $MODULES"
+"$JLINK" --add-modules "$MODULES" --strip-debug --no-man-pages --no-header-files --output ` + jlinkRuntimeDirName + `
+echo "$MODULES" > ` + jlinkModulesName + `
+`
+
+// minimizeJavaRuntime replaces the Java runtime in the file artifacts with the minimal runtime created with jlink
+// (the runtime has only the JDK modules used by the jar and class files the app loaded during profiling).
+// The original runtime is kept if the minimal runtime can't be created.
+func minimizeJavaRuntime(
+	xc *app.ExecutionContext,
+	opts config.AppJavaRuntimeOptions,
+	imageInspector *image.Inspector,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	//the target image is used by its ID (the target image reference might not be local)
+	jlinkImage := opts.JLinkImage
+	info := &report.JavaRuntimeInfo{
+		JLinkImage: opts.JLinkImage,
+	}
+
+	if jlinkImage == "" {
+		jlinkImage = imageInspector.ImageInfo.ID
+		info.JLinkImage = imageInspector.ImageRef
+	}
+
+	cmdReport.JavaRuntime = info
+
+	fail := func(status string, err error) {
+		logger.Debugf("minimizeJavaRuntime: %s - %v", status, err)
+		info.Status = status
+		info.Error = err.Error()
+		xc.Out.Info("java.runtime",
+			ovars{
+				"status":  status,
+				"error":   err.Error(),
+				"message": "keeping the original Java runtime",
+			})
+	}
+
+	xc.Out.State("java.runtime.jlink.start")
+	defer xc.Out.State("java.runtime.jlink.done")
+
+	tarPath := filepath.Join(imageInspector.ArtifactLocation, fileArtifactsTar)
+	if !fsutil.IsRegularFile(tarPath) {
+		fail("error", ErrNoFileArtifactsTar)
+		return
+	}
+
+	artifacts, err := listTarHeaders(tarPath)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	var imageEnv []string
+	if imageInspector.ImageInfo.Config != nil {
+		imageEnv = imageInspector.ImageInfo.Config.Env
+	}
+
+	javaHome := findJavaHome(artifacts, imageEnv)
+	if javaHome == "" {
+		fail("no.java.runtime", ErrNoJavaRuntime)
+		return
+	}
+
+	info.JavaHome = "/" + javaHome
+
+	var appFiles []string
+	for name, hdr := range artifacts {
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch {
+		case isPathInDir(name, javaHome):
+			info.OriginalSize += hdr.Size
+		case strings.HasSuffix(name, ".jar"), strings.HasSuffix(name, ".class"):
+			appFiles = append(appFiles, name)
+		}
+	}
+
+	sort.Strings(appFiles)
+	info.AppFileCount = len(appFiles)
+
+	if opts.JLinkImage != "" {
+		jlinkInspector, err := image.NewInspector(client, opts.JLinkImage)
+		if err != nil {
+			fail("error", err)
+			return
+		}
+
+		if jlinkInspector.NoImage() {
+			if err := jlinkInspector.Pull(xc.Context, false, "", "", ""); err != nil {
+				fail("jlink.image.pull.error", err)
+				return
+			}
+		}
+	}
+
+	modules := append(append([]string{}, defaultJLinkModules...), opts.AddModules...)
+	runtimeTar, modulesData, output, err := runJLink(xc, client, jlinkImage, tarPath, appFiles, modules)
+	if err != nil {
+		if output != "" {
+			xc.Out.LogDump("java.runtime.jlink", output,
+				ovars{
+					"image": jlinkImage,
+				})
+		}
+
+		fail("jlink.error", err)
+		return
+	}
+
+	defer os.Remove(runtimeTar)
+
+	logger.Tracef("minimizeJavaRuntime: jlink output - %s", output)
+
+	size, err := replaceArtifactDir(tarPath, javaHome, runtimeTar, jlinkRuntimeDirName)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	info.Status = "ok"
+	info.Size = size
+	info.Modules = strings.Split(strings.TrimSpace(modulesData), ",")
+
+	xc.Out.Info("java.runtime",
+		ovars{
+			"status":              info.Status,
+			"java.home":           info.JavaHome,
+			"app.files":           info.AppFileCount,
+			"modules":             strings.Join(info.Modules, ","),
+			"original.size.human": humanize.Bytes(uint64(info.OriginalSize)),
+			"size.human":          humanize.Bytes(uint64(info.Size)),
+		})
+}
+
+// findJavaHome returns the Java runtime directory (relative to the file artifacts root)
+// (JAVA_HOME is used if there's more than one runtime)
+func findJavaHome(artifacts map[string]*tar.Header, env []string) string {
+	var homes []string
+	for name, hdr := range artifacts {
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(name, "/"+javaModulesImagePath) {
+			continue
+		}
+
+		home := strings.TrimSuffix(name, "/"+javaModulesImagePath)
+		if _, found := artifacts[path.Join(home, javaLauncherPath)]; found {
+			homes = append(homes, home)
+		}
+	}
+
+	if len(homes) == 0 {
+		return ""
+	}
+
+	sort.Strings(homes)
+	for _, envVar := range env {
+		if strings.HasPrefix(envVar, "JAVA_HOME=") {
+			javaHome := strings.Trim(strings.TrimPrefix(envVar, "JAVA_HOME="), "/")
+			for _, home := range homes {
+				if home == javaHome {
+					return home
+				}
+			}
+		}
+	}
+
+	return homes[0]
+}
+
+// runJLink runs jdeps and jlink in a temporary container
+// and returns the runtime archive path, the selected modules and the script output
+func runJLink(
+	xc *app.ExecutionContext,
+	client *dockerapi.Client,
+	jlinkImage string,
+	tarPath string,
+	appFiles []string,
+	modules []string) (string, string, string, error) {
+	containerInfo, err := client.CreateContainer(dockerapi.CreateContainerOptions{
+		Config: &dockerapi.Config{
+			Image:      jlinkImage,
+			Entrypoint: []string{"/bin/sh", "/" + path.Join(jlinkWorkDir, jlinkScriptName)},
+			Env:        []string{fmt.Sprintf("%s=%s", jlinkAddModulesEnv, strings.Join(modules, ","))},
+			User:       "0",
+		},
+	})
+	if err != nil {
+		return "", "", "", err
+	}
+
+	defer removeInactiveContainer(client, containerInfo.ID)
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeJLinkInputs(writer, tarPath, appFiles))
+	}()
+
+	err = client.UploadToContainer(containerInfo.ID, dockerapi.UploadToContainerOptions{
+		InputStream: reader,
+		Path:        "/",
+	})
+	reader.Close()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if err := client.StartContainer(containerInfo.ID, nil); err != nil {
+		return "", "", "", err
+	}
+
+	exitCode, err := client.WaitContainerWithContext(containerInfo.ID, xc.Context)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var output bytes.Buffer
+	err = client.Logs(dockerapi.LogsOptions{
+		Container:    containerInfo.ID,
+		OutputStream: &output,
+		ErrorStream:  &output,
+		Stdout:       true,
+		Stderr:       true,
+	})
+	if err != nil {
+		log.Debugf("runJLink: error getting container logs - %v", err)
+	}
+
+	if exitCode != 0 {
+		return "", "", output.String(), fmt.Errorf("jlink container exit code - %d", exitCode)
+	}
+
+	var modulesData bytes.Buffer
+	err = client.DownloadFromContainer(containerInfo.ID, dockerapi.DownloadFromContainerOptions{
+		Path:         "/" + path.Join(jlinkWorkDir, jlinkModulesName),
+		OutputStream: &modulesData,
+	})
+	if err != nil {
+		return "", "", output.String(), err
+	}
+
+	tr := tar.NewReader(&modulesData)
+	if _, err := tr.Next(); err != nil {
+		return "", "", output.String(), err
+	}
+
+	selectedModules, err := ioutil.ReadAll(tr)
+	if err != nil {
+		return "", "", output.String(), err
+	}
+
+	runtimeFile, err := ioutil.TempFile(filepath.Dir(tarPath), "jre.*.tar")
+	if err != nil {
+		return "", "", output.String(), err
+	}
+
+	err = client.DownloadFromContainer(containerInfo.ID, dockerapi.DownloadFromContainerOptions{
+		Path:         "/" + path.Join(jlinkWorkDir, jlinkRuntimeDirName),
+		OutputStream: runtimeFile,
+	})
+	runtimeFile.Close()
+	if err != nil {
+		os.Remove(runtimeFile.Name())
+		return "", "", output.String(), err
+	}
+
+	return runtimeFile.Name(), string(selectedModules), output.String(), nil
+}
+
+// writeJLinkInputs writes the jlink script and the app jar and class files (from the file artifacts) to the archive
+func writeJLinkInputs(w io.Writer, tarPath string, appFiles []string) error {
+	selected := map[string]struct{}{}
+	for _, name := range appFiles {
+		selected[name] = struct{}{}
+	}
+
+	tw := tar.NewWriter(w)
+	err := tw.WriteHeader(&tar.Header{
+		Name:     path.Join(jlinkWorkDir, jlinkScriptName),
+		Mode:     0755,
+		Size:     int64(len(jlinkScript)),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(tw, jlinkScript); err != nil {
+		return err
+	}
+
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+
+	defer inFile.Close()
+
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, found := selected[strings.TrimSuffix(hdr.Name, "/")]; !found {
+			continue
+		}
+
+		fileHdr := *hdr
+		fileHdr.Name = path.Join(jlinkWorkDir, jlinkFilesDirName, hdr.Name)
+		fileHdr.Mode = 0644
+		fileHdr.Uid = 0
+		fileHdr.Gid = 0
+		if err := tw.WriteHeader(&fileHdr); err != nil {
+			return err
+		}
+
+		if _, err := fsutil.CopyStream(tw, tr); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// replaceArtifactDir replaces the directory objects in the file artifacts archive
+// with the objects from the replacement archive (the replacement archive objects are in the 'prefix' directory)
+// and returns the size of the new directory files
+func replaceArtifactDir(tarPath, dir, replacementPath, prefix string) (int64, error) {
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return 0, err
+	}
+
+	defer inFile.Close()
+
+	replacementFile, err := os.Open(replacementPath)
+	if err != nil {
+		return 0, err
+	}
+
+	defer replacementFile.Close()
+
+	tmpPath := tarPath + ".tmp"
+	outFile, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+
+	defer os.Remove(tmpPath)
+
+	tw := tar.NewWriter(outFile)
+	copyObjects := func(tr *tar.Reader, rename func(hdr *tar.Header) bool) (int64, error) {
+		var size int64
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return size, nil
+			}
+
+			if err != nil {
+				return size, err
+			}
+
+			if !rename(hdr) {
+				continue
+			}
+
+			if err := tw.WriteHeader(hdr); err != nil {
+				return size, err
+			}
+
+			if hdr.Typeflag == tar.TypeReg {
+				size += hdr.Size
+			}
+
+			if _, err := fsutil.CopyStream(tw, tr); err != nil {
+				return size, err
+			}
+		}
+	}
+
+	//the original directory objects are skipped (the directory itself is replaced too)
+	_, err = copyObjects(tar.NewReader(inFile), func(hdr *tar.Header) bool {
+		name := strings.TrimSuffix(hdr.Name, "/")
+		return name != dir && !isPathInDir(name, dir)
+	})
+	if err != nil {
+		outFile.Close()
+		return 0, err
+	}
+
+	size, err := copyObjects(tar.NewReader(replacementFile), func(hdr *tar.Header) bool {
+		hdr.Name = replacePathPrefix(hdr.Name, prefix, dir)
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = replacePathPrefix(hdr.Linkname, prefix, dir)
+		}
+
+		return true
+	})
+	if err != nil {
+		outFile.Close()
+		return 0, err
+	}
+
+	if err := tw.Close(); err != nil {
+		outFile.Close()
+		return 0, err
+	}
+
+	if err := outFile.Close(); err != nil {
+		return 0, err
+	}
+
+	return size, os.Rename(tmpPath, tarPath)
+}
+
+// listTarHeaders returns the archive object headers (by the object name without the trailing slash)
+func listTarHeaders(tarPath string) (map[string]*tar.Header, error) {
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer inFile.Close()
+
+	headers := map[string]*tar.Header{}
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return headers, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		headers[strings.TrimSuffix(hdr.Name, "/")] = hdr
+	}
+}
+
+func isPathInDir(name, dir string) bool {
+	return strings.HasPrefix(name, dir+"/")
+}
+
+// replacePathPrefix replaces the first path element (the prefix) with the new prefix
+func replacePathPrefix(name, prefix, newPrefix string) string {
+	if name == prefix || strings.HasPrefix(name, prefix+"/") {
+		return newPrefix + strings.TrimPrefix(name, prefix)
+	}
+
+	return name
+}
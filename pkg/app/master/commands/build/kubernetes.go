@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/cache"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
@@ -63,12 +64,16 @@ func newKubeHandler(
 	}
 }
 
+const kubePartialPlanReason = "the container overrides and the image metadata instructions (--new-* flags) are not supported in the Kubernetes mode yet (the plan metadata changes don't include them)"
+
 type kubeHandleOptions struct {
 	DoPull                    bool
 	DoShowPullLogs            bool
 	DoShowBuildLogs           bool
 	DoShowContainerLogs       bool
 	DoDeleteFatImage          bool
+	DoDryRun                  bool
+	SizePolicy                *config.SizePolicy
 	DoRmFileArtifacts         bool
 	RtaOnbuildBaseImage       bool
 	RtaSourcePT               bool
@@ -79,12 +84,15 @@ type kubeHandleOptions struct {
 	PathPerms                 map[string]*fsutil.AccessInfo
 	ArchiveState              string
 	StatePath                 string
+	ResultCache               *cache.Store
 	CopyMetaArtifactsLocation string
 	Debug                     bool
 	LogLevel                  string
 	LogFormat                 string
 	SensorIPCEndpoint         string
 	CBOpts                    *config.ContainerBuildOptions
+	BuildEngineOpts           *config.ImageBuildEngineOptions
+	Telemetry                 *commands.Telemetry
 
 	CustomImageTag string
 	AdditionalTags []string
@@ -102,14 +110,19 @@ func (h *kubeHandler) Handle(
 	targetOverride config.KubernetesTargetOverride,
 	manifests *kubernetes.Manifests,
 	opts kubeHandleOptions,
-) {
+) error {
 	// 1. Pre-process the workload
 	//    - find the running workload in the cluster
 	//    - ...or in the supplied manifest
-	workload := h.findWorkloadOrFail(target)
+	workload, err := h.findWorkload(target)
+	if err != nil {
+		return err
+	}
 
 	if manifests != nil {
-		h.applyManifestsOrFail(manifests, workload)
+		if err := h.applyManifests(manifests, workload); err != nil {
+			return err
+		}
 	}
 
 	// 2. Inspect the workload's original image.
@@ -120,7 +133,7 @@ func (h *kubeHandler) Handle(
 			targetOverride.Image)
 	}
 
-	imageInspector, _, statePath, stateKey := inspectFatImage(
+	imageInspector, _, statePath, stateKey, err := inspectFatImage(
 		h.ExecutionContext,
 		workload.TargetContainer().Image,
 		opts.DoPull,
@@ -130,9 +143,19 @@ func (h *kubeHandler) Handle(
 		opts.RegistryAccount,
 		opts.RegistrySecret,
 		opts.StatePath,
+		opts.ResultCache,
+		opts.Telemetry,
 		h.dockerClient,
 		h.logger,
 		h.report)
+	if err != nil {
+		return err
+	}
+
+	if err := checkImageBuildPlatforms(h.ExecutionContext, opts.BuildEngineOpts, imageInspector, h.report); err != nil {
+		return err
+	}
+
 	workload.TargetContainer().Image = imageInspector.ImageRef
 
 	// 3. Patch and run the workload
@@ -160,7 +183,9 @@ func (h *kubeHandler) Handle(
 		opts.PortBindings,
 		opts.DoPublishExposedPorts,
 	)
-	h.FailOn(err)
+	if err != nil {
+		return err
+	}
 
 	h.AddCleanupHandler(func() {
 		podInspector.FinishMonitoring()
@@ -172,7 +197,10 @@ func (h *kubeHandler) Handle(
 	if err != nil && opts.DoShowContainerLogs {
 		podInspector.ShowPodLogs()
 	}
-	h.FailOn(err)
+
+	if err != nil {
+		return app.WrapError(err, app.ErrorCategoryContainer, "pod.run", "")
+	}
 
 	h.Out.Info("pod",
 		ovars{
@@ -182,9 +210,13 @@ func (h *kubeHandler) Handle(
 			"message":          "YOU CAN USE THESE PORTS TO INTERACT WITH THE POD",
 		})
 
+	profileDone := opts.Telemetry.Phase(commands.PhaseProfile)
+
 	// 4. Monitor the workload.
 	h.logger.Info("watching pod monitor...")
-	h.monitorPod(opts, podInspector)
+	if err := h.monitorPod(opts, podInspector); err != nil {
+		return err
+	}
 
 	// 5. Copy the artifact from the workload.
 	h.Out.State("pod.inspection.finishing")
@@ -200,9 +232,37 @@ func (h *kubeHandler) Handle(
 	}
 
 	// 7. Build the slim image & create AppArmor and seccomp profiles
-	h.processCollectedDataOrFail(podInspector, imageInspector)
+	if err := h.processCollectedData(podInspector, imageInspector); err != nil {
+		return err
+	}
 
-	minifiedImageName := buildSlimImage(
+	profileDone()
+
+	if opts.DoDryRun {
+		//the Kubernetes mode build doesn't use the container overrides and the image instructions yet,
+		//so the plan has the same metadata changes, but it's marked as partial
+		err := dryRunPostProcess(
+			h.ExecutionContext,
+			opts.CustomImageTag,
+			nil, // TODO: overrrides
+			nil, // TODO: imageOverrideSelectors,
+			nil, // TODO: instructions,
+			kubePartialPlanReason,
+			opts.DoRmFileArtifacts,
+			imageInspector,
+			h.dockerClient,
+			h.logger,
+			h.report)
+		if err != nil {
+			return err
+		}
+
+		opts.Telemetry.Finish(h.report.State)
+		return nil
+	}
+
+	buildDone := opts.Telemetry.Phase(commands.PhaseBuild)
+	minifiedImageName, err := buildSlimImage(
 		h.ExecutionContext,
 		opts.CustomImageTag,
 		opts.AdditionalTags,
@@ -212,27 +272,42 @@ func (h *kubeHandler) Handle(
 		nil, // TODO: instructions,
 		opts.DoDeleteFatImage,
 		opts.DoShowBuildLogs,
+		opts.BuildEngineOpts,
 		imageInspector,
 		h.dockerClient,
 		h.logger,
 		h.report)
+	if err != nil {
+		return err
+	}
 
-	slimmingPostProcess(
+	buildDone()
+
+	err = slimmingPostProcess(
 		h.ExecutionContext,
 		minifiedImageName,
 		opts.CopyMetaArtifactsLocation,
 		opts.DoRmFileArtifacts,
 		opts.ArchiveState,
 		stateKey,
+		opts.SizePolicy,
 		imageInspector,
 		h.dockerClient,
 		h.logger,
 		h.report)
+	if err != nil {
+		return err
+	}
+
+	opts.Telemetry.Finish(h.report.State)
+	return nil
 }
 
-func (h *kubeHandler) findWorkloadOrFail(target config.KubernetesTarget) *kubernetes.Workload {
+func (h *kubeHandler) findWorkload(target config.KubernetesTarget) (*kubernetes.Workload, error) {
 	workload, err := h.finder.Find(target)
-	h.FailOn(err)
+	if err != nil {
+		return nil, err
+	}
 
 	if workload == nil {
 		h.Out.Info("kubernetes.workload.error",
@@ -249,7 +324,7 @@ func (h *kubeHandler) findWorkloadOrFail(target config.KubernetesTarget) *kubern
 				"location":  fsutil.ExeDir(),
 			})
 
-		h.Exit(exitCode)
+		return nil, app.NewExitError(exitCode)
 	}
 
 	if workload.TargetContainer() == nil {
@@ -267,7 +342,7 @@ func (h *kubeHandler) findWorkloadOrFail(target config.KubernetesTarget) *kubern
 				"location":  fsutil.ExeDir(),
 			})
 
-		h.Exit(exitCode)
+		return nil, app.NewExitError(exitCode)
 	}
 
 	h.Out.Info("kubernetes.workload",
@@ -278,13 +353,13 @@ func (h *kubeHandler) findWorkloadOrFail(target config.KubernetesTarget) *kubern
 			"target":    asJSON(workload.TargetContainer()),
 		})
 
-	return workload
+	return workload, nil
 }
 
-func (h *kubeHandler) applyManifestsOrFail(
+func (h *kubeHandler) applyManifests(
 	manifests *kubernetes.Manifests,
 	workload *kubernetes.Workload,
-) {
+) error {
 	h.AddCleanupHandler(func() {
 		errutil.WarnOn(manifests.Delete(h.ctx))
 	})
@@ -294,20 +369,23 @@ func (h *kubeHandler) applyManifestsOrFail(
 		return workload.Info().Mapping.GroupVersionKind.GroupKind() != info.Mapping.GroupVersionKind.GroupKind() ||
 			workload.Name() != info.Name || workload.Namespace() != info.Namespace
 	})
-	h.FailOn(err)
+
+	return err
 }
 
 func (h *kubeHandler) monitorPod(
 	opts kubeHandleOptions,
 	podInspector *pod.Inspector,
-) {
+) error {
 	var probe *http.CustomProbe
 	if opts.httpProbeOpts.Do {
 		var err error
 		probe, err = http.NewPodProbe(h.ExecutionContext, podInspector, opts.httpProbeOpts, true)
-		h.FailOn(err)
+		if err != nil {
+			return err
+		}
 
-		if len(probe.Ports()) == 0 {
+		if len(probe.Ports()) == 0 && len(probe.UDPPorts()) == 0 {
 			h.Out.State("http.probe.error",
 				ovars{
 					"error":   "NO EXPOSED PORTS",
@@ -318,7 +396,7 @@ func (h *kubeHandler) monitorPod(
 			h.Out.State("exited", ovars{"exit.code": exitCode})
 
 			h.report.Error = "no.exposed.ports"
-			h.Exit(exitCode)
+			return app.NewExitError(exitCode)
 		}
 
 		probe.Start()
@@ -376,17 +454,52 @@ func (h *kubeHandler) monitorPod(
 				h.Out.Error("probe.error", "no.successful.calls")
 
 				podInspector.ShowPodLogs()
-				h.Out.State("exited", ovars{"exit.code": -1})
-				h.Exit(-1)
+				h.Out.State("exited", ovars{"exit.code": commands.ECTBuild | ecbNoSuccessfulProbes})
+				return app.NewExitError(commands.ECTBuild | ecbNoSuccessfulProbes)
+			}
+
+			if probe.FailureThresholdExceeded() {
+				h.Out.Error("probe.error", "failure.threshold.exceeded")
+				h.Out.Info("probe.failures",
+					ovars{
+						"commands":        probe.CmdCount,
+						"failed.commands": probe.CmdFailCount,
+						"threshold":       opts.httpProbeOpts.FailThreshold,
+					})
+
+				podInspector.ShowPodLogs()
+				exitCode := commands.ECTBuild | ecbProbeFailureThreshold
+				h.Out.State("exited", ovars{"exit.code": exitCode})
+				return app.NewExitError(exitCode)
 			}
 
+		case config.CAMSession:
+			failCount := commands.RunExecSession(h.ExecutionContext, opts.continueAfter, "",
+				func(command string) (int, error) {
+					out, err := podInspector.Exec("sh", "-c", command)
+					fmt.Printf("%s[session]: output: %s\n", appName, out)
+					if err != nil {
+						//no exit code info for the failed pod execs
+						return 1, nil
+					}
+
+					return 0, nil
+				})
+			h.Out.Info("continue.after", ovars{"mode": config.CAMSession, "failures": failCount})
+
 		default:
-			errutil.Fail("unknown continue-after mode")
+			return commands.UnknownContinueAfterModeError(mode)
 		}
 	}
+
+	if probe != nil {
+		h.report.HTTPProbe = probe.Report()
+	}
+
+	return nil
 }
 
-func (h *kubeHandler) processCollectedDataOrFail(podInspector *pod.Inspector, imageInspector *image.Inspector) {
+func (h *kubeHandler) processCollectedData(podInspector *pod.Inspector, imageInspector *image.Inspector) error {
 	if !podInspector.HasCollectedData() {
 		imageInspector.ShowFatImageDockerInstructions()
 		h.Out.Info("results",
@@ -403,11 +516,11 @@ func (h *kubeHandler) processCollectedDataOrFail(podInspector *pod.Inspector, im
 			})
 
 		h.report.Error = "no.data.collected"
-		h.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
 	h.logger.Info("processing instrumented 'fat' container info...")
-	h.FailOn(podInspector.ProcessCollectedData())
+	return podInspector.ProcessCollectedData()
 }
 
 func asJSON(val interface{}) string {
@@ -0,0 +1,695 @@
+package build
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	layersDirName         = "layers"
+	imageArchiveName      = "image.tar"
+	imageManifestName     = "manifest.json"
+	layerArchiveName      = "layer.tar"
+	digestPrefix          = "sha256:"
+	layeredImageTagLatest = ":latest"
+)
+
+var ErrBadImageArchive = errors.New("bad image archive")
+
+// layerEntry is a filesystem object in an image layer (or in the file artifacts archive)
+type layerEntry struct {
+	Layer    int
+	Type     byte
+	Mode     int64
+	UID      int
+	GID      int
+	Size     int64
+	Linkname string
+	Hash     string
+}
+
+func (e *layerEntry) matches(other *layerEntry) bool {
+	return e.Type == other.Type &&
+		e.Mode == other.Mode &&
+		e.UID == other.UID &&
+		e.GID == other.GID &&
+		e.Size == other.Size &&
+		e.Linkname == other.Linkname &&
+		e.Hash == other.Hash
+}
+
+// preserveImageLayers recreates the optimized image reusing the original image layers
+// (the layers where at least minKeptPercent of the file data is kept are reused as is
+// and the rest of the kept files are added in a new layer on top of them,
+// so the optimized image filesystem stays the same)
+func preserveImageLayers(
+	xc *app.ExecutionContext,
+	minifiedImageName string,
+	additionalTags []string,
+	minKeptPercent int,
+	fatImageID string,
+	artifactLocation string,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	xc.Out.State("layers.preserve.start")
+
+	workDir := filepath.Join(artifactLocation, layersDirName)
+	defer os.RemoveAll(workDir)
+
+	tags := []string{imageTagName(minifiedImageName)}
+	for _, tag := range additionalTags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, imageTagName(tag))
+		}
+	}
+
+	info, err := createLayeredImage(
+		client,
+		fatImageID,
+		minifiedImageName,
+		tags,
+		filepath.Join(artifactLocation, fileArtifactsTar),
+		workDir,
+		minKeptPercent)
+	if err != nil {
+		logger.Errorf("preserveImageLayers: error - %v", err)
+		xc.Out.Info("layers.preserve",
+			ovars{
+				"status":  "error",
+				"error":   err.Error(),
+				"message": "keeping the optimized image with a single layer",
+			})
+		return
+	}
+
+	cmdReport.Layers = info
+	xc.Out.Info("layers.preserve",
+		ovars{
+			"original":       info.OriginalCount,
+			"reused":         info.ReusedCount,
+			"reused.size":    humanize.Bytes(uint64(info.ReusedSize)),
+			"new.layer.size": humanize.Bytes(uint64(info.NewLayerSize)),
+			"new.files":      info.NewFileCount,
+			"whiteouts":      info.Whiteouts,
+		})
+
+	xc.Out.State("layers.preserve.done")
+}
+
+func imageTagName(name string) string {
+	if strings.Contains(name, "@") {
+		return name
+	}
+
+	if strings.LastIndex(name, ":") > strings.LastIndex(name, "/") {
+		return name
+	}
+
+	return name + layeredImageTagLatest
+}
+
+func createLayeredImage(
+	client *dockerapi.Client,
+	fatImageID string,
+	slimImageRef string,
+	tags []string,
+	filesTarPath string,
+	workDir string,
+	minKeptPercent int) (*report.LayerReuseInfo, error) {
+	if _, err := os.Stat(filesTarPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoFileArtifactsTar
+		}
+
+		return nil, err
+	}
+
+	fatDir := filepath.Join(workDir, "fat")
+	slimDir := filepath.Join(workDir, "slim")
+	outDir := filepath.Join(workDir, "out")
+	for _, dir := range []string{fatDir, slimDir, outDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dockerutil.SaveImage(client, fatImageID, filepath.Join(fatDir, imageArchiveName), true, true); err != nil {
+		return nil, err
+	}
+
+	if err := dockerutil.SaveImage(client, slimImageRef, filepath.Join(slimDir, imageArchiveName), true, true); err != nil {
+		return nil, err
+	}
+
+	fatManifest, fatConfig, err := loadImageArchiveMetadata(fatDir)
+	if err != nil {
+		return nil, err
+	}
+
+	_, slimConfig, err := loadImageArchiveMetadata(slimDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fatDiffIDs := configDiffIDs(fatConfig)
+	if len(fatDiffIDs) != len(fatManifest.Layers) {
+		return nil, ErrBadImageArchive
+	}
+
+	//the filesystem view of the original image (path -> the top layer entry)
+	view := map[string]*layerEntry{}
+	layerPaths := make([]map[string]*layerEntry, len(fatManifest.Layers))
+	for idx, layerPath := range fatManifest.Layers {
+		entries, err := scanLayer(filepath.Join(fatDir, layerPath), idx, view)
+		if err != nil {
+			return nil, err
+		}
+
+		layerPaths[idx] = entries
+	}
+
+	kept, err := scanArchive(filesTarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &report.LayerReuseInfo{
+		OriginalCount: len(fatManifest.Layers),
+	}
+
+	//selecting the layers to reuse
+	reused := map[int]bool{}
+	for idx := range fatManifest.Layers {
+		var totalSize, keptSize int64
+		var visibleCount int
+		for p, entry := range layerPaths[idx] {
+			if view[p] != entry {
+				continue
+			}
+
+			visibleCount++
+			totalSize += entry.Size
+			if keptEntry, found := kept[p]; found && keptEntry.matches(entry) {
+				keptSize += entry.Size
+			}
+		}
+
+		if visibleCount == 0 {
+			continue
+		}
+
+		if keptSize*100 >= totalSize*int64(minKeptPercent) {
+			reused[idx] = true
+			info.ReusedCount++
+			info.ReusedSize += totalSize
+			info.ReusedDiffIDs = append(info.ReusedDiffIDs, fatDiffIDs[idx])
+		}
+	}
+
+	if info.ReusedCount == 0 {
+		return nil, fmt.Errorf("no original image layers to reuse (min kept data - %d%%)", minKeptPercent)
+	}
+
+	//the paths provided by the reused layers (the top reused layer entry for each path)
+	reusedView := map[string]*layerEntry{}
+	for idx := range fatManifest.Layers {
+		if !reused[idx] {
+			continue
+		}
+
+		for p, entry := range layerPaths[idx] {
+			reusedView[p] = entry
+		}
+	}
+
+	newLayerPath := filepath.Join(outDir, layerArchiveName)
+	newFiles, whiteouts, err := writeNewLayer(filesTarPath, newLayerPath, kept, reusedView)
+	if err != nil {
+		return nil, err
+	}
+
+	info.NewFileCount = newFiles
+	info.Whiteouts = whiteouts
+
+	newDiffID, newLayerSize, err := fileDigest(newLayerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info.NewLayerSize = newLayerSize
+
+	//the new image archive (in the 'docker save' format)
+	var layers []string
+	var diffIDs []string
+	for idx, layerPath := range fatManifest.Layers {
+		if !reused[idx] {
+			continue
+		}
+
+		name := path.Join(strings.TrimPrefix(fatDiffIDs[idx], digestPrefix), layerArchiveName)
+		if err := linkOrCopyFile(filepath.Join(fatDir, layerPath), filepath.Join(outDir, name)); err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, name)
+		diffIDs = append(diffIDs, fatDiffIDs[idx])
+	}
+
+	name := path.Join(strings.TrimPrefix(newDiffID, digestPrefix), layerArchiveName)
+	if err := os.MkdirAll(filepath.Join(outDir, path.Dir(name)), 0755); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(newLayerPath, filepath.Join(outDir, name)); err != nil {
+		return nil, err
+	}
+
+	layers = append(layers, name)
+	diffIDs = append(diffIDs, newDiffID)
+
+	slimConfig["rootfs"] = map[string]interface{}{
+		"type":     dockerimage.TypeLayers,
+		"diff_ids": diffIDs,
+	}
+	slimConfig["history"] = layeredImageHistory(fatConfig, slimConfig, reused)
+
+	configData, err := json.Marshal(slimConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	configSum := sha256.Sum256(configData)
+	configName := fmt.Sprintf("%x.json", configSum)
+	if err := ioutil.WriteFile(filepath.Join(outDir, configName), configData, 0644); err != nil {
+		return nil, err
+	}
+
+	manifestData, err := json.Marshal([]dockerimage.ManifestObject{
+		{
+			Config:   configName,
+			RepoTags: tags,
+			Layers:   layers,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(outDir, imageManifestName), manifestData, 0644); err != nil {
+		return nil, err
+	}
+
+	oldSlimImage, err := client.InspectImage(slimImageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := imageformat.LoadImageDir(client, outDir); err != nil {
+		return nil, err
+	}
+
+	if oldSlimImage.ID != digestPrefix+fmt.Sprintf("%x", configSum) {
+		if err := client.RemoveImage(oldSlimImage.ID); err != nil {
+			log.Debugf("createLayeredImage: error removing the single layer image (%s) - %v", oldSlimImage.ID, err)
+		}
+	}
+
+	return info, nil
+}
+
+func loadImageArchiveMetadata(dir string) (*dockerimage.ManifestObject, map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, imageManifestName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var manifests []dockerimage.ManifestObject
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, nil, err
+	}
+
+	if len(manifests) == 0 {
+		return nil, nil, ErrBadImageArchive
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, manifests[0].Config))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//using a generic map to keep all config fields when the config is updated
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, err
+	}
+
+	return &manifests[0], config, nil
+}
+
+func configDiffIDs(config map[string]interface{}) []string {
+	rootfs, ok := config["rootfs"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	values, ok := rootfs["diff_ids"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var diffIDs []string
+	for _, val := range values {
+		if diffID, ok := val.(string); ok {
+			diffIDs = append(diffIDs, diffID)
+		}
+	}
+
+	return diffIDs
+}
+
+// layeredImageHistory returns the original history records for the reused layers
+// and the optimized image history records (including the record for the new layer)
+func layeredImageHistory(fatConfig, slimConfig map[string]interface{}, reused map[int]bool) []interface{} {
+	var history []interface{}
+	fatHistory, _ := fatConfig["history"].([]interface{})
+	layerIdx := 0
+	for _, record := range fatHistory {
+		fields, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if isEmpty, _ := fields["empty_layer"].(bool); isEmpty {
+			continue
+		}
+
+		if reused[layerIdx] {
+			history = append(history, record)
+		}
+
+		layerIdx++
+	}
+
+	slimHistory, _ := slimConfig["history"].([]interface{})
+	return append(history, slimHistory...)
+}
+
+// scanLayer records the layer objects (and updates the filesystem view with the layer changes)
+func scanLayer(layerPath string, layerIdx int, view map[string]*layerEntry) (map[string]*layerEntry, error) {
+	entries := map[string]*layerEntry{}
+	err := readArchive(layerPath, func(hdr *tar.Header, reader io.Reader) error {
+		p := path.Clean("/" + hdr.Name)
+		dir, base := path.Split(p)
+		switch {
+		case base == dockerimage.WhiteoutOpaqueDir:
+			//the lower layer objects in the directory are hidden
+			removeViewPaths(view, path.Clean(dir), layerIdx, false)
+			return nil
+		case strings.HasPrefix(base, dockerimage.WhiteoutPrefix):
+			removeViewPaths(view, path.Join(dir, strings.TrimPrefix(base, dockerimage.WhiteoutPrefix)), layerIdx, true)
+			return nil
+		}
+
+		entry, err := newLayerEntry(hdr, reader)
+		if err != nil {
+			return err
+		}
+
+		entry.Layer = layerIdx
+		entries[p] = entry
+		view[p] = entry
+		return nil
+	})
+
+	return entries, err
+}
+
+func removeViewPaths(view map[string]*layerEntry, target string, layerIdx int, includeTarget bool) {
+	prefix := target + "/"
+	if target == "/" {
+		prefix = "/"
+	}
+
+	for p, entry := range view {
+		if entry.Layer >= layerIdx {
+			continue
+		}
+
+		if (includeTarget && p == target) || strings.HasPrefix(p, prefix) {
+			delete(view, p)
+		}
+	}
+}
+
+// scanArchive records the file artifacts archive objects
+func scanArchive(archivePath string) (map[string]*layerEntry, error) {
+	entries := map[string]*layerEntry{}
+	err := readArchive(archivePath, func(hdr *tar.Header, reader io.Reader) error {
+		entry, err := newLayerEntry(hdr, reader)
+		if err != nil {
+			return err
+		}
+
+		entry.Layer = -1
+		entries[path.Clean("/"+hdr.Name)] = entry
+		return nil
+	})
+
+	return entries, err
+}
+
+func newLayerEntry(hdr *tar.Header, reader io.Reader) (*layerEntry, error) {
+	entry := &layerEntry{
+		Type:     hdr.Typeflag,
+		Mode:     hdr.Mode,
+		UID:      hdr.Uid,
+		GID:      hdr.Gid,
+		Linkname: hdr.Linkname,
+	}
+
+	if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+		entry.Type = tar.TypeReg
+		entry.Size = hdr.Size
+		hasher := sha256.New()
+		if _, err := fsutil.CopyStream(hasher, reader); err != nil {
+			return nil, err
+		}
+
+		entry.Hash = hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	return entry, nil
+}
+
+// readArchive calls the handler for each archive object (the archive can be compressed)
+func readArchive(archivePath string, handler func(hdr *tar.Header, reader io.Reader) error) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	var reader io.Reader = bufio.NewReader(file)
+	if magic, err := reader.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+
+		defer gzReader.Close()
+		reader = gzReader
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := handler(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// writeNewLayer writes the kept objects that are not provided by the reused layers
+// and the whiteouts for the reused layer objects that are not kept
+func writeNewLayer(
+	filesTarPath string,
+	layerPath string,
+	kept map[string]*layerEntry,
+	reusedView map[string]*layerEntry) (int, int, error) {
+	included := map[string]bool{}
+	for p, entry := range kept {
+		if reusedEntry, found := reusedView[p]; !found || !reusedEntry.matches(entry) {
+			included[p] = true
+		}
+	}
+
+	//the hard link targets need to be in the same layer
+	for p, entry := range kept {
+		if included[p] && entry.Type == tar.TypeLink {
+			included[path.Clean("/"+entry.Linkname)] = true
+		}
+	}
+
+	outFile, err := os.Create(layerPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer outFile.Close()
+
+	tw := tar.NewWriter(outFile)
+	var fileCount int
+	err = readArchive(filesTarPath, func(hdr *tar.Header, reader io.Reader) error {
+		if !included[path.Clean("/"+hdr.Name)] {
+			return nil
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err := fsutil.CopyStream(tw, reader); err != nil {
+			return err
+		}
+
+		fileCount++
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	whiteoutPaths := layerWhiteouts(kept, reusedView)
+	for _, p := range whiteoutPaths {
+		dir, base := path.Split(p)
+		hdr := &tar.Header{
+			Name:     strings.TrimPrefix(path.Join(dir, dockerimage.WhiteoutPrefix+base), "/"),
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, 0, err
+	}
+
+	return fileCount, len(whiteoutPaths), nil
+}
+
+// layerWhiteouts returns the reused layer paths to hide
+// (only the top level paths are returned when the nested paths are not kept either)
+func layerWhiteouts(kept map[string]*layerEntry, reusedView map[string]*layerEntry) []string {
+	keptDirs := map[string]bool{}
+	for p := range kept {
+		for dir := path.Dir(p); dir != "/"; dir = path.Dir(dir) {
+			keptDirs[dir] = true
+		}
+	}
+
+	removable := func(p string) bool {
+		_, isKept := kept[p]
+		_, isReused := reusedView[p]
+		return isReused && !isKept && !keptDirs[p]
+	}
+
+	var whiteouts []string
+	for p := range reusedView {
+		if p == "/" || !removable(p) {
+			continue
+		}
+
+		if parent := path.Dir(p); parent != "/" && removable(parent) {
+			continue
+		}
+
+		whiteouts = append(whiteouts, p)
+	}
+
+	sort.Strings(whiteouts)
+	return whiteouts
+}
+
+func fileDigest(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := fsutil.CopyStream(hasher, file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return digestPrefix + hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+func linkOrCopyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fsutil.CopyStream(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,421 @@
+package build
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	nodeModulesDirName      = "node_modules"
+	nodePackageFileName     = "package.json"
+	nodeModulesBinDirName   = ".bin"
+	nodeScopedPackagePrefix = "@"
+)
+
+var ErrNoNodePackages = errors.New("no Node.js packages (node_modules) in the file artifacts")
+
+// nodePackageMetadata contains the package.json fields used to find the package dependencies
+type nodePackageMetadata struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+}
+
+// nodePackage is an installed package (a node_modules directory package)
+type nodePackage struct {
+	name     string
+	dir      string
+	metadata *nodePackageMetadata
+	used     bool
+	named    bool
+	kept     bool
+	dev      bool
+}
+
+// pruneNodePackages removes the installed Node.js packages the app didn't require or import during profiling
+// (the dev dependencies are removed too). The declared dependencies of the app and the used packages are kept
+// by default, so the packages loaded with dynamic require/import calls in the code paths the profiling didn't cover still work.
+// The file artifacts are not changed if the Node.js packages can't be analyzed.
+func pruneNodePackages(
+	xc *app.ExecutionContext,
+	opts config.AppNodejsPackageOptions,
+	artifactLocation string,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	info := &report.NodePackagesInfo{}
+	cmdReport.NodePackages = info
+
+	fail := func(status string, err error) {
+		logger.Debugf("pruneNodePackages: %s - %v", status, err)
+		info.Status = status
+		info.Error = err.Error()
+		xc.Out.Info("node.packages",
+			ovars{
+				"status":  status,
+				"error":   err.Error(),
+				"message": "keeping all Node.js packages",
+			})
+	}
+
+	tarPath := filepath.Join(artifactLocation, fileArtifactsTar)
+	if !fsutil.IsRegularFile(tarPath) {
+		fail("error", ErrNoFileArtifactsTar)
+		return
+	}
+
+	observed, err := loadObservedFiles(filepath.Join(artifactLocation, report.DefaultContainerReportFileName))
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	artifacts, err := listTarHeaders(tarPath)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	packages := map[string]*nodePackage{}
+	appDirs := map[string]struct{}{}
+	for name := range artifacts {
+		if dir := nodePackageDir(name); dir != "" {
+			if _, found := packages[dir]; !found {
+				packages[dir] = &nodePackage{
+					name: nodePackageName(dir),
+					dir:  dir,
+				}
+			}
+		}
+
+		if dir := nodeAppDir(name); dir != "" {
+			appDirs[dir] = struct{}{}
+		}
+	}
+
+	if len(packages) == 0 {
+		fail("no.node.packages", ErrNoNodePackages)
+		return
+	}
+
+	var metadataFiles []string
+	for dir := range packages {
+		metadataFiles = append(metadataFiles, path.Join(dir, nodePackageFileName))
+	}
+
+	for dir := range appDirs {
+		metadataFiles = append(metadataFiles, path.Join(dir, nodePackageFileName))
+	}
+
+	metadata, err := loadNodePackageMetadata(tarPath, metadataFiles)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	for dir, pkg := range packages {
+		pkg.metadata = metadata[path.Join(dir, nodePackageFileName)]
+	}
+
+	info.PackageCount = len(packages)
+
+	keep := map[string]struct{}{}
+	for _, name := range opts.KeepPackages {
+		keep[name] = struct{}{}
+	}
+
+	for name := range observed {
+		if pkg := packages[nodePackageDir(name)]; pkg != nil {
+			pkg.used = true
+		}
+	}
+
+	var queue []*nodePackage
+	keepPackage := func(pkg *nodePackage) {
+		if pkg != nil && !pkg.kept {
+			pkg.kept = true
+			queue = append(queue, pkg)
+		}
+	}
+
+	for _, pkg := range packages {
+		if _, found := keep[pkg.name]; found {
+			pkg.named = true
+		}
+
+		if pkg.named || pkg.used {
+			keepPackage(pkg)
+		}
+	}
+
+	//the dev dependencies are only removed if they are not the dependencies of the kept packages
+	for dir := range appDirs {
+		appMetadata := metadata[path.Join(dir, nodePackageFileName)]
+		if appMetadata == nil {
+			continue
+		}
+
+		for name := range appMetadata.DevDependencies {
+			if pkg := resolveNodePackage(dir, name, packages); pkg != nil {
+				pkg.dev = true
+			}
+		}
+
+		if opts.KeepDeclaredDeps {
+			for name := range nodeProdDependencies(appMetadata) {
+				keepPackage(resolveNodePackage(dir, name, packages))
+			}
+		}
+	}
+
+	//the dependency closure for the named packages is always kept
+	//(the declared dependencies of the used packages are kept only in the 'keep declared dependencies' mode)
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		if pkg.metadata == nil || (pkg.used && !pkg.named && !opts.KeepDeclaredDeps) {
+			continue
+		}
+
+		for name := range nodeProdDependencies(pkg.metadata) {
+			keepPackage(resolveNodePackage(pkg.dir, name, packages))
+		}
+	}
+
+	removedPackages := map[string]*report.NodePackageInfo{}
+	for dir, pkg := range packages {
+		if pkg.kept {
+			continue
+		}
+
+		pkgInfo := &report.NodePackageInfo{
+			Name: pkg.name,
+			Path: "/" + dir,
+			Dev:  pkg.dev,
+		}
+
+		if pkg.metadata != nil {
+			pkgInfo.Version = pkg.metadata.Version
+		}
+
+		removedPackages[dir] = pkgInfo
+	}
+
+	//the package directories are removed if they don't have the kept (nested) packages
+	removed := map[string]struct{}{}
+	var removedSize int64
+	for name, hdr := range artifacts {
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		pkgInfo, found := removedPackages[nodePackageDir(name)]
+		if !found {
+			//the package command links (the .bin directory symlinks) for the removed packages
+			pkgInfo, found = removedPackages[nodePackageDir(nodeBinLinkTarget(name, hdr))]
+			if !found {
+				continue
+			}
+		}
+
+		removed[name] = struct{}{}
+		if hdr.Typeflag == tar.TypeReg {
+			pkgInfo.FileCount++
+			pkgInfo.Size += hdr.Size
+			removedSize += hdr.Size
+		}
+	}
+
+	for _, dir := range findEmptyParentDirs(artifacts, removed, func(dir string) bool {
+		return strings.Contains("/"+dir+"/", "/"+nodeModulesDirName+"/")
+	}) {
+		removed[dir] = struct{}{}
+	}
+
+	if len(removed) > 0 {
+		if err := removeArtifactPaths(tarPath, removed); err != nil {
+			fail("error", err)
+			return
+		}
+	}
+
+	for _, pkgInfo := range removedPackages {
+		info.RemovedPackages = append(info.RemovedPackages, pkgInfo)
+		if pkgInfo.Dev {
+			info.RemovedDevPackages++
+		}
+	}
+
+	sort.Slice(info.RemovedPackages, func(i, j int) bool {
+		return info.RemovedPackages[i].Path < info.RemovedPackages[j].Path
+	})
+
+	info.Status = "ok"
+	info.RemovedSize = removedSize
+
+	xc.Out.Info("node.packages",
+		ovars{
+			"status":               info.Status,
+			"packages":             info.PackageCount,
+			"removed.packages":     len(info.RemovedPackages),
+			"removed.dev.packages": info.RemovedDevPackages,
+			"removed.size.human":   humanize.Bytes(uint64(info.RemovedSize)),
+		})
+}
+
+// nodePackageDir returns the (innermost) package directory for a file artifact
+// (e.g., 'app/node_modules/@scope/pkg' for 'app/node_modules/@scope/pkg/lib/index.js')
+func nodePackageDir(name string) string {
+	parts := strings.Split(name, "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] != nodeModulesDirName {
+			continue
+		}
+
+		pkgName := parts[i+1]
+		if strings.HasPrefix(pkgName, ".") || pkgName == nodeModulesDirName {
+			return ""
+		}
+
+		end := i + 2
+		if strings.HasPrefix(pkgName, nodeScopedPackagePrefix) {
+			if len(parts) < i+3 {
+				return ""
+			}
+
+			end = i + 3
+		}
+
+		return path.Join(parts[:end]...)
+	}
+
+	return ""
+}
+
+// nodePackageName returns the package name for a package directory
+func nodePackageName(dir string) string {
+	parent, name := path.Split(dir)
+	if scope := path.Base(parent); strings.HasPrefix(scope, nodeScopedPackagePrefix) {
+		return scope + "/" + name
+	}
+
+	return name
+}
+
+// nodeAppDir returns the app directory (the directory with the top level node_modules directory) for a file artifact
+func nodeAppDir(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		if part == nodeModulesDirName {
+			if i == 0 {
+				return "."
+			}
+
+			return path.Join(parts[:i]...)
+		}
+	}
+
+	return ""
+}
+
+// nodeBinLinkTarget returns the symlink target for a package command link (in the node_modules/.bin directory)
+func nodeBinLinkTarget(name string, hdr *tar.Header) string {
+	if hdr.Typeflag != tar.TypeSymlink || path.Base(path.Dir(name)) != nodeModulesBinDirName {
+		return ""
+	}
+
+	if path.IsAbs(hdr.Linkname) {
+		return strings.TrimPrefix(path.Clean(hdr.Linkname), "/")
+	}
+
+	return path.Join(path.Dir(name), hdr.Linkname)
+}
+
+// resolveNodePackage finds the package the way the Node.js module resolution does
+// (checking the node_modules directories from the package directory to the root directory)
+func resolveNodePackage(fromDir, name string, packages map[string]*nodePackage) *nodePackage {
+	for dir := fromDir; ; dir = path.Dir(dir) {
+		if path.Base(dir) != nodeModulesDirName {
+			if pkg, found := packages[path.Join(dir, nodeModulesDirName, name)]; found {
+				return pkg
+			}
+		}
+
+		if dir == "." || dir == "/" || dir == "" {
+			return nil
+		}
+	}
+}
+
+func nodeProdDependencies(metadata *nodePackageMetadata) map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, deps := range []map[string]string{
+		metadata.Dependencies,
+		metadata.OptionalDependencies,
+		metadata.PeerDependencies,
+	} {
+		for name := range deps {
+			names[name] = struct{}{}
+		}
+	}
+
+	return names
+}
+
+// loadNodePackageMetadata loads the package.json files (the files that can't be parsed are ignored)
+func loadNodePackageMetadata(tarPath string, files []string) (map[string]*nodePackageMetadata, error) {
+	selected := map[string]struct{}{}
+	for _, name := range files {
+		selected[name] = struct{}{}
+	}
+
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer inFile.Close()
+
+	metadata := map[string]*nodePackageMetadata{}
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return metadata, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, found := selected[hdr.Name]; !found || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		var data nodePackageMetadata
+		if err := json.NewDecoder(tr).Decode(&data); err != nil {
+			log.Debugf("loadNodePackageMetadata: error parsing %s - %v", hdr.Name, err)
+			continue
+		}
+
+		metadata[hdr.Name] = &data
+	}
+}
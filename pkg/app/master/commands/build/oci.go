@@ -0,0 +1,256 @@
+package build
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// OCI layer compression types
+const (
+	OCILayerGzip    = "gzip"
+	OCILayerZstd    = "zstd"
+	OCILayerEstargz = "estargz"
+)
+
+const (
+	ociDirName            = "oci"
+	ociRefNameAnnotation  = ocispec.AnnotationRefName
+	defaultOCICompression = OCILayerGzip
+)
+
+// IsOCILayerCompression returns true if the value is a supported layer compression type
+func IsOCILayerCompression(val string) bool {
+	switch val {
+	case OCILayerGzip, OCILayerZstd, OCILayerEstargz:
+		return true
+	}
+
+	return false
+}
+
+// saveOCIImage saves the optimized image in the OCI image layout directory
+// with its layers compressed with gzip or zstd (or formatted as eStargz for lazy pulling)
+func saveOCIImage(
+	xc *app.ExecutionContext,
+	minifiedImageName string,
+	outputDir string,
+	compression string,
+	artifactLocation string,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	xc.Out.State("oci.output.start")
+
+	if compression == "" {
+		compression = defaultOCICompression
+	}
+
+	workDir := filepath.Join(artifactLocation, ociDirName)
+	defer os.RemoveAll(workDir)
+
+	info, err := writeOCILayout(client, minifiedImageName, outputDir, compression, workDir)
+	if err != nil {
+		logger.Errorf("saveOCIImage: error - %v", err)
+		xc.Out.Info("oci.output",
+			ovars{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		return
+	}
+
+	cmdReport.OCIOutput = info
+	xc.Out.Info("oci.output",
+		ovars{
+			"location":    info.Location,
+			"compression": info.Compression,
+			"manifest":    info.ManifestDigest,
+			"layers":      len(info.Layers),
+		})
+
+	xc.Out.State("oci.output.done")
+}
+
+func writeOCILayout(
+	client *dockerapi.Client,
+	imageRef string,
+	outputDir string,
+	compression string,
+	workDir string) (*report.OCIOutputInfo, error) {
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := dockerutil.SaveImage(client, imageRef, filepath.Join(workDir, imageArchiveName), true, true); err != nil {
+		return nil, err
+	}
+
+	manifest, config, err := loadImageArchiveMetadata(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	blobsDir := filepath.Join(outputDir, imageformat.BlobsDirName, string(digest.SHA256))
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return nil, err
+	}
+
+	info := &report.OCIOutputInfo{
+		Location:    outputDir,
+		Compression: compression,
+	}
+
+	var layers []ocispec.Descriptor
+	var diffIDs []string
+	for _, layerPath := range manifest.Layers {
+		desc, diffID, err := writeOCILayer(filepath.Join(workDir, layerPath), blobsDir, compression)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, desc)
+		diffIDs = append(diffIDs, diffID.String())
+		info.Layers = append(info.Layers, desc.Digest.String())
+	}
+
+	//eStargz changes the layer tar data, so the uncompressed layer digests need to be updated
+	if rootfs, ok := config["rootfs"].(map[string]interface{}); ok {
+		rootfs["diff_ids"] = diffIDs
+	}
+
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	configDesc, err := imageformat.WriteBlob(blobsDir, ocispec.MediaTypeImageConfig, configData)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestData, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    configDesc,
+		Layers:    layers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifestDesc, err := imageformat.WriteBlob(blobsDir, ocispec.MediaTypeImageManifest, manifestData)
+	if err != nil {
+		return nil, err
+	}
+
+	refName := imageTagName(imageRef)
+	manifestDesc.Annotations = map[string]string{ociRefNameAnnotation: refName}
+	info.ManifestDigest = manifestDesc.Digest.String()
+
+	if err := imageformat.UpdateIndex(outputDir, refName, manifestDesc); err != nil {
+		return nil, err
+	}
+
+	if err := imageformat.WriteLayoutFile(outputDir); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// writeOCILayer saves the compressed layer blob and returns its descriptor and its uncompressed digest
+func writeOCILayer(layerPath, blobsDir, compression string) (ocispec.Descriptor, digest.Digest, error) {
+	var desc ocispec.Descriptor
+	layerFile, err := os.Open(layerPath)
+	if err != nil {
+		return desc, "", err
+	}
+
+	defer layerFile.Close()
+
+	if compression == OCILayerEstargz {
+		layerInfo, err := layerFile.Stat()
+		if err != nil {
+			return desc, "", err
+		}
+
+		blob, err := estargz.Build(io.NewSectionReader(layerFile, 0, layerInfo.Size()))
+		if err != nil {
+			return desc, "", err
+		}
+
+		defer blob.Close()
+
+		desc, err = imageformat.WriteBlobStream(blobsDir, ocispec.MediaTypeImageLayerGzip, blob)
+		if err != nil {
+			return desc, "", err
+		}
+
+		desc.Annotations = map[string]string{
+			estargz.TOCJSONDigestAnnotation:         blob.TOCDigest().String(),
+			estargz.StoreUncompressedSizeAnnotation: strconv.FormatInt(layerInfo.Size(), 10),
+		}
+
+		return desc, blob.DiffID(), nil
+	}
+
+	mediaType := ocispec.MediaTypeImageLayerGzip
+	if compression == OCILayerZstd {
+		mediaType = ocispec.MediaTypeImageLayerZstd
+	}
+
+	reader, writer := io.Pipe()
+	diffHasher := sha256.New()
+	go func() {
+		writer.CloseWithError(compressLayer(writer, io.TeeReader(layerFile, diffHasher), compression))
+	}()
+
+	desc, err = imageformat.WriteBlobStream(blobsDir, mediaType, reader)
+	reader.Close()
+	if err != nil {
+		return desc, "", err
+	}
+
+	return desc, digest.NewDigestFromEncoded(digest.SHA256, hex.EncodeToString(diffHasher.Sum(nil))), nil
+}
+
+func compressLayer(output io.Writer, input io.Reader, compression string) error {
+	var cw io.WriteCloser
+	switch compression {
+	case OCILayerZstd:
+		zw, err := zstd.NewWriter(output)
+		if err != nil {
+			return err
+		}
+
+		cw = zw
+	default:
+		cw = gzip.NewWriter(output)
+	}
+
+	if _, err := io.Copy(cw, input); err != nil {
+		cw.Close()
+		return err
+	}
+
+	return cw.Close()
+}
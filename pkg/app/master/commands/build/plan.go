@@ -0,0 +1,422 @@
+package build
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/builder"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	dryRunPlanFileName = "slim.plan.json"
+	dryRunMaxListed    = 20
+)
+
+// dryRunPostProcess creates the build plan instead of building the optimized image
+// (partialReason is set when the plan can't include all build changes)
+func dryRunPostProcess(
+	xc *app.ExecutionContext,
+	customImageTag string,
+	overrides *config.ContainerOverrides,
+	imageOverrideSelectors map[string]bool,
+	instructions *config.ImageNewInstructions,
+	partialReason string,
+	doRmFileArtifacts bool,
+	imageInspector *image.Inspector,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) error {
+	xc.Out.State("dry.run.plan",
+		ovars{
+			"message": "creating build plan (optimized image will not be built)",
+		})
+
+	if customImageTag == "" {
+		customImageTag = imageInspector.SlimImageRepo
+	}
+
+	plan, err := createBuildPlan(
+		customImageTag,
+		overrides,
+		imageOverrideSelectors,
+		instructions,
+		imageInspector,
+		client)
+	if err != nil {
+		xc.Out.Error("dry.run.plan", err.Error())
+		exitCode := commands.ECTBuild | ecbDryRunPlanError
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "dry.run.plan.error"
+		return app.NewExitError(exitCode)
+	}
+
+	plan.PartialReason = partialReason
+	cmdReport.Plan = plan
+	printBuildPlan(xc, plan)
+
+	//the plan is also saved in the command report
+	//(the plan file is not saved if the artifacts are removed)
+	if !doRmFileArtifacts {
+		planPath := filepath.Join(imageInspector.ArtifactLocation, dryRunPlanFileName)
+		if err := savePlanFile(plan, planPath); err == nil {
+			xc.Out.Info("dry.run.plan",
+				ovars{
+					"file": planPath,
+				})
+		} else {
+			logger.Errorf("error saving build plan - %v", err)
+		}
+	}
+
+	cmdReport.ArtifactLocation = imageInspector.ArtifactLocation
+	cmdReport.ContainerReportName = report.DefaultContainerReportFileName
+
+	if doRmFileArtifacts {
+		logger.Info("removing temporary artifacts...")
+		err = fsutil.Remove(imageInspector.ArtifactLocation)
+		errutil.WarnOn(err)
+	}
+
+	xc.Out.State("done")
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	return nil
+}
+
+func createBuildPlan(
+	customImageTag string,
+	overrides *config.ContainerOverrides,
+	imageOverrideSelectors map[string]bool,
+	instructions *config.ImageNewInstructions,
+	imageInspector *image.Inspector,
+	client *dockerapi.Client,
+) (*report.BuildPlan, error) {
+	plan, err := createFilePlan(imageInspector, client)
+	if err != nil {
+		return nil, err
+	}
+
+	//the builder calculates the final image metadata (nothing is built here)
+	imageBuilder, err := builder.NewImageBuilder(client,
+		customImageTag,
+		nil,
+		imageInspector.ImageInfo,
+		imageInspector.ArtifactLocation,
+		false,
+		imageOverrideSelectors,
+		overrides,
+		instructions,
+		imageInspector.ImageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	orig := imageInspector.ImageInfo.Config
+	if orig == nil {
+		orig = &dockerapi.Config{}
+	}
+
+	plan.MetadataChanges = appendPlanChange(plan.MetadataChanges, "entrypoint",
+		strings.Join(orig.Entrypoint, " "),
+		strings.Join(imageBuilder.Entrypoint, " "))
+	plan.MetadataChanges = appendPlanChange(plan.MetadataChanges, "cmd",
+		strings.Join(orig.Cmd, " "),
+		strings.Join(imageBuilder.Cmd, " "))
+	plan.MetadataChanges = appendPlanChange(plan.MetadataChanges, "workdir",
+		orig.WorkingDir,
+		imageBuilder.WorkingDir)
+	plan.MetadataChanges = appendPlanChange(plan.MetadataChanges, "user",
+		orig.User,
+		imageBuilder.User)
+	plan.MetadataChanges = appendPlanListChanges(plan.MetadataChanges, "env",
+		orig.Env,
+		imageBuilder.Env)
+
+	var origLabels, newLabels []string
+	for k, v := range orig.Labels {
+		origLabels = append(origLabels, fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range imageBuilder.Labels {
+		newLabels = append(newLabels, fmt.Sprintf("%s=%s", k, v))
+	}
+	plan.MetadataChanges = appendPlanListChanges(plan.MetadataChanges, "label",
+		origLabels,
+		newLabels)
+
+	var origPorts, newPorts []string
+	for k := range orig.ExposedPorts {
+		origPorts = append(origPorts, string(k))
+	}
+	for k := range imageBuilder.ExposedPorts {
+		newPorts = append(newPorts, string(k))
+	}
+	plan.MetadataChanges = appendPlanListChanges(plan.MetadataChanges, "expose",
+		origPorts,
+		newPorts)
+
+	var origVolumes, newVolumes []string
+	for k := range orig.Volumes {
+		origVolumes = append(origVolumes, k)
+	}
+	for k := range imageBuilder.Volumes {
+		newVolumes = append(newVolumes, k)
+	}
+	plan.MetadataChanges = appendPlanListChanges(plan.MetadataChanges, "volume",
+		origVolumes,
+		newVolumes)
+
+	return plan, nil
+}
+
+// createFilePlan creates the kept and removed file lists
+// (the kept files are from the container report and the removed files are the other files in the target image)
+func createFilePlan(imageInspector *image.Inspector, client *dockerapi.Client) (*report.BuildPlan, error) {
+	creportPath := filepath.Join(imageInspector.ArtifactLocation, report.DefaultContainerReportFileName)
+	creportData, err := ioutil.ReadFile(creportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var creport report.ContainerReport
+	if err := json.Unmarshal(creportData, &creport); err != nil {
+		return nil, err
+	}
+
+	imageFiles, err := listImageFiles(client, imageInspector.ImageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &report.BuildPlan{}
+	keep := map[string]struct{}{}
+	for _, info := range creport.Image.Files {
+		if info == nil {
+			continue
+		}
+
+		keep[info.FilePath] = struct{}{}
+		plan.Keep = append(plan.Keep, &report.BuildPlanFile{
+			Path: info.FilePath,
+			Size: info.FileSize,
+		})
+		plan.KeepSize += info.FileSize
+	}
+
+	for _, fileInfo := range imageFiles {
+		if _, found := keep[fileInfo.Path]; found {
+			continue
+		}
+
+		plan.Drop = append(plan.Drop, fileInfo)
+		plan.DropSize += fileInfo.Size
+	}
+
+	plan.KeepCount = len(plan.Keep)
+	plan.DropCount = len(plan.Drop)
+	sortPlanFiles(plan.Keep)
+	sortPlanFiles(plan.Drop)
+
+	return plan, nil
+}
+
+// listImageFiles returns the files (and links) in the image filesystem
+// it exports the filesystem of a temporary (never started) container created from the image
+func listImageFiles(client *dockerapi.Client, imageRef string) ([]*report.BuildPlanFile, error) {
+	containerID, err := createInactiveContainer(client, imageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	defer removeInactiveContainer(client, containerID)
+
+	reader, writer := io.Pipe()
+	go func() {
+		err := client.ExportContainer(dockerapi.ExportContainerOptions{
+			ID:           containerID,
+			OutputStream: writer,
+		})
+		writer.CloseWithError(err)
+	}()
+
+	defer reader.Close()
+
+	var files []*report.BuildPlanFile
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+			files = append(files, &report.BuildPlanFile{
+				Path: filepath.Join("/", hdr.Name),
+				Size: hdr.Size,
+			})
+		}
+	}
+
+	return files, nil
+}
+
+// createInactiveContainer creates a container that is never started
+// (used to access the image filesystem)
+func createInactiveContainer(client *dockerapi.Client, imageRef string) (string, error) {
+	containerOptions := dockerapi.CreateContainerOptions{
+		Config: &dockerapi.Config{
+			Image:      imageRef,
+			Entrypoint: []string{"/inactive"},
+		},
+	}
+
+	containerInfo, err := client.CreateContainer(containerOptions)
+	if err != nil {
+		return "", err
+	}
+
+	return containerInfo.ID, nil
+}
+
+func removeInactiveContainer(client *dockerapi.Client, containerID string) {
+	err := client.RemoveContainer(dockerapi.RemoveContainerOptions{
+		ID:    containerID,
+		Force: true,
+	})
+	errutil.WarnOn(err)
+}
+
+func savePlanFile(plan *report.BuildPlan, planPath string) error {
+	planData, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(planPath, planData, 0644)
+}
+
+func sortPlanFiles(files []*report.BuildPlanFile) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Size != files[j].Size {
+			return files[i].Size > files[j].Size
+		}
+
+		return files[i].Path < files[j].Path
+	})
+}
+
+func appendPlanChange(changes []*report.BuildPlanChange, field, orig, new string) []*report.BuildPlanChange {
+	if orig == new {
+		return changes
+	}
+
+	return append(changes, &report.BuildPlanChange{
+		Field:    field,
+		Original: orig,
+		New:      new,
+	})
+}
+
+func appendPlanListChanges(changes []*report.BuildPlanChange, field string, orig, new []string) []*report.BuildPlanChange {
+	origSet := map[string]struct{}{}
+	for _, v := range orig {
+		origSet[v] = struct{}{}
+	}
+
+	newSet := map[string]struct{}{}
+	for _, v := range new {
+		newSet[v] = struct{}{}
+	}
+
+	sort.Strings(orig)
+	for _, v := range orig {
+		if _, found := newSet[v]; !found {
+			changes = append(changes, &report.BuildPlanChange{Field: field, Original: v})
+		}
+	}
+
+	sort.Strings(new)
+	for _, v := range new {
+		if _, found := origSet[v]; !found {
+			changes = append(changes, &report.BuildPlanChange{Field: field, New: v})
+		}
+	}
+
+	return changes
+}
+
+func printBuildPlan(xc *app.ExecutionContext, plan *report.BuildPlan) {
+	xc.Out.Info("dry.run.plan.summary",
+		ovars{
+			"keep.count": plan.KeepCount,
+			"keep.size":  humanize.Bytes(uint64(plan.KeepSize)),
+			"drop.count": plan.DropCount,
+			"drop.size":  humanize.Bytes(uint64(plan.DropSize)),
+		})
+
+	for idx, info := range plan.Drop {
+		if idx == dryRunMaxListed {
+			xc.Out.Info("dry.run.plan.drop",
+				ovars{
+					"message": fmt.Sprintf("%d more files (see the plan file)", len(plan.Drop)-idx),
+				})
+			break
+		}
+
+		xc.Out.Info("dry.run.plan.drop",
+			ovars{
+				"path": info.Path,
+				"size": humanize.Bytes(uint64(info.Size)),
+			})
+	}
+
+	for _, change := range plan.MetadataChanges {
+		xc.Out.Info("dry.run.plan.metadata",
+			ovars{
+				"field":    change.Field,
+				"original": change.Original,
+				"new":      change.New,
+			})
+	}
+
+	if plan.PartialReason != "" {
+		xc.Out.Info("dry.run.plan.partial",
+			ovars{
+				"message": plan.PartialReason,
+			})
+	}
+}
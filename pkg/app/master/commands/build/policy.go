@@ -0,0 +1,74 @@
+package build
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// checkSizePolicy returns the exit error with a policy specific exit code
+// if the optimized image doesn't meet the size policy requirements
+func checkSizePolicy(
+	xc *app.ExecutionContext,
+	policy *config.SizePolicy,
+	cmdReport *report.BuildCommand) error {
+	if !policy.IsSet() ||
+		cmdReport.SourceImage.Size <= 0 ||
+		cmdReport.MinifiedImageSize <= 0 {
+		return nil
+	}
+
+	fatSize := cmdReport.SourceImage.Size
+	slimSize := cmdReport.MinifiedImageSize
+	reduction := float64(fatSize-slimSize) * 100 / float64(fatSize)
+
+	var exitCode int
+	var violation string
+	var message string
+	switch {
+	case policy.FailOnNoReduction && slimSize >= fatSize:
+		exitCode = ecbPolicyNoReduction
+		violation = "policy.no.reduction"
+		message = "optimized image is not smaller than the original image"
+	case policy.MaxSize > 0 && uint64(slimSize) > policy.MaxSize:
+		exitCode = ecbPolicyMaxSlimSize
+		violation = "policy.max.slim.size"
+		message = fmt.Sprintf("optimized image size (%s) is bigger than the max size (%s)",
+			humanize.Bytes(uint64(slimSize)),
+			humanize.Bytes(policy.MaxSize))
+	case policy.MinReductionPercent > 0 && reduction < float64(policy.MinReductionPercent):
+		exitCode = ecbPolicyMinReduction
+		violation = "policy.min.reduction"
+		message = fmt.Sprintf("image size reduction (%.2f%%) is less than the min reduction (%d%%)",
+			reduction,
+			policy.MinReductionPercent)
+	default:
+		xc.Out.Info("policy",
+			ovars{
+				"status":    "passed",
+				"reduction": fmt.Sprintf("%.2f%%", reduction),
+			})
+		return nil
+	}
+
+	xc.Out.Info("policy",
+		ovars{
+			"status":  "failed",
+			"error":   violation,
+			"message": message,
+		})
+
+	exitCode = commands.ECTBuild | exitCode
+	xc.Out.State("exited",
+		ovars{
+			"exit.code": exitCode,
+		})
+
+	cmdReport.Error = violation
+	return app.NewExitError(exitCode)
+}
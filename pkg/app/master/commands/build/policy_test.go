@@ -0,0 +1,43 @@
+package build
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+func TestCheckSizePolicy(t *testing.T) {
+	xc := app.NewExecutionContext(Name, "text")
+
+	newReport := func(fatSize, slimSize int64) *report.BuildCommand {
+		cmdReport := report.NewBuildCommand("", false)
+		cmdReport.SourceImage.Size = fatSize
+		cmdReport.MinifiedImageSize = slimSize
+		return cmdReport
+	}
+
+	cmdReport := newReport(100, 50)
+	assert.NoError(t, checkSizePolicy(xc, &config.SizePolicy{MinReductionPercent: 40}, cmdReport))
+	assert.Empty(t, cmdReport.Error)
+
+	//the policy violations are returned as the command exits (they don't stop the process)
+	cmdReport = newReport(100, 80)
+	err := checkSizePolicy(xc, &config.SizePolicy{MinReductionPercent: 40}, cmdReport)
+
+	var exitErr *app.ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, commands.ECTBuild|ecbPolicyMinReduction, exitErr.ExitCode)
+	assert.Equal(t, "policy.min.reduction", cmdReport.Error)
+
+	cmdReport = newReport(100, 100)
+	err = checkSizePolicy(xc, &config.SizePolicy{FailOnNoReduction: true}, cmdReport)
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, commands.ECTBuild|ecbPolicyNoReduction, exitErr.ExitCode)
+}
@@ -2,6 +2,8 @@ package build
 
 import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/vulnscan"
 
 	"github.com/c-bata/go-prompt"
 )
@@ -14,6 +16,8 @@ var CommandSuggestion = prompt.Suggest{
 var CommandFlagSuggestions = &commands.FlagSuggestions{
 	Names: []prompt.Suggest{
 		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(FlagTargetsFile), Description: FlagTargetsFileUsage},
+		{Text: commands.FullFlagName(FlagBatchWorkers), Description: FlagBatchWorkersUsage},
 		{Text: commands.FullFlagName(commands.FlagComposeFile), Description: commands.FlagComposeFileUsage},
 		{Text: commands.FullFlagName(commands.FlagTargetComposeSvc), Description: commands.FlagTargetComposeSvcUsage},
 		{Text: commands.FullFlagName(commands.FlagTargetComposeSvcImage), Description: commands.FlagTargetComposeSvcImageUsage},
@@ -62,6 +66,29 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagHTTPMaxConcurrentCrawlers), Description: commands.FlagHTTPMaxConcurrentCrawlersUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpec), Description: commands.FlagHTTPProbeAPISpecUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile), Description: commands.FlagHTTPProbeAPISpecFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHeaderFile), Description: commands.FlagHTTPProbeHeaderFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHeaderEnv), Description: commands.FlagHTTPProbeHeaderEnvUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientCert), Description: commands.FlagHTTPProbeClientCertUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientKey), Description: commands.FlagHTTPProbeClientKeyUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeCACert), Description: commands.FlagHTTPProbeCACertUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2TokenURL), Description: commands.FlagHTTPProbeOAuth2TokenURLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientID), Description: commands.FlagHTTPProbeOAuth2ClientIDUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientSecret), Description: commands.FlagHTTPProbeOAuth2ClientSecretUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientSecretFile), Description: commands.FlagHTTPProbeOAuth2ClientSecretFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2Scope), Description: commands.FlagHTTPProbeOAuth2ScopeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHARFile), Description: commands.FlagHTTPProbeHARFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeURLFile), Description: commands.FlagHTTPProbeURLFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeFailThreshold), Description: commands.FlagHTTPProbeFailThresholdUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeConcurrency), Description: commands.FlagHTTPProbeConcurrencyUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeRateLimit), Description: commands.FlagHTTPProbeRateLimitUsage},
+		{Text: commands.FullFlagName(commands.FlagProbeWhen), Description: commands.FlagProbeWhenUsage},
+		{Text: commands.FullFlagName(commands.FlagProbeWhenTimeout), Description: commands.FlagProbeWhenTimeoutUsage},
+		{Text: commands.FullFlagName(commands.FlagExecHook), Description: commands.FlagExecHookUsage},
+		{Text: commands.FullFlagName(commands.FlagSessionRecord), Description: commands.FlagSessionRecordUsage},
+		{Text: commands.FullFlagName(commands.FlagSessionReplay), Description: commands.FlagSessionReplayUsage},
+		{Text: commands.FullFlagName(commands.FlagDepService), Description: commands.FlagDepServiceUsage},
+		{Text: commands.FullFlagName(commands.FlagDepComposeFile), Description: commands.FlagDepComposeFileUsage},
+		{Text: commands.FullFlagName(commands.FlagDepHealthyTimeout), Description: commands.FlagDepHealthyTimeoutUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishPort), Description: commands.FlagPublishPortUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishExposedPorts), Description: commands.FlagPublishExposedPortsUsage},
 		{Text: commands.FullFlagName(commands.FlagHostExec), Description: commands.FlagHostExecUsage},
@@ -70,6 +97,13 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagRunTargetAsUser), Description: commands.FlagRunTargetAsUserUsage},
 		{Text: commands.FullFlagName(commands.FlagCopyMetaArtifacts), Description: commands.FlagCopyMetaArtifactsUsage},
 		{Text: commands.FullFlagName(commands.FlagRemoveFileArtifacts), Description: commands.FlagRemoveFileArtifactsUsage},
+		{Text: commands.FullFlagName(commands.FlagSBOM), Description: commands.FlagSBOMUsage},
+		{Text: commands.FullFlagName(commands.FlagSBOMOutput), Description: commands.FlagSBOMOutputUsage},
+		{Text: commands.FullFlagName(commands.FlagReportHTML), Description: commands.FlagReportHTMLUsage},
+		{Text: commands.FullFlagName(commands.FlagReportUpload), Description: commands.FlagReportUploadUsage},
+		{Text: commands.FullFlagName(commands.FlagReportUploadArtifacts), Description: commands.FlagReportUploadArtifactsUsage},
+		{Text: commands.FullFlagName(commands.FlagTraceOutput), Description: commands.FlagTraceOutputUsage},
+		{Text: commands.FullFlagName(commands.FlagTraceFormat), Description: commands.FlagTraceFormatUsage},
 		{Text: commands.FullFlagName(FlagTag), Description: FlagTagUsage},
 		{Text: commands.FullFlagName(FlagImageOverrides), Description: FlagImageOverridesUsage},
 		{Text: commands.FullFlagName(commands.FlagUser), Description: commands.FlagUserUsage},
@@ -109,6 +143,8 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagIncludeBinFile), Description: FlagIncludeBinFileUsage},
 		{Text: commands.FullFlagName(FlagIncludeExe), Description: FlagIncludeExeUsage},
 		{Text: commands.FullFlagName(FlagIncludeExeFile), Description: FlagIncludeExeFileUsage},
+		{Text: commands.FullFlagName(FlagIncludePkg), Description: FlagIncludePkgUsage},
+		{Text: commands.FullFlagName(FlagIncludePkgDeps), Description: FlagIncludePkgDepsUsage},
 		{Text: commands.FullFlagName(FlagIncludeShell), Description: FlagIncludeShellUsage},
 		{Text: commands.FullFlagName(FlagIncludeCertAll), Description: FlagIncludeCertAllUsage},
 		{Text: commands.FullFlagName(FlagIncludeCertBundles), Description: FlagIncludeCertBundlesUsage},
@@ -116,8 +152,11 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagIncludeCertPKAll), Description: FlagIncludeCertPKAllUsage},
 		{Text: commands.FullFlagName(FlagIncludeCertPKDirs), Description: FlagIncludeCertPKDirsUsage},
 		{Text: commands.FullFlagName(FlagIncludeNew), Description: FlagIncludeNewUsage},
+		{Text: commands.FullFlagName(FlagIncludeRuntimeEssentials), Description: FlagIncludeRuntimeEssentialsUsage},
+		{Text: commands.FullFlagName(FlagRunAsUser), Description: FlagRunAsUserUsage},
 		{Text: commands.FullFlagName(commands.FlagMount), Description: commands.FlagMountUsage},
 		{Text: commands.FullFlagName(commands.FlagContinueAfter), Description: commands.FlagContinueAfterUsage},
+		{Text: commands.FullFlagName(commands.FlagPreset), Description: commands.FlagPresetUsage},
 		{Text: commands.FullFlagName(commands.FlagUseLocalMounts), Description: commands.FlagUseLocalMountsUsage},
 		{Text: commands.FullFlagName(commands.FlagUseSensorVolume), Description: commands.FlagUseSensorVolumeUsage},
 		{Text: commands.FullFlagName(FlagKeepTmpArtifacts), Description: FlagKeepTmpArtifactsUsage},
@@ -132,6 +171,13 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagIncludeAppNextStaticDir), Description: FlagIncludeAppNextStaticDirUsage},
 		{Text: commands.FullFlagName(FlagIncludeAppNextNodeModulesDir), Description: FlagIncludeAppNextNodeModulesDirUsage},
 		{Text: commands.FullFlagName(FlagIncludeNodePackage), Description: FlagIncludeNodePackageUsage},
+		{Text: commands.FullFlagName(FlagNodePrunePackages), Description: FlagNodePrunePackagesUsage},
+		{Text: commands.FullFlagName(FlagNodeKeepDeclaredDeps), Description: FlagNodeKeepDeclaredDepsUsage},
+		{Text: commands.FullFlagName(FlagJavaJLink), Description: FlagJavaJLinkUsage},
+		{Text: commands.FullFlagName(FlagJavaJLinkImage), Description: FlagJavaJLinkImageUsage},
+		{Text: commands.FullFlagName(FlagJavaAddModules), Description: FlagJavaAddModulesUsage},
+		{Text: commands.FullFlagName(FlagPythonPrunePackages), Description: FlagPythonPrunePackagesUsage},
+		{Text: commands.FullFlagName(FlagPythonKeepPackage), Description: FlagPythonKeepPackageUsage},
 		{Text: commands.FullFlagName(FlagBuildFromDockerfile), Description: FlagBuildFromDockerfileUsage},
 		{Text: commands.FullFlagName(FlagDockerfileContext), Description: FlagDockerfileContextUsage},
 		{Text: commands.FullFlagName(FlagTagFat), Description: FlagTagFatUsage},
@@ -141,7 +187,36 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagCBOTarget), Description: FlagCBOTargetUsage},
 		{Text: commands.FullFlagName(FlagCBONetwork), Description: FlagCBONetworkUsage},
 		{Text: commands.FullFlagName(FlagCBOCacheFrom), Description: FlagCBOCacheFromUsage},
+		{Text: commands.FullFlagName(FlagCBOCacheTo), Description: FlagCBOCacheToUsage},
+		{Text: commands.FullFlagName(FlagCBOBuildContext), Description: FlagCBOBuildContextUsage},
 		{Text: commands.FullFlagName(commands.FlagDeleteFatImage), Description: commands.FlagDeleteFatImageUsage},
+		{Text: commands.FullFlagName(FlagDryRun), Description: FlagDryRunUsage},
+		{Text: commands.FullFlagName(FlagMaxSlimSize), Description: FlagMaxSlimSizeUsage},
+		{Text: commands.FullFlagName(FlagMinReductionPercent), Description: FlagMinReductionPercentUsage},
+		{Text: commands.FullFlagName(FlagFailOnNoReduction), Description: FlagFailOnNoReductionUsage},
+		{Text: commands.FullFlagName(FlagVerifySlim), Description: FlagVerifySlimUsage},
+		{Text: commands.FullFlagName(FlagVerifyRetries), Description: FlagVerifyRetriesUsage},
+		{Text: commands.FullFlagName(FlagPreserveLayers), Description: FlagPreserveLayersUsage},
+		{Text: commands.FullFlagName(FlagPreserveLayersMinKept), Description: FlagPreserveLayersMinKeptUsage},
+		{Text: commands.FullFlagName(FlagReproducible), Description: FlagReproducibleUsage},
+		{Text: commands.FullFlagName(FlagCacheSensor), Description: FlagCacheSensorUsage},
+		{Text: commands.FullFlagName(FlagImportSensorData), Description: FlagImportSensorDataUsage},
+		{Text: commands.FullFlagName(FlagOCIOutput), Description: FlagOCIOutputUsage},
+		{Text: commands.FullFlagName(FlagOCILayerCompression), Description: FlagOCILayerCompressionUsage},
+		{Text: commands.FullFlagName(FlagScanVulns), Description: FlagScanVulnsUsage},
+		{Text: commands.FullFlagName(FlagScanVulnsExe), Description: FlagScanVulnsExeUsage},
+		{Text: commands.FullFlagName(FlagAuditELF), Description: FlagAuditELFUsage},
+		{Text: commands.FullFlagName(FlagStaticScratch), Description: FlagStaticScratchUsage},
+		{Text: commands.FullFlagName(FlagIncludeDebugImage), Description: FlagIncludeDebugImageUsage},
+		{Text: commands.FullFlagName(FlagDebugImageTag), Description: FlagDebugImageTagUsage},
+		{Text: commands.FullFlagName(FlagDebugImageToolbox), Description: FlagDebugImageToolboxUsage},
+		{Text: commands.FullFlagName(FlagImageBuildEngine), Description: FlagImageBuildEngineUsage},
+		{Text: commands.FullFlagName(FlagImageBuildEngineEndpoint), Description: FlagImageBuildEngineEndpointUsage},
+		{Text: commands.FullFlagName(FlagImageBuildCacheFrom), Description: FlagImageBuildCacheFromUsage},
+		{Text: commands.FullFlagName(FlagImageBuildCacheTo), Description: FlagImageBuildCacheToUsage},
+		{Text: commands.FullFlagName(FlagImageBuildProvenance), Description: FlagImageBuildProvenanceUsage},
+		{Text: commands.FullFlagName(FlagImageBuildPlatform), Description: FlagImageBuildPlatformUsage},
+		{Text: commands.FullFlagName(FlagImageBuildOutput), Description: FlagImageBuildOutputUsage},
 		{Text: commands.FullFlagName(commands.FlagRTAOnbuildBaseImage), Description: commands.FlagRTAOnbuildBaseImageUsage},
 		{Text: commands.FullFlagName(commands.FlagRTASourcePT), Description: commands.FlagRTASourcePTUsage},
 		{Text: commands.FullFlagName(commands.FlagSensorIPCMode), Description: commands.FlagSensorIPCModeUsage},
@@ -149,46 +224,66 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 	},
 	Values: map[string]commands.CompleteValue{
 		//NOTE: with FlagPull target complete needs to check remote registries too
-		commands.FullFlagName(commands.FlagPull):                           commands.CompleteBool,
-		commands.FullFlagName(commands.FlagShowPullLogs):                   commands.CompleteBool,
-		commands.FullFlagName(commands.FlagDockerConfigPath):               commands.CompleteFile,
-		commands.FullFlagName(commands.FlagTarget):                         commands.CompleteTarget,
-		commands.FullFlagName(commands.FlagComposeFile):                    commands.CompleteFile,
-		commands.FullFlagName(commands.FlagDepIncludeTargetComposeSvcDeps): commands.CompleteBool,
-		commands.FullFlagName(commands.FlagComposeEnvNoHost):               commands.CompleteBool,
-		commands.FullFlagName(commands.FlagComposeEnvFile):                 commands.CompleteFile,
-		commands.FullFlagName(commands.FlagComposeWorkdir):                 commands.CompleteFile,
-		commands.FullFlagName(commands.FlagKubeManifestFile):               commands.CompleteFile,
-		commands.FullFlagName(commands.FlagKubeKubeconfigFile):             commands.CompleteFile,
-		commands.FullFlagName(FlagShowBuildLogs):                           commands.CompleteBool,
-		commands.FullFlagName(commands.FlagShowContainerLogs):              commands.CompleteBool,
-		commands.FullFlagName(commands.FlagPublishExposedPorts):            commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbeOff):                   commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbe):                      commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeCmdFile):               commands.CompleteFile,
-		commands.FullFlagName(commands.FlagHTTPProbeFull):                  commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbeExitOnFailure):         commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbeCrawl):                 commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile):           commands.CompleteFile,
-		commands.FullFlagName(commands.FlagHostExecFile):                   commands.CompleteFile,
-		commands.FullFlagName(FlagKeepPerms):                               commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagRunTargetAsUser):                commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagRemoveFileArtifacts):            commands.CompleteBool,
-		commands.FullFlagName(commands.FlagNetwork):                        commands.CompleteNetwork,
-		commands.FullFlagName(commands.FlagExcludeMounts):                  commands.CompleteTBool,
-		commands.FullFlagName(FlagPathPermsFile):                           commands.CompleteFile,
-		commands.FullFlagName(FlagPreservePathFile):                        commands.CompleteFile,
-		commands.FullFlagName(FlagIncludePathFile):                         commands.CompleteFile,
-		commands.FullFlagName(FlagIncludeBinFile):                          commands.CompleteFile,
-		commands.FullFlagName(FlagIncludeExeFile):                          commands.CompleteFile,
-		commands.FullFlagName(FlagIncludeShell):                            commands.CompleteBool,
-		commands.FullFlagName(FlagIncludeCertAll):                          commands.CompleteBool,
-		commands.FullFlagName(FlagIncludeCertBundles):                      commands.CompleteBool,
-		commands.FullFlagName(FlagIncludeCertDirs):                         commands.CompleteBool,
-		commands.FullFlagName(FlagIncludeCertPKAll):                        commands.CompleteBool,
-		commands.FullFlagName(FlagIncludeCertPKDirs):                       commands.CompleteBool,
-		commands.FullFlagName(FlagIncludeNew):                              commands.CompleteBool,
-		commands.FullFlagName(commands.FlagContinueAfter):                  commands.CompleteContinueAfter,
+		commands.FullFlagName(commands.FlagPull):                            commands.CompleteBool,
+		commands.FullFlagName(commands.FlagShowPullLogs):                    commands.CompleteBool,
+		commands.FullFlagName(commands.FlagDockerConfigPath):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagTarget):                          commands.CompleteTarget,
+		commands.FullFlagName(FlagTargetsFile):                              commands.CompleteFile,
+		commands.FullFlagName(commands.FlagComposeFile):                     commands.CompleteFile,
+		commands.FullFlagName(commands.FlagDepComposeFile):                  commands.CompleteFile,
+		commands.FullFlagName(commands.FlagDepIncludeTargetComposeSvcDeps):  commands.CompleteBool,
+		commands.FullFlagName(commands.FlagComposeEnvNoHost):                commands.CompleteBool,
+		commands.FullFlagName(commands.FlagComposeEnvFile):                  commands.CompleteFile,
+		commands.FullFlagName(commands.FlagComposeWorkdir):                  commands.CompleteFile,
+		commands.FullFlagName(commands.FlagKubeManifestFile):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagKubeKubeconfigFile):              commands.CompleteFile,
+		commands.FullFlagName(FlagShowBuildLogs):                            commands.CompleteBool,
+		commands.FullFlagName(commands.FlagShowContainerLogs):               commands.CompleteBool,
+		commands.FullFlagName(commands.FlagPublishExposedPorts):             commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeOff):                    commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbe):                       commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeCmdFile):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeFull):                   commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeExitOnFailure):          commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeCrawl):                  commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile):            commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeHeaderFile):             commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientCert):             commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientKey):              commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeCACert):                 commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientSecretFile): commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeHARFile):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeURLFile):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagSessionRecord):                   commands.CompleteFile,
+		commands.FullFlagName(commands.FlagSessionReplay):                   commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHostExecFile):                    commands.CompleteFile,
+		commands.FullFlagName(FlagKeepPerms):                                commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagRunTargetAsUser):                 commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagRemoveFileArtifacts):             commands.CompleteBool,
+		commands.FullFlagName(commands.FlagSBOM):                            commands.CompleteSBOMFormat,
+		commands.FullFlagName(commands.FlagSBOMOutput):                      commands.CompleteFile,
+		commands.FullFlagName(commands.FlagReportHTML):                      commands.CompleteFile,
+		commands.FullFlagName(commands.FlagReportUploadArtifacts):           commands.CompleteBool,
+		commands.FullFlagName(commands.FlagTraceOutput):                     commands.CompleteFile,
+		commands.FullFlagName(commands.FlagTraceFormat):                     commands.CompleteTraceFormat,
+		commands.FullFlagName(commands.FlagNetwork):                         commands.CompleteNetwork,
+		commands.FullFlagName(commands.FlagExcludeMounts):                   commands.CompleteTBool,
+		commands.FullFlagName(FlagPathPermsFile):                            commands.CompleteFile,
+		commands.FullFlagName(FlagPreservePathFile):                         commands.CompleteFile,
+		commands.FullFlagName(FlagIncludePathFile):                          commands.CompleteFile,
+		commands.FullFlagName(FlagIncludeBinFile):                           commands.CompleteFile,
+		commands.FullFlagName(FlagIncludeExeFile):                           commands.CompleteFile,
+		commands.FullFlagName(FlagIncludeShell):                             commands.CompleteBool,
+		commands.FullFlagName(FlagIncludePkgDeps):                           commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeCertAll):                           commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeCertBundles):                       commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeCertDirs):                          commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeCertPKAll):                         commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeCertPKDirs):                        commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeNew):                               commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeRuntimeEssentials):                 commands.CompleteBool,
+		commands.FullFlagName(commands.FlagContinueAfter):                   commands.CompleteContinueAfter,
+		commands.FullFlagName(commands.FlagPreset):                          commands.CompletePreset,
 		//commands.FullFlagName(commands.FlagConsoleFormat):                  commands.CompleteConsoleOutput,
 		commands.FullFlagName(commands.FlagUseLocalMounts):      commands.CompleteBool,
 		commands.FullFlagName(commands.FlagUseSensorVolume):     commands.CompleteVolume,
@@ -203,11 +298,59 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(FlagIncludeAppNextDistDir):        commands.CompleteBool,
 		commands.FullFlagName(FlagIncludeAppNextStaticDir):      commands.CompleteBool,
 		commands.FullFlagName(FlagIncludeAppNextNodeModulesDir): commands.CompleteBool,
+		commands.FullFlagName(FlagNodePrunePackages):            commands.CompleteBool,
+		commands.FullFlagName(FlagNodeKeepDeclaredDeps):         commands.CompleteBool,
+		commands.FullFlagName(FlagJavaJLink):                    commands.CompleteBool,
+		commands.FullFlagName(FlagPythonPrunePackages):          commands.CompleteBool,
 		commands.FullFlagName(commands.FlagCROHostConfigFile):   commands.CompleteFile,
 		commands.FullFlagName(FlagDockerfileContext):            commands.CompleteFile,
 		commands.FullFlagName(FlagDeleteFatImage):               commands.CompleteBool,
+		commands.FullFlagName(FlagDryRun):                       commands.CompleteBool,
+		commands.FullFlagName(FlagFailOnNoReduction):            commands.CompleteBool,
+		commands.FullFlagName(FlagVerifySlim):                   commands.CompleteBool,
+		commands.FullFlagName(FlagPreserveLayers):               commands.CompleteBool,
+		commands.FullFlagName(FlagReproducible):                 commands.CompleteBool,
+		commands.FullFlagName(FlagCacheSensor):                  commands.CompleteBool,
+		commands.FullFlagName(FlagImportSensorData):             commands.CompleteFile,
+		commands.FullFlagName(FlagOCIOutput):                    commands.CompleteFile,
+		commands.FullFlagName(FlagOCILayerCompression):          completeOCILayerCompression,
+		commands.FullFlagName(FlagScanVulns):                    completeScanVulns,
+		commands.FullFlagName(FlagScanVulnsExe):                 commands.CompleteFile,
+		commands.FullFlagName(FlagAuditELF):                     commands.CompleteTBool,
+		commands.FullFlagName(FlagStaticScratch):                commands.CompleteBool,
+		commands.FullFlagName(FlagIncludeDebugImage):            commands.CompleteBool,
+		commands.FullFlagName(FlagImageBuildEngine):             completeImageBuildEngine,
 		commands.FullFlagName(commands.FlagRTAOnbuildBaseImage): commands.CompleteBool,
 		commands.FullFlagName(commands.FlagRTASourcePT):         commands.CompleteBool,
 		commands.FullFlagName(commands.FlagSensorIPCMode):       commands.CompleteIPCMode,
 	},
 }
+
+var ociLayerCompressionValues = []prompt.Suggest{
+	{Text: OCILayerGzip, Description: "Default, gzip compressed layers"},
+	{Text: OCILayerZstd, Description: "zstd compressed layers"},
+	{Text: OCILayerEstargz, Description: "eStargz (seekable gzip) layers for lazy pulling"},
+}
+
+func completeOCILayerCompression(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(ociLayerCompressionValues, token, true)
+}
+
+var scanVulnsValues = []prompt.Suggest{
+	{Text: vulnscan.ScannerTrivy, Description: "Trivy vulnerability scanner"},
+	{Text: vulnscan.ScannerGrype, Description: "Grype vulnerability scanner"},
+}
+
+func completeScanVulns(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(scanVulnsValues, token, true)
+}
+
+var imageBuildEngineValues = []prompt.Suggest{
+	{Text: config.ImageBuildEngineInternal, Description: "Default, classic Docker build API"},
+	{Text: config.ImageBuildEngineBuildx, Description: "Docker buildx (BuildKit)"},
+	{Text: config.ImageBuildEngineBuildkitd, Description: "buildkitd instance (using buildctl)"},
+}
+
+func completeImageBuildEngine(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(imageBuildEngineValues, token, true)
+}
@@ -0,0 +1,669 @@
+package build
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	pySitePackagesDirName = "site-packages"
+	pyDistPackagesDirName = "dist-packages"
+	pyDistInfoDirSuffix   = ".dist-info"
+	pyEggInfoDirSuffix    = ".egg-info"
+	pyRecordFileName      = "RECORD"
+	pyTopLevelFileName    = "top_level.txt"
+	pyCacheDirName        = "__pycache__"
+)
+
+// the test directories inside the installed packages (the top level 'tests' packages are not removed)
+var pyTestDirNames = map[string]struct{}{
+	"tests": {},
+	"test":  {},
+}
+
+var pyPackageNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+var ErrNoPythonPackages = errors.New("no Python package directories (site-packages or dist-packages) in the file artifacts")
+var ErrNoMonitorData = errors.New("no file monitor data in the container report")
+
+// pyDistribution is an installed Python distribution (a site-packages directory package with the dist-info or egg-info metadata)
+type pyDistribution struct {
+	name        string
+	version     string
+	packagesDir string
+	infoDir     string
+	//the distribution files (from the RECORD file)
+	files map[string]struct{}
+	//the top level module and package paths (from the RECORD or top_level.txt files)
+	topLevel map[string]struct{}
+	used     bool
+}
+
+// prunePythonPackages removes the installed Python distributions the app didn't import during profiling
+// (including their dist-info/egg-info metadata), the bytecode cache directories and the package test directories
+// from the file artifacts. The file artifacts are not changed if the Python packages can't be analyzed.
+func prunePythonPackages(
+	xc *app.ExecutionContext,
+	opts config.AppPythonPackageOptions,
+	artifactLocation string,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	info := &report.PythonPackagesInfo{}
+	cmdReport.PythonPackages = info
+
+	fail := func(status string, err error) {
+		logger.Debugf("prunePythonPackages: %s - %v", status, err)
+		info.Status = status
+		info.Error = err.Error()
+		xc.Out.Info("python.packages",
+			ovars{
+				"status":  status,
+				"error":   err.Error(),
+				"message": "keeping all Python packages",
+			})
+	}
+
+	tarPath := filepath.Join(artifactLocation, fileArtifactsTar)
+	if !fsutil.IsRegularFile(tarPath) {
+		fail("error", ErrNoFileArtifactsTar)
+		return
+	}
+
+	observed, err := loadObservedFiles(filepath.Join(artifactLocation, report.DefaultContainerReportFileName))
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	artifacts, err := listTarHeaders(tarPath)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	packagesDirs := findPythonPackagesDirs(artifacts)
+	if len(packagesDirs) == 0 {
+		fail("no.python.packages", ErrNoPythonPackages)
+		return
+	}
+
+	for _, dir := range packagesDirs {
+		info.PackagesDirs = append(info.PackagesDirs, "/"+dir)
+	}
+
+	dists, err := loadPythonDistributions(tarPath, packagesDirs)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	info.PackageCount = len(dists)
+
+	keep := map[string]struct{}{}
+	for _, name := range opts.KeepPackages {
+		keep[normalizePythonPackageName(name)] = struct{}{}
+	}
+
+	//the files owned by each distribution (by the artifact path)
+	owners := map[string]*pyDistribution{}
+	for _, dist := range dists {
+		for name := range dist.files {
+			owners[name] = dist
+		}
+
+		if _, found := keep[normalizePythonPackageName(dist.name)]; found {
+			dist.used = true
+		}
+	}
+
+	for name := range observed {
+		if dist := findPythonFileOwner(name, owners, dists); dist != nil {
+			dist.used = true
+		}
+	}
+
+	removed := map[string]struct{}{}
+	var removedSize int64
+	removeFile := func(name string) {
+		if _, found := removed[name]; found {
+			return
+		}
+
+		removed[name] = struct{}{}
+		if hdr := artifacts[name]; hdr.Typeflag == tar.TypeReg {
+			removedSize += hdr.Size
+		}
+	}
+
+	for _, dist := range dists {
+		if dist.used {
+			continue
+		}
+
+		pkgInfo := &report.PythonPackageInfo{
+			Name:    dist.name,
+			Version: dist.version,
+		}
+
+		for name, hdr := range artifacts {
+			if name != dist.infoDir && !isPathInDir(name, dist.infoDir) && owners[name] != dist &&
+				owners[pyCacheSourcePath(name)] != dist {
+				continue
+			}
+
+			if _, found := removed[name]; found {
+				continue
+			}
+
+			removeFile(name)
+			if hdr.Typeflag == tar.TypeReg {
+				pkgInfo.FileCount++
+				pkgInfo.Size += hdr.Size
+			}
+		}
+
+		info.RemovedPackages = append(info.RemovedPackages, pkgInfo)
+	}
+
+	sort.Slice(info.RemovedPackages, func(i, j int) bool {
+		return info.RemovedPackages[i].Name < info.RemovedPackages[j].Name
+	})
+
+	//the test directories the app used are kept
+	usedTestDirs := map[string]struct{}{}
+	for name := range observed {
+		if dir := findPythonTestDir(name, packagesDirs); dir != "" {
+			usedTestDirs[dir] = struct{}{}
+		}
+	}
+
+	for name, hdr := range artifacts {
+		if _, found := removed[name]; found {
+			continue
+		}
+
+		if isPythonCachePath(name, packagesDirs) {
+			removeFile(name)
+			if hdr.Typeflag == tar.TypeReg {
+				info.RemovedCacheFiles++
+			}
+
+			continue
+		}
+
+		if dir := findPythonTestDir(name, packagesDirs); dir != "" {
+			if _, found := usedTestDirs[dir]; found {
+				continue
+			}
+
+			removeFile(name)
+			if hdr.Typeflag == tar.TypeReg {
+				info.RemovedTestFiles++
+			}
+		}
+	}
+
+	//the parent directories without the remaining files are removed too
+	for _, dir := range findEmptyParentDirs(artifacts, removed, func(dir string) bool {
+		return isPythonPackagesSubdir(dir, packagesDirs)
+	}) {
+		removeFile(dir)
+	}
+
+	if len(removed) > 0 {
+		if err := removeArtifactPaths(tarPath, removed); err != nil {
+			fail("error", err)
+			return
+		}
+	}
+
+	info.Status = "ok"
+	info.RemovedSize = removedSize
+
+	var removedNames []string
+	for _, pkgInfo := range info.RemovedPackages {
+		removedNames = append(removedNames, pkgInfo.Name)
+	}
+
+	xc.Out.Info("python.packages",
+		ovars{
+			"status":             info.Status,
+			"packages":           info.PackageCount,
+			"removed.packages":   strings.Join(removedNames, ","),
+			"removed.cache":      info.RemovedCacheFiles,
+			"removed.tests":      info.RemovedTestFiles,
+			"removed.size.human": humanize.Bytes(uint64(info.RemovedSize)),
+		})
+}
+
+// loadObservedFiles returns the files the monitors saw the app use (relative to the file artifacts root)
+func loadObservedFiles(creportPath string) (map[string]struct{}, error) {
+	creport, err := loadContainerReport(creportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return observedFiles(creport)
+}
+
+func loadContainerReport(creportPath string) (*report.ContainerReport, error) {
+	data, err := ioutil.ReadFile(creportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var creport report.ContainerReport
+	if err := json.Unmarshal(data, &creport); err != nil {
+		return nil, err
+	}
+
+	return &creport, nil
+}
+
+func observedFiles(creport *report.ContainerReport) (map[string]struct{}, error) {
+	observed := map[string]struct{}{}
+	if creport.Monitors.Fan != nil {
+		for _, files := range creport.Monitors.Fan.ProcessFiles {
+			for name := range files {
+				observed[strings.TrimPrefix(name, "/")] = struct{}{}
+			}
+		}
+	}
+
+	//the import system checks a lot of paths (only the opened files are used)
+	if creport.Monitors.Pt != nil {
+		for name, activity := range creport.Monitors.Pt.FSActivity {
+			if activity != nil && !activity.IsSubdir && activity.OpsAll > activity.OpsCheckFile {
+				observed[strings.TrimPrefix(name, "/")] = struct{}{}
+			}
+		}
+	}
+
+	if len(observed) == 0 {
+		return nil, ErrNoMonitorData
+	}
+
+	return observed, nil
+}
+
+func findPythonPackagesDirs(artifacts map[string]*tar.Header) []string {
+	var dirs []string
+	for name, hdr := range artifacts {
+		base := path.Base(name)
+		if hdr.Typeflag == tar.TypeDir &&
+			(base == pySitePackagesDirName || base == pyDistPackagesDirName) {
+			dirs = append(dirs, name)
+		}
+	}
+
+	sort.Strings(dirs)
+	return dirs
+}
+
+// loadPythonDistributions loads the distribution metadata (the dist-info RECORD and the egg-info top_level.txt files)
+func loadPythonDistributions(tarPath string, packagesDirs []string) ([]*pyDistribution, error) {
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer inFile.Close()
+
+	var dists []*pyDistribution
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		infoDir, fileName := path.Split(strings.TrimSuffix(hdr.Name, "/"))
+		infoDir = strings.TrimSuffix(infoDir, "/")
+		packagesDir := path.Dir(infoDir)
+		if !isPythonPackagesDir(packagesDir, packagesDirs) {
+			continue
+		}
+
+		var files []string
+		switch {
+		case strings.HasSuffix(infoDir, pyDistInfoDirSuffix) && fileName == pyRecordFileName:
+			files, err = readPythonRecord(tr)
+		case strings.HasSuffix(infoDir, pyEggInfoDirSuffix) && fileName == pyTopLevelFileName:
+			files, err = readPythonTopLevel(tr)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		name, version := parsePythonInfoDirName(path.Base(infoDir))
+		dist := &pyDistribution{
+			name:        name,
+			version:     version,
+			packagesDir: packagesDir,
+			infoDir:     infoDir,
+			files:       map[string]struct{}{},
+			topLevel:    map[string]struct{}{},
+		}
+
+		for _, file := range files {
+			//the RECORD files can be outside of the packages directory (e.g., the console scripts)
+			name := path.Clean(path.Join(packagesDir, file))
+			if name == infoDir || isPathInDir(name, infoDir) {
+				continue
+			}
+
+			dist.files[name] = struct{}{}
+			if isPathInDir(name, packagesDir) {
+				if topLevel := pythonTopLevelName(strings.TrimPrefix(name, packagesDir+"/")); topLevel != "" {
+					dist.topLevel[path.Join(packagesDir, topLevel)] = struct{}{}
+				}
+			}
+		}
+
+		dists = append(dists, dist)
+	}
+
+	return dists, nil
+}
+
+// readPythonRecord returns the file paths from the dist-info RECORD file (CSV: path,hash,size)
+func readPythonRecord(reader io.Reader) ([]string, error) {
+	cr := csv.NewReader(reader)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, record := range records {
+		if len(record) > 0 && record[0] != "" {
+			files = append(files, record[0])
+		}
+	}
+
+	return files, nil
+}
+
+// readPythonTopLevel returns the top level module and package names from the egg-info top_level.txt file
+func readPythonTopLevel(reader io.Reader) ([]string, error) {
+	var names []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names, scanner.Err()
+}
+
+// parsePythonInfoDirName returns the distribution name and version from the dist-info/egg-info directory name
+// (e.g., 'requests-2.31.0.dist-info' or 'six-1.16.0-py3.11.egg-info')
+func parsePythonInfoDirName(name string) (string, string) {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, pyDistInfoDirSuffix), pyEggInfoDirSuffix)
+	parts := strings.Split(name, "-")
+	if len(parts) == 1 {
+		return name, ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// normalizePythonPackageName normalizes the package names (PEP 503)
+func normalizePythonPackageName(name string) string {
+	return pyPackageNameSeparators.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+}
+
+// pythonTopLevelName returns the top level module or package name for a packages directory file
+func pythonTopLevelName(name string) string {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 {
+		if parts[0] == pyCacheDirName {
+			//the module bytecode file (e.g., '__pycache__/six.cpython-311.pyc')
+			return strings.SplitN(parts[1], ".", 2)[0]
+		}
+
+		return parts[0]
+	}
+
+	if strings.HasSuffix(name, ".pth") {
+		return ""
+	}
+
+	//the module or the extension module file (e.g., 'six.py' or '_cffi_backend.cpython-311-x86_64-linux-gnu.so')
+	return strings.SplitN(name, ".", 2)[0]
+}
+
+// findPythonFileOwner returns the distribution for a used file
+// (the .pth files and the distribution metadata don't make a distribution used)
+func findPythonFileOwner(name string, owners map[string]*pyDistribution, dists []*pyDistribution) *pyDistribution {
+	if strings.HasSuffix(name, ".pth") {
+		return nil
+	}
+
+	if dist, found := owners[name]; found {
+		return dist
+	}
+
+	if dist, found := owners[pyCacheSourcePath(name)]; found {
+		return dist
+	}
+
+	for _, dist := range dists {
+		if !isPathInDir(name, dist.packagesDir) {
+			continue
+		}
+
+		topLevel := pythonTopLevelName(strings.TrimPrefix(name, dist.packagesDir+"/"))
+		if topLevel == "" {
+			continue
+		}
+
+		if _, found := dist.topLevel[path.Join(dist.packagesDir, topLevel)]; found {
+			return dist
+		}
+	}
+
+	return nil
+}
+
+// pyCacheSourcePath returns the module source path for a bytecode cache file
+// (e.g., 'requests/__pycache__/api.cpython-311.pyc' -> 'requests/api.py')
+func pyCacheSourcePath(name string) string {
+	dir, fileName := path.Split(name)
+	if path.Base(dir) != pyCacheDirName || !strings.HasSuffix(fileName, ".pyc") {
+		return ""
+	}
+
+	return path.Join(path.Dir(dir), strings.SplitN(fileName, ".", 2)[0]+".py")
+}
+
+func isPythonPackagesDir(dir string, packagesDirs []string) bool {
+	for _, packagesDir := range packagesDirs {
+		if dir == packagesDir {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isPythonPackagesSubdir(dir string, packagesDirs []string) bool {
+	for _, packagesDir := range packagesDirs {
+		if isPathInDir(dir, packagesDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isPythonCachePath(name string, packagesDirs []string) bool {
+	if !isPythonPackagesSubdir(name, packagesDirs) {
+		return false
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if part == pyCacheDirName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findPythonTestDir returns the package test directory for a packages directory file
+func findPythonTestDir(name string, packagesDirs []string) string {
+	for _, packagesDir := range packagesDirs {
+		if !isPathInDir(name, packagesDir) {
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(name, packagesDir+"/"), "/")
+		//the test directory has to be inside a package (not a top level package)
+		for i := 1; i < len(parts); i++ {
+			if _, found := pyTestDirNames[parts[i]]; found {
+				return path.Join(packagesDir, path.Join(parts[:i+1]...))
+			}
+		}
+	}
+
+	return ""
+}
+
+// findEmptyParentDirs returns the parent directories of the removed objects
+// that will be empty when the objects are removed (only the 'removable' directories are checked)
+func findEmptyParentDirs(
+	artifacts map[string]*tar.Header,
+	removed map[string]struct{},
+	removable func(dir string) bool) []string {
+	//the directory objects don't make their parent directories non-empty
+	nonEmptyDirs := map[string]struct{}{}
+	for name, hdr := range artifacts {
+		if _, found := removed[name]; found || hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			nonEmptyDirs[dir] = struct{}{}
+		}
+	}
+
+	emptyDirs := map[string]struct{}{}
+	for name := range removed {
+		for dir := path.Dir(name); removable(dir); dir = path.Dir(dir) {
+			if _, found := nonEmptyDirs[dir]; found {
+				break
+			}
+
+			if _, found := artifacts[dir]; found {
+				emptyDirs[dir] = struct{}{}
+			}
+		}
+	}
+
+	var dirs []string
+	for dir := range emptyDirs {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+	return dirs
+}
+
+// removeArtifactPaths removes the objects from the file artifacts archive
+// (the 'added' objects without data, like the directories, are added to the archive)
+func removeArtifactPaths(tarPath string, removed map[string]struct{}, added ...*tar.Header) error {
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+
+	defer inFile.Close()
+
+	tmpPath := tarPath + ".tmp"
+	outFile, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpPath)
+
+	tw := tar.NewWriter(outFile)
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			outFile.Close()
+			return err
+		}
+
+		if _, found := removed[strings.TrimSuffix(hdr.Name, "/")]; found {
+			continue
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			outFile.Close()
+			return err
+		}
+
+		if _, err := fsutil.CopyStream(tw, tr); err != nil {
+			outFile.Close()
+			return err
+		}
+	}
+
+	for _, hdr := range added {
+		if err := tw.WriteHeader(hdr); err != nil {
+			outFile.Close()
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		outFile.Close()
+		return err
+	}
+
+	if err := outFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, tarPath)
+}
@@ -0,0 +1,194 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const (
+	sourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+	reproducibleDirName   = "reproducible"
+)
+
+// reproducibleTime returns the fixed timestamp for the reproducible builds
+// (SOURCE_DATE_EPOCH if it's set or the Unix epoch otherwise)
+func reproducibleTime() time.Time {
+	if val := os.Getenv(sourceDateEpochEnvVar); val != "" {
+		if secs, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+
+		log.Debugf("reproducibleTime: bad %s value - '%s'", sourceDateEpochEnvVar, val)
+	}
+
+	return time.Unix(0, 0).UTC()
+}
+
+// normalizeFileArtifacts sorts the file artifacts and sets their timestamps to the fixed time
+// before they are added to the optimized image
+func normalizeFileArtifacts(artifactLocation string, modTime time.Time) error {
+	tarPath := filepath.Join(artifactLocation, fileArtifactsTar)
+	if _, err := os.Stat(tarPath); err == nil {
+		return dockerutil.NormalizeDataArchive(tarPath, modTime)
+	}
+
+	//the file artifacts are not archived when the local mounts are used
+	dirPath := filepath.Join(artifactLocation, fileArtifactsDir)
+	if _, err := os.Stat(dirPath); err != nil {
+		return nil
+	}
+
+	return filepath.Walk(dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			return nil
+		}
+
+		return os.Chtimes(filePath, modTime, modTime)
+	})
+}
+
+// makeImageReproducible replaces the build specific values in the optimized image config
+// (the creation timestamps and the build container info), so the image ID doesn't change
+// when the same optimized image is built again
+func makeImageReproducible(
+	xc *app.ExecutionContext,
+	minifiedImageName string,
+	additionalTags []string,
+	modTime time.Time,
+	artifactLocation string,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	workDir := filepath.Join(artifactLocation, reproducibleDirName)
+	defer os.RemoveAll(workDir)
+
+	tags := []string{imageTagName(minifiedImageName)}
+	for _, tag := range additionalTags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, imageTagName(tag))
+		}
+	}
+
+	imageID, err := rewriteImageConfig(client, minifiedImageName, tags, modTime, workDir)
+	if err != nil {
+		logger.Errorf("makeImageReproducible: error - %v", err)
+		xc.Out.Info("reproducible.image",
+			ovars{
+				"status": "error",
+				"error":  err.Error(),
+			})
+		return
+	}
+
+	cmdReport.Reproducible = true
+	xc.Out.Info("reproducible.image",
+		ovars{
+			"id":      imageID,
+			"created": modTime.Format(time.RFC3339),
+		})
+}
+
+func rewriteImageConfig(
+	client *dockerapi.Client,
+	imageRef string,
+	tags []string,
+	modTime time.Time,
+	workDir string) (string, error) {
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return "", err
+	}
+
+	if err := dockerutil.SaveImage(client, imageRef, filepath.Join(workDir, imageArchiveName), true, true); err != nil {
+		return "", err
+	}
+
+	manifest, config, err := loadImageArchiveMetadata(workDir)
+	if err != nil {
+		return "", err
+	}
+
+	created := modTime.Format(time.RFC3339)
+	config["created"] = created
+	delete(config, "container")
+	delete(config, "container_config")
+	if history, ok := config["history"].([]interface{}); ok {
+		for _, record := range history {
+			if fields, ok := record.(map[string]interface{}); ok {
+				fields["created"] = created
+			}
+		}
+	}
+
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	imageID := fmt.Sprintf("%s%x", digestPrefix, sha256.Sum256(configData))
+	oldImage, err := client.InspectImage(imageRef)
+	if err != nil {
+		return "", err
+	}
+
+	if oldImage.ID == imageID {
+		return imageID, nil
+	}
+
+	if err := os.Remove(filepath.Join(workDir, manifest.Config)); err != nil {
+		return "", err
+	}
+
+	configName := strings.TrimPrefix(imageID, digestPrefix) + ".json"
+	if err := ioutil.WriteFile(filepath.Join(workDir, configName), configData, 0644); err != nil {
+		return "", err
+	}
+
+	manifestData, err := json.Marshal([]dockerimage.ManifestObject{
+		{
+			Config:   configName,
+			RepoTags: tags,
+			Layers:   manifest.Layers,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(workDir, imageManifestName), manifestData, 0644); err != nil {
+		return "", err
+	}
+
+	//the 'repositories' file has the old image tags
+	os.Remove(filepath.Join(workDir, "repositories"))
+
+	if err := imageformat.LoadImageDir(client, workDir); err != nil {
+		return "", err
+	}
+
+	if err := client.RemoveImage(oldImage.ID); err != nil {
+		log.Debugf("rewriteImageConfig: error removing the original build image (%s) - %v", oldImage.ID, err)
+	}
+
+	return imageID, nil
+}
@@ -0,0 +1,191 @@
+package build
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/sbom"
+	"github.com/docker-slim/docker-slim/pkg/system"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// saveSlimImageSBOM generates the SBOM for the optimized image
+// from the package databases kept in the optimized image and the executable files in the container report
+func saveSlimImageSBOM(
+	xc *app.ExecutionContext,
+	format string,
+	output string,
+	minifiedImageName string,
+	artifactLocation string,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	creportPath := filepath.Join(artifactLocation, report.DefaultContainerReportFileName)
+	var creport report.ContainerReport
+	if err := fsutil.LoadStructFromFile(creportPath, &creport); err != nil {
+		logger.Errorf("saveSlimImageSBOM: error loading container report - %v", err)
+		xc.Out.Info("sbom",
+			ovars{
+				"format": format,
+				"error":  err.Error(),
+			})
+		return
+	}
+
+	doc := &sbom.Document{
+		ImageName:   minifiedImageName,
+		ToolVersion: v.Current(),
+		Distro: &system.DistroInfo{
+			Name:        creport.System.Distro.Name,
+			Version:     creport.System.Distro.Version,
+			DisplayName: creport.System.Distro.DisplayName,
+		},
+	}
+
+	if imageInfo, err := client.InspectImage(minifiedImageName); err == nil {
+		doc.ImageID = imageInfo.ID
+	} else {
+		logger.Debugf("saveSlimImageSBOM: error inspecting optimized image - %v", err)
+	}
+
+	packageDBs := map[string]string{}
+	for _, info := range creport.Image.Files {
+		if info.FileType == report.DirArtifactType || info.FileType == report.SymlinkArtifactType {
+			continue
+		}
+
+		if dbType := system.OSPackageDBType(info.FilePath); dbType != "" {
+			packageDBs[info.FilePath] = dbType
+			continue
+		}
+
+		//FileMode string format (e.g., '-rwxr-xr-x')
+		if mode := info.ModeText; len(mode) >= 9 && strings.Contains(mode[len(mode)-9:], "x") {
+			doc.Files = append(doc.Files, &sbom.File{
+				Path: info.FilePath,
+				Size: info.FileSize,
+				SHA1: info.Sha1Hash,
+			})
+		}
+	}
+
+	for path, data := range slimFilesData(artifactLocation, packageDBs, logger) {
+		packages, err := system.NewOSPackagesFromData(packageDBs[path], data)
+		if err != nil {
+			logger.Debugf("saveSlimImageSBOM: error parsing package database (%s) - %v", path, err)
+			continue
+		}
+
+		for _, p := range packages {
+			doc.Packages = append(doc.Packages, &sbom.Package{
+				Name:    p.Name,
+				Version: p.Version,
+				Arch:    p.Arch,
+				Type:    packageDBs[path],
+			})
+		}
+	}
+
+	if output == "" {
+		output = filepath.Join(artifactLocation, sbom.DefaultFileName(format))
+	}
+
+	if err := sbom.Save(format, output, doc); err != nil {
+		logger.Errorf("saveSlimImageSBOM: error saving SBOM - %v", err)
+		xc.Out.Info("sbom",
+			ovars{
+				"format": format,
+				"error":  err.Error(),
+			})
+		return
+	}
+
+	cmdReport.SBOM = &report.SBOMInfo{
+		Format:       format,
+		Location:     output,
+		PackageCount: len(doc.Packages),
+		FileCount:    len(doc.Files),
+	}
+
+	xc.Out.Info("sbom",
+		ovars{
+			"format":   format,
+			"location": output,
+			"packages": len(doc.Packages),
+			"files":    len(doc.Files),
+		})
+}
+
+// slimFilesData reads the selected files from the optimized image file artifacts
+// (from the file artifacts archive or from the file artifacts directory)
+func slimFilesData(artifactLocation string, paths map[string]string, logger *log.Entry) map[string][]byte {
+	result := map[string][]byte{}
+	if len(paths) == 0 {
+		return result
+	}
+
+	tarPath := filepath.Join(artifactLocation, fileArtifactsTar)
+	if !fsutil.IsRegularFile(tarPath) {
+		for path := range paths {
+			data, err := ioutil.ReadFile(filepath.Join(artifactLocation, fileArtifactsDir, path))
+			if err != nil {
+				logger.Debugf("slimFilesData: error reading file (%s) - %v", path, err)
+				continue
+			}
+
+			result[path] = data
+		}
+
+		return result
+	}
+
+	tfile, err := os.Open(tarPath)
+	if err != nil {
+		logger.Debugf("slimFilesData: error opening file artifacts archive - %v", err)
+		return result
+	}
+
+	defer tfile.Close()
+	tr := tar.NewReader(tfile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			logger.Debugf("slimFilesData: error reading file artifacts archive - %v", err)
+			break
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		path := filepath.Clean("/" + hdr.Name)
+		if _, found := paths[path]; !found {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			logger.Debugf("slimFilesData: error reading file (%s) - %v", path, err)
+			continue
+		}
+
+		result[path] = data
+	}
+
+	return result
+}
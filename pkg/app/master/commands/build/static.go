@@ -0,0 +1,461 @@
+package build
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/certdiscover"
+	"github.com/docker-slim/docker-slim/pkg/elfaudit"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+// Static binary scratch output file reasons (why the files are kept or excluded)
+const (
+	staticReasonAppBinary     = "app.binary"
+	staticReasonUsed          = "used.by.app"
+	staticReasonCerts         = "ca.certs"
+	staticReasonUsers         = "users"
+	staticReasonIncluded      = "included"
+	staticReasonLibrary       = "shared.library"
+	staticReasonExecutable    = "executable.not.run"
+	staticReasonLinkerConfig  = "linker.config"
+	staticReasonNotUsed       = "not.used.by.app"
+	staticReasonBrokenSymlink = "symlink.to.excluded"
+)
+
+// the user and group files are needed to run the app as a named (non-root) user
+var staticUserFiles = map[string]struct{}{
+	"etc/passwd": {},
+	"etc/group":  {},
+}
+
+var staticTmpDirs = []string{"tmp", "var/tmp"}
+
+const staticMaxSymlinkDepth = 16
+
+var ErrNoProcessData = errors.New("no process data in the container report")
+var ErrNotSingleBinary = errors.New("the app runs more than one executable")
+var ErrNotStaticBinary = errors.New("the app executable is not a static binary")
+
+// processStaticBinary detects if the app is a single static binary
+// (one executable with no program interpreter and no shared library dependencies).
+// In the 'static scratch' mode the file artifacts are reduced to the app binary,
+// the files the app used, the CA certificates, the user files and the tmp directories.
+func processStaticBinary(
+	xc *app.ExecutionContext,
+	doScratch bool,
+	includePaths []string,
+	artifactLocation string,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	info := &report.StaticBinaryInfo{}
+
+	fail := func(status string, err error) {
+		logger.Debugf("processStaticBinary: %s - %v", status, err)
+		if !doScratch {
+			return
+		}
+
+		info.Status = status
+		info.Error = err.Error()
+		cmdReport.StaticBinary = info
+		xc.Out.Info("static.binary",
+			ovars{
+				"status":  status,
+				"error":   err.Error(),
+				"message": "keeping all file artifacts",
+			})
+	}
+
+	tarPath := filepath.Join(artifactLocation, fileArtifactsTar)
+	if !fsutil.IsRegularFile(tarPath) {
+		fail("error", ErrNoFileArtifactsTar)
+		return
+	}
+
+	creport, err := loadContainerReport(filepath.Join(artifactLocation, report.DefaultContainerReportFileName))
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	if creport.Monitors.Fan == nil || len(creport.Monitors.Fan.Processes) == 0 {
+		fail("error", ErrNoProcessData)
+		return
+	}
+
+	exePaths := map[string]struct{}{}
+	for _, pinfo := range creport.Monitors.Fan.Processes {
+		if pinfo != nil && pinfo.Path != "" {
+			exePaths[strings.TrimPrefix(pinfo.Path, "/")] = struct{}{}
+		}
+	}
+
+	if len(exePaths) != 1 {
+		fail("not.detected", ErrNotSingleBinary)
+		return
+	}
+
+	var binPath string
+	for name := range exePaths {
+		binPath = name
+	}
+
+	binData, err := readArtifactFile(tarPath, binPath)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	object, err := elfaudit.ParseELF(bytes.NewReader(binData))
+	if err != nil || object == nil || !object.Static {
+		fail("not.detected", ErrNotStaticBinary)
+		return
+	}
+
+	info.Path = "/" + binPath
+	info.Size = int64(len(binData))
+	cmdReport.StaticBinary = info
+
+	if !doScratch {
+		info.Status = "detected"
+		xc.Out.Info("static.binary",
+			ovars{
+				"status":  info.Status,
+				"path":    info.Path,
+				"message": "the app is a single static binary (use the --static-scratch flag to keep only the binary, the files it uses, the CA certificates and the tmp directories)",
+			})
+		return
+	}
+
+	observed, err := observedFiles(creport)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	//the included binaries need their dependencies
+	for _, depsInfo := range creport.Image.IncludeDeps {
+		for _, name := range depsInfo.Files {
+			includePaths = append(includePaths, strings.Trim(name, "/"))
+		}
+	}
+
+	artifacts, elfFiles, err := listArtifactsWithELF(tarPath)
+	if err != nil {
+		fail("error", err)
+		return
+	}
+
+	kept := map[string]string{}
+	excluded := map[string]string{}
+	for name, hdr := range artifacts {
+		if hdr.Typeflag == tar.TypeDir || hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+			continue
+		}
+
+		if reason := staticKeepReason(name, binPath, observed, includePaths); reason != "" {
+			kept[name] = reason
+			continue
+		}
+
+		switch {
+		case elfFiles[name] && isSharedLibraryName(name):
+			excluded[name] = staticReasonLibrary
+		case elfFiles[name]:
+			excluded[name] = staticReasonExecutable
+		case strings.HasPrefix(name, "etc/ld.so."):
+			excluded[name] = staticReasonLinkerConfig
+		default:
+			excluded[name] = staticReasonNotUsed
+		}
+	}
+
+	//the hard links are kept with their targets
+	for name, hdr := range artifacts {
+		if hdr.Typeflag != tar.TypeLink {
+			continue
+		}
+
+		target := strings.TrimSuffix(hdr.Linkname, "/")
+		if reason := staticKeepReason(name, binPath, observed, includePaths); reason != "" {
+			kept[name] = reason
+			if _, found := excluded[target]; found {
+				delete(excluded, target)
+				kept[target] = reason
+			}
+
+			continue
+		}
+
+		if reason, found := kept[target]; found {
+			kept[name] = reason
+			continue
+		}
+
+		excluded[name] = staticReasonNotUsed
+		if reason, found := excluded[target]; found {
+			excluded[name] = reason
+		}
+	}
+
+	//the symlinks are kept if they point to the kept files (the cert directory symlinks are kept too)
+	for name, hdr := range artifacts {
+		if hdr.Typeflag != tar.TypeSymlink {
+			continue
+		}
+
+		if reason := staticKeepReason(name, binPath, observed, includePaths); reason != "" {
+			kept[name] = reason
+			continue
+		}
+
+		if target := resolveArtifactSymlink(name, artifacts); target != "" {
+			if _, found := kept[target]; found {
+				kept[name] = kept[target]
+				continue
+			}
+		}
+
+		excluded[name] = staticReasonBrokenSymlink
+	}
+
+	//the directories are kept if they have the kept objects (or if they are the tmp directories)
+	keptDirs := map[string]struct{}{}
+	for name := range kept {
+		for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			keptDirs[dir] = struct{}{}
+		}
+	}
+
+	for _, name := range staticTmpDirs {
+		for dir := name; dir != "." && dir != "/"; dir = path.Dir(dir) {
+			keptDirs[dir] = struct{}{}
+		}
+	}
+
+	removed := map[string]struct{}{}
+	for name := range excluded {
+		removed[name] = struct{}{}
+		if hdr := artifacts[name]; hdr.Typeflag == tar.TypeReg {
+			info.ExcludedSize += hdr.Size
+		}
+	}
+
+	for name, hdr := range artifacts {
+		if hdr.Typeflag != tar.TypeDir {
+			continue
+		}
+
+		if _, found := keptDirs[name]; found {
+			continue
+		}
+
+		removed[name] = struct{}{}
+	}
+
+	var tmpDirs []string
+	var added []*tar.Header
+	for _, dir := range staticTmpDirs {
+		tmpDirs = append(tmpDirs, "/"+dir)
+		if _, found := artifacts[dir]; found {
+			continue
+		}
+
+		added = append(added, &tar.Header{
+			Name:     dir + "/",
+			Typeflag: tar.TypeDir,
+			Mode:     01777,
+			ModTime:  time.Now(),
+		})
+	}
+
+	if err := removeArtifactPaths(tarPath, removed, added...); err != nil {
+		fail("error", err)
+		return
+	}
+
+	for name, reason := range kept {
+		info.KeptFiles = append(info.KeptFiles, &report.StaticBinaryFileInfo{Path: "/" + name, Reason: reason})
+	}
+
+	for name, reason := range excluded {
+		info.ExcludedFiles = append(info.ExcludedFiles, &report.StaticBinaryFileInfo{Path: "/" + name, Reason: reason})
+	}
+
+	sortStaticBinaryFiles(info.KeptFiles)
+	sortStaticBinaryFiles(info.ExcludedFiles)
+	info.TmpDirs = tmpDirs
+	info.Status = "scratch"
+
+	xc.Out.Info("static.binary",
+		ovars{
+			"status":              info.Status,
+			"path":                info.Path,
+			"kept.files":          len(info.KeptFiles),
+			"excluded.files":      len(info.ExcludedFiles),
+			"excluded.size.human": humanize.Bytes(uint64(info.ExcludedSize)),
+			"tmp.dirs":            strings.Join(info.TmpDirs, ","),
+		})
+
+	for _, fileInfo := range info.ExcludedFiles {
+		xc.Out.Info("static.binary.excluded",
+			ovars{
+				"path":   fileInfo.Path,
+				"reason": fileInfo.Reason,
+			})
+	}
+}
+
+// staticKeepReason returns the reason to keep the file in the static binary output (empty if the file is not kept)
+func staticKeepReason(name, binPath string, observed map[string]struct{}, includePaths []string) string {
+	if name == binPath {
+		return staticReasonAppBinary
+	}
+
+	if _, found := observed[name]; found {
+		return staticReasonUsed
+	}
+
+	if isCACertPath("/" + name) {
+		return staticReasonCerts
+	}
+
+	if _, found := staticUserFiles[name]; found {
+		return staticReasonUsers
+	}
+
+	for _, includePath := range includePaths {
+		if name == includePath || isPathInDir(name, includePath) {
+			return staticReasonIncluded
+		}
+	}
+
+	return ""
+}
+
+func isCACertPath(name string) bool {
+	if certdiscover.IsCertFile(name) ||
+		certdiscover.IsCACertFile(name) ||
+		certdiscover.IsCertDirPath(name) ||
+		certdiscover.IsCACertDirPath(name) {
+		return true
+	}
+
+	for _, dir := range certdiscover.CertExtraDirList() {
+		if isPathInDir(name, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSharedLibraryName(name string) bool {
+	base := path.Base(name)
+	return strings.HasSuffix(base, ".so") || strings.Contains(base, ".so.")
+}
+
+// resolveArtifactSymlink returns the final symlink target in the file artifacts (empty if it's not there)
+func resolveArtifactSymlink(name string, artifacts map[string]*tar.Header) string {
+	for i := 0; i < staticMaxSymlinkDepth; i++ {
+		hdr, found := artifacts[name]
+		if !found {
+			return ""
+		}
+
+		if hdr.Typeflag != tar.TypeSymlink {
+			return name
+		}
+
+		if path.IsAbs(hdr.Linkname) {
+			name = strings.TrimPrefix(path.Clean(hdr.Linkname), "/")
+		} else {
+			name = path.Join(path.Dir(name), hdr.Linkname)
+		}
+	}
+
+	return ""
+}
+
+func sortStaticBinaryFiles(files []*report.StaticBinaryFileInfo) {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+}
+
+// readArtifactFile returns the file data from the file artifacts archive
+func readArtifactFile(tarPath, name string) ([]byte, error) {
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer inFile.Close()
+
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, os.ErrNotExist
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Name == name && hdr.Typeflag == tar.TypeReg {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+// listArtifactsWithELF returns the archive object headers and the ELF files in the file artifacts archive
+func listArtifactsWithELF(tarPath string) (map[string]*tar.Header, map[string]bool, error) {
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer inFile.Close()
+
+	headers := map[string]*tar.Header{}
+	elfFiles := map[string]bool{}
+	head := make([]byte, 4)
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return headers, elfFiles, nil
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		name := strings.TrimSuffix(hdr.Name, "/")
+		headers[name] = hdr
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if n, _ := io.ReadFull(tr, head); elfaudit.IsELFData(head[:n]) {
+			elfFiles[name] = true
+		}
+	}
+}
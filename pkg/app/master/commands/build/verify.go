@@ -0,0 +1,395 @@
+package build
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/http"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	//the expanded include directories need to have at least this many path components
+	//(to avoid including the top level system directories like /usr or /etc)
+	minExpandDirDepth = 2
+)
+
+var ErrNoFileArtifactsTar = errors.New("no file artifacts archive")
+
+// verifySlimImage probes the optimized image and rebuilds it with the expanded includes
+// (up to maxRetries times) if the verification fails
+func verifySlimImage(
+	xc *app.ExecutionContext,
+	minifiedImageName string,
+	maxRetries int,
+	rebuild func() (string, error),
+	overrides *config.ContainerOverrides,
+	httpProbeOpts config.HTTPProbeOptions,
+	imageInspector *image.Inspector,
+	client *dockerapi.Client,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) (string, error) {
+	var keptPaths []string
+	expanded := map[string]struct{}{}
+	var addedPaths []string
+
+	for round := 0; ; round++ {
+		xc.Out.State("verify.slim.image",
+			ovars{
+				"round": round,
+				"image": minifiedImageName,
+			})
+
+		roundInfo := &report.VerifyRound{
+			Round:      round,
+			AddedPaths: addedPaths,
+		}
+
+		cmdReport.Verification = append(cmdReport.Verification, roundInfo)
+
+		probe, err := commands.RunImageProbe(xc, client, minifiedImageName, overrides, httpProbeOpts, nil, false)
+		if err == commands.ErrNoProbePorts {
+			roundInfo.Error = err.Error()
+			xc.Out.Info("verify.slim.image",
+				ovars{
+					"status":  "skipped",
+					"message": "no ports to probe in the optimized image",
+				})
+			return minifiedImageName, nil
+		}
+
+		if err != nil {
+			roundInfo.Error = err.Error()
+		} else {
+			roundInfo.CallCount = probe.CallCount
+			roundInfo.OkCount = probe.OkCount
+			roundInfo.ErrorCount = probe.ErrCount
+			roundInfo.Passed = verifyPassed(probe, httpProbeOpts)
+		}
+
+		xc.Out.Info("verify.slim.image",
+			ovars{
+				"round":  round,
+				"passed": roundInfo.Passed,
+				"calls":  roundInfo.CallCount,
+				"ok":     roundInfo.OkCount,
+				"errors": roundInfo.ErrorCount,
+			})
+
+		if roundInfo.Passed {
+			return minifiedImageName, nil
+		}
+
+		if round >= maxRetries {
+			break
+		}
+
+		if keptPaths == nil {
+			keptPaths, err = loadKeptPaths(imageInspector.ArtifactLocation)
+			if err != nil {
+				logger.Errorf("verifySlimImage: error loading container report - %v", err)
+				break
+			}
+		}
+
+		expandDirs := expandedIncludeDirs(keptPaths, round+1, expanded)
+		if len(expandDirs) == 0 {
+			xc.Out.Info("verify.slim.image",
+				ovars{
+					"message": "no more paths to include",
+				})
+			break
+		}
+
+		addedPaths, err = addArtifactPaths(client, imageInspector.ImageRef, imageInspector.ArtifactLocation, expandDirs)
+		if err == nil && len(addedPaths) == 0 {
+			xc.Out.Info("verify.slim.image",
+				ovars{
+					"message": "no more paths to include",
+				})
+			break
+		}
+
+		if err != nil {
+			logger.Errorf("verifySlimImage: error adding paths - %v", err)
+			xc.Out.Info("verify.slim.image",
+				ovars{
+					"message": "could not expand the included paths",
+					"error":   err.Error(),
+				})
+			break
+		}
+
+		xc.Out.Info("verify.slim.image.expand",
+			ovars{
+				"round": round + 1,
+				"paths": strings.Join(addedPaths, ","),
+			})
+
+		minifiedImageName, err = rebuild()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	exitCode := commands.ECTBuild | ecbSlimImageVerifyFailure
+	xc.Out.State("exited",
+		ovars{
+			"exit.code": exitCode,
+		})
+
+	cmdReport.Error = "slim.image.verify.failure"
+	return "", app.NewExitError(exitCode)
+}
+
+func verifyPassed(probe *http.CustomProbe, httpProbeOpts config.HTTPProbeOptions) bool {
+	if probe.CallCount == 0 {
+		return false
+	}
+
+	if httpProbeOpts.FailThreshold >= 0 {
+		return !probe.FailureThresholdExceeded()
+	}
+
+	return probe.CmdFailCount == 0
+}
+
+func loadKeptPaths(artifactLocation string) ([]string, error) {
+	creportPath := filepath.Join(artifactLocation, report.DefaultContainerReportFileName)
+	creportData, err := ioutil.ReadFile(creportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var creport report.ContainerReport
+	if err := json.Unmarshal(creportData, &creport); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, info := range creport.Image.Files {
+		if info != nil {
+			paths = append(paths, info.FilePath)
+		}
+	}
+
+	return paths, nil
+}
+
+// expandedIncludeDirs returns the new directories to include:
+// the parent directories of the kept files in the first expansion round,
+// their parent directories in the second round and so on
+func expandedIncludeDirs(keptPaths []string, level int, expanded map[string]struct{}) []string {
+	candidates := map[string]struct{}{}
+	for _, fp := range keptPaths {
+		dir := fp
+		for i := 0; i < level; i++ {
+			dir = filepath.Dir(dir)
+		}
+
+		if len(strings.Split(strings.Trim(dir, "/"), "/")) < minExpandDirDepth {
+			continue
+		}
+
+		candidates[dir] = struct{}{}
+	}
+
+	var dirs []string
+	for dir := range candidates {
+		if isExpandedPath(dir, expanded) {
+			continue
+		}
+
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+
+	//skip the nested directories (their parent directories are already selected)
+	var result []string
+	for _, dir := range dirs {
+		if len(result) > 0 && strings.HasPrefix(dir, result[len(result)-1]+"/") {
+			continue
+		}
+
+		result = append(result, dir)
+		expanded[dir] = struct{}{}
+	}
+
+	return result
+}
+
+func isExpandedPath(dir string, expanded map[string]struct{}) bool {
+	for p := dir; p != "/" && p != "."; p = filepath.Dir(p) {
+		if _, found := expanded[p]; found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addArtifactPaths copies the selected directories from the original image
+// to the file artifacts archive used to build the optimized image
+func addArtifactPaths(client *dockerapi.Client, imageRef, artifactLocation string, dirs []string) ([]string, error) {
+	tarPath := filepath.Join(artifactLocation, fileArtifactsTar)
+	inFile, err := os.Open(tarPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoFileArtifactsTar
+		}
+
+		return nil, err
+	}
+
+	defer inFile.Close()
+
+	tmpPath := tarPath + ".tmp"
+	outFile, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(tmpPath)
+
+	tw := tar.NewWriter(outFile)
+	names := map[string]struct{}{}
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			outFile.Close()
+			return nil, err
+		}
+
+		names[strings.TrimSuffix(hdr.Name, "/")] = struct{}{}
+		if err := tw.WriteHeader(hdr); err != nil {
+			outFile.Close()
+			return nil, err
+		}
+
+		if _, err := fsutil.CopyStream(tw, tr); err != nil {
+			outFile.Close()
+			return nil, err
+		}
+	}
+
+	containerID, err := createInactiveContainer(client, imageRef)
+	if err != nil {
+		outFile.Close()
+		return nil, err
+	}
+
+	defer removeInactiveContainer(client, containerID)
+
+	var added []string
+	for _, dir := range dirs {
+		count, err := copyContainerDir(client, containerID, dir, tw, names)
+		if err != nil {
+			log.Debugf("addArtifactPaths: error copying '%s' - %v", dir, err)
+			continue
+		}
+
+		if count > 0 {
+			added = append(added, dir)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		outFile.Close()
+		return nil, err
+	}
+
+	if err := outFile.Close(); err != nil {
+		return nil, err
+	}
+
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	return added, os.Rename(tmpPath, tarPath)
+}
+
+// copyContainerDir writes the container directory objects (not already in the archive) to the archive
+func copyContainerDir(
+	client *dockerapi.Client,
+	containerID string,
+	dir string,
+	tw *tar.Writer,
+	names map[string]struct{}) (int, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		err := client.DownloadFromContainer(containerID, dockerapi.DownloadFromContainerOptions{
+			Path:         dir,
+			OutputStream: writer,
+		})
+		writer.CloseWithError(err)
+	}()
+
+	defer reader.Close()
+
+	//the archive object names start with the base name of the selected directory
+	namePrefix := strings.TrimPrefix(filepath.Dir(dir), "/")
+
+	var count int
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return count, err
+		}
+
+		hdr.Name = filepath.Join(namePrefix, hdr.Name)
+		if hdr.Typeflag == tar.TypeDir {
+			hdr.Name += "/"
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			//hard link targets are relative to the selected directory too
+			hdr.Linkname = filepath.Join(namePrefix, hdr.Linkname)
+		}
+
+		name := strings.TrimSuffix(hdr.Name, "/")
+		if _, found := names[name]; found {
+			continue
+		}
+
+		names[name] = struct{}{}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return count, err
+		}
+
+		if _, err := fsutil.CopyStream(tw, tr); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,102 @@
+package build
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/vulnscan"
+)
+
+// scanImageVulnerabilities scans the original and the optimized images
+// and reports the vulnerabilities removed by the optimization
+func scanImageVulnerabilities(
+	xc *app.ExecutionContext,
+	scanner string,
+	scannerExe string,
+	originalImageName string,
+	minifiedImageName string,
+	logger *log.Entry,
+	cmdReport *report.BuildCommand,
+) {
+	xc.Out.State("scan.vulns.start",
+		ovars{
+			"scanner": scanner,
+		})
+
+	vulnReport := &report.VulnScanReport{
+		Scanner: scanner,
+	}
+
+	cmdReport.Vulnerabilities = vulnReport
+
+	scanImage := func(name string) (*vulnscan.Result, *report.VulnScanSummary) {
+		summary := &report.VulnScanSummary{Image: name}
+		result, err := vulnscan.Scan(scanner, scannerExe, name)
+		if err != nil {
+			logger.Errorf("scanImageVulnerabilities: error scanning image (%s) - %v", name, err)
+			summary.Error = err.Error()
+			xc.Out.Info("scan.vulns.error",
+				ovars{
+					"image": name,
+					"error": err.Error(),
+				})
+			return nil, summary
+		}
+
+		summary.Total = len(result.Vulnerabilities)
+		summary.BySeverity = result.CountBySeverity()
+		return result, summary
+	}
+
+	original, originalSummary := scanImage(originalImageName)
+	vulnReport.Original = originalSummary
+	minified, minifiedSummary := scanImage(minifiedImageName)
+	vulnReport.Minified = minifiedSummary
+
+	if original == nil || minified == nil {
+		xc.Out.State("scan.vulns.done",
+			ovars{
+				"status": "failed",
+			})
+		return
+	}
+
+	delta := vulnscan.Compare(original, minified)
+	vulnReport.Removed = delta.Removed
+	vulnReport.Added = delta.Added
+	vulnReport.Reduction = map[string]int{}
+	for _, severity := range []string{
+		vulnscan.SeverityCritical,
+		vulnscan.SeverityHigh,
+		vulnscan.SeverityMedium,
+		vulnscan.SeverityLow,
+		vulnscan.SeverityUnknown,
+	} {
+		removed := originalSummary.BySeverity[severity] - minifiedSummary.BySeverity[severity]
+		if removed != 0 {
+			vulnReport.Reduction[severity] = removed
+		}
+
+		xc.Out.Info("scan.vulns.severity",
+			ovars{
+				"severity": severity,
+				"original": originalSummary.BySeverity[severity],
+				"minified": minifiedSummary.BySeverity[severity],
+			})
+	}
+
+	xc.Out.Info("scan.vulns",
+		ovars{
+			"scanner":  scanner,
+			"original": originalSummary.Total,
+			"minified": minifiedSummary.Total,
+			"removed":  len(delta.Removed),
+			"added":    len(delta.Added),
+		})
+
+	xc.Out.State("scan.vulns.done",
+		ovars{
+			"status": "ok",
+		})
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	Name  = "cache"
+	Usage = "Manage the cached analysis results"
+	Alias = "ch"
+
+	ListCmdName       = "list"
+	ListCmdNameUsage  = "List the cached analysis results"
+	ClearCmdName      = "clear"
+	ClearCmdNameUsage = "Remove the cached analysis results"
+)
+
+func fullCmdName(subCmdName string) string {
+	return fmt.Sprintf("%s.%s", Name, subCmdName)
+}
+
+type CommandParams struct {
+	TargetRef string
+	OlderThan string
+}
+
+func CommandFlagValues(ctx *cli.Context) (*CommandParams, error) {
+	values := &CommandParams{
+		TargetRef: ctx.String(commands.FlagTarget),
+		OlderThan: ctx.String(FlagOlderThan),
+	}
+
+	if values.TargetRef == "" && ctx.Args().Len() > 0 {
+		values.TargetRef = ctx.Args().First()
+	}
+
+	return values, nil
+}
+
+var CLI = &cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Subcommands: []*cli.Command{
+		{
+			Name:  ListCmdName,
+			Usage: ListCmdNameUsage,
+			Flags: []cli.Flag{
+				commands.Cflag(commands.FlagTarget),
+			},
+			Action: func(ctx *cli.Context) error {
+				xc := app.NewExecutionContext(fullCmdName(ListCmdName), ctx.String(commands.FlagConsoleFormat))
+
+				gcvalues, err := commands.GlobalFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				cparams, err := CommandFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				OnListCommand(xc, gcvalues, cparams)
+				return nil
+			},
+		},
+		{
+			Name:  ClearCmdName,
+			Usage: ClearCmdNameUsage,
+			Flags: []cli.Flag{
+				commands.Cflag(commands.FlagTarget),
+				cflag(FlagOlderThan),
+			},
+			Action: func(ctx *cli.Context) error {
+				xc := app.NewExecutionContext(fullCmdName(ClearCmdName), ctx.String(commands.FlagConsoleFormat))
+
+				gcvalues, err := commands.GlobalFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				cparams, err := CommandFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				OnClearCommand(xc, gcvalues, cparams)
+				return nil
+			},
+		},
+	},
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Cache command flag names
+const (
+	FlagOlderThan = "older-than"
+)
+
+// Cache command flag usage info
+const (
+	FlagOlderThanUsage = "Remove only the cached results older than the duration (e.g., 24h)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagOlderThan: &cli.StringFlag{
+		Name:    FlagOlderThan,
+		Value:   "",
+		Usage:   FlagOlderThanUsage,
+		EnvVars: []string{"DSLIM_CACHE_OLDER_THAN"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	resultcache "github.com/docker-slim/docker-slim/pkg/app/master/cache"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// Cache command exit codes
+const (
+	eccOther = iota + 1
+	eccBadOlderThan
+)
+
+// exitCodes documents the cache command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTCache | eccOther, Name: "cache.other", Description: "other cache command error"},
+	{Code: commands.ECTCache | eccBadOlderThan, Name: "cache.bad.older.than", Description: "invalid --older-than value"},
+}
+
+// OnListCommand implements the 'cache list' docker-slim command
+func OnListCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	cmdName := fullCmdName(ListCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	xc.Out.State("started")
+
+	store := resultcache.NewStore(gparams.StatePath)
+	entries, err := store.List(resolveImageID(gparams, cparams.TargetRef, logger))
+	xc.FailOn(err)
+
+	var totalSize int64
+	for _, entry := range entries {
+		totalSize += entry.Size
+		xc.Out.Info("cache.entry",
+			ovars{
+				"image":   entry.ImageID,
+				"kind":    entry.Kind,
+				"key":     entry.Key,
+				"created": entry.Created.Format(time.RFC3339),
+				"size":    humanize.Bytes(uint64(entry.Size)),
+			})
+	}
+
+	xc.Out.Info("cache",
+		ovars{
+			"location": store.Location,
+			"entries":  len(entries),
+			"size":     humanize.Bytes(uint64(totalSize)),
+		})
+
+	xc.Out.State("done")
+}
+
+// OnClearCommand implements the 'cache clear' docker-slim command
+func OnClearCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	cmdName := fullCmdName(ClearCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	var maxAge time.Duration
+	if cparams.OlderThan != "" {
+		var err error
+		maxAge, err = time.ParseDuration(cparams.OlderThan)
+		if err != nil || maxAge <= 0 {
+			xc.Out.Error("param.older-than", "malformed duration")
+			exitCode := commands.ECTCache | eccBadOlderThan
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+			xc.Exit(exitCode)
+		}
+	}
+
+	xc.Out.State("started")
+
+	store := resultcache.NewStore(gparams.StatePath)
+	count, err := store.Remove(resolveImageID(gparams, cparams.TargetRef, logger), maxAge)
+	xc.FailOn(err)
+
+	xc.Out.Info("cache",
+		ovars{
+			"location": store.Location,
+			"removed":  count,
+		})
+
+	xc.Out.State("done")
+}
+
+// resolveImageID returns the ID for the target image reference
+// (the reference is used as the ID if the image is not found)
+func resolveImageID(gparams *commands.GenericParams, targetRef string, logger *log.Entry) string {
+	if targetRef == "" {
+		return ""
+	}
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err != nil {
+		logger.Debugf("resolveImageID: docker client error - %v", err)
+		return targetRef
+	}
+
+	identity, err := dockerutil.HasImage(client, targetRef)
+	if err != nil {
+		logger.Debugf("resolveImageID: image not found (%s) - %v", targetRef, err)
+		return targetRef
+	}
+
+	return identity.ID
+}
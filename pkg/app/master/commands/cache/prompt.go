@@ -0,0 +1,10 @@
+package cache
+
+import (
+	"github.com/c-bata/go-prompt"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Flag config file locations
+const (
+	UserFlagConfigDir      = ".docker-slim"
+	UserFlagConfigFile     = "config.yaml"
+	ProjectFlagConfigFile  = ".dockerslim.yml"
+	flagConfigCmdSeparator = "."
+)
+
+// FlagConfigValues contains the flag values (by flag name) for the global flags and for the commands
+// (the subcommand keys include the parent command name, e.g., 'cache.clear')
+type FlagConfigValues struct {
+	Global   map[string]interface{}            `json:"global,omitempty"`
+	Commands map[string]map[string]interface{} `json:"commands,omitempty"`
+}
+
+// FlagConfig is the flag config file data (the user and project level config files)
+type FlagConfig struct {
+	FlagConfigValues
+	//named flag value sets selected with the '--profile' global flag
+	Profiles map[string]*FlagConfigValues `json:"profiles,omitempty"`
+}
+
+func (v *FlagConfigValues) merge(other *FlagConfigValues) {
+	if other == nil {
+		return
+	}
+
+	if v.Global == nil {
+		v.Global = map[string]interface{}{}
+	}
+
+	for name, value := range other.Global {
+		v.Global[name] = value
+	}
+
+	if v.Commands == nil {
+		v.Commands = map[string]map[string]interface{}{}
+	}
+
+	for cmdName, values := range other.Commands {
+		if v.Commands[cmdName] == nil {
+			v.Commands[cmdName] = map[string]interface{}{}
+		}
+
+		for name, value := range values {
+			v.Commands[cmdName][name] = value
+		}
+	}
+}
+
+// FlagConfigFiles returns the flag config file paths (in the order they are applied)
+func FlagConfigFiles() []string {
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, UserFlagConfigDir, UserFlagConfigFile))
+	}
+
+	return append(paths, ProjectFlagConfigFile)
+}
+
+// LoadFlagConfig loads the flag config files
+// (the project config file values override the user config file values)
+func LoadFlagConfig(paths []string) (*FlagConfig, error) {
+	result := &FlagConfig{
+		Profiles: map[string]*FlagConfigValues{},
+	}
+
+	for _, filePath := range paths {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		var config FlagConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("%s: %v", filePath, err)
+		}
+
+		log.Debugf("commands.LoadFlagConfig: loaded '%s'", filePath)
+		result.merge(&config.FlagConfigValues)
+		for name, profile := range config.Profiles {
+			if result.Profiles[name] == nil {
+				result.Profiles[name] = &FlagConfigValues{}
+			}
+
+			result.Profiles[name].merge(profile)
+		}
+	}
+
+	return result, nil
+}
+
+// Values returns the flag values with the selected profile values applied
+func (c *FlagConfig) Values(profile string) (*FlagConfigValues, error) {
+	values := &FlagConfigValues{}
+	values.merge(&c.FlagConfigValues)
+	if profile == "" {
+		return values, nil
+	}
+
+	profileValues, ok := c.Profiles[profile]
+	if !ok {
+		var names []string
+		for name := range c.Profiles {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown config profile '%s' (available: %s)", profile, strings.Join(names, ", "))
+	}
+
+	values.merge(profileValues)
+	return values, nil
+}
+
+// ApplyFlagConfigValues sets the flags that are not set on the command line
+// (or with their environment variables) to the config values
+func ApplyFlagConfigValues(ctx *cli.Context, flags []cli.Flag, values map[string]interface{}) error {
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		flag := findFlag(flags, name)
+		if flag == nil {
+			return fmt.Errorf("unknown flag '%s'", name)
+		}
+
+		flagName := flag.Names()[0]
+		if ctx.IsSet(flagName) {
+			continue
+		}
+
+		fieldValues, err := flagConfigValueStrings(values[name])
+		if err != nil {
+			return fmt.Errorf("flag '%s': %v", name, err)
+		}
+
+		if len(fieldValues) > 1 && !isSliceFlag(flag) {
+			return fmt.Errorf("flag '%s': multiple values for a single value flag", name)
+		}
+
+		for _, fv := range fieldValues {
+			if err := ctx.Set(flagName, fv); err != nil {
+				return fmt.Errorf("flag '%s': %v", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetupFlagConfig makes the commands (and their subcommands) use the flag config values
+// (the command 'Before' functions apply the values and then the '--preset' values
+// before the existing 'Before' functions run)
+func SetupFlagConfig(cmds []*cli.Command, prefix string) {
+	for _, cmd := range cmds {
+		cmdName := prefix + cmd.Name
+		flags := cmd.Flags
+		before := cmd.Before
+		cmd.Before = func(ctx *cli.Context) error {
+			if values, ok := CLIContextGet(ctx.Context, FlagConfigParams).(*FlagConfigValues); ok && values != nil {
+				if err := ApplyFlagConfigValues(ctx, flags, values.Commands[cmdName]); err != nil {
+					return fmt.Errorf("config (command '%s'): %v", cmdName, err)
+				}
+			}
+
+			if findFlag(flags, FlagPreset) != nil {
+				if err := ApplyFlagPreset(ctx, flags, ctx.String(FlagPreset)); err != nil {
+					return fmt.Errorf("command '%s': %v", cmdName, err)
+				}
+			}
+
+			if before != nil {
+				return before(ctx)
+			}
+
+			return nil
+		}
+
+		SetupFlagConfig(cmd.Subcommands, cmdName+flagConfigCmdSeparator)
+	}
+}
+
+// ApplyGlobalFlagConfig loads the flag config files, applies the global flag values
+// and saves the command flag values in the CLI context
+func ApplyGlobalFlagConfig(ctx *cli.Context, flags []cli.Flag) error {
+	config, err := LoadFlagConfig(FlagConfigFiles())
+	if err != nil {
+		return err
+	}
+
+	values, err := config.Values(ctx.String(FlagProfile))
+	if err != nil {
+		return err
+	}
+
+	if err := ApplyFlagConfigValues(ctx, flags, values.Global); err != nil {
+		return fmt.Errorf("config (global): %v", err)
+	}
+
+	if ctx.Context == nil {
+		ctx.Context = context.Background()
+	}
+
+	ctx.Context = CLIContextSave(ctx.Context, FlagConfigParams, values)
+	return nil
+}
+
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		for _, flagName := range flag.Names() {
+			if flagName == name {
+				return flag
+			}
+		}
+	}
+
+	return nil
+}
+
+func isSliceFlag(flag cli.Flag) bool {
+	switch flag.(type) {
+	case *cli.StringSliceFlag, *cli.IntSliceFlag, *cli.Int64SliceFlag, *cli.Float64SliceFlag:
+		return true
+	}
+
+	return false
+}
+
+// flagConfigValueStrings converts the config value to the flag value strings
+// (the lists are used for the flags that can be used multiple times)
+func flagConfigValueStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			itemValues, err := flagConfigValueStrings(item)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(itemValues) != 1 {
+				return nil, fmt.Errorf("unsupported list item value - %v", item)
+			}
+
+			list = append(list, itemValues[0])
+		}
+
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("unsupported value type - %T", value)
+}
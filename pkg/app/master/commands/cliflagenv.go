@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Flag environment variable name prefix
+const FlagEnvVarPrefix = "DSLIM_"
+
+// FlagEnvVarName generates the environment variable name for the flag name
+// (e.g., 'http-probe-cmd' => 'DSLIM_HTTP_PROBE_CMD')
+func FlagEnvVarName(flagName string) string {
+	name := strings.ToUpper(flagName)
+	name = strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	return FlagEnvVarPrefix + name
+}
+
+// SetupFlagEnvVars adds the generated DSLIM_* environment variable to the flags
+// (the existing environment variables are kept and they are checked first;
+// precedence: command line flag > environment variable > flag config file > flag default)
+func SetupFlagEnvVars(flags []cli.Flag) {
+	for _, flag := range flags {
+		envVars := flagEnvVars(flag)
+		if envVars == nil {
+			continue
+		}
+
+		name := FlagEnvVarName(flag.Names()[0])
+		if !hasEnvVar(*envVars, name) {
+			*envVars = append(*envVars, name)
+		}
+	}
+}
+
+// SetupCommandFlagEnvVars adds the generated environment variables
+// to the command flags (and to the subcommand flags)
+func SetupCommandFlagEnvVars(cmds []*cli.Command) {
+	for _, cmd := range cmds {
+		SetupFlagEnvVars(cmd.Flags)
+		SetupCommandFlagEnvVars(cmd.Subcommands)
+	}
+}
+
+func hasEnvVar(envVars []string, name string) bool {
+	for _, envVar := range envVars {
+		if envVar == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func flagEnvVars(flag cli.Flag) *[]string {
+	switch f := flag.(type) {
+	case *cli.StringFlag:
+		return &f.EnvVars
+	case *cli.BoolFlag:
+		return &f.EnvVars
+	case *cli.IntFlag:
+		return &f.EnvVars
+	case *cli.Int64Flag:
+		return &f.EnvVars
+	case *cli.UintFlag:
+		return &f.EnvVars
+	case *cli.Uint64Flag:
+		return &f.EnvVars
+	case *cli.Float64Flag:
+		return &f.EnvVars
+	case *cli.DurationFlag:
+		return &f.EnvVars
+	case *cli.PathFlag:
+		return &f.EnvVars
+	case *cli.StringSliceFlag:
+		return &f.EnvVars
+	case *cli.IntSliceFlag:
+		return &f.EnvVars
+	case *cli.Int64SliceFlag:
+		return &f.EnvVars
+	case *cli.Float64SliceFlag:
+		return &f.EnvVars
+	case *cli.GenericFlag:
+		return &f.EnvVars
+	case *cli.TimestampFlag:
+		return &f.EnvVars
+	}
+
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/docker-slim/docker-slim/pkg/trace"
 )
 
 /////////////////////////////////////////////////////////
@@ -16,6 +18,8 @@ const (
 	FlagCheckVersion  = "check-version"
 	FlagDebug         = "debug"
 	FlagVerbose       = "verbose"
+	FlagVeryVerbose   = "vv"
+	FlagQuiet         = "quiet"
 	FlagLogLevel      = "log-level"
 	FlagLog           = "log"
 	FlagLogFormat     = "log-format"
@@ -27,7 +31,19 @@ const (
 	FlagInContainer   = "in-container"
 	FlagArchiveState  = "archive-state"
 	FlagNoColor       = "no-color"
+	FlagNoCache       = "no-cache"
 	FlagConsoleFormat = "console-format"
+	FlagOTelEndpoint  = "otel-endpoint"
+	FlagOTelHeaders   = "otel-headers"
+	FlagProfile       = "profile"
+	FlagOutput        = "output"
+	FlagOutputFile    = "output-file"
+	FlagDiagBundle    = "diag-bundle"
+	FlagOutputSink    = "output-sink"
+	FlagRegMirror     = "registry-mirror"
+	FlagRegCACert     = "registry-ca-cert"
+	FlagRegProxy      = "registry-proxy"
+	FlagRegNoProxy    = "registry-no-proxy"
 )
 
 // Global flag usage info
@@ -36,6 +52,8 @@ const (
 	FlagCheckVersionUsage  = "check if the current version is outdated"
 	FlagDebugUsage         = "enable debug logs"
 	FlagVerboseUsage       = "enable info logs"
+	FlagVeryVerboseUsage   = "enable debug logs (more verbose than --verbose)"
+	FlagQuietUsage         = "show only the errors and the final command state (no community info)"
 	FlagLogLevelUsage      = "set the logging level ('trace', 'debug', 'info', 'warn' (default), 'error', 'fatal', 'panic')"
 	FlagLogUsage           = "log file to store logs"
 	FlagLogFormatUsage     = "set the format used by logs ('text' (default), or 'json')"
@@ -48,6 +66,18 @@ const (
 	FlagInContainerUsage   = "DockerSlim is running in a container"
 	FlagArchiveStateUsage  = "archive DockerSlim state to the selected Docker volume (default volume - docker-slim-state). By default, enabled when DockerSlim is running in a container (disabled otherwise). Set it to \"off\" to disable explicitly."
 	FlagNoColorUsage       = "disable color output"
+	FlagNoCacheUsage       = "don't use the cached analysis results (and don't cache the new results)"
+	FlagOTelEndpointUsage  = "export the command phase spans and metrics to the OpenTelemetry collector (OTLP/HTTP endpoint, e.g. http://localhost:4318)"
+	FlagOTelHeadersUsage   = "extra OTLP export request headers ('key1=value1,key2=value2')"
+	FlagProfileUsage       = "flag config profile to use (from ~/.docker-slim/config.yaml or .dockerslim.yml)"
+	FlagOutputUsage        = "machine-readable output format ('jsonl' for the command event stream or 'json' for the JSON console output)"
+	FlagOutputFileUsage    = "command event stream file (default: stdout, replacing the regular console output)"
+	FlagOutputSinkUsage    = "extra command event stream destination ('file:<path>', 'syslog', 'syslog:<udp|tcp>://<host:port>' or 'journald'; the console output is not changed)"
+	FlagDiagBundleUsage    = "save a diagnostics bundle (tar.gz with the logs, Docker info, partial reports and environment; secrets redacted) when the command fails (file or directory path)"
+	FlagRegMirrorUsage     = "registry mirror used for the image pulls from the registry ('[<registry>=]<mirror host or URL>[/<path prefix>]'; the mirror is for Docker Hub if the registry is not set; can be used multiple times)"
+	FlagRegCACertUsage     = "extra CA bundle file (PEM) trusted for the registry connections (can be used multiple times)"
+	FlagRegProxyUsage      = "HTTP(S) or SOCKS proxy for the registry connections ('http://', 'https://' or 'socks5://' URL; the proxy env vars are used by default)"
+	FlagRegNoProxyUsage    = "registry host, domain or IP network that doesn't use the registry proxy (can be used multiple times)"
 )
 
 // Shared command flag names
@@ -92,28 +122,58 @@ const (
 	//       etc.
 	// Naming convention: keep the well known kubectl flag names as-is and prefix them with `--kube-`
 
-	FlagRemoveFileArtifacts = "remove-file-artifacts"
-	FlagCopyMetaArtifacts   = "copy-meta-artifacts"
-
-	FlagHTTPProbe                 = "http-probe"
-	FlagHTTPProbeOff              = "http-probe-off" //alternative way to disable http probing
-	FlagHTTPProbeCmd              = "http-probe-cmd"
-	FlagHTTPProbeCmdFile          = "http-probe-cmd-file"
-	FlagHTTPProbeStartWait        = "http-probe-start-wait"
-	FlagHTTPProbeRetryCount       = "http-probe-retry-count"
-	FlagHTTPProbeRetryWait        = "http-probe-retry-wait"
-	FlagHTTPProbePorts            = "http-probe-ports"
-	FlagHTTPProbeFull             = "http-probe-full"
-	FlagHTTPProbeExitOnFailure    = "http-probe-exit-on-failure"
-	FlagHTTPProbeCrawl            = "http-probe-crawl"
-	FlagHTTPCrawlMaxDepth         = "http-crawl-max-depth"
-	FlagHTTPCrawlMaxPageCount     = "http-crawl-max-page-count"
-	FlagHTTPCrawlConcurrency      = "http-crawl-concurrency"
-	FlagHTTPMaxConcurrentCrawlers = "http-max-concurrent-crawlers"
-	FlagHTTPProbeAPISpec          = "http-probe-apispec"
-	FlagHTTPProbeAPISpecFile      = "http-probe-apispec-file"
-	FlagHTTPProbeProxyEndpoint    = "http-probe-proxy-endpoint"
-	FlagHTTPProbeProxyPort        = "http-probe-proxy-port"
+	FlagRemoveFileArtifacts   = "remove-file-artifacts"
+	FlagCopyMetaArtifacts     = "copy-meta-artifacts"
+	FlagSBOM                  = "sbom"
+	FlagSBOMOutput            = "sbom-output"
+	FlagReportHTML            = "report-html"
+	FlagReportUpload          = "report-upload"
+	FlagReportUploadArtifacts = "report-upload-artifacts"
+	FlagTraceOutput           = "trace-output"
+	FlagTraceFormat           = "trace-format"
+
+	FlagHTTPProbe                       = "http-probe"
+	FlagHTTPProbeOff                    = "http-probe-off" //alternative way to disable http probing
+	FlagHTTPProbeCmd                    = "http-probe-cmd"
+	FlagHTTPProbeCmdFile                = "http-probe-cmd-file"
+	FlagHTTPProbeStartWait              = "http-probe-start-wait"
+	FlagHTTPProbeRetryCount             = "http-probe-retry-count"
+	FlagHTTPProbeRetryWait              = "http-probe-retry-wait"
+	FlagHTTPProbePorts                  = "http-probe-ports"
+	FlagHTTPProbeFull                   = "http-probe-full"
+	FlagHTTPProbeExitOnFailure          = "http-probe-exit-on-failure"
+	FlagHTTPProbeCrawl                  = "http-probe-crawl"
+	FlagHTTPCrawlMaxDepth               = "http-crawl-max-depth"
+	FlagHTTPCrawlMaxPageCount           = "http-crawl-max-page-count"
+	FlagHTTPCrawlConcurrency            = "http-crawl-concurrency"
+	FlagHTTPMaxConcurrentCrawlers       = "http-max-concurrent-crawlers"
+	FlagHTTPProbeAPISpec                = "http-probe-apispec"
+	FlagHTTPProbeAPISpecFile            = "http-probe-apispec-file"
+	FlagHTTPProbeProxyEndpoint          = "http-probe-proxy-endpoint"
+	FlagHTTPProbeProxyPort              = "http-probe-proxy-port"
+	FlagHTTPProbeHeaderFile             = "http-probe-header-file"
+	FlagHTTPProbeHeaderEnv              = "http-probe-header-env"
+	FlagHTTPProbeClientCert             = "http-probe-client-cert"
+	FlagHTTPProbeClientKey              = "http-probe-client-key"
+	FlagHTTPProbeCACert                 = "http-probe-ca-cert"
+	FlagHTTPProbeOAuth2TokenURL         = "http-probe-oauth2-token-url"
+	FlagHTTPProbeOAuth2ClientID         = "http-probe-oauth2-client-id"
+	FlagHTTPProbeOAuth2ClientSecret     = "http-probe-oauth2-client-secret"
+	FlagHTTPProbeOAuth2ClientSecretFile = "http-probe-oauth2-client-secret-file"
+	FlagHTTPProbeOAuth2Scope            = "http-probe-oauth2-scope"
+	FlagHTTPProbeHARFile                = "http-probe-har-file"
+	FlagHTTPProbeURLFile                = "http-probe-url-file"
+	FlagHTTPProbeFailThreshold          = "http-probe-fail-threshold"
+	FlagHTTPProbeConcurrency            = "http-probe-concurrency"
+	FlagHTTPProbeRateLimit              = "http-probe-rate-limit"
+	FlagProbeWhen                       = "probe-when"
+	FlagProbeWhenTimeout                = "probe-when-timeout"
+	FlagExecHook                        = "exec-hook"
+	FlagSessionRecord                   = "session-record"
+	FlagSessionReplay                   = "session-replay"
+	FlagDepService                      = "dep-service"
+	FlagDepComposeFile                  = "dep-compose-file"
+	FlagDepHealthyTimeout               = "dep-healthy-timeout"
 
 	FlagHostExec     = "host-exec"
 	FlagHostExecFile = "host-exec-file"
@@ -129,6 +189,7 @@ const (
 	FlagUseLocalMounts  = "use-local-mounts"
 	FlagUseSensorVolume = "use-sensor-volume"
 	FlagContinueAfter   = "continue-after"
+	FlagPreset          = "preset"
 
 	//RunTime Analysis Options
 	FlagRTAOnbuildBaseImage = "rta-onbuild-base-image"
@@ -204,28 +265,58 @@ const (
 	FlagKubeManifestFileUsage            = "[Experimental] Kubernetes manifest(s) to apply before run"
 	FlagKubeKubeconfigFileUsage          = "[Experimental] Path to the kubeconfig file"
 
-	FlagRemoveFileArtifactsUsage = "remove file artifacts when command is done"
-	FlagCopyMetaArtifactsUsage   = "copy metadata artifacts to the selected location when command is done"
-
-	FlagHTTPProbeUsage                 = "Enable or disable HTTP probing"
-	FlagHTTPProbeOffUsage              = "Alternative way to disable HTTP probing"
-	FlagHTTPProbeCmdUsage              = "User defined HTTP probes"
-	FlagHTTPProbeCmdFileUsage          = "File with user defined HTTP probes"
-	FlagHTTPProbeStartWaitUsage        = "Number of seconds to wait before starting HTTP probing"
-	FlagHTTPProbeRetryCountUsage       = "Number of retries for each HTTP probe"
-	FlagHTTPProbeRetryWaitUsage        = "Number of seconds to wait before retrying HTTP probe (doubles when target is not ready)"
-	FlagHTTPProbePortsUsage            = "Explicit list of ports to probe (in the order you want them to be probed)"
-	FlagHTTPProbeFullUsage             = "Do full HTTP probe for all selected ports (if false, finish after first successful scan)"
-	FlagHTTPProbeExitOnFailureUsage    = "Exit when all HTTP probe commands fail"
-	FlagHTTPProbeCrawlUsage            = "Enable crawling for the default HTTP probe command"
-	FlagHTTPCrawlMaxDepthUsage         = "Max depth to use for the HTTP probe crawler"
-	FlagHTTPCrawlMaxPageCountUsage     = "Max number of pages to visit for the HTTP probe crawler"
-	FlagHTTPCrawlConcurrencyUsage      = "Number of concurrent workers when crawling an HTTP target"
-	FlagHTTPMaxConcurrentCrawlersUsage = "Number of concurrent crawlers in the HTTP probe"
-	FlagHTTPProbeAPISpecUsage          = "Run HTTP probes for API spec"
-	FlagHTTPProbeAPISpecFileUsage      = "Run HTTP probes for API spec from file"
-	FlagHTTPProbeProxyEndpointUsage    = "Endpoint to proxy HTTP probes"
-	FlagHTTPProbeProxyPortUsage        = "Port to proxy HTTP probes (used with HTTP probe proxy endpoint)"
+	FlagRemoveFileArtifactsUsage   = "remove file artifacts when command is done"
+	FlagCopyMetaArtifactsUsage     = "copy metadata artifacts to the selected location when command is done"
+	FlagSBOMUsage                  = "Generate the software bill of materials (SBOM) in the selected format (spdx-json or cyclonedx-json)"
+	FlagSBOMOutputUsage            = "SBOM file path (defaults to the SBOM file in the artifacts location)"
+	FlagReportHTMLUsage            = "Save the command report as a standalone HTML page (file path)"
+	FlagReportUploadUsage          = "Upload the command reports to the remote location (s3://bucket/prefix, gs://bucket/prefix or http(s)://host/path)"
+	FlagReportUploadArtifactsUsage = "Also upload the artifact files to the report upload location"
+	FlagTraceOutputUsage           = "Save the profiling run timeline (container lifecycle, probe calls and sensor events) to the file"
+	FlagTraceFormatUsage           = "Timeline file format (chrome or otel)"
+
+	FlagHTTPProbeUsage                       = "Enable or disable HTTP probing"
+	FlagHTTPProbeOffUsage                    = "Alternative way to disable HTTP probing"
+	FlagHTTPProbeCmdUsage                    = "User defined HTTP probes"
+	FlagHTTPProbeCmdFileUsage                = "File with user defined HTTP probes"
+	FlagHTTPProbeStartWaitUsage              = "Number of seconds to wait before starting HTTP probing"
+	FlagHTTPProbeRetryCountUsage             = "Number of retries for each HTTP probe"
+	FlagHTTPProbeRetryWaitUsage              = "Number of seconds to wait before retrying HTTP probe (doubles when target is not ready)"
+	FlagHTTPProbePortsUsage                  = "Explicit list of ports to probe (in the order you want them to be probed)"
+	FlagHTTPProbeFullUsage                   = "Do full HTTP probe for all selected ports (if false, finish after first successful scan)"
+	FlagHTTPProbeExitOnFailureUsage          = "Exit when all HTTP probe commands fail"
+	FlagHTTPProbeCrawlUsage                  = "Enable crawling for the default HTTP probe command"
+	FlagHTTPCrawlMaxDepthUsage               = "Max depth to use for the HTTP probe crawler"
+	FlagHTTPCrawlMaxPageCountUsage           = "Max number of pages to visit for the HTTP probe crawler"
+	FlagHTTPCrawlConcurrencyUsage            = "Number of concurrent workers when crawling an HTTP target"
+	FlagHTTPMaxConcurrentCrawlersUsage       = "Number of concurrent crawlers in the HTTP probe"
+	FlagHTTPProbeAPISpecUsage                = "Run HTTP probes for API spec"
+	FlagHTTPProbeAPISpecFileUsage            = "Run HTTP probes for API spec from file"
+	FlagHTTPProbeProxyEndpointUsage          = "Endpoint to proxy HTTP probes"
+	FlagHTTPProbeProxyPortUsage              = "Port to proxy HTTP probes (used with HTTP probe proxy endpoint)"
+	FlagHTTPProbeHeaderFileUsage             = "Load extra HTTP probe headers ('Name: value' lines) from file"
+	FlagHTTPProbeHeaderEnvUsage              = "Extra HTTP probe header with the value from an environment variable (format: 'Name:ENV_VAR_NAME')"
+	FlagHTTPProbeClientCertUsage             = "Client certificate (PEM) file for the HTTP probes (mTLS)"
+	FlagHTTPProbeClientKeyUsage              = "Client certificate key (PEM) file for the HTTP probes (mTLS)"
+	FlagHTTPProbeCACertUsage                 = "CA certificate (PEM) file to verify the target TLS certificate in the HTTP probes"
+	FlagHTTPProbeOAuth2TokenURLUsage         = "OAuth2 token endpoint to get a client credentials access token for the HTTP probes"
+	FlagHTTPProbeOAuth2ClientIDUsage         = "OAuth2 client ID for the HTTP probes"
+	FlagHTTPProbeOAuth2ClientSecretUsage     = "OAuth2 client secret for the HTTP probes (prefer the env var or the secret file flag)"
+	FlagHTTPProbeOAuth2ClientSecretFileUsage = "File with the OAuth2 client secret for the HTTP probes"
+	FlagHTTPProbeOAuth2ScopeUsage            = "OAuth2 scope for the HTTP probe access token"
+	FlagHTTPProbeHARFileUsage                = "Replay the HTTP requests recorded in a HAR file as HTTP probes"
+	FlagHTTPProbeURLFileUsage                = "Replay the HTTP requests from a file with '[METHOD] URL [BODY]' lines as HTTP probes"
+	FlagHTTPProbeFailThresholdUsage          = "Exit with an error when the percentage of the failed HTTP probe commands is greater than this value (-1 to disable)"
+	FlagHTTPProbeConcurrencyUsage            = "Number of concurrent workers executing the HTTP probe commands"
+	FlagHTTPProbeRateLimitUsage              = "Max number of HTTP probe calls per second (0 means no limit)"
+	FlagProbeWhenUsage                       = "Readiness condition to wait for before starting the HTTP probe ('log:<regex>', 'tcp', 'tcp:<port>' or 'healthcheck') [can use this flag multiple times]"
+	FlagProbeWhenTimeoutUsage                = "Max number of seconds to wait for the probe readiness conditions"
+	FlagExecHookUsage                        = "Hook command to run at the selected container profiling phase ('<after-start|probe-round|before-commit>:<host|container>:<command>') [can use this flag multiple times]"
+	FlagSessionRecordUsage                   = "Run an interactive container exec session and save the executed commands to the selected file (for replay)"
+	FlagSessionReplayUsage                   = "Replay the exec session commands from the selected session file"
+	FlagDepServiceUsage                      = "Dependency service to start before the target container ('[name=]image[,ENV_NAME=value...]') [can use this flag multiple times]"
+	FlagDepComposeFileUsage                  = "Compose file with the dependency services to start before the target container [can use this flag multiple times]"
+	FlagDepHealthyTimeoutUsage               = "Max number of seconds to wait for the dependency services to be healthy"
 
 	FlagHostExecUsage     = "Host commands to execute (aka host commands probes)"
 	FlagHostExecFileUsage = "Host commands to execute loaded from file (aka host commands probes)"
@@ -240,7 +331,8 @@ const (
 	FlagExcludePatternUsage  = "Exclude path pattern (Glob/Match in Go and **) from image"
 	FlagUseLocalMountsUsage  = "Mount local paths for target container artifact input and output"
 	FlagUseSensorVolumeUsage = "Sensor volume name to use"
-	FlagContinueAfterUsage   = "Select continue mode: enter | signal | probe | timeout-number-in-seconds | container.probe"
+	FlagContinueAfterUsage   = "Select continue mode: enter | signal | probe | timeout-number-in-seconds | container.probe | session"
+	FlagPresetUsage          = "Use the built-in flag preset for a common workload: node-web | python-api | java-spring (explicit flags override the preset values)"
 
 	FlagRTAOnbuildBaseImageUsage = "Enable runtime analysis for onbuild base images"
 	FlagRTASourcePTUsage         = "Enable PTRACE runtime analysis source"
@@ -295,8 +387,18 @@ func GlobalFlags() []cli.Flag {
 			Usage: FlagDebugUsage,
 		},
 		&cli.BoolFlag{
-			Name:  FlagVerbose,
-			Usage: "enable info logs",
+			Name:    FlagVerbose,
+			Aliases: []string{"v"},
+			Usage:   FlagVerboseUsage,
+		},
+		&cli.BoolFlag{
+			Name:  FlagVeryVerbose,
+			Usage: FlagVeryVerboseUsage,
+		},
+		&cli.BoolFlag{
+			Name:    FlagQuiet,
+			Aliases: []string{"q"},
+			Usage:   FlagQuietUsage,
 		},
 		&cli.StringFlag{
 			Name:  FlagLogLevel,
@@ -355,6 +457,66 @@ func GlobalFlags() []cli.Flag {
 			Name:  FlagNoColor,
 			Usage: FlagNoColorUsage,
 		},
+		&cli.BoolFlag{
+			Name:    FlagNoCache,
+			Usage:   FlagNoCacheUsage,
+			EnvVars: []string{"DSLIM_NO_CACHE"},
+		},
+		&cli.StringFlag{
+			Name:    FlagOTelEndpoint,
+			Usage:   FlagOTelEndpointUsage,
+			EnvVars: []string{"DSLIM_OTEL_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		&cli.StringFlag{
+			Name:    FlagOTelHeaders,
+			Usage:   FlagOTelHeadersUsage,
+			EnvVars: []string{"DSLIM_OTEL_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS"},
+		},
+		&cli.StringFlag{
+			Name:    FlagProfile,
+			Usage:   FlagProfileUsage,
+			EnvVars: []string{"DSLIM_PROFILE"},
+		},
+		&cli.StringFlag{
+			Name:    FlagOutput,
+			Usage:   FlagOutputUsage,
+			EnvVars: []string{"DSLIM_OUTPUT"},
+		},
+		&cli.StringFlag{
+			Name:    FlagOutputFile,
+			Usage:   FlagOutputFileUsage,
+			EnvVars: []string{"DSLIM_OUTPUT_FILE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    FlagOutputSink,
+			Usage:   FlagOutputSinkUsage,
+			EnvVars: []string{"DSLIM_OUTPUT_SINK"},
+		},
+		&cli.StringFlag{
+			Name:    FlagDiagBundle,
+			Usage:   FlagDiagBundleUsage,
+			EnvVars: []string{"DSLIM_DIAG_BUNDLE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    FlagRegMirror,
+			Usage:   FlagRegMirrorUsage,
+			EnvVars: []string{"DSLIM_REGISTRY_MIRROR"},
+		},
+		&cli.StringSliceFlag{
+			Name:    FlagRegCACert,
+			Usage:   FlagRegCACertUsage,
+			EnvVars: []string{"DSLIM_REGISTRY_CA_CERT"},
+		},
+		&cli.StringFlag{
+			Name:    FlagRegProxy,
+			Usage:   FlagRegProxyUsage,
+			EnvVars: []string{"DSLIM_REGISTRY_PROXY"},
+		},
+		&cli.StringSliceFlag{
+			Name:    FlagRegNoProxy,
+			Usage:   FlagRegNoProxyUsage,
+			EnvVars: []string{"DSLIM_REGISTRY_NO_PROXY"},
+		},
 	}
 }
 
@@ -551,6 +713,42 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:   FlagCopyMetaArtifactsUsage,
 		EnvVars: []string{"DSLIM_CP_META_ARTIFACTS"},
 	},
+	FlagSBOM: &cli.StringFlag{
+		Name:    FlagSBOM,
+		Usage:   FlagSBOMUsage,
+		EnvVars: []string{"DSLIM_SBOM"},
+	},
+	FlagSBOMOutput: &cli.StringFlag{
+		Name:    FlagSBOMOutput,
+		Usage:   FlagSBOMOutputUsage,
+		EnvVars: []string{"DSLIM_SBOM_OUTPUT"},
+	},
+	FlagReportHTML: &cli.StringFlag{
+		Name:    FlagReportHTML,
+		Usage:   FlagReportHTMLUsage,
+		EnvVars: []string{"DSLIM_REPORT_HTML"},
+	},
+	FlagReportUpload: &cli.StringFlag{
+		Name:    FlagReportUpload,
+		Usage:   FlagReportUploadUsage,
+		EnvVars: []string{"DSLIM_REPORT_UPLOAD"},
+	},
+	FlagReportUploadArtifacts: &cli.BoolFlag{
+		Name:    FlagReportUploadArtifacts,
+		Usage:   FlagReportUploadArtifactsUsage,
+		EnvVars: []string{"DSLIM_REPORT_UPLOAD_ARTIFACTS"},
+	},
+	FlagTraceOutput: &cli.StringFlag{
+		Name:    FlagTraceOutput,
+		Usage:   FlagTraceOutputUsage,
+		EnvVars: []string{"DSLIM_TRACE_OUTPUT"},
+	},
+	FlagTraceFormat: &cli.StringFlag{
+		Name:    FlagTraceFormat,
+		Value:   trace.FormatChrome,
+		Usage:   FlagTraceFormatUsage,
+		EnvVars: []string{"DSLIM_TRACE_FORMAT"},
+	},
 	//
 	FlagHTTPProbe: &cli.BoolFlag{ //true by default
 		Name:    FlagHTTPProbe,
@@ -664,6 +862,144 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:   FlagHTTPProbeProxyPortUsage,
 		EnvVars: []string{"DSLIM_HTTP_PROBE_PROXY_PORT"},
 	},
+	FlagHTTPProbeHeaderFile: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeHeaderFile,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeHeaderFileUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_HEADER_FILE"},
+	},
+	FlagHTTPProbeHeaderEnv: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeHeaderEnv,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeHeaderEnvUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_HEADER_ENV"},
+	},
+	FlagHTTPProbeClientCert: &cli.StringFlag{
+		Name:    FlagHTTPProbeClientCert,
+		Value:   "",
+		Usage:   FlagHTTPProbeClientCertUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_CLIENT_CERT"},
+	},
+	FlagHTTPProbeClientKey: &cli.StringFlag{
+		Name:    FlagHTTPProbeClientKey,
+		Value:   "",
+		Usage:   FlagHTTPProbeClientKeyUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_CLIENT_KEY"},
+	},
+	FlagHTTPProbeCACert: &cli.StringFlag{
+		Name:    FlagHTTPProbeCACert,
+		Value:   "",
+		Usage:   FlagHTTPProbeCACertUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_CA_CERT"},
+	},
+	FlagHTTPProbeOAuth2TokenURL: &cli.StringFlag{
+		Name:    FlagHTTPProbeOAuth2TokenURL,
+		Value:   "",
+		Usage:   FlagHTTPProbeOAuth2TokenURLUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_OAUTH2_TOKEN_URL"},
+	},
+	FlagHTTPProbeOAuth2ClientID: &cli.StringFlag{
+		Name:    FlagHTTPProbeOAuth2ClientID,
+		Value:   "",
+		Usage:   FlagHTTPProbeOAuth2ClientIDUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_OAUTH2_CLIENT_ID"},
+	},
+	FlagHTTPProbeOAuth2ClientSecret: &cli.StringFlag{
+		Name:    FlagHTTPProbeOAuth2ClientSecret,
+		Value:   "",
+		Usage:   FlagHTTPProbeOAuth2ClientSecretUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_OAUTH2_CLIENT_SECRET"},
+	},
+	FlagHTTPProbeOAuth2ClientSecretFile: &cli.StringFlag{
+		Name:    FlagHTTPProbeOAuth2ClientSecretFile,
+		Value:   "",
+		Usage:   FlagHTTPProbeOAuth2ClientSecretFileUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_OAUTH2_CLIENT_SECRET_FILE"},
+	},
+	FlagHTTPProbeOAuth2Scope: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeOAuth2Scope,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeOAuth2ScopeUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_OAUTH2_SCOPE"},
+	},
+	FlagHTTPProbeHARFile: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeHARFile,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeHARFileUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_HAR_FILE"},
+	},
+	FlagHTTPProbeURLFile: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeURLFile,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeURLFileUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_URL_FILE"},
+	},
+	FlagHTTPProbeFailThreshold: &cli.IntFlag{
+		Name:    FlagHTTPProbeFailThreshold,
+		Value:   -1,
+		Usage:   FlagHTTPProbeFailThresholdUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_FAIL_THRESHOLD"},
+	},
+	FlagHTTPProbeConcurrency: &cli.IntFlag{
+		Name:    FlagHTTPProbeConcurrency,
+		Value:   1,
+		Usage:   FlagHTTPProbeConcurrencyUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_CONCURRENCY"},
+	},
+	FlagHTTPProbeRateLimit: &cli.IntFlag{
+		Name:    FlagHTTPProbeRateLimit,
+		Value:   0,
+		Usage:   FlagHTTPProbeRateLimitUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_RATE_LIMIT"},
+	},
+	FlagProbeWhen: &cli.StringSliceFlag{
+		Name:    FlagProbeWhen,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagProbeWhenUsage,
+		EnvVars: []string{"DSLIM_PROBE_WHEN"},
+	},
+	FlagProbeWhenTimeout: &cli.IntFlag{
+		Name:    FlagProbeWhenTimeout,
+		Value:   120,
+		Usage:   FlagProbeWhenTimeoutUsage,
+		EnvVars: []string{"DSLIM_PROBE_WHEN_TIMEOUT"},
+	},
+	FlagExecHook: &cli.StringSliceFlag{
+		Name:    FlagExecHook,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagExecHookUsage,
+		EnvVars: []string{"DSLIM_EXEC_HOOK"},
+	},
+	FlagSessionRecord: &cli.StringFlag{
+		Name:    FlagSessionRecord,
+		Value:   "",
+		Usage:   FlagSessionRecordUsage,
+		EnvVars: []string{"DSLIM_SESSION_RECORD"},
+	},
+	FlagSessionReplay: &cli.StringFlag{
+		Name:    FlagSessionReplay,
+		Value:   "",
+		Usage:   FlagSessionReplayUsage,
+		EnvVars: []string{"DSLIM_SESSION_REPLAY"},
+	},
+	FlagDepService: &cli.StringSliceFlag{
+		Name:    FlagDepService,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagDepServiceUsage,
+		EnvVars: []string{"DSLIM_DEP_SERVICE"},
+	},
+	FlagDepComposeFile: &cli.StringSliceFlag{
+		Name:    FlagDepComposeFile,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagDepComposeFileUsage,
+		EnvVars: []string{"DSLIM_DEP_COMPOSE_FILE"},
+	},
+	FlagDepHealthyTimeout: &cli.IntFlag{
+		Name:    FlagDepHealthyTimeout,
+		Value:   120,
+		Usage:   FlagDepHealthyTimeoutUsage,
+		EnvVars: []string{"DSLIM_DEP_HEALTHY_TIMEOUT"},
+	},
 	FlagHostExec: &cli.StringSliceFlag{
 		Name:    FlagHostExec,
 		Value:   cli.NewStringSlice(),
@@ -751,6 +1087,12 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:   FlagContinueAfterUsage,
 		EnvVars: []string{"DSLIM_CONTINUE_AFTER"},
 	},
+	FlagPreset: &cli.StringFlag{
+		Name:    FlagPreset,
+		Value:   "",
+		Usage:   FlagPresetUsage,
+		EnvVars: []string{"DSLIM_PRESET"},
+	},
 	//Container Run Options
 	FlagCRORuntime: &cli.StringFlag{
 		Name:    FlagCRORuntime,
@@ -914,6 +1256,23 @@ func HTTPProbeFlags() []cli.Flag {
 		Cflag(FlagHTTPMaxConcurrentCrawlers),
 		Cflag(FlagHTTPProbeAPISpec),
 		Cflag(FlagHTTPProbeAPISpecFile),
+		Cflag(FlagHTTPProbeHeaderFile),
+		Cflag(FlagHTTPProbeHeaderEnv),
+		Cflag(FlagHTTPProbeClientCert),
+		Cflag(FlagHTTPProbeClientKey),
+		Cflag(FlagHTTPProbeCACert),
+		Cflag(FlagHTTPProbeOAuth2TokenURL),
+		Cflag(FlagHTTPProbeOAuth2ClientID),
+		Cflag(FlagHTTPProbeOAuth2ClientSecret),
+		Cflag(FlagHTTPProbeOAuth2ClientSecretFile),
+		Cflag(FlagHTTPProbeOAuth2Scope),
+		Cflag(FlagHTTPProbeHARFile),
+		Cflag(FlagHTTPProbeURLFile),
+		Cflag(FlagHTTPProbeFailThreshold),
+		Cflag(FlagHTTPProbeConcurrency),
+		Cflag(FlagHTTPProbeRateLimit),
+		Cflag(FlagProbeWhen),
+		Cflag(FlagProbeWhenTimeout),
 	}
 }
 
@@ -4,6 +4,7 @@ package commands
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -17,6 +18,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
 	"github.com/docker-slim/docker-slim/pkg/app/master/signals"
+	"github.com/docker-slim/docker-slim/pkg/docker/registryconfig"
 )
 
 func GetContainerRunOptions(ctx *cli.Context) (*config.ContainerRunOptions, error) {
@@ -59,11 +61,15 @@ func GetHTTPProbeOptions(xc *app.ExecutionContext, ctx *cli.Context) config.HTTP
 		Do:            ctx.Bool(FlagHTTPProbe) && !ctx.Bool(FlagHTTPProbeOff),
 		Full:          ctx.Bool(FlagHTTPProbeFull),
 		ExitOnFailure: ctx.Bool(FlagHTTPProbeExitOnFailure),
+		FailThreshold: ctx.Int(FlagHTTPProbeFailThreshold),
 
 		StartWait:  ctx.Int(FlagHTTPProbeStartWait),
 		RetryCount: ctx.Int(FlagHTTPProbeRetryCount),
 		RetryWait:  ctx.Int(FlagHTTPProbeRetryWait),
 
+		Concurrency: ctx.Int(FlagHTTPProbeConcurrency),
+		RateLimit:   ctx.Int(FlagHTTPProbeRateLimit),
+
 		CrawlMaxDepth:       ctx.Int(FlagHTTPCrawlMaxDepth),
 		CrawlMaxPageCount:   ctx.Int(FlagHTTPCrawlMaxPageCount),
 		CrawlConcurrency:    ctx.Int(FlagHTTPCrawlConcurrency),
@@ -75,9 +81,9 @@ func GetHTTPProbeOptions(xc *app.ExecutionContext, ctx *cli.Context) config.HTTP
 		xc.Out.Error("param.http.probe", err.Error())
 		xc.Out.State("exited",
 			ovars{
-				"exit.code": -1,
+				"exit.code": ECTCommon | ECBadParams,
 			})
-		xc.Exit(-1)
+		xc.Exit(ECTCommon | ECBadParams)
 	}
 	opts.Cmds = cmds
 
@@ -105,9 +111,9 @@ func GetHTTPProbeOptions(xc *app.ExecutionContext, ctx *cli.Context) config.HTTP
 		xc.Out.Error("param.http.probe.ports", err.Error())
 		xc.Out.State("exited",
 			ovars{
-				"exit.code": -1,
+				"exit.code": ECTCommon | ECBadParams,
 			})
-		xc.Exit(-1)
+		xc.Exit(ECTCommon | ECBadParams)
 	}
 	opts.Ports = ports
 
@@ -125,9 +131,9 @@ func GetHTTPProbeOptions(xc *app.ExecutionContext, ctx *cli.Context) config.HTTP
 			xc.Out.Error("param.error.http.api.spec.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": ECTCommon | ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(ECTCommon | ECBadParams)
 		}
 	}
 	opts.APISpecFiles = apiSpecFiles
@@ -136,6 +142,35 @@ func GetHTTPProbeOptions(xc *app.ExecutionContext, ctx *cli.Context) config.HTTP
 		opts.Do = true
 	}
 
+	auth, err := GetHTTPProbeAuth(ctx)
+	if err != nil {
+		xc.Out.Error("param.http.probe.auth", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": ECTCommon | ECBadParams,
+			})
+		xc.Exit(ECTCommon | ECBadParams)
+	}
+
+	readyWhen, err := ParseProbeWhen(ctx.StringSlice(FlagProbeWhen))
+	if err != nil {
+		xc.Out.Error("param.probe.when", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": ECTCommon | ECBadParams,
+			})
+		xc.Exit(ECTCommon | ECBadParams)
+	}
+
+	opts.ReadyWhen = readyWhen
+	opts.ReadyTimeout = ctx.Int(FlagProbeWhenTimeout)
+
+	opts.Headers = auth.Headers
+	opts.ClientCert = auth.ClientCert
+	opts.ClientKey = auth.ClientKey
+	opts.CACert = auth.CACert
+	opts.OAuth2 = auth.OAuth2
+
 	return opts
 }
 
@@ -162,9 +197,87 @@ func GetHTTPProbes(ctx *cli.Context) ([]config.HTTPProbeCmd, error) {
 		httpProbeCmds = append(httpProbeCmds, moreHTTPProbeCmds...)
 	}
 
+	for _, fileName := range ctx.StringSlice(FlagHTTPProbeHARFile) {
+		harProbeCmds, err := ParseHTTPProbesHARFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		httpProbeCmds = append(httpProbeCmds, harProbeCmds...)
+	}
+
+	for _, fileName := range ctx.StringSlice(FlagHTTPProbeURLFile) {
+		urlProbeCmds, err := ParseHTTPProbesURLFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		httpProbeCmds = append(httpProbeCmds, urlProbeCmds...)
+	}
+
 	return httpProbeCmds, nil
 }
 
+// GetHTTPProbeAuth returns the HTTP probe auth options
+// (only the Headers, ClientCert, ClientKey, CACert and OAuth2 fields are set)
+func GetHTTPProbeAuth(ctx *cli.Context) (*config.HTTPProbeOptions, error) {
+	auth := &config.HTTPProbeOptions{
+		ClientCert: ctx.String(FlagHTTPProbeClientCert),
+		ClientKey:  ctx.String(FlagHTTPProbeClientKey),
+		CACert:     ctx.String(FlagHTTPProbeCACert),
+	}
+
+	for _, fileName := range ctx.StringSlice(FlagHTTPProbeHeaderFile) {
+		headers, err := ParseHTTPProbeHeaderFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		auth.Headers = append(auth.Headers, headers...)
+	}
+
+	headers, err := ParseHTTPProbeHeaderEnv(ctx.StringSlice(FlagHTTPProbeHeaderEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	auth.Headers = append(auth.Headers, headers...)
+
+	for _, fileName := range []string{auth.ClientCert, auth.ClientKey, auth.CACert} {
+		if fileName == "" {
+			continue
+		}
+
+		if _, err := os.Stat(fileName); err != nil {
+			return nil, err
+		}
+	}
+
+	if tokenURL := ctx.String(FlagHTTPProbeOAuth2TokenURL); tokenURL != "" {
+		auth.OAuth2 = &config.OAuth2ClientCredentials{
+			TokenURL:     tokenURL,
+			ClientID:     ctx.String(FlagHTTPProbeOAuth2ClientID),
+			ClientSecret: ctx.String(FlagHTTPProbeOAuth2ClientSecret),
+			Scopes:       ctx.StringSlice(FlagHTTPProbeOAuth2Scope),
+		}
+
+		if secretFile := ctx.String(FlagHTTPProbeOAuth2ClientSecretFile); secretFile != "" {
+			secret, err := ioutil.ReadFile(secretFile)
+			if err != nil {
+				return nil, err
+			}
+
+			auth.OAuth2.ClientSecret = strings.TrimSpace(string(secret))
+		}
+
+		if auth.OAuth2.ClientID == "" {
+			return nil, fmt.Errorf("missing OAuth2 client ID")
+		}
+	}
+
+	return auth, nil
+}
+
 func GetContinueAfter(ctx *cli.Context) (*config.ContinueAfter, error) {
 	info := &config.ContinueAfter{
 		Mode: config.CAMEnter,
@@ -187,6 +300,8 @@ func GetContinueAfter(ctx *cli.Context) (*config.ContinueAfter, error) {
 		info.Mode = config.CAMHostExec
 	case config.CAMAppExit:
 		info.Mode = config.CAMAppExit
+	case config.CAMSession:
+		info.Mode = config.CAMSession
 	case config.CAMTimeout:
 		info.Mode = config.CAMTimeout
 		info.Timeout = 60
@@ -222,6 +337,23 @@ func RemoveContinueAfterMode(continueAfter, mode string) string {
 	return strings.Join(modes, "&")
 }
 
+// GetExecSessionFiles returns the exec session record and replay files
+func GetExecSessionFiles(ctx *cli.Context) (string, string, error) {
+	recordFile := ctx.String(FlagSessionRecord)
+	replayFile := ctx.String(FlagSessionReplay)
+	if recordFile != "" && replayFile != "" {
+		return "", "", fmt.Errorf("can't record and replay exec sessions at the same time")
+	}
+
+	if replayFile != "" {
+		if _, err := LoadExecSession(replayFile); err != nil {
+			return "", "", err
+		}
+	}
+
+	return recordFile, replayFile, nil
+}
+
 func GetContinueAfterModeNames(continueAfter string) []string {
 	return strings.Split(continueAfter, "&")
 }
@@ -317,6 +449,10 @@ func UpdateGlobalFlagValues(appOpts *config.AppOptions, values *GenericParams) *
 		values.NoColor = *appOpts.Global.NoColor
 	}
 
+	if appOpts.Global.NoCache != nil {
+		values.NoCache = *appOpts.Global.NoCache
+	}
+
 	if appOpts.Global.Debug != nil {
 		values.Debug = *appOpts.Global.Debug
 	}
@@ -361,12 +497,25 @@ func GlobalFlagValues(ctx *cli.Context) (*GenericParams, error) {
 		CheckVersion:   ctx.Bool(FlagCheckVersion),
 		Debug:          ctx.Bool(FlagDebug),
 		Verbose:        ctx.Bool(FlagVerbose),
+		Quiet:          ctx.Bool(FlagQuiet),
 		LogLevel:       ctx.String(FlagLogLevel),
 		LogFormat:      ctx.String(FlagLogFormat),
 		ConsoleOutput:  ctx.String(FlagConsoleFormat),
 		Log:            ctx.String(FlagLog),
 		StatePath:      ctx.String(FlagStatePath),
 		ReportLocation: ctx.String(FlagCommandReport),
+		NoCache:        ctx.Bool(FlagNoCache),
+		OTelEndpoint:   ctx.String(FlagOTelEndpoint),
+		OTelHeaders:    ctx.String(FlagOTelHeaders),
+		Output:         ctx.String(FlagOutput),
+		OutputFile:     ctx.String(FlagOutputFile),
+		OutputSinks:    ctx.StringSlice(FlagOutputSink),
+		DiagBundle:     ctx.String(FlagDiagBundle),
+	}
+
+	//-vv is the same as --debug
+	if ctx.Bool(FlagVeryVerbose) {
+		values.Debug = true
 	}
 
 	if values.ReportLocation == "off" {
@@ -378,6 +527,16 @@ func GlobalFlagValues(ctx *cli.Context) (*GenericParams, error) {
 
 	values.ClientConfig = GetDockerClientConfig(ctx)
 
+	var err error
+	values.RegistryConfig, err = registryconfig.New(
+		ctx.StringSlice(FlagRegMirror),
+		ctx.StringSlice(FlagRegCACert),
+		ctx.String(FlagRegProxy),
+		ctx.StringSlice(FlagRegNoProxy))
+	if err != nil {
+		return nil, err
+	}
+
 	return &values, nil
 }
 
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
@@ -548,6 +550,12 @@ func ParseHTTPProbes(values []string) ([]config.HTTPProbeCmd, error) {
 		switch len(parts) {
 		case 0:
 		case 1:
+			if config.IsRawProto(parts[0]) {
+				//raw protocol probes don't need the method and resource
+				proto = strings.ToLower(parts[0])
+				break
+			}
+
 			if parts[0] == "" || !isResource(parts[0]) {
 				return nil, fmt.Errorf("invalid HTTP probe command resource: %+v", raw)
 			}
@@ -640,7 +648,12 @@ func ParseHTTPProbesFile(filePath string) ([]config.HTTPProbeCmd, error) {
 
 			cmd.Method = strings.ToUpper(cmd.Method)
 
-			if cmd.Resource == "" || !isResource(cmd.Resource) {
+			cmd.Protocol = strings.ToLower(cmd.Protocol)
+			if config.IsRawProto(cmd.Protocol) {
+				if cmd.Resource == "" {
+					cmd.Resource = "/"
+				}
+			} else if cmd.Resource == "" || !isResource(cmd.Resource) {
 				return nil, fmt.Errorf("invalid HTTP probe command resource: %+v", cmd)
 			}
 
@@ -648,6 +661,12 @@ func ParseHTTPProbesFile(filePath string) ([]config.HTTPProbeCmd, error) {
 				return nil, fmt.Errorf("invalid HTTP probe command port: %v", cmd)
 			}
 
+			if cmd.ExpectBody != "" {
+				if _, err := regexp.Compile(cmd.ExpectBody); err != nil {
+					return nil, fmt.Errorf("invalid HTTP probe command body expectation: %v (%v)", cmd, err)
+				}
+			}
+
 			if cmd.BodyFile != "" {
 				bfFullPath, err := filepath.Abs(cmd.BodyFile)
 				if err != nil {
@@ -672,6 +691,197 @@ func ParseHTTPProbesFile(filePath string) ([]config.HTTPProbeCmd, error) {
 	return probes, nil
 }
 
+// harLog contains the HAR file fields used to create the HTTP probe commands
+type harLog struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// headers that shouldn't be replayed as-is against the temporary container
+var harSkipHeaders = map[string]struct{}{
+	"host":              {},
+	"content-length":    {},
+	"connection":        {},
+	"accept-encoding":   {},
+	"transfer-encoding": {},
+	"upgrade":           {},
+	"te":                {},
+}
+
+// ParseHTTPProbesHARFile creates HTTP probe commands from the requests
+// recorded in a HAR file (duplicate requests are replayed only once)
+func ParseHTTPProbesHARFile(filePath string) ([]config.HTTPProbeCmd, error) {
+	probes := []config.HTTPProbeCmd{}
+
+	if filePath == "" {
+		return probes, nil
+	}
+
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileData, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var har harLog
+	if err = json.Unmarshal(fileData, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR file (%s) - %v", filePath, err)
+	}
+
+	seen := map[string]struct{}{}
+	for _, entry := range har.Log.Entries {
+		req := entry.Request
+		if !isMethod(req.Method) {
+			log.Debugf("ParseHTTPProbesHARFile: skipping unsupported method - %s %s", req.Method, req.URL)
+			continue
+		}
+
+		resource, err := parseProbeURL(req.URL)
+		if err != nil {
+			log.Debugf("ParseHTTPProbesHARFile: skipping request with bad URL - %s (%v)", req.URL, err)
+			continue
+		}
+
+		cmd := config.HTTPProbeCmd{
+			Method:   strings.ToUpper(req.Method),
+			Resource: resource,
+		}
+
+		for _, header := range req.Headers {
+			if strings.HasPrefix(header.Name, ":") {
+				//HTTP/2 pseudo-headers
+				continue
+			}
+
+			if _, found := harSkipHeaders[strings.ToLower(header.Name)]; found {
+				continue
+			}
+
+			cmd.Headers = append(cmd.Headers, fmt.Sprintf("%s: %s", header.Name, header.Value))
+		}
+
+		if req.PostData != nil {
+			cmd.Body = req.PostData.Text
+		}
+
+		key := fmt.Sprintf("%s %s %s", cmd.Method, cmd.Resource, cmd.Body)
+		if _, found := seen[key]; found {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		probes = append(probes, cmd)
+	}
+
+	return probes, nil
+}
+
+// ParseHTTPProbesURLFile creates HTTP probe commands from a file
+// where each line has a URL (or a resource path) with an optional method and body:
+// [METHOD] URL [BODY]
+func ParseHTTPProbesURLFile(filePath string) ([]config.HTTPProbeCmd, error) {
+	probes := []config.HTTPProbeCmd{}
+
+	if filePath == "" {
+		return probes, nil
+	}
+
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileData, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(fileData), "\n")
+	for idx, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		method := "GET"
+		parts := strings.SplitN(line, " ", 2)
+		if isMethod(parts[0]) && len(parts) == 2 {
+			method = strings.ToUpper(parts[0])
+			line = strings.TrimSpace(parts[1])
+		}
+
+		var body string
+		parts = strings.SplitN(line, " ", 2)
+		if len(parts) == 2 {
+			body = strings.TrimSpace(parts[1])
+		}
+
+		resource, err := parseProbeURL(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in %s (line %d) - %v", filePath, idx+1, err)
+		}
+
+		cmd := config.HTTPProbeCmd{
+			Method:   method,
+			Resource: resource,
+			Body:     body,
+		}
+
+		probes = append(probes, cmd)
+	}
+
+	return probes, nil
+}
+
+// parseProbeURL returns the probe resource for a URL or a resource path
+// (the URL scheme and host are ignored because the probes target the temporary container
+// and the protocol is selected based on the container port)
+func parseProbeURL(raw string) (string, error) {
+	if isResource(raw) {
+		return raw, nil
+	}
+
+	pu, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(pu.Scheme) {
+	case config.ProtoHTTP, config.ProtoHTTPS:
+	default:
+		return "", fmt.Errorf("unsupported URL scheme - '%s'", pu.Scheme)
+	}
+
+	resource := pu.EscapedPath()
+	if resource == "" {
+		resource = "/"
+	}
+
+	if pu.RawQuery != "" {
+		resource = fmt.Sprintf("%s?%s", resource, pu.RawQuery)
+	}
+
+	return resource, nil
+}
+
 func isMethod(value string) bool {
 	switch strings.ToUpper(value) {
 	case "HEAD", "GET", "POST", "PUT", "DELETE", "PATCH":
@@ -697,6 +907,147 @@ func isPortNum(value int) bool {
 	return false
 }
 
+// ParseProbeWhen parses the probe readiness conditions
+// ('log:<regex>', 'tcp', 'tcp:<port>' or 'healthcheck')
+func ParseProbeWhen(values []string) ([]config.ProbeReadyCondition, error) {
+	var conditions []config.ProbeReadyCondition
+	for _, raw := range values {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		condType := raw
+		var condParam string
+		if idx := strings.Index(raw, ":"); idx != -1 {
+			condType = raw[:idx]
+			condParam = raw[idx+1:]
+		}
+
+		condition := config.ProbeReadyCondition{
+			Type: strings.ToLower(condType),
+		}
+
+		switch condition.Type {
+		case config.ProbeReadyLog:
+			if condParam == "" {
+				return nil, fmt.Errorf("missing log pattern - %s", raw)
+			}
+
+			if _, err := regexp.Compile(condParam); err != nil {
+				return nil, fmt.Errorf("bad log pattern - %s (%v)", raw, err)
+			}
+
+			condition.Pattern = condParam
+		case config.ProbeReadyTCP:
+			if condParam != "" {
+				if _, err := strconv.ParseUint(condParam, 10, 16); err != nil {
+					return nil, fmt.Errorf("bad tcp port - %s", raw)
+				}
+
+				condition.Port = condParam
+			}
+		case config.ProbeReadyHealthcheck:
+			if condParam != "" {
+				return nil, fmt.Errorf("unexpected healthcheck parameter - %s", raw)
+			}
+		default:
+			return nil, fmt.Errorf("unknown readiness condition - %s", raw)
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+// ParseDepServices parses the dependency service flag values
+// ('[name=]image[,ENV_NAME=value...]')
+func ParseDepServices(values []string) ([]config.DepService, error) {
+	var services []config.DepService
+	for _, raw := range values {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		parts := strings.Split(raw, ",")
+		var svc config.DepService
+		svcInfo := strings.TrimSpace(parts[0])
+		if idx := strings.Index(svcInfo, "="); idx != -1 {
+			svc.Name = svcInfo[:idx]
+			svc.Image = svcInfo[idx+1:]
+		} else {
+			svc.Image = svcInfo
+			//use the image repo name as the service name
+			svc.Name = svcInfo
+			if idx := strings.LastIndex(svc.Name, "/"); idx != -1 {
+				svc.Name = svc.Name[idx+1:]
+			}
+
+			if idx := strings.IndexAny(svc.Name, ":@"); idx != -1 {
+				svc.Name = svc.Name[:idx]
+			}
+		}
+
+		if svc.Name == "" || svc.Image == "" {
+			return nil, fmt.Errorf("malformed dependency service - %s", raw)
+		}
+
+		for _, envVar := range parts[1:] {
+			envVar = strings.TrimSpace(envVar)
+			if !strings.Contains(envVar, "=") {
+				return nil, fmt.Errorf("malformed dependency service env var - %s", raw)
+			}
+
+			svc.Env = append(svc.Env, envVar)
+		}
+
+		services = append(services, svc)
+	}
+
+	return services, nil
+}
+
+// ParseExecHooks parses the exec hook flag values
+// ('<after-start|probe-round|before-commit>:<host|container>:<command>')
+func ParseExecHooks(values []string) ([]config.ExecHook, error) {
+	var hooks []config.ExecHook
+	for _, raw := range values {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		parts := strings.SplitN(raw, ":", 3)
+		if len(parts) != 3 || strings.TrimSpace(parts[2]) == "" {
+			return nil, fmt.Errorf("malformed exec hook - %s", raw)
+		}
+
+		hook := config.ExecHook{
+			Phase:   strings.ToLower(parts[0]),
+			Target:  strings.ToLower(parts[1]),
+			Command: strings.TrimSpace(parts[2]),
+		}
+
+		switch hook.Phase {
+		case config.ExecHookAfterStart, config.ExecHookProbeRound, config.ExecHookBeforeCommit:
+		default:
+			return nil, fmt.Errorf("unknown exec hook phase - %s", raw)
+		}
+
+		switch hook.Target {
+		case config.ExecHookHost, config.ExecHookContainer:
+		default:
+			return nil, fmt.Errorf("unknown exec hook target - %s", raw)
+		}
+
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, nil
+}
+
 func ParseHTTPProbesPorts(portList string) ([]uint16, error) {
 	var ports []uint16
 
@@ -755,6 +1106,67 @@ func ParseHTTPProbeExecFile(filePath string) ([]string, error) {
 	return appCalls, nil
 }
 
+func ParseHTTPProbeHeaderFile(filePath string) ([]string, error) {
+	var headers []string
+
+	if filePath == "" {
+		return headers, nil
+	}
+
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return headers, err
+	}
+
+	fileData, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return headers, err
+	}
+
+	lines := strings.Split(string(fileData), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !strings.Contains(line, ":") {
+			return nil, fmt.Errorf("malformed header in %s - '%s'", filePath, line)
+		}
+
+		headers = append(headers, line)
+	}
+
+	return headers, nil
+}
+
+// ParseHTTPProbeHeaderEnv takes 'Name:ENV_VAR_NAME' values
+// and returns 'Name: value' headers with the values from the environment
+func ParseHTTPProbeHeaderEnv(values []string) ([]string, error) {
+	var headers []string
+	for _, raw := range values {
+		parts := strings.SplitN(raw, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed header env param - '%s'", raw)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		envName := strings.TrimSpace(parts[1])
+		if name == "" || envName == "" {
+			return nil, fmt.Errorf("malformed header env param - '%s'", raw)
+		}
+
+		value, found := os.LookupEnv(envName)
+		if !found {
+			return nil, fmt.Errorf("header env var is not set - '%s'", envName)
+		}
+
+		headers = append(headers, fmt.Sprintf("%s: %s", name, value))
+	}
+
+	return headers, nil
+}
+
 func ParseEnvFile(filePath string) ([]string, error) {
 	var envVars []string
 
@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const testHARFile = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {
+        "request": {
+          "method": "get",
+          "url": "https://example.com/api/items?page=2&sort=name",
+          "headers": [
+            {"name": ":authority", "value": "example.com"},
+            {"name": "Host", "value": "example.com"},
+            {"name": "Accept", "value": "application/json"},
+            {"name": "Accept-Encoding", "value": "gzip"},
+            {"name": "Content-Length", "value": "0"}
+          ]
+        }
+      },
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://example.com/api/items?page=2&sort=name",
+          "headers": [
+            {"name": "Accept", "value": "text/html"}
+          ]
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "http://localhost:8080/api/items",
+          "headers": [
+            {"name": "Content-Type", "value": "application/json"},
+            {"name": "Connection", "value": "keep-alive"}
+          ],
+          "postData": {"mimeType": "application/json", "text": "{\"name\":\"one\"}"}
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "http://localhost:8080/api/items",
+          "postData": {"mimeType": "application/json", "text": "{\"name\":\"two\"}"}
+        }
+      },
+      {
+        "request": {
+          "method": "OPTIONS",
+          "url": "https://example.com/api/items"
+        }
+      },
+      {
+        "request": {
+          "method": "GET",
+          "url": "wss://example.com/ws"
+        }
+      },
+      {
+        "request": {
+          "method": "DELETE",
+          "url": "https://example.com"
+        }
+      }
+    ]
+  }
+}`
+
+func writeTestProbesFile(t *testing.T, name, data string) string {
+	dir, err := ioutil.TempDir("", "probes")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	filePath := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filePath, []byte(data), 0644))
+	return filePath
+}
+
+func TestParseHTTPProbesHARFile(t *testing.T) {
+	probes, err := ParseHTTPProbesHARFile(writeTestProbesFile(t, "session.har", testHARFile))
+	require.NoError(t, err)
+
+	expected := []config.HTTPProbeCmd{
+		{
+			//the pseudo-headers and the connection specific headers are not replayed
+			//(the duplicate request with different headers is replayed only once)
+			Method:   "GET",
+			Resource: "/api/items?page=2&sort=name",
+			Headers:  []string{"Accept: application/json"},
+		},
+		{
+			Method:   "POST",
+			Resource: "/api/items",
+			Headers:  []string{"Content-Type: application/json"},
+			Body:     `{"name":"one"}`,
+		},
+		{
+			Method:   "POST",
+			Resource: "/api/items",
+			Body:     `{"name":"two"}`,
+		},
+		{
+			Method:   "DELETE",
+			Resource: "/",
+		},
+	}
+
+	assert.Equal(t, expected, probes)
+}
+
+func TestParseHTTPProbesHARFileErrors(t *testing.T) {
+	probes, err := ParseHTTPProbesHARFile("")
+	require.NoError(t, err)
+	assert.Empty(t, probes)
+
+	_, err = ParseHTTPProbesHARFile(writeTestProbesFile(t, "bad.har", `{"log": [`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid HAR file")
+
+	_, err = ParseHTTPProbesHARFile(filepath.Join(os.TempDir(), "no-such-dir", "session.har"))
+	assert.Error(t, err)
+}
+
+func TestParseHTTPProbesURLFile(t *testing.T) {
+	data := `# probes
+https://example.com/health
+
+/api/items?page=1
+post http://example.com/api/items {"name": "one"}
+DELETE /api/items/1
+PUT /api/items/1 plain body
+GET
+`
+
+	_, err := ParseHTTPProbesURLFile(writeTestProbesFile(t, "urls.txt", data))
+	require.Error(t, err)
+	//the method without a URL is not valid
+	assert.Contains(t, err.Error(), "(line 8)")
+
+	probes, err := ParseHTTPProbesURLFile(writeTestProbesFile(t, "urls.txt", data[:len(data)-len("GET\n")]))
+	require.NoError(t, err)
+
+	expected := []config.HTTPProbeCmd{
+		{Method: "GET", Resource: "/health"},
+		{Method: "GET", Resource: "/api/items?page=1"},
+		{Method: "POST", Resource: "/api/items", Body: `{"name": "one"}`},
+		{Method: "DELETE", Resource: "/api/items/1"},
+		{Method: "PUT", Resource: "/api/items/1", Body: "plain body"},
+	}
+
+	assert.Equal(t, expected, probes)
+}
+
+func TestParseProbeURL(t *testing.T) {
+	tt := []struct {
+		raw      string
+		expected string
+		err      bool
+	}{
+		{raw: "/api/v1?q=1", expected: "/api/v1?q=1"},
+		{raw: "http://example.com", expected: "/"},
+		{raw: "HTTPS://example.com:8443/a%20b/c?x=1&y=2", expected: "/a%20b/c?x=1&y=2"},
+		{raw: "https://example.com/?x=1", expected: "/?x=1"},
+		{raw: "ftp://example.com/file", err: true},
+		{raw: "example.com/path", err: true},
+		{raw: "http://[::1", err: true},
+	}
+
+	for _, test := range tt {
+		resource, err := parseProbeURL(test.raw)
+		if test.err {
+			assert.Error(t, err, test.raw)
+			continue
+		}
+
+		require.NoError(t, err, test.raw)
+		assert.Equal(t, test.expected, resource, test.raw)
+	}
+}
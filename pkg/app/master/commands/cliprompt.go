@@ -21,6 +21,8 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/sbom"
+	"github.com/docker-slim/docker-slim/pkg/trace"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	"github.com/docker-slim/docker-slim/pkg/version"
@@ -49,7 +51,7 @@ func NewInteractiveApp(app *cli.App, gparams *GenericParams) *InteractiveApp {
 		}
 		fmt.Printf("docker-slim: info=docker.connect.error message='%s'\n", exitMsg)
 		fmt.Printf("docker-slim: state=exited version=%s location='%s'\n", version.Current(), fsutil.ExeDir())
-		os.Exit(-777)
+		os.Exit(ECTCommon | ECNoDockerConnectInfo)
 	}
 	errutil.FailOn(err)
 
@@ -58,7 +60,7 @@ func NewInteractiveApp(app *cli.App, gparams *GenericParams) *InteractiveApp {
 	ia.appPrompt = prompt.New(
 		ia.execute,
 		ia.complete,
-		prompt.OptionTitle(fmt.Sprintf("%s: interactive prompt", AppName)),
+		prompt.OptionTitle(fmt.Sprintf("%s: interactive prompt", CLIName)),
 		prompt.OptionPrefix(">>> "),
 		prompt.OptionInputTextColor(prompt.Red),
 		prompt.OptionCompletionWordSeparator(completer.FilePathCompletionSeparator),
@@ -219,6 +221,9 @@ var GlobalFlagSuggestions = []prompt.Suggest{
 	{Text: FullFlagName(FlagInContainer), Description: FlagInContainerUsage},
 	{Text: FullFlagName(FlagCheckVersion), Description: FlagCheckVersionUsage},
 	{Text: FullFlagName(FlagNoColor), Description: FlagNoColorUsage},
+	{Text: FullFlagName(FlagNoCache), Description: FlagNoCacheUsage},
+	{Text: FullFlagName(FlagOTelEndpoint), Description: FlagOTelEndpointUsage},
+	{Text: FullFlagName(FlagOTelHeaders), Description: FlagOTelHeadersUsage},
 }
 
 func FullFlagName(name string) string {
@@ -238,6 +243,7 @@ var tboolValues = []prompt.Suggest{
 var continueAfterValues = []prompt.Suggest{
 	{Text: config.CAMAppExit, Description: "Continue after the target app exits"},
 	{Text: config.CAMHostExec, Description: "Continue after host command execution is finished running"},
+	{Text: config.CAMSession, Description: "Continue after the interactive (or replayed) container exec session is done"},
 	{Text: config.CAMExec, Description: "Continue after container command execution is finished running"},
 	{Text: config.CAMProbe, Description: "Continue after the HTTP probe is finished running"},
 	{Text: config.CAMEnter, Description: "Use the <enter> key to indicate you that you are done using the container"},
@@ -257,6 +263,16 @@ var ipcModeValues = []prompt.Suggest{
 	{Text: "direct", Description: "Direct sensor ipc mode"},
 }
 
+var sbomFormatValues = []prompt.Suggest{
+	{Text: sbom.FormatSPDXJSON, Description: "SPDX JSON SBOM"},
+	{Text: sbom.FormatCycloneDXJSON, Description: "CycloneDX JSON SBOM"},
+}
+
+var traceFormatValues = []prompt.Suggest{
+	{Text: trace.FormatChrome, Description: "Chrome trace event format (chrome://tracing, Perfetto)"},
+	{Text: trace.FormatOTel, Description: "OpenTelemetry spans (OTLP JSON)"},
+}
+
 func CompleteProgress(ia *InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	switch runtime.GOOS {
 	case "darwin":
@@ -278,6 +294,15 @@ func CompleteContinueAfter(ia *InteractiveApp, token string, params prompt.Docum
 	return prompt.FilterHasPrefix(continueAfterValues, token, true)
 }
 
+func CompletePreset(ia *InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	var values []prompt.Suggest
+	for _, name := range FlagPresetNames() {
+		values = append(values, prompt.Suggest{Text: name, Description: FlagPresets[name].Description})
+	}
+
+	return prompt.FilterHasPrefix(values, token, true)
+}
+
 func CompleteConsoleOutput(ia *InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	return prompt.FilterHasPrefix(consoleOutputValues, token, true)
 }
@@ -286,6 +311,14 @@ func CompleteIPCMode(ia *InteractiveApp, token string, params prompt.Document) [
 	return prompt.FilterHasPrefix(ipcModeValues, token, true)
 }
 
+func CompleteSBOMFormat(ia *InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(sbomFormatValues, token, true)
+}
+
+func CompleteTraceFormat(ia *InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(traceFormatValues, token, true)
+}
+
 func CompleteTarget(ia *InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	images, err := dockerutil.ListImages(ia.dclient, "")
 	if err != nil {
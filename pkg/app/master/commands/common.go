@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -15,8 +16,10 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/cache"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/docker/registryconfig"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 )
 
@@ -33,6 +36,8 @@ type CLIContextKey int
 const (
 	GlobalParams CLIContextKey = 1
 	AppParams    CLIContextKey = 2
+	//flag config values (from the flag config files)
+	FlagConfigParams CLIContextKey = 3
 )
 
 func CLIContextSave(ctx context.Context, key CLIContextKey, data interface{}) context.Context {
@@ -54,6 +59,7 @@ type GenericParams struct {
 	CheckVersion   bool
 	Debug          bool
 	Verbose        bool
+	Quiet          bool
 	LogLevel       string
 	LogFormat      string
 	ConsoleOutput  string
@@ -63,26 +69,50 @@ type GenericParams struct {
 	InContainer    bool
 	IsDSImage      bool
 	ArchiveState   string
+	NoCache        bool
+	OTelEndpoint   string
+	OTelHeaders    string
+	Output         string
+	OutputFile     string
+	OutputSinks    []string
+	DiagBundle     string
 	ClientConfig   *config.DockerClient
+	RegistryConfig *registryconfig.Config
 }
 
 // Exit Code Types
 const (
-	ECTCommon  = 0x01000000
-	ECTBuild   = 0x02000000
-	ectProfile = 0x03000000
-	ectInfo    = 0x04000000
-	ectUpdate  = 0x05000000
-	ectVersion = 0x06000000
-	ECTXray    = 0x07000000
-	ECTRun     = 0x08000000
+	ECTCommon         = 0x01000000
+	ECTBuild          = 0x02000000
+	ECTProfile        = 0x03000000
+	ectInfo           = 0x04000000
+	ectUpdate         = 0x05000000
+	ectVersion        = 0x06000000
+	ECTXray           = 0x07000000
+	ECTRun            = 0x08000000
+	ECTProbe          = 0x09000000
+	ECTVerify         = 0x0a000000
+	ECTCache          = 0x0b000000
+	ECTValidateReport = 0x0c000000
+	ECTLint           = 0x0d000000
+	ECTPolicy         = 0x0e000000
+	ECTState          = 0x0f000000
+	ECTDoctor         = 0x10000000
+	ECTExtract        = 0x11000000
+	ECTHistory        = 0x12000000
+	ECTConvert        = 0x13000000
+	ECTRegistry       = 0x14000000
+	ECTContainerize   = 0x15000000
+	ECTInstrument     = 0x16000000
 )
 
-// Build command exit codes
+// Common command exit codes
 const (
 	ecOther = iota + 1
 	ECNoDockerConnectInfo
 	ECBadNetworkName
+	ECBadParams
+	ECCommandExec
 )
 
 const (
@@ -90,8 +120,21 @@ const (
 	appName = "docker-slim"
 )
 
+// CLIName is the command name shown to the users
+// ('docker slim' when docker-slim is executed as a Docker CLI plugin)
+var CLIName = AppName
+
 //Common command handler code
 
+// ResultCache returns the analysis result cache (nil if the cache is disabled)
+func ResultCache(gparams *GenericParams) *cache.Store {
+	if gparams.NoCache {
+		return nil
+	}
+
+	return cache.NewStore(gparams.StatePath)
+}
+
 func DoArchiveState(logger *log.Entry, client *docker.Client, localStatePath, volumeName, stateKey string) error {
 	if volumeName == "" {
 		return nil
@@ -257,6 +300,41 @@ func RunHostExecProbes(printState bool, xc *app.ExecutionContext, hostExecProbes
 	}
 }
 
+// RunSelfCommand runs another docker-slim command (using the current docker-slim executable)
+// and returns its exit code (the command gets the same console streams)
+func RunSelfCommand(xc *app.ExecutionContext, args []string) (int, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return -1, err
+	}
+
+	cmd := exec.Command(exePath, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	//the command gets the interrupt signal too (wait for its cleanup when the current command is interrupted)
+	cmdDone := make(chan struct{})
+	xc.AddCleanupHandler(func() {
+		if xc.Context.Err() != nil {
+			<-cmdDone
+		}
+	})
+
+	err = cmd.Run()
+	close(cmdDone)
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+
+	return -1, err
+}
+
 func exeAppCall(appCall string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
 	defer cancel()
@@ -290,7 +368,7 @@ func exeAppCall(appCall string) error {
 	err = cmd.Wait()
 	fmt.Printf("\n")
 	if err != nil {
-		log.Fatalf("exeAppCall(%s): command exited with error: %v", appCall, err)
+		log.Errorf("exeAppCall(%s): command exited with error: %v", appCall, err)
 		return err
 	}
 
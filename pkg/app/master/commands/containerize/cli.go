@@ -3,42 +3,83 @@ package containerize
 import (
 	"fmt"
 
+	"github.com/google/shlex"
 	"github.com/urfave/cli/v2"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 )
 
+//Containerize the app source code (detect the app type, generate a multi-stage Dockerfile and build the image)
+
 const (
 	Name  = "containerize"
-	Usage = "Containerize the target app"
+	Usage = "Containerize the target app (generate a Dockerfile for the app source directory and build the image)"
 	Alias = "c"
 )
 
+type CommandParams struct {
+	SourceDir     string
+	AppType       string
+	Tag           string
+	Dockerfile    string
+	Port          int
+	GenerateOnly  bool
+	ShowBuildLogs bool
+	DoSlim        bool
+	SlimArgs      []string
+}
+
 var CLI = &cli.Command{
-	Name:    Name,
-	Aliases: []string{Alias},
-	Usage:   Usage,
+	Name:      Name,
+	Aliases:   []string{Alias},
+	Usage:     Usage,
+	ArgsUsage: "<SOURCE_DIRECTORY>",
+	Flags: []cli.Flag{
+		cflag(FlagAppType),
+		cflag(FlagTag),
+		cflag(FlagDockerfile),
+		cflag(FlagPort),
+		cflag(FlagGenerateOnly),
+		cflag(FlagShowBuildLogs),
+		cflag(FlagSlim),
+		cflag(FlagSlimArgs),
+	},
 	Action: func(ctx *cli.Context) error {
 		if ctx.Args().Len() < 1 {
-			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
+			fmt.Printf("docker-slim[%s]: missing source directory...\n\n", Name)
 			cli.ShowCommandHelp(ctx, Name)
 			return nil
 		}
 
+		slimArgs, err := shlex.Split(ctx.String(FlagSlimArgs))
+		if err != nil {
+			return fmt.Errorf("bad --%s value: %v", FlagSlimArgs, err)
+		}
+
+		cparams := &CommandParams{
+			SourceDir:     ctx.Args().First(),
+			AppType:       ctx.String(FlagAppType),
+			Tag:           ctx.String(FlagTag),
+			Dockerfile:    ctx.String(FlagDockerfile),
+			Port:          ctx.Int(FlagPort),
+			GenerateOnly:  ctx.Bool(FlagGenerateOnly),
+			ShowBuildLogs: ctx.Bool(FlagShowBuildLogs),
+			DoSlim:        ctx.Bool(FlagSlim),
+			SlimArgs:      slimArgs,
+		}
+
 		gcvalues, err := commands.GlobalFlagValues(ctx)
 		if err != nil {
 			return err
 		}
 
-		targetRef := ctx.Args().First()
-
 		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
 
 		OnCommand(
 			xc,
 			gcvalues,
-			targetRef)
+			cparams)
 
 		return nil
 	},
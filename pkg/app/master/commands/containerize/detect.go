@@ -0,0 +1,394 @@
+package containerize
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Supported app types
+const (
+	AppTypeAuto   = "auto"
+	AppTypeGo     = "go"
+	AppTypeNode   = "node"
+	AppTypePython = "python"
+	AppTypeJava   = "java"
+)
+
+// App build tools
+const (
+	BuildToolGo     = "go"
+	BuildToolNPM    = "npm"
+	BuildToolYarn   = "yarn"
+	BuildToolPNPM   = "pnpm"
+	BuildToolPip    = "pip"
+	BuildToolPipenv = "pipenv"
+	BuildToolMaven  = "maven"
+	BuildToolGradle = "gradle"
+)
+
+// Default runtime versions (used when the app doesn't specify the version)
+const (
+	defaultGoVersion     = "1.21"
+	defaultNodeVersion   = "20"
+	defaultPythonVersion = "3.12"
+	defaultJavaVersion   = "17"
+)
+
+// Default app ports
+const (
+	defaultGoPort     = 8080
+	defaultNodePort   = 3000
+	defaultPythonPort = 8000
+	defaultJavaPort   = 8080
+)
+
+var (
+	ErrUnknownAppType = errors.New("unknown app type (no go.mod, package.json, pom.xml, build.gradle, requirements.txt, pyproject.toml or Pipfile)")
+	ErrBadAppType     = errors.New("unsupported app type")
+)
+
+// AppInfo is the detected app info used to generate the Dockerfile
+type AppInfo struct {
+	Type          string `json:"type"`
+	Version       string `json:"version"`
+	VersionSource string `json:"version_source,omitempty"`
+	BuildTool     string `json:"build_tool"`
+	//the app name (also the Go binary name)
+	Name string `json:"name,omitempty"`
+	//the Go main package or the Node main script
+	Main string `json:"main,omitempty"`
+	//the Python dependency file (the app is installed as a package if it's empty)
+	DependencyFile string   `json:"dependency_file,omitempty"`
+	HasWrapper     bool     `json:"has_wrapper,omitempty"`
+	HasBuildScript bool     `json:"has_build_script,omitempty"`
+	HasStartScript bool     `json:"has_start_script,omitempty"`
+	Port           int      `json:"port"`
+	Entrypoint     []string `json:"entrypoint"`
+	//extra .dockerignore patterns (the app dependencies and the build outputs)
+	Ignores []string `json:"-"`
+}
+
+var appTypeDetectors = []struct {
+	appType string
+	files   []string
+}{
+	{appType: AppTypeGo, files: []string{"go.mod"}},
+	{appType: AppTypeJava, files: []string{"pom.xml", "build.gradle", "build.gradle.kts"}},
+	{appType: AppTypeNode, files: []string{"package.json"}},
+	{appType: AppTypePython, files: []string{"requirements.txt", "pyproject.toml", "Pipfile", "setup.py"}},
+}
+
+// DetectAppType returns the app type based on the build/package manifest files in the source directory
+func DetectAppType(sourceDir string) (string, error) {
+	for _, detector := range appTypeDetectors {
+		for _, name := range detector.files {
+			if fileExists(sourceDir, name) {
+				return detector.appType, nil
+			}
+		}
+	}
+
+	return "", ErrUnknownAppType
+}
+
+// InspectApp detects the app info (the app type is detected if it's 'auto' or empty
+// and the app type default port is used if the port is not set)
+func InspectApp(sourceDir, appType string, port int) (*AppInfo, error) {
+	if appType == "" || appType == AppTypeAuto {
+		var err error
+		if appType, err = DetectAppType(sourceDir); err != nil {
+			return nil, err
+		}
+	}
+
+	switch appType {
+	case AppTypeGo:
+		return inspectGoApp(sourceDir, port)
+	case AppTypeNode:
+		return inspectNodeApp(sourceDir, port)
+	case AppTypePython:
+		return inspectPythonApp(sourceDir, port)
+	case AppTypeJava:
+		return inspectJavaApp(sourceDir, port)
+	default:
+		return nil, fmt.Errorf("%w - %s", ErrBadAppType, appType)
+	}
+}
+
+var (
+	goVersionPattern    = regexp.MustCompile(`(?m)^go\s+(\d+\.\d+)`)
+	goModulePattern     = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	goMainPkgPattern    = regexp.MustCompile(`(?m)^package\s+main\s*$`)
+	nodeVersionPattern  = regexp.MustCompile(`\d+`)
+	pyVersionPattern    = regexp.MustCompile(`(\d+\.\d+)`)
+	mavenVersionPattern = regexp.MustCompile(
+		`<(?:java\.version|maven\.compiler\.release|maven\.compiler\.source|maven\.compiler\.target)>\s*(?:1\.)?(\d+)`)
+	gradleVersionPattern = regexp.MustCompile(
+		`(?:JavaLanguageVersion\.of\(\s*(\d+)\s*\)|(?:source|target)Compatibility\s*=\s*['"]?(?:JavaVersion\.VERSION_)?(?:1[._])?(\d+))`)
+)
+
+func inspectGoApp(sourceDir string, port int) (*AppInfo, error) {
+	info := &AppInfo{
+		Type:      AppTypeGo,
+		Version:   defaultGoVersion,
+		BuildTool: BuildToolGo,
+		Main:      ".",
+		Name:      dirName(sourceDir),
+		Port:      appPort(port, defaultGoPort),
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(sourceDir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	if matches := goVersionPattern.FindSubmatch(data); matches != nil {
+		info.Version = string(matches[1])
+		info.VersionSource = "go.mod"
+	}
+
+	if matches := goModulePattern.FindSubmatch(data); matches != nil {
+		info.Name = filepath.Base(string(matches[1]))
+	}
+
+	//the main package is in the source directory or in one of the 'cmd' subdirectories
+	if !hasGoMainPackage(sourceDir) {
+		cmdDirs, _ := filepath.Glob(filepath.Join(sourceDir, "cmd", "*"))
+		sort.Strings(cmdDirs)
+		for _, cmdDir := range cmdDirs {
+			if hasGoMainPackage(cmdDir) {
+				info.Main = "./cmd/" + filepath.Base(cmdDir)
+				info.Name = filepath.Base(cmdDir)
+				break
+			}
+		}
+	}
+
+	info.Entrypoint = []string{"/app/" + info.Name}
+	return info, nil
+}
+
+func hasGoMainPackage(dir string) bool {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		if goMainPkgPattern.Match(data) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nodePackage is the subset of the package.json data used to containerize the app
+type nodePackage struct {
+	Name    string            `json:"name"`
+	Main    string            `json:"main"`
+	Scripts map[string]string `json:"scripts"`
+	Engines map[string]string `json:"engines"`
+}
+
+func inspectNodeApp(sourceDir string, port int) (*AppInfo, error) {
+	info := &AppInfo{
+		Type:      AppTypeNode,
+		Version:   defaultNodeVersion,
+		BuildTool: BuildToolNPM,
+		Name:      dirName(sourceDir),
+		Port:      appPort(port, defaultNodePort),
+		Ignores:   []string{"node_modules", "npm-debug.log*", "yarn-error.log*"},
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(sourceDir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg nodePackage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("bad package.json - %v", err)
+	}
+
+	if pkg.Name != "" {
+		info.Name = pkg.Name
+	}
+
+	if version := nodeVersionPattern.FindString(pkg.Engines["node"]); version != "" {
+		info.Version = version
+		info.VersionSource = "package.json"
+	} else if version := readVersionFile(sourceDir, ".nvmrc", nodeVersionPattern); version != "" {
+		info.Version = version
+		info.VersionSource = ".nvmrc"
+	}
+
+	switch {
+	case fileExists(sourceDir, "yarn.lock"):
+		info.BuildTool = BuildToolYarn
+	case fileExists(sourceDir, "pnpm-lock.yaml"):
+		info.BuildTool = BuildToolPNPM
+	}
+
+	_, info.HasBuildScript = pkg.Scripts["build"]
+	_, info.HasStartScript = pkg.Scripts["start"]
+
+	//running the main script directly (instead of 'npm start') makes the app the container main process
+	startScript := strings.Fields(pkg.Scripts["start"])
+	switch {
+	case len(startScript) == 2 && startScript[0] == "node" && fileExists(sourceDir, startScript[1]):
+		info.Main = startScript[1]
+	case info.HasStartScript:
+	case pkg.Main != "" && fileExists(sourceDir, pkg.Main):
+		info.Main = pkg.Main
+	default:
+		for _, name := range []string{"server.js", "index.js", "app.js", "main.js"} {
+			if fileExists(sourceDir, name) {
+				info.Main = name
+				break
+			}
+		}
+	}
+
+	switch {
+	case info.Main != "":
+		info.Entrypoint = []string{"node", info.Main}
+	case info.HasStartScript:
+		info.Entrypoint = []string{"npm", "start"}
+	default:
+		info.Entrypoint = []string{"node", "index.js"}
+	}
+
+	return info, nil
+}
+
+func inspectPythonApp(sourceDir string, port int) (*AppInfo, error) {
+	info := &AppInfo{
+		Type:      AppTypePython,
+		Version:   defaultPythonVersion,
+		BuildTool: BuildToolPip,
+		Name:      dirName(sourceDir),
+		Port:      appPort(port, defaultPythonPort),
+		Ignores:   []string{"__pycache__", "*.pyc", ".venv", "venv", ".pytest_cache"},
+	}
+
+	if version := readVersionFile(sourceDir, ".python-version", pyVersionPattern); version != "" {
+		info.Version = version
+		info.VersionSource = ".python-version"
+	} else if version := readVersionFile(sourceDir, "runtime.txt", pyVersionPattern); version != "" {
+		info.Version = version
+		info.VersionSource = "runtime.txt"
+	}
+
+	switch {
+	case fileExists(sourceDir, "requirements.txt"):
+		info.DependencyFile = "requirements.txt"
+	case fileExists(sourceDir, "Pipfile"):
+		info.BuildTool = BuildToolPipenv
+		info.DependencyFile = "Pipfile"
+	}
+
+	switch {
+	case fileExists(sourceDir, "manage.py"):
+		info.Entrypoint = []string{"python", "manage.py", "runserver", fmt.Sprintf("0.0.0.0:%d", info.Port)}
+	default:
+		info.Entrypoint = []string{"python", "main.py"}
+		for _, name := range []string{"main.py", "app.py", "server.py", "wsgi.py"} {
+			if fileExists(sourceDir, name) {
+				info.Entrypoint = []string{"python", name}
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func inspectJavaApp(sourceDir string, port int) (*AppInfo, error) {
+	info := &AppInfo{
+		Type:       AppTypeJava,
+		Version:    defaultJavaVersion,
+		BuildTool:  BuildToolMaven,
+		Name:       dirName(sourceDir),
+		Port:       appPort(port, defaultJavaPort),
+		Entrypoint: []string{"java", "-jar", "/app/app.jar"},
+		Ignores:    []string{"target", "build", ".gradle", ".idea"},
+	}
+
+	buildFile := "pom.xml"
+	versionPattern := mavenVersionPattern
+	if !fileExists(sourceDir, buildFile) {
+		info.BuildTool = BuildToolGradle
+		versionPattern = gradleVersionPattern
+		buildFile = "build.gradle"
+		if !fileExists(sourceDir, buildFile) {
+			buildFile = "build.gradle.kts"
+		}
+	}
+
+	switch info.BuildTool {
+	case BuildToolMaven:
+		info.HasWrapper = fileExists(sourceDir, "mvnw") && fileExists(sourceDir, ".mvn")
+	case BuildToolGradle:
+		info.HasWrapper = fileExists(sourceDir, "gradlew") && fileExists(sourceDir, "gradle")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(sourceDir, buildFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if matches := versionPattern.FindSubmatch(data); matches != nil {
+		for _, match := range matches[1:] {
+			if len(match) > 0 {
+				info.Version = string(match)
+				info.VersionSource = buildFile
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func appPort(port, defaultPort int) int {
+	if port > 0 {
+		return port
+	}
+
+	return defaultPort
+}
+
+func readVersionFile(sourceDir, name string, pattern *regexp.Regexp) string {
+	data, err := ioutil.ReadFile(filepath.Join(sourceDir, name))
+	if err != nil {
+		return ""
+	}
+
+	return pattern.FindString(string(data))
+}
+
+func fileExists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func dirName(dir string) string {
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+
+	return filepath.Base(dir)
+}
@@ -0,0 +1,223 @@
+package containerize
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultDockerfileName = "Dockerfile.dslim"
+	dockerignoreFileName  = ".dockerignore"
+)
+
+// Runtime base images (the final image stage)
+const (
+	goRuntimeImage     = "gcr.io/distroless/static-debian12:nonroot"
+	nodeRuntimeImage   = "node:%s-slim"
+	pythonRuntimeImage = "python:%s-slim"
+	javaRuntimeImage   = "eclipse-temurin:%s-jre"
+)
+
+// Builder images (the build stage)
+const (
+	goBuilderImage     = "golang:%s"
+	mavenBuilderImage  = "maven:3-eclipse-temurin-%s"
+	gradleBuilderImage = "gradle:jdk%s"
+	jdkBuilderImage    = "eclipse-temurin:%s-jdk"
+)
+
+var commonIgnores = []string{
+	".git",
+	".gitignore",
+	".dockerignore",
+	".env",
+}
+
+// GenerateDockerfile generates the multi-stage Dockerfile for the app
+// (the builder stage has the build toolchain and the final stage has only the runtime and the app)
+func GenerateDockerfile(info *AppInfo) (string, error) {
+	var df dockerfileWriter
+	df.line("# Generated by docker-slim containerize (app type: %s, build tool: %s)", info.Type, info.BuildTool)
+
+	switch info.Type {
+	case AppTypeGo:
+		generateGoStages(&df, info)
+	case AppTypeNode:
+		generateNodeStages(&df, info)
+	case AppTypePython:
+		generatePythonStages(&df, info)
+	case AppTypeJava:
+		generateJavaStages(&df, info)
+	default:
+		return "", fmt.Errorf("%w - %s", ErrBadAppType, info.Type)
+	}
+
+	if info.Port > 0 {
+		df.line("EXPOSE %d", info.Port)
+	}
+
+	entrypoint, err := json.Marshal(info.Entrypoint)
+	if err != nil {
+		return "", err
+	}
+
+	switch info.Type {
+	case AppTypeNode, AppTypePython:
+		df.line("CMD %s", entrypoint)
+	default:
+		df.line("ENTRYPOINT %s", entrypoint)
+	}
+
+	return df.String(), nil
+}
+
+func generateGoStages(df *dockerfileWriter, info *AppInfo) {
+	df.stage(goBuilderImage, info.Version)
+	df.line("WORKDIR /src")
+	df.line("COPY go.* ./")
+	df.line("RUN go mod download")
+	df.line("COPY . .")
+	df.line(`RUN CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o /out/%s %s`, info.Name, info.Main)
+
+	df.finalStage(goRuntimeImage)
+	df.line("COPY --from=builder /out/%s /app/%s", info.Name, info.Name)
+}
+
+func generateNodeStages(df *dockerfileWriter, info *AppInfo) {
+	df.stage(nodeRuntimeImage, info.Version)
+	df.line("WORKDIR /app")
+	switch info.BuildTool {
+	case BuildToolYarn:
+		df.line("COPY package.json yarn.lock ./")
+		df.line("RUN yarn install --frozen-lockfile")
+	case BuildToolPNPM:
+		df.line("RUN corepack enable")
+		df.line("COPY package.json pnpm-lock.yaml ./")
+		df.line("RUN pnpm install --frozen-lockfile")
+	default:
+		df.line("COPY package*.json ./")
+		df.line("RUN if [ -f package-lock.json ]; then npm ci; else npm install; fi")
+	}
+
+	df.line("COPY . .")
+	if info.HasBuildScript {
+		df.line("RUN %s run build", info.BuildTool)
+	}
+
+	//the dev dependencies are not needed at runtime
+	switch info.BuildTool {
+	case BuildToolYarn:
+		df.line("RUN yarn install --production --frozen-lockfile --ignore-scripts --prefer-offline")
+	case BuildToolPNPM:
+		df.line("RUN pnpm prune --prod")
+	default:
+		df.line("RUN npm prune --production")
+	}
+
+	df.finalStage(fmt.Sprintf(nodeRuntimeImage, info.Version))
+	df.line("ENV NODE_ENV=production")
+	df.line("WORKDIR /app")
+	df.line("COPY --from=builder --chown=node:node /app /app")
+	df.line("USER node")
+}
+
+func generatePythonStages(df *dockerfileWriter, info *AppInfo) {
+	df.stage(pythonRuntimeImage, info.Version)
+	df.line("WORKDIR /app")
+	if info.BuildTool == BuildToolPipenv {
+		//pipenv is only used to export the locked dependencies (it's not installed in the app virtual env)
+		df.line("RUN pip install --no-cache-dir pipenv")
+	}
+
+	df.line("RUN python -m venv /opt/venv")
+	df.line(`ENV PATH="/opt/venv/bin:$PATH"`)
+	switch info.BuildTool {
+	case BuildToolPipenv:
+		df.line("COPY Pipfile Pipfile.lock* ./")
+		df.line("RUN pipenv requirements > /tmp/requirements.txt && pip install --no-cache-dir -r /tmp/requirements.txt")
+		df.line("COPY . .")
+	default:
+		if info.DependencyFile != "" {
+			df.line("COPY %s ./", info.DependencyFile)
+			df.line("RUN pip install --no-cache-dir -r %s", info.DependencyFile)
+			df.line("COPY . .")
+		} else {
+			df.line("COPY . .")
+			df.line("RUN pip install --no-cache-dir .")
+		}
+	}
+
+	df.finalStage(fmt.Sprintf(pythonRuntimeImage, info.Version))
+	df.line(`ENV PYTHONUNBUFFERED=1 PATH="/opt/venv/bin:$PATH"`)
+	df.line("WORKDIR /app")
+	df.line("COPY --from=builder /opt/venv /opt/venv")
+	df.line("COPY --from=builder /app /app")
+}
+
+func generateJavaStages(df *dockerfileWriter, info *AppInfo) {
+	var buildCmd, outputDir, excludes string
+	switch info.BuildTool {
+	case BuildToolGradle:
+		outputDir = "build/libs"
+		excludes = "! -name '*-plain.jar'"
+		if info.HasWrapper {
+			df.stage(jdkBuilderImage, info.Version)
+			buildCmd = "./gradlew --no-daemon build -x test"
+		} else {
+			df.stage(gradleBuilderImage, info.Version)
+			buildCmd = "gradle --no-daemon build -x test"
+		}
+	default:
+		outputDir = "target"
+		excludes = "! -name 'original-*.jar'"
+		if info.HasWrapper {
+			df.stage(jdkBuilderImage, info.Version)
+			buildCmd = "./mvnw -B -DskipTests package"
+		} else {
+			df.stage(mavenBuilderImage, info.Version)
+			buildCmd = "mvn -B -DskipTests package"
+		}
+	}
+
+	df.line("WORKDIR /src")
+	df.line("COPY . .")
+	df.line("RUN %s", buildCmd)
+	//the app jar is the only jar that's not a sources, javadoc or (shading/boot plugin) intermediate jar
+	df.line("RUN find %s -maxdepth 1 -name '*.jar' ! -name '*-sources.jar' ! -name '*-javadoc.jar' %s | head -n 1 | xargs -I{} cp {} /app.jar",
+		outputDir, excludes)
+
+	df.finalStage(fmt.Sprintf(javaRuntimeImage, info.Version))
+	df.line("WORKDIR /app")
+	df.line("COPY --from=builder /app.jar /app/app.jar")
+}
+
+// GenerateDockerignore generates the .dockerignore file content for the app
+// (the generated Dockerfile is ignored too)
+func GenerateDockerignore(info *AppInfo, dockerfileName string) string {
+	var lines []string
+	lines = append(lines, commonIgnores...)
+	lines = append(lines, dockerfileName)
+	lines = append(lines, info.Ignores...)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+type dockerfileWriter struct {
+	strings.Builder
+}
+
+func (w *dockerfileWriter) line(format string, args ...interface{}) {
+	w.WriteString(fmt.Sprintf(format, args...))
+	w.WriteString("\n")
+}
+
+// stage starts the builder stage (the image template has the runtime version placeholder)
+func (w *dockerfileWriter) stage(imageTemplate, version string) {
+	w.WriteString("\n")
+	w.line("FROM %s AS builder", fmt.Sprintf(imageTemplate, version))
+}
+
+func (w *dockerfileWriter) finalStage(image string) {
+	w.WriteString("\n")
+	w.line("FROM %s", image)
+}
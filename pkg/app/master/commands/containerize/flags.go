@@ -0,0 +1,87 @@
+package containerize
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Containerize command flag names
+const (
+	FlagAppType       = "app-type"
+	FlagTag           = "tag"
+	FlagDockerfile    = "dockerfile"
+	FlagPort          = "port"
+	FlagGenerateOnly  = "generate-only"
+	FlagShowBuildLogs = "show-blogs"
+	FlagSlim          = "slim"
+	FlagSlimArgs      = "slim-args"
+)
+
+// Containerize command flag usage info
+const (
+	FlagAppTypeUsage       = "Source app type (auto, go, node, python or java)"
+	FlagTagUsage           = "Tag for the built image (the source directory name is used by default)"
+	FlagDockerfileUsage    = "Name of the generated Dockerfile (saved in the source directory)"
+	FlagPortUsage          = "Port exposed by the app (the app type default port is used by default)"
+	FlagGenerateOnlyUsage  = "Only generate the Dockerfile (don't build the image)"
+	FlagShowBuildLogsUsage = "Show image build logs"
+	FlagSlimUsage          = "Slim the built image (runs the build command with the built image as the target)"
+	FlagSlimArgsUsage      = "Extra build command parameters used to slim the built image (e.g., '--http-probe-cmd /health')"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagAppType: &cli.StringFlag{
+		Name:    FlagAppType,
+		Value:   AppTypeAuto,
+		Usage:   FlagAppTypeUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_APP_TYPE"},
+	},
+	FlagTag: &cli.StringFlag{
+		Name:    FlagTag,
+		Value:   "",
+		Usage:   FlagTagUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_TAG"},
+	},
+	FlagDockerfile: &cli.StringFlag{
+		Name:    FlagDockerfile,
+		Value:   defaultDockerfileName,
+		Usage:   FlagDockerfileUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_DOCKERFILE"},
+	},
+	FlagPort: &cli.IntFlag{
+		Name:    FlagPort,
+		Value:   0,
+		Usage:   FlagPortUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_PORT"},
+	},
+	FlagGenerateOnly: &cli.BoolFlag{
+		Name:    FlagGenerateOnly,
+		Usage:   FlagGenerateOnlyUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_GENERATE_ONLY"},
+	},
+	FlagShowBuildLogs: &cli.BoolFlag{
+		Name:    FlagShowBuildLogs,
+		Usage:   FlagShowBuildLogsUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_SHOW_BLOGS"},
+	},
+	FlagSlim: &cli.BoolFlag{
+		Name:    FlagSlim,
+		Usage:   FlagSlimUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_SLIM"},
+	},
+	FlagSlimArgs: &cli.StringFlag{
+		Name:    FlagSlimArgs,
+		Value:   "",
+		Usage:   FlagSlimArgsUsage,
+		EnvVars: []string{"DSLIM_CONTAINERIZE_SLIM_ARGS"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -1,15 +1,23 @@
 package containerize
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/builder"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/build"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/report"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
@@ -20,11 +28,33 @@ const appName = commands.AppName
 
 type ovars = app.OutVars
 
+// Containerize command exit codes
+const (
+	eczOther = iota + 1
+	eczBadSourceDir
+	eczUnknownAppType
+	eczBadDockerfile
+	eczImageBuildError
+	eczSlimError
+)
+
+// exitCodes documents the containerize command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTContainerize | eczOther, Name: "containerize.other", Description: "other containerize command error"},
+	{Code: commands.ECTContainerize | eczBadSourceDir, Name: "containerize.bad.source.dir", Description: "source directory not found"},
+	{Code: commands.ECTContainerize | eczUnknownAppType, Name: "containerize.unknown.app.type", Description: "app type not detected or not supported"},
+	{Code: commands.ECTContainerize | eczBadDockerfile, Name: "containerize.bad.dockerfile", Description: "error saving the generated Dockerfile"},
+	{Code: commands.ECTContainerize | eczImageBuildError, Name: "containerize.image.build.error", Description: "error building the app image"},
+	{Code: commands.ECTContainerize | eczSlimError, Name: "containerize.slim.error", Description: "error slimming the app image (the build command failed)"},
+}
+
+var badTagChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
 // OnCommand implements the 'containerize' docker-slim command
 func OnCommand(
 	xc *app.ExecutionContext,
 	gparams *commands.GenericParams,
-	targetRef string) {
+	cparams *CommandParams) {
 	const cmdName = Name
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
 	prefix := fmt.Sprintf("cmd=%s", cmdName)
@@ -33,13 +63,113 @@ func OnCommand(
 
 	cmdReport := report.NewContainerizeCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
+	cmdReport.SourceDir = cparams.SourceDir
 
 	xc.Out.State("started")
 	xc.Out.Info("params",
 		ovars{
-			"target": targetRef,
+			"source.dir":    cparams.SourceDir,
+			"app.type":      cparams.AppType,
+			"tag":           cparams.Tag,
+			"dockerfile":    cparams.Dockerfile,
+			"generate.only": cparams.GenerateOnly,
+			"slim":          cparams.DoSlim,
 		})
 
+	exitWithError := func(exitCode int, status string) {
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = status
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	if info, err := os.Stat(cparams.SourceDir); err != nil || !info.IsDir() {
+		xc.Out.Info("param.error",
+			ovars{
+				"status": "source.dir.not.found",
+				"value":  cparams.SourceDir,
+			})
+
+		exitWithError(commands.ECTContainerize|eczBadSourceDir, "source.dir.not.found")
+	}
+
+	//the Dockerfile must be in the build context (the source directory)
+	if cparams.Dockerfile == "" || filepath.Base(cparams.Dockerfile) != cparams.Dockerfile {
+		xc.Out.Info("param.error",
+			ovars{
+				"status":  "bad.dockerfile.name",
+				"value":   cparams.Dockerfile,
+				"message": "the Dockerfile name must be a file name (the Dockerfile is saved in the source directory)",
+			})
+
+		exitWithError(commands.ECTContainerize|eczBadDockerfile, "bad.dockerfile.name")
+	}
+
+	appInfo, err := InspectApp(cparams.SourceDir, cparams.AppType, cparams.Port)
+	if err != nil {
+		logger.Debugf("InspectApp error - %v", err)
+		xc.Out.Error("app.type.error", err.Error())
+
+		if errors.Is(err, ErrUnknownAppType) || errors.Is(err, ErrBadAppType) {
+			exitWithError(commands.ECTContainerize|eczUnknownAppType, "unknown.app.type")
+		}
+
+		exitWithError(commands.ECTContainerize|eczOther, "app.inspect.error")
+	}
+
+	cmdReport.AppType = appInfo.Type
+	cmdReport.AppVersion = appInfo.Version
+	cmdReport.BuildTool = appInfo.BuildTool
+
+	xc.Out.Info("app.info",
+		ovars{
+			"type":           appInfo.Type,
+			"version":        appInfo.Version,
+			"version.source": appInfo.VersionSource,
+			"build.tool":     appInfo.BuildTool,
+			"port":           appInfo.Port,
+			"entrypoint":     strings.Join(appInfo.Entrypoint, " "),
+		})
+
+	dockerfileData, err := GenerateDockerfile(appInfo)
+	xc.FailOn(err)
+
+	dockerfilePath := filepath.Join(cparams.SourceDir, cparams.Dockerfile)
+	if err := ioutil.WriteFile(dockerfilePath, []byte(dockerfileData), 0644); err != nil {
+		xc.Out.Error("dockerfile.save.error", err.Error())
+		exitWithError(commands.ECTContainerize|eczBadDockerfile, "dockerfile.save.error")
+	}
+
+	cmdReport.Dockerfile = dockerfilePath
+
+	//the existing .dockerignore file is not replaced
+	dockerignorePath := filepath.Join(cparams.SourceDir, dockerignoreFileName)
+	dockerignoreStatus := "existing"
+	if !fsutil.Exists(dockerignorePath) {
+		dockerignoreData := GenerateDockerignore(appInfo, cparams.Dockerfile)
+		if err := ioutil.WriteFile(dockerignorePath, []byte(dockerignoreData), 0644); err != nil {
+			xc.Out.Error("dockerignore.save.error", err.Error())
+			exitWithError(commands.ECTContainerize|eczBadDockerfile, "dockerignore.save.error")
+		}
+
+		dockerignoreStatus = "generated"
+	}
+
+	xc.Out.Info("dockerfile",
+		ovars{
+			"file":         dockerfilePath,
+			"dockerignore": dockerignoreStatus,
+		})
+
+	if cparams.GenerateOnly {
+		finishCommand(xc, cmdReport, viChan)
+		return
+	}
+
 	client, err := dockerclient.New(gparams.ClientConfig)
 	if err == dockerclient.ErrNoDockerInfo {
 		exitMsg := "missing Docker connection info"
@@ -61,12 +191,119 @@ func OnCommand(
 			})
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
+	imageTag := cparams.Tag
+	if imageTag == "" {
+		imageTag = defaultImageTag(cparams.SourceDir)
+	}
+
+	cmdReport.ImageTag = imageTag
+
+	cbOpts := &config.ContainerBuildOptions{
+		Dockerfile: cparams.Dockerfile,
+		Tag:        imageTag,
+		Labels: map[string]string{
+			"dockerslim.containerize.app.type": appInfo.Type,
+		},
+	}
+
+	xc.Out.State("image.build.started",
+		ovars{
+			"tag": imageTag,
+		})
+
+	imageBuilder, err := builder.NewBasicImageBuilder(
+		client,
+		cbOpts,
+		cparams.SourceDir,
+		cparams.ShowBuildLogs)
+	xc.FailOn(err)
+
+	imageBuilder.BuildOptions.Context = xc.Context
+	err = imageBuilder.Build()
+
+	if cparams.ShowBuildLogs || err != nil {
+		xc.Out.LogDump("app.image.build", imageBuilder.BuildLog.String(),
+			ovars{
+				"tag": imageTag,
+			})
+	}
+
+	if err != nil {
+		cmdReport.Error = "image.build.error"
+		cmdReport.Save()
+
+		xc.Fail(&app.Error{
+			Category: app.ErrorCategoryImage,
+			Type:     "app.image.build.error",
+			Message:  err.Error(),
+			Hint:     fmt.Sprintf("check the generated Dockerfile (%s) and the build logs shown above", dockerfilePath),
+			ExitCode: commands.ECTContainerize | eczImageBuildError,
+			Err:      err,
+		})
+	}
+
+	xc.Out.State("image.build.completed",
+		ovars{
+			"tag": imageTag,
+		})
+
+	if cparams.DoSlim {
+		//the build command report is saved separately (it would replace the containerize command report otherwise)
+		slimReport := "off"
+		if gparams.ReportLocation != "" {
+			slimReport = strings.TrimSuffix(gparams.ReportLocation, filepath.Ext(gparams.ReportLocation)) + ".build.json"
+			cmdReport.SlimReport = slimReport
+		}
+
+		args := []string{
+			"--" + commands.FlagCommandReport, slimReport,
+			build.Name,
+			"--" + commands.FlagTarget, imageTag,
+		}
+		args = append(args, cparams.SlimArgs...)
+
+		xc.Out.State("slim.started",
+			ovars{
+				"target": imageTag,
+				"args":   strings.Join(cparams.SlimArgs, " "),
+			})
+
+		exitCode, err := commands.RunSelfCommand(xc, args)
+		if err != nil {
+			logger.Debugf("error running the build command - %v", err)
+			xc.Out.Error("slim.run", err.Error())
+			exitWithError(commands.ECTCommon|commands.ECCommandExec, "slim.run.error")
+		}
+
+		cmdReport.SlimExitCode = exitCode
+		if exitCode != 0 {
+			xc.Out.Info("slim.error",
+				ovars{
+					"build.exit.code": exitCode,
+				})
+
+			exitWithError(commands.ECTContainerize|eczSlimError, "slim.error")
+		}
+
+		xc.Out.State("slim.completed",
+			ovars{
+				"report": cmdReport.SlimReport,
+			})
+	}
+
+	finishCommand(xc, cmdReport, viChan)
+}
+
+func finishCommand(
+	xc *app.ExecutionContext,
+	cmdReport *report.ContainerizeCommand,
+	viChan <-chan *version.CheckVersionInfo) {
 	xc.Out.State("completed")
 	cmdReport.State = command.StateCompleted
 	xc.Out.State("done")
@@ -82,3 +319,13 @@ func OnCommand(
 			})
 	}
 }
+
+// defaultImageTag returns the image tag based on the source directory name
+func defaultImageTag(sourceDir string) string {
+	name := strings.Trim(badTagChars.ReplaceAllString(strings.ToLower(dirName(sourceDir)), "-"), "-._")
+	if name == "" {
+		name = "app"
+	}
+
+	return name + ":latest"
+}
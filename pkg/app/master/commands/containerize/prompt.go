@@ -1,6 +1,8 @@
 package containerize
 
 import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
 	"github.com/c-bata/go-prompt"
 )
 
@@ -8,3 +10,34 @@ var CommandSuggestion = prompt.Suggest{
 	Text:        Name,
 	Description: Usage,
 }
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(FlagAppType), Description: FlagAppTypeUsage},
+		{Text: commands.FullFlagName(FlagTag), Description: FlagTagUsage},
+		{Text: commands.FullFlagName(FlagDockerfile), Description: FlagDockerfileUsage},
+		{Text: commands.FullFlagName(FlagPort), Description: FlagPortUsage},
+		{Text: commands.FullFlagName(FlagGenerateOnly), Description: FlagGenerateOnlyUsage},
+		{Text: commands.FullFlagName(FlagShowBuildLogs), Description: FlagShowBuildLogsUsage},
+		{Text: commands.FullFlagName(FlagSlim), Description: FlagSlimUsage},
+		{Text: commands.FullFlagName(FlagSlimArgs), Description: FlagSlimArgsUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(FlagAppType):       completeAppType,
+		commands.FullFlagName(FlagGenerateOnly):  commands.CompleteBool,
+		commands.FullFlagName(FlagShowBuildLogs): commands.CompleteBool,
+		commands.FullFlagName(FlagSlim):          commands.CompleteBool,
+	},
+}
+
+var appTypeValues = []prompt.Suggest{
+	{Text: AppTypeAuto, Description: "Detect the app type"},
+	{Text: AppTypeGo, Description: "Go app (go.mod)"},
+	{Text: AppTypeNode, Description: "Node.js app (package.json)"},
+	{Text: AppTypePython, Description: "Python app (requirements.txt, pyproject.toml or Pipfile)"},
+	{Text: AppTypeJava, Description: "Java app (Maven or Gradle)"},
+}
+
+func completeAppType(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(appTypeValues, token, true)
+}
@@ -7,21 +7,56 @@ import (
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
 )
 
+//Image format conversion (the Docker image archives, the OCI image layouts and the Docker daemon images)
+
 const (
 	Name  = "convert"
-	Usage = "Convert container image"
+	Usage = "Convert container image between the docker-archive, OCI layout and Docker daemon formats"
 	Alias = "k"
 )
 
+type CommandParams struct {
+	Source      *imageformat.Reference
+	Destination *imageformat.Reference
+	MediaTypes  string
+}
+
 var CLI = &cli.Command{
-	Name:    Name,
-	Aliases: []string{Alias},
-	Usage:   Usage,
+	Name:      Name,
+	Aliases:   []string{Alias},
+	Usage:     Usage,
+	ArgsUsage: "<SOURCE> <DESTINATION> (docker-archive:<FILE>[:<IMAGE>], oci:<DIR>[:<REF_NAME>] or [docker-daemon:]<IMAGE>)",
+	Flags: []cli.Flag{
+		cflag(FlagMediaTypes),
+	},
 	Action: func(ctx *cli.Context) error {
-		if ctx.Args().Len() < 1 {
-			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
+		if ctx.Args().Len() < 2 {
+			fmt.Printf("docker-slim[%s]: missing source or destination image...\n\n", Name)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		cparams := &CommandParams{
+			MediaTypes: ctx.String(FlagMediaTypes),
+		}
+
+		var err error
+		cparams.Source, err = imageformat.ParseReference(ctx.Args().Get(0))
+		if err == nil {
+			cparams.Destination, err = imageformat.ParseReference(ctx.Args().Get(1))
+		}
+
+		if err != nil {
+			fmt.Printf("docker-slim[%s]: %v...\n\n", Name, err)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		if !imageformat.IsMediaTypes(cparams.MediaTypes) {
+			fmt.Printf("docker-slim[%s]: unknown manifest media types - '%s'...\n\n", Name, cparams.MediaTypes)
 			cli.ShowCommandHelp(ctx, Name)
 			return nil
 		}
@@ -31,14 +66,12 @@ var CLI = &cli.Command{
 			return err
 		}
 
-		targetRef := ctx.Args().First()
-
 		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
 
 		OnCommand(
 			xc,
 			gcvalues,
-			targetRef)
+			cparams)
 
 		return nil
 	},
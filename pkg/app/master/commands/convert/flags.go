@@ -0,0 +1,36 @@
+package convert
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
+)
+
+// Convert command flag names
+const (
+	FlagMediaTypes = "media-types"
+)
+
+// Convert command flag usage info
+const (
+	FlagMediaTypesUsage = "Manifest media types for the OCI layout destinations (oci or docker for the Docker v2 schema2 manifests)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagMediaTypes: &cli.StringFlag{
+		Name:    FlagMediaTypes,
+		Value:   imageformat.MediaTypesOCI,
+		Usage:   FlagMediaTypesUsage,
+		EnvVars: []string{"DSLIM_CONVERT_MEDIA_TYPES"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -2,74 +2,175 @@ package convert
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
 	"github.com/docker-slim/docker-slim/pkg/report"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
-
-	log "github.com/sirupsen/logrus"
 )
 
 const appName = commands.AppName
 
 type ovars = app.OutVars
 
+// Convert command exit codes
+const (
+	ecvOther = iota + 1
+	ecvImageNotFound
+	ecvConvertError
+)
+
+// exitCodes documents the convert command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTConvert | ecvOther, Name: "convert.other", Description: "other convert command error"},
+	{Code: commands.ECTConvert | ecvImageNotFound, Name: "convert.image.not.found", Description: "source image not found"},
+	{Code: commands.ECTConvert | ecvConvertError, Name: "convert.error", Description: "error converting the image"},
+}
+
 // OnCommand implements the 'convert' docker-slim command
 func OnCommand(
 	xc *app.ExecutionContext,
 	gparams *commands.GenericParams,
-	targetRef string) {
-	const cmdName = Name
-	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
-	prefix := fmt.Sprintf("cmd=%s", cmdName)
+	cparams *CommandParams) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+	prefix := fmt.Sprintf("cmd=%s", Name)
 
 	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
 
 	cmdReport := report.NewConvertCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
+	cmdReport.Source = cparams.Source.String()
+	cmdReport.Destination = cparams.Destination.String()
+	cmdReport.MediaTypes = cparams.MediaTypes
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
 
 	xc.Out.State("started")
 	xc.Out.Info("params",
 		ovars{
-			"target": targetRef,
+			"source":      cmdReport.Source,
+			"destination": cmdReport.Destination,
+			"media.types": cparams.MediaTypes,
 		})
 
-	client, err := dockerclient.New(gparams.ClientConfig)
-	if err == dockerclient.ErrNoDockerInfo {
-		exitMsg := "missing Docker connection info"
-		if gparams.InContainer && gparams.IsDSImage {
-			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+	//the Docker connection is needed only for the daemon images
+	var client *dockerapi.Client
+	if cparams.Source.Transport == imageformat.TransportDaemon ||
+		cparams.Destination.Transport == imageformat.TransportDaemon {
+		var err error
+		client, err = dockerclient.New(gparams.ClientConfig)
+		if err == dockerclient.ErrNoDockerInfo {
+			exitMsg := "missing Docker connection info"
+			if gparams.InContainer && gparams.IsDSImage {
+				exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+			}
+
+			xc.Out.Info("docker.connect.error",
+				ovars{
+					"message": exitMsg,
+				})
+
+			exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+					"version":   v.Current(),
+					"location":  fsutil.ExeDir(),
+				})
+			xc.Exit(exitCode)
 		}
+		xc.FailOn(err)
+
+		if gparams.Debug {
+			version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+		}
+	}
 
-		xc.Out.Info("docker.connect.error",
+	if sourceImageMissing(xc, client, cparams.Source) {
+		xc.Out.Info("source.image.error",
 			ovars{
-				"message": exitMsg,
+				"status":  "image.not.found",
+				"image":   cmdReport.Source,
+				"message": "make sure the source image exists",
 			})
 
-		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		exitCode := commands.ECTConvert | ecvImageNotFound
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "image.not.found"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	workDir, err := ioutil.TempDir("", "docker-slim-convert-")
+	xc.FailOn(err)
+	defer os.RemoveAll(workDir)
+
+	convertProgress := xc.Out.NewSpinner("image.convert")
+	info, err := imageformat.Convert(client, cparams.Source, cparams.Destination, cparams.MediaTypes, workDir)
+	convertProgress.Done()
+	if err != nil {
+		logger.Errorf("imageformat.Convert error - %v", err)
+		xc.Out.Error("convert.error", err.Error())
+
+		exitCode := commands.ECTConvert | ecvConvertError
 		xc.Out.State("exited",
 			ovars{
 				"exit.code": exitCode,
-				"version":   v.Current(),
-				"location":  fsutil.ExeDir(),
 			})
+
+		cmdReport.Error = "convert.error"
+		cmdReport.Save()
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
 
-	if gparams.Debug {
-		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	cmdReport.Convert = info
+
+	results := ovars{
+		"destination": info.Destination,
+		"image.id":    info.ImageID,
+		"layers":      len(info.Layers),
+	}
+
+	if info.ManifestDigest != "" {
+		results["manifest"] = info.ManifestDigest
+		results["manifest.media.type"] = info.ManifestMediaType
+	}
+
+	if len(info.Tags) > 0 {
+		results["tags"] = strings.Join(info.Tags, ",")
+	}
+
+	if len(info.Annotations) > 0 {
+		results["annotations"] = len(info.Annotations)
+	}
+
+	xc.Out.Info("convert.results", results)
+
+	if info.DroppedAnnotations {
+		xc.Out.Info("convert.annotations",
+			ovars{
+				"status":  "dropped",
+				"message": "the destination format doesn't support the image annotations",
+			})
 	}
 
 	xc.Out.State("completed")
 	cmdReport.State = command.StateCompleted
-	xc.Out.State("done")
 
 	vinfo := <-viChan
 	version.PrintCheckVersion(xc, "", vinfo)
@@ -81,4 +182,18 @@ func OnCommand(
 				"file": cmdReport.ReportLocation(),
 			})
 	}
+
+	xc.Out.State("done")
+}
+
+// sourceImageMissing checks if the source daemon image or the source image file/directory exist
+func sourceImageMissing(xc *app.ExecutionContext, client *dockerapi.Client, ref *imageformat.Reference) bool {
+	if ref.Transport != imageformat.TransportDaemon {
+		return !fsutil.Exists(ref.Path)
+	}
+
+	imageInspector, err := image.NewInspector(client, ref.Name)
+	xc.FailOn(err)
+
+	return imageInspector.NoImage()
 }
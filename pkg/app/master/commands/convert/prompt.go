@@ -1,6 +1,9 @@
 package convert
 
 import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
+
 	"github.com/c-bata/go-prompt"
 )
 
@@ -8,3 +11,21 @@ var CommandSuggestion = prompt.Suggest{
 	Text:        Name,
 	Description: Usage,
 }
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(FlagMediaTypes), Description: FlagMediaTypesUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(FlagMediaTypes): completeMediaTypes,
+	},
+}
+
+var mediaTypesValues = []prompt.Suggest{
+	{Text: imageformat.MediaTypesOCI, Description: "Use the OCI image manifest media types"},
+	{Text: imageformat.MediaTypesDocker, Description: "Use the Docker image manifest v2 schema2 media types"},
+}
+
+func completeMediaTypes(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(mediaTypesValues, token, true)
+}
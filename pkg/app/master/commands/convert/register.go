@@ -6,5 +6,7 @@ import (
 
 func RegisterCommand() {
 	commands.CLI = append(commands.CLI, CLI)
+	commands.RegisterExitCodes(Name, exitCodes...)
+	commands.CommandFlagSuggestions[Name] = CommandFlagSuggestions
 	commands.CommandSuggestions = append(commands.CommandSuggestions, CommandSuggestion)
 }
@@ -13,12 +13,11 @@ import (
 
 const (
 	Name  = "debug"
-	Usage = "Debug the target container image from a debug container"
+	Usage = "Debug the running target container from a debug (tools) container sharing its namespaces"
 	Alias = "dbg"
 
-	FlagDebugImage = "debug-image"
-
 	DefaultDebugImage = "nicolaka/netshoot"
+	DefaultCapability = "SYS_PTRACE"
 )
 
 type CommandParams struct {
@@ -30,57 +29,66 @@ type CommandParams struct {
 	DebugContainerImageCmd []string
 	/// launch the debug container with --it
 	AttachTty bool
+	/// mount the target container volumes (--volumes-from)
+	ShareVolumes bool
+	/// extra Linux capabilities for the debug container (--cap-add)
+	CapAdd []string
+}
+
+func CommandFlagValues(ctx *cli.Context) *CommandParams {
+	values := &CommandParams{
+		TargetRef:              ctx.String(commands.FlagTarget),
+		DebugContainerImage:    ctx.String(FlagDebugImage),
+		DebugContainerImageCmd: []string{},
+		AttachTty:              true,
+		ShareVolumes:           ctx.Bool(FlagShareVolumes),
+		CapAdd:                 ctx.StringSlice(FlagCapAdd),
+	}
+
+	args := ctx.Args().Slice()
+	if values.TargetRef == "" && len(args) > 0 && args[0] != "--" {
+		values.TargetRef = args[0]
+		args = args[1:]
+	}
+
+	//the debug container command is passed after '--' (the container is not interactive then)
+	if len(args) > 0 && args[0] == "--" {
+		values.AttachTty = false
+		values.DebugContainerImageCmd = args[1:]
+	}
+
+	if values.DebugContainerImage == "" {
+		values.DebugContainerImage = DefaultDebugImage
+	}
+
+	return values
 }
 
 var CLI = &cli.Command{
-	Name:    Name,
-	Aliases: []string{Alias},
-	Usage:   Usage,
+	Name:      Name,
+	Aliases:   []string{Alias},
+	Usage:     Usage,
+	ArgsUsage: "<RUNNING_CONTAINER_NAME_OR_ID> [-- <DEBUG_CONTAINER_CMD>]",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:        FlagDebugImage,
-			DefaultText: DefaultDebugImage,
-			Required:    false,
-		},
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagDebugImage),
+		cflag(FlagShareVolumes),
+		cflag(FlagCapAdd),
 	},
 	Action: func(ctx *cli.Context) error {
-		if ctx.Args().Len() < 1 {
-			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
-			cli.ShowCommandHelp(ctx, Name)
-			return nil
-		}
-
 		gcvalues, err := commands.GlobalFlagValues(ctx)
 		if err != nil {
 			return err
 		}
 
-		commandParams := &CommandParams{
-			TargetRef:              ctx.String(commands.FlagTarget),
-			DebugContainerImage:    ctx.String(FlagDebugImage),
-			DebugContainerImageCmd: []string{},
-			AttachTty:              true,
-		}
-
-		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
-
+		commandParams := CommandFlagValues(ctx)
 		if commandParams.TargetRef == "" {
-			if ctx.Args().Len() < 1 {
-				xc.Out.Error("param.target", "missing target")
-				cli.ShowCommandHelp(ctx, Name)
-				return nil
-			} else {
-				commandParams.TargetRef = ctx.Args().First()
-				if ctx.Args().Len() > 1 && ctx.Args().Slice()[1] == "--" {
-					commandParams.AttachTty = false
-					commandParams.DebugContainerImageCmd = ctx.Args().Slice()[2:]
-				}
-			}
+			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
 		}
 
-		if commandParams.DebugContainerImage == "" {
-			commandParams.DebugContainerImage = DefaultDebugImage
-		}
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
 
 		OnCommand(
 			xc,
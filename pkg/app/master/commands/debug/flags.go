@@ -0,0 +1,50 @@
+package debug
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Debug command flag names
+const (
+	FlagDebugImage   = "debug-image"
+	FlagShareVolumes = "share-volumes"
+	FlagCapAdd       = "cap-add"
+)
+
+// Debug command flag usage info
+const (
+	FlagDebugImageUsage   = "Debug (tools) container image with the shell and the debugging tools (e.g., busybox, strace, curl)"
+	FlagShareVolumesUsage = "Mount the target container volumes in the debug container"
+	FlagCapAddUsage       = "Add the Linux capability to the debug container (SYS_PTRACE is added by default, so you can use strace)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagDebugImage: &cli.StringFlag{
+		Name:    FlagDebugImage,
+		Value:   DefaultDebugImage,
+		Usage:   FlagDebugImageUsage,
+		EnvVars: []string{"DSLIM_DEBUG_IMAGE"},
+	},
+	FlagShareVolumes: &cli.BoolFlag{
+		Name:    FlagShareVolumes,
+		Value:   true,
+		Usage:   FlagShareVolumesUsage,
+		EnvVars: []string{"DSLIM_DEBUG_SHARE_VOLUMES"},
+	},
+	FlagCapAdd: &cli.StringSliceFlag{
+		Name:    FlagCapAdd,
+		Value:   cli.NewStringSlice(DefaultCapability),
+		Usage:   FlagCapAddUsage,
+		EnvVars: []string{"DSLIM_DEBUG_CAP_ADD"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
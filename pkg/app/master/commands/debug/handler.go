@@ -1,6 +1,7 @@
 package debug
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
@@ -15,11 +16,16 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
+	dockerapi "github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
 )
 
 const appName = commands.AppName
 
+// targetRootPath is the target container filesystem in the debug container
+// (the target container main process is PID 1 in the shared PID namespace)
+const targetRootPath = "/proc/1/root"
+
 type ovars = app.OutVars
 
 // OnCommand implements the 'debug' docker-slim command
@@ -42,6 +48,8 @@ func OnCommand(
 			"debug-image":     commandParams.DebugContainerImage,
 			"debug-image-cmd": commandParams.DebugContainerImageCmd,
 			"attach-tty":      commandParams.AttachTty,
+			"share-volumes":   commandParams.ShareVolumes,
+			"cap-add":         commandParams.CapAdd,
 		})
 
 	client, err := dockerclient.New(gparams.ClientConfig)
@@ -65,20 +73,36 @@ func OnCommand(
 			})
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
+	targetInfo := inspectTarget(xc, client, commandParams.TargetRef)
+	xc.Out.Info("target.container",
+		ovars{
+			"name":  targetInfo.Name,
+			"id":    targetInfo.ID,
+			"image": targetInfo.Config.Image,
+			"pid":   targetInfo.State.Pid,
+		})
+
 	imageInspector, err := image.NewInspector(client, commandParams.DebugContainerImage)
+	xc.FailOn(err)
+
+	if imageInspector.NoImage() {
+		err := imageInspector.Pull(xc.Context, true, "", "", "")
+		xc.FailOn(commands.ImagePullError(err))
+	}
+
 	options := container.ExecutionOptions{
 		Cmd:      commandParams.DebugContainerImageCmd,
 		Terminal: commandParams.AttachTty,
-	}
-	if imageInspector.NoImage() {
-		err := imageInspector.Pull(true, "", "", "")
-		errutil.FailOn(err)
+		EnvVars: []string{
+			fmt.Sprintf("TARGET_ROOT=%s", targetRootPath),
+			fmt.Sprintf("TARGET_CONTAINER=%s", targetInfo.ID),
+		},
 	}
 
 	exe, err := container.NewExecution(
@@ -90,20 +114,39 @@ func OnCommand(
 		nil,
 		true,
 		true)
+	xc.FailOn(err)
 
 	// attach network, IPC & PIDs, essentially this is run --network container:golang_service --pid container:golang_service --ipc container:golang_service
-	mode := fmt.Sprintf("container:%s", commandParams.TargetRef)
+	// (Docker can't share the mount namespace, so the target filesystem is available through /proc/1/root
+	// and the target volumes are mounted with --volumes-from)
+	mode := fmt.Sprintf("container:%s", targetInfo.ID)
 	exe.IpcMode = mode
 	exe.NetworkMode = mode
 	exe.PidMode = mode
+	exe.CapAdd = commandParams.CapAdd
+	if commandParams.ShareVolumes && len(targetInfo.Mounts) > 0 {
+		exe.VolumesFrom = []string{targetInfo.ID}
+	}
 
-	errutil.FailOn(err)
+	xc.Out.Info("debug.container",
+		ovars{
+			"target.fs": targetRootPath,
+			"volumes":   len(exe.VolumesFrom) > 0,
+			"message":   "the target container filesystem is available at $TARGET_ROOT (the target processes are visible with ps)",
+		})
+
+	//remove the debug container if the command is interrupted
+	xc.AddCleanupHandler(func() {
+		if xc.Context.Err() != nil {
+			_ = exe.Cleanup()
+		}
+	})
 
 	err = exe.Start()
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	_, err = exe.Wait()
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	defer func() {
 		err = exe.Cleanup()
@@ -129,3 +172,25 @@ func OnCommand(
 			})
 	}
 }
+
+// inspectTarget returns the running target container info
+func inspectTarget(xc *app.ExecutionContext, client *dockerapi.Client, targetRef string) *dockerapi.Container {
+	info, err := client.InspectContainerWithOptions(dockerapi.InspectContainerOptions{ID: targetRef})
+	var noContainerErr *dockerapi.NoSuchContainer
+	if errors.As(err, &noContainerErr) {
+		xc.Fail(app.WrapError(err,
+			app.ErrorCategoryContainer,
+			"debug.target.not.found",
+			"use the name or the ID of a running container (see 'docker ps')"))
+	}
+	xc.FailOn(err)
+
+	if !info.State.Running {
+		xc.Fail(app.NewError(app.ErrorCategoryContainer,
+			"debug.target.not.running",
+			fmt.Sprintf("target container is not running (status=%s)", info.State.Status),
+			"start the target container before attaching the debug container"))
+	}
+
+	return info
+}
@@ -1,6 +1,8 @@
 package debug
 
 import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
 	"github.com/c-bata/go-prompt"
 )
 
@@ -8,3 +10,15 @@ var CommandSuggestion = prompt.Suggest{
 	Text:        Name,
 	Description: Usage,
 }
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: "Running target container name or ID"},
+		{Text: commands.FullFlagName(FlagDebugImage), Description: FlagDebugImageUsage},
+		{Text: commands.FullFlagName(FlagShareVolumes), Description: FlagShareVolumesUsage},
+		{Text: commands.FullFlagName(FlagCapAdd), Description: FlagCapAddUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(FlagShareVolumes): commands.CompleteTBool,
+	},
+}
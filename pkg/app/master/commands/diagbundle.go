@@ -0,0 +1,354 @@
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/system"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// Diagnostics bundle constants
+const (
+	DiagBundleDefaultName = "slim.diag.tar.gz"
+
+	diagMaxLogLines     = 10000
+	diagMaxArtifactSize = 10 * 1024 * 1024
+	diagDockerTimeout   = 10 * time.Second
+	redactedValue       = "[REDACTED]"
+)
+
+// the artifact files included in the diagnostics bundle
+var diagArtifactPatterns = []string{
+	report.DefaultContainerReportFileName,
+	"Dockerfile.fat",
+	"*.log",
+}
+
+var (
+	secretNamePat  = `[\w.-]*(?:password|passwd|secret|token|api[_.-]?key|access[_.-]?key|auth|credential)[\w.-]*`
+	secretNameRE   = regexp.MustCompile(`(?i)^` + secretNamePat + `$`)
+	secretJSONRE   = regexp.MustCompile(`(?i)("` + secretNamePat + `"\s*:\s*")(?:[^"\\]|\\.)*(")`)
+	secretKVRE     = regexp.MustCompile(`(?i)(\b` + secretNamePat + `\s*[=:]\s*)("?)[^\s"',]+`)
+	secretBearerRE = regexp.MustCompile(`(?i)(\b(?:bearer|basic)\s+)[\w.~+/=-]+`)
+	secretURLRE    = regexp.MustCompile(`(://)[^/\s:@]+:[^/\s@]+@`)
+)
+
+// diagBundle collects the diagnostics data for the failed commands
+type diagBundle struct {
+	location string
+	gparams  *GenericParams
+	start    time.Time
+	logs     *diagLogHook
+}
+
+// EnableDiagBundle enables the diagnostics bundle for the failed commands.
+// The bundle (a tar.gz file) includes the logs, the Docker info, the partial command reports,
+// the temporary container logs and the environment (the secrets are redacted).
+func EnableDiagBundle(location string, gparams *GenericParams) {
+	if location == "" {
+		return
+	}
+
+	if info, err := os.Stat(location); err == nil && info.IsDir() {
+		location = filepath.Join(location, DiagBundleDefaultName)
+	}
+
+	bundle := &diagBundle{
+		location: location,
+		gparams:  gparams,
+		start:    time.Now(),
+		logs:     &diagLogHook{formatter: &log.TextFormatter{DisableColors: true}},
+	}
+
+	log.AddHook(bundle.logs)
+	app.SetDiagnosticsHandler(bundle.collect)
+}
+
+func (ref *diagBundle) collect(xc *app.ExecutionContext, exitCode int) {
+	if err := ref.save(xc, exitCode); err != nil {
+		log.Errorf("diagBundle.collect: error saving diagnostics bundle (%s) - %v", ref.location, err)
+		xc.Out.Info("diag.bundle",
+			ovars{
+				"file":  ref.location,
+				"error": err.Error(),
+			})
+		return
+	}
+
+	xc.Out.Info("diag.bundle",
+		ovars{
+			"file": ref.location,
+		})
+}
+
+func (ref *diagBundle) save(xc *app.ExecutionContext, exitCode int) error {
+	if dir := filepath.Dir(ref.location); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	bundleFile, err := os.Create(ref.location)
+	if err != nil {
+		return err
+	}
+	defer bundleFile.Close()
+
+	gzw := gzip.NewWriter(bundleFile)
+	tw := tar.NewWriter(gzw)
+
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		_, err := tw.Write(data)
+		return err
+	}
+
+	addJSON := func(name string, data interface{}) error {
+		raw, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		return add(name, []byte(RedactSecrets(string(raw))))
+	}
+
+	info := map[string]interface{}{
+		"version":   v.Current(),
+		"command":   xc.Out.CmdName,
+		"args":      RedactArgs(os.Args),
+		"exit_code": exitCode,
+		"os":        runtime.GOOS,
+		"arch":      runtime.GOARCH,
+		"start":     ref.start.UTC().Format(time.RFC3339),
+		"end":       time.Now().UTC().Format(time.RFC3339),
+		"system":    system.GetSystemInfo(),
+	}
+
+	if xerr := xc.Err(); xerr != nil {
+		info["error"] = xerr.ReportInfo()
+	}
+
+	if err := addJSON("info.json", info); err != nil {
+		return err
+	}
+
+	if err := add("env.txt", []byte(RedactEnv(os.Environ()))); err != nil {
+		return err
+	}
+
+	if err := add("logs.txt", []byte(RedactSecrets(ref.logs.String()))); err != nil {
+		return err
+	}
+
+	for name, data := range ref.dockerInfo() {
+		if err := addJSON(name, data); err != nil {
+			return err
+		}
+	}
+
+	for name, fpath := range ref.reportFiles() {
+		raw, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			log.Debugf("diagBundle.save: error reading %s - %v", fpath, err)
+			continue
+		}
+
+		if err := add(name, []byte(RedactSecrets(string(raw)))); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gzw.Close()
+}
+
+// dockerInfo returns the Docker info and version data (or the Docker client errors)
+func (ref *diagBundle) dockerInfo() map[string]interface{} {
+	data := map[string]interface{}{}
+
+	client, err := dockerclient.New(ref.gparams.ClientConfig)
+	if err != nil {
+		data["docker/error.json"] = map[string]string{"error": err.Error()}
+		return data
+	}
+
+	client.SetTimeout(diagDockerTimeout)
+
+	if info, err := client.Info(); err != nil {
+		data["docker/info.json"] = map[string]string{"error": err.Error()}
+	} else {
+		data["docker/info.json"] = info
+	}
+
+	if version, err := client.Version(); err != nil {
+		data["docker/version.json"] = map[string]string{"error": err.Error()}
+	} else {
+		data["docker/version.json"] = version.Map()
+	}
+
+	return data
+}
+
+// reportFiles returns the command report and the artifact files
+// (only the files updated by the failed command are included)
+func (ref *diagBundle) reportFiles() map[string]string {
+	files := map[string]string{}
+	if ref.gparams.ReportLocation == "" || !ref.isRecent(ref.gparams.ReportLocation) {
+		return files
+	}
+
+	files["reports/"+filepath.Base(ref.gparams.ReportLocation)] = ref.gparams.ReportLocation
+
+	raw, err := ioutil.ReadFile(ref.gparams.ReportLocation)
+	if err != nil {
+		return files
+	}
+
+	var cmdReport struct {
+		ArtifactLocation string `json:"artifact_location"`
+	}
+
+	if err := json.Unmarshal(raw, &cmdReport); err != nil || cmdReport.ArtifactLocation == "" {
+		return files
+	}
+
+	for _, pattern := range diagArtifactPatterns {
+		matches, _ := filepath.Glob(filepath.Join(cmdReport.ArtifactLocation, pattern))
+		for _, fpath := range matches {
+			if ref.isRecent(fpath) {
+				files["artifacts/"+filepath.Base(fpath)] = fpath
+			}
+		}
+	}
+
+	return files
+}
+
+func (ref *diagBundle) isRecent(fpath string) bool {
+	info, err := os.Stat(fpath)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	return info.Size() <= diagMaxArtifactSize && !info.ModTime().Before(ref.start)
+}
+
+// diagLogHook keeps the recent log records for the diagnostics bundle
+type diagLogHook struct {
+	mu        sync.Mutex
+	formatter log.Formatter
+	lines     [][]byte
+}
+
+func (h *diagLogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *diagLogHook) Fire(entry *log.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.lines) >= diagMaxLogLines {
+		h.lines = h.lines[1:]
+	}
+
+	h.lines = append(h.lines, line)
+	return nil
+}
+
+func (h *diagLogHook) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return string(bytes.Join(h.lines, nil))
+}
+
+// RedactSecrets replaces the secret values (passwords, tokens, keys and URL credentials) in the text
+func RedactSecrets(text string) string {
+	text = secretJSONRE.ReplaceAllString(text, "${1}"+redactedValue+"${2}")
+	text = secretKVRE.ReplaceAllString(text, "${1}${2}"+redactedValue)
+	text = secretBearerRE.ReplaceAllString(text, "${1}"+redactedValue)
+	return secretURLRE.ReplaceAllString(text, "${1}"+redactedValue+"@")
+}
+
+// RedactArgs returns the command line args with the secret flag values redacted
+func RedactArgs(args []string) []string {
+	var redacted []string
+	redactNext := false
+	for _, arg := range args {
+		switch {
+		case redactNext:
+			arg = redactedValue
+			redactNext = false
+		case strings.HasPrefix(arg, "-"):
+			name := strings.TrimLeft(arg, "-")
+			if idx := strings.Index(name, "="); idx >= 0 {
+				if isSecretName(name[:idx]) {
+					arg = arg[:len(arg)-len(name)+idx+1] + redactedValue
+				}
+			} else if isSecretName(name) {
+				redactNext = true
+			}
+		}
+
+		redacted = append(redacted, RedactSecrets(arg))
+	}
+
+	return redacted
+}
+
+// RedactEnv returns the sorted environment variables with the secret values redacted
+func RedactEnv(env []string) string {
+	var lines []string
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 && isSecretName(parts[0]) {
+			kv = fmt.Sprintf("%s=%s", parts[0], redactedValue)
+		}
+
+		lines = append(lines, RedactSecrets(kv))
+	}
+
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func isSecretName(name string) bool {
+	//the OTLP headers usually include the collector credentials
+	return secretNameRE.MatchString(name) || strings.Contains(strings.ToLower(name), "headers")
+}
@@ -14,6 +14,27 @@ const (
 	Usage = "Plugin metadata for the docker cli"
 )
 
+const (
+	//the Docker CLI plugin name (from the 'docker-slim' binary name)
+	PluginName        = "slim"
+	PluginCommandName = "docker slim"
+)
+
+// IsPluginInvocation returns true if docker-slim is executed by the Docker CLI as its plugin
+// (the Docker CLI passes the plugin name as the first arg: 'docker-slim slim build ...')
+func IsPluginInvocation(args []string) bool {
+	return len(args) > 1 && args[1] == PluginName
+}
+
+// PluginArgs returns the command args without the plugin name passed by the Docker CLI
+func PluginArgs(args []string) []string {
+	if !IsPluginInvocation(args) {
+		return args
+	}
+
+	return append([]string{args[0]}, args[2:]...)
+}
+
 type pluginMetadata struct {
 	SchemaVersion    string
 	Vendor           string
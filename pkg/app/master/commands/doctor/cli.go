@@ -0,0 +1,124 @@
+package doctor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Broken optimized image diagnostics (original vs optimized image file lookups)
+
+const (
+	Name  = "doctor"
+	Usage = "Diagnose a broken optimized image by tracing the app in the original and optimized images"
+	Alias = "doc"
+)
+
+type CommandParams struct {
+	TargetRef           string
+	SlimImage           string
+	RunTimeout          int
+	DoShowContainerLogs bool
+}
+
+var CLI = &cli.Command{
+	Name:      Name,
+	Aliases:   []string{Alias},
+	Usage:     Usage,
+	ArgsUsage: "<ORIGINAL_IMAGE> [<OPTIMIZED_IMAGE>]",
+	Flags: append([]cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagSlimImage),
+		cflag(FlagRunTimeout),
+		commands.Cflag(commands.FlagEntrypoint),
+		commands.Cflag(commands.FlagCmd),
+		commands.Cflag(commands.FlagEnv),
+		commands.Cflag(commands.FlagExpose),
+		commands.Cflag(commands.FlagUser),
+		commands.Cflag(commands.FlagWorkdir),
+		commands.Cflag(commands.FlagNetwork),
+		commands.Cflag(commands.FlagShowContainerLogs),
+	}, commands.HTTPProbeFlags()...),
+	Action: func(ctx *cli.Context) error {
+		cparams := &CommandParams{
+			TargetRef:           ctx.String(commands.FlagTarget),
+			SlimImage:           ctx.String(FlagSlimImage),
+			RunTimeout:          ctx.Int(FlagRunTimeout),
+			DoShowContainerLogs: ctx.Bool(commands.FlagShowContainerLogs),
+		}
+
+		args := ctx.Args().Slice()
+		if cparams.TargetRef == "" {
+			if len(args) < 1 {
+				fmt.Printf("docker-slim[%s]: missing target image...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			}
+
+			cparams.TargetRef = args[0]
+			args = args[1:]
+		}
+
+		if cparams.SlimImage == "" {
+			if len(args) > 0 {
+				cparams.SlimImage = args[0]
+			} else {
+				cparams.SlimImage = defaultSlimImage(cparams.TargetRef)
+			}
+		}
+
+		if cparams.RunTimeout <= 0 {
+			cparams.RunTimeout = defaultRunTimeout
+		}
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		overrides, err := commands.GetContainerOverrides(ctx)
+		if err != nil {
+			xc.Out.Error("param.error.container.overrides", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		httpProbeOpts := commands.GetHTTPProbeOptions(xc, ctx)
+		if httpProbeOpts.Do && len(httpProbeOpts.Cmds) == 0 {
+			httpProbeOpts.Cmds = append(httpProbeOpts.Cmds, commands.GetDefaultHTTPProbe())
+		}
+
+		OnCommand(
+			xc,
+			gcvalues,
+			cparams,
+			overrides,
+			httpProbeOpts)
+
+		return nil
+	},
+}
+
+// defaultSlimImage returns the default optimized image name
+// created by the build command for the target image
+func defaultSlimImage(targetRef string) string {
+	name := targetRef
+	if idx := strings.Index(name, "@"); idx > 0 {
+		name = name[:idx]
+	}
+
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		name = name[:idx]
+	}
+
+	return fmt.Sprintf("%s.slim", name)
+}
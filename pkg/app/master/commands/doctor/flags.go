@@ -0,0 +1,44 @@
+package doctor
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Doctor command flag names
+const (
+	FlagSlimImage  = "slim-image"
+	FlagRunTimeout = "run-timeout"
+)
+
+// Doctor command flag usage info
+const (
+	FlagSlimImageUsage  = "Optimized image to diagnose (default: '<target_image_name>.slim')"
+	FlagRunTimeoutUsage = "How long to run each image (in seconds) when the HTTP probe is not used"
+)
+
+const defaultRunTimeout = 10
+
+var Flags = map[string]cli.Flag{
+	FlagSlimImage: &cli.StringFlag{
+		Name:    FlagSlimImage,
+		Value:   "",
+		Usage:   FlagSlimImageUsage,
+		EnvVars: []string{"DSLIM_DOCTOR_SLIM_IMAGE"},
+	},
+	FlagRunTimeout: &cli.IntFlag{
+		Name:    FlagRunTimeout,
+		Value:   defaultRunTimeout,
+		Usage:   FlagRunTimeoutUsage,
+		EnvVars: []string{"DSLIM_DOCTOR_RUN_TIMEOUT"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
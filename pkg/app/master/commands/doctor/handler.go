@@ -0,0 +1,432 @@
+package doctor
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/build"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/container"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/http"
+	"github.com/docker-slim/docker-slim/pkg/app/master/version"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/system"
+	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// Doctor command exit codes
+const (
+	ecdOther = iota + 1
+	ecdImageNotFound
+	ecdNoEntrypoint
+	ecdNoTraceData
+	ecdMissingPaths
+)
+
+// exitCodes documents the doctor command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTDoctor | ecdOther, Name: "doctor.other", Description: "other doctor command error"},
+	{Code: commands.ECTDoctor | ecdImageNotFound, Name: "doctor.image.not.found", Description: "original or optimized image not found"},
+	{Code: commands.ECTDoctor | ecdNoEntrypoint, Name: "doctor.no.entrypoint", Description: "target image has no entrypoint or cmd (use --entrypoint or --cmd)"},
+	{Code: commands.ECTDoctor | ecdNoTraceData, Name: "doctor.no.trace.data", Description: "no trace data collected from the temporary container"},
+	{Code: commands.ECTDoctor | ecdMissingPaths, Name: "doctor.missing.paths", Description: "the optimized image is missing the files used by the app"},
+}
+
+// traceResult is the file lookup data collected running one of the images
+type traceResult struct {
+	archName string
+	activity map[string]*report.FSActivityInfo
+	missing  map[string]*report.FSMissingInfo
+	probe    *report.HTTPProbeReport
+}
+
+// OnCommand implements the 'doctor' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams,
+	overrides *config.ContainerOverrides,
+	httpProbeOpts config.HTTPProbeOptions) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+	prefix := fmt.Sprintf("cmd=%s", Name)
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewDoctorCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.TargetRef
+	cmdReport.SlimImage = cparams.SlimImage
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"target":     cparams.TargetRef,
+			"slim.image": cparams.SlimImage,
+		})
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err == dockerclient.ErrNoDockerInfo {
+		exitMsg := "missing Docker connection info"
+		if gparams.InContainer && gparams.IsDSImage {
+			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+		}
+
+		xc.Out.Info("docker.connect.error",
+			ovars{
+				"message": exitMsg,
+			})
+
+		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+		xc.Exit(exitCode)
+	}
+	xc.FailOn(err)
+
+	if gparams.Debug {
+		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	}
+
+	images := []string{cparams.TargetRef, cparams.SlimImage}
+	inspectors := make([]*image.Inspector, len(images))
+	for idx, imageRef := range images {
+		imageInspector, err := image.NewInspector(client, imageRef)
+		xc.FailOn(err)
+
+		if imageInspector.NoImage() {
+			xc.Out.Info("target.image.error",
+				ovars{
+					"status": "image.not.found",
+					"image":  imageRef,
+				})
+
+			exitCode := commands.ECTDoctor | ecdImageNotFound
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+
+			cmdReport.Error = "image.not.found"
+			cmdReport.Save()
+			xc.Exit(exitCode)
+		}
+
+		inspectors[idx] = imageInspector
+	}
+
+	//tracing the images one by one (the optimized image uses the same ports)
+	results := make([]*traceResult, len(images))
+	for idx, imageInspector := range inspectors {
+		xc.Out.State("doctor.tracing",
+			ovars{
+				"image": images[idx],
+			})
+
+		results[idx] = traceImage(
+			xc,
+			gparams,
+			cparams,
+			client,
+			imageInspector,
+			overrides,
+			httpProbeOpts,
+			logger)
+	}
+
+	cmdReport.OriginalProbe = results[0].probe
+	cmdReport.SlimProbe = results[1].probe
+
+	cmdReport.MissingPaths = missingPaths(results[0], results[1])
+	for _, info := range cmdReport.MissingPaths {
+		cmdReport.IncludeFlags = append(cmdReport.IncludeFlags, info.IncludeFlag)
+
+		xc.Out.Info("doctor.missing.path",
+			ovars{
+				"path":      info.Path,
+				"exec":      info.Exec,
+				"confirmed": info.Confirmed,
+				"syscalls":  strings.Join(info.Syscalls, ","),
+				"include":   info.IncludeFlag,
+			})
+	}
+
+	cmdReport.Healthy = len(cmdReport.MissingPaths) == 0
+	summary := ovars{
+		"healthy":       cmdReport.Healthy,
+		"missing.paths": len(cmdReport.MissingPaths),
+	}
+
+	if !cmdReport.Healthy {
+		summary["build.flags"] = strings.Join(cmdReport.IncludeFlags, " ")
+	}
+
+	xc.Out.Info("doctor.summary", summary)
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	if !cmdReport.Healthy {
+		exitCode := commands.ECTDoctor | ecdMissingPaths
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+		xc.Exit(exitCode)
+	}
+
+	xc.Out.State("done")
+}
+
+// traceImage runs the image in an instrumented container
+// and returns the file lookups collected by the sensor
+func traceImage(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams,
+	client *dockerapi.Client,
+	imageInspector *image.Inspector,
+	overrides *config.ContainerOverrides,
+	httpProbeOpts config.HTTPProbeOptions,
+	logger *log.Entry) *traceResult {
+	printState := true
+	imageRef := imageInspector.ImageRef
+
+	err := imageInspector.Inspect()
+	xc.FailOn(err)
+
+	localVolumePath, artifactLocation, statePath, _, err := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+	xc.FailOn(commands.StateDirError(err))
+	imageInspector.ArtifactLocation = artifactLocation
+
+	err = imageInspector.ProcessCollectedData()
+	xc.FailOn(err)
+
+	//each container inspector updates its overrides
+	var imageOverrides *config.ContainerOverrides
+	if overrides != nil {
+		ocopy := *overrides
+		imageOverrides = &ocopy
+	}
+
+	containerInspector, err := container.NewInspector(
+		xc,
+		nil, //crOpts
+		logger,
+		client,
+		statePath,
+		imageInspector,
+		localVolumePath,
+		false, //doUseLocalMounts
+		"",    //doUseSensorVolume
+		false, //doKeepTmpArtifacts,
+		imageOverrides,
+		nil,   //explicitVolumeMounts
+		nil,   //baseMounts,
+		nil,   //baseVolumesFrom,
+		nil,   //portBindings
+		false, //doPublishExposedPorts
+		true,  //hasClassicLinks
+		nil,   //links
+		nil,   //etcHostsMaps
+		nil,   //dnsServers
+		nil,   //dnsSearchDomains
+		cparams.DoShowContainerLogs,
+		false, //doKeepPerms,
+		nil,   //pathPerms,
+		nil,   //excludePatterns
+		nil,   //preservePaths,
+		nil,   //includePaths,
+		nil,   //includeBins,
+		nil,   //includeExes,
+		nil,   //includePkgs,
+		false, //doIncludePkgDeps,
+		false, //doIncludeShell,
+		false, //doIncludeCertAll
+		false, //doIncludeCertBundles
+		false, //doIncludeCertDirs
+		false, //doIncludeCertPKAll
+		false, //doIncludeCertPKDirs
+		false, //doIncludeNew
+		false, //doIncludeEssentials
+		nil,   //runAsUser
+		nil,   //selectedNetNames
+		gparams.Debug,
+		gparams.LogLevel,
+		gparams.LogFormat,
+		gparams.InContainer,
+		true, //rtaSourcePT
+		"",   //sensorIPCEndpoint
+		"",   //sensorIPCMode
+		printState,
+		config.AppNodejsInspectOptions{})
+	xc.FailOn(err)
+
+	if len(containerInspector.FatContainerCmd) == 0 {
+		xc.Out.Info("target.image.error",
+			ovars{
+				"status":  "no.entrypoint.cmd",
+				"image":   imageRef,
+				"message": "no ENTRYPOINT/CMD",
+			})
+
+		exitCode := commands.ECTDoctor | ecdNoEntrypoint
+		xc.Out.State("exited", ovars{"exit.code": exitCode})
+		xc.Exit(exitCode)
+	}
+
+	logger.Debugf("traceImage: starting instrumented container for %s", imageRef)
+	err = containerInspector.RunContainer()
+	xc.FailOn(app.WrapError(err, app.ErrorCategoryContainer, "container.run", commands.HintContainerLogs))
+
+	xc.Out.Info("container",
+		ovars{
+			"image": imageRef,
+			"name":  containerInspector.ContainerName,
+			"id":    containerInspector.ContainerID,
+		})
+
+	result := &traceResult{}
+
+	var probe *http.CustomProbe
+	if httpProbeOpts.Do {
+		probe, err = http.NewContainerProbe(xc, containerInspector, httpProbeOpts, printState)
+		xc.FailOn(err)
+
+		if len(probe.Ports()) == 0 && len(probe.UDPPorts()) == 0 {
+			xc.Out.Info("http.probe",
+				ovars{
+					"image":   imageRef,
+					"message": "no exposed ports, running the image without probing",
+				})
+			probe = nil
+		}
+	}
+
+	if probe != nil {
+		probe.Start()
+		<-probe.DoneChan()
+		result.probe = probe.Report()
+	} else {
+		xc.Out.Info("doctor.wait",
+			ovars{
+				"image":   imageRef,
+				"seconds": cparams.RunTimeout,
+			})
+		<-time.After(time.Duration(cparams.RunTimeout) * time.Second)
+	}
+
+	containerInspector.FinishMonitoring()
+
+	err = containerInspector.ShutdownContainer()
+	errutil.WarnOn(err)
+
+	if !containerInspector.HasCollectedData() {
+		xc.Out.Info("doctor.trace.error",
+			ovars{
+				"image":  imageRef,
+				"status": "no data collected",
+			})
+
+		exitCode := commands.ECTDoctor | ecdNoTraceData
+		xc.Out.State("exited", ovars{"exit.code": exitCode})
+		xc.Exit(exitCode)
+	}
+
+	var creport report.ContainerReport
+	err = fsutil.LoadStructFromFile(filepath.Join(artifactLocation, report.DefaultContainerReportFileName), &creport)
+	xc.FailOn(err)
+
+	result.archName = creport.Monitors.Pt.ArchName
+	result.activity = creport.Monitors.Pt.FSActivity
+	result.missing = creport.Monitors.Pt.FSMissing
+	return result
+}
+
+// missingPaths returns the paths the app fails to find in the optimized image,
+// but not in the original image (the failed lookups in both images are expected)
+func missingPaths(original, slim *traceResult) []*report.DoctorMissingPath {
+	callName := system.CallNumberResolver(system.ArchName(slim.archName))
+
+	var paths []string
+	for fpath := range slim.missing {
+		if _, ok := original.missing[fpath]; ok {
+			continue
+		}
+
+		paths = append(paths, fpath)
+	}
+
+	sort.Strings(paths)
+
+	var result []*report.DoctorMissingPath
+	for _, fpath := range paths {
+		//the parent directory is already missing (and it'll be included with its files)
+		if len(result) > 0 && strings.HasPrefix(fpath, result[len(result)-1].Path+"/") {
+			continue
+		}
+
+		info := slim.missing[fpath]
+		mpath := &report.DoctorMissingPath{
+			Path: fpath,
+			Exec: info.Exec,
+		}
+
+		if _, ok := original.activity[fpath]; ok {
+			mpath.Confirmed = true
+		}
+
+		for num := range info.Syscalls {
+			name := fmt.Sprintf("%d", num)
+			if callName != nil {
+				name = callName(uint32(num))
+			}
+
+			mpath.Syscalls = append(mpath.Syscalls, name)
+		}
+
+		sort.Strings(mpath.Syscalls)
+
+		if mpath.Exec {
+			mpath.IncludeFlag = fmt.Sprintf("--%s %s", build.FlagIncludeBin, fpath)
+		} else {
+			mpath.IncludeFlag = fmt.Sprintf("--%s %s", build.FlagIncludePath, fpath)
+		}
+
+		result = append(result, mpath)
+	}
+
+	return result
+}
@@ -0,0 +1,31 @@
+package doctor
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/c-bata/go-prompt"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(FlagSlimImage), Description: FlagSlimImageUsage},
+		{Text: commands.FullFlagName(FlagRunTimeout), Description: FlagRunTimeoutUsage},
+		{Text: commands.FullFlagName(commands.FlagEntrypoint), Description: commands.FlagEntrypointUsage},
+		{Text: commands.FullFlagName(commands.FlagCmd), Description: commands.FlagCmdUsage},
+		{Text: commands.FullFlagName(commands.FlagEnv), Description: commands.FlagEnvUsage},
+		{Text: commands.FullFlagName(commands.FlagExpose), Description: commands.FlagExposeUsage},
+		{Text: commands.FullFlagName(commands.FlagShowContainerLogs), Description: commands.FlagShowContainerLogsUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbe), Description: commands.FlagHTTPProbeUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget):            commands.CompleteTarget,
+		commands.FullFlagName(commands.FlagShowContainerLogs): commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbe):         commands.CompleteTBool,
+	},
+}
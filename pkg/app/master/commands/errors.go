@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// Command error remediation hints
+const (
+	HintImagePull     = "check the image name and the registry credentials (--docker-config-path or --registry-account and --registry-secret)"
+	HintContainerLogs = "use --show-clogs to see the temporary container logs"
+	HintStatePath     = "check the state location (--" + FlagStatePath + ")"
+)
+
+// ImagePullError returns the structured image pull error (nil if there's no error)
+func ImagePullError(err error) error {
+	return app.WrapError(err, app.ErrorCategoryImage, "image.pull", HintImagePull)
+}
+
+// StateDirError returns the structured state directory error (nil if there's no error)
+func StateDirError(err error) error {
+	return app.WrapError(err, app.ErrorCategoryFilesystem, "state.dir", HintStatePath)
+}
+
+// ExecStillRunningError returns the structured error for the continue-after exec commands that didn't finish
+func ExecStillRunningError() error {
+	return app.NewError(app.ErrorCategoryContainer,
+		"continue.after.exec",
+		"exec command is still running",
+		HintContainerLogs)
+}
+
+// UnknownContinueAfterModeError returns the structured error for the unsupported continue-after modes
+func UnknownContinueAfterModeError(mode string) error {
+	return app.NewError(app.ErrorCategoryInput,
+		"continue.after.mode",
+		fmt.Sprintf("unknown continue-after mode - '%s'", mode),
+		fmt.Sprintf("check the --%s flag value", FlagContinueAfter))
+}
+
+// SaveReportOnFailure saves the command report if the command fails or if it's interrupted
+// (the structured command error is saved in the report)
+func SaveReportOnFailure(xc *app.ExecutionContext, cmdReport *report.Command, save func() bool) {
+	xc.AddCleanupHandler(func() {
+		if cmdReport.State == command.StateDone {
+			//the final report is already saved
+			return
+		}
+
+		cmdReport.State = command.StateError
+		if app.IsInterrupted() {
+			cmdReport.State = command.StateInterrupted
+		}
+
+		if xerr := xc.Err(); xerr != nil {
+			cmdReport.Error = xerr.Type
+			cmdReport.ErrorInfo = xerr.ReportInfo()
+		}
+
+		if save() {
+			xc.Out.Info("report",
+				app.OutVars{
+					"file": cmdReport.ReportLocation(),
+				})
+		}
+	})
+}
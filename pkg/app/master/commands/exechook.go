@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/util/printbuffer"
+)
+
+// RunExecHooks executes the exec hooks for the selected phase
+// (the failed hooks are reported, but they don't stop the command)
+func RunExecHooks(
+	xc *app.ExecutionContext,
+	printState bool,
+	hooks []config.ExecHook,
+	phase string,
+	client *docker.Client,
+	containerID string) {
+	for idx, hook := range hooks {
+		if hook.Phase != phase {
+			continue
+		}
+
+		if printState {
+			xc.Out.Info("exec.hook",
+				ovars{
+					"idx":     idx,
+					"phase":   hook.Phase,
+					"target":  hook.Target,
+					"command": hook.Command,
+				})
+		}
+
+		var err error
+		switch hook.Target {
+		case config.ExecHookHost:
+			if printState {
+				xc.Out.Info("exec.hook.output.start")
+			}
+
+			err = exeAppCall(hook.Command)
+			if printState {
+				xc.Out.Info("exec.hook.output.end")
+			}
+		case config.ExecHookContainer:
+			var exitCode int
+			exitCode, err = ContainerExec(client, containerID, hook.Command, "exec.hook")
+			if err == nil && exitCode != 0 {
+				err = fmt.Errorf("exit code - %d", exitCode)
+			}
+		default:
+			err = fmt.Errorf("unknown exec hook target - %s", hook.Target)
+		}
+
+		statusCode := "ok"
+		callErrorStr := "none"
+		if err != nil {
+			statusCode = "error"
+			callErrorStr = err.Error()
+		}
+
+		if printState || err != nil {
+			xc.Out.Info("exec.hook",
+				ovars{
+					"idx":    idx,
+					"phase":  hook.Phase,
+					"status": statusCode,
+					"error":  callErrorStr,
+					"time":   time.Now().UTC().Format(time.RFC3339),
+				})
+		}
+	}
+}
+
+// ContainerExec runs the shell command in the target container
+// and returns its exit code (the command output is printed with the selected prefix)
+func ContainerExec(client *docker.Client, containerID, command, outputPrefix string) (int, error) {
+	if client == nil || containerID == "" {
+		return -1, fmt.Errorf("no target container")
+	}
+
+	exec, err := client.CreateExec(docker.CreateExecOptions{
+		Container:    containerID,
+		Cmd:          []string{"sh", "-c", command},
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	buffer := &printbuffer.PrintBuffer{Prefix: fmt.Sprintf("%s[%s]: output:", appName, outputPrefix)}
+	err = client.StartExec(exec.ID, docker.StartExecOptions{
+		InputStream:  bytes.NewBufferString(""),
+		OutputStream: buffer,
+		ErrorStream:  buffer,
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	inspect, err := client.InspectExec(exec.ID)
+	if err != nil {
+		return -1, err
+	}
+
+	return inspect.ExitCode, nil
+}
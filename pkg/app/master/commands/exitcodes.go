@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/sensor"
+)
+
+// ExitCode describes a documented command exit code.
+// The command exit codes are shown in the 'exited' state (the 'exit.code' field).
+// The process exit status is mapped from the exit code (see ExitStatus).
+type ExitCode struct {
+	Code int `json:"code"`
+	//process exit status (as seen by the shell)
+	Status int    `json:"status"`
+	Name   string `json:"name"`
+	//command name (empty for the exit codes shared by all commands)
+	Command     string `json:"command,omitempty"`
+	Description string `json:"description"`
+}
+
+// Process exit status ranges for the exit codes with the exit code type
+const (
+	ectMask           = 0x7f000000
+	commonStatusBase  = 32
+	commandStatusBase = 64
+)
+
+// ExitStatus returns the process exit status for the command exit code:
+// the exit codes from 0 to 255 (e.g., the structured error exit codes) are the exit statuses,
+// the negative sensor exit codes are negated (e.g., 123 for -123),
+// the shared ECTCommon exit codes are mapped to 32 + the exit code number
+// and the command specific exit codes are mapped to 64 + the exit code number
+// (the registered exit code statuses are unique for each command; see RegisterExitCodes)
+func ExitStatus(exitCode int) int {
+	switch {
+	case exitCode >= 0 && exitCode <= 0xff:
+		return exitCode
+	case exitCode < 0 && exitCode >= -0xff:
+		return -exitCode
+	case exitCode&ectMask == ECTCommon:
+		return commonStatusBase + exitCode&0xff
+	default:
+		return commandStatusBase + exitCode&0xff
+	}
+}
+
+var exitCodeRegistry struct {
+	mu    sync.Mutex
+	codes map[int]ExitCode
+}
+
+// RegisterExitCodes adds the command exit codes to the exit code registry
+// (the exit codes and their exit statuses must be unique: the shared exit code statuses
+// must be different from all other statuses, and the command exit code statuses must be unique for the command;
+// the duplicates are programming errors)
+func RegisterExitCodes(cmdName string, codes ...ExitCode) {
+	exitCodeRegistry.mu.Lock()
+	defer exitCodeRegistry.mu.Unlock()
+
+	if exitCodeRegistry.codes == nil {
+		exitCodeRegistry.codes = map[int]ExitCode{}
+	}
+
+	for _, info := range codes {
+		if existing, found := exitCodeRegistry.codes[info.Code]; found {
+			panic(fmt.Sprintf("duplicate exit code %d (0x%x) - '%s' and '%s'", info.Code, info.Code, existing.Name, info.Name))
+		}
+
+		info.Command = cmdName
+		info.Status = ExitStatus(info.Code)
+		if info.Status > 0xff {
+			panic(fmt.Sprintf("exit code %d (0x%x) - '%s' status is out of range (%d)", info.Code, info.Code, info.Name, info.Status))
+		}
+
+		for _, existing := range exitCodeRegistry.codes {
+			if existing.Status == info.Status &&
+				(existing.Command == "" || info.Command == "" || existing.Command == info.Command) {
+				panic(fmt.Sprintf("duplicate exit status %d - '%s' (0x%x) and '%s' (0x%x)",
+					info.Status, existing.Name, existing.Code, info.Name, info.Code))
+			}
+		}
+
+		exitCodeRegistry.codes[info.Code] = info
+	}
+}
+
+// ExitCodes returns the registered exit codes (the shared exit codes first, then by command and code)
+func ExitCodes() []ExitCode {
+	exitCodeRegistry.mu.Lock()
+	defer exitCodeRegistry.mu.Unlock()
+
+	var codes []ExitCode
+	for _, info := range exitCodeRegistry.codes {
+		codes = append(codes, info)
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		if codes[i].Command != codes[j].Command {
+			return codes[i].Command < codes[j].Command
+		}
+
+		return codes[i].Code < codes[j].Code
+	})
+
+	return codes
+}
+
+// LookupExitCode returns the exit code info for a registered exit code
+func LookupExitCode(code int) (ExitCode, bool) {
+	exitCodeRegistry.mu.Lock()
+	defer exitCodeRegistry.mu.Unlock()
+
+	info, found := exitCodeRegistry.codes[code]
+	return info, found
+}
+
+// the exit codes shared by all commands
+var commonExitCodes = []ExitCode{
+	{Code: 0, Name: "ok", Description: "command completed successfully"},
+	{Code: app.ExitCodeInternal, Name: "error.internal", Description: "unexpected error"},
+	{Code: app.ExitCodeDocker, Name: "error.docker", Description: "Docker connection or Docker API error"},
+	{Code: app.ExitCodeImage, Name: "error.image", Description: "target image error (missing image, image pull or image build failure)"},
+	{Code: app.ExitCodeContainer, Name: "error.container", Description: "temporary container error (container start, monitoring or artifact collection failure)"},
+	{Code: app.ExitCodeNetwork, Name: "error.network", Description: "container registry or network error"},
+	{Code: app.ExitCodeInput, Name: "error.input", Description: "bad command parameters or input files"},
+	{Code: app.ExitCodeFilesystem, Name: "error.filesystem", Description: "local file system error"},
+	{Code: app.ExitCodeInterrupted, Name: "interrupted", Description: "command interrupted (SIGINT or SIGTERM)"},
+	{Code: ECTCommon | ecOther, Name: "common.other", Description: "other command error"},
+	{Code: ECTCommon | ECNoDockerConnectInfo, Name: "common.no.docker.connect.info", Description: "missing Docker connection info"},
+	{Code: ECTCommon | ECBadNetworkName, Name: "common.bad.network.name", Description: "unknown container network"},
+	{Code: ECTCommon | ECBadParams, Name: "common.bad.params", Description: "invalid command flag or parameter value"},
+	{Code: ECTCommon | ECCommandExec, Name: "common.command.exec", Description: "error running the external command (plugin or the 'build' command started by the wizard or the containerize command)"},
+	{Code: sensor.ExitCodeContainerCrashed, Name: "sensor.container.crashed", Description: "temporary container exited with an error"},
+	{Code: sensor.ExitCodeSensorError, Name: "sensor.error", Description: "sensor error (see the sensor logs in the temporary container logs)"},
+	{Code: sensor.ExitCodeNoSensor, Name: "sensor.not.found", Description: "sensor binary not found"},
+	{Code: sensor.ExitCodeCmdPortConflict, Name: "sensor.cmd.port.conflict", Description: "sensor command port conflict (published port conflicts with the sensor IPC port)"},
+	{Code: sensor.ExitCodeEvtPortConflict, Name: "sensor.evt.port.conflict", Description: "sensor event port conflict (published port conflicts with the sensor IPC port)"},
+}
+
+func init() {
+	RegisterExitCodes("", commonExitCodes...)
+	app.ExitStatus = ExitStatus
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+)
+
+func TestExitStatus(t *testing.T) {
+	tt := []struct {
+		code   int
+		status int
+	}{
+		{code: 0, status: 0},
+		{code: app.ExitCodeInternal, status: 1},
+		{code: app.ExitCodeFilesystem, status: 15},
+		{code: app.ExitCodeInterrupted, status: 130},
+		{code: -123, status: 123},
+		{code: ECTCommon | 4, status: 36},
+		{code: ECTBuild | 3, status: 67},
+		{code: ECTInstrument | 1, status: 65},
+	}
+
+	for _, test := range tt {
+		assert.Equal(t, test.status, ExitStatus(test.code), "exit code 0x%x", test.code)
+	}
+}
+
+func TestRegisterExitCodesStatus(t *testing.T) {
+	//the registered exit code statuses are unique for each command (and the shared exit codes)
+	seen := map[string]map[int]string{}
+	var shared []ExitCode
+	for _, info := range ExitCodes() {
+		if info.Command == "" {
+			shared = append(shared, info)
+			continue
+		}
+
+		if seen[info.Command] == nil {
+			seen[info.Command] = map[int]string{}
+		}
+
+		assert.Empty(t, seen[info.Command][info.Status], info.Name)
+		seen[info.Command][info.Status] = info.Name
+	}
+
+	for _, info := range shared {
+		for cmdName, statuses := range seen {
+			assert.Empty(t, statuses[info.Status], "%s - %s", cmdName, info.Name)
+		}
+	}
+
+	//the duplicate exit statuses are programming errors
+	//(the panics happen before the exit codes are registered)
+	assert.Panics(t, func() {
+		RegisterExitCodes("test.exit.codes", ExitCode{Code: -1, Name: "test.internal"})
+	})
+	assert.Panics(t, func() {
+		RegisterExitCodes("", ExitCode{Code: ECTCommon | 0x101, Name: "test.common"})
+	})
+	assert.Panics(t, func() {
+		RegisterExitCodes("test.exit.codes", ExitCode{Code: ECTCommon | 0xfe, Name: "test.range"})
+	})
+}
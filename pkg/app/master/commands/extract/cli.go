@@ -0,0 +1,120 @@
+package extract
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Image file extraction (without running the image)
+
+const (
+	Name  = "extract"
+	Usage = "Extract files and directories from a container image without running it"
+	Alias = "ex"
+)
+
+const outputNameSuffix = ".extract"
+
+type CommandParams struct {
+	TargetRef  string
+	Paths      []string
+	OutputType string
+	Output     string
+}
+
+var CLI = &cli.Command{
+	Name:      Name,
+	Aliases:   []string{Alias},
+	Usage:     Usage,
+	ArgsUsage: "<IMAGE> <PATH>...",
+	Flags: []cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagPath),
+		cflag(FlagTo),
+		cflag(FlagOutput),
+	},
+	Action: func(ctx *cli.Context) error {
+		cparams := &CommandParams{
+			TargetRef:  ctx.String(commands.FlagTarget),
+			Paths:      ctx.StringSlice(FlagPath),
+			OutputType: ctx.String(FlagTo),
+			Output:     ctx.String(FlagOutput),
+		}
+
+		args := ctx.Args().Slice()
+		if cparams.TargetRef == "" {
+			if len(args) < 1 {
+				fmt.Printf("docker-slim[%s]: missing target image...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			}
+
+			cparams.TargetRef = args[0]
+			args = args[1:]
+		}
+
+		cparams.Paths = append(cparams.Paths, args...)
+		if len(cparams.Paths) == 0 {
+			fmt.Printf("docker-slim[%s]: missing paths to extract...\n\n", Name)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		switch cparams.OutputType {
+		case OutputDir, OutputTar, OutputNewImage:
+		default:
+			fmt.Printf("docker-slim[%s]: unknown output type - '%s'...\n\n", Name, cparams.OutputType)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		if cparams.Output == "" {
+			cparams.Output = defaultOutput(cparams.TargetRef, cparams.OutputType)
+		}
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		OnCommand(
+			xc,
+			gcvalues,
+			cparams)
+
+		return nil
+	},
+}
+
+// defaultOutput returns the default output location for the output type
+// (<name>.extract in the current directory for the files
+// and <repo>.extract:<tag> for the new image)
+func defaultOutput(targetRef string, outputType string) string {
+	name := targetRef
+	if idx := strings.Index(name, "@"); idx > 0 {
+		name = name[:idx]
+	}
+
+	var tag string
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		tag = name[idx:]
+		name = name[:idx]
+	}
+
+	switch outputType {
+	case OutputNewImage:
+		return name + outputNameSuffix + tag
+	case OutputTar:
+		return filepath.Base(name) + outputNameSuffix + ".tar"
+	default:
+		return filepath.Base(name) + outputNameSuffix
+	}
+}
@@ -0,0 +1,57 @@
+package extract
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Extract command flag names
+const (
+	FlagPath   = "path"
+	FlagTo     = "to"
+	FlagOutput = "output"
+)
+
+// Extract command flag usage info
+const (
+	FlagPathUsage   = "File or directory to extract from the image (you can use this flag multiple times)"
+	FlagToUsage     = "Extracted data output type (values: dir, tar, new-image)"
+	FlagOutputUsage = "Output directory, tar archive file path or new image name (default: '<image_name>.extract')"
+)
+
+// Extract command output types
+const (
+	OutputDir      = "dir"
+	OutputTar      = "tar"
+	OutputNewImage = "new-image"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagPath: &cli.StringSliceFlag{
+		Name:    FlagPath,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagPathUsage,
+		EnvVars: []string{"DSLIM_EXTRACT_PATH"},
+	},
+	FlagTo: &cli.StringFlag{
+		Name:    FlagTo,
+		Value:   OutputDir,
+		Usage:   FlagToUsage,
+		EnvVars: []string{"DSLIM_EXTRACT_TO"},
+	},
+	FlagOutput: &cli.StringFlag{
+		Name:    FlagOutput,
+		Value:   "",
+		Usage:   FlagOutputUsage,
+		EnvVars: []string{"DSLIM_EXTRACT_OUTPUT"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -0,0 +1,275 @@
+package extract
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/app/master/version"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// Extract command exit codes
+const (
+	eceOther = iota + 1
+	eceImageNotFound
+	eceExtractError
+	eceMissingPaths
+	eceImageBuildError
+)
+
+// exitCodes documents the extract command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTExtract | eceOther, Name: "extract.other", Description: "other extract command error"},
+	{Code: commands.ECTExtract | eceImageNotFound, Name: "extract.image.not.found", Description: "target image not found"},
+	{Code: commands.ECTExtract | eceExtractError, Name: "extract.error", Description: "error extracting the image data"},
+	{Code: commands.ECTExtract | eceMissingPaths, Name: "extract.missing.paths", Description: "some of the paths to extract are not in the image"},
+	{Code: commands.ECTExtract | eceImageBuildError, Name: "extract.image.build.error", Description: "error creating the new image with the extracted data"},
+}
+
+const (
+	imageArchiveName = "image.tar"
+	dataArchiveName  = "files.tar"
+	//the image the extracted data comes from
+	extractImageLabelName = "docker-slim.extract.source"
+)
+
+// OnCommand implements the 'extract' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+	prefix := fmt.Sprintf("cmd=%s", Name)
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewExtractCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.TargetRef
+	cmdReport.OutputType = cparams.OutputType
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"target": cparams.TargetRef,
+			"paths":  strings.Join(cparams.Paths, ","),
+			"to":     cparams.OutputType,
+			"output": cparams.Output,
+		})
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err == dockerclient.ErrNoDockerInfo {
+		exitMsg := "missing Docker connection info"
+		if gparams.InContainer && gparams.IsDSImage {
+			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+		}
+
+		xc.Out.Info("docker.connect.error",
+			ovars{
+				"message": exitMsg,
+			})
+
+		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+		xc.Exit(exitCode)
+	}
+	xc.FailOn(err)
+
+	if gparams.Debug {
+		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	}
+
+	imageInspector, err := image.NewInspector(client, cparams.TargetRef)
+	xc.FailOn(err)
+
+	if imageInspector.NoImage() {
+		xc.Out.Info("target.image.error",
+			ovars{
+				"status":  "image.not.found",
+				"image":   cparams.TargetRef,
+				"message": "make sure the target image already exists locally",
+			})
+
+		exitCode := commands.ECTExtract | eceImageNotFound
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "image.not.found"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	err = imageInspector.Inspect()
+	xc.FailOn(err)
+
+	imageID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
+	cmdReport.ImageID = imageID
+
+	workDir, err := ioutil.TempDir("", "docker-slim-extract-")
+	xc.FailOn(err)
+	defer os.RemoveAll(workDir)
+
+	//saving the image without running it (the layer data is read from the image archive)
+	archivePath := filepath.Join(workDir, imageArchiveName)
+	saveProgress := xc.Out.NewSpinner("image.save")
+	err = dockerutil.SaveImage(client, imageID, archivePath, false, false)
+	saveProgress.Done()
+	xc.FailOn(err)
+
+	output := cparams.Output
+	isArchive := cparams.OutputType == OutputTar
+	if cparams.OutputType == OutputNewImage {
+		output = filepath.Join(workDir, dataArchiveName)
+		isArchive = true
+	}
+
+	info, err := dockerimage.ExtractPaths(archivePath, cparams.Paths, output, isArchive)
+	if err != nil {
+		logger.Errorf("dockerimage.ExtractPaths error - %v", err)
+		xc.Out.Error("extract.error", err.Error())
+
+		exitCode := commands.ECTExtract | eceExtractError
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "extract.error"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	cmdReport.Extract = info
+	for _, fpath := range info.Missing {
+		xc.Out.Info("extract.missing.path",
+			ovars{
+				"path": fpath,
+			})
+	}
+
+	if cparams.OutputType == OutputNewImage {
+		//the data archive is a temporary artifact
+		info.Output = cparams.Output
+		info.IsArchive = false
+
+		imageInfo, err := createImage(xc, client, cparams.TargetRef, cparams.Output, workDir)
+		if err != nil {
+			logger.Errorf("createImage error - %v", err)
+			xc.Out.Error("extract.image.build.error", err.Error())
+
+			exitCode := commands.ECTExtract | eceImageBuildError
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+
+			cmdReport.Error = "image.build.error"
+			cmdReport.Save()
+			xc.Exit(exitCode)
+		}
+
+		cmdReport.NewImage = &report.ExtractImageInfo{
+			Name: cparams.Output,
+			ID:   imageInfo.ID,
+			Size: imageInfo.Size,
+		}
+	}
+
+	xc.Out.Info("extract.results",
+		ovars{
+			"to":         cparams.OutputType,
+			"output":     info.Output,
+			"objects":    info.ObjectCount,
+			"size.human": humanize.Bytes(uint64(info.DataSize)),
+			"missing":    len(info.Missing),
+		})
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	if len(info.Missing) > 0 {
+		exitCode := commands.ECTExtract | eceMissingPaths
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+		xc.Exit(exitCode)
+	}
+
+	xc.Out.State("done")
+}
+
+// createImage creates a new image (FROM scratch) with the extracted data archive
+func createImage(
+	xc *app.ExecutionContext,
+	client *dockerapi.Client,
+	sourceRef string,
+	imageName string,
+	workDir string) (*dockerapi.Image, error) {
+	dockerfile := fmt.Sprintf("FROM scratch\nADD %s /\nLABEL %s=%q\n",
+		dataArchiveName,
+		extractImageLabelName,
+		sourceRef)
+	if err := ioutil.WriteFile(filepath.Join(workDir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return nil, err
+	}
+
+	//the saved image archive is not a part of the build context
+	if err := ioutil.WriteFile(filepath.Join(workDir, ".dockerignore"), []byte(imageArchiveName+"\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	err := client.BuildImage(dockerapi.BuildImageOptions{
+		Context:             xc.Context,
+		Name:                imageName,
+		ContextDir:          workDir,
+		Dockerfile:          "Dockerfile",
+		RmTmpContainer:      true,
+		ForceRmTmpContainer: true,
+		OutputStream:        ioutil.Discard,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("image build error - %w", err)
+	}
+
+	return client.InspectImage(imageName)
+}
@@ -0,0 +1,35 @@
+package extract
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/c-bata/go-prompt"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(FlagPath), Description: FlagPathUsage},
+		{Text: commands.FullFlagName(FlagTo), Description: FlagToUsage},
+		{Text: commands.FullFlagName(FlagOutput), Description: FlagOutputUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget): commands.CompleteTarget,
+		commands.FullFlagName(FlagTo):              completeOutputType,
+	},
+}
+
+var outputTypeValues = []prompt.Suggest{
+	{Text: OutputDir, Description: "Save the extracted files in a host directory"},
+	{Text: OutputTar, Description: "Save the extracted files in a tar archive (.tar, .tar.gz or .tgz)"},
+	{Text: OutputNewImage, Description: "Create a new (FROM scratch) image with the extracted files"},
+}
+
+func completeOutputType(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(outputTypeValues, token, true)
+}
@@ -0,0 +1,12 @@
+package extract
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+)
+
+func RegisterCommand() {
+	commands.CLI = append(commands.CLI, CLI)
+	commands.RegisterExitCodes(Name, exitCodes...)
+	commands.CommandFlagSuggestions[Name] = CommandFlagSuggestions
+	commands.CommandSuggestions = append(commands.CommandSuggestions, CommandSuggestion)
+}
@@ -1,7 +1,14 @@
 package help
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+
 	"github.com/urfave/cli/v2"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 )
 
 const (
@@ -10,12 +17,73 @@ const (
 	Alias = "h"
 )
 
+const (
+	ExitCodesName  = "exit-codes"
+	ExitCodesUsage = "Show the documented command exit codes"
+	FlagJSON       = "json"
+	FlagJSONUsage  = "print the exit codes as a JSON array"
+)
+
+type ovars = app.OutVars
+
 var CLI = &cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
 	Usage:   Usage,
 	Action: func(ctx *cli.Context) error {
-		cli.ShowAppHelp(ctx)
+		//the subcommands run in their own app, so the main app help comes from the root context
+		rootCtx := ctx
+		for _, parent := range ctx.Lineage() {
+			if parent.App != nil {
+				rootCtx = parent
+			}
+		}
+
+		cli.ShowAppHelp(rootCtx)
 		return nil
 	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  ExitCodesName,
+			Usage: ExitCodesUsage,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  FlagJSON,
+					Usage: FlagJSONUsage,
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				codes := commands.ExitCodes()
+				if ctx.Bool(FlagJSON) {
+					data, err := json.MarshalIndent(codes, "", "  ")
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintln(os.Stdout, string(data))
+					return nil
+				}
+
+				xc := app.NewExecutionContext(fmt.Sprintf("%s %s", Name, ExitCodesName), ctx.String(commands.FlagConsoleFormat))
+				for _, info := range codes {
+					command := info.Command
+					if command == "" {
+						command = "all"
+					}
+
+					xc.Out.Info("exit.code",
+						ovars{
+							"code":        info.Code,
+							"hex":         fmt.Sprintf("0x%08x", uint32(info.Code)),
+							"status":      info.Status,
+							"name":        info.Name,
+							"command":     command,
+							"description": info.Description,
+						})
+				}
+
+				return nil
+			},
+		},
+	},
 }
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"fmt"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Image history (the image stack reverse engineered from the image history records)
+
+const (
+	Name  = "history"
+	Usage = "Show the image history as a tree (the image stack, the instructions with their sizes and the intermediate images)"
+	Alias = "hi"
+)
+
+type CommandParams struct {
+	TargetRef string
+	DoJSON    bool
+	DoNoTrunc bool
+}
+
+var CLI = &cli.Command{
+	Name:      Name,
+	Aliases:   []string{Alias},
+	Usage:     Usage,
+	ArgsUsage: "<IMAGE>",
+	Flags: []cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagJSON),
+		cflag(FlagNoTrunc),
+	},
+	Action: func(ctx *cli.Context) error {
+		cparams := &CommandParams{
+			TargetRef: ctx.String(commands.FlagTarget),
+			DoJSON:    ctx.Bool(FlagJSON),
+			DoNoTrunc: ctx.Bool(FlagNoTrunc),
+		}
+
+		if cparams.TargetRef == "" {
+			if ctx.Args().Len() < 1 {
+				fmt.Printf("docker-slim[%s]: missing target image...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			}
+
+			cparams.TargetRef = ctx.Args().First()
+		}
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cparams.DoJSON {
+			//only the errors are shown, so the JSON output can be parsed
+			app.SetOutputLevel(app.OutputQuiet)
+		}
+
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		OnCommand(
+			xc,
+			gcvalues,
+			cparams)
+
+		return nil
+	},
+}
@@ -0,0 +1,40 @@
+package history
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// History command flag names
+const (
+	FlagJSON    = "json"
+	FlagNoTrunc = "no-trunc"
+)
+
+// History command flag usage info
+const (
+	FlagJSONUsage    = "Print the image history as JSON (without the other console output)"
+	FlagNoTruncUsage = "Show the full instructions (instead of the instruction snippets)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagJSON: &cli.BoolFlag{
+		Name:    FlagJSON,
+		Usage:   FlagJSONUsage,
+		EnvVars: []string{"DSLIM_HISTORY_JSON"},
+	},
+	FlagNoTrunc: &cli.BoolFlag{
+		Name:    FlagNoTrunc,
+		Usage:   FlagNoTruncUsage,
+		EnvVars: []string{"DSLIM_HISTORY_NO_TRUNC"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -0,0 +1,213 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/app/master/version"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// History command exit codes
+const (
+	echOther = iota + 1
+	echImageNotFound
+	echNoHistory
+)
+
+// exitCodes documents the history command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTHistory | echOther, Name: "history.other", Description: "other history command error"},
+	{Code: commands.ECTHistory | echImageNotFound, Name: "history.image.not.found", Description: "target image not found"},
+	{Code: commands.ECTHistory | echNoHistory, Name: "history.no.history", Description: "error getting the image history"},
+}
+
+// OnCommand implements the 'history' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+	prefix := fmt.Sprintf("cmd=%s", Name)
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewHistoryCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.TargetRef
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"target": cparams.TargetRef,
+		})
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err == dockerclient.ErrNoDockerInfo {
+		exitMsg := "missing Docker connection info"
+		if gparams.InContainer && gparams.IsDSImage {
+			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+		}
+
+		xc.Out.Info("docker.connect.error",
+			ovars{
+				"message": exitMsg,
+			})
+
+		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+		xc.Exit(exitCode)
+	}
+	xc.FailOn(err)
+
+	if gparams.Debug {
+		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	}
+
+	imageInspector, err := image.NewInspector(client, cparams.TargetRef)
+	xc.FailOn(err)
+
+	if imageInspector.NoImage() {
+		xc.Out.Info("target.image.error",
+			ovars{
+				"status":  "image.not.found",
+				"image":   cparams.TargetRef,
+				"message": "make sure the target image already exists locally",
+			})
+
+		exitCode := commands.ECTHistory | echImageNotFound
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "image.not.found"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	err = imageInspector.Inspect()
+	xc.FailOn(err)
+
+	dockerfileInfo, err := reverse.DockerfileFromHistory(client, imageInspector.ImageInfo.ID)
+	if err != nil {
+		logger.Errorf("reverse.DockerfileFromHistory error - %v", err)
+		xc.Out.Error("image.history.error", err.Error())
+
+		exitCode := commands.ECTHistory | echNoHistory
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "no.history"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	history := &report.ImageHistoryInfo{
+		ID:               imageInspector.ImageInfo.ID,
+		Tags:             imageInspector.ImageInfo.RepoTags,
+		Size:             imageInspector.ImageInfo.VirtualSize,
+		SizeHuman:        humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
+		CreateTime:       imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
+		InstructionCount: len(dockerfileInfo.AllInstructions),
+		ImageStack:       dockerfileInfo.ImageStack,
+	}
+
+	cmdReport.History = history
+
+	if cparams.DoJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		xc.FailOn(encoder.Encode(history))
+	} else {
+		printHistory(xc, cparams.TargetRef, history, cparams.DoNoTrunc)
+	}
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	if !cparams.DoJSON {
+		xc.Out.State("done")
+	}
+}
+
+// printHistory shows the image history as a tree
+// (the instruction events are used instead of the tree with the JSON console output)
+func printHistory(xc *app.ExecutionContext, targetRef string, history *report.ImageHistoryInfo, noTrunc bool) {
+	if xc.Out.JSONFlag == "json" {
+		var instIdx int
+		for imageIdx, imageInfo := range history.ImageStack {
+			xc.Out.Info("history.image", imageVars(imageIdx, imageInfo))
+			for _, instInfo := range imageInfo.Instructions {
+				xc.Out.Info("history.instruction",
+					ovars{
+						"image":        imageIdx,
+						"index":        instIdx,
+						"type":         instInfo.Type,
+						"size":         instInfo.Size,
+						"intermediate": intermediateImage(instInfo),
+						"instruction":  instInfo.CommandAll,
+					})
+				instIdx++
+			}
+		}
+
+		return
+	}
+
+	for _, line := range historyTree(targetRef, history, noTrunc) {
+		fmt.Println(line)
+	}
+}
+
+func imageVars(index int, imageInfo *reverse.ImageInfo) ovars {
+	vars := ovars{
+		"index":        index,
+		"name":         imageName(imageInfo),
+		"id":           shortID(imageInfo.ID),
+		"new.size":     imageInfo.NewSizeHuman,
+		"created":      imageInfo.CreateTime,
+		"instructions": len(imageInfo.Instructions),
+	}
+
+	if imageInfo.IsTopImage {
+		vars["top"] = true
+	}
+
+	return vars
+}
@@ -0,0 +1,25 @@
+package history
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/c-bata/go-prompt"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(FlagJSON), Description: FlagJSONUsage},
+		{Text: commands.FullFlagName(FlagNoTrunc), Description: FlagNoTruncUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget): commands.CompleteTarget,
+		commands.FullFlagName(FlagJSON):            commands.CompleteBool,
+		commands.FullFlagName(FlagNoTrunc):         commands.CompleteBool,
+	},
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const (
+	treeBranch     = "├── "
+	treeLastBranch = "└── "
+	treeIndent     = "│   "
+	treeLastIndent = "    "
+
+	shortIDLen     = 12
+	missingImageID = "<missing>"
+	unknownImage   = "<unknown>"
+)
+
+// historyTree renders the image stack as a tree:
+// the images (the base images first) with their instructions
+// (the instruction index, the size of the layer it created,
+// the intermediate image ID if it's available locally and the instruction itself)
+func historyTree(targetRef string, history *report.ImageHistoryInfo, noTrunc bool) []string {
+	lines := []string{
+		fmt.Sprintf("%s (id: %s, size: %s, created: %s, images: %d, instructions: %d)",
+			targetRef,
+			shortID(history.ID),
+			history.SizeHuman,
+			history.CreateTime,
+			len(history.ImageStack),
+			history.InstructionCount),
+	}
+
+	var instIdx int
+	for imageIdx, imageInfo := range history.ImageStack {
+		branch, indent := treeBranch, treeIndent
+		if imageIdx == len(history.ImageStack)-1 {
+			branch, indent = treeLastBranch, treeLastIndent
+		}
+
+		var details []string
+		details = append(details, fmt.Sprintf("id: %s", shortID(imageInfo.ID)))
+		if len(imageInfo.RawTags) > 1 {
+			details = append(details, fmt.Sprintf("tags: %s", strings.Join(imageInfo.RawTags, ",")))
+		}
+
+		details = append(details, fmt.Sprintf("new size: %s", imageInfo.NewSizeHuman))
+		if imageInfo.CreateTime != "" {
+			details = append(details, fmt.Sprintf("created: %s", imageInfo.CreateTime))
+		}
+
+		name := imageName(imageInfo)
+		if imageInfo.IsTopImage {
+			name = fmt.Sprintf("%s [top]", name)
+		}
+
+		lines = append(lines, fmt.Sprintf("%simage[%d] %s (%s)",
+			branch, imageIdx, name, strings.Join(details, ", ")))
+
+		for idx, instInfo := range imageInfo.Instructions {
+			instBranch := treeBranch
+			if idx == len(imageInfo.Instructions)-1 {
+				instBranch = treeLastBranch
+			}
+
+			instruction := instInfo.CommandSnippet
+			if noTrunc {
+				instruction = instInfo.CommandAll
+			}
+
+			lines = append(lines, fmt.Sprintf("%s%s[%d] %8s  %-12s  %s",
+				indent,
+				instBranch,
+				instIdx,
+				humanize.Bytes(uint64(instInfo.Size)),
+				intermediateImage(instInfo),
+				oneLine(instruction)))
+			instIdx++
+		}
+	}
+
+	return lines
+}
+
+// imageName returns the first image tag (the base images usually don't have local tags)
+func imageName(imageInfo *reverse.ImageInfo) string {
+	if imageInfo.FullName != "" {
+		return imageInfo.FullName
+	}
+
+	return unknownImage
+}
+
+// intermediateImage returns the short ID of the image created by the instruction
+// if it's available locally (the last instruction creates the image itself)
+func intermediateImage(instInfo *reverse.InstructionInfo) string {
+	switch {
+	case instInfo.IsLastInstruction:
+		return "[image]"
+	case instInfo.LocalImageExists && instInfo.IntermediateImageID != "":
+		return shortID(instInfo.IntermediateImageID)
+	default:
+		return missingImageID
+	}
+}
+
+// oneLine formats the multiline instructions (e.g., the RUN instructions split with '&& \') as one line
+func oneLine(instruction string) string {
+	instruction = strings.ReplaceAll(instruction, "\\\n", " ")
+	return strings.Join(strings.Fields(instruction), " ")
+}
+
+func shortID(id string) string {
+	if id == "" || id == missingImageID {
+		return missingImageID
+	}
+
+	id = dockerutil.CleanImageID(id)
+	if len(id) > shortIDLen {
+		return id[:shortIDLen]
+	}
+
+	return id
+}
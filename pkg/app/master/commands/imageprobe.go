@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerhost"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/http"
+	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+)
+
+const imageProbeStartWait = 3 * time.Second
+
+var ErrNoProbePorts = errors.New("no ports to probe")
+
+// RunImageProbe starts a temporary container from the selected image
+// (publishing all exposed ports), probes it with the HTTP probe engine
+// and removes the container when the probe is done
+func RunImageProbe(
+	xc *app.ExecutionContext,
+	client *docker.Client,
+	imageRef string,
+	overrides *config.ContainerOverrides,
+	opts config.HTTPProbeOptions,
+	responseHook http.ResponseHook,
+	printState bool,
+) (*http.CustomProbe, error) {
+	logger := log.WithFields(log.Fields{
+		"op":    "commands.RunImageProbe",
+		"image": imageRef,
+	})
+
+	containerOptions := docker.CreateContainerOptions{
+		Config: &docker.Config{
+			Image: imageRef,
+		},
+		HostConfig: &docker.HostConfig{
+			PublishAllPorts: true,
+		},
+	}
+
+	if overrides != nil {
+		containerOptions.Config.Env = overrides.Env
+		containerOptions.Config.ExposedPorts = overrides.ExposedPorts
+		if len(overrides.Entrypoint) > 0 || overrides.ClearEntrypoint {
+			containerOptions.Config.Entrypoint = overrides.Entrypoint
+		}
+
+		if len(overrides.Cmd) > 0 || overrides.ClearCmd {
+			containerOptions.Config.Cmd = overrides.Cmd
+		}
+	}
+
+	containerInfo, err := client.CreateContainer(containerOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := client.RemoveContainer(docker.RemoveContainerOptions{
+			ID:    containerInfo.ID,
+			Force: true,
+		})
+		errutil.WarnOn(err)
+	}()
+
+	if err := client.StartContainer(containerInfo.ID, nil); err != nil {
+		return nil, err
+	}
+
+	containerInfo, err = client.InspectContainerWithOptions(docker.InspectContainerOptions{ID: containerInfo.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	selected := map[string]struct{}{}
+	for _, pnum := range opts.Ports {
+		selected[strconv.Itoa(int(pnum))] = struct{}{}
+	}
+
+	var ports []string
+	var udpPorts []string
+	if containerInfo.NetworkSettings != nil {
+		for portKey, bindings := range containerInfo.NetworkSettings.Ports {
+			if len(bindings) == 0 {
+				continue
+			}
+
+			if len(selected) > 0 {
+				if _, found := selected[portKey.Port()]; !found {
+					continue
+				}
+			}
+
+			if portKey.Proto() == "udp" {
+				udpPorts = append(udpPorts, bindings[0].HostPort)
+			} else {
+				ports = append(ports, bindings[0].HostPort)
+			}
+		}
+	}
+
+	logger.Debugf("ports=%+v udp.ports=%+v", ports, udpPorts)
+	if len(ports) == 0 && len(udpPorts) == 0 {
+		return nil, ErrNoProbePorts
+	}
+
+	//the probe retries the calls if the target is not ready yet
+	//(--http-probe-start-wait is also applied by the probe)
+	time.Sleep(imageProbeStartWait)
+
+	probe, err := http.NewEndpointProbe(xc, dockerhost.GetIP(client), ports, udpPorts, opts, printState)
+	if err != nil {
+		return nil, err
+	}
+
+	if responseHook != nil {
+		probe.SetResponseHook(responseHook)
+	}
+
+	probe.Start()
+	<-probe.DoneChan()
+
+	return probe, nil
+}
@@ -16,8 +16,23 @@ const (
 	FlagBinDir      = "bin-dir"
 	FlagBinDirUsage = "Install binaries to the standard user app bin directory (/usr/local/bin)"
 
+	FlagTargetDir      = "target-dir"
+	FlagTargetDirUsage = "Install binaries to the selected directory (created if it doesn't exist)"
+
+	FlagUser      = "user"
+	FlagUserUsage = "Install binaries to the user bin directory (~/.local/bin; no sudo needed)"
+
 	FlagDockerCLIPlugin      = "docker-cli-plugin"
 	FlagDockerCLIPluginUsage = "Install as Docker CLI plugin"
+
+	FlagSystemd      = "systemd"
+	FlagSystemdUsage = "Install and enable a systemd service running the 'server' command (Linux; needs root)"
+
+	FlagSystemdListen      = "systemd-listen"
+	FlagSystemdListenUsage = "Server listen address for the systemd service (default: 127.0.0.1:7777)"
+
+	FlagSystemdUser      = "systemd-user"
+	FlagSystemdUserUsage = "User to run the systemd service (default: the user who ran sudo or the current user)"
 )
 
 var CLI = &cli.Command{
@@ -30,11 +45,36 @@ var CLI = &cli.Command{
 			Usage:   FlagBinDirUsage,
 			EnvVars: []string{"DSLIM_INSTALL_BIN_DIR"},
 		},
+		&cli.StringFlag{
+			Name:    FlagTargetDir,
+			Usage:   FlagTargetDirUsage,
+			EnvVars: []string{"DSLIM_INSTALL_TARGET_DIR"},
+		},
+		&cli.BoolFlag{
+			Name:    FlagUser,
+			Usage:   FlagUserUsage,
+			EnvVars: []string{"DSLIM_INSTALL_USER"},
+		},
 		&cli.BoolFlag{
 			Name:    FlagDockerCLIPlugin,
 			Usage:   FlagDockerCLIPluginUsage,
 			EnvVars: []string{"DSLIM_INSTALL_DOCKER_CLI_PLUGIN"},
 		},
+		&cli.BoolFlag{
+			Name:    FlagSystemd,
+			Usage:   FlagSystemdUsage,
+			EnvVars: []string{"DSLIM_INSTALL_SYSTEMD"},
+		},
+		&cli.StringFlag{
+			Name:    FlagSystemdListen,
+			Usage:   FlagSystemdListenUsage,
+			EnvVars: []string{"DSLIM_INSTALL_SYSTEMD_LISTEN"},
+		},
+		&cli.StringFlag{
+			Name:    FlagSystemdUser,
+			Usage:   FlagSystemdUserUsage,
+			EnvVars: []string{"DSLIM_INSTALL_SYSTEMD_USER"},
+		},
 	},
 	Action: func(ctx *cli.Context) error {
 		doDebug := ctx.Bool(commands.FlagDebug)
@@ -43,9 +83,19 @@ var CLI = &cli.Command{
 		archiveState := commands.ArchiveState(ctx.String(commands.FlagArchiveState), inContainer)
 
 		binDir := ctx.Bool(FlagBinDir)
+		targetDir := ctx.String(FlagTargetDir)
+		userInstall := ctx.Bool(FlagUser)
 		dockerCLIPlugin := ctx.Bool(FlagDockerCLIPlugin)
 
-		OnCommand(doDebug, statePath, archiveState, inContainer, isDSImage, binDir, dockerCLIPlugin)
+		var systemdParams *SystemdParams
+		if ctx.Bool(FlagSystemd) {
+			systemdParams = &SystemdParams{
+				Listen: ctx.String(FlagSystemdListen),
+				User:   ctx.String(FlagSystemdUser),
+			}
+		}
+
+		OnCommand(doDebug, statePath, archiveState, inContainer, isDSImage, binDir, targetDir, userInstall, dockerCLIPlugin, systemdParams)
 		return nil
 	},
 }
@@ -2,8 +2,11 @@ package install
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/docker-slim/go-update"
 	log "github.com/sirupsen/logrus"
@@ -14,10 +17,13 @@ import (
 )
 
 const (
-	dockerCLIPluginDirSuffx = "/.docker/cli-plugins"
+	dockerCLIPluginDirSuffx = ".docker/cli-plugins"
 	masterAppName           = "docker-slim"
 	sensorAppName           = "docker-slim-sensor"
 	binDirName              = "/usr/local/bin"
+	userBinDirSuffix        = ".local/bin"
+	dirPerms                = 0755
+	windowsExeExt           = ".exe"
 )
 
 // OnCommand implements the 'install' docker-slim command
@@ -28,25 +34,51 @@ func OnCommand(
 	inContainer bool,
 	isDSImage bool,
 	binDir bool,
-	dockerCLIPlugin bool) {
+	targetDir string,
+	userInstall bool,
+	dockerCLIPlugin bool,
+	systemdParams *SystemdParams) {
 	logger := log.WithFields(log.Fields{"app": "docker-slim", "command": "install"})
 
 	appPath, err := os.Executable()
 	errutil.FailOn(err)
 	appDirPath := filepath.Dir(appPath)
 
-	if binDir {
-		err := installToBinDir(logger, statePath, inContainer, isDSImage, appDirPath)
+	installDir, err := resolveInstallDir(binDir, targetDir, userInstall)
+	if err != nil {
+		fmt.Printf("docker-slim[install]: info=status message='error resolving install dir - %v'\n", err)
+		fmt.Printf("docker-slim[install]: state=exited version=%s\n", vinfo.Current())
+		return
+	}
+
+	if installDir != "" {
+		if err := ensureWritableDir(installDir, installDir != binDirName); err != nil {
+			logger.Debugf("install dir is not writable (%s): %v", installDir, err)
+			fmt.Printf("docker-slim[install]: info=status message='install dir is not writable' dir='%s' error='%v'\n", installDir, err)
+			fmt.Printf("docker-slim[install]: info=hint message='run the command with sudo, use --%s to install to ~/%s or use --%s to select a writable directory'\n",
+				FlagUser, userBinDirSuffix, FlagTargetDir)
+			fmt.Printf("docker-slim[install]: state=exited version=%s\n", vinfo.Current())
+			return
+		}
+
+		err := installToBinDir(logger, statePath, inContainer, isDSImage, appDirPath, installDir)
 		if err != nil {
 			fmt.Printf("docker-slim[install]: info=status message='error installing to bin dir'\n")
 			fmt.Printf("docker-slim[install]: state=exited version=%s\n", vinfo.Current())
 			return
 		}
 
-		fmt.Printf("docker-slim[install]: state=bin.dir.installed\n")
+		fmt.Printf("docker-slim[install]: state=bin.dir.installed dir='%s'\n", installDir)
+		if !inPath(installDir) {
+			if runtime.GOOS == "windows" {
+				fmt.Printf("docker-slim[install]: info=hint message='install dir is not in PATH; add it to your user PATH: setx PATH \"%%PATH%%;%s\"'\n", installDir)
+			} else {
+				fmt.Printf("docker-slim[install]: info=hint message='install dir is not in PATH; add it to your shell profile: export PATH=\"%s:$PATH\"'\n", installDir)
+			}
+		}
 
 		//use the path from the bin dir, so installing docker CLI plugin symlinks to the right binaries
-		appDirPath = binDirName
+		appDirPath = installDir
 	}
 
 	if dockerCLIPlugin {
@@ -60,10 +92,94 @@ func OnCommand(
 
 		fmt.Printf("docker-slim[install]: state=docker.cli.plugin.installed\n")
 	}
+
+	if systemdParams != nil {
+		//the service runs the installed binary (or the current binary if it's not installed)
+		serviceBinPath := appPath
+		if installDir != "" {
+			serviceBinPath = filepath.Join(installDir, masterAppFileName())
+		}
+
+		err := installSystemdService(logger, serviceBinPath, statePath, systemdParams)
+		if err != nil {
+			logger.Debugf("installSystemdService error: %v", err)
+			fmt.Printf("docker-slim[install]: info=status message='error installing systemd service'\n")
+			fmt.Printf("docker-slim[install]: state=exited version=%s\n", vinfo.Current())
+			return
+		}
+
+		fmt.Printf("docker-slim[install]: state=systemd.service.installed\n")
+	}
+}
+
+// resolveInstallDir returns the directory where to install the binaries
+// (empty if the binaries are not installed; the target dir takes precedence over the user and the standard bin dirs)
+func resolveInstallDir(binDir bool, targetDir string, userInstall bool) (string, error) {
+	switch {
+	case targetDir != "":
+		if strings.HasPrefix(targetDir, "~/") || strings.HasPrefix(targetDir, `~\`) {
+			hd, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+
+			targetDir = filepath.Join(hd, targetDir[2:])
+		}
+
+		return filepath.Abs(targetDir)
+	case userInstall:
+		hd, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(hd, filepath.FromSlash(userBinDirSuffix)), nil
+	case binDir:
+		if runtime.GOOS == "windows" {
+			return "", fmt.Errorf("no standard bin dir on Windows (use --%s or --%s)", FlagTargetDir, FlagUser)
+		}
+
+		return binDirName, nil
+	}
+
+	return "", nil
 }
 
-func installToBinDir(logger *log.Entry, statePath string, inContainer, isDSImage bool, appDirPath string) error {
-	if err := installRelease(logger, appDirPath, statePath, binDirName); err != nil {
+// ensureWritableDir checks if the binaries can be installed in the directory
+// (creating the directory if it doesn't exist and doCreate is true)
+func ensureWritableDir(dirPath string, doCreate bool) error {
+	if doCreate && !fsutil.Exists(dirPath) {
+		if err := os.MkdirAll(dirPath, dirPerms); err != nil {
+			return err
+		}
+	}
+
+	f, err := ioutil.TempFile(dirPath, ".docker-slim-install-")
+	if err != nil {
+		return err
+	}
+
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// inPath returns true if the directory is in the PATH environment variable
+func inPath(dirPath string) bool {
+	for _, pathDir := range filepath.SplitList(os.Getenv("PATH")) {
+		if pathDir == "" {
+			continue
+		}
+
+		if filepath.Clean(pathDir) == filepath.Clean(dirPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func installToBinDir(logger *log.Entry, statePath string, inContainer, isDSImage bool, appDirPath, installDir string) error {
+	if err := installRelease(logger, appDirPath, statePath, installDir); err != nil {
 		logger.Debugf("installToBinDir error: %v", err)
 		return err
 	}
@@ -71,21 +187,38 @@ func installToBinDir(logger *log.Entry, statePath string, inContainer, isDSImage
 	return nil
 }
 
+// masterAppFileName returns the master app file name for the current platform
+// (the sensor is always a Linux binary, so its file name doesn't change)
+func masterAppFileName() string {
+	if runtime.GOOS == "windows" {
+		return masterAppName + windowsExeExt
+	}
+
+	return masterAppName
+}
+
 func symlinkBinaries(logger *log.Entry, appRootPath, symlinkRootPath string) error {
-	symlinkMasterAppPath := filepath.Join(symlinkRootPath, masterAppName)
+	symlinkMasterAppPath := filepath.Join(symlinkRootPath, masterAppFileName())
 	symlinkSensorAppPath := filepath.Join(symlinkRootPath, sensorAppName)
 	targetSensorAppPath := filepath.Join(appRootPath, sensorAppName)
-	targetMasterAppPath := filepath.Join(appRootPath, masterAppName)
+	targetMasterAppPath := filepath.Join(appRootPath, masterAppFileName())
 
-	//todo:
-	//should not symlink the sensor because Docker CLI will treat it as an invalid plugin
-	//need to improve sensor bin discovery from master app symlink
-	err := os.Symlink(targetSensorAppPath, symlinkSensorAppPath)
-	if err != nil {
-		return err
+	//not symlinking the sensor because Docker CLI will treat it as an invalid plugin
+	//(the sensor is discovered using the real master app location, not the symlink location)
+	if runtime.GOOS == "windows" {
+		//the master app is copied on Windows, so the sensor needs to be copied too
+		//(Docker CLI ignores it there because it doesn't have the '.exe' extension)
+		if err := linkBinary(targetSensorAppPath, symlinkSensorAppPath); err != nil {
+			return err
+		}
+	} else if fsutil.IsSymlink(symlinkSensorAppPath) {
+		//remove the sensor symlink created by the older versions
+		if err := os.Remove(symlinkSensorAppPath); err != nil {
+			logger.Debugf("symlinkBinaries: error removing old sensor symlink (%s) - %v", symlinkSensorAppPath, err)
+		}
 	}
 
-	err = os.Symlink(targetMasterAppPath, symlinkMasterAppPath)
+	err := linkBinary(targetMasterAppPath, symlinkMasterAppPath)
 	if err != nil {
 		return err
 	}
@@ -93,9 +226,27 @@ func symlinkBinaries(logger *log.Entry, appRootPath, symlinkRootPath string) err
 	return nil
 }
 
+// linkBinary creates a symlink to the binary
+// (on Windows the binary is copied because creating symlinks needs extra privileges there)
+func linkBinary(targetPath, linkPath string) error {
+	//replacing the symlink (or the copied binary) from the previous install
+	if fsutil.IsSymlink(linkPath) || (runtime.GOOS == "windows" && fsutil.Exists(linkPath)) {
+		if err := os.Remove(linkPath); err != nil {
+			return err
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return fsutil.CopyRegularFile(false, targetPath, linkPath, false)
+	}
+
+	return os.Symlink(targetPath, linkPath)
+}
+
 func installDockerCLIPlugin(logger *log.Entry, statePath string, inContainer, isDSImage bool, appDirPath string) error {
+	//os.UserHomeDir uses %USERPROFILE% on Windows
 	hd, _ := os.UserHomeDir()
-	dockerCLIPluginDir := filepath.Join(hd, dockerCLIPluginDirSuffx)
+	dockerCLIPluginDir := filepath.Join(hd, filepath.FromSlash(dockerCLIPluginDirSuffx))
 
 	if !fsutil.Exists(dockerCLIPluginDir) {
 		var dirMode os.FileMode = 0755
@@ -114,10 +265,10 @@ func installDockerCLIPlugin(logger *log.Entry, statePath string, inContainer, is
 }
 
 func installRelease(logger *log.Entry, appRootPath, statePath, targetRootPath string) error {
-	targetMasterAppPath := filepath.Join(targetRootPath, masterAppName)
+	targetMasterAppPath := filepath.Join(targetRootPath, masterAppFileName())
 	targetSensorAppPath := filepath.Join(targetRootPath, sensorAppName)
 	srcSensorAppPath := filepath.Join(appRootPath, sensorAppName)
-	srcMasterAppPath := filepath.Join(appRootPath, masterAppName)
+	srcMasterAppPath := filepath.Join(appRootPath, masterAppFileName())
 
 	err := updateFile(logger, srcSensorAppPath, targetSensorAppPath)
 	if err != nil {
@@ -13,9 +13,19 @@ var CommandSuggestion = prompt.Suggest{
 
 var CommandFlagSuggestions = &commands.FlagSuggestions{
 	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(FlagBinDir), Description: FlagBinDirUsage},
+		{Text: commands.FullFlagName(FlagTargetDir), Description: FlagTargetDirUsage},
+		{Text: commands.FullFlagName(FlagUser), Description: FlagUserUsage},
 		{Text: commands.FullFlagName(FlagDockerCLIPlugin), Description: FlagDockerCLIPluginUsage},
+		{Text: commands.FullFlagName(FlagSystemd), Description: FlagSystemdUsage},
+		{Text: commands.FullFlagName(FlagSystemdListen), Description: FlagSystemdListenUsage},
+		{Text: commands.FullFlagName(FlagSystemdUser), Description: FlagSystemdUserUsage},
 	},
 	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(FlagBinDir):          commands.CompleteBool,
+		commands.FullFlagName(FlagTargetDir):       commands.CompleteFile,
+		commands.FullFlagName(FlagUser):            commands.CompleteBool,
 		commands.FullFlagName(FlagDockerCLIPlugin): commands.CompleteBool,
+		commands.FullFlagName(FlagSystemd):         commands.CompleteBool,
 	},
 }
@@ -0,0 +1,173 @@
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	systemdUnitDir       = "/etc/systemd/system"
+	systemdUnitName      = "docker-slim-server.service"
+	systemdUnitPerms     = 0644
+	systemdRestartPolicy = "on-failure"
+	systemctlCmd         = "systemctl"
+	defaultServerListen  = "127.0.0.1:7777"
+	//the exit code when the server is stopped (app.ExitCodeInterrupted)
+	interruptedExitCode = 130
+)
+
+// SystemdParams are the systemd service parameters
+type SystemdParams struct {
+	//server listen address
+	Listen string
+	//user to run the server (the current user by default)
+	User string
+}
+
+type systemdUnitInfo struct {
+	BinPath             string
+	StatePath           string
+	Listen              string
+	User                string
+	Restart             string
+	InterruptedExitCode int
+}
+
+var systemdUnitTemplate = template.Must(template.New("unit").Parse(`[Unit]
+Description=DockerSlim API server
+Documentation=https://github.com/docker-slim/docker-slim
+After=network-online.target docker.service
+Wants=network-online.target
+
+[Service]
+Type=simple
+User={{.User}}
+ExecStart={{.BinPath}}{{if .StatePath}} --state-path {{.StatePath}}{{end}} server --listen {{.Listen}}
+Restart={{.Restart}}
+RestartSec=5
+#the server exits with the 'interrupted' exit code when it's stopped
+SuccessExitStatus={{.InterruptedExitCode}}
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// renderSystemdUnit returns the systemd unit file data for the 'server' command
+func renderSystemdUnit(binPath, statePath string, params *SystemdParams) ([]byte, error) {
+	info := systemdUnitInfo{
+		BinPath:             binPath,
+		StatePath:           statePath,
+		Listen:              params.Listen,
+		User:                params.User,
+		Restart:             systemdRestartPolicy,
+		InterruptedExitCode: interruptedExitCode,
+	}
+
+	var buf bytes.Buffer
+	if err := systemdUnitTemplate.Execute(&buf, &info); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// systemdServiceUser returns the user to run the server
+// (the user who ran sudo if the install command is executed with sudo)
+func systemdServiceUser(name string) (string, error) {
+	if name != "" {
+		if _, err := user.Lookup(name); err != nil {
+			return "", err
+		}
+
+		return name, nil
+	}
+
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser, nil
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	return current.Username, nil
+}
+
+// installSystemdService writes the systemd unit for the 'server' command and enables the service
+func installSystemdService(logger *log.Entry, binPath, statePath string, params *SystemdParams) error {
+	if runtime.GOOS != "linux" {
+		fmt.Printf("docker-slim[install]: info=status message='systemd services are supported only on Linux'\n")
+		return fmt.Errorf("unsupported platform - %s", runtime.GOOS)
+	}
+
+	if params.Listen == "" {
+		params.Listen = defaultServerListen
+	}
+
+	serviceUser, err := systemdServiceUser(params.User)
+	if err != nil {
+		fmt.Printf("docker-slim[install]: info=status message='unknown service user' user='%s'\n", params.User)
+		return err
+	}
+	params.User = serviceUser
+
+	if statePath != "" {
+		if statePath, err = filepath.Abs(statePath); err != nil {
+			return err
+		}
+	}
+
+	data, err := renderSystemdUnit(binPath, statePath, params)
+	if err != nil {
+		return err
+	}
+
+	unitPath := filepath.Join(systemdUnitDir, systemdUnitName)
+	if err := ensureWritableDir(systemdUnitDir, false); err != nil {
+		logger.Debugf("installSystemdService: unit dir is not writable - %v", err)
+		fmt.Printf("docker-slim[install]: info=status message='systemd unit dir is not writable' dir='%s'\n", systemdUnitDir)
+		fmt.Printf("docker-slim[install]: info=hint message='run the command with sudo'\n")
+		return err
+	}
+
+	if fsutil.Exists(unitPath) {
+		logger.Debugf("installSystemdService: replacing the existing unit - %s", unitPath)
+	}
+
+	if err := ioutil.WriteFile(unitPath, data, systemdUnitPerms); err != nil {
+		return err
+	}
+
+	fmt.Printf("docker-slim[install]: state=systemd.unit.saved file='%s' user='%s' listen='%s'\n", unitPath, params.User, params.Listen)
+
+	if _, err := exec.LookPath(systemctlCmd); err != nil {
+		fmt.Printf("docker-slim[install]: info=hint message='systemctl not found; enable the service manually: systemctl enable --now %s'\n", systemdUnitName)
+		return nil
+	}
+
+	for _, args := range [][]string{
+		{"daemon-reload"},
+		{"enable", "--now", systemdUnitName},
+	} {
+		if output, err := exec.Command(systemctlCmd, args...).CombinedOutput(); err != nil {
+			logger.Debugf("installSystemdService: systemctl %v error - %v (%s)", args, err, string(output))
+			fmt.Printf("docker-slim[install]: info=status message='systemctl error' args='%v' output='%s'\n", args, bytes.TrimSpace(output))
+			return err
+		}
+	}
+
+	fmt.Printf("docker-slim[install]: info=hint message='the service user needs access to the Docker daemon (e.g., the docker group)'\n")
+	return nil
+}
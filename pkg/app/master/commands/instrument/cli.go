@@ -0,0 +1,103 @@
+package instrument
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+)
+
+//Instrument the target image (embed the sensor, so the data can be collected in production and imported by the build command)
+
+const (
+	Name  = "instrument"
+	Usage = "Build a variant of the target image with the auto-starting sensor (collect the sensor data in canary deployments and import it with 'build --import-sensor-data')"
+	Alias = "ins"
+)
+
+type CommandParams struct {
+	TargetRef        string
+	Tag              string
+	DoPull           bool
+	DockerConfigPath string
+	RegistryAccount  string
+	RegistrySecret   string
+	DoShowPullLogs   bool
+	RunTargetAsUser  bool
+	ExcludePatterns  []string
+	IncludePaths     []string
+	IncludeBins      []string
+	DoIncludeShell   bool
+	SensorLogLevel   string
+	ShowBuildLogs    bool
+}
+
+var CLI = &cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Flags: []cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		commands.Cflag(commands.FlagPull),
+		commands.Cflag(commands.FlagDockerConfigPath),
+		commands.Cflag(commands.FlagRegistryAccount),
+		commands.Cflag(commands.FlagRegistrySecret),
+		commands.Cflag(commands.FlagShowPullLogs),
+		commands.Cflag(commands.FlagRunTargetAsUser),
+		commands.Cflag(commands.FlagExcludePattern),
+		cflag(FlagTag),
+		cflag(FlagIncludePath),
+		cflag(FlagIncludeBin),
+		cflag(FlagIncludeShell),
+		cflag(FlagSensorLogLevel),
+		cflag(FlagShowBuildLogs),
+	},
+	Action: func(ctx *cli.Context) error {
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		targetRef := ctx.String(commands.FlagTarget)
+		if targetRef == "" {
+			if ctx.Args().Len() < 1 {
+				xc.Out.Error("param.target", "missing image ID/name")
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			}
+
+			targetRef = ctx.Args().First()
+		}
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			xc.Out.Error("param.global", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		cparams := &CommandParams{
+			TargetRef:        targetRef,
+			Tag:              ctx.String(FlagTag),
+			DoPull:           ctx.Bool(commands.FlagPull),
+			DockerConfigPath: ctx.String(commands.FlagDockerConfigPath),
+			RegistryAccount:  ctx.String(commands.FlagRegistryAccount),
+			RegistrySecret:   ctx.String(commands.FlagRegistrySecret),
+			DoShowPullLogs:   ctx.Bool(commands.FlagShowPullLogs),
+			RunTargetAsUser:  ctx.Bool(commands.FlagRunTargetAsUser),
+			ExcludePatterns:  ctx.StringSlice(commands.FlagExcludePattern),
+			IncludePaths:     ctx.StringSlice(FlagIncludePath),
+			IncludeBins:      ctx.StringSlice(FlagIncludeBin),
+			DoIncludeShell:   ctx.Bool(FlagIncludeShell),
+			SensorLogLevel:   ctx.String(FlagSensorLogLevel),
+			ShowBuildLogs:    ctx.Bool(FlagShowBuildLogs),
+		}
+
+		OnCommand(
+			xc,
+			gcvalues,
+			cparams)
+
+		return nil
+	},
+}
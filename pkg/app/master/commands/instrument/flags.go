@@ -0,0 +1,72 @@
+package instrument
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Instrument command flag names
+const (
+	FlagTag            = "tag"
+	FlagIncludePath    = "include-path"
+	FlagIncludeBin     = "include-bin"
+	FlagIncludeShell   = "include-shell"
+	FlagSensorLogLevel = "sensor-log-level"
+	FlagShowBuildLogs  = "show-blogs"
+)
+
+// Instrument command flag usage info
+const (
+	FlagTagUsage            = "Tag for the instrumented image (default: <target image repo>.instrumented:<target image tag>)"
+	FlagIncludePathUsage    = "Include directory or file from the target image in the collected sensor data"
+	FlagIncludeBinUsage     = "Include binary from the target image (with its shared libraries) in the collected sensor data"
+	FlagIncludeShellUsage   = "Include basic shell functionality in the collected sensor data"
+	FlagSensorLogLevelUsage = "Log level for the sensor embedded in the instrumented image ('debug', 'info', 'warn' or 'error')"
+	FlagShowBuildLogsUsage  = "Show instrumented image build logs"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagTag: &cli.StringFlag{
+		Name:    FlagTag,
+		Value:   "",
+		Usage:   FlagTagUsage,
+		EnvVars: []string{"DSLIM_INSTRUMENT_TAG"},
+	},
+	FlagIncludePath: &cli.StringSliceFlag{
+		Name:    FlagIncludePath,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagIncludePathUsage,
+		EnvVars: []string{"DSLIM_INSTRUMENT_INCLUDE_PATH"},
+	},
+	FlagIncludeBin: &cli.StringSliceFlag{
+		Name:    FlagIncludeBin,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagIncludeBinUsage,
+		EnvVars: []string{"DSLIM_INSTRUMENT_INCLUDE_BIN"},
+	},
+	FlagIncludeShell: &cli.BoolFlag{
+		Name:    FlagIncludeShell,
+		Usage:   FlagIncludeShellUsage,
+		EnvVars: []string{"DSLIM_INSTRUMENT_INCLUDE_SHELL"},
+	},
+	FlagSensorLogLevel: &cli.StringFlag{
+		Name:    FlagSensorLogLevel,
+		Value:   "info",
+		Usage:   FlagSensorLogLevelUsage,
+		EnvVars: []string{"DSLIM_INSTRUMENT_SENSOR_LOG_LEVEL"},
+	},
+	FlagShowBuildLogs: &cli.BoolFlag{
+		Name:    FlagShowBuildLogs,
+		Usage:   FlagShowBuildLogsUsage,
+		EnvVars: []string{"DSLIM_INSTRUMENT_SHOW_BLOGS"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -0,0 +1,444 @@
+package instrument
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/container"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/sensor"
+	"github.com/docker-slim/docker-slim/pkg/app/master/version"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	ipccommand "github.com/docker-slim/docker-slim/pkg/ipc/command"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// Instrument command exit codes
+const (
+	eciOther = iota + 1
+	eciImageNotFound
+	eciNoEntrypoint
+	eciImageBuildError
+)
+
+// exitCodes documents the instrument command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTInstrument | eciOther, Name: "instrument.other", Description: "other instrument command error"},
+	{Code: commands.ECTInstrument | eciImageNotFound, Name: "instrument.image.not.found", Description: "target image not found"},
+	{Code: commands.ECTInstrument | eciNoEntrypoint, Name: "instrument.no.entrypoint", Description: "target image has no entrypoint or cmd"},
+	{Code: commands.ECTInstrument | eciImageBuildError, Name: "instrument.image.build.error", Description: "error building the instrumented image"},
+}
+
+const (
+	instrumentDirName = "instrument"
+	imageNameSuffix   = ".instrumented"
+	sensorFileName    = "docker-slim-sensor"
+	commandFileName   = "commands.json"
+	//the 'start monitor' command used by the sensor in the standalone mode
+	commandFilePath = "/opt/dockerslim/commands.json"
+	//the target image used to build the instrumented image
+	targetLabelName = "docker-slim.instrumented.target"
+)
+
+// RequiredCapabilities are the capabilities the sensor needs in the instrumented containers (fanotify and ptrace)
+var RequiredCapabilities = []string{"SYS_ADMIN", "SYS_PTRACE"}
+
+type dockerfileInfo struct {
+	BaseImage   string
+	SensorFile  string
+	SensorPath  string
+	CommandFile string
+	CommandPath string
+	Label       string
+	Target      string
+	Entrypoint  string
+	Cmd         string
+}
+
+// the sensor runs as root (it starts the app as the original image user)
+var dockerfileTemplate = template.Must(template.New("dockerfile").Parse(`FROM {{.BaseImage}}
+COPY {{.SensorFile}} {{.SensorPath}}
+COPY {{.CommandFile}} {{.CommandPath}}
+USER root
+LABEL {{.Label}}="{{.Target}}"
+ENTRYPOINT {{.Entrypoint}}
+{{- if .Cmd}}
+CMD {{.Cmd}}
+{{- end}}
+`))
+
+// OnCommand implements the 'instrument' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	const cmdName = Name
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+	prefix := fmt.Sprintf("cmd=%s", cmdName)
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewInstrumentCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.TargetRef
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"target":        cparams.TargetRef,
+			"tag":           cparams.Tag,
+			"include.paths": strings.Join(cparams.IncludePaths, ","),
+			"include.bins":  strings.Join(cparams.IncludeBins, ","),
+			"include.shell": cparams.DoIncludeShell,
+		})
+
+	exitWithError := func(exitCode int, status string) {
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = status
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err == dockerclient.ErrNoDockerInfo {
+		exitMsg := "missing Docker connection info"
+		if gparams.InContainer && gparams.IsDSImage {
+			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+		}
+
+		xc.Out.Info("docker.connect.error",
+			ovars{
+				"message": exitMsg,
+			})
+
+		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+		xc.Exit(exitCode)
+	}
+	xc.FailOn(err)
+
+	if gparams.Debug {
+		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	}
+
+	imageInspector, err := image.NewInspector(client, cparams.TargetRef)
+	xc.FailOn(err)
+
+	if imageInspector.NoImage() {
+		if !cparams.DoPull {
+			xc.Out.Error("image.not.found", "make sure the target image already exists locally (use --pull flag to auto-download it from registry)")
+			exitWithError(commands.ECTInstrument|eciImageNotFound, "image.not.found")
+		}
+
+		xc.Out.Info("target.image",
+			ovars{
+				"status":  "not.found",
+				"image":   cparams.TargetRef,
+				"message": "trying to pull target image",
+			})
+
+		err := imageInspector.Pull(xc.Context, cparams.DoShowPullLogs, cparams.DockerConfigPath, cparams.RegistryAccount, cparams.RegistrySecret)
+		xc.FailOn(commands.ImagePullError(err))
+	}
+
+	err = imageInspector.Inspect()
+	xc.FailOn(err)
+
+	targetRef := imageInspector.ImageRef
+	cmdReport.TargetReference = targetRef
+
+	var entrypoint, cmd []string
+	var appUser string
+	if imageConfig := imageInspector.ImageInfo.Config; imageConfig != nil {
+		entrypoint = imageConfig.Entrypoint
+		cmd = imageConfig.Cmd
+		appUser = imageConfig.User
+	}
+
+	if len(entrypoint) == 0 && len(cmd) == 0 {
+		xc.Out.Info("target.image.error",
+			ovars{
+				"status":  "no.entrypoint",
+				"image":   targetRef,
+				"message": "the instrumented image needs the target image entrypoint or cmd to start the app",
+			})
+
+		exitWithError(commands.ECTInstrument|eciNoEntrypoint, "no.entrypoint")
+	}
+
+	cmdReport.AppEntrypoint = entrypoint
+	cmdReport.AppCmd = cmd
+	cmdReport.AppUser = appUser
+	cmdReport.ArtifactsPath = container.ArtifactsVolumePath
+
+	_, artifactLocation, statePath, _, err := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+	xc.FailOn(commands.StateDirError(err))
+	sensorPath, err := sensor.EnsureLocalBinary(xc, logger, statePath, true)
+	xc.FailOn(err)
+
+	sensorInfo := sensor.LocalBinVersion(statePath)
+	if sensorInfo.BuildInfo != nil {
+		cmdReport.SensorVersion = sensorInfo.Version
+
+		//the sensor binary is added to the target image, so it needs to be built for the image architecture
+		arch := imageInspector.ImageInfo.Architecture
+		if arch != "" && sensorInfo.Platform != "" && !strings.HasSuffix(sensorInfo.Platform, "/"+arch) {
+			xc.Out.Info("sensor.platform",
+				ovars{
+					"sensor.platform": sensorInfo.Platform,
+					"image.arch":      arch,
+					"message":         "the sensor platform doesn't match the target image architecture",
+				})
+		}
+	}
+
+	imageTag := cparams.Tag
+	if imageTag == "" {
+		imageTag = instrumentedImageName(targetRef)
+	}
+
+	cmdReport.ImageTag = imageTag
+
+	monitorCmd := &ipccommand.StartMonitor{
+		ProtocolVersion: ipccommand.ProtocolVersion,
+		RTASourcePT:     true,
+		KeepPerms:       true,
+		IncludeShell:    cparams.DoIncludeShell,
+		IncludeCertAll:  true,
+	}
+
+	if len(cparams.ExcludePatterns) > 0 {
+		monitorCmd.Excludes = cparams.ExcludePatterns
+	}
+
+	if includePaths := commands.ParsePaths(cparams.IncludePaths); len(includePaths) > 0 {
+		monitorCmd.Includes = includePaths
+	}
+
+	if len(cparams.IncludeBins) > 0 {
+		monitorCmd.IncludeBins = cparams.IncludeBins
+	}
+
+	if appUser != "" {
+		monitorCmd.AppUser = appUser
+
+		if strings.ToLower(appUser) != "root" {
+			monitorCmd.RunTargetAsUser = cparams.RunTargetAsUser
+		}
+	}
+
+	xc.Out.State("image.build.started",
+		ovars{
+			"tag": imageTag,
+		})
+
+	workDir := filepath.Join(artifactLocation, instrumentDirName)
+	defer os.RemoveAll(workDir)
+
+	var buildLog bytes.Buffer
+	imageInfo, err := createInstrumentedImage(
+		xc,
+		client,
+		targetRef,
+		imageTag,
+		sensorPath,
+		cparams.SensorLogLevel,
+		monitorCmd,
+		entrypoint,
+		cmd,
+		workDir,
+		&buildLog)
+
+	if cparams.ShowBuildLogs || err != nil {
+		xc.Out.LogDump("instrumented.image.build", buildLog.String(),
+			ovars{
+				"tag": imageTag,
+			})
+	}
+
+	if err != nil {
+		cmdReport.Error = "image.build.error"
+		cmdReport.Save()
+
+		xc.Fail(&app.Error{
+			Category: app.ErrorCategoryImage,
+			Type:     "instrumented.image.build.error",
+			Message:  err.Error(),
+			Hint:     "check the build logs shown above",
+			ExitCode: commands.ECTInstrument | eciImageBuildError,
+			Err:      err,
+		})
+	}
+
+	cmdReport.ImageID = imageInfo.ID
+	cmdReport.ImageSize = imageInfo.Size
+
+	xc.Out.State("image.build.completed",
+		ovars{
+			"tag":        imageTag,
+			"id":         imageInfo.ID,
+			"size.human": humanize.Bytes(uint64(imageInfo.Size)),
+		})
+
+	xc.Out.Info("instrumented.image",
+		ovars{
+			"run":       fmt.Sprintf("--cap-add %s", strings.Join(RequiredCapabilities, " --cap-add ")),
+			"artifacts": container.ArtifactsVolumePath,
+			"message":   "the sensor data is saved when the app exits or when the container is stopped",
+		})
+
+	xc.Out.Info("instrumented.image.import",
+		ovars{
+			"copy":   fmt.Sprintf("docker cp <container>:%s - > sensor-data.tar", container.ArtifactsVolumePath),
+			"import": fmt.Sprintf("%s build --target %s --import-sensor-data sensor-data.tar", commands.CLIName, targetRef),
+		})
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+	xc.Out.State("done")
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+}
+
+// instrumentedImageName returns the instrumented image name for the target image
+// (<repo>.instrumented:<tag> - the same naming scheme as the one used for the fat images)
+func instrumentedImageName(targetRef string) string {
+	name := targetRef
+	if idx := strings.LastIndex(name, "@"); idx >= 0 {
+		//digest references
+		name = name[:idx]
+	}
+
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		return name[:idx] + imageNameSuffix + name[idx:]
+	}
+
+	return name + imageNameSuffix
+}
+
+func createInstrumentedImage(
+	xc *app.ExecutionContext,
+	client *dockerapi.Client,
+	baseImage string,
+	imageTag string,
+	sensorPath string,
+	sensorLogLevel string,
+	monitorCmd *ipccommand.StartMonitor,
+	entrypoint []string,
+	cmd []string,
+	workDir string,
+	buildLog *bytes.Buffer) (*dockerapi.Image, error) {
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := fsutil.CopyRegularFile(false, sensorPath, filepath.Join(workDir, sensorFileName), false); err != nil {
+		return nil, err
+	}
+
+	cmdData, err := json.Marshal(monitorCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(workDir, commandFileName), cmdData, 0644); err != nil {
+		return nil, err
+	}
+
+	//the sensor starts the original entrypoint (and cmd) passed after '--'
+	sensorEntrypoint := []string{
+		container.SensorBinPath,
+		"-standalone",
+		"-command-file", commandFilePath,
+		"-log-level", sensorLogLevel,
+		"--",
+	}
+
+	info := dockerfileInfo{
+		BaseImage:   baseImage,
+		SensorFile:  sensorFileName,
+		SensorPath:  container.SensorBinPath,
+		CommandFile: commandFileName,
+		CommandPath: commandFilePath,
+		Label:       targetLabelName,
+		Target:      baseImage,
+	}
+
+	entrypointData, err := json.Marshal(append(sensorEntrypoint, entrypoint...))
+	if err != nil {
+		return nil, err
+	}
+
+	info.Entrypoint = string(entrypointData)
+
+	//ENTRYPOINT resets the cmd inherited from the base image
+	if len(cmd) > 0 {
+		cmdData, err := json.Marshal(cmd)
+		if err != nil {
+			return nil, err
+		}
+
+		info.Cmd = string(cmdData)
+	}
+
+	var dockerfile bytes.Buffer
+	if err := dockerfileTemplate.Execute(&dockerfile, &info); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(workDir, "Dockerfile"), dockerfile.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+
+	err = client.BuildImage(dockerapi.BuildImageOptions{
+		Context:             xc.Context,
+		Name:                imageTag,
+		ContextDir:          workDir,
+		Dockerfile:          "Dockerfile",
+		RmTmpContainer:      true,
+		ForceRmTmpContainer: true,
+		OutputStream:        buildLog,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("instrumented image build error - %w", err)
+	}
+
+	return client.InspectImage(imageTag)
+}
@@ -0,0 +1,9 @@
+package init
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/instrument"
+)
+
+func init() {
+	instrument.RegisterCommand()
+}
@@ -0,0 +1,52 @@
+package instrument
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/c-bata/go-prompt"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(commands.FlagPull), Description: commands.FlagPullUsage},
+		{Text: commands.FullFlagName(commands.FlagShowPullLogs), Description: commands.FlagShowPullLogsUsage},
+		{Text: commands.FullFlagName(commands.FlagRegistryAccount), Description: commands.FlagRegistryAccountUsage},
+		{Text: commands.FullFlagName(commands.FlagRegistrySecret), Description: commands.FlagRegistrySecretUsage},
+		{Text: commands.FullFlagName(commands.FlagDockerConfigPath), Description: commands.FlagDockerConfigPathUsage},
+		{Text: commands.FullFlagName(commands.FlagRunTargetAsUser), Description: commands.FlagRunTargetAsUserUsage},
+		{Text: commands.FullFlagName(commands.FlagExcludePattern), Description: commands.FlagExcludePatternUsage},
+		{Text: commands.FullFlagName(FlagTag), Description: FlagTagUsage},
+		{Text: commands.FullFlagName(FlagIncludePath), Description: FlagIncludePathUsage},
+		{Text: commands.FullFlagName(FlagIncludeBin), Description: FlagIncludeBinUsage},
+		{Text: commands.FullFlagName(FlagIncludeShell), Description: FlagIncludeShellUsage},
+		{Text: commands.FullFlagName(FlagSensorLogLevel), Description: FlagSensorLogLevelUsage},
+		{Text: commands.FullFlagName(FlagShowBuildLogs), Description: FlagShowBuildLogsUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget):           commands.CompleteTarget,
+		commands.FullFlagName(commands.FlagPull):             commands.CompleteBool,
+		commands.FullFlagName(commands.FlagShowPullLogs):     commands.CompleteBool,
+		commands.FullFlagName(commands.FlagDockerConfigPath): commands.CompleteFile,
+		commands.FullFlagName(commands.FlagRunTargetAsUser):  commands.CompleteTBool,
+		commands.FullFlagName(FlagIncludeShell):              commands.CompleteBool,
+		commands.FullFlagName(FlagSensorLogLevel):            completeSensorLogLevel,
+		commands.FullFlagName(FlagShowBuildLogs):             commands.CompleteBool,
+	},
+}
+
+var sensorLogLevelValues = []prompt.Suggest{
+	{Text: "debug", Description: "Debug logs"},
+	{Text: "info", Description: "Info logs (default)"},
+	{Text: "warn", Description: "Warning logs"},
+	{Text: "error", Description: "Error logs"},
+}
+
+func completeSensorLogLevel(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(sensorLogLevelValues, token, true)
+}
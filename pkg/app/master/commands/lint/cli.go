@@ -1,10 +1,13 @@
 package lint
 
 import (
+	"fmt"
+
 	"github.com/urfave/cli/v2"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
 )
 
 const (
@@ -32,6 +35,16 @@ var CLI = &cli.Command{
 		cflag(FlagShowNoHits),
 		cflag(FlagShowSnippet),
 		cflag(FlagListChecks),
+		cflag(FlagSARIF),
+		cflag(FlagRulesFile),
+		cflag(FlagBaseline),
+		cflag(FlagUpdateBaseline),
+		cflag(FlagSkipInlineIgnores),
+		cflag(FlagIncludeDockerfile),
+		cflag(FlagExcludeDockerfile),
+		cflag(FlagFailLevel),
+		cflag(FlagRuntimeReport),
+		cflag(FlagJUnit),
 	},
 	Action: func(ctx *cli.Context) error {
 		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
@@ -56,9 +69,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.global", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		targetType := ctx.String(FlagTargetType)
@@ -71,9 +84,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.invalid.include.check.labels", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		excludeCheckLabels, err := commands.ParseCheckTags(ctx.StringSlice(FlagExcludeCheckLabel))
@@ -81,9 +94,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.invalid.exclude.check.labels", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		includeCheckIDs, err := commands.ParseTokenSet(ctx.StringSlice(FlagIncludeCheckID))
@@ -91,9 +104,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.invalid.include.check.ids", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		includeCheckIDFile := ctx.String(FlagIncludeCheckIDFile)
@@ -102,9 +115,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.invalid.include.check.ids.from.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		for k, v := range moreIncludeCheckIDs {
@@ -116,9 +129,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.invalid.exclude.check.ids", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		excludeCheckIDFile := ctx.String(FlagExcludeCheckIDFile)
@@ -127,9 +140,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.invalid.exclude.check.ids.from.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		for k, v := range moreExcludeCheckIDs {
@@ -139,7 +152,28 @@ var CLI = &cli.Command{
 		doShowNoHits := ctx.Bool(FlagShowNoHits)
 		doShowSnippet := ctx.Bool(FlagShowSnippet)
 
-		OnCommand(
+		baselineFile := ctx.String(FlagBaseline)
+		doUpdateBaseline := ctx.Bool(FlagUpdateBaseline)
+		if doUpdateBaseline && baselineFile == "" {
+			xc.Out.Error("param.error.baseline", "missing lint baseline file")
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		failLevel := ctx.String(FlagFailLevel)
+		if failLevel != "" && check.LevelRank(failLevel) == 0 {
+			xc.Out.Error("param.error.fail.level", fmt.Sprintf("unknown lint level - %s", failLevel))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		xc.FailOn(OnCommand(
 			xc,
 			gcvalues,
 			targetRef,
@@ -153,7 +187,17 @@ var CLI = &cli.Command{
 			excludeCheckIDs,
 			doShowNoHits,
 			doShowSnippet,
-			doListChecks)
+			doListChecks,
+			ctx.String(FlagSARIF),
+			ctx.StringSlice(FlagRulesFile),
+			baselineFile,
+			doUpdateBaseline,
+			ctx.Bool(FlagSkipInlineIgnores),
+			ctx.StringSlice(FlagIncludeDockerfile),
+			ctx.StringSlice(FlagExcludeDockerfile),
+			failLevel,
+			ctx.String(FlagRuntimeReport),
+			ctx.String(FlagJUnit)))
 
 		return nil
 	},
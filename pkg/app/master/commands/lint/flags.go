@@ -22,12 +22,22 @@ const (
 	FlagShowNoHits         = "show-nohits"
 	FlagShowSnippet        = "show-snippet"
 	FlagListChecks         = "list-checks"
+	FlagSARIF              = "sarif"
+	FlagRulesFile          = "rules-file"
+	FlagBaseline           = "baseline"
+	FlagUpdateBaseline     = "update-baseline"
+	FlagSkipInlineIgnores  = "skip-inline-ignores"
+	FlagIncludeDockerfile  = "include-dockerfile"
+	FlagExcludeDockerfile  = "exclude-dockerfile"
+	FlagFailLevel          = "fail-level"
+	FlagRuntimeReport      = "runtime-report"
+	FlagJUnit              = "junit"
 )
 
 // Lint command flag usage info
 const (
 	FlagLintTargetUsage         = "Target Dockerfile path (or container image)"
-	FlagTargetTypeUsage         = "Explicitly specify the command target type (values: dockerfile, image)"
+	FlagTargetTypeUsage         = "Explicitly specify the command target type (values: dockerfile, image, directory)"
 	FlagSkipBuildContextUsage   = "Don't try to analyze build context"
 	FlagBuildContextDirUsage    = "Explicitly specify the build context directory"
 	FlagSkipDockerignoreUsage   = "Don't try to analyze .dockerignore"
@@ -40,6 +50,16 @@ const (
 	FlagShowNoHitsUsage         = "Show checks with no matches"
 	FlagShowSnippetUsage        = "Show check match snippet"
 	FlagListChecksUsage         = "List available checks"
+	FlagSARIFUsage              = "Save the lint results in the SARIF 2.1.0 format (file path)"
+	FlagRulesFileUsage          = "Load user-defined lint rules from the config file (YAML or JSON)"
+	FlagBaselineUsage           = "Lint baseline file with the known findings to suppress"
+	FlagUpdateBaselineUsage     = "Save the current findings in the lint baseline file"
+	FlagSkipInlineIgnoresUsage  = "Don't use the inline '# ds-lint ignore=...' Dockerfile comments"
+	FlagIncludeDockerfileUsage  = "Dockerfile glob pattern to include when the target is a directory"
+	FlagExcludeDockerfileUsage  = "Dockerfile (or directory) glob pattern to exclude when the target is a directory"
+	FlagFailLevelUsage          = "Exit with an error if there are check hits with this level or higher (values: fatal, error, warn, info, style)"
+	FlagRuntimeReportUsage      = "Use the runtime data from the build or profile command report (or the container report) for the runtime-informed checks"
+	FlagJUnitUsage              = "Save the lint results in the JUnit XML format (file path)"
 )
 
 var Flags = map[string]cli.Flag{
@@ -123,6 +143,62 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagListChecksUsage,
 		EnvVars: []string{"DSLIM_LINT_LIST_CHECKS"},
 	},
+	FlagSARIF: &cli.StringFlag{
+		Name:    FlagSARIF,
+		Usage:   FlagSARIFUsage,
+		EnvVars: []string{"DSLIM_LINT_SARIF"},
+	},
+	FlagRulesFile: &cli.StringSliceFlag{
+		Name:    FlagRulesFile,
+		Value:   cli.NewStringSlice(""),
+		Usage:   FlagRulesFileUsage,
+		EnvVars: []string{"DSLIM_LINT_RULES_FILE"},
+	},
+	FlagBaseline: &cli.StringFlag{
+		Name:    FlagBaseline,
+		Value:   "",
+		Usage:   FlagBaselineUsage,
+		EnvVars: []string{"DSLIM_LINT_BASELINE"},
+	},
+	FlagUpdateBaseline: &cli.BoolFlag{
+		Name:    FlagUpdateBaseline,
+		Usage:   FlagUpdateBaselineUsage,
+		EnvVars: []string{"DSLIM_LINT_UPDATE_BASELINE"},
+	},
+	FlagSkipInlineIgnores: &cli.BoolFlag{
+		Name:    FlagSkipInlineIgnores,
+		Usage:   FlagSkipInlineIgnoresUsage,
+		EnvVars: []string{"DSLIM_LINT_SKIP_INLINE_IGNORES"},
+	},
+	FlagIncludeDockerfile: &cli.StringSliceFlag{
+		Name:    FlagIncludeDockerfile,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagIncludeDockerfileUsage,
+		EnvVars: []string{"DSLIM_LINT_INCLUDE_DOCKERFILE"},
+	},
+	FlagExcludeDockerfile: &cli.StringSliceFlag{
+		Name:    FlagExcludeDockerfile,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagExcludeDockerfileUsage,
+		EnvVars: []string{"DSLIM_LINT_EXCLUDE_DOCKERFILE"},
+	},
+	FlagFailLevel: &cli.StringFlag{
+		Name:    FlagFailLevel,
+		Value:   "",
+		Usage:   FlagFailLevelUsage,
+		EnvVars: []string{"DSLIM_LINT_FAIL_LEVEL"},
+	},
+	FlagRuntimeReport: &cli.StringFlag{
+		Name:    FlagRuntimeReport,
+		Value:   "",
+		Usage:   FlagRuntimeReportUsage,
+		EnvVars: []string{"DSLIM_LINT_RUNTIME_REPORT"},
+	},
+	FlagJUnit: &cli.StringFlag{
+		Name:    FlagJUnit,
+		Usage:   FlagJUnitUsage,
+		EnvVars: []string{"DSLIM_LINT_JUNIT"},
+	},
 }
 
 func cflag(name string) cli.Flag {
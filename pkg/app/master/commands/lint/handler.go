@@ -2,16 +2,23 @@ package lint
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
 	"github.com/docker-slim/docker-slim/pkg/docker/linter"
 	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
 	"github.com/docker-slim/docker-slim/pkg/report"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
 
 	dockerapi "github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
@@ -19,6 +26,26 @@ import (
 
 const appName = commands.AppName
 
+// Lint command exit codes
+const (
+	eclOther = iota + 1
+	eclFailLevel
+	eclReadError
+	eclWriteError
+	eclImageNotFound
+	eclImageDockerfile
+)
+
+// exitCodes documents the lint command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTLint | eclOther, Name: "lint.other", Description: "other lint command error"},
+	{Code: commands.ECTLint | eclFailLevel, Name: "lint.fail.level", Description: "lint issues at or above the fail level (--fail-level)"},
+	{Code: commands.ECTLint | eclReadError, Name: "lint.read.error", Description: "error reading the lint rules, baseline, runtime report or Dockerfiles"},
+	{Code: commands.ECTLint | eclWriteError, Name: "lint.write.error", Description: "error saving the lint baseline"},
+	{Code: commands.ECTLint | eclImageNotFound, Name: "lint.image.not.found", Description: "target image not found"},
+	{Code: commands.ECTLint | eclImageDockerfile, Name: "lint.image.dockerfile", Description: "error reverse engineering the target image Dockerfile"},
+}
+
 type ovars = app.OutVars
 
 // OnCommand implements the 'lint' docker-slim command
@@ -36,7 +63,17 @@ func OnCommand(
 	excludeCheckIDs map[string]struct{},
 	doShowNoHits bool,
 	doShowSnippet bool,
-	doListChecks bool) {
+	doListChecks bool,
+	sarifOutput string,
+	ruleFiles []string,
+	baselineFile string,
+	doUpdateBaseline bool,
+	doSkipInlineIgnores bool,
+	includeDockerfiles []string,
+	excludeDockerfiles []string,
+	failLevel string,
+	runtimeReportFile string,
+	junitOutput string) error {
 	const cmdName = Name
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
 	prefix := fmt.Sprintf("cmd=%s", cmdName)
@@ -53,54 +90,206 @@ func OnCommand(
 			"list.checks": doListChecks,
 		})
 
-	/*
-		do it only when targetting images
-		client, err := dockerclient.New(gparams.ClientConfig)
+	if !doListChecks && targetType == "" {
+		switch {
+		case fsutil.IsDir(targetRef):
+			targetType = linter.DirectoryTargetType
+		case fsutil.Exists(targetRef):
+			targetType = linter.DockerfileTargetType
+		default:
+			targetType = linter.ImageTargetType
+		}
+	}
+
+	var failCount int
+
+	var client *dockerapi.Client
+	if !doListChecks && targetType == linter.ImageTargetType {
+		var err error
+		client, err = dockerclient.New(gparams.ClientConfig)
 		if err == dockerclient.ErrNoDockerInfo {
 			exitMsg := "missing Docker connection info"
 			if gparams.InContainer && gparams.IsDSImage {
 				exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
 			}
-			fmt.Printf("cmd=%s info=docker.connect.error message='%s'\n", cmdName, exitMsg)
-			fmt.Printf("cmd=%s state=exited version=%s location='%s'\n", cmdName, v.Current(), fsutil.ExeDir())
-			os.Exit(ectCommon | ecNoDockerConnectInfo)
+
+			xc.Out.Error("docker.connect.error", exitMsg)
+
+			exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+					"version":   v.Current(),
+					"location":  fsutil.ExeDir(),
+				})
+			return app.NewExitError(exitCode)
 		}
-		errutil.FailOn(err)
-	*/
-	var client *dockerapi.Client
+
+		if err != nil {
+			return err
+		}
+	}
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
+	registry, err := linter.NewRegistry(ruleFiles)
+	if err != nil {
+		xc.Out.Error("lint.rules", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": commands.ECTLint | eclReadError,
+			})
+		return app.NewExitError(commands.ECTLint | eclReadError)
+	}
+
 	if doListChecks {
-		checks := linter.ListChecks()
+		checks := linter.ListRegistryChecks(registry)
 		printLintChecks(xc, checks, appName, cmdName)
 	} else {
-		cmdReport.TargetType = linter.DockerfileTargetType
+		cmdReport.TargetType = targetType
 		cmdReport.TargetReference = targetRef
 
+		var baseline *linter.Baseline
+		if baselineFile != "" && !doUpdateBaseline {
+			baseline, err = linter.LoadBaseline(baselineFile)
+			if err != nil {
+				xc.Out.Error("lint.baseline", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTLint | eclReadError,
+					})
+				return app.NewExitError(commands.ECTLint | eclReadError)
+			}
+		}
+
+		var runtimeInfo *check.RuntimeInfo
+		if runtimeReportFile != "" {
+			runtimeInfo, err = linter.LoadRuntimeInfo(runtimeReportFile)
+			if err != nil {
+				xc.Out.Error("lint.runtime.report", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTLint | eclReadError,
+					})
+				return app.NewExitError(commands.ECTLint | eclReadError)
+			}
+
+			cmdReport.RuntimeReport = runtimeReportFile
+		}
+
+		df, err := imageDockerfile(xc, client, targetType, targetRef, logger)
+		if err != nil {
+			return err
+		}
+
+		if df != nil {
+			//no build context for the image targets
+			doSkipBuildContext = true
+			doSkipDockerignore = true
+		}
+
 		options := linter.Options{
 			DockerfilePath:   targetRef,
 			SkipBuildContext: doSkipBuildContext,
 			BuildContextDir:  buildContextDir,
 			SkipDockerignore: doSkipDockerignore,
+			Dockerfile:       df,
 			Selector: linter.CheckSelector{
 				IncludeCheckLabels: includeCheckLabels,
 				IncludeCheckIDs:    includeCheckIDs,
 				ExcludeCheckLabels: excludeCheckLabels,
 				ExcludeCheckIDs:    excludeCheckIDs,
 			},
+			Registry:          registry,
+			SkipInlineIgnores: doSkipInlineIgnores,
+			Baseline:          baseline,
+			Runtime:           runtimeInfo,
+		}
+
+		var fileReports []*linter.FileReport
+		if targetType == linter.DirectoryTargetType {
+			paths, err := linter.FindDockerfiles(targetRef, includeDockerfiles, excludeDockerfiles)
+			if err != nil {
+				xc.Out.Error("lint.dockerfiles", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTLint | eclReadError,
+					})
+				return app.NewExitError(commands.ECTLint | eclReadError)
+			}
+
+			xc.Out.Info("lint.dockerfiles",
+				ovars{
+					"dir":   targetRef,
+					"count": len(paths),
+				})
+
+			fileReports = linter.ExecuteFiles(paths, options, 0)
+			printBatchLintResults(xc, fileReports, cmdReport, doShowNoHits, doShowSnippet)
+		} else {
+			lintResults, err := linter.Execute(options)
+			if err != nil {
+				return err
+			}
+
+			cmdReport.BuildContextDir = lintResults.BuildContextDir
+			cmdReport.Hits = lintResults.Hits
+			cmdReport.Errors = lintResults.Errors
+			cmdReport.Suppressed = lintResults.Suppressed
+
+			printLintResults(xc, lintResults, appName, cmdName, cmdReport, doShowNoHits, doShowSnippet)
+			fileReports = []*linter.FileReport{
+				{
+					DockerfilePath: targetRef,
+					Report:         lintResults,
+				},
+			}
+		}
+
+		if sarifOutput != "" {
+			saveSARIF(xc, sarifOutput, fileReports, logger)
 		}
 
-		lintResults, err := linter.Execute(options)
-		errutil.FailOn(err)
+		if junitOutput != "" {
+			saveJUnit(xc, junitOutput, fileReports, logger)
+		}
 
-		cmdReport.BuildContextDir = lintResults.BuildContextDir
-		cmdReport.Hits = lintResults.Hits
-		cmdReport.Errors = lintResults.Errors
+		if doUpdateBaseline {
+			updated := linter.NewBaseline(nil, "")
+			for _, fr := range fileReports {
+				updated.Add(fr.Report, fr.DockerfilePath)
+			}
+
+			if err := updated.Save(baselineFile); err != nil {
+				logger.Errorf("error saving lint baseline (%s) - %v", baselineFile, err)
+				xc.Out.Error("lint.baseline", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTLint | eclWriteError,
+					})
+				return app.NewExitError(commands.ECTLint | eclWriteError)
+			}
 
-		printLintResults(xc, lintResults, appName, cmdName, cmdReport, doShowNoHits, doShowSnippet)
+			xc.Out.Info("lint.baseline",
+				ovars{
+					"file":     baselineFile,
+					"findings": len(updated.Findings),
+				})
+		}
+
+		if failLevel != "" {
+			for _, fr := range fileReports {
+				if fr.Error != nil {
+					//the Dockerfiles that can't be linted are always failures
+					failCount++
+					continue
+				}
+
+				failCount += fr.Report.CountHits(failLevel)
+			}
+		}
 	}
 
 	xc.Out.State("completed")
@@ -117,6 +306,129 @@ func OnCommand(
 				"file": cmdReport.ReportLocation(),
 			})
 	}
+
+	if failCount > 0 {
+		exitCode := commands.ECTLint | eclFailLevel
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"message":   fmt.Sprintf("%d lint finding(s) with the '%s' level or higher", failCount, failLevel),
+			})
+		return app.NewExitError(exitCode)
+	}
+
+	return nil
+}
+
+// imageDockerfile reverse engineers the target image instructions
+// (returns nil for the Dockerfile targets)
+func imageDockerfile(
+	xc *app.ExecutionContext,
+	client *dockerapi.Client,
+	targetType string,
+	targetRef string,
+	logger *log.Entry) (*spec.Dockerfile, error) {
+	if targetType != linter.ImageTargetType {
+		return nil, nil
+	}
+
+	if _, err := dockerutil.HasImage(client, targetRef); err != nil {
+		if err == dockerutil.ErrNotFound {
+			xc.Out.Error("image.not.found", fmt.Sprintf("target image not found - %s", targetRef))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTLint | eclImageNotFound,
+				})
+			return nil, app.NewExitError(commands.ECTLint | eclImageNotFound)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := reverse.DockerfileFromHistory(client, targetRef)
+	if err != nil {
+		return nil, err
+	}
+
+	df, err := linter.ImageDockerfile(info, targetRef)
+	if err != nil {
+		logger.Errorf("imageDockerfile: error parsing the reverse engineered Dockerfile - %v", err)
+		xc.Out.Error("image.dockerfile", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": commands.ECTLint | eclImageDockerfile,
+			})
+		return nil, app.NewExitError(commands.ECTLint | eclImageDockerfile)
+	}
+
+	xc.Out.Info("image.dockerfile",
+		ovars{
+			"target":       targetRef,
+			"base.image":   linter.ImageBase(info),
+			"instructions": len(df.AllInstructions),
+		})
+
+	return df, nil
+}
+
+func saveSARIF(
+	xc *app.ExecutionContext,
+	sarifOutput string,
+	fileReports []*linter.FileReport,
+	logger *log.Entry) {
+	err := func() error {
+		if dir := filepath.Dir(sarifOutput); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+
+		file, err := os.Create(sarifOutput)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		return linter.WriteSARIFFiles(file, fileReports, v.Current())
+	}()
+
+	if err != nil {
+		logger.Errorf("saveSARIF: error saving SARIF results (%s) - %v", sarifOutput, err)
+		xc.Out.Info("lint.sarif",
+			ovars{
+				"file":  sarifOutput,
+				"error": err.Error(),
+			})
+		return
+	}
+
+	xc.Out.Info("lint.sarif",
+		ovars{
+			"file": sarifOutput,
+		})
+}
+
+func saveJUnit(
+	xc *app.ExecutionContext,
+	junitOutput string,
+	fileReports []*linter.FileReport,
+	logger *log.Entry) {
+	if err := linter.JUnitReport(fileReports).Save(junitOutput); err != nil {
+		logger.Errorf("saveJUnit: error saving JUnit results (%s) - %v", junitOutput, err)
+		xc.Out.Info("lint.junit",
+			ovars{
+				"file":  junitOutput,
+				"error": err.Error(),
+			})
+		return
+	}
+
+	xc.Out.Info("lint.junit",
+		ovars{
+			"file": junitOutput,
+		})
 }
 
 func printLintChecks(
@@ -161,18 +473,85 @@ func printLintResults(
 	cmdReport.HitsCount = len(lintResults.Hits)
 	cmdReport.NoHitsCount = len(lintResults.NoHits)
 	cmdReport.ErrorsCount = len(lintResults.Errors)
+	cmdReport.SuppressedCount = len(lintResults.Suppressed)
+
+	xc.Out.Info("lint.results",
+		ovars{
+			"hits":       cmdReport.HitsCount,
+			"nohits":     cmdReport.NoHitsCount,
+			"errors":     cmdReport.ErrorsCount,
+			"suppressed": cmdReport.SuppressedCount,
+		})
+
+	printLintDetails(xc, lintResults, doShowNoHits, doShowSnippet)
+}
+
+func printBatchLintResults(
+	xc *app.ExecutionContext,
+	fileReports []*linter.FileReport,
+	cmdReport *report.LintCommand,
+	doShowNoHits bool,
+	doShowSnippet bool) {
+	for _, fr := range fileReports {
+		fileResult := &report.LintFileResult{
+			DockerfilePath: fr.DockerfilePath,
+		}
+
+		cmdReport.Files = append(cmdReport.Files, fileResult)
+		if fr.Error != nil {
+			fileResult.Error = fr.Error.Error()
+			xc.Out.Info("lint.file",
+				ovars{
+					"file":  fr.DockerfilePath,
+					"error": fileResult.Error,
+				})
+			continue
+		}
+
+		fileResult.HitsCount = len(fr.Report.Hits)
+		fileResult.NoHitsCount = len(fr.Report.NoHits)
+		fileResult.ErrorsCount = len(fr.Report.Errors)
+		fileResult.SuppressedCount = len(fr.Report.Suppressed)
+		fileResult.Hits = fr.Report.Hits
+		fileResult.Errors = fr.Report.Errors
+		fileResult.Suppressed = fr.Report.Suppressed
+
+		cmdReport.HitsCount += fileResult.HitsCount
+		cmdReport.NoHitsCount += fileResult.NoHitsCount
+		cmdReport.ErrorsCount += fileResult.ErrorsCount
+		cmdReport.SuppressedCount += fileResult.SuppressedCount
+
+		xc.Out.Info("lint.file",
+			ovars{
+				"file":       fr.DockerfilePath,
+				"hits":       fileResult.HitsCount,
+				"nohits":     fileResult.NoHitsCount,
+				"errors":     fileResult.ErrorsCount,
+				"suppressed": fileResult.SuppressedCount,
+			})
+
+		printLintDetails(xc, fr.Report, doShowNoHits, doShowSnippet)
+	}
 
 	xc.Out.Info("lint.results",
 		ovars{
-			"hits":   cmdReport.HitsCount,
-			"nohits": cmdReport.NoHitsCount,
-			"errors": cmdReport.ErrorsCount,
+			"files":      len(fileReports),
+			"hits":       cmdReport.HitsCount,
+			"nohits":     cmdReport.NoHitsCount,
+			"errors":     cmdReport.ErrorsCount,
+			"suppressed": cmdReport.SuppressedCount,
 		})
+}
 
-	if cmdReport.HitsCount > 0 {
+func printLintDetails(
+	xc *app.ExecutionContext,
+	lintResults *linter.Report,
+	doShowNoHits bool,
+	doShowSnippet bool) {
+	if len(lintResults.Hits) > 0 {
 		xc.Out.Info("lint.check.hits",
 			ovars{
-				"count": cmdReport.HitsCount,
+				"count": len(lintResults.Hits),
 			})
 
 		for id, result := range lintResults.Hits {
@@ -226,10 +605,36 @@ func printLintResults(
 		}
 	}
 
-	if doShowNoHits && cmdReport.NoHitsCount > 0 {
+	if len(lintResults.Suppressed) > 0 {
+		xc.Out.Info("lint.check.suppressed",
+			ovars{
+				"count": len(lintResults.Suppressed),
+			})
+
+		for _, info := range lintResults.Suppressed {
+			sinfo := ovars{
+				"id":     info.CheckID,
+				"source": info.Source,
+			}
+
+			if info.Match != nil && info.Match.Instruction != nil {
+				sinfo["line"] = info.Match.Instruction.StartLine
+			} else if info.Match != nil && info.Match.Stage != nil {
+				sinfo["line"] = info.Match.Stage.StartLine
+			}
+
+			if info.Reason != "" {
+				sinfo["reason"] = info.Reason
+			}
+
+			xc.Out.Info("lint.check.suppressed.item", sinfo)
+		}
+	}
+
+	if doShowNoHits && len(lintResults.NoHits) > 0 {
 		xc.Out.Info("lint.check.nohits",
 			ovars{
-				"count": cmdReport.NoHitsCount,
+				"count": len(lintResults.NoHits),
 			})
 
 		for id, result := range lintResults.NoHits {
@@ -241,10 +646,10 @@ func printLintResults(
 		}
 	}
 
-	if cmdReport.ErrorsCount > 0 {
+	if len(lintResults.Errors) > 0 {
 		xc.Out.Info("lint.check.errors",
 			ovars{
-				"count": cmdReport.ErrorsCount,
+				"count": len(lintResults.Errors),
 			})
 
 		for id, err := range lintResults.Errors {
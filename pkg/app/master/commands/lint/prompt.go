@@ -28,6 +28,16 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagShowNoHits), Description: FlagShowNoHitsUsage},
 		{Text: commands.FullFlagName(FlagShowSnippet), Description: FlagShowSnippetUsage},
 		{Text: commands.FullFlagName(FlagListChecks), Description: FlagListChecksUsage},
+		{Text: commands.FullFlagName(FlagSARIF), Description: FlagSARIFUsage},
+		{Text: commands.FullFlagName(FlagRulesFile), Description: FlagRulesFileUsage},
+		{Text: commands.FullFlagName(FlagBaseline), Description: FlagBaselineUsage},
+		{Text: commands.FullFlagName(FlagUpdateBaseline), Description: FlagUpdateBaselineUsage},
+		{Text: commands.FullFlagName(FlagSkipInlineIgnores), Description: FlagSkipInlineIgnoresUsage},
+		{Text: commands.FullFlagName(FlagIncludeDockerfile), Description: FlagIncludeDockerfileUsage},
+		{Text: commands.FullFlagName(FlagExcludeDockerfile), Description: FlagExcludeDockerfileUsage},
+		{Text: commands.FullFlagName(FlagFailLevel), Description: FlagFailLevelUsage},
+		{Text: commands.FullFlagName(FlagRuntimeReport), Description: FlagRuntimeReportUsage},
+		{Text: commands.FullFlagName(FlagJUnit), Description: FlagJUnitUsage},
 	},
 	Values: map[string]commands.CompleteValue{
 		commands.FullFlagName(commands.FlagTarget):    completeLintTarget,
@@ -42,6 +52,14 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(FlagShowNoHits):         commands.CompleteBool,
 		commands.FullFlagName(FlagShowSnippet):        commands.CompleteTBool,
 		commands.FullFlagName(FlagListChecks):         commands.CompleteBool,
+		commands.FullFlagName(FlagSARIF):              commands.CompleteFile,
+		commands.FullFlagName(FlagRulesFile):          commands.CompleteFile,
+		commands.FullFlagName(FlagBaseline):           commands.CompleteFile,
+		commands.FullFlagName(FlagUpdateBaseline):     commands.CompleteBool,
+		commands.FullFlagName(FlagSkipInlineIgnores):  commands.CompleteBool,
+		commands.FullFlagName(FlagFailLevel):          completeLintLevel,
+		commands.FullFlagName(FlagRuntimeReport):      commands.CompleteFile,
+		commands.FullFlagName(FlagJUnit):              commands.CompleteFile,
 	},
 }
 
@@ -55,15 +73,28 @@ func completeLintTarget(ia *commands.InteractiveApp, token string, params prompt
 var lintTargetTypeValues = []prompt.Suggest{
 	{Text: "dockerfile", Description: "Dockerfile target type"},
 	{Text: "image", Description: "Docker image target type"},
+	{Text: "directory", Description: "Directory with Dockerfiles target type"},
 }
 
 func completeLintTargetType(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	return prompt.FilterHasPrefix(lintTargetTypeValues, token, true)
 }
 
+var lintLevelValues = []prompt.Suggest{
+	{Text: check.LevelFatal, Description: "Fatal check hits"},
+	{Text: check.LevelError, Description: "Error (or higher) check hits"},
+	{Text: check.LevelWarn, Description: "Warning (or higher) check hits"},
+	{Text: check.LevelInfo, Description: "Info (or higher) check hits"},
+	{Text: check.LevelStyle, Description: "All check hits"},
+}
+
+func completeLintLevel(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(lintLevelValues, token, true)
+}
+
 func completeLintCheckID(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	var values []prompt.Suggest
-	for _, check := range check.AllChecks {
+	for _, check := range check.DefaultRegistry.Checks() {
 		info := check.Get()
 		entry := prompt.Suggest{
 			Text:        info.ID,
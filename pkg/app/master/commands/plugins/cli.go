@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Plugin commands ('docker-slim-<cmd>' executables on PATH)
+
+const (
+	UsageFormat = "Plugin command (%s)"
+)
+
+type ovars = app.OutVars
+
+// CommandParams contains the plugin command params
+type CommandParams struct {
+	Plugin *Plugin
+	Args   []string
+	//global flag values by flag name (passed to the plugin as DSLIM_* environment variables)
+	GlobalFlags map[string]string
+}
+
+// NewCLI creates the CLI command for the plugin
+// (the command args and flags are passed to the plugin executable as-is)
+func NewCLI(p *Plugin) *cli.Command {
+	return &cli.Command{
+		Name:            p.Name,
+		Usage:           fmt.Sprintf(UsageFormat, p.Path),
+		SkipFlagParsing: true,
+		Action: func(ctx *cli.Context) error {
+			xc := app.NewExecutionContext(p.Name, ctx.String(commands.FlagConsoleFormat))
+
+			gparams, ok := commands.CLIContextGet(ctx.Context, commands.GlobalParams).(*commands.GenericParams)
+			if !ok || gparams == nil {
+				xc.Out.Error("param.global", "missing params")
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
+
+			cparams := &CommandParams{
+				Plugin:      p,
+				Args:        ctx.Args().Slice(),
+				GlobalFlags: map[string]string{},
+			}
+
+			for _, flag := range ctx.App.Flags {
+				name := flag.Names()[0]
+				if name == cli.HelpFlag.Names()[0] || name == cli.VersionFlag.Names()[0] {
+					continue
+				}
+
+				cparams.GlobalFlags[name] = ctx.String(name)
+			}
+
+			OnCommand(xc, gparams, cparams)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Plugin executable name prefix
+const BinPrefix = "docker-slim-"
+
+// the 'docker-slim-*' executables that are not plugins
+var reservedNames = map[string]struct{}{
+	"sensor": {},
+}
+
+// Plugin is a plugin command executable ('docker-slim-<cmd>' on PATH)
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Discover finds the plugin executables on PATH
+// (the first executable on PATH wins, the plugins can't replace the built-in commands)
+func Discover(pathEnv string, builtin map[string]struct{}) []*Plugin {
+	found := map[string]*Plugin{}
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			dir = "."
+		}
+
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, info := range files {
+			name, ok := pluginName(info)
+			if !ok {
+				continue
+			}
+
+			if _, ok := builtin[name]; ok {
+				log.Debugf("plugins.Discover: skipping plugin with built-in command name - %s", filepath.Join(dir, info.Name()))
+				continue
+			}
+
+			if _, ok := found[name]; ok {
+				continue
+			}
+
+			found[name] = &Plugin{
+				Name: name,
+				Path: filepath.Join(dir, info.Name()),
+			}
+		}
+	}
+
+	var names []string
+	for name := range found {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var plugins []*Plugin
+	for _, name := range names {
+		plugins = append(plugins, found[name])
+	}
+
+	return plugins
+}
+
+func pluginName(info os.FileInfo) (string, bool) {
+	fileName := info.Name()
+	if !strings.HasPrefix(fileName, BinPrefix) {
+		return "", false
+	}
+
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(fileName))
+		if ext != ".exe" {
+			return "", false
+		}
+
+		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+
+	if info.IsDir() {
+		return "", false
+	}
+
+	if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(fileName, BinPrefix)
+	if name == "" {
+		return "", false
+	}
+
+	if _, ok := reservedNames[name]; ok {
+		return "", false
+	}
+
+	return name, true
+}
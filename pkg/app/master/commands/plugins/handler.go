@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+const appName = commands.AppName
+
+// Plugin environment variables (in addition to the DSLIM_* global flag environment variables)
+const (
+	EnvPluginName = "DSLIM_PLUGIN_NAME"
+	EnvBin        = "DSLIM_BIN"
+	EnvVersion    = "DSLIM_VERSION"
+)
+
+// OnCommand runs the plugin executable
+// (the plugin exit code is the command exit code)
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": cparams.Plugin.Name})
+
+	cmd := exec.Command(cparams.Plugin.Path, cparams.Args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = pluginEnv(gparams, cparams)
+
+	//the plugin gets the interrupt signal too (wait for it to exit when the command is interrupted)
+	pluginDone := make(chan struct{})
+	xc.AddCleanupHandler(func() {
+		if xc.Context.Err() != nil {
+			<-pluginDone
+		}
+	})
+
+	logger.Debugf("running plugin - %s %s", cparams.Plugin.Path, strings.Join(cparams.Args, " "))
+	err := cmd.Run()
+	close(pluginDone)
+	if err == nil {
+		return
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		logger.Debugf("plugin exit code - %d", exitErr.ExitCode())
+		xc.Exit(exitErr.ExitCode())
+	}
+
+	xc.Out.Error("plugin.run", err.Error())
+	xc.Out.State("exited",
+		ovars{
+			"exit.code": commands.ECTCommon | commands.ECCommandExec,
+			"plugin":    cparams.Plugin.Path,
+		})
+	xc.Exit(commands.ECTCommon | commands.ECCommandExec)
+}
+
+// pluginEnv creates the plugin environment
+// (the global flag values and the Docker connection config are added to the current environment)
+func pluginEnv(gparams *commands.GenericParams, cparams *CommandParams) []string {
+	env := os.Environ()
+	env = append(env, EnvPluginName+"="+cparams.Plugin.Name)
+	if exePath, err := os.Executable(); err == nil {
+		env = append(env, EnvBin+"="+exePath)
+	}
+
+	env = append(env, EnvVersion+"="+v.Current())
+	for name, value := range cparams.GlobalFlags {
+		env = append(env, commands.FlagEnvVarName(name)+"="+value)
+	}
+
+	//the Docker connection config (the docker-slim global flags override the DOCKER_* environment variables)
+	if cc := gparams.ClientConfig; cc != nil {
+		if cc.Host != "" {
+			env = append(env, dockerclient.EnvDockerHost+"="+cc.Host)
+		}
+
+		if cc.TLSCertPath != "" {
+			env = append(env, dockerclient.EnvDockerCertPath+"="+cc.TLSCertPath)
+			if cc.UseTLS && cc.VerifyTLS {
+				env = append(env, dockerclient.EnvDockerTLSVerify+"=1")
+			}
+		}
+	}
+
+	return env
+}
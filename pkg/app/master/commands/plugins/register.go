@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"os"
+
+	"github.com/c-bata/go-prompt"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+)
+
+// RegisterCommands adds the plugin commands found on PATH
+// (call it after the built-in commands are registered)
+func RegisterCommands() {
+	builtin := map[string]struct{}{}
+	for _, cmd := range commands.CLI {
+		for _, name := range cmd.Names() {
+			builtin[name] = struct{}{}
+		}
+	}
+
+	for _, p := range Discover(os.Getenv("PATH"), builtin) {
+		cmd := NewCLI(p)
+		commands.CLI = append(commands.CLI, cmd)
+		commands.CommandSuggestions = append(commands.CommandSuggestions,
+			prompt.Suggest{
+				Text:        cmd.Name,
+				Description: cmd.Usage,
+			})
+	}
+}
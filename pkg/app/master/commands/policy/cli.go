@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/report"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Command report policy evaluation
+
+const (
+	Name  = "policy"
+	Usage = "Evaluate the user-defined policies against a command report"
+	Alias = "pl"
+)
+
+type CommandParams struct {
+	TargetReport string
+	PolicyFiles  []string
+	FailOnWarn   bool
+	JUnitOutput  string
+}
+
+var CLI = &cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Flags: []cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagPolicyFile),
+		cflag(FlagFailOnWarn),
+		cflag(FlagJUnit),
+	},
+	Action: func(ctx *cli.Context) error {
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		cparams := &CommandParams{
+			TargetReport: ctx.String(commands.FlagTarget),
+			PolicyFiles:  ctx.StringSlice(FlagPolicyFile),
+			FailOnWarn:   ctx.Bool(FlagFailOnWarn),
+			JUnitOutput:  ctx.String(FlagJUnit),
+		}
+
+		if cparams.TargetReport == "" && ctx.Args().Len() > 0 {
+			cparams.TargetReport = ctx.Args().First()
+		}
+
+		//evaluating the report from the last command by default
+		if cparams.TargetReport == "" {
+			cparams.TargetReport = gcvalues.ReportLocation
+		}
+
+		if cparams.TargetReport == "" {
+			cparams.TargetReport = report.DefaultFilename
+		}
+
+		if len(cparams.PolicyFiles) == 0 {
+			xc.Out.Error("param.error.policy.file", "missing policy file")
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		OnCommand(xc, gcvalues, cparams)
+		return nil
+	},
+}
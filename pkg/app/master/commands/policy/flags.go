@@ -0,0 +1,50 @@
+package policy
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Policy command flag names
+const (
+	FlagPolicyFile = "policy-file"
+	FlagFailOnWarn = "fail-on-warn"
+	FlagJUnit      = "junit"
+)
+
+// Policy command flag usage info
+const (
+	FlagTargetReportUsage = "Command report file to evaluate the policies against (default: the command report location)"
+	FlagPolicyFileUsage   = "Policy config file (YAML or JSON)"
+	FlagFailOnWarnUsage   = "Fail if the 'warn' level policies fail too"
+	FlagJUnitUsage        = "Save the policy results in the JUnit XML format (file path)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagPolicyFile: &cli.StringSliceFlag{
+		Name:    FlagPolicyFile,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagPolicyFileUsage,
+		EnvVars: []string{"DSLIM_POLICY_FILE"},
+	},
+	FlagFailOnWarn: &cli.BoolFlag{
+		Name:    FlagFailOnWarn,
+		Usage:   FlagFailOnWarnUsage,
+		EnvVars: []string{"DSLIM_POLICY_FAIL_ON_WARN"},
+	},
+	FlagJUnit: &cli.StringFlag{
+		Name:    FlagJUnit,
+		Value:   "",
+		Usage:   FlagJUnitUsage,
+		EnvVars: []string{"DSLIM_POLICY_JUNIT"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
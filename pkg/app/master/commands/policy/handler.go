@@ -0,0 +1,253 @@
+package policy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/junit"
+	reportpolicy "github.com/docker-slim/docker-slim/pkg/policy"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// Policy command exit codes
+const (
+	ecpOther = iota + 1
+	ecpReadError
+	ecpBadPolicy
+	ecpFailed
+)
+
+// exitCodes documents the policy command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTPolicy | ecpOther, Name: "policy.other", Description: "other policy command error"},
+	{Code: commands.ECTPolicy | ecpReadError, Name: "policy.read.error", Description: "error reading the command report"},
+	{Code: commands.ECTPolicy | ecpBadPolicy, Name: "policy.bad.policy", Description: "invalid policy file"},
+	{Code: commands.ECTPolicy | ecpFailed, Name: "policy.failed", Description: "policy violations"},
+}
+
+// OnCommand implements the 'policy' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+
+	cmdReport := report.NewPolicyCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReport = cparams.TargetReport
+	cmdReport.PolicyFiles = cparams.PolicyFiles
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"target":   cparams.TargetReport,
+			"policies": strings.Join(cparams.PolicyFiles, ","),
+		})
+
+	policies, err := reportpolicy.Load(cparams.PolicyFiles)
+	if err != nil {
+		logger.Debugf("error loading policies - %v", err)
+		exitWithError(xc, "policy.load", err, ecpBadPolicy)
+	}
+
+	input, err := reportpolicy.LoadInput(cparams.TargetReport)
+	if err != nil {
+		logger.Debugf("error loading report (%s) - %v", cparams.TargetReport, err)
+		exitWithError(xc, "report.read", err, ecpReadError)
+	}
+
+	cmdReport.TargetType = input.Type
+	xc.Out.Info("report",
+		ovars{
+			"type":             input.Type,
+			"container.report": input.ContainerReport != nil,
+		})
+
+	results := reportpolicy.Evaluate(policies, input)
+	failed := false
+	for _, result := range results {
+		cmdReport.Results = append(cmdReport.Results, &report.PolicyResult{
+			ID:         result.ID,
+			Name:       result.Name,
+			Level:      result.Level,
+			Skipped:    result.Skipped,
+			Passed:     result.Passed,
+			Violations: result.Violations,
+			Error:      result.Error,
+		})
+
+		status := "passed"
+		switch {
+		case result.Skipped:
+			cmdReport.SkipCount++
+			status = "skipped"
+		case result.Error != "":
+			cmdReport.ErrorCount++
+			status = "error"
+			failed = true
+		case result.Passed:
+			cmdReport.PassCount++
+		case result.Level == reportpolicy.LevelWarn:
+			cmdReport.WarnCount++
+			status = "warn"
+			if cparams.FailOnWarn {
+				failed = true
+			}
+		default:
+			cmdReport.FailCount++
+			status = "failed"
+			failed = true
+		}
+
+		outVars := ovars{
+			"id":     result.ID,
+			"name":   result.Name,
+			"level":  result.Level,
+			"status": status,
+		}
+
+		if result.Error != "" {
+			outVars["error"] = result.Error
+		}
+
+		xc.Out.Info("policy.result", outVars)
+		for _, violation := range result.Violations {
+			xc.Out.Info("policy.violation",
+				ovars{
+					"id":      result.ID,
+					"message": violation,
+				})
+		}
+	}
+
+	cmdReport.Passed = !failed
+	xc.Out.Info("policy.summary",
+		ovars{
+			"passed":   cmdReport.PassCount,
+			"failed":   cmdReport.FailCount,
+			"warnings": cmdReport.WarnCount,
+			"errors":   cmdReport.ErrorCount,
+			"skipped":  cmdReport.SkipCount,
+		})
+
+	if cparams.JUnitOutput != "" {
+		saveJUnit(xc, cparams.JUnitOutput, junitReport(cparams, results), logger)
+	}
+
+	exitCode := 0
+	if failed {
+		exitCode = commands.ECTPolicy | ecpFailed
+		cmdReport.Error = "policy.failed"
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+	} else {
+		xc.Out.State("completed")
+		cmdReport.State = command.StateCompleted
+		xc.Out.State("done")
+		cmdReport.State = command.StateDone
+	}
+
+	//not overwriting the target report (the default report location is the same)
+	if sameFile(gparams.ReportLocation, cparams.TargetReport) {
+		logger.Debugf("not saving the command report (same as the target report - %s)", cparams.TargetReport)
+	} else if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	if exitCode != 0 {
+		xc.Exit(exitCode)
+	}
+}
+
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+
+	return absA == absB
+}
+
+// junitReport creates a JUnit XML report with the policy results
+// (the failed policies are failures, the 'warn' level failed policies are failures only with '--fail-on-warn')
+func junitReport(cparams *CommandParams, results []*reportpolicy.Result) *junit.TestSuites {
+	suite := &junit.TestSuite{Name: cparams.TargetReport}
+	for _, result := range results {
+		tc := &junit.TestCase{
+			Name:      fmt.Sprintf("%s: %s", result.ID, result.Name),
+			ClassName: "docker-slim.policy",
+		}
+
+		switch {
+		case result.Skipped:
+			tc.Skipped = &junit.Skipped{Message: "the policy doesn't apply to the report type"}
+		case result.Error != "":
+			tc.Error = &junit.Result{Message: result.Error}
+		case result.Passed:
+		case result.Level == reportpolicy.LevelWarn && !cparams.FailOnWarn:
+			tc.SystemOut = strings.Join(result.Violations, "\n")
+		default:
+			tc.Failure = &junit.Result{
+				Message: fmt.Sprintf("%d violation(s)", len(result.Violations)),
+				Type:    result.Level,
+				Details: strings.Join(result.Violations, "\n"),
+			}
+		}
+
+		suite.Add(tc)
+	}
+
+	return junit.NewReport("docker-slim-policy", []*junit.TestSuite{suite})
+}
+
+func saveJUnit(
+	xc *app.ExecutionContext,
+	junitOutput string,
+	results *junit.TestSuites,
+	logger *log.Entry) {
+	if err := results.Save(junitOutput); err != nil {
+		logger.Errorf("saveJUnit: error saving JUnit results (%s) - %v", junitOutput, err)
+		xc.Out.Info("policy.junit",
+			ovars{
+				"file":  junitOutput,
+				"error": err.Error(),
+			})
+		return
+	}
+
+	xc.Out.Info("policy.junit",
+		ovars{
+			"file": junitOutput,
+		})
+}
+
+func exitWithError(xc *app.ExecutionContext, key string, err error, code int) {
+	xc.Out.Error(key, err.Error())
+
+	exitCode := commands.ECTPolicy | code
+	xc.Out.State("exited",
+		ovars{
+			"exit.code": exitCode,
+		})
+	xc.Exit(exitCode)
+}
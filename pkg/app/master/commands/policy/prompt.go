@@ -0,0 +1,27 @@
+package policy
+
+import (
+	"github.com/c-bata/go-prompt"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: FlagTargetReportUsage},
+		{Text: commands.FullFlagName(FlagPolicyFile), Description: FlagPolicyFileUsage},
+		{Text: commands.FullFlagName(FlagFailOnWarn), Description: FlagFailOnWarnUsage},
+		{Text: commands.FullFlagName(FlagJUnit), Description: FlagJUnitUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget): commands.CompleteFile,
+		commands.FullFlagName(FlagPolicyFile):      commands.CompleteFile,
+		commands.FullFlagName(FlagFailOnWarn):      commands.CompleteBool,
+		commands.FullFlagName(FlagJUnit):           commands.CompleteFile,
+	},
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Preset is a built-in flag value bundle for a common workload type
+// (the values are used only for the flags that are not set explicitly or in the flag config files)
+type Preset struct {
+	Name        string
+	Description string
+	Values      map[string]interface{}
+}
+
+// Built-in flag presets
+// (the build command flag names are used as strings because the build flags are defined in the build command package)
+var FlagPresets = map[string]*Preset{
+	"node-web": {
+		Name:        "node-web",
+		Description: "Node.js web app (Express, Next.js, Nuxt)",
+		Values: map[string]interface{}{
+			FlagContinueAfter:           "probe",
+			FlagHTTPProbe:               true,
+			FlagHTTPProbeCrawl:          true,
+			FlagHTTPProbeFull:           true,
+			FlagHTTPProbeStartWait:      "3",
+			FlagHTTPProbeRetryCount:     "10",
+			"include-cert-bundles-only": true,
+			"include-path":              []interface{}{"/etc/ssl/certs", "/etc/hosts", "/etc/nsswitch.conf"},
+		},
+	},
+	"python-api": {
+		Name:        "python-api",
+		Description: "Python API service (Flask, FastAPI, Django)",
+		Values: map[string]interface{}{
+			FlagContinueAfter:           "probe",
+			FlagHTTPProbe:               true,
+			FlagHTTPProbeFull:           true,
+			FlagHTTPProbeStartWait:      "5",
+			FlagHTTPProbeRetryCount:     "10",
+			FlagHTTPProbeRetryWait:      "3",
+			"include-cert-bundles-only": true,
+			"include-path":              []interface{}{"/etc/ssl/certs", "/etc/mime.types", "/etc/nsswitch.conf"},
+		},
+	},
+	"java-spring": {
+		Name:        "java-spring",
+		Description: "Java Spring Boot service (slow JVM startup)",
+		Values: map[string]interface{}{
+			FlagContinueAfter:       "probe",
+			FlagHTTPProbe:           true,
+			FlagHTTPProbeFull:       true,
+			FlagHTTPProbeStartWait:  "20",
+			FlagHTTPProbeRetryCount: "20",
+			FlagHTTPProbeRetryWait:  "5",
+			"include-path":          []interface{}{"/tmp", "/etc/ssl/certs", "/etc/nsswitch.conf"},
+		},
+	},
+}
+
+// FlagPresetNames returns the sorted built-in flag preset names
+func FlagPresetNames() []string {
+	var names []string
+	for name := range FlagPresets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ApplyFlagPreset sets the command flags that are not set yet to the preset values
+// (the preset values for the flags the command doesn't have are ignored)
+func ApplyFlagPreset(ctx *cli.Context, flags []cli.Flag, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	preset, ok := FlagPresets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset '%s' (available: %s)", name, strings.Join(FlagPresetNames(), ", "))
+	}
+
+	values := map[string]interface{}{}
+	for flagName, value := range preset.Values {
+		if findFlag(flags, flagName) != nil {
+			values[flagName] = value
+		}
+	}
+
+	return ApplyFlagConfigValues(ctx, flags, values)
+}
@@ -2,9 +2,13 @@ package probe
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"strings"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 
 	"github.com/urfave/cli/v2"
 )
@@ -21,11 +25,19 @@ var CLI = &cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
 	Usage:   Usage,
+	Flags: append([]cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+	}, commands.HTTPProbeFlags()...),
 	Action: func(ctx *cli.Context) error {
-		if ctx.Args().Len() < 1 {
-			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
-			cli.ShowCommandHelp(ctx, Name)
-			return nil
+		targetRef := ctx.String(commands.FlagTarget)
+		if targetRef == "" {
+			if ctx.Args().Len() < 1 {
+				fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			}
+
+			targetRef = ctx.Args().First()
 		}
 
 		gcvalues, err := commands.GlobalFlagValues(ctx)
@@ -33,15 +45,108 @@ var CLI = &cli.Command{
 			return err
 		}
 
-		targetRef := ctx.Args().First()
-
 		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
 
+		target, err := parseTarget(targetRef)
+		if err != nil {
+			xc.Out.Error("param.target", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		httpProbeOpts := commands.GetHTTPProbeOptions(xc, ctx)
+		//probing is the whole point of the command
+		httpProbeOpts.Do = true
+		if len(httpProbeOpts.Cmds) == 0 {
+			httpProbeOpts.Cmds = append(httpProbeOpts.Cmds, commands.GetDefaultHTTPProbe())
+			if ctx.Bool(commands.FlagHTTPProbeCrawl) {
+				httpProbeOpts.Cmds[0].Crawl = true
+			}
+		}
+
+		if target.proto != "" {
+			for idx := range httpProbeOpts.Cmds {
+				cmd := &httpProbeOpts.Cmds[idx]
+				//the default probe uses 'http' (which would also try 'https')
+				if cmd.Protocol == "" ||
+					(cmd.Protocol == config.ProtoHTTP && target.proto == config.ProtoHTTPS) {
+					cmd.Protocol = target.proto
+				}
+			}
+		}
+
+		if target.port != "" {
+			httpProbeOpts.Ports = nil
+		} else if len(httpProbeOpts.Ports) == 0 {
+			target.port = defaultPort(target.proto)
+		}
+
 		OnCommand(
 			xc,
 			gcvalues,
-			targetRef)
+			targetRef,
+			target,
+			httpProbeOpts)
 
 		return nil
 	},
 }
+
+type probeTarget struct {
+	proto string
+	host  string
+	port  string
+}
+
+// parseTarget parses the probe target info
+// (supported formats: 'host', 'host:port', 'proto://host[:port]')
+func parseTarget(targetRef string) (*probeTarget, error) {
+	targetRef = strings.TrimSpace(targetRef)
+	if targetRef == "" {
+		return nil, fmt.Errorf("empty target")
+	}
+
+	var target probeTarget
+	if strings.Contains(targetRef, "://") {
+		parsed, err := url.Parse(targetRef)
+		if err != nil {
+			return nil, err
+		}
+
+		target.proto = strings.ToLower(parsed.Scheme)
+		if !config.IsProto(target.proto) {
+			return nil, fmt.Errorf("unsupported target protocol - %s", parsed.Scheme)
+		}
+
+		target.host = parsed.Hostname()
+		target.port = parsed.Port()
+	} else {
+		host, port, err := net.SplitHostPort(targetRef)
+		if err != nil {
+			//no port
+			host = strings.Trim(targetRef, "[]")
+		}
+
+		target.host = host
+		target.port = port
+	}
+
+	if target.host == "" {
+		return nil, fmt.Errorf("missing target host - %s", targetRef)
+	}
+
+	return &target, nil
+}
+
+func defaultPort(proto string) string {
+	switch proto {
+	case config.ProtoHTTPS, config.ProtoHTTP2, config.ProtoWSS,
+		config.ProtoGRPCS, config.ProtoSSES, config.ProtoTLS:
+		return "443"
+	default:
+		return "80"
+	}
+}
@@ -2,14 +2,15 @@ package probe
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
-	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/http"
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/report"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
@@ -20,11 +21,29 @@ const appName = commands.AppName
 
 type ovars = app.OutVars
 
+// Probe command exit codes
+const (
+	ecprOther = iota + 1
+	ecprNoPorts
+	ecprProbeFailure
+	ecprProbeFailureThreshold
+)
+
+// exitCodes documents the probe command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTProbe | ecprOther, Name: "probe.other", Description: "other probe command error"},
+	{Code: commands.ECTProbe | ecprNoPorts, Name: "probe.no.ports", Description: "no target ports to probe"},
+	{Code: commands.ECTProbe | ecprProbeFailure, Name: "probe.failure", Description: "no successful HTTP probe calls"},
+	{Code: commands.ECTProbe | ecprProbeFailureThreshold, Name: "probe.failure.threshold", Description: "HTTP probe failure threshold exceeded"},
+}
+
 // OnCommand implements the 'probe' docker-slim command
 func OnCommand(
 	xc *app.ExecutionContext,
 	gparams *commands.GenericParams,
-	targetRef string) {
+	targetRef string,
+	target *probeTarget,
+	httpProbeOpts config.HTTPProbeOptions) {
 	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
 	prefix := fmt.Sprintf("cmd=%s", Name)
 
@@ -32,6 +51,8 @@ func OnCommand(
 
 	cmdReport := report.NewProbeCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = targetRef
+	cmdReport.TargetHost = target.host
 
 	xc.Out.State("started")
 	xc.Out.Info("params",
@@ -39,31 +60,90 @@ func OnCommand(
 			"target": targetRef,
 		})
 
-	client, err := dockerclient.New(gparams.ClientConfig)
-	if err == dockerclient.ErrNoDockerInfo {
-		exitMsg := "missing Docker connection info"
-		if gparams.InContainer && gparams.IsDSImage {
-			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+	if gparams.Debug {
+		//the command doesn't need a Docker connection
+		version.Print(prefix, logger, nil, false, gparams.InContainer, gparams.IsDSImage)
+	}
+
+	var ports []string
+	if target.port != "" {
+		ports = append(ports, target.port)
+	} else {
+		for _, port := range httpProbeOpts.Ports {
+			ports = append(ports, strconv.Itoa(int(port)))
 		}
+	}
 
-		xc.Out.Info("docker.connect.error",
+	var tcpPorts []string
+	var udpPorts []string
+	for _, cmd := range httpProbeOpts.Cmds {
+		if cmd.Protocol == config.ProtoUDP {
+			udpPorts = ports
+		} else {
+			tcpPorts = ports
+		}
+	}
+
+	cmdReport.TargetPorts = ports
+
+	if len(ports) == 0 {
+		xc.Out.Info("probe.ports",
 			ovars{
-				"message": exitMsg,
+				"message": "no ports to probe",
 			})
 
-		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		exitCode := commands.ECTProbe | ecprNoPorts
 		xc.Out.State("exited",
 			ovars{
 				"exit.code": exitCode,
 				"version":   v.Current(),
 				"location":  fsutil.ExeDir(),
 			})
+		cmdReport.Error = "no.ports"
+		cmdReport.Save()
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
 
-	if gparams.Debug {
-		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	probe, err := http.NewEndpointProbe(xc, target.host, tcpPorts, udpPorts, httpProbeOpts, true)
+	xc.FailOn(err)
+
+	probe.Start()
+	<-probe.DoneChan()
+
+	cmdReport.HTTPProbe = probe.Report()
+
+	xc.Out.Info("probe.summary",
+		ovars{
+			"calls":  probe.CallCount,
+			"ok":     probe.OkCount,
+			"errors": probe.ErrCount,
+		})
+
+	exitCode := 0
+	switch {
+	case probe.FailureThresholdExceeded():
+		exitCode = commands.ECTProbe | ecprProbeFailureThreshold
+		cmdReport.Error = "probe.failure.threshold"
+	case probe.CallCount > 0 && probe.OkCount == 0 && httpProbeOpts.ExitOnFailure:
+		exitCode = commands.ECTProbe | ecprProbeFailure
+		cmdReport.Error = "probe.failure"
+	}
+
+	if exitCode != 0 {
+		xc.Out.Error("probe.failure", cmdReport.Error)
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+		if cmdReport.Save() {
+			xc.Out.Info("report",
+				ovars{
+					"file": cmdReport.ReportLocation(),
+				})
+		}
+		xc.Exit(exitCode)
 	}
 
 	xc.Out.State("completed")
@@ -6,5 +6,6 @@ import (
 
 func RegisterCommand() {
 	commands.CLI = append(commands.CLI, CLI)
+	commands.RegisterExitCodes(Name, exitCodes...)
 	commands.CommandSuggestions = append(commands.CommandSuggestions, CommandSuggestion)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/trace"
 )
 
 const (
@@ -48,6 +49,9 @@ var CLI = &cli.Command{
 		commands.Cflag(commands.FlagPublishExposedPorts),
 		commands.Cflag(commands.FlagHostExec),
 		commands.Cflag(commands.FlagHostExecFile),
+		commands.Cflag(commands.FlagExecHook),
+		commands.Cflag(commands.FlagSessionRecord),
+		commands.Cflag(commands.FlagSessionReplay),
 		//commands.Cflag(commands.FlagKeepPerms),
 		commands.Cflag(commands.FlagRunTargetAsUser),
 		commands.Cflag(commands.FlagShowContainerLogs),
@@ -78,11 +82,14 @@ var CLI = &cli.Command{
 		commands.Cflag(commands.FlagExcludePattern), //should remove too (no need)
 		commands.Cflag(commands.FlagMount),
 		commands.Cflag(commands.FlagContinueAfter),
+		commands.Cflag(commands.FlagPreset),
 		commands.Cflag(commands.FlagUseLocalMounts),
 		commands.Cflag(commands.FlagUseSensorVolume),
 		//Sensor flags:
 		commands.Cflag(commands.FlagSensorIPCEndpoint),
 		commands.Cflag(commands.FlagSensorIPCMode),
+		commands.Cflag(commands.FlagTraceOutput),
+		commands.Cflag(commands.FlagTraceFormat),
 	}, commands.HTTPProbeFlags()...),
 	Action: func(ctx *cli.Context) error {
 		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
@@ -108,9 +115,19 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.container.run.options", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		traceFormat := ctx.String(commands.FlagTraceFormat)
+		if !trace.IsFormat(traceFormat) {
+			xc.Out.Error("param.error.trace.format", fmt.Sprintf("unsupported trace format - '%s'", traceFormat))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		doPull := ctx.Bool(commands.FlagPull)
@@ -127,9 +144,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.publish.port", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		doPublishExposedPorts := ctx.Bool(commands.FlagPublishExposedPorts)
@@ -141,9 +158,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.continue.after", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		if !httpProbeOpts.Do && continueAfter.Mode == "probe" {
@@ -160,15 +177,53 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.host.exec.file", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		if len(moreHostExecProbes) > 0 {
 			hostExecProbes = append(hostExecProbes, moreHostExecProbes...)
 		}
 
+		sessionRecordFile, sessionReplayFile, err := commands.GetExecSessionFiles(ctx)
+		if err != nil {
+			xc.Out.Error("param.session", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		continueAfter.SessionRecordFile = sessionRecordFile
+		continueAfter.SessionReplayFile = sessionReplayFile
+		if sessionRecordFile != "" || sessionReplayFile != "" {
+			if !strings.Contains(continueAfter.Mode, config.CAMSession) {
+				if continueAfter.Mode == "" || continueAfter.Mode == config.CAMEnter {
+					//the interactive session replaces the 'enter' mode
+					continueAfter.Mode = config.CAMSession
+				} else {
+					continueAfter.Mode = fmt.Sprintf("%s&%s", continueAfter.Mode, config.CAMSession)
+				}
+
+				xc.Out.Info("session",
+					ovars{
+						"message": fmt.Sprintf("updating continue-after mode to %s", continueAfter.Mode),
+					})
+			}
+		}
+
+		execHooks, err := commands.ParseExecHooks(ctx.StringSlice(commands.FlagExecHook))
+		if err != nil {
+			xc.Out.Error("param.exec.hook", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
 		if strings.Contains(continueAfter.Mode, config.CAMHostExec) &&
 			len(hostExecProbes) == 0 {
 			if continueAfter.Mode == config.CAMHostExec {
@@ -211,9 +266,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.container.overrides", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		volumeMounts, err := commands.ParseVolumeMounts(ctx.StringSlice(commands.FlagMount))
@@ -221,9 +276,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.mount", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		excludePatterns := commands.ParsePaths(ctx.StringSlice(commands.FlagExcludePattern))
@@ -234,9 +289,9 @@ var CLI = &cli.Command{
 		//	xc.Out.Error("param.error.include.path.file", err.Error())
 		//	xc.Out.State("exited",
 		//		ovars{
-		//			"exit.code": -1,
+		//			"exit.code": commands.ECTCommon | commands.ECBadParams,
 		//		})
-		//	xc.Exit(-1)
+		//	xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		//} else {
 		//	for k, v := range moreIncludePaths {
 		//		includePaths[k] = v
@@ -249,9 +304,9 @@ var CLI = &cli.Command{
 		//	xc.Out.Error("param.error.path.perms.file", err.Error())
 		//	xc.Out.State("exited",
 		//		ovars{
-		//			"exit.code": -1,
+		//			"exit.code": commands.ECTCommon | commands.ECBadParams,
 		//		})
-		//	xc.Exit(-1)
+		//	xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		//} else {
 		//	for k, v := range morePathPerms {
 		//		pathPerms[k] = v
@@ -281,7 +336,7 @@ var CLI = &cli.Command{
 			commandReport = ""
 		}
 
-		OnCommand(
+		xc.FailOn(OnCommand(
 			xc,
 			gcvalues,
 			targetRef,
@@ -295,6 +350,7 @@ var CLI = &cli.Command{
 			portBindings,
 			doPublishExposedPorts,
 			hostExecProbes,
+			execHooks,
 			doRmFileArtifacts,
 			doCopyMetaArtifacts,
 			doRunTargetAsUser,
@@ -319,7 +375,9 @@ var CLI = &cli.Command{
 			ctx.String(commands.FlagSensorIPCEndpoint),
 			ctx.String(commands.FlagSensorIPCMode),
 			ctx.String(commands.FlagLogLevel),
-			ctx.String(commands.FlagLogFormat))
+			ctx.String(commands.FlagLogFormat),
+			ctx.String(commands.FlagTraceOutput),
+			traceFormat))
 
 		return nil
 	},
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/trace"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
@@ -33,8 +35,23 @@ const (
 	ecpOther = iota + 1
 	ecpNoEntrypoint
 	ecpImageNotFound
+	ecpProbeFailureThreshold
+	ecpNoPorts
+	ecpNoSuccessfulProbes
+	ecpNoData
 )
 
+// exitCodes documents the profile command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTProfile | ecpOther, Name: "profile.other", Description: "other profile command error"},
+	{Code: commands.ECTProfile | ecpNoEntrypoint, Name: "profile.no.entrypoint", Description: "target image has no entrypoint or cmd (use --entrypoint or --cmd)"},
+	{Code: commands.ECTProfile | ecpImageNotFound, Name: "profile.image.not.found", Description: "target image not found"},
+	{Code: commands.ECTProfile | ecpProbeFailureThreshold, Name: "profile.probe.failure.threshold", Description: "HTTP probe failure threshold exceeded (--http-probe-fail-threshold)"},
+	{Code: commands.ECTProfile | ecpNoPorts, Name: "profile.no.ports", Description: "no exposed ports for HTTP probing (use --expose or --http-probe=false)"},
+	{Code: commands.ECTProfile | ecpNoSuccessfulProbes, Name: "profile.no.successful.probes", Description: "no successful HTTP probe calls (--http-probe-exit-on-failure)"},
+	{Code: commands.ECTProfile | ecpNoData, Name: "profile.no.data", Description: "no data collected from the temporary container"},
+}
+
 type ovars = app.OutVars
 
 //note: the runtime part of the 'profile' logic is a bit behind 'build'
@@ -55,6 +72,7 @@ func OnCommand(
 	portBindings map[docker.Port][]docker.PortBinding,
 	doPublishExposedPorts bool,
 	hostExecProbes []string,
+	execHooks []config.ExecHook,
 	doRmFileArtifacts bool,
 	copyMetaArtifactsLocation string,
 	doRunTargetAsUser bool,
@@ -79,7 +97,9 @@ func OnCommand(
 	sensorIPCEndpoint string,
 	sensorIPCMode string,
 	logLevel string,
-	logFormat string) {
+	logFormat string,
+	traceOutput string,
+	traceFormat string) error {
 	printState := true
 	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
 
@@ -88,6 +108,7 @@ func OnCommand(
 	cmdReport := report.NewProfileCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
 	cmdReport.OriginalImage = targetRef
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
 
 	xc.Out.State("started")
 	xc.Out.Info("params",
@@ -95,6 +116,8 @@ func OnCommand(
 			"target": targetRef,
 		})
 
+	telemetry := commands.NewTelemetry(xc, gparams, Name, targetRef, traceOutput != "", logger)
+
 	client, err := dockerclient.New(gparams.ClientConfig)
 	if err == dockerclient.ErrNoDockerInfo {
 		exitMsg := "missing Docker connection info"
@@ -114,9 +137,12 @@ func OnCommand(
 				"version":   v.Current(),
 				"location":  fsutil.ExeDir(),
 			})
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
+	}
+
+	if err != nil {
+		return err
 	}
-	errutil.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(fmt.Sprintf("cmd=%s", Name), logger, client, false, gparams.InContainer, gparams.IsDSImage)
@@ -136,7 +162,7 @@ func OnCommand(
 				"version":   v.Current(),
 				"location":  fsutil.ExeDir(),
 			})
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
 	if !commands.ConfirmNetwork(logger, client, overrides.Network) {
@@ -153,11 +179,14 @@ func OnCommand(
 				"version":   v.Current(),
 				"location":  fsutil.ExeDir(),
 			})
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
 	imageInspector, err := image.NewInspector(client, targetRef)
-	errutil.FailOn(err)
+	if err != nil {
+		return err
+	}
+	imageInspector.ResultCache = commands.ResultCache(gparams)
 
 	if imageInspector.NoImage() {
 		if doPull {
@@ -168,8 +197,15 @@ func OnCommand(
 					"message": "trying to pull target image",
 				})
 
-			err := imageInspector.Pull(doShowPullLogs, dockerConfigPath, registryAccount, registrySecret)
-			errutil.FailOn(err)
+			pullDone := telemetry.Phase(commands.PhasePull)
+			pullProgress := xc.Out.NewProgress("image.pull", 0, app.ProgressUnitBytes)
+			imageInspector.PullProgress = pullProgress.Update
+			err := imageInspector.Pull(xc.Context, doShowPullLogs, dockerConfigPath, registryAccount, registrySecret)
+			pullProgress.Done()
+			if err != nil {
+				return commands.ImagePullError(err)
+			}
+			pullDone()
 		} else {
 			xc.Out.Info("target.image.error",
 				ovars{
@@ -178,9 +214,9 @@ func OnCommand(
 					"message": "make sure the target image already exists locally",
 				})
 
-			exitCode := commands.ECTCommon | ecpImageNotFound
+			exitCode := commands.ECTProfile | ecpImageNotFound
 			xc.Out.State("exited", ovars{"exit.code": exitCode})
-			xc.Exit(exitCode)
+			return app.NewExitError(exitCode)
 		}
 	}
 
@@ -191,9 +227,15 @@ func OnCommand(
 
 	logger.Info("inspecting 'fat' image metadata...")
 	err = imageInspector.Inspect()
-	errutil.FailOn(err)
+	if err != nil {
+		return err
+	}
+
+	localVolumePath, artifactLocation, statePath, stateKey, err := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+	if err != nil {
+		return commands.StateDirError(err)
+	}
 
-	localVolumePath, artifactLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
 	imageInspector.ArtifactLocation = artifactLocation
 	logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
 
@@ -206,8 +248,12 @@ func OnCommand(
 		})
 
 	logger.Info("processing 'fat' image info...")
+	reverseDone := telemetry.Phase(commands.PhaseReverse)
 	err = imageInspector.ProcessCollectedData()
-	errutil.FailOn(err)
+	if err != nil {
+		return err
+	}
+	reverseDone()
 
 	xc.Out.State("image.inspection.done")
 	xc.Out.State("container.inspection.start")
@@ -248,6 +294,8 @@ func OnCommand(
 		nil,   //includePaths,
 		nil,   //includeBins,
 		nil,   //includeExes,
+		nil,   //includePkgs,
+		false, //doIncludePkgDeps,
 		false, //doIncludeShell,
 		false, //doIncludeCertAll
 		false, //doIncludeCertBundles
@@ -255,6 +303,8 @@ func OnCommand(
 		false, //doIncludeCertPKAll
 		false, //doIncludeCertPKDirs
 		false, //doIncludeNew
+		false, //doIncludeEssentials
+		nil,   //runAsUser
 		nil,   //selectedNetNames
 		//nil,
 		gparams.Debug,
@@ -266,7 +316,13 @@ func OnCommand(
 		sensorIPCMode,
 		printState,
 		config.AppNodejsInspectOptions{})
-	errutil.FailOn(err)
+	if err != nil {
+		return err
+	}
+
+	traceRecorder := telemetry.Recorder()
+	containerInspector.Trace = traceRecorder
+	profileDone := telemetry.Phase(commands.PhaseProfile)
 
 	if len(containerInspector.FatContainerCmd) == 0 {
 		xc.Out.Info("target.image.error",
@@ -276,14 +332,16 @@ func OnCommand(
 				"message": "no ENTRYPOINT/CMD",
 			})
 
-		exitCode := commands.ECTBuild | ecpNoEntrypoint
+		exitCode := commands.ECTProfile | ecpNoEntrypoint
 		xc.Out.State("exited", ovars{"exit.code": exitCode})
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
 	logger.Info("starting instrumented 'fat' container...")
 	err = containerInspector.RunContainer()
-	errutil.FailOn(err)
+	if err != nil {
+		return app.WrapError(err, app.ErrorCategoryContainer, "container.run", commands.HintContainerLogs)
+	}
 
 	xc.Out.Info("container",
 		ovars{
@@ -296,6 +354,10 @@ func OnCommand(
 
 	logger.Info("watching container monitor...")
 
+	traceMonitorDone := traceRecorder.Begin(trace.TrackCommand, "command", "container.monitor")
+	commands.RunExecHooks(xc, printState, execHooks, config.ExecHookAfterStart,
+		containerInspector.APIClient, containerInspector.ContainerID)
+
 	if config.CAMProbe == continueAfter.Mode {
 		httpProbeOpts.Do = true
 	}
@@ -304,9 +366,11 @@ func OnCommand(
 	if httpProbeOpts.Do {
 		var err error
 		probe, err = http.NewContainerProbe(xc, containerInspector, httpProbeOpts, printState)
-		errutil.FailOn(err)
+		if err != nil {
+			return err
+		}
 
-		if len(probe.Ports()) == 0 {
+		if len(probe.Ports()) == 0 && len(probe.UDPPorts()) == 0 {
 			xc.Out.State("http.probe.error",
 				ovars{
 					"error":   "NO EXPOSED PORTS",
@@ -317,12 +381,25 @@ func OnCommand(
 			containerInspector.FinishMonitoring()
 			_ = containerInspector.ShutdownContainer()
 
-			xc.Out.State("exited", ovars{"exit.code": -1})
-			xc.Exit(-1)
+			xc.Out.State("exited", ovars{"exit.code": commands.ECTProfile | ecpNoPorts})
+			return app.NewExitError(commands.ECTProfile | ecpNoPorts)
 		}
 
+		probe.SetTrace(traceRecorder)
+		probe.SetRoundHook(func(round int) {
+			commands.RunExecHooks(xc, printState, execHooks, config.ExecHookProbeRound,
+				containerInspector.APIClient, containerInspector.ContainerID)
+		})
+
+		probeDone := telemetry.Phase(commands.PhaseProbe)
+		probeProgress := xc.Out.NewSpinner("http.probe")
 		probe.Start()
 		continueAfter.ContinueChan = probe.DoneChan()
+		go func(done <-chan struct{}) {
+			<-done
+			probeProgress.Done()
+			probeDone()
+		}(probe.DoneChan())
 	}
 
 	continueAfterMsg := "provide the expected input to allow the container inspector to continue its execution"
@@ -423,30 +500,78 @@ func OnCommand(
 					"message": "HTTP probe is done",
 				})
 
+			//the probe results are saved in the report even if the command exits on the probe failures
+			if probe != nil {
+				cmdReport.HTTPProbe = probe.Report()
+			}
+
 			if probe != nil && probe.CallCount > 0 && probe.OkCount == 0 && httpProbeOpts.ExitOnFailure {
 				xc.Out.Error("probe.error", "no.successful.calls")
 
 				containerInspector.ShowContainerLogs()
-				xc.Out.State("exited", ovars{"exit.code": -1})
-				xc.Exit(-1)
+				saveProbeFailureReport(xc, cmdReport, "probe.no.successful.calls")
+				xc.Out.State("exited", ovars{"exit.code": commands.ECTProfile | ecpNoSuccessfulProbes})
+				return app.NewExitError(commands.ECTProfile | ecpNoSuccessfulProbes)
+			}
+
+			if probe != nil && probe.FailureThresholdExceeded() {
+				xc.Out.Error("probe.error", "failure.threshold.exceeded")
+				xc.Out.Info("probe.failures",
+					ovars{
+						"commands":        probe.CmdCount,
+						"failed.commands": probe.CmdFailCount,
+						"threshold":       httpProbeOpts.FailThreshold,
+					})
+
+				containerInspector.ShowContainerLogs()
+				saveProbeFailureReport(xc, cmdReport, "probe.failure.threshold.exceeded")
+				exitCode := commands.ECTProfile | ecpProbeFailureThreshold
+				xc.Out.State("exited", ovars{"exit.code": exitCode})
+				return app.NewExitError(exitCode)
 			}
 		case config.CAMHostExec:
 			commands.RunHostExecProbes(printState, xc, hostExecProbes)
+		case config.CAMSession:
+			failCount := commands.RunExecSession(xc, continueAfter, targetRef,
+				func(command string) (int, error) {
+					return commands.ContainerExec(containerInspector.APIClient, containerInspector.ContainerID, command, "session")
+				})
+			xc.Out.Info("continue.after",
+				ovars{
+					"mode":     config.CAMSession,
+					"failures": failCount,
+				})
+
+			if failCount > 0 && continueAfter.SessionReplayFile != "" {
+				//replayed sessions need to be deterministic
+				execFail = true
+			}
 		case config.CAMAppExit:
 			xc.Out.Prompt("waiting for the target app to exit")
 			//TBD
 		default:
-			errutil.Fail("unknown continue-after mode")
+			return commands.UnknownContinueAfterModeError(mode)
 		}
 	}
 
+	commands.RunExecHooks(xc, printState, execHooks, config.ExecHookBeforeCommit,
+		containerInspector.APIClient, containerInspector.ContainerID)
+
+	if probe != nil {
+		cmdReport.HTTPProbe = probe.Report()
+	}
+
+	traceMonitorDone(nil)
+
 	xc.Out.State("container.inspection.finishing")
 
 	containerInspector.FinishMonitoring()
 
 	logger.Info("shutting down 'fat' container...")
 	err = containerInspector.ShutdownContainer()
-	errutil.WarnOn(err)
+	if err != nil {
+		return app.WrapError(err, app.ErrorCategoryContainer, "container.artifacts.copy", commands.HintContainerLogs)
+	}
 
 	if execFail {
 		xc.Out.Info("continue.after",
@@ -462,7 +587,7 @@ func OnCommand(
 			})
 
 		cmdReport.Error = "exec.cmd.failure"
-		xc.Exit(exitCode)
+		return app.NewExitError(exitCode)
 	}
 
 	xc.Out.State("container.inspection.artifact.processing")
@@ -476,13 +601,21 @@ func OnCommand(
 				"location": fsutil.ExeDir(),
 			})
 
-		xc.Out.State("exited", ovars{"exit.code": -1})
-		xc.Exit(-1)
+		xc.Out.State("exited", ovars{"exit.code": commands.ECTProfile | ecpNoData})
+		return app.NewExitError(commands.ECTProfile | ecpNoData)
 	}
 
 	logger.Info("processing instrumented 'fat' container info...")
 	err = containerInspector.ProcessCollectedData()
-	errutil.FailOn(err)
+	if err != nil {
+		return err
+	}
+
+	profileDone()
+	if traceOutput != "" {
+		commands.AddSensorTraceEvents(traceRecorder, artifactLocation, logger)
+		commands.SaveTrace(xc, traceRecorder, traceOutput, traceFormat, logger)
+	}
 
 	xc.Out.State("container.inspection.done")
 	xc.Out.State("completed")
@@ -491,6 +624,11 @@ func OnCommand(
 
 	cmdReport.SeccompProfileName = imageInspector.SeccompProfileName
 	cmdReport.AppArmorProfileName = imageInspector.AppArmorProfileName
+	//the capabilities report is not created without the syscall info
+	hasCapsReport := fsutil.Exists(filepath.Join(artifactLocation, imageInspector.CapabilitiesName))
+	if hasCapsReport {
+		cmdReport.CapabilitiesReportName = imageInspector.CapabilitiesName
+	}
 
 	xc.Out.Info("results",
 		ovars{
@@ -502,12 +640,23 @@ func OnCommand(
 			"artifacts.apparmor": cmdReport.AppArmorProfileName,
 		})
 
+	if hasCapsReport {
+		xc.Out.Info("results",
+			ovars{
+				"artifacts.capabilities": cmdReport.CapabilitiesReportName,
+			})
+	}
+
 	if copyMetaArtifactsLocation != "" {
 		toCopy := []string{
 			report.DefaultContainerReportFileName,
 			imageInspector.SeccompProfileName,
 			imageInspector.AppArmorProfileName,
 		}
+
+		if hasCapsReport {
+			toCopy = append(toCopy, imageInspector.CapabilitiesName)
+		}
 		if !commands.CopyMetaArtifacts(logger,
 			toCopy,
 			artifactLocation, copyMetaArtifactsLocation) {
@@ -539,6 +688,22 @@ func OnCommand(
 	version.PrintCheckVersion(xc, "", vinfo)
 
 	cmdReport.State = command.StateDone
+	telemetry.Finish(cmdReport.State)
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	return nil
+}
+
+// saveProbeFailureReport saves the command report (with the HTTP probe results)
+// when the command exits because of the HTTP probe failures
+func saveProbeFailureReport(xc *app.ExecutionContext, cmdReport *report.ProfileCommand, errName string) {
+	cmdReport.State = command.StateError
+	cmdReport.Error = errName
 	if cmdReport.Save() {
 		xc.Out.Info("report",
 			ovars{
@@ -38,6 +38,26 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagHTTPMaxConcurrentCrawlers), Description: commands.FlagHTTPMaxConcurrentCrawlersUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpec), Description: commands.FlagHTTPProbeAPISpecUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile), Description: commands.FlagHTTPProbeAPISpecFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHeaderFile), Description: commands.FlagHTTPProbeHeaderFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHeaderEnv), Description: commands.FlagHTTPProbeHeaderEnvUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientCert), Description: commands.FlagHTTPProbeClientCertUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientKey), Description: commands.FlagHTTPProbeClientKeyUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeCACert), Description: commands.FlagHTTPProbeCACertUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2TokenURL), Description: commands.FlagHTTPProbeOAuth2TokenURLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientID), Description: commands.FlagHTTPProbeOAuth2ClientIDUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientSecret), Description: commands.FlagHTTPProbeOAuth2ClientSecretUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientSecretFile), Description: commands.FlagHTTPProbeOAuth2ClientSecretFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeOAuth2Scope), Description: commands.FlagHTTPProbeOAuth2ScopeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHARFile), Description: commands.FlagHTTPProbeHARFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeURLFile), Description: commands.FlagHTTPProbeURLFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeFailThreshold), Description: commands.FlagHTTPProbeFailThresholdUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeConcurrency), Description: commands.FlagHTTPProbeConcurrencyUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeRateLimit), Description: commands.FlagHTTPProbeRateLimitUsage},
+		{Text: commands.FullFlagName(commands.FlagProbeWhen), Description: commands.FlagProbeWhenUsage},
+		{Text: commands.FullFlagName(commands.FlagProbeWhenTimeout), Description: commands.FlagProbeWhenTimeoutUsage},
+		{Text: commands.FullFlagName(commands.FlagExecHook), Description: commands.FlagExecHookUsage},
+		{Text: commands.FullFlagName(commands.FlagSessionRecord), Description: commands.FlagSessionRecordUsage},
+		{Text: commands.FullFlagName(commands.FlagSessionReplay), Description: commands.FlagSessionReplayUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishPort), Description: commands.FlagPublishPortUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishExposedPorts), Description: commands.FlagPublishExposedPortsUsage},
 		{Text: commands.FullFlagName(commands.FlagHostExec), Description: commands.FlagHostExecUsage},
@@ -64,25 +84,37 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagExcludePattern), Description: commands.FlagExcludePatternUsage},
 		{Text: commands.FullFlagName(commands.FlagMount), Description: commands.FlagMountUsage},
 		{Text: commands.FullFlagName(commands.FlagContinueAfter), Description: commands.FlagContinueAfterUsage},
+		{Text: commands.FullFlagName(commands.FlagPreset), Description: commands.FlagPresetUsage},
 		{Text: commands.FullFlagName(commands.FlagUseLocalMounts), Description: commands.FlagUseLocalMountsUsage},
 		{Text: commands.FullFlagName(commands.FlagUseSensorVolume), Description: commands.FlagUseSensorVolumeUsage},
 		{Text: commands.FullFlagName(commands.FlagSensorIPCMode), Description: commands.FlagSensorIPCModeUsage},
 		{Text: commands.FullFlagName(commands.FlagSensorIPCEndpoint), Description: commands.FlagSensorIPCEndpointUsage},
+		{Text: commands.FullFlagName(commands.FlagTraceOutput), Description: commands.FlagTraceOutputUsage},
+		{Text: commands.FullFlagName(commands.FlagTraceFormat), Description: commands.FlagTraceFormatUsage},
 	},
 	Values: map[string]commands.CompleteValue{
-		commands.FullFlagName(commands.FlagPull):                   commands.CompleteBool,
-		commands.FullFlagName(commands.FlagShowPullLogs):           commands.CompleteBool,
-		commands.FullFlagName(commands.FlagTarget):                 commands.CompleteTarget,
-		commands.FullFlagName(commands.FlagShowContainerLogs):      commands.CompleteBool,
-		commands.FullFlagName(commands.FlagPublishExposedPorts):    commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbeOff):           commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbe):              commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeCmdFile):       commands.CompleteFile,
-		commands.FullFlagName(commands.FlagHTTPProbeFull):          commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbeExitOnFailure): commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeCrawl):         commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile):   commands.CompleteFile,
-		commands.FullFlagName(commands.FlagHostExecFile):           commands.CompleteFile,
+		commands.FullFlagName(commands.FlagPull):                            commands.CompleteBool,
+		commands.FullFlagName(commands.FlagShowPullLogs):                    commands.CompleteBool,
+		commands.FullFlagName(commands.FlagTarget):                          commands.CompleteTarget,
+		commands.FullFlagName(commands.FlagShowContainerLogs):               commands.CompleteBool,
+		commands.FullFlagName(commands.FlagPublishExposedPorts):             commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeOff):                    commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbe):                       commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeCmdFile):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeFull):                   commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeExitOnFailure):          commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeCrawl):                  commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile):            commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeHeaderFile):             commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientCert):             commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientKey):              commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeCACert):                 commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeOAuth2ClientSecretFile): commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeHARFile):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeURLFile):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagSessionRecord):                   commands.CompleteFile,
+		commands.FullFlagName(commands.FlagSessionReplay):                   commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHostExecFile):                    commands.CompleteFile,
 		//commands.FullFlagName(commands.FlagKeepPerms):              commands.CompleteTBool,
 		commands.FullFlagName(commands.FlagRunTargetAsUser):     commands.CompleteTBool,
 		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
@@ -92,11 +124,14 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		//commands.FullFlagName(commands.FlagIncludePathFile):        commands.CompleteFile,
 		//commands.FullFlagName(commands.FlagIncludeShell):           commands.CompleteBool,
 		commands.FullFlagName(commands.FlagContinueAfter): commands.CompleteContinueAfter,
+		commands.FullFlagName(commands.FlagPreset):        commands.CompletePreset,
 		//commands.FullFlagName(commands.FlagConsoleOutput):   commands.CompleteConsoleOutput,
 		commands.FullFlagName(commands.FlagUseLocalMounts):  commands.CompleteBool,
 		commands.FullFlagName(commands.FlagUseSensorVolume): commands.CompleteVolume,
 		//commands.FullFlagName(commands.FlagKeepTmpArtifacts):       commands.CompleteBool,
 		commands.FullFlagName(commands.FlagCROHostConfigFile): commands.CompleteFile,
 		commands.FullFlagName(commands.FlagSensorIPCMode):     commands.CompleteIPCMode,
+		commands.FullFlagName(commands.FlagTraceOutput):       commands.CompleteFile,
+		commands.FullFlagName(commands.FlagTraceFormat):       commands.CompleteTraceFormat,
 	},
 }
@@ -14,18 +14,35 @@ const (
 	Usage = "Execute registry operations"
 	Alias = "r"
 
-	PullCmdName      = "pull"
-	PullCmdNameUsage = "Pull a container image from registry"
-	PushCmdName      = "push"
-	PushCmdNameUsage = "Push a container image to a registry"
-	CopyCmdName      = "copy"
-	CopyCmdNameUsage = "Copy a container image from one registry to another"
+	PullCmdName         = "pull"
+	PullCmdNameUsage    = "Pull a container image from registry"
+	PushCmdName         = "push"
+	PushCmdNameUsage    = "Push a container image to a registry"
+	CopyCmdName         = "copy"
+	CopyCmdNameUsage    = "Copy a container image from one registry to another"
+	TagsCmdName         = "tags"
+	TagsCmdNameUsage    = "List the repository tags with their digests and sizes"
+	InspectCmdName      = "inspect"
+	InspectCmdNameUsage = "Print the image manifest and config as JSON (including the platform images for the multi-platform images)"
+
+	ManifestCmdName              = "manifest"
+	ManifestCmdNameUsage         = "Assemble and push multi-platform image manifest lists"
+	ManifestCreateCmdName        = "create"
+	ManifestCreateCmdNameUsage   = "Create a local manifest list from the platform images"
+	ManifestAnnotateCmdName      = "annotate"
+	ManifestAnnotateCmdNameUsage = "Update the platform and annotations of an image in the local manifest list"
+	ManifestPushCmdName          = "push"
+	ManifestPushCmdNameUsage     = "Push the local manifest list to the registry"
 )
 
 func fullCmdName(subCmdName string) string {
 	return fmt.Sprintf("%s.%s", Name, subCmdName)
 }
 
+func manifestCmdName(subCmdName string) string {
+	return fullCmdName(fmt.Sprintf("%s.%s", ManifestCmdName, subCmdName))
+}
+
 type PullCommandParams struct {
 	TargetRef    string
 	SaveToDocker bool
@@ -40,6 +57,161 @@ func PullCommandFlagValues(ctx *cli.Context) (*PullCommandParams, error) {
 	return values, nil
 }
 
+// RegistryAuth is the registry access info for one side of the copy operation
+type RegistryAuth struct {
+	Username string
+	Password string
+	Insecure bool
+}
+
+type CopyCommandParams struct {
+	SourceRef        string
+	DestinationRef   string
+	Source           RegistryAuth
+	Destination      RegistryAuth
+	CopySignatures   bool
+	CopyAttestations bool
+}
+
+func CopyCommandFlagValues(ctx *cli.Context) (*CopyCommandParams, error) {
+	values := &CopyCommandParams{
+		SourceRef:      ctx.Args().Get(0),
+		DestinationRef: ctx.Args().Get(1),
+		Source: RegistryAuth{
+			Username: ctx.String(FlagSrcUsername),
+			Password: ctx.String(FlagSrcPassword),
+			Insecure: ctx.Bool(FlagSrcInsecure),
+		},
+		Destination: RegistryAuth{
+			Username: ctx.String(FlagDstUsername),
+			Password: ctx.String(FlagDstPassword),
+			Insecure: ctx.Bool(FlagDstInsecure),
+		},
+		CopySignatures:   ctx.Bool(FlagCopySignatures),
+		CopyAttestations: ctx.Bool(FlagCopyAttestations),
+	}
+
+	return values, nil
+}
+
+type TagsCommandParams struct {
+	Repository string
+	Auth       RegistryAuth
+	PageSize   int
+	DoJSON     bool
+}
+
+func TagsCommandFlagValues(ctx *cli.Context) (*TagsCommandParams, error) {
+	values := &TagsCommandParams{
+		Repository: ctx.Args().First(),
+		Auth:       authFlagValues(ctx),
+		PageSize:   ctx.Int(FlagPageSize),
+		DoJSON:     ctx.Bool(FlagJSON),
+	}
+
+	return values, nil
+}
+
+type InspectCommandParams struct {
+	TargetRef string
+	Auth      RegistryAuth
+	Platform  string
+}
+
+func InspectCommandFlagValues(ctx *cli.Context) (*InspectCommandParams, error) {
+	values := &InspectCommandParams{
+		TargetRef: ctx.String(commands.FlagTarget),
+		Auth:      authFlagValues(ctx),
+		Platform:  ctx.String(FlagPlatform),
+	}
+
+	if values.TargetRef == "" {
+		values.TargetRef = ctx.Args().First()
+	}
+
+	return values, nil
+}
+
+type ManifestCreateCommandParams struct {
+	ListRef string
+	Images  []string
+	Auth    RegistryAuth
+	Amend   bool
+}
+
+func ManifestCreateCommandFlagValues(ctx *cli.Context) (*ManifestCreateCommandParams, error) {
+	values := &ManifestCreateCommandParams{
+		ListRef: ctx.Args().First(),
+		Auth:    authFlagValues(ctx),
+		Amend:   ctx.Bool(FlagAmend),
+	}
+
+	if ctx.Args().Len() > 1 {
+		values.Images = ctx.Args().Slice()[1:]
+	}
+
+	return values, nil
+}
+
+type ManifestAnnotateCommandParams struct {
+	ListRef     string
+	Image       string
+	OS          string
+	Arch        string
+	Variant     string
+	OSVersion   string
+	OSFeatures  []string
+	Annotations map[string]string
+}
+
+func ManifestAnnotateCommandFlagValues(ctx *cli.Context) (*ManifestAnnotateCommandParams, error) {
+	values := &ManifestAnnotateCommandParams{
+		ListRef:    ctx.Args().Get(0),
+		Image:      ctx.Args().Get(1),
+		OS:         ctx.String(FlagOS),
+		Arch:       ctx.String(FlagArch),
+		Variant:    ctx.String(FlagVariant),
+		OSVersion:  ctx.String(FlagOSVersion),
+		OSFeatures: ctx.StringSlice(FlagOSFeatures),
+	}
+
+	var err error
+	if values.Annotations, err = commands.ParseTokenMap(ctx.StringSlice(FlagAnnotation)); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// hasPlatform returns true if any image platform value is set
+func (p *ManifestAnnotateCommandParams) hasPlatform() bool {
+	return p.OS != "" || p.Arch != "" || p.Variant != "" || p.OSVersion != "" || len(p.OSFeatures) > 0
+}
+
+type ManifestPushCommandParams struct {
+	ListRef string
+	Auth    RegistryAuth
+	Purge   bool
+}
+
+func ManifestPushCommandFlagValues(ctx *cli.Context) (*ManifestPushCommandParams, error) {
+	values := &ManifestPushCommandParams{
+		ListRef: ctx.Args().First(),
+		Auth:    authFlagValues(ctx),
+		Purge:   ctx.Bool(FlagPurge),
+	}
+
+	return values, nil
+}
+
+func authFlagValues(ctx *cli.Context) RegistryAuth {
+	return RegistryAuth{
+		Username: ctx.String(FlagUsername),
+		Password: ctx.String(FlagPassword),
+		Insecure: ctx.Bool(FlagInsecure),
+	}
+}
+
 var CLI = &cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
@@ -94,18 +266,229 @@ var CLI = &cli.Command{
 			},
 		},
 		{
-			Name:  CopyCmdName,
-			Usage: CopyCmdNameUsage,
+			Name:      CopyCmdName,
+			Usage:     CopyCmdNameUsage,
+			ArgsUsage: "<SOURCE_IMAGE> <DESTINATION_IMAGE>",
+			Flags: []cli.Flag{
+				cflag(FlagSrcUsername),
+				cflag(FlagSrcPassword),
+				cflag(FlagSrcInsecure),
+				cflag(FlagDstUsername),
+				cflag(FlagDstPassword),
+				cflag(FlagDstInsecure),
+				cflag(FlagCopySignatures),
+				cflag(FlagCopyAttestations),
+			},
 			Action: func(ctx *cli.Context) error {
+				xc := app.NewExecutionContext(fullCmdName(CopyCmdName), ctx.String(commands.FlagConsoleFormat))
+
 				gcvalues, err := commands.GlobalFlagValues(ctx)
 				if err != nil {
 					return err
 				}
 
-				xc := app.NewExecutionContext(fullCmdName(CopyCmdName), ctx.String(commands.FlagConsoleFormat))
-				OnCopyCommand(xc, gcvalues)
+				cparams, err := CopyCommandFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				if cparams.SourceRef == "" || cparams.DestinationRef == "" {
+					xc.Out.Error("param.target", "missing source or destination image")
+					cli.ShowCommandHelp(ctx, CopyCmdName)
+					return nil
+				}
+
+				OnCopyCommand(xc, gcvalues, cparams)
+				return nil
+			},
+		},
+		{
+			Name:      TagsCmdName,
+			Usage:     TagsCmdNameUsage,
+			ArgsUsage: "<REPOSITORY>",
+			Flags: []cli.Flag{
+				cflag(FlagUsername),
+				cflag(FlagPassword),
+				cflag(FlagInsecure),
+				cflag(FlagPageSize),
+				cflag(FlagJSON),
+			},
+			Action: func(ctx *cli.Context) error {
+				cparams, err := TagsCommandFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				if cparams.DoJSON {
+					//only the errors are shown, so the JSON output can be parsed
+					app.SetOutputLevel(app.OutputQuiet)
+				}
+
+				xc := app.NewExecutionContext(fullCmdName(TagsCmdName), ctx.String(commands.FlagConsoleFormat))
+
+				gcvalues, err := commands.GlobalFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				if cparams.Repository == "" {
+					xc.Out.Error("param.repository", "missing repository")
+					cli.ShowCommandHelp(ctx, TagsCmdName)
+					return nil
+				}
+
+				OnTagsCommand(xc, gcvalues, cparams)
+				return nil
+			},
+		},
+		{
+			Name:      InspectCmdName,
+			Usage:     InspectCmdNameUsage,
+			ArgsUsage: "<IMAGE>",
+			Flags: []cli.Flag{
+				commands.Cflag(commands.FlagTarget),
+				cflag(FlagUsername),
+				cflag(FlagPassword),
+				cflag(FlagInsecure),
+				cflag(FlagPlatform),
+			},
+			Action: func(ctx *cli.Context) error {
+				//only the errors are shown, so the JSON output can be parsed
+				app.SetOutputLevel(app.OutputQuiet)
+				xc := app.NewExecutionContext(fullCmdName(InspectCmdName), ctx.String(commands.FlagConsoleFormat))
+
+				gcvalues, err := commands.GlobalFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				cparams, err := InspectCommandFlagValues(ctx)
+				if err != nil {
+					return err
+				}
+
+				if cparams.TargetRef == "" {
+					xc.Out.Error("param.target", "missing target")
+					cli.ShowCommandHelp(ctx, InspectCmdName)
+					return nil
+				}
+
+				OnInspectCommand(xc, gcvalues, cparams)
 				return nil
 			},
 		},
+		{
+			Name:  ManifestCmdName,
+			Usage: ManifestCmdNameUsage,
+			Subcommands: []*cli.Command{
+				{
+					Name:      ManifestCreateCmdName,
+					Usage:     ManifestCreateCmdNameUsage,
+					ArgsUsage: "<MANIFEST_LIST> <IMAGE> [<IMAGE>...]",
+					Flags: []cli.Flag{
+						cflag(FlagAmend),
+						cflag(FlagUsername),
+						cflag(FlagPassword),
+						cflag(FlagInsecure),
+					},
+					Action: func(ctx *cli.Context) error {
+						xc := app.NewExecutionContext(manifestCmdName(ManifestCreateCmdName), ctx.String(commands.FlagConsoleFormat))
+
+						gcvalues, err := commands.GlobalFlagValues(ctx)
+						if err != nil {
+							return err
+						}
+
+						cparams, err := ManifestCreateCommandFlagValues(ctx)
+						if err != nil {
+							return err
+						}
+
+						if cparams.ListRef == "" || len(cparams.Images) == 0 {
+							xc.Out.Error("param.target", "missing manifest list or images")
+							cli.ShowCommandHelp(ctx, ManifestCreateCmdName)
+							return nil
+						}
+
+						OnManifestCreateCommand(xc, gcvalues, cparams)
+						return nil
+					},
+				},
+				{
+					Name:      ManifestAnnotateCmdName,
+					Usage:     ManifestAnnotateCmdNameUsage,
+					ArgsUsage: "<MANIFEST_LIST> [<IMAGE>]",
+					Flags: []cli.Flag{
+						cflag(FlagOS),
+						cflag(FlagArch),
+						cflag(FlagVariant),
+						cflag(FlagOSVersion),
+						cflag(FlagOSFeatures),
+						cflag(FlagAnnotation),
+					},
+					Action: func(ctx *cli.Context) error {
+						xc := app.NewExecutionContext(manifestCmdName(ManifestAnnotateCmdName), ctx.String(commands.FlagConsoleFormat))
+
+						gcvalues, err := commands.GlobalFlagValues(ctx)
+						if err != nil {
+							return err
+						}
+
+						cparams, err := ManifestAnnotateCommandFlagValues(ctx)
+						if err != nil {
+							return err
+						}
+
+						if cparams.ListRef == "" {
+							xc.Out.Error("param.target", "missing manifest list")
+							cli.ShowCommandHelp(ctx, ManifestAnnotateCmdName)
+							return nil
+						}
+
+						if cparams.Image == "" && cparams.hasPlatform() {
+							xc.Out.Error("param.image", "missing image (the platform values are image values)")
+							cli.ShowCommandHelp(ctx, ManifestAnnotateCmdName)
+							return nil
+						}
+
+						OnManifestAnnotateCommand(xc, gcvalues, cparams)
+						return nil
+					},
+				},
+				{
+					Name:      ManifestPushCmdName,
+					Usage:     ManifestPushCmdNameUsage,
+					ArgsUsage: "<MANIFEST_LIST>",
+					Flags: []cli.Flag{
+						cflag(FlagPurge),
+						cflag(FlagUsername),
+						cflag(FlagPassword),
+						cflag(FlagInsecure),
+					},
+					Action: func(ctx *cli.Context) error {
+						xc := app.NewExecutionContext(manifestCmdName(ManifestPushCmdName), ctx.String(commands.FlagConsoleFormat))
+
+						gcvalues, err := commands.GlobalFlagValues(ctx)
+						if err != nil {
+							return err
+						}
+
+						cparams, err := ManifestPushCommandFlagValues(ctx)
+						if err != nil {
+							return err
+						}
+
+						if cparams.ListRef == "" {
+							xc.Out.Error("param.target", "missing manifest list")
+							cli.ShowCommandHelp(ctx, ManifestPushCmdName)
+							return nil
+						}
+
+						OnManifestPushCommand(xc, gcvalues, cparams)
+						return nil
+					},
+				},
+			},
+		},
 	},
 }
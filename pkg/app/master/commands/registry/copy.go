@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	gocrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/registryauth"
+	"github.com/docker-slim/docker-slim/pkg/docker/registryconfig"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// the signatures and the attestations (created by cosign) are stored
+// in the image repository as the '<digest_algorithm>-<digest_hex>.<suffix>' tags
+const (
+	signatureTagSuffix   = "sig"
+	attestationTagSuffix = "att"
+)
+
+// ErrUnsupportedManifest is returned if the source manifest is not an image or an image index
+var ErrUnsupportedManifest = errors.New("unsupported manifest media type")
+
+// parseReference parses the image reference (plain HTTP is allowed for the insecure registries)
+func parseReference(ref string, auth RegistryAuth) (name.Reference, error) {
+	if auth.Insecure {
+		return name.ParseReference(ref, name.Insecure)
+	}
+
+	return name.ParseReference(ref)
+}
+
+// parseRepository parses the repository name (plain HTTP is allowed for the insecure registries)
+func parseRepository(repo string, auth RegistryAuth) (name.Repository, error) {
+	if auth.Insecure {
+		return name.NewRepository(repo, name.Insecure)
+	}
+
+	return name.NewRepository(repo)
+}
+
+// remoteOptions returns the registry access options
+// (the Docker credentials or the native cloud registry credentials are used if the username and password are not provided)
+func remoteOptions(ctx context.Context, auth RegistryAuth) []remote.Option {
+	options := []remote.Option{remote.WithContext(ctx)}
+	if auth.Username != "" || auth.Password != "" {
+		options = append(options, remote.WithAuth(&authn.Basic{
+			Username: auth.Username,
+			Password: auth.Password,
+		}))
+	} else {
+		options = append(options, remote.WithAuthFromKeychain(registryauth.Keychain(ctx, "")))
+	}
+
+	//the registry config CA bundles and proxy are used for all registry connections
+	options = append(options, remote.WithTransport(registryconfig.Transport(auth.Insecure)))
+	return options
+}
+
+// resolvePullReference returns the registry mirror reference and options if one of the registry mirrors has the image
+// (the mirrors use the Docker credentials, so the registry credentials are not sent to the mirrors)
+func resolvePullReference(ctx context.Context, ref name.Reference, options []remote.Option) (name.Reference, []remote.Option) {
+	mirrorOptions := remoteOptions(ctx, RegistryAuth{})
+	if pullRef := registryconfig.ResolvePullReference(ref, mirrorOptions...); pullRef != ref {
+		return pullRef, mirrorOptions
+	}
+
+	return ref, options
+}
+
+// copyManifest copies the image or the image index (with the images for all platforms)
+// without changing the manifests, so the destination has the same digest
+func copyManifest(
+	src name.Reference,
+	dst name.Reference,
+	srcOptions []remote.Option,
+	dstOptions []remote.Option) (*report.RegistryCopyInfo, error) {
+	desc, err := remote.Get(src, srcOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &report.RegistryCopyInfo{
+		Source:      src.String(),
+		Destination: dst.String(),
+		Digest:      desc.Digest.String(),
+		MediaType:   string(desc.MediaType),
+	}
+
+	switch {
+	case desc.MediaType.IsIndex():
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mdesc := range manifest.Manifests {
+			info.Platforms = append(info.Platforms, platformName(mdesc.Platform))
+		}
+
+		err = remote.WriteIndex(dst, idx, dstOptions...)
+		if err != nil {
+			return nil, err
+		}
+	case desc.MediaType.IsImage():
+		img, err := desc.Image()
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := img.ConfigFile()
+		if err != nil {
+			return nil, err
+		}
+
+		info.Platforms = append(info.Platforms, platformName(&gocrv1.Platform{
+			OS:           config.OS,
+			Architecture: config.Architecture,
+		}))
+		if err := remote.Write(dst, img, dstOptions...); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedManifest, desc.MediaType)
+	}
+
+	return info, nil
+}
+
+// copyArtifact copies the signature or attestation tag for the image digest
+// (it returns nil if the source repository doesn't have the artifact tag)
+func copyArtifact(
+	src name.Reference,
+	dst name.Reference,
+	digest string,
+	suffix string,
+	srcOptions []remote.Option,
+	dstOptions []remote.Option) (*report.RegistryCopyInfo, error) {
+	hash, err := gocrv1.NewHash(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := fmt.Sprintf("%s-%s.%s", hash.Algorithm, hash.Hex, suffix)
+	info, err := copyManifest(
+		src.Context().Tag(tag),
+		dst.Context().Tag(tag),
+		srcOptions,
+		dstOptions)
+	if isNotFound(err) {
+		return nil, nil
+	}
+
+	return info, err
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return false
+	}
+
+	if terr.StatusCode == http.StatusNotFound {
+		return true
+	}
+
+	for _, diag := range terr.Errors {
+		if diag.Code == transport.ManifestUnknownErrorCode || diag.Code == transport.NameUnknownErrorCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func platformName(platform *gocrv1.Platform) string {
+	if platform == nil {
+		return "unknown"
+	}
+
+	name := fmt.Sprintf("%s/%s", platform.OS, platform.Architecture)
+	if platform.Variant != "" {
+		name = fmt.Sprintf("%s/%s", name, platform.Variant)
+	}
+
+	return name
+}
@@ -8,11 +8,61 @@ import (
 // Registry command flag names
 const (
 	FlagSaveToDocker = "save-to-docker"
+
+	FlagSrcUsername      = "src-username"
+	FlagSrcPassword      = "src-password"
+	FlagSrcInsecure      = "src-insecure"
+	FlagDstUsername      = "dst-username"
+	FlagDstPassword      = "dst-password"
+	FlagDstInsecure      = "dst-insecure"
+	FlagCopySignatures   = "copy-signatures"
+	FlagCopyAttestations = "copy-attestations"
+
+	FlagUsername = "username"
+	FlagPassword = "password"
+	FlagInsecure = "insecure"
+	FlagPageSize = "page-size"
+	FlagPlatform = "platform"
+	FlagJSON     = "json"
+
+	FlagAmend      = "amend"
+	FlagOS         = "os"
+	FlagArch       = "arch"
+	FlagVariant    = "variant"
+	FlagOSVersion  = "os-version"
+	FlagOSFeatures = "os-features"
+	FlagAnnotation = "annotation"
+	FlagPurge      = "purge"
 )
 
 // Registry command flag usage info
 const (
 	FlagSaveToDockerUsage = "Save pulled image to docker"
+
+	FlagSrcUsernameUsage      = "Source registry username (the Docker credentials are used by default)"
+	FlagSrcPasswordUsage      = "Source registry password or token"
+	FlagSrcInsecureUsage      = "Allow plain HTTP (and self-signed TLS certificates) for the source registry"
+	FlagDstUsernameUsage      = "Destination registry username (the Docker credentials are used by default)"
+	FlagDstPasswordUsage      = "Destination registry password or token"
+	FlagDstInsecureUsage      = "Allow plain HTTP (and self-signed TLS certificates) for the destination registry"
+	FlagCopySignaturesUsage   = "Copy the image signatures (the cosign '.sig' tags)"
+	FlagCopyAttestationsUsage = "Copy the image attestations (the cosign '.att' tags)"
+
+	FlagUsernameUsage = "Registry username (the Docker credentials are used by default)"
+	FlagPasswordUsage = "Registry password or token"
+	FlagInsecureUsage = "Allow plain HTTP (and self-signed TLS certificates) for the registry"
+	FlagPageSizeUsage = "Number of tags to request per page (the registry default is used if it's not set)"
+	FlagPlatformUsage = "Inspect only the image for the platform ('os/arch[/variant]') if the image is a multi-platform image"
+	FlagJSONUsage     = "Print the tag list as JSON (without the other console output)"
+
+	FlagAmendUsage      = "Add the images to the existing local manifest list"
+	FlagOSUsage         = "Set the image operating system"
+	FlagArchUsage       = "Set the image architecture"
+	FlagVariantUsage    = "Set the image architecture variant"
+	FlagOSVersionUsage  = "Set the image operating system version"
+	FlagOSFeaturesUsage = "Set the image operating system features"
+	FlagAnnotationUsage = "Add an annotation ('key=value') to the image (or to the manifest list if the image is not provided)"
+	FlagPurgeUsage      = "Remove the local manifest list after it's pushed"
 )
 
 var Flags = map[string]cli.Flag{
@@ -22,6 +72,112 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagSaveToDockerUsage,
 		EnvVars: []string{"DSLIM_REG_PULL_SAVE_TO_DOCKER"},
 	},
+	FlagSrcUsername: &cli.StringFlag{
+		Name:    FlagSrcUsername,
+		Usage:   FlagSrcUsernameUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_SRC_USERNAME"},
+	},
+	FlagSrcPassword: &cli.StringFlag{
+		Name:    FlagSrcPassword,
+		Usage:   FlagSrcPasswordUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_SRC_PASSWORD"},
+	},
+	FlagSrcInsecure: &cli.BoolFlag{
+		Name:    FlagSrcInsecure,
+		Usage:   FlagSrcInsecureUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_SRC_INSECURE"},
+	},
+	FlagDstUsername: &cli.StringFlag{
+		Name:    FlagDstUsername,
+		Usage:   FlagDstUsernameUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_DST_USERNAME"},
+	},
+	FlagDstPassword: &cli.StringFlag{
+		Name:    FlagDstPassword,
+		Usage:   FlagDstPasswordUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_DST_PASSWORD"},
+	},
+	FlagDstInsecure: &cli.BoolFlag{
+		Name:    FlagDstInsecure,
+		Usage:   FlagDstInsecureUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_DST_INSECURE"},
+	},
+	FlagCopySignatures: &cli.BoolFlag{
+		Name:    FlagCopySignatures,
+		Usage:   FlagCopySignaturesUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_SIGNATURES"},
+	},
+	FlagCopyAttestations: &cli.BoolFlag{
+		Name:    FlagCopyAttestations,
+		Usage:   FlagCopyAttestationsUsage,
+		EnvVars: []string{"DSLIM_REG_COPY_ATTESTATIONS"},
+	},
+	FlagUsername: &cli.StringFlag{
+		Name:    FlagUsername,
+		Usage:   FlagUsernameUsage,
+		EnvVars: []string{"DSLIM_REG_USERNAME"},
+	},
+	FlagPassword: &cli.StringFlag{
+		Name:    FlagPassword,
+		Usage:   FlagPasswordUsage,
+		EnvVars: []string{"DSLIM_REG_PASSWORD"},
+	},
+	FlagInsecure: &cli.BoolFlag{
+		Name:    FlagInsecure,
+		Usage:   FlagInsecureUsage,
+		EnvVars: []string{"DSLIM_REG_INSECURE"},
+	},
+	FlagPageSize: &cli.IntFlag{
+		Name:    FlagPageSize,
+		Usage:   FlagPageSizeUsage,
+		EnvVars: []string{"DSLIM_REG_TAGS_PAGE_SIZE"},
+	},
+	FlagPlatform: &cli.StringFlag{
+		Name:    FlagPlatform,
+		Usage:   FlagPlatformUsage,
+		EnvVars: []string{"DSLIM_REG_INSPECT_PLATFORM"},
+	},
+	FlagJSON: &cli.BoolFlag{
+		Name:    FlagJSON,
+		Usage:   FlagJSONUsage,
+		EnvVars: []string{"DSLIM_REG_TAGS_JSON"},
+	},
+	FlagAmend: &cli.BoolFlag{
+		Name:    FlagAmend,
+		Usage:   FlagAmendUsage,
+		EnvVars: []string{"DSLIM_REG_MANIFEST_AMEND"},
+	},
+	FlagOS: &cli.StringFlag{
+		Name:  FlagOS,
+		Usage: FlagOSUsage,
+	},
+	FlagArch: &cli.StringFlag{
+		Name:  FlagArch,
+		Usage: FlagArchUsage,
+	},
+	FlagVariant: &cli.StringFlag{
+		Name:  FlagVariant,
+		Usage: FlagVariantUsage,
+	},
+	FlagOSVersion: &cli.StringFlag{
+		Name:  FlagOSVersion,
+		Usage: FlagOSVersionUsage,
+	},
+	FlagOSFeatures: &cli.StringSliceFlag{
+		Name:  FlagOSFeatures,
+		Value: cli.NewStringSlice(),
+		Usage: FlagOSFeaturesUsage,
+	},
+	FlagAnnotation: &cli.StringSliceFlag{
+		Name:  FlagAnnotation,
+		Value: cli.NewStringSlice(),
+		Usage: FlagAnnotationUsage,
+	},
+	FlagPurge: &cli.BoolFlag{
+		Name:    FlagPurge,
+		Usage:   FlagPurgeUsage,
+		EnvVars: []string{"DSLIM_REG_MANIFEST_PURGE"},
+	},
 }
 
 func cflag(name string) cli.Flag {
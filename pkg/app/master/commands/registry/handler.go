@@ -1,21 +1,26 @@
 package registry
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
-	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/dustin/go-humanize"
 	"github.com/google/go-containerregistry/pkg/name"
 	gocrv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/report"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 )
@@ -24,6 +29,34 @@ const appName = commands.AppName
 
 type ovars = app.OutVars
 
+// Registry command exit codes
+const (
+	ecrOther = iota + 1
+	ecrBadReference
+	ecrCopyError
+	ecrArtifactCopyError
+	ecrTagsError
+	ecrInspectError
+	ecrBadPlatform
+	ecrManifestListError
+	ecrManifestListNotFound
+	ecrManifestPushError
+)
+
+// exitCodes documents the registry command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTRegistry | ecrOther, Name: "registry.other", Description: "other registry command error"},
+	{Code: commands.ECTRegistry | ecrBadReference, Name: "registry.bad.reference", Description: "bad source or destination image reference"},
+	{Code: commands.ECTRegistry | ecrCopyError, Name: "registry.copy.error", Description: "error copying the image"},
+	{Code: commands.ECTRegistry | ecrArtifactCopyError, Name: "registry.copy.artifact.error", Description: "error copying the image signatures or attestations"},
+	{Code: commands.ECTRegistry | ecrTagsError, Name: "registry.tags.error", Description: "error listing the repository tags"},
+	{Code: commands.ECTRegistry | ecrInspectError, Name: "registry.inspect.error", Description: "error fetching the image manifest or config"},
+	{Code: commands.ECTRegistry | ecrBadPlatform, Name: "registry.bad.platform", Description: "bad platform value"},
+	{Code: commands.ECTRegistry | ecrManifestListError, Name: "registry.manifest.error", Description: "error creating or updating the local manifest list"},
+	{Code: commands.ECTRegistry | ecrManifestListNotFound, Name: "registry.manifest.not.found", Description: "local manifest list (or the image in the manifest list) not found"},
+	{Code: commands.ECTRegistry | ecrManifestPushError, Name: "registry.manifest.push.error", Description: "error pushing the manifest list"},
+}
+
 // OnPullCommand implements the 'registry pull' docker-slim command
 func OnPullCommand(
 	xc *app.ExecutionContext,
@@ -66,25 +99,29 @@ func OnPullCommand(
 			})
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
 	//todo: pass a custom client to Pull (based on `client` above)
-	targetImage, err := crane.Pull(cparams.TargetRef)
-	errutil.FailOn(err)
+	ref, err := name.ParseReference(cparams.TargetRef)
+	xc.FailOn(err)
+
+	pullRef, options := resolvePullReference(xc.Context, ref, remoteOptions(xc.Context, RegistryAuth{}))
+	targetImage, err := remote.Image(pullRef, options...)
+	xc.FailOn(err)
 	outImageInfo(xc, targetImage)
 
 	if cparams.SaveToDocker {
 		xc.Out.State("save.docker.start")
 
 		tag, err := name.NewTag(cparams.TargetRef)
-		errutil.FailOn(err)
+		xc.FailOn(err)
 
 		rawResponse, err := daemon.Write(tag, targetImage)
-		errutil.FailOn(err)
+		xc.FailOn(err)
 		logger.Tracef("Image save to Docker response: %v", rawResponse)
 
 		xc.Out.State("save.docker.done")
@@ -142,7 +179,7 @@ func OnPushCommand(
 			})
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
@@ -165,50 +202,577 @@ func OnPushCommand(
 }
 
 // OnCopyCommand implements the 'registry copy' docker-slim command
+// (the image is copied from one registry to another without the Docker daemon)
 func OnCopyCommand(
 	xc *app.ExecutionContext,
-	gparams *commands.GenericParams) {
+	gparams *commands.GenericParams,
+	cparams *CopyCommandParams) {
 	cmdName := fullCmdName(CopyCmdName)
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
-	prefix := fmt.Sprintf("cmd=%s", cmdName)
 
 	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
 
 	cmdReport := report.NewRegistryCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.SourceRef
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
 
 	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"source":            cparams.SourceRef,
+			"destination":       cparams.DestinationRef,
+			"copy.signatures":   cparams.CopySignatures,
+			"copy.attestations": cparams.CopyAttestations,
+		})
 
-	client, err := dockerclient.New(gparams.ClientConfig)
-	if err == dockerclient.ErrNoDockerInfo {
-		exitMsg := "missing Docker connection info"
-		if gparams.InContainer && gparams.IsDSImage {
-			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+	var dstRef name.Reference
+	srcRef, err := parseReference(cparams.SourceRef, cparams.Source)
+	if err == nil {
+		dstRef, err = parseReference(cparams.DestinationRef, cparams.Destination)
+	}
+
+	if err != nil {
+		xc.Out.Error("param.reference", err.Error())
+
+		exitCode := commands.ECTRegistry | ecrBadReference
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "bad.reference"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	onCopy(xc, logger, cmdReport, cparams, srcRef, dstRef)
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	xc.Out.State("done")
+}
+
+func onCopy(
+	xc *app.ExecutionContext,
+	logger *log.Entry,
+	cmdReport *report.RegistryCommand,
+	cparams *CopyCommandParams,
+	srcRef name.Reference,
+	dstRef name.Reference) {
+	srcOptions := remoteOptions(xc.Context, cparams.Source)
+	dstOptions := remoteOptions(xc.Context, cparams.Destination)
+	srcRef, srcOptions = resolvePullReference(xc.Context, srcRef, srcOptions)
+
+	copyProgress := xc.Out.NewSpinner("image.copy")
+	info, err := copyManifest(srcRef, dstRef, srcOptions, dstOptions)
+	copyProgress.Done()
+	if err != nil {
+		logger.Errorf("copyManifest error - %v", err)
+		xc.Out.Error("registry.copy.error", err.Error())
+
+		exitCode := commands.ECTRegistry | ecrCopyError
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "copy.error"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	cmdReport.Copy = info
+	xc.Out.Info("image.copy",
+		ovars{
+			"source":      info.Source,
+			"destination": info.Destination,
+			"digest":      info.Digest,
+			"media.type":  info.MediaType,
+			"platforms":   strings.Join(info.Platforms, ","),
+		})
+
+	var suffixes []string
+	if cparams.CopySignatures {
+		suffixes = append(suffixes, signatureTagSuffix)
+	}
+
+	if cparams.CopyAttestations {
+		suffixes = append(suffixes, attestationTagSuffix)
+	}
+
+	for _, suffix := range suffixes {
+		artifact, err := copyArtifact(srcRef, dstRef, info.Digest, suffix, srcOptions, dstOptions)
+		if err != nil {
+			logger.Errorf("copyArtifact(%s) error - %v", suffix, err)
+			xc.Out.Error("registry.copy.artifact.error", err.Error())
+
+			exitCode := commands.ECTRegistry | ecrArtifactCopyError
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+
+			cmdReport.Error = "artifact.copy.error"
+			cmdReport.Save()
+			xc.Exit(exitCode)
 		}
 
-		xc.Out.Info("docker.connect.error",
+		if artifact == nil {
+			xc.Out.Info("artifact.copy",
+				ovars{
+					"type":   suffix,
+					"status": "not.found",
+				})
+			continue
+		}
+
+		cmdReport.Artifacts = append(cmdReport.Artifacts, artifact)
+		xc.Out.Info("artifact.copy",
 			ovars{
-				"message": exitMsg,
+				"type":        suffix,
+				"status":      "copied",
+				"destination": artifact.Destination,
+				"digest":      artifact.Digest,
 			})
+	}
+}
 
-		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+// OnTagsCommand implements the 'registry tags' docker-slim command
+func OnTagsCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *TagsCommandParams) {
+	cmdName := fullCmdName(TagsCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewRegistryCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.Repository
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"repository": cparams.Repository,
+			"page.size":  cparams.PageSize,
+		})
+
+	repo, err := parseRepository(cparams.Repository, cparams.Auth)
+	if err != nil {
+		xc.Out.Error("param.repository", err.Error())
+
+		exitCode := commands.ECTRegistry | ecrBadReference
 		xc.Out.State("exited",
 			ovars{
 				"exit.code": exitCode,
-				"version":   v.Current(),
-				"location":  fsutil.ExeDir(),
 			})
+
+		cmdReport.Error = "bad.reference"
+		cmdReport.Save()
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
 
-	if gparams.Debug {
-		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	tags, err := listTags(repo, remoteOptions(xc.Context, cparams.Auth), cparams.PageSize)
+	if err != nil {
+		logger.Errorf("listTags error - %v", err)
+		xc.Out.Error("registry.tags.error", err.Error())
+
+		exitCode := commands.ECTRegistry | ecrTagsError
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "tags.error"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	cmdReport.Tags = tags
+	if cparams.DoJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		xc.FailOn(encoder.Encode(tags))
+	} else {
+		for _, info := range tags {
+			vars := ovars{
+				"tag":        info.Tag,
+				"digest":     info.Digest,
+				"media.type": info.MediaType,
+			}
+
+			if info.Size > 0 {
+				vars["size.human"] = humanize.Bytes(uint64(info.Size))
+			}
+
+			if len(info.Platforms) > 0 {
+				vars["platforms"] = strings.Join(info.Platforms, ",")
+			}
+
+			if info.Error != "" {
+				vars["error"] = info.Error
+			}
+
+			xc.Out.Info("tag", vars)
+		}
+
+		xc.Out.Info("tags",
+			ovars{
+				"repository": repo.String(),
+				"count":      len(tags),
+			})
+	}
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	if !cparams.DoJSON {
+		xc.Out.State("done")
+	}
+}
+
+// OnInspectCommand implements the 'registry inspect' docker-slim command
+// (the manifest and config JSON is printed without the other console output)
+func OnInspectCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *InspectCommandParams) {
+	cmdName := fullCmdName(InspectCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewRegistryCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.TargetRef
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	xc.Out.State("started")
+
+	var platform *gocrv1.Platform
+	if cparams.Platform != "" {
+		var err error
+		platform, err = image.ParsePlatform(cparams.Platform)
+		if err != nil {
+			xc.Out.Error("param.platform", err.Error())
+
+			exitCode := commands.ECTRegistry | ecrBadPlatform
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+
+			cmdReport.Error = "bad.platform"
+			cmdReport.Save()
+			xc.Exit(exitCode)
+		}
+	}
+
+	ref, err := parseReference(cparams.TargetRef, cparams.Auth)
+	if err != nil {
+		xc.Out.Error("param.target", err.Error())
+
+		exitCode := commands.ECTRegistry | ecrBadReference
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "bad.reference"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	ref, options := resolvePullReference(xc.Context, ref, remoteOptions(xc.Context, cparams.Auth))
+	info, err := inspectManifest(ref, options, platform)
+	if err != nil {
+		logger.Errorf("inspectManifest error - %v", err)
+		xc.Out.Error("registry.inspect.error", err.Error())
+
+		exitCode := commands.ECTRegistry | ecrInspectError
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+
+		cmdReport.Error = "inspect.error"
+		cmdReport.Save()
+		xc.Exit(exitCode)
+	}
+
+	cmdReport.Inspect = info
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	xc.FailOn(encoder.Encode(info))
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	cmdReport.Save()
+}
+
+// OnManifestCreateCommand implements the 'registry manifest create' docker-slim command
+// (the manifest list is saved in the state directory, so the images can be added by separate commands)
+func OnManifestCreateCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *ManifestCreateCommandParams) {
+	cmdName := manifestCmdName(ManifestCreateCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewRegistryCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.ListRef
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"manifest.list": cparams.ListRef,
+			"images":        strings.Join(cparams.Images, ","),
+			"amend":         cparams.Amend,
+		})
+
+	listRef, err := parseManifestListName(cparams.ListRef, cparams.Auth)
+	if err != nil {
+		exitOnError(xc, cmdReport, "param.target", err, ecrBadReference, "bad.reference")
+	}
+
+	store := newManifestListStore(gparams.StatePath)
+	list, err := store.load(listRef.Name())
+	switch {
+	case err == nil:
+		if !cparams.Amend {
+			err = fmt.Errorf("%w: '%s' (use --%s to add the images)", ErrManifestListExists, listRef.Name(), FlagAmend)
+			exitOnError(xc, cmdReport, "registry.manifest.error", err, ecrManifestListError, "manifest.list.exists")
+		}
+	case errors.Is(err, ErrManifestListNotFound):
+		list = &report.RegistryManifestList{Name: listRef.Name()}
+	default:
+		logger.Errorf("manifestListStore.load error - %v", err)
+		exitOnError(xc, cmdReport, "registry.manifest.error", err, ecrManifestListError, "manifest.list.error")
+	}
+
+	options := remoteOptions(xc.Context, cparams.Auth)
+	for _, image := range cparams.Images {
+		imageRef, err := parseReference(image, cparams.Auth)
+		if err != nil {
+			exitOnError(xc, cmdReport, "param.image", err, ecrBadReference, "bad.reference")
+		}
+
+		entries, err := manifestListEntries(imageRef, image, options)
+		if err != nil {
+			logger.Errorf("manifestListEntries(%s) error - %v", image, err)
+			exitOnError(xc, cmdReport, "registry.inspect.error", err, ecrInspectError, "inspect.error")
+		}
+
+		addManifestListEntries(list, entries)
+		for _, entry := range entries {
+			xc.Out.Info("manifest.list.image", manifestEntryVars(entry))
+		}
+	}
+
+	if err := store.save(list); err != nil {
+		logger.Errorf("manifestListStore.save error - %v", err)
+		exitOnError(xc, cmdReport, "registry.manifest.error", err, ecrManifestListError, "manifest.list.error")
+	}
+
+	cmdReport.ManifestList = list
+	xc.Out.Info("manifest.list",
+		ovars{
+			"name":       list.Name,
+			"media.type": list.MediaType,
+			"images":     len(list.Manifests),
+		})
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
+	xc.Out.State("done")
+}
+
+// OnManifestAnnotateCommand implements the 'registry manifest annotate' docker-slim command
+func OnManifestAnnotateCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *ManifestAnnotateCommandParams) {
+	cmdName := manifestCmdName(ManifestAnnotateCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewRegistryCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.ListRef
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"manifest.list": cparams.ListRef,
+			"image":         cparams.Image,
+		})
+
+	listRef, err := parseManifestListName(cparams.ListRef, RegistryAuth{})
+	if err != nil {
+		exitOnError(xc, cmdReport, "param.target", err, ecrBadReference, "bad.reference")
+	}
+
+	store := newManifestListStore(gparams.StatePath)
+	list, err := store.load(listRef.Name())
+	if err != nil {
+		logger.Errorf("manifestListStore.load error - %v", err)
+		exitOnManifestLoadError(xc, cmdReport, err)
+	}
+
+	if err := annotateManifestList(list, cparams); err != nil {
+		exitOnError(xc, cmdReport, "registry.manifest.error", err, ecrManifestListNotFound, "manifest.not.found")
+	}
+
+	if err := store.save(list); err != nil {
+		logger.Errorf("manifestListStore.save error - %v", err)
+		exitOnError(xc, cmdReport, "registry.manifest.error", err, ecrManifestListError, "manifest.list.error")
+	}
+
+	cmdReport.ManifestList = list
+	if cparams.Image != "" {
+		entry, err := findManifestListEntry(list, cparams.Image)
+		xc.FailOn(err)
+		xc.Out.Info("manifest.list.image", manifestEntryVars(entry))
+	} else {
+		xc.Out.Info("manifest.list",
+			ovars{
+				"name":        list.Name,
+				"media.type":  list.MediaType,
+				"annotations": len(list.Annotations),
+			})
 	}
 
 	xc.Out.State("completed")
 	cmdReport.State = command.StateCompleted
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+
 	xc.Out.State("done")
+}
+
+// OnManifestPushCommand implements the 'registry manifest push' docker-slim command
+func OnManifestPushCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *ManifestPushCommandParams) {
+	cmdName := manifestCmdName(ManifestPushCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewRegistryCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.ListRef
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"manifest.list": cparams.ListRef,
+			"purge":         cparams.Purge,
+		})
+
+	listRef, err := parseManifestListName(cparams.ListRef, cparams.Auth)
+	if err != nil {
+		exitOnError(xc, cmdReport, "param.target", err, ecrBadReference, "bad.reference")
+	}
+
+	store := newManifestListStore(gparams.StatePath)
+	list, err := store.load(listRef.Name())
+	if err != nil {
+		logger.Errorf("manifestListStore.load error - %v", err)
+		exitOnManifestLoadError(xc, cmdReport, err)
+	}
+
+	pushProgress := xc.Out.NewSpinner("manifest.list.push")
+	list.Digest, err = pushManifestList(list, listRef, cparams.Auth, remoteOptions(xc.Context, cparams.Auth))
+	pushProgress.Done()
+	if err != nil {
+		logger.Errorf("pushManifestList error - %v", err)
+		exitOnError(xc, cmdReport, "registry.manifest.push.error", err, ecrManifestPushError, "manifest.push.error")
+	}
+
+	cmdReport.ManifestList = list
+	xc.Out.Info("manifest.list.push",
+		ovars{
+			"name":       list.Name,
+			"digest":     list.Digest,
+			"media.type": list.MediaType,
+			"images":     len(list.Manifests),
+		})
+
+	if cparams.Purge {
+		if err := store.remove(list.Name); err != nil {
+			logger.Errorf("manifestListStore.remove error - %v", err)
+			xc.Out.Info("manifest.list.purge",
+				ovars{
+					"status": "error",
+					"error":  err.Error(),
+				})
+		}
+	}
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
 
 	vinfo := <-viChan
 	version.PrintCheckVersion(xc, "", vinfo)
@@ -220,22 +784,78 @@ func OnCopyCommand(
 				"file": cmdReport.ReportLocation(),
 			})
 	}
+
+	xc.Out.State("done")
+}
+
+func manifestEntryVars(entry *report.RegistryManifestListEntry) ovars {
+	platform := &gocrv1.Platform{
+		OS:           entry.OS,
+		Architecture: entry.Architecture,
+		Variant:      entry.Variant,
+	}
+
+	vars := ovars{
+		"image":      entry.Image,
+		"media.type": entry.MediaType,
+		"platform":   platformName(platform),
+	}
+
+	if entry.OSVersion != "" {
+		vars["os.version"] = entry.OSVersion
+	}
+
+	if len(entry.Annotations) > 0 {
+		vars["annotations"] = len(entry.Annotations)
+	}
+
+	return vars
+}
+
+// exitOnManifestLoadError exits with the 'not found' exit code if the local manifest list doesn't exist
+func exitOnManifestLoadError(xc *app.ExecutionContext, cmdReport *report.RegistryCommand, err error) {
+	if errors.Is(err, ErrManifestListNotFound) {
+		exitOnError(xc, cmdReport, "registry.manifest.error", err, ecrManifestListNotFound, "manifest.list.not.found")
+	}
+
+	exitOnError(xc, cmdReport, "registry.manifest.error", err, ecrManifestListError, "manifest.list.error")
+}
+
+// exitOnError shows the error and exits with the registry command exit code
+func exitOnError(
+	xc *app.ExecutionContext,
+	cmdReport *report.RegistryCommand,
+	event string,
+	err error,
+	ecode int,
+	reportError string) {
+	xc.Out.Error(event, err.Error())
+
+	exitCode := commands.ECTRegistry | ecode
+	xc.Out.State("exited",
+		ovars{
+			"exit.code": exitCode,
+		})
+
+	cmdReport.Error = reportError
+	cmdReport.Save()
+	xc.Exit(exitCode)
 }
 
 func outImageInfo(
 	xc *app.ExecutionContext,
 	targetImage gocrv1.Image) {
 	cn, err := targetImage.ConfigName()
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	d, err := targetImage.Digest()
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	cf, err := targetImage.ConfigFile()
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	m, err := targetImage.Manifest()
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	xc.Out.Info("image.info",
 		ovars{
@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	gocrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// ErrNoPlatformImage is returned if the multi-platform image doesn't have an image for the selected platform
+var ErrNoPlatformImage = errors.New("no image for the platform")
+
+// listTags returns the repository tags with their manifest info
+// (remote.List follows the registry 'Link' headers to get all tag list pages)
+func listTags(repo name.Repository, options []remote.Option, pageSize int) ([]*report.RegistryTagInfo, error) {
+	listOptions := options
+	if pageSize > 0 {
+		listOptions = append(append([]remote.Option{}, options...), remote.WithPageSize(pageSize))
+	}
+
+	tags, err := remote.List(repo, listOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []*report.RegistryTagInfo
+	for _, tag := range tags {
+		infos = append(infos, tagInfo(repo.Tag(tag), options))
+	}
+
+	return infos, nil
+}
+
+// tagInfo fetches the tag manifest (the errors are saved in the tag info, so one bad tag doesn't fail the listing)
+func tagInfo(ref name.Tag, options []remote.Option) *report.RegistryTagInfo {
+	info := &report.RegistryTagInfo{
+		Tag: ref.TagStr(),
+	}
+
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+
+	info.Digest = desc.Digest.String()
+	info.MediaType = string(desc.MediaType)
+
+	switch {
+	case desc.MediaType.IsIndex():
+		manifest, err := gocrv1.ParseIndexManifest(bytes.NewReader(desc.Manifest))
+		if err != nil {
+			info.Error = err.Error()
+			return info
+		}
+
+		for _, mdesc := range manifest.Manifests {
+			info.Platforms = append(info.Platforms, platformName(mdesc.Platform))
+		}
+	case desc.MediaType.IsImage():
+		manifest, err := gocrv1.ParseManifest(bytes.NewReader(desc.Manifest))
+		if err != nil {
+			info.Error = err.Error()
+			return info
+		}
+
+		info.Size = manifest.Config.Size
+		for _, layer := range manifest.Layers {
+			info.Size += layer.Size
+		}
+	}
+
+	return info
+}
+
+// inspectManifest fetches the image manifest and config
+// (and the platform image manifests and configs for the multi-platform images)
+func inspectManifest(
+	ref name.Reference,
+	options []remote.Option,
+	platform *gocrv1.Platform) (*report.RegistryManifestInfo, error) {
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &report.RegistryManifestInfo{
+		Reference: ref.String(),
+		Digest:    desc.Digest.String(),
+		MediaType: string(desc.MediaType),
+		Manifest:  desc.Manifest,
+	}
+
+	switch {
+	case desc.MediaType.IsIndex():
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mdesc := range manifest.Manifests {
+			if !mdesc.MediaType.IsImage() ||
+				(platform != nil && !matchPlatform(mdesc.Platform, platform)) {
+				continue
+			}
+
+			img, err := idx.Image(mdesc.Digest)
+			if err != nil {
+				return nil, err
+			}
+
+			pinfo, err := imageManifestInfo(ref.Context().Digest(mdesc.Digest.String()), img)
+			if err != nil {
+				return nil, err
+			}
+
+			pinfo.Platform = platformName(mdesc.Platform)
+			info.Platforms = append(info.Platforms, pinfo)
+		}
+
+		if platform != nil && len(info.Platforms) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoPlatformImage, platformName(platform))
+		}
+	case desc.MediaType.IsImage():
+		img, err := desc.Image()
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Config, err = img.RawConfigFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+func imageManifestInfo(ref name.Reference, img gocrv1.Image) (*report.RegistryManifestInfo, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, err := img.MediaType()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := img.RawManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := img.RawConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return &report.RegistryManifestInfo{
+		Reference: ref.String(),
+		Digest:    digest.String(),
+		MediaType: string(mediaType),
+		Manifest:  manifest,
+		Config:    config,
+	}, nil
+}
+
+// matchPlatform checks if the image platform is the selected platform
+// (any variant matches if the selected platform doesn't have a variant)
+func matchPlatform(platform, selected *gocrv1.Platform) bool {
+	if platform == nil {
+		return false
+	}
+
+	return platform.OS == selected.OS &&
+		platform.Architecture == selected.Architecture &&
+		(selected.Variant == "" || platform.Variant == selected.Variant)
+}
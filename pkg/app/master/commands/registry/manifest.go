@@ -0,0 +1,342 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	gocrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const manifestListFileExt = ".json"
+
+var (
+	ErrManifestListExists   = errors.New("manifest list already exists")
+	ErrManifestListNotFound = errors.New("manifest list not found")
+	ErrManifestListEmpty    = errors.New("manifest list has no images")
+	ErrManifestNotInList    = errors.New("image not found in the manifest list")
+	ErrManifestListTag      = errors.New("manifest list reference must be a tag")
+)
+
+// manifestListStore keeps the local manifest lists in the state directory
+// (one JSON file per manifest list, so the lists can be assembled by separate commands)
+type manifestListStore struct {
+	location string
+}
+
+func newManifestListStore(statePrefix string) *manifestListStore {
+	return &manifestListStore{
+		location: fsutil.ManifestStateDir(statePrefix),
+	}
+}
+
+func (s *manifestListStore) path(listName string) string {
+	return filepath.Join(s.location, url.QueryEscape(listName)+manifestListFileExt)
+}
+
+// load loads the local manifest list (returns ErrManifestListNotFound if it doesn't exist)
+func (s *manifestListStore) load(listName string) (*report.RegistryManifestList, error) {
+	data, err := ioutil.ReadFile(s.path(listName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: '%s'", ErrManifestListNotFound, listName)
+		}
+
+		return nil, err
+	}
+
+	var list report.RegistryManifestList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+func (s *manifestListStore) save(list *report.RegistryManifestList) error {
+	if err := os.MkdirAll(s.location, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path(list.Name), data, 0644)
+}
+
+func (s *manifestListStore) remove(listName string) error {
+	err := os.Remove(s.path(listName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// parseManifestListName parses the manifest list reference
+// (the full reference name is used, so 'app' and 'docker.io/library/app:latest' are the same list)
+func parseManifestListName(ref string, auth RegistryAuth) (name.Tag, error) {
+	parsed, err := parseReference(ref, auth)
+	if err != nil {
+		return name.Tag{}, err
+	}
+
+	tag, ok := parsed.(name.Tag)
+	if !ok {
+		return name.Tag{}, fmt.Errorf("%w: '%s'", ErrManifestListTag, ref)
+	}
+
+	return tag, nil
+}
+
+// manifestListEntries returns the manifest list entries for the image
+// (all platform images are added if the image is a multi-platform image)
+func manifestListEntries(ref name.Reference, source string, options []remote.Option) ([]*report.RegistryManifestListEntry, error) {
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case desc.MediaType.IsIndex():
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+
+		var entries []*report.RegistryManifestListEntry
+		for _, mdesc := range manifest.Manifests {
+			if !mdesc.MediaType.IsImage() {
+				continue
+			}
+
+			entry := manifestListEntry(ref.Context().Digest(mdesc.Digest.String()), source, mdesc)
+			entry.Annotations = mdesc.Annotations
+			entries = append(entries, entry)
+		}
+
+		return entries, nil
+	case desc.MediaType.IsImage():
+		img, err := desc.Image()
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := img.RawConfigFile()
+		if err != nil {
+			return nil, err
+		}
+
+		//the image config has the same platform fields as the manifest descriptors
+		//(and the config file type doesn't have the 'variant' field)
+		var platform gocrv1.Platform
+		if err := json.Unmarshal(config, &platform); err != nil {
+			return nil, err
+		}
+
+		mdesc := desc.Descriptor
+		mdesc.Platform = &platform
+
+		return []*report.RegistryManifestListEntry{
+			manifestListEntry(ref.Context().Digest(desc.Digest.String()), source, mdesc),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedManifest, desc.MediaType)
+}
+
+func manifestListEntry(ref name.Digest, source string, desc gocrv1.Descriptor) *report.RegistryManifestListEntry {
+	entry := &report.RegistryManifestListEntry{
+		Source:    source,
+		Image:     ref.String(),
+		Digest:    desc.Digest.String(),
+		MediaType: string(desc.MediaType),
+		Size:      desc.Size,
+	}
+
+	if desc.Platform != nil {
+		entry.OS = desc.Platform.OS
+		entry.Architecture = desc.Platform.Architecture
+		entry.Variant = desc.Platform.Variant
+		entry.OSVersion = desc.Platform.OSVersion
+		entry.OSFeatures = desc.Platform.OSFeatures
+	}
+
+	return entry
+}
+
+// addManifestListEntries adds the images to the manifest list
+// (the images already in the list are replaced)
+func addManifestListEntries(list *report.RegistryManifestList, entries []*report.RegistryManifestListEntry) {
+	for _, entry := range entries {
+		var replaced bool
+		for idx, current := range list.Manifests {
+			if current.Digest == entry.Digest {
+				list.Manifests[idx] = entry
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			list.Manifests = append(list.Manifests, entry)
+		}
+	}
+
+	list.MediaType = manifestListMediaType(list)
+}
+
+// manifestListMediaType returns the Docker manifest list media type if all images have the Docker manifests
+// (the OCI image index is used otherwise and for the annotated lists because the Docker manifest lists don't have annotations)
+func manifestListMediaType(list *report.RegistryManifestList) string {
+	if len(list.Annotations) > 0 {
+		return string(types.OCIImageIndex)
+	}
+
+	for _, entry := range list.Manifests {
+		if types.MediaType(entry.MediaType) != types.DockerManifestSchema2 || len(entry.Annotations) > 0 {
+			return string(types.OCIImageIndex)
+		}
+	}
+
+	return string(types.DockerManifestList)
+}
+
+// findManifestListEntry finds the image in the manifest list
+// (the image can be the reference used to add it, its digest reference or its digest)
+func findManifestListEntry(list *report.RegistryManifestList, image string) (*report.RegistryManifestListEntry, error) {
+	for _, entry := range list.Manifests {
+		if entry.Source == image || entry.Image == image || entry.Digest == image {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrManifestNotInList, image)
+}
+
+// pushManifestList pushes the manifest list
+// (the images from the other repositories are copied to the manifest list repository)
+func pushManifestList(
+	list *report.RegistryManifestList,
+	ref name.Tag,
+	auth RegistryAuth,
+	options []remote.Option) (string, error) {
+	if len(list.Manifests) == 0 {
+		return "", fmt.Errorf("%w: '%s'", ErrManifestListEmpty, list.Name)
+	}
+
+	idx := mutate.IndexMediaType(empty.Index, types.MediaType(list.MediaType))
+	for _, entry := range list.Manifests {
+		imageRef, err := parseReference(entry.Image, auth)
+		if err != nil {
+			return "", err
+		}
+
+		img, err := remote.Image(imageRef, options...)
+		if err != nil {
+			return "", err
+		}
+
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+			Add: img,
+			Descriptor: gocrv1.Descriptor{
+				MediaType: types.MediaType(entry.MediaType),
+				Platform: &gocrv1.Platform{
+					OS:           entry.OS,
+					Architecture: entry.Architecture,
+					Variant:      entry.Variant,
+					OSVersion:    entry.OSVersion,
+					OSFeatures:   entry.OSFeatures,
+				},
+				Annotations: entry.Annotations,
+			},
+		})
+	}
+
+	if len(list.Annotations) > 0 {
+		idx = mutate.Annotations(idx, list.Annotations).(gocrv1.ImageIndex)
+	}
+
+	if err := remote.WriteIndex(ref, idx, options...); err != nil {
+		return "", err
+	}
+
+	digest, err := idx.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	return digest.String(), nil
+}
+
+// annotateManifestList updates the image platform and annotations
+// (the manifest list annotations are updated if the image is not selected)
+func annotateManifestList(list *report.RegistryManifestList, params *ManifestAnnotateCommandParams) error {
+	if params.Image == "" {
+		if len(params.Annotations) > 0 && list.Annotations == nil {
+			list.Annotations = map[string]string{}
+		}
+
+		for k, v := range params.Annotations {
+			list.Annotations[k] = v
+		}
+
+		list.MediaType = manifestListMediaType(list)
+		return nil
+	}
+
+	entry, err := findManifestListEntry(list, params.Image)
+	if err != nil {
+		return err
+	}
+
+	if params.OS != "" {
+		entry.OS = params.OS
+	}
+
+	if params.Arch != "" {
+		entry.Architecture = params.Arch
+	}
+
+	if params.Variant != "" {
+		entry.Variant = params.Variant
+	}
+
+	if params.OSVersion != "" {
+		entry.OSVersion = params.OSVersion
+	}
+
+	if len(params.OSFeatures) > 0 {
+		entry.OSFeatures = params.OSFeatures
+	}
+
+	if len(params.Annotations) > 0 && entry.Annotations == nil {
+		entry.Annotations = map[string]string{}
+	}
+
+	for k, v := range params.Annotations {
+		entry.Annotations[k] = v
+	}
+
+	list.MediaType = manifestListMediaType(list)
+	return nil
+}
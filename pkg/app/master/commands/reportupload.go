@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/upload"
+)
+
+// UploadReportFiles uploads the report (and artifact) files to the remote location
+// (upload errors are reported, but they don't fail the command)
+func UploadReportFiles(
+	xc *app.ExecutionContext,
+	location string,
+	files []string,
+	logger *log.Entry) {
+	if location == "" || len(files) == 0 {
+		return
+	}
+
+	uploader, err := upload.New(location)
+	if err != nil {
+		logger.Errorf("UploadReportFiles: error creating uploader (%s) - %v", location, err)
+		xc.Out.Info("report.upload",
+			ovars{
+				"location": location,
+				"error":    err.Error(),
+			})
+		return
+	}
+
+	for _, fpath := range files {
+		if fpath == "" {
+			continue
+		}
+
+		remote, err := uploader.Upload(fpath, filepath.Base(fpath))
+		if err != nil {
+			logger.Errorf("UploadReportFiles: error uploading %s - %v", fpath, err)
+			xc.Out.Info("report.upload",
+				ovars{
+					"file":  fpath,
+					"error": err.Error(),
+				})
+			continue
+		}
+
+		xc.Out.Info("report.upload",
+			ovars{
+				"file":     fpath,
+				"location": remote,
+			})
+	}
+}
+
+// ArtifactFiles returns the regular files in the artifact location
+// (the subdirectories are not included)
+func ArtifactFiles(artifactLocation string) []string {
+	if artifactLocation == "" {
+		return nil
+	}
+
+	infos, err := ioutil.ReadDir(artifactLocation)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, info := range infos {
+		if info.Mode().IsRegular() {
+			files = append(files, filepath.Join(artifactLocation, info.Name()))
+		}
+	}
+
+	return files
+}
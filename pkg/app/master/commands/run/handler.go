@@ -27,6 +27,12 @@ const (
 	ecbTarget
 )
 
+// exitCodes documents the run command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTRun | ecbOther, Name: "run.other", Description: "other run command error"},
+	{Code: commands.ECTRun | ecbTarget, Name: "run.image.not.found", Description: "target image not found"},
+}
+
 type ovars = app.OutVars
 
 // OnCommand implements the 'run' docker-slim command
@@ -70,14 +76,14 @@ func OnCommand(
 			})
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
 	imageInspector, err := image.NewInspector(client, cparams.TargetRef)
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	if imageInspector.NoImage() {
 		if cparams.DoPull {
@@ -88,8 +94,11 @@ func OnCommand(
 					"message": "trying to pull target image",
 				})
 
-			err := imageInspector.Pull(cparams.DoShowPullLogs, cparams.DockerConfigPath, cparams.RegistryAccount, cparams.RegistrySecret)
-			errutil.FailOn(err)
+			pullProgress := xc.Out.NewProgress("image.pull", 0, app.ProgressUnitBytes)
+			imageInspector.PullProgress = pullProgress.Update
+			err := imageInspector.Pull(xc.Context, cparams.DoShowPullLogs, cparams.DockerConfigPath, cparams.RegistryAccount, cparams.RegistrySecret)
+			pullProgress.Done()
+			xc.FailOn(commands.ImagePullError(err))
 		} else {
 			xc.Out.Info("target.image.error",
 				ovars{
@@ -139,7 +148,16 @@ func OnCommand(
 		true,
 		true)
 
-	errutil.FailOn(err)
+	xc.FailOn(err)
+
+	if cparams.DoRemoveOnExit {
+		//remove the container if the command is interrupted
+		xc.AddCleanupHandler(func() {
+			if xc.Context.Err() != nil {
+				_ = exe.Cleanup()
+			}
+		})
+	}
 
 	continueCh := make(chan struct{})
 	go func() {
@@ -175,7 +193,7 @@ func OnCommand(
 	}()
 
 	err = exe.Start()
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	<-continueCh
 
@@ -13,10 +13,22 @@ const (
 	Alias = "s"
 )
 
+const (
+	FlagListen      = "listen"
+	FlagListenUsage = "HTTP server listen address (the server is not started if it's not provided)"
+)
+
 var CLI = &cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
 	Usage:   Usage,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    FlagListen,
+			Usage:   FlagListenUsage,
+			EnvVars: []string{"DSLIM_SERVER_LISTEN"},
+		},
+	},
 	Action: func(ctx *cli.Context) error {
 		gcvalues, err := commands.GlobalFlagValues(ctx)
 		if err != nil {
@@ -27,7 +39,8 @@ var CLI = &cli.Command{
 
 		OnCommand(
 			xc,
-			gcvalues)
+			gcvalues,
+			ctx.String(FlagListen))
 
 		return nil
 	},
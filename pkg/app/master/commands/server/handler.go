@@ -9,7 +9,6 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/report"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
@@ -23,7 +22,8 @@ type ovars = app.OutVars
 // OnCommand implements the 'server' docker-slim command
 func OnCommand(
 	xc *app.ExecutionContext,
-	gparams *commands.GenericParams) {
+	gparams *commands.GenericParams,
+	listenAddr string) {
 	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
 	prefix := fmt.Sprintf("cmd=%s", Name)
 
@@ -55,12 +55,17 @@ func OnCommand(
 			})
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
+	xc.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
+	if listenAddr != "" {
+		err := runHTTPServer(xc, logger, listenAddr)
+		xc.FailOn(app.WrapError(err, app.ErrorCategoryNetwork, "server.listen", ""))
+	}
+
 	xc.Out.State("completed")
 	cmdReport.State = command.StateCompleted
 	xc.Out.State("done")
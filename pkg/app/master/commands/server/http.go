@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// runHTTPServer serves the server endpoints until the app is interrupted
+func runHTTPServer(xc *app.ExecutionContext, logger *log.Entry, listenAddr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"version": v.Current()})
+	})
+
+	srv := &http.Server{
+		Addr:    listenAddr,
+		Handler: mux,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	xc.Out.Info("server",
+		ovars{
+			"status": "listening",
+			"listen": listenAddr,
+		})
+
+	ctx := app.AppContext()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		logger.Debug("runHTTPServer: app interrupted, shutting down the server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Debugf("server.writeJSON: error - %v", err)
+	}
+}
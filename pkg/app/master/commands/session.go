@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const execSessionVersion = "1.0"
+
+// ExecSession is a recorded interactive container exec session
+type ExecSession struct {
+	Version  string           `json:"version"`
+	Target   string           `json:"target,omitempty"`
+	Started  time.Time        `json:"started"`
+	Commands []ExecSessionCmd `json:"commands"`
+}
+
+// ExecSessionCmd is a recorded session command
+type ExecSessionCmd struct {
+	//time since the start of the session (in milliseconds)
+	Offset   int64  `json:"offset"`
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	//command execution time (in milliseconds)
+	Duration int64 `json:"duration"`
+}
+
+// SessionExecFunc executes the session command in the target
+type SessionExecFunc func(command string) (int, error)
+
+// LoadExecSession loads a recorded exec session file
+func LoadExecSession(filePath string) (*ExecSession, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var session ExecSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+
+	if len(session.Commands) == 0 {
+		return nil, fmt.Errorf("no commands in session file - %s", filePath)
+	}
+
+	return &session, nil
+}
+
+// RunExecSession runs an interactive exec session (saving it if the record file is set)
+// or replays a recorded session if the replay file is set.
+// It returns the number of failed (or mismatched in the replay mode) commands.
+func RunExecSession(
+	xc *app.ExecutionContext,
+	continueAfter *config.ContinueAfter,
+	target string,
+	execFunc SessionExecFunc) int {
+	if continueAfter.SessionReplayFile != "" {
+		return replayExecSession(xc, continueAfter.SessionReplayFile, execFunc)
+	}
+
+	return recordExecSession(xc, continueAfter.SessionRecordFile, target, execFunc)
+}
+
+func recordExecSession(
+	xc *app.ExecutionContext,
+	recordFile string,
+	target string,
+	execFunc SessionExecFunc) int {
+	session := ExecSession{
+		Version: execSessionVersion,
+		Target:  target,
+		Started: time.Now().UTC(),
+	}
+
+	xc.Out.Prompt("USER INPUT REQUIRED, ENTER THE COMMANDS TO RUN IN THE TARGET CONTAINER ('exit' OR <CTRL-D> WHEN YOU ARE DONE)")
+
+	var failCount int
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Printf("%s[session]> ", appName)
+		if !scanner.Scan() {
+			fmt.Println()
+			break
+		}
+
+		command := strings.TrimSpace(scanner.Text())
+		if command == "" {
+			continue
+		}
+
+		if command == "exit" {
+			break
+		}
+
+		offset := time.Since(session.Started)
+		cmdStart := time.Now()
+		exitCode, err := execFunc(command)
+		if err != nil {
+			failCount++
+			xc.Out.Info("session.exec.error",
+				ovars{
+					"command": command,
+					"error":   err,
+				})
+			//not recording the commands that couldn't be executed
+			continue
+		}
+
+		if exitCode != 0 {
+			failCount++
+		}
+
+		xc.Out.Info("session.exec",
+			ovars{
+				"command":   command,
+				"exit.code": exitCode,
+			})
+
+		session.Commands = append(session.Commands,
+			ExecSessionCmd{
+				Offset:   offset.Milliseconds(),
+				Command:  command,
+				ExitCode: exitCode,
+				Duration: time.Since(cmdStart).Milliseconds(),
+			})
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Debugf("recordExecSession: input error - %v", err)
+	}
+
+	if recordFile != "" {
+		data, err := json.MarshalIndent(&session, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(recordFile, data, 0644)
+		}
+
+		if err != nil {
+			xc.Out.Error("session.record.error", err.Error())
+		} else {
+			xc.Out.Info("session.record",
+				ovars{
+					"file":     recordFile,
+					"commands": len(session.Commands),
+				})
+		}
+	}
+
+	return failCount
+}
+
+func replayExecSession(
+	xc *app.ExecutionContext,
+	replayFile string,
+	execFunc SessionExecFunc) int {
+	session, err := LoadExecSession(replayFile)
+	if err != nil {
+		xc.Out.Error("session.replay.error", err.Error())
+		return 1
+	}
+
+	xc.Out.Info("session.replay",
+		ovars{
+			"file":     replayFile,
+			"commands": len(session.Commands),
+		})
+
+	var failCount int
+	replayStart := time.Now()
+	for idx, cmd := range session.Commands {
+		//keep the original session timings
+		if wait := time.Duration(cmd.Offset)*time.Millisecond - time.Since(replayStart); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		exitCode, err := execFunc(cmd.Command)
+		status := "ok"
+		switch {
+		case err != nil:
+			failCount++
+			status = "error"
+		case exitCode != cmd.ExitCode:
+			failCount++
+			status = "mismatch"
+		}
+
+		outVars := ovars{
+			"idx":       idx,
+			"command":   cmd.Command,
+			"status":    status,
+			"exit.code": exitCode,
+		}
+
+		if status == "mismatch" {
+			outVars["expected.exit.code"] = cmd.ExitCode
+		}
+
+		if err != nil {
+			outVars["error"] = err
+		}
+
+		xc.Out.Info("session.replay.exec", outVars)
+	}
+
+	return failCount
+}
@@ -0,0 +1,113 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	Name  = "state"
+	Usage = "Manage the saved image state (the artifacts from the past runs)"
+	Alias = "st"
+
+	ListCmdName         = "list"
+	ListCmdNameUsage    = "List the saved runs with their sizes and dates"
+	InspectCmdName      = "inspect"
+	InspectCmdNameUsage = "Show the files saved for a run"
+	CleanCmdName        = "clean"
+	CleanCmdNameUsage   = "Remove the saved state for the old runs (by age, total size or target)"
+	ArchiveCmdName      = "archive"
+	ArchiveCmdNameUsage = "Archive the saved state for a run to a tarball"
+)
+
+func fullCmdName(subCmdName string) string {
+	return fmt.Sprintf("%s.%s", Name, subCmdName)
+}
+
+type CommandParams struct {
+	//run ID (image ID or its unique prefix) or image name
+	TargetRef   string
+	OlderThan   string
+	MaxSize     string
+	All         bool
+	DryRun      bool
+	ArchiveFile string
+}
+
+func CommandFlagValues(ctx *cli.Context) (*CommandParams, error) {
+	values := &CommandParams{
+		TargetRef:   ctx.String(commands.FlagTarget),
+		OlderThan:   ctx.String(FlagOlderThan),
+		MaxSize:     ctx.String(FlagMaxSize),
+		All:         ctx.Bool(FlagAll),
+		DryRun:      ctx.Bool(FlagDryRun),
+		ArchiveFile: ctx.String(FlagArchiveFile),
+	}
+
+	if values.TargetRef == "" && ctx.Args().Len() > 0 {
+		values.TargetRef = ctx.Args().First()
+	}
+
+	return values, nil
+}
+
+type commandHandler func(xc *app.ExecutionContext, gparams *commands.GenericParams, cparams *CommandParams)
+
+func subcommand(name, usage string, flags []cli.Flag, handler commandHandler) *cli.Command {
+	return &cli.Command{
+		Name:  name,
+		Usage: usage,
+		Flags: flags,
+		Action: func(ctx *cli.Context) error {
+			xc := app.NewExecutionContext(fullCmdName(name), ctx.String(commands.FlagConsoleFormat))
+
+			gcvalues, err := commands.GlobalFlagValues(ctx)
+			if err != nil {
+				return err
+			}
+
+			cparams, err := CommandFlagValues(ctx)
+			if err != nil {
+				return err
+			}
+
+			handler(xc, gcvalues, cparams)
+			return nil
+		},
+	}
+}
+
+var CLI = &cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Subcommands: []*cli.Command{
+		subcommand(ListCmdName, ListCmdNameUsage,
+			nil,
+			OnListCommand),
+		subcommand(InspectCmdName, InspectCmdNameUsage,
+			[]cli.Flag{
+				commands.Cflag(commands.FlagTarget),
+			},
+			OnInspectCommand),
+		subcommand(CleanCmdName, CleanCmdNameUsage,
+			[]cli.Flag{
+				commands.Cflag(commands.FlagTarget),
+				cflag(FlagOlderThan),
+				cflag(FlagMaxSize),
+				cflag(FlagAll),
+				cflag(FlagDryRun),
+			},
+			OnCleanCommand),
+		subcommand(ArchiveCmdName, ArchiveCmdNameUsage,
+			[]cli.Flag{
+				commands.Cflag(commands.FlagTarget),
+				cflag(FlagArchiveFile),
+			},
+			OnArchiveCommand),
+	},
+}
@@ -0,0 +1,64 @@
+package state
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// State command flag names
+const (
+	FlagOlderThan   = "older-than"
+	FlagMaxSize     = "max-size"
+	FlagAll         = "all"
+	FlagDryRun      = "dry-run"
+	FlagArchiveFile = "archive-file"
+)
+
+// State command flag usage info
+const (
+	FlagOlderThanUsage   = "Remove the state for the runs older than the duration (e.g., 72h)"
+	FlagMaxSizeUsage     = "Remove the state for the oldest runs until the total state size is below the limit (e.g., 2GB)"
+	FlagAllUsage         = "Remove the state for all runs"
+	FlagDryRunUsage      = "Show the runs to remove without removing them"
+	FlagArchiveFileUsage = "Archive file path (default: <run ID prefix>.state.tar)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagOlderThan: &cli.StringFlag{
+		Name:    FlagOlderThan,
+		Value:   "",
+		Usage:   FlagOlderThanUsage,
+		EnvVars: []string{"DSLIM_STATE_OLDER_THAN"},
+	},
+	FlagMaxSize: &cli.StringFlag{
+		Name:    FlagMaxSize,
+		Value:   "",
+		Usage:   FlagMaxSizeUsage,
+		EnvVars: []string{"DSLIM_STATE_MAX_SIZE"},
+	},
+	FlagAll: &cli.BoolFlag{
+		Name:    FlagAll,
+		Usage:   FlagAllUsage,
+		EnvVars: []string{"DSLIM_STATE_ALL"},
+	},
+	FlagDryRun: &cli.BoolFlag{
+		Name:    FlagDryRun,
+		Usage:   FlagDryRunUsage,
+		EnvVars: []string{"DSLIM_STATE_DRY_RUN"},
+	},
+	FlagArchiveFile: &cli.StringFlag{
+		Name:    FlagArchiveFile,
+		Value:   "",
+		Usage:   FlagArchiveFileUsage,
+		EnvVars: []string{"DSLIM_STATE_ARCHIVE_FILE"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -0,0 +1,288 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	statestore "github.com/docker-slim/docker-slim/pkg/app/master/state"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// State command exit codes
+const (
+	ecsOther = iota + 1
+	ecsBadOlderThan
+	ecsBadMaxSize
+	ecsNoCleanFilter
+	ecsRunNotFound
+)
+
+// exitCodes documents the state command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTState | ecsOther, Name: "state.other", Description: "other state command error"},
+	{Code: commands.ECTState | ecsBadOlderThan, Name: "state.bad.older.than", Description: "invalid --older-than value"},
+	{Code: commands.ECTState | ecsBadMaxSize, Name: "state.bad.max.size", Description: "invalid --max-size value"},
+	{Code: commands.ECTState | ecsNoCleanFilter, Name: "state.no.clean.filter", Description: "no runs selected for 'state clean' (use --target, --older-than, --max-size or --all)"},
+	{Code: commands.ECTState | ecsRunNotFound, Name: "state.run.not.found", Description: "saved run not found (or the run ID prefix is ambiguous)"},
+}
+
+// OnListCommand implements the 'state list' docker-slim command
+func OnListCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	xc.Out.State("started")
+
+	store := statestore.NewStore(gparams.StatePath)
+	runs, err := store.List()
+	xc.FailOn(err)
+
+	var totalSize int64
+	for _, run := range runs {
+		totalSize += run.Size
+		xc.Out.Info("state.run",
+			ovars{
+				"id":      run.ID,
+				"updated": run.Updated.Format(time.RFC3339),
+				"age":     humanize.Time(run.Updated),
+				"size":    humanize.Bytes(uint64(run.Size)),
+				"files":   run.Files,
+			})
+	}
+
+	xc.Out.Info("state",
+		ovars{
+			"location": store.Location,
+			"runs":     len(runs),
+			"size":     humanize.Bytes(uint64(totalSize)),
+		})
+
+	xc.Out.State("done")
+}
+
+// OnInspectCommand implements the 'state inspect' docker-slim command
+func OnInspectCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	cmdName := fullCmdName(InspectCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	xc.Out.State("started")
+
+	store := statestore.NewStore(gparams.StatePath)
+	run := findRun(xc, gparams, store, cparams.TargetRef, logger)
+
+	files, err := store.Files(run)
+	xc.FailOn(err)
+
+	for _, file := range files {
+		xc.Out.Info("state.file",
+			ovars{
+				"path":     file.Path,
+				"size":     humanize.Bytes(uint64(file.Size)),
+				"modified": file.Modified.Format(time.RFC3339),
+			})
+	}
+
+	xc.Out.Info("state.run",
+		ovars{
+			"id":       run.ID,
+			"location": run.Location,
+			"updated":  run.Updated.Format(time.RFC3339),
+			"size":     humanize.Bytes(uint64(run.Size)),
+			"files":    run.Files,
+		})
+
+	xc.Out.State("done")
+}
+
+// OnCleanCommand implements the 'state clean' docker-slim command
+func OnCleanCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	cmdName := fullCmdName(CleanCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	var maxAge time.Duration
+	if cparams.OlderThan != "" {
+		var err error
+		maxAge, err = time.ParseDuration(cparams.OlderThan)
+		if err != nil || maxAge <= 0 {
+			xc.Out.Error("param.older-than", "malformed duration")
+			exitWithCode(xc, ecsBadOlderThan)
+		}
+	}
+
+	var maxSize int64
+	if cparams.MaxSize != "" {
+		size, err := humanize.ParseBytes(cparams.MaxSize)
+		if err != nil {
+			xc.Out.Error("param.max-size", "malformed size")
+			exitWithCode(xc, ecsBadMaxSize)
+		}
+
+		maxSize = int64(size)
+	}
+
+	if cparams.TargetRef == "" && maxAge == 0 && maxSize == 0 && !cparams.All {
+		xc.Out.Error("param.clean", "no runs selected (use --target, --older-than, --max-size or --all)")
+		exitWithCode(xc, ecsNoCleanFilter)
+	}
+
+	xc.Out.State("started")
+
+	store := statestore.NewStore(gparams.StatePath)
+	var selected []*statestore.RunInfo
+	switch {
+	case cparams.TargetRef != "":
+		selected = append(selected, findRun(xc, gparams, store, cparams.TargetRef, logger))
+	default:
+		runs, err := store.List()
+		xc.FailOn(err)
+
+		if cparams.All {
+			selected = runs
+		} else {
+			selected = statestore.Prune(runs, maxAge, maxSize)
+		}
+	}
+
+	var removedSize int64
+	for _, run := range selected {
+		status := "removed"
+		if cparams.DryRun {
+			status = "selected"
+		} else {
+			xc.FailOn(store.Remove(run))
+		}
+
+		removedSize += run.Size
+		xc.Out.Info("state.run",
+			ovars{
+				"id":      run.ID,
+				"status":  status,
+				"updated": run.Updated.Format(time.RFC3339),
+				"size":    humanize.Bytes(uint64(run.Size)),
+			})
+	}
+
+	vars := ovars{
+		"location": store.Location,
+		"size":     humanize.Bytes(uint64(removedSize)),
+	}
+
+	if cparams.DryRun {
+		vars["selected"] = len(selected)
+	} else {
+		vars["removed"] = len(selected)
+	}
+
+	xc.Out.Info("state", vars)
+	xc.Out.State("done")
+}
+
+// OnArchiveCommand implements the 'state archive' docker-slim command
+func OnArchiveCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	cmdName := fullCmdName(ArchiveCmdName)
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+
+	xc.Out.State("started")
+
+	store := statestore.NewStore(gparams.StatePath)
+	run := findRun(xc, gparams, store, cparams.TargetRef, logger)
+
+	archiveFile := cparams.ArchiveFile
+	if archiveFile == "" {
+		archiveFile = fmt.Sprintf("%s.state.tar", shortID(run.ID))
+	}
+
+	err := store.Archive(run, archiveFile)
+	xc.FailOn(app.WrapError(err, app.ErrorCategoryFilesystem, "state.archive", ""))
+
+	xc.Out.Info("state.archive",
+		ovars{
+			"id":   run.ID,
+			"file": archiveFile,
+			"size": humanize.Bytes(uint64(run.Size)),
+		})
+
+	xc.Out.State("done")
+}
+
+// findRun returns the saved run for the run ID (or its unique prefix) or for the image name
+func findRun(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	store *statestore.Store,
+	targetRef string,
+	logger *log.Entry) *statestore.RunInfo {
+	if targetRef == "" {
+		xc.Out.Error("param.target", "missing run ID or image name")
+		exitWithCode(xc, ecsRunNotFound)
+	}
+
+	run, err := store.Find(targetRef)
+	if errors.Is(err, statestore.ErrRunNotFound) {
+		if imageID := resolveImageID(gparams, targetRef, logger); imageID != "" {
+			run, err = store.Find(imageID)
+		}
+	}
+
+	if errors.Is(err, statestore.ErrRunNotFound) || errors.Is(err, statestore.ErrAmbiguousRun) {
+		xc.Out.Error("state.run", err.Error())
+		exitWithCode(xc, ecsRunNotFound)
+	}
+
+	xc.FailOn(err)
+	return run
+}
+
+// resolveImageID returns the ID for the image name (empty if the image is not found)
+func resolveImageID(gparams *commands.GenericParams, targetRef string, logger *log.Entry) string {
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err != nil {
+		logger.Debugf("resolveImageID: docker client error - %v", err)
+		return ""
+	}
+
+	identity, err := dockerutil.HasImage(client, targetRef)
+	if err != nil {
+		logger.Debugf("resolveImageID: image not found (%s) - %v", targetRef, err)
+		return ""
+	}
+
+	return identity.ID
+}
+
+func exitWithCode(xc *app.ExecutionContext, code int) {
+	exitCode := commands.ECTState | code
+	xc.Out.State("exited",
+		ovars{
+			"exit.code": exitCode,
+		})
+	xc.Exit(exitCode)
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+
+	return id
+}
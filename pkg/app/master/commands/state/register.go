@@ -0,0 +1,11 @@
+package state
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+)
+
+func RegisterCommand() {
+	commands.CLI = append(commands.CLI, CLI)
+	commands.RegisterExitCodes(Name, exitCodes...)
+	commands.CommandSuggestions = append(commands.CommandSuggestions, CommandSuggestion)
+}
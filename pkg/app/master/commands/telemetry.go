@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/trace"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// Command phase names
+const (
+	PhasePull    = "pull"
+	PhaseReverse = "reverse"
+	PhaseProfile = "profile"
+	PhaseProbe   = "probe"
+	PhaseBuild   = "build"
+)
+
+// Telemetry records the command phases for the run timeline
+// and exports them (as spans and metrics) to the OpenTelemetry collector
+// (all methods are safe to call on a nil telemetry value)
+type Telemetry struct {
+	recorder *trace.Recorder
+	exporter *trace.Exporter
+	endpoint string
+	command  string
+	start    time.Time
+	once     sync.Once
+	xc       *app.ExecutionContext
+	logger   *log.Entry
+}
+
+// NewTelemetry creates the command telemetry if the run timeline is saved (doRecord)
+// or if the OpenTelemetry export is enabled
+// (the failed commands are exported when the command exits)
+func NewTelemetry(
+	xc *app.ExecutionContext,
+	gparams *GenericParams,
+	cmdName string,
+	target string,
+	doRecord bool,
+	logger *log.Entry) *Telemetry {
+	if !doRecord && gparams.OTelEndpoint == "" {
+		return nil
+	}
+
+	recorder := trace.NewRecorder(fmt.Sprintf("docker-slim %s %s", cmdName, target))
+	recorder.SetAttribute("service.version", v.Current())
+	recorder.SetAttribute("docker_slim.command", cmdName)
+	recorder.SetAttribute("docker_slim.target", target)
+
+	t := &Telemetry{
+		recorder: recorder,
+		command:  cmdName,
+		start:    time.Now(),
+		xc:       xc,
+		logger:   logger,
+	}
+
+	if gparams.OTelEndpoint != "" {
+		t.endpoint = gparams.OTelEndpoint
+		t.exporter = trace.NewExporter(gparams.OTelEndpoint, trace.ParseHeaders(gparams.OTelHeaders))
+		xc.AddCleanupHandler(func() {
+			t.Finish(command.StateError)
+		})
+	}
+
+	return t
+}
+
+// Recorder returns the run timeline recorder
+func (t *Telemetry) Recorder() *trace.Recorder {
+	if t == nil {
+		return nil
+	}
+
+	return t.recorder
+}
+
+// Phase starts a command phase span and returns the function to end it
+func (t *Telemetry) Phase(name string) func() {
+	if t == nil {
+		return func() {}
+	}
+
+	done := t.recorder.Begin(trace.TrackCommand, "phase", name)
+	return func() {
+		done(nil)
+	}
+}
+
+// Finish exports the command spans and metrics (only once)
+// (export errors are reported, but they don't fail the command)
+func (t *Telemetry) Finish(state command.State) {
+	if t == nil || t.exporter == nil {
+		return
+	}
+
+	t.once.Do(func() {
+		for _, e := range t.recorder.Events() {
+			if e.Track != trace.TrackCommand || e.Category != "phase" || e.IsInstant() {
+				continue
+			}
+
+			t.recorder.Gauge("docker_slim.phase.duration", "s",
+				"Command phase duration",
+				e.End.Sub(e.Start).Seconds(),
+				map[string]interface{}{
+					"command": t.command,
+					"phase":   e.Name,
+				})
+		}
+
+		attrs := map[string]interface{}{
+			"command": t.command,
+			"state":   string(state),
+		}
+
+		t.recorder.Gauge("docker_slim.command.duration", "s",
+			"Command duration", time.Since(t.start).Seconds(), attrs)
+		t.recorder.Count("docker_slim.command.runs", "{run}",
+			"Command runs", 1, attrs)
+
+		if err := t.exporter.Export(t.recorder); err != nil {
+			t.logger.Errorf("Telemetry.Finish: error exporting telemetry (%s) - %v", t.endpoint, err)
+			t.xc.Out.Info("otel.export",
+				ovars{
+					"endpoint": t.endpoint,
+					"error":    err.Error(),
+				})
+			return
+		}
+
+		t.xc.Out.Info("otel.export",
+			ovars{
+				"endpoint": t.endpoint,
+				"spans":    len(t.recorder.Events()),
+				"metrics":  len(t.recorder.Metrics()),
+			})
+	})
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/trace"
+)
+
+// AddSensorTraceEvents adds the sensor file and exec events from the container report to the timeline
+// (one timeline track for each monitored process)
+func AddSensorTraceEvents(recorder *trace.Recorder, artifactLocation string, logger *log.Entry) {
+	if recorder == nil || artifactLocation == "" {
+		return
+	}
+
+	creportPath := filepath.Join(artifactLocation, report.DefaultContainerReportFileName)
+	data, err := ioutil.ReadFile(creportPath)
+	if err != nil {
+		logger.Debugf("AddSensorTraceEvents: error reading container report (%s) - %v", creportPath, err)
+		return
+	}
+
+	var creport report.ContainerReport
+	if err := json.Unmarshal(data, &creport); err != nil {
+		logger.Debugf("AddSensorTraceEvents: error decoding container report (%s) - %v", creportPath, err)
+		return
+	}
+
+	fan := creport.Monitors.Fan
+	if fan == nil {
+		return
+	}
+
+	if fan.StartTime > 0 && fan.StopTime > 0 {
+		recorder.Span(trace.TrackSensor, "sensor", "sensor.monitor",
+			time.Unix(0, fan.StartTime), time.Unix(0, fan.StopTime),
+			map[string]interface{}{"events": fan.EventCount})
+	}
+
+	var pids []string
+	for pid := range fan.ProcessFiles {
+		pids = append(pids, pid)
+	}
+
+	sort.Slice(pids, func(i, j int) bool {
+		pi, _ := strconv.Atoi(pids[i])
+		pj, _ := strconv.Atoi(pids[j])
+		return pi < pj
+	})
+
+	for _, pid := range pids {
+		track := fmt.Sprintf("%s: pid %s", trace.TrackSensor, pid)
+		if pinfo := fan.Processes[pid]; pinfo != nil {
+			track = fmt.Sprintf("%s %s", track, pinfo.Name)
+			if pinfo.FirstEventTime > 0 {
+				recorder.Instant(track, "process", "process.first.event",
+					time.Unix(0, pinfo.FirstEventTime),
+					map[string]interface{}{"path": pinfo.Path, "cmd": pinfo.Cmd})
+			}
+		}
+
+		for name, info := range fan.ProcessFiles[pid] {
+			if info.FirstEventTime == 0 {
+				//created by an older sensor (no timestamps)
+				continue
+			}
+
+			category := "file"
+			if info.ExeCount > 0 {
+				category = "exec"
+			}
+
+			args := map[string]interface{}{
+				"events": info.EventCount,
+				"reads":  info.ReadCount,
+				"writes": info.WriteCount,
+			}
+
+			start := time.Unix(0, info.FirstEventTime)
+			if info.LastEventTime > info.FirstEventTime {
+				recorder.Span(track, category, name, start, time.Unix(0, info.LastEventTime), args)
+			} else {
+				recorder.Instant(track, category, name, start, args)
+			}
+		}
+	}
+}
+
+// SaveTrace saves the command run timeline
+// (save errors are reported, but they don't fail the command)
+func SaveTrace(
+	xc *app.ExecutionContext,
+	recorder *trace.Recorder,
+	location string,
+	format string,
+	logger *log.Entry) {
+	if recorder == nil || location == "" {
+		return
+	}
+
+	if err := recorder.Save(location, format); err != nil {
+		logger.Errorf("SaveTrace: error saving trace (%s) - %v", location, err)
+		xc.Out.Info("trace",
+			ovars{
+				"file":  location,
+				"error": err.Error(),
+			})
+		return
+	}
+
+	xc.Out.Info("trace",
+		ovars{
+			"file":   location,
+			"format": format,
+			"events": len(recorder.Events()),
+		})
+}
@@ -2,9 +2,13 @@ package update
 
 import (
 	"fmt"
+	"os"
 	"runtime"
+	"strings"
 
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/update"
+	vinfo "github.com/docker-slim/docker-slim/pkg/version"
 
 	"github.com/urfave/cli/v2"
 )
@@ -13,6 +17,24 @@ const (
 	Name  = "update"
 	Usage = "Updates docker-slim"
 	Alias = "u"
+
+	FlagChannel      = "channel"
+	FlagChannelUsage = "Release channel: stable, beta or nightly"
+
+	FlagBundle      = "bundle"
+	FlagBundleUsage = "Update from a local release bundle (the release package or a directory with it and its checksums files; no network access)"
+
+	FlagPublicKey      = "public-key"
+	FlagPublicKeyUsage = "File with the base64 encoded Ed25519 public key to verify the release checksums signature (the embedded release key by default; the update is refused if no key is available and the verification is not skipped)"
+
+	FlagSkipVerify      = "skip-verify"
+	FlagSkipVerifyUsage = "Don't verify the release package checksum and the checksums signature (not recommended)"
+
+	FlagSensorOnly      = "sensor-only"
+	FlagSensorOnlyUsage = "Update only the sensor (pinned if --version is provided; unpinned when updated to the latest version)"
+
+	FlagVersion      = "version"
+	FlagVersionUsage = "Install the selected release version instead of the latest version in the release channel"
 )
 
 var CLI = &cli.Command{
@@ -21,6 +43,37 @@ var CLI = &cli.Command{
 	Usage:   Usage,
 	Flags: []cli.Flag{
 		initFlagShowProgress(),
+		&cli.StringFlag{
+			Name:    FlagChannel,
+			Value:   update.ChannelStable,
+			Usage:   FlagChannelUsage,
+			EnvVars: []string{"DSLIM_UPDATE_CHANNEL"},
+		},
+		&cli.StringFlag{
+			Name:    FlagBundle,
+			Usage:   FlagBundleUsage,
+			EnvVars: []string{"DSLIM_UPDATE_BUNDLE"},
+		},
+		&cli.StringFlag{
+			Name:    FlagPublicKey,
+			Usage:   FlagPublicKeyUsage,
+			EnvVars: []string{"DSLIM_UPDATE_PUBLIC_KEY"},
+		},
+		&cli.BoolFlag{
+			Name:    FlagSkipVerify,
+			Usage:   FlagSkipVerifyUsage,
+			EnvVars: []string{"DSLIM_UPDATE_SKIP_VERIFY"},
+		},
+		&cli.BoolFlag{
+			Name:    FlagSensorOnly,
+			Usage:   FlagSensorOnlyUsage,
+			EnvVars: []string{"DSLIM_UPDATE_SENSOR_ONLY"},
+		},
+		&cli.StringFlag{
+			Name:    FlagVersion,
+			Usage:   FlagVersionUsage,
+			EnvVars: []string{"DSLIM_UPDATE_VERSION"},
+		},
 	},
 	Action: func(ctx *cli.Context) error {
 		doDebug := ctx.Bool(commands.FlagDebug)
@@ -29,7 +82,24 @@ var CLI = &cli.Command{
 		archiveState := commands.ArchiveState(ctx.String(commands.FlagArchiveState), inContainer)
 		doShowProgress := ctx.Bool(commands.FlagShowProgress)
 
-		OnCommand(doDebug, statePath, archiveState, inContainer, isDSImage, doShowProgress)
+		channel := strings.ToLower(ctx.String(FlagChannel))
+		if !update.IsValidChannel(channel) {
+			fmt.Printf("docker-slim[update]: info=status message='unknown release channel (use %s)' channel='%s'\n",
+				strings.Join(update.Channels, ", "), channel)
+			fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
+			os.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		opts := update.Options{
+			Channel:       channel,
+			BundlePath:    ctx.String(FlagBundle),
+			PublicKeyPath: ctx.String(FlagPublicKey),
+			SkipVerify:    ctx.Bool(FlagSkipVerify),
+			SensorOnly:    ctx.Bool(FlagSensorOnly),
+			Version:       ctx.String(FlagVersion),
+		}
+
+		OnCommand(doDebug, statePath, archiveState, inContainer, isDSImage, doShowProgress, opts)
 		return nil
 	},
 }
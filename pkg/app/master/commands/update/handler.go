@@ -5,6 +5,13 @@ import (
 )
 
 // OnCommand implements the 'update' docker-slim command
-func OnCommand(doDebug bool, statePath, archiveState string, inContainer, isDSImage bool, doShowProgress bool) {
-	update.Run(doDebug, statePath, inContainer, isDSImage, doShowProgress)
+func OnCommand(
+	doDebug bool,
+	statePath string,
+	archiveState string,
+	inContainer bool,
+	isDSImage bool,
+	doShowProgress bool,
+	opts update.Options) {
+	update.Run(doDebug, statePath, inContainer, isDSImage, doShowProgress, opts)
 }
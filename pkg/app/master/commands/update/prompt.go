@@ -2,6 +2,7 @@ package update
 
 import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/update"
 
 	"github.com/c-bata/go-prompt"
 )
@@ -14,8 +15,28 @@ var CommandSuggestion = prompt.Suggest{
 var CommandFlagSuggestions = &commands.FlagSuggestions{
 	Names: []prompt.Suggest{
 		{Text: commands.FullFlagName(commands.FlagShowProgress), Description: commands.FlagShowProgressUsage},
+		{Text: commands.FullFlagName(FlagChannel), Description: FlagChannelUsage},
+		{Text: commands.FullFlagName(FlagBundle), Description: FlagBundleUsage},
+		{Text: commands.FullFlagName(FlagPublicKey), Description: FlagPublicKeyUsage},
+		{Text: commands.FullFlagName(FlagSkipVerify), Description: FlagSkipVerifyUsage},
+		{Text: commands.FullFlagName(FlagSensorOnly), Description: FlagSensorOnlyUsage},
+		{Text: commands.FullFlagName(FlagVersion), Description: FlagVersionUsage},
 	},
 	Values: map[string]commands.CompleteValue{
 		commands.FullFlagName(commands.FlagShowProgress): commands.CompleteProgress,
+		commands.FullFlagName(FlagChannel):               completeChannel,
+		commands.FullFlagName(FlagBundle):                commands.CompleteFile,
+		commands.FullFlagName(FlagPublicKey):             commands.CompleteFile,
+		commands.FullFlagName(FlagSkipVerify):            commands.CompleteBool,
+		commands.FullFlagName(FlagSensorOnly):            commands.CompleteBool,
 	},
 }
+
+func completeChannel(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	var values []prompt.Suggest
+	for _, channel := range update.Channels {
+		values = append(values, prompt.Suggest{Text: channel})
+	}
+
+	return prompt.FilterHasPrefix(values, token, true)
+}
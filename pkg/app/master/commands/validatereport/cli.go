@@ -0,0 +1,80 @@
+package validatereport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/report"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Command report schema validation
+
+const (
+	Name  = "validate-report"
+	Usage = "Validate a command report using its JSON schema"
+	Alias = "vr"
+)
+
+type CommandParams struct {
+	TargetReport string
+	Schema       string
+	SchemaOutput string
+}
+
+var CLI = &cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Flags: []cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagSchema),
+		cflag(FlagSchemaOutput),
+	},
+	Action: func(ctx *cli.Context) error {
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		cparams := &CommandParams{
+			TargetReport: ctx.String(commands.FlagTarget),
+			Schema:       ctx.String(FlagSchema),
+			SchemaOutput: ctx.String(FlagSchemaOutput),
+		}
+
+		if cparams.TargetReport == "" && ctx.Args().Len() > 0 {
+			cparams.TargetReport = ctx.Args().First()
+		}
+
+		//validating the report from the last command by default
+		if cparams.TargetReport == "" {
+			cparams.TargetReport = gcvalues.ReportLocation
+		}
+
+		if cparams.TargetReport == "" {
+			cparams.TargetReport = report.DefaultFilename
+		}
+
+		//the event schema is available only as the schema output
+		isEventSchema := cparams.Schema == report.SchemaEvent && cparams.SchemaOutput != ""
+		if cparams.Schema != "" && !report.IsSchemaName(cparams.Schema) && !isEventSchema {
+			xc.Out.Error("param.error.schema",
+				fmt.Sprintf("unknown report schema - '%s' (supported: %s)",
+					cparams.Schema, strings.Join(report.SchemaNames(), ", ")))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		OnCommand(xc, gcvalues, cparams)
+		return nil
+	},
+}
@@ -0,0 +1,43 @@
+package validatereport
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Validate-report command flag names
+const (
+	FlagSchema       = "schema"
+	FlagSchemaOutput = "schema-output"
+)
+
+// Validate-report command flag usage info
+const (
+	FlagTargetReportUsage = "Command report file to validate (default: the command report location)"
+	FlagSchemaUsage       = "Report schema to use (default: selected by the report type)"
+	FlagSchemaOutputUsage = "Save the report JSON schema to the file instead of validating the report (use '--schema event' to save the command event schema)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagSchema: &cli.StringFlag{
+		Name:    FlagSchema,
+		Value:   "",
+		Usage:   FlagSchemaUsage,
+		EnvVars: []string{"DSLIM_VALIDATE_REPORT_SCHEMA"},
+	},
+	FlagSchemaOutput: &cli.StringFlag{
+		Name:    FlagSchemaOutput,
+		Value:   "",
+		Usage:   FlagSchemaOutputUsage,
+		EnvVars: []string{"DSLIM_VALIDATE_REPORT_SCHEMA_OUTPUT"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
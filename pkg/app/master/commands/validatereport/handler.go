@@ -0,0 +1,134 @@
+package validatereport
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// Validate-report command exit codes
+const (
+	ecvrOther = iota + 1
+	ecvrReadError
+	ecvrUnknownReport
+	ecvrInvalidReport
+)
+
+// exitCodes documents the validate-report command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTValidateReport | ecvrOther, Name: "validate-report.other", Description: "other validate-report command error"},
+	{Code: commands.ECTValidateReport | ecvrReadError, Name: "validate-report.read.error", Description: "error reading the report file"},
+	{Code: commands.ECTValidateReport | ecvrUnknownReport, Name: "validate-report.unknown.report", Description: "unknown report type or report parsing error"},
+	{Code: commands.ECTValidateReport | ecvrInvalidReport, Name: "validate-report.invalid.report", Description: "report does not match its schema"},
+}
+
+// OnCommand implements the 'validate-report' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+
+	xc.Out.State("started")
+
+	var data []byte
+	if cparams.SchemaOutput == "" || cparams.Schema == "" {
+		var err error
+		data, err = ioutil.ReadFile(cparams.TargetReport)
+		if err != nil {
+			logger.Debugf("error reading report (%s) - %v", cparams.TargetReport, err)
+			exitWithError(xc, "report.read", err, ecvrReadError)
+		}
+	}
+
+	schemaName := cparams.Schema
+	if schemaName == "" {
+		var err error
+		schemaName, err = report.ReportSchemaName(data)
+		if err != nil {
+			exitWithError(xc, "report.schema", err, ecvrUnknownReport)
+		}
+	}
+
+	if cparams.SchemaOutput != "" {
+		schemaVersion := report.SchemaVersion
+		schemaData, err := report.SchemaData(schemaName)
+		if schemaName == report.SchemaEvent {
+			schemaVersion = report.EventSchemaVersion
+			schemaData, err = report.EventSchemaData()
+		}
+		xc.FailOn(err)
+
+		err = ioutil.WriteFile(cparams.SchemaOutput, schemaData, 0644)
+		xc.FailOn(err)
+
+		xc.Out.Info("schema",
+			ovars{
+				"name":    schemaName,
+				"version": schemaVersion,
+				"file":    cparams.SchemaOutput,
+			})
+		xc.Out.State("done")
+		return
+	}
+
+	xc.Out.Info("params",
+		ovars{
+			"target": cparams.TargetReport,
+			"schema": schemaName,
+		})
+
+	result, err := report.ValidateReportWithSchema(schemaName, data)
+	if err != nil {
+		exitWithError(xc, "report.parse", err, ecvrUnknownReport)
+	}
+
+	for _, verr := range result.Errors {
+		xc.Out.Info("report.error",
+			ovars{
+				"path":    verr.Path,
+				"message": verr.Message,
+			})
+	}
+
+	xc.Out.Info("results",
+		ovars{
+			"schema":                result.Schema,
+			"schema.version":        result.SchemaVersion,
+			"report.schema.version": result.ReportVersion,
+			"valid":                 result.Valid(),
+			"errors":                len(result.Errors),
+		})
+
+	if !result.Valid() {
+		exitCode := commands.ECTValidateReport | ecvrInvalidReport
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"message":   fmt.Sprintf("report has %d schema error(s)", len(result.Errors)),
+			})
+		xc.Exit(exitCode)
+	}
+
+	xc.Out.State("done")
+}
+
+func exitWithError(xc *app.ExecutionContext, key string, err error, code int) {
+	xc.Out.Error(key, err.Error())
+
+	exitCode := commands.ECTValidateReport | code
+	xc.Out.State("exited",
+		ovars{
+			"exit.code": exitCode,
+		})
+	xc.Exit(exitCode)
+}
@@ -0,0 +1,35 @@
+package validatereport
+
+import (
+	"github.com/c-bata/go-prompt"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: FlagTargetReportUsage},
+		{Text: commands.FullFlagName(FlagSchema), Description: FlagSchemaUsage},
+		{Text: commands.FullFlagName(FlagSchemaOutput), Description: FlagSchemaOutputUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget): commands.CompleteFile,
+		commands.FullFlagName(FlagSchema):          completeSchema,
+		commands.FullFlagName(FlagSchemaOutput):    commands.CompleteFile,
+	},
+}
+
+func completeSchema(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	var values []prompt.Suggest
+	for _, name := range report.SchemaNames() {
+		values = append(values, prompt.Suggest{Text: name})
+	}
+
+	return prompt.FilterHasPrefix(values, token, true)
+}
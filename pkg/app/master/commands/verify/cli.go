@@ -0,0 +1,114 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Differential (original vs optimized image) verification
+
+const (
+	Name  = "verify"
+	Usage = "Compare the original and optimized image behavior"
+	Alias = "vf"
+)
+
+type CommandParams struct {
+	TargetRef     string
+	SlimImage     string
+	IgnoreHeaders []string
+	DoIgnoreBody  bool
+	EnvVars       []string
+	JUnitOutput   string
+}
+
+var CLI = &cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Flags: append([]cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagSlimImage),
+		cflag(FlagIgnoreHeader),
+		cflag(FlagIgnoreBody),
+		cflag(FlagJUnit),
+		commands.Cflag(commands.FlagEnv),
+	}, commands.HTTPProbeFlags()...),
+	Action: func(ctx *cli.Context) error {
+		cparams := &CommandParams{
+			TargetRef:     ctx.String(commands.FlagTarget),
+			SlimImage:     ctx.String(FlagSlimImage),
+			IgnoreHeaders: ctx.StringSlice(FlagIgnoreHeader),
+			DoIgnoreBody:  ctx.Bool(FlagIgnoreBody),
+			EnvVars:       ctx.StringSlice(commands.FlagEnv),
+			JUnitOutput:   ctx.String(FlagJUnit),
+		}
+
+		if cparams.TargetRef == "" {
+			if ctx.Args().Len() < 1 {
+				fmt.Printf("docker-slim[%s]: missing target image...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			}
+
+			cparams.TargetRef = ctx.Args().First()
+		}
+
+		if cparams.SlimImage == "" {
+			cparams.SlimImage = defaultSlimImage(cparams.TargetRef)
+		}
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		httpProbeOpts := commands.GetHTTPProbeOptions(xc, ctx)
+		//probing is the whole point of the command
+		httpProbeOpts.Do = true
+		if len(httpProbeOpts.Cmds) == 0 {
+			httpProbeOpts.Cmds = append(httpProbeOpts.Cmds, commands.GetDefaultHTTPProbe())
+		}
+
+		OnCommand(
+			xc,
+			gcvalues,
+			cparams,
+			httpProbeOpts)
+
+		return nil
+	},
+}
+
+// defaultSlimImage returns the default optimized image name
+// created by the build command for the target image
+func defaultSlimImage(targetRef string) string {
+	name := targetRef
+	if idx := strings.Index(name, "@"); idx > 0 {
+		name = name[:idx]
+	}
+
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		name = name[:idx]
+	}
+
+	return fmt.Sprintf("%s.slim", name)
+}
+
+func newContainerOverrides(cparams *CommandParams) *config.ContainerOverrides {
+	if len(cparams.EnvVars) == 0 {
+		return nil
+	}
+
+	return &config.ContainerOverrides{
+		Env: cparams.EnvVars,
+	}
+}
@@ -0,0 +1,57 @@
+package verify
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// Verify command flag names
+const (
+	FlagSlimImage    = "slim-image"
+	FlagIgnoreHeader = "ignore-header"
+	FlagIgnoreBody   = "ignore-body"
+	FlagJUnit        = "junit"
+)
+
+// Verify command flag usage info
+const (
+	FlagSlimImageUsage    = "Optimized image to compare with the target image (default: '<target_image_name>.slim')"
+	FlagIgnoreHeaderUsage = "Response header to ignore when comparing the responses (in addition to the default set of volatile headers)"
+	FlagIgnoreBodyUsage   = "Don't compare the response bodies"
+	FlagJUnitUsage        = "Save the verification results in the JUnit XML format (file path)"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagSlimImage: &cli.StringFlag{
+		Name:    FlagSlimImage,
+		Value:   "",
+		Usage:   FlagSlimImageUsage,
+		EnvVars: []string{"DSLIM_VERIFY_SLIM_IMAGE"},
+	},
+	FlagIgnoreHeader: &cli.StringSliceFlag{
+		Name:    FlagIgnoreHeader,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagIgnoreHeaderUsage,
+		EnvVars: []string{"DSLIM_VERIFY_IGNORE_HEADER"},
+	},
+	FlagIgnoreBody: &cli.BoolFlag{
+		Name:    FlagIgnoreBody,
+		Usage:   FlagIgnoreBodyUsage,
+		EnvVars: []string{"DSLIM_VERIFY_IGNORE_BODY"},
+	},
+	FlagJUnit: &cli.StringFlag{
+		Name:    FlagJUnit,
+		Value:   "",
+		Usage:   FlagJUnitUsage,
+		EnvVars: []string{"DSLIM_VERIFY_JUNIT"},
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
@@ -0,0 +1,392 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	probe "github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/http"
+	"github.com/docker-slim/docker-slim/pkg/app/master/version"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+// Verify command exit codes
+const (
+	ecvOther = iota + 1
+	ecvNoImage
+	ecvProbeError
+	ecvIncompatible
+)
+
+// exitCodes documents the verify command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTVerify | ecvOther, Name: "verify.other", Description: "other verify command error"},
+	{Code: commands.ECTVerify | ecvNoImage, Name: "verify.image.not.found", Description: "target image not found"},
+	{Code: commands.ECTVerify | ecvProbeError, Name: "verify.probe.error", Description: "HTTP probe error"},
+	{Code: commands.ECTVerify | ecvIncompatible, Name: "verify.incompatible", Description: "optimized image is not compatible with the original image"},
+}
+
+// the response headers expected to be different for each call
+var volatileHeaders = []string{
+	"Date",
+	"Expires",
+	"Last-Modified",
+	"Etag",
+	"Age",
+	"Set-Cookie",
+	"X-Request-Id",
+}
+
+// OnCommand implements the 'verify' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	cparams *CommandParams,
+	httpProbeOpts config.HTTPProbeOptions) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+	prefix := fmt.Sprintf("cmd=%s", Name)
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewVerifyCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = cparams.TargetRef
+	cmdReport.SlimImage = cparams.SlimImage
+
+	xc.Out.State("started")
+	xc.Out.Info("params",
+		ovars{
+			"target":     cparams.TargetRef,
+			"slim.image": cparams.SlimImage,
+		})
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err == dockerclient.ErrNoDockerInfo {
+		exitMsg := "missing Docker connection info"
+		if gparams.InContainer && gparams.IsDSImage {
+			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+		}
+
+		xc.Out.Info("docker.connect.error",
+			ovars{
+				"message": exitMsg,
+			})
+
+		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+		xc.Exit(exitCode)
+	}
+	xc.FailOn(err)
+
+	if gparams.Debug {
+		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	}
+
+	for _, imageRef := range []string{cparams.TargetRef, cparams.SlimImage} {
+		imageInspector, err := image.NewInspector(client, imageRef)
+		xc.FailOn(err)
+
+		if imageInspector.NoImage() {
+			xc.Out.Info("target.image.error",
+				ovars{
+					"status": "image.not.found",
+					"image":  imageRef,
+				})
+
+			exitCode := commands.ECTVerify | ecvNoImage
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+
+			cmdReport.Error = "image.not.found"
+			cmdReport.Save()
+			xc.Exit(exitCode)
+		}
+	}
+
+	//probing both images side by side
+	overrides := newContainerOverrides(cparams)
+	images := []string{cparams.TargetRef, cparams.SlimImage}
+	recorders := []*responseRecorder{newResponseRecorder(), newResponseRecorder()}
+	probes := make([]*probe.CustomProbe, len(images))
+	probeErrors := make([]error, len(images))
+
+	xc.Out.State("verify.probing")
+
+	var wg sync.WaitGroup
+	for idx := range images {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			probes[idx], probeErrors[idx] = commands.RunImageProbe(
+				xc,
+				client,
+				images[idx],
+				overrides,
+				httpProbeOpts,
+				recorders[idx].record,
+				false)
+		}(idx)
+	}
+
+	wg.Wait()
+
+	for idx, err := range probeErrors {
+		if err != nil {
+			xc.Out.Error("verify.probe", fmt.Sprintf("%s - %v", images[idx], err))
+
+			if cparams.JUnitOutput != "" {
+				saveJUnit(xc,
+					cparams.JUnitOutput,
+					junitReport(cparams, nil, nil, fmt.Errorf("%s - %v", images[idx], err)),
+					logger)
+			}
+
+			exitCode := commands.ECTVerify | ecvProbeError
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+				})
+
+			cmdReport.Error = "probe.error"
+			cmdReport.Save()
+			xc.Exit(exitCode)
+		}
+	}
+
+	cmdReport.OriginalProbe = probes[0].Report()
+	cmdReport.SlimProbe = probes[1].Report()
+
+	ignoredHeaders := map[string]struct{}{}
+	for _, name := range append(volatileHeaders, cparams.IgnoreHeaders...) {
+		ignoredHeaders[http.CanonicalHeaderKey(strings.TrimSpace(name))] = struct{}{}
+	}
+
+	keys := recorders[0].keys(recorders[1])
+	diffsByKey := map[string][]*report.VerifyDifference{}
+	for _, key := range keys {
+		cmdReport.CompareCount++
+		diffs := compareResponses(
+			recorders[0].responses[key],
+			recorders[1].responses[key],
+			ignoredHeaders,
+			cparams.DoIgnoreBody)
+		if len(diffs) == 0 {
+			cmdReport.MatchCount++
+			continue
+		}
+
+		diffsByKey[key] = diffs
+		cmdReport.Differences = append(cmdReport.Differences, diffs...)
+	}
+
+	for _, diff := range cmdReport.Differences {
+		xc.Out.Info("verify.difference",
+			ovars{
+				"method":   diff.Method,
+				"resource": diff.Resource,
+				"protocol": diff.Protocol,
+				"field":    diff.Field,
+				"name":     diff.Name,
+				"original": diff.Original,
+				"slim":     diff.Slim,
+			})
+	}
+
+	cmdReport.Compatible = cmdReport.CompareCount > 0 && len(cmdReport.Differences) == 0
+	if cparams.JUnitOutput != "" {
+		saveJUnit(xc, cparams.JUnitOutput, junitReport(cparams, keys, diffsByKey, nil), logger)
+	}
+
+	xc.Out.Info("verify.summary",
+		ovars{
+			"compared":    cmdReport.CompareCount,
+			"matched":     cmdReport.MatchCount,
+			"differences": len(cmdReport.Differences),
+			"compatible":  cmdReport.Compatible,
+		})
+
+	if !cmdReport.Compatible {
+		exitCode := commands.ECTVerify | ecvIncompatible
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"version":   v.Current(),
+				"location":  fsutil.ExeDir(),
+			})
+
+		cmdReport.Error = "incompatible"
+		if cmdReport.Save() {
+			xc.Out.Info("report",
+				ovars{
+					"file": cmdReport.ReportLocation(),
+				})
+		}
+		xc.Exit(exitCode)
+	}
+
+	xc.Out.State("completed")
+	cmdReport.State = command.StateCompleted
+	xc.Out.State("done")
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(xc, "", vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		xc.Out.Info("report",
+			ovars{
+				"file": cmdReport.ReportLocation(),
+			})
+	}
+}
+
+// responseRecorder keeps the last probe response for each probe command
+// (the host ports are different for each image, so they are not a part of the key)
+type responseRecorder struct {
+	mu        sync.Mutex
+	responses map[string]*probe.ProbeResponse
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{
+		responses: map[string]*probe.ProbeResponse{},
+	}
+}
+
+func responseKey(response *probe.ProbeResponse) string {
+	return fmt.Sprintf("%s %s %s", response.Method, response.Protocol, response.Resource)
+}
+
+func (r *responseRecorder) record(response *probe.ProbeResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses[responseKey(response)] = response
+}
+
+func (r *responseRecorder) keys(other *responseRecorder) []string {
+	set := map[string]struct{}{}
+	for key := range r.responses {
+		set[key] = struct{}{}
+	}
+
+	for key := range other.responses {
+		set[key] = struct{}{}
+	}
+
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func compareResponses(
+	original *probe.ProbeResponse,
+	slim *probe.ProbeResponse,
+	ignoredHeaders map[string]struct{},
+	ignoreBody bool) []*report.VerifyDifference {
+	var ref *probe.ProbeResponse
+	if original != nil {
+		ref = original
+	} else {
+		ref = slim
+	}
+
+	newDiff := func(field, name, originalVal, slimVal string) *report.VerifyDifference {
+		return &report.VerifyDifference{
+			Method:   ref.Method,
+			Protocol: ref.Protocol,
+			Resource: ref.Resource,
+			Field:    field,
+			Name:     name,
+			Original: originalVal,
+			Slim:     slimVal,
+		}
+	}
+
+	if original == nil || slim == nil {
+		return []*report.VerifyDifference{
+			newDiff("response", "", responseSummary(original), responseSummary(slim)),
+		}
+	}
+
+	if original.StatusCode != slim.StatusCode || (original.StatusCode == 0 && original.Error != slim.Error) {
+		return []*report.VerifyDifference{
+			newDiff("status", "", responseSummary(original), responseSummary(slim)),
+		}
+	}
+
+	var diffs []*report.VerifyDifference
+	names := map[string]struct{}{}
+	for name := range original.Header {
+		names[name] = struct{}{}
+	}
+
+	for name := range slim.Header {
+		names[name] = struct{}{}
+	}
+
+	var sortedNames []string
+	for name := range names {
+		if _, ignored := ignoredHeaders[http.CanonicalHeaderKey(name)]; !ignored {
+			sortedNames = append(sortedNames, name)
+		}
+	}
+
+	sort.Strings(sortedNames)
+	for _, name := range sortedNames {
+		originalVal := strings.Join(original.Header.Values(name), ", ")
+		slimVal := strings.Join(slim.Header.Values(name), ", ")
+		if originalVal != slimVal {
+			diffs = append(diffs, newDiff("header", name, originalVal, slimVal))
+		}
+	}
+
+	if !ignoreBody && !bytes.Equal(original.Body, slim.Body) {
+		diffs = append(diffs, newDiff("body", "", bodySummary(original.Body), bodySummary(slim.Body)))
+	}
+
+	return diffs
+}
+
+func responseSummary(response *probe.ProbeResponse) string {
+	switch {
+	case response == nil:
+		return "missing"
+	case response.StatusCode == 0:
+		return fmt.Sprintf("error: %s", response.Error)
+	default:
+		return fmt.Sprintf("%d", response.StatusCode)
+	}
+}
+
+func bodySummary(body []byte) string {
+	return fmt.Sprintf("size=%d sha256=%x", len(body), sha256.Sum256(body))
+}
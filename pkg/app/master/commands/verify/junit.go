@@ -0,0 +1,90 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/junit"
+	"github.com/docker-slim/docker-slim/pkg/report"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// junitReport creates a JUnit XML report with the verification results
+// (one test case for each compared probe call; the calls with response differences are failures)
+func junitReport(
+	cparams *CommandParams,
+	keys []string,
+	diffs map[string][]*report.VerifyDifference,
+	probeErr error) *junit.TestSuites {
+	suite := &junit.TestSuite{
+		Name: fmt.Sprintf("%s vs %s", cparams.TargetRef, cparams.SlimImage),
+	}
+
+	className := fmt.Sprintf("docker-slim.verify.%s", cparams.SlimImage)
+	switch {
+	case probeErr != nil:
+		suite.Add(&junit.TestCase{
+			Name:      "probe",
+			ClassName: className,
+			Error:     &junit.Result{Message: probeErr.Error()},
+		})
+	case len(keys) == 0:
+		suite.Add(&junit.TestCase{
+			Name:      "probe",
+			ClassName: className,
+			Error:     &junit.Result{Message: "no probe responses to compare"},
+		})
+	}
+
+	for _, key := range keys {
+		tc := &junit.TestCase{
+			Name:      key,
+			ClassName: className,
+		}
+
+		if keyDiffs := diffs[key]; len(keyDiffs) > 0 {
+			var lines []string
+			for _, diff := range keyDiffs {
+				field := diff.Field
+				if diff.Name != "" {
+					field = fmt.Sprintf("%s '%s'", field, diff.Name)
+				}
+
+				lines = append(lines, fmt.Sprintf("%s: original='%s' slim='%s'", field, diff.Original, diff.Slim))
+			}
+
+			tc.Failure = &junit.Result{
+				Message: fmt.Sprintf("%d response difference(s)", len(keyDiffs)),
+				Type:    "difference",
+				Details: strings.Join(lines, "\n"),
+			}
+		}
+
+		suite.Add(tc)
+	}
+
+	return junit.NewReport("docker-slim-verify", []*junit.TestSuite{suite})
+}
+
+func saveJUnit(
+	xc *app.ExecutionContext,
+	junitOutput string,
+	results *junit.TestSuites,
+	logger *log.Entry) {
+	if err := results.Save(junitOutput); err != nil {
+		logger.Errorf("saveJUnit: error saving JUnit results (%s) - %v", junitOutput, err)
+		xc.Out.Info("verify.junit",
+			ovars{
+				"file":  junitOutput,
+				"error": err.Error(),
+			})
+		return
+	}
+
+	xc.Out.Info("verify.junit",
+		ovars{
+			"file": junitOutput,
+		})
+}
@@ -13,14 +13,31 @@ const (
 	Alias = "v"
 )
 
+const (
+	FlagJSON      = "json"
+	FlagJSONUsage = "Print the version info with the build metadata, the sensor version and the update status as JSON"
+)
+
 var CLI = &cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
 	Usage:   Usage,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  FlagJSON,
+			Usage: FlagJSONUsage,
+		},
+	},
 	Action: func(ctx *cli.Context) error {
 		doDebug := ctx.Bool(commands.FlagDebug)
 		inContainer, isDSImage := commands.IsInContainer(ctx.Bool(commands.FlagInContainer))
 		clientConfig := commands.GetDockerClientConfig(ctx)
+		checkVersion := ctx.Bool(commands.FlagCheckVersion)
+		statePath := ctx.String(commands.FlagStatePath)
+
+		if ctx.Bool(FlagJSON) {
+			return OnJSONCommand(checkVersion, inContainer, isDSImage, statePath)
+		}
 
 		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
 
@@ -28,6 +45,8 @@ var CLI = &cli.Command{
 			doDebug,
 			inContainer,
 			isDSImage,
+			checkVersion,
+			statePath,
 			clientConfig)
 
 		//app.ShowCommunityInfo()
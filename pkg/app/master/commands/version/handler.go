@@ -1,17 +1,19 @@
 package version
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
-	//"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/sensor"
 	"github.com/docker-slim/docker-slim/pkg/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 )
@@ -22,8 +24,13 @@ type ovars = app.OutVars
 func OnCommand(
 	xc *app.ExecutionContext,
 	doDebug, inContainer, isDSImage bool,
+	checkVersion bool,
+	statePath string,
 	clientConfig *config.DockerClient) {
 	logger := log.WithFields(log.Fields{"app": "docker-slim", "command": command.Version})
+	prefix := fmt.Sprintf("cmd=%s", Name)
+
+	viChan := version.CheckAsync(checkVersion, inContainer, isDSImage)
 
 	client, err := dockerclient.New(clientConfig)
 	if err == dockerclient.ErrNoDockerInfo {
@@ -37,7 +44,7 @@ func OnCommand(
 				"message": exitMsg,
 			})
 
-		exitCode := -777
+		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
 		xc.Out.State("exited",
 			ovars{
 				"exit.code": exitCode,
@@ -46,7 +53,64 @@ func OnCommand(
 			})
 		xc.Exit(exitCode)
 	}
-	errutil.FailOn(err)
+	xc.FailOn(err)
+
+	version.Print(prefix, logger, client, false, inContainer, isDSImage)
+
+	sensorInfo := sensor.LocalBinVersion(statePath)
+	if sensorInfo.BuildInfo != nil {
+		fmt.Printf("%s info=sensor version='%s' commit=%s build_time=%s protocol=%d location='%s'\n",
+			prefix, sensorInfo.Version, sensorInfo.Commit, sensorInfo.BuildTime, sensorInfo.ProtocolVersion, sensorInfo.Location)
+	} else {
+		fmt.Printf("%s info=sensor location='%s' error='%s'\n", prefix, sensorInfo.Location, sensorInfo.Error)
+	}
+
+	if checkVersion {
+		vinfo := <-viChan
+		outdated := "unknown"
+		current := "unknown"
+		if vinfo != nil && vinfo.Status == "success" {
+			outdated = fmt.Sprintf("%v", vinfo.Outdated)
+			current = vinfo.Current
+		}
+
+		fmt.Printf("%s info=app outdated=%v current=%v verdict='%v'\n",
+			prefix, outdated, current, version.GetCheckVersionVerdict(vinfo))
+	}
+}
+
+// jsonVersionInfo is the 'version --json' output
+type jsonVersionInfo struct {
+	*v.BuildInfo
+	Location string               `json:"location"`
+	Sensor   *sensor.LocalBinInfo `json:"sensor"`
+	Update   *jsonUpdateInfo      `json:"update,omitempty"`
+}
+
+type jsonUpdateInfo struct {
+	*version.CheckVersionInfo
+	Verdict string `json:"verdict"`
+}
+
+// OnJSONCommand implements the 'version --json' docker-slim command
+// (it doesn't need the Docker connection)
+func OnJSONCommand(checkVersion, inContainer, isDSImage bool, statePath string) error {
+	viChan := version.CheckAsync(checkVersion, inContainer, isDSImage)
+
+	info := jsonVersionInfo{
+		BuildInfo: v.Build(),
+		Location:  fsutil.ExeDir(),
+		Sensor:    sensor.LocalBinVersion(statePath),
+	}
+
+	if vinfo := <-viChan; vinfo != nil {
+		info.Update = &jsonUpdateInfo{
+			CheckVersionInfo: vinfo,
+			Verdict:          version.GetCheckVersionVerdict(vinfo),
+		}
+	}
 
-	version.Print(fmt.Sprintf("cmd=%s", Name), logger, client, true, inContainer, isDSImage)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&info)
 }
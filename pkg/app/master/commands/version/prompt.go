@@ -1,6 +1,8 @@
 package version
 
 import (
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
 	"github.com/c-bata/go-prompt"
 )
 
@@ -8,3 +10,12 @@ var CommandSuggestion = prompt.Suggest{
 	Text:        Name,
 	Description: Usage,
 }
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(FlagJSON), Description: FlagJSONUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(FlagJSON): commands.CompleteBool,
+	},
+}
@@ -0,0 +1,33 @@
+package wizard
+
+import (
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+
+	"github.com/urfave/cli/v2"
+)
+
+//Interactive 'build' command wizard
+
+const (
+	Name  = "wizard"
+	Usage = "Interactively creates (and optionally runs) a build command (for first-time users)"
+	Alias = "w"
+)
+
+var CLI = &cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Action: func(ctx *cli.Context) error {
+		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
+
+		gcvalues, err := commands.GlobalFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		OnCommand(xc, gcvalues)
+		return nil
+	},
+}
@@ -0,0 +1,374 @@
+package wizard
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands/build"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const appName = commands.AppName
+
+type ovars = app.OutVars
+
+const (
+	presetNone         = "none"
+	defaultRunDuration = "60"
+)
+
+// OnCommand implements the 'wizard' docker-slim command
+func OnCommand(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams) {
+	logger := log.WithFields(log.Fields{"app": appName, "command": Name})
+
+	w := &wizard{
+		in:  bufio.NewReader(os.Stdin),
+		out: os.Stdout,
+	}
+
+	fmt.Fprintln(w.out, "This wizard creates a 'build' command for your container image.")
+	fmt.Fprintln(w.out, "Press Enter to accept the default answer (in brackets).")
+	fmt.Fprintln(w.out)
+
+	flags := &buildFlags{}
+
+	target := w.ask("Target image (name or ID)", "")
+	if target == "" {
+		xc.Out.Error("wizard.target", "missing target image")
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": commands.ECTCommon | commands.ECBadParams,
+			})
+		xc.Exit(commands.ECTCommon | commands.ECBadParams)
+	}
+
+	flags.add(commands.FlagTarget, target)
+
+	presets := append([]string{presetNone}, commands.FlagPresetNames()...)
+	preset := w.askChoice("Workload type", presets, presetNone)
+	if preset != presetNone {
+		flags.add(commands.FlagPreset, preset)
+	}
+
+	fmt.Fprintln(w.out)
+	fmt.Fprintln(w.out, "DockerSlim needs to exercise your app to see what it uses.")
+	httpProbe := w.askYesNo("Does the image run an HTTP service?", true)
+	if httpProbe {
+		if ports := w.ask("HTTP ports to probe (comma separated; empty to probe all exposed ports)", ""); ports != "" {
+			flags.add(commands.FlagHTTPProbePorts, ports)
+		}
+
+		for _, path := range w.askList("HTTP paths to probe (comma separated, e.g., /health,/api/users; empty to crawl from /)") {
+			if !strings.HasPrefix(path, "/") {
+				path = "/" + path
+			}
+
+			flags.add(commands.FlagHTTPProbeCmd, path)
+		}
+	} else {
+		flags.addBool(commands.FlagHTTPProbe, false)
+	}
+
+	execCmd := w.ask("Shell commands to run in the container to exercise the app (empty to skip)", "")
+	if execCmd != "" {
+		flags.add(commands.FlagExec, execCmd)
+	}
+
+	switch {
+	case httpProbe && execCmd != "":
+		flags.add(commands.FlagContinueAfter, config.CAMProbe+"&"+config.CAMExec)
+	case execCmd != "":
+		flags.add(commands.FlagContinueAfter, config.CAMExec)
+	case !httpProbe:
+		duration := w.askNumber("How long to run the container before the analysis is done (seconds)", defaultRunDuration)
+		flags.add(commands.FlagContinueAfter, duration)
+	}
+
+	fmt.Fprintln(w.out)
+	fmt.Fprintln(w.out, "DockerSlim keeps only what your app uses, but you can keep extra files too.")
+	for _, path := range w.askList("Extra paths to keep (comma separated; empty to skip)") {
+		flags.add(build.FlagIncludePath, path)
+	}
+
+	for _, path := range w.askList("Extra binaries to keep (absolute paths, comma separated; empty to skip)") {
+		flags.add(build.FlagIncludeBin, path)
+	}
+
+	if w.askYesNo("Keep basic shell functionality?", false) {
+		flags.addBool(build.FlagIncludeShell, true)
+	}
+
+	if tag := w.ask("Tag for the optimized image (empty to use the default '.slim' tag)", ""); tag != "" {
+		flags.add(build.FlagTag, tag)
+	}
+
+	cmdLine := flags.commandLine()
+	fmt.Fprintln(w.out)
+	xc.Out.Info("wizard.command",
+		ovars{
+			"command": cmdLine,
+		})
+
+	fmt.Fprintln(w.out)
+	if profile := w.ask(fmt.Sprintf("Save the settings as a config profile in %s (profile name; empty to skip)", commands.ProjectFlagConfigFile), ""); profile != "" {
+		if err := saveProfile(commands.ProjectFlagConfigFile, profile, flags); err != nil {
+			logger.Debugf("error saving config profile - %v", err)
+			xc.Out.Error("wizard.profile", err.Error())
+		} else {
+			xc.Out.Info("wizard.profile",
+				ovars{
+					"name":    profile,
+					"file":    commands.ProjectFlagConfigFile,
+					"command": fmt.Sprintf("%s --%s %s %s", appName, commands.FlagProfile, profile, build.Name),
+				})
+		}
+	}
+
+	if !w.askYesNo("Run the build command now?", false) {
+		xc.Out.State("done")
+		return
+	}
+
+	exitCode, err := commands.RunSelfCommand(xc, flags.args())
+	if err != nil {
+		logger.Debugf("error running the build command - %v", err)
+		xc.Out.Error("wizard.run", err.Error())
+		exitCode = commands.ECTCommon | commands.ECCommandExec
+	}
+
+	if exitCode != 0 {
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+			})
+		xc.Exit(exitCode)
+	}
+
+	xc.Out.State("done")
+}
+
+type buildFlag struct {
+	name   string
+	values []string
+	isBool bool
+}
+
+// buildFlags contains the build command flags (in the order they were added)
+type buildFlags struct {
+	list []*buildFlag
+}
+
+func (ref *buildFlags) get(name string) *buildFlag {
+	for _, flag := range ref.list {
+		if flag.name == name {
+			return flag
+		}
+	}
+
+	flag := &buildFlag{name: name}
+	ref.list = append(ref.list, flag)
+	return flag
+}
+
+func (ref *buildFlags) add(name, value string) {
+	flag := ref.get(name)
+	flag.values = append(flag.values, value)
+}
+
+func (ref *buildFlags) addBool(name string, value bool) {
+	flag := ref.get(name)
+	flag.isBool = true
+	flag.values = []string{strconv.FormatBool(value)}
+}
+
+func (ref *buildFlags) args() []string {
+	args := []string{build.Name}
+	for _, flag := range ref.list {
+		for _, value := range flag.values {
+			if flag.isBool {
+				args = append(args, fmt.Sprintf("--%s=%s", flag.name, value))
+			} else {
+				args = append(args, "--"+flag.name, value)
+			}
+		}
+	}
+
+	return args
+}
+
+func (ref *buildFlags) commandLine() string {
+	parts := []string{appName}
+	for _, arg := range ref.args() {
+		parts = append(parts, shellQuote(arg))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// configValues returns the flag values for the flag config file
+func (ref *buildFlags) configValues() map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, flag := range ref.list {
+		switch {
+		case flag.isBool:
+			values[flag.name] = flag.values[0] == "true"
+		case len(flag.values) == 1 && !isMultiValueFlag(flag.name):
+			values[flag.name] = flag.values[0]
+		default:
+			values[flag.name] = flag.values
+		}
+	}
+
+	return values
+}
+
+func isMultiValueFlag(name string) bool {
+	switch name {
+	case commands.FlagHTTPProbeCmd, build.FlagIncludePath, build.FlagIncludeBin, build.FlagTag:
+		return true
+	}
+
+	return false
+}
+
+func shellQuote(value string) string {
+	if value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@%+", r))
+	}) == -1 {
+		return value
+	}
+
+	return "'" + strings.Replace(value, "'", `'"'"'`, -1) + "'"
+}
+
+// saveProfile adds (or replaces) the build command profile in the flag config file
+// (the existing config file comments are not preserved)
+func saveProfile(filePath, name string, flags *buildFlags) error {
+	fileConfig := map[string]interface{}{}
+	data, err := ioutil.ReadFile(filePath)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &fileConfig); err != nil {
+			return fmt.Errorf("%s: %v", filePath, err)
+		}
+
+		if fileConfig == nil {
+			fileConfig = map[string]interface{}{}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	profiles, _ := fileConfig["profiles"].(map[string]interface{})
+	if profiles == nil {
+		profiles = map[string]interface{}{}
+	}
+
+	profiles[name] = map[string]interface{}{
+		"commands": map[string]interface{}{
+			build.Name: flags.configValues(),
+		},
+	}
+
+	fileConfig["profiles"] = profiles
+	data, err = yaml.Marshal(fileConfig)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, data, 0644)
+}
+
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask returns the answer or the default value if the answer is empty
+// (or if there's no more input)
+func (ref *wizard) ask(question, defaultValue string) string {
+	if defaultValue != "" {
+		fmt.Fprintf(ref.out, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(ref.out, "%s: ", question)
+	}
+
+	line, err := ref.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && line == "" {
+		fmt.Fprintln(ref.out)
+	}
+
+	if line == "" {
+		return defaultValue
+	}
+
+	return line
+}
+
+func (ref *wizard) askYesNo(question string, defaultValue bool) bool {
+	choices := "y/N"
+	if defaultValue {
+		choices = "Y/n"
+	}
+
+	for {
+		switch strings.ToLower(ref.ask(fmt.Sprintf("%s (%s)", question, choices), "")) {
+		case "":
+			return defaultValue
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+
+		fmt.Fprintln(ref.out, "Please answer 'y' or 'n'.")
+	}
+}
+
+func (ref *wizard) askChoice(question string, choices []string, defaultValue string) string {
+	for {
+		answer := ref.ask(fmt.Sprintf("%s (%s)", question, strings.Join(choices, ", ")), defaultValue)
+		for _, choice := range choices {
+			if answer == choice {
+				return answer
+			}
+		}
+
+		fmt.Fprintf(ref.out, "Unknown value - '%s'.\n", answer)
+	}
+}
+
+func (ref *wizard) askNumber(question, defaultValue string) string {
+	for {
+		answer := ref.ask(question, defaultValue)
+		if n, err := strconv.Atoi(answer); err == nil && n > 0 {
+			return answer
+		}
+
+		fmt.Fprintf(ref.out, "Not a positive number - '%s'.\n", answer)
+	}
+}
+
+func (ref *wizard) askList(question string) []string {
+	var list []string
+	for _, value := range strings.Split(ref.ask(question, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,126 @@
+package xray
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/cache"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// analysisCacheParams includes the xray parameters that affect the image data analysis results
+type analysisCacheParams struct {
+	Version                string
+	Changes                map[string]struct{}
+	ChangesOutputs         map[string]struct{}
+	Layers                 map[string]struct{}
+	LayerChangesMax        int
+	AllChangesMax          int
+	AddChangesMax          int
+	ModifyChangesMax       int
+	DeleteChangesMax       int
+	TopChangesMax          int
+	DoHashData             bool
+	DoDetectDuplicates     bool
+	DoShowDuplicates       bool
+	DoShowSpecialPerms     bool
+	ChangeMatchLayersOnly  bool
+	DoAddImageManifest     bool
+	DoAddImageConfig       bool
+	DoDetectAllCertFiles   bool
+	DoDetectAllCertPKFiles bool
+	DoDetectWastedSpace    bool
+	WastedSpaceFilesMax    int
+	DoTopSizes             bool
+	TopSizesMax            int
+	TopSizesSort           string
+	DoDetectPackages       bool
+	DoDetectLicenses       bool
+	SecretsMaxFileSize     int
+	DoAuditELF             bool
+	BlobsMinFileSize       int64
+	FindPaths              []string
+	FindContent            []string
+}
+
+// analysisResult includes the xray report data produced by the image data analysis
+type analysisResult struct {
+	ImageReport      *dockerimage.ImageReport    `json:"image_report,omitempty"`
+	ImageStack       []*reverse.ImageInfo        `json:"image_stack"`
+	ImageLayers      []*dockerimage.LayerReport  `json:"image_layers"`
+	ContainerEntry   report.ContainerEntryInfo   `json:"container_entry"`
+	RawImageManifest *dockerimage.ManifestObject `json:"raw_image_manifest,omitempty"`
+	RawImageConfig   *dockerimage.ConfigObject   `json:"raw_image_config,omitempty"`
+}
+
+// analysisResultKey returns the cache key for the image data analysis results
+// (an empty key means the analysis results can't be cached)
+func analysisResultKey(params *analysisCacheParams, logger *log.Entry) string {
+	params.Version = v.Current()
+	key, err := cache.ParamsKey(params)
+	if err != nil {
+		logger.Debugf("analysisResultKey: error creating cache key - %v", err)
+		return ""
+	}
+
+	return key
+}
+
+// loadCachedAnalysis loads the cached image data analysis results into the command report
+func loadCachedAnalysis(
+	xc *app.ExecutionContext,
+	resultCache *cache.Store,
+	key string,
+	imageID string,
+	cmdReport *report.XrayCommand,
+	logger *log.Entry) bool {
+	var result analysisResult
+	found, err := resultCache.Load(imageID, cache.KindAnalysis, key, &result)
+	if err != nil {
+		logger.Debugf("loadCachedAnalysis: error loading cached results - %v", err)
+		return false
+	}
+
+	status := "miss"
+	if found {
+		status = "hit"
+		cmdReport.ImageReport = result.ImageReport
+		cmdReport.ImageStack = result.ImageStack
+		cmdReport.ImageLayers = result.ImageLayers
+		cmdReport.SourceImage.ContainerEntry = result.ContainerEntry
+		cmdReport.RawImageManifest = result.RawImageManifest
+		cmdReport.RawImageConfig = result.RawImageConfig
+	}
+
+	xc.Out.Info("analysis.cache",
+		ovars{
+			"status": status,
+			"key":    key,
+		})
+
+	return found
+}
+
+// saveCachedAnalysis saves the image data analysis results from the command report in the cache
+func saveCachedAnalysis(
+	resultCache *cache.Store,
+	key string,
+	imageID string,
+	cmdReport *report.XrayCommand,
+	logger *log.Entry) {
+	result := &analysisResult{
+		ImageReport:      cmdReport.ImageReport,
+		ImageStack:       cmdReport.ImageStack,
+		ImageLayers:      cmdReport.ImageLayers,
+		ContainerEntry:   cmdReport.SourceImage.ContainerEntry,
+		RawImageManifest: cmdReport.RawImageManifest,
+		RawImageConfig:   cmdReport.RawImageConfig,
+	}
+
+	if err := resultCache.Save(imageID, cache.KindAnalysis, key, result); err != nil {
+		logger.Debugf("saveCachedAnalysis: error saving results in cache - %v", err)
+	}
+}
@@ -7,8 +7,12 @@ import (
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/sbom"
+	"github.com/docker-slim/docker-slim/pkg/upload"
 
+	"github.com/dustin/go-humanize"
 	"github.com/urfave/cli/v2"
 )
 
@@ -29,6 +33,8 @@ var CLI = &cli.Command{
 		commands.Cflag(commands.FlagRegistryAccount),
 		commands.Cflag(commands.FlagRegistrySecret),
 		commands.Cflag(commands.FlagShowPullLogs),
+		cflag(FlagRemote),
+		cflag(FlagRemotePlatform),
 		cflag(FlagChanges),
 		cflag(FlagChangesOutput),
 		cflag(FlagLayer),
@@ -53,7 +59,38 @@ var CLI = &cli.Command{
 		cflag(FlagShowSpecialPerms),
 		cflag(FlagChangeDataHash),
 		cflag(FlagExportAllDataArtifacts),
+		cflag(FlagLayerParallelism),
+		cflag(FlagDetectWastedSpace),
+		cflag(FlagWastedSpaceFilesMax),
+		cflag(FlagTopSizes),
+		cflag(FlagTopSizesMax),
+		cflag(FlagTopSizesSort),
+		cflag(FlagSizeTree),
+		cflag(FlagSizeTreeDepth),
+		cflag(FlagDetectPackages),
+		cflag(FlagShowPackages),
+		cflag(FlagDetectLicenses),
+		cflag(FlagShowLicenses),
+		cflag(FlagDenyLicenses),
+		cflag(FlagDetectSecrets),
+		cflag(FlagDetectSecretsMaxSize),
+		cflag(FlagAuditELF),
+		cflag(FlagDetectBlobs),
+		cflag(FlagDetectBlobsMinSize),
+		cflag(FlagFindContent),
+		cflag(FlagFindPath),
+		cflag(FlagExportLayer),
+		cflag(FlagExportLayerMerged),
+		cflag(FlagExportLayerOutput),
+		cflag(FlagCompareWith),
+		cflag(FlagCompareFilesMax),
+		cflag(FlagTUI),
 		commands.Cflag(commands.FlagRemoveFileArtifacts),
+		commands.Cflag(commands.FlagSBOM),
+		commands.Cflag(commands.FlagSBOMOutput),
+		commands.Cflag(commands.FlagReportHTML),
+		commands.Cflag(commands.FlagReportUpload),
+		commands.Cflag(commands.FlagReportUploadArtifacts),
 	},
 	Action: func(ctx *cli.Context) error {
 		xc := app.NewExecutionContext(Name, ctx.String(commands.FlagConsoleFormat))
@@ -75,9 +112,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.global", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		doDetectAllCertFiles := ctx.Bool(FlagDetectAllCertFiles)
@@ -91,14 +128,27 @@ var CLI = &cli.Command{
 		registrySecret := ctx.String(commands.FlagRegistrySecret)
 		doShowPullLogs := ctx.Bool(commands.FlagShowPullLogs)
 
+		doRemote := ctx.Bool(FlagRemote)
+		remotePlatform := ctx.String(FlagRemotePlatform)
+		if remotePlatform != "" {
+			if _, err := image.ParsePlatform(remotePlatform); err != nil {
+				xc.Out.Error("param.error.remote.platform", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
+		}
+
 		changes, err := parseChangeTypes(ctx.StringSlice(FlagChanges))
 		if err != nil {
 			xc.Out.Error("param.error.change.types", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		if xdArtifactsPath != "" {
@@ -118,9 +168,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.change.output", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		if xdArtifactsPath != "" {
@@ -132,9 +182,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.layer", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		layerChangesMax := ctx.Int(FlagLayerChangesMax)
@@ -149,9 +199,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.change.path", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		changeDataMatchers, err := parseChangeDataMatchers(ctx.StringSlice(FlagChangeData))
@@ -159,9 +209,9 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.change.data", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		doAddImageManifest := ctx.Bool(FlagAddImageManifest)
@@ -177,8 +227,11 @@ var CLI = &cli.Command{
 		doRmFileArtifacts := ctx.Bool(commands.FlagRemoveFileArtifacts)
 		doReuseSavedImage := ctx.Bool(FlagReuseSavedImage)
 
+		//the image comparison uses the file data hashes to find the changed files
+		compareWith := ctx.String(FlagCompareWith)
+
 		doHashData := ctx.Bool(FlagHashData)
-		if xdArtifactsPath != "" {
+		if xdArtifactsPath != "" || compareWith != "" {
 			doHashData = true
 		}
 
@@ -187,6 +240,43 @@ var CLI = &cli.Command{
 			doHashData = true
 		}
 
+		sbomFormat := ctx.String(commands.FlagSBOM)
+		if sbomFormat != "" && !sbom.IsFormat(sbomFormat) {
+			xc.Out.Error("param.error.sbom", fmt.Sprintf("unsupported SBOM format - %s", sbomFormat))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		//the SBOM file records need the file hashes
+		if sbomFormat != "" {
+			doHashData = true
+		}
+
+		sbomOutput := ctx.String(commands.FlagSBOMOutput)
+
+		reportUpload := ctx.String(commands.FlagReportUpload)
+		if reportUpload != "" && !upload.IsLocation(reportUpload) {
+			xc.Out.Error("param.error.report.upload", fmt.Sprintf("unsupported report upload location - '%s'", reportUpload))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		doTUI := ctx.Bool(FlagTUI)
+		if doTUI && !hasTerminal() {
+			xc.Out.Error("param.error.tui", errNoTerminal.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
 		rawDetectUTF8 := ctx.String(FlagDetectUTF8)
 		if xdArtifactsPath != "" && rawDetectUTF8 == "" {
 			rawDetectUTF8 = "dump:utf8.tgz::10000000"
@@ -197,18 +287,18 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.detect.utf8", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		if utf8Detector != nil && !doHashData {
 			xc.Out.Error("param.error.detect.utf8", "--detect-utf8 requires option --hash-data")
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		doShowDuplicates := ctx.Bool(FlagShowDuplicates)
@@ -219,14 +309,107 @@ var CLI = &cli.Command{
 			xc.Out.Error("param.error.change.data.hash", err.Error())
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -1,
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
 				})
-			xc.Exit(-1)
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
 		}
 
 		changeMatchLayersOnly := ctx.Bool(FlagChangeMatchLayersOnly)
+		layerParallelism := ctx.Int(FlagLayerParallelism)
+		doDetectWastedSpace := ctx.Bool(FlagDetectWastedSpace)
+		wastedSpaceFilesMax := ctx.Int(FlagWastedSpaceFilesMax)
+		doTopSizes := ctx.Bool(FlagTopSizes)
+		topSizesMax := ctx.Int(FlagTopSizesMax)
+		topSizesSort := ctx.String(FlagTopSizesSort)
+		if !dockerimage.IsSizeSortOrder(topSizesSort) {
+			xc.Out.Error("param.error.top.sizes.sort", fmt.Sprintf("unsupported sort order - %s", topSizesSort))
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": commands.ECTCommon | commands.ECBadParams,
+				})
+			xc.Exit(commands.ECTCommon | commands.ECBadParams)
+		}
+
+		doSizeTree := ctx.Bool(FlagSizeTree)
+		sizeTreeDepth := ctx.Int(FlagSizeTreeDepth)
+		doDetectPackages := ctx.Bool(FlagDetectPackages)
+		if sbomFormat != "" {
+			doDetectPackages = true
+		}
+
+		doShowPackages := ctx.Bool(FlagShowPackages)
+
+		var denyLicenses []string
+		for _, license := range ctx.StringSlice(FlagDenyLicenses) {
+			if license = strings.TrimSpace(license); license != "" {
+				denyLicenses = append(denyLicenses, license)
+			}
+		}
+
+		doShowLicenses := ctx.Bool(FlagShowLicenses)
+		doDetectLicenses := ctx.Bool(FlagDetectLicenses)
+		if doShowLicenses || len(denyLicenses) > 0 {
+			doDetectLicenses = true
+		}
+
+		//the package licenses come from the package inventory
+		if doDetectLicenses {
+			doDetectPackages = true
+		}
+
+		var secretDetector *dockerimage.SecretDetector
+		if ctx.Bool(FlagDetectSecrets) {
+			maxSize, err := humanize.ParseBytes(ctx.String(FlagDetectSecretsMaxSize))
+			if err != nil {
+				xc.Out.Error("param.error.detect.secrets.max.size", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
+
+			secretDetector = &dockerimage.SecretDetector{
+				MaxSizeBytes: int(maxSize),
+			}
+		}
+
+		var blobDetector *dockerimage.BlobDetector
+		if ctx.Bool(FlagDetectBlobs) {
+			minSize, err := humanize.ParseBytes(ctx.String(FlagDetectBlobsMinSize))
+			if err != nil {
+				xc.Out.Error("param.error.detect.blobs.min.size", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
+
+			blobDetector = &dockerimage.BlobDetector{
+				MinSizeBytes: int64(minSize),
+			}
+		}
+
+		var contentFinder *dockerimage.ContentFinder
+		findContent := ctx.StringSlice(FlagFindContent)
+		findPaths := ctx.StringSlice(FlagFindPath)
+		if len(findContent) > 0 || len(findPaths) > 0 {
+			contentFinder, err = dockerimage.NewContentFinder(
+				findPaths,
+				findContent,
+				dockerimage.DefaultFindContentMatchesMax)
+			if err != nil {
+				xc.Out.Error("param.error.find", err.Error())
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": commands.ECTCommon | commands.ECBadParams,
+					})
+				xc.Exit(commands.ECTCommon | commands.ECBadParams)
+			}
+		}
 
-		OnCommand(
+		xc.FailOn(OnCommand(
 			xc,
 			gcvalues,
 			targetRef,
@@ -235,6 +418,8 @@ var CLI = &cli.Command{
 			registryAccount,
 			registrySecret,
 			doShowPullLogs,
+			doRemote,
+			remotePlatform,
 			changes,
 			changesOutputs,
 			layers,
@@ -260,7 +445,35 @@ var CLI = &cli.Command{
 			doDetectAllCertFiles,
 			doDetectAllCertPKFiles,
 			xdArtifactsPath,
-		)
+			layerParallelism,
+			doDetectWastedSpace,
+			wastedSpaceFilesMax,
+			doTopSizes,
+			topSizesMax,
+			topSizesSort,
+			doSizeTree,
+			sizeTreeDepth,
+			doDetectPackages,
+			doShowPackages,
+			doDetectLicenses,
+			doShowLicenses,
+			denyLicenses,
+			secretDetector,
+			blobDetector,
+			ctx.Bool(FlagAuditELF),
+			contentFinder,
+			ctx.Int(FlagExportLayer),
+			ctx.Bool(FlagExportLayerMerged),
+			ctx.String(FlagExportLayerOutput),
+			compareWith,
+			ctx.Int(FlagCompareFilesMax),
+			sbomFormat,
+			sbomOutput,
+			doTUI,
+			ctx.String(commands.FlagReportHTML),
+			reportUpload,
+			ctx.Bool(commands.FlagReportUploadArtifacts),
+		))
 
 		return nil
 	},
@@ -0,0 +1,241 @@
+package xray
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+// loadCompareImage inspects and loads the image data for the image compared with the target image
+// (the image data is loaded without the detectors and matchers, but the file data is always hashed)
+func loadCompareImage(
+	xc *app.ExecutionContext,
+	gparams *commands.GenericParams,
+	client *dockerapi.Client,
+	imageRef string,
+	doPull bool,
+	doShowPullLogs bool,
+	doRemote bool,
+	remotePlatform string,
+	dockerConfigPath string,
+	registryAccount string,
+	registrySecret string,
+	doReuseSavedImage bool,
+	layerParallelism int,
+	logger *log.Entry) (*image.Inspector, *dockerimage.Package, string, error) {
+	imageInspector, err := image.NewInspector(client, imageRef)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if doRemote {
+		err = imageInspector.InspectRemote(dockerConfigPath, registryAccount, registrySecret, remotePlatform)
+		if err != nil {
+			return nil, nil, "", err
+		}
+	} else {
+		if imageInspector.NoImage() {
+			if !doPull {
+				xc.Out.Error("compare.image.not.found", "make sure the compared image already exists locally (use --pull flag to auto-download it from registry)")
+
+				exitCode := commands.ECTBuild | ecxImageNotFound
+				xc.Out.State("exited",
+					ovars{
+						"exit.code": exitCode,
+					})
+				return nil, nil, "", app.NewExitError(exitCode)
+			}
+
+			xc.Out.Info("compare.image",
+				ovars{
+					"status":  "not.found",
+					"image":   imageRef,
+					"message": "trying to pull compared image",
+				})
+
+			pullProgress := xc.Out.NewProgress("image.pull", 0, app.ProgressUnitBytes)
+			imageInspector.PullProgress = pullProgress.Update
+			err := imageInspector.Pull(xc.Context, doShowPullLogs, dockerConfigPath, registryAccount, registrySecret)
+			pullProgress.Done()
+			if err != nil {
+				return nil, nil, "", commands.ImagePullError(err)
+			}
+		}
+
+		err = imageInspector.Inspect()
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	imageID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
+	localVolumePath, _, _, _, err := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+	if err != nil {
+		return nil, nil, "", commands.StateDirError(err)
+	}
+
+	iaPath := filepath.Join(localVolumePath, "image", fmt.Sprintf("%s.tar", imageID))
+
+	xc.Out.Info("compare.image",
+		ovars{
+			"image": imageInspector.ImageRef,
+			"id":    imageInspector.ImageInfo.ID,
+		})
+
+	err = saveImageArchive(xc, client, imageInspector, iaPath, doReuseSavedImage, doRemote, logger)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	layerProgress := xc.Out.NewProgress("image.layers", 0, app.ProgressUnitItems)
+	imagePkg, err := dockerimage.LoadPackage(
+		iaPath,
+		imageID,
+		false,
+		0,
+		true,
+		false,
+		nil,
+		nil,
+		nil,
+		nil,
+		false,
+		false,
+		false,
+		false,
+		false,
+		nil,
+		nil,
+		nil,
+		layerParallelism,
+		func(progress *dockerimage.LayerProgress) {
+			layerProgress.Update(int64(progress.Completed), int64(progress.Total))
+		})
+	layerProgress.Done()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return imageInspector, imagePkg, iaPath, nil
+}
+
+func printComparison(
+	xc *app.ExecutionContext,
+	comparison *dockerimage.CompareReport,
+	cmdReport *report.XrayCommand) {
+	cmdReport.ImageReport.Comparison = comparison
+
+	xc.Out.Info("image.compare",
+		ovars{
+			"image":         comparison.Image,
+			"other.image":   comparison.OtherImage,
+			"config.diffs":  len(comparison.Config),
+			"files.added":   comparison.Files.AddedCount,
+			"files.removed": comparison.Files.RemovedCount,
+			"files.changed": comparison.Files.ModifiedCount,
+		})
+
+	for _, diff := range comparison.Config {
+		diffInfo := ovars{
+			"field":  diff.Field,
+			"change": diff.Change,
+		}
+
+		if diff.Key != "" {
+			diffInfo["key"] = diff.Key
+		}
+
+		if diff.Value != "" {
+			diffInfo["value"] = diff.Value
+		}
+
+		if diff.OtherValue != "" {
+			diffInfo["other.value"] = diff.OtherValue
+		}
+
+		xc.Out.Info("image.compare.config", diffInfo)
+	}
+
+	xc.Out.Info("image.compare.layers",
+		ovars{
+			"count":       comparison.Layers.Count,
+			"other.count": comparison.Layers.OtherCount,
+			"shared.base": comparison.Layers.SharedBase,
+			"shared":      comparison.Layers.Shared,
+			"removed":     len(comparison.Layers.Removed),
+			"added":       len(comparison.Layers.Added),
+		})
+
+	for _, layer := range comparison.Layers.Removed {
+		xc.Out.Info("image.compare.layer",
+			ovars{
+				"change":     dockerimage.CompareRemoved,
+				"index":      layer.Index,
+				"diff_id":    layer.DiffID,
+				"size.human": humanize.Bytes(layer.Size),
+			})
+	}
+
+	for _, layer := range comparison.Layers.Added {
+		xc.Out.Info("image.compare.layer",
+			ovars{
+				"change":     dockerimage.CompareAdded,
+				"index":      layer.Index,
+				"diff_id":    layer.DiffID,
+				"size.human": humanize.Bytes(layer.Size),
+			})
+	}
+
+	for _, file := range comparison.Files.Removed {
+		xc.Out.Info("image.compare.file",
+			ovars{
+				"change":     dockerimage.CompareRemoved,
+				"path":       file.Path,
+				"size.human": humanize.Bytes(uint64(file.Size)),
+			})
+	}
+
+	for _, file := range comparison.Files.Added {
+		xc.Out.Info("image.compare.file",
+			ovars{
+				"change":     dockerimage.CompareAdded,
+				"path":       file.Path,
+				"size.human": humanize.Bytes(uint64(file.OtherSize)),
+			})
+	}
+
+	for _, file := range comparison.Files.Modified {
+		xc.Out.Info("image.compare.file",
+			ovars{
+				"change":           dockerimage.CompareModified,
+				"path":             file.Path,
+				"diff":             strings.Join(file.Diff, ","),
+				"size.human":       humanize.Bytes(uint64(file.Size)),
+				"other.size.human": humanize.Bytes(uint64(file.OtherSize)),
+			})
+	}
+
+	xc.Out.Info("image.compare.sizes",
+		ovars{
+			"layers.human":        humanize.Bytes(comparison.Sizes.LayersSize),
+			"other.layers.human":  humanize.Bytes(comparison.Sizes.OtherLayersSize),
+			"layers.delta":        comparison.Sizes.LayersDelta,
+			"files.human":         humanize.Bytes(comparison.Sizes.FilesSize),
+			"other.files.human":   humanize.Bytes(comparison.Sizes.OtherFilesSize),
+			"files.delta":         comparison.Sizes.FilesDelta,
+			"files.added.human":   humanize.Bytes(comparison.Files.AddedSize),
+			"files.removed.human": humanize.Bytes(comparison.Files.RemovedSize),
+		})
+}
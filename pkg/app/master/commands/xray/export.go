@@ -0,0 +1,55 @@
+package xray
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// saveLayerExport extracts the layer data (or the merged filesystem at the layer index)
+// from the saved image archive (defaults to a directory in the artifacts location)
+func saveLayerExport(
+	xc *app.ExecutionContext,
+	archivePath string,
+	imagePkg *dockerimage.Package,
+	index int,
+	merged bool,
+	output string,
+	cmdReport *report.XrayCommand,
+	logger *log.Entry) {
+	if output == "" {
+		name := fmt.Sprintf("layer.%d", index)
+		if merged {
+			name = fmt.Sprintf("%s.merged", name)
+		}
+
+		output = filepath.Join(cmdReport.ArtifactLocation, name)
+	}
+
+	info, err := dockerimage.ExportLayer(archivePath, imagePkg, index, merged, output)
+	if err != nil {
+		logger.Errorf("saveLayerExport: error exporting layer %d - %v", index, err)
+		xc.Out.Info("image.layer.export",
+			ovars{
+				"layer": index,
+				"error": err.Error(),
+			})
+		return
+	}
+
+	cmdReport.LayerExport = info
+	xc.Out.Info("image.layer.export",
+		ovars{
+			"layer":      info.Layer,
+			"merged":     info.Merged,
+			"output":     info.Output,
+			"objects":    info.ObjectCount,
+			"size.human": humanize.Bytes(uint64(info.DataSize)),
+		})
+}
@@ -3,6 +3,8 @@ package xray
 import (
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
 )
 
 // Xray command flag names
@@ -31,6 +33,34 @@ const (
 	FlagExportAllDataArtifacts = "export-all-data-artifacts"
 	FlagDetectAllCertFiles     = "detect-all-certs"
 	FlagDetectAllCertPKFiles   = "detect-all-cert-pks"
+	FlagLayerParallelism       = "layer-parallelism"
+	FlagDetectWastedSpace      = "detect-wasted-space"
+	FlagWastedSpaceFilesMax    = "wasted-space-files-max"
+	FlagTopSizes               = "top-sizes"
+	FlagTopSizesMax            = "top-sizes-max"
+	FlagTopSizesSort           = "top-sizes-sort"
+	FlagSizeTree               = "size-tree"
+	FlagSizeTreeDepth          = "size-tree-depth"
+	FlagDetectPackages         = "detect-packages"
+	FlagShowPackages           = "show-packages"
+	FlagDetectLicenses         = "detect-licenses"
+	FlagShowLicenses           = "show-licenses"
+	FlagDenyLicenses           = "deny-licenses"
+	FlagDetectSecrets          = "detect-secrets"
+	FlagDetectSecretsMaxSize   = "detect-secrets-max-size"
+	FlagAuditELF               = "audit-elf"
+	FlagDetectBlobs            = "detect-blobs"
+	FlagDetectBlobsMinSize     = "detect-blobs-min-size"
+	FlagFindContent            = "find-content"
+	FlagFindPath               = "find-path"
+	FlagExportLayer            = "export-layer"
+	FlagExportLayerMerged      = "export-layer-merged"
+	FlagExportLayerOutput      = "export-layer-output"
+	FlagRemote                 = "remote"
+	FlagRemotePlatform         = "remote-platform"
+	FlagCompareWith            = "compare-with"
+	FlagCompareFilesMax        = "compare-files-max"
+	FlagTUI                    = "tui"
 )
 
 // Xray command flag usage info
@@ -59,6 +89,34 @@ const (
 	FlagExportAllDataArtifactsUsage = "TAR archive file path to export all text data artifacts (if value is set to `.` then the archive file path defaults to `./data-artifacts.tar`)"
 	FlagDetectAllCertFilesUsage     = "Detect all certifcate files"
 	FlagDetectAllCertPKFilesUsage   = "Detect all certifcate private key files"
+	FlagLayerParallelismUsage       = "Number of image layers to process concurrently"
+	FlagDetectWastedSpaceUsage      = "Detect the wasted space (files overwritten or deleted in other layers, cache directories and duplicate files)"
+	FlagWastedSpaceFilesMaxUsage    = "Maximum number of wasted space files to show"
+	FlagTopSizesUsage               = "Show the largest files and directories for the image and for each layer"
+	FlagTopSizesMaxUsage            = "Maximum number of the largest files and directories to show"
+	FlagTopSizesSortUsage           = "Sort order for the largest files and directories (values: size, count, path)"
+	FlagSizeTreeUsage               = "Show the directory size tree view for the image"
+	FlagSizeTreeDepthUsage          = "Maximum directory depth in the size tree view"
+	FlagDetectPackagesUsage         = "Detect the installed OS packages (apk, dpkg and rpm package databases)"
+	FlagShowPackagesUsage           = "Show the installed OS packages"
+	FlagDetectLicensesUsage         = "Detect the package licenses (from the package metadata) and the license files (e.g., LICENSE or COPYING)"
+	FlagShowLicensesUsage           = "Show the package licenses and the license files"
+	FlagDenyLicensesUsage           = "Fail if the image has any of the denied licenses (license IDs or wildcard patterns, e.g., 'AGPL-*')"
+	FlagDetectSecretsUsage          = "Detect secrets (private keys, tokens and passwords) in the layer files, env vars and build history"
+	FlagDetectSecretsMaxSizeUsage   = "Max size of the files scanned for secrets (e.g., 512KB or 2MB)"
+	FlagAuditELFUsage               = "Inventory the dynamic ELF binaries and report the unresolved library dependencies and program interpreters"
+	FlagDetectBlobsUsage            = "Detect high entropy data, embedded archives and bundled interpreter runtimes in the layer files (likely compressed or encrypted payloads)"
+	FlagDetectBlobsMinSizeUsage     = "Min size of the files scanned for compressed, encrypted or bundled payloads (e.g., 512KB or 10MB)"
+	FlagFindContentUsage            = "Find the text files with the content matching the regular expression in all layers (including the deleted and overwritten files)"
+	FlagFindPathUsage               = "Find the objects with the paths matching the glob pattern in all layers (including the deleted and overwritten files)"
+	FlagExportLayerUsage            = "Export the layer data for the selected layer index"
+	FlagExportLayerMergedUsage      = "Export the merged filesystem at the selected layer index (instead of the layer data)"
+	FlagExportLayerOutputUsage      = "Layer export directory or tar archive (.tar, .tar.gz or .tgz)"
+	FlagRemoteUsage                 = "Analyze the target image directly from the registry (without pulling it with the Docker daemon)"
+	FlagRemotePlatformUsage         = "Platform (os/arch[/variant]) to select from a multi-platform remote image"
+	FlagCompareWithUsage            = "Compare the target image with another image (config, layers, files and sizes)"
+	FlagCompareFilesMaxUsage        = "Maximum number of files to show for each file change type in the image comparison"
+	FlagTUIUsage                    = "Explore the image layers and their filesystem trees in the interactive terminal UI (after the analysis)"
 )
 
 var Flags = map[string]cli.Flag{
@@ -197,6 +255,163 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagDetectAllCertPKFilesUsage,
 		EnvVars: []string{"DSLIM_XRAY_DETECT_ALL_CERT_PKS"},
 	},
+	FlagLayerParallelism: &cli.IntFlag{
+		Name:    FlagLayerParallelism,
+		Value:   dockerimage.DefaultLayerParallelism,
+		Usage:   FlagLayerParallelismUsage,
+		EnvVars: []string{"DSLIM_XRAY_LAYER_PARALLELISM"},
+	},
+	FlagDetectWastedSpace: &cli.BoolFlag{
+		Name:    FlagDetectWastedSpace,
+		Value:   true, //enabled by default
+		Usage:   FlagDetectWastedSpaceUsage,
+		EnvVars: []string{"DSLIM_XRAY_DETECT_WASTED_SPACE"},
+	},
+	FlagWastedSpaceFilesMax: &cli.IntFlag{
+		Name:    FlagWastedSpaceFilesMax,
+		Value:   dockerimage.DefaultWastedFilesMax,
+		Usage:   FlagWastedSpaceFilesMaxUsage,
+		EnvVars: []string{"DSLIM_XRAY_WASTED_SPACE_FILES_MAX"},
+	},
+	FlagTopSizes: &cli.BoolFlag{
+		Name:    FlagTopSizes,
+		Usage:   FlagTopSizesUsage,
+		EnvVars: []string{"DSLIM_XRAY_TOP_SIZES"},
+	},
+	FlagTopSizesMax: &cli.IntFlag{
+		Name:    FlagTopSizesMax,
+		Value:   dockerimage.DefaultTopSizesMax,
+		Usage:   FlagTopSizesMaxUsage,
+		EnvVars: []string{"DSLIM_XRAY_TOP_SIZES_MAX"},
+	},
+	FlagTopSizesSort: &cli.StringFlag{
+		Name:    FlagTopSizesSort,
+		Value:   dockerimage.SizeSortBySize,
+		Usage:   FlagTopSizesSortUsage,
+		EnvVars: []string{"DSLIM_XRAY_TOP_SIZES_SORT"},
+	},
+	FlagSizeTree: &cli.BoolFlag{
+		Name:    FlagSizeTree,
+		Usage:   FlagSizeTreeUsage,
+		EnvVars: []string{"DSLIM_XRAY_SIZE_TREE"},
+	},
+	FlagSizeTreeDepth: &cli.IntFlag{
+		Name:    FlagSizeTreeDepth,
+		Value:   dockerimage.DefaultSizeTreeDepth,
+		Usage:   FlagSizeTreeDepthUsage,
+		EnvVars: []string{"DSLIM_XRAY_SIZE_TREE_DEPTH"},
+	},
+	FlagDetectPackages: &cli.BoolFlag{
+		Name:    FlagDetectPackages,
+		Value:   true, //enabled by default
+		Usage:   FlagDetectPackagesUsage,
+		EnvVars: []string{"DSLIM_XRAY_DETECT_PACKAGES"},
+	},
+	FlagShowPackages: &cli.BoolFlag{
+		Name:    FlagShowPackages,
+		Usage:   FlagShowPackagesUsage,
+		EnvVars: []string{"DSLIM_XRAY_SHOW_PACKAGES"},
+	},
+	FlagDetectLicenses: &cli.BoolFlag{
+		Name:    FlagDetectLicenses,
+		Usage:   FlagDetectLicensesUsage,
+		EnvVars: []string{"DSLIM_XRAY_DETECT_LICENSES"},
+	},
+	FlagShowLicenses: &cli.BoolFlag{
+		Name:    FlagShowLicenses,
+		Usage:   FlagShowLicensesUsage,
+		EnvVars: []string{"DSLIM_XRAY_SHOW_LICENSES"},
+	},
+	FlagDenyLicenses: &cli.StringSliceFlag{
+		Name:    FlagDenyLicenses,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagDenyLicensesUsage,
+		EnvVars: []string{"DSLIM_XRAY_DENY_LICENSES"},
+	},
+	FlagDetectSecrets: &cli.BoolFlag{
+		Name:    FlagDetectSecrets,
+		Usage:   FlagDetectSecretsUsage,
+		EnvVars: []string{"DSLIM_XRAY_DETECT_SECRETS"},
+	},
+	FlagDetectSecretsMaxSize: &cli.StringFlag{
+		Name:    FlagDetectSecretsMaxSize,
+		Value:   dockerimage.DefaultSecretsMaxFileSize,
+		Usage:   FlagDetectSecretsMaxSizeUsage,
+		EnvVars: []string{"DSLIM_XRAY_DETECT_SECRETS_MAX_SIZE"},
+	},
+	FlagAuditELF: &cli.BoolFlag{
+		Name:    FlagAuditELF,
+		Usage:   FlagAuditELFUsage,
+		EnvVars: []string{"DSLIM_XRAY_AUDIT_ELF"},
+	},
+	FlagDetectBlobs: &cli.BoolFlag{
+		Name:    FlagDetectBlobs,
+		Usage:   FlagDetectBlobsUsage,
+		EnvVars: []string{"DSLIM_XRAY_DETECT_BLOBS"},
+	},
+	FlagDetectBlobsMinSize: &cli.StringFlag{
+		Name:    FlagDetectBlobsMinSize,
+		Value:   dockerimage.DefaultBlobsMinFileSize,
+		Usage:   FlagDetectBlobsMinSizeUsage,
+		EnvVars: []string{"DSLIM_XRAY_DETECT_BLOBS_MIN_SIZE"},
+	},
+	FlagFindContent: &cli.StringSliceFlag{
+		Name:    FlagFindContent,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagFindContentUsage,
+		EnvVars: []string{"DSLIM_XRAY_FIND_CONTENT"},
+	},
+	FlagFindPath: &cli.StringSliceFlag{
+		Name:    FlagFindPath,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagFindPathUsage,
+		EnvVars: []string{"DSLIM_XRAY_FIND_PATH"},
+	},
+	FlagExportLayer: &cli.IntFlag{
+		Name:    FlagExportLayer,
+		Value:   -1, //disabled by default
+		Usage:   FlagExportLayerUsage,
+		EnvVars: []string{"DSLIM_XRAY_EXPORT_LAYER"},
+	},
+	FlagExportLayerMerged: &cli.BoolFlag{
+		Name:    FlagExportLayerMerged,
+		Usage:   FlagExportLayerMergedUsage,
+		EnvVars: []string{"DSLIM_XRAY_EXPORT_LAYER_MERGED"},
+	},
+	FlagExportLayerOutput: &cli.StringFlag{
+		Name:    FlagExportLayerOutput,
+		Value:   "",
+		Usage:   FlagExportLayerOutputUsage,
+		EnvVars: []string{"DSLIM_XRAY_EXPORT_LAYER_OUTPUT"},
+	},
+	FlagRemote: &cli.BoolFlag{
+		Name:    FlagRemote,
+		Usage:   FlagRemoteUsage,
+		EnvVars: []string{"DSLIM_XRAY_REMOTE"},
+	},
+	FlagRemotePlatform: &cli.StringFlag{
+		Name:    FlagRemotePlatform,
+		Value:   "",
+		Usage:   FlagRemotePlatformUsage,
+		EnvVars: []string{"DSLIM_XRAY_REMOTE_PLATFORM"},
+	},
+	FlagCompareWith: &cli.StringFlag{
+		Name:    FlagCompareWith,
+		Value:   "",
+		Usage:   FlagCompareWithUsage,
+		EnvVars: []string{"DSLIM_XRAY_COMPARE_WITH"},
+	},
+	FlagCompareFilesMax: &cli.IntFlag{
+		Name:    FlagCompareFilesMax,
+		Value:   dockerimage.DefaultCompareFilesMax,
+		Usage:   FlagCompareFilesMaxUsage,
+		EnvVars: []string{"DSLIM_XRAY_COMPARE_FILES_MAX"},
+	},
+	FlagTUI: &cli.BoolFlag{
+		Name:    FlagTUI,
+		Usage:   FlagTUIUsage,
+		EnvVars: []string{"DSLIM_XRAY_TUI"},
+	},
 }
 
 func cflag(name string) cli.Flag {
@@ -16,13 +16,16 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/docker/buildpackinfo"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/elfaudit"
 	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/secretdiscover"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
 	//"github.com/bmatcuk/doublestar/v3"
 	"github.com/dustin/go-humanize"
+	dockerapi "github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -34,8 +37,16 @@ type ovars = app.OutVars
 const (
 	ecxOther = iota + 1
 	ecxImageNotFound
+	ecxDeniedLicenses
 )
 
+// exitCodes documents the xray command exit codes (see commands.ExitCodes)
+var exitCodes = []commands.ExitCode{
+	{Code: commands.ECTXray | ecxOther, Name: "xray.other", Description: "other xray command error"},
+	{Code: commands.ECTXray | ecxImageNotFound, Name: "xray.image.not.found", Description: "target image not found"},
+	{Code: commands.ECTXray | ecxDeniedLicenses, Name: "xray.denied.licenses", Description: "target image has packages with denied licenses"},
+}
+
 const (
 	fatDockerfileName = "Dockerfile.fat"
 )
@@ -50,6 +61,8 @@ func OnCommand(
 	registryAccount string,
 	registrySecret string,
 	doShowPullLogs bool,
+	doRemote bool,
+	remotePlatform string,
 	changes map[string]struct{},
 	changesOutputs map[string]struct{},
 	layers map[string]struct{},
@@ -75,7 +88,35 @@ func OnCommand(
 	doDetectAllCertFiles bool,
 	doDetectAllCertPKFiles bool,
 	xdArtifactsPath string,
-) {
+	layerParallelism int,
+	doDetectWastedSpace bool,
+	wastedSpaceFilesMax int,
+	doTopSizes bool,
+	topSizesMax int,
+	topSizesSort string,
+	doSizeTree bool,
+	sizeTreeDepth int,
+	doDetectPackages bool,
+	doShowPackages bool,
+	doDetectLicenses bool,
+	doShowLicenses bool,
+	denyLicenses []string,
+	secretDetector *dockerimage.SecretDetector,
+	blobDetector *dockerimage.BlobDetector,
+	doAuditELF bool,
+	contentFinder *dockerimage.ContentFinder,
+	exportLayer int,
+	doExportLayerMerged bool,
+	exportLayerOutput string,
+	compareWith string,
+	compareFilesMax int,
+	sbomFormat string,
+	sbomOutput string,
+	doTUI bool,
+	reportHTML string,
+	reportUpload string,
+	doUploadArtifacts bool,
+) error {
 	const cmdName = Name
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
 	prefix := fmt.Sprintf("cmd=%s", cmdName)
@@ -83,7 +124,9 @@ func OnCommand(
 	changeDataMatchers := map[string]*dockerimage.ChangeDataMatcher{}
 	for _, cdm := range changeDataMatcherList {
 		matcher, err := regexp.Compile(cdm.DataPattern)
-		errutil.FailOn(err)
+		if err != nil {
+			return app.WrapError(err, app.ErrorCategoryInput, "change.data.matcher", "check the change data matcher pattern")
+		}
 
 		cdm.Matcher = matcher
 		changeDataMatchers[cdm.DataPattern] = cdm
@@ -99,44 +142,60 @@ func OnCommand(
 	cmdReport := report.NewXrayCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
 	cmdReport.TargetReference = targetRef
+	commands.SaveReportOnFailure(xc, &cmdReport.Command, cmdReport.Save)
+
+	telemetry := commands.NewTelemetry(xc, gparams, cmdName, targetRef, false, logger)
 
 	xc.Out.State("started")
 	xc.Out.Info("params",
 		ovars{
 			"target":             targetRef,
+			"remote":             doRemote,
 			"add-image-manifest": doAddImageManifest,
 			"add-image-config":   doAddImageConfig,
 			"rm-file-artifacts":  doRmFileArtifacts,
 		})
 
-	client, err := dockerclient.New(gparams.ClientConfig)
-	if err == dockerclient.ErrNoDockerInfo {
-		exitMsg := "missing Docker connection info"
-		if gparams.InContainer && gparams.IsDSImage {
-			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
-		}
+	//the remote images are analyzed without the Docker daemon
+	var client *dockerapi.Client
+	var err error
+	if !doRemote {
+		client, err = dockerclient.New(gparams.ClientConfig)
+		if err == dockerclient.ErrNoDockerInfo {
+			exitMsg := "missing Docker connection info"
+			if gparams.InContainer && gparams.IsDSImage {
+				exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+			}
 
-		xc.Out.Error("docker.connect.error", exitMsg)
+			xc.Out.Error("docker.connect.error", exitMsg)
 
-		exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
-		xc.Out.State("exited",
-			ovars{
-				"exit.code": exitCode,
-				"version":   v.Current(),
-				"location":  fsutil.ExeDir(),
-			})
-		xc.Exit(exitCode)
+			exitCode := commands.ECTCommon | commands.ECNoDockerConnectInfo
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": exitCode,
+					"version":   v.Current(),
+					"location":  fsutil.ExeDir(),
+				})
+			return app.NewExitError(exitCode)
+		}
+
+		if err != nil {
+			return err
+		}
 	}
-	errutil.FailOn(err)
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
 	imageInspector, err := image.NewInspector(client, targetRef)
-	errutil.FailOn(err)
+	if err != nil {
+		return err
+	}
+	imageInspector.ResultCache = commands.ResultCache(gparams)
+	imageInspector.RedactSecrets = secretDetector != nil
 
-	if imageInspector.NoImage() {
+	if !doRemote && imageInspector.NoImage() {
 		if doPull {
 			xc.Out.Info("target.image",
 				ovars{
@@ -145,8 +204,15 @@ func OnCommand(
 					"message": "trying to pull target image",
 				})
 
-			err := imageInspector.Pull(doShowPullLogs, dockerConfigPath, registryAccount, registrySecret)
-			errutil.FailOn(err)
+			pullDone := telemetry.Phase(commands.PhasePull)
+			pullProgress := xc.Out.NewProgress("image.pull", 0, app.ProgressUnitBytes)
+			imageInspector.PullProgress = pullProgress.Update
+			err := imageInspector.Pull(xc.Context, doShowPullLogs, dockerConfigPath, registryAccount, registrySecret)
+			pullProgress.Done()
+			if err != nil {
+				return commands.ImagePullError(err)
+			}
+			pullDone()
 		} else {
 			xc.Out.Error("image.not.found", "make sure the target image already exists locally (use --pull flag to auto-download it from registry)")
 
@@ -155,7 +221,7 @@ func OnCommand(
 				ovars{
 					"exit.code": exitCode,
 				})
-			xc.Exit(exitCode)
+			return app.NewExitError(exitCode)
 		}
 	}
 
@@ -165,11 +231,23 @@ func OnCommand(
 
 	xc.Out.State("image.api.inspection.start")
 
-	logger.Info("inspecting 'fat' image metadata...")
-	err = imageInspector.Inspect()
-	errutil.FailOn(err)
+	if doRemote {
+		logger.Info("inspecting 'fat' image metadata in registry...")
+		err = imageInspector.InspectRemote(dockerConfigPath, registryAccount, registrySecret, remotePlatform)
+	} else {
+		logger.Info("inspecting 'fat' image metadata...")
+		err = imageInspector.Inspect()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	localVolumePath, artifactLocation, statePath, stateKey, err := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+	if err != nil {
+		return commands.StateDirError(err)
+	}
 
-	localVolumePath, artifactLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
 	imageInspector.ArtifactLocation = artifactLocation
 	logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
 
@@ -182,8 +260,12 @@ func OnCommand(
 		})
 
 	logger.Info("processing 'fat' image info...")
+	reverseDone := telemetry.Phase(commands.PhaseReverse)
 	err = imageInspector.ProcessCollectedData()
-	errutil.FailOn(err)
+	if err != nil {
+		return err
+	}
+	reverseDone()
 
 	if imageInspector.DockerfileInfo != nil {
 		if imageInspector.DockerfileInfo.ExeUser != "" {
@@ -243,6 +325,11 @@ func OnCommand(
 	}
 
 	cmdReport.SourceImage.EnvVars = imageInspector.ImageInfo.Config.Env
+	if secretDetector != nil {
+		//the detected secret values are redacted in the saved report
+		cmdReport.SourceImage.EnvVars = secretdiscover.RedactEnv(cmdReport.SourceImage.EnvVars)
+		cmdReport.SourceImage.InheritedInstructions = secretdiscover.RedactCommands(cmdReport.SourceImage.InheritedInstructions)
+	}
 
 	for k := range imageInspector.ImageInfo.Config.ExposedPorts {
 		cmdReport.SourceImage.ExposedPorts = append(cmdReport.SourceImage.ExposedPorts, string(k))
@@ -292,192 +379,357 @@ func OnCommand(
 	imageID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
 	iaName := fmt.Sprintf("%s.tar", imageID)
 	iaPath := filepath.Join(localVolumePath, "image", iaName)
-	iaPathReady := fmt.Sprintf("%s.ready", iaPath)
 
-	var doSave bool
-	if fsutil.IsRegularFile(iaPath) {
-		if !doReuseSavedImage {
-			doSave = true
+	var compareArchivePath string
+	var analysisCacheKey string
+	resultCache := imageInspector.ResultCache
+	//the change matchers and the UTF8 detector dump the matched data (not a part of the report)
+	if resultCache != nil &&
+		len(changePathMatchers) == 0 &&
+		len(changeDataMatchers) == 0 &&
+		len(changeDataHashMatchers) == 0 &&
+		utf8Detector == nil {
+		var secretsMaxFileSize int
+		if secretDetector != nil {
+			secretsMaxFileSize = secretDetector.MaxSizeBytes
 		}
 
-		if !fsutil.Exists(iaPathReady) {
-			doSave = true
+		var blobsMinFileSize int64
+		if blobDetector != nil {
+			blobsMinFileSize = blobDetector.MinSizeBytes
 		}
-	} else {
-		doSave = true
-	}
 
-	if doSave {
-		if fsutil.Exists(iaPathReady) {
-			fsutil.Remove(iaPathReady)
+		var findPaths, findContent []string
+		if contentFinder != nil {
+			findPaths = contentFinder.PathPatterns
+			findContent = contentFinder.ContentPatterns
 		}
 
-		xc.Out.Info("image.data.inspection.save.image.start")
-		err = dockerutil.SaveImage(client, imageID, iaPath, false, false)
-		errutil.FailOn(err)
-
-		err = fsutil.Touch(iaPathReady)
-		errutil.WarnOn(err)
+		analysisCacheKey = analysisResultKey(&analysisCacheParams{
+			Changes:                changes,
+			ChangesOutputs:         changesOutputs,
+			Layers:                 layers,
+			LayerChangesMax:        layerChangesMax,
+			AllChangesMax:          allChangesMax,
+			AddChangesMax:          addChangesMax,
+			ModifyChangesMax:       modifyChangesMax,
+			DeleteChangesMax:       deleteChangesMax,
+			TopChangesMax:          topChangesMax,
+			DoHashData:             doHashData,
+			DoDetectDuplicates:     doDetectDuplicates,
+			DoShowDuplicates:       doShowDuplicates,
+			DoShowSpecialPerms:     doShowSpecialPerms,
+			ChangeMatchLayersOnly:  changeMatchLayersOnly,
+			DoAddImageManifest:     doAddImageManifest,
+			DoAddImageConfig:       doAddImageConfig,
+			DoDetectAllCertFiles:   doDetectAllCertFiles,
+			DoDetectAllCertPKFiles: doDetectAllCertPKFiles,
+			DoDetectWastedSpace:    doDetectWastedSpace,
+			WastedSpaceFilesMax:    wastedSpaceFilesMax,
+			DoTopSizes:             doTopSizes,
+			TopSizesMax:            topSizesMax,
+			TopSizesSort:           topSizesSort,
+			DoDetectPackages:       doDetectPackages,
+			DoDetectLicenses:       doDetectLicenses,
+			SecretsMaxFileSize:     secretsMaxFileSize,
+			DoAuditELF:             doAuditELF,
+			BlobsMinFileSize:       blobsMinFileSize,
+			FindPaths:              findPaths,
+			FindContent:            findContent,
+		}, logger)
+	}
 
-		xc.Out.Info("image.data.inspection.save.image.end")
-	} else {
-		logger.Debugf("exported image already exists - %s", iaPath)
+	//the size tree view, the SBOM, the layer export and the interactive mode are not a part of the report
+	//(so they need the image data) and the comparison depends on the other image
+	if doSizeTree || sbomFormat != "" || exportLayer > -1 || compareWith != "" || doTUI {
+		analysisCacheKey = ""
 	}
 
-	xc.Out.Info("image.data.inspection.process.image.start")
-	imagePkg, err := dockerimage.LoadPackage(
-		iaPath,
-		imageID,
-		false,
-		topChangesMax,
-		doHashData,
-		doDetectDuplicates,
-		changeDataHashMatchers,
-		changePathMatchers,
-		changeDataMatchers,
-		utf8Detector,
-		doDetectAllCertFiles,
-		doDetectAllCertPKFiles)
+	//reusing the cached analysis results skips the image export and the image data processing
+	if analysisCacheKey == "" ||
+		!loadCachedAnalysis(xc, resultCache, analysisCacheKey, imageID, cmdReport, logger) {
+		err = saveImageArchive(xc, client, imageInspector, iaPath, doReuseSavedImage, doRemote, logger)
+		if err != nil {
+			return err
+		}
 
-	errutil.FailOn(err)
-	xc.Out.Info("image.data.inspection.process.image.end")
+		xc.Out.Info("image.data.inspection.process.image.start")
+		layerProgress := xc.Out.NewProgress("image.layers", 0, app.ProgressUnitItems)
+		imagePkg, err := dockerimage.LoadPackage(
+			iaPath,
+			imageID,
+			false,
+			topChangesMax,
+			doHashData,
+			doDetectDuplicates,
+			changeDataHashMatchers,
+			changePathMatchers,
+			changeDataMatchers,
+			utf8Detector,
+			doDetectAllCertFiles,
+			doDetectAllCertPKFiles,
+			doDetectPackages,
+			doDetectLicenses,
+			doAuditELF,
+			secretDetector,
+			blobDetector,
+			contentFinder,
+			layerParallelism,
+			func(progress *dockerimage.LayerProgress) {
+				layerProgress.Update(int64(progress.Completed), int64(progress.Total))
+				xc.Out.Info("image.data.inspection.process.layer",
+					ovars{
+						"id":        progress.ID,
+						"size":      humanize.Bytes(uint64(progress.Size)),
+						"completed": fmt.Sprintf("%d/%d", progress.Completed, progress.Total),
+					})
+			})
 
-	if utf8Detector != nil {
-		errutil.FailOn(utf8Detector.Close())
-	}
+		layerProgress.Done()
+		if err != nil {
+			return err
+		}
+		xc.Out.Info("image.data.inspection.process.image.end")
 
-	xc.Out.State("image.data.inspection.done")
+		if utf8Detector != nil {
+			if err := utf8Detector.Close(); err != nil {
+				return err
+			}
+		}
 
-	if len(imageInspector.DockerfileInfo.AllInstructions) == len(imagePkg.Config.History) {
-		for instIdx, instInfo := range imageInspector.DockerfileInfo.AllInstructions {
-			instInfo.Author = imagePkg.Config.History[instIdx].Author
-			instInfo.EmptyLayer = imagePkg.Config.History[instIdx].EmptyLayer
-			instInfo.LayerID = imagePkg.Config.History[instIdx].LayerID
-			instInfo.LayerIndex = imagePkg.Config.History[instIdx].LayerIndex
-			instInfo.LayerFSDiffID = imagePkg.Config.History[instIdx].LayerFSDiffID
+		xc.Out.State("image.data.inspection.done")
+
+		if len(imageInspector.DockerfileInfo.AllInstructions) == len(imagePkg.Config.History) {
+			for instIdx, instInfo := range imageInspector.DockerfileInfo.AllInstructions {
+				instInfo.Author = imagePkg.Config.History[instIdx].Author
+				instInfo.EmptyLayer = imagePkg.Config.History[instIdx].EmptyLayer
+				instInfo.LayerID = imagePkg.Config.History[instIdx].LayerID
+				instInfo.LayerIndex = imagePkg.Config.History[instIdx].LayerIndex
+				instInfo.LayerFSDiffID = imagePkg.Config.History[instIdx].LayerFSDiffID
+			}
+		} else {
+			logger.Debugf("history instruction set size mismatch - %v/%v ",
+				len(imageInspector.DockerfileInfo.AllInstructions),
+				len(imagePkg.Config.History))
 		}
-	} else {
-		logger.Debugf("history instruction set size mismatch - %v/%v ",
-			len(imageInspector.DockerfileInfo.AllInstructions),
-			len(imagePkg.Config.History))
-	}
 
-	allEntryParams := append(cmdReport.SourceImage.ContainerEntry.Entrypoint,
-		cmdReport.SourceImage.ContainerEntry.Cmd...)
-	if len(allEntryParams) > 0 {
-		cmdReport.SourceImage.ContainerEntry.ExePath = allEntryParams[0]
-		cmdReport.SourceImage.ContainerEntry.ExeArgs = allEntryParams[1:]
+		allEntryParams := append(cmdReport.SourceImage.ContainerEntry.Entrypoint,
+			cmdReport.SourceImage.ContainerEntry.Cmd...)
+		if len(allEntryParams) > 0 {
+			cmdReport.SourceImage.ContainerEntry.ExePath = allEntryParams[0]
+			cmdReport.SourceImage.ContainerEntry.ExeArgs = allEntryParams[1:]
 
-		//fix up exe path if relative
-		if !strings.HasPrefix(cmdReport.SourceImage.ContainerEntry.ExePath, "/") {
-			//check relative path
-			if strings.HasPrefix(cmdReport.SourceImage.ContainerEntry.ExePath, "./") ||
-				strings.HasPrefix(cmdReport.SourceImage.ContainerEntry.ExePath, "../") {
+			//fix up exe path if relative
+			if !strings.HasPrefix(cmdReport.SourceImage.ContainerEntry.ExePath, "/") {
+				//check relative path
+				if strings.HasPrefix(cmdReport.SourceImage.ContainerEntry.ExePath, "./") ||
+					strings.HasPrefix(cmdReport.SourceImage.ContainerEntry.ExePath, "../") {
 
-				fullExePath := filepath.Join(cmdReport.SourceImage.WorkDir, cmdReport.SourceImage.ContainerEntry.ExePath)
-				object := findChange(imagePkg, fullExePath)
+					fullExePath := filepath.Join(cmdReport.SourceImage.WorkDir, cmdReport.SourceImage.ContainerEntry.ExePath)
+					object := findChange(imagePkg, fullExePath)
+					if object != nil {
+						cmdReport.SourceImage.ContainerEntry.FullExePath =
+							&report.ContainerFileInfo{
+								Name:  fullExePath,
+								Layer: object.LayerIndex,
+							}
+					}
+				} else {
+					//check env paths
+					var envPaths []string
+					for _, envInfo := range cmdReport.SourceImage.EnvVars {
+						if strings.HasPrefix(envInfo, "PATH=") {
+							envInfo = strings.TrimPrefix(envInfo, "PATH=")
+							envPaths = strings.Split(envInfo, ":")
+							break
+						}
+					}
+
+					for _, envPath := range envPaths {
+						fullExePath := fmt.Sprintf("%s/%s", envPath, cmdReport.SourceImage.ContainerEntry.ExePath)
+						object := findChange(imagePkg, fullExePath)
+						if object != nil {
+							cmdReport.SourceImage.ContainerEntry.FullExePath =
+								&report.ContainerFileInfo{
+									Name:  fullExePath,
+									Layer: object.LayerIndex,
+								}
+							break
+						}
+					}
+				}
+			} else {
+				object := findChange(imagePkg, cmdReport.SourceImage.ContainerEntry.ExePath)
 				if object != nil {
 					cmdReport.SourceImage.ContainerEntry.FullExePath =
 						&report.ContainerFileInfo{
-							Name:  fullExePath,
+							Name:  cmdReport.SourceImage.ContainerEntry.ExePath,
 							Layer: object.LayerIndex,
 						}
 				}
-			} else {
-				//check env paths
-				var envPaths []string
-				for _, envInfo := range cmdReport.SourceImage.EnvVars {
-					if strings.HasPrefix(envInfo, "PATH=") {
-						envInfo = strings.TrimPrefix(envInfo, "PATH=")
-						envPaths = strings.Split(envInfo, ":")
-						break
-					}
+			}
+
+			//find files in exe args
+			for _, exeArg := range cmdReport.SourceImage.ContainerEntry.ExeArgs {
+				//if starts with / assume a full path and lookup/find in the layer references
+				//otherwise try to use workdir and lookup/find in the layer references
+				if strings.HasPrefix(exeArg, "-") {
+					//skip flag names (might have false positives)
+					continue
 				}
 
-				for _, envPath := range envPaths {
-					fullExePath := fmt.Sprintf("%s/%s", envPath, cmdReport.SourceImage.ContainerEntry.ExePath)
-					object := findChange(imagePkg, fullExePath)
-					if object != nil {
-						cmdReport.SourceImage.ContainerEntry.FullExePath =
+				var filePath string
+				if strings.HasPrefix(exeArg, "/") {
+					filePath = exeArg
+				} else {
+					//not a perfect way to find potential files
+					//but better than nothing
+					filePath = fmt.Sprintf("%s/%s", cmdReport.SourceImage.WorkDir, exeArg)
+				}
+
+				object := findChange(imagePkg, filePath)
+				if object != nil {
+					cmdReport.SourceImage.ContainerEntry.ArgFiles =
+						append(cmdReport.SourceImage.ContainerEntry.ArgFiles,
 							&report.ContainerFileInfo{
-								Name:  fullExePath,
+								Name:  filePath,
 								Layer: object.LayerIndex,
-							}
-						break
-					}
+							})
+					break
 				}
 			}
-		} else {
-			object := findChange(imagePkg, cmdReport.SourceImage.ContainerEntry.ExePath)
-			if object != nil {
-				cmdReport.SourceImage.ContainerEntry.FullExePath =
-					&report.ContainerFileInfo{
-						Name:  cmdReport.SourceImage.ContainerEntry.ExePath,
-						Layer: object.LayerIndex,
-					}
-			}
 		}
 
-		//find files in exe args
-		for _, exeArg := range cmdReport.SourceImage.ContainerEntry.ExeArgs {
-			//if starts with / assume a full path and lookup/find in the layer references
-			//otherwise try to use workdir and lookup/find in the layer references
-			if strings.HasPrefix(exeArg, "-") {
-				//skip flag names (might have false positives)
-				continue
+		printImagePackage(
+			xc,
+			imagePkg,
+			appName,
+			cmdName,
+			changes,
+			changesOutputs,
+			layers,
+			layerChangesMax,
+			allChangesMax,
+			addChangesMax,
+			modifyChangesMax,
+			deleteChangesMax,
+			doHashData,
+			doDetectDuplicates,
+			doShowDuplicates,
+			doShowSpecialPerms,
+			changeMatchLayersOnly,
+			changeDataHashMatchers,
+			changePathMatchers,
+			changeDataMatchers,
+			cmdReport)
+
+		if doDetectWastedSpace {
+			printWastedSpace(xc, dockerimage.WastedSpace(imagePkg, wastedSpaceFilesMax), cmdReport)
+		}
+
+		if doDetectPackages {
+			inventory := dockerimage.Packages(imagePkg)
+			printPackages(xc, inventory, doShowPackages, cmdReport)
+
+			if doDetectLicenses {
+				cmdReport.ImageReport.Licenses = dockerimage.Licenses(imagePkg, inventory)
 			}
 
-			var filePath string
-			if strings.HasPrefix(exeArg, "/") {
-				filePath = exeArg
-			} else {
-				//not a perfect way to find potential files
-				//but better than nothing
-				filePath = fmt.Sprintf("%s/%s", cmdReport.SourceImage.WorkDir, exeArg)
+			if sbomFormat != "" {
+				saveSBOM(xc, sbomFormat, sbomOutput, targetRef, imageInspector.ImageInfo.ID, imagePkg, inventory, cmdReport, logger)
 			}
+		}
 
-			object := findChange(imagePkg, filePath)
-			if object != nil {
-				cmdReport.SourceImage.ContainerEntry.ArgFiles =
-					append(cmdReport.SourceImage.ContainerEntry.ArgFiles,
-						&report.ContainerFileInfo{
-							Name:  filePath,
-							Layer: object.LayerIndex,
-						})
-				break
+		if secretDetector != nil {
+			printSecrets(xc, dockerimage.Secrets(imagePkg), cmdReport)
+		}
+
+		if blobDetector != nil {
+			printBlobs(xc, dockerimage.Blobs(imagePkg), cmdReport)
+		}
+
+		if doAuditELF {
+			printELFDeps(xc, dockerimage.AuditELFDependencies(imagePkg), cmdReport)
+		}
+
+		if contentFinder != nil {
+			printFound(xc, dockerimage.FindObjects(imagePkg, contentFinder), cmdReport)
+		}
+
+		if doTopSizes {
+			printTopSizes(xc, dockerimage.Sizes(imagePkg, topSizesMax, topSizesSort), cmdReport)
+		}
+
+		if doSizeTree {
+			xc.Out.LogDump("image.size.tree",
+				dockerimage.SizeTree(imagePkg).Text(sizeTreeDepth, topSizesMax, topSizesSort),
+				ovars{
+					"depth": sizeTreeDepth,
+				})
+		}
+
+		if exportLayer > -1 {
+			saveLayerExport(xc, iaPath, imagePkg, exportLayer, doExportLayerMerged, exportLayerOutput, cmdReport, logger)
+		}
+
+		if compareWith != "" {
+			otherInspector, otherPkg, otherPath, err := loadCompareImage(
+				xc,
+				gparams,
+				client,
+				compareWith,
+				doPull,
+				doShowPullLogs,
+				doRemote,
+				remotePlatform,
+				dockerConfigPath,
+				registryAccount,
+				registrySecret,
+				doReuseSavedImage,
+				layerParallelism,
+				logger)
+			if err != nil {
+				return err
 			}
+			compareArchivePath = otherPath
+
+			comparison := dockerimage.Compare(imagePkg, otherPkg, compareFilesMax)
+			comparison.Image = targetRef
+			comparison.ImageID = imageInspector.ImageInfo.ID
+			comparison.OtherImage = otherInspector.ImageRef
+			comparison.OtherImageID = otherInspector.ImageInfo.ID
+			printComparison(xc, comparison, cmdReport)
 		}
-	}
 
-	printImagePackage(
-		xc,
-		imagePkg,
-		appName,
-		cmdName,
-		changes,
-		changesOutputs,
-		layers,
-		layerChangesMax,
-		allChangesMax,
-		addChangesMax,
-		modifyChangesMax,
-		deleteChangesMax,
-		doHashData,
-		doDetectDuplicates,
-		doShowDuplicates,
-		doShowSpecialPerms,
-		changeMatchLayersOnly,
-		changeDataHashMatchers,
-		changePathMatchers,
-		changeDataMatchers,
-		cmdReport)
+		if doTUI {
+			if err := runImageExplorer(imagePkg, targetRef); err != nil {
+				logger.Errorf("error running the interactive mode - %v", err)
+				xc.Out.Info("image.tui",
+					ovars{
+						"error": err.Error(),
+					})
+			}
+		}
 
-	if doAddImageManifest {
-		cmdReport.RawImageManifest = imagePkg.Manifest
+		if doAddImageManifest {
+			cmdReport.RawImageManifest = imagePkg.Manifest
+		}
+
+		if doAddImageConfig {
+			cmdReport.RawImageConfig = imagePkg.Config
+			if secretDetector != nil {
+				cmdReport.RawImageConfig = dockerimage.RedactConfigSecrets(imagePkg.Config)
+			}
+		}
+
+		if analysisCacheKey != "" {
+			saveCachedAnalysis(resultCache, analysisCacheKey, imageID, cmdReport, logger)
+		}
 	}
 
-	if doAddImageConfig {
-		cmdReport.RawImageConfig = imagePkg.Config
+	var deniedLicenses []*dockerimage.DeniedLicense
+	if doDetectLicenses && cmdReport.ImageReport != nil && cmdReport.ImageReport.Licenses != nil {
+		deniedLicenses = printLicenses(xc, cmdReport.ImageReport.Licenses, doShowLicenses, denyLicenses)
 	}
 
 	xc.Out.State("completed")
@@ -487,7 +739,12 @@ func OnCommand(
 		logger.Info("removing temporary artifacts...")
 		err = fsutil.Remove(iaPath)
 		errutil.WarnOn(err)
-	} else {
+
+		if compareArchivePath != "" && compareArchivePath != iaPath {
+			err = fsutil.Remove(compareArchivePath)
+			errutil.WarnOn(err)
+		}
+	} else if fsutil.Exists(iaPath) {
 		cmdReport.ImageArchiveLocation = iaPath
 	}
 
@@ -507,6 +764,12 @@ func OnCommand(
 	version.PrintCheckVersion(xc, "", vinfo)
 
 	cmdReport.State = command.StateDone
+	if len(deniedLicenses) > 0 {
+		cmdReport.Error = "denied.licenses"
+	}
+
+	telemetry.Finish(cmdReport.State)
+
 	if cmdReport.Save() {
 		xc.Out.Info("report",
 			ovars{
@@ -514,6 +777,30 @@ func OnCommand(
 			})
 	}
 
+	if reportHTML != "" {
+		if err := cmdReport.SaveHTML(reportHTML); err == nil {
+			xc.Out.Info("report.html",
+				ovars{
+					"file": reportHTML,
+				})
+		} else {
+			logger.Errorf("error saving HTML report - %v", err)
+			xc.Out.Info("report.html",
+				ovars{
+					"error": err.Error(),
+				})
+		}
+	}
+
+	if reportUpload != "" {
+		uploadFiles := []string{cmdReport.ReportLocation(), reportHTML}
+		if doUploadArtifacts {
+			uploadFiles = append(uploadFiles, commands.ArtifactFiles(cmdReport.ArtifactLocation)...)
+		}
+
+		commands.UploadReportFiles(xc, reportUpload, uploadFiles, logger)
+	}
+
 	if xdArtifactsPath != "" {
 		var filesToExport []string
 		filesToExport = append(filesToExport, cmdReport.ReportLocation())
@@ -535,6 +822,408 @@ func OnCommand(
 			logger.Errorf("error exporting data artifacts (%s) - %v", xdArtifactsPath, err)
 		}
 	}
+
+	if len(deniedLicenses) > 0 {
+		exitCode := commands.ECTXray | ecxDeniedLicenses
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": exitCode,
+				"message":   fmt.Sprintf("image has %d denied license(s)", len(deniedLicenses)),
+			})
+		return app.NewExitError(exitCode)
+	}
+
+	return nil
+}
+
+func printSecrets(
+	xc *app.ExecutionContext,
+	secrets *dockerimage.SecretsReport,
+	cmdReport *report.XrayCommand) {
+	cmdReport.ImageReport.Secrets = secrets
+
+	xc.Out.Info("image.secrets",
+		ovars{
+			"count": secrets.Count,
+		})
+
+	for _, info := range secrets.Findings {
+		secretInfo := ovars{
+			"source": info.Source,
+			"rule":   info.Rule,
+			"value":  info.Value,
+		}
+
+		if info.Layer > -1 {
+			secretInfo["layer"] = info.Layer
+		}
+
+		if info.Path != "" {
+			secretInfo["path"] = info.Path
+			secretInfo["line"] = info.Line
+		}
+
+		if info.Name != "" {
+			secretInfo["name"] = info.Name
+		}
+
+		if info.HistoryIndex != nil {
+			secretInfo["history"] = *info.HistoryIndex
+		}
+
+		if info.Removed {
+			secretInfo["removed"] = true
+		}
+
+		xc.Out.Info("image.secret", secretInfo)
+	}
+}
+
+func printBlobs(
+	xc *app.ExecutionContext,
+	blobs *dockerimage.BlobsReport,
+	cmdReport *report.XrayCommand) {
+	cmdReport.ImageReport.Blobs = blobs
+
+	xc.Out.Info("image.blobs",
+		ovars{
+			"count":      blobs.Count,
+			"size.human": humanize.Bytes(blobs.Size),
+		})
+
+	for _, info := range blobs.Findings {
+		blobInfo := ovars{
+			"kind":       info.Kind,
+			"layer":      info.Layer,
+			"path":       info.Path,
+			"size.human": humanize.Bytes(uint64(info.Size)),
+			"entropy":    info.Entropy,
+		}
+
+		if info.Format != "" {
+			blobInfo["format"] = info.Format
+		}
+
+		if info.Offset > 0 {
+			blobInfo["offset"] = info.Offset
+		}
+
+		if info.Removed {
+			blobInfo["removed"] = true
+		}
+
+		xc.Out.Info("image.blob", blobInfo)
+	}
+}
+
+func printELFDeps(
+	xc *app.ExecutionContext,
+	elfDeps *elfaudit.Report,
+	cmdReport *report.XrayCommand) {
+	cmdReport.ImageReport.ELFDeps = elfDeps
+
+	xc.Out.Info("image.elf.deps",
+		ovars{
+			"executables":        elfDeps.ExecutableCount,
+			"libraries":          elfDeps.LibraryCount,
+			"static":             elfDeps.StaticCount,
+			"broken.executables": elfDeps.BrokenExecutableCount,
+			"broken.libraries":   elfDeps.BrokenLibraryCount,
+		})
+
+	for _, info := range elfDeps.Executables {
+		if info.Broken() {
+			printBrokenELFObject(xc, info)
+		}
+	}
+
+	for _, info := range elfDeps.BrokenLibraries {
+		printBrokenELFObject(xc, info)
+	}
+}
+
+func printBrokenELFObject(xc *app.ExecutionContext, info *elfaudit.ObjectDeps) {
+	objectInfo := ovars{
+		"type": info.Type,
+		"path": info.Path,
+	}
+
+	if info.MissingInterpreter {
+		objectInfo["missing.interpreter"] = info.Interpreter
+	}
+
+	if len(info.Missing) > 0 {
+		objectInfo["missing.libraries"] = strings.Join(info.Missing, ",")
+	}
+
+	xc.Out.Info("image.elf.deps.missing", objectInfo)
+}
+
+// printLicenses shows the license inventory and returns the denied licenses found in the image
+func printLicenses(
+	xc *app.ExecutionContext,
+	licenses *dockerimage.LicenseReport,
+	doShowLicenses bool,
+	denyLicenses []string) []*dockerimage.DeniedLicense {
+	licenses.Denied = licenses.FindDenied(denyLicenses)
+
+	xc.Out.Info("image.licenses",
+		ovars{
+			"count":            len(licenses.Licenses),
+			"files":            len(licenses.Files),
+			"unknown.packages": licenses.UnknownPackages,
+			"denied":           len(licenses.Denied),
+		})
+
+	for _, info := range licenses.Licenses {
+		xc.Out.Info("image.license",
+			ovars{
+				"license":  info.License,
+				"packages": info.Packages,
+				"files":    info.Files,
+			})
+	}
+
+	if doShowLicenses {
+		for _, p := range licenses.Packages {
+			xc.Out.Info("image.license.package",
+				ovars{
+					"name":    p.Name,
+					"version": p.Version,
+					"license": p.License,
+					"source":  p.Source,
+				})
+		}
+
+		for _, info := range licenses.Files {
+			xc.Out.Info("image.license.file",
+				ovars{
+					"path":     info.Path,
+					"layer":    info.Layer,
+					"licenses": strings.Join(info.Licenses, ","),
+				})
+		}
+	}
+
+	for _, info := range licenses.Denied {
+		deniedInfo := ovars{
+			"license": info.License,
+		}
+
+		if info.Package != "" {
+			deniedInfo["package"] = info.Package
+		}
+
+		if info.Path != "" {
+			deniedInfo["path"] = info.Path
+		}
+
+		xc.Out.Info("image.license.denied", deniedInfo)
+	}
+
+	return licenses.Denied
+}
+
+func printFound(
+	xc *app.ExecutionContext,
+	found *dockerimage.FindReport,
+	cmdReport *report.XrayCommand) {
+	for _, object := range found.Objects {
+		if object.Layer < len(cmdReport.ImageLayers) {
+			object.Instruction = cmdReport.ImageLayers[object.Layer].ChangeInstruction
+		}
+	}
+
+	cmdReport.ImageReport.Found = found
+
+	xc.Out.Info("image.found",
+		ovars{
+			"count": found.Count,
+		})
+
+	for _, object := range found.Objects {
+		objectInfo := ovars{
+			"path":   object.Path,
+			"layer":  object.Layer,
+			"status": object.Status,
+		}
+
+		if object.Status == dockerimage.FoundOverwritten || object.Status == dockerimage.FoundDeleted {
+			objectInfo["changed_in"] = object.ChangedIn
+		}
+
+		if object.Status != dockerimage.FoundWhiteout {
+			objectInfo["size.human"] = humanize.Bytes(uint64(object.Size))
+		}
+
+		if object.Instruction != nil {
+			objectInfo["instruction"] = object.Instruction.Snippet
+		}
+
+		xc.Out.Info("image.found.object", objectInfo)
+
+		for _, match := range object.Content {
+			xc.Out.Info("image.found.content",
+				ovars{
+					"path": object.Path,
+					"line": match.Line,
+					"text": match.Text,
+				})
+		}
+	}
+}
+
+func printWastedSpace(
+	xc *app.ExecutionContext,
+	wasted *dockerimage.WastedSpaceReport,
+	cmdReport *report.XrayCommand) {
+	for _, layer := range wasted.Layers {
+		if layer.Index < len(cmdReport.ImageLayers) {
+			layer.Instruction = cmdReport.ImageLayers[layer.Index].ChangeInstruction
+		}
+	}
+
+	cmdReport.ImageReport.WastedSpace = wasted
+
+	xc.Out.Info("image.wasted.space",
+		ovars{
+			"efficiency":        fmt.Sprintf("%.2f%%", wasted.Efficiency*100),
+			"total_size.human":  humanize.Bytes(wasted.TotalSize),
+			"wasted.bytes":      wasted.WastedSize,
+			"wasted.human":      humanize.Bytes(wasted.WastedSize),
+			"overwritten.human": humanize.Bytes(wasted.OverwrittenSize),
+			"deleted.human":     humanize.Bytes(wasted.DeletedSize),
+			"cache.human":       humanize.Bytes(wasted.CacheSize),
+			"duplicate.human":   humanize.Bytes(wasted.DuplicateSize),
+			"whiteouts":         wasted.WhiteoutCount,
+		})
+
+	for _, layer := range wasted.Layers {
+		if layer.WastedSize == 0 {
+			continue
+		}
+
+		layerInfo := ovars{
+			"index":        layer.Index,
+			"wasted.bytes": layer.WastedSize,
+			"wasted.human": humanize.Bytes(layer.WastedSize),
+		}
+
+		if layer.Instruction != nil {
+			layerInfo["instruction"] = layer.Instruction.Snippet
+		}
+
+		xc.Out.Info("image.wasted.space.layer", layerInfo)
+	}
+
+	for _, info := range wasted.Files {
+		xc.Out.Info("image.wasted.space.file",
+			ovars{
+				"path":         info.Path,
+				"reason":       info.Reason,
+				"count":        info.Count,
+				"wasted.human": humanize.Bytes(info.WastedSize),
+			})
+	}
+}
+
+func printPackages(
+	xc *app.ExecutionContext,
+	inventory *dockerimage.PackageInventory,
+	doShowPackages bool,
+	cmdReport *report.XrayCommand) {
+	cmdReport.ImageReport.Packages = inventory
+
+	xc.Out.Info("image.packages",
+		ovars{
+			"count":            inventory.Count,
+			"total_size.human": humanize.Bytes(inventory.TotalSize),
+		})
+
+	for _, db := range inventory.DBs {
+		dbInfo := ovars{
+			"type":  db.Type,
+			"path":  db.Path,
+			"layer": db.Layer,
+			"count": db.Count,
+		}
+
+		if db.Error != "" {
+			dbInfo["error"] = db.Error
+		}
+
+		xc.Out.Info("image.packages.db", dbInfo)
+	}
+
+	if doShowPackages {
+		for _, p := range inventory.Packages {
+			xc.Out.Info("image.package",
+				ovars{
+					"name":       p.Name,
+					"version":    p.Version,
+					"arch":       p.Arch,
+					"size.human": humanize.Bytes(p.Size),
+					"type":       p.Type,
+					"layer":      p.Layer,
+				})
+		}
+	}
+}
+
+func printTopSizes(
+	xc *app.ExecutionContext,
+	sizes *dockerimage.SizeReport,
+	cmdReport *report.XrayCommand) {
+	cmdReport.ImageReport.Sizes = sizes
+
+	printSizeBreakdown := func(prefix string, layer int, sb *dockerimage.SizeBreakdown) {
+		for _, info := range sb.Dirs {
+			dirInfo := ovars{
+				"path":       info.Path,
+				"size.human": humanize.Bytes(info.Size),
+				"files":      info.FileCount,
+			}
+
+			if layer > -1 {
+				dirInfo["layer"] = layer
+			}
+
+			xc.Out.Info(prefix+".dir", dirInfo)
+		}
+
+		for _, info := range sb.Files {
+			xc.Out.Info(prefix+".file",
+				ovars{
+					"path":       info.Path,
+					"size.human": humanize.Bytes(info.Size),
+					"layer":      info.Layer,
+				})
+		}
+	}
+
+	xc.Out.Info("image.top.sizes",
+		ovars{
+			"sort":             sizes.SortBy,
+			"total_size.human": humanize.Bytes(sizes.Image.TotalSize),
+			"files":            sizes.Image.FileCount,
+		})
+
+	printSizeBreakdown("image.top.sizes", -1, sizes.Image)
+
+	for _, layer := range sizes.Layers {
+		if layer.FileCount == 0 {
+			continue
+		}
+
+		xc.Out.Info("layer.top.sizes",
+			ovars{
+				"index":            layer.Index,
+				"total_size.human": humanize.Bytes(layer.TotalSize),
+				"files":            layer.FileCount,
+			})
+
+		printSizeBreakdown("layer.top.sizes", layer.Index, &layer.SizeBreakdown)
+	}
 }
 
 func findChange(pkg *dockerimage.Package, filepath string) *dockerimage.ObjectMetadata {
@@ -1287,3 +1976,54 @@ func printObject(xc *app.ExecutionContext, object *dockerimage.ObjectMetadata) {
 		fmt.Printf("\n")
 	}
 }
+
+// saveImageArchive saves the image data in the image archive
+// (the existing archive is reused if it's complete and reusing is enabled)
+func saveImageArchive(
+	xc *app.ExecutionContext,
+	client *dockerapi.Client,
+	imageInspector *image.Inspector,
+	iaPath string,
+	doReuseSavedImage bool,
+	doRemote bool,
+	logger *log.Entry) error {
+	iaPathReady := fmt.Sprintf("%s.ready", iaPath)
+	if fsutil.IsRegularFile(iaPath) && doReuseSavedImage && fsutil.Exists(iaPathReady) {
+		logger.Debugf("exported image already exists - %s", iaPath)
+		return nil
+	}
+
+	if fsutil.Exists(iaPathReady) {
+		fsutil.Remove(iaPathReady)
+	}
+
+	xc.Out.Info("image.data.inspection.save.image.start")
+	var err error
+	if doRemote {
+		fetchProgress := xc.Out.NewProgress("image.fetch", 0, app.ProgressUnitItems)
+		err = imageInspector.SaveRemoteImage(iaPath, func(progress *image.RemoteLayerProgress) {
+			fetchProgress.Update(int64(progress.Index+1), int64(progress.Total))
+			xc.Out.Info("image.data.inspection.fetch.layer",
+				ovars{
+					"digest":    progress.Digest,
+					"size":      humanize.Bytes(uint64(progress.Size)),
+					"completed": fmt.Sprintf("%d/%d", progress.Index+1, progress.Total),
+				})
+		})
+		fetchProgress.Done()
+	} else {
+		saveProgress := xc.Out.NewSpinner("image.save")
+		err = dockerutil.SaveImage(client, dockerutil.CleanImageID(imageInspector.ImageInfo.ID), iaPath, false, false)
+		saveProgress.Done()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = fsutil.Touch(iaPathReady)
+	errutil.WarnOn(err)
+
+	xc.Out.Info("image.data.inspection.save.image.end")
+	return nil
+}
@@ -2,6 +2,7 @@ package xray
 
 import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
 
 	"github.com/c-bata/go-prompt"
 )
@@ -19,6 +20,8 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagRegistryAccount), Description: commands.FlagRegistryAccountUsage},
 		{Text: commands.FullFlagName(commands.FlagRegistrySecret), Description: commands.FlagRegistrySecretUsage},
 		{Text: commands.FullFlagName(commands.FlagDockerConfigPath), Description: commands.FlagDockerConfigPathUsage},
+		{Text: commands.FullFlagName(FlagRemote), Description: FlagRemoteUsage},
+		{Text: commands.FullFlagName(FlagRemotePlatform), Description: FlagRemotePlatformUsage},
 		{Text: commands.FullFlagName(FlagChanges), Description: FlagChangesUsage},
 		{Text: commands.FullFlagName(FlagChangesOutput), Description: FlagChangesOutputUsage},
 		{Text: commands.FullFlagName(FlagLayer), Description: FlagLayerUsage},
@@ -42,25 +45,75 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagDetectAllCertFiles), Description: FlagDetectAllCertFilesUsage},
 		{Text: commands.FullFlagName(FlagDetectAllCertPKFiles), Description: FlagDetectAllCertPKFilesUsage},
 		{Text: commands.FullFlagName(FlagExportAllDataArtifacts), Description: FlagExportAllDataArtifactsUsage},
+		{Text: commands.FullFlagName(FlagLayerParallelism), Description: FlagLayerParallelismUsage},
+		{Text: commands.FullFlagName(FlagDetectWastedSpace), Description: FlagDetectWastedSpaceUsage},
+		{Text: commands.FullFlagName(FlagWastedSpaceFilesMax), Description: FlagWastedSpaceFilesMaxUsage},
+		{Text: commands.FullFlagName(FlagTopSizes), Description: FlagTopSizesUsage},
+		{Text: commands.FullFlagName(FlagTopSizesMax), Description: FlagTopSizesMaxUsage},
+		{Text: commands.FullFlagName(FlagTopSizesSort), Description: FlagTopSizesSortUsage},
+		{Text: commands.FullFlagName(FlagSizeTree), Description: FlagSizeTreeUsage},
+		{Text: commands.FullFlagName(FlagSizeTreeDepth), Description: FlagSizeTreeDepthUsage},
+		{Text: commands.FullFlagName(FlagDetectPackages), Description: FlagDetectPackagesUsage},
+		{Text: commands.FullFlagName(FlagShowPackages), Description: FlagShowPackagesUsage},
+		{Text: commands.FullFlagName(FlagDetectLicenses), Description: FlagDetectLicensesUsage},
+		{Text: commands.FullFlagName(FlagShowLicenses), Description: FlagShowLicensesUsage},
+		{Text: commands.FullFlagName(FlagDenyLicenses), Description: FlagDenyLicensesUsage},
+		{Text: commands.FullFlagName(FlagDetectSecrets), Description: FlagDetectSecretsUsage},
+		{Text: commands.FullFlagName(FlagDetectSecretsMaxSize), Description: FlagDetectSecretsMaxSizeUsage},
+		{Text: commands.FullFlagName(FlagAuditELF), Description: FlagAuditELFUsage},
+		{Text: commands.FullFlagName(FlagDetectBlobs), Description: FlagDetectBlobsUsage},
+		{Text: commands.FullFlagName(FlagDetectBlobsMinSize), Description: FlagDetectBlobsMinSizeUsage},
+		{Text: commands.FullFlagName(FlagFindContent), Description: FlagFindContentUsage},
+		{Text: commands.FullFlagName(FlagFindPath), Description: FlagFindPathUsage},
+		{Text: commands.FullFlagName(FlagExportLayer), Description: FlagExportLayerUsage},
+		{Text: commands.FullFlagName(FlagExportLayerMerged), Description: FlagExportLayerMergedUsage},
+		{Text: commands.FullFlagName(FlagExportLayerOutput), Description: FlagExportLayerOutputUsage},
+		{Text: commands.FullFlagName(FlagCompareWith), Description: FlagCompareWithUsage},
+		{Text: commands.FullFlagName(FlagCompareFilesMax), Description: FlagCompareFilesMaxUsage},
+		{Text: commands.FullFlagName(FlagTUI), Description: FlagTUIUsage},
 		{Text: commands.FullFlagName(commands.FlagRemoveFileArtifacts), Description: commands.FlagRemoveFileArtifactsUsage},
+		{Text: commands.FullFlagName(commands.FlagSBOM), Description: commands.FlagSBOMUsage},
+		{Text: commands.FullFlagName(commands.FlagSBOMOutput), Description: commands.FlagSBOMOutputUsage},
+		{Text: commands.FullFlagName(commands.FlagReportHTML), Description: commands.FlagReportHTMLUsage},
+		{Text: commands.FullFlagName(commands.FlagReportUpload), Description: commands.FlagReportUploadUsage},
+		{Text: commands.FullFlagName(commands.FlagReportUploadArtifacts), Description: commands.FlagReportUploadArtifactsUsage},
 	},
 	Values: map[string]commands.CompleteValue{
-		commands.FullFlagName(commands.FlagPull):                commands.CompleteBool,
-		commands.FullFlagName(commands.FlagShowPullLogs):        commands.CompleteBool,
-		commands.FullFlagName(commands.FlagDockerConfigPath):    commands.CompleteFile,
-		commands.FullFlagName(commands.FlagTarget):              commands.CompleteTarget,
-		commands.FullFlagName(FlagChanges):                      completeLayerChanges,
-		commands.FullFlagName(FlagChangesOutput):                completeOutputs,
-		commands.FullFlagName(FlagAddImageManifest):             commands.CompleteBool,
-		commands.FullFlagName(FlagAddImageConfig):               commands.CompleteBool,
-		commands.FullFlagName(FlagHashData):                     commands.CompleteBool,
-		commands.FullFlagName(FlagDetectDuplicates):             commands.CompleteBool,
-		commands.FullFlagName(FlagShowDuplicates):               commands.CompleteTBool,
-		commands.FullFlagName(FlagShowSpecialPerms):             commands.CompleteTBool,
-		commands.FullFlagName(FlagReuseSavedImage):              commands.CompleteTBool,
-		commands.FullFlagName(FlagDetectAllCertFiles):           commands.CompleteBool,
-		commands.FullFlagName(FlagDetectAllCertPKFiles):         commands.CompleteBool,
-		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
+		commands.FullFlagName(commands.FlagPull):                  commands.CompleteBool,
+		commands.FullFlagName(commands.FlagShowPullLogs):          commands.CompleteBool,
+		commands.FullFlagName(commands.FlagDockerConfigPath):      commands.CompleteFile,
+		commands.FullFlagName(commands.FlagTarget):                commands.CompleteTarget,
+		commands.FullFlagName(FlagChanges):                        completeLayerChanges,
+		commands.FullFlagName(FlagChangesOutput):                  completeOutputs,
+		commands.FullFlagName(FlagAddImageManifest):               commands.CompleteBool,
+		commands.FullFlagName(FlagAddImageConfig):                 commands.CompleteBool,
+		commands.FullFlagName(FlagHashData):                       commands.CompleteBool,
+		commands.FullFlagName(FlagRemote):                         commands.CompleteBool,
+		commands.FullFlagName(FlagDetectDuplicates):               commands.CompleteBool,
+		commands.FullFlagName(FlagShowDuplicates):                 commands.CompleteTBool,
+		commands.FullFlagName(FlagShowSpecialPerms):               commands.CompleteTBool,
+		commands.FullFlagName(FlagReuseSavedImage):                commands.CompleteTBool,
+		commands.FullFlagName(FlagDetectAllCertFiles):             commands.CompleteBool,
+		commands.FullFlagName(FlagDetectAllCertPKFiles):           commands.CompleteBool,
+		commands.FullFlagName(FlagDetectWastedSpace):              commands.CompleteTBool,
+		commands.FullFlagName(FlagTopSizes):                       commands.CompleteBool,
+		commands.FullFlagName(FlagTopSizesSort):                   completeSizesSort,
+		commands.FullFlagName(FlagSizeTree):                       commands.CompleteBool,
+		commands.FullFlagName(FlagDetectPackages):                 commands.CompleteTBool,
+		commands.FullFlagName(FlagShowPackages):                   commands.CompleteBool,
+		commands.FullFlagName(FlagDetectLicenses):                 commands.CompleteBool,
+		commands.FullFlagName(FlagShowLicenses):                   commands.CompleteBool,
+		commands.FullFlagName(FlagDetectSecrets):                  commands.CompleteBool,
+		commands.FullFlagName(FlagAuditELF):                       commands.CompleteBool,
+		commands.FullFlagName(FlagDetectBlobs):                    commands.CompleteBool,
+		commands.FullFlagName(FlagExportLayerMerged):              commands.CompleteBool,
+		commands.FullFlagName(FlagExportLayerOutput):              commands.CompleteFile,
+		commands.FullFlagName(FlagTUI):                            commands.CompleteBool,
+		commands.FullFlagName(commands.FlagRemoveFileArtifacts):   commands.CompleteBool,
+		commands.FullFlagName(commands.FlagSBOM):                  commands.CompleteSBOMFormat,
+		commands.FullFlagName(commands.FlagSBOMOutput):            commands.CompleteFile,
+		commands.FullFlagName(commands.FlagReportHTML):            commands.CompleteFile,
+		commands.FullFlagName(commands.FlagReportUploadArtifacts): commands.CompleteBool,
 	},
 }
 
@@ -85,3 +138,13 @@ var outputsValues = []prompt.Suggest{
 func completeOutputs(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	return prompt.FilterHasPrefix(outputsValues, token, true)
 }
+
+var sizesSortValues = []prompt.Suggest{
+	{Text: dockerimage.SizeSortBySize, Description: "Sort the largest files and directories by size"},
+	{Text: dockerimage.SizeSortByCount, Description: "Sort the largest directories by file count"},
+	{Text: dockerimage.SizeSortByPath, Description: "Sort the largest files and directories by path"},
+}
+
+func completeSizesSort(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(sizesSortValues, token, true)
+}
@@ -0,0 +1,92 @@
+package xray
+
+import (
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/sbom"
+	"github.com/docker-slim/docker-slim/pkg/system"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// saveSBOM generates the SBOM from the installed package inventory
+// and the executable files in the final image filesystem
+func saveSBOM(
+	xc *app.ExecutionContext,
+	format string,
+	output string,
+	targetRef string,
+	imageID string,
+	imagePkg *dockerimage.Package,
+	inventory *dockerimage.PackageInventory,
+	cmdReport *report.XrayCommand,
+	logger *log.Entry) {
+	doc := &sbom.Document{
+		ImageName:   targetRef,
+		ImageID:     imageID,
+		ToolVersion: v.Current(),
+	}
+
+	var distro *system.DistroInfo
+	for _, layer := range imagePkg.Layers {
+		if layer.Distro != nil {
+			distro = layer.Distro
+		}
+	}
+
+	doc.Distro = distro
+
+	for _, p := range inventory.Packages {
+		doc.Packages = append(doc.Packages, &sbom.Package{
+			Name:    p.Name,
+			Version: p.Version,
+			Arch:    p.Arch,
+			Type:    p.Type,
+		})
+	}
+
+	for path, object := range dockerimage.ImageFiles(imagePkg) {
+		if object.Mode&0111 == 0 {
+			continue
+		}
+
+		doc.Files = append(doc.Files, &sbom.File{
+			Path: path,
+			Size: object.Size,
+			SHA1: object.Hash,
+		})
+	}
+
+	if output == "" {
+		output = filepath.Join(cmdReport.ArtifactLocation, sbom.DefaultFileName(format))
+	}
+
+	if err := sbom.Save(format, output, doc); err != nil {
+		logger.Errorf("saveSBOM: error saving SBOM - %v", err)
+		xc.Out.Info("sbom",
+			ovars{
+				"format": format,
+				"error":  err.Error(),
+			})
+		return
+	}
+
+	cmdReport.SBOM = &report.SBOMInfo{
+		Format:       format,
+		Location:     output,
+		PackageCount: len(doc.Packages),
+		FileCount:    len(doc.Files),
+	}
+
+	xc.Out.Info("sbom",
+		ovars{
+			"format":   format,
+			"location": output,
+			"packages": len(doc.Packages),
+			"files":    len(doc.Files),
+		})
+}
@@ -0,0 +1,736 @@
+package xray
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/term"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+)
+
+var errNoTerminal = errors.New("interactive mode needs a terminal (stdin and stdout)")
+
+const (
+	ansiAltScreenOn  = "\x1b[?1049h"
+	ansiAltScreenOff = "\x1b[?1049l"
+	ansiCursorHide   = "\x1b[?25l"
+	ansiCursorShow   = "\x1b[?25h"
+	ansiCursorHome   = "\x1b[H"
+	ansiClearLine    = "\x1b[K"
+	ansiReset        = "\x1b[0m"
+	ansiBold         = "\x1b[1m"
+	ansiReverse      = "\x1b[7m"
+	ansiRed          = "\x1b[31m"
+	ansiGreen        = "\x1b[32m"
+	ansiYellow       = "\x1b[33m"
+	ansiCyan         = "\x1b[36m"
+)
+
+type tuiKey int
+
+const (
+	keyNone tuiKey = iota
+	keyRune
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyTab
+	keyEsc
+	keyBackspace
+	keyCtrlC
+)
+
+type tuiEvent struct {
+	key tuiKey
+	ch  rune
+}
+
+type tuiPane int
+
+const (
+	paneLayers tuiPane = iota
+	paneFiles
+)
+
+type treeRow struct {
+	node  *dockerimage.FileTreeNode
+	depth int
+}
+
+// imageExplorer is the interactive terminal UI for the image layers and their filesystem trees
+type imageExplorer struct {
+	pkg        *dockerimage.Package
+	imageRef   string
+	layerCmds  map[int]string
+	pane       tuiPane
+	layerIdx   int
+	layerTop   int
+	tree       *dockerimage.FileTreeNode
+	rows       []*treeRow
+	fileIdx    int
+	fileTop    int
+	expanded   map[string]bool
+	onlyChange bool
+	search     string
+	searching  bool
+	input      []rune
+	status     string
+	width      int
+	height     int
+}
+
+// runImageExplorer shows the interactive terminal UI for the image package (until the user exits)
+func runImageExplorer(pkg *dockerimage.Package, imageRef string) error {
+	if !hasTerminal() {
+		return errNoTerminal
+	}
+
+	inFd := int(os.Stdin.Fd())
+	outFd := int(os.Stdout.Fd())
+
+	width, height, err := term.GetSize(outFd)
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return err
+	}
+
+	defer term.Restore(inFd, state)
+
+	os.Stdout.WriteString(ansiAltScreenOn + ansiCursorHide)
+	defer os.Stdout.WriteString(ansiCursorShow + ansiAltScreenOff)
+
+	ui := &imageExplorer{
+		pkg:       pkg,
+		imageRef:  imageRef,
+		layerCmds: map[int]string{},
+		expanded:  map[string]bool{"/": true},
+		width:     width,
+		height:    height,
+	}
+
+	if pkg.Config != nil {
+		for _, record := range pkg.Config.History {
+			if !record.EmptyLayer {
+				ui.layerCmds[record.LayerIndex] = cleanLayerCmd(record.CreatedBy)
+			}
+		}
+	}
+
+	if err := ui.selectLayer(0); err != nil {
+		return err
+	}
+
+	events := make(chan *tuiEvent)
+	go readTUIEvents(os.Stdin, events)
+
+	//there's no portable resize signal, so the terminal size is checked periodically
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	ui.render()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if ui.handle(event) {
+				return nil
+			}
+		case <-ticker.C:
+			width, height, err := term.GetSize(outFd)
+			if err != nil || (width == ui.width && height == ui.height) {
+				continue
+			}
+
+			ui.width = width
+			ui.height = height
+		}
+
+		ui.render()
+	}
+}
+
+func hasTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func cleanLayerCmd(cmd string) string {
+	cmd = strings.TrimPrefix(cmd, "/bin/sh -c ")
+	cmd = strings.TrimPrefix(cmd, "#(nop) ")
+	return strings.Join(strings.Fields(cmd), " ")
+}
+
+func readTUIEvents(input *os.File, events chan<- *tuiEvent) {
+	defer close(events)
+
+	buf := make([]byte, 256)
+	for {
+		n, err := input.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for _, event := range parseTUIEvents(buf[:n]) {
+			events <- event
+		}
+	}
+}
+
+var escapeKeys = map[string]tuiKey{
+	"[A":  keyUp,
+	"[B":  keyDown,
+	"[C":  keyRight,
+	"[D":  keyLeft,
+	"OA":  keyUp,
+	"OB":  keyDown,
+	"OC":  keyRight,
+	"OD":  keyLeft,
+	"[5~": keyPageUp,
+	"[6~": keyPageDown,
+	"[H":  keyHome,
+	"[F":  keyEnd,
+	"[1~": keyHome,
+	"[4~": keyEnd,
+	"OH":  keyHome,
+	"OF":  keyEnd,
+}
+
+func parseTUIEvents(data []byte) []*tuiEvent {
+	var events []*tuiEvent
+	for len(data) > 0 {
+		switch data[0] {
+		case 0x1b:
+			if len(data) == 1 || (data[1] != '[' && data[1] != 'O') {
+				events = append(events, &tuiEvent{key: keyEsc})
+				break
+			}
+
+			//the escape sequences end with a letter or a tilde
+			end := 1
+			for end < len(data) && end < 8 {
+				c := data[end]
+				end++
+				if end > 2 && ((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || c == '~') {
+					break
+				}
+			}
+
+			if key, found := escapeKeys[string(data[1:end])]; found {
+				events = append(events, &tuiEvent{key: key})
+			}
+
+			data = data[end:]
+			continue
+		case '\r', '\n':
+			events = append(events, &tuiEvent{key: keyEnter})
+		case '\t':
+			events = append(events, &tuiEvent{key: keyTab})
+		case 0x7f, 0x08:
+			events = append(events, &tuiEvent{key: keyBackspace})
+		case 0x03:
+			events = append(events, &tuiEvent{key: keyCtrlC})
+		default:
+			r, size := utf8.DecodeRune(data)
+			if r >= ' ' {
+				events = append(events, &tuiEvent{key: keyRune, ch: r})
+			}
+
+			data = data[size:]
+			continue
+		}
+
+		data = data[1:]
+	}
+
+	return events
+}
+
+// handle processes the input event (returns true if the user wants to exit)
+func (ui *imageExplorer) handle(event *tuiEvent) bool {
+	if event.key == keyCtrlC {
+		return true
+	}
+
+	ui.status = ""
+	if ui.searching {
+		switch event.key {
+		case keyEnter:
+			ui.searching = false
+			ui.applySearch(string(ui.input))
+		case keyEsc:
+			ui.searching = false
+		case keyBackspace:
+			if len(ui.input) > 0 {
+				ui.input = ui.input[:len(ui.input)-1]
+			}
+		case keyRune:
+			ui.input = append(ui.input, event.ch)
+		}
+
+		return false
+	}
+
+	switch event.key {
+	case keyTab:
+		if ui.pane == paneLayers {
+			ui.pane = paneFiles
+		} else {
+			ui.pane = paneLayers
+		}
+	case keyEsc:
+		if ui.search != "" {
+			ui.applySearch("")
+		}
+	case keyUp:
+		ui.move(-1)
+	case keyDown:
+		ui.move(1)
+	case keyPageUp:
+		ui.move(-ui.listHeight())
+	case keyPageDown:
+		ui.move(ui.listHeight())
+	case keyHome:
+		ui.move(-len(ui.rows) - len(ui.pkg.Layers))
+	case keyEnd:
+		ui.move(len(ui.rows) + len(ui.pkg.Layers))
+	case keyEnter, keyRight, keyLeft:
+		if ui.pane == paneFiles {
+			ui.toggle(event.key)
+		} else if event.key == keyEnter || event.key == keyRight {
+			ui.pane = paneFiles
+		}
+	case keyRune:
+		switch event.ch {
+		case 'q', 'Q':
+			return true
+		case 'k':
+			ui.move(-1)
+		case 'j':
+			ui.move(1)
+		case ' ':
+			if ui.pane == paneFiles {
+				ui.toggle(keyEnter)
+			}
+		case '/':
+			ui.searching = true
+			ui.input = []rune(ui.search)
+		case 'n':
+			ui.nextMatch(1)
+		case 'N':
+			ui.nextMatch(-1)
+		case 'c':
+			ui.onlyChange = !ui.onlyChange
+			ui.refreshRows(ui.selectedPath())
+		}
+	}
+
+	return false
+}
+
+func (ui *imageExplorer) move(delta int) {
+	if ui.pane == paneLayers {
+		idx := clampIndex(ui.layerIdx+delta, len(ui.pkg.Layers))
+		if idx != ui.layerIdx {
+			if err := ui.selectLayer(idx); err != nil {
+				ui.status = err.Error()
+			}
+		}
+
+		return
+	}
+
+	ui.fileIdx = clampIndex(ui.fileIdx+delta, len(ui.rows))
+}
+
+func clampIndex(idx, count int) int {
+	if idx >= count {
+		idx = count - 1
+	}
+
+	if idx < 0 {
+		idx = 0
+	}
+
+	return idx
+}
+
+func (ui *imageExplorer) selectLayer(idx int) error {
+	tree, err := dockerimage.LayerFileTree(ui.pkg, idx)
+	if err != nil {
+		return err
+	}
+
+	selected := ui.selectedPath()
+	ui.layerIdx = idx
+	ui.tree = tree
+	ui.refreshRows(selected)
+	return nil
+}
+
+func (ui *imageExplorer) selectedPath() string {
+	if ui.fileIdx < len(ui.rows) {
+		return ui.rows[ui.fileIdx].node.Path
+	}
+
+	return ""
+}
+
+func (ui *imageExplorer) toggle(key tuiKey) {
+	if ui.fileIdx >= len(ui.rows) {
+		return
+	}
+
+	node := ui.rows[ui.fileIdx].node
+	//all directories are expanded in the search results
+	canExpand := node.IsDir && len(node.Children) > 0 && ui.search == ""
+	expanded := ui.expanded[node.Path]
+	switch {
+	case key == keyLeft && (!canExpand || !expanded):
+		//collapsing a file (or a collapsed directory) selects its parent directory
+		for i := ui.fileIdx - 1; i >= 0; i-- {
+			if ui.rows[i].node == node.Parent {
+				ui.fileIdx = i
+				break
+			}
+		}
+
+		return
+	case !canExpand:
+		return
+	case key == keyLeft:
+		ui.expanded[node.Path] = false
+	case key == keyRight:
+		ui.expanded[node.Path] = true
+	default:
+		ui.expanded[node.Path] = !expanded
+	}
+
+	ui.refreshRows(node.Path)
+}
+
+func (ui *imageExplorer) applySearch(search string) {
+	ui.search = search
+	ui.refreshRows(ui.selectedPath())
+	if search != "" {
+		ui.fileIdx = 0
+		ui.nextMatch(0)
+		if len(ui.rows) == 0 {
+			ui.status = fmt.Sprintf("no matches for '%s'", search)
+		}
+	}
+}
+
+func (ui *imageExplorer) isMatch(node *dockerimage.FileTreeNode) bool {
+	return ui.search != "" &&
+		strings.Contains(strings.ToLower(node.Name), strings.ToLower(ui.search))
+}
+
+// nextMatch selects the next (or the previous) file tree row with the name matching the search
+func (ui *imageExplorer) nextMatch(direction int) {
+	if ui.search == "" || len(ui.rows) == 0 {
+		return
+	}
+
+	step := direction
+	if step == 0 {
+		step = 1
+	}
+
+	for i := 0; i < len(ui.rows); i++ {
+		idx := ((ui.fileIdx+direction+i*step)%len(ui.rows) + len(ui.rows)) % len(ui.rows)
+		if ui.isMatch(ui.rows[idx].node) {
+			ui.pane = paneFiles
+			ui.fileIdx = idx
+			return
+		}
+	}
+}
+
+// refreshRows rebuilds the visible file tree rows (keeping the selected path if it's still visible)
+func (ui *imageExplorer) refreshRows(selected string) {
+	ui.rows = ui.rows[:0]
+
+	var visit func(node *dockerimage.FileTreeNode, depth int)
+	visit = func(node *dockerimage.FileTreeNode, depth int) {
+		for _, child := range node.Children {
+			if ui.onlyChange && !child.HasChanges() {
+				continue
+			}
+
+			if ui.search != "" && !ui.hasMatch(child) {
+				continue
+			}
+
+			ui.rows = append(ui.rows, &treeRow{node: child, depth: depth})
+			if child.IsDir && (ui.search != "" || ui.expanded[child.Path]) {
+				visit(child, depth+1)
+			}
+		}
+	}
+
+	visit(ui.tree, 0)
+
+	ui.fileIdx = clampIndex(ui.fileIdx, len(ui.rows))
+	if selected == "" {
+		return
+	}
+
+	for idx, row := range ui.rows {
+		if row.node.Path == selected {
+			ui.fileIdx = idx
+			return
+		}
+	}
+}
+
+func (ui *imageExplorer) hasMatch(node *dockerimage.FileTreeNode) bool {
+	if ui.isMatch(node) {
+		return true
+	}
+
+	for _, child := range node.Children {
+		if ui.hasMatch(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listHeight returns the number of the list rows in the panes
+// (the header, the pane titles, the details and the help line use the rest)
+func (ui *imageExplorer) listHeight() int {
+	if h := ui.height - 4; h > 1 {
+		return h
+	}
+
+	return 1
+}
+
+func (ui *imageExplorer) render() {
+	listHeight := ui.listHeight()
+	leftWidth := ui.width * 2 / 5
+	if leftWidth < 24 {
+		leftWidth = 24
+	}
+
+	rightWidth := ui.width - leftWidth - 1
+	if rightWidth < 0 {
+		rightWidth = 0
+	}
+
+	ui.layerTop = scrollTop(ui.layerTop, ui.layerIdx, listHeight)
+	ui.fileTop = scrollTop(ui.fileTop, ui.fileIdx, listHeight)
+
+	var out strings.Builder
+	out.WriteString(ansiCursorHome)
+
+	writeLine := func(line string) {
+		out.WriteString(line)
+		out.WriteString(ansiReset + ansiClearLine + "\r\n")
+	}
+
+	writeLine(ansiBold + fitText(fmt.Sprintf(" %s (%d layers)", ui.imageRef, len(ui.pkg.Layers)), ui.width))
+
+	filesTitle := " Files"
+	if ui.onlyChange {
+		filesTitle += " [changes only]"
+	}
+
+	if ui.search != "" {
+		filesTitle += fmt.Sprintf(" [search: %s]", ui.search)
+	}
+
+	writeLine(paneTitle(" Layers", leftWidth, ui.pane == paneLayers) + " " +
+		paneTitle(filesTitle, rightWidth, ui.pane == paneFiles))
+
+	for i := 0; i < listHeight; i++ {
+		writeLine(ui.layerLine(ui.layerTop+i, leftWidth) + " " + ui.fileLine(ui.fileTop+i, rightWidth))
+	}
+
+	writeLine(ui.detailsLine())
+
+	//the last line doesn't end with a new line to avoid scrolling
+	switch {
+	case ui.searching:
+		out.WriteString(fitText(" search: "+string(ui.input)+"_", ui.width))
+	case ui.status != "":
+		out.WriteString(ansiRed + fitText(" "+ui.status, ui.width))
+	default:
+		out.WriteString(ansiCyan + fitText(" tab: switch pane | arrows/jk: move | enter/space: expand | /: search | n/N: next/prev match | c: changes only | q: quit", ui.width))
+	}
+
+	out.WriteString(ansiReset)
+	os.Stdout.WriteString(out.String())
+}
+
+func scrollTop(top, selected, height int) int {
+	if selected < top {
+		return selected
+	}
+
+	if selected >= top+height {
+		return selected - height + 1
+	}
+
+	return top
+}
+
+func paneTitle(title string, width int, active bool) string {
+	if active {
+		return ansiReverse + ansiBold + fitText(title, width) + ansiReset
+	}
+
+	return ansiBold + fitText(title, width) + ansiReset
+}
+
+func (ui *imageExplorer) layerLine(idx, width int) string {
+	if idx >= len(ui.pkg.Layers) {
+		return fitText("", width)
+	}
+
+	layer := ui.pkg.Layers[idx]
+	cmd := ui.layerCmds[idx]
+	if cmd == "" {
+		cmd = layer.ID
+	}
+
+	text := fitText(fmt.Sprintf(" %3d %8s  %s", idx, humanize.Bytes(layer.Stats.AllSize), cmd), width)
+	switch {
+	case idx == ui.layerIdx && ui.pane == paneLayers:
+		return ansiReverse + text + ansiReset
+	case idx == ui.layerIdx:
+		return ansiBold + ansiCyan + text + ansiReset
+	default:
+		return text
+	}
+}
+
+func (ui *imageExplorer) fileLine(idx, width int) string {
+	if idx >= len(ui.rows) {
+		return fitText("", width)
+	}
+
+	row := ui.rows[idx]
+	node := row.node
+
+	mode := "----------"
+	owner := "-"
+	if node.Object != nil {
+		mode = node.Object.Mode.String()
+		owner = fmt.Sprintf("%d:%d", node.Object.UID, node.Object.GID)
+	} else if node.IsDir {
+		mode = "d---------"
+	}
+
+	marker := "  "
+	if node.IsDir && len(node.Children) > 0 {
+		if ui.search != "" || ui.expanded[node.Path] {
+			marker = "- "
+		} else {
+			marker = "+ "
+		}
+	}
+
+	name := node.Name
+	if node.Object != nil && node.Object.LinkTarget != "" {
+		name = fmt.Sprintf("%s -> %s", name, node.Object.LinkTarget)
+	}
+
+	text := fitText(fmt.Sprintf(" %-10s %11s %8s  %s%s%s",
+		mode,
+		owner,
+		humanize.Bytes(node.Size),
+		strings.Repeat("  ", row.depth),
+		marker,
+		name), width)
+
+	var color string
+	switch node.Change {
+	case dockerimage.ChangeAdd:
+		color = ansiGreen
+	case dockerimage.ChangeModify:
+		color = ansiYellow
+	case dockerimage.ChangeDelete:
+		color = ansiRed
+	}
+
+	switch {
+	case idx == ui.fileIdx && ui.pane == paneFiles:
+		return ansiReverse + color + text + ansiReset
+	case ui.isMatch(node):
+		return ansiBold + color + text + ansiReset
+	default:
+		return color + text + ansiReset
+	}
+}
+
+func (ui *imageExplorer) detailsLine() string {
+	if ui.pane == paneFiles && ui.fileIdx < len(ui.rows) {
+		node := ui.rows[ui.fileIdx].node
+		details := fmt.Sprintf(" %s  size=%s", node.Path, humanize.Bytes(node.Size))
+		if node.Change != dockerimage.ChangeUnknown {
+			details += fmt.Sprintf("  change=%s", node.Change)
+		}
+
+		if node.Object != nil {
+			details += fmt.Sprintf("  layer=%d  mtime=%s",
+				node.Object.LayerIndex,
+				node.Object.ModTime.UTC().Format(time.RFC3339))
+		}
+
+		return ansiBold + fitText(details, ui.width)
+	}
+
+	if ui.layerIdx >= len(ui.pkg.Layers) {
+		return fitText("", ui.width)
+	}
+
+	layer := ui.pkg.Layers[ui.layerIdx]
+	details := fmt.Sprintf(" layer %d  id=%s  size=%s  objects=%d  added=%d  modified=%d  deleted=%d",
+		ui.layerIdx,
+		layer.ID,
+		humanize.Bytes(layer.Stats.AllSize),
+		layer.Stats.ObjectCount,
+		len(layer.Changes.Added),
+		len(layer.Changes.Modified),
+		len(layer.Changes.Deleted))
+
+	return ansiBold + fitText(details, ui.width)
+}
+
+// fitText truncates or pads the text to the width (in runes)
+func fitText(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	if len(runes) > width {
+		if width > 1 {
+			return string(runes[:width-1]) + "~"
+		}
+
+		return string(runes[:width])
+	}
+
+	return text + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,120 @@
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	"github.com/ghodss/yaml"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const (
+	healthStatusHealthy = "healthy"
+	healthCheckInterval = time.Second
+	//the services without healthchecks are ready when they are running for some time
+	//(the same start wait the dependency services get without the health checks)
+	noHealthCheckStartWait = 3 * time.Second
+)
+
+type depServicesFile struct {
+	Version  string                    `json:"version"`
+	Services map[string]depServiceInfo `json:"services"`
+}
+
+type depServiceInfo struct {
+	Image       string   `json:"image"`
+	Environment []string `json:"environment,omitempty"`
+}
+
+// WriteDepServicesFile saves the dependency services as a compose file
+func WriteDepServicesFile(filePath string, services []config.DepService) error {
+	data := depServicesFile{
+		Version:  "3",
+		Services: map[string]depServiceInfo{},
+	}
+
+	for _, svc := range services {
+		if _, found := data.Services[svc.Name]; found {
+			return fmt.Errorf("duplicate dependency service - %s", svc.Name)
+		}
+
+		data.Services[svc.Name] = depServiceInfo{
+			Image:       svc.Image,
+			Environment: svc.Env,
+		}
+	}
+
+	raw, err := yaml.Marshal(&data)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, raw, 0644)
+}
+
+// WaitForHealthyServices waits until all running services are healthy
+// (the services without healthchecks need to be running for the start wait time)
+func (ref *Execution) WaitForHealthyServices() error {
+	timeout := time.Duration(ref.options.SvcHealthyTimeout) * time.Second
+	pending := map[string]*RunningService{}
+	for name, svc := range ref.RunningServices {
+		if name == ref.ContainerProbeSvc {
+			continue
+		}
+
+		pending[name] = svc
+	}
+
+	waitStart := time.Now()
+	for len(pending) > 0 {
+		for name, svc := range pending {
+			info, err := ref.apiClient.InspectContainerWithOptions(dockerapi.InspectContainerOptions{ID: svc.ID})
+			if err != nil {
+				return err
+			}
+
+			if !info.State.Running {
+				return fmt.Errorf("dependency service is not running - %s (exit code: %d)", name, info.State.ExitCode)
+			}
+
+			status := info.State.Health.Status
+			ref.logger.Debugf("Execution.WaitForHealthyServices: service=%s health=%s", name, status)
+			ready := status == healthStatusHealthy
+			if status == "" {
+				ready = time.Since(info.State.StartedAt) >= noHealthCheckStartWait
+			}
+
+			if ready {
+				delete(pending, name)
+				if ref.printState {
+					ref.xc.Out.Info("compose.service.ready",
+						ovars{
+							"name":   name,
+							"health": status,
+							"time":   time.Since(waitStart).Round(time.Millisecond),
+						})
+				}
+			}
+		}
+
+		if len(pending) == 0 {
+			break
+		}
+
+		if time.Since(waitStart) > timeout {
+			var names []string
+			for name := range pending {
+				names = append(names, name)
+			}
+
+			return fmt.Errorf("dependency services are not healthy - %v", names)
+		}
+
+		time.Sleep(healthCheckInterval)
+	}
+
+	return nil
+}
@@ -85,6 +85,8 @@ const (
 
 type ExecutionOptions struct {
 	SvcStartWait int
+	//max number of seconds to wait for the started services to be healthy (0 - don't wait)
+	SvcHealthyTimeout int
 }
 
 type Execution struct {
@@ -817,7 +819,7 @@ func (ref *Execution) CleanupServices() error {
 }
 
 func (ref *Execution) StopService(key string) error {
-	ref.logger.Debug("Execution.StopService(%s)\n", key)
+	ref.logger.Debugf("Execution.StopService(%s)", key)
 	service, running := ref.RunningServices[key]
 	if !running {
 		ref.logger.Debugf("Execution.StopService(%s) - no running service", key)
@@ -981,7 +983,7 @@ func EnvVarsFromService(varMap types.MappingWithEquals, varFiles types.StringLis
 	for _, file := range varFiles {
 		data, err := ioutil.ReadFile(file)
 		if err != nil {
-			log.Debugf("compose.EnvVarsFromService: error reading '%s' - %v", err)
+			log.Debugf("compose.EnvVarsFromService: error reading '%s' - %v", file, err)
 			continue
 		}
 
@@ -1208,6 +1210,38 @@ func buildImage(ctx context.Context, apiClient *dockerapi.Client, basePath, imag
 	return nil
 }
 
+func healthConfigFromService(hc *types.HealthCheckConfig) *dockerapi.HealthConfig {
+	if hc == nil {
+		return nil
+	}
+
+	if hc.Disable {
+		return &dockerapi.HealthConfig{Test: []string{"NONE"}}
+	}
+
+	config := &dockerapi.HealthConfig{
+		Test: []string(hc.Test),
+	}
+
+	if hc.Interval != nil {
+		config.Interval = time.Duration(*hc.Interval)
+	}
+
+	if hc.Timeout != nil {
+		config.Timeout = time.Duration(*hc.Timeout)
+	}
+
+	if hc.StartPeriod != nil {
+		config.StartPeriod = time.Duration(*hc.StartPeriod)
+	}
+
+	if hc.Retries != nil {
+		config.Retries = int(*hc.Retries)
+	}
+
+	return config
+}
+
 func durationToSeconds(d *types.Duration) int {
 	if d == nil {
 		return 0
@@ -1368,9 +1402,9 @@ func startContainer(
 			ExposedPorts: ExposedPorts(service.Expose, service.Ports),
 			Labels:       labels,
 			//Volumes:    - covered by "volume" HostConfig.Mounts,
-			StopSignal:  service.StopSignal,
-			StopTimeout: durationToSeconds(service.StopGracePeriod),
-			//Healthcheck *HealthConfig -> service.HealthCheck *HealthCheckConfig - todo
+			StopSignal:   service.StopSignal,
+			StopTimeout:  durationToSeconds(service.StopGracePeriod),
+			Healthcheck:  healthConfigFromService(service.HealthCheck),
 			SecurityOpts: service.SecurityOpt,
 			//AttachStdout: true, //todo: revisit
 			//AttachStderr: true, //todo: revisit
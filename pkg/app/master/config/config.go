@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -25,6 +26,7 @@ type AppOptions struct {
 // GlobalAppOptions provides a set of global application parameters
 type GlobalAppOptions struct {
 	NoColor      *bool   `json:"no_color,omitempty"`
+	NoCache      *bool   `json:"no_cache,omitempty"`
 	Debug        *bool   `json:"debug,omitempty"`
 	Verbose      *bool   `json:"verbose,omitempty"`
 	LogLevel     *string `json:"log_level,omitempty"`
@@ -92,6 +94,7 @@ type ImageNewInstructions struct {
 	RemoveVolumes      map[string]struct{}
 	RemoveExposedPorts map[docker.Port]struct{}
 	RemoveLabels       map[string]struct{}
+	User               string
 }
 
 // ContainerBuildOptions provides the options to use when
@@ -104,8 +107,11 @@ type ContainerBuildOptions struct {
 	BuildArgs         []CBOBuildArg
 	Labels            map[string]string
 	CacheFrom         []string
+	CacheTo           []string
 	Target            string
 	NetworkMode       string
+	//named build contexts (name -> directory, URL or 'docker-image://' reference)
+	NamedContexts map[string]string
 }
 
 type CBOBuildArg struct {
@@ -113,6 +119,32 @@ type CBOBuildArg struct {
 	Value string
 }
 
+// Image build engines
+const (
+	ImageBuildEngineInternal  = "internal"
+	ImageBuildEngineBuildx    = "buildx"
+	ImageBuildEngineBuildkitd = "buildkitd"
+)
+
+// ImageBuildEngineOptions provides the options to assemble the optimized image with BuildKit
+type ImageBuildEngineOptions struct {
+	Engine string
+	//buildkitd address (for 'buildkitd') or builder instance name (for 'buildx')
+	Endpoint   string
+	CacheFrom  []string
+	CacheTo    []string
+	Provenance string
+	Platforms  []string
+	//BuildKit output spec (the image is also loaded into the Docker engine)
+	Output string
+}
+
+// UseBuildKit returns true if the optimized image is assembled with BuildKit
+func (opts *ImageBuildEngineOptions) UseBuildKit() bool {
+	return opts != nil &&
+		(opts.Engine == ImageBuildEngineBuildx || opts.Engine == ImageBuildEngineBuildkitd)
+}
+
 // ContainerRunOptions provides the options to use running a container
 type ContainerRunOptions struct {
 	HostConfig *docker.HostConfig
@@ -138,6 +170,13 @@ const (
 	ProtoHTTP2C = "http2c"
 	ProtoWS     = "ws"
 	ProtoWSS    = "wss"
+	ProtoGRPC   = "grpc"
+	ProtoGRPCS  = "grpcs"
+	ProtoSSE    = "sse"
+	ProtoSSES   = "sses"
+	ProtoTCP    = "tcp"
+	ProtoTLS    = "tls"
+	ProtoUDP    = "udp"
 )
 
 func IsProto(value string) bool {
@@ -147,7 +186,25 @@ func IsProto(value string) bool {
 		ProtoHTTP2,
 		ProtoHTTP2C,
 		ProtoWS,
-		ProtoWSS:
+		ProtoWSS,
+		ProtoGRPC,
+		ProtoGRPCS,
+		ProtoSSE,
+		ProtoSSES,
+		ProtoTCP,
+		ProtoTLS,
+		ProtoUDP:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRawProto returns true for the protocols used to exercise
+// the non-HTTP services (the probe command resource is not used)
+func IsRawProto(value string) bool {
+	switch strings.ToLower(value) {
+	case ProtoTCP, ProtoTLS, ProtoUDP:
 		return true
 	default:
 		return false
@@ -167,6 +224,14 @@ type HTTPProbeCmd struct {
 	Password string   `json:"password"`
 	Crawl    bool     `json:"crawl"`
 
+	//websocket text frames to send in order (the body or body_file frame is sent if not set)
+	Frames []string `json:"frames,omitempty"`
+
+	//expected response status codes (any status code if not set)
+	ExpectStatus []int `json:"expect_status,omitempty"`
+	//regular expression to match the response body
+	ExpectBody string `json:"expect_body,omitempty"`
+
 	FastCGI *FastCGIProbeWrapperConfig `json:"fastcgi,omitempty"`
 }
 
@@ -218,6 +283,7 @@ const (
 	CAMExec           = "exec"
 	CAMHostExec       = "host-exec"
 	CAMAppExit        = "app-exit"
+	CAMSession        = "session"
 )
 
 // ContinueAfter provides the command execution mode parameters
@@ -225,12 +291,71 @@ type ContinueAfter struct {
 	Mode         string
 	Timeout      time.Duration
 	ContinueChan <-chan struct{}
+	//interactive session file to save (for the 'session' mode)
+	SessionRecordFile string
+	//recorded session file to replay (for the 'session' mode)
+	SessionReplayFile string
+}
+
+// DepService is a dependency service (started with the target container)
+type DepService struct {
+	Name  string
+	Image string
+	Env   []string
+}
+
+// SizePolicy provides the optimized image size requirements (the build fails if they are not met)
+type SizePolicy struct {
+	//max optimized image size in bytes (0 means disabled)
+	MaxSize uint64
+	//min size reduction percentage (0 means disabled)
+	MinReductionPercent int
+	FailOnNoReduction   bool
+}
+
+// IsSet returns true if any of the policy requirements are set
+func (p *SizePolicy) IsSet() bool {
+	return p != nil && (p.MaxSize > 0 || p.MinReductionPercent > 0 || p.FailOnNoReduction)
+}
+
+// RunAsUser provides the non-root user to create (and to use) in the optimized image
+type RunAsUser struct {
+	Name string
+	UID  int
+	GID  int
+}
+
+// String returns the user in the 'uid:gid' format used with the USER instruction
+func (u *RunAsUser) String() string {
+	return fmt.Sprintf("%d:%d", u.UID, u.GID)
+}
+
+// Exec hook phases
+const (
+	ExecHookAfterStart   = "after-start"
+	ExecHookProbeRound   = "probe-round"
+	ExecHookBeforeCommit = "before-commit"
+)
+
+// Exec hook targets
+const (
+	ExecHookHost      = "host"
+	ExecHookContainer = "container"
+)
+
+// ExecHook is a command executed at the selected container profiling phase
+type ExecHook struct {
+	Phase   string
+	Target  string
+	Command string
 }
 
 type HTTPProbeOptions struct {
 	Do            bool
 	Full          bool
 	ExitOnFailure bool
+	//max percentage of failed probe commands (-1 means disabled)
+	FailThreshold int
 
 	Cmds  []HTTPProbeCmd
 	Ports []uint16
@@ -239,6 +364,16 @@ type HTTPProbeOptions struct {
 	RetryCount int
 	RetryWait  int
 
+	//number of concurrent probe command workers
+	Concurrency int
+	//max number of probe calls per second (0 means no limit)
+	RateLimit int
+
+	//readiness conditions to wait for before probing
+	ReadyWhen []ProbeReadyCondition
+	//max number of seconds to wait for the readiness conditions
+	ReadyTimeout int
+
 	CrawlMaxDepth       int
 	CrawlMaxPageCount   int
 	CrawlConcurrency    int
@@ -249,6 +384,39 @@ type HTTPProbeOptions struct {
 
 	ProxyEndpoint string
 	ProxyPort     int
+
+	//extra headers ("name: value") added to all probe calls
+	Headers []string
+
+	ClientCert string
+	ClientKey  string
+	CACert     string
+
+	OAuth2 *OAuth2ClientCredentials
+}
+
+// Probe readiness condition types
+const (
+	ProbeReadyLog         = "log"
+	ProbeReadyTCP         = "tcp"
+	ProbeReadyHealthcheck = "healthcheck"
+)
+
+// ProbeReadyCondition describes a condition the target needs to satisfy before probing
+type ProbeReadyCondition struct {
+	Type string
+	//container log line regex (for the 'log' conditions)
+	Pattern string
+	//optional container port (for the 'tcp' conditions)
+	Port string
+}
+
+// OAuth2ClientCredentials provides the OAuth2 client credentials flow parameters
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 type AppNodejsInspectOptions struct {
@@ -257,6 +425,30 @@ type AppNodejsInspectOptions struct {
 	NuxtOpts        NodejsWebFrameworkInspectOptions
 }
 
+// AppJavaRuntimeOptions provides the Java runtime minimization (jlink) parameters
+type AppJavaRuntimeOptions struct {
+	JLink bool
+	//JDK image with jdeps and jlink (the target image is used if it's empty)
+	JLinkImage string
+	AddModules []string
+}
+
+// AppPythonPackageOptions provides the Python package pruning parameters
+type AppPythonPackageOptions struct {
+	Prune bool
+	//the distributions to keep even if the app didn't import them
+	KeepPackages []string
+}
+
+// AppNodejsPackageOptions provides the Node.js package pruning parameters
+type AppNodejsPackageOptions struct {
+	Prune bool
+	//keep the declared (non-dev) dependencies of the app and the used packages
+	//(for the packages loaded with dynamic require/import calls)
+	KeepDeclaredDeps bool
+	KeepPackages     []string
+}
+
 type NodejsWebFrameworkInspectOptions struct {
 	IncludeAppDir         bool
 	IncludeBuildDir       bool
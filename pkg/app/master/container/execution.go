@@ -3,6 +3,7 @@ package container
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -99,6 +100,9 @@ type Execution struct {
 	PidMode     string
 	NetworkMode string
 	IpcMode     string
+	/// the same as docker's `--cap-add` and `--volumes-from` CLI flags
+	CapAdd      []string
+	VolumesFrom []string
 
 	imageRef          string
 	APIClient         *dockerapi.Client
@@ -161,6 +165,8 @@ func (ref *Execution) Start() error {
 		NetworkMode: ref.NetworkMode,
 		PidMode:     ref.PidMode,
 		IpcMode:     ref.IpcMode,
+		CapAdd:      ref.CapAdd,
+		VolumesFrom: ref.VolumesFrom,
 	}
 
 	containerOptions := dockerapi.CreateContainerOptions{
@@ -169,6 +175,7 @@ func (ref *Execution) Start() error {
 			Image: ref.imageRef,
 		},
 		HostConfig: hostConfig,
+		Context:    ref.context(),
 	}
 
 	if ref.options != nil {
@@ -302,7 +309,7 @@ func (ref *Execution) Start() error {
 		}
 	}
 
-	if err := ref.APIClient.StartContainer(ref.ContainerID, nil); err != nil {
+	if err := ref.APIClient.StartContainerWithContext(ref.ContainerID, nil, ref.context()); err != nil {
 		ref.State = "error"
 		return err
 	}
@@ -415,8 +422,7 @@ func (ref *Execution) Cleanup() error {
 
 // Wait waits the container execution
 func (ref *Execution) Wait() (int, error) {
-	//TODO: use WaitContainerWithContext
-	return ref.APIClient.WaitContainer(ref.ContainerID)
+	return ref.APIClient.WaitContainerWithContext(ref.ContainerID, ref.context())
 }
 
 func (ref *Execution) monitorContainerExit() {
@@ -499,17 +505,18 @@ func (ref *Execution) monitorContainerExit() {
 	}()
 }
 
+// monitorSysExit stops the container if the command is interrupted
 func (ref *Execution) monitorSysExit() {
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT)
-	go func() {
-		<-signals
-		ref.isInterrupted = true
-		//_ = ref.APIClient.KillContainer(dockerapi.KillContainerOptions{ID: ref.ContainerID})
-		//if ref.logger != nil {
-		//	ref.logger.Debugf("Execution.monitorSysExit: received SIGINT, killing container %s", ref.ContainerID)
-		//}
+	if ref.xc == nil {
+		return
+	}
 
+	ref.xc.AddCleanupHandler(func() {
+		if ref.xc.Context.Err() == nil {
+			return
+		}
+
+		ref.isInterrupted = true
 		if ref.eventCh != nil {
 			ref.eventCh <- &ExecutionEvenInfo{
 				Event: XEInterrupt,
@@ -522,7 +529,16 @@ func (ref *Execution) monitorSysExit() {
 				ref.logger.Debugf("ref.Stop error: id=%s err=%v", ref.ContainerID, err)
 			}
 		}
-	}()
+	})
+}
+
+// context returns the command context (it's canceled when the command is interrupted)
+func (ref *Execution) context() context.Context {
+	if ref.xc == nil {
+		return context.Background()
+	}
+
+	return ref.xc.Context
 }
 
 func (ref *Execution) startTerminal() {
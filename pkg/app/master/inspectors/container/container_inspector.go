@@ -6,10 +6,8 @@ import (
 	goerr "errors"
 	"fmt"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/docker-slim/docker-slim/pkg/aflag"
@@ -20,12 +18,14 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/ipc"
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/sensor"
 	"github.com/docker-slim/docker-slim/pkg/app/master/security/apparmor"
+	"github.com/docker-slim/docker-slim/pkg/app/master/security/capabilities"
 	"github.com/docker-slim/docker-slim/pkg/app/master/security/seccomp"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
 	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
 	"github.com/docker-slim/docker-slim/pkg/ipc/event"
 	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/trace"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
@@ -51,6 +51,8 @@ const (
 	SensorMountPat       = "%s:/opt/dockerslim/bin/docker-slim-sensor:ro"
 	VolumeSensorMountPat = "%s:/opt/dockerslim/bin:ro"
 	LabelName            = "dockerslim"
+	//the temporary container (and sensor) logs saved in the artifact location for the diagnostics bundle
+	ContainerLogsFileName = "container.log"
 )
 
 type ovars = app.OutVars
@@ -64,6 +66,12 @@ var (
 
 var ErrStartMonitorTimeout = goerr.New("start monitor timeout")
 
+// Container network errors
+var (
+	ErrNoNetworkInfo     = goerr.New("no container network info")
+	ErrMissingCommsPorts = goerr.New("missing container comms ports")
+)
+
 const (
 	sensorVolumeBaseName = "docker-slim-sensor"
 )
@@ -115,6 +123,8 @@ type Inspector struct {
 	IncludePaths          map[string]*fsutil.AccessInfo
 	IncludeBins           map[string]*fsutil.AccessInfo
 	IncludeExes           map[string]*fsutil.AccessInfo
+	IncludePkgs           []string
+	DoIncludePkgDeps      bool
 	DoIncludeShell        bool
 	DoIncludeCertAll      bool
 	DoIncludeCertBundles  bool
@@ -122,6 +132,8 @@ type Inspector struct {
 	DoIncludeCertPKAll    bool
 	DoIncludeCertPKDirs   bool
 	DoIncludeNew          bool
+	DoIncludeEssentials   bool
+	RunAsUser             *config.RunAsUser
 	SelectedNetworks      map[string]NetNameInfo
 	DoDebug               bool
 	LogLevel              string
@@ -132,6 +144,7 @@ type Inspector struct {
 	SensorIPCEndpoint     string
 	SensorIPCMode         string
 	TargetHost            string
+	Trace                 *trace.Recorder
 	dockerEventCh         chan *dockerapi.APIEvents
 	dockerEventStopCh     chan struct{}
 	isDone                aflag.Type
@@ -187,6 +200,8 @@ func NewInspector(
 	includePaths map[string]*fsutil.AccessInfo,
 	includeBins map[string]*fsutil.AccessInfo,
 	includeExes map[string]*fsutil.AccessInfo,
+	includePkgs []string,
+	doIncludePkgDeps bool,
 	doIncludeShell bool,
 	doIncludeCertAll bool,
 	doIncludeCertBundles bool,
@@ -194,6 +209,8 @@ func NewInspector(
 	doIncludeCertPKAll bool,
 	doIncludeCertPKDirs bool,
 	doIncludeNew bool,
+	doIncludeEssentials bool,
+	runAsUser *config.RunAsUser,
 	selectedNetworks map[string]NetNameInfo,
 	//serviceAliases []string,
 	doDebug bool,
@@ -236,6 +253,8 @@ func NewInspector(
 		IncludePaths:          includePaths,
 		IncludeBins:           includeBins,
 		IncludeExes:           includeExes,
+		IncludePkgs:           includePkgs,
+		DoIncludePkgDeps:      doIncludePkgDeps,
 		DoIncludeShell:        doIncludeShell,
 		DoIncludeCertAll:      doIncludeCertAll,
 		DoIncludeCertBundles:  doIncludeCertBundles,
@@ -243,6 +262,8 @@ func NewInspector(
 		DoIncludeCertPKAll:    doIncludeCertPKAll,
 		DoIncludeCertPKDirs:   doIncludeCertPKDirs,
 		DoIncludeNew:          doIncludeNew,
+		DoIncludeEssentials:   doIncludeEssentials,
+		RunAsUser:             runAsUser,
 		SelectedNetworks:      selectedNetworks,
 		DoDebug:               doDebug,
 		LogLevel:              logLevel,
@@ -305,7 +326,10 @@ func NewInspector(
 // RunContainer starts the container inspector instance execution
 func (i *Inspector) RunContainer() error {
 	artifactsPath := filepath.Join(i.LocalVolumePath, ArtifactsDir)
-	sensorPath := sensor.EnsureLocalBinary(i.xc, i.logger, i.StatePath, i.PrintState)
+	sensorPath, err := sensor.EnsureLocalBinary(i.xc, i.logger, i.StatePath, i.PrintState)
+	if err != nil {
+		return err
+	}
 
 	allMountsMap := map[string]dockerapi.HostMount{}
 
@@ -412,11 +436,12 @@ func (i *Inspector) RunContainer() error {
 		allMountsMap[mkey] = vm
 	}
 
-	var err error
 	var volumeName string
 	if !i.DoUseLocalMounts {
 		volumeName, err = ensureSensorVolume(i.logger, i.APIClient, sensorPath, i.SensorVolumeName)
-		errutil.FailOn(err)
+		if err != nil {
+			return err
+		}
 	}
 
 	//var artifactsMountInfo string
@@ -536,6 +561,7 @@ func (i *Inspector) RunContainer() error {
 		},
 		HostConfig:       hostConfig,
 		NetworkingConfig: &dockerapi.NetworkingConfig{},
+		Context:          i.xc.Context,
 	}
 
 	if i.crOpts != nil {
@@ -575,7 +601,11 @@ func (i *Inspector) RunContainer() error {
 		//"Unrecognized input header" error
 	}
 
-	hostProbePorts := i.setPorts(&containerOptions)
+	hostProbePorts, err := i.setPorts(&containerOptions)
+	if err != nil {
+		return err
+	}
+
 	commsExposedPorts := containerOptions.Config.ExposedPorts
 
 	if i.Overrides.Network != "" {
@@ -614,11 +644,14 @@ func (i *Inspector) RunContainer() error {
 		i.logger.Debugf("RunContainer: HostConfig.DNSSearch => %v", i.DNSSearchDomains)
 	}
 
+	traceCreateDone := i.Trace.Begin(trace.TrackContainer, "container", "container.create")
 	containerInfo, err := i.APIClient.CreateContainer(containerOptions)
 	if err != nil {
 		return err
 	}
 
+	traceCreateDone(map[string]interface{}{"id": containerInfo.ID, "name": containerInfo.Name})
+
 	if i.ContainerName != containerInfo.Name {
 		i.logger.Debugf("RunContainer: Container name mismatch expected=%v got=%v", i.ContainerName, containerInfo.Name)
 	}
@@ -682,11 +715,13 @@ func (i *Inspector) RunContainer() error {
 							if i.PrintState {
 								i.xc.Out.State("exited",
 									ovars{
-										"exit.code": -123,
+										"exit.code": sensor.ExitCodeContainerCrashed,
 										"version":   v.Current(),
 									})
 							}
-							i.xc.Exit(-123)
+
+							i.xc.Abort(app.NewExitError(sensor.ExitCodeContainerCrashed))
+							return
 						}
 					}
 				}
@@ -698,29 +733,37 @@ func (i *Inspector) RunContainer() error {
 		}
 	}()
 
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT)
-	go func() {
-		<-signals
-		_ = i.APIClient.KillContainer(dockerapi.KillContainerOptions{ID: i.ContainerID})
-		i.logger.Fatalf("KillContainer: docker-slim received SIGINT, killing container %s", i.ContainerID)
-	}()
+	//stop and remove the container if the command is interrupted
+	i.xc.AddCleanupHandler(func() {
+		if i.xc.Context.Err() != nil {
+			i.logger.Debugf("RunContainer: command interrupted, removing container %s", i.ContainerID)
+			i.FinishMonitoring()
+			_ = i.ShutdownContainer()
+		}
+	})
 
-	if err := i.APIClient.StartContainer(i.ContainerID, nil); err != nil {
+	traceStartDone := i.Trace.Begin(trace.TrackContainer, "container", "container.start")
+	if err := i.APIClient.StartContainerWithContext(i.ContainerID, nil, i.xc.Context); err != nil {
 		return err
 	}
 
+	traceStartDone(nil)
+
 	inspectContainerOpts := dockerapi.InspectContainerOptions{ID: i.ContainerID, Size: true}
 	if i.ContainerInfo, err = i.APIClient.InspectContainerWithOptions(inspectContainerOpts); err != nil {
 		return err
 	}
 
-	errutil.FailWhen(i.ContainerInfo.NetworkSettings == nil, "docker-slim: error => no network info")
+	if i.ContainerInfo.NetworkSettings == nil {
+		return ErrNoNetworkInfo
+	}
 
 	if hCfg := i.ContainerInfo.HostConfig; hCfg != nil && !i.isHostNetworked() {
 		i.logger.Debugf("RunContainer: container HostConfig.NetworkMode => %s len(ports)=%d",
 			hCfg.NetworkMode, len(i.ContainerInfo.NetworkSettings.Ports))
-		errutil.FailWhen(len(i.ContainerInfo.NetworkSettings.Ports) < len(commsExposedPorts), "docker-slim: error => missing comms ports")
+		if len(i.ContainerInfo.NetworkSettings.Ports) < len(commsExposedPorts) {
+			return ErrMissingCommsPorts
+		}
 	}
 
 	i.logger.Debugf("RunContainer: container NetworkSettings.Ports => %#v", i.ContainerInfo.NetworkSettings.Ports)
@@ -741,8 +784,9 @@ func (i *Inspector) RunContainer() error {
 	}
 
 	cmd := &command.StartMonitor{
-		RTASourcePT: i.RTASourcePT,
-		AppName:     i.FatContainerCmd[0],
+		ProtocolVersion: command.ProtocolVersion,
+		RTASourcePT:     i.RTASourcePT,
+		AppName:         i.FatContainerCmd[0],
 	}
 
 	if len(i.FatContainerCmd) > 1 {
@@ -775,6 +819,11 @@ func (i *Inspector) RunContainer() error {
 		cmd.IncludeExes = pathMapKeys(i.IncludeExes)
 	}
 
+	if len(i.IncludePkgs) > 0 {
+		cmd.IncludePkgs = i.IncludePkgs
+		cmd.IncludePkgDeps = i.DoIncludePkgDeps
+	}
+
 	cmd.IncludeShell = i.DoIncludeShell
 	cmd.IncludeCertAll = i.DoIncludeCertAll
 	cmd.IncludeCertBundles = i.DoIncludeCertBundles
@@ -782,6 +831,15 @@ func (i *Inspector) RunContainer() error {
 	cmd.IncludeCertPKAll = i.DoIncludeCertPKAll
 	cmd.IncludeCertPKDirs = i.DoIncludeCertPKDirs
 	cmd.IncludeNew = i.DoIncludeNew
+	cmd.IncludeRuntimeEssentials = i.DoIncludeEssentials
+
+	if i.RunAsUser != nil {
+		cmd.RunAsUser = &command.UserInfo{
+			Name: i.RunAsUser.Name,
+			UID:  i.RunAsUser.UID,
+			GID:  i.RunAsUser.GID,
+		}
+	}
 
 	if runAsUser != "" {
 		cmd.AppUser = runAsUser
@@ -805,11 +863,16 @@ func (i *Inspector) RunContainer() error {
 
 	cmd.IncludeNodePackages = i.appNodejsInspectOpts.IncludePackages
 
-	_, err = i.ipcClient.SendCommand(cmd)
+	traceMonitorDone := i.Trace.Begin(trace.TrackSensor, "sensor", "sensor.start.monitor")
+	resp, err := i.ipcClient.SendCommand(cmd)
 	if err != nil {
 		return err
 	}
 
+	if err := sensor.CheckProtocol(i.xc, i.logger, resp, i.PrintState); err != nil {
+		return err
+	}
+
 	if i.PrintState {
 		i.xc.Out.Info("cmd.startmonitor",
 			ovars{
@@ -845,6 +908,7 @@ func (i *Inspector) RunContainer() error {
 		}
 
 		if evt.Name == event.StartMonitorDone {
+			traceMonitorDone(nil)
 			if i.PrintState {
 				i.xc.Out.Info("event.startmonitor.done",
 					ovars{
@@ -864,13 +928,13 @@ func (i *Inspector) RunContainer() error {
 
 				i.xc.Out.State("exited",
 					ovars{
-						"exit.code": -124,
+						"exit.code": sensor.ExitCodeSensorError,
 						"component": "container.inspector",
 						"version":   v.Current(),
 					})
 			}
 
-			i.xc.Exit(-124)
+			return app.NewExitError(sensor.ExitCodeSensorError)
 		}
 
 		if evt.Name != event.StartMonitorDone {
@@ -903,7 +967,7 @@ const localHostIP = "127.0.0.1"
 // Exposed tcp ports are returned as hostProbePorts for containers configured with host networks,
 // as those ports are exposed directly by the contained application on the loopback interface,
 // and will not be surfaced in network settings.
-func (i *Inspector) setPorts(ctrOpts *dockerapi.CreateContainerOptions) (hostProbePorts map[dockerapi.Port][]dockerapi.PortBinding) {
+func (i *Inspector) setPorts(ctrOpts *dockerapi.CreateContainerOptions) (hostProbePorts map[dockerapi.Port][]dockerapi.PortBinding, err error) {
 	// This is the minimal set of ports to either expose or directly use.
 	commsExposedPorts := map[dockerapi.Port]struct{}{
 		i.CmdPort: {},
@@ -931,10 +995,10 @@ func (i *Inspector) setPorts(ctrOpts *dockerapi.CreateContainerOptions) (hostPro
 		cmdPort := dockerapi.Port(i.CmdPort)
 		evtPort := dockerapi.Port(i.EvtPort)
 		if pbInfo, ok := i.portBindings[cmdPort]; ok {
-			i.exitIPCPortConflict(pbInfo, "cmd", -126)
+			return nil, i.exitIPCPortConflict(pbInfo, "cmd", sensor.ExitCodeCmdPortConflict)
 		}
 		if pbInfo, ok := i.portBindings[evtPort]; ok {
-			i.exitIPCPortConflict(pbInfo, "evt", -127)
+			return nil, i.exitIPCPortConflict(pbInfo, "evt", sensor.ExitCodeEvtPortConflict)
 		}
 
 		i.portBindings[cmdPort] = []dockerapi.PortBinding{{HostPort: cmdPortStrDefault}}
@@ -995,7 +1059,7 @@ func (i *Inspector) setPorts(ctrOpts *dockerapi.CreateContainerOptions) (hostPro
 		i.logger.Debugf("RunContainer: host network loopback ports => %v", hostProbePorts)
 	}
 
-	return hostProbePorts
+	return hostProbePorts, nil
 }
 
 func (i *Inspector) setAvailablePorts(hostProbePorts map[dockerapi.Port][]dockerapi.PortBinding) {
@@ -1057,7 +1121,7 @@ func (i *Inspector) setAvailablePorts(hostProbePorts map[dockerapi.Port][]docker
 	}
 }
 
-func (i *Inspector) exitIPCPortConflict(port []dockerapi.PortBinding, typ string, code int) {
+func (i *Inspector) exitIPCPortConflict(port []dockerapi.PortBinding, typ string, code int) error {
 	i.logger.Errorf("RunContainer: port bindings comms port conflict (%s) = %#v", typ, port)
 	if i.PrintState {
 		i.xc.Out.Info("sensor.error",
@@ -1074,7 +1138,7 @@ func (i *Inspector) exitIPCPortConflict(port []dockerapi.PortBinding, typ string
 			})
 	}
 
-	i.xc.Exit(code)
+	return app.NewExitError(code)
 }
 
 func (i *Inspector) ShowContainerLogs() {
@@ -1106,6 +1170,26 @@ func (i *Inspector) ShowContainerLogs() {
 	}
 }
 
+// SaveContainerLogs saves the container stdout and stderr logs to the selected file
+func (i *Inspector) SaveContainerLogs(filePath string) error {
+	logFile, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	logsOptions := dockerapi.LogsOptions{
+		Container:    i.ContainerID,
+		OutputStream: logFile,
+		ErrorStream:  logFile,
+		Stdout:       true,
+		Stderr:       true,
+		Timestamps:   true,
+	}
+
+	return i.APIClient.Logs(logsOptions)
+}
+
 // ShutdownContainer terminates the container inspector instance execution
 func (i *Inspector) ShutdownContainer() error {
 	if i.isDone.IsOn() {
@@ -1113,59 +1197,30 @@ func (i *Inspector) ShutdownContainer() error {
 	}
 
 	i.isDone.On()
-	if !i.DoUseLocalMounts {
-		deleteOrig := true
-		if i.DoKeepTmpArtifacts {
-			deleteOrig = false
-		}
-
-		reportLocalPath := filepath.Join(i.LocalVolumePath, ArtifactsDir, ReportArtifactTar)
-		reportRemotePath := filepath.Join(ArtifactsVolumePath, report.DefaultContainerReportFileName)
-		err := dockerutil.CopyFromContainer(i.APIClient, i.ContainerID, reportRemotePath, reportLocalPath, true, deleteOrig)
-		if err != nil {
-			errutil.FailOn(err)
-		}
-
-		/*
-			//ALTERNATIVE WAY TO XFER THE FILE ARTIFACTS
-			filesOutLocalPath := filepath.Join(i.LocalVolumePath, ArtifactsDir, FileArtifactsArchiveTar)
-			filesTarRemotePath := filepath.Join(ArtifactsVolumePath, fileArtifactsTar)
-			err = dockerutil.CopyFromContainer(i.APIClient,
-				i.ContainerID,
-				filesTarRemotePath,
-				filesOutLocalPath,
-				true,
-				false) //make it 'true' once tested/debugged
-			if err != nil {
-				errutil.FailOn(err)
-			}
-		*/
+	//the artifacts are not copied if the command is interrupted
+	//(the container is still removed if the artifacts can't be copied)
+	var copyErr error
+	if !i.DoUseLocalMounts && i.xc.Context.Err() == nil {
+		copyErr = i.copyArtifacts()
+	}
 
-		filesOutLocalPath := filepath.Join(i.LocalVolumePath, ArtifactsDir, FileArtifactsOutTar)
-		filesRemotePath := filepath.Join(ArtifactsVolumePath, sensor.FileArtifactsDirName)
-		err = dockerutil.CopyFromContainer(i.APIClient, i.ContainerID, filesRemotePath, filesOutLocalPath, false, false)
-		if err != nil {
-			errutil.FailOn(err)
-		}
+	i.shutdownContainerChannels()
 
-		//NOTE: possible enhancement (if the original filemode bits still get lost)
-		//(alternative to archiving files in the container to preserve filemodes)
-		//Rewrite the filemode bits using the data from creport.json,
-		//but creport.json also needs to be enhanced to use
-		//octal filemodes for the file records
-		err = dockerutil.PrepareContainerDataArchive(filesOutLocalPath, fileArtifactsTar, sensor.FileArtifactsPrefix, deleteOrig)
-		if err != nil {
-			errutil.FailOn(err)
+	//the container is removed, so the logs are saved for the diagnostics bundle
+	if app.DiagnosticsEnabled() && i.ImageInspector.ArtifactLocation != "" {
+		logsPath := filepath.Join(i.ImageInspector.ArtifactLocation, ContainerLogsFileName)
+		if err := i.SaveContainerLogs(logsPath); err != nil {
+			i.logger.Debugf("error saving container logs => %v - %v", i.ContainerID, err)
 		}
 	}
 
-	i.shutdownContainerChannels()
-
 	if i.DoShowContainerLogs {
 		i.ShowContainerLogs()
 	}
 
+	traceStopDone := i.Trace.Begin(trace.TrackContainer, "container", "container.stop")
 	err := i.APIClient.StopContainer(i.ContainerID, 9)
+	traceStopDone(nil)
 
 	if _, ok := err.(*dockerapi.ContainerNotRunning); ok {
 		i.logger.Info("can't stop the docker-slim container (container is not running)...")
@@ -1179,11 +1234,68 @@ func (i *Inspector) ShutdownContainer() error {
 		Force:         true,
 	}
 
+	traceRemoveDone := i.Trace.Begin(trace.TrackContainer, "container", "container.remove")
 	if err := i.APIClient.RemoveContainer(removeOption); err != nil {
 		i.logger.Info("error removing container =>", err)
 	}
 
-	return nil
+	traceRemoveDone(nil)
+
+	return copyErr
+}
+
+// copyArtifacts copies the sensor report and the file artifacts from the container
+func (i *Inspector) copyArtifacts() error {
+	traceCopyDone := i.Trace.Begin(trace.TrackContainer, "container", "container.artifacts.copy")
+	defer traceCopyDone(nil)
+	copyProgress := i.xc.Out.NewSpinner("container.artifacts.copy")
+	defer copyProgress.Done()
+
+	deleteOrig := true
+	if i.DoKeepTmpArtifacts {
+		deleteOrig = false
+	}
+
+	reportLocalPath := filepath.Join(i.LocalVolumePath, ArtifactsDir, ReportArtifactTar)
+	reportRemotePath := filepath.Join(ArtifactsVolumePath, report.DefaultContainerReportFileName)
+	err := dockerutil.CopyFromContainer(i.APIClient, i.ContainerID, reportRemotePath, reportLocalPath, true, deleteOrig)
+	if err != nil {
+		return err
+	}
+
+	/*
+		//ALTERNATIVE WAY TO XFER THE FILE ARTIFACTS
+		filesOutLocalPath := filepath.Join(i.LocalVolumePath, ArtifactsDir, FileArtifactsArchiveTar)
+		filesTarRemotePath := filepath.Join(ArtifactsVolumePath, fileArtifactsTar)
+		err = dockerutil.CopyFromContainer(i.APIClient,
+			i.ContainerID,
+			filesTarRemotePath,
+			filesOutLocalPath,
+			true,
+			false) //make it 'true' once tested/debugged
+		if err != nil {
+			errutil.FailOn(err)
+		}
+	*/
+
+	filesOutLocalPath := filepath.Join(i.LocalVolumePath, ArtifactsDir, FileArtifactsOutTar)
+	filesRemotePath := filepath.Join(ArtifactsVolumePath, sensor.FileArtifactsDirName)
+	err = dockerutil.CopyFromContainer(i.APIClient, i.ContainerID, filesRemotePath, filesOutLocalPath, false, false)
+	if err != nil {
+		return err
+	}
+
+	//NOTE: possible enhancement (if the original filemode bits still get lost)
+	//(alternative to archiving files in the container to preserve filemodes)
+	//Rewrite the filemode bits using the data from creport.json,
+	//but creport.json also needs to be enhanced to use
+	//octal filemodes for the file records
+	creportPath := filepath.Join(i.LocalVolumePath, ArtifactsDir, report.DefaultContainerReportFileName)
+	return dockerutil.PrepareContainerDataArchive(filesOutLocalPath,
+		fileArtifactsTar,
+		sensor.FileArtifactsPrefix,
+		deleteOrig,
+		reportXattrs(creportPath))
 }
 
 // FinishMonitoring ends the target container monitoring activities
@@ -1202,6 +1314,14 @@ func (i *Inspector) FinishMonitoring() {
 	close(i.dockerEventStopCh)
 	i.dockerEventStopCh = nil
 
+	if i.xc.Context.Err() != nil {
+		//the command is interrupted (the container is removed without waiting for the sensor)
+		return
+	}
+
+	traceStopDone := i.Trace.Begin(trace.TrackSensor, "sensor", "sensor.stop.monitor")
+	defer traceStopDone(nil)
+
 	cmdResponse, err := i.ipcClient.SendCommand(&command.StopMonitor{})
 	errutil.WarnOn(err)
 	//_ = cmdResponse
@@ -1238,8 +1358,10 @@ func (i *Inspector) initContainerChannels() error {
 	ipAddr := i.ContainerInfo.NetworkSettings.IPAddress
 	if cn != "" {
 		network, found := i.ContainerInfo.NetworkSettings.Networks[cn]
-		errutil.FailWhen(!found, fmt.Sprintf("docker-slim: error => expected NetworkSettings.Networks to contain %s: %v",
-			cn, i.ContainerInfo.NetworkSettings.Networks))
+		if !found {
+			return fmt.Errorf("%s: expected NetworkSettings.Networks to contain %s: %v",
+				op, cn, i.ContainerInfo.NetworkSettings.Networks)
+		}
 
 		ipAddr = network.IPAddress
 	}
@@ -1334,7 +1456,12 @@ func (i *Inspector) ProcessCollectedData() error {
 		return err
 	}
 
-	return seccomp.GenProfile(i.ImageInspector.ArtifactLocation, i.ImageInspector.SeccompProfileName)
+	err = seccomp.GenProfile(i.ImageInspector.ArtifactLocation, i.ImageInspector.SeccompProfileName)
+	if err != nil {
+		return err
+	}
+
+	return capabilities.GenProfile(i.ImageInspector.ArtifactLocation, i.ImageInspector.CapabilitiesName)
 }
 
 /////////////////////////////////////////////////////////////////////////////////
@@ -1401,3 +1528,14 @@ func attachContainerToNetwork(
 
 	return nil
 }
+
+// reportXattrs loads the extended attributes for the file artifacts from the container report
+func reportXattrs(creportPath string) map[string]map[string][]byte {
+	var creport report.ContainerReport
+	if err := fsutil.LoadStructFromFile(creportPath, &creport); err != nil {
+		log.Debugf("reportXattrs: error loading container report (%s) - %v", creportPath, err)
+		return nil
+	}
+
+	return creport.Image.Xattrs
+}
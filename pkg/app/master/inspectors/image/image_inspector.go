@@ -2,15 +2,19 @@ package image
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/docker-slim/docker-slim/pkg/app/master/cache"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/docker/registryauth"
+	"github.com/docker-slim/docker-slim/pkg/secretdiscover"
+	v "github.com/docker-slim/docker-slim/pkg/version"
 
 	docker "github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
@@ -20,9 +24,11 @@ const (
 	slimImageRepo          = "slim"
 	appArmorProfileName    = "apparmor-profile"
 	seccompProfileName     = "seccomp-profile"
+	capabilitiesReportName = "capabilities-report.json"
 	fatDockerfileName      = "Dockerfile.fat"
 	appArmorProfileNamePat = "%s-apparmor-profile"
 	seccompProfileNamePat  = "%s-seccomp.json"
+	capabilitiesNamePat    = "%s-capabilities.json"
 	https                  = "https://"
 	http                   = "http://"
 )
@@ -34,11 +40,18 @@ type Inspector struct {
 	SlimImageRepo       string
 	AppArmorProfileName string
 	SeccompProfileName  string
+	CapabilitiesName    string
 	ImageInfo           *docker.Image
 	ImageRecordInfo     docker.APIImages
 	APIClient           *docker.Client
 	//fatImageDockerInstructions []string
 	DockerfileInfo *reverse.Dockerfile
+	ResultCache    *cache.Store //nil if the analysis result cache is disabled
+	Remote         *RemoteImage //nil if the image is inspected using the Docker daemon
+	//PullProgress (optional) tracks the image layer downloads in Pull (ignored if the pull log is shown)
+	PullProgress PullProgressFunc
+	//RedactSecrets hides the secret values in the reverse engineered Dockerfile and the image stack
+	RedactSecrets bool
 }
 
 // NewInspector creates a new container image inspector
@@ -48,6 +61,7 @@ func NewInspector(client *docker.Client, imageRef string /*, artifactLocation st
 		SlimImageRepo:       slimImageRepo,
 		AppArmorProfileName: appArmorProfileName,
 		SeccompProfileName:  seccompProfileName,
+		CapabilitiesName:    capabilitiesReportName,
 		//ArtifactLocation:    artifactLocation,
 		APIClient: client,
 	}
@@ -90,7 +104,7 @@ func (i *Inspector) NoImage() bool {
 }
 
 // Pull tries to download the target image
-func (i *Inspector) Pull(showPullLog bool, dockerConfigPath, registryAccount, registrySecret string) error {
+func (i *Inspector) Pull(ctx context.Context, showPullLog bool, dockerConfigPath, registryAccount, registrySecret string) error {
 	var pullLog bytes.Buffer
 	var repo string
 	var tag string
@@ -106,16 +120,20 @@ func (i *Inspector) Pull(showPullLog bool, dockerConfigPath, registryAccount, re
 	input := docker.PullImageOptions{
 		Repository: repo,
 		Tag:        tag,
+		Context:    ctx,
 	}
 
 	if showPullLog {
 		input.OutputStream = &pullLog
+	} else if i.PullProgress != nil {
+		input.OutputStream = newPullProgressWriter(i.PullProgress)
+		input.RawJSONStream = true
 	}
 
 	var err error
 	var authConfig *docker.AuthConfiguration
 	registry := extractRegistry(repo)
-	authConfig, err = getRegistryCredential(registryAccount, registrySecret, dockerConfigPath, registry)
+	authConfig, err = getRegistryCredential(ctx, registryAccount, registrySecret, dockerConfigPath, registry)
 	if err != nil {
 		log.Warnf("image.inspector.Pull: failed to get registry credential for registry=%s with err=%v", registry, err)
 		//warn, attempt pull anyway, needs to work for public registries
@@ -140,7 +158,32 @@ func (i *Inspector) Pull(showPullLog bool, dockerConfigPath, registryAccount, re
 	return nil
 }
 
-func getRegistryCredential(registryAccount, registrySecret, dockerConfigPath, registry string) (cred *docker.AuthConfiguration, err error) {
+// getRegistryCredential returns the registry credential from the flags or the docker config
+// (the native cloud registry token flows are used if the docker config doesn't have a credential for the registry)
+func getRegistryCredential(ctx context.Context, registryAccount, registrySecret, dockerConfigPath, registry string) (*docker.AuthConfiguration, error) {
+	cred, err := dockerRegistryCredential(registryAccount, registrySecret, dockerConfigPath, registry)
+	if err == nil && cred != nil {
+		return cred, nil
+	}
+
+	auth, cerr := registryauth.CloudCredential(ctx, registry)
+	if cerr != nil {
+		log.Debugf("image.inspector: cloud registry credential error for registry=%s - %v", registry, cerr)
+		return nil, err
+	}
+
+	if auth == nil {
+		return cred, err
+	}
+
+	return &docker.AuthConfiguration{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		ServerAddress: registry,
+	}, nil
+}
+
+func dockerRegistryCredential(registryAccount, registrySecret, dockerConfigPath, registry string) (cred *docker.AuthConfiguration, err error) {
 	if registryAccount != "" && registrySecret != "" {
 		cred = &docker.AuthConfiguration{
 			Username: registryAccount,
@@ -151,7 +194,8 @@ func getRegistryCredential(registryAccount, registrySecret, dockerConfigPath, re
 
 	missingAuthConfigErr := errors.New(fmt.Sprintf("could not find an auth config for registry - %s", registry))
 	if dockerConfigPath != "" {
-		dAuthConfigs, err := docker.NewAuthConfigurationsFromFile(dockerConfigPath)
+		//the credential helpers and stores configured in the docker config are used too
+		auth, err := registryauth.ConfigCredential(dockerConfigPath, registry)
 		if err != nil {
 			log.Warnf(
 				"image.inspector.Pull: getDockerCredential - failed to acquire local docker config path=%s err=%s",
@@ -160,11 +204,15 @@ func getRegistryCredential(registryAccount, registrySecret, dockerConfigPath, re
 			)
 			return nil, err
 		}
-		r, found := dAuthConfigs.Configs[registry]
-		if !found {
+		if auth == nil {
 			return nil, missingAuthConfigErr
 		}
-		cred = &r
+		cred = &docker.AuthConfiguration{
+			Username:      auth.Username,
+			Password:      auth.Password,
+			IdentityToken: auth.IdentityToken,
+			RegistryToken: auth.RegistryToken,
+		}
 		return cred, nil
 	}
 
@@ -285,12 +333,15 @@ func (i *Inspector) processImageName() {
 			if nameParts := strings.Split(rtInfo[0], "/"); len(nameParts) > 1 {
 				i.AppArmorProfileName = strings.Join(nameParts, "-")
 				i.SeccompProfileName = strings.Join(nameParts, "-")
+				i.CapabilitiesName = strings.Join(nameParts, "-")
 			} else {
 				i.AppArmorProfileName = rtInfo[0]
 				i.SeccompProfileName = rtInfo[0]
+				i.CapabilitiesName = rtInfo[0]
 			}
 			i.AppArmorProfileName = fmt.Sprintf(appArmorProfileNamePat, i.AppArmorProfileName)
 			i.SeccompProfileName = fmt.Sprintf(seccompProfileNamePat, i.SeccompProfileName)
+			i.CapabilitiesName = fmt.Sprintf(capabilitiesNamePat, i.CapabilitiesName)
 		}
 	}
 }
@@ -300,15 +351,88 @@ func (i *Inspector) ProcessCollectedData() error {
 	i.processImageName()
 
 	var err error
-	i.DockerfileInfo, err = reverse.DockerfileFromHistory(i.APIClient, i.ImageRef)
+	i.DockerfileInfo, err = i.reverseDockerfile()
 	if err != nil {
 		return err
 	}
+
+	if i.RedactSecrets {
+		redactDockerfileSecrets(i.DockerfileInfo)
+	}
+
 	fatImageDockerfileLocation := filepath.Join(i.ArtifactLocation, fatDockerfileName)
-	err = reverse.SaveDockerfileData(fatImageDockerfileLocation, i.DockerfileInfo.Lines)
-	errutil.FailOn(err)
+	return reverse.SaveDockerfileData(fatImageDockerfileLocation, i.DockerfileInfo.Lines)
+}
 
-	return nil
+// redactDockerfileSecrets hides the secret values in the reverse engineered instructions
+// (the instruction list and the image stack share the instruction info objects)
+func redactDockerfileSecrets(info *reverse.Dockerfile) {
+	for idx, line := range info.Lines {
+		info.Lines[idx] = secretdiscover.RedactCommand(line)
+	}
+
+	redactInstruction := func(instInfo *reverse.InstructionInfo) {
+		redacted := secretdiscover.RedactCommand(instInfo.CommandAll)
+		if redacted == instInfo.CommandAll {
+			return
+		}
+
+		instInfo.CommandAll = redacted
+		instInfo.CommandSnippet = reverse.CommandSnippet(redacted)
+		for idx, cmd := range instInfo.SystemCommands {
+			instInfo.SystemCommands[idx] = secretdiscover.RedactCommand(cmd)
+		}
+	}
+
+	for _, instInfo := range info.AllInstructions {
+		redactInstruction(instInfo)
+	}
+
+	for _, imageInfo := range info.ImageStack {
+		for _, instInfo := range imageInfo.Instructions {
+			redactInstruction(instInfo)
+		}
+	}
+}
+
+func (i *Inspector) reverseDockerfile() (*reverse.Dockerfile, error) {
+	if i.ResultCache == nil || i.ImageInfo == nil {
+		return i.dockerfileFromHistory()
+	}
+
+	//the reverse engineered info depends only on the image and the app version
+	key, err := cache.ParamsKey(v.Current())
+	if err != nil {
+		return nil, err
+	}
+
+	var info reverse.Dockerfile
+	found, err := i.ResultCache.Load(i.ImageInfo.ID, cache.KindReverse, key, &info)
+	if err != nil {
+		log.Debugf("image.inspector.reverseDockerfile: error loading cached result - %v", err)
+	}
+
+	if found {
+		log.Debugf("image.inspector.reverseDockerfile: using cached result (image=%s key=%s)", i.ImageInfo.ID, key)
+		//the instruction objects are shared by the image stack and the full instruction list
+		info.AllInstructions = nil
+		for _, imageInfo := range info.ImageStack {
+			info.AllInstructions = append(info.AllInstructions, imageInfo.Instructions...)
+		}
+
+		return &info, nil
+	}
+
+	dockerfileInfo, err := i.dockerfileFromHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.ResultCache.Save(i.ImageInfo.ID, cache.KindReverse, key, dockerfileInfo); err != nil {
+		log.Debugf("image.inspector.reverseDockerfile: error saving result in cache - %v", err)
+	}
+
+	return dockerfileInfo, nil
 }
 
 // ShowFatImageDockerInstructions prints the original target image Dockerfile instructions
@@ -0,0 +1,93 @@
+package image
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PullProgressFunc is called with the total number of downloaded bytes for the image layers
+// (the total can grow while the pull is in progress because the layer sizes are discovered incrementally)
+type PullProgressFunc func(current, total int64)
+
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+}
+
+type layerPullState struct {
+	current int64
+	total   int64
+}
+
+// pullProgressWriter parses the raw JSON pull message stream from the Docker daemon
+type pullProgressWriter struct {
+	onProgress PullProgressFunc
+	buf        []byte
+	layers     map[string]*layerPullState
+}
+
+func newPullProgressWriter(onProgress PullProgressFunc) *pullProgressWriter {
+	return &pullProgressWriter{
+		onProgress: onProgress,
+		layers:     map[string]*layerPullState{},
+	}
+}
+
+func (w *pullProgressWriter) Write(data []byte) (int, error) {
+	w.buf = append(w.buf, data...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+
+		line := bytes.TrimSpace(w.buf[:idx])
+		w.buf = w.buf[idx+1:]
+		if len(line) > 0 {
+			w.process(line)
+		}
+	}
+
+	return len(data), nil
+}
+
+func (w *pullProgressWriter) process(line []byte) {
+	var msg pullMessage
+	if err := json.Unmarshal(line, &msg); err != nil || msg.ID == "" {
+		return
+	}
+
+	layer := w.layers[msg.ID]
+	switch msg.Status {
+	case "Downloading":
+		if layer == nil {
+			layer = &layerPullState{}
+			w.layers[msg.ID] = layer
+		}
+
+		layer.current = msg.ProgressDetail.Current
+		if msg.ProgressDetail.Total > 0 {
+			layer.total = msg.ProgressDetail.Total
+		}
+	case "Download complete", "Pull complete":
+		if layer == nil {
+			return
+		}
+
+		layer.current = layer.total
+	default:
+		return
+	}
+
+	var current, total int64
+	for _, info := range w.layers {
+		current += info.current
+		total += info.total
+	}
+
+	w.onProgress(current, total)
+}
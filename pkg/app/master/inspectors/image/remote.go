@@ -0,0 +1,398 @@
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	gocrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/docker/registryauth"
+	"github.com/docker-slim/docker-slim/pkg/docker/registryconfig"
+)
+
+const missingHistoryID = "<missing>"
+
+// ErrBadPlatform is returned if the remote image platform value is malformed
+var ErrBadPlatform = errors.New("bad platform (expected 'os/arch' or 'os/arch/variant')")
+
+// RemoteImage is the target image in a registry (inspected without the Docker daemon)
+type RemoteImage struct {
+	Ref        name.Reference
+	Digest     string
+	Image      gocrv1.Image
+	ConfigFile *gocrv1.ConfigFile
+	//compressed layer sizes (in the manifest layer order)
+	LayerSizes []int64
+}
+
+// RemoteLayerProgress is the remote image layer fetch progress info
+type RemoteLayerProgress struct {
+	Index  int
+	Total  int
+	Digest string
+	Size   int64
+}
+
+// ParsePlatform parses the 'os/arch[/variant]' platform value
+func ParsePlatform(value string) (*gocrv1.Platform, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, ErrBadPlatform
+	}
+
+	platform := &gocrv1.Platform{
+		OS:           parts[0],
+		Architecture: parts[1],
+	}
+
+	if len(parts) == 3 {
+		platform.Variant = parts[2]
+	}
+
+	return platform, nil
+}
+
+// InspectRemote fetches the target image manifest and config from the registry
+// (the image data is not pulled and the Docker daemon is not used)
+func (i *Inspector) InspectRemote(dockerConfigPath, registryAccount, registrySecret, platform string) error {
+	ref, err := name.ParseReference(i.ImageRef)
+	if err != nil {
+		return err
+	}
+
+	//the registry config CA bundles and proxy are used for the registry connections
+	options := []remote.Option{remote.WithTransport(registryconfig.Transport(false))}
+	keychainAuth := remote.WithAuthFromKeychain(registryauth.Keychain(context.Background(), dockerConfigPath))
+
+	pullRef := registryconfig.ResolvePullReference(ref, append(options, keychainAuth)...)
+	authConfig, err := getRegistryCredential(context.Background(), registryAccount, registrySecret, dockerConfigPath, extractRegistry(i.ImageRef))
+	switch {
+	case pullRef != ref:
+		//the registry mirrors use the Docker credentials (the registry credentials are not sent to the mirrors)
+		log.Debugf("image.inspector.InspectRemote: using registry mirror - %s", pullRef)
+		options = append(options, keychainAuth)
+	case err == nil && authConfig != nil:
+		options = append(options, remote.WithAuth(authn.FromConfig(authn.AuthConfig{
+			Username:      authConfig.Username,
+			Password:      authConfig.Password,
+			IdentityToken: authConfig.IdentityToken,
+			RegistryToken: authConfig.RegistryToken,
+		})))
+	default:
+		log.Debugf("image.inspector.InspectRemote: no registry credential (using the default keychain) - %v", err)
+		options = append(options, keychainAuth)
+	}
+
+	if platform != "" {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return err
+		}
+
+		options = append(options, remote.WithPlatform(*p))
+	}
+
+	img, err := remote.Image(pullRef, options...)
+	if err != nil {
+		return err
+	}
+
+	configName, err := img.ConfigName()
+	if err != nil {
+		return err
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return err
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	i.Remote = &RemoteImage{
+		Ref:        ref,
+		Digest:     digest.String(),
+		Image:      img,
+		ConfigFile: configFile,
+	}
+
+	var imageSize int64
+	for _, layer := range manifest.Layers {
+		i.Remote.LayerSizes = append(i.Remote.LayerSizes, layer.Size)
+		imageSize += layer.Size
+	}
+
+	//the image config has the same field names as the Docker API image config
+	var config docker.Config
+	configData, err := json.Marshal(&configFile.Config)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(configData, &config); err != nil {
+		return err
+	}
+
+	i.ImageInfo = &docker.Image{
+		ID:            configName.String(),
+		Created:       configFile.Created.Time,
+		DockerVersion: configFile.DockerVersion,
+		Author:        configFile.Author,
+		Config:        &config,
+		Architecture:  configFile.Architecture,
+		OS:            configFile.OS,
+		//the compressed image size (the image is not unpacked)
+		Size:        imageSize,
+		VirtualSize: imageSize,
+		RepoDigests: []string{fmt.Sprintf("%s@%s", ref.Context().Name(), digest.String())},
+	}
+
+	if _, ok := ref.(name.Tag); ok {
+		i.ImageInfo.RepoTags = []string{i.ImageRef}
+	}
+
+	i.ImageRecordInfo = docker.APIImages{
+		ID:          i.ImageInfo.ID,
+		RepoTags:    i.ImageInfo.RepoTags,
+		RepoDigests: i.ImageInfo.RepoDigests,
+		Created:     i.ImageInfo.Created.Unix(),
+		Size:        imageSize,
+		VirtualSize: imageSize,
+		Labels:      config.Labels,
+	}
+
+	log.Tracef("image.Inspector.InspectRemote: ImageInfo=%#v", i.ImageInfo)
+	return nil
+}
+
+// history returns the image history records in the Docker API format (newest first)
+func (ri *RemoteImage) history(imageID string, tags []string) []docker.ImageHistory {
+	var records []docker.ImageHistory
+	layerIdx := 0
+	for _, h := range ri.ConfigFile.History {
+		record := docker.ImageHistory{
+			ID:        missingHistoryID,
+			Created:   h.Created.Unix(),
+			CreatedBy: h.CreatedBy,
+			Comment:   h.Comment,
+		}
+
+		if !h.EmptyLayer {
+			if layerIdx < len(ri.LayerSizes) {
+				record.Size = ri.LayerSizes[layerIdx]
+			}
+
+			layerIdx++
+		}
+
+		records = append([]docker.ImageHistory{record}, records...)
+	}
+
+	if len(records) > 0 {
+		records[0].ID = imageID
+		records[0].Tags = tags
+	}
+
+	return records
+}
+
+func (i *Inspector) dockerfileFromHistory() (*reverse.Dockerfile, error) {
+	if i.Remote != nil {
+		return reverse.DockerfileFromHistoryData(i.Remote.history(i.ImageInfo.ID, i.ImageInfo.RepoTags))
+	}
+
+	return reverse.DockerfileFromHistory(i.APIClient, i.ImageRef)
+}
+
+// SaveRemoteImage fetches the remote image layers and saves them in an image archive
+// (in the 'docker save' format without going through the Docker daemon)
+func (i *Inspector) SaveRemoteImage(archivePath string, onLayer func(progress *RemoteLayerProgress)) error {
+	if i.Remote == nil {
+		return errors.New("no remote image")
+	}
+
+	layers, err := i.Remote.Image.Layers()
+	if err != nil {
+		return err
+	}
+
+	rawConfig, err := i.Remote.Image.RawConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return err
+	}
+
+	afile, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer afile.Close()
+	tw := tar.NewWriter(afile)
+
+	manifest := dockerimage.ManifestObject{
+		Config:   fmt.Sprintf("%s.json", dockerutil.CleanImageID(i.ImageInfo.ID)),
+		RepoTags: i.ImageInfo.RepoTags,
+	}
+
+	if err := writeTarData(tw, manifest.Config, rawConfig); err != nil {
+		return err
+	}
+
+	//the layer directories are named using the layer chain IDs (unique for each layer position)
+	var chainID string
+	savedLayers := map[string]string{}
+	for idx, layer := range layers {
+		diffID, err := layer.DiffID()
+		if err != nil {
+			return err
+		}
+
+		if chainID == "" {
+			chainID = diffID.Hex
+		} else {
+			chainID = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("sha256:%s sha256:%s", chainID, diffID.Hex))))
+		}
+
+		layerPath := fmt.Sprintf("%s/layer.tar", chainID)
+		manifest.Layers = append(manifest.Layers, layerPath)
+
+		if onLayer != nil {
+			progress := &RemoteLayerProgress{
+				Index: idx,
+				Total: len(layers),
+			}
+
+			if digest, err := layer.Digest(); err == nil {
+				progress.Digest = digest.String()
+			}
+
+			if size, err := layer.Size(); err == nil {
+				progress.Size = size
+			}
+
+			onLayer(progress)
+		}
+
+		//the same layer data is saved only once (the other layers link to it like in 'docker save')
+		if srcPath, found := savedLayers[diffID.String()]; found {
+			hdr := &tar.Header{
+				Name:     layerPath,
+				Typeflag: tar.TypeSymlink,
+				Linkname: fmt.Sprintf("../%s", srcPath),
+				Mode:     0644,
+			}
+
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := writeRemoteLayer(tw, layerPath, layer, filepath.Dir(archivePath)); err != nil {
+			return err
+		}
+
+		savedLayers[diffID.String()] = layerPath
+	}
+
+	manifestData, err := json.Marshal([]dockerimage.ManifestObject{manifest})
+	if err != nil {
+		return err
+	}
+
+	if err := writeTarData(tw, "manifest.json", manifestData); err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// writeRemoteLayer unpacks the layer blob to a temporary file (to get its size for the tar header)
+// and copies it to the image archive
+func writeRemoteLayer(tw *tar.Writer, layerPath string, layer gocrv1.Layer, tmpDir string) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	tmpFile, err := ioutil.TempFile(tmpDir, "layer.*.tar")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}()
+
+	size, err := io.Copy(tmpFile, rc)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:     layerPath,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     size,
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, tmpFile)
+	return err
+}
+
+func writeTarData(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(data)),
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+	return err
+}
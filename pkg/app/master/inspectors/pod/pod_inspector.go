@@ -18,6 +18,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/sensor"
 	"github.com/docker-slim/docker-slim/pkg/app/master/kubernetes"
 	"github.com/docker-slim/docker-slim/pkg/app/master/security/apparmor"
+	"github.com/docker-slim/docker-slim/pkg/app/master/security/capabilities"
 	"github.com/docker-slim/docker-slim/pkg/app/master/security/seccomp"
 	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
@@ -180,7 +181,11 @@ func (i *Inspector) RunPod() error {
 		return err
 	}
 
-	localSensorPath := sensor.EnsureLocalBinary(i.xc, i.logger, i.statePath, true)
+	localSensorPath, err := sensor.EnsureLocalBinary(i.xc, i.logger, i.statePath, true)
+	if err != nil {
+		return err
+	}
+
 	i.logger.Debugf("RunPod: detected sensor at %q", localSensorPath)
 
 	if err := i.injectSensor(localSensorPath); err != nil {
@@ -296,7 +301,12 @@ func (i *Inspector) ProcessCollectedData() error {
 		return err
 	}
 
-	return seccomp.GenProfile(i.imageInspector.ArtifactLocation, i.imageInspector.SeccompProfileName)
+	err = seccomp.GenProfile(i.imageInspector.ArtifactLocation, i.imageInspector.SeccompProfileName)
+	if err != nil {
+		return err
+	}
+
+	return capabilities.GenProfile(i.imageInspector.ArtifactLocation, i.imageInspector.CapabilitiesName)
 }
 
 func (i *Inspector) Exec(cmd string, args ...string) ([]byte, error) {
@@ -398,10 +408,10 @@ func (i *Inspector) publishPorts() error {
 	if len(i.portBindings) > 0 {
 		for contPort, hostPorts := range i.portBindings {
 			if contPort.Port() == toStringPort(channel.CmdPort) {
-				i.exitIPCPortConflict(hostPorts, "cmd", -126)
+				return i.exitIPCPortConflict(hostPorts, "cmd", sensor.ExitCodeCmdPortConflict)
 			}
 			if contPort.Port() == toStringPort(channel.EvtPort) {
-				i.exitIPCPortConflict(hostPorts, "evt", -127)
+				return i.exitIPCPortConflict(hostPorts, "evt", sensor.ExitCodeEvtPortConflict)
 			}
 			toPublish[contPort] = hostPorts
 		}
@@ -470,7 +480,7 @@ func (i *Inspector) publishPorts() error {
 	return nil
 }
 
-func (i *Inspector) exitIPCPortConflict(port []dockerapi.PortBinding, typ string, code int) {
+func (i *Inspector) exitIPCPortConflict(port []dockerapi.PortBinding, typ string, code int) error {
 	i.logger.Errorf("RunPod: port bindings comms port conflict (%s) = %#v", typ, port)
 
 	i.xc.Out.Info("sensor.error",
@@ -486,7 +496,7 @@ func (i *Inspector) exitIPCPortConflict(port []dockerapi.PortBinding, typ string
 			"version":   v.Current(),
 		})
 
-	i.xc.Exit(code)
+	return app.NewExitError(code)
 }
 
 func (i *Inspector) injectSensor(localSensorPath string) error {
@@ -556,9 +566,10 @@ func (i *Inspector) sensorConnect() error {
 
 func (i *Inspector) sensorCommandStart() error {
 	cmd := &command.StartMonitor{
-		RTASourcePT: i.rtaSourcePT,
-		AppName:     i.fatContainerCmd[0],
-		KeepPerms:   i.keepPerms,
+		ProtocolVersion: command.ProtocolVersion,
+		RTASourcePT:     i.rtaSourcePT,
+		AppName:         i.fatContainerCmd[0],
+		KeepPerms:       i.keepPerms,
 	}
 	if len(i.fatContainerCmd) > 1 {
 		cmd.AppArgs = i.fatContainerCmd[1:]
@@ -618,7 +629,12 @@ func (i *Inspector) sensorCommandStart() error {
 
 	// cmd.IncludeNodePackages = i.appNodejsInspectOpts.IncludePackages
 
-	if _, err := i.sensorIPCClient.SendCommand(cmd); err != nil {
+	resp, err := i.sensorIPCClient.SendCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := sensor.CheckProtocol(i.xc, i.logger, resp, true); err != nil {
 		return err
 	}
 
@@ -0,0 +1,283 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/oauth2"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+// probeAuth contains the client authentication settings applied to all probe calls
+type probeAuth struct {
+	tlsConfig *tls.Config
+	headers   http.Header
+	//OAuth2 access tokens (refreshed when they expire)
+	tokens oauth2.TokenSource
+}
+
+func newProbeAuth(opts config.HTTPProbeOptions) (*probeAuth, error) {
+	auth := &probeAuth{
+		headers: http.Header{},
+	}
+
+	for _, hline := range opts.Headers {
+		hparts := strings.SplitN(hline, ":", 2)
+		if len(hparts) != 2 {
+			log.Debugf("http.newProbeAuth - ignoring malformed header (%v)", hline)
+			continue
+		}
+
+		auth.headers.Add(strings.TrimSpace(hparts[0]), strings.TrimSpace(hparts[1]))
+	}
+
+	if opts.ClientCert != "" || opts.CACert != "" {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: true,
+		}
+
+		if opts.ClientCert != "" {
+			keyFile := opts.ClientKey
+			if keyFile == "" {
+				//the key can be in the same PEM file
+				keyFile = opts.ClientCert
+			}
+
+			cert, err := tls.LoadX509KeyPair(opts.ClientCert, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("error loading client certificate - %v", err)
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		if opts.CACert != "" {
+			caData, err := ioutil.ReadFile(opts.CACert)
+			if err != nil {
+				return nil, fmt.Errorf("error loading CA certificate - %v", err)
+			}
+
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caData) {
+				return nil, fmt.Errorf("no CA certificates in %s", opts.CACert)
+			}
+
+			tlsConfig.RootCAs = pool
+			tlsConfig.InsecureSkipVerify = false
+		}
+
+		auth.tlsConfig = tlsConfig
+	}
+
+	return auth, nil
+}
+
+func (a *probeAuth) enabled() bool {
+	return a != nil && (a.tlsConfig != nil || len(a.headers) > 0 || a.tokens != nil)
+}
+
+// initOAuth2 gets the first access token using the OAuth2 client credentials flow
+// (the probe calls get a new token when it expires)
+func (a *probeAuth) initOAuth2(info *config.OAuth2ClientCredentials) error {
+	if info == nil || info.TokenURL == "" {
+		return nil
+	}
+
+	tokens := oauth2.ReuseTokenSource(nil, &clientCredentialsSource{auth: a, info: info})
+	if _, err := tokens.Token(); err != nil {
+		return err
+	}
+
+	a.tokens = tokens
+	return nil
+}
+
+// clientCredentialsSource gets the access tokens from the token endpoint
+type clientCredentialsSource struct {
+	auth *probeAuth
+	info *config.OAuth2ClientCredentials
+}
+
+func (s *clientCredentialsSource) Token() (*oauth2.Token, error) {
+	info := s.info
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(info.Scopes) > 0 {
+		form.Set("scope", strings.Join(info.Scopes, " "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, info.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", mimeForm)
+	req.Header.Set("Accept", mimeJSON)
+	req.SetBasicAuth(url.QueryEscape(info.ClientID), url.QueryEscape(info.ClientSecret))
+
+	//only the client certificates are used with the token endpoint (not the extra headers)
+	client := getHTTP1Client()
+	s.auth.applyTLS(client)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected token endpoint status code - %d", res.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, err
+	}
+
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in the token endpoint response")
+	}
+
+	result := &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+	}
+
+	if token.ExpiresIn > 0 {
+		result.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	log.Debugf("http.clientCredentialsSource.Token - new access token (expiry=%v)", result.Expiry)
+	return result, nil
+}
+
+// apply configures the client to use the client certificates
+// and to add the extra headers to all requests
+func (a *probeAuth) apply(client *http.Client) *http.Client {
+	if a == nil || client == nil {
+		return client
+	}
+
+	a.applyTLS(client)
+
+	if len(a.headers) > 0 || a.tokens != nil {
+		client.Transport = &headerTransport{
+			base:    client.Transport,
+			headers: a.headers,
+			tokens:  a.tokens,
+		}
+	}
+
+	return client
+}
+
+// applyHeaders adds the extra headers and the current access token
+// to the headers that are not set yet (used for the websocket handshakes)
+func (a *probeAuth) applyHeaders(headers http.Header) error {
+	if a == nil {
+		return nil
+	}
+
+	for name, values := range a.headers {
+		if headers.Get(name) == "" {
+			headers[name] = values
+		}
+	}
+
+	if a.tokens != nil && headers.Get("Authorization") == "" {
+		token, err := a.tokens.Token()
+		if err != nil {
+			return err
+		}
+
+		headers.Set("Authorization", token.Type()+" "+token.AccessToken)
+	}
+
+	return nil
+}
+
+// wsDialer creates a websocket dialer that uses the client certificates
+func (a *probeAuth) wsDialer() *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+	if a != nil && a.tlsConfig != nil {
+		dialer.TLSClientConfig = a.tlsConfig.Clone()
+	}
+
+	return &dialer
+}
+
+func (a *probeAuth) applyTLS(client *http.Client) {
+	if a.tlsConfig == nil {
+		return
+	}
+
+	switch transport := client.Transport.(type) {
+	case *http.Transport:
+		transport.TLSClientConfig = a.tlsConfig.Clone()
+	case *http2.Transport:
+		transport.TLSClientConfig = a.tlsConfig.Clone()
+	}
+}
+
+// headerTransport adds the configured headers and the access token to the requests
+// (the headers set on the request take precedence)
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+	tokens  oauth2.TokenSource
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		if req.Header.Get(name) != "" {
+			continue
+		}
+
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if t.tokens != nil && req.Header.Get("Authorization") == "" {
+		token, err := t.tokens.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		token.SetAuthHeader(req)
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(req)
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 	"net/http/cookiejar"
+	"regexp"
 	"strings"
 	"time"
 
@@ -22,9 +23,9 @@ const (
 func (p *CustomProbe) crawl(proto, domain, addr string) {
 
 	var httpClient *http.Client
-	if strings.HasPrefix(proto, config.ProtoHTTP2) {
+	if strings.HasPrefix(proto, config.ProtoHTTP2) || p.auth.enabled() {
 		var err error
-		if httpClient, err = getHTTPClient(proto); err != nil {
+		if httpClient, err = p.newHTTPClient(proto); err != nil {
 			p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
 			return
 		}
@@ -98,24 +99,26 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 			e.Request.Visit(e.Attr("href"))
 		})
 
-		c.OnHTML("script[src], source[src], img[src]", func(e *colly.HTMLElement) {
+		c.OnHTML("script[src], source[src], img[src], iframe[src], embed[src], video[src], audio[src], track[src]", func(e *colly.HTMLElement) {
 			if p.opts.CrawlMaxPageCount > 0 &&
 				pageCount > p.opts.CrawlMaxPageCount {
-				log.Debugf("http.CustomProbe.crawl.OnHTML(script/source/img) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+				log.Debugf("http.CustomProbe.crawl.OnHTML([src]) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
 				return
 			}
 
 			e.Request.Visit(e.Attr("src"))
 		})
 
-		c.OnHTML("source[srcset]", func(e *colly.HTMLElement) {
+		c.OnHTML("source[srcset], img[srcset]", func(e *colly.HTMLElement) {
 			if p.opts.CrawlMaxPageCount > 0 &&
 				pageCount > p.opts.CrawlMaxPageCount {
-				log.Debugf("http.CustomProbe.crawl.OnHTML(source[srcset]) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+				log.Debugf("http.CustomProbe.crawl.OnHTML([srcset]) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
 				return
 			}
 
-			e.Request.Visit(e.Attr("srcset"))
+			for _, src := range parseSrcset(e.Attr("srcset")) {
+				e.Request.Visit(src)
+			}
 		})
 
 		c.OnHTML("[data-src]", func(e *colly.HTMLElement) {
@@ -128,6 +131,53 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 			e.Request.Visit(e.Attr("data-src"))
 		})
 
+		c.OnHTML("video[poster], object[data]", func(e *colly.HTMLElement) {
+			if p.opts.CrawlMaxPageCount > 0 &&
+				pageCount > p.opts.CrawlMaxPageCount {
+				log.Debugf("http.CustomProbe.crawl.OnHTML(poster/data) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+				return
+			}
+
+			e.Request.Visit(e.Attr("poster"))
+			e.Request.Visit(e.Attr("data"))
+		})
+
+		c.OnHTML("style", func(e *colly.HTMLElement) {
+			for _, ref := range cssAssetRefs([]byte(e.Text)) {
+				if p.opts.CrawlMaxPageCount > 0 &&
+					pageCount > p.opts.CrawlMaxPageCount {
+					log.Debugf("http.CustomProbe.crawl.OnHTML(style) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+					return
+				}
+
+				e.Request.Visit(ref)
+			}
+		})
+
+		//the assets and the lazy loaded code chunks referenced in the CSS and JS files
+		c.OnResponse(func(r *colly.Response) {
+			var refs []string
+			ctype := r.Headers.Get("Content-Type")
+			switch {
+			case strings.Contains(ctype, "css"):
+				refs = cssAssetRefs(r.Body)
+			case strings.Contains(ctype, "javascript"):
+				refs = jsAssetRefs(r.Body)
+			default:
+				return
+			}
+
+			for _, ref := range refs {
+				if p.opts.CrawlMaxPageCount > 0 &&
+					pageCount > p.opts.CrawlMaxPageCount {
+					log.Debugf("http.CustomProbe.crawl.OnResponse - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+					return
+				}
+
+				r.Request.Visit(ref)
+			}
+		})
+
 		c.OnRequest(func(r *colly.Request) {
 			p.xc.Out.Info("http.probe.crawler",
 				ovars{
@@ -162,3 +212,55 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 			})
 	}()
 }
+
+var (
+	cssURLPat    = regexp.MustCompile(`url\(\s*['"]?([^'")\s]+)['"]?\s*\)`)
+	cssImportPat = regexp.MustCompile(`@import\s+['"]([^'"]+)['"]`)
+	jsAssetPat   = regexp.MustCompile(`["'\x60]((?:\.{1,2})?/[^"'\x60\s<>{}]+?\.(?:js|mjs|css|json|wasm|html|png|jpe?g|gif|svg|webp|ico|woff2?|ttf|eot))["'\x60]`)
+)
+
+func cssAssetRefs(data []byte) []string {
+	var refs []string
+	for _, pat := range []*regexp.Regexp{cssURLPat, cssImportPat} {
+		for _, match := range pat.FindAllSubmatch(data, -1) {
+			ref := string(match[1])
+			if strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+				continue
+			}
+
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// jsAssetRefs returns the absolute and relative paths to assets in JS code
+// (primitive, but good enough for the lazy loaded chunks with static paths)
+func jsAssetRefs(data []byte) []string {
+	var refs []string
+	for _, match := range jsAssetPat.FindAllSubmatch(data, -1) {
+		ref := string(match[1])
+		if strings.HasPrefix(ref, "//") {
+			//protocol relative URLs are for other domains
+			continue
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
+
+// parseSrcset returns the image candidate URLs from a srcset attribute value
+func parseSrcset(value string) []string {
+	var srcs []string
+	for _, candidate := range strings.Split(value, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 {
+			srcs = append(srcs, fields[0])
+		}
+	}
+
+	return srcs
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -11,15 +12,20 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	dockerapi "github.com/fsouza/go-dockerclient"
+	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/container"
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/pod"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/trace"
 )
 
 const (
@@ -28,6 +34,8 @@ const (
 	defaultHTTPPortStr    = "80"
 	defaultHTTPSPortStr   = "443"
 	defaultFastCGIPortStr = "9000"
+
+	defaultBaseStartWait = 9 * time.Second
 )
 
 type ovars = app.OutVars
@@ -39,16 +47,35 @@ type CustomProbe struct {
 	opts config.HTTPProbeOptions
 
 	ports      []string
+	udpPorts   []string
 	targetHost string
 
 	APISpecProbes []apiSpecInfo
 
+	auth *probeAuth
+
 	printState bool
 
+	//the time to wait for the target app to start (before probing)
+	baseStartWait time.Duration
+	readyTarget   readyTarget
+
+	//called after each probe round (one round for each probed port)
+	roundHook func(round int)
+	//called for each probe command HTTP call
+	responseHook ResponseHook
+
 	CallCount uint64
 	ErrCount  uint64
 	OkCount   uint64
 
+	//probe command outcomes (a command is ok if it succeeds on any of the probed ports)
+	CmdCount     uint64
+	CmdFailCount uint64
+
+	stats       probeStats
+	rateLimiter *rate.Limiter
+
 	doneChan           chan struct{}
 	workers            sync.WaitGroup
 	concurrentCrawlers chan struct{}
@@ -61,13 +88,23 @@ func NewContainerProbe(
 	opts config.HTTPProbeOptions,
 	printState bool,
 ) (*CustomProbe, error) {
-	probe := newCustomProbe(xc, inspector.TargetHost, opts, printState)
+	probe, err := newCustomProbe(xc, inspector.TargetHost, opts, printState)
+	if err != nil {
+		return nil, err
+	}
+
+	probe.readyTarget.apiClient = inspector.APIClient
+	probe.readyTarget.containerID = inspector.ContainerID
 
 	availableHostPorts := map[string]string{}
 	for nsPortKey, nsPortData := range inspector.AvailablePorts {
 		log.Debugf("HTTP probe - target's network port key='%s' data='%#v'", nsPortKey, nsPortData)
 
 		if nsPortKey.Proto() != "tcp" {
+			if nsPortKey.Proto() == "udp" && nsPortData.HostPort != "" {
+				probe.addUDPPort(nsPortKey.Port(), nsPortData.HostPort, inspector.SensorIPCMode == container.SensorIPCModeDirect)
+			}
+
 			log.Debugf("HTTP probe - skipping non-tcp port => %v", nsPortKey)
 			continue
 		}
@@ -78,6 +115,11 @@ func NewContainerProbe(
 		}
 
 		availableHostPorts[nsPortData.HostPort] = nsPortKey.Port()
+		if inspector.SensorIPCMode == container.SensorIPCModeDirect {
+			probe.readyTarget.ports[nsPortKey.Port()] = nsPortKey.Port()
+		} else {
+			probe.readyTarget.ports[nsPortKey.Port()] = nsPortData.HostPort
+		}
 	}
 
 	log.Debugf("HTTP probe - available host ports => %+v", availableHostPorts)
@@ -147,18 +189,53 @@ func NewContainerProbe(
 	return probe, nil
 }
 
+// NewEndpointProbe creates a new custom HTTP probe for an already running target
+func NewEndpointProbe(
+	xc *app.ExecutionContext,
+	targetHost string,
+	ports []string,
+	udpPorts []string,
+	opts config.HTTPProbeOptions,
+	printState bool,
+) (*CustomProbe, error) {
+	probe, err := newCustomProbe(xc, targetHost, opts, printState)
+	if err != nil {
+		return nil, err
+	}
+
+	//the target is expected to be ready
+	probe.baseStartWait = 0
+	probe.ports = ports
+	probe.udpPorts = udpPorts
+
+	if len(probe.opts.APISpecFiles) > 0 {
+		probe.loadAPISpecFiles()
+	}
+
+	return probe, nil
+}
+
 func NewPodProbe(
 	xc *app.ExecutionContext,
 	inspector *pod.Inspector,
 	opts config.HTTPProbeOptions,
 	printState bool,
 ) (*CustomProbe, error) {
-	probe := newCustomProbe(xc, inspector.TargetHost(), opts, printState)
+	probe, err := newCustomProbe(xc, inspector.TargetHost(), opts, printState)
+	if err != nil {
+		return nil, err
+	}
 
 	availableHostPorts := map[string]string{}
 	for nsPortKey, nsPortData := range inspector.AvailablePorts() {
 		log.Debugf("HTTP probe - target's network port key='%s' data='%#v'", nsPortKey, nsPortData)
+		if nsPortKey.Proto() == "udp" {
+			probe.addUDPPort(nsPortKey.Port(), nsPortData.HostPort, false)
+			continue
+		}
+
 		availableHostPorts[nsPortData.HostPort] = nsPortKey.Port()
+		probe.readyTarget.ports[nsPortKey.Port()] = nsPortData.HostPort
 	}
 
 	log.Debugf("HTTP probe - available host ports => %+v", availableHostPorts)
@@ -194,7 +271,7 @@ func newCustomProbe(
 	targetHost string,
 	opts config.HTTPProbeOptions,
 	printState bool,
-) *CustomProbe {
+) (*CustomProbe, error) {
 	//note: the default probe should already be there if the user asked for it
 
 	//-1 means disabled
@@ -217,25 +294,108 @@ func newCustomProbe(
 		opts.CrawlConcurrencyMax = defaultMaxConcurrentCrawlers
 	}
 
+	auth, err := newProbeAuth(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	probe := &CustomProbe{
-		xc:         xc,
-		opts:       opts,
-		printState: printState,
-		targetHost: targetHost,
-		doneChan:   make(chan struct{}),
+		xc:            xc,
+		opts:          opts,
+		auth:          auth,
+		printState:    printState,
+		targetHost:    targetHost,
+		baseStartWait: defaultBaseStartWait,
+		readyTarget:   readyTarget{ports: map[string]string{}},
+		doneChan:      make(chan struct{}),
 	}
 
 	if opts.CrawlConcurrencyMax > 0 {
 		probe.concurrentCrawlers = make(chan struct{}, opts.CrawlConcurrencyMax)
 	}
 
-	return probe
+	if opts.RateLimit > 0 {
+		probe.rateLimiter = rate.NewLimiter(rate.Limit(opts.RateLimit), 1)
+	}
+
+	return probe, nil
+}
+
+// SetRoundHook sets the function called after each probe round
+// (needs to be set before the probe is started)
+func (p *CustomProbe) SetRoundHook(hook func(round int)) {
+	p.roundHook = hook
+}
+
+// SetTrace sets the timeline recorder for the probe calls
+// (needs to be set before the probe is started)
+func (p *CustomProbe) SetTrace(recorder *trace.Recorder) {
+	p.stats.trace = recorder
+}
+
+// ProbeResponse contains the probe command HTTP call response info
+type ProbeResponse struct {
+	Method     string
+	Protocol   string
+	Resource   string
+	Attempt    int
+	StatusCode int
+	Header     http.Header
+	//the response body (up to 1MB)
+	Body  []byte
+	Error string
+}
+
+// ResponseHook is a function called with the probe command HTTP call response info
+type ResponseHook func(response *ProbeResponse)
+
+// SetResponseHook sets the function called for each probe command HTTP call
+// (needs to be set before the probe is started)
+func (p *CustomProbe) SetResponseHook(hook ResponseHook) {
+	p.responseHook = hook
 }
 
 func (p *CustomProbe) Ports() []string {
 	return p.ports
 }
 
+// UDPPorts returns the UDP ports used by the 'udp' probe commands
+func (p *CustomProbe) UDPPorts() []string {
+	return p.udpPorts
+}
+
+func (p *CustomProbe) addUDPPort(containerPort, hostPort string, direct bool) {
+	if len(p.opts.Ports) > 0 {
+		var found bool
+		for _, pnum := range p.opts.Ports {
+			if fmt.Sprintf("%d", pnum) == containerPort {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			log.Debugf("HTTP probe - ignoring udp port => %v", containerPort)
+			return
+		}
+	}
+
+	if direct {
+		p.udpPorts = append(p.udpPorts, containerPort)
+	} else if hostPort != "" {
+		p.udpPorts = append(p.udpPorts, hostPort)
+	}
+}
+
+func (p *CustomProbe) newHTTPClient(proto string) (*http.Client, error) {
+	client, err := getHTTPClient(proto)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.auth.apply(client), nil
+}
+
 // Start starts the HTTP probe instance execution
 func (p *CustomProbe) Start() {
 	if p.printState {
@@ -246,8 +406,14 @@ func (p *CustomProbe) Start() {
 	}
 
 	go func() {
-		//TODO: need to do a better job figuring out if the target app is ready to accept connections
-		time.Sleep(9 * time.Second) //base start wait time
+		if len(p.opts.ReadyWhen) > 0 {
+			//the readiness conditions replace the base start wait time
+			p.waitForReady()
+		} else {
+			//TODO: need to do a better job figuring out if the target app is ready to accept connections
+			time.Sleep(p.baseStartWait)
+		}
+
 		if p.opts.StartWait > 0 {
 			if p.printState {
 				p.xc.Out.State("http.probe.start.wait", ovars{"time": p.opts.StartWait})
@@ -267,6 +433,16 @@ func (p *CustomProbe) Start() {
 
 		log.Info("HTTP probe started...")
 
+		if p.opts.OAuth2 != nil {
+			if err := p.auth.initOAuth2(p.opts.OAuth2); err != nil {
+				p.xc.Out.Info("http.probe.oauth2.error",
+					ovars{
+						"message": "error getting oauth2 access token",
+						"error":   err,
+					})
+			}
+		}
+
 		findIdx := func(ports []string, target string) int {
 			for idx, val := range ports {
 				if val == target {
@@ -311,281 +487,635 @@ func (p *CustomProbe) Start() {
 				})
 		}
 
+		cmdOk := make([]bool, len(p.opts.Cmds))
+		var probedCmds bool
+		var round int
 		for _, port := range p.ports {
 			//If it's ok stop after the first successful probe pass
-			if p.OkCount > 0 && !p.opts.Full {
+			if atomic.LoadUint64(&p.OkCount) > 0 && !p.opts.Full {
 				break
 			}
 
-			for _, cmd := range p.opts.Cmds {
-				var reqBody io.Reader
-				var rbSeeker io.Seeker
+			round++
+			probedCmds = true
+			p.probeCmds(port, cmdOk)
 
-				if cmd.BodyFile != "" {
-					_, err := os.Stat(cmd.BodyFile)
-					if err != nil {
-						log.Errorf("http.probe - cmd.BodyFile (%s) check error: %v", cmd.BodyFile, err)
-					} else {
-						bodyFile, err := os.Open(cmd.BodyFile)
-						if err != nil {
-							log.Errorf("http.probe - cmd.BodyFile (%s) read error: %v", cmd.BodyFile, err)
-						} else {
-							reqBody = bodyFile
-							rbSeeker = bodyFile
-							//the file will be closed only when the function exits
-							defer bodyFile.Close()
-						}
-					}
-				} else {
-					strBody := strings.NewReader(cmd.Body)
-					reqBody = strBody
-					rbSeeker = strBody
+			if p.roundHook != nil {
+				p.roundHook(round)
+			}
+		}
+
+		for cmdIdx, cmd := range p.opts.Cmds {
+			if !strings.EqualFold(cmd.Protocol, config.ProtoUDP) {
+				continue
+			}
+
+			for _, port := range p.udpPorts {
+				probedCmds = true
+				if p.probeUDP(port, cmd) {
+					cmdOk[cmdIdx] = true
 				}
+			}
+		}
+
+		if probedCmds {
+			p.CmdCount = uint64(len(cmdOk))
+			for _, ok := range cmdOk {
+				if !ok {
+					p.CmdFailCount++
+				}
+			}
+		}
+
+		log.Info("HTTP probe done.")
+
+		if p.printState {
+			p.xc.Out.Info("http.probe.summary",
+				ovars{
+					"total":           p.CallCount,
+					"failures":        p.ErrCount,
+					"successful":      p.OkCount,
+					"commands":        p.CmdCount,
+					"failed.commands": p.CmdFailCount,
+				})
+
+			outVars := ovars{}
+			//warning := ""
+			switch {
+			case p.CallCount == 0:
+				outVars["warning"] = "no.calls"
+				//warning = "warning=no.calls"
+			case p.OkCount == 0:
+				//warning = "warning=no.successful.calls"
+				outVars["warning"] = "no.successful.calls"
+			case p.FailureThresholdExceeded():
+				outVars["warning"] = "failure.threshold.exceeded"
+			}
+
+			p.xc.Out.State("http.probe.done", outVars)
+		}
+
+		p.workers.Wait()
+		close(p.doneChan)
+	}()
+}
+
+// probeCmds executes the probe commands for the target port
+// using the configured number of concurrent workers
+func (p *CustomProbe) probeCmds(port string, cmdOk []bool) {
+	workerCount := p.opts.Concurrency
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	if workerCount > len(p.opts.Cmds) {
+		workerCount = len(p.opts.Cmds)
+	}
+
+	cmdIdxCh := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range cmdIdxCh {
+				if p.probeCmd(port, p.opts.Cmds[idx]) {
+					cmdOk[idx] = true
+				}
+			}
+		}()
+	}
+
+	for idx := range p.opts.Cmds {
+		cmdIdxCh <- idx
+	}
+
+	close(cmdIdxCh)
+	wg.Wait()
+}
 
-				// TODO: need a smarter and more dynamic way to determine the actual protocol type
+// probeCmd executes the probe command for the target port
+// and returns true if the command succeeds with any of the protocols
+func (p *CustomProbe) probeCmd(port string, cmd config.HTTPProbeCmd) bool {
+	if strings.EqualFold(cmd.Protocol, config.ProtoUDP) {
+		//the 'udp' commands use the UDP ports
+		return false
+	}
+
+	var ok bool
+	expect, err := newProbeExpectation(cmd)
+	if err != nil {
+		p.xc.Out.Error("HTTP probe - bad expectation - %v", err.Error())
+	}
+
+	var reqBody io.Reader
+	var rbSeeker io.Seeker
+
+	if cmd.BodyFile != "" {
+		_, err := os.Stat(cmd.BodyFile)
+		if err != nil {
+			log.Errorf("http.probe - cmd.BodyFile (%s) check error: %v", cmd.BodyFile, err)
+		} else {
+			bodyFile, err := os.Open(cmd.BodyFile)
+			if err != nil {
+				log.Errorf("http.probe - cmd.BodyFile (%s) read error: %v", cmd.BodyFile, err)
+			} else {
+				reqBody = bodyFile
+				rbSeeker = bodyFile
+				//the file will be closed only when the function exits
+				defer bodyFile.Close()
+			}
+		}
+	} else {
+		strBody := strings.NewReader(cmd.Body)
+		reqBody = strBody
+		rbSeeker = strBody
+	}
+
+	// TODO: need a smarter and more dynamic way to determine the actual protocol type
+
+	// Set up FastCGI defaults if the default CGI port is used without a FastCGI config.
+	if port == defaultFastCGIPortStr && cmd.FastCGI == nil {
+		log.Debugf("HTTP probe - FastCGI default port (%s) used, setting up HTTP probe FastCGI wrapper defaults", port)
+
+		// Typicall the entrypoint into a PHP app.
+		if cmd.Resource == "/" {
+			cmd.Resource = "/index.php"
+		}
+
+		// SplitPath is typically on the first .php path element.
+		var splitPath []string
+		if phpIdx := strings.Index(cmd.Resource, ".php"); phpIdx != -1 {
+			splitPath = []string{cmd.Resource[:phpIdx+4]}
+		}
+
+		cmd.FastCGI = &config.FastCGIProbeWrapperConfig{
+			// /var/www is a typical root for PHP indices.
+			Root:      "/var/www",
+			SplitPath: splitPath,
+		}
+	}
 
-				// Set up FastCGI defaults if the default CGI port is used without a FastCGI config.
-				if port == defaultFastCGIPortStr && cmd.FastCGI == nil {
-					log.Debugf("HTTP probe - FastCGI default port (%s) used, setting up HTTP probe FastCGI wrapper defaults", port)
+	var protocols []string
+	if cmd.Protocol == "" {
+		switch port {
+		case defaultHTTPPortStr:
+			protocols = []string{config.ProtoHTTP}
+		case defaultHTTPSPortStr:
+			protocols = []string{config.ProtoHTTPS}
+		default:
+			protocols = []string{config.ProtoHTTP, config.ProtoHTTPS}
+		}
+	} else {
+		protocols = []string{cmd.Protocol}
+	}
+
+	for _, proto := range protocols {
+		maxRetryCount := probeRetryCount
+		if p.opts.RetryCount > 0 {
+			maxRetryCount = p.opts.RetryCount
+		}
+
+		notReadyErrorWait := time.Duration(16)
+		webErrorWait := time.Duration(8)
+		otherErrorWait := time.Duration(4)
+		if p.opts.RetryWait > 0 {
+			webErrorWait = time.Duration(p.opts.RetryWait)
+			notReadyErrorWait = time.Duration(p.opts.RetryWait * 2)
+			otherErrorWait = time.Duration(p.opts.RetryWait / 2)
+		}
+
+		if IsValidWSProto(proto) {
+			if p.probeWebsocket(proto, port, cmd, maxRetryCount, notReadyErrorWait) {
+				ok = true
+			}
+			continue
+		}
 
-					// Typicall the entrypoint into a PHP app.
-					if cmd.Resource == "/" {
-						cmd.Resource = "/index.php"
+		if IsValidSSEProto(proto) {
+			if p.probeSSE(proto, port, cmd, maxRetryCount, notReadyErrorWait) {
+				ok = true
+			}
+			continue
+		}
+
+		if IsValidGRPCProto(proto) {
+			if p.probeGRPC(proto, port, cmd, maxRetryCount, notReadyErrorWait) {
+				ok = true
+			}
+			continue
+		}
+
+		if proto == config.ProtoTCP || proto == config.ProtoTLS {
+			if p.probeTCP(proto, port, cmd, maxRetryCount, notReadyErrorWait) {
+				ok = true
+			}
+			continue
+		}
+
+		var client *http.Client
+		switch {
+		case cmd.FastCGI != nil:
+			log.Debug("HTTP probe - FastCGI embedded proxy configured")
+			client = p.auth.apply(getFastCGIClient(cmd.FastCGI))
+		default:
+			var err error
+			if client, err = p.newHTTPClient(proto); err != nil {
+				p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
+				continue
+			}
+		}
+
+		baseAddr := getHTTPAddr(proto, p.targetHost, port)
+		// TODO: cmd.Resource may need to be a part of cmd.FastCGI instead.
+		addr := fmt.Sprintf("%s%s", baseAddr, cmd.Resource)
+
+		req, err := newHTTPRequestFromCmd(cmd, addr, reqBody)
+		if err != nil {
+			p.xc.Out.Error("HTTP probe - construct request error - %v", err.Error())
+			continue
+		}
+
+		for i := 0; i < maxRetryCount; i++ {
+			p.waitForRate()
+			callStart := time.Now()
+			res, err := client.Do(req.Clone(context.Background()))
+			atomic.AddUint64(&p.CallCount, 1)
+			rbSeeker.Seek(0, 0)
+
+			var resBody []byte
+			if res != nil {
+				if res.Body != nil {
+					if expect.needBody() || p.responseHook != nil {
+						resBody, _ = ioutil.ReadAll(io.LimitReader(res.Body, maxExpectBodySize))
 					}
 
-					// SplitPath is typically on the first .php path element.
-					var splitPath []string
-					if phpIdx := strings.Index(cmd.Resource, ".php"); phpIdx != -1 {
-						splitPath = []string{cmd.Resource[:phpIdx+4]}
+					io.Copy(ioutil.Discard, res.Body)
+				}
+
+				res.Body.Close()
+			}
+
+			callLatency := time.Since(callStart)
+			statusCode := "error"
+			callErrorStr := "none"
+			if err == nil {
+				statusCode = fmt.Sprintf("%v", res.StatusCode)
+				err = expect.check(res.StatusCode, resBody)
+			}
+
+			if err != nil {
+				callErrorStr = err.Error()
+			}
+
+			p.stats.record(cmd.Method, addr, statusCode, callLatency, err)
+
+			if p.responseHook != nil {
+				response := &ProbeResponse{
+					Method:   cmd.Method,
+					Protocol: proto,
+					Resource: cmd.Resource,
+					Attempt:  i + 1,
+					Body:     resBody,
+				}
+
+				if err != nil {
+					response.Error = err.Error()
+				}
+
+				if res != nil {
+					response.StatusCode = res.StatusCode
+					response.Header = res.Header
+				}
+
+				p.responseHook(response)
+			}
+
+			if p.printState {
+				p.xc.Out.Info("http.probe.call",
+					ovars{
+						"status":  statusCode,
+						"method":  cmd.Method,
+						"target":  addr,
+						"attempt": i + 1,
+						"error":   callErrorStr,
+						"time":    time.Now().UTC().Format(time.RFC3339),
+					})
+			}
+
+			if err == nil {
+				ok = true
+				if atomic.AddUint64(&p.OkCount, 1) == 1 {
+					if len(p.opts.APISpecs) != 0 && len(p.opts.APISpecFiles) != 0 && cmd.FastCGI != nil {
+						p.xc.Out.Info("HTTP probe - API spec probing not implemented for fastcgi")
+					} else {
+						p.probeAPISpecs(proto, p.targetHost, port)
 					}
+				}
 
-					cmd.FastCGI = &config.FastCGIProbeWrapperConfig{
-						// /var/www is a typical root for PHP indices.
-						Root:      "/var/www",
-						SplitPath: splitPath,
+				if cmd.Crawl {
+					if cmd.FastCGI != nil {
+						p.xc.Out.Info("HTTP probe - crawling not implemented for fastcgi")
+					} else {
+						p.crawl(proto, p.targetHost, addr)
 					}
 				}
+				break
+			} else {
+				atomic.AddUint64(&p.ErrCount, 1)
 
-				var protocols []string
-				if cmd.Protocol == "" {
-					switch port {
-					case defaultHTTPPortStr:
-						protocols = []string{config.ProtoHTTP}
-					case defaultHTTPSPortStr:
-						protocols = []string{config.ProtoHTTPS}
-					default:
-						protocols = []string{config.ProtoHTTP, config.ProtoHTTPS}
+				urlErr := &url.Error{}
+				if errors.As(err, &urlErr) {
+					if errors.Is(urlErr.Err, io.EOF) {
+						log.Debugf("HTTP probe - target not ready yet (retry again later)...")
+						time.Sleep(notReadyErrorWait * time.Second)
+					} else {
+						log.Debugf("HTTP probe - web error... retry again later...")
+						time.Sleep(webErrorWait * time.Second)
 					}
+
 				} else {
-					protocols = []string{cmd.Protocol}
+					log.Debugf("HTTP probe - other error... retry again later...")
+					time.Sleep(otherErrorWait * time.Second)
 				}
+			}
 
-				for _, proto := range protocols {
-					maxRetryCount := probeRetryCount
-					if p.opts.RetryCount > 0 {
-						maxRetryCount = p.opts.RetryCount
-					}
+		}
+	}
 
-					notReadyErrorWait := time.Duration(16)
-					webErrorWait := time.Duration(8)
-					otherErrorWait := time.Duration(4)
-					if p.opts.RetryWait > 0 {
-						webErrorWait = time.Duration(p.opts.RetryWait)
-						notReadyErrorWait = time.Duration(p.opts.RetryWait * 2)
-						otherErrorWait = time.Duration(p.opts.RetryWait / 2)
-					}
+	return ok
+}
 
-					if IsValidWSProto(proto) {
-						wc, err := NewWebsocketClient(proto, p.targetHost, port)
-						if err != nil {
-							log.Debugf("HTTP probe - new websocket error - %v", err)
-							continue
-						}
+func (p *CustomProbe) probeWebsocket(proto, port string, cmd config.HTTPProbeCmd, maxRetryCount int, notReadyErrorWait time.Duration) bool {
+	wc, err := NewWebsocketClient(proto, p.targetHost, port, cmd.Resource)
+	if err != nil {
+		log.Debugf("HTTP probe - new websocket error - %v", err)
+		return false
+	}
 
-						wc.ReadCh = make(chan WebsocketMessage, 10)
-						for i := 0; i < maxRetryCount; i++ {
-							err = wc.Connect()
-							if err != nil {
-								log.Debugf("HTTP probe - ws target not ready yet (retry again later)...")
-								time.Sleep(notReadyErrorWait * time.Second)
-							}
-
-							wc.CheckConnection()
-							//TODO: prep data to write from the HTTPProbeCmd fields
-							err = wc.WriteString("ws.data")
-							p.CallCount++
-
-							if p.printState {
-								statusCode := "error"
-								callErrorStr := "none"
-								if err == nil {
-									statusCode = "ok"
-								} else {
-									callErrorStr = err.Error()
-								}
-
-								p.xc.Out.Info("http.probe.call.ws",
-									ovars{
-										"status":    statusCode,
-										"stats.rc":  wc.ReadCount,
-										"stats.pic": wc.PingCount,
-										"stats.poc": wc.PongCount,
-										"target":    wc.Addr,
-										"attempt":   i + 1,
-										"error":     callErrorStr,
-										"time":      time.Now().UTC().Format(time.RFC3339),
-									})
-							}
-
-							if err != nil {
-								p.ErrCount++
-								log.Debugf("HTTP probe - websocket write error - %v", err)
-								time.Sleep(notReadyErrorWait * time.Second)
-							} else {
-								p.OkCount++
-
-								//try to read something from the socket
-								select {
-								case wsMsg := <-wc.ReadCh:
-									log.Debugf("HTTP probe - websocket read - [type=%v data=%s]", wsMsg.Type, string(wsMsg.Data))
-								case <-time.After(time.Second * 5):
-									log.Debugf("HTTP probe - websocket read time out")
-								}
-
-								break
-							}
-						}
+	cmdHeaders := probeCmdHeaders(cmd)
+	wc.Dialer = p.auth.wsDialer()
+	wc.ReadCh = make(chan WebsocketMessage, 10)
 
-						wc.Disconnect()
-						continue
-					}
+	//the frames come from the probe command frames or its body (binary frame for body files)
+	var frames []WebsocketMessage
+	switch {
+	case len(cmd.Frames) > 0:
+		for _, data := range cmd.Frames {
+			frames = append(frames, WebsocketMessage{Type: websocket.TextMessage, Data: []byte(data)})
+		}
+	case cmd.BodyFile != "":
+		frameData, err := ioutil.ReadFile(cmd.BodyFile)
+		if err != nil {
+			log.Errorf("http.probe - cmd.BodyFile (%s) read error: %v", cmd.BodyFile, err)
+			return false
+		}
 
-					var client *http.Client
-					switch {
-					case cmd.FastCGI != nil:
-						log.Debug("HTTP probe - FastCGI embedded proxy configured")
-						client = getFastCGIClient(cmd.FastCGI)
-					default:
-						var err error
-						if client, err = getHTTPClient(proto); err != nil {
-							p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
-							continue
-						}
-					}
+		frames = append(frames, WebsocketMessage{Type: websocket.BinaryMessage, Data: frameData})
+	case cmd.Body != "":
+		frames = append(frames, WebsocketMessage{Type: websocket.TextMessage, Data: []byte(cmd.Body)})
+	default:
+		frames = append(frames, WebsocketMessage{Type: websocket.TextMessage, Data: []byte("ws.data")})
+	}
 
-					baseAddr := getHTTPAddr(proto, p.targetHost, port)
-					// TODO: cmd.Resource may need to be a part of cmd.FastCGI instead.
-					addr := fmt.Sprintf("%s%s", baseAddr, cmd.Resource)
+	var ok bool
+	for i := 0; i < maxRetryCount; i++ {
+		p.waitForRate()
+		callStart := time.Now()
+		//the auth headers are added for each handshake (the access token can expire)
+		wc.Headers = cmdHeaders.Clone()
+		if err = p.auth.applyHeaders(wc.Headers); err != nil {
+			log.Debugf("HTTP probe - websocket auth error - %v", err)
+			time.Sleep(notReadyErrorWait * time.Second)
+			continue
+		}
 
-					req, err := newHTTPRequestFromCmd(cmd, addr, reqBody)
-					if err != nil {
-						p.xc.Out.Error("HTTP probe - construct request error - %v", err.Error())
-						continue
-					}
+		err = wc.Connect()
+		if err != nil {
+			log.Debugf("HTTP probe - ws target not ready yet (retry again later)...")
+			time.Sleep(notReadyErrorWait * time.Second)
+			continue
+		}
 
-					for i := 0; i < maxRetryCount; i++ {
-						res, err := client.Do(req.Clone(context.Background()))
-						p.CallCount++
-						rbSeeker.Seek(0, 0)
+		wc.CheckConnection()
+		for _, frame := range frames {
+			if frame.Type == websocket.BinaryMessage {
+				err = wc.WriteBinary(frame.Data)
+			} else {
+				err = wc.WriteString(string(frame.Data))
+			}
 
-						if res != nil {
-							if res.Body != nil {
-								io.Copy(ioutil.Discard, res.Body)
-							}
+			if err != nil {
+				break
+			}
+		}
 
-							res.Body.Close()
-						}
+		atomic.AddUint64(&p.CallCount, 1)
 
-						statusCode := "error"
-						callErrorStr := "none"
-						if err == nil {
-							statusCode = fmt.Sprintf("%v", res.StatusCode)
-						} else {
-							callErrorStr = err.Error()
-						}
+		statusCode := "error"
+		callErrorStr := "none"
+		if err == nil {
+			statusCode = "ok"
+		} else {
+			callErrorStr = err.Error()
+		}
 
-						if p.printState {
-							p.xc.Out.Info("http.probe.call",
-								ovars{
-									"status":  statusCode,
-									"method":  cmd.Method,
-									"target":  addr,
-									"attempt": i + 1,
-									"error":   callErrorStr,
-									"time":    time.Now().UTC().Format(time.RFC3339),
-								})
-						}
+		p.stats.record("WS", wc.Addr, statusCode, time.Since(callStart), err)
 
-						if err == nil {
-							p.OkCount++
-
-							if p.OkCount == 1 {
-								if len(p.opts.APISpecs) != 0 && len(p.opts.APISpecFiles) != 0 && cmd.FastCGI != nil {
-									p.xc.Out.Info("HTTP probe - API spec probing not implemented for fastcgi")
-								} else {
-									p.probeAPISpecs(proto, p.targetHost, port)
-								}
-							}
-
-							if cmd.Crawl {
-								if cmd.FastCGI != nil {
-									p.xc.Out.Info("HTTP probe - crawling not implemented for fastcgi")
-								} else {
-									p.crawl(proto, p.targetHost, addr)
-								}
-							}
-							break
-						} else {
-							p.ErrCount++
-
-							urlErr := &url.Error{}
-							if errors.As(err, &urlErr) {
-								if errors.Is(urlErr.Err, io.EOF) {
-									log.Debugf("HTTP probe - target not ready yet (retry again later)...")
-									time.Sleep(notReadyErrorWait * time.Second)
-								} else {
-									log.Debugf("HTTP probe - web error... retry again later...")
-									time.Sleep(webErrorWait * time.Second)
-								}
-
-							} else {
-								log.Debugf("HTTP probe - other error... retry again later...")
-								time.Sleep(otherErrorWait * time.Second)
-							}
-						}
+		if p.printState {
+			p.xc.Out.Info("http.probe.call.ws",
+				ovars{
+					"status":    statusCode,
+					"stats.rc":  wc.ReadCount.Value(),
+					"stats.pic": wc.PingCount.Value(),
+					"stats.poc": wc.PongCount.Value(),
+					"target":    wc.Addr,
+					"attempt":   i + 1,
+					"error":     callErrorStr,
+					"time":      time.Now().UTC().Format(time.RFC3339),
+				})
+		}
 
-					}
-				}
-			}
+		if err != nil {
+			atomic.AddUint64(&p.ErrCount, 1)
+			log.Debugf("HTTP probe - websocket write error - %v", err)
+			wc.Disconnect()
+			time.Sleep(notReadyErrorWait * time.Second)
+			continue
 		}
 
-		log.Info("HTTP probe done.")
+		atomic.AddUint64(&p.OkCount, 1)
+		ok = true
+
+		//try to read something from the socket
+		select {
+		case wsMsg := <-wc.ReadCh:
+			log.Debugf("HTTP probe - websocket read - [type=%v data=%s]", wsMsg.Type, string(wsMsg.Data))
+		case <-time.After(time.Second * 5):
+			log.Debugf("HTTP probe - websocket read time out")
+		}
+
+		break
+	}
+
+	wc.Disconnect()
+	return ok
+}
+
+func (p *CustomProbe) probeSSE(proto, port string, cmd config.HTTPProbeCmd, maxRetryCount int, notReadyErrorWait time.Duration) bool {
+	sc, err := NewSSEClient(proto, p.targetHost, port, cmd.Resource)
+	if err != nil {
+		log.Debugf("HTTP probe - new sse client error - %v", err)
+		return false
+	}
+
+	sc.Headers = probeCmdHeaders(cmd)
+	sc.client = p.auth.apply(sc.client)
+	if (cmd.Username != "") || (cmd.Password != "") {
+		auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", cmd.Username, cmd.Password)))
+		sc.Headers.Set("Authorization", fmt.Sprintf("Basic %s", auth))
+	}
+
+	for i := 0; i < maxRetryCount; i++ {
+		p.waitForRate()
+		callStart := time.Now()
+		err = sc.Consume(defaultSSEReadTime, defaultSSEEventCount, func(event SSEEvent) {
+			log.Debugf("HTTP probe - sse event - [type=%v id=%v data=%s]", event.Type, event.ID, event.Data)
+		})
+
+		atomic.AddUint64(&p.CallCount, 1)
+
+		statusCode := "ok"
+		callErrorStr := "none"
+		if err != nil {
+			statusCode = "error"
+			callErrorStr = err.Error()
+		}
+
+		p.stats.record("SSE", sc.Addr, statusCode, time.Since(callStart), err)
 
 		if p.printState {
-			p.xc.Out.Info("http.probe.summary",
+			p.xc.Out.Info("http.probe.call.sse",
 				ovars{
-					"total":      p.CallCount,
-					"failures":   p.ErrCount,
-					"successful": p.OkCount,
+					"status":       statusCode,
+					"stats.events": sc.EventCount.Value(),
+					"target":       sc.Addr,
+					"attempt":      i + 1,
+					"error":        callErrorStr,
+					"time":         time.Now().UTC().Format(time.RFC3339),
 				})
+		}
 
-			outVars := ovars{}
-			//warning := ""
-			switch {
-			case p.CallCount == 0:
-				outVars["warning"] = "no.calls"
-				//warning = "warning=no.calls"
-			case p.OkCount == 0:
-				//warning = "warning=no.successful.calls"
-				outVars["warning"] = "no.successful.calls"
+		if err == nil {
+			atomic.AddUint64(&p.OkCount, 1)
+			return true
+		}
+
+		atomic.AddUint64(&p.ErrCount, 1)
+		log.Debugf("HTTP probe - sse target not ready yet (retry again later)...")
+		time.Sleep(notReadyErrorWait * time.Second)
+	}
+
+	return false
+}
+
+func (p *CustomProbe) probeGRPC(proto, port string, cmd config.HTTPProbeCmd, maxRetryCount int, notReadyErrorWait time.Duration) bool {
+	gc, err := NewGRPCClient(proto, p.targetHost, port)
+	if err != nil {
+		log.Debugf("HTTP probe - new grpc client error - %v", err)
+		return false
+	}
+
+	gc.client = p.auth.apply(gc.client)
+
+	var methods []string
+	if cmd.Resource != "" && cmd.Resource != "/" {
+		//explicit method (/package.Service/Method)
+		methods = append(methods, cmd.Resource)
+	} else {
+		var services []string
+		for i := 0; i < maxRetryCount; i++ {
+			services, err = gc.ListServices()
+			if err == nil || err == ErrGRPCReflectionNotSupported {
+				break
 			}
 
-			p.xc.Out.State("http.probe.done", outVars)
+			log.Debugf("HTTP probe - grpc target not ready yet (retry again later)...")
+			time.Sleep(notReadyErrorWait * time.Second)
 		}
 
-		p.workers.Wait()
-		close(p.doneChan)
-	}()
+		if err != nil {
+			atomic.AddUint64(&p.CallCount, 1)
+			atomic.AddUint64(&p.ErrCount, 1)
+			if p.printState {
+				p.xc.Out.Info("http.probe.call.grpc",
+					ovars{
+						"status": "error",
+						"target": gc.Addr,
+						"method": "reflection.list",
+						"error":  err.Error(),
+						"time":   time.Now().UTC().Format(time.RFC3339),
+					})
+			}
+
+			return false
+		}
+
+		for _, service := range services {
+			serviceMethods, err := gc.ServiceMethods(service)
+			if err != nil {
+				log.Debugf("HTTP probe - grpc service (%s) methods error - %v", service, err)
+				continue
+			}
+
+			methods = append(methods, serviceMethods...)
+		}
+	}
+
+	var ok bool
+	for _, method := range methods {
+		//note: using empty messages (all fields have their default values)
+		p.waitForRate()
+		callStart := time.Now()
+		_, err := gc.Call(method, nil)
+		atomic.AddUint64(&p.CallCount, 1)
+
+		statusCode := "ok"
+		callErrorStr := "none"
+		if err != nil {
+			if statusErr, ok := err.(*GRPCStatusError); ok {
+				//the call reached the target app, which is what we need
+				statusCode = statusErr.Code
+			} else {
+				statusCode = "error"
+			}
+
+			callErrorStr = err.Error()
+		}
+
+		if statusCode == "error" {
+			atomic.AddUint64(&p.ErrCount, 1)
+		} else {
+			atomic.AddUint64(&p.OkCount, 1)
+			ok = true
+			err = nil
+		}
+
+		p.stats.record("GRPC", fmt.Sprintf("%s%s", gc.Addr, method), statusCode, time.Since(callStart), err)
+
+		if p.printState {
+			p.xc.Out.Info("http.probe.call.grpc",
+				ovars{
+					"status": statusCode,
+					"target": gc.Addr,
+					"method": method,
+					"error":  callErrorStr,
+					"time":   time.Now().UTC().Format(time.RFC3339),
+				})
+		}
+	}
+
+	return ok
 }
 
 func (p *CustomProbe) probeAPISpecs(proto, targetHost, port string) {
@@ -612,6 +1142,36 @@ func (p *CustomProbe) probeAPISpecs(proto, targetHost, port string) {
 	}
 }
 
+// waitForRate blocks until the next probe call is allowed by the rate limit
+func (p *CustomProbe) waitForRate() {
+	if p.rateLimiter != nil {
+		p.rateLimiter.Wait(context.Background())
+	}
+}
+
+// Report returns the probe call statistics
+func (p *CustomProbe) Report() *report.HTTPProbeReport {
+	return &report.HTTPProbeReport{
+		CallCount:     atomic.LoadUint64(&p.CallCount),
+		OkCount:       atomic.LoadUint64(&p.OkCount),
+		ErrorCount:    atomic.LoadUint64(&p.ErrCount),
+		CmdCount:      p.CmdCount,
+		CmdFailCount:  p.CmdFailCount,
+		EndpointStats: p.stats.endpoints(),
+		TLSEndpoints:  p.stats.tlsEndpoints(),
+	}
+}
+
+// FailureThresholdExceeded returns true if the percentage of the failed probe commands
+// is greater than the configured failure threshold
+func (p *CustomProbe) FailureThresholdExceeded() bool {
+	if p.opts.FailThreshold < 0 || p.CmdCount == 0 {
+		return false
+	}
+
+	return p.CmdFailCount*100 > uint64(p.opts.FailThreshold)*p.CmdCount
+}
+
 // DoneChan returns the 'done' channel for the HTTP probe instance
 func (p *CustomProbe) DoneChan() <-chan struct{} {
 	return p.doneChan
@@ -623,6 +1183,17 @@ func newHTTPRequestFromCmd(cmd config.HTTPProbeCmd, addr string, reqBody io.Read
 		return nil, err
 	}
 
+	req.Header = probeCmdHeaders(cmd)
+
+	if (cmd.Username != "") || (cmd.Password != "") {
+		req.SetBasicAuth(cmd.Username, cmd.Password)
+	}
+
+	return req, nil
+}
+
+func probeCmdHeaders(cmd config.HTTPProbeCmd) http.Header {
+	headers := http.Header{}
 	for _, hline := range cmd.Headers {
 		hparts := strings.SplitN(hline, ":", 2)
 		if len(hparts) != 2 {
@@ -632,12 +1203,8 @@ func newHTTPRequestFromCmd(cmd config.HTTPProbeCmd, addr string, reqBody io.Read
 
 		hname := strings.TrimSpace(hparts[0])
 		hvalue := strings.TrimSpace(hparts[1])
-		req.Header.Add(hname, hvalue)
+		headers.Add(hname, hvalue)
 	}
 
-	if (cmd.Username != "") || (cmd.Password != "") {
-		req.SetBasicAuth(cmd.Username, cmd.Password)
-	}
-
-	return req, nil
+	return headers
 }
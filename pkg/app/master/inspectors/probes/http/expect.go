@@ -0,0 +1,62 @@
+package http
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const maxExpectBodySize = 1024 * 1024
+
+// probeExpectation checks the probe call responses
+// against the expected status codes and body pattern
+type probeExpectation struct {
+	status map[int]struct{}
+	body   *regexp.Regexp
+}
+
+func newProbeExpectation(cmd config.HTTPProbeCmd) (*probeExpectation, error) {
+	if len(cmd.ExpectStatus) == 0 && cmd.ExpectBody == "" {
+		return nil, nil
+	}
+
+	pe := &probeExpectation{
+		status: map[int]struct{}{},
+	}
+
+	for _, code := range cmd.ExpectStatus {
+		pe.status[code] = struct{}{}
+	}
+
+	if cmd.ExpectBody != "" {
+		var err error
+		if pe.body, err = regexp.Compile(cmd.ExpectBody); err != nil {
+			return nil, err
+		}
+	}
+
+	return pe, nil
+}
+
+func (pe *probeExpectation) needBody() bool {
+	return pe != nil && pe.body != nil
+}
+
+func (pe *probeExpectation) check(statusCode int, body []byte) error {
+	if pe == nil {
+		return nil
+	}
+
+	if len(pe.status) > 0 {
+		if _, found := pe.status[statusCode]; !found {
+			return fmt.Errorf("unexpected status code - %d", statusCode)
+		}
+	}
+
+	if pe.body != nil && !pe.body.Match(body) {
+		return fmt.Errorf("response body does not match '%s'", pe.body.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,332 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const (
+	grpcContentType          = "application/grpc"
+	grpcReflectionInfoMethod = "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+	grpcFrameHeaderSize      = 5
+	grpcMaxMessageSize       = 4 * 1024 * 1024 //the default max receive message size in grpc-go
+	grpcStatusOK             = "0"
+)
+
+// gRPC server reflection message field numbers
+// (see grpc/reflection/v1alpha/reflection.proto)
+const (
+	grpcRefReqFileContainingSymbol protowire.Number = 4
+	grpcRefReqListServices         protowire.Number = 7
+
+	grpcRefResFileDescriptorResponse protowire.Number = 4
+	grpcRefResListServicesResponse   protowire.Number = 6
+	grpcRefResErrorResponse          protowire.Number = 7
+
+	grpcRefFileDescriptorProto protowire.Number = 1
+	grpcRefListService         protowire.Number = 1
+	grpcRefServiceName         protowire.Number = 1
+	grpcRefErrorMessage        protowire.Number = 2
+)
+
+var ErrGRPCReflectionNotSupported = errors.New("grpc reflection is not supported")
+
+// GRPCStatusError is returned when a gRPC call completes with a non-OK status
+type GRPCStatusError struct {
+	Code    string
+	Message string
+}
+
+func (e *GRPCStatusError) Error() string {
+	return fmt.Sprintf("grpc status code=%s message='%s'", e.Code, e.Message)
+}
+
+// GRPCClient is a minimal gRPC client that uses server reflection
+// to discover the target services and then calls their methods with empty messages
+type GRPCClient struct {
+	Addr      string
+	CallCount uint64
+	client    *http.Client
+}
+
+func NewGRPCClient(proto, host, port string) (*GRPCClient, error) {
+	if proto == "" {
+		proto = config.ProtoGRPC
+	}
+
+	if !IsValidGRPCProto(proto) {
+		return nil, fmt.Errorf("invalid grpc proto - %s", proto)
+	}
+
+	client, err := getHTTPClient(proto)
+	if err != nil {
+		return nil, err
+	}
+
+	gc := &GRPCClient{
+		Addr:   getHTTPAddr(proto, host, port),
+		client: client,
+	}
+
+	return gc, nil
+}
+
+func IsValidGRPCProto(proto string) bool {
+	switch proto {
+	case config.ProtoGRPC, config.ProtoGRPCS:
+		return true
+	default:
+		return false
+	}
+}
+
+// ListServices returns the names of the services exposed by the target
+// (excluding the reflection service itself)
+func (gc *GRPCClient) ListServices() ([]string, error) {
+	res, err := gc.reflect(grpcRefReqListServices, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	lsData, err := protoBytesFields(res, grpcRefResListServicesResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lsData) == 0 {
+		return nil, fmt.Errorf("no list services response")
+	}
+
+	serviceRecords, err := protoBytesFields(lsData[0], grpcRefListService)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []string
+	for _, record := range serviceRecords {
+		names, err := protoBytesFields(record, grpcRefServiceName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if strings.HasPrefix(string(name), "grpc.reflection.") {
+				continue
+			}
+
+			services = append(services, string(name))
+		}
+	}
+
+	return services, nil
+}
+
+// ServiceMethods returns the full method names (/service/method) for the target service
+func (gc *GRPCClient) ServiceMethods(service string) ([]string, error) {
+	res, err := gc.reflect(grpcRefReqFileContainingSymbol, service)
+	if err != nil {
+		return nil, err
+	}
+
+	fdrData, err := protoBytesFields(res, grpcRefResFileDescriptorResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fdrData) == 0 {
+		return nil, fmt.Errorf("no file descriptor response")
+	}
+
+	fdList, err := protoBytesFields(fdrData[0], grpcRefFileDescriptorProto)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	for _, fdData := range fdList {
+		var fd descriptorpb.FileDescriptorProto
+		if err := proto.Unmarshal(fdData, &fd); err != nil {
+			log.Debugf("GRPCClient.ServiceMethods: error decoding file descriptor - %v", err)
+			continue
+		}
+
+		for _, sd := range fd.GetService() {
+			name := sd.GetName()
+			if fd.GetPackage() != "" {
+				name = fmt.Sprintf("%s.%s", fd.GetPackage(), name)
+			}
+
+			if name != service {
+				continue
+			}
+
+			for _, md := range sd.GetMethod() {
+				methods = append(methods, fmt.Sprintf("/%s/%s", service, md.GetName()))
+			}
+		}
+	}
+
+	return methods, nil
+}
+
+// Call invokes a gRPC method with the provided (encoded) protobuf message.
+// A GRPCStatusError is returned when the call reaches the target
+// and the target returns a non-OK status.
+func (gc *GRPCClient) Call(fullMethod string, msg []byte) ([][]byte, error) {
+	addr := fmt.Sprintf("%s%s", gc.Addr, fullMethod)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, addr, bytes.NewReader(grpcFrame(msg)))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", grpcContentType)
+	req.Header.Set("TE", "trailers")
+
+	gc.CallCount++
+	res, err := gc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected http status code - %d", res.StatusCode)
+	}
+
+	//trailers-only responses have the status in the headers
+	status := res.Trailer.Get("grpc-status")
+	message := res.Trailer.Get("grpc-message")
+	if status == "" {
+		status = res.Header.Get("grpc-status")
+		message = res.Header.Get("grpc-message")
+	}
+
+	messages, err := grpcUnframe(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != "" && status != grpcStatusOK {
+		return messages, &GRPCStatusError{Code: status, Message: message}
+	}
+
+	return messages, nil
+}
+
+func (gc *GRPCClient) reflect(field protowire.Number, value string) ([]byte, error) {
+	var msg []byte
+	msg = protowire.AppendTag(msg, field, protowire.BytesType)
+	msg = protowire.AppendString(msg, value)
+
+	messages, err := gc.Call(grpcReflectionInfoMethod, msg)
+	if err != nil {
+		var statusErr *GRPCStatusError
+		if errors.As(err, &statusErr) && statusErr.Code == "12" { //UNIMPLEMENTED
+			return nil, ErrGRPCReflectionNotSupported
+		}
+
+		return nil, err
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no reflection response")
+	}
+
+	if errData, err := protoBytesFields(messages[0], grpcRefResErrorResponse); err == nil && len(errData) > 0 {
+		errMessage, _ := protoBytesFields(errData[0], grpcRefErrorMessage)
+		var text string
+		if len(errMessage) > 0 {
+			text = string(errMessage[0])
+		}
+
+		return nil, fmt.Errorf("reflection error - '%s'", text)
+	}
+
+	return messages[0], nil
+}
+
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, grpcFrameHeaderSize+len(msg))
+	//frame[0] is the compression flag (no compression)
+	binary.BigEndian.PutUint32(frame[1:grpcFrameHeaderSize], uint32(len(msg)))
+	copy(frame[grpcFrameHeaderSize:], msg)
+	return frame
+}
+
+func grpcUnframe(data []byte) ([][]byte, error) {
+	var messages [][]byte
+	for len(data) > 0 {
+		if len(data) < grpcFrameHeaderSize {
+			return nil, fmt.Errorf("truncated grpc frame header")
+		}
+
+		if data[0] != 0 {
+			return nil, fmt.Errorf("compressed grpc messages are not supported")
+		}
+
+		size := binary.BigEndian.Uint32(data[1:grpcFrameHeaderSize])
+		if size > grpcMaxMessageSize {
+			return nil, fmt.Errorf("grpc message is too large (%d bytes)", size)
+		}
+
+		data = data[grpcFrameHeaderSize:]
+		if uint32(len(data)) < size {
+			return nil, fmt.Errorf("truncated grpc message")
+		}
+
+		messages = append(messages, data[:size])
+		data = data[size:]
+	}
+
+	return messages, nil
+}
+
+// protoBytesFields returns all length-delimited values for the target field number
+func protoBytesFields(data []byte, target protowire.Number) ([][]byte, error) {
+	var values [][]byte
+	for len(data) > 0 {
+		num, wtype, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+
+		data = data[n:]
+		if num == target && wtype == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+
+			values = append(values, value)
+			data = data[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(num, wtype, data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+
+		data = data[n:]
+	}
+
+	return values, nil
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGRPCFrame(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 0, 0}, grpcFrame(nil))
+	assert.Equal(t, []byte{0, 0, 0, 0, 3, 'a', 'b', 'c'}, grpcFrame([]byte("abc")))
+}
+
+func TestGRPCUnframe(t *testing.T) {
+	var data []byte
+	data = append(data, grpcFrame([]byte("first"))...)
+	data = append(data, grpcFrame(nil)...)
+	data = append(data, grpcFrame([]byte("second"))...)
+
+	messages, err := grpcUnframe(data)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "first", string(messages[0]))
+	assert.Empty(t, messages[1])
+	assert.Equal(t, "second", string(messages[2]))
+
+	messages, err = grpcUnframe(nil)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestGRPCUnframeErrors(t *testing.T) {
+	tt := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "truncated header",
+			data: []byte{0, 0, 0},
+		},
+		{
+			name: "truncated header after a message",
+			data: append(grpcFrame([]byte("abc")), 0, 0),
+		},
+		{
+			name: "truncated message",
+			data: []byte{0, 0, 0, 0, 10, 'a', 'b', 'c'},
+		},
+		{
+			name: "compressed message",
+			data: []byte{1, 0, 0, 0, 3, 'a', 'b', 'c'},
+		},
+		{
+			name: "oversized message",
+			data: []byte{0, 0xff, 0xff, 0xff, 0xff, 'a', 'b', 'c'},
+		},
+		{
+			name: "message over the max message size",
+			data: []byte{0, 0, 0x40, 0, 1},
+		},
+	}
+
+	for _, test := range tt {
+		messages, err := grpcUnframe(test.data)
+		assert.Error(t, err, test.name)
+		assert.Nil(t, messages, test.name)
+	}
+}
@@ -17,8 +17,10 @@ func getHTTP1Client() *http.Client {
 	client := &http.Client{
 		Timeout: time.Second * 30,
 		Transport: &http.Transport{
-			MaxIdleConns:    10,
-			IdleConnTimeout: 30 * time.Second,
+			//negotiate HTTP/2 (h2) with TLS targets when they support it
+			ForceAttemptHTTP2: true,
+			MaxIdleConns:      10,
+			IdleConnTimeout:   30 * time.Second,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: true,
 			},
@@ -52,9 +54,9 @@ func getHTTP2Client(h2c bool) *http.Client {
 
 func getHTTPClient(proto string) (*http.Client, error) {
 	switch proto {
-	case config.ProtoHTTP2:
+	case config.ProtoHTTP2, config.ProtoGRPCS:
 		return getHTTP2Client(false), nil
-	case config.ProtoHTTP2C:
+	case config.ProtoHTTP2C, config.ProtoGRPC:
 		return getHTTP2Client(true), nil
 	default:
 		return getHTTP1Client(), nil
@@ -75,9 +77,9 @@ func getHTTPScheme(proto string) string {
 		scheme = proto
 	case config.ProtoHTTPS:
 		scheme = proto
-	case config.ProtoHTTP2:
+	case config.ProtoHTTP2, config.ProtoGRPCS:
 		scheme = config.ProtoHTTPS
-	case config.ProtoHTTP2C:
+	case config.ProtoHTTP2C, config.ProtoGRPC:
 		scheme = config.ProtoHTTP
 	}
 
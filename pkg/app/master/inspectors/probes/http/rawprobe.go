@@ -0,0 +1,283 @@
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const (
+	rawProbeTimeout     = 10 * time.Second
+	rawProbeReadTimeout = 3 * time.Second
+	maxRawProbeReadSize = 4096
+)
+
+// probeTCP connects to the target port (completing the TLS handshake for 'tls'),
+// sends the probe command data (if any) and reads the response (or the service banner)
+func (p *CustomProbe) probeTCP(proto, port string, cmd config.HTTPProbeCmd, maxRetryCount int, notReadyErrorWait time.Duration) bool {
+	data, err := rawProbeData(cmd)
+	if err != nil {
+		log.Errorf("http.probe - cmd.BodyFile (%s) read error: %v", cmd.BodyFile, err)
+		return false
+	}
+
+	addr := net.JoinHostPort(p.targetHost, port)
+	target := fmt.Sprintf("%s://%s", proto, addr)
+	for i := 0; i < maxRetryCount; i++ {
+		p.waitForRate()
+		callStart := time.Now()
+		readCount, tlsState, err := p.tcpCall(proto, addr, data)
+		atomic.AddUint64(&p.CallCount, 1)
+
+		statusCode := "ok"
+		callErrorStr := "none"
+		if err != nil {
+			statusCode = "error"
+			callErrorStr = err.Error()
+		}
+
+		p.stats.record(strings.ToUpper(proto), target, statusCode, time.Since(callStart), err)
+
+		if p.printState {
+			p.xc.Out.Info("http.probe.call.raw",
+				ovars{
+					"status":     statusCode,
+					"target":     target,
+					"read.bytes": readCount,
+					"attempt":    i + 1,
+					"error":      callErrorStr,
+					"time":       time.Now().UTC().Format(time.RFC3339),
+				})
+		}
+
+		if tlsState != nil {
+			info := newTLSEndpointInfo(target, tlsState)
+			p.stats.recordTLS(info)
+
+			if p.printState {
+				outVars := ovars{
+					"target":  target,
+					"version": info.Version,
+					"cipher":  info.CipherSuite,
+				}
+
+				if len(info.Certificates) > 0 {
+					outVars["subject"] = info.Certificates[0].Subject
+					outVars["issuer"] = info.Certificates[0].Issuer
+					outVars["not.after"] = info.Certificates[0].NotAfter.Format(time.RFC3339)
+				}
+
+				p.xc.Out.Info("http.probe.tls", outVars)
+			}
+		}
+
+		if err == nil {
+			atomic.AddUint64(&p.OkCount, 1)
+			return true
+		}
+
+		atomic.AddUint64(&p.ErrCount, 1)
+		log.Debugf("HTTP probe - %s target not ready yet (retry again later)...", proto)
+		time.Sleep(notReadyErrorWait * time.Second)
+	}
+
+	return false
+}
+
+func (p *CustomProbe) tcpCall(proto, addr string, data []byte) (int, *tls.ConnectionState, error) {
+	dialer := &net.Dialer{Timeout: rawProbeTimeout}
+
+	var conn net.Conn
+	var state *tls.ConnectionState
+	if proto == config.ProtoTLS {
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if p.auth != nil && p.auth.tlsConfig != nil {
+			tlsConfig = p.auth.tlsConfig.Clone()
+		}
+
+		tlsConn, err := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		cs := tlsConn.ConnectionState()
+		state = &cs
+		conn = tlsConn
+	} else {
+		var err error
+		if conn, err = dialer.Dial("tcp", addr); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	defer conn.Close()
+
+	if len(data) > 0 {
+		conn.SetWriteDeadline(time.Now().Add(rawProbeTimeout))
+		if _, err := conn.Write(data); err != nil {
+			return 0, state, err
+		}
+	}
+
+	//read the response (or the banner) if the service sends anything
+	conn.SetReadDeadline(time.Now().Add(rawProbeReadTimeout))
+	buf := make([]byte, maxRawProbeReadSize)
+	n, err := conn.Read(buf)
+	if err != nil && err != io.EOF && !isTimeoutError(err) {
+		return n, state, err
+	}
+
+	return n, state, nil
+}
+
+// probeUDP sends the probe command data to the target UDP port
+func (p *CustomProbe) probeUDP(port string, cmd config.HTTPProbeCmd) bool {
+	data, err := rawProbeData(cmd)
+	if err != nil {
+		log.Errorf("http.probe - cmd.BodyFile (%s) read error: %v", cmd.BodyFile, err)
+		return false
+	}
+
+	if len(data) == 0 {
+		//need to send something
+		data = []byte("\n")
+	}
+
+	maxRetryCount := probeRetryCount
+	if p.opts.RetryCount > 0 {
+		maxRetryCount = p.opts.RetryCount
+	}
+
+	errorWait := time.Duration(4)
+	if p.opts.RetryWait > 0 {
+		errorWait = time.Duration(p.opts.RetryWait)
+	}
+
+	addr := net.JoinHostPort(p.targetHost, port)
+	target := fmt.Sprintf("%s://%s", config.ProtoUDP, addr)
+	for i := 0; i < maxRetryCount; i++ {
+		p.waitForRate()
+		callStart := time.Now()
+		readCount, err := udpCall(addr, data)
+		atomic.AddUint64(&p.CallCount, 1)
+
+		statusCode := "ok"
+		callErrorStr := "none"
+		if err != nil {
+			statusCode = "error"
+			callErrorStr = err.Error()
+		}
+
+		p.stats.record("UDP", target, statusCode, time.Since(callStart), err)
+
+		if p.printState {
+			p.xc.Out.Info("http.probe.call.raw",
+				ovars{
+					"status":     statusCode,
+					"target":     target,
+					"read.bytes": readCount,
+					"attempt":    i + 1,
+					"error":      callErrorStr,
+					"time":       time.Now().UTC().Format(time.RFC3339),
+				})
+		}
+
+		if err == nil {
+			atomic.AddUint64(&p.OkCount, 1)
+			return true
+		}
+
+		atomic.AddUint64(&p.ErrCount, 1)
+		time.Sleep(errorWait * time.Second)
+	}
+
+	return false
+}
+
+func udpCall(addr string, data []byte) (int, error) {
+	conn, err := net.DialTimeout("udp", addr, rawProbeTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(rawProbeTimeout))
+	if _, err := conn.Write(data); err != nil {
+		return 0, err
+	}
+
+	//no response is normal for many UDP services,
+	//but a closed port will be reported as a read error
+	conn.SetReadDeadline(time.Now().Add(rawProbeReadTimeout))
+	buf := make([]byte, maxRawProbeReadSize)
+	n, err := conn.Read(buf)
+	if err != nil && !isTimeoutError(err) {
+		return n, err
+	}
+
+	return n, nil
+}
+
+func rawProbeData(cmd config.HTTPProbeCmd) ([]byte, error) {
+	if cmd.BodyFile != "" {
+		return ioutil.ReadFile(cmd.BodyFile)
+	}
+
+	return []byte(cmd.Body), nil
+}
+
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+func newTLSEndpointInfo(endpoint string, state *tls.ConnectionState) *report.TLSEndpointInfo {
+	info := &report.TLSEndpointInfo{
+		Endpoint:    endpoint,
+		Version:     tlsVersionNames[state.Version],
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ALPN:        state.NegotiatedProtocol,
+	}
+
+	if info.Version == "" {
+		info.Version = fmt.Sprintf("0x%04x", state.Version)
+	}
+
+	for _, cert := range state.PeerCertificates {
+		certInfo := &report.TLSCertInfo{
+			Subject:      cert.Subject.String(),
+			Issuer:       cert.Issuer.String(),
+			SerialNumber: cert.SerialNumber.String(),
+			DNSNames:     cert.DNSNames,
+			NotBefore:    cert.NotBefore,
+			NotAfter:     cert.NotAfter,
+		}
+
+		for _, ip := range cert.IPAddresses {
+			certInfo.IPAddresses = append(certInfo.IPAddresses, ip.String())
+		}
+
+		info.Certificates = append(info.Certificates, certInfo)
+	}
+
+	return info
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const (
+	defaultReadyTimeout = 120 * time.Second
+	readyCheckInterval  = time.Second
+	readyDialTimeout    = 2 * time.Second
+)
+
+// readyTarget provides the container info used by the readiness conditions
+type readyTarget struct {
+	apiClient   *dockerapi.Client
+	containerID string
+	//container port -> probe port
+	ports map[string]string
+}
+
+// waitForReady waits until all readiness conditions are satisfied
+// (returns false if the conditions are not satisfied before the timeout)
+func (p *CustomProbe) waitForReady() bool {
+	timeout := defaultReadyTimeout
+	if p.opts.ReadyTimeout > 0 {
+		timeout = time.Duration(p.opts.ReadyTimeout) * time.Second
+	}
+
+	pending := map[int]config.ProbeReadyCondition{}
+	for idx, cond := range p.opts.ReadyWhen {
+		if p.readyTarget.apiClient == nil &&
+			(cond.Type == config.ProbeReadyLog || cond.Type == config.ProbeReadyHealthcheck) {
+			p.xc.Out.Info("http.probe.ready.skip",
+				ovars{
+					"condition": cond.Type,
+					"message":   "readiness condition is not supported for the target",
+				})
+			continue
+		}
+
+		pending[idx] = cond
+	}
+
+	if p.printState && len(pending) > 0 {
+		p.xc.Out.State("http.probe.ready.wait", ovars{"conditions": len(pending), "timeout": timeout})
+	}
+
+	waitStart := time.Now()
+	for {
+		for idx, cond := range pending {
+			ready, err := p.checkReady(cond)
+			if err != nil {
+				log.Debugf("http.probe.ready - %s check error: %v", cond.Type, err)
+			}
+
+			if ready {
+				delete(pending, idx)
+				if p.printState {
+					p.xc.Out.Info("http.probe.ready",
+						ovars{
+							"condition": cond.Type,
+							"time":      time.Since(waitStart).Round(time.Millisecond),
+						})
+				}
+			}
+		}
+
+		if len(pending) == 0 {
+			return true
+		}
+
+		if time.Since(waitStart) > timeout {
+			for _, cond := range pending {
+				p.xc.Out.Info("http.probe.ready.timeout",
+					ovars{
+						"condition": cond.Type,
+						"message":   "readiness condition is not satisfied (probing anyway)",
+					})
+			}
+
+			return false
+		}
+
+		time.Sleep(readyCheckInterval)
+	}
+}
+
+func (p *CustomProbe) checkReady(cond config.ProbeReadyCondition) (bool, error) {
+	switch cond.Type {
+	case config.ProbeReadyLog:
+		return p.checkReadyLog(cond.Pattern)
+	case config.ProbeReadyTCP:
+		return p.checkReadyTCP(cond.Port)
+	case config.ProbeReadyHealthcheck:
+		return p.checkReadyHealthcheck()
+	}
+
+	return false, fmt.Errorf("unknown readiness condition - %s", cond.Type)
+}
+
+func (p *CustomProbe) checkReadyLog(pattern string) (bool, error) {
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	var logData bytes.Buffer
+	logsOptions := dockerapi.LogsOptions{
+		Container:    p.readyTarget.containerID,
+		OutputStream: &logData,
+		ErrorStream:  &logData,
+		Stdout:       true,
+		Stderr:       true,
+	}
+
+	if err := p.readyTarget.apiClient.Logs(logsOptions); err != nil {
+		return false, err
+	}
+
+	return matcher.Match(logData.Bytes()), nil
+}
+
+func (p *CustomProbe) checkReadyTCP(containerPort string) (bool, error) {
+	ports := p.ports
+	if containerPort != "" {
+		port, found := p.readyTarget.ports[containerPort]
+		if !found {
+			port = containerPort
+		}
+
+		ports = []string{port}
+	}
+
+	var lastErr error
+	for _, port := range ports {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(p.targetHost, port), readyDialTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		conn.Close()
+		return true, nil
+	}
+
+	return false, lastErr
+}
+
+func (p *CustomProbe) checkReadyHealthcheck() (bool, error) {
+	info, err := p.readyTarget.apiClient.InspectContainerWithOptions(
+		dockerapi.InspectContainerOptions{ID: p.readyTarget.containerID})
+	if err != nil {
+		return false, err
+	}
+
+	switch info.State.Health.Status {
+	case "healthy":
+		return true, nil
+	case "":
+		//no healthcheck to wait for
+		log.Debug("http.probe.ready - target container has no healthcheck")
+		return true, nil
+	}
+
+	return false, nil
+}
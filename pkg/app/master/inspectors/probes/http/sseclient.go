@@ -0,0 +1,204 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/acounter"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const (
+	sseContentType       = "text/event-stream"
+	defaultSSEReadTime   = 5 * time.Second
+	defaultSSEEventCount = 10
+)
+
+// SSEClient consumes Server-Sent Events streams
+type SSEClient struct {
+	Addr       string
+	Headers    http.Header
+	EventCount acounter.Type
+	client     *http.Client
+}
+
+type SSEEvent struct {
+	ID    string
+	Type  string
+	Data  string
+	Retry string
+}
+
+func NewSSEClient(proto, host, port, resource string) (*SSEClient, error) {
+	if proto == "" {
+		proto = config.ProtoSSE
+	}
+
+	if !IsValidSSEProto(proto) {
+		return nil, fmt.Errorf("invalid sse proto - %s", proto)
+	}
+
+	httpProto := config.ProtoHTTP
+	if proto == config.ProtoSSES {
+		httpProto = config.ProtoHTTPS
+	}
+
+	client := getHTTP1Client()
+	//the stream read time is controlled by the context in Consume
+	client.Timeout = 0
+
+	sc := &SSEClient{
+		Addr:    fmt.Sprintf("%s%s", getHTTPAddr(httpProto, host, port), resource),
+		Headers: http.Header{},
+		client:  client,
+	}
+
+	return sc, nil
+}
+
+func IsValidSSEProto(proto string) bool {
+	switch proto {
+	case config.ProtoSSE, config.ProtoSSES:
+		return true
+	default:
+		return false
+	}
+}
+
+// Consume connects to the event stream and reads events until the read time expires,
+// the max number of events is received or the target closes the stream
+func (sc *SSEClient) Consume(readTime time.Duration, maxEvents int, onEvent func(event SSEEvent)) error {
+	if readTime <= 0 {
+		readTime = defaultSSEReadTime
+	}
+
+	if maxEvents <= 0 {
+		maxEvents = defaultSSEEventCount
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readTime)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sc.Addr, nil)
+	if err != nil {
+		return err
+	}
+
+	for hname, hvalues := range sc.Headers {
+		for _, hvalue := range hvalues {
+			req.Header.Add(hname, hvalue)
+		}
+	}
+
+	req.Header.Set("Accept", sseContentType)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	res, err := sc.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected http status code - %d", res.StatusCode)
+	}
+
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, sseContentType) {
+		log.Debugf("SSEClient.Consume: unexpected content type - '%s'", ct)
+	}
+
+	var count int
+	err = readSSEEvents(res.Body, func(event SSEEvent) bool {
+		sc.EventCount.Inc()
+		count++
+		if onEvent != nil {
+			onEvent(event)
+		}
+
+		return count < maxEvents
+	})
+
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
+
+// readSSEEvents parses the event stream and calls onEvent for each event
+// until onEvent returns false or the stream ends
+// (see the 'event stream interpretation' section in the HTML spec)
+func readSSEEvents(r io.Reader, onEvent func(event SSEEvent) bool) error {
+	var event SSEEvent
+	var dataLines []string
+	//the last event ID is kept for the following events
+	var lastID string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			//dispatch the event
+			if len(dataLines) > 0 {
+				event.ID = lastID
+				event.Data = strings.Join(dataLines, "\n")
+				if !onEvent(event) {
+					return nil
+				}
+			}
+
+			event = SSEEvent{}
+			dataLines = nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			//comment
+			continue
+		}
+
+		field, value := line, ""
+		if idx := strings.Index(line, ":"); idx != -1 {
+			field = line[:idx]
+			value = strings.TrimPrefix(line[idx+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event.Type = value
+		case "data":
+			dataLines = append(dataLines, value)
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				lastID = value
+			}
+		case "retry":
+			if isSSERetryValue(value) {
+				event.Retry = value
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+func isSSERetryValue(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
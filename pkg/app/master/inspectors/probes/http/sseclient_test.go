@@ -0,0 +1,87 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSSEStream = `: stream comment
+retry: 3000
+id: 1
+event: update
+data: first line
+data:second line
+data
+
+id: 2
+data: {"value":2}
+
+: no data (not dispatched)
+event: ignored
+id: 3
+
+retry: soon
+data: third
+
+id: bad` + "\x00" + `id
+data: fourth
+
+data: not terminated`
+
+func TestReadSSEEvents(t *testing.T) {
+	var events []SSEEvent
+	err := readSSEEvents(strings.NewReader(testSSEStream), func(event SSEEvent) bool {
+		events = append(events, event)
+		return true
+	})
+	require.NoError(t, err)
+
+	expected := []SSEEvent{
+		{
+			ID:    "1",
+			Type:  "update",
+			Data:  "first line\nsecond line\n",
+			Retry: "3000",
+		},
+		{
+			ID:   "2",
+			Data: `{"value":2}`,
+		},
+		{
+			//the last event ID is kept and the invalid retry value is ignored
+			ID:   "3",
+			Data: "third",
+		},
+		{
+			//the ID with a NULL character is ignored
+			ID:   "3",
+			Data: "fourth",
+		},
+	}
+
+	assert.Equal(t, expected, events)
+}
+
+func TestReadSSEEventsStop(t *testing.T) {
+	var count int
+	err := readSSEEvents(strings.NewReader(testSSEStream), func(event SSEEvent) bool {
+		count++
+		return count < 2
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestReadSSEEventsCRLF(t *testing.T) {
+	var events []SSEEvent
+	stream := "id: 7\r\ndata: a\r\ndata: b\r\n\r\n"
+	err := readSSEEvents(strings.NewReader(stream), func(event SSEEvent) bool {
+		events = append(events, event)
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []SSEEvent{{ID: "7", Data: "a\nb"}}, events)
+}
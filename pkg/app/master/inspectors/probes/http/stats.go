@@ -0,0 +1,138 @@
+package http
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/trace"
+)
+
+// probeStats collects the per-endpoint probe call statistics
+type probeStats struct {
+	mu    sync.Mutex
+	order []string
+	data  map[string]*endpointCalls
+	tls   []*report.TLSEndpointInfo
+	trace *trace.Recorder
+}
+
+type endpointCalls struct {
+	method      string
+	endpoint    string
+	errorCount  uint64
+	lastError   string
+	statusCodes map[string]uint64
+	latencies   []time.Duration
+}
+
+func (ps *probeStats) record(method, endpoint, status string, latency time.Duration, err error) {
+	callArgs := map[string]interface{}{"status": status}
+	if err != nil {
+		callArgs["error"] = err.Error()
+	}
+
+	now := time.Now()
+	ps.trace.Span(trace.TrackProbe, "http", method+" "+endpoint, now.Add(-latency), now, callArgs)
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.data == nil {
+		ps.data = map[string]*endpointCalls{}
+	}
+
+	key := method + " " + endpoint
+	calls, found := ps.data[key]
+	if !found {
+		calls = &endpointCalls{
+			method:      method,
+			endpoint:    endpoint,
+			statusCodes: map[string]uint64{},
+		}
+
+		ps.data[key] = calls
+		ps.order = append(ps.order, key)
+	}
+
+	calls.statusCodes[status]++
+	calls.latencies = append(calls.latencies, latency)
+	if err != nil {
+		calls.errorCount++
+		calls.lastError = err.Error()
+	}
+}
+
+// recordTLS saves the TLS handshake information (once per endpoint)
+func (ps *probeStats) recordTLS(info *report.TLSEndpointInfo) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, current := range ps.tls {
+		if current.Endpoint == info.Endpoint {
+			return
+		}
+	}
+
+	ps.tls = append(ps.tls, info)
+}
+
+func (ps *probeStats) tlsEndpoints() []*report.TLSEndpointInfo {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.tls
+}
+
+func (ps *probeStats) endpoints() []*report.HTTPProbeEndpointStats {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	var result []*report.HTTPProbeEndpointStats
+	for _, key := range ps.order {
+		calls := ps.data[key]
+		info := &report.HTTPProbeEndpointStats{
+			Method:      calls.method,
+			Endpoint:    calls.endpoint,
+			CallCount:   uint64(len(calls.latencies)),
+			ErrorCount:  calls.errorCount,
+			LastError:   calls.lastError,
+			StatusCodes: map[string]uint64{},
+		}
+
+		for status, count := range calls.statusCodes {
+			info.StatusCodes[status] = count
+		}
+
+		latencies := make([]time.Duration, len(calls.latencies))
+		copy(latencies, calls.latencies)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		if len(latencies) > 0 {
+			var total time.Duration
+			for _, latency := range latencies {
+				total += latency
+			}
+
+			info.LatencyMin = toMillis(latencies[0])
+			info.LatencyMax = toMillis(latencies[len(latencies)-1])
+			info.LatencyAvg = toMillis(total / time.Duration(len(latencies)))
+			info.LatencyP50 = toMillis(percentile(latencies, 50))
+			info.LatencyP95 = toMillis(percentile(latencies, 95))
+		}
+
+		result = append(result, info)
+	}
+
+	return result
+}
+
+// percentile expects sorted values
+func percentile(values []time.Duration, pct int) time.Duration {
+	return values[(len(values)-1)*pct/100]
+}
+
+func toMillis(value time.Duration) float64 {
+	return float64(value) / float64(time.Millisecond)
+}
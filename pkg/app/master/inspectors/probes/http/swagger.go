@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi2"
@@ -202,7 +203,7 @@ func apiSpecPrefix(spec *openapi3.T) (string, error) {
 func (p *CustomProbe) loadAPISpecs(proto, targetHost, port string) {
 
 	baseAddr := getHTTPAddr(proto, targetHost, port)
-	client, err := getHTTPClient(proto)
+	client, err := p.newHTTPClient(proto)
 	if err != nil {
 		p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
 		return
@@ -283,32 +284,24 @@ func (p *CustomProbe) probeAPISpecEndpoints(proto, targetHost, port, prefix stri
 			})
 	}
 
-	httpClient, err := getHTTPClient(proto)
+	httpClient, err := p.newHTTPClient(proto)
 	if err != nil {
 		p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
 		return
 	}
 
 	for apiPath, pathInfo := range spec.Paths {
-		//very primitive way to set the path params (will break for numeric values)
-		if strings.Contains(apiPath, "{") {
-			apiPath = strings.ReplaceAll(apiPath, "{", "")
-
-			if strings.Contains(apiPath, "}") {
-				apiPath = strings.ReplaceAll(apiPath, "}", "")
-			}
-		}
-
-		endpoint := fmt.Sprintf("%s%s%s", addr, prefix, apiPath)
 		ops := pathOps(pathInfo)
-		for apiMethod := range ops {
-			//make a call (no params for now)
-			p.apiSpecEndpointCall(httpClient, endpoint, apiMethod)
+		for apiMethod, op := range ops {
+			//generate the path/query/header params and the request body
+			//from the examples and schemas in the spec
+			call := newAPISpecCall(apiPath, pathInfo, op)
+			p.apiSpecEndpointCall(httpClient, call.endpoint(addr, prefix), apiMethod, call)
 		}
 	}
 }
 
-func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method string) {
+func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method string, call *apiSpecCall) {
 	maxRetryCount := probeRetryCount
 	if p.opts.RetryCount > 0 {
 		maxRetryCount = p.opts.RetryCount
@@ -325,15 +318,31 @@ func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method
 
 	method = strings.ToUpper(method)
 	for i := 0; i < maxRetryCount; i++ {
-		req, err := http.NewRequest(method, endpoint, nil)
+		var body io.Reader
+		if len(call.body) > 0 {
+			body = bytes.NewReader(call.body)
+		}
+
+		req, err := http.NewRequest(method, endpoint, body)
 		if err != nil {
 			p.xc.Out.Error("HTTP probe - construct request error - %v", err.Error())
 			// Break since the same args are passed to NewRequest() on each loop.
 			break
 		}
-		//no body, no request headers and no credentials for now
+
+		if call.contentType != "" && body != nil {
+			req.Header.Set("Content-Type", call.contentType)
+		}
+
+		for hname, hvalue := range call.headers {
+			req.Header.Set(hname, hvalue)
+		}
+
+		//no credentials for now
+		p.waitForRate()
+		callStart := time.Now()
 		res, err := client.Do(req)
-		p.CallCount++
+		atomic.AddUint64(&p.CallCount, 1)
 
 		if res != nil {
 			if res.Body != nil {
@@ -351,6 +360,8 @@ func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method
 			callErrorStr = err.Error()
 		}
 
+		p.stats.record(method, endpoint, statusCode, time.Since(callStart), err)
+
 		if p.printState {
 			p.xc.Out.Info("http.probe.api-spec.probe.endpoint.call",
 				ovars{
@@ -364,10 +375,10 @@ func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method
 		}
 
 		if err == nil {
-			p.OkCount++
+			atomic.AddUint64(&p.OkCount, 1)
 			break
 		} else {
-			p.ErrCount++
+			atomic.AddUint64(&p.ErrCount, 1)
 
 			if urlErr, ok := err.(*url.Error); ok {
 				if urlErr.Err == io.EOF {
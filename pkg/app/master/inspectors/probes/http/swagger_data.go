@@ -0,0 +1,297 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxSampleSchemaDepth = 8
+
+	mimeJSON = "application/json"
+	mimeForm = "application/x-www-form-urlencoded"
+	mimeText = "text/plain"
+)
+
+// apiSpecCall contains the request data generated from an API spec operation
+type apiSpecCall struct {
+	path        string
+	query       url.Values
+	headers     map[string]string
+	body        []byte
+	contentType string
+}
+
+func newAPISpecCall(apiPath string, pathInfo *openapi3.PathItem, op *openapi3.Operation) *apiSpecCall {
+	call := &apiSpecCall{
+		path:    apiPath,
+		query:   url.Values{},
+		headers: map[string]string{},
+	}
+
+	//operation parameters override the path item parameters with the same name and location
+	params := map[string]*openapi3.Parameter{}
+	for _, plist := range []openapi3.Parameters{pathInfo.Parameters, op.Parameters} {
+		for _, pref := range plist {
+			if pref == nil || pref.Value == nil {
+				continue
+			}
+
+			params[fmt.Sprintf("%s:%s", pref.Value.In, pref.Value.Name)] = pref.Value
+		}
+	}
+
+	for _, param := range params {
+		value := sampleParamValue(param)
+		switch param.In {
+		case openapi3.ParameterInPath:
+			call.path = strings.ReplaceAll(call.path, fmt.Sprintf("{%s}", param.Name), url.PathEscape(value))
+		case openapi3.ParameterInQuery:
+			if param.Required {
+				call.query.Set(param.Name, value)
+			}
+		case openapi3.ParameterInHeader:
+			if param.Required {
+				call.headers[param.Name] = value
+			}
+		}
+	}
+
+	//very primitive way to set the path params not described in the spec
+	if strings.Contains(call.path, "{") {
+		call.path = strings.ReplaceAll(call.path, "{", "")
+
+		if strings.Contains(call.path, "}") {
+			call.path = strings.ReplaceAll(call.path, "}", "")
+		}
+	}
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		call.contentType, call.body = sampleRequestBody(op.RequestBody.Value.Content)
+	}
+
+	return call
+}
+
+func (c *apiSpecCall) endpoint(addr, prefix string) string {
+	endpoint := fmt.Sprintf("%s%s%s", addr, prefix, c.path)
+	if len(c.query) > 0 {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, c.query.Encode())
+	}
+
+	return endpoint
+}
+
+func sampleParamValue(param *openapi3.Parameter) string {
+	var value interface{}
+	switch {
+	case param.Example != nil:
+		value = param.Example
+	case len(param.Examples) > 0:
+		value = firstExampleValue(param.Examples)
+	}
+
+	if value == nil {
+		value = sampleSchemaValue(param.Schema, 0)
+	}
+
+	if value == nil {
+		value = param.Name
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			parts = append(parts, fmt.Sprintf("%v", item))
+		}
+
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func sampleRequestBody(content openapi3.Content) (string, []byte) {
+	if len(content) == 0 {
+		return "", nil
+	}
+
+	contentType := mimeJSON
+	mt := content.Get(contentType)
+	if mt == nil {
+		contentType = mimeForm
+		mt = content.Get(contentType)
+	}
+
+	if mt == nil {
+		//pick the first content type (in a stable way)
+		var names []string
+		for name := range content {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		contentType = names[0]
+		mt = content[contentType]
+	}
+
+	if mt == nil {
+		return "", nil
+	}
+
+	var value interface{}
+	switch {
+	case mt.Example != nil:
+		value = mt.Example
+	case len(mt.Examples) > 0:
+		value = firstExampleValue(mt.Examples)
+	}
+
+	if value == nil {
+		value = sampleSchemaValue(mt.Schema, 0)
+	}
+
+	if value == nil {
+		return contentType, nil
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		data, err := json.Marshal(value)
+		if err != nil {
+			log.Debugf("http.sampleRequestBody - json.Marshal error=%v", err)
+			return contentType, nil
+		}
+
+		return contentType, data
+	case contentType == mimeForm:
+		form := url.Values{}
+		if fields, ok := value.(map[string]interface{}); ok {
+			for k, v := range fields {
+				form.Set(k, fmt.Sprintf("%v", v))
+			}
+		}
+
+		return contentType, []byte(form.Encode())
+	default:
+		if s, ok := value.(string); ok {
+			return contentType, []byte(s)
+		}
+
+		return contentType, []byte(fmt.Sprintf("%v", value))
+	}
+}
+
+func firstExampleValue(examples openapi3.Examples) interface{} {
+	var names []string
+	for name := range examples {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if eref := examples[name]; eref != nil && eref.Value != nil && eref.Value.Value != nil {
+			return eref.Value.Value
+		}
+	}
+
+	return nil
+}
+
+func sampleSchemaValue(sref *openapi3.SchemaRef, depth int) interface{} {
+	if sref == nil || sref.Value == nil || depth > maxSampleSchemaDepth {
+		return nil
+	}
+
+	schema := sref.Value
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := map[string]interface{}{}
+		for _, item := range schema.AllOf {
+			if fields, ok := sampleSchemaValue(item, depth+1).(map[string]interface{}); ok {
+				for k, v := range fields {
+					merged[k] = v
+				}
+			}
+		}
+
+		return merged
+	case len(schema.OneOf) > 0:
+		return sampleSchemaValue(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return sampleSchemaValue(schema.AnyOf[0], depth+1)
+	}
+
+	switch schema.Type {
+	case "string":
+		return sampleStringValue(schema.Format)
+	case "integer":
+		if schema.Min != nil {
+			return int64(*schema.Min)
+		}
+
+		return 1
+	case "number":
+		if schema.Min != nil {
+			return *schema.Min
+		}
+
+		return 1.0
+	case "boolean":
+		return true
+	case "array":
+		if item := sampleSchemaValue(schema.Items, depth+1); item != nil {
+			return []interface{}{item}
+		}
+
+		return []interface{}{}
+	default:
+		//'object' or no type
+		fields := map[string]interface{}{}
+		for name, prop := range schema.Properties {
+			if value := sampleSchemaValue(prop, depth+1); value != nil {
+				fields[name] = value
+			}
+		}
+
+		return fields
+	}
+}
+
+func sampleStringValue(format string) string {
+	switch format {
+	case "date":
+		return "2020-01-01"
+	case "date-time":
+		return "2020-01-01T00:00:00Z"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000001"
+	case "uri", "url":
+		return "http://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "ZGF0YQ=="
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAPISpec = `
+openapi: 3.0.0
+info:
+  title: test
+  version: "1.0"
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      parameters:
+        - name: verbose
+          in: query
+          required: true
+          schema:
+            type: boolean
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                  example: bob
+                email:
+                  type: string
+                  format: email
+`
+
+func TestAPISpecCall(t *testing.T) {
+	spec, err := parseAPISpec([]byte(testAPISpec))
+	require.NoError(t, err)
+	require.NotNil(t, spec)
+
+	pathInfo := spec.Paths["/users/{id}"]
+	require.NotNil(t, pathInfo)
+
+	getCall := newAPISpecCall("/users/{id}", pathInfo, pathInfo.Get)
+	assert.Equal(t, "http://localhost:80/api/users/1?verbose=true", getCall.endpoint("http://localhost:80", "/api"))
+	assert.Empty(t, getCall.body)
+
+	putCall := newAPISpecCall("/users/{id}", pathInfo, pathInfo.Put)
+	assert.Equal(t, "/users/1", putCall.path)
+	assert.Equal(t, mimeJSON, putCall.contentType)
+	assert.JSONEq(t, `{"name":"bob","email":"user@example.com"}`, string(putCall.body))
+}
@@ -2,6 +2,8 @@ package http
 
 import (
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -23,7 +25,10 @@ type WebsocketClient struct {
 	PongCount acounter.Type
 	PingCount acounter.Type
 	Addr      string
+	Headers   http.Header
+	Dialer    *websocket.Dialer
 	pongCh    chan string
+	doneMu    sync.Mutex
 	doneCh    chan struct{}
 }
 
@@ -32,7 +37,7 @@ type WebsocketMessage struct {
 	Data []byte
 }
 
-func NewWebsocketClient(proto, host, port string) (*WebsocketClient, error) {
+func NewWebsocketClient(proto, host, port, resource string) (*WebsocketClient, error) {
 	if proto == "" {
 		proto = ProtoWS
 	}
@@ -42,8 +47,7 @@ func NewWebsocketClient(proto, host, port string) (*WebsocketClient, error) {
 	}
 
 	wsclient := &WebsocketClient{
-		Addr:   fmt.Sprintf("%s://%s:%s", proto, host, port),
-		doneCh: make(chan struct{}),
+		Addr:   fmt.Sprintf("%s://%s:%s%s", proto, host, port, resource),
 		pongCh: make(chan string, 10),
 	}
 
@@ -60,7 +64,12 @@ func IsValidWSProto(proto string) bool {
 }
 
 func (wc *WebsocketClient) Connect() error {
-	conn, _, err := websocket.DefaultDialer.Dial(wc.Addr, nil)
+	dialer := wc.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	conn, _, err := dialer.Dial(wc.Addr, wc.Headers)
 	if err != nil {
 		log.Debugf("WebsocketClient.Connect: ws.Dial error=%v", err)
 		return err
@@ -82,11 +91,17 @@ func (wc *WebsocketClient) Connect() error {
 	}
 	conn.SetPingHandler(pingHandler)
 
+	//each connection gets its own done channel (the client can reconnect after Disconnect)
+	doneCh := make(chan struct{})
+	wc.doneMu.Lock()
+	wc.doneCh = doneCh
+	wc.doneMu.Unlock()
+
 	go func() {
 		for {
 			log.Debug("WebsocketClient: reader - waiting for errors...")
 			select {
-			case <-wc.doneCh:
+			case <-doneCh:
 				log.Debug("WebsocketClient: reader - error collector - done...")
 				return
 			default:
@@ -176,10 +191,14 @@ func (wc *WebsocketClient) WriteBinary(data []byte) error {
 }
 
 func (wc *WebsocketClient) Disconnect() error {
-	if wc.Conn != nil {
+	wc.doneMu.Lock()
+	if wc.doneCh != nil {
 		close(wc.doneCh)
 		wc.doneCh = nil
+	}
+	wc.doneMu.Unlock()
 
+	if wc.Conn != nil {
 		err := wc.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
 		if err != nil {
 			log.Debugf("WebsocketClient.Disconnect: conn.WriteMessage(websocket.CloseMessage) error=%v", err)
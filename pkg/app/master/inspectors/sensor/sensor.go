@@ -1,11 +1,18 @@
 package sensor
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
@@ -22,7 +29,18 @@ const (
 	FileArtifactsPrefix  = "files/"
 )
 
-func EnsureLocalBinary(xc *app.ExecutionContext, logger *log.Entry, statePath string, printState bool) string {
+// Sensor and temporary container exit codes
+// (used by the container and pod inspectors)
+const (
+	ExitCodeContainerCrashed = -123
+	ExitCodeSensorError      = -124
+	ExitCodeNoSensor         = -125
+	ExitCodeCmdPortConflict  = -126
+	ExitCodeEvtPortConflict  = -127
+)
+
+// LocalBinPath returns the location of the local sensor binary
+func LocalBinPath(statePath string) string {
 	sensorPath := filepath.Join(fsutil.ExeDir(), LocalBinFile)
 
 	if runtime.GOOS == "darwin" {
@@ -32,6 +50,14 @@ func EnsureLocalBinary(xc *app.ExecutionContext, logger *log.Entry, statePath st
 		}
 	}
 
+	return sensorPath
+}
+
+// EnsureLocalBinary returns the location of the local sensor binary
+// (the exit error with the ExitCodeNoSensor exit code is returned if there's no sensor)
+func EnsureLocalBinary(xc *app.ExecutionContext, logger *log.Entry, statePath string, printState bool) (string, error) {
+	sensorPath := LocalBinPath(statePath)
+
 	if !fsutil.Exists(sensorPath) {
 		if printState {
 			xc.Out.Info("sensor.error",
@@ -42,13 +68,13 @@ func EnsureLocalBinary(xc *app.ExecutionContext, logger *log.Entry, statePath st
 
 			xc.Out.State("exited",
 				ovars{
-					"exit.code": -125,
+					"exit.code": ExitCodeNoSensor,
 					"component": "container.inspector",
 					"version":   v.Current(),
 				})
 		}
 
-		xc.Exit(-125)
+		return "", app.NewExitError(ExitCodeNoSensor)
 	}
 
 	if finfo, err := os.Lstat(sensorPath); err == nil {
@@ -65,5 +91,92 @@ func EnsureLocalBinary(xc *app.ExecutionContext, logger *log.Entry, statePath st
 		logger.Errorf("RunContainer: error getting sensor (%s) info => %#v", sensorPath, err)
 	}
 
-	return sensorPath
+	return sensorPath, nil
+}
+
+// ErrIncompatibleSensor is returned when the sensor doesn't support the master IPC protocol version
+var ErrIncompatibleSensor = errors.New("incompatible sensor")
+
+// HintSensorUpdate is the remediation hint for the incompatible sensors
+const HintSensorUpdate = "update the sensor to the master version ('docker-slim update --sensor-only --version <version>') or update both binaries ('docker-slim update')"
+
+// CheckProtocol checks if the sensor is compatible with the master using the sensor command response
+// (the older sensors don't report their protocol version, so they are treated as compatible)
+func CheckProtocol(xc *app.ExecutionContext, logger *log.Entry, resp *command.Response, printState bool) error {
+	if resp == nil {
+		return nil
+	}
+
+	logger.Debugf("sensor: version='%s' protocol=%d (master protocol=%d)",
+		resp.SensorVersion, resp.ProtocolVersion, command.ProtocolVersion)
+
+	var err error
+	switch {
+	case resp.Status == command.ResponseStatusError && resp.Error != "":
+		err = fmt.Errorf("%w - %s", ErrIncompatibleSensor, resp.Error)
+	case !command.IsCompatibleProtocol(resp.ProtocolVersion):
+		err = fmt.Errorf("%w - unsupported sensor protocol version %d (supported: %d-%d)",
+			ErrIncompatibleSensor, resp.ProtocolVersion, command.MinProtocolVersion, command.ProtocolVersion)
+	}
+
+	if err != nil {
+		return app.WrapError(err, app.ErrorCategoryContainer, "sensor.protocol", HintSensorUpdate)
+	}
+
+	if printState && resp.SensorVersion != "" && resp.SensorVersion != v.Current() {
+		//the sensor is updated or pinned separately
+		xc.Out.Info("sensor",
+			ovars{
+				"version":  resp.SensorVersion,
+				"protocol": resp.ProtocolVersion,
+				"message":  "sensor version is different from the master version",
+			})
+	}
+
+	return nil
+}
+
+// LocalBinInfo contains the local sensor binary build metadata
+type LocalBinInfo struct {
+	Location string `json:"location"`
+	*v.BuildInfo
+	ProtocolVersion int `json:"protocol_version,omitempty"`
+	//the reason the build metadata is not available
+	Error string `json:"error,omitempty"`
+}
+
+const localBinVersionTimeout = 5 * time.Second
+
+// LocalBinVersion returns the build metadata for the local sensor binary
+// (the sensor is a Linux binary, so the build metadata is available only on Linux;
+// the older sensors don't support the '-version' flag)
+func LocalBinVersion(statePath string) *LocalBinInfo {
+	info := &LocalBinInfo{
+		Location: LocalBinPath(statePath),
+	}
+
+	if !fsutil.Exists(info.Location) {
+		info.Error = "sensor binary not found"
+		return info
+	}
+
+	if runtime.GOOS != "linux" {
+		info.Error = fmt.Sprintf("sensor build metadata is not available on %s", runtime.GOOS)
+		return info
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), localBinVersionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, info.Location, "-version").Output()
+	if err != nil {
+		info.Error = fmt.Sprintf("error getting sensor build metadata - %v", err)
+		return info
+	}
+
+	if err := json.Unmarshal(output, info); err != nil {
+		info.Error = fmt.Sprintf("malformed sensor build metadata - %v", err)
+	}
+
+	return info
 }
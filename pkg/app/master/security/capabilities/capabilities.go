@@ -0,0 +1,323 @@
+package capabilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Linux capability names (without the 'CAP_' prefix, the way Docker and Kubernetes use them)
+const (
+	CapChown          = "CHOWN"
+	CapDacOverride    = "DAC_OVERRIDE"
+	CapFowner         = "FOWNER"
+	CapFsetid         = "FSETID"
+	CapKill           = "KILL"
+	CapSetgid         = "SETGID"
+	CapSetuid         = "SETUID"
+	CapSetpcap        = "SETPCAP"
+	CapSetfcap        = "SETFCAP"
+	CapNetBindService = "NET_BIND_SERVICE"
+	CapNetRaw         = "NET_RAW"
+	CapNetAdmin       = "NET_ADMIN"
+	CapIpcLock        = "IPC_LOCK"
+	CapSysModule      = "SYS_MODULE"
+	CapSysRawio       = "SYS_RAWIO"
+	CapSysChroot      = "SYS_CHROOT"
+	CapSysPtrace      = "SYS_PTRACE"
+	CapSysPacct       = "SYS_PACCT"
+	CapSysAdmin       = "SYS_ADMIN"
+	CapSysBoot        = "SYS_BOOT"
+	CapSysNice        = "SYS_NICE"
+	CapSysResource    = "SYS_RESOURCE"
+	CapSysTime        = "SYS_TIME"
+	CapMknod          = "MKNOD"
+	CapSyslog         = "SYSLOG"
+	CapAuditWrite     = "AUDIT_WRITE"
+)
+
+// note: a syscall doesn't always need its capability
+// (e.g., 'setuid' needs SETUID only when changing to a different user),
+// so the recommendations are conservative and err on the side of keeping capabilities
+// ('bind' is special: it needs NET_BIND_SERVICE only for the ports below 1024,
+// so the capability is dropped only when the known listen ports are all unprivileged)
+var syscallCaps = map[string][]string{
+	"chown":              {CapChown},
+	"fchown":             {CapChown},
+	"lchown":             {CapChown},
+	"fchownat":           {CapChown},
+	"chown32":            {CapChown},
+	"fchown32":           {CapChown},
+	"lchown32":           {CapChown},
+	"setuid":             {CapSetuid},
+	"setreuid":           {CapSetuid},
+	"setresuid":          {CapSetuid},
+	"setfsuid":           {CapSetuid},
+	"setuid32":           {CapSetuid},
+	"setreuid32":         {CapSetuid},
+	"setresuid32":        {CapSetuid},
+	"setfsuid32":         {CapSetuid},
+	"setgid":             {CapSetgid},
+	"setregid":           {CapSetgid},
+	"setresgid":          {CapSetgid},
+	"setfsgid":           {CapSetgid},
+	"setgroups":          {CapSetgid},
+	"setgid32":           {CapSetgid},
+	"setregid32":         {CapSetgid},
+	"setresgid32":        {CapSetgid},
+	"setfsgid32":         {CapSetgid},
+	"setgroups32":        {CapSetgid},
+	"capset":             {CapSetpcap},
+	"chroot":             {CapSysChroot},
+	"mknod":              {CapMknod},
+	"mknodat":            {CapMknod},
+	"ptrace":             {CapSysPtrace},
+	"process_vm_readv":   {CapSysPtrace},
+	"process_vm_writev":  {CapSysPtrace},
+	"mount":              {CapSysAdmin},
+	"umount":             {CapSysAdmin},
+	"umount2":            {CapSysAdmin},
+	"pivot_root":         {CapSysAdmin},
+	"unshare":            {CapSysAdmin},
+	"setns":              {CapSysAdmin},
+	"sethostname":        {CapSysAdmin},
+	"setdomainname":      {CapSysAdmin},
+	"swapon":             {CapSysAdmin},
+	"swapoff":            {CapSysAdmin},
+	"quotactl":           {CapSysAdmin},
+	"bpf":                {CapSysAdmin},
+	"setpriority":        {CapSysNice},
+	"sched_setscheduler": {CapSysNice},
+	"sched_setaffinity":  {CapSysNice},
+	"ioprio_set":         {CapSysNice},
+	"setrlimit":          {CapSysResource},
+	"prlimit64":          {CapSysResource},
+	"settimeofday":       {CapSysTime},
+	"clock_settime":      {CapSysTime},
+	"adjtimex":           {CapSysTime},
+	"clock_adjtime":      {CapSysTime},
+	"stime":              {CapSysTime},
+	"init_module":        {CapSysModule},
+	"finit_module":       {CapSysModule},
+	"delete_module":      {CapSysModule},
+	"iopl":               {CapSysRawio},
+	"ioperm":             {CapSysRawio},
+	"reboot":             {CapSysBoot},
+	"kexec_load":         {CapSysBoot},
+	"acct":               {CapSysPacct},
+	"syslog":             {CapSyslog},
+	"mlock":              {CapIpcLock},
+	"mlock2":             {CapIpcLock},
+	"mlockall":           {CapIpcLock},
+}
+
+// privilege escalation related syscalls
+var privEscalationCalls = map[string]struct{}{
+	"setuid":      {},
+	"setreuid":    {},
+	"setresuid":   {},
+	"setuid32":    {},
+	"setreuid32":  {},
+	"setresuid32": {},
+	"capset":      {},
+}
+
+// the ports below this port need NET_BIND_SERVICE
+const privilegedPortLimit = 1024
+
+// CapabilityInfo describes a capability the monitored application needed
+type CapabilityInfo struct {
+	Name     string   `json:"name"`
+	Syscalls []string `json:"syscalls"`
+}
+
+// Report is the capability and privilege requirements report
+// (the pod security context has the pod level settings;
+// use the cap_drop and cap_add lists for the container security context capabilities)
+type Report struct {
+	ArchName                 string                     `json:"arch_name"`
+	Used                     []*CapabilityInfo          `json:"used,omitempty"`
+	CapDrop                  []string                   `json:"cap_drop"`
+	CapAdd                   []string                   `json:"cap_add,omitempty"`
+	AllowPrivilegeEscalation bool                       `json:"allow_privilege_escalation"`
+	DockerRunFlags           string                     `json:"docker_run_flags"`
+	PodSecurityContext       *corev1.PodSecurityContext `json:"pod_security_context"`
+}
+
+// NewReport creates a capabilities report from the observed syscall stats,
+// the ports the app listened on and the main app process info (both are optional)
+func NewReport(archName string,
+	syscallStats map[string]report.SyscallStatInfo,
+	listenPorts []*report.ListenPortInfo,
+	mainProcess *report.ProcessInfo) *Report {
+	used := map[string]map[string]struct{}{}
+	addUsed := func(name, call string) {
+		if _, ok := used[name]; !ok {
+			used[name] = map[string]struct{}{}
+		}
+
+		used[name][call] = struct{}{}
+	}
+
+	var allowEscalation bool
+	var hasBind bool
+	for _, scInfo := range syscallStats {
+		if _, ok := privEscalationCalls[scInfo.Name]; ok {
+			allowEscalation = true
+		}
+
+		if scInfo.Name == "bind" {
+			hasBind = true
+		}
+
+		for _, name := range syscallCaps[scInfo.Name] {
+			addUsed(name, scInfo.Name)
+		}
+	}
+
+	if hasBind && needsNetBindService(listenPorts) {
+		addUsed(CapNetBindService, "bind")
+	}
+
+	r := &Report{
+		ArchName:                 archName,
+		CapDrop:                  []string{"ALL"},
+		AllowPrivilegeEscalation: allowEscalation,
+	}
+
+	for name, calls := range used {
+		info := &CapabilityInfo{Name: name}
+		for call := range calls {
+			info.Syscalls = append(info.Syscalls, call)
+		}
+
+		sort.Strings(info.Syscalls)
+		r.Used = append(r.Used, info)
+		r.CapAdd = append(r.CapAdd, name)
+	}
+
+	sort.Slice(r.Used, func(i, j int) bool {
+		return r.Used[i].Name < r.Used[j].Name
+	})
+	sort.Strings(r.CapAdd)
+
+	var flags []string
+	for _, name := range r.CapDrop {
+		flags = append(flags, fmt.Sprintf("--cap-drop=%s", name))
+	}
+
+	for _, name := range r.CapAdd {
+		flags = append(flags, fmt.Sprintf("--cap-add=%s", name))
+	}
+
+	if !allowEscalation {
+		flags = append(flags, "--security-opt=no-new-privileges")
+	}
+
+	r.DockerRunFlags = strings.Join(flags, " ")
+	r.PodSecurityContext = newPodSecurityContext(mainProcess)
+	return r
+}
+
+// needsNetBindService returns true if the app listened on a privileged port
+// or if the listen ports are unknown (the app might have bound a privileged port)
+func needsNetBindService(listenPorts []*report.ListenPortInfo) bool {
+	var known bool
+	for _, portInfo := range listenPorts {
+		if portInfo == nil || portInfo.Port <= 0 {
+			continue
+		}
+
+		if portInfo.Port < privilegedPortLimit {
+			return true
+		}
+
+		known = true
+	}
+
+	return !known
+}
+
+// newPodSecurityContext creates the pod security context for the observed app user
+// (the runtime default seccomp profile is used unless the generated seccomp profile is installed on the nodes)
+func newPodSecurityContext(mainProcess *report.ProcessInfo) *corev1.PodSecurityContext {
+	psc := &corev1.PodSecurityContext{
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+
+	//the user settings are added only for the non-root apps
+	if mainProcess == nil || mainProcess.UID == nil || *mainProcess.UID == 0 {
+		return psc
+	}
+
+	nonRoot := true
+	uid := int64(*mainProcess.UID)
+	psc.RunAsNonRoot = &nonRoot
+	psc.RunAsUser = &uid
+	if mainProcess.GID != nil {
+		gid := int64(*mainProcess.GID)
+		psc.RunAsGroup = &gid
+	}
+
+	return psc
+}
+
+// GenProfile creates a capability and privilege requirements report
+func GenProfile(artifactLocation string, profileName string) error {
+	containerReportFilePath := filepath.Join(artifactLocation, report.DefaultContainerReportFileName)
+
+	if _, err := os.Stat(containerReportFilePath); err != nil {
+		return err
+	}
+	reportFile, err := os.Open(containerReportFilePath)
+	if err != nil {
+		return err
+	}
+	defer reportFile.Close()
+
+	var creport report.ContainerReport
+	if err = json.NewDecoder(reportFile).Decode(&creport); err != nil {
+		return err
+	}
+
+	if creport.Monitors.Pt == nil || !creport.Monitors.Pt.Enabled {
+		log.Debug("capabilities.GenProfile: not generating capabilities report (PT mon disabled, no syscall info)")
+		return nil
+	}
+
+	profilePath := filepath.Join(artifactLocation, profileName)
+	log.Debug("capabilities.GenProfile: saving capabilities report to ", profilePath)
+
+	var listenPorts []*report.ListenPortInfo
+	if creport.Network != nil {
+		listenPorts = creport.Network.ListenPorts
+	}
+
+	var mainProcess *report.ProcessInfo
+	if creport.Monitors.Fan != nil {
+		mainProcess = creport.Monitors.Fan.MainProcess
+	}
+
+	capReport := NewReport(creport.Monitors.Pt.ArchName,
+		creport.Monitors.Pt.SyscallStats,
+		listenPorts,
+		mainProcess)
+
+	profileData, err := json.MarshalIndent(capReport, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(profilePath, profileData, 0644)
+}
@@ -0,0 +1,152 @@
+package capabilities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+func syscallStats(names ...string) map[string]report.SyscallStatInfo {
+	stats := map[string]report.SyscallStatInfo{}
+	for idx, name := range names {
+		stats[name] = report.SyscallStatInfo{
+			Number: uint32(idx),
+			Name:   name,
+			Count:  1,
+		}
+	}
+
+	return stats
+}
+
+func listenPorts(ports ...int) []*report.ListenPortInfo {
+	var info []*report.ListenPortInfo
+	for _, port := range ports {
+		info = append(info, &report.ListenPortInfo{
+			Protocol: "tcp",
+			Address:  "0.0.0.0",
+			Port:     port,
+		})
+	}
+
+	return info
+}
+
+func TestNewReportCapabilities(t *testing.T) {
+	tt := []struct {
+		name       string
+		syscalls   []string
+		ports      []*report.ListenPortInfo
+		capAdd     []string
+		escalation bool
+		flags      string
+	}{
+		{
+			name:     "no privileged calls",
+			syscalls: []string{"read", "write", "openat"},
+			flags:    "--cap-drop=ALL --security-opt=no-new-privileges",
+		},
+		{
+			name:     "chown and setgid",
+			syscalls: []string{"fchownat", "setgroups", "read"},
+			capAdd:   []string{CapChown, CapSetgid},
+			flags:    "--cap-drop=ALL --cap-add=CHOWN --cap-add=SETGID --security-opt=no-new-privileges",
+		},
+		{
+			name:       "setuid allows privilege escalation",
+			syscalls:   []string{"setresuid"},
+			capAdd:     []string{CapSetuid},
+			escalation: true,
+			flags:      "--cap-drop=ALL --cap-add=SETUID",
+		},
+		{
+			name:     "bind on a privileged port",
+			syscalls: []string{"socket", "bind", "listen"},
+			ports:    listenPorts(8080, 80),
+			capAdd:   []string{CapNetBindService},
+			flags:    "--cap-drop=ALL --cap-add=NET_BIND_SERVICE --security-opt=no-new-privileges",
+		},
+		{
+			name:     "bind on an unprivileged port",
+			syscalls: []string{"socket", "bind", "listen"},
+			ports:    listenPorts(8080),
+			flags:    "--cap-drop=ALL --security-opt=no-new-privileges",
+		},
+		{
+			//keeping the capability because the bound port is unknown
+			name:     "bind without the listen port info",
+			syscalls: []string{"bind"},
+			capAdd:   []string{CapNetBindService},
+			flags:    "--cap-drop=ALL --cap-add=NET_BIND_SERVICE --security-opt=no-new-privileges",
+		},
+		{
+			name:     "privileged port without bind",
+			syscalls: []string{"read"},
+			ports:    listenPorts(443),
+			flags:    "--cap-drop=ALL --security-opt=no-new-privileges",
+		},
+		{
+			name:     "namespaces and mounts",
+			syscalls: []string{"unshare", "mount", "setns"},
+			capAdd:   []string{CapSysAdmin},
+			flags:    "--cap-drop=ALL --cap-add=SYS_ADMIN --security-opt=no-new-privileges",
+		},
+	}
+
+	for _, test := range tt {
+		r := NewReport("x86_64", syscallStats(test.syscalls...), test.ports, nil)
+		if !reflect.DeepEqual(r.CapAdd, test.capAdd) {
+			t.Errorf("%s: cap_add - got %v expected %v", test.name, r.CapAdd, test.capAdd)
+		}
+
+		if r.AllowPrivilegeEscalation != test.escalation {
+			t.Errorf("%s: allow_privilege_escalation - got %v expected %v", test.name, r.AllowPrivilegeEscalation, test.escalation)
+		}
+
+		if r.DockerRunFlags != test.flags {
+			t.Errorf("%s: docker_run_flags - got '%s' expected '%s'", test.name, r.DockerRunFlags, test.flags)
+		}
+
+		if len(r.Used) != len(test.capAdd) {
+			t.Errorf("%s: used - got %d capabilities expected %d", test.name, len(r.Used), len(test.capAdd))
+		}
+	}
+}
+
+func TestNewReportUsedSyscalls(t *testing.T) {
+	r := NewReport("x86_64", syscallStats("setuid", "setresuid", "chown"), nil, nil)
+	expected := []*CapabilityInfo{
+		{Name: CapChown, Syscalls: []string{"chown"}},
+		{Name: CapSetuid, Syscalls: []string{"setresuid", "setuid"}},
+	}
+
+	if !reflect.DeepEqual(r.Used, expected) {
+		t.Errorf("got %+v expected %+v", r.Used, expected)
+	}
+}
+
+func TestNewReportPodSecurityContext(t *testing.T) {
+	uid := uint32(1000)
+	gid := uint32(2000)
+	root := uint32(0)
+
+	r := NewReport("x86_64", nil, nil, &report.ProcessInfo{UID: &uid, GID: &gid})
+	psc := r.PodSecurityContext
+	if psc == nil || psc.SeccompProfile == nil || psc.SeccompProfile.Type != "RuntimeDefault" {
+		t.Fatalf("missing the runtime default seccomp profile - %+v", psc)
+	}
+
+	if psc.RunAsNonRoot == nil || !*psc.RunAsNonRoot ||
+		psc.RunAsUser == nil || *psc.RunAsUser != 1000 ||
+		psc.RunAsGroup == nil || *psc.RunAsGroup != 2000 {
+		t.Errorf("unexpected non-root app user settings - %+v", psc)
+	}
+
+	for _, mainProcess := range []*report.ProcessInfo{nil, {}, {UID: &root, GID: &root}} {
+		psc := NewReport("x86_64", nil, nil, mainProcess).PodSecurityContext
+		if psc.RunAsNonRoot != nil || psc.RunAsUser != nil || psc.RunAsGroup != nil {
+			t.Errorf("unexpected app user settings for %+v - %+v", mainProcess, psc)
+		}
+	}
+}
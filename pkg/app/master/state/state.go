@@ -0,0 +1,227 @@
+// Package state manages the image state directories (the artifacts from the past command runs).
+// Each target image has its own state directory keyed by the image ID.
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+var (
+	ErrRunNotFound  = errors.New("state run not found")
+	ErrAmbiguousRun = errors.New("ambiguous state run ID prefix")
+)
+
+// Store is the image state directory store
+type Store struct {
+	Location string
+}
+
+// RunInfo describes the saved state for one target image
+type RunInfo struct {
+	//image ID (without the hash type prefix)
+	ID       string    `json:"id"`
+	Location string    `json:"location"`
+	Updated  time.Time `json:"updated"`
+	Size     int64     `json:"size"`
+	Files    int       `json:"files"`
+}
+
+// FileInfo describes a file in the saved state
+type FileInfo struct {
+	//path relative to the run state directory
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// NewStore creates a new state store for the state path
+func NewStore(statePrefix string) *Store {
+	return &Store{
+		Location: fsutil.ImageStateDir(statePrefix),
+	}
+}
+
+// List returns the saved runs (the most recently updated runs first)
+func (s *Store) List() ([]*RunInfo, error) {
+	infos, err := ioutil.ReadDir(s.Location)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var runs []*RunInfo
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+
+		runs = append(runs, s.runInfo(info.Name()))
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Updated.After(runs[j].Updated)
+	})
+
+	return runs, nil
+}
+
+// Find returns the saved run for the image ID or for the unique image ID prefix
+// (the hash type prefix, e.g. 'sha256:', is optional)
+func (s *Store) Find(imageID string) (*RunInfo, error) {
+	id := runKey(imageID)
+	if id == "" {
+		return nil, ErrRunNotFound
+	}
+
+	runs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var found *RunInfo
+	for _, run := range runs {
+		if run.ID == id {
+			return run, nil
+		}
+
+		if strings.HasPrefix(run.ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("%w - '%s'", ErrAmbiguousRun, imageID)
+			}
+
+			found = run
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w - '%s'", ErrRunNotFound, imageID)
+	}
+
+	return found, nil
+}
+
+// Files returns the files saved for the run
+func (s *Store) Files(run *RunInfo) ([]*FileInfo, error) {
+	var files []*FileInfo
+	err := filepath.Walk(run.Location, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(run.Location, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, &FileInfo{
+			Path:     relPath,
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+
+		return nil
+	})
+
+	return files, err
+}
+
+// Remove removes the saved run state
+func (s *Store) Remove(run *RunInfo) error {
+	return os.RemoveAll(run.Location)
+}
+
+// Archive saves the run state to a tar file
+func (s *Store) Archive(run *RunInfo, archivePath string) error {
+	return fsutil.ArchiveDir(archivePath, run.Location, filepath.Dir(run.Location)+"/", "")
+}
+
+// Prune selects the runs to remove: the runs older than maxAge
+// and the oldest runs over the maxSize total size limit (zero values disable the limits)
+func Prune(runs []*RunInfo, maxAge time.Duration, maxSize int64) []*RunInfo {
+	//oldest runs first
+	sorted := make([]*RunInfo, len(runs))
+	copy(sorted, runs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Updated.Before(sorted[j].Updated)
+	})
+
+	var totalSize int64
+	for _, run := range sorted {
+		totalSize += run.Size
+	}
+
+	var selected []*RunInfo
+	for _, run := range sorted {
+		switch {
+		case maxAge > 0 && time.Since(run.Updated) > maxAge:
+		case maxSize > 0 && totalSize > maxSize:
+		default:
+			continue
+		}
+
+		selected = append(selected, run)
+		totalSize -= run.Size
+	}
+
+	return selected
+}
+
+func (s *Store) runInfo(id string) *RunInfo {
+	run := &RunInfo{
+		ID:       id,
+		Location: filepath.Join(s.Location, id),
+	}
+
+	if info, err := os.Stat(run.Location); err == nil {
+		run.Updated = info.ModTime()
+	}
+
+	err := filepath.Walk(run.Location, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().After(run.Updated) {
+			run.Updated = info.ModTime()
+		}
+
+		if info.Mode().IsRegular() {
+			run.Size += info.Size()
+			run.Files++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Debugf("state.Store.runInfo: error scanning state (%s) - %v", run.Location, err)
+	}
+
+	return run
+}
+
+func runKey(imageID string) string {
+	if idx := strings.Index(imageID, ":"); idx != -1 {
+		imageID = imageID[idx+1:]
+	}
+
+	return imageID
+}
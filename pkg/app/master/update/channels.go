@@ -0,0 +1,98 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	vchecker "github.com/docker-slim/docker-slim/pkg/app/master/version"
+	vinfo "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// Release channels
+const (
+	ChannelStable  = "stable"
+	ChannelBeta    = "beta"
+	ChannelNightly = "nightly"
+)
+
+// Channels lists the supported release channels
+var Channels = []string{ChannelStable, ChannelBeta, ChannelNightly}
+
+// IsValidChannel returns true if the release channel is supported
+func IsValidChannel(channel string) bool {
+	for _, name := range Channels {
+		if name == channel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// channelInfo is the release channel manifest ('<download endpoint>/channels/<channel>.json')
+type channelInfo struct {
+	Channel string `json:"channel"`
+	Version string `json:"version"`
+}
+
+// checkChannel returns the version check info for the release channel
+// (the stable channel uses the version check API)
+func checkChannel(channel string, inContainer, isDSImage bool) (*vchecker.CheckVersionInfo, error) {
+	if channel == "" || channel == ChannelStable {
+		return vchecker.Check(inContainer, isDSImage), nil
+	}
+
+	data, err := fetchFile(fmt.Sprintf("%s/channels/%s.json", downloadEndpoint, channel))
+	if err != nil {
+		return nil, err
+	}
+
+	var info channelInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+
+	if info.Version == "" {
+		return nil, fmt.Errorf("no version in the '%s' channel manifest", channel)
+	}
+
+	return &vchecker.CheckVersionInfo{
+		Status:   "success",
+		Outdated: info.Version != vinfo.Tag(),
+		Current:  info.Version,
+	}, nil
+}
+
+// fetchFile downloads a small file (manifests, checksums and signatures)
+func fetchFile(location string) ([]byte, error) {
+	client := http.Client{
+		Timeout: 13 * time.Second,
+	}
+
+	req, err := http.NewRequest("GET", location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(hdrUserAgent, fmt.Sprintf("DockerSlimApp/%s", vinfo.Current()))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errUnexpectedHTTPStatus
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
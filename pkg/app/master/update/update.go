@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	vchecker "github.com/docker-slim/docker-slim/pkg/app/master/version"
@@ -31,21 +32,63 @@ const (
 	sensorAppName    = "docker-slim-sensor"
 	distDirName      = "dist"
 	artifactsPerms   = 0740
+	//the release state dir for the offline updates from local bundles
+	offlineReleaseDirName = "offline"
+	sensorPinFileName     = "sensor.pin"
 )
 
 var (
 	errUnexpectedHTTPStatus = errors.New("unexpected HTTP status code")
 )
 
-// Run checks the current version and updates it if it doesn't match the latest available version
-func Run(doDebug bool, statePath string, inContainer, isDSImage, doShowProgress bool) {
+// Options are the update options
+type Options struct {
+	//release channel (stable by default)
+	Channel string
+	//local release bundle for the offline updates
+	BundlePath    string
+	PublicKeyPath string
+	SkipVerify    bool
+	//update only the sensor (pinning it if Version is set)
+	SensorOnly bool
+	//release version to install instead of the latest version in the release channel
+	Version string
+}
+
+// Run checks the current version and updates it if it doesn't match the latest available version in the release channel
+// (or the selected version; or updates it from the local release bundle if the bundle path is provided)
+func Run(doDebug bool, statePath string, inContainer, isDSImage, doShowProgress bool, opts Options) {
 	logger := log.WithFields(log.Fields{"app": "docker-slim", "command": "update"})
 
 	appPath, err := os.Executable()
 	errutil.FailOn(err)
 	appDirPath := filepath.Dir(appPath)
 
-	vstatus := vchecker.Check(inContainer, isDSImage)
+	if err := checkVerifyKey(opts); err != nil {
+		logger.Debugf("release signing public key error: %v", err)
+		printVerifyError(err)
+		return
+	}
+
+	if opts.BundlePath != "" {
+		runOffline(logger, appDirPath, statePath, opts)
+		return
+	}
+
+	var vstatus *vchecker.CheckVersionInfo
+	if opts.Version != "" {
+		//the selected version is installed even if it's older than the current version
+		vstatus = &vchecker.CheckVersionInfo{
+			Status:   "success",
+			Outdated: opts.Version != vinfo.Tag(),
+			Current:  opts.Version,
+		}
+	} else {
+		vstatus, err = checkChannel(opts.Channel, inContainer, isDSImage)
+		if err != nil {
+			logger.Debugf("error checking the '%s' release channel: %v", opts.Channel, err)
+		}
+	}
 	logger.Debugf("Version Status => %+v", vstatus)
 
 	if vstatus == nil || vstatus.Status != "success" {
@@ -54,13 +97,15 @@ func Run(doDebug bool, statePath string, inContainer, isDSImage, doShowProgress
 		return
 	}
 
-	if !vstatus.Outdated {
+	//the sensor can be different from the master (e.g., pinned), so the sensor-only updates don't depend on the master version
+	if !vstatus.Outdated && !opts.SensorOnly {
 		fmt.Printf("docker-slim[update]: info=status message='already using the current version'\n")
 		fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
 		return
 	}
 
-	fmt.Printf("docker-slim[update]: info=version local=%s current=%s\n", vinfo.Tag(), vstatus.Current)
+	fmt.Printf("docker-slim[update]: info=version local=%s current=%s channel=%s sensor.only=%v\n",
+		vinfo.Tag(), vstatus.Current, opts.Channel, opts.SensorOnly)
 
 	blobNameBase, blobNameExt := getReleaseBlobInfo()
 	errutil.FailWhen(blobNameBase == "", "could not discover platform-specific release package name")
@@ -106,6 +151,25 @@ func Run(doDebug bool, statePath string, inContainer, isDSImage, doShowProgress
 
 	fmt.Println("docker-slim[update]: state=update.download.completed")
 
+	if opts.SkipVerify {
+		printSkipVerify()
+	} else {
+		checksums, signature, err := downloadChecksums(vstatus.Current)
+		if err == nil {
+			err = verifyRelease(blobPath, blobName, checksums, signature, opts.PublicKeyPath)
+		}
+
+		if err != nil {
+			logger.Debugf("error verifying release package: %v", err)
+			//not keeping the bad package, so the next update doesn't treat it as already downloaded
+			os.Remove(blobPath)
+			printVerifyError(err)
+			return
+		}
+
+		fmt.Println("docker-slim[update]: state=update.verified")
+	}
+
 	if err := unpackRelease(logger, blobPath, releaseDirPath, blobNameBase); err != nil {
 		logger.Debugf("error unpacking release package: %v", err)
 		fmt.Printf("docker-slim[update]: info=status message='error unpacking release package'\n")
@@ -115,7 +179,7 @@ func Run(doDebug bool, statePath string, inContainer, isDSImage, doShowProgress
 
 	fmt.Println("docker-slim[update]: state=update.unpacked")
 
-	if err := installRelease(logger, appDirPath, statePath, releaseDirPath); err != nil {
+	if err := installRelease(logger, appDirPath, statePath, releaseDirPath, opts); err != nil {
 		logger.Debugf("error installing release: %v", err)
 		fmt.Printf("docker-slim[update]: info=status message='error installing release'\n")
 		fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
@@ -126,6 +190,118 @@ func Run(doDebug bool, statePath string, inContainer, isDSImage, doShowProgress
 	fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
 }
 
+// runOffline updates the app from a local release bundle
+// (the release package file or a directory with the release package; the checksums files are in the same directory)
+func runOffline(logger *log.Entry, appDirPath, statePath string, opts Options) {
+	bundlePath := opts.BundlePath
+	blobNameBase, blobNameExt := getReleaseBlobInfo()
+	errutil.FailWhen(blobNameBase == "", "could not discover platform-specific release package name")
+
+	blobName := fmt.Sprintf("%s.%s", blobNameBase, blobNameExt)
+	bundleDirPath := bundlePath
+	bundleBlobPath := filepath.Join(bundlePath, blobName)
+	if !fsutil.IsDir(bundlePath) {
+		bundleDirPath = filepath.Dir(bundlePath)
+		bundleBlobPath = bundlePath
+		blobName = filepath.Base(bundlePath)
+	}
+
+	if !fsutil.IsRegularFile(bundleBlobPath) {
+		fmt.Printf("docker-slim[update]: info=status message='release package not found in the bundle' location='%s'\n", bundleBlobPath)
+		fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
+		return
+	}
+
+	fmt.Printf("docker-slim[update]: info=bundle location='%s'\n", bundleBlobPath)
+
+	if opts.SkipVerify {
+		printSkipVerify()
+	} else {
+		checksums, err := ioutil.ReadFile(filepath.Join(bundleDirPath, checksumsFileName))
+		var signature []byte
+		if err == nil {
+			signature, err = ioutil.ReadFile(filepath.Join(bundleDirPath, checksumsSigFileName))
+		}
+
+		if err == nil {
+			err = verifyRelease(bundleBlobPath, blobName, checksums, signature, opts.PublicKeyPath)
+		}
+
+		if err != nil {
+			logger.Debugf("error verifying release bundle: %v", err)
+			printVerifyError(err)
+			return
+		}
+
+		fmt.Println("docker-slim[update]: state=update.verified")
+	}
+
+	releaseDirPath, statePath := fsutil.PrepareReleaseStateDirs(statePath, offlineReleaseDirName)
+
+	//the offline release dir is reused, so the files unpacked from the previous bundles are removed
+	for _, name := range []string{distDirName, blobNameBase} {
+		if err := os.RemoveAll(filepath.Join(releaseDirPath, name)); err != nil {
+			logger.Debugf("error removing old offline release files: %v", err)
+		}
+	}
+
+	//unpacking a copy (unpackRelease removes the release package)
+	blobPath := filepath.Join(releaseDirPath, fmt.Sprintf("%s.%s", blobNameBase, blobNameExt))
+	if err := fsutil.CopyRegularFile(false, bundleBlobPath, blobPath, false); err != nil {
+		logger.Debugf("error copying release bundle: %v", err)
+		fmt.Printf("docker-slim[update]: info=status message='error copying release package'\n")
+		fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
+		return
+	}
+
+	if err := unpackRelease(logger, blobPath, releaseDirPath, blobNameBase); err != nil {
+		logger.Debugf("error unpacking release package: %v", err)
+		fmt.Printf("docker-slim[update]: info=status message='error unpacking release package'\n")
+		fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
+		return
+	}
+
+	fmt.Println("docker-slim[update]: state=update.unpacked")
+
+	if err := installRelease(logger, appDirPath, statePath, releaseDirPath, opts); err != nil {
+		logger.Debugf("error installing release: %v", err)
+		fmt.Printf("docker-slim[update]: info=status message='error installing release'\n")
+		fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
+		return
+	}
+
+	fmt.Println("docker-slim[update]: state=update.installed")
+	fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
+}
+
+// downloadChecksums downloads the release checksums and their signature
+func downloadChecksums(version string) ([]byte, []byte, error) {
+	checksums, err := fetchFile(fmt.Sprintf("%s/%s/%s", downloadEndpoint, version, checksumsFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signature, err := fetchFile(fmt.Sprintf("%s/%s/%s", downloadEndpoint, version, checksumsSigFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return checksums, signature, nil
+}
+
+func printSkipVerify() {
+	fmt.Printf("docker-slim[update]: info=status message='skipping release package verification'\n")
+}
+
+func printVerifyError(err error) {
+	fmt.Printf("docker-slim[update]: info=status message='release package verification failed' error='%v'\n", err)
+	if errors.Is(err, errNoPublicKey) {
+		fmt.Printf("docker-slim[update]: info=hint message='this build has no embedded release signing public key, so the update is disabled; provide the key with --public-key (or use --skip-verify if you trust the release package)'\n")
+	}
+
+	fmt.Printf("docker-slim[update]: state=exited version=%s\n", vinfo.Current())
+}
+
 func getReleaseBlobInfo() (base string, ext string) {
 	switch runtime.GOOS {
 	case "darwin":
@@ -294,20 +470,40 @@ func unpackRelease(logger *log.Entry, blobPath, releaseRootPath, blobNameBase st
 	return nil
 }
 
-func installRelease(logger *log.Entry, appRootPath, statePath, releaseRootPath string) error {
+func installRelease(logger *log.Entry, appRootPath, statePath, releaseRootPath string, opts Options) error {
 	newMasterAppPath := filepath.Join(releaseRootPath, distDirName, masterAppName)
 	newSensorAppPath := filepath.Join(releaseRootPath, distDirName, sensorAppName)
 	sensorAppPath := filepath.Join(appRootPath, sensorAppName)
+	pinPath := sensorPinPath(releaseRootPath)
+
+	pinnedVersion := loadSensorPin(pinPath)
+	if pinnedVersion != "" && !opts.SensorOnly {
+		fmt.Printf("docker-slim[update]: info=status message='keeping the pinned sensor (use --sensor-only to update it)' sensor.version=%s\n", pinnedVersion)
+	} else {
+		err := updateFile(logger, newSensorAppPath, sensorAppPath)
+		if err != nil {
+			return err
+		}
 
-	err := updateFile(logger, newSensorAppPath, sensorAppPath)
-	if err != nil {
-		return err
+		//will copy the sensor to the state dir if DS is installed in a bad non-shared location on Macs
+		fsutil.PreparePostUpdateStateDir(statePath)
 	}
 
-	//will copy the sensor to the state dir if DS is installed in a bad non-shared location on Macs
-	fsutil.PreparePostUpdateStateDir(statePath)
+	if opts.SensorOnly {
+		//the sensor is pinned when the version is selected explicitly
+		//(it's unpinned when it's updated to the latest version)
+		if err := saveSensorPin(pinPath, opts.Version); err != nil {
+			logger.Debugf("installRelease: error saving sensor pin - %v", err)
+		}
 
-	err = updateFile(logger, newMasterAppPath, "")
+		if opts.Version != "" {
+			fmt.Printf("docker-slim[update]: info=status message='sensor pinned' sensor.version=%s\n", opts.Version)
+		}
+
+		return nil
+	}
+
+	err := updateFile(logger, newMasterAppPath, "")
 	if err != nil {
 		return err
 	}
@@ -315,6 +511,35 @@ func installRelease(logger *log.Entry, appRootPath, statePath, releaseRootPath s
 	return nil
 }
 
+// sensorPinPath returns the location of the sensor pin file
+// (it's shared by all releases, so it's in the parent directory of the release state directories)
+func sensorPinPath(releaseRootPath string) string {
+	return filepath.Join(filepath.Dir(releaseRootPath), sensorPinFileName)
+}
+
+// loadSensorPin returns the pinned sensor version (empty if the sensor is not pinned)
+func loadSensorPin(pinPath string) string {
+	data, err := ioutil.ReadFile(pinPath)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// saveSensorPin saves the pinned sensor version (removing the pin file if the version is empty)
+func saveSensorPin(pinPath, version string) error {
+	if version == "" {
+		if err := os.Remove(pinPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	return ioutil.WriteFile(pinPath, []byte(version+"\n"), artifactsPerms)
+}
+
 func updateFile(logger *log.Entry, sourcePath, targetPath string) error {
 	file, err := os.Open(sourcePath)
 	if err != nil {
@@ -0,0 +1,156 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	checksumsFileName    = "checksums.txt"
+	checksumsSigFileName = "checksums.txt.sig"
+)
+
+// releasePublicKey is the base64 encoded Ed25519 public key used to sign the release checksums
+// (set at build time: -ldflags "-X github.com/docker-slim/docker-slim/pkg/app/master/update.releasePublicKey=...")
+var releasePublicKey = ""
+
+var (
+	errNoPublicKey       = errors.New("no release signing public key")
+	errBadPublicKey      = errors.New("malformed release signing public key")
+	errBadSignature      = errors.New("release checksums signature verification failed")
+	errNoChecksum        = errors.New("no checksum for the release package")
+	errChecksumMismatch  = errors.New("release package checksum mismatch")
+	errMalformedChecksum = errors.New("malformed release checksums")
+)
+
+// checkVerifyKey checks that the release package can be verified before the update starts
+// (the verification is mandatory unless it's skipped explicitly, so the update is refused
+// if the build has no embedded release key and no public key file is provided)
+func checkVerifyKey(opts Options) error {
+	if opts.SkipVerify {
+		return nil
+	}
+
+	_, err := loadPublicKey(opts.PublicKeyPath)
+	return err
+}
+
+// loadPublicKey returns the release signing public key
+// (from the key file if it's provided or the key embedded at build time)
+func loadPublicKey(keyPath string) (ed25519.PublicKey, error) {
+	encoded := releasePublicKey
+	if keyPath != "" {
+		data, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded = string(data)
+	}
+
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, errNoPublicKey
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errBadPublicKey
+	}
+
+	return ed25519.PublicKey(raw), nil
+}
+
+// verifyChecksums checks the checksums signature (the signature data is base64 encoded)
+func verifyChecksums(key ed25519.PublicKey, checksums, signature []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errBadSignature
+	}
+
+	if !ed25519.Verify(key, checksums, sig) {
+		return errBadSignature
+	}
+
+	return nil
+}
+
+// parseChecksums parses the checksums in the 'sha256sum' format ('<hex digest>  <file name>')
+func parseChecksums(checksums []byte) (map[string]string, error) {
+	records := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errMalformedChecksum
+		}
+
+		//the binary mode marker is optional
+		records[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// verifyBlob checks the release package against its checksum record
+func verifyBlob(blobPath, blobName string, checksums []byte) error {
+	records, err := parseChecksums(checksums)
+	if err != nil {
+		return err
+	}
+
+	expected, found := records[blobName]
+	if !found {
+		return fmt.Errorf("%w - %s", errNoChecksum, blobName)
+	}
+
+	file, err := os.Open(blobPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+		return fmt.Errorf("%w - %s (expected=%s actual=%s)", errChecksumMismatch, blobName, expected, actual)
+	}
+
+	return nil
+}
+
+// verifyRelease checks the checksums signature and then the release package checksum
+func verifyRelease(blobPath, blobName string, checksums, signature []byte, publicKeyPath string) error {
+	key, err := loadPublicKey(publicKeyPath)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyChecksums(key, checksums, signature); err != nil {
+		return err
+	}
+
+	return verifyBlob(blobPath, blobName, checksums)
+}
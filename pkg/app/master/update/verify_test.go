@@ -0,0 +1,56 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckVerifyKey(t *testing.T) {
+	defer func(key string) { releasePublicKey = key }(releasePublicKey)
+
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(pub)
+
+	//the update is refused without the release key (fail closed)
+	releasePublicKey = ""
+	if err := checkVerifyKey(Options{}); err != errNoPublicKey {
+		t.Errorf("no key - got %v expected %v", err, errNoPublicKey)
+	}
+
+	if err := checkVerifyKey(Options{SkipVerify: true}); err != nil {
+		t.Errorf("skip verify - got %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "release.pub")
+	if err := ioutil.WriteFile(keyPath, []byte(encoded+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyKey(Options{PublicKeyPath: keyPath}); err != nil {
+		t.Errorf("key file - got %v", err)
+	}
+
+	releasePublicKey = encoded
+	if err := checkVerifyKey(Options{}); err != nil {
+		t.Errorf("embedded key - got %v", err)
+	}
+
+	releasePublicKey = "bad"
+	if err := checkVerifyKey(Options{}); err != errBadPublicKey {
+		t.Errorf("bad key - got %v expected %v", err, errBadPublicKey)
+	}
+}
@@ -83,6 +83,8 @@ func GetCheckVersionVerdict(info *CheckVersionInfo) string {
 // Print shows the master app version information
 func Print(printPrefix string, logger *log.Entry, client *docker.Client, checkVersion, inContainer, isDSImage bool) {
 	fmt.Printf("%s info=app version='%s' container=%v dsimage=%v\n", printPrefix, v.Current(), inContainer, isDSImage)
+	binfo := v.Build()
+	fmt.Printf("%s info=app commit=%s build_time=%s go=%s platform=%s\n", printPrefix, binfo.Commit, binfo.BuildTime, binfo.GoVersion, binfo.Platform)
 	if checkVersion {
 		vinfo := Check(inContainer, isDSImage)
 		outdated := "unknown"
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/term"
+)
+
+// Progress units
+const (
+	ProgressUnitBytes = "bytes"
+	ProgressUnitItems = "items"
+)
+
+const (
+	progressBarWidth     = 30
+	progressRenderPeriod = 100 * time.Millisecond
+	//how often the progress is logged when the console is not a terminal
+	progressLogPeriod = 10 * time.Second
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+var progressOut = os.Stderr
+
+// progressLine tracks the progress line rendered on the terminal,
+// so the other console output can clear it before printing
+var progressLine struct {
+	mu    sync.Mutex
+	shown bool
+}
+
+// clearProgressLine removes the rendered progress line (it's redrawn on the next progress update)
+func clearProgressLine() {
+	progressLine.mu.Lock()
+	defer progressLine.mu.Unlock()
+
+	if progressLine.shown {
+		fmt.Fprint(progressOut, "\r\033[K")
+		progressLine.shown = false
+	}
+}
+
+func renderProgressLine(line string) {
+	progressLine.mu.Lock()
+	defer progressLine.mu.Unlock()
+
+	fmt.Fprintf(progressOut, "\r\033[K%s", line)
+	progressLine.shown = true
+}
+
+// finishProgressLine renders the final progress line (it stays on the terminal)
+func finishProgressLine(line string) {
+	progressLine.mu.Lock()
+	defer progressLine.mu.Unlock()
+
+	fmt.Fprintf(progressOut, "\r\033[K%s\n", line)
+	progressLine.shown = false
+}
+
+// Progress shows the progress of a long running operation.
+// It renders a progress bar (or a spinner if the total is unknown) when the console is a terminal
+// and it falls back to periodic 'progress' info lines otherwise.
+type Progress struct {
+	out     *Output
+	name    string
+	unit    string
+	spinner bool
+	current int64
+	total   int64
+	start   time.Time
+	tty     bool
+	//true if the progress was rendered or logged at least once
+	logged   bool
+	doneCh   chan struct{}
+	stopped  chan struct{}
+	doneOnce sync.Once
+}
+
+// NewProgress starts tracking the progress of an operation
+// (the total can be zero if it's not known yet; use Update to set it later)
+func (ref *Output) NewProgress(name string, total int64, unit string) *Progress {
+	return ref.startProgress(name, total, unit, false)
+}
+
+// NewSpinner starts tracking an operation with no measurable progress
+func (ref *Output) NewSpinner(name string) *Progress {
+	return ref.startProgress(name, 0, ProgressUnitItems, true)
+}
+
+func (ref *Output) startProgress(name string, total int64, unit string, spinner bool) *Progress {
+	p := &Progress{
+		out:     ref,
+		name:    name,
+		unit:    unit,
+		spinner: spinner,
+		total:   total,
+		start:   time.Now(),
+		tty:     ref.progressTTY(),
+		doneCh:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go p.run()
+	return p
+}
+
+// progressTTY returns true if the progress can be rendered in place on the terminal
+func (ref *Output) progressTTY() bool {
+	return consoleEnabled() &&
+		!IsQuiet() &&
+		ref.JSONFlag == cfText &&
+		term.IsTerminal(int(progressOut.Fd()))
+}
+
+// Update sets the current progress and the total (a non-positive total keeps the current total)
+func (p *Progress) Update(current, total int64) {
+	if p == nil {
+		return
+	}
+
+	atomic.StoreInt64(&p.current, current)
+	if total > 0 {
+		atomic.StoreInt64(&p.total, total)
+	}
+}
+
+// Add increments the current progress
+func (p *Progress) Add(delta int64) {
+	if p == nil {
+		return
+	}
+
+	atomic.AddInt64(&p.current, delta)
+}
+
+// Done stops tracking the progress (it's safe to call it more than once)
+func (p *Progress) Done() {
+	if p == nil {
+		return
+	}
+
+	p.doneOnce.Do(func() {
+		close(p.doneCh)
+		<-p.stopped
+	})
+}
+
+func (p *Progress) run() {
+	defer close(p.stopped)
+
+	period := progressLogPeriod
+	if p.tty {
+		period = progressRenderPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		select {
+		case <-ticker.C:
+			if p.tty {
+				p.logged = true
+				renderProgressLine(p.line(frame))
+			} else {
+				p.log("running")
+			}
+		case <-p.doneCh:
+			//the final progress is shown only if the progress was shown before
+			//(no extra output for the quick operations)
+			switch {
+			case !p.logged:
+			case p.tty:
+				finishProgressLine(p.line(-1))
+			default:
+				p.log("done")
+			}
+
+			return
+		}
+	}
+}
+
+func (p *Progress) log(status string) {
+	p.logged = true
+	current := atomic.LoadInt64(&p.current)
+	total := atomic.LoadInt64(&p.total)
+	vars := OutVars{
+		"name":    p.name,
+		"status":  status,
+		"elapsed": time.Since(p.start).Round(time.Second).String(),
+	}
+
+	if !p.spinner {
+		vars["completed"] = p.amount(current)
+		if total > 0 {
+			vars["total"] = p.amount(total)
+			vars["percent"] = percent(current, total)
+		}
+	}
+
+	p.out.Info("progress", vars)
+}
+
+// line creates the progress line (frame is negative for the final line)
+func (p *Progress) line(frame int) string {
+	elapsed := time.Since(p.start).Round(time.Second)
+	if p.spinner {
+		if frame < 0 {
+			return fmt.Sprintf("%s done (%s)", p.name, elapsed)
+		}
+
+		return fmt.Sprintf("%s %s (%s)", p.name, spinnerFrames[frame%len(spinnerFrames)], elapsed)
+	}
+
+	current := atomic.LoadInt64(&p.current)
+	total := atomic.LoadInt64(&p.total)
+	if total <= 0 {
+		return fmt.Sprintf("%s %s (%s)", p.name, p.amount(current), elapsed)
+	}
+
+	pct := percent(current, total)
+	filled := progressBarWidth * pct / 100
+	return fmt.Sprintf("%s [%s%s] %3d%% %s/%s (%s)",
+		p.name,
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled),
+		pct,
+		p.amount(current),
+		p.amount(total),
+		elapsed)
+}
+
+func (p *Progress) amount(value int64) string {
+	if p.unit == ProgressUnitBytes {
+		return humanize.Bytes(uint64(value))
+	}
+
+	return fmt.Sprintf("%d", value)
+}
+
+func percent(current, total int64) int {
+	if total <= 0 {
+		return 0
+	}
+
+	if current >= total {
+		return 100
+	}
+
+	return int(current * 100 / total)
+}
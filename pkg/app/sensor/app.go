@@ -4,6 +4,7 @@
 package app
 
 import (
+	"encoding/json"
 	"flag"
 	"os"
 	"path/filepath"
@@ -20,6 +21,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/sysenv"
 	"github.com/docker-slim/docker-slim/pkg/system"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/version"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -72,6 +74,7 @@ func startMonitor(errorCh chan error,
 	stopWorkAck chan bool,
 	pids chan []int,
 	ptmonStartChan chan int,
+	appEvents *ptrace.AppEvents,
 	cmd *command.StartMonitor,
 	dirName string) bool {
 	origPaths, err := getCurrentPaths("/")
@@ -111,6 +114,7 @@ func startMonitor(errorCh chan error,
 		startAckChan,
 		ptmonStartChan,
 		stopMonitor,
+		appEvents,
 		cmd.AppName,
 		cmd.AppArgs,
 		dirName,
@@ -124,11 +128,16 @@ func startMonitor(errorCh chan error,
 		return false
 	}
 
+	portTracker := newListenPortTracker()
+	go portTracker.run(stopMonitor)
+
 	go func() {
 		log.Debug("sensor: monitor.worker - waiting to stop monitoring...")
 		<-stopWork
 		log.Debug("sensor: monitor.worker - stop message...")
 
+		//the app is still running (its listen ports are gone after the monitors are stopped)
+		portTracker.collect()
 		close(stopMonitor)
 
 		log.Debug("sensor: monitor.worker - processing data...")
@@ -141,7 +150,7 @@ func startMonitor(errorCh chan error,
 			//TODO: when peReport is available filter file events from fanReport
 		}
 
-		processReports(cmd, mountPoint, origPaths, fanReport, ptReport, peReport)
+		processReports(cmd, mountPoint, origPaths, fanReport, ptReport, peReport, portTracker.list())
 		stopWorkAck <- true
 	}()
 
@@ -154,12 +163,18 @@ var (
 	enableDebug  bool
 	logLevelName string
 	logFormat    string
+	showVersion  bool
+	standalone   bool
+	commandFile  string
 )
 
 func init() {
+	flag.BoolVar(&showVersion, "version", false, "print the sensor build metadata (JSON) and exit")
 	flag.BoolVar(&enableDebug, "d", false, "enable debug logging")
 	flag.StringVar(&logLevelName, "log-level", "info", "set the logging level ('debug', 'info' (default), 'warn', 'error', 'fatal', 'panic')")
 	flag.StringVar(&logFormat, "log-format", "text", "set the format used by logs ('text' (default), or 'json')")
+	flag.BoolVar(&standalone, "standalone", false, "run without the master (monitor the app passed after '--' until it exits or until the sensor is stopped)")
+	flag.StringVar(&commandFile, "command-file", defaultCommandFile, "the 'start monitor' command file (JSON) used in the standalone mode")
 }
 
 /////////
@@ -168,6 +183,20 @@ func init() {
 func Run() {
 	flag.Parse()
 
+	if showVersion {
+		//used by the master to get the sensor version (the protocol version is included for the compatibility checks)
+		info := struct {
+			*version.BuildInfo
+			ProtocolVersion int `json:"protocol_version"`
+		}{
+			BuildInfo:       version.Build(),
+			ProtocolVersion: command.ProtocolVersion,
+		}
+
+		errutil.FailOn(json.NewEncoder(os.Stdout).Encode(&info))
+		return
+	}
+
 	err := configureLogger(enableDebug, logLevelName, logFormat)
 	errutil.FailOn(err)
 
@@ -185,6 +214,11 @@ func Run() {
 	errutil.WarnOn(err)
 	log.Debugf("sensor: cwd => %#v", dirName)
 
+	if standalone {
+		runStandalone(dirName)
+		return
+	}
+
 	initSignalHandlers()
 	defer func() {
 		log.Debug("deferred cleanup on shutdown...")
@@ -241,7 +275,7 @@ doneRunning:
 					log.Debugf("sensor: 'start' monitor command - run app as user='%s'", data.AppUser)
 				}
 
-				started := startMonitor(errorCh, monStartAckChan, monDoneChan, monDoneAckChan, pidsChan, ptmonStartChan, data, dirName)
+				started := startMonitor(errorCh, monStartAckChan, monDoneChan, monDoneAckChan, pidsChan, ptmonStartChan, nil, data, dirName)
 				if !started {
 					log.Info("sensor: monitor not started...")
 					time.Sleep(3 * time.Second) //give error event time to get sent
@@ -23,6 +23,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/detectors/binfile"
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/inspectors/ospkgs"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/inspectors/sodeps"
 	"github.com/docker-slim/docker-slim/pkg/certdiscover"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
@@ -227,11 +228,15 @@ func saveResults(
 	fileNames map[string]*report.ArtifactProps,
 	fanMonReport *report.FanMonitorReport,
 	ptMonReport *report.PtMonitorReport,
-	peReport *report.PeMonitorReport) {
+	peReport *report.PeMonitorReport,
+	listenPorts []*report.ListenPortInfo) {
 	log.Debugf("saveResults(%v,...)", len(fileNames))
 
 	artifactDirName := defaultArtifactDirName
 	artifactStore := newArtifactStore(artifactDirName, origPaths, fileNames, fanMonReport, ptMonReport, peReport, cmd)
+	artifactStore.listenPorts = listenPorts
+	//keep the hardlinked files as hardlinks in the slim filesystem
+	fsutil.TrackHardlinks(true)
 	artifactStore.prepareArtifacts()
 	artifactStore.saveArtifacts()
 	//artifactStore.archiveArtifacts() //alternative way to xfer artifacts
@@ -252,6 +257,9 @@ type artifactStore struct {
 	cmd           *command.StartMonitor
 	appStacks     map[string]*appStackInfo
 	origPaths     map[string]interface{}
+	includeDeps   []*report.IncludeDepsInfo
+	essentials    *report.RuntimeEssentialsInfo
+	listenPorts   []*report.ListenPortInfo //recorded while the app was running
 }
 
 func newArtifactStore(
@@ -516,7 +524,7 @@ func (p *artifactStore) resolveLinks() {
 
 		fileInfo, err := os.Lstat(fpath)
 		if err != nil {
-			log.Debugf("resolveLinks.files - os.Lstat(%s) error: ", fpath, err)
+			log.Debugf("resolveLinks.files - os.Lstat(%s) error: %v", fpath, err)
 			continue
 		}
 
@@ -527,7 +535,7 @@ func (p *artifactStore) resolveLinks() {
 
 		linkRef, err := os.Readlink(fpath)
 		if err != nil {
-			log.Debugf("resolveLinks.files - os.Readlink(%s) error: ", fpath, err)
+			log.Debugf("resolveLinks.files - os.Readlink(%s) error: %v", fpath, err)
 			continue
 		}
 
@@ -1042,7 +1050,7 @@ copyFiles:
 			if isNuxtConfigFile(fileName) {
 				nuxtConfig, err := getNuxtConfig(fileName)
 				if err != nil {
-					log.Warn("saveArtifacts: failed to get nuxt config: %v", err)
+					log.Warnf("saveArtifacts: failed to get nuxt config: %v", err)
 					continue
 				}
 				if nuxtConfig == nil {
@@ -1329,7 +1337,7 @@ copyIncludes:
 	}
 
 	for _, exePath := range p.cmd.IncludeExes {
-		exeArtifacts, err := sodeps.AllExeDependencies(exePath, true)
+		exeArtifacts, err := p.binDependencies(exePath, true)
 		if err != nil {
 			log.Warnf("saveArtifacts - %v - error getting exe artifacts => %v\n", exePath, err)
 			continue
@@ -1347,7 +1355,7 @@ copyIncludes:
 	}
 
 	for _, binPath := range p.cmd.IncludeBins {
-		binArtifacts, err := sodeps.AllDependencies(binPath)
+		binArtifacts, err := p.binDependencies(binPath, false)
 		if err != nil {
 			log.Warnf("saveArtifacts - %v - error getting bin artifacts => %v\n", binPath, err)
 			continue
@@ -1364,6 +1372,30 @@ copyIncludes:
 		}
 	}
 
+	if len(p.cmd.IncludePkgs) > 0 {
+		pkgDB, err := ospkgs.Load()
+		if err == ospkgs.ErrNoRpmTool {
+			log.Warnf("saveArtifacts - %v (use --include-path to keep the package files) => %v", err, p.cmd.IncludePkgs)
+		} else if err != nil {
+			log.Warnf("saveArtifacts - error loading package database => %v", err)
+		} else {
+			pkgArtifacts, missing := pkgDB.Files(p.cmd.IncludePkgs, p.cmd.IncludePkgDeps)
+			if len(missing) > 0 {
+				log.Warnf("saveArtifacts - packages not installed (%s) => %s", pkgDB.Manager, strings.Join(missing, ","))
+			}
+
+			log.Debugf("saveArtifacts - include packages %v (%s): artifacts (%d):\n%v\n",
+				p.cmd.IncludePkgs, pkgDB.Manager, len(pkgArtifacts), strings.Join(pkgArtifacts, "\n"))
+
+			for _, apath := range pkgArtifacts {
+				dstPath := fmt.Sprintf("%s/files%s", p.storeLocation, apath)
+				if err := fsutil.CopyFile(p.cmd.KeepPerms, apath, dstPath, true); err != nil {
+					log.Warnf("CopyFile(%v,%v) error: %v", apath, dstPath, err)
+				}
+			}
+		}
+	}
+
 	if p.cmd.IncludeShell {
 		shellArtifacts, err := shellDependencies()
 		if err == nil {
@@ -1384,6 +1416,10 @@ copyIncludes:
 
 	p.saveCertsData()
 
+	if p.cmd.IncludeRuntimeEssentials {
+		p.essentials = p.saveRuntimeEssentials()
+	}
+
 	if fsutil.DirExists("/tmp") {
 		tdTargetPath := fmt.Sprintf("%s/files/tmp", p.storeLocation)
 		if !fsutil.DirExists(tdTargetPath) {
@@ -1425,7 +1461,7 @@ copyIncludes:
 	}
 
 	if len(p.cmd.Preserves) > 0 {
-		log.Debug("saveArtifacts: restoring preserved paths - %d", len(p.cmd.Preserves))
+		log.Debugf("saveArtifacts: restoring preserved paths - %d", len(p.cmd.Preserves))
 
 		preservedDirPath := filepath.Join(p.storeLocation, preservedDirName)
 		filesDirPath := filepath.Join(p.storeLocation, filesDirName)
@@ -1454,6 +1490,8 @@ copyIncludes:
 			log.Debug("saveArtifacts(): preserved root path doesnt exist")
 		}
 	}
+
+	p.saveRunAsUser()
 }
 
 func (p *artifactStore) detectAppStack(fileName string) {
@@ -1640,6 +1678,37 @@ func (p *artifactStore) archiveArtifacts() {
 	errutil.FailOn(err)
 }
 
+// binDependencies resolves the ELF dependency closure for the included binary
+// (falling back to ldd if the binary can't be parsed)
+func (p *artifactStore) binDependencies(binPath string, isExe bool) ([]string, error) {
+	var info *sodeps.ClosureInfo
+	var err error
+	if isExe {
+		info, err = sodeps.ExeELFDependencies(binPath)
+	} else {
+		info, err = sodeps.ELFDependencies(binPath)
+	}
+
+	if err != nil {
+		log.Debugf("saveArtifacts - %v - error resolving ELF dependencies (using ldd) => %v", binPath, err)
+		if isExe {
+			return sodeps.AllExeDependencies(binPath, true)
+		}
+
+		return sodeps.AllDependencies(binPath)
+	}
+
+	p.includeDeps = append(p.includeDeps, &report.IncludeDepsInfo{
+		Target:          info.Target,
+		Interpreter:     info.Interpreter,
+		Libraries:       info.Libraries,
+		DlopenLibraries: info.DlopenLibraries,
+		Files:           info.Files,
+	})
+
+	return info.Files, nil
+}
+
 func (p *artifactStore) saveReport() {
 	sort.Strings(p.nameList)
 
@@ -1665,6 +1734,25 @@ func (p *artifactStore) saveReport() {
 		creport.Image.Files = append(creport.Image.Files, p.rawNames[fname])
 	}
 
+	creport.Image.IncludeDeps = p.includeDeps
+	creport.Image.RuntimeEssentials = p.essentials
+
+	creport.Image.Xattrs = collectXattrs(filepath.Join(p.storeLocation, filesDirName))
+
+	if len(p.listenPorts) > 0 {
+		creport.Network = &report.NetworkReport{
+			ListenPorts: p.listenPorts,
+		}
+	}
+
+	for _, issue := range fsutil.XattrIssues() {
+		creport.Image.XattrIssues = append(creport.Image.XattrIssues, &report.XattrIssueInfo{
+			Path:  issue.Path,
+			Name:  issue.Name,
+			Error: issue.Error,
+		})
+	}
+
 	reportName := report.DefaultContainerReportFileName
 
 	_, err := os.Stat(p.storeLocation)
@@ -1688,13 +1776,43 @@ func (p *artifactStore) saveReport() {
 	errutil.FailOn(err)
 }
 
+// collectXattrs returns the extended attributes for the file objects in the artifact files directory
+func collectXattrs(filesDir string) map[string]map[string][]byte {
+	all := map[string]map[string][]byte{}
+	filepath.Walk(filesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == filesDir {
+			return nil
+		}
+
+		attrs, err := fsutil.Xattrs(path)
+		if err != nil || len(attrs) == 0 {
+			return nil
+		}
+
+		all[strings.TrimPrefix(path, filesDir)] = attrs
+		return nil
+	})
+
+	if len(all) == 0 {
+		return nil
+	}
+
+	return all
+}
+
 func getFileHash(artifactFileName string) (string, error) {
-	fileData, err := ioutil.ReadFile(artifactFileName)
+	file, err := os.Open(artifactFileName)
 	if err != nil {
 		return "", err
 	}
+	defer file.Close()
+
+	hasher := sha1.New()
+	if _, err := fsutil.CopyStream(hasher, file); err != nil {
+		return "", err
+	}
 
-	hash := sha1.Sum(fileData)
+	hash := hasher.Sum(nil)
 	return hex.EncodeToString(hash[:]), nil
 }
 
@@ -1755,7 +1873,7 @@ func cpFile(src, dst string) error {
 		}
 	}
 
-	if _, err := io.Copy(d, s); err != nil {
+	if _, err := fsutil.CopyStream(d, s); err != nil {
 		d.Close()
 		return err
 	}
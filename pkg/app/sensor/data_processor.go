@@ -23,7 +23,8 @@ func processReports(
 	origPaths map[string]interface{},
 	fanReport *report.FanMonitorReport,
 	ptReport *report.PtMonitorReport,
-	peReport *report.PeMonitorReport) {
+	peReport *report.PeMonitorReport,
+	listenPorts []*report.ListenPortInfo) {
 
 	fileCount := 0
 	for _, processFileMap := range fanReport.ProcessFiles {
@@ -38,7 +39,7 @@ func processReports(
 
 	log.Debugf("processReports(): len(fanReport.ProcessFiles)=%v / fileCount=%v", len(fanReport.ProcessFiles), fileCount)
 	allFilesMap := findSymlinks(fileList, mountPoint)
-	saveResults(cmd, origPaths, allFilesMap, fanReport, ptReport, peReport)
+	saveResults(cmd, origPaths, allFilesMap, fanReport, ptReport, peReport, listenPorts)
 }
 
 func getProcessChildren(pid int, targetPidList map[int]bool, processChildrenMap map[int][]int) {
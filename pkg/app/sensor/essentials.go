@@ -0,0 +1,271 @@
+//go:build linux
+// +build linux
+
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/certdiscover"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+// Runtime essentials related consts
+const (
+	passwdFilePath    = "/etc/passwd"
+	groupFilePath     = "/etc/group"
+	nsswitchFilePath  = "/etc/nsswitch.conf"
+	localtimeFilePath = "/etc/localtime"
+	timezoneFilePath  = "/etc/timezone"
+	zoneinfoDirPath   = "/usr/share/zoneinfo"
+	tzEnvVar          = "TZ"
+)
+
+// the libraries (and the library name prefixes) indicating TLS usage
+var tlsLibPrefixes = []string{
+	"libssl.so",
+	"libcrypto.so",
+	"libgnutls.so",
+	"libnss3.so",
+	"libmbedtls.so",
+	"libwolfssl.so",
+	"_ssl.",
+}
+
+// the libraries indicating user and group lookups
+var userLookupLibPrefixes = []string{
+	"libnss_files.so",
+	"libnss_compat.so",
+}
+
+// the users and groups always kept in the minimal passwd and group files
+var essentialAccounts = map[string]struct{}{
+	"root":    {},
+	"nobody":  {},
+	"nogroup": {},
+}
+
+// saveRuntimeEssentials keeps the CA certificates, the timezone data and the minimal passwd/group files
+// when the profiled application uses TLS, timezone lookups or user lookups
+// (the application might not use them while it's profiled, so the related libraries and files are the hints)
+func (p *artifactStore) saveRuntimeEssentials() *report.RuntimeEssentialsInfo {
+	info := &report.RuntimeEssentialsInfo{}
+	for _, name := range p.nameList {
+		base := filepath.Base(name)
+		switch {
+		case hasAnyPrefix(base, tlsLibPrefixes),
+			certdiscover.IsCertFile(name),
+			certdiscover.IsCACertFile(name),
+			certdiscover.IsCertDirPath(name),
+			certdiscover.IsCACertDirPath(name):
+			info.TLS = true
+		case name == localtimeFilePath,
+			name == timezoneFilePath,
+			strings.HasPrefix(name, zoneinfoDirPath+"/"):
+			info.Timezone = true
+		case name == passwdFilePath,
+			name == groupFilePath,
+			name == nsswitchFilePath,
+			hasAnyPrefix(base, userLookupLibPrefixes):
+			info.Users = true
+		}
+	}
+
+	if os.Getenv(tzEnvVar) != "" {
+		info.Timezone = true
+	}
+
+	if p.cmd.AppUser != "" {
+		info.Users = true
+	}
+
+	log.Debugf("saveRuntimeEssentials: detected - %+v", info)
+
+	if info.TLS {
+		for _, list := range [][]string{certdiscover.CertFileList(), certdiscover.CACertFileList()} {
+			for _, fname := range list {
+				p.copyEssentialPath(fname)
+			}
+		}
+
+		for _, list := range [][]string{certdiscover.CertDirList(), certdiscover.CACertDirList()} {
+			for _, dname := range list {
+				p.copyEssentialPath(dname)
+			}
+		}
+	}
+
+	if info.Timezone {
+		p.copyEssentialPath(localtimeFilePath)
+		p.copyEssentialPath(timezoneFilePath)
+		p.copyEssentialPath(zoneinfoDirPath)
+	}
+
+	if info.Users {
+		users, groups := minimalAccounts(p.cmd.AppUser, p.nameList)
+		p.saveAccountFile(passwdFilePath, users, 2)
+		p.saveAccountFile(groupFilePath, groups, 2)
+		p.copyEssentialPath(nsswitchFilePath)
+	}
+
+	return info
+}
+
+// copyEssentialPath copies the file or directory (if it exists)
+// including the target when the path is a symlink (e.g., '/etc/localtime')
+func (p *artifactStore) copyEssentialPath(srcPath string) {
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return
+	}
+
+	dstPath := fmt.Sprintf("%s/files%s", p.storeLocation, srcPath)
+	if info.IsDir() {
+		err, errs := fsutil.CopyDir(p.cmd.KeepPerms, srcPath, dstPath, true, true, nil, nil, nil)
+		if err != nil {
+			log.Warnf("saveRuntimeEssentials: fsutil.CopyDir(%v,%v) error - %v", srcPath, dstPath, err)
+		}
+
+		if len(errs) > 0 {
+			//the directory might already have some of the files (used during profiling)
+			log.Debugf("saveRuntimeEssentials: fsutil.CopyDir(%v,%v) copy errors - %+v", srcPath, dstPath, errs)
+		}
+
+		return
+	}
+
+	if err := fsutil.CopyFile(p.cmd.KeepPerms, srcPath, dstPath, true); err != nil {
+		log.Warnf("saveRuntimeEssentials: fsutil.CopyFile(%v,%v) error - %v", srcPath, dstPath, err)
+		return
+	}
+
+	if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+		if target, err := filepath.EvalSymlinks(srcPath); err == nil && target != srcPath {
+			if !fsutil.Exists(fmt.Sprintf("%s/files%s", p.storeLocation, target)) {
+				p.copyEssentialPath(target)
+			}
+		}
+	}
+}
+
+// minimalAccounts returns the user and group names (or IDs) to keep for the app user
+// and for the owners of the files used by the app
+// (the app user can be 'name', 'uid', 'name:group' or 'uid:gid')
+func minimalAccounts(appUser string, fileNames []string) (map[string]struct{}, map[string]struct{}) {
+	users := map[string]struct{}{}
+	groups := map[string]struct{}{}
+	for name := range essentialAccounts {
+		users[name] = struct{}{}
+		groups[name] = struct{}{}
+	}
+
+	users["0"] = struct{}{}
+	groups["0"] = struct{}{}
+
+	for _, name := range fileNames {
+		if info, err := os.Lstat(name); err == nil {
+			if sysStat, ok := info.Sys().(*syscall.Stat_t); ok {
+				users[strconv.FormatUint(uint64(sysStat.Uid), 10)] = struct{}{}
+				groups[strconv.FormatUint(uint64(sysStat.Gid), 10)] = struct{}{}
+			}
+		}
+	}
+
+	if appUser == "" {
+		return users, groups
+	}
+
+	parts := strings.SplitN(appUser, ":", 2)
+	users[parts[0]] = struct{}{}
+	if len(parts) > 1 && parts[1] != "" {
+		groups[parts[1]] = struct{}{}
+	}
+
+	//the primary group of the app user
+	for _, line := range readLines(passwdFilePath) {
+		fields := strings.Split(line, ":")
+		if len(fields) < 4 {
+			continue
+		}
+
+		if fields[0] == parts[0] || fields[2] == parts[0] {
+			groups[fields[3]] = struct{}{}
+		}
+	}
+
+	return users, groups
+}
+
+// saveAccountFile saves the minimal passwd or group file
+// with the entries matching the selected names (or IDs in the idField column)
+func (p *artifactStore) saveAccountFile(srcPath string, selected map[string]struct{}, idField int) {
+	lines := readLines(srcPath)
+	if lines == nil {
+		return
+	}
+
+	var kept []string
+	for _, line := range lines {
+		fields := strings.Split(line, ":")
+		if len(fields) <= idField {
+			continue
+		}
+
+		_, nameFound := selected[fields[0]]
+		_, idFound := selected[fields[idField]]
+		if nameFound || idFound {
+			kept = append(kept, line)
+		}
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+
+	dstPath := fmt.Sprintf("%s/files%s", p.storeLocation, srcPath)
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0777); err != nil {
+		log.Warnf("saveRuntimeEssentials: error creating directory for %v - %v", dstPath, err)
+		return
+	}
+
+	data := strings.Join(kept, "\n") + "\n"
+	if err := ioutil.WriteFile(dstPath, []byte(data), info.Mode().Perm()); err != nil {
+		log.Warnf("saveRuntimeEssentials: error saving %v - %v", dstPath, err)
+	}
+}
+
+func readLines(filePath string) []string {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,370 @@
+package ospkgs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+// Inspector errors
+var (
+	ErrNoPackageDB = errors.New("no package database")
+	ErrNoRpmTool   = errors.New("no rpm tool or supported rpm database file to read the rpm package database")
+)
+
+const (
+	dpkgInfoDirPath  = "/var/lib/dpkg/info"
+	rpmExeName       = "rpm"
+	rpmDBDirPath     = "/var/lib/rpm"
+	rpmDBDirPathAlt  = "/usr/lib/sysimage/rpm"
+	rpmQueryTimeout  = 60 * time.Second
+	maxPackageDepLen = 10000
+)
+
+// the rpm database files (the SQLite, NDB and Berkeley DB formats)
+var rpmDBFileNames = []string{
+	filepath.Base(system.RpmSqliteDBFile),
+	filepath.Base(system.RpmNdbDBFile),
+	filepath.Base(system.RpmPackagesDBFile),
+}
+
+// PackageManager types
+const (
+	PMApk  = "apk"
+	PMDpkg = "dpkg"
+	PMRpm  = "rpm"
+)
+
+// PackageInfo contains the installed package info
+type PackageInfo struct {
+	Name     string
+	Depends  []string
+	Provides []string
+	Files    []string
+}
+
+// PackageDB contains the installed packages
+type PackageDB struct {
+	Manager  string
+	Packages map[string]*PackageInfo
+	//capability (or virtual package) name to package name
+	providers map[string]string
+}
+
+// Load loads the package database for the package manager used in the filesystem
+func Load() (*PackageDB, error) {
+	switch {
+	case fileExists(system.ApkInstalledDBFile):
+		return loadApkDB(system.ApkInstalledDBFile)
+	case fileExists(system.DpkgStatusDBFile):
+		return loadDpkgDB(system.DpkgStatusDBFile, dpkgInfoDirPath)
+	case fileExists(rpmDBDirPathAlt):
+		return loadRpmDB(rpmDBDirPathAlt)
+	case fileExists(rpmDBDirPath):
+		return loadRpmDB(rpmDBDirPath)
+	}
+
+	return nil, ErrNoPackageDB
+}
+
+// Files returns the files owned by the named packages
+// (including the files owned by their dependencies if withDeps is true)
+// and the names of the packages that are not installed
+func (db *PackageDB) Files(names []string, withDeps bool) ([]string, []string) {
+	selected := map[string]struct{}{}
+	var missing []string
+	queue := []string{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		pkgName := db.lookup(name)
+		if pkgName == "" {
+			missing = append(missing, name)
+			continue
+		}
+
+		queue = append(queue, pkgName)
+	}
+
+	for len(queue) > 0 && len(selected) < maxPackageDepLen {
+		pkgName := queue[0]
+		queue = queue[1:]
+		if _, found := selected[pkgName]; found {
+			continue
+		}
+
+		selected[pkgName] = struct{}{}
+		if !withDeps {
+			continue
+		}
+
+		for _, dep := range db.Packages[pkgName].Depends {
+			if depName := db.lookup(dep); depName != "" {
+				queue = append(queue, depName)
+			} else {
+				log.Debugf("ospkgs.Files: %s - unresolved dependency '%s'", pkgName, dep)
+			}
+		}
+	}
+
+	seen := map[string]struct{}{}
+	var files []string
+	for pkgName := range selected {
+		for _, fp := range db.Packages[pkgName].Files {
+			if _, found := seen[fp]; found {
+				continue
+			}
+
+			seen[fp] = struct{}{}
+			//the directories are not included (only the files owned by the packages)
+			if info, err := os.Lstat(fp); err == nil && !info.IsDir() {
+				files = append(files, fp)
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, missing
+}
+
+// lookup returns the name of the installed package matching the package (or capability) name
+// (the first installed package is used when there are alternatives)
+func (db *PackageDB) lookup(name string) string {
+	for _, alt := range strings.Split(name, "|") {
+		if _, found := db.Packages[alt]; found {
+			return alt
+		}
+
+		if pkgName, found := db.providers[alt]; found {
+			return pkgName
+		}
+	}
+
+	return ""
+}
+
+func (db *PackageDB) add(pkg *PackageInfo) {
+	if pkg == nil || pkg.Name == "" {
+		return
+	}
+
+	db.Packages[pkg.Name] = pkg
+	for _, name := range pkg.Provides {
+		if _, found := db.providers[name]; !found {
+			db.providers[name] = pkg.Name
+		}
+	}
+}
+
+func newPackageDB(manager string) *PackageDB {
+	return &PackageDB{
+		Manager:   manager,
+		Packages:  map[string]*PackageInfo{},
+		providers: map[string]string{},
+	}
+}
+
+// loadApkDB loads the Alpine package database
+func loadApkDB(filePath string) (*PackageDB, error) {
+	return loadPackageDB(PMApk, system.OSPackageDBApk, filePath, nil)
+}
+
+// loadDpkgDB loads the Debian package database
+// (the package records are in the status file and the package files are in the '.list' files)
+func loadDpkgDB(statusFilePath, infoDirPath string) (*PackageDB, error) {
+	return loadPackageDB(PMDpkg, system.OSPackageDBDpkg, statusFilePath, func(pkg *system.OSPackageInfo) []string {
+		listFilePath := filepath.Join(infoDirPath, pkg.Name+".list")
+		if pkg.Arch != "" && pkg.Arch != "all" && !fileExists(listFilePath) {
+			listFilePath = filepath.Join(infoDirPath, pkg.Name+":"+pkg.Arch+".list")
+		}
+
+		return readLines(listFilePath)
+	})
+}
+
+// loadPackageDB loads the package database file with the shared package database parsers
+// (the optional pkgFiles function returns the package files when they are not in the database file)
+func loadPackageDB(manager, dbType, filePath string, pkgFiles func(pkg *system.OSPackageInfo) []string) (*PackageDB, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	packages, err := system.NewOSPackageInfoFromData(dbType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	db := newPackageDB(manager)
+	for _, pkg := range packages {
+		files := pkg.Files
+		if pkgFiles != nil {
+			files = pkgFiles(pkg)
+		}
+
+		db.add(&PackageInfo{
+			Name:     pkg.Name,
+			Depends:  pkg.Depends,
+			Provides: pkg.Provides,
+			Files:    files,
+		})
+	}
+
+	return db, nil
+}
+
+// loadRpmDB loads the RPM package database
+// (the database format depends on the distro (Berkeley DB, SQLite or NDB),
+// so the rpm tool in the image is used to query it if it's available,
+// otherwise the database file is parsed directly;
+// no package data is returned if any query fails)
+func loadRpmDB(dbDirPath string) (*PackageDB, error) {
+	rpmExePath, err := exec.LookPath(rpmExeName)
+	if err != nil {
+		log.Debugf("ospkgs.loadRpmDB(%s): rpm tool not found - %v", dbDirPath, err)
+		return loadRpmDBFile(dbDirPath)
+	}
+
+	db := newPackageDB(PMRpm)
+	getPkg := func(name string) *PackageInfo {
+		pkg, found := db.Packages[name]
+		if !found {
+			pkg = &PackageInfo{Name: name}
+			db.Packages[name] = pkg
+		}
+
+		return pkg
+	}
+
+	queries := []string{
+		"[%{NAME}\tF\t%{FILENAMES}\n]",
+		"[%{NAME}\tP\t%{PROVIDENAME}\n]",
+		"[%{NAME}\tR\t%{REQUIRENAME}\n]",
+	}
+
+	for _, query := range queries {
+		out, err := rpmQuery(rpmExePath, dbDirPath, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(string(out), "\n") {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 || parts[0] == "" || parts[2] == "" || parts[2] == "(none)" {
+				continue
+			}
+
+			pkg := getPkg(parts[0])
+			switch parts[1] {
+			case "F":
+				pkg.Files = append(pkg.Files, parts[2])
+				//the file dependencies (e.g., '/bin/sh') are provided by the packages that own the files
+				pkg.Provides = append(pkg.Provides, parts[2])
+			case "P":
+				pkg.Provides = append(pkg.Provides, parts[2])
+			case "R":
+				//the rpmlib capabilities are provided by the rpm tool itself
+				if !strings.HasPrefix(parts[2], "rpmlib(") {
+					pkg.Depends = append(pkg.Depends, parts[2])
+				}
+			}
+		}
+	}
+
+	for _, pkg := range db.Packages {
+		for _, name := range pkg.Provides {
+			if _, found := db.providers[name]; !found {
+				db.providers[name] = pkg.Name
+			}
+		}
+	}
+
+	return db, nil
+}
+
+// loadRpmDBFile loads the RPM package database without the rpm tool
+// (the package file paths are also provided by the packages like with the rpm tool queries)
+func loadRpmDBFile(dbDirPath string) (*PackageDB, error) {
+	for _, name := range rpmDBFileNames {
+		filePath := filepath.Join(dbDirPath, name)
+		if !fileExists(filePath) {
+			continue
+		}
+
+		db, err := loadPackageDB(PMRpm, system.OSPackageDBRpm, filePath, nil)
+		if err != nil {
+			log.Debugf("ospkgs.loadRpmDBFile(%s): error loading the package database - %v", filePath, err)
+			return nil, ErrNoRpmTool
+		}
+
+		for _, pkg := range db.Packages {
+			pkg.Provides = append(pkg.Provides, pkg.Files...)
+			for _, fp := range pkg.Files {
+				if _, found := db.providers[fp]; !found {
+					db.providers[fp] = pkg.Name
+				}
+			}
+		}
+
+		return db, nil
+	}
+
+	return nil, ErrNoRpmTool
+}
+
+func rpmQuery(rpmExePath, dbDirPath, query string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rpmQueryTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, rpmExePath, "--dbpath", dbDirPath, "-qa", "--qf", query)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		return nil, fmt.Errorf("rpm query error (dbpath=%s) - %v", dbDirPath, err)
+	}
+
+	return out, nil
+}
+
+func readLines(filePath string) []string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
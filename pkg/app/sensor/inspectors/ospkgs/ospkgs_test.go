@@ -0,0 +1,296 @@
+package ospkgs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testApkDB = `C:Q1abc=
+P:musl
+V:1.2.2-r7
+p:so:libc.musl-x86_64.so.1=1
+F:lib
+R:ld-musl-x86_64.so.1
+R:libc.musl-x86_64.so.1
+
+P:busybox
+V:1.34.1-r3
+D:so:libc.musl-x86_64.so.1 !busybox-extras
+p:/bin/sh cmd:busybox=1.34.1-r3
+F:bin
+R:busybox
+F:etc
+R:securetty
+
+P:ca-certificates-bundle
+D:
+F:etc/ssl/certs
+R:ca-certificates.crt
+`
+
+const testDpkgStatus = `Package: libc6
+Status: install ok installed
+Architecture: amd64
+Version: 2.31-13
+Depends: libgcc-s1, libcrypt1 (>= 1:4.4.10-10~)
+Description: GNU C Library
+ Contains the standard libraries.
+
+Package: curl
+Status: install ok installed
+Architecture: amd64
+Pre-Depends: libc6 (>= 2.17)
+Depends: libcurl4 (= 7.74.0-1.3), zlib1g | zlib-ng [amd64], python3:any
+Provides: curl-client
+
+Package: removed-pkg
+Status: deinstall ok config-files
+Architecture: amd64
+
+Package: tzdata
+Status: install ok installed
+Architecture: all
+`
+
+func writeTestFile(t *testing.T, filePath, data string) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filePath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ospkgs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestLoadApkDB(t *testing.T) {
+	dbPath := filepath.Join(newTestDir(t), "installed")
+	writeTestFile(t, dbPath, testApkDB)
+
+	db, err := loadApkDB(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Manager != PMApk {
+		t.Errorf("manager - got %s expected %s", db.Manager, PMApk)
+	}
+
+	expected := map[string]*PackageInfo{
+		"musl": {
+			Name:     "musl",
+			Provides: []string{"so:libc.musl-x86_64.so.1"},
+			Files:    []string{"/lib/ld-musl-x86_64.so.1", "/lib/libc.musl-x86_64.so.1"},
+		},
+		"busybox": {
+			Name:     "busybox",
+			Depends:  []string{"so:libc.musl-x86_64.so.1"},
+			Provides: []string{"/bin/sh", "cmd:busybox"},
+			Files:    []string{"/bin/busybox", "/etc/securetty"},
+		},
+		"ca-certificates-bundle": {
+			Name:  "ca-certificates-bundle",
+			Files: []string{"/etc/ssl/certs/ca-certificates.crt"},
+		},
+	}
+
+	if !reflect.DeepEqual(db.Packages, expected) {
+		t.Errorf("packages - got %+v expected %+v", db.Packages, expected)
+	}
+
+	tt := []struct {
+		name     string
+		expected string
+	}{
+		{name: "busybox", expected: "busybox"},
+		{name: "so:libc.musl-x86_64.so.1", expected: "musl"},
+		{name: "cmd:busybox", expected: "busybox"},
+		{name: "bash|/bin/sh", expected: "busybox"},
+		{name: "bash", expected: ""},
+	}
+
+	for _, test := range tt {
+		if actual := db.lookup(test.name); actual != test.expected {
+			t.Errorf("lookup(%s) - got '%s' expected '%s'", test.name, actual, test.expected)
+		}
+	}
+}
+
+func TestLoadDpkgDB(t *testing.T) {
+	dir := newTestDir(t)
+	statusPath := filepath.Join(dir, "status")
+	infoDir := filepath.Join(dir, "info")
+	writeTestFile(t, statusPath, testDpkgStatus)
+	//the multi-arch package file lists have the architecture suffix
+	writeTestFile(t, filepath.Join(infoDir, "libc6:amd64.list"), "/.\n/lib/x86_64-linux-gnu/libc.so.6\n\n")
+	writeTestFile(t, filepath.Join(infoDir, "curl.list"), "/usr/bin/curl\n")
+	writeTestFile(t, filepath.Join(infoDir, "tzdata.list"), "/usr/share/zoneinfo/UTC\n")
+
+	db, err := loadDpkgDB(statusPath, infoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Manager != PMDpkg {
+		t.Errorf("manager - got %s expected %s", db.Manager, PMDpkg)
+	}
+
+	expected := map[string]*PackageInfo{
+		"libc6": {
+			Name:    "libc6",
+			Depends: []string{"libgcc-s1", "libcrypt1"},
+			Files:   []string{"/.", "/lib/x86_64-linux-gnu/libc.so.6"},
+		},
+		"curl": {
+			Name:     "curl",
+			Depends:  []string{"libc6", "libcurl4", "zlib1g|zlib-ng", "python3"},
+			Provides: []string{"curl-client"},
+			Files:    []string{"/usr/bin/curl"},
+		},
+		"tzdata": {
+			Name:  "tzdata",
+			Files: []string{"/usr/share/zoneinfo/UTC"},
+		},
+	}
+
+	if !reflect.DeepEqual(db.Packages, expected) {
+		t.Errorf("packages - got %+v expected %+v", db.Packages, expected)
+	}
+
+	if actual := db.lookup("curl-client"); actual != "curl" {
+		t.Errorf("lookup(curl-client) - got '%s' expected 'curl'", actual)
+	}
+}
+
+func TestPackageDBFiles(t *testing.T) {
+	dir := newTestDir(t)
+	muslLib := filepath.Join(dir, "lib", "libc.musl.so")
+	busyboxBin := filepath.Join(dir, "bin", "busybox")
+	writeTestFile(t, muslLib, "lib")
+	writeTestFile(t, busyboxBin, "bin")
+
+	db := newPackageDB(PMApk)
+	db.add(&PackageInfo{
+		Name:     "musl",
+		Provides: []string{"so:libc.musl.so"},
+		Files:    []string{filepath.Join(dir, "lib"), muslLib},
+	})
+	db.add(&PackageInfo{
+		Name:    "busybox",
+		Depends: []string{"so:libc.musl.so", "missing-dep"},
+		Files:   []string{busyboxBin, filepath.Join(dir, "bin", "removed")},
+	})
+
+	files, missing := db.Files([]string{"busybox", " ", "bash"}, false)
+	if !reflect.DeepEqual(files, []string{busyboxBin}) || !reflect.DeepEqual(missing, []string{"bash"}) {
+		t.Errorf("no deps - got %v (missing %v)", files, missing)
+	}
+
+	//the directories and the files that don't exist are not included
+	files, missing = db.Files([]string{"busybox"}, true)
+	if !reflect.DeepEqual(files, []string{busyboxBin, muslLib}) || len(missing) != 0 {
+		t.Errorf("with deps - got %v (missing %v)", files, missing)
+	}
+}
+
+func TestLoadRpmDBNoTool(t *testing.T) {
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", newTestDir(t))
+
+	db, err := loadRpmDB(newTestDir(t))
+	if err != ErrNoRpmTool || db != nil {
+		t.Errorf("got db=%v err=%v expected err=%v", db, err, ErrNoRpmTool)
+	}
+}
+
+// newTestRpmNDB creates an NDB rpm database with one package header
+// (the header has the package name and the file path tags)
+func newTestRpmNDB(name string, dirNames, baseNames []string, dirIndexes []uint32) []byte {
+	var index, store bytes.Buffer
+	addTag := func(tag, dataType uint32, count int, data []byte) {
+		binary.Write(&index, binary.BigEndian, []uint32{tag, dataType, uint32(store.Len()), uint32(count)})
+		store.Write(data)
+	}
+
+	addTag(1000, 6, 1, []byte(name+"\x00"))
+	addTag(1118, 8, len(dirNames), []byte(strings.Join(dirNames, "\x00")+"\x00"))
+	addTag(1117, 8, len(baseNames), []byte(strings.Join(baseNames, "\x00")+"\x00"))
+	store.Write(make([]byte, (4-store.Len()%4)%4))
+	var indexes bytes.Buffer
+	binary.Write(&indexes, binary.BigEndian, dirIndexes)
+	addTag(1116, 4, len(dirIndexes), indexes.Bytes())
+
+	var blob bytes.Buffer
+	binary.Write(&blob, binary.BigEndian, []uint32{uint32(index.Len() / 16), uint32(store.Len())})
+	blob.Write(index.Bytes())
+	blob.Write(store.Bytes())
+
+	//the slot page (the database header, one package slot and the empty slots) and the package blob
+	db := make([]byte, 4096)
+	binary.LittleEndian.PutUint32(db[0:], 'R'|'p'<<8|'m'<<16|'P'<<24)
+	binary.LittleEndian.PutUint32(db[12:], 1)
+	for offset := 32; offset < len(db); offset += 16 {
+		binary.LittleEndian.PutUint32(db[offset:], 'S'|'l'<<8|'o'<<16|'t'<<24)
+	}
+
+	binary.LittleEndian.PutUint32(db[36:], 1)
+	binary.LittleEndian.PutUint32(db[40:], 4096/16)
+
+	var blobHeader bytes.Buffer
+	binary.Write(&blobHeader, binary.LittleEndian, []uint32{'B' | 'l'<<8 | 'b'<<16 | 'S'<<24, 1, 0, uint32(blob.Len())})
+	return append(append(db, blobHeader.Bytes()...), blob.Bytes()...)
+}
+
+func TestLoadRpmDBFile(t *testing.T) {
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", newTestDir(t))
+
+	//the rpm database file is parsed directly when the rpm tool is not available
+	dir := newTestDir(t)
+	writeTestFile(t, filepath.Join(dir, "Packages.db"),
+		string(newTestRpmNDB("bash", []string{"/usr/bin/"}, []string{"bash", "sh"}, []uint32{0, 0})))
+
+	db, err := loadRpmDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]*PackageInfo{
+		"bash": {
+			Name:     "bash",
+			Provides: []string{"/usr/bin/bash", "/usr/bin/sh"},
+			Files:    []string{"/usr/bin/bash", "/usr/bin/sh"},
+		},
+	}
+
+	if db.Manager != PMRpm || !reflect.DeepEqual(db.Packages, expected) {
+		t.Errorf("got %s %+v expected %+v", db.Manager, db.Packages, expected)
+	}
+
+	if actual := db.lookup("/usr/bin/sh"); actual != "bash" {
+		t.Errorf("lookup(/usr/bin/sh) - got '%s' expected 'bash'", actual)
+	}
+
+	//the database files that can't be parsed are reported like the missing rpm tool
+	writeTestFile(t, filepath.Join(dir, "rpmdb.sqlite"), "SQLite format 3\x00bad")
+	if db, err := loadRpmDB(dir); err != ErrNoRpmTool || db != nil {
+		t.Errorf("bad database - got db=%v err=%v expected err=%v", db, err, ErrNoRpmTool)
+	}
+}
@@ -0,0 +1,393 @@
+package sodeps
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ldCacheFilePath   = "/etc/ld.so.cache"
+	ldCacheMagic      = "glibc-ld.so.cache1.1"
+	ldCacheHeaderSize = 48
+	ldCacheEntrySize  = 24
+	ldConfFilePath    = "/etc/ld.so.conf"
+	originVar         = "$ORIGIN"
+	originVarAlt      = "${ORIGIN}"
+	maxClosureDepth   = 32
+)
+
+var defaultLibDirs = []string{
+	"/lib64",
+	"/usr/lib64",
+	"/lib",
+	"/usr/lib",
+	"/usr/local/lib",
+}
+
+// libraries loaded with dlopen() by the libraries in the dependency closure
+// (they are never listed as DT_NEEDED dependencies)
+var dlopenDeps = map[string][]string{
+	"libc.so.6": {
+		"libnss_files.so.2",
+		"libnss_dns.so.2",
+		"libresolv.so.2",
+		//used by pthread_cancel() and the C++ exception unwinding
+		"libgcc_s.so.1",
+	},
+	"libpthread.so.0": {
+		"libgcc_s.so.1",
+	},
+}
+
+// ClosureInfo contains the ELF dependency closure for a binary
+type ClosureInfo struct {
+	Target      string
+	Interpreter string
+	//DT_NEEDED dependencies (direct and indirect)
+	Libraries []string
+	//known dlopen() dependencies
+	DlopenLibraries []string
+	//all dependency files (including the symlink targets)
+	Files []string
+}
+
+// ELFDependencies resolves the ELF dependency closure for a binary
+// (the program interpreter, the DT_NEEDED libraries and the known dlopen libraries)
+// parsing the ELF files directly (no external resolver is needed)
+func ELFDependencies(binFilePath string) (*ClosureInfo, error) {
+	if !strings.HasPrefix(binFilePath, "/") {
+		return nil, ErrFilePathNotAbs
+	}
+
+	bin, err := elf.Open(binFilePath)
+	if err != nil {
+		if _, ok := err.(*elf.FormatError); ok {
+			return nil, ErrFileNotBin
+		}
+
+		return nil, err
+	}
+
+	defer bin.Close()
+
+	resolver := newClosureResolver(bin)
+	info := &ClosureInfo{
+		Target: binFilePath,
+	}
+
+	for _, prog := range bin.Progs {
+		if prog.Type == elf.PT_INTERP {
+			data, err := ioutil.ReadAll(prog.Open())
+			if err == nil {
+				info.Interpreter = string(bytes.TrimRight(data, "\x00"))
+			}
+			break
+		}
+	}
+
+	resolved := map[string]string{}
+	resolver.walk(binFilePath, bin, resolved, 0)
+
+	for name, libPath := range resolved {
+		if libPath != "" {
+			info.Libraries = append(info.Libraries, libPath)
+		} else {
+			log.Debugf("sodeps.ELFDependencies(%v): library not found - %v", binFilePath, name)
+		}
+	}
+
+	dlopenSeen := map[string]struct{}{}
+	for name := range resolved {
+		for _, dlName := range dlopenDeps[name] {
+			if _, found := resolved[dlName]; found {
+				continue
+			}
+
+			if _, found := dlopenSeen[dlName]; found {
+				continue
+			}
+
+			dlopenSeen[dlName] = struct{}{}
+			if libPath := resolver.find(dlName, nil, filepath.Dir(binFilePath)); libPath != "" {
+				info.DlopenLibraries = append(info.DlopenLibraries, libPath)
+			}
+		}
+	}
+
+	sort.Strings(info.Libraries)
+	sort.Strings(info.DlopenLibraries)
+
+	deps := []string{binFilePath}
+	if info.Interpreter != "" {
+		deps = append(deps, info.Interpreter)
+	}
+
+	deps = append(deps, info.Libraries...)
+	deps = append(deps, info.DlopenLibraries...)
+
+	seen := map[string]struct{}{}
+	for depth := 0; len(deps) > 0; depth++ {
+		var fileDeps []string
+		fileDeps, deps = resolveDepArtifacts(deps)
+		for _, fp := range fileDeps {
+			if _, found := seen[fp]; !found {
+				seen[fp] = struct{}{}
+				info.Files = append(info.Files, fp)
+			}
+		}
+
+		if depth > 5 {
+			log.Debugf("sodeps.ELFDependencies(%v): link ref too deep - breaking", binFilePath)
+			break
+		}
+	}
+
+	return info, nil
+}
+
+// ExeELFDependencies resolves the ELF dependency closure for an executable
+// (the executable is looked up in PATH if its path is not absolute)
+func ExeELFDependencies(exeFileName string) (*ClosureInfo, error) {
+	if !strings.HasPrefix(exeFileName, "/") {
+		exePath, err := exec.LookPath(exeFileName)
+		if err != nil {
+			return nil, err
+		}
+
+		exeFileName = exePath
+	}
+
+	return ELFDependencies(exeFileName)
+}
+
+type closureResolver struct {
+	machine elf.Machine
+	class   elf.Class
+	cache   map[string][]string
+	dirs    []string
+}
+
+func newClosureResolver(bin *elf.File) *closureResolver {
+	r := &closureResolver{
+		machine: bin.Machine,
+		class:   bin.Class,
+		cache:   loadLDCache(ldCacheFilePath),
+	}
+
+	r.dirs = append(loadLDConfDirs(ldConfFilePath, 0), muslLibDirs()...)
+	r.dirs = append(r.dirs, defaultLibDirs...)
+	return r
+}
+
+// walk resolves the DT_NEEDED dependencies for the object (recursively)
+func (r *closureResolver) walk(objPath string, obj *elf.File, resolved map[string]string, depth int) {
+	if depth > maxClosureDepth {
+		return
+	}
+
+	needed, err := obj.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return
+	}
+
+	rpath, _ := obj.DynString(elf.DT_RPATH)
+	runpath, _ := obj.DynString(elf.DT_RUNPATH)
+	var searchPaths []string
+	if len(runpath) > 0 {
+		//DT_RPATH is ignored when DT_RUNPATH is set
+		searchPaths = splitSearchPaths(runpath)
+	} else {
+		searchPaths = splitSearchPaths(rpath)
+	}
+
+	origin := filepath.Dir(objPath)
+	for _, name := range needed {
+		if _, found := resolved[name]; found {
+			continue
+		}
+
+		libPath := r.find(name, searchPaths, origin)
+		resolved[name] = libPath
+		if libPath == "" {
+			continue
+		}
+
+		lib, err := elf.Open(libPath)
+		if err != nil {
+			continue
+		}
+
+		r.walk(libPath, lib, resolved, depth+1)
+		lib.Close()
+	}
+}
+
+// find returns the library file path (or an empty string if it's not found)
+func (r *closureResolver) find(name string, searchPaths []string, origin string) string {
+	if strings.Contains(name, "/") {
+		if r.compatible(name) {
+			return name
+		}
+
+		return ""
+	}
+
+	for _, dir := range searchPaths {
+		dir = strings.Replace(dir, originVarAlt, origin, -1)
+		dir = strings.Replace(dir, originVar, origin, -1)
+		if candidate := filepath.Join(dir, name); r.compatible(candidate) {
+			return candidate
+		}
+	}
+
+	for _, candidate := range r.cache[name] {
+		if r.compatible(candidate) {
+			return candidate
+		}
+	}
+
+	for _, dir := range r.dirs {
+		if candidate := filepath.Join(dir, name); r.compatible(candidate) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// compatible returns true if the file is an ELF object for the same architecture
+func (r *closureResolver) compatible(filePath string) bool {
+	obj, err := elf.Open(filePath)
+	if err != nil {
+		return false
+	}
+
+	defer obj.Close()
+	return obj.Machine == r.machine && obj.Class == r.class
+}
+
+func splitSearchPaths(values []string) []string {
+	var paths []string
+	for _, val := range values {
+		for _, p := range strings.Split(val, ":") {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	return paths
+}
+
+// loadLDCache loads the library name to path mappings from the glibc ld.so.cache file
+// (only the 'new' cache format is supported)
+func loadLDCache(filePath string) map[string][]string {
+	entries := map[string][]string{}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return entries
+	}
+
+	//the 'new' format section follows the 'old' format section if both are present
+	//and the string offsets are relative to the 'new' format header
+	start := bytes.Index(data, []byte(ldCacheMagic))
+	if start < 0 || len(data) < start+ldCacheHeaderSize {
+		log.Debugf("sodeps.loadLDCache(%v): unsupported cache format", filePath)
+		return entries
+	}
+
+	cache := data[start:]
+	count := int(binary.LittleEndian.Uint32(cache[len(ldCacheMagic):]))
+	readString := func(offset uint32) string {
+		if int(offset) >= len(cache) {
+			return ""
+		}
+
+		str := cache[offset:]
+		if end := bytes.IndexByte(str, 0); end >= 0 {
+			str = str[:end]
+		}
+
+		return string(str)
+	}
+
+	for i := 0; i < count; i++ {
+		pos := ldCacheHeaderSize + i*ldCacheEntrySize
+		if pos+ldCacheEntrySize > len(cache) {
+			break
+		}
+
+		key := readString(binary.LittleEndian.Uint32(cache[pos+4:]))
+		value := readString(binary.LittleEndian.Uint32(cache[pos+8:]))
+		if key != "" && value != "" {
+			entries[key] = append(entries[key], value)
+		}
+	}
+
+	return entries
+}
+
+// loadLDConfDirs loads the library directories from the ld.so.conf file (and its includes)
+func loadLDConfDirs(filePath string, depth int) []string {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil || depth > 5 {
+		return nil
+	}
+
+	var dirs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "include "):
+			pattern := strings.TrimSpace(strings.TrimPrefix(line, "include "))
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(filePath), pattern)
+			}
+
+			matches, _ := filepath.Glob(pattern)
+			sort.Strings(matches)
+			for _, match := range matches {
+				dirs = append(dirs, loadLDConfDirs(match, depth+1)...)
+			}
+		case strings.HasPrefix(line, "/"):
+			dirs = append(dirs, line)
+		}
+	}
+
+	return dirs
+}
+
+// muslLibDirs loads the library directories from the musl dynamic linker config files
+func muslLibDirs() []string {
+	matches, _ := filepath.Glob("/etc/ld-musl-*.path")
+	var dirs []string
+	for _, match := range matches {
+		data, err := ioutil.ReadFile(match)
+		if err != nil {
+			continue
+		}
+
+		for _, field := range strings.FieldsFunc(string(data), func(c rune) bool {
+			return c == ':' || c == '\n'
+		}) {
+			if field = strings.TrimSpace(field); field != "" {
+				dirs = append(dirs, field)
+			}
+		}
+	}
+
+	return dirs
+}
@@ -0,0 +1,196 @@
+package sodeps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type testLDCacheEntry struct {
+	key   string
+	value string
+}
+
+// newTestLDCache creates the 'new' format ld.so.cache data
+// (48 byte header: magic+version, entry count, string table size, flags, extension offset, unused;
+// 24 byte entries: flags, key offset, value offset, OS version, hwcap;
+// and then the string table; the string offsets are relative to the header)
+func newTestLDCache(entries []testLDCacheEntry) []byte {
+	var strs bytes.Buffer
+	strStart := ldCacheHeaderSize + len(entries)*ldCacheEntrySize
+	addString := func(val string) uint32 {
+		offset := uint32(strStart + strs.Len())
+		strs.WriteString(val)
+		strs.WriteByte(0)
+		return offset
+	}
+
+	var entryData bytes.Buffer
+	for _, e := range entries {
+		fields := []uint32{
+			0x0303, //flags (ELF libc6, x86-64)
+			addString(e.key),
+			addString(e.value),
+			0, //OS version
+		}
+
+		binary.Write(&entryData, binary.LittleEndian, fields)
+		binary.Write(&entryData, binary.LittleEndian, uint64(0)) //hwcap
+	}
+
+	var data bytes.Buffer
+	data.WriteString(ldCacheMagic)
+	binary.Write(&data, binary.LittleEndian, uint32(len(entries)))
+	binary.Write(&data, binary.LittleEndian, uint32(strs.Len()))
+	data.Write(make([]byte, ldCacheHeaderSize-data.Len()))
+	data.Write(entryData.Bytes())
+	data.Write(strs.Bytes())
+	return data.Bytes()
+}
+
+// newTestOldLDCache creates the 'old' format section
+// (its string offsets are relative to its string table, so they are not used here)
+func newTestOldLDCache(count int) []byte {
+	var data bytes.Buffer
+	data.WriteString("ld.so-1.7.0")
+	binary.Write(&data, binary.LittleEndian, uint32(count))
+	for i := 0; i < count; i++ {
+		binary.Write(&data, binary.LittleEndian, []int32{0x0303, int32(i * 8), int32(i*8 + 4)})
+	}
+
+	return data.Bytes()
+}
+
+func writeTestLDCache(t *testing.T, data []byte) string {
+	dir, err := ioutil.TempDir("", "ldcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	filePath := filepath.Join(dir, "ld.so.cache")
+	if err := ioutil.WriteFile(filePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return filePath
+}
+
+var testLDCacheEntries = []testLDCacheEntry{
+	{key: "libz.so.1", value: "/lib/x86_64-linux-gnu/libz.so.1"},
+	{key: "libc.so.6", value: "/lib/x86_64-linux-gnu/libc.so.6"},
+	{key: "libc.so.6", value: "/lib32/libc.so.6"},
+	{key: "libssl.so.3", value: "/usr/lib/x86_64-linux-gnu/libssl.so.3"},
+}
+
+var testLDCacheExpected = map[string][]string{
+	"libz.so.1":   {"/lib/x86_64-linux-gnu/libz.so.1"},
+	"libc.so.6":   {"/lib/x86_64-linux-gnu/libc.so.6", "/lib32/libc.so.6"},
+	"libssl.so.3": {"/usr/lib/x86_64-linux-gnu/libssl.so.3"},
+}
+
+func TestLoadLDCache(t *testing.T) {
+	filePath := writeTestLDCache(t, newTestLDCache(testLDCacheEntries))
+	entries := loadLDCache(filePath)
+	if !reflect.DeepEqual(entries, testLDCacheExpected) {
+		t.Errorf("got %v expected %v", entries, testLDCacheExpected)
+	}
+}
+
+func TestLoadLDCacheCompatFormat(t *testing.T) {
+	//the old format section comes first and the new format string offsets are relative to the new header
+	data := append(newTestOldLDCache(3), newTestLDCache(testLDCacheEntries)...)
+	entries := loadLDCache(writeTestLDCache(t, data))
+	if !reflect.DeepEqual(entries, testLDCacheExpected) {
+		t.Errorf("got %v expected %v", entries, testLDCacheExpected)
+	}
+}
+
+func TestLoadLDCacheBadData(t *testing.T) {
+	full := newTestLDCache(testLDCacheEntries)
+
+	//the entry count is bigger than the number of entries in the file
+	badCount := newTestLDCache(testLDCacheEntries[:1])
+	binary.LittleEndian.PutUint32(badCount[len(ldCacheMagic):], 1000)
+
+	//the string offsets are outside of the file
+	badOffsets := newTestLDCache(testLDCacheEntries[:1])
+	binary.LittleEndian.PutUint32(badOffsets[ldCacheHeaderSize+4:], 0xffffff)
+
+	tt := []struct {
+		name     string
+		data     []byte
+		expected map[string][]string
+	}{
+		{
+			name:     "empty",
+			data:     nil,
+			expected: map[string][]string{},
+		},
+		{
+			name:     "old format only",
+			data:     newTestOldLDCache(2),
+			expected: map[string][]string{},
+		},
+		{
+			name:     "truncated header",
+			data:     full[:ldCacheHeaderSize-1],
+			expected: map[string][]string{},
+		},
+		{
+			name: "truncated entries",
+			data: full[:ldCacheHeaderSize+ldCacheEntrySize+ldCacheEntrySize/2],
+			//the string table is cut off too
+			expected: map[string][]string{},
+		},
+		{
+			name:     "bad entry count",
+			data:     badCount,
+			expected: map[string][]string{"libz.so.1": {"/lib/x86_64-linux-gnu/libz.so.1"}},
+		},
+		{
+			name:     "bad string offsets",
+			data:     badOffsets,
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, test := range tt {
+		entries := loadLDCache(writeTestLDCache(t, test.data))
+		if !reflect.DeepEqual(entries, test.expected) {
+			t.Errorf("%s: got %v expected %v", test.name, entries, test.expected)
+		}
+	}
+}
+
+func TestLoadLDCacheNoFile(t *testing.T) {
+	entries := loadLDCache(filepath.Join(os.TempDir(), "no-such-dir", "ld.so.cache"))
+	if len(entries) != 0 {
+		t.Errorf("unexpected entries - %v", entries)
+	}
+}
+
+func TestLoadLDCacheSystem(t *testing.T) {
+	data, err := ioutil.ReadFile(ldCacheFilePath)
+	if err != nil || !bytes.Contains(data, []byte(ldCacheMagic)) {
+		t.Skipf("no glibc ld.so.cache (%s)", ldCacheFilePath)
+	}
+
+	entries := loadLDCache(ldCacheFilePath)
+	if len(entries) == 0 {
+		t.Fatalf("no entries in %s", ldCacheFilePath)
+	}
+
+	for name, paths := range entries {
+		for _, fpath := range paths {
+			if !filepath.IsAbs(fpath) {
+				t.Errorf("unexpected cache entry - %s => %s", name, fpath)
+			}
+		}
+	}
+}
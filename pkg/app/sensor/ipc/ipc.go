@@ -9,6 +9,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
 	"github.com/docker-slim/docker-slim/pkg/ipc/event"
+	"github.com/docker-slim/docker-slim/pkg/version"
 )
 
 type Server struct {
@@ -68,12 +69,21 @@ func (s *Server) CommandChan() <-chan command.Message {
 
 func (s *Server) OnRequest(data []byte) ([]byte, error) {
 	resp := command.Response{
-		Status: command.ResponseStatusError,
+		Status:          command.ResponseStatusError,
+		ProtocolVersion: command.ProtocolVersion,
+		SensorVersion:   version.Current(),
 	}
 
 	if cmd, err := command.Decode(data); err == nil {
-		s.cmdChan <- cmd
-		resp.Status = command.ResponseStatusOk
+		if startCmd, ok := cmd.(*command.StartMonitor); ok && !command.IsCompatibleProtocol(startCmd.ProtocolVersion) {
+			//not starting the monitor for the incompatible master (the master will report the error)
+			resp.Error = fmt.Sprintf("unsupported master protocol version %d (supported: %d-%d)",
+				startCmd.ProtocolVersion, command.MinProtocolVersion, command.ProtocolVersion)
+			log.Errorf("ipc.Server.OnRequest: %s", resp.Error)
+		} else {
+			s.cmdChan <- cmd
+			resp.Status = command.ResponseStatusOk
+		}
 	} else {
 		log.Errorf("ipc.Server.OnRequest: error decoding request = %v", err)
 	}
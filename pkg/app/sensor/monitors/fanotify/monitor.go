@@ -9,6 +9,8 @@ import (
 	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/docker-slim/docker-slim/pkg/errors"
 	"github.com/docker-slim/docker-slim/pkg/report"
@@ -25,6 +27,7 @@ type Event struct {
 	File    string
 	IsRead  bool
 	IsWrite bool
+	Time    int64
 }
 
 const (
@@ -67,6 +70,7 @@ func Run(errorCh chan error,
 		fanReport := &report.FanMonitorReport{
 			MonitorPid:       os.Getpid(),
 			MonitorParentPid: os.Getppid(),
+			StartTime:        time.Now().UnixNano(),
 			ProcessFiles:     map[string]map[string]*report.FileInfo{},
 		}
 
@@ -115,7 +119,14 @@ func Run(errorCh chan error,
 				data.File.Close()
 				if doNotify {
 					eventID++
-					e := Event{ID: eventID, Pid: data.Pid, File: path, IsRead: isRead, IsWrite: isWrite}
+					e := Event{
+						ID:      eventID,
+						Pid:     data.Pid,
+						File:    path,
+						IsRead:  isRead,
+						IsWrite: isWrite,
+						Time:    time.Now().UnixNano(),
+					}
 
 					select {
 					case eventChan <- e:
@@ -149,6 +160,7 @@ func Run(errorCh chan error,
 				if e.ID == 1 {
 					//first event represents the main process
 					if pinfo, err := getProcessInfo(e.Pid); (err == nil) && (pinfo != nil) {
+						pinfo.FirstEventTime = e.Time
 						fanReport.MainProcess = pinfo
 						fanReport.Processes = map[string]*report.ProcessInfo{}
 						fanReport.Processes[strconv.Itoa(int(e.Pid))] = pinfo
@@ -156,6 +168,7 @@ func Run(errorCh chan error,
 				} else {
 					if _, ok := fanReport.Processes[strconv.Itoa(int(e.Pid))]; !ok {
 						if pinfo, err := getProcessInfo(e.Pid); (err == nil) && (pinfo != nil) {
+							pinfo.FirstEventTime = e.Time
 							fanReport.Processes[strconv.Itoa(int(e.Pid))] = pinfo
 						}
 					}
@@ -167,9 +180,11 @@ func Run(errorCh chan error,
 
 				if existingFi, ok := fanReport.ProcessFiles[strconv.Itoa(int(e.Pid))][e.File]; !ok {
 					fi := &report.FileInfo{
-						EventCount:   1,
-						Name:         e.File,
-						FirstEventID: e.ID,
+						EventCount:     1,
+						Name:           e.File,
+						FirstEventID:   e.ID,
+						FirstEventTime: e.Time,
+						LastEventTime:  e.Time,
 					}
 
 					if e.IsRead {
@@ -187,6 +202,7 @@ func Run(errorCh chan error,
 					fanReport.ProcessFiles[strconv.Itoa(int(e.Pid))][e.File] = fi
 				} else {
 					existingFi.EventCount++
+					existingFi.LastEventTime = e.Time
 
 					if e.IsRead {
 						existingFi.ReadCount++
@@ -203,6 +219,7 @@ func Run(errorCh chan error,
 			}
 		}
 
+		fanReport.StopTime = time.Now().UnixNano()
 		log.Debugf("fanmon: processor - sending report (processed %v events)...", fanReport.EventCount)
 		resultChan <- fanReport
 	}()
@@ -270,5 +287,35 @@ func getProcessInfo(pid int32) (*report.ProcessInfo, error) {
 		info.ParentPid = int32(procPpid)
 	}
 
+	if status, err := ioutil.ReadFile(procFilePath(int(pid), "status")); err == nil {
+		info.UID = procStatusID(status, "Uid:")
+		info.GID = procStatusID(status, "Gid:")
+	}
+
 	return info, nil
 }
+
+// procStatusID returns the effective ID from the 'Uid:' or 'Gid:' proc status line
+// (the line fields are: real, effective, saved set, filesystem)
+func procStatusID(status []byte, prefix string) *uint32 {
+	for _, line := range strings.Split(string(status), "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(line, prefix))
+		if len(fields) < 2 {
+			return nil
+		}
+
+		id, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil
+		}
+
+		value := uint32(id)
+		return &value
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package ptrace
+
+// AppEvents provides the target app process events to the standalone sensor mode
+type AppEvents struct {
+	//the target app PID (sent when the app is started)
+	StartCh chan int
+	//the target app exit code (sent when the app is done;
+	//128 + the signal number if it's terminated by a signal)
+	DoneCh chan int
+}
+
+// NewAppEvents creates the target app event channels
+func NewAppEvents() *AppEvents {
+	return &AppEvents{
+		StartCh: make(chan int, 1),
+		DoneCh:  make(chan int, 1),
+	}
+}
+
+func (ref *AppEvents) started(pid int) {
+	if ref != nil {
+		ref.StartCh <- pid
+	}
+}
+
+func (ref *AppEvents) done(exitCode int) {
+	if ref != nil {
+		ref.DoneCh <- exitCode
+	}
+}
@@ -15,12 +15,14 @@ import (
 )
 
 // Run starts the PTRACE monitor
+// (appEvents gets the target app start and exit events, it's optional)
 func Run(
 	rtaSourcePT bool,
 	errorCh chan error,
 	ackCh chan<- bool,
 	startCh <-chan int,
 	stopCh chan struct{},
+	appEvents *AppEvents,
 	appName string,
 	appArgs []string,
 	dirName string,
@@ -71,14 +73,25 @@ func Run(
 					if ackCh != nil {
 						ackCh <- true
 					}
+
+					appEvents.started(ptApp.MainPID())
 				case ptrace.AppFailed:
 					log.Debug("ptmon: pta state watcher - state(failed)...")
 					if ackCh != nil {
 						ackCh <- false
 					}
+
+					exitCode := ptApp.ExitCode()
+					if exitCode == 0 {
+						exitCode = 1
+					}
+
+					appEvents.done(exitCode)
 					return
 				case ptrace.AppDone, ptrace.AppExited:
 					log.Debug("ptmon: pta state watcher - state(terminated)...")
+					appEvents.done(ptApp.ExitCode())
+					return
 				}
 			}
 		}
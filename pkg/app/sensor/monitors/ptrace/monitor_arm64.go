@@ -42,12 +42,14 @@ const (
 */
 
 // Run starts the PTRACE monitor
+// (appEvents gets the target app start and exit events, it's optional)
 func Run(
 	rtaSourcePT bool,
 	errorCh chan error,
 	ackChan chan<- bool,
 	startChan <-chan int,
 	stopChan chan struct{},
+	appEvents *AppEvents,
 	appName string,
 	appArgs []string,
 	dirName string,
@@ -76,6 +78,8 @@ func Run(
 		collectorDoneChan := make(chan int, 1)
 
 		var app *exec.Cmd
+		//the target app exit code (set by the collector before it's done)
+		exitCode := 1
 
 		go func() {
 			log.Debug("ptmon: collector - starting...")
@@ -98,6 +102,7 @@ func Run(
 			errutil.FailOn(err)
 
 			targetPid := app.Process.Pid
+			appEvents.started(targetPid)
 
 			//pgid, err := syscall.Getpgid(targetPid)
 			//if err != nil {
@@ -145,6 +150,21 @@ func Run(
 			var callNum uint64
 			var retVal uint64
 			for wstat.Stopped() {
+				if sig := wstat.StopSignal(); sig != unix.SIGTRAP {
+					//signal-delivery-stop: passing the signal to the app (e.g., SIGTERM when the container is stopped)
+					if err = unix.PtraceSyscall(targetPid, int(sig)); err != nil {
+						log.Warnf("ptmon: collector - PtraceSyscall(signal) error: %v", err)
+						break
+					}
+
+					if pid, err = unix.Wait4(targetPid, &wstat, 0, nil); err != nil {
+						log.Warnf("ptmon: collector - error waiting 4 %d: %v", targetPid, err)
+						break
+					}
+
+					continue
+				}
+
 				var regs unix.PtraceRegsArm64
 
 				switch syscallReturn {
@@ -202,6 +222,13 @@ func Run(
 			}
 
 			log.Infoln("ptmon: collector - exiting... status=", wstat)
+			switch {
+			case wstat.Exited():
+				exitCode = wstat.ExitStatus()
+			case wstat.Signaled():
+				exitCode = 128 + int(wstat.Signal())
+			}
+
 			collectorDoneChan <- 0
 		}()
 
@@ -210,6 +237,7 @@ func Run(
 			select {
 			case rc := <-collectorDoneChan:
 				log.Info("ptmon: processor - collector finished =>", rc)
+				appEvents.done(exitCode)
 				break done
 			case <-stopChan:
 				log.Info("ptmon: processor - stopping...")
@@ -0,0 +1,180 @@
+//go:build linux
+// +build linux
+
+package app
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const tcpListenState = "0A"
+
+var procNetTCPFiles = map[string]string{
+	"/proc/net/tcp":  "tcp",
+	"/proc/net/tcp6": "tcp6",
+}
+
+// how often the listen ports are collected while the monitored app is running
+const listenPortPollInterval = 2 * time.Second
+
+// listenPortTracker records the TCP ports the monitored app listens on while it's running
+// (the app is stopped before the sensor report is saved, so its sockets are gone by then)
+type listenPortTracker struct {
+	mu    sync.Mutex
+	ports map[string]*report.ListenPortInfo
+}
+
+func newListenPortTracker() *listenPortTracker {
+	return &listenPortTracker{
+		ports: map[string]*report.ListenPortInfo{},
+	}
+}
+
+// run collects the listen ports until the monitor is stopped
+func (t *listenPortTracker) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(listenPortPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.collect()
+		}
+	}
+}
+
+// collect adds the current listen ports to the recorded ports
+func (t *listenPortTracker) collect() {
+	ports := collectListenPorts()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, info := range ports {
+		t.ports[listenPortKey(info)] = info
+	}
+}
+
+// list returns all recorded listen ports
+func (t *listenPortTracker) list() []*report.ListenPortInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var ports []*report.ListenPortInfo
+	for _, info := range t.ports {
+		ports = append(ports, info)
+	}
+
+	sortListenPorts(ports)
+	return ports
+}
+
+// collectListenPorts returns the TCP ports the container is listening on
+// (the sensor shares the network namespace with the monitored app)
+func collectListenPorts() []*report.ListenPortInfo {
+	var ports []*report.ListenPortInfo
+	seen := map[string]struct{}{}
+	for filePath, protocol := range procNetTCPFiles {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Debugf("sensor: collectListenPorts - error reading '%s': %v", filePath, err)
+			continue
+		}
+
+		for _, info := range parseProcNetTCP(string(data), protocol) {
+			key := listenPortKey(info)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			ports = append(ports, info)
+		}
+	}
+
+	sortListenPorts(ports)
+	return ports
+}
+
+func listenPortKey(info *report.ListenPortInfo) string {
+	return info.Protocol + "/" + info.Address + "/" + strconv.Itoa(info.Port)
+}
+
+func sortListenPorts(ports []*report.ListenPortInfo) {
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+
+		return ports[i].Address < ports[j].Address
+	})
+}
+
+// parseProcNetTCP returns the listening sockets from the /proc/net/tcp[6] data
+func parseProcNetTCP(data string, protocol string) []*report.ListenPortInfo {
+	var ports []*report.ListenPortInfo
+	for idx, line := range strings.Split(data, "\n") {
+		//the first line is the header
+		if idx == 0 {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[3] != tcpListenState {
+			continue
+		}
+
+		parts := strings.Split(fields[1], ":")
+		if len(parts) != 2 {
+			continue
+		}
+
+		port, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
+			continue
+		}
+
+		ports = append(ports, &report.ListenPortInfo{
+			Protocol: protocol,
+			Address:  procNetAddress(parts[0]),
+			Port:     int(port),
+		})
+	}
+
+	return ports
+}
+
+// procNetAddress decodes the hex encoded proc net address
+// (the address is stored as a sequence of host byte order (little endian) 32-bit words)
+func procNetAddress(raw string) string {
+	data, err := hex.DecodeString(raw)
+	if err != nil || len(data)%4 != 0 {
+		return raw
+	}
+
+	ip := make(net.IP, len(data))
+	for i := 0; i < len(data); i += 4 {
+		ip[i] = data[i+3]
+		ip[i+1] = data[i+2]
+		ip[i+2] = data[i+1]
+		ip[i+3] = data[i]
+	}
+
+	return ip.String()
+}
@@ -0,0 +1,78 @@
+//go:build linux
+// +build linux
+
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const testProcNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 100 0 0 10 0
+   2: 0200000A:0050 0300000A:C350 01 00000000:00000000 00:00000000 00000000     0        0 1003 1 0000000000000000 100 0 0 10 0
+   3: bad
+`
+
+func TestParseProcNetTCP(t *testing.T) {
+	//only the sockets in the LISTEN state are included
+	expected := []*report.ListenPortInfo{
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 80},
+		{Protocol: "tcp", Address: "127.0.0.1", Port: 8080},
+	}
+
+	if actual := parseProcNetTCP(testProcNetTCP, "tcp"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %+v expected %+v", actual, expected)
+	}
+}
+
+func TestProcNetAddress(t *testing.T) {
+	tt := map[string]string{
+		"0100007F":                         "127.0.0.1",
+		"00000000000000000000000001000000": "::1",
+		"00000000000000000000000000000000": "::",
+		"bad":                              "bad",
+	}
+
+	for raw, expected := range tt {
+		if actual := procNetAddress(raw); actual != expected {
+			t.Errorf("procNetAddress(%s) - got '%s' expected '%s'", raw, actual, expected)
+		}
+	}
+}
+
+func TestListenPortTrackerList(t *testing.T) {
+	tracker := newListenPortTracker()
+	for _, info := range []*report.ListenPortInfo{
+		{Protocol: "tcp6", Address: "::", Port: 443},
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 443},
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 80},
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 80},
+	} {
+		tracker.ports[listenPortKey(info)] = info
+	}
+
+	//the ports recorded earlier are kept when the app stops listening
+	tracker.collect()
+
+	ports := tracker.list()
+	var found []report.ListenPortInfo
+	for _, info := range ports {
+		if info.Port == 80 || info.Port == 443 {
+			found = append(found, *info)
+		}
+	}
+
+	expected := []report.ListenPortInfo{
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 80},
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 443},
+		{Protocol: "tcp6", Address: "::", Port: 443},
+	}
+
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("got %+v expected %+v", found, expected)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build linux
+// +build linux
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/ptrace"
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The standalone mode is used by the instrumented images ('instrument' command).
+// The sensor starts the app right away using the 'start monitor' command saved in the image
+// and it saves the collected artifacts (in the default artifact directory) when the app exits
+// or when the container is stopped, so the artifacts can be imported later by the 'build' command.
+// The stop signals are passed to the app and the sensor exits with the app exit code.
+
+const (
+	defaultCommandFile = "/opt/dockerslim/commands.json"
+	//max time to wait for the app to exit after it gets the stop signal
+	//(the rest of the container stop timeout is used to save the artifacts)
+	appStopTimeout = 5 * time.Second
+)
+
+var ErrNoTargetApp = errors.New("no target app command")
+
+var standaloneSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+	syscall.SIGQUIT,
+	syscall.SIGHUP,
+}
+
+func loadStartMonitorCommand(filePath string) (*command.StartMonitor, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmd command.StartMonitor
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return nil, err
+	}
+
+	return &cmd, nil
+}
+
+func runStandalone(dirName string) {
+	log.Infof("sensor: standalone mode (command file - '%s')", commandFile)
+
+	cmd := &command.StartMonitor{}
+	if commandFile != "" {
+		var err error
+		cmd, err = loadStartMonitorCommand(commandFile)
+		errutil.FailOn(err)
+	}
+
+	//the app command comes from the sensor args (the original image entrypoint and cmd)
+	if args := flag.Args(); len(args) > 0 {
+		cmd.AppName = args[0]
+		cmd.AppArgs = args[1:]
+	}
+
+	if cmd.AppName == "" {
+		errutil.FailOn(ErrNoTargetApp)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, standaloneSignals...)
+
+	errorCh := make(chan error, 10)
+	go func() {
+		for err := range errorCh {
+			log.Errorf("sensor: standalone - monitor error = %+v", err)
+		}
+	}()
+
+	monStartAckChan := make(chan bool, 3)
+	monDoneChan := make(chan bool, 1)
+	monDoneAckChan := make(chan bool)
+	pidsChan := make(chan []int, 1)
+	ptmonStartChan := make(chan int, 1)
+	appEvents := ptrace.NewAppEvents()
+
+	log.Debugf("sensor: standalone - starting target app => %v %#v", cmd.AppName, cmd.AppArgs)
+	if !startMonitor(errorCh, monStartAckChan, monDoneChan, monDoneAckChan, pidsChan, ptmonStartChan, appEvents, cmd, dirName) {
+		log.Error("sensor: standalone - monitor not started...")
+		os.Exit(1)
+	}
+
+	started := <-monStartAckChan
+	if !started {
+		log.Error("sensor: standalone - target app not started...")
+		os.Exit(1)
+	}
+
+	appPid := <-appEvents.StartCh
+	log.Infof("sensor: standalone - monitor started (target app PID - %d)...", appPid)
+
+	var exitCode int
+	select {
+	case exitCode = <-appEvents.DoneCh:
+		log.Infof("sensor: standalone - target app exited (exit code - %d)...", exitCode)
+	case sig := <-sigChan:
+		//the app is still traced, so the monitor passes the signal to it
+		log.Infof("sensor: standalone - passing signal (%v) to target app...", sig)
+		if err := syscall.Kill(appPid, sig.(syscall.Signal)); err != nil {
+			log.Debugf("sensor: standalone - error signaling target app - %v", err)
+		}
+
+		select {
+		case exitCode = <-appEvents.DoneCh:
+			log.Infof("sensor: standalone - target app exited (exit code - %d)...", exitCode)
+		case <-time.After(appStopTimeout):
+			log.Warnf("sensor: standalone - target app didn't exit in %v (stopping it)...", appStopTimeout)
+			exitCode = 128 + int(sig.(syscall.Signal))
+		}
+	}
+
+	monDoneChan <- true
+	log.Info("sensor: standalone - waiting for monitor to finish...")
+	<-monDoneAckChan
+	log.Infof("sensor: standalone - artifacts saved (%s)", defaultArtifactDirName)
+	os.Exit(exitCode)
+}
@@ -0,0 +1,145 @@
+//go:build linux
+// +build linux
+
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	runAsUserShell   = "/sbin/nologin"
+	rootPasswdRecord = "root:x:0:0:root:/root:/bin/sh"
+	rootGroupRecord  = "root:x:0:"
+)
+
+// the directories that never belong to the app (they are not chowned even if they are the working directory)
+var systemDirs = map[string]struct{}{
+	"/":     {},
+	"/bin":  {},
+	"/sbin": {},
+	"/lib":  {},
+	"/usr":  {},
+	"/etc":  {},
+	"/var":  {},
+	"/opt":  {},
+	"/home": {},
+	"/root": {},
+	"/tmp":  {},
+}
+
+// saveRunAsUser adds the non-root user (and its group) to the passwd and group files
+// in the optimized image and makes the app paths owned by the user:
+// the working directory and the files written by the app while it was profiled
+func (p *artifactStore) saveRunAsUser() {
+	user := p.cmd.RunAsUser
+	if user == nil || user.Name == "" {
+		return
+	}
+
+	homeDir, err := os.Getwd()
+	if err != nil || homeDir == "" {
+		homeDir = "/"
+	}
+
+	passwdRecord := fmt.Sprintf("%s:x:%d:%d::%s:%s", user.Name, user.UID, user.GID, homeDir, runAsUserShell)
+	groupRecord := fmt.Sprintf("%s:x:%d:", user.Name, user.GID)
+
+	p.saveAccountRecord(passwdFilePath, rootPasswdRecord, user.Name, strconv.Itoa(user.UID), passwdRecord, false)
+	//an existing group with the same GID is reused
+	p.saveAccountRecord(groupFilePath, rootGroupRecord, user.Name, strconv.Itoa(user.GID), groupRecord, true)
+
+	chown := func(filePath string) {
+		if err := os.Lchown(filePath, user.UID, user.GID); err != nil {
+			log.Debugf("saveRunAsUser: os.Lchown(%v) error - %v", filePath, err)
+		}
+	}
+
+	filesDirPath := filepath.Join(p.storeLocation, filesDirName)
+	if _, found := systemDirs[homeDir]; !found {
+		appDirPath := filepath.Join(filesDirPath, homeDir)
+		if fsutil.DirExists(appDirPath) {
+			err := filepath.Walk(appDirPath, func(filePath string, info os.FileInfo, err error) error {
+				if err == nil {
+					chown(filePath)
+				}
+
+				return nil
+			})
+
+			if err != nil {
+				log.Warnf("saveRunAsUser: error updating the app directory owner (%v) - %v", homeDir, err)
+			}
+		}
+	}
+
+	for fileName, props := range p.rawNames {
+		if props == nil || !props.Flags["W"] {
+			continue
+		}
+
+		dstPath := filepath.Join(filesDirPath, fileName)
+		if fsutil.Exists(dstPath) {
+			chown(dstPath)
+			//the app needs to create files in the same directory too
+			if dirName := filepath.Dir(fileName); dirName != "/" {
+				if _, found := systemDirs[dirName]; !found {
+					chown(filepath.Dir(dstPath))
+				}
+			}
+		}
+	}
+}
+
+// saveAccountRecord adds (or replaces) the user or group record in the passwd or group file
+// (the original file is used if the file is not in the optimized image yet)
+func (p *artifactStore) saveAccountRecord(srcPath, rootRecord, name, id, record string, reuseID bool) {
+	dstPath := fmt.Sprintf("%s/files%s", p.storeLocation, srcPath)
+
+	var lines []string
+	switch {
+	case fsutil.Exists(dstPath):
+		lines = readLines(dstPath)
+	case fsutil.Exists(srcPath):
+		lines = readLines(srcPath)
+	default:
+		lines = []string{rootRecord}
+	}
+
+	var kept []string
+	hasID := false
+	for _, line := range lines {
+		fields := strings.Split(line, ":")
+		if len(fields) > 2 && fields[2] == id && fields[0] != name && reuseID {
+			hasID = true
+		} else if len(fields) > 2 && (fields[0] == name || fields[2] == id) {
+			log.Debugf("saveRunAsUser: replacing '%s' record - %s", srcPath, line)
+			continue
+		}
+
+		kept = append(kept, line)
+	}
+
+	if !hasID {
+		kept = append(kept, record)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		log.Warnf("saveRunAsUser: error creating directory for %v - %v", dstPath, err)
+		return
+	}
+
+	data := strings.Join(kept, "\n") + "\n"
+	if err := ioutil.WriteFile(dstPath, []byte(data), 0644); err != nil {
+		log.Warnf("saveRunAsUser: error saving %v - %v", dstPath, err)
+	}
+}
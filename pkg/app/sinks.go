@@ -0,0 +1,181 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// Output sink types
+const (
+	//the event stream file ('file:<path>')
+	OutputSinkFile = "file"
+	//the local syslog daemon ('syslog') or the remote syslog server ('syslog:udp://host:514' or 'syslog:tcp://host:514')
+	OutputSinkSyslog = "syslog"
+	//the local systemd journal ('journald')
+	OutputSinkJournald = "journald"
+)
+
+const (
+	outputSinkTag   = "docker-slim"
+	journaldSocket  = "/run/systemd/journal/socket"
+	journaldFieldNS = "DOCKER_SLIM_"
+)
+
+var (
+	ErrUnknownOutputSink     = errors.New("unknown output sink")
+	ErrUnsupportedOutputSink = errors.New("output sink is not supported on this platform")
+)
+
+// Syslog/journald message priorities
+const (
+	priorityErr    = 3
+	priorityNotice = 5
+	priorityInfo   = 6
+	priorityDebug  = 7
+)
+
+// eventSink is an event stream destination
+type eventSink interface {
+	write(event *report.Event, data []byte) error
+	close() error
+}
+
+// AddOutputSink adds an extra event stream destination ('file:<path>', 'syslog[:<network>://<address>]' or 'journald').
+// The regular console output is not changed, so the console can show the human readable output
+// while the machine readable events are saved in the sinks.
+func AddOutputSink(spec string) error {
+	kind := spec
+	var target string
+	if idx := strings.Index(spec, ":"); idx >= 0 {
+		kind = spec[:idx]
+		target = spec[idx+1:]
+	}
+
+	var sink eventSink
+	var err error
+	switch kind {
+	case OutputSinkFile:
+		if target == "" {
+			return fmt.Errorf("%w - '%s' (missing file path)", ErrUnknownOutputSink, spec)
+		}
+
+		sink, err = newFileSink(target)
+	case OutputSinkSyslog:
+		var network, address string
+		if target != "" {
+			parts := strings.SplitN(target, "://", 2)
+			if len(parts) != 2 || parts[1] == "" {
+				return fmt.Errorf("%w - '%s' (expected 'syslog:<network>://<address>')", ErrUnknownOutputSink, spec)
+			}
+
+			network, address = parts[0], parts[1]
+		}
+
+		sink, err = newSyslogSink(network, address)
+	case OutputSinkJournald:
+		sink, err = newJournaldSink()
+	default:
+		return fmt.Errorf("%w - '%s'", ErrUnknownOutputSink, spec)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	eventStreamInstance().addSink(sink)
+	return nil
+}
+
+// writerSink writes the JSONL events to a writer (stdout or a file)
+type writerSink struct {
+	w      io.Writer
+	closer io.Closer
+}
+
+func newFileSink(filePath string) (*writerSink, error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &writerSink{w: f, closer: f}, nil
+}
+
+func (ref *writerSink) write(event *report.Event, data []byte) error {
+	_, err := ref.w.Write(append(data, '\n'))
+	return err
+}
+
+func (ref *writerSink) close() error {
+	if ref.closer != nil {
+		return ref.closer.Close()
+	}
+
+	return nil
+}
+
+// journaldSink sends the events to the systemd journal (using the journal native protocol)
+// The event JSON is the journal message and the event fields are the extra journal fields
+// (e.g., 'journalctl DOCKER_SLIM_EVENT_TYPE=error').
+type journaldSink struct {
+	conn net.Conn
+}
+
+func newJournaldSink() (*journaldSink, error) {
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+
+	return &journaldSink{conn: conn}, nil
+}
+
+func (ref *journaldSink) write(event *report.Event, data []byte) error {
+	var msg bytes.Buffer
+	journaldField(&msg, "MESSAGE", string(data))
+	journaldField(&msg, "PRIORITY", fmt.Sprintf("%d", eventPriority(event)))
+	journaldField(&msg, "SYSLOG_IDENTIFIER", outputSinkTag)
+	journaldField(&msg, journaldFieldNS+"COMMAND", event.Command)
+	journaldField(&msg, journaldFieldNS+"EVENT_TYPE", event.Type)
+	if event.Name != "" {
+		journaldField(&msg, journaldFieldNS+"EVENT_NAME", event.Name)
+	}
+
+	journaldField(&msg, journaldFieldNS+"EVENT_SEQ", fmt.Sprintf("%d", event.Seq))
+
+	_, err := ref.conn.Write(msg.Bytes())
+	return err
+}
+
+func (ref *journaldSink) close() error {
+	return ref.conn.Close()
+}
+
+// journaldField adds a journal field ('NAME=value'; the event JSON values don't have new lines)
+func journaldField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteByte('=')
+	buf.WriteString(strings.ReplaceAll(value, "\n", " "))
+	buf.WriteByte('\n')
+}
+
+// eventPriority maps the event types to the syslog priorities
+func eventPriority(event *report.Event) int {
+	switch event.Type {
+	case report.EventTypeError:
+		return priorityErr
+	case report.EventTypeState:
+		return priorityNotice
+	case report.EventTypeLog:
+		return priorityDebug
+	}
+
+	return priorityInfo
+}
@@ -0,0 +1,12 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package app
+
+import (
+	"fmt"
+)
+
+func newSyslogSink(network, address string) (eventSink, error) {
+	return nil, fmt.Errorf("%w - '%s'", ErrUnsupportedOutputSink, OutputSinkSyslog)
+}
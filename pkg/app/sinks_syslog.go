@@ -0,0 +1,44 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package app
+
+import (
+	"log/syslog"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// syslogSink sends the events (the JSON event data) to syslog
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// newSyslogSink connects to the local syslog daemon (if the network and the address are empty)
+// or to the remote syslog server
+func newSyslogSink(network, address string) (*syslogSink, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_USER, outputSinkTag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogSink{w: w}, nil
+}
+
+func (ref *syslogSink) write(event *report.Event, data []byte) error {
+	msg := string(data)
+	switch eventPriority(event) {
+	case priorityErr:
+		return ref.w.Err(msg)
+	case priorityNotice:
+		return ref.w.Notice(msg)
+	case priorityDebug:
+		return ref.w.Debug(msg)
+	}
+
+	return ref.w.Info(msg)
+}
+
+func (ref *syslogSink) close() error {
+	return ref.w.Close()
+}
@@ -0,0 +1,36 @@
+package app
+
+// Output levels
+type OutputLevel int
+
+const (
+	//only the errors and the final command state
+	OutputQuiet OutputLevel = iota
+	OutputNormal
+	//info logs (-v)
+	OutputVerbose
+	//debug logs (-vv)
+	OutputDebug
+)
+
+var outputLevel = OutputNormal
+
+// SetOutputLevel sets the console output level for all commands
+func SetOutputLevel(level OutputLevel) {
+	outputLevel = level
+}
+
+// IsQuiet returns true if the console output is limited to the errors and the final command state
+func IsQuiet() bool {
+	return outputLevel == OutputQuiet
+}
+
+// isFinalState returns true for the command states shown in the quiet mode
+func isFinalState(state string) bool {
+	switch state {
+	case "exited", "done", "interrupted":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,389 @@
+package blobdiscover
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"path"
+	"strings"
+)
+
+// Blob finding kinds
+const (
+	KindArchive            = "archive"
+	KindEmbeddedArchive    = "embedded-archive"
+	KindHighEntropy        = "high-entropy"
+	KindBundledInterpreter = "bundled-interpreter"
+)
+
+// EntropyThreshold is the min Shannon entropy (bits per byte) for the high entropy (compressed or encrypted) data
+const EntropyThreshold = 7.5
+
+// Match is a blob finding in the scanned data
+type Match struct {
+	Kind string `json:"kind"`
+	//archive format or interpreter runtime
+	Format string `json:"format,omitempty"`
+	//embedded archive offset
+	Offset int64 `json:"offset,omitempty"`
+}
+
+// Result is the blob scan result for a file
+type Result struct {
+	Size    int64    `json:"size"`
+	Entropy float64  `json:"entropy"`
+	Matches []*Match `json:"matches"`
+}
+
+type signature struct {
+	format string
+	magic  []byte
+	//magic offset from the beginning of the archive
+	offset int
+	//the magic is specific enough to look for it inside the other files
+	embedded bool
+}
+
+var signatures = []*signature{
+	{format: "zip", magic: []byte("PK\x03\x04"), embedded: true},
+	//the embedded gzip data needs the compression method and flags to reduce the false positives
+	{format: "gzip", magic: []byte{0x1f, 0x8b, 0x08, 0x00}, embedded: true},
+	{format: "gzip", magic: []byte{0x1f, 0x8b, 0x08, 0x08}, embedded: true},
+	{format: "gzip", magic: []byte{0x1f, 0x8b}},
+	{format: "bzip2", magic: []byte("BZh91AY&SY"), embedded: true},
+	{format: "bzip2", magic: []byte("BZh")},
+	{format: "xz", magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, embedded: true},
+	{format: "zstd", magic: []byte{0x28, 0xb5, 0x2f, 0xfd}, embedded: true},
+	{format: "7z", magic: []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, embedded: true},
+	{format: "rar", magic: []byte("Rar!\x1a\x07"), embedded: true},
+	{format: "squashfs", magic: []byte("hsqs"), embedded: true},
+	{format: "lz4", magic: []byte{0x04, 0x22, 0x4d, 0x18}},
+	{format: "tar", magic: []byte("ustar"), offset: 257},
+	{format: "cpio", magic: []byte("070701")},
+	{format: "rpm", magic: []byte{0xed, 0xab, 0xee, 0xdb}},
+	{format: "deb", magic: []byte("!<arch>\ndebian")},
+}
+
+// the media files are compressed too, but they are not hidden payloads
+var mediaMagics = [][]byte{
+	[]byte("\x89PNG"),
+	{0xff, 0xd8, 0xff},
+	[]byte("GIF8"),
+	[]byte("RIFF"),
+	[]byte("wOFF"),
+	[]byte("wOF2"),
+	[]byte("OggS"),
+	[]byte("%PDF"),
+	[]byte("ID3"),
+}
+
+type interpreterMarker struct {
+	format string
+	marker []byte
+	//the runtime is bundled only if the file is not the interpreter itself
+	exeNames []string
+}
+
+var interpreterMarkers = []*interpreterMarker{
+	{format: "pyinstaller", marker: []byte("MEI\x0c\x0b\x0a\x0b\x0e")},
+	{format: "nuitka", marker: []byte("NUITKA_ONEFILE_PARENT")},
+	{format: "node-sea", marker: []byte("NODE_SEA_FUSE_fce680ab2cc467b6e072b8b5df1996b2:1")},
+	{format: "node", marker: []byte("NODE_SEA_FUSE_fce680ab2cc467b6e072b8b5df1996b2:0"), exeNames: []string{"node", "nodejs"}},
+	{format: "nexe", marker: []byte("<nexe~~sentinel>")},
+	{format: "deno", marker: []byte("d3n0l4nd")},
+	{format: "bun", marker: []byte("\n---- Bun! ----\n")},
+}
+
+var (
+	elfMagic = []byte("\x7fELF")
+	upxMagic = []byte("UPX!")
+)
+
+const (
+	headSize       = 4096
+	maxPatternSize = 64
+)
+
+// Scanner scans a file data stream (written to it) for the compressed, encrypted or bundled payloads
+// (the data is not buffered)
+type Scanner struct {
+	filePath     string
+	size         int64
+	counts       [256]uint64
+	head         []byte
+	carry        []byte
+	embedded     map[string]int64
+	interpreters map[string]struct{}
+	hasUPX       bool
+	//the ELF object data end (0 if it's not known yet)
+	elfEnd int64
+}
+
+// NewScanner creates a new blob scanner for the file
+func NewScanner(filePath string) *Scanner {
+	return &Scanner{
+		filePath:     filePath,
+		embedded:     map[string]int64{},
+		interpreters: map[string]struct{}{},
+	}
+}
+
+// Write scans the next chunk of the file data
+func (s *Scanner) Write(p []byte) (int, error) {
+	for _, b := range p {
+		s.counts[b]++
+	}
+
+	if len(s.head) < headSize {
+		n := headSize - len(s.head)
+		if n > len(p) {
+			n = len(p)
+		}
+
+		s.head = append(s.head, p[:n]...)
+	}
+
+	//the carried bytes from the previous chunk make it possible to find the patterns across the chunk boundaries
+	data := append(s.carry, p...)
+	base := s.size - int64(len(s.carry))
+
+	//the ELF files can have the archive magic values in their code and data,
+	//so only the archives appended to the ELF data are reported
+	minOffset := int64(1)
+	if bytes.HasPrefix(s.head, elfMagic) {
+		minOffset = math.MaxInt64
+		if len(s.head) == headSize {
+			if s.elfEnd == 0 {
+				if s.elfEnd = elfDataEnd(s.head); s.elfEnd <= 0 {
+					s.elfEnd = math.MaxInt64
+				}
+			}
+
+			minOffset = s.elfEnd
+		}
+	}
+
+	for _, sig := range signatures {
+		if !sig.embedded {
+			continue
+		}
+
+		if _, found := s.embedded[sig.format]; found {
+			continue
+		}
+
+		//the matches at the beginning of the file are the regular archives (not embedded)
+		for start := 0; start < len(data); {
+			idx := bytes.Index(data[start:], sig.magic)
+			if idx < 0 {
+				break
+			}
+
+			offset := base + int64(start+idx) - int64(sig.offset)
+			if offset >= minOffset {
+				s.embedded[sig.format] = offset
+				break
+			}
+
+			start += idx + 1
+		}
+	}
+
+	for _, im := range interpreterMarkers {
+		if _, found := s.interpreters[im.format]; found {
+			continue
+		}
+
+		if bytes.Contains(data, im.marker) {
+			s.interpreters[im.format] = struct{}{}
+		}
+	}
+
+	if !s.hasUPX && bytes.Contains(data, upxMagic) {
+		s.hasUPX = true
+	}
+
+	s.size += int64(len(p))
+	if len(data) > maxPatternSize {
+		data = data[len(data)-maxPatternSize:]
+	}
+
+	s.carry = append(s.carry[:0:0], data...)
+	return len(p), nil
+}
+
+// Entropy returns the Shannon entropy (bits per byte) for the scanned data
+func (s *Scanner) Entropy() float64 {
+	if s.size == 0 {
+		return 0
+	}
+
+	var entropy float64
+	total := float64(s.size)
+	for _, count := range s.counts {
+		if count == 0 {
+			continue
+		}
+
+		p := float64(count) / total
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+// Result returns the scan result (nil if there are no findings)
+func (s *Scanner) Result() *Result {
+	result := &Result{
+		Size:    s.size,
+		Entropy: math.Round(s.Entropy()*1000) / 1000,
+	}
+
+	archiveFormat := s.archiveFormat()
+	isELF := bytes.HasPrefix(s.head, elfMagic)
+
+	switch {
+	case archiveFormat != "":
+		result.Matches = append(result.Matches, &Match{
+			Kind:   KindArchive,
+			Format: archiveFormat,
+		})
+	case result.Entropy >= EntropyThreshold && !s.isMedia():
+		match := &Match{Kind: KindHighEntropy}
+		if isELF && s.hasUPX {
+			match.Format = "upx"
+		}
+
+		result.Matches = append(result.Matches, match)
+	}
+
+	//the archives have other archives inside (and the archive entries have the same magic),
+	//so only the first embedded archive is reported (the other ones are likely inside it)
+	if archiveFormat == "" && len(s.embedded) > 0 {
+		match := &Match{Kind: KindEmbeddedArchive}
+		for format, offset := range s.embedded {
+			if match.Format == "" || offset < match.Offset {
+				match.Format = format
+				match.Offset = offset
+			}
+		}
+
+		result.Matches = append(result.Matches, match)
+	}
+
+	if isELF {
+		name := path.Base(s.filePath)
+		for _, im := range interpreterMarkers {
+			if _, found := s.interpreters[im.format]; !found || isExeName(name, im.exeNames) {
+				continue
+			}
+
+			result.Matches = append(result.Matches, &Match{
+				Kind:   KindBundledInterpreter,
+				Format: im.format,
+			})
+		}
+	}
+
+	if len(result.Matches) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+func (s *Scanner) archiveFormat() string {
+	for _, sig := range signatures {
+		if len(s.head) >= sig.offset+len(sig.magic) &&
+			bytes.Equal(s.head[sig.offset:sig.offset+len(sig.magic)], sig.magic) {
+			return sig.format
+		}
+	}
+
+	return ""
+}
+
+func (s *Scanner) isMedia() bool {
+	for _, magic := range mediaMagics {
+		if bytes.HasPrefix(s.head, magic) {
+			return true
+		}
+	}
+
+	//ISO base media files (e.g., mp4)
+	return len(s.head) >= 8 && string(s.head[4:8]) == "ftyp"
+}
+
+func isExeName(name string, exeNames []string) bool {
+	for _, exeName := range exeNames {
+		if name == exeName || strings.HasPrefix(name, exeName+"-") || strings.HasPrefix(name, exeName+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// elfDataEnd returns the end of the ELF object data
+// (the end of the section header table or of the last segment, whichever is further)
+// or -1 if the ELF header can't be parsed from the file head
+func elfDataEnd(head []byte) int64 {
+	if len(head) < 64 {
+		return -1
+	}
+
+	var bo binary.ByteOrder
+	switch head[5] {
+	case 1:
+		bo = binary.LittleEndian
+	case 2:
+		bo = binary.BigEndian
+	default:
+		return -1
+	}
+
+	var phoff, shoff, phentsize, phnum, shentsize, shnum uint64
+	is64 := head[4] == 2
+	if is64 {
+		phoff = bo.Uint64(head[0x20:])
+		shoff = bo.Uint64(head[0x28:])
+		phentsize = uint64(bo.Uint16(head[0x36:]))
+		phnum = uint64(bo.Uint16(head[0x38:]))
+		shentsize = uint64(bo.Uint16(head[0x3a:]))
+		shnum = uint64(bo.Uint16(head[0x3c:]))
+	} else {
+		phoff = uint64(bo.Uint32(head[0x1c:]))
+		shoff = uint64(bo.Uint32(head[0x20:]))
+		phentsize = uint64(bo.Uint16(head[0x2a:]))
+		phnum = uint64(bo.Uint16(head[0x2c:]))
+		shentsize = uint64(bo.Uint16(head[0x2e:]))
+		shnum = uint64(bo.Uint16(head[0x30:]))
+	}
+
+	end := shoff + shentsize*shnum
+	for i := uint64(0); i < phnum; i++ {
+		pos := phoff + i*phentsize
+		var offset, fileSize uint64
+		if is64 {
+			if pos+0x28 > uint64(len(head)) {
+				return -1
+			}
+
+			offset = bo.Uint64(head[pos+0x08:])
+			fileSize = bo.Uint64(head[pos+0x20:])
+		} else {
+			if pos+0x20 > uint64(len(head)) {
+				return -1
+			}
+
+			offset = uint64(bo.Uint32(head[pos+0x04:]))
+			fileSize = uint64(bo.Uint32(head[pos+0x10:]))
+		}
+
+		if offset+fileSize > end {
+			end = offset + fileSize
+		}
+	}
+
+	return int64(end)
+}
@@ -0,0 +1,470 @@
+package cloudauth
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsSignAlgorithm = "AWS4-HMAC-SHA256"
+	awsAmzDateFormat = "20060102T150405Z"
+	awsShortDateFmt  = "20060102"
+	awsDefaultConfig = "default"
+	awsSessionName   = "docker-slim"
+	awsSTSVersion    = "2011-06-15"
+	awsSTSGlobalHost = "sts.amazonaws.com"
+	ecsCredsHost     = "http://169.254.170.2"
+	ec2MetadataHost  = "http://169.254.169.254"
+)
+
+// AWSCredentials are the AWS access keys (and the session token for the temporary credentials)
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSDefaultCredentials returns the AWS credentials from the standard provider chain:
+// the env vars, the web identity token (EKS service accounts and the CI OIDC providers),
+// the shared credentials file, the ECS container credentials and the EC2 instance profile
+func AWSDefaultCredentials(ctx context.Context) (*AWSCredentials, error) {
+	if creds := awsEnvCredentials(); creds != nil {
+		return creds, nil
+	}
+
+	if tokenFile, roleARN := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && roleARN != "" {
+		return awsWebIdentityCredentials(ctx, tokenFile, roleARN)
+	}
+
+	if creds, err := awsSharedCredentials(); err == nil && creds != nil {
+		return creds, nil
+	}
+
+	if creds, err := awsContainerCredentials(ctx); err == nil && creds != nil {
+		return creds, nil
+	}
+
+	if creds, err := awsInstanceCredentials(ctx); err == nil && creds != nil {
+		return creds, nil
+	}
+
+	return nil, fmt.Errorf("aws: %w", ErrNoCredentials)
+}
+
+// AWSRegion returns the region from the env vars or from the shared config file
+// (an empty string is returned if the region is not configured)
+func AWSRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+
+	if region := os.Getenv("AWS_DEFAULT_REGION"); region != "" {
+		return region
+	}
+
+	if sections, err := readINIFile(awsConfigPath("AWS_CONFIG_FILE", "config")); err == nil {
+		profile := awsProfile()
+		if profile != awsDefaultConfig {
+			profile = "profile " + profile
+		}
+
+		return sections[profile]["region"]
+	}
+
+	return ""
+}
+
+// SignAWSRequest signs the request with the AWS Signature Version 4
+// (the host, the content type and the x-amz-* headers are signed)
+func SignAWSRequest(req *http.Request, creds *AWSCredentials, region, service, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(awsAmzDateFormat)
+	shortDate := now.Format(awsShortDateFmt)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lname := strings.ToLower(name)
+		if strings.HasPrefix(lname, "x-amz-") || lname == "content-type" || lname == "range" {
+			headers[lname] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteString(":")
+		canonicalHeaders.WriteString(headers[name])
+		canonicalHeaders.WriteString("\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	canonicalPath := req.URL.EscapedPath()
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", shortDate, region, service)
+	stringToSign := strings.Join([]string{
+		awsSignAlgorithm,
+		amzDate,
+		scope,
+		SHA256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), shortDate)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSignAlgorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// SHA256Hex returns the hex encoded SHA256 hash (used for the signed request payload hashes)
+func SHA256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func awsCanonicalQuery(query url.Values) string {
+	var keys []string
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, AWSEscape(key, true)+"="+AWSEscape(value, true))
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// AWSEscape escapes everything except the unreserved characters (RFC 3986)
+// (the slashes are kept if escapeSlash is false, which is used for the S3 object keys)
+func AWSEscape(value string, escapeSlash bool) string {
+	var out strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9'),
+			b == '-', b == '_', b == '.', b == '~':
+			out.WriteByte(b)
+		case b == '/' && !escapeSlash:
+			out.WriteByte(b)
+		default:
+			fmt.Fprintf(&out, "%%%02X", b)
+		}
+	}
+
+	return out.String()
+}
+
+func awsEnvCredentials() *AWSCredentials {
+	creds := &AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if creds.AccessKeyID == "" {
+		creds.AccessKeyID = os.Getenv("AWS_ACCESS_KEY")
+	}
+
+	if creds.SecretAccessKey == "" {
+		creds.SecretAccessKey = os.Getenv("AWS_SECRET_KEY")
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil
+	}
+
+	return creds
+}
+
+func awsProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+
+	return awsDefaultConfig
+}
+
+func awsConfigPath(envName, fileName string) string {
+	if filePath := os.Getenv(envName); filePath != "" {
+		return filePath
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".aws", fileName)
+}
+
+func awsSharedCredentials() (*AWSCredentials, error) {
+	sections, err := readINIFile(awsConfigPath("AWS_SHARED_CREDENTIALS_FILE", "credentials"))
+	if err != nil {
+		return nil, err
+	}
+
+	section := sections[awsProfile()]
+	creds := &AWSCredentials{
+		AccessKeyID:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, nil
+	}
+
+	return creds, nil
+}
+
+// readINIFile reads the AWS config (or credentials) file sections
+func readINIFile(filePath string) (map[string]map[string]string, error) {
+	if filePath == "" {
+		return nil, os.ErrNotExist
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	sections := map[string]map[string]string{}
+	var current map[string]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = map[string]string{}
+			sections[name] = current
+		case current != nil:
+			if idx := strings.Index(line, "="); idx > 0 {
+				current[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+			}
+		}
+	}
+
+	return sections, scanner.Err()
+}
+
+// awsWebIdentityCredentials exchanges the web identity token for the role credentials
+// (the AssumeRoleWithWebIdentity requests are not signed)
+func awsWebIdentityCredentials(ctx context.Context, tokenFile, roleARN string) (*AWSCredentials, error) {
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = awsSessionName
+	}
+
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {awsSTSVersion},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+
+	host := awsSTSGlobalHost
+	if region := AWSRegion(); region != "" {
+		host = fmt.Sprintf("sts.%s.amazonaws.com", region)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/?%s", host, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if err := checkResponse(req, resp); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &AWSCredentials{
+		AccessKeyID:     result.Credentials.AccessKeyID,
+		SecretAccessKey: result.Credentials.SecretAccessKey,
+		SessionToken:    result.Credentials.SessionToken,
+	}, nil
+}
+
+type awsMetadataCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+func (c *awsMetadataCredentials) credentials() *AWSCredentials {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil
+	}
+
+	return &AWSCredentials{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.Token,
+	}
+}
+
+// awsContainerCredentials returns the ECS (or EKS pod identity) container credentials
+func awsContainerCredentials(ctx context.Context) (*AWSCredentials, error) {
+	credsURL := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relativeURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relativeURI != "" {
+		credsURL = ecsCredsHost + relativeURI
+	}
+
+	if credsURL == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, credsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		data, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		token = strings.TrimSpace(string(data))
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	var info awsMetadataCredentials
+	if err := GetJSON(metadataClient, req, &info); err != nil {
+		return nil, err
+	}
+
+	return info.credentials(), nil
+}
+
+// awsInstanceCredentials returns the EC2 instance profile credentials (using IMDSv2)
+func awsInstanceCredentials(ctx context.Context) (*AWSCredentials, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return nil, nil
+	}
+
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, ec2MetadataHost+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := readMetadata(tokenReq)
+	if err != nil {
+		return nil, err
+	}
+
+	credsPath := ec2MetadataHost + "/latest/meta-data/iam/security-credentials/"
+	roleReq, err := http.NewRequestWithContext(ctx, http.MethodGet, credsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	roleReq.Header.Set("X-aws-ec2-metadata-token", token)
+	roles, err := readMetadata(roleReq)
+	if err != nil {
+		return nil, err
+	}
+
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	if role == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, credsPath+role, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+
+	var info awsMetadataCredentials
+	if err := GetJSON(metadataClient, req, &info); err != nil {
+		return nil, err
+	}
+
+	return info.credentials(), nil
+}
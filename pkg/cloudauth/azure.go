@@ -0,0 +1,89 @@
+package cloudauth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	azureDefaultAuthority = "https://login.microsoftonline.com/"
+	azureIMDSTokenURL     = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureIMDSAPIVersion   = "2018-02-01"
+	azureAssertionType    = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	azureScopeSuffix      = "/.default"
+)
+
+// AzureAccessToken returns the Azure AD access token from the standard identity chain:
+// the service principal secret (AZURE_CLIENT_SECRET), the workload identity federated token
+// (AZURE_FEDERATED_TOKEN_FILE) and the managed identity (the scope is the resource with the '/.default' suffix)
+func AzureAccessToken(ctx context.Context, scope string) (*Token, error) {
+	tenantID := os.Getenv("AZURE_TENANT_ID")
+	clientID := os.Getenv("AZURE_CLIENT_ID")
+
+	if tenantID == "" || clientID == "" {
+		return azureManagedIdentityToken(ctx, clientID, scope)
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "client_credentials")
+	params.Set("client_id", clientID)
+	params.Set("scope", scope)
+
+	switch {
+	case os.Getenv("AZURE_CLIENT_SECRET") != "":
+		params.Set("client_secret", os.Getenv("AZURE_CLIENT_SECRET"))
+	case os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "":
+		assertion, err := ioutil.ReadFile(os.Getenv("AZURE_FEDERATED_TOKEN_FILE"))
+		if err != nil {
+			return nil, err
+		}
+
+		params.Set("client_assertion_type", azureAssertionType)
+		params.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	default:
+		return azureManagedIdentityToken(ctx, clientID, scope)
+	}
+
+	authority := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authority == "" {
+		authority = azureDefaultAuthority
+	}
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authority, "/"), tenantID)
+	token, err := PostTokenForm(ctx, tokenURL, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.Token(), nil
+}
+
+// azureManagedIdentityToken returns the managed identity token from the instance metadata service
+// (the client ID selects the user-assigned managed identity)
+func azureManagedIdentityToken(ctx context.Context, clientID, scope string) (*Token, error) {
+	query := url.Values{}
+	query.Set("api-version", azureIMDSAPIVersion)
+	query.Set("resource", strings.TrimSuffix(scope, azureScopeSuffix)+"/")
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, azureIMDSTokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Metadata", "true")
+
+	var token TokenResponse
+	if err := GetJSON(metadataClient, req, &token); err != nil {
+		return nil, fmt.Errorf("azure: %w (%v)", ErrNoCredentials, err)
+	}
+
+	return token.Token(), nil
+}
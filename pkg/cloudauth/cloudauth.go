@@ -0,0 +1,148 @@
+// Package cloudauth looks up the cloud provider credentials the same way the cloud SDKs do
+// (the AWS credential chain, the Google Application Default Credentials and the Azure identity chain),
+// so the report uploads and the cloud registry operations work without the cloud SDKs and CLIs.
+package cloudauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	requestTimeout     = 30 * time.Second
+	metadataTimeout    = 2 * time.Second
+	defaultTokenExpiry = time.Hour
+	maxErrorBodySize   = 512
+)
+
+var (
+	ErrNoCredentials          = errors.New("no credentials found")
+	ErrUnsupportedCredentials = errors.New("unsupported credentials type")
+	ErrRequestFailed          = errors.New("credentials request failed")
+)
+
+var (
+	httpClient = &http.Client{Timeout: requestTimeout}
+	//the metadata services are not available outside of the cloud instances
+	metadataClient = &http.Client{Timeout: metadataTimeout}
+)
+
+// Token is the OAuth2 access token
+type Token struct {
+	AccessToken string
+	Expires     time.Time
+}
+
+// TokenResponse is the OAuth2 token endpoint (or the metadata service token) response
+// (the refresh token is used by the token exchanges)
+type TokenResponse struct {
+	AccessToken  string   `json:"access_token"`
+	RefreshToken string   `json:"refresh_token"`
+	ExpiresIn    tokenTTL `json:"expires_in"`
+}
+
+// Token returns the access token with its expiration time
+func (r *TokenResponse) Token() *Token {
+	return &Token{
+		AccessToken: r.AccessToken,
+		Expires:     r.ExpiresIn.expires(),
+	}
+}
+
+// tokenTTL is the token lifetime in seconds
+// (some metadata services return it as a string)
+type tokenTTL int64
+
+func (ttl *tokenTTL) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*ttl = tokenTTL(value)
+	return nil
+}
+
+// expires returns the token expiration time (the default expiry is used if the TTL is not known)
+func (ttl tokenTTL) expires() time.Time {
+	if ttl <= 0 {
+		return time.Now().Add(defaultTokenExpiry)
+	}
+
+	return time.Now().Add(time.Duration(ttl) * time.Second)
+}
+
+// PostTokenForm sends the form encoded OAuth2 token request
+// (it's also used for the provider specific token exchanges)
+func PostTokenForm(ctx context.Context, endpoint string, form url.Values) (*TokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token TokenResponse
+	if err := GetJSON(httpClient, req, &token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// GetJSON sends the request and decodes the JSON response
+func GetJSON(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = httpClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if err := checkResponse(req, resp); err != nil {
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func readMetadata(req *http.Request) (string, error) {
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	if err := checkResponse(req, resp); err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func checkResponse(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return fmt.Errorf("%w - %s %s (%s): %s",
+		ErrRequestFailed, req.Method, req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,211 @@
+package cloudauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	googleTokenURL      = "https://oauth2.googleapis.com/token"
+	gceMetadataHost     = "metadata.google.internal"
+	gceTokenPath        = "/computeMetadata/v1/instance/service-accounts/default/token"
+	gcpCredsFileName    = "application_default_credentials.json"
+	credsServiceAccount = "service_account"
+	credsAuthorizedUser = "authorized_user"
+)
+
+// googleCredentials is the application default credentials file data
+type googleCredentials struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// GoogleAccessToken returns the OAuth2 access token from the standard provider chain:
+// the access token env var, the application default credentials file (service account or user credentials)
+// and the GCE metadata server (the scope is used for the service account tokens)
+func GoogleAccessToken(ctx context.Context, scope string) (*Token, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return &Token{AccessToken: token, Expires: time.Now().Add(defaultTokenExpiry)}, nil
+	}
+
+	credsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if credsPath == "" {
+		if wellKnownPath := googleWellKnownCredsPath(); wellKnownPath != "" {
+			if _, err := os.Stat(wellKnownPath); err == nil {
+				credsPath = wellKnownPath
+			}
+		}
+	}
+
+	if credsPath != "" {
+		data, err := ioutil.ReadFile(credsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var creds googleCredentials
+		if err := json.Unmarshal(data, &creds); err != nil {
+			return nil, err
+		}
+
+		return creds.accessToken(ctx, scope)
+	}
+
+	if token, err := gceAccessToken(ctx); err == nil && token.AccessToken != "" {
+		return token, nil
+	}
+
+	return nil, fmt.Errorf("gcp: %w", ErrNoCredentials)
+}
+
+// googleWellKnownCredsPath returns the gcloud application default credentials file path
+func googleWellKnownCredsPath() string {
+	if configDir := os.Getenv("CLOUDSDK_CONFIG"); configDir != "" {
+		return filepath.Join(configDir, gcpCredsFileName)
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", gcpCredsFileName)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".config", "gcloud", gcpCredsFileName)
+}
+
+func (c *googleCredentials) accessToken(ctx context.Context, scope string) (*Token, error) {
+	tokenURL := c.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+
+	params := url.Values{}
+	switch c.Type {
+	case credsServiceAccount:
+		assertion, err := c.jwtAssertion(tokenURL, scope, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		params.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		params.Set("assertion", assertion)
+	case credsAuthorizedUser:
+		params.Set("grant_type", "refresh_token")
+		params.Set("client_id", c.ClientID)
+		params.Set("client_secret", c.ClientSecret)
+		params.Set("refresh_token", c.RefreshToken)
+	default:
+		return nil, fmt.Errorf("%w - %s", ErrUnsupportedCredentials, c.Type)
+	}
+
+	token, err := PostTokenForm(ctx, tokenURL, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.Token(), nil
+}
+
+// jwtAssertion creates the signed JWT for the service account token request
+func (c *googleCredentials) jwtAssertion(audience, scope string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return "", errors.New("invalid service account private key")
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", err
+		}
+	}
+
+	key, ok := parsedKey.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("service account private key is not an RSA key")
+	}
+
+	header := map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	}
+
+	if c.PrivateKeyID != "" {
+		header["kid"] = c.PrivateKeyID
+	}
+
+	claims := map[string]interface{}{
+		"iss":   c.ClientEmail,
+		"scope": scope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+
+	claimsData, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(headerData) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsData)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// gceAccessToken returns the default service account token from the GCE metadata server
+func gceAccessToken(ctx context.Context) (*Token, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = gceMetadataHost
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+gceTokenPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var token TokenResponse
+	if err := GetJSON(metadataClient, req, &token); err != nil {
+		return nil, err
+	}
+
+	return token.Token(), nil
+}
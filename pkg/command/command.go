@@ -7,10 +7,16 @@ const (
 	Xray         Type = "xray"
 	Lint         Type = "lint"
 	Containerize Type = "containerize"
+	Instrument   Type = "instrument"
 	Convert      Type = "convert"
 	Edit         Type = "edit"
 	Debug        Type = "debug"
 	Probe        Type = "probe"
+	Verify       Type = "verify"
+	Doctor       Type = "doctor"
+	Extract      Type = "extract"
+	History      Type = "history"
+	Policy       Type = "policy"
 	Run          Type = "run"
 	Server       Type = "server"
 	Registry     Type = "registry"
@@ -23,12 +29,13 @@ type Type string
 
 // Command state constants
 const (
-	StateUnknown   = "unknown"
-	StateError     = "error"
-	StateStarted   = "started"
-	StateCompleted = "completed"
-	StateExited    = "exited"
-	StateDone      = "done"
+	StateUnknown     = "unknown"
+	StateError       = "error"
+	StateStarted     = "started"
+	StateCompleted   = "completed"
+	StateExited      = "exited"
+	StateDone        = "done"
+	StateInterrupted = "interrupted"
 )
 
 // State is the command state type
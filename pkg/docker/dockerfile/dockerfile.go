@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -69,8 +70,16 @@ func GenerateFromInfo(location string,
 	dsInfoLabel := fmt.Sprintf("LABEL %s=\"%s\"\n", consts.ContainerLabelName, v.Current())
 	dfData.WriteString(dsInfoLabel)
 
+	//the map based instructions are sorted to keep the generated Dockerfile stable
 	if len(labels) > 0 {
-		for name, value := range labels {
+		var labelNames []string
+		for name := range labels {
+			labelNames = append(labelNames, name)
+		}
+
+		sort.Strings(labelNames)
+		for _, name := range labelNames {
+			value := labels[name]
 			var encoded bytes.Buffer
 			encoder := json.NewEncoder(&encoded)
 			encoder.SetEscapeHTML(false)
@@ -99,6 +108,8 @@ func GenerateFromInfo(location string,
 			volumeList = append(volumeList, strconv.Quote(volumeName))
 		}
 
+		sort.Strings(volumeList)
+
 		volumeInst := fmt.Sprintf("VOLUME [%s]", strings.Join(volumeList, ","))
 		dfData.WriteString(volumeInst)
 		dfData.WriteByte('\n')
@@ -126,9 +137,15 @@ func GenerateFromInfo(location string,
 	}
 
 	if len(exposedPorts) > 0 {
+		var portList []string
 		for portInfo := range exposedPorts {
+			portList = append(portList, string(portInfo))
+		}
+
+		sort.Strings(portList)
+		for _, portInfo := range portList {
 			dfData.WriteString(instPrefixExpose)
-			dfData.WriteString(string(portInfo))
+			dfData.WriteString(portInfo)
 			dfData.WriteByte('\n')
 		}
 	}
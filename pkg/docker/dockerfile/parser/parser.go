@@ -3,6 +3,7 @@ package parser
 
 import (
 	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -19,7 +20,6 @@ var (
 
 //TODO:
 //* support incremental, partial and instruction level parsing
-//* support parsing from reader and from string
 
 func FromFile(fpath string) (*spec.Dockerfile, error) {
 	fo, err := os.Open(fpath)
@@ -29,7 +29,19 @@ func FromFile(fpath string) (*spec.Dockerfile, error) {
 
 	defer fo.Close()
 
-	astParsed, err := ast.Parse(fo)
+	return FromReader(fo, filepath.Base(fpath), filepath.Dir(fpath))
+}
+
+// FromString parses the Dockerfile data
+// (the name is used for the Dockerfile name and it doesn't need to be a file path)
+func FromString(data string, name string) (*spec.Dockerfile, error) {
+	return FromReader(strings.NewReader(data), name, "")
+}
+
+// FromReader parses the Dockerfile data from the reader
+// (location is the Dockerfile directory, if it's known)
+func FromReader(input io.Reader, name string, location string) (*spec.Dockerfile, error) {
+	astParsed, err := ast.Parse(input)
 	if err != nil {
 		return nil, err
 	}
@@ -39,8 +51,8 @@ func FromFile(fpath string) (*spec.Dockerfile, error) {
 	}
 
 	dockerfile := spec.NewDockerfile()
-	dockerfile.Name = filepath.Base(fpath)
-	dockerfile.Location = filepath.Dir(fpath)
+	dockerfile.Name = name
+	dockerfile.Location = location
 	dockerfile.Lines = astParsed.Lines
 
 	if astParsed.AST.StartLine > -1 && len(astParsed.AST.Children) > 0 {
@@ -127,6 +127,11 @@ func DockerfileFromHistory(apiClient *docker.Client, imageID string) (*Dockerfil
 		return nil, err
 	}
 
+	return DockerfileFromHistoryData(imageHistory)
+}
+
+// DockerfileFromHistoryData recreates Dockerfile information from the image history records (newest first)
+func DockerfileFromHistoryData(imageHistory []docker.ImageHistory) (*Dockerfile, error) {
 	var out Dockerfile
 
 	log.Debugf("\n\nIMAGE HISTORY =>\n%#v\n\n", imageHistory)
@@ -387,11 +392,7 @@ func DockerfileFromHistory(apiClient *docker.Client, imageID string) (*Dockerfil
 				instInfo.CommandAll = healthInst
 			}
 
-			if len(instInfo.CommandAll) > 44 {
-				instInfo.CommandSnippet = fmt.Sprintf("%s...", instInfo.CommandAll[0:44])
-			} else {
-				instInfo.CommandSnippet = instInfo.CommandAll
-			}
+			instInfo.CommandSnippet = CommandSnippet(instInfo.CommandAll)
 
 			if instInfo.Size > 0 {
 				instInfo.SizeHuman = humanize.Bytes(uint64(instInfo.Size))
@@ -507,6 +508,15 @@ func DockerfileFromHistory(apiClient *docker.Client, imageID string) (*Dockerfil
 }
 
 // SaveDockerfileData saves the Dockerfile information to a file
+// CommandSnippet returns the short version of the instruction command
+func CommandSnippet(command string) string {
+	if len(command) > 44 {
+		return fmt.Sprintf("%s...", command[0:44])
+	}
+
+	return command
+}
+
 func SaveDockerfileData(fatImageDockerfileLocation string, fatImageDockerfileLines []string) error {
 	var data bytes.Buffer
 	data.WriteString(strings.Join(fatImageDockerfileLines, "\n"))
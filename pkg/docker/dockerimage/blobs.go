@@ -0,0 +1,73 @@
+package dockerimage
+
+import (
+	"sort"
+)
+
+// DefaultBlobsMinFileSize is the default min size for the files scanned for the compressed, encrypted or bundled payloads
+const DefaultBlobsMinFileSize = "1MB"
+
+// BlobsReport contains the compressed, encrypted or bundled payloads found in the image layers
+type BlobsReport struct {
+	Count int `json:"count"`
+	//total size of the files with findings
+	Size     uint64         `json:"size"`
+	Findings []*BlobFinding `json:"findings,omitempty"`
+}
+
+// BlobFinding is a payload found in a layer file
+// (the removed files are not in the final image filesystem, but they are still in their layers)
+type BlobFinding struct {
+	Layer   int     `json:"layer"`
+	Path    string  `json:"path"`
+	Size    int64   `json:"size"`
+	Entropy float64 `json:"entropy"`
+	Kind    string  `json:"kind"`
+	Format  string  `json:"format,omitempty"`
+	Offset  int64   `json:"offset,omitempty"`
+	Removed bool    `json:"removed,omitempty"`
+}
+
+// Blobs creates the blobs report from the payloads detected in the layer files
+// (the findings for each layer are sorted by file size, largest first)
+func Blobs(pkg *Package) *BlobsReport {
+	report := &BlobsReport{}
+	imageFiles := ImageFiles(pkg)
+
+	for idx, layer := range pkg.Layers {
+		var paths []string
+		for path := range layer.Blobs {
+			paths = append(paths, path)
+		}
+
+		sort.Slice(paths, func(i, j int) bool {
+			if layer.Blobs[paths[i]].Size != layer.Blobs[paths[j]].Size {
+				return layer.Blobs[paths[i]].Size > layer.Blobs[paths[j]].Size
+			}
+
+			return paths[i] < paths[j]
+		})
+
+		for _, path := range paths {
+			result := layer.Blobs[path]
+			object, found := imageFiles[path]
+			removed := !found || object.LayerIndex != idx
+			report.Size += uint64(result.Size)
+			for _, m := range result.Matches {
+				report.Findings = append(report.Findings, &BlobFinding{
+					Layer:   idx,
+					Path:    path,
+					Size:    result.Size,
+					Entropy: result.Entropy,
+					Kind:    m.Kind,
+					Format:  m.Format,
+					Offset:  m.Offset,
+					Removed: removed,
+				})
+			}
+		}
+	}
+
+	report.Count = len(report.Findings)
+	return report
+}
@@ -0,0 +1,404 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Image comparison change types
+const (
+	CompareAdded    = "added"
+	CompareRemoved  = "removed"
+	CompareModified = "modified"
+)
+
+// Compared file properties
+const (
+	CompareDiffSize  = "size"
+	CompareDiffMode  = "mode"
+	CompareDiffOwner = "owner"
+	CompareDiffHash  = "hash"
+	CompareDiffLink  = "link"
+	CompareDiffType  = "type"
+)
+
+// DefaultCompareFilesMax is the default number of files to report for each file change type
+const DefaultCompareFilesMax = 100
+
+// CompareReport is the comparison of the target image with another image
+// (the changes are from the target image to the other image)
+type CompareReport struct {
+	Image        string        `json:"image"`
+	ImageID      string        `json:"image_id,omitempty"`
+	OtherImage   string        `json:"other_image"`
+	OtherImageID string        `json:"other_image_id,omitempty"`
+	Config       []*ConfigDiff `json:"config,omitempty"`
+	Layers       *LayersDiff   `json:"layers"`
+	Files        *FilesDiff    `json:"files"`
+	Sizes        *SizesDiff    `json:"sizes"`
+}
+
+// ConfigDiff is an image config difference
+type ConfigDiff struct {
+	Field      string `json:"field"`
+	Key        string `json:"key,omitempty"`
+	Change     string `json:"change"`
+	Value      string `json:"value,omitempty"`
+	OtherValue string `json:"other_value,omitempty"`
+}
+
+// LayersDiff is the layer digest (diff ID) comparison
+type LayersDiff struct {
+	Count      int          `json:"count"`
+	OtherCount int          `json:"other_count"`
+	SharedBase int          `json:"shared_base"`
+	Shared     int          `json:"shared"`
+	Removed    []*LayerDiff `json:"removed,omitempty"`
+	Added      []*LayerDiff `json:"added,omitempty"`
+}
+
+// LayerDiff is a layer that exists only in one of the compared images
+type LayerDiff struct {
+	Index  int    `json:"index"`
+	DiffID string `json:"diff_id"`
+	Size   uint64 `json:"size"`
+}
+
+// FilesDiff is the final filesystem comparison
+// (the file lists are limited, but the counts and the sizes include all files)
+type FilesDiff struct {
+	AddedCount    int         `json:"added_count"`
+	AddedSize     uint64      `json:"added_size"`
+	RemovedCount  int         `json:"removed_count"`
+	RemovedSize   uint64      `json:"removed_size"`
+	ModifiedCount int         `json:"modified_count"`
+	ModifiedDelta int64       `json:"modified_delta"`
+	Added         []*FileDiff `json:"added,omitempty"`
+	Removed       []*FileDiff `json:"removed,omitempty"`
+	Modified      []*FileDiff `json:"modified,omitempty"`
+}
+
+// FileDiff is a file change between the compared images
+type FileDiff struct {
+	Path      string   `json:"path"`
+	Size      int64    `json:"size,omitempty"`
+	OtherSize int64    `json:"other_size,omitempty"`
+	Diff      []string `json:"diff,omitempty"`
+}
+
+// SizesDiff is the size comparison
+type SizesDiff struct {
+	LayersSize      uint64 `json:"layers_size"`
+	OtherLayersSize uint64 `json:"other_layers_size"`
+	LayersDelta     int64  `json:"layers_delta"`
+	FilesSize       uint64 `json:"files_size"`
+	OtherFilesSize  uint64 `json:"other_files_size"`
+	FilesDelta      int64  `json:"files_delta"`
+}
+
+// Compare compares the target image package with another image package
+func Compare(pkg *Package, other *Package, filesMax int) *CompareReport {
+	if filesMax < 0 {
+		filesMax = DefaultCompareFilesMax
+	}
+
+	report := &CompareReport{
+		Config: compareConfigs(pkg.Config, other.Config),
+		Layers: compareLayers(pkg, other),
+		Files:  &FilesDiff{},
+		Sizes:  &SizesDiff{},
+	}
+
+	for _, layer := range pkg.Layers {
+		report.Sizes.LayersSize += layer.Stats.AllSize
+	}
+
+	for _, layer := range other.Layers {
+		report.Sizes.OtherLayersSize += layer.Stats.AllSize
+	}
+
+	report.Sizes.LayersDelta = int64(report.Sizes.OtherLayersSize) - int64(report.Sizes.LayersSize)
+
+	objects := visibleObjects(pkg, isComparedObject)
+	otherObjects := visibleObjects(other, isComparedObject)
+
+	for path, object := range objects {
+		if isSizedObject(object) {
+			report.Sizes.FilesSize += uint64(object.Size)
+		}
+
+		otherObject, found := otherObjects[path]
+		if !found {
+			report.Files.RemovedCount++
+			report.Files.RemovedSize += uint64(object.Size)
+			report.Files.Removed = append(report.Files.Removed, &FileDiff{
+				Path: path,
+				Size: object.Size,
+			})
+			continue
+		}
+
+		if diff := compareObjects(object, otherObject); len(diff) > 0 {
+			report.Files.ModifiedCount++
+			report.Files.ModifiedDelta += otherObject.Size - object.Size
+			report.Files.Modified = append(report.Files.Modified, &FileDiff{
+				Path:      path,
+				Size:      object.Size,
+				OtherSize: otherObject.Size,
+				Diff:      diff,
+			})
+		}
+	}
+
+	for path, otherObject := range otherObjects {
+		if isSizedObject(otherObject) {
+			report.Sizes.OtherFilesSize += uint64(otherObject.Size)
+		}
+
+		if _, found := objects[path]; !found {
+			report.Files.AddedCount++
+			report.Files.AddedSize += uint64(otherObject.Size)
+			report.Files.Added = append(report.Files.Added, &FileDiff{
+				Path:      path,
+				OtherSize: otherObject.Size,
+			})
+		}
+	}
+
+	report.Sizes.FilesDelta = int64(report.Sizes.OtherFilesSize) - int64(report.Sizes.FilesSize)
+
+	report.Files.Added = sortedFileDiffs(report.Files.Added, filesMax)
+	report.Files.Removed = sortedFileDiffs(report.Files.Removed, filesMax)
+	report.Files.Modified = sortedFileDiffs(report.Files.Modified, filesMax)
+	return report
+}
+
+// the directories are not compared (their metadata changes are mostly noise)
+func isComparedObject(object *ObjectMetadata) bool {
+	return object.Change != ChangeDelete && object.TypeFlag != tar.TypeDir
+}
+
+func compareObjects(object, other *ObjectMetadata) []string {
+	var diff []string
+	if object.TypeFlag != other.TypeFlag &&
+		!(isSizedObject(object) && isSizedObject(other)) {
+		diff = append(diff, CompareDiffType)
+	}
+
+	if object.Size != other.Size {
+		diff = append(diff, CompareDiffSize)
+	}
+
+	if object.Mode.Perm() != other.Mode.Perm() {
+		diff = append(diff, CompareDiffMode)
+	}
+
+	if object.UID != other.UID || object.GID != other.GID {
+		diff = append(diff, CompareDiffOwner)
+	}
+
+	if object.LinkTarget != other.LinkTarget {
+		diff = append(diff, CompareDiffLink)
+	}
+
+	//the hashes are available only if the data is hashed for both images
+	if object.Hash != "" && other.Hash != "" && object.Hash != other.Hash {
+		diff = append(diff, CompareDiffHash)
+	}
+
+	return diff
+}
+
+func sortedFileDiffs(list []*FileDiff, max int) []*FileDiff {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+
+	if max > 0 && len(list) > max {
+		list = list[:max]
+	}
+
+	return list
+}
+
+func compareLayers(pkg *Package, other *Package) *LayersDiff {
+	diff := &LayersDiff{
+		Count:      len(pkg.Layers),
+		OtherCount: len(other.Layers),
+	}
+
+	for idx := 0; idx < len(pkg.Layers) && idx < len(other.Layers); idx++ {
+		if pkg.Layers[idx].FSDiffID == "" ||
+			pkg.Layers[idx].FSDiffID != other.Layers[idx].FSDiffID {
+			break
+		}
+
+		diff.SharedBase++
+	}
+
+	diffIDs := map[string]struct{}{}
+	for _, layer := range pkg.Layers {
+		diffIDs[layer.FSDiffID] = struct{}{}
+	}
+
+	otherDiffIDs := map[string]struct{}{}
+	for _, layer := range other.Layers {
+		otherDiffIDs[layer.FSDiffID] = struct{}{}
+		if _, found := diffIDs[layer.FSDiffID]; found {
+			diff.Shared++
+			continue
+		}
+
+		diff.Added = append(diff.Added, &LayerDiff{
+			Index:  layer.Index,
+			DiffID: layer.FSDiffID,
+			Size:   layer.Stats.AllSize,
+		})
+	}
+
+	for _, layer := range pkg.Layers {
+		if _, found := otherDiffIDs[layer.FSDiffID]; !found {
+			diff.Removed = append(diff.Removed, &LayerDiff{
+				Index:  layer.Index,
+				DiffID: layer.FSDiffID,
+				Size:   layer.Stats.AllSize,
+			})
+		}
+	}
+
+	return diff
+}
+
+func compareConfigs(config, other *ConfigObject) []*ConfigDiff {
+	var cc, oc ContainerConfig
+	if config != nil && config.Config != nil {
+		cc = *config.Config
+	}
+
+	if other != nil && other.Config != nil {
+		oc = *other.Config
+	}
+
+	var diffs []*ConfigDiff
+	addValueDiff := func(field, value, otherValue string) {
+		if value == otherValue {
+			return
+		}
+
+		change := CompareModified
+		switch {
+		case value == "":
+			change = CompareAdded
+		case otherValue == "":
+			change = CompareRemoved
+		}
+
+		diffs = append(diffs, &ConfigDiff{
+			Field:      field,
+			Change:     change,
+			Value:      value,
+			OtherValue: otherValue,
+		})
+	}
+
+	addMapDiffs := func(field string, values, otherValues map[string]string) {
+		var keys []string
+		for k := range values {
+			keys = append(keys, k)
+		}
+
+		for k := range otherValues {
+			if _, found := values[k]; !found {
+				keys = append(keys, k)
+			}
+		}
+
+		sort.Strings(keys)
+		for _, k := range keys {
+			value, found := values[k]
+			otherValue, otherFound := otherValues[k]
+			var change string
+			switch {
+			case !found:
+				change = CompareAdded
+			case !otherFound:
+				change = CompareRemoved
+			case value != otherValue:
+				change = CompareModified
+			default:
+				continue
+			}
+
+			diffs = append(diffs, &ConfigDiff{
+				Field:      field,
+				Key:        k,
+				Change:     change,
+				Value:      value,
+				OtherValue: otherValue,
+			})
+		}
+	}
+
+	if config != nil && other != nil {
+		addValueDiff("os", config.OS, other.OS)
+		addValueDiff("architecture", config.Architecture, other.Architecture)
+	}
+
+	addValueDiff("user", cc.User, oc.User)
+	addValueDiff("workdir", cc.WorkingDir, oc.WorkingDir)
+	addValueDiff("entrypoint", listValue(cc.Entrypoint), listValue(oc.Entrypoint))
+	addValueDiff("cmd", listValue(cc.Cmd), listValue(oc.Cmd))
+	addValueDiff("shell", listValue(cc.Shell), listValue(oc.Shell))
+	addValueDiff("stop_signal", cc.StopSignal, oc.StopSignal)
+	addValueDiff("onbuild", listValue(cc.OnBuild), listValue(oc.OnBuild))
+
+	var healthcheck, otherHealthcheck string
+	if cc.Healthcheck != nil {
+		healthcheck = listValue(cc.Healthcheck.Test)
+	}
+
+	if oc.Healthcheck != nil {
+		otherHealthcheck = listValue(oc.Healthcheck.Test)
+	}
+
+	addValueDiff("healthcheck", healthcheck, otherHealthcheck)
+
+	addMapDiffs("env", envMap(cc.Env), envMap(oc.Env))
+	addMapDiffs("labels", cc.Labels, oc.Labels)
+	addMapDiffs("exposed_ports", setMap(cc.ExposedPorts), setMap(oc.ExposedPorts))
+	addMapDiffs("volumes", setMap(cc.Volumes), setMap(oc.Volumes))
+	return diffs
+}
+
+func listValue(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%q", list)
+}
+
+func envMap(env []string) map[string]string {
+	result := map[string]string{}
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			result[parts[0]] = parts[1]
+		} else {
+			result[parts[0]] = ""
+		}
+	}
+
+	return result
+}
+
+func setMap(set map[string]struct{}) map[string]string {
+	result := map[string]string{}
+	for k := range set {
+		result[k] = ""
+	}
+
+	return result
+}
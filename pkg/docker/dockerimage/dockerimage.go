@@ -2,10 +2,12 @@ package dockerimage
 
 import (
 	"archive/tar"
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"container/heap"
 	"crypto/sha1"
+	"debug/elf"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -22,8 +24,12 @@ import (
 	"github.com/dustin/go-humanize"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/docker-slim/docker-slim/pkg/blobdiscover"
 	"github.com/docker-slim/docker-slim/pkg/certdiscover"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/elfaudit"
+	"github.com/docker-slim/docker-slim/pkg/licensediscover"
+	"github.com/docker-slim/docker-slim/pkg/secretdiscover"
 	"github.com/docker-slim/docker-slim/pkg/system"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 )
@@ -84,6 +90,15 @@ type ImageReport struct {
 	OSShells     []*system.OSShell                `json:"shells,omitempty"`
 	Certs        CertsInfo                        `json:"certs"`
 	CACerts      CertsInfo                        `json:"ca_certs"`
+	WastedSpace  *WastedSpaceReport               `json:"wasted_space,omitempty"`
+	Sizes        *SizeReport                      `json:"sizes,omitempty"`
+	Packages     *PackageInventory                `json:"packages,omitempty"`
+	Licenses     *LicenseReport                   `json:"licenses,omitempty"`
+	Secrets      *SecretsReport                   `json:"secrets,omitempty"`
+	Blobs        *BlobsReport                     `json:"blobs,omitempty"`
+	Found        *FindReport                      `json:"found,omitempty"`
+	Comparison   *CompareReport                   `json:"comparison,omitempty"`
+	ELFDeps      *elfaudit.Report                 `json:"elf_deps,omitempty"`
 }
 
 type DuplicateFilesReport struct {
@@ -138,8 +153,15 @@ type Layer struct {
 	References          map[string]*ObjectMetadata
 	Top                 TopObjects
 	Distro              *system.DistroInfo
-	DataMatches         map[string][]*ChangeDataMatcher   //object.Name -> matched CDM
-	DataHashMatches     map[string]*ChangeDataHashMatcher //object.Name -> matched CDHM
+	PackageDBs          map[string]*PackageDB              //object.Name -> parsed package database
+	LicenseFiles        map[string][]string                //object.Name -> detected licenses
+	Secrets             map[string][]*secretdiscover.Match //object.Name -> detected secrets
+	Blobs               map[string]*blobdiscover.Result    //object.Name -> detected compressed, encrypted or bundled payloads
+	FindMatches         map[string]*FindMatch              //object.Name -> found object (path or content match)
+	ELFObjects          map[string]*elfaudit.Object        //object.Name -> ELF object dynamic linking info
+	LibConfigs          map[string][]byte                  //object.Name -> dynamic linker library path config data
+	DataMatches         map[string][]*ChangeDataMatcher    //object.Name -> matched CDM
+	DataHashMatches     map[string]*ChangeDataHashMatcher  //object.Name -> matched CDHM
 	pathMatches         bool
 }
 
@@ -274,6 +296,16 @@ type UTF8DetectorMatcher struct {
 	Matcher     *regexp.Regexp
 }
 
+// SecretDetector enables the secret detection for the image files
+// (the files bigger than MaxSizeBytes are not scanned)
+type SecretDetector struct {
+	MaxSizeBytes int
+}
+
+type BlobDetector struct {
+	MinSizeBytes int64
+}
+
 type TarWriter struct {
 	file       *os.File
 	bufferGzip *gzip.Writer
@@ -447,6 +479,13 @@ func newLayer(id string, topChangesMax int) *Layer {
 		Top:             NewTopObjects(topChangesCount),
 		DataMatches:     map[string][]*ChangeDataMatcher{},
 		DataHashMatches: map[string]*ChangeDataHashMatcher{},
+		PackageDBs:      map[string]*PackageDB{},
+		LicenseFiles:    map[string][]string{},
+		Secrets:         map[string][]*secretdiscover.Match{},
+		Blobs:           map[string]*blobdiscover.Result{},
+		FindMatches:     map[string]*FindMatch{},
+		ELFObjects:      map[string]*elfaudit.Object{},
+		LibConfigs:      map[string][]byte{},
 	}
 
 	heap.Init(&(layer.Top))
@@ -466,6 +505,14 @@ func LoadPackage(archivePath string,
 	utf8Detector *UTF8Detector,
 	doDetectAllCertFiles bool,
 	doDetectAllCertPKFiles bool,
+	doDetectPackages bool,
+	doDetectLicenses bool,
+	doAuditELF bool,
+	secretDetector *SecretDetector,
+	blobDetector *BlobDetector,
+	contentFinder *ContentFinder,
+	parallelism int,
+	onLayerProgress LayerProgressFunc,
 ) (*Package, error) {
 	imageID = dockerutil.CleanImageID(imageID)
 
@@ -483,6 +530,8 @@ func LoadPackage(archivePath string,
 	layers := map[string]*Layer{}
 
 	var archiveFiles []string
+	var layerJobs []*layerJob
+	var linkedLayers []*linkedLayer
 	tr := tar.NewReader(afile)
 	for {
 		hdr, err := tr.Next()
@@ -536,60 +585,104 @@ func LoadPackage(archivePath string,
 				parts := strings.Split(hdr.Name, "/")
 				layerID := parts[0]
 
-				var layer *Layer
 				if hdr.Typeflag == tar.TypeSymlink {
-					layer = newLayer(layerID, topChangesMax)
-					layer.Path = hdr.Name
-					layer.MetadataChangesOnly = true
-
-					parts := strings.Split(hdr.Linkname, "/")
-					if len(parts) == 3 && parts[2] == "layer.tar" {
-						layer.LayerDataSource = parts[1]
-
-						if srcLayer, ok := layers[layer.LayerDataSource]; ok {
-							for _, srcObj := range srcLayer.Objects {
-								if srcObj.Change != ChangeDelete {
-									newObj := *srcObj
-									newObj.Change = ChangeUnknown
-									layer.Objects = append(layer.Objects, &newObj)
-									layer.References[srcObj.Name] = &newObj
-									layer.Stats.ObjectCount++
-								}
-							}
+					//the linked layers are resolved after all layers with data are loaded
+					linkedLayers = append(linkedLayers, &linkedLayer{
+						id:       layerID,
+						path:     hdr.Name,
+						linkname: hdr.Linkname,
+					})
+					continue
+				}
 
-							layer.Stats.LinkCount = srcLayer.Stats.LinkCount - srcLayer.Stats.DeletedLinkCount
-							layer.Stats.FileCount = srcLayer.Stats.FileCount - srcLayer.Stats.DeletedFileCount
-							layer.Stats.DirCount = srcLayer.Stats.DirCount - srcLayer.Stats.DeletedDirCount
-						} else {
-							log.Debugf("dockerimage.LoadPackage: could not find source layer - %v", layer.LayerDataSource)
-						}
-					}
-				} else {
-					layer, err = layerFromStream(
-						pkg,
-						hdr.Name,
-						tar.NewReader(tr),
-						layerID,
-						topChangesMax,
-						doHashData,
-						doDetectDuplicates,
-						changeDataHashMatchers,
-						changePathMatchers,
-						cpmDumps,
-						changeDataMatchers,
-						utf8Detector,
-						doDetectAllCertFiles,
-						doDetectAllCertPKFiles,
-					)
-					if err != nil {
-						log.Errorf("dockerimage.LoadPackage: error reading layer from archive(%v/%v) - %v", archivePath, hdr.Name, err)
-						return nil, err
+				//the tar reader doesn't read ahead, so the layer data starts at the current file offset
+				offset, err := afile.Seek(0, io.SeekCurrent)
+				if err != nil {
+					log.Errorf("dockerimage.LoadPackage: error getting layer offset in archive(%v/%v) - %v", archivePath, hdr.Name, err)
+					return nil, err
+				}
+
+				layerJobs = append(layerJobs, &layerJob{
+					index:  len(layerJobs),
+					id:     layerID,
+					path:   hdr.Name,
+					offset: offset,
+					size:   hdr.Size,
+				})
+			}
+		}
+	}
+
+	if utf8Detector != nil || cpmDumps || hasDataMatcherDumps(changeDataMatchers, changeDataHashMatchers) {
+		//the utf8 data archive and the dumps are shared by all layers
+		parallelism = 1
+	}
+
+	processLayer := func(lpkg *Package, job *layerJob, ltr *tar.Reader) (*Layer, error) {
+		return layerFromStream(
+			lpkg,
+			job.path,
+			ltr,
+			job.id,
+			topChangesMax,
+			doHashData,
+			doDetectDuplicates,
+			changeDataHashMatchers,
+			changePathMatchers,
+			cpmDumps,
+			changeDataMatchers,
+			utf8Detector,
+			doDetectAllCertFiles,
+			doDetectAllCertPKFiles,
+			doDetectPackages,
+			doDetectLicenses,
+			doAuditELF,
+			secretDetector,
+			blobDetector,
+			contentFinder,
+		)
+	}
+
+	layerResults, err := loadLayers(afile, layerJobs, parallelism, onLayerProgress, processLayer)
+	if err != nil {
+		log.Errorf("dockerimage.LoadPackage: error reading layers from archive(%v) - %v", archivePath, err)
+		return nil, err
+	}
+
+	for _, result := range layerResults {
+		pkg.merge(result.pkg)
+		layers[result.job.id] = result.layer
+	}
+
+	for _, ll := range linkedLayers {
+		layer := newLayer(ll.id, topChangesMax)
+		layer.Path = ll.path
+		layer.MetadataChangesOnly = true
+
+		parts := strings.Split(ll.linkname, "/")
+		if len(parts) == 3 && parts[2] == "layer.tar" {
+			layer.LayerDataSource = parts[1]
+
+			if srcLayer, ok := layers[layer.LayerDataSource]; ok {
+				for _, srcObj := range srcLayer.Objects {
+					if srcObj.Change != ChangeDelete {
+						newObj := *srcObj
+						newObj.Change = ChangeUnknown
+						layer.Objects = append(layer.Objects, &newObj)
+						layer.References[srcObj.Name] = &newObj
+						layer.Stats.ObjectCount++
 					}
 				}
 
-				layers[layerID] = layer
+				layer.Stats.LinkCount = srcLayer.Stats.LinkCount - srcLayer.Stats.DeletedLinkCount
+				layer.Stats.FileCount = srcLayer.Stats.FileCount - srcLayer.Stats.DeletedFileCount
+				layer.Stats.DirCount = srcLayer.Stats.DirCount - srcLayer.Stats.DeletedDirCount
+			} else {
+				log.Debugf("dockerimage.LoadPackage: could not find source layer - %v", layer.LayerDataSource)
 			}
 		}
+
+		layers[ll.id] = layer
 	}
 
 	if pkg.Manifest == nil {
@@ -864,6 +957,24 @@ func hasChangePathMatcherDumps(changePathMatchers []*ChangePathMatcher) bool {
 	return false
 }
 
+func hasDataMatcherDumps(
+	changeDataMatchers map[string]*ChangeDataMatcher,
+	changeDataHashMatchers map[string]*ChangeDataHashMatcher) bool {
+	for _, cdm := range changeDataMatchers {
+		if cdm.Dump {
+			return true
+		}
+	}
+
+	for _, dhm := range changeDataHashMatchers {
+		if dhm.Dump {
+			return true
+		}
+	}
+
+	return false
+}
+
 func linkTargetToFullPath(fullPath, target string) string {
 	if filepath.IsAbs(target) {
 		return target
@@ -893,6 +1004,12 @@ func layerFromStream(
 	utf8Detector *UTF8Detector,
 	doDetectAllCertFiles bool,
 	doDetectAllCertPKFiles bool,
+	doDetectPackages bool,
+	doDetectLicenses bool,
+	doAuditELF bool,
+	secretDetector *SecretDetector,
+	blobDetector *BlobDetector,
+	contentFinder *ContentFinder,
 ) (*Layer, error) {
 
 	layer := newLayer(layerID, topChangesMax)
@@ -947,6 +1064,13 @@ func layerFromStream(
 		layer.Objects = append(layer.Objects, object)
 		layer.References[object.Name] = object
 
+		//the content matches are collected when the file data is inspected
+		if contentFinder != nil &&
+			!contentFinder.HasContentPatterns() &&
+			contentFinder.MatchPath(object.Name) {
+			layer.FindMatches[object.Name] = &FindMatch{}
+		}
+
 		heap.Push(&(layer.Top), object)
 		if layer.Top.Len() > topChangesCount {
 			_ = heap.Pop(&(layer.Top))
@@ -1068,9 +1192,17 @@ func layerFromStream(
 					pkg.SpecialPermRefs.Sticky[object.Name] = object
 				}
 
+				//the blob scanner gets the file data read by the other detectors and then the rest of it
+				var fileReader io.Reader = tr
+				var blobScanner *blobdiscover.Scanner
+				if blobDetector != nil && object.Size >= blobDetector.MinSizeBytes {
+					blobScanner = blobdiscover.NewScanner(object.Name)
+					fileReader = io.TeeReader(tr, blobScanner)
+				}
+
 				err = inspectFile(
 					object,
-					tr,
+					fileReader,
 					pkg,
 					layer,
 					doHashData,
@@ -1081,7 +1213,20 @@ func layerFromStream(
 					utf8Detector,
 					doDetectAllCertFiles,
 					doDetectAllCertPKFiles,
+					doDetectPackages,
+					doDetectLicenses,
+					doAuditELF,
+					secretDetector,
+					contentFinder,
 				)
+				if err == nil && blobScanner != nil {
+					if _, err = fsutil.CopyStream(ioutil.Discard, fileReader); err == nil {
+						if result := blobScanner.Result(); result != nil {
+							layer.Blobs[object.Name] = result
+						}
+					}
+				}
+
 				if err != nil {
 					log.Errorf("layerFromStream: error inspecting layer file (%s) - (%v) - %v", object.Name, layerID, err)
 				} else {
@@ -1125,7 +1270,7 @@ func layerFromStream(
 func getStreamHash(reader io.Reader) (string, error) {
 	hasher := sha1.New()
 
-	_, err := io.Copy(hasher, reader)
+	_, err := fsutil.CopyStream(hasher, reader)
 	if err != nil {
 		log.Errorf("getStreamHash: error=%v", err)
 		return "", err
@@ -1135,6 +1280,32 @@ func getStreamHash(reader io.Reader) (string, error) {
 	return hex.EncodeToString(hash[:]), nil
 }
 
+// maxDetectDataSize is the max amount of file data loaded in memory for the file type detectors
+const maxDetectDataSize = 8 * 1024 * 1024
+
+// readDataHead reads up to maxSize bytes from the reader
+// (optionally hashing the full data stream without buffering it)
+func readDataHead(reader io.Reader, maxSize int64, doHash bool) ([]byte, string, error) {
+	if !doHash {
+		data, err := ioutil.ReadAll(io.LimitReader(reader, maxSize))
+		return data, "", err
+	}
+
+	hasher := sha1.New()
+	tee := io.TeeReader(reader, hasher)
+	data, err := ioutil.ReadAll(io.LimitReader(tee, maxSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err := fsutil.CopyStream(ioutil.Discard, tee); err != nil {
+		return nil, "", err
+	}
+
+	hash := hasher.Sum(nil)
+	return data, hex.EncodeToString(hash[:]), nil
+}
+
 func getBytesHash(data []byte) string {
 	hash := sha1.Sum(data)
 	return hex.EncodeToString(hash[:])
@@ -1166,6 +1337,11 @@ func inspectFile(
 	utf8Detector *UTF8Detector,
 	doDetectAllCertFiles bool,
 	doDetectAllCertPKFiles bool,
+	doDetectPackages bool,
+	doDetectLicenses bool,
+	doAuditELF bool,
+	secretDetector *SecretDetector,
+	contentFinder *ContentFinder,
 ) error {
 	//TODO: refactor and enhance the OS Distro detection logic
 	fullPath := object.Name
@@ -1193,15 +1369,69 @@ func inspectFile(
 		isKnownCertFile = true
 	}
 
+	packageDBType := ""
+	if doDetectPackages {
+		packageDBType = system.OSPackageDBType(fullPath)
+	}
+
+	isLicenseFile := doDetectLicenses && licensediscover.IsLicenseFile(fullPath)
+	isLibConfigFile := doAuditELF && elfaudit.IsLibConfigFile(fullPath)
+
+	//the ELF objects are detected by their magic (peeking at the file data without consuming it)
+	var isELFCandidate bool
+	if doAuditELF && !isLibConfigFile && object.Size > 0 {
+		br := bufio.NewReader(reader)
+		head, _ := br.Peek(len(elf.ELFMAG))
+		isELFCandidate = elfaudit.IsELFData(head)
+		reader = br
+	}
+
+	isSecretCandidate := secretDetector != nil &&
+		object.Size > 0 &&
+		object.Size <= int64(secretDetector.MaxSizeBytes)
+	isFindCandidate := contentFinder != nil &&
+		contentFinder.HasContentPatterns() &&
+		object.Size > 0 &&
+		contentFinder.MatchPath(fullPath)
+
 	if system.IsOSReleaseFile(fullPath) ||
 		system.IsOSShellsFile(fullPath) ||
+		packageDBType != "" ||
+		isLicenseFile ||
+		isLibConfigFile ||
+		isELFCandidate ||
+		isSecretCandidate ||
+		isFindCandidate ||
 		len(changeDataMatchers) > 0 ||
 		cpmDumps ||
 		cdhmDumps ||
 		utf8Detector != nil ||
 		(!isKnownCertFile && doDetectAllCertFiles) ||
 		(!isKnownCertFile && doDetectAllCertPKFiles) {
-		data, err := ioutil.ReadAll(reader)
+		//the full file data is needed only for the data matchers, dumps, utf8 detection,
+		//the package databases, the license files, the ELF objects and the content search,
+		//the other detectors only need the beginning of the file
+		needFullData := len(changeDataMatchers) > 0 ||
+			cpmDumps ||
+			cdhmDumps ||
+			utf8Detector != nil ||
+			packageDBType != "" ||
+			isLicenseFile ||
+			isLibConfigFile ||
+			isELFCandidate ||
+			isFindCandidate
+
+		var data []byte
+		var streamHash string
+		var err error
+		if needFullData {
+			data, err = ioutil.ReadAll(reader)
+		} else {
+			data, streamHash, err = readDataHead(reader,
+				maxDetectDataSize,
+				doHashData || len(changeDataHashMatchers) > 0)
+		}
+
 		if err != nil {
 			return err
 		}
@@ -1243,6 +1473,48 @@ func inspectFile(
 			}
 		}
 
+		if packageDBType != "" {
+			packages, err := system.NewOSPackagesFromData(packageDBType, data)
+			if err != nil {
+				log.Debugf("dockerimage.inspectFile: error parsing package database (%s) - %v", fullPath, err)
+			}
+
+			layer.PackageDBs[fullPath] = &PackageDB{
+				Type:     packageDBType,
+				Packages: packages,
+				Error:    err,
+			}
+		}
+
+		if isLicenseFile {
+			layer.LicenseFiles[fullPath] = licensediscover.Detect(data)
+		}
+
+		if isLibConfigFile {
+			layer.LibConfigs[fullPath] = data
+		}
+
+		if isELFCandidate {
+			elfObject, err := elfaudit.ParseELF(bytes.NewReader(data))
+			if err != nil {
+				log.Debugf("dockerimage.inspectFile: error parsing ELF object (%s) - %v", fullPath, err)
+			} else if elfObject != nil {
+				layer.ELFObjects[fullPath] = elfObject
+			}
+		}
+
+		if isFindCandidate && !secretdiscover.IsBinaryData(data) {
+			if matches := contentFinder.MatchContent(data); len(matches) > 0 {
+				layer.FindMatches[fullPath] = &FindMatch{Content: matches}
+			}
+		}
+
+		if isSecretCandidate {
+			if matches := secretdiscover.ScanData(data); len(matches) > 0 {
+				layer.Secrets[fullPath] = matches
+			}
+		}
+
 		if system.IsOSReleaseFile(fullPath) {
 			osr, err := system.NewOsRelease(data)
 			if err != nil {
@@ -1272,10 +1544,11 @@ func inspectFile(
 			layer.Distro = distro
 		}
 
-		var hash string
-		if doHashData ||
-			len(changeDataHashMatchers) > 0 ||
-			utf8Detector != nil {
+		hash := streamHash
+		if hash == "" &&
+			(doHashData ||
+				len(changeDataHashMatchers) > 0 ||
+				utf8Detector != nil) {
 			hash = getBytesHash(data)
 		}
 
@@ -0,0 +1,98 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/elfaudit"
+)
+
+const ldLibraryPathEnv = "LD_LIBRARY_PATH"
+
+// AuditELFDependencies resolves the library dependencies for the dynamic ELF objects
+// in the final image filesystem (the ELF objects are collected only if the package is loaded with the ELF audit enabled)
+func AuditELFDependencies(pkg *Package) *elfaudit.Report {
+	tree := elfaudit.NewTree()
+	objects := visibleObjects(pkg, func(object *ObjectMetadata) bool {
+		return object.Change != ChangeDelete
+	})
+
+	objectLayer := func(object *ObjectMetadata) (*Layer, bool) {
+		if object.LayerIndex < 0 || object.LayerIndex >= len(pkg.Layers) {
+			return nil, false
+		}
+
+		return pkg.Layers[object.LayerIndex], true
+	}
+
+	for path, object := range objects {
+		switch object.TypeFlag {
+		case tar.TypeDir:
+			tree.AddDir(path)
+		case tar.TypeSymlink:
+			tree.AddSymlink(path, object.LinkTarget)
+		case tar.TypeLink:
+			//the hard links share the data with the link target file
+			var elfObject *elfaudit.Object
+			if target, found := objects[linkTargetPath(object.LinkTarget)]; found {
+				if layer, ok := objectLayer(target); ok {
+					elfObject = layer.ELFObjects[target.Name]
+				}
+			}
+
+			tree.AddFile(path, elfObject)
+		default:
+			layer, ok := objectLayer(object)
+			if !ok {
+				tree.AddFile(path, nil)
+				continue
+			}
+
+			if data, found := layer.LibConfigs[path]; found {
+				tree.AddLibConfig(path, data)
+				continue
+			}
+
+			tree.AddFile(path, layer.ELFObjects[path])
+		}
+	}
+
+	return elfaudit.Audit(tree, imageLibraryPath(pkg))
+}
+
+// imageLibraryPath returns the LD_LIBRARY_PATH directories from the image config
+func imageLibraryPath(pkg *Package) []string {
+	if pkg.Config == nil || pkg.Config.Config == nil {
+		return nil
+	}
+
+	return LibraryPathDirs(pkg.Config.Config.Env)
+}
+
+// LibraryPathDirs returns the LD_LIBRARY_PATH directories from the environment variables
+// (the last LD_LIBRARY_PATH value is used if it's set more than once)
+func LibraryPathDirs(env []string) []string {
+	var dirs []string
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, ldLibraryPathEnv+"=") {
+			continue
+		}
+
+		dirs = nil
+		for _, dir := range strings.Split(strings.TrimPrefix(kv, ldLibraryPathEnv+"="), ":") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	return dirs
+}
+
+func linkTargetPath(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return target
+	}
+
+	return "/" + target
+}
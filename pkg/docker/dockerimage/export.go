@@ -0,0 +1,405 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrBadLayerIndex  = errors.New("bad layer index")
+	ErrNoLayerData    = errors.New("no layer data in image archive")
+	ErrUnsafeLinkPath = errors.New("unsafe link path")
+)
+
+// LayerExportInfo describes the exported layer data
+type LayerExportInfo struct {
+	Layer       int    `json:"layer"`
+	Merged      bool   `json:"merged"`
+	Output      string `json:"output"`
+	IsArchive   bool   `json:"is_archive"`
+	ObjectCount int    `json:"object_count"`
+	DataSize    int64  `json:"data_size"`
+}
+
+// IsArchiveOutput returns true if the export output is a tar archive (.tar, .tar.gz or .tgz)
+func IsArchiveOutput(output string) bool {
+	return strings.HasSuffix(output, ".tar") ||
+		strings.HasSuffix(output, ".tar.gz") ||
+		strings.HasSuffix(output, ".tgz")
+}
+
+type layerData struct {
+	offset int64
+	size   int64
+}
+
+// ExportLayer saves the layer data (or the merged filesystem at the layer index)
+// from the image archive to a host directory or to a tar archive
+// (the layer data is saved as-is including the whiteouts,
+// the merged filesystem has the lower layer objects that are not deleted or overwritten in the upper layers)
+func ExportLayer(archivePath string, pkg *Package, index int, merged bool, output string) (*LayerExportInfo, error) {
+	if index < 0 || index >= len(pkg.Layers) {
+		return nil, ErrBadLayerIndex
+	}
+
+	info := &LayerExportInfo{
+		Layer:     index,
+		Merged:    merged,
+		Output:    output,
+		IsArchive: IsArchiveOutput(output),
+	}
+
+	first := index
+	if merged {
+		first = 0
+	}
+
+	var layerPaths []string
+	for idx := first; idx <= index; idx++ {
+		layerPaths = append(layerPaths, layerArchivePath(pkg.Layers[idx]))
+	}
+
+	afile, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer afile.Close()
+
+	dataRefs, err := findLayerData(afile, layerPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	//the final object versions in the merged filesystem (layer path -> object names)
+	var selected map[string]map[string]struct{}
+	if merged {
+		selected, err = mergedObjects(afile, layerPaths, dataRefs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer, err := newObjectWriter(output, info.IsArchive)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, layerPath := range layerPaths {
+		data := dataRefs[layerPath]
+		tr := tar.NewReader(io.NewSectionReader(afile, data.offset, data.size))
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				writer.Close()
+				return nil, err
+			}
+
+			name := filepath.Clean(hdr.Name)
+			if merged {
+				if _, found := selected[layerPath][name]; !found {
+					continue
+				}
+			}
+
+			hdr.Name = name
+			if err := writer.Write(hdr, tr); err != nil {
+				writer.Close()
+				return nil, err
+			}
+
+			info.ObjectCount++
+			if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+				info.DataSize += hdr.Size
+			}
+		}
+	}
+
+	return info, writer.Close()
+}
+
+func layerArchivePath(layer *Layer) string {
+	if layer.MetadataChangesOnly && layer.LayerDataSource != "" {
+		return fmt.Sprintf("%s/%s", layer.LayerDataSource, "layer.tar")
+	}
+
+	return layer.Path
+}
+
+func findLayerData(afile *os.File, layerPaths []string) (map[string]*layerData, error) {
+	wanted := map[string]struct{}{}
+	for _, path := range layerPaths {
+		wanted[path] = struct{}{}
+	}
+
+	refs := map[string]*layerData{}
+	tr := tar.NewReader(afile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, found := wanted[hdr.Name]; !found || hdr.Typeflag == tar.TypeSymlink {
+			continue
+		}
+
+		//the tar reader doesn't read ahead, so the layer data starts at the current file offset
+		offset, err := afile.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+
+		refs[hdr.Name] = &layerData{offset: offset, size: hdr.Size}
+	}
+
+	for _, path := range layerPaths {
+		if _, found := refs[path]; !found {
+			return nil, fmt.Errorf("%w - %s", ErrNoLayerData, path)
+		}
+	}
+
+	return refs, nil
+}
+
+// mergedObjects selects the final object versions in the merged filesystem for each layer
+func mergedObjects(afile io.ReaderAt, layerPaths []string, dataRefs map[string]*layerData) (map[string]map[string]struct{}, error) {
+	visible := map[string]string{} //object name -> layer path
+	deleteVisible := func(prefix string) {
+		for name := range visible {
+			if strings.HasPrefix(name, prefix) {
+				delete(visible, name)
+			}
+		}
+	}
+
+	for _, layerPath := range layerPaths {
+		data := dataRefs[layerPath]
+		tr := tar.NewReader(io.NewSectionReader(afile, data.offset, data.size))
+		var added []string
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			name := filepath.Clean(hdr.Name)
+			base := filepath.Base(name)
+			switch {
+			case base == WhiteoutOpaqueDir:
+				deleteVisible(filepath.Dir(name) + "/")
+			case strings.HasPrefix(base, WhiteoutPrefix):
+				deleted := filepath.Join(filepath.Dir(name), strings.TrimPrefix(base, WhiteoutPrefix))
+				delete(visible, deleted)
+				deleteVisible(deleted + "/")
+			default:
+				added = append(added, name)
+			}
+		}
+
+		//the whiteouts only apply to the lower layers
+		for _, name := range added {
+			visible[name] = layerPath
+		}
+	}
+
+	selected := map[string]map[string]struct{}{}
+	for name, layerPath := range visible {
+		if selected[layerPath] == nil {
+			selected[layerPath] = map[string]struct{}{}
+		}
+
+		selected[layerPath][name] = struct{}{}
+	}
+
+	return selected, nil
+}
+
+type objectWriter interface {
+	Write(hdr *tar.Header, data io.Reader) error
+	Close() error
+}
+
+func newObjectWriter(output string, isArchive bool) (objectWriter, error) {
+	if isArchive {
+		aw, err := newArchiveWriter(output)
+		if err != nil {
+			return nil, err
+		}
+
+		return aw, nil
+	}
+
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return nil, err
+	}
+
+	return newDirWriter(output), nil
+}
+
+type archiveWriter struct {
+	file *os.File
+	gzw  *gzip.Writer
+	tw   *tar.Writer
+}
+
+func newArchiveWriter(output string) (*archiveWriter, error) {
+	if dir := filepath.Dir(output); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &archiveWriter{file: file}
+	if strings.HasSuffix(output, ".tar") {
+		w.tw = tar.NewWriter(file)
+	} else {
+		w.gzw = gzip.NewWriter(file)
+		w.tw = tar.NewWriter(w.gzw)
+	}
+
+	return w, nil
+}
+
+func (ref *archiveWriter) Write(hdr *tar.Header, data io.Reader) error {
+	if err := ref.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+		if _, err := io.Copy(ref.tw, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ref *archiveWriter) Close() error {
+	err := ref.tw.Close()
+	if ref.gzw != nil {
+		if gerr := ref.gzw.Close(); err == nil {
+			err = gerr
+		}
+	}
+
+	if ferr := ref.file.Close(); err == nil {
+		err = ferr
+	}
+
+	return err
+}
+
+// dirWriter extracts the objects to a host directory
+// (the special files are skipped and the directory permissions are set last,
+// so the read-only directories can still be populated)
+type dirWriter struct {
+	root     string
+	dirModes map[string]os.FileMode
+}
+
+func newDirWriter(root string) *dirWriter {
+	return &dirWriter{
+		root:     root,
+		dirModes: map[string]os.FileMode{},
+	}
+}
+
+// target returns the host path for the object (the objects can't escape the output directory)
+func (ref *dirWriter) target(name string) string {
+	return filepath.Join(ref.root, filepath.Clean("/"+name))
+}
+
+func (ref *dirWriter) Write(hdr *tar.Header, data io.Reader) error {
+	target := ref.target(hdr.Name)
+	if target == ref.root {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	mode := hdr.FileInfo().Mode()
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return err
+		}
+
+		ref.dirModes[target] = mode.Perm()
+	case tar.TypeReg, tar.TypeRegA:
+		os.Remove(target)
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(file, data); err != nil {
+			file.Close()
+			return err
+		}
+
+		if err := file.Close(); err != nil {
+			return err
+		}
+
+		if err := os.Chtimes(target, hdr.ModTime, hdr.ModTime); err != nil {
+			log.Debugf("dockerimage.dirWriter: error setting file times (%s) - %v", target, err)
+		}
+	case tar.TypeSymlink:
+		os.Remove(target)
+		if err := os.Symlink(hdr.Linkname, target); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		linkTarget := ref.target(hdr.Linkname)
+		if !strings.HasPrefix(linkTarget, ref.root) {
+			return ErrUnsafeLinkPath
+		}
+
+		os.Remove(target)
+		if err := os.Link(linkTarget, target); err != nil {
+			log.Debugf("dockerimage.dirWriter: error creating hardlink (%s -> %s) - %v", target, linkTarget, err)
+		}
+	default:
+		log.Debugf("dockerimage.dirWriter: skipping special file (%s / %v)", hdr.Name, hdr.Typeflag)
+	}
+
+	return nil
+}
+
+func (ref *dirWriter) Close() error {
+	for dir, mode := range ref.dirModes {
+		if err := os.Chmod(dir, mode); err != nil {
+			log.Debugf("dockerimage.dirWriter: error setting directory permissions (%s) - %v", dir, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoExtractPaths = errors.New("no paths to extract")
+	ErrNoManifest     = errors.New("no manifest in image archive")
+)
+
+// PathExtractInfo describes the data extracted from the image filesystem
+type PathExtractInfo struct {
+	Paths       []string `json:"paths"`
+	Missing     []string `json:"missing,omitempty"`
+	Output      string   `json:"output"`
+	IsArchive   bool     `json:"is_archive"`
+	ObjectCount int      `json:"object_count"`
+	DataSize    int64    `json:"data_size"`
+}
+
+// ExtractPaths saves the selected files and directories from the merged image filesystem
+// (the final object versions from all image layers) to a host directory or to a tar archive
+// (the parent directories of the selected paths are saved too, so their ownership and permissions are preserved;
+// the image archive is not loaded as a package, so only the layer data with the selected objects is processed)
+func ExtractPaths(archivePath string, paths []string, output string, isArchive bool) (*PathExtractInfo, error) {
+	if len(paths) == 0 {
+		return nil, ErrNoExtractPaths
+	}
+
+	info := &PathExtractInfo{
+		Output:    output,
+		IsArchive: isArchive,
+	}
+
+	//the object names in the layer archives don't have the leading slash
+	wanted := map[string]bool{} //object name -> found
+	parents := map[string]struct{}{}
+	for _, p := range paths {
+		name := strings.TrimPrefix(filepath.Clean("/"+p), "/")
+		if name == "" {
+			//the whole filesystem
+			name = "."
+		}
+
+		wanted[name] = false
+		info.Paths = append(info.Paths, "/"+strings.TrimPrefix(name, "."))
+		for dir := filepath.Dir(name); dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+			parents[dir] = struct{}{}
+		}
+	}
+
+	isSelected := func(name string) bool {
+		if _, found := parents[name]; found {
+			return true
+		}
+
+		for target := range wanted {
+			if target == "." || name == target || strings.HasPrefix(name, target+"/") {
+				wanted[target] = true
+				return true
+			}
+		}
+
+		return false
+	}
+
+	afile, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer afile.Close()
+
+	layerPaths, err := manifestLayerPaths(afile)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := afile.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	dataRefs, err := findLayerData(afile, layerPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := mergedObjects(afile, layerPaths, dataRefs)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := newObjectWriter(output, isArchive)
+	if err != nil {
+		return nil, err
+	}
+
+	written := map[string]struct{}{}
+	for _, layerPath := range layerPaths {
+		//the linked layers share the layer data
+		if _, found := written[layerPath]; found {
+			continue
+		}
+
+		written[layerPath] = struct{}{}
+		data := dataRefs[layerPath]
+		tr := tar.NewReader(io.NewSectionReader(afile, data.offset, data.size))
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				writer.Close()
+				return nil, err
+			}
+
+			name := filepath.Clean(hdr.Name)
+			if _, found := selected[layerPath][name]; !found {
+				continue
+			}
+
+			if !isSelected(name) {
+				continue
+			}
+
+			hdr.Name = name
+			if err := writer.Write(hdr, tr); err != nil {
+				writer.Close()
+				return nil, err
+			}
+
+			info.ObjectCount++
+			if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+				info.DataSize += hdr.Size
+			}
+		}
+	}
+
+	for name, found := range wanted {
+		if !found && name != "." {
+			info.Missing = append(info.Missing, "/"+name)
+		}
+	}
+
+	sort.Strings(info.Missing)
+	return info, writer.Close()
+}
+
+// manifestLayerPaths returns the layer data paths in the image archive in the manifest order
+// (the linked layers are resolved to the layers with the data)
+func manifestLayerPaths(afile *os.File) ([]string, error) {
+	var manifests []ManifestObject
+	links := map[string]string{}
+	tr := tar.NewReader(afile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Clean(hdr.Name)
+		switch {
+		case name == manifestFileName:
+			if err := jsonFromStream(tr, &manifests); err != nil {
+				return nil, err
+			}
+		case hdr.Typeflag == tar.TypeSymlink:
+			links[name] = filepath.Join(filepath.Dir(name), hdr.Linkname)
+		}
+	}
+
+	if len(manifests) == 0 {
+		return nil, ErrNoManifest
+	}
+
+	var layerPaths []string
+	for _, layerPath := range manifests[0].Layers {
+		if target, found := links[layerPath]; found {
+			layerPath = target
+		}
+
+		layerPaths = append(layerPaths, layerPath)
+	}
+
+	return layerPaths, nil
+}
@@ -0,0 +1,134 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"sort"
+	"strings"
+)
+
+// FileTreeNode is a node in the merged filesystem tree at a layer
+type FileTreeNode struct {
+	Name string
+	Path string
+	//nil for the directories that don't have their own objects in the layers
+	Object *ObjectMetadata
+	IsDir  bool
+	//total size of the files in the directory (or the file size)
+	Size uint64
+	//the change made by the selected layer (ChangeUnknown if the object is from a lower layer)
+	Change   ChangeType
+	Children []*FileTreeNode
+	Parent   *FileTreeNode
+}
+
+// HasChanges returns true if the node or any of its children are changed by the selected layer
+func (ref *FileTreeNode) HasChanges() bool {
+	if ref.Change != ChangeUnknown {
+		return true
+	}
+
+	for _, child := range ref.Children {
+		if child.HasChanges() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LayerFileTree creates the merged filesystem tree at the layer index
+// (the objects added or modified by the layer are marked and the objects deleted by the layer are included)
+func LayerFileTree(pkg *Package, index int) (*FileTreeNode, error) {
+	if index < 0 || index >= len(pkg.Layers) {
+		return nil, ErrBadLayerIndex
+	}
+
+	root := &FileTreeNode{
+		Name:  "/",
+		Path:  "/",
+		IsDir: true,
+	}
+
+	nodes := map[string]*FileTreeNode{"/": root}
+	var addNode func(path string) *FileTreeNode
+	addNode = func(path string) *FileTreeNode {
+		if node, found := nodes[path]; found {
+			return node
+		}
+
+		idx := strings.LastIndex(path, "/")
+		parentPath := path[:idx]
+		if parentPath == "" {
+			parentPath = "/"
+		}
+
+		parent := addNode(parentPath)
+		parent.IsDir = true
+		node := &FileTreeNode{
+			Name:   path[idx+1:],
+			Path:   path,
+			Parent: parent,
+		}
+
+		parent.Children = append(parent.Children, node)
+		nodes[path] = node
+		return node
+	}
+
+	objects := visibleObjectsAt(pkg, index, func(object *ObjectMetadata) bool {
+		return object.Change != ChangeDelete
+	})
+
+	for path, object := range objects {
+		node := addNode(path)
+		node.Object = object
+		node.IsDir = node.IsDir || object.TypeFlag == tar.TypeDir
+		if object.LayerIndex == index {
+			node.Change = object.Change
+		}
+	}
+
+	for _, object := range pkg.Layers[index].Objects {
+		if object.Change != ChangeDelete || object.DirContentDelete {
+			continue
+		}
+
+		if _, found := objects[object.Name]; found {
+			continue
+		}
+
+		node := addNode(object.Name)
+		node.Object = object
+		node.Change = ChangeDelete
+	}
+
+	root.updateSizes()
+	return root, nil
+}
+
+func (ref *FileTreeNode) updateSizes() uint64 {
+	if !ref.IsDir {
+		if ref.Object != nil && isSizedObject(ref.Object) {
+			ref.Size = uint64(ref.Object.Size)
+		}
+
+		return ref.Size
+	}
+
+	sort.Slice(ref.Children, func(i, j int) bool {
+		return ref.Children[i].Name < ref.Children[j].Name
+	})
+
+	ref.Size = 0
+	for _, child := range ref.Children {
+		//the deleted objects don't use space in the merged filesystem
+		if child.Change == ChangeDelete {
+			child.updateSizes()
+			continue
+		}
+
+		ref.Size += child.updateSizes()
+	}
+
+	return ref.Size
+}
@@ -0,0 +1,242 @@
+package dockerimage
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// Found object status values
+const (
+	FoundCurrent     = "current"
+	FoundOverwritten = "overwritten"
+	FoundDeleted     = "deleted"
+	FoundWhiteout    = "whiteout"
+)
+
+// DefaultFindContentMatchesMax is the default max number of the content matches reported for a file
+const DefaultFindContentMatchesMax = 10
+
+const maxContentMatchText = 160
+
+// ContentFinder finds the objects by path (glob patterns) and by content (regular expressions)
+// in all image layers (including the deleted and overwritten file versions)
+type ContentFinder struct {
+	PathPatterns    []string
+	ContentPatterns []string
+	MatchesMax      int
+	matchers        []*regexp.Regexp
+}
+
+// ContentMatch is a content pattern match in a file
+type ContentMatch struct {
+	Pattern string `json:"pattern"`
+	Line    int    `json:"line"`
+	Text    string `json:"text"`
+}
+
+// FindMatch is an object found in a layer
+type FindMatch struct {
+	Content []*ContentMatch
+}
+
+// FindReport contains the objects found in the image layers
+type FindReport struct {
+	PathPatterns    []string       `json:"path_patterns,omitempty"`
+	ContentPatterns []string       `json:"content_patterns,omitempty"`
+	Count           int            `json:"count"`
+	Objects         []*FoundObject `json:"objects,omitempty"`
+}
+
+// FoundObject is an object version found in a layer
+// (ChangedIn is the layer where the object was overwritten or deleted)
+type FoundObject struct {
+	Path        string              `json:"path"`
+	Layer       int                 `json:"layer"`
+	Status      string              `json:"status"`
+	ChangedIn   int                 `json:"changed_in,omitempty"`
+	Size        int64               `json:"size"`
+	Content     []*ContentMatch     `json:"content,omitempty"`
+	Instruction *InstructionSummary `json:"instruction,omitempty"`
+}
+
+// NewContentFinder creates a new content finder
+func NewContentFinder(pathPatterns, contentPatterns []string, matchesMax int) (*ContentFinder, error) {
+	finder := &ContentFinder{
+		PathPatterns:    pathPatterns,
+		ContentPatterns: contentPatterns,
+		MatchesMax:      matchesMax,
+	}
+
+	for _, pattern := range pathPatterns {
+		if _, err := doublestar.Match(pattern, "/"); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pattern := range contentPatterns {
+		matcher, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		finder.matchers = append(finder.matchers, matcher)
+	}
+
+	return finder, nil
+}
+
+// HasContentPatterns returns true if the finder searches the file content
+func (ref *ContentFinder) HasContentPatterns() bool {
+	return len(ref.matchers) > 0
+}
+
+// MatchPath returns true if the object path matches any of the path patterns
+// (all paths match if there are no path patterns)
+func (ref *ContentFinder) MatchPath(name string) bool {
+	if len(ref.PathPatterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range ref.PathPatterns {
+		matched, err := doublestar.Match(pattern, name)
+		if err != nil {
+			log.Errorf("doublestar.Match name='%s' error=%v", name, err)
+			continue
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchContent returns the content pattern matches in the file data
+func (ref *ContentFinder) MatchContent(data []byte) []*ContentMatch {
+	var matches []*ContentMatch
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Bytes()
+		for idx, matcher := range ref.matchers {
+			if !matcher.Match(text) {
+				continue
+			}
+
+			matchText := strings.TrimSpace(string(text))
+			if len(matchText) > maxContentMatchText {
+				matchText = matchText[:maxContentMatchText]
+			}
+
+			matches = append(matches, &ContentMatch{
+				Pattern: ref.ContentPatterns[idx],
+				Line:    line,
+				Text:    matchText,
+			})
+
+			if ref.MatchesMax > 0 && len(matches) >= ref.MatchesMax {
+				return matches
+			}
+		}
+	}
+
+	return matches
+}
+
+// FindObjects creates the report for the objects found in all image layers
+// (the status shows if the object version is in the final image filesystem
+// or if it's overwritten or deleted in one of the upper layers)
+func FindObjects(pkg *Package, finder *ContentFinder) *FindReport {
+	report := &FindReport{
+		PathPatterns:    finder.PathPatterns,
+		ContentPatterns: finder.ContentPatterns,
+	}
+
+	visible := map[string]*FoundObject{}
+	changeVisible := func(prefix string, status string, layerIdx int) {
+		for path, found := range visible {
+			if strings.HasPrefix(path, prefix) {
+				found.Status = status
+				found.ChangedIn = layerIdx
+				delete(visible, path)
+			}
+		}
+	}
+
+	for idx, layer := range pkg.Layers {
+		if layer.MetadataChangesOnly {
+			continue
+		}
+
+		//the whiteouts apply to the lower layers, so they are processed first
+		for _, object := range layer.Objects {
+			if object.Change != ChangeDelete {
+				continue
+			}
+
+			if object.DirContentDelete {
+				changeVisible(strings.TrimSuffix(object.Name, "*"), FoundDeleted, idx)
+				continue
+			}
+
+			if found, ok := visible[object.Name]; ok {
+				found.Status = FoundDeleted
+				found.ChangedIn = idx
+				delete(visible, object.Name)
+			}
+
+			changeVisible(object.Name+"/", FoundDeleted, idx)
+		}
+
+		for _, object := range layer.Objects {
+			if object.Change != ChangeDelete {
+				if found, ok := visible[object.Name]; ok {
+					found.Status = FoundOverwritten
+					found.ChangedIn = idx
+					delete(visible, object.Name)
+				}
+			}
+
+			match, ok := layer.FindMatches[object.Name]
+			if !ok {
+				continue
+			}
+
+			found := &FoundObject{
+				Path:    object.Name,
+				Layer:   idx,
+				Status:  FoundCurrent,
+				Size:    object.Size,
+				Content: match.Content,
+			}
+
+			if object.Change == ChangeDelete {
+				found.Status = FoundWhiteout
+			} else {
+				visible[object.Name] = found
+			}
+
+			report.Objects = append(report.Objects, found)
+		}
+	}
+
+	sort.SliceStable(report.Objects, func(i, j int) bool {
+		if report.Objects[i].Path != report.Objects[j].Path {
+			return report.Objects[i].Path < report.Objects[j].Path
+		}
+
+		return report.Objects[i].Layer < report.Objects[j].Layer
+	})
+
+	report.Count = len(report.Objects)
+	return report
+}
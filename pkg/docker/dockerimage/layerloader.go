@@ -0,0 +1,199 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultLayerParallelism is the default number of layers processed concurrently
+const DefaultLayerParallelism = 4
+
+// LayerProgress describes the state of a processed image layer
+type LayerProgress struct {
+	ID        string
+	Path      string
+	Size      int64
+	Completed int
+	Total     int
+	Error     error
+}
+
+// LayerProgressFunc is called (sequentially) each time an image layer is processed
+type LayerProgressFunc func(progress *LayerProgress)
+
+// layerJob references the layer tar data in the image archive
+type layerJob struct {
+	index  int
+	id     string
+	path   string
+	offset int64
+	size   int64
+}
+
+// layerResult is the processed layer along with the package data collected from it
+type layerResult struct {
+	job   *layerJob
+	layer *Layer
+	pkg   *Package
+	err   error
+}
+
+// linkedLayer is a layer reusing the data of another layer
+type linkedLayer struct {
+	id       string
+	path     string
+	linkname string
+}
+
+// loadLayers processes the layer tar data with a pool of workers
+// (each layer is processed with its own package state merged in the archive order,
+// so the results don't depend on the number of workers)
+func loadLayers(
+	archive io.ReaderAt,
+	jobs []*layerJob,
+	parallelism int,
+	onProgress LayerProgressFunc,
+	process func(pkg *Package, job *layerJob, tr *tar.Reader) (*Layer, error),
+) ([]*layerResult, error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	if parallelism > len(jobs) {
+		parallelism = len(jobs)
+	}
+
+	jobCh := make(chan *layerJob)
+	resultCh := make(chan *layerResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				result := &layerResult{
+					job: job,
+					pkg: newPackage(),
+				}
+
+				data := io.NewSectionReader(archive, job.offset, job.size)
+				result.layer, result.err = process(result.pkg, job, tar.NewReader(data))
+				resultCh <- result
+			}
+		}()
+	}
+
+	go func() {
+		for _, job := range jobs {
+			jobCh <- job
+		}
+
+		close(jobCh)
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	results := make([]*layerResult, len(jobs))
+	var firstErr error
+	var completed int
+	for result := range resultCh {
+		completed++
+		results[result.job.index] = result
+		if result.err != nil && firstErr == nil {
+			firstErr = result.err
+		}
+
+		log.Debugf("dockerimage.loadLayers: layer processed %v/%v - %v (err=%v)",
+			completed, len(jobs), result.job.path, result.err)
+
+		if onProgress != nil {
+			onProgress(&LayerProgress{
+				ID:        result.job.id,
+				Path:      result.job.path,
+				Size:      result.job.size,
+				Completed: completed,
+				Total:     len(jobs),
+				Error:     result.err,
+			})
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return results, nil
+}
+
+// merge adds the package data collected from a layer
+func (ref *Package) merge(other *Package) {
+	for k, v := range other.HashReferences {
+		hr, found := ref.HashReferences[k]
+		if !found {
+			hr = map[string]*ObjectMetadata{}
+			ref.HashReferences[k] = hr
+		}
+
+		for name, om := range v {
+			hr[name] = om
+		}
+	}
+
+	for k, v := range other.OSShells {
+		ref.OSShells[k] = v
+	}
+
+	for k, v := range other.SpecialPermRefs.Setuid {
+		ref.SpecialPermRefs.Setuid[k] = v
+	}
+
+	for k, v := range other.SpecialPermRefs.Setgid {
+		ref.SpecialPermRefs.Setgid[k] = v
+	}
+
+	for k, v := range other.SpecialPermRefs.Sticky {
+		ref.SpecialPermRefs.Sticky[k] = v
+	}
+
+	ref.Certs.merge(&other.Certs)
+	ref.CACerts.merge(&other.CACerts)
+
+	ref.Stats.DeletedCount += other.Stats.DeletedCount
+	ref.Stats.DeletedDirContentCount += other.Stats.DeletedDirContentCount
+	ref.Stats.DeletedDirCount += other.Stats.DeletedDirCount
+	ref.Stats.DeletedFileCount += other.Stats.DeletedFileCount
+	ref.Stats.DeletedLinkCount += other.Stats.DeletedLinkCount
+	ref.Stats.SetuidCount += other.Stats.SetuidCount
+	ref.Stats.SetgidCount += other.Stats.SetgidCount
+	ref.Stats.StickyCount += other.Stats.StickyCount
+}
+
+func (ref *CertsRefInfo) merge(other *CertsRefInfo) {
+	for k := range other.Bundles {
+		ref.Bundles[k] = struct{}{}
+	}
+
+	for k := range other.Files {
+		ref.Files[k] = struct{}{}
+	}
+
+	for k, v := range other.Links {
+		ref.Links[k] = v
+	}
+
+	for k, v := range other.Hashes {
+		ref.Hashes[k] = v
+	}
+
+	for k := range other.PrivateKeys {
+		ref.PrivateKeys[k] = struct{}{}
+	}
+
+	for k, v := range other.PrivateKeyLinks {
+		ref.PrivateKeyLinks[k] = v
+	}
+}
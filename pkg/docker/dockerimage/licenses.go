@@ -0,0 +1,194 @@
+package dockerimage
+
+import (
+	"sort"
+
+	"github.com/docker-slim/docker-slim/pkg/licensediscover"
+)
+
+// License sources for the installed packages
+const (
+	LicenseSourcePackage   = "package"
+	LicenseSourceCopyright = "copyright"
+)
+
+// LicenseReport is the license inventory for the final image filesystem
+type LicenseReport struct {
+	Licenses        []*LicenseSummary  `json:"licenses"`
+	UnknownPackages int                `json:"unknown_packages"`
+	Packages        []*PackageLicense  `json:"packages,omitempty"`
+	Files           []*LicenseFileInfo `json:"files,omitempty"`
+	Denied          []*DeniedLicense   `json:"denied,omitempty"`
+}
+
+// LicenseSummary is the number of packages and license files for a license
+type LicenseSummary struct {
+	License  string `json:"license"`
+	Packages int    `json:"packages"`
+	Files    int    `json:"files"`
+}
+
+// PackageLicense is the license of an installed OS package
+// (from the package metadata or from the package copyright file)
+type PackageLicense struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Type    string `json:"type"`
+	License string `json:"license,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// LicenseFileInfo is a license file in the final image filesystem
+// (the package is set for the package copyright files used for the package licenses)
+type LicenseFileInfo struct {
+	Path     string   `json:"path"`
+	Layer    int      `json:"layer"`
+	Licenses []string `json:"licenses,omitempty"`
+	Package  string   `json:"package,omitempty"`
+}
+
+// DeniedLicense is a denied license found in the image
+// (the package or the license file path where it was found)
+type DeniedLicense struct {
+	License string `json:"license"`
+	Package string `json:"package,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Licenses creates the license inventory from the installed packages
+// and the license files visible in the final image filesystem
+func Licenses(pkg *Package, inventory *PackageInventory) *LicenseReport {
+	report := &LicenseReport{}
+	imageFiles := ImageFiles(pkg)
+
+	copyrights := map[string]*LicenseFileInfo{}
+	for path, object := range imageFiles {
+		if object.LayerIndex >= len(pkg.Layers) {
+			continue
+		}
+
+		licenses, found := pkg.Layers[object.LayerIndex].LicenseFiles[path]
+		if !found {
+			continue
+		}
+
+		info := &LicenseFileInfo{
+			Path:     path,
+			Layer:    object.LayerIndex,
+			Licenses: licenses,
+		}
+
+		if name := licensediscover.DpkgCopyrightPackage(path); name != "" {
+			copyrights[name] = info
+		}
+
+		report.Files = append(report.Files, info)
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Path < report.Files[j].Path
+	})
+
+	summaries := map[string]*LicenseSummary{}
+	summary := func(license string) *LicenseSummary {
+		info, found := summaries[license]
+		if !found {
+			info = &LicenseSummary{License: license}
+			summaries[license] = info
+		}
+
+		return info
+	}
+
+	if inventory != nil {
+		for _, p := range inventory.Packages {
+			info := &PackageLicense{
+				Name:    p.Name,
+				Version: p.Version,
+				Type:    p.Type,
+			}
+
+			if p.License != "" {
+				info.License = p.License
+				info.Source = LicenseSourcePackage
+				summary(info.License).Packages++
+			} else if copyright, found := copyrights[p.Name]; found && len(copyright.Licenses) > 0 {
+				info.Source = LicenseSourceCopyright
+				copyright.Package = p.Name
+				for idx, license := range copyright.Licenses {
+					if idx > 0 {
+						info.License += " AND "
+					}
+
+					info.License += license
+					summary(license).Packages++
+				}
+			} else {
+				report.UnknownPackages++
+			}
+
+			report.Packages = append(report.Packages, info)
+		}
+	}
+
+	//the package copyright files are already counted for their packages
+	for _, info := range report.Files {
+		if info.Package != "" {
+			continue
+		}
+
+		for _, license := range info.Licenses {
+			summary(license).Files++
+		}
+	}
+
+	for _, info := range summaries {
+		report.Licenses = append(report.Licenses, info)
+	}
+
+	sort.Slice(report.Licenses, func(i, j int) bool {
+		ci := report.Licenses[i].Packages + report.Licenses[i].Files
+		cj := report.Licenses[j].Packages + report.Licenses[j].Files
+		if ci != cj {
+			return ci > cj
+		}
+
+		return report.Licenses[i].License < report.Licenses[j].License
+	})
+
+	return report
+}
+
+// FindDenied returns the package and file licenses matching the denied license patterns
+func (ref *LicenseReport) FindDenied(patterns []string) []*DeniedLicense {
+	var denied []*DeniedLicense
+	if len(patterns) == 0 {
+		return denied
+	}
+
+	for _, p := range ref.Packages {
+		for _, license := range licensediscover.Denied(p.License, patterns) {
+			denied = append(denied, &DeniedLicense{
+				License: license,
+				Package: p.Name,
+			})
+		}
+	}
+
+	for _, info := range ref.Files {
+		if info.Package != "" {
+			continue
+		}
+
+		for _, expression := range info.Licenses {
+			for _, license := range licensediscover.Denied(expression, patterns) {
+				denied = append(denied, &DeniedLicense{
+					License: license,
+					Path:    info.Path,
+				})
+			}
+		}
+	}
+
+	return denied
+}
@@ -0,0 +1,120 @@
+package dockerimage
+
+import (
+	"sort"
+
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+// PackageDB is a package manager database found in a layer
+type PackageDB struct {
+	Type     string
+	Packages []*system.OSPackage
+	Error    error
+}
+
+// PackageInventory is the installed OS packages inventory for the final image filesystem
+type PackageInventory struct {
+	Count     int                 `json:"count"`
+	TotalSize uint64              `json:"total_size"`
+	DBs       []*PackageDBInfo    `json:"dbs"`
+	Packages  []*InstalledPackage `json:"packages,omitempty"`
+}
+
+// PackageDBInfo describes a package manager database in the final image filesystem
+type PackageDBInfo struct {
+	Type  string `json:"type"`
+	Path  string `json:"path"`
+	Layer int    `json:"layer"`
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"`
+}
+
+// InstalledPackage is an installed OS package
+// (the layer is where the current package version was installed)
+type InstalledPackage struct {
+	system.OSPackage
+	Type  string `json:"type"`
+	Layer int    `json:"layer"`
+}
+
+// Packages creates the installed OS packages inventory from the package manager databases
+// visible in the final image filesystem
+func Packages(pkg *Package) *PackageInventory {
+	inventory := &PackageInventory{}
+	imageFiles := ImageFiles(pkg)
+
+	var dbPaths []string
+	for path, object := range imageFiles {
+		if object.LayerIndex < len(pkg.Layers) {
+			if _, found := pkg.Layers[object.LayerIndex].PackageDBs[path]; found {
+				dbPaths = append(dbPaths, path)
+			}
+		}
+	}
+
+	sort.Strings(dbPaths)
+	for _, path := range dbPaths {
+		dbLayer := imageFiles[path].LayerIndex
+		db := pkg.Layers[dbLayer].PackageDBs[path]
+		info := &PackageDBInfo{
+			Type:  db.Type,
+			Path:  path,
+			Layer: dbLayer,
+			Count: len(db.Packages),
+		}
+
+		if db.Error != nil {
+			info.Error = db.Error.Error()
+		}
+
+		inventory.DBs = append(inventory.DBs, info)
+
+		//finding the layer where the current version of each package was installed
+		//(tracking the package database versions in the lower layers)
+		installedIn := map[string]int{}
+		versions := map[string]string{}
+		for idx := 0; idx <= dbLayer; idx++ {
+			layerDB, found := pkg.Layers[idx].PackageDBs[path]
+			if !found {
+				continue
+			}
+
+			current := map[string]string{}
+			for _, p := range layerDB.Packages {
+				key := packageKey(p)
+				current[key] = p.Version
+				if version, found := versions[key]; !found || version != p.Version {
+					installedIn[key] = idx
+				}
+			}
+
+			versions = current
+		}
+
+		for _, p := range db.Packages {
+			inventory.Packages = append(inventory.Packages, &InstalledPackage{
+				OSPackage: *p,
+				Type:      db.Type,
+				Layer:     installedIn[packageKey(p)],
+			})
+
+			inventory.TotalSize += p.Size
+		}
+	}
+
+	inventory.Count = len(inventory.Packages)
+	sort.Slice(inventory.Packages, func(i, j int) bool {
+		if inventory.Packages[i].Name != inventory.Packages[j].Name {
+			return inventory.Packages[i].Name < inventory.Packages[j].Name
+		}
+
+		return inventory.Packages[i].Arch < inventory.Packages[j].Arch
+	})
+
+	return inventory
+}
+
+func packageKey(p *system.OSPackage) string {
+	return p.Name + "/" + p.Arch
+}
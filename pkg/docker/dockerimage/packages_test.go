@@ -0,0 +1,157 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+func newTestLayer(index int, dbs map[string]*PackageDB, deleted ...string) *Layer {
+	layer := &Layer{
+		Index:      index,
+		PackageDBs: map[string]*PackageDB{},
+	}
+
+	for _, name := range deleted {
+		layer.Objects = append(layer.Objects, &ObjectMetadata{
+			Name:       name,
+			Change:     ChangeDelete,
+			LayerIndex: index,
+		})
+	}
+
+	for name, db := range dbs {
+		layer.Objects = append(layer.Objects, &ObjectMetadata{
+			Name:       name,
+			Change:     ChangeModify,
+			TypeFlag:   tar.TypeReg,
+			LayerIndex: index,
+		})
+
+		layer.PackageDBs[name] = db
+	}
+
+	return layer
+}
+
+func TestPackages(t *testing.T) {
+	musl := &system.OSPackage{Name: "musl", Version: "1.2.2-r7", Arch: "x86_64", Size: 100}
+	pkg := &Package{
+		Layers: []*Layer{
+			newTestLayer(0, map[string]*PackageDB{
+				system.ApkInstalledDBFile: {
+					Type: system.OSPackageDBApk,
+					Packages: []*system.OSPackage{
+						musl,
+						{Name: "busybox", Version: "1.34.1-r3", Arch: "x86_64", Size: 200},
+						{Name: "zlib", Version: "1.2.11-r3", Arch: "x86_64", Size: 50},
+					},
+				},
+				system.DpkgStatusDBFile: {
+					Type:     system.OSPackageDBDpkg,
+					Packages: []*system.OSPackage{{Name: "libc6", Version: "2.31-13"}},
+				},
+			}),
+			newTestLayer(1, map[string]*PackageDB{
+				system.ApkInstalledDBFile: {
+					Type: system.OSPackageDBApk,
+					Packages: []*system.OSPackage{
+						musl,
+						{Name: "busybox", Version: "1.34.1-r4", Arch: "x86_64", Size: 210},
+						{Name: "curl", Version: "7.80.0-r0", Arch: "x86_64", Size: 300},
+					},
+				},
+			}),
+			//the removed package database is not in the inventory
+			newTestLayer(2, map[string]*PackageDB{
+				system.RpmSqliteDBFile: {
+					Type:  system.OSPackageDBRpm,
+					Error: errors.New("bad SQLite page size - 0"),
+				},
+			}, system.DpkgStatusDBFile),
+		},
+	}
+
+	inventory := Packages(pkg)
+
+	expectedDBs := []*PackageDBInfo{
+		{Type: system.OSPackageDBApk, Path: system.ApkInstalledDBFile, Layer: 1, Count: 3},
+		{Type: system.OSPackageDBRpm, Path: system.RpmSqliteDBFile, Layer: 2, Error: "bad SQLite page size - 0"},
+	}
+
+	if !reflect.DeepEqual(inventory.DBs, expectedDBs) {
+		t.Errorf("dbs - got %+v expected %+v", inventory.DBs, expectedDBs)
+	}
+
+	//the package layer is where its current version was installed
+	//(the packages removed in the upper layers are not included)
+	expectedPackages := []*InstalledPackage{
+		{
+			OSPackage: system.OSPackage{Name: "busybox", Version: "1.34.1-r4", Arch: "x86_64", Size: 210},
+			Type:      system.OSPackageDBApk,
+			Layer:     1,
+		},
+		{
+			OSPackage: system.OSPackage{Name: "curl", Version: "7.80.0-r0", Arch: "x86_64", Size: 300},
+			Type:      system.OSPackageDBApk,
+			Layer:     1,
+		},
+		{
+			OSPackage: *musl,
+			Type:      system.OSPackageDBApk,
+			Layer:     0,
+		},
+	}
+
+	if !reflect.DeepEqual(inventory.Packages, expectedPackages) {
+		t.Errorf("packages - got %+v expected %+v", inventory.Packages, expectedPackages)
+	}
+
+	if inventory.Count != 3 || inventory.TotalSize != 610 {
+		t.Errorf("count/size - got %d/%d expected 3/610", inventory.Count, inventory.TotalSize)
+	}
+}
+
+func TestPackagesReinstalled(t *testing.T) {
+	v1 := &PackageDB{
+		Type:     system.OSPackageDBDpkg,
+		Packages: []*system.OSPackage{{Name: "curl", Version: "7.74.0-1", Arch: "amd64"}},
+	}
+
+	v2 := &PackageDB{
+		Type:     system.OSPackageDBDpkg,
+		Packages: []*system.OSPackage{{Name: "curl", Version: "7.74.0-2", Arch: "amd64"}},
+	}
+
+	//the package version is tracked only in the layers with the package database changes
+	//(and the downgraded package is installed in the layer with the downgrade)
+	pkg := &Package{
+		Layers: []*Layer{
+			newTestLayer(0, map[string]*PackageDB{system.DpkgStatusDBFile: v1}),
+			newTestLayer(1, nil),
+			newTestLayer(2, map[string]*PackageDB{system.DpkgStatusDBFile: v2}),
+			newTestLayer(3, map[string]*PackageDB{system.DpkgStatusDBFile: v1}),
+		},
+	}
+
+	inventory := Packages(pkg)
+	if len(inventory.Packages) != 1 || inventory.Packages[0].Layer != 3 {
+		t.Errorf("got %+v", inventory.Packages)
+	}
+
+	pkg.Layers = pkg.Layers[:2]
+	inventory = Packages(pkg)
+	if len(inventory.Packages) != 1 || inventory.Packages[0].Layer != 0 {
+		t.Errorf("unchanged - got %+v", inventory.Packages)
+	}
+}
+
+func TestPackagesNoDBs(t *testing.T) {
+	inventory := Packages(&Package{Layers: []*Layer{newTestLayer(0, nil)}})
+	if inventory.Count != 0 || len(inventory.DBs) != 0 || len(inventory.Packages) != 0 {
+		t.Errorf("got %+v", inventory)
+	}
+}
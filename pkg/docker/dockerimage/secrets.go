@@ -0,0 +1,140 @@
+package dockerimage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/secretdiscover"
+)
+
+// Secret finding sources
+const (
+	SecretSourceFile    = "file"
+	SecretSourceEnv     = "env"
+	SecretSourceHistory = "history"
+)
+
+// DefaultSecretsMaxFileSize is the default max size for the files scanned for secrets
+const DefaultSecretsMaxFileSize = "1MB"
+
+// SecretsReport contains the secrets found in the image layers, env vars and build history
+type SecretsReport struct {
+	Count    int              `json:"count"`
+	Findings []*SecretFinding `json:"findings,omitempty"`
+}
+
+// SecretFinding is a secret found in the image (the value is redacted)
+// (the removed files are not in the final image filesystem, but they are still in their layers)
+type SecretFinding struct {
+	Source       string `json:"source"`
+	Rule         string `json:"rule"`
+	Description  string `json:"description"`
+	Value        string `json:"value"`
+	Layer        int    `json:"layer"`
+	Path         string `json:"path,omitempty"`
+	Line         int    `json:"line,omitempty"`
+	Name         string `json:"name,omitempty"`
+	HistoryIndex *int   `json:"history_index,omitempty"`
+	Removed      bool   `json:"removed,omitempty"`
+}
+
+// Secrets creates the secrets report from the secrets detected in the layer files,
+// the image env vars and the image build history
+func Secrets(pkg *Package) *SecretsReport {
+	report := &SecretsReport{}
+	imageFiles := ImageFiles(pkg)
+
+	for idx, layer := range pkg.Layers {
+		var paths []string
+		for path := range layer.Secrets {
+			paths = append(paths, path)
+		}
+
+		sort.Strings(paths)
+		for _, path := range paths {
+			object, found := imageFiles[path]
+			removed := !found || object.LayerIndex != idx
+			for _, m := range layer.Secrets[path] {
+				report.Findings = append(report.Findings, &SecretFinding{
+					Source:      SecretSourceFile,
+					Rule:        m.Rule,
+					Description: m.Description,
+					Value:       m.Value,
+					Layer:       idx,
+					Path:        path,
+					Line:        m.Line,
+					Removed:     removed,
+				})
+			}
+		}
+	}
+
+	if pkg.Config != nil {
+		if pkg.Config.Config != nil {
+			for _, envVar := range pkg.Config.Config.Env {
+				parts := strings.SplitN(envVar, "=", 2)
+				if len(parts) != 2 {
+					continue
+				}
+
+				for _, m := range secretdiscover.ScanVariable(parts[0], parts[1]) {
+					report.Findings = append(report.Findings, &SecretFinding{
+						Source:      SecretSourceEnv,
+						Rule:        m.Rule,
+						Description: m.Description,
+						Value:       m.Value,
+						Layer:       -1,
+						Name:        m.Name,
+					})
+				}
+			}
+		}
+
+		for idx, history := range pkg.Config.History {
+			historyIndex := idx
+			for _, m := range secretdiscover.ScanCommand(history.CreatedBy) {
+				report.Findings = append(report.Findings, &SecretFinding{
+					Source:       SecretSourceHistory,
+					Rule:         m.Rule,
+					Description:  m.Description,
+					Value:        m.Value,
+					Layer:        history.LayerIndex,
+					Name:         m.Name,
+					HistoryIndex: &historyIndex,
+				})
+			}
+		}
+	}
+
+	report.Count = len(report.Findings)
+	return report
+}
+
+// RedactConfigSecrets returns a copy of the image config with the secret values redacted
+// in the env vars and the build commands (used for the saved reports)
+func RedactConfigSecrets(config *ConfigObject) *ConfigObject {
+	if config == nil {
+		return nil
+	}
+
+	redacted := *config
+	redacted.ContainerConfig.Env = secretdiscover.RedactEnv(config.ContainerConfig.Env)
+	redacted.ContainerConfig.Cmd = secretdiscover.RedactCommands(config.ContainerConfig.Cmd)
+	redacted.ContainerConfig.OnBuild = secretdiscover.RedactCommands(config.ContainerConfig.OnBuild)
+	if config.Config != nil {
+		containerConfig := *config.Config
+		containerConfig.Env = secretdiscover.RedactEnv(config.Config.Env)
+		containerConfig.OnBuild = secretdiscover.RedactCommands(config.Config.OnBuild)
+		redacted.Config = &containerConfig
+	}
+
+	if config.History != nil {
+		redacted.History = make([]XHistory, len(config.History))
+		for idx, history := range config.History {
+			history.CreatedBy = secretdiscover.RedactCommand(history.CreatedBy)
+			redacted.History[idx] = history
+		}
+	}
+
+	return &redacted
+}
@@ -0,0 +1,63 @@
+package dockerimage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactConfigSecrets(t *testing.T) {
+	config := &ConfigObject{
+		V1ConfigObject: V1ConfigObject{
+			ContainerConfig: ContainerConfig{
+				Env: []string{"API_KEY=abcdefghijkl"},
+				Cmd: []string{"/bin/sh", "-c", "#(nop) ENV API_KEY=abcdefghijkl"},
+			},
+			Config: &ContainerConfig{
+				Env:     []string{"PATH=/usr/bin", "API_KEY=abcdefghijkl"},
+				OnBuild: []string{"RUN TOKEN=abcdefghijkl make"},
+			},
+		},
+		History: []XHistory{
+			{CreatedBy: "/bin/sh -c #(nop) ENV API_KEY=abcdefghijkl"},
+			{CreatedBy: "/bin/sh -c make", EmptyLayer: true},
+		},
+	}
+
+	redacted := RedactConfigSecrets(config)
+
+	if expected := []string{"PATH=/usr/bin", "API_KEY=abcd********"}; !reflect.DeepEqual(redacted.Config.Env, expected) {
+		t.Errorf("config env - got %q expected %q", redacted.Config.Env, expected)
+	}
+
+	if expected := []string{"RUN TOKEN=abcd******** make"}; !reflect.DeepEqual(redacted.Config.OnBuild, expected) {
+		t.Errorf("config onbuild - got %q expected %q", redacted.Config.OnBuild, expected)
+	}
+
+	if expected := []string{"API_KEY=abcd********"}; !reflect.DeepEqual(redacted.ContainerConfig.Env, expected) {
+		t.Errorf("container config env - got %q expected %q", redacted.ContainerConfig.Env, expected)
+	}
+
+	if expected := "#(nop) ENV API_KEY=abcd********"; redacted.ContainerConfig.Cmd[2] != expected {
+		t.Errorf("container config cmd - got %q expected %q", redacted.ContainerConfig.Cmd[2], expected)
+	}
+
+	expectedHistory := []XHistory{
+		{CreatedBy: "/bin/sh -c #(nop) ENV API_KEY=abcd********"},
+		{CreatedBy: "/bin/sh -c make", EmptyLayer: true},
+	}
+
+	if !reflect.DeepEqual(redacted.History, expectedHistory) {
+		t.Errorf("history - got %+v expected %+v", redacted.History, expectedHistory)
+	}
+
+	//the original config is not changed (it's still used by the other image checks)
+	if config.Config.Env[1] != "API_KEY=abcdefghijkl" ||
+		config.ContainerConfig.Env[0] != "API_KEY=abcdefghijkl" ||
+		config.History[0].CreatedBy != "/bin/sh -c #(nop) ENV API_KEY=abcdefghijkl" {
+		t.Errorf("original config changed - %+v", config)
+	}
+
+	if RedactConfigSecrets(nil) != nil {
+		t.Error("nil config - expected nil")
+	}
+}
@@ -0,0 +1,368 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Size breakdown sort orders
+const (
+	SizeSortBySize  = "size"
+	SizeSortByCount = "count"
+	SizeSortByPath  = "path"
+)
+
+// DefaultTopSizesMax is the default number of files and directories in the size breakdown lists
+const DefaultTopSizesMax = 20
+
+// DefaultSizeTreeDepth is the default directory depth for the size tree view
+const DefaultSizeTreeDepth = 3
+
+// IsSizeSortOrder returns true if the value is a supported size breakdown sort order
+func IsSizeSortOrder(value string) bool {
+	switch value {
+	case SizeSortBySize, SizeSortByCount, SizeSortByPath:
+		return true
+	}
+
+	return false
+}
+
+// SizeReport is the largest files and directories breakdown for the image and for each layer
+type SizeReport struct {
+	SortBy string                `json:"sort_by"`
+	Image  *SizeBreakdown        `json:"image"`
+	Layers []*LayerSizeBreakdown `json:"layers"`
+}
+
+// LayerSizeBreakdown is the size breakdown for the data added or modified in a layer
+type LayerSizeBreakdown struct {
+	Index int    `json:"index"`
+	ID    string `json:"id"`
+	SizeBreakdown
+}
+
+// SizeBreakdown includes the largest files and directories
+type SizeBreakdown struct {
+	TotalSize uint64          `json:"total_size"`
+	FileCount uint64          `json:"file_count"`
+	Files     []*FileSizeInfo `json:"files,omitempty"`
+	Dirs      []*DirSizeInfo  `json:"dirs,omitempty"`
+}
+
+// FileSizeInfo is the size of a file (and the layer where it's stored)
+type FileSizeInfo struct {
+	Path  string `json:"path"`
+	Size  uint64 `json:"size"`
+	Layer int    `json:"layer"`
+}
+
+// DirSizeInfo is the total size of the files in a directory (including its subdirectories)
+type DirSizeInfo struct {
+	Path      string `json:"path"`
+	Size      uint64 `json:"size"`
+	FileCount uint64 `json:"file_count"`
+}
+
+// SizeTreeNode is a directory (or file) in the size tree
+type SizeTreeNode struct {
+	Name      string
+	Path      string
+	Size      uint64
+	FileCount uint64
+	IsDir     bool
+	Children  map[string]*SizeTreeNode
+}
+
+// ImageFiles returns the regular files visible in the final image filesystem
+// (the files deleted or overwritten in the upper layers are excluded)
+func ImageFiles(pkg *Package) map[string]*ObjectMetadata {
+	return visibleObjects(pkg, isSizedObject)
+}
+
+// visibleObjects returns the selected objects visible in the final image filesystem
+func visibleObjects(pkg *Package, include func(object *ObjectMetadata) bool) map[string]*ObjectMetadata {
+	return visibleObjectsAt(pkg, len(pkg.Layers)-1, include)
+}
+
+// visibleObjectsAt returns the selected objects visible in the merged filesystem at the layer index
+func visibleObjectsAt(pkg *Package, index int, include func(object *ObjectMetadata) bool) map[string]*ObjectMetadata {
+	visible := map[string]*ObjectMetadata{}
+	deleteVisible := func(prefix string) {
+		for path := range visible {
+			if strings.HasPrefix(path, prefix) {
+				delete(visible, path)
+			}
+		}
+	}
+
+	for idx, layer := range pkg.Layers {
+		if idx > index {
+			break
+		}
+
+		if layer.MetadataChangesOnly {
+			continue
+		}
+
+		for _, object := range layer.Objects {
+			if object.Change != ChangeDelete {
+				continue
+			}
+
+			if object.DirContentDelete {
+				deleteVisible(strings.TrimSuffix(object.Name, "*"))
+				continue
+			}
+
+			delete(visible, object.Name)
+			deleteVisible(object.Name + "/")
+		}
+
+		for _, object := range layer.Objects {
+			if include(object) {
+				visible[object.Name] = object
+			}
+		}
+	}
+
+	return visible
+}
+
+func isSizedObject(object *ObjectMetadata) bool {
+	return object.Change != ChangeDelete &&
+		(object.TypeFlag == tar.TypeReg || object.TypeFlag == tar.TypeRegA)
+}
+
+// Sizes creates the largest files and directories breakdown for the image and its layers
+func Sizes(pkg *Package, topMax int, sortBy string) *SizeReport {
+	if !IsSizeSortOrder(sortBy) {
+		sortBy = SizeSortBySize
+	}
+
+	sr := &SizeReport{
+		SortBy: sortBy,
+	}
+
+	var imageFiles []*ObjectMetadata
+	for _, object := range ImageFiles(pkg) {
+		imageFiles = append(imageFiles, object)
+	}
+
+	sr.Image = newSizeBreakdown(imageFiles, topMax, sortBy)
+
+	for _, layer := range pkg.Layers {
+		var layerFiles []*ObjectMetadata
+		if !layer.MetadataChangesOnly {
+			for _, object := range layer.Objects {
+				if isSizedObject(object) {
+					layerFiles = append(layerFiles, object)
+				}
+			}
+		}
+
+		sr.Layers = append(sr.Layers, &LayerSizeBreakdown{
+			Index:         layer.Index,
+			ID:            layer.ID,
+			SizeBreakdown: *newSizeBreakdown(layerFiles, topMax, sortBy),
+		})
+	}
+
+	return sr
+}
+
+func newSizeBreakdown(objects []*ObjectMetadata, topMax int, sortBy string) *SizeBreakdown {
+	sb := &SizeBreakdown{}
+	dirs := map[string]*DirSizeInfo{}
+	for _, object := range objects {
+		size := uint64(object.Size)
+		sb.TotalSize += size
+		sb.FileCount++
+
+		sb.Files = append(sb.Files, &FileSizeInfo{
+			Path:  object.Name,
+			Size:  size,
+			Layer: object.LayerIndex,
+		})
+
+		for _, dir := range parentDirs(object.Name) {
+			info, found := dirs[dir]
+			if !found {
+				info = &DirSizeInfo{Path: dir}
+				dirs[dir] = info
+			}
+
+			info.Size += size
+			info.FileCount++
+		}
+	}
+
+	for _, info := range dirs {
+		sb.Dirs = append(sb.Dirs, info)
+	}
+
+	sort.Slice(sb.Files, func(i, j int) bool {
+		return lessBySize(sortBy,
+			sb.Files[i].Path, sb.Files[i].Size, 1,
+			sb.Files[j].Path, sb.Files[j].Size, 1)
+	})
+
+	sort.Slice(sb.Dirs, func(i, j int) bool {
+		return lessBySize(sortBy,
+			sb.Dirs[i].Path, sb.Dirs[i].Size, sb.Dirs[i].FileCount,
+			sb.Dirs[j].Path, sb.Dirs[j].Size, sb.Dirs[j].FileCount)
+	})
+
+	if topMax > -1 {
+		if len(sb.Files) > topMax {
+			sb.Files = sb.Files[:topMax]
+		}
+
+		if len(sb.Dirs) > topMax {
+			sb.Dirs = sb.Dirs[:topMax]
+		}
+	}
+
+	return sb
+}
+
+// parentDirs returns the parent directories of a path (excluding the root directory)
+func parentDirs(path string) []string {
+	var dirs []string
+	for idx := strings.LastIndex(path, "/"); idx > 0; idx = strings.LastIndex(path[:idx], "/") {
+		dirs = append(dirs, path[:idx])
+	}
+
+	return dirs
+}
+
+func lessBySize(sortBy string,
+	pathI string, sizeI uint64, countI uint64,
+	pathJ string, sizeJ uint64, countJ uint64) bool {
+	switch sortBy {
+	case SizeSortByPath:
+		return pathI < pathJ
+	case SizeSortByCount:
+		if countI != countJ {
+			return countI > countJ
+		}
+	}
+
+	if sizeI != sizeJ {
+		return sizeI > sizeJ
+	}
+
+	return pathI < pathJ
+}
+
+// SizeTree creates the directory size tree for the final image filesystem
+func SizeTree(pkg *Package) *SizeTreeNode {
+	root := &SizeTreeNode{
+		Name:     "/",
+		Path:     "/",
+		IsDir:    true,
+		Children: map[string]*SizeTreeNode{},
+	}
+
+	for path, object := range ImageFiles(pkg) {
+		size := uint64(object.Size)
+		root.Size += size
+		root.FileCount++
+
+		node := root
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		for idx, name := range parts {
+			child, found := node.Children[name]
+			if !found {
+				child = &SizeTreeNode{
+					Name:  name,
+					Path:  node.Path + name,
+					IsDir: idx < len(parts)-1,
+				}
+
+				if child.IsDir {
+					child.Path += "/"
+					child.Children = map[string]*SizeTreeNode{}
+				}
+
+				node.Children[name] = child
+			}
+
+			child.Size += size
+			child.FileCount++
+			node = child
+		}
+	}
+
+	return root
+}
+
+// Text renders the size tree up to the selected depth
+// (showing up to childrenMax largest children for each directory)
+func (ref *SizeTreeNode) Text(depth int, childrenMax int, sortBy string) string {
+	var builder strings.Builder
+	builder.WriteString(ref.label())
+	builder.WriteString("\n")
+	ref.writeChildren(&builder, "", depth, childrenMax, sortBy)
+	return builder.String()
+}
+
+func (ref *SizeTreeNode) label() string {
+	if ref.IsDir {
+		return fmt.Sprintf("%s %s (%d files)", ref.Name, humanize.Bytes(ref.Size), ref.FileCount)
+	}
+
+	return fmt.Sprintf("%s %s", ref.Name, humanize.Bytes(ref.Size))
+}
+
+func (ref *SizeTreeNode) writeChildren(builder *strings.Builder, indent string, depth int, childrenMax int, sortBy string) {
+	if depth == 0 || len(ref.Children) == 0 {
+		return
+	}
+
+	children := make([]*SizeTreeNode, 0, len(ref.Children))
+	for _, child := range ref.Children {
+		children = append(children, child)
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return lessBySize(sortBy,
+			children[i].Name, children[i].Size, children[i].FileCount,
+			children[j].Name, children[j].Size, children[j].FileCount)
+	})
+
+	var other []*SizeTreeNode
+	if childrenMax > -1 && len(children) > childrenMax {
+		other = children[childrenMax:]
+		children = children[:childrenMax]
+	}
+
+	for idx, child := range children {
+		branch, nextIndent := "├── ", "│   "
+		if idx == len(children)-1 && len(other) == 0 {
+			branch, nextIndent = "└── ", "    "
+		}
+
+		builder.WriteString(indent)
+		builder.WriteString(branch)
+		builder.WriteString(child.label())
+		builder.WriteString("\n")
+		child.writeChildren(builder, indent+nextIndent, depth-1, childrenMax, sortBy)
+	}
+
+	if len(other) > 0 {
+		var otherSize uint64
+		for _, child := range other {
+			otherSize += child.Size
+		}
+
+		builder.WriteString(indent)
+		builder.WriteString("└── ")
+		builder.WriteString(fmt.Sprintf("... %d more %s", len(other), humanize.Bytes(otherSize)))
+		builder.WriteString("\n")
+	}
+}
@@ -0,0 +1,265 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"sort"
+	"strings"
+)
+
+// Wasted space reasons
+const (
+	WastedOverwritten = "overwritten"
+	WastedDeleted     = "deleted"
+	WastedCache       = "cache"
+	WastedDuplicate   = "duplicate"
+)
+
+// DefaultWastedFilesMax is the default number of wasted space files to report
+const DefaultWastedFilesMax = 20
+
+// CacheDirPrefixes are the well known package manager, build tool and temp directories
+// that usually don't need to be in the final image
+var CacheDirPrefixes = []string{
+	"/var/cache/apt/",
+	"/var/lib/apt/lists/",
+	"/var/cache/apk/",
+	"/var/cache/yum/",
+	"/var/cache/dnf/",
+	"/var/cache/zypp/",
+	"/root/.cache/",
+	"/root/.npm/",
+	"/root/.m2/repository/",
+	"/root/.gradle/caches/",
+	"/root/.cargo/registry/",
+	"/root/go/pkg/mod/cache/",
+	"/usr/local/share/.cache/",
+	"/tmp/",
+	"/var/tmp/",
+}
+
+// WastedSpaceReport describes the image data that's not visible in the final image filesystem
+// or that's not needed there (the bytes that can be reclaimed)
+type WastedSpaceReport struct {
+	TotalSize       uint64              `json:"total_size"`
+	WastedSize      uint64              `json:"wasted_size"`
+	Efficiency      float64             `json:"efficiency"`
+	OverwrittenSize uint64              `json:"overwritten_size"`
+	DeletedSize     uint64              `json:"deleted_size"`
+	CacheSize       uint64              `json:"cache_size"`
+	DuplicateSize   uint64              `json:"duplicate_size"`
+	WhiteoutCount   uint64              `json:"whiteout_count"`
+	Layers          []*LayerWastedSpace `json:"layers"`
+	Files           []*WastedFileInfo   `json:"files,omitempty"`
+}
+
+// LayerWastedSpace is the reclaimable data in a layer
+// (the data is attributed to the layer where it's stored)
+type LayerWastedSpace struct {
+	Index           int                 `json:"index"`
+	ID              string              `json:"id"`
+	WastedSize      uint64              `json:"wasted_size"`
+	OverwrittenSize uint64              `json:"overwritten_size,omitempty"`
+	DeletedSize     uint64              `json:"deleted_size,omitempty"`
+	CacheSize       uint64              `json:"cache_size,omitempty"`
+	DuplicateSize   uint64              `json:"duplicate_size,omitempty"`
+	Instruction     *InstructionSummary `json:"instruction,omitempty"`
+}
+
+// WastedFileInfo is the reclaimable data for a file path
+type WastedFileInfo struct {
+	Path       string `json:"path"`
+	Reason     string `json:"reason"`
+	Count      int    `json:"count"`
+	WastedSize uint64 `json:"wasted_size"`
+	Layers     []int  `json:"layers"`
+}
+
+type fileInstance struct {
+	layer  int
+	size   uint64
+	object *ObjectMetadata
+}
+
+type wastedSpaceCollector struct {
+	report *WastedSpaceReport
+	files  map[string]*WastedFileInfo
+}
+
+func (c *wastedSpaceCollector) add(reason string, path string, instance *fileInstance) {
+	if instance.size == 0 {
+		return
+	}
+
+	layer := c.report.Layers[instance.layer]
+	layer.WastedSize += instance.size
+	c.report.WastedSize += instance.size
+
+	switch reason {
+	case WastedOverwritten:
+		layer.OverwrittenSize += instance.size
+		c.report.OverwrittenSize += instance.size
+	case WastedDeleted:
+		layer.DeletedSize += instance.size
+		c.report.DeletedSize += instance.size
+	case WastedCache:
+		layer.CacheSize += instance.size
+		c.report.CacheSize += instance.size
+	case WastedDuplicate:
+		layer.DuplicateSize += instance.size
+		c.report.DuplicateSize += instance.size
+	}
+
+	key := reason + ":" + path
+	info, found := c.files[key]
+	if !found {
+		info = &WastedFileInfo{
+			Path:   path,
+			Reason: reason,
+		}
+
+		c.files[key] = info
+	}
+
+	info.Count++
+	info.WastedSize += instance.size
+	info.Layers = append(info.Layers, instance.layer)
+}
+
+// IsCacheDirPath returns true if the path is in one of the well known cache directories
+func IsCacheDirPath(path string) bool {
+	for _, prefix := range CacheDirPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return strings.Contains(path, "/.cache/")
+}
+
+// WastedSpace finds the files overwritten or deleted in the upper layers,
+// the files in the cache directories and the duplicate files (if the file hashes are available)
+func WastedSpace(pkg *Package, filesMax int) *WastedSpaceReport {
+	c := &wastedSpaceCollector{
+		report: &WastedSpaceReport{},
+		files:  map[string]*WastedFileInfo{},
+	}
+
+	visible := map[string]*fileInstance{}
+	deleteVisible := func(prefix string) {
+		for path, instance := range visible {
+			if strings.HasPrefix(path, prefix) {
+				c.add(WastedDeleted, path, instance)
+				delete(visible, path)
+			}
+		}
+	}
+
+	for _, layer := range pkg.Layers {
+		c.report.Layers = append(c.report.Layers, &LayerWastedSpace{
+			Index: layer.Index,
+			ID:    layer.ID,
+		})
+
+		if layer.MetadataChangesOnly {
+			//the layer data is stored in another layer
+			continue
+		}
+
+		//the whiteouts apply to the lower layers, so they are processed first
+		for _, object := range layer.Objects {
+			if object.Change != ChangeDelete {
+				continue
+			}
+
+			c.report.WhiteoutCount++
+			if object.DirContentDelete {
+				deleteVisible(strings.TrimSuffix(object.Name, "*"))
+				continue
+			}
+
+			if instance, found := visible[object.Name]; found {
+				c.add(WastedDeleted, object.Name, instance)
+				delete(visible, object.Name)
+			}
+
+			deleteVisible(object.Name + "/")
+		}
+
+		for _, object := range layer.Objects {
+			if object.Change == ChangeDelete ||
+				(object.TypeFlag != tar.TypeReg && object.TypeFlag != tar.TypeRegA) {
+				continue
+			}
+
+			c.report.TotalSize += uint64(object.Size)
+			instance := &fileInstance{
+				layer:  len(c.report.Layers) - 1,
+				size:   uint64(object.Size),
+				object: object,
+			}
+
+			if prev, found := visible[object.Name]; found {
+				c.add(WastedOverwritten, object.Name, prev)
+			}
+
+			visible[object.Name] = instance
+		}
+	}
+
+	paths := make([]string, 0, len(visible))
+	for path := range visible {
+		paths = append(paths, path)
+	}
+
+	//using a stable order, so the first copy of the duplicate files is the same every time
+	sort.Slice(paths, func(i, j int) bool {
+		ii := visible[paths[i]]
+		ij := visible[paths[j]]
+		if ii.layer != ij.layer {
+			return ii.layer < ij.layer
+		}
+
+		return paths[i] < paths[j]
+	})
+
+	hashes := map[string]struct{}{}
+	for _, path := range paths {
+		instance := visible[path]
+		if IsCacheDirPath(path) {
+			c.add(WastedCache, path, instance)
+			continue
+		}
+
+		if hash := instance.object.Hash; hash != "" && instance.size > 0 {
+			if _, found := hashes[hash]; found {
+				c.add(WastedDuplicate, path, instance)
+			} else {
+				hashes[hash] = struct{}{}
+			}
+		}
+	}
+
+	if c.report.TotalSize > 0 {
+		c.report.Efficiency = float64(c.report.TotalSize-c.report.WastedSize) / float64(c.report.TotalSize)
+	} else {
+		c.report.Efficiency = 1
+	}
+
+	for _, info := range c.files {
+		c.report.Files = append(c.report.Files, info)
+	}
+
+	sort.Slice(c.report.Files, func(i, j int) bool {
+		if c.report.Files[i].WastedSize != c.report.Files[j].WastedSize {
+			return c.report.Files[i].WastedSize > c.report.Files[j].WastedSize
+		}
+
+		return c.report.Files[i].Path < c.report.Files[j].Path
+	})
+
+	if filesMax > -1 && len(c.report.Files) > filesMax {
+		c.report.Files = c.report.Files[:filesMax]
+	}
+
+	return c.report
+}
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -251,56 +253,22 @@ func SaveImage(dclient *dockerapi.Client, imageRef, local string, extract, remov
 		}
 	}
 
-	dfile, err := os.Create(local)
-	if err != nil {
-		return err
-	}
-
-	options := dockerapi.ExportImageOptions{
-		Name:              imageRef,
-		OutputStream:      dfile,
-		InactivityTimeout: 20 * time.Second,
-	}
-
-	err = dclient.ExportImage(options)
-	if err != nil {
-		log.Errorf("dockerutil.SaveImage: dclient.ExportImage() error = %v", err)
-		dfile.Close()
-		return err
-	}
-
-	dfile.Close()
-
-	if extract {
-		dstDir := filepath.Dir(local)
-		arc := archive.NewDefaultArchiver()
-
-		afile, err := os.Open(local)
-		if err != nil {
-			log.Errorf("dockerutil.SaveImage: os.Open error - %v", err)
-			return err
+	export := func(output io.Writer) error {
+		options := dockerapi.ExportImageOptions{
+			Name:              imageRef,
+			OutputStream:      output,
+			InactivityTimeout: 20 * time.Second,
 		}
 
-		tarOptions := &archive.TarOptions{
-			NoLchown: true,
-			UIDMaps:  arc.IDMapping.UIDs(),
-			GIDMaps:  arc.IDMapping.GIDs(),
-		}
-		err = arc.Untar(afile, dstDir, tarOptions)
+		err := dclient.ExportImage(options)
 		if err != nil {
-			log.Errorf("dockerutil.SaveImage: error unpacking tar - %v", err)
-			afile.Close()
-			return err
+			log.Errorf("dockerutil.SaveImage: dclient.ExportImage() error = %v", err)
 		}
 
-		afile.Close()
-
-		if removeOrig {
-			os.Remove(local)
-		}
+		return err
 	}
 
-	return nil
+	return saveArchive(local, extract, removeOrig, export)
 }
 
 func HasVolume(dclient *dockerapi.Client, name string) error {
@@ -559,59 +527,118 @@ func CopyFromContainer(dclient *dockerapi.Client, containerID, remote, local str
 		}
 	}
 
-	dfile, err := os.Create(local)
-	if err != nil {
+	download := func(output io.Writer) error {
+		downloadOptions := dockerapi.DownloadFromContainerOptions{
+			Path:              remote,
+			OutputStream:      output,
+			InactivityTimeout: 20 * time.Second,
+		}
+
+		err := dclient.DownloadFromContainer(containerID, downloadOptions)
+		if err != nil {
+			log.Errorf("dockerutil.CopyFromContainer: dclient.DownloadFromContainer() error = %v", err)
+		}
+
 		return err
 	}
 
-	downloadOptions := dockerapi.DownloadFromContainerOptions{
-		Path:              remote,
-		OutputStream:      dfile,
-		InactivityTimeout: 20 * time.Second,
+	return saveArchive(local, extract, removeOrig, download)
+}
+
+// saveArchive saves the tar stream produced by 'produce' in the 'local' file
+// and/or extracts it in the directory of the 'local' file.
+// If the archive file doesn't need to be kept the stream is extracted
+// as it's received without buffering the whole archive on disk first.
+func saveArchive(local string, extract, removeOrig bool, produce func(output io.Writer) error) error {
+	dstDir := filepath.Dir(local)
+	if extract && removeOrig {
+		reader, writer := io.Pipe()
+		produceErrCh := make(chan error, 1)
+		go func() {
+			err := produce(writer)
+			writer.CloseWithError(err)
+			produceErrCh <- err
+		}()
+
+		err := untarStream(reader, dstDir)
+		if err == nil {
+			//drain the tar padding the extraction didn't consume
+			_, err = io.Copy(ioutil.Discard, reader)
+		}
+
+		//unblock the producer if the extraction stopped early
+		reader.CloseWithError(err)
+		if produceErr := <-produceErrCh; err == nil {
+			err = produceErr
+		}
+
+		return err
 	}
 
-	err = dclient.DownloadFromContainer(containerID, downloadOptions)
+	dfile, err := os.Create(local)
 	if err != nil {
-		log.Errorf("dockerutil.CopyFromContainer: dclient.DownloadFromContainer() error = %v", err)
-		dfile.Close()
 		return err
 	}
 
+	err = produce(dfile)
 	dfile.Close()
+	if err != nil {
+		return err
+	}
 
 	if extract {
-		dstDir := filepath.Dir(local)
-		arc := archive.NewDefaultArchiver()
-
 		afile, err := os.Open(local)
 		if err != nil {
-			log.Errorf("dockerutil.CopyFromContainer: os.Open error - %v", err)
+			log.Errorf("dockerutil.saveArchive: os.Open error - %v", err)
 			return err
 		}
 
-		tarOptions := &archive.TarOptions{
-			NoLchown: true,
-			UIDMaps:  arc.IDMapping.UIDs(),
-			GIDMaps:  arc.IDMapping.GIDs(),
-		}
-		err = arc.Untar(afile, dstDir, tarOptions)
+		err = untarStream(afile, dstDir)
+		afile.Close()
 		if err != nil {
-			log.Errorf("dockerutil.CopyFromContainer: error unpacking tar - %v", err)
-			afile.Close()
 			return err
 		}
+	}
 
-		afile.Close()
+	return nil
+}
 
-		if removeOrig {
-			os.Remove(local)
-		}
+// UnpackArchive extracts the tar archive (e.g., a saved image archive) in the target directory
+func UnpackArchive(archivePath, dstDir string) error {
+	afile, err := os.Open(archivePath)
+	if err != nil {
+		log.Errorf("dockerutil.UnpackArchive: os.Open error - %v", err)
+		return err
+	}
+
+	defer afile.Close()
+
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+
+	return untarStream(afile, dstDir)
+}
+
+func untarStream(input io.Reader, dstDir string) error {
+	arc := archive.NewDefaultArchiver()
+	tarOptions := &archive.TarOptions{
+		NoLchown: true,
+		UIDMaps:  arc.IDMapping.UIDs(),
+		GIDMaps:  arc.IDMapping.GIDs(),
+	}
+
+	if err := arc.Untar(input, dstDir, tarOptions); err != nil {
+		log.Errorf("dockerutil.untarStream: error unpacking tar - %v", err)
+		return err
 	}
 
 	return nil
 }
 
-func PrepareContainerDataArchive(fullPath, newName, removePrefix string, removeOrig bool) error {
+// PrepareContainerDataArchive creates a new archive from the container data archive
+// removing the path prefix and adding the extended attributes (keyed by the object path)
+func PrepareContainerDataArchive(fullPath, newName, removePrefix string, removeOrig bool, xattrs map[string]map[string][]byte) error {
 	if fullPath == "" || newName == "" || removePrefix == "" {
 		return ErrBadParam
 	}
@@ -660,6 +687,23 @@ func PrepareContainerDataArchive(fullPath, newName, removePrefix string, removeO
 			hdr.Name = strings.TrimPrefix(hdr.Name, removePrefix)
 		}
 
+		if hdr.Typeflag == tar.TypeLink && strings.HasPrefix(hdr.Linkname, removePrefix) {
+			//hardlink targets are archive paths too
+			hdr.Linkname = strings.TrimPrefix(hdr.Linkname, removePrefix)
+		}
+
+		if attrs, found := xattrs["/"+strings.TrimPrefix(filepath.Clean(hdr.Name), "/")]; found {
+			if hdr.PAXRecords == nil {
+				hdr.PAXRecords = map[string]string{}
+			}
+
+			for name, value := range attrs {
+				hdr.PAXRecords["SCHILY.xattr."+name] = string(value)
+			}
+
+			hdr.Format = tar.FormatPAX
+		}
+
 		if err := tw.WriteHeader(hdr); err != nil {
 			log.Errorf("dockerutil.PrepareContainerDataArchive: error writing header to archive(%v) - %v", dstPath, err)
 			inFile.Close()
@@ -667,7 +711,7 @@ func PrepareContainerDataArchive(fullPath, newName, removePrefix string, removeO
 			return err
 		}
 
-		if _, err := io.Copy(tw, tr); err != nil {
+		if _, err := fsutil.CopyStream(tw, tr); err != nil {
 			log.Errorf("dockerutil.PrepareContainerDataArchive: error copying data to archive(%v) - %v", dstPath, err)
 			inFile.Close()
 			outFile.Close()
@@ -689,6 +733,107 @@ func PrepareContainerDataArchive(fullPath, newName, removePrefix string, removeO
 	return nil
 }
 
+// NormalizeDataArchive rewrites the data archive with the objects sorted by name
+// and with the same modification time for all objects (the hard links are added after the other objects)
+func NormalizeDataArchive(fullPath string, modTime time.Time) error {
+	if fullPath == "" {
+		return ErrBadParam
+	}
+
+	inFile, err := os.Open(fullPath)
+	if err != nil {
+		return err
+	}
+
+	defer inFile.Close()
+
+	type archiveObject struct {
+		hdr    *tar.Header
+		offset int64
+	}
+
+	//the tar reader doesn't read ahead, so the object data starts at the current file offset
+	var objects []archiveObject
+	tr := tar.NewReader(inFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			log.Errorf("dockerutil.NormalizeDataArchive: error reading archive(%v) - %v", fullPath, err)
+			return err
+		}
+
+		offset, err := inFile.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, archiveObject{hdr: hdr, offset: offset})
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		iLink := objects[i].hdr.Typeflag == tar.TypeLink
+		jLink := objects[j].hdr.Typeflag == tar.TypeLink
+		if iLink != jLink {
+			return jLink
+		}
+
+		return objects[i].hdr.Name < objects[j].hdr.Name
+	})
+
+	tmpPath := fullPath + ".tmp"
+	outFile, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(outFile)
+	for _, obj := range objects {
+		hdr := obj.hdr
+		hdr.ModTime = modTime
+		hdr.AccessTime = time.Time{}
+		hdr.ChangeTime = time.Time{}
+		hdr.Format = tar.FormatPAX
+		//the PAX records for the header fields are recreated from the header (only the extended attributes are kept)
+		for key := range hdr.PAXRecords {
+			if !strings.HasPrefix(key, "SCHILY.xattr.") {
+				delete(hdr.PAXRecords, key)
+			}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			log.Errorf("dockerutil.NormalizeDataArchive: error writing header to archive(%v) - %v", tmpPath, err)
+			outFile.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+
+		if hdr.Size > 0 && (hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA) {
+			if _, err := fsutil.CopyStream(tw, io.NewSectionReader(inFile, obj.offset, hdr.Size)); err != nil {
+				log.Errorf("dockerutil.NormalizeDataArchive: error copying data to archive(%v) - %v", tmpPath, err)
+				outFile.Close()
+				os.Remove(tmpPath)
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		outFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := outFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, fullPath)
+}
+
 func ListNetworks(dclient *dockerapi.Client, nameFilter string) ([]string, error) {
 	var err error
 	if dclient == nil {
@@ -0,0 +1,365 @@
+package imageformat
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	archiveManifestName  = "manifest.json"
+	archiveLayerFileName = "layer.tar"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ReadDockerArchiveDir reads the image from the unpacked Docker image archive
+// (the image name is required if the archive has more than one image)
+func ReadDockerArchiveDir(dir, name string) (*Image, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, archiveManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []dockerimage.ManifestObject
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, err
+	}
+
+	manifest, err := selectArchiveManifest(manifests, name)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{
+		Tags: manifest.RepoTags,
+	}
+
+	img.Config, err = ioutil.ReadFile(filepath.Join(dir, manifest.Config))
+	if err != nil {
+		return nil, err
+	}
+
+	diffIDs, err := configDiffIDs(img.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(diffIDs) != len(manifest.Layers) {
+		return nil, fmt.Errorf("%w: %d layers and %d layer diff IDs", ErrBadImage, len(manifest.Layers), len(diffIDs))
+	}
+
+	for idx, layerPath := range manifest.Layers {
+		layer := &Layer{
+			Path:   filepath.Join(dir, layerPath),
+			DiffID: diffIDs[idx],
+		}
+
+		layerInfo, err := os.Stat(layer.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		layer.Size = layerInfo.Size()
+		if layer.Compression, err = detectCompression(layer.Path); err != nil {
+			return nil, err
+		}
+
+		if layer.Compression == CompressionNone {
+			layer.Digest = layer.DiffID
+		}
+
+		img.Layers = append(img.Layers, layer)
+	}
+
+	return img, nil
+}
+
+func selectArchiveManifest(manifests []dockerimage.ManifestObject, name string) (*dockerimage.ManifestObject, error) {
+	if name == "" {
+		switch len(manifests) {
+		case 0:
+			return nil, ErrImageNotFound
+		case 1:
+			return &manifests[0], nil
+		default:
+			return nil, fmt.Errorf("%w: the archive has %d images", ErrMissingImageName, len(manifests))
+		}
+	}
+
+	tagName := TagName(name)
+	for idx := range manifests {
+		for _, tag := range manifests[idx].RepoTags {
+			if tag == tagName {
+				return &manifests[idx], nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrImageNotFound, name)
+}
+
+// WriteDockerArchiveDir saves the image in the Docker image archive format (unpacked) in the directory
+func WriteDockerArchiveDir(img *Image, tags []string, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	manifest := dockerimage.ManifestObject{
+		Config:   fmt.Sprintf("%s.json", digest.FromBytes(img.Config).Encoded()),
+		RepoTags: tags,
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, manifest.Config), img.Config, 0644); err != nil {
+		return err
+	}
+
+	written := map[digest.Digest]bool{}
+	for _, layer := range img.Layers {
+		layerPath := filepath.Join(layer.DiffID.Encoded(), archiveLayerFileName)
+		manifest.Layers = append(manifest.Layers, layerPath)
+
+		//the same layer can be used more than once
+		if written[layer.DiffID] {
+			continue
+		}
+
+		if err := writeArchiveLayer(layer, filepath.Join(dir, layerPath)); err != nil {
+			return err
+		}
+
+		written[layer.DiffID] = true
+	}
+
+	data, err := json.Marshal([]dockerimage.ManifestObject{manifest})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, archiveManifestName), data, 0644)
+}
+
+// writeArchiveLayer saves the uncompressed layer tar (checking its diff ID)
+func writeArchiveLayer(layer *Layer, layerPath string) error {
+	if err := os.MkdirAll(filepath.Dir(layerPath), 0755); err != nil {
+		return err
+	}
+
+	if layer.Compression == CompressionNone {
+		if err := os.Link(layer.Path, layerPath); err == nil {
+			return nil
+		}
+
+		return fsutil.CopyRegularFile(false, layer.Path, layerPath, false)
+	}
+
+	reader, err := openLayer(layer)
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	output, err := os.Create(layerPath)
+	if err != nil {
+		return err
+	}
+
+	hasher := sha256.New()
+	_, err = fsutil.CopyStream(io.MultiWriter(output, hasher), reader)
+	if cerr := output.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	diffID := digest.NewDigestFromEncoded(digest.SHA256, hex.EncodeToString(hasher.Sum(nil)))
+	if diffID != layer.DiffID {
+		return fmt.Errorf("%w: layer diff ID mismatch (%s != %s)", ErrBadImage, diffID, layer.DiffID)
+	}
+
+	return nil
+}
+
+// openLayer returns the uncompressed layer data reader
+func openLayer(layer *Layer) (io.ReadCloser, error) {
+	file, err := os.Open(layer.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch layer.Compression {
+	case CompressionGzip:
+		gr, err := gzip.NewReader(bufio.NewReader(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		return &layerReader{Reader: gr, closers: []io.Closer{gr, file}}, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(bufio.NewReader(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		return &layerReader{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), file}}, nil
+	}
+
+	return file, nil
+}
+
+type layerReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *layerReader) Close() error {
+	var err error
+	for _, closer := range r.closers {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// detectCompression checks the layer data header
+// (the image archives usually have uncompressed layers, but it's not always the case)
+func detectCompression(layerPath string) (string, error) {
+	file, err := os.Open(layerPath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	header := make([]byte, len(zstdMagic))
+	if _, err := io.ReadFull(file, header); err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return CompressionGzip, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		return CompressionZstd, nil
+	}
+
+	return CompressionNone, nil
+}
+
+// WriteDockerArchive creates the Docker image archive file from the unpacked image archive directory
+func WriteDockerArchive(dir, archivePath string) error {
+	if dirPath := filepath.Dir(archivePath); dirPath != "" {
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			return err
+		}
+	}
+
+	afile, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+
+	err = WriteDirArchive(dir, afile)
+	if cerr := afile.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// LoadImageDir loads the image archive created from the directory
+func LoadImageDir(client *dockerapi.Client, dir string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(WriteDirArchive(dir, writer))
+	}()
+
+	defer reader.Close()
+
+	var output strings.Builder
+	err := client.LoadImage(dockerapi.LoadImageOptions{
+		InputStream:  reader,
+		OutputStream: &output,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("imageformat.LoadImageDir: %s", output.String())
+	return nil
+}
+
+// WriteDirArchive writes the directory content as a tar stream
+func WriteDirArchive(dir string, writer io.Writer) error {
+	tw := tar.NewWriter(writer)
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, filePath)
+		if err != nil || name == "." {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+
+		_, err = fsutil.CopyStream(tw, file)
+		file.Close()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
@@ -0,0 +1,350 @@
+// Package imageformat converts container images between the Docker image archives,
+// the OCI image layout directories and the images in the Docker daemon.
+package imageformat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+)
+
+// Image transports (used as the image reference prefixes)
+const (
+	TransportDockerArchive = "docker-archive"
+	TransportOCI           = "oci"
+	TransportDaemon        = "docker-daemon"
+)
+
+// Manifest media type sets
+const (
+	MediaTypesOCI    = "oci"
+	MediaTypesDocker = "docker"
+)
+
+// Docker image manifest v2 schema2 media types
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerConfig       = "application/vnd.docker.container.image.v1+json"
+	MediaTypeDockerLayer        = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	MediaTypeDockerForeignLayer = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+)
+
+// Layer compression types
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+const (
+	sourceDirName      = "source"
+	destinationDirName = "destination"
+	savedImageName     = "image.tar"
+	defaultTag         = "latest"
+)
+
+var (
+	ErrBadReference         = errors.New("bad image reference")
+	ErrImageNotFound        = errors.New("image not found")
+	ErrBadImage             = errors.New("bad image data")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrBadMediaTypes        = errors.New("bad manifest media types")
+	ErrMissingImageName     = errors.New("missing image name")
+)
+
+// Reference is a transport specific image reference
+type Reference struct {
+	Transport string
+	//the image archive file or the OCI layout directory (not used for the daemon images)
+	Path string
+	//the image name (the tag in the image archive or the reference name in the OCI layout)
+	Name string
+}
+
+// ParseReference parses the 'docker-archive:<FILE>[:<IMAGE>]', 'oci:<DIR>[:<REF_NAME>]'
+// and 'docker-daemon:<IMAGE>' image references
+// (the references without a transport prefix are the Docker daemon image references)
+func ParseReference(ref string) (*Reference, error) {
+	result := &Reference{Transport: TransportDaemon}
+	value := ref
+	if idx := strings.Index(ref, ":"); idx > 0 {
+		switch prefix := ref[:idx]; prefix {
+		case TransportDockerArchive, TransportOCI, TransportDaemon:
+			result.Transport = prefix
+			value = ref[idx+1:]
+		}
+	}
+
+	if result.Transport == TransportDaemon {
+		result.Name = value
+	} else if idx := strings.Index(value, ":"); idx > -1 {
+		result.Path, result.Name = value[:idx], value[idx+1:]
+	} else {
+		result.Path = value
+	}
+
+	if result.Name == "" && result.Path == "" {
+		return nil, fmt.Errorf("%w: '%s'", ErrBadReference, ref)
+	}
+
+	if result.Transport != TransportDaemon && result.Path == "" {
+		return nil, fmt.Errorf("%w: '%s' (missing path)", ErrBadReference, ref)
+	}
+
+	return result, nil
+}
+
+func (ref *Reference) String() string {
+	switch {
+	case ref.Transport == TransportDaemon:
+		return fmt.Sprintf("%s:%s", ref.Transport, ref.Name)
+	case ref.Name != "":
+		return fmt.Sprintf("%s:%s:%s", ref.Transport, ref.Path, ref.Name)
+	default:
+		return fmt.Sprintf("%s:%s", ref.Transport, ref.Path)
+	}
+}
+
+// IsMediaTypes returns true if the value is a supported manifest media type set
+func IsMediaTypes(val string) bool {
+	switch val {
+	case MediaTypesOCI, MediaTypesDocker:
+		return true
+	}
+
+	return false
+}
+
+// Image is the transport independent image data
+type Image struct {
+	//the original config data (it's not changed, so the image ID stays the same)
+	Config []byte
+	Layers []*Layer
+	//the manifest media type (empty for the images from the image archives)
+	ManifestMediaType string
+	//the manifest annotations
+	Annotations map[string]string
+	//the annotations of the manifest descriptor in the OCI layout index
+	IndexAnnotations map[string]string
+	Tags             []string
+}
+
+// Layer is the image layer data
+type Layer struct {
+	//the file with the layer data
+	Path        string
+	MediaType   string
+	Compression string
+	//the layer blob digest (not set for the compressed layers in the image archives)
+	Digest      digest.Digest
+	Size        int64
+	DiffID      digest.Digest
+	Annotations map[string]string
+}
+
+// ConvertInfo describes the converted image
+type ConvertInfo struct {
+	Source            string            `json:"source"`
+	Destination       string            `json:"destination"`
+	MediaTypes        string            `json:"media_types,omitempty"`
+	ImageID           string            `json:"image_id"`
+	ManifestDigest    string            `json:"manifest_digest,omitempty"`
+	ManifestMediaType string            `json:"manifest_media_type,omitempty"`
+	Tags              []string          `json:"tags,omitempty"`
+	Layers            []string          `json:"layers,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	//the image archives and the daemon images don't have a place for the annotations
+	DroppedAnnotations bool `json:"dropped_annotations,omitempty"`
+}
+
+// Convert converts the source image to the destination image format
+// (the manifest media types are used for the OCI layout destinations)
+func Convert(
+	client *dockerapi.Client,
+	src *Reference,
+	dst *Reference,
+	mediaTypes string,
+	workDir string) (*ConvertInfo, error) {
+	if mediaTypes == "" {
+		mediaTypes = MediaTypesOCI
+	}
+
+	if !IsMediaTypes(mediaTypes) {
+		return nil, fmt.Errorf("%w: '%s'", ErrBadMediaTypes, mediaTypes)
+	}
+
+	if dst.Transport == TransportDaemon && dst.Name == "" {
+		return nil, ErrMissingImageName
+	}
+
+	img, err := ReadImage(client, src, filepath.Join(workDir, sourceDirName))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ConvertInfo{
+		Source:      src.String(),
+		Destination: dst.String(),
+		ImageID:     digest.FromBytes(img.Config).String(),
+		Annotations: img.Annotations,
+	}
+
+	switch dst.Transport {
+	case TransportOCI:
+		refName := dst.Name
+		if refName == "" {
+			refName = img.IndexAnnotations[ocispec.AnnotationRefName]
+		}
+
+		if refName == "" && len(img.Tags) > 0 {
+			refName = img.Tags[0]
+		}
+
+		manifestDesc, layers, err := WriteOCILayout(img, dst.Path, refName, mediaTypes)
+		if err != nil {
+			return nil, err
+		}
+
+		info.MediaTypes = mediaTypes
+		info.ManifestDigest = manifestDesc.Digest.String()
+		info.ManifestMediaType = manifestDesc.MediaType
+		if refName != "" {
+			info.Tags = []string{refName}
+		}
+
+		for _, desc := range layers {
+			info.Layers = append(info.Layers, desc.Digest.String())
+		}
+	default:
+		tags := img.Tags
+		if dst.Name != "" {
+			tags = []string{TagName(dst.Name)}
+		}
+
+		archiveDir := filepath.Join(workDir, destinationDirName)
+		if err := WriteDockerArchiveDir(img, tags, archiveDir); err != nil {
+			return nil, err
+		}
+
+		if dst.Transport == TransportDaemon {
+			err = LoadImageDir(client, archiveDir)
+		} else {
+			err = WriteDockerArchive(archiveDir, dst.Path)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		info.Tags = tags
+		for _, layer := range img.Layers {
+			info.Layers = append(info.Layers, layer.DiffID.String())
+		}
+
+		info.DroppedAnnotations = hasAnnotations(img)
+	}
+
+	return info, nil
+}
+
+// ReadImage reads the image data (the daemon images and the image archives are unpacked in the work directory)
+func ReadImage(client *dockerapi.Client, ref *Reference, workDir string) (*Image, error) {
+	switch ref.Transport {
+	case TransportOCI:
+		return ReadOCILayout(ref.Path, ref.Name)
+	case TransportDockerArchive:
+		if err := dockerutil.UnpackArchive(ref.Path, workDir); err != nil {
+			return nil, err
+		}
+
+		return ReadDockerArchiveDir(workDir, ref.Name)
+	case TransportDaemon:
+		if err := os.MkdirAll(workDir, 0755); err != nil {
+			return nil, err
+		}
+
+		if err := dockerutil.SaveImage(client, ref.Name, filepath.Join(workDir, savedImageName), true, true); err != nil {
+			return nil, err
+		}
+
+		//the saved archive tags don't include the image digest references
+		return ReadDockerArchiveDir(workDir, "")
+	}
+
+	return nil, fmt.Errorf("%w: unknown transport '%s'", ErrBadReference, ref.Transport)
+}
+
+// TagName returns the full image tag name ('latest' is used if the name doesn't have a tag)
+func TagName(name string) string {
+	if strings.Contains(name, "@") {
+		return name
+	}
+
+	if strings.LastIndex(name, ":") > strings.LastIndex(name, "/") {
+		return name
+	}
+
+	return fmt.Sprintf("%s:%s", name, defaultTag)
+}
+
+// hasAnnotations returns true if the image has any annotations except the OCI layout reference name
+func hasAnnotations(img *Image) bool {
+	if len(img.Annotations) > 0 {
+		return true
+	}
+
+	for name := range img.IndexAnnotations {
+		if name != ocispec.AnnotationRefName {
+			return true
+		}
+	}
+
+	for _, layer := range img.Layers {
+		if len(layer.Annotations) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// configDiffIDs returns the uncompressed layer digests from the image config
+func configDiffIDs(config []byte) ([]digest.Digest, error) {
+	var data struct {
+		RootFS struct {
+			DiffIDs []digest.Digest `json:"diff_ids"`
+		} `json:"rootfs"`
+	}
+
+	if err := json.Unmarshal(config, &data); err != nil {
+		return nil, err
+	}
+
+	return data.RootFS.DiffIDs, nil
+}
+
+// configPlatform returns the image platform from the image config
+func configPlatform(config []byte) *ocispec.Platform {
+	var platform ocispec.Platform
+	if err := json.Unmarshal(config, &platform); err != nil {
+		log.Debugf("imageformat.configPlatform: error - %v", err)
+		return nil
+	}
+
+	if platform.OS == "" || platform.Architecture == "" {
+		return nil
+	}
+
+	return &platform
+}
@@ -0,0 +1,454 @@
+package imageformat
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// OCI image layout names
+const (
+	BlobsDirName  = "blobs"
+	IndexFileName = "index.json"
+)
+
+// ReadOCILayout reads the image from the OCI image layout directory
+// (the reference name is required if the layout index has more than one image)
+func ReadOCILayout(dir, refName string) (*Image, error) {
+	var index ocispec.Index
+	if err := readJSONFile(filepath.Join(dir, IndexFileName), &index); err != nil {
+		return nil, err
+	}
+
+	manifestDesc, err := selectIndexManifest(index.Manifests, refName)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{
+		IndexAnnotations: manifestDesc.Annotations,
+	}
+
+	//using the platform specific image manifest if it's a multi-platform image
+	if isIndexMediaType(manifestDesc.MediaType) {
+		var platformIndex ocispec.Index
+		if err := readBlobJSON(dir, manifestDesc, &platformIndex); err != nil {
+			return nil, err
+		}
+
+		if manifestDesc, err = selectPlatformManifest(platformIndex.Manifests); err != nil {
+			return nil, err
+		}
+	}
+
+	switch manifestDesc.MediaType {
+	case ocispec.MediaTypeImageManifest, MediaTypeDockerManifest:
+	default:
+		return nil, fmt.Errorf("%w: manifest - '%s'", ErrUnsupportedMediaType, manifestDesc.MediaType)
+	}
+
+	var manifest ocispec.Manifest
+	if err := readBlobJSON(dir, manifestDesc, &manifest); err != nil {
+		return nil, err
+	}
+
+	img.ManifestMediaType = manifestDesc.MediaType
+	img.Annotations = manifest.Annotations
+	if img.Config, err = readBlob(dir, manifest.Config); err != nil {
+		return nil, err
+	}
+
+	diffIDs, err := configDiffIDs(img.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(diffIDs) != len(manifest.Layers) {
+		return nil, fmt.Errorf("%w: %d layers and %d layer diff IDs", ErrBadImage, len(manifest.Layers), len(diffIDs))
+	}
+
+	for idx, desc := range manifest.Layers {
+		compression, err := layerCompression(desc.MediaType)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := desc.Digest.Validate(); err != nil {
+			return nil, err
+		}
+
+		img.Layers = append(img.Layers, &Layer{
+			Path:        blobPath(dir, desc.Digest),
+			MediaType:   desc.MediaType,
+			Compression: compression,
+			Digest:      desc.Digest,
+			Size:        desc.Size,
+			DiffID:      diffIDs[idx],
+			Annotations: desc.Annotations,
+		})
+	}
+
+	//the reference names are often just tags, so only the full image names are used as the image tags
+	if name := img.IndexAnnotations[ocispec.AnnotationRefName]; strings.Contains(name, ":") {
+		img.Tags = []string{name}
+	}
+
+	return img, nil
+}
+
+func selectIndexManifest(manifests []ocispec.Descriptor, refName string) (*ocispec.Descriptor, error) {
+	if refName == "" {
+		switch len(manifests) {
+		case 0:
+			return nil, ErrImageNotFound
+		case 1:
+			return &manifests[0], nil
+		default:
+			return nil, fmt.Errorf("%w: the layout has %d images", ErrMissingImageName, len(manifests))
+		}
+	}
+
+	for idx := range manifests {
+		if manifests[idx].Annotations[ocispec.AnnotationRefName] == refName {
+			return &manifests[idx], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: '%s'", ErrImageNotFound, refName)
+}
+
+// selectPlatformManifest returns the image manifest for the current architecture
+func selectPlatformManifest(manifests []ocispec.Descriptor) (*ocispec.Descriptor, error) {
+	for idx := range manifests {
+		platform := manifests[idx].Platform
+		if platform != nil && platform.OS == "linux" && platform.Architecture == runtime.GOARCH {
+			return &manifests[idx], nil
+		}
+	}
+
+	if len(manifests) == 1 {
+		return &manifests[0], nil
+	}
+
+	return nil, fmt.Errorf("%w: no image manifest for linux/%s", ErrImageNotFound, runtime.GOARCH)
+}
+
+func isIndexMediaType(mediaType string) bool {
+	return mediaType == ocispec.MediaTypeImageIndex || mediaType == MediaTypeDockerManifestList
+}
+
+func layerCompression(mediaType string) (string, error) {
+	switch mediaType {
+	case ocispec.MediaTypeImageLayer,
+		ocispec.MediaTypeImageLayerNonDistributable:
+		return CompressionNone, nil
+	case ocispec.MediaTypeImageLayerGzip,
+		ocispec.MediaTypeImageLayerNonDistributableGzip,
+		MediaTypeDockerLayer,
+		MediaTypeDockerForeignLayer:
+		return CompressionGzip, nil
+	case ocispec.MediaTypeImageLayerZstd,
+		ocispec.MediaTypeImageLayerNonDistributableZstd:
+		return CompressionZstd, nil
+	}
+
+	return "", fmt.Errorf("%w: layer - '%s'", ErrUnsupportedMediaType, mediaType)
+}
+
+func isForeignLayer(mediaType string) bool {
+	switch mediaType {
+	case ocispec.MediaTypeImageLayerNonDistributable,
+		ocispec.MediaTypeImageLayerNonDistributableGzip,
+		ocispec.MediaTypeImageLayerNonDistributableZstd,
+		MediaTypeDockerForeignLayer:
+		return true
+	}
+
+	return false
+}
+
+// layerMediaType returns the layer media type and compression for the manifest media type set
+// (the uncompressed layers are compressed with gzip and the Docker manifests don't support zstd)
+func layerMediaType(layer *Layer, mediaTypes string) (string, string) {
+	foreign := isForeignLayer(layer.MediaType)
+	if mediaTypes == MediaTypesDocker {
+		if foreign {
+			return MediaTypeDockerForeignLayer, CompressionGzip
+		}
+
+		return MediaTypeDockerLayer, CompressionGzip
+	}
+
+	if layer.Compression == CompressionZstd {
+		if foreign {
+			return ocispec.MediaTypeImageLayerNonDistributableZstd, CompressionZstd
+		}
+
+		return ocispec.MediaTypeImageLayerZstd, CompressionZstd
+	}
+
+	if foreign {
+		return ocispec.MediaTypeImageLayerNonDistributableGzip, CompressionGzip
+	}
+
+	return ocispec.MediaTypeImageLayerGzip, CompressionGzip
+}
+
+// WriteOCILayout saves the image in the OCI image layout directory
+// using the OCI or the Docker (v2 schema2) manifest media types
+// (the existing layout images with the same reference name are replaced)
+func WriteOCILayout(
+	img *Image,
+	outputDir string,
+	refName string,
+	mediaTypes string) (ocispec.Descriptor, []ocispec.Descriptor, error) {
+	var manifestDesc ocispec.Descriptor
+	blobsDir := filepath.Join(outputDir, BlobsDirName, string(digest.SHA256))
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return manifestDesc, nil, err
+	}
+
+	var layers []ocispec.Descriptor
+	for _, layer := range img.Layers {
+		desc, err := writeOCILayer(layer, blobsDir, mediaTypes)
+		if err != nil {
+			return manifestDesc, nil, err
+		}
+
+		layers = append(layers, desc)
+	}
+
+	manifestMediaType, configMediaType := ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageConfig
+	if mediaTypes == MediaTypesDocker {
+		manifestMediaType, configMediaType = MediaTypeDockerManifest, MediaTypeDockerConfig
+	}
+
+	configDesc, err := WriteBlob(blobsDir, configMediaType, img.Config)
+	if err != nil {
+		return manifestDesc, nil, err
+	}
+
+	manifestData, err := json.Marshal(ocispec.Manifest{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		MediaType:   manifestMediaType,
+		Config:      configDesc,
+		Layers:      layers,
+		Annotations: img.Annotations,
+	})
+	if err != nil {
+		return manifestDesc, nil, err
+	}
+
+	manifestDesc, err = WriteBlob(blobsDir, manifestMediaType, manifestData)
+	if err != nil {
+		return manifestDesc, nil, err
+	}
+
+	manifestDesc.Platform = configPlatform(img.Config)
+	if len(img.IndexAnnotations) > 0 || refName != "" {
+		manifestDesc.Annotations = map[string]string{}
+		for k, v := range img.IndexAnnotations {
+			manifestDesc.Annotations[k] = v
+		}
+
+		if refName != "" {
+			manifestDesc.Annotations[ocispec.AnnotationRefName] = refName
+		}
+	}
+
+	if err := UpdateIndex(outputDir, refName, manifestDesc); err != nil {
+		return manifestDesc, nil, err
+	}
+
+	if err := WriteLayoutFile(outputDir); err != nil {
+		return manifestDesc, nil, err
+	}
+
+	return manifestDesc, layers, nil
+}
+
+// writeOCILayer saves the layer blob (the layer data is recompressed only if its compression is not supported)
+func writeOCILayer(layer *Layer, blobsDir, mediaTypes string) (ocispec.Descriptor, error) {
+	mediaType, compression := layerMediaType(layer, mediaTypes)
+	if compression == layer.Compression {
+		file, err := os.Open(layer.Path)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+
+		defer file.Close()
+
+		desc, err := WriteBlobStream(blobsDir, mediaType, file)
+		if err != nil {
+			return desc, err
+		}
+
+		if layer.Digest != "" && desc.Digest != layer.Digest {
+			return desc, fmt.Errorf("%w: layer digest mismatch (%s != %s)", ErrBadImage, desc.Digest, layer.Digest)
+		}
+
+		//the layer annotations describe the blob data, so they are kept only for the unchanged blobs
+		desc.Annotations = layer.Annotations
+		return desc, nil
+	}
+
+	input, err := openLayer(layer)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	defer input.Close()
+
+	reader, writer := io.Pipe()
+	go func() {
+		gw := gzip.NewWriter(writer)
+		_, err := io.Copy(gw, input)
+		if cerr := gw.Close(); err == nil {
+			err = cerr
+		}
+
+		writer.CloseWithError(err)
+	}()
+
+	desc, err := WriteBlobStream(blobsDir, mediaType, reader)
+	reader.Close()
+	return desc, err
+}
+
+// WriteBlob saves the blob data in the layout blobs directory
+func WriteBlob(blobsDir, mediaType string, data []byte) (ocispec.Descriptor, error) {
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+
+	err := ioutil.WriteFile(filepath.Join(blobsDir, desc.Digest.Encoded()), data, 0644)
+	return desc, err
+}
+
+// WriteBlobStream saves the blob stream in the layout blobs directory
+func WriteBlobStream(blobsDir, mediaType string, reader io.Reader) (ocispec.Descriptor, error) {
+	var desc ocispec.Descriptor
+	tmpFile, err := ioutil.TempFile(blobsDir, ".tmp-blob-")
+	if err != nil {
+		return desc, err
+	}
+
+	tmpPath := tmpFile.Name()
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, hasher), reader)
+	if cerr := tmpFile.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return desc, err
+	}
+
+	desc = ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.NewDigestFromEncoded(digest.SHA256, hex.EncodeToString(hasher.Sum(nil))),
+		Size:      size,
+	}
+
+	if err := os.Rename(tmpPath, filepath.Join(blobsDir, desc.Digest.Encoded())); err != nil {
+		os.Remove(tmpPath)
+		return desc, err
+	}
+
+	return desc, nil
+}
+
+// UpdateIndex adds the image manifest to the layout index
+// (replacing the manifest with the same reference name if the layout already has it)
+func UpdateIndex(outputDir, refName string, manifestDesc ocispec.Descriptor) error {
+	indexPath := filepath.Join(outputDir, IndexFileName)
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+	}
+
+	if data, err := ioutil.ReadFile(indexPath); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return err
+		}
+	}
+
+	var manifests []ocispec.Descriptor
+	for _, desc := range index.Manifests {
+		if refName == "" || desc.Annotations[ocispec.AnnotationRefName] != refName {
+			manifests = append(manifests, desc)
+		}
+	}
+
+	index.Manifests = append(manifests, manifestDesc)
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(indexPath, data, 0644)
+}
+
+// WriteLayoutFile saves the 'oci-layout' file with the layout version
+func WriteLayoutFile(outputDir string) error {
+	data, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(outputDir, ocispec.ImageLayoutFile), data, 0644)
+}
+
+func blobPath(dir string, dgst digest.Digest) string {
+	return filepath.Join(dir, BlobsDirName, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+func readBlob(dir string, desc ocispec.Descriptor) ([]byte, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(blobPath(dir, desc.Digest))
+	if err != nil {
+		return nil, err
+	}
+
+	if dgst := desc.Digest.Algorithm().FromBytes(data); dgst != desc.Digest {
+		return nil, fmt.Errorf("%w: blob digest mismatch (%s != %s)", ErrBadImage, dgst, desc.Digest)
+	}
+
+	return data, nil
+}
+
+func readBlobJSON(dir string, desc *ocispec.Descriptor, out interface{}) error {
+	data, err := readBlob(dir, *desc)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func readJSONFile(filePath string, out interface{}) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, out)
+}
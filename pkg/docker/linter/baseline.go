@@ -0,0 +1,188 @@
+package linter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+// BaselineVersion is the lint baseline file format version
+const BaselineVersion = "1"
+
+// Baseline contains the known (pre-existing) lint findings
+// that are suppressed in the lint results
+type Baseline struct {
+	Version  string             `json:"version"`
+	Findings []*BaselineFinding `json:"findings"`
+}
+
+// BaselineFinding is a known lint finding
+// (the fingerprint doesn't depend on the instruction line numbers,
+// so the findings are still suppressed when the Dockerfile lines move)
+type BaselineFinding struct {
+	CheckID     string `json:"check_id"`
+	Dockerfile  string `json:"dockerfile,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	//informational fields
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewBaseline creates a baseline with the lint report check hits
+func NewBaseline(report *Report, dockerfilePath string) *Baseline {
+	baseline := &Baseline{
+		Version:  BaselineVersion,
+		Findings: []*BaselineFinding{},
+	}
+
+	baseline.Add(report, dockerfilePath)
+	return baseline
+}
+
+// Add adds the lint report check hits to the baseline
+func (b *Baseline) Add(report *Report, dockerfilePath string) {
+	if report == nil {
+		return
+	}
+
+	var ids []string
+	for id := range report.Hits {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	dockerfile := baselinePath(dockerfilePath)
+	for _, id := range ids {
+		result := report.Hits[id]
+		if len(result.Matches) == 0 {
+			b.Findings = append(b.Findings, &BaselineFinding{
+				CheckID:     id,
+				Dockerfile:  dockerfile,
+				Fingerprint: Fingerprint(id, nil),
+				Message:     result.Message,
+			})
+
+			continue
+		}
+
+		for _, m := range result.Matches {
+			b.Findings = append(b.Findings, &BaselineFinding{
+				CheckID:     id,
+				Dockerfile:  dockerfile,
+				Fingerprint: Fingerprint(id, m),
+				Line:        matchLine(m),
+				Message:     m.Message,
+			})
+		}
+	}
+}
+
+// LoadBaseline loads the lint baseline file
+func LoadBaseline(filePath string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	if baseline.Version != BaselineVersion {
+		return nil, fmt.Errorf("%s: unsupported baseline version '%s'", filePath, baseline.Version)
+	}
+
+	return &baseline, nil
+}
+
+// Save saves the baseline file
+func (b *Baseline) Save(filePath string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(filePath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(filePath, data, 0644)
+}
+
+// ApplyBaseline suppresses the lint report check hits that are in the baseline
+// (each baseline finding suppresses one matching check hit)
+func ApplyBaseline(report *Report, baseline *Baseline, dockerfilePath string) {
+	if report == nil || baseline == nil {
+		return
+	}
+
+	dockerfile := baselinePath(dockerfilePath)
+	known := map[string]int{}
+	for _, f := range baseline.Findings {
+		if f.Dockerfile != "" && f.Dockerfile != dockerfile {
+			continue
+		}
+
+		known[f.CheckID+"/"+f.Fingerprint]++
+	}
+
+	if len(known) == 0 {
+		return
+	}
+
+	report.suppress(SuppressionBaseline, func(id string, m *check.Match) (bool, string) {
+		key := id + "/" + Fingerprint(id, m)
+		if known[key] > 0 {
+			known[key]--
+			return true, ""
+		}
+
+		return false, ""
+	})
+}
+
+// Fingerprint creates a line independent check hit match fingerprint
+// (based on the matched instruction or stage)
+func Fingerprint(checkID string, m *check.Match) string {
+	var key string
+	switch {
+	case m == nil:
+		key = "result"
+	case m.Instruction != nil:
+		key = fmt.Sprintf("instruction|%s|%s|%s",
+			strings.ToLower(m.Instruction.Name),
+			strings.Join(m.Instruction.Flags, " "),
+			strings.Join(strings.Fields(m.Instruction.ArgsRaw), " "))
+	case m.Stage != nil:
+		key = fmt.Sprintf("stage|%s|%s:%s@%s",
+			m.Stage.Name,
+			m.Stage.Parent.Name,
+			m.Stage.Parent.Tag,
+			m.Stage.Parent.Digest)
+	default:
+		key = "message|" + m.Message
+	}
+
+	sum := sha256.Sum256([]byte(checkID + "\n" + key))
+	return hex.EncodeToString(sum[:16])
+}
+
+func baselinePath(dockerfilePath string) string {
+	if dockerfilePath == "" {
+		return ""
+	}
+
+	return filepath.ToSlash(filepath.Clean(dockerfilePath))
+}
@@ -0,0 +1,171 @@
+package linter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v3"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+// DirectoryTargetType is the target type for the batch lint mode
+// (all Dockerfiles in the target directory tree are linted)
+const DirectoryTargetType = "directory"
+
+// DefaultDockerfilePatterns are the default Dockerfile discovery patterns
+// (relative to the target directory)
+var DefaultDockerfilePatterns = []string{
+	"**/Dockerfile",
+	"**/Dockerfile.*",
+	"**/*.Dockerfile",
+	"**/*.dockerfile",
+	"**/Containerfile",
+}
+
+// directories that are never scanned
+var skipDirs = map[string]struct{}{
+	".git": {},
+	".hg":  {},
+	".svn": {},
+}
+
+// FindDockerfiles returns the paths for the Dockerfiles in the directory tree
+// (the include and exclude glob patterns are relative to the root directory;
+// the default Dockerfile patterns are used if there are no include patterns)
+func FindDockerfiles(rootDir string, includes []string, excludes []string) ([]string, error) {
+	if len(includes) == 0 {
+		includes = DefaultDockerfilePatterns
+	}
+
+	for _, pattern := range append(append([]string{}, includes...), excludes...) {
+		if _, err := doublestar.Match(pattern, "/"); err != nil {
+			return nil, err
+		}
+	}
+
+	matchAny := func(patterns []string, name string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := doublestar.Match(pattern, name); matched {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var paths []string
+	err := filepath.Walk(rootDir, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(rootDir, fullPath)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if _, ok := skipDirs[info.Name()]; ok {
+				return filepath.SkipDir
+			}
+
+			if matchAny(excludes, rel) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if matchAny(includes, rel) && !matchAny(excludes, rel) {
+			paths = append(paths, fullPath)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// FileReport contains the lint results for one of the Dockerfiles (batch lint mode)
+type FileReport struct {
+	DockerfilePath string
+	Report         *Report
+	Error          error
+}
+
+// ExecuteFiles lints the Dockerfiles concurrently
+// (the options are used for each Dockerfile, each Dockerfile directory is its build context;
+// the results are in the same order as the Dockerfile paths)
+func ExecuteFiles(paths []string, options Options, workers int) []*FileReport {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]*FileReport, len(paths))
+	pathCh := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range pathCh {
+				fileOptions := options
+				fileOptions.DockerfilePath = paths[idx]
+				fileOptions.Dockerfile = nil
+				fileOptions.BuildContextDir = ""
+				fileOptions.Dockerignore = nil
+
+				report, err := Execute(fileOptions)
+				results[idx] = &FileReport{
+					DockerfilePath: paths[idx],
+					Report:         report,
+					Error:          err,
+				}
+			}
+		}()
+	}
+
+	for idx := range paths {
+		pathCh <- idx
+	}
+
+	close(pathCh)
+	wg.Wait()
+	return results
+}
+
+// CountHits returns the number of check hits with the selected level or higher
+func (r *Report) CountHits(minLevel string) int {
+	minRank := check.LevelRank(minLevel)
+	if r == nil || minRank == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, result := range r.Hits {
+		if result.Source != nil &&
+			check.LevelRank(result.Source.Labels[check.LabelLevel]) >= minRank {
+			count++
+		}
+	}
+
+	return count
+}
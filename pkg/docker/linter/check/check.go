@@ -12,6 +12,7 @@ type Context struct {
 	Dockerfile      *spec.Dockerfile
 	BuildContextDir string
 	Dockerignore    *dockerignore.Matcher
+	Runtime         *RuntimeInfo //nil if there's no runtime data (used by the runtime-informed checks)
 }
 
 type Options struct {
@@ -33,6 +34,8 @@ const (
 	LabelInstruction = "instruction"
 	LabelApp         = "app"
 	LabelShell       = "shell"
+	LabelCustom      = "custom"
+	LabelRuntime     = "runtime"
 )
 
 const (
@@ -44,6 +47,19 @@ const (
 	LevelStyle = "style"
 )
 
+var levelRanks = map[string]int{
+	LevelStyle: 1,
+	LevelInfo:  2,
+	LevelWarn:  3,
+	LevelError: 4,
+	LevelFatal: 5,
+}
+
+// LevelRank returns the level severity rank (higher is more severe; 0 for unknown levels)
+func LevelRank(level string) int {
+	return levelRanks[level]
+}
+
 const (
 	ScopeAll          = "all"
 	ScopeDockerfile   = "dockerfile"
@@ -53,14 +69,17 @@ const (
 	ScopeData         = "data"
 	ScopeApp          = "app"
 	ScopeShell        = "shell"
+	ScopeRuntime      = "runtime"
 )
 
 //Possible labels:
 //"level" -> "info", "warn", "error", "style"
-//"scope" -> "app", "shell", "instruction", "stage", "dockerfile", "all", "dockerignore", "data"
+//"scope" -> "app", "shell", "instruction", "stage", "dockerfile", "all", "dockerignore", "data", "runtime"
 //"instruction" -> "list,of,instructions" (negative with !instruction)
 //"app" -> "list,of,app names"
 //"shell" -> "general or specific shell name"
+//"custom" -> "true" (user-defined checks)
+//"runtime" -> "true" (checks that need the runtime data)
 
 func (i *Info) Get() *Info {
 	return i
@@ -80,14 +99,33 @@ type Match struct {
 	Message     string             `json:"message,omitempty"`
 }
 
+// Suppression is a suppressed check hit (or check hit match)
+type Suppression struct {
+	CheckID string `json:"check_id"`
+	Source  string `json:"source"` //"inline" or "baseline"
+	Reason  string `json:"reason,omitempty"`
+	Match   *Match `json:"match,omitempty"`
+}
+
 type Runner interface {
 	Get() *Info
 	Run(opts *Options, ctx *Context) (*Result, error)
 }
 
-//regex-based (with rules)
-//type PolicyCheck struct {
-//	Info
-//}
+// DefaultRegistry contains the built-in checks
+var DefaultRegistry = NewRegistry()
 
+// AllChecks contains the built-in checks (the same checks as in DefaultRegistry)
+//
+// Deprecated: use DefaultRegistry.Checks() instead.
 var AllChecks = []Runner{}
+
+// Register adds the built-in check to the default registry
+// (used in the check 'init' functions, so the registration errors are fatal)
+func Register(c Runner) {
+	if err := DefaultRegistry.Register(c); err != nil {
+		panic(err)
+	}
+
+	AllChecks = DefaultRegistry.Checks()
+}
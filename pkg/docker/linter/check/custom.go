@@ -0,0 +1,435 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+var (
+	ErrBadRule = errors.New("bad custom rule")
+)
+
+// Custom rule stage selectors
+const (
+	RuleStageAll  = "all"
+	RuleStageLast = "last"
+)
+
+// CustomRule is a user-defined check spec (loaded from the lint rules config files)
+type CustomRule struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Level       string            `json:"level,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Match       RuleMatcher       `json:"match,omitempty"`
+	//Starlark expression evaluated for each matched instruction
+	//(the 'instruction' and 'stage' values are available in the expression)
+	Expr string `json:"expr,omitempty"`
+}
+
+// RuleMatcher contains the declarative instruction matching conditions
+// (all configured conditions must match; the regular expressions are not anchored)
+type RuleMatcher struct {
+	//instruction names (any instruction if empty)
+	Instructions []string `json:"instructions,omitempty"`
+	//instruction args (raw) patterns
+	Args    string `json:"args,omitempty"`
+	NotArgs string `json:"not_args,omitempty"`
+	//instruction flag pattern (matches if any of the flags match)
+	Flags string `json:"flags,omitempty"`
+	//stage base image patterns ('name:tag@digest')
+	BaseImage    string `json:"base_image,omitempty"`
+	NotBaseImage string `json:"not_base_image,omitempty"`
+	//stages to check: 'all' (default) or 'last'
+	Stage string `json:"stage,omitempty"`
+	//report the stages where none of the instructions match
+	Missing bool `json:"missing,omitempty"`
+}
+
+func (m *RuleMatcher) hasInstructionConditions() bool {
+	return len(m.Instructions) > 0 ||
+		m.Args != "" ||
+		m.NotArgs != "" ||
+		m.Flags != ""
+}
+
+// CustomCheck is a check created from a user-defined rule
+type CustomCheck struct {
+	Info
+	Rule *CustomRule
+
+	instructions map[string]struct{}
+	args         *regexp.Regexp
+	notArgs      *regexp.Regexp
+	flags        *regexp.Regexp
+	baseImage    *regexp.Regexp
+	notBaseImage *regexp.Regexp
+	expr         *starlark.Function
+}
+
+// NewCustomCheck creates a check from the user-defined rule
+func NewCustomCheck(rule *CustomRule) (*CustomCheck, error) {
+	if rule == nil || rule.ID == "" {
+		return nil, fmt.Errorf("%w - missing rule ID", ErrBadRule)
+	}
+
+	ruleErr := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w (%s) - %s", ErrBadRule, rule.ID, fmt.Sprintf(format, args...))
+	}
+
+	level := strings.ToLower(rule.Level)
+	switch level {
+	case "":
+		level = LevelWarn
+	case LevelFatal, LevelError, LevelWarn, LevelInfo, LevelStyle:
+	default:
+		return nil, ruleErr("unknown level '%s'", rule.Level)
+	}
+
+	stage := strings.ToLower(rule.Match.Stage)
+	switch stage {
+	case "", RuleStageAll, RuleStageLast:
+	default:
+		return nil, ruleErr("unknown stage selector '%s'", rule.Match.Stage)
+	}
+
+	if !rule.Match.hasInstructionConditions() &&
+		rule.Match.BaseImage == "" &&
+		rule.Match.NotBaseImage == "" &&
+		rule.Expr == "" {
+		return nil, ruleErr("no match conditions")
+	}
+
+	name := rule.Name
+	if name == "" {
+		name = rule.ID
+	}
+
+	message := rule.Message
+	if message == "" {
+		message = name
+	}
+
+	description := rule.Description
+	if description == "" {
+		description = name
+	}
+
+	scope := ScopeInstruction
+	if rule.Match.Missing || !rule.Match.hasInstructionConditions() && rule.Expr == "" {
+		scope = ScopeStage
+	}
+
+	labels := map[string]string{}
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+
+	labels[LabelLevel] = level
+	labels[LabelScope] = scope
+	labels[LabelCustom] = "true"
+
+	c := &CustomCheck{
+		Info: Info{
+			ID:          rule.ID,
+			Name:        name,
+			Description: description,
+			DetailsURL:  rule.URL,
+			MainMessage: message,
+			Labels:      labels,
+		},
+		Rule: rule,
+	}
+
+	if len(rule.Match.Instructions) > 0 {
+		c.instructions = map[string]struct{}{}
+		for _, name := range rule.Match.Instructions {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, ok := instruction.Specs[name]; !ok {
+				return nil, ruleErr("unknown instruction '%s'", name)
+			}
+
+			c.instructions[name] = struct{}{}
+		}
+	}
+
+	patterns := []struct {
+		field string
+		expr  string
+		re    **regexp.Regexp
+	}{
+		{"args", rule.Match.Args, &c.args},
+		{"not_args", rule.Match.NotArgs, &c.notArgs},
+		{"flags", rule.Match.Flags, &c.flags},
+		{"base_image", rule.Match.BaseImage, &c.baseImage},
+		{"not_base_image", rule.Match.NotBaseImage, &c.notBaseImage},
+	}
+
+	for _, p := range patterns {
+		if p.expr == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(p.expr)
+		if err != nil {
+			return nil, ruleErr("bad '%s' pattern - %v", p.field, err)
+		}
+
+		*p.re = re
+	}
+
+	if rule.Expr != "" {
+		fn, err := compileRuleExpr(rule.ID, rule.Expr)
+		if err != nil {
+			return nil, ruleErr("bad expression - %v", err)
+		}
+
+		c.expr = fn
+	}
+
+	return c, nil
+}
+
+// compileRuleExpr wraps the Starlark rule expression in a function
+// (taking the 'instruction' and 'stage' params)
+func compileRuleExpr(id, expr string) (*starlark.Function, error) {
+	if _, err := syntax.ParseExpr(id, expr, 0); err != nil {
+		return nil, err
+	}
+
+	src := fmt.Sprintf("def match(instruction, stage):\n    return (%s\n    )\n", expr)
+	thread := &starlark.Thread{Name: id}
+	globals, err := starlark.ExecFile(thread, id, src, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, ok := globals["match"].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("no expression function")
+	}
+
+	return fn, nil
+}
+
+func (c *CustomCheck) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	if ctx.Dockerfile == nil {
+		return result, nil
+	}
+
+	stages := ctx.Dockerfile.Stages
+	if strings.ToLower(c.Rule.Match.Stage) == RuleStageLast {
+		stages = nil
+		if ctx.Dockerfile.LastStage != nil {
+			stages = []*spec.BuildStage{ctx.Dockerfile.LastStage}
+		}
+	}
+
+	addMatch := func(m *Match) {
+		if !result.Hit {
+			result.Hit = true
+			result.Message = c.MainMessage
+		}
+
+		result.Matches = append(result.Matches, m)
+	}
+
+	thread := &starlark.Thread{Name: c.ID}
+	for _, stage := range stages {
+		if !c.matchBaseImage(ctx.Dockerfile, stage) {
+			continue
+		}
+
+		if !c.Rule.Match.hasInstructionConditions() && c.expr == nil {
+			//stage level rule (base image conditions only)
+			addMatch(&Match{
+				Stage:       stage,
+				Instruction: stage.FromInstruction,
+				Message:     stageMessage(stage),
+			})
+
+			continue
+		}
+
+		var stageValue starlark.Value
+		if c.expr != nil {
+			stageValue = starlarkStage(stage, stage == ctx.Dockerfile.LastStage)
+		}
+
+		found := false
+		for _, inst := range stage.CurrentInstructions {
+			if !c.matchInstruction(inst) {
+				continue
+			}
+
+			if c.expr != nil {
+				val, err := starlark.Call(thread, c.expr,
+					starlark.Tuple{starlarkInstruction(inst), stageValue}, nil)
+				if err != nil {
+					return nil, fmt.Errorf("rule %s: expression error (line %d) - %v", c.ID, inst.StartLine, err)
+				}
+
+				if !bool(val.Truth()) {
+					continue
+				}
+			}
+
+			found = true
+			if !c.Rule.Match.Missing {
+				addMatch(&Match{
+					Stage:       stage,
+					Instruction: inst,
+					Message: fmt.Sprintf("instruction: start=%d end=%d name='%s' stage=%d",
+						inst.StartLine, inst.EndLine, inst.Name, stage.Index),
+				})
+			}
+		}
+
+		if c.Rule.Match.Missing && !found {
+			addMatch(&Match{
+				Stage:   stage,
+				Message: stageMessage(stage),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func stageMessage(stage *spec.BuildStage) string {
+	return fmt.Sprintf("Stage: index=%d name='%s' start=%d end=%d parent='%s'",
+		stage.Index,
+		stage.Name,
+		stage.StartLine,
+		stage.EndLine,
+		baseImageRef(stage))
+}
+
+func (c *CustomCheck) matchBaseImage(df *spec.Dockerfile, stage *spec.BuildStage) bool {
+	if c.baseImage == nil && c.notBaseImage == nil {
+		return true
+	}
+
+	if stage.Parent.Name == "" {
+		return false
+	}
+
+	if _, ok := df.StagesByName[stage.Parent.Name]; ok {
+		//the base image is one of the build stages
+		return false
+	}
+
+	ref := baseImageRef(stage)
+	if c.baseImage != nil && !c.baseImage.MatchString(ref) {
+		return false
+	}
+
+	if c.notBaseImage != nil && c.notBaseImage.MatchString(ref) {
+		return false
+	}
+
+	return true
+}
+
+func (c *CustomCheck) matchInstruction(inst *instruction.Field) bool {
+	if c.instructions != nil {
+		if _, ok := c.instructions[strings.ToLower(inst.Name)]; !ok {
+			return false
+		}
+	}
+
+	if c.args != nil && !c.args.MatchString(inst.ArgsRaw) {
+		return false
+	}
+
+	if c.notArgs != nil && c.notArgs.MatchString(inst.ArgsRaw) {
+		return false
+	}
+
+	if c.flags != nil {
+		found := false
+		for _, flag := range inst.Flags {
+			if c.flags.MatchString(flag) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func baseImageRef(stage *spec.BuildStage) string {
+	ref := stage.Parent.Name
+	if stage.Parent.Tag != "" {
+		ref = ref + ":" + stage.Parent.Tag
+	}
+
+	if stage.Parent.Digest != "" {
+		ref = ref + "@" + stage.Parent.Digest
+	}
+
+	return ref
+}
+
+func starlarkStrings(values []string) *starlark.List {
+	var list []starlark.Value
+	for _, v := range values {
+		list = append(list, starlark.String(v))
+	}
+
+	return starlark.NewList(list)
+}
+
+func starlarkInstruction(inst *instruction.Field) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"name":       starlark.String(strings.ToLower(inst.Name)),
+		"args":       starlarkStrings(inst.Args),
+		"args_raw":   starlark.String(inst.ArgsRaw),
+		"flags":      starlarkStrings(inst.Flags),
+		"raw":        starlark.String(inst.RawData),
+		"is_json":    starlark.Bool(inst.IsJSONForm),
+		"is_onbuild": starlark.Bool(inst.IsOnBuild),
+		"start_line": starlark.MakeInt(inst.StartLine),
+		"end_line":   starlark.MakeInt(inst.EndLine),
+	})
+}
+
+func starlarkStage(stage *spec.BuildStage, isLast bool) starlark.Value {
+	env := starlark.NewDict(len(stage.EnvVars))
+	for k, v := range stage.EnvVars {
+		env.SetKey(starlark.String(k), starlark.String(v))
+	}
+
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"index":       starlark.MakeInt(stage.Index),
+		"name":        starlark.String(stage.Name),
+		"is_last":     starlark.Bool(isLast),
+		"base_image":  starlark.String(stage.Parent.Name),
+		"base_tag":    starlark.String(stage.Parent.Tag),
+		"base_digest": starlark.String(stage.Parent.Digest),
+		"env":         env,
+	})
+}
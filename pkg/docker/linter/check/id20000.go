@@ -23,7 +23,7 @@ func init() {
 		},
 	}
 
-	AllChecks = append(AllChecks, check)
+	Register(check)
 }
 
 type InvalidInstruction struct {
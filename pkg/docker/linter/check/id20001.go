@@ -20,7 +20,7 @@ func init() {
 		},
 	}
 
-	AllChecks = append(AllChecks, check)
+	Register(check)
 }
 
 type EmptyDockerfile struct {
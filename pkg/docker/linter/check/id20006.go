@@ -24,7 +24,7 @@ func init() {
 		},
 	}
 
-	AllChecks = append(AllChecks, check)
+	Register(check)
 }
 
 type StageFromLatest struct {
@@ -38,7 +38,9 @@ func (c *StageFromLatest) Run(opts *Options, ctx *Context) (*Result, error) {
 	}
 
 	for _, stage := range ctx.Dockerfile.Stages {
-		if stage.Parent.Name != "" {
+		if stage.Parent.Name != "" &&
+			stage.Parent.Name != "scratch" &&
+			stage.Parent.ParentStage == nil {
 			if (stage.Parent.Tag == "" || strings.ToLower(stage.Parent.Tag) == "latest") &&
 				stage.Parent.Digest == "" {
 				if !result.Hit {
@@ -25,7 +25,7 @@ func init() {
 		},
 	}
 
-	AllChecks = append(AllChecks, check)
+	Register(check)
 }
 
 type MultipleCmdInstructions struct {
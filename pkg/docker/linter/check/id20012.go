@@ -29,7 +29,7 @@ func init() {
 		},
 	}
 
-	AllChecks = append(AllChecks, check)
+	Register(check)
 }
 
 type EntrypointCmdShellForm struct {
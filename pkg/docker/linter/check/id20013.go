@@ -26,7 +26,7 @@ func init() {
 		},
 	}
 
-	AllChecks = append(AllChecks, check)
+	Register(check)
 }
 
 type MalformedInstExecForm struct {
@@ -27,7 +27,7 @@ func init() {
 		},
 	}
 
-	AllChecks = append(AllChecks, check)
+	Register(check)
 }
 
 type PyPipInstallLatest struct {
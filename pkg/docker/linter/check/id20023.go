@@ -0,0 +1,109 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+func init() {
+	check := &SecretInEnvOrArg{
+		Info: Info{
+			ID:           "ID.20023",
+			Name:         "Secret in ENV or ARG instruction",
+			Description:  "Secret in ENV or ARG instruction (the values are saved in the image config or history)",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.20023",
+			MainMessage:  "Secret in ENV or ARG instruction",
+			MatchMessage: "Instruction: start=%d end=%d global_index=%d stage_id=%d stage_index=%d name=%s",
+			Labels: map[string]string{
+				LabelLevel: LevelError,
+				LabelScope: ScopeStage,
+			},
+		},
+		NamePattern: regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api_?key|access_?key|private_?key|credentials?)`),
+	}
+
+	Register(check)
+}
+
+type SecretInEnvOrArg struct {
+	Info
+	NamePattern *regexp.Regexp
+}
+
+func (c *SecretInEnvOrArg) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	for _, stage := range ctx.Dockerfile.Stages {
+		for _, inst := range stage.CurrentInstructions {
+			var names []string
+			switch inst.Name {
+			case instruction.Env:
+				//name/value pairs
+				for i := 0; i+1 < len(inst.Args); i += 2 {
+					if isLiteralValue(inst.Args[i+1]) {
+						names = append(names, inst.Args[i])
+					}
+				}
+			case instruction.Arg:
+				//the build arg values are saved in the image history
+				//(even if the instruction doesn't have a default value)
+				for _, arg := range inst.Args {
+					names = append(names, strings.SplitN(arg, "=", 2)[0])
+				}
+			default:
+				continue
+			}
+
+			for _, name := range names {
+				if !c.NamePattern.MatchString(name) {
+					continue
+				}
+
+				if !result.Hit {
+					result.Hit = true
+					result.Message = c.MainMessage
+				}
+
+				match := &Match{
+					Stage:       stage,
+					Instruction: inst,
+					Message: fmt.Sprintf(c.MatchMessage,
+						inst.StartLine,
+						inst.EndLine,
+						inst.GlobalIndex,
+						inst.StageID,
+						inst.StageIndex,
+						name),
+				}
+
+				result.Matches = append(result.Matches, match)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// isLiteralValue returns false for the empty values and the variable references
+func isLiteralValue(value string) bool {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	if value == "" {
+		return false
+	}
+
+	if strings.HasPrefix(value, "$") &&
+		!strings.ContainsAny(strings.Trim(value, "${}"), "$ /:") {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,63 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+func init() {
+	check := &NoUser{
+		Info: Info{
+			ID:           "ID.20024",
+			Name:         "No USER instruction",
+			Description:  "No USER instruction in the last stage (the container will run as root, unless the base image sets the user)",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.20024",
+			MainMessage:  "No USER instruction in the last stage",
+			MatchMessage: "Stage: index=%d name='%s' start=%d end=%d parent='%s'",
+			Labels: map[string]string{
+				LabelLevel: LevelWarn,
+				LabelScope: ScopeStage,
+			},
+		},
+	}
+
+	Register(check)
+}
+
+type NoUser struct {
+	Info
+}
+
+func (c *NoUser) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	stage := ctx.Dockerfile.LastStage
+	if stage == nil {
+		return result, nil
+	}
+
+	if len(stage.CurrentInstructionsByType[instruction.User]) > 0 {
+		return result, nil
+	}
+
+	result.Hit = true
+	result.Message = c.MainMessage
+	result.Matches = append(result.Matches, &Match{
+		Stage: stage,
+		Message: fmt.Sprintf(c.MatchMessage,
+			stage.Index,
+			stage.Name,
+			stage.StartLine,
+			stage.EndLine,
+			stage.Parent.Name),
+	})
+
+	return result, nil
+}
@@ -0,0 +1,77 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+func init() {
+	check := &NoHealthcheckForHTTPApp{
+		Info: Info{
+			ID:           "ID.20025",
+			Name:         "No HEALTHCHECK instruction for HTTP app",
+			Description:  "No HEALTHCHECK instruction in the last stage, but the app served HTTP requests at runtime (needs runtime data)",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.20025",
+			MainMessage:  "No HEALTHCHECK instruction for HTTP app",
+			MatchMessage: "Stage: index=%d name='%s' start=%d end=%d (suggestion: '%s')",
+			Labels: map[string]string{
+				LabelLevel:   LevelInfo,
+				LabelScope:   ScopeRuntime,
+				LabelRuntime: "true",
+			},
+		},
+	}
+
+	Register(check)
+}
+
+type NoHealthcheckForHTTPApp struct {
+	Info
+}
+
+func (c *NoHealthcheckForHTTPApp) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	endpoint := ctx.Runtime.HealthEndpoint()
+	if endpoint == nil {
+		return result, nil
+	}
+
+	stage := ctx.Dockerfile.LastStage
+	if stage == nil {
+		return result, nil
+	}
+
+	if len(stage.CurrentInstructionsByType[instruction.Healthcheck]) > 0 {
+		return result, nil
+	}
+
+	port := "<port>"
+	if appPort := appPort(stage, ctx.Runtime); appPort > 0 {
+		port = fmt.Sprintf("%d", appPort)
+	}
+
+	suggestion := fmt.Sprintf("HEALTHCHECK CMD wget -q -O /dev/null http://localhost:%s%s || exit 1",
+		port, endpoint.Path)
+
+	result.Hit = true
+	result.Message = c.MainMessage
+	result.Matches = append(result.Matches, &Match{
+		Stage: stage,
+		Message: fmt.Sprintf(c.MatchMessage,
+			stage.Index,
+			stage.Name,
+			stage.StartLine,
+			stage.EndLine,
+			suggestion),
+	})
+
+	return result, nil
+}
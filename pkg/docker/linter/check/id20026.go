@@ -0,0 +1,74 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+func init() {
+	check := &RootUserAtRuntime{
+		Info: Info{
+			ID:           "ID.20026",
+			Name:         "App runs as root",
+			Description:  "The app process ran as root at runtime (needs runtime data)",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.20026",
+			MainMessage:  "App runs as root (uid=0)",
+			MatchMessage: "%s (suggestion: add a non-root USER instruction, e.g. 'USER %d')",
+			Labels: map[string]string{
+				LabelLevel:   LevelWarn,
+				LabelScope:   ScopeRuntime,
+				LabelRuntime: "true",
+			},
+		},
+		SuggestedUID: 65532,
+	}
+
+	Register(check)
+}
+
+type RootUserAtRuntime struct {
+	Info
+	SuggestedUID int
+}
+
+func (c *RootUserAtRuntime) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	if ctx.Runtime == nil || ctx.Runtime.UID == nil || *ctx.Runtime.UID != 0 {
+		return result, nil
+	}
+
+	stage := ctx.Dockerfile.LastStage
+	if stage == nil {
+		return result, nil
+	}
+
+	match := &Match{}
+	if users := stage.CurrentInstructionsByType[instruction.User]; len(users) > 0 {
+		//the last USER instruction sets the runtime user
+		inst := users[len(users)-1]
+		match.Instruction = inst
+		match.Message = fmt.Sprintf(c.MatchMessage,
+			fmt.Sprintf("Instruction: start=%d end=%d name=%s args='%s'",
+				inst.StartLine, inst.EndLine, inst.Name, inst.ArgsRaw),
+			c.SuggestedUID)
+	} else {
+		match.Stage = stage
+		match.Message = fmt.Sprintf(c.MatchMessage,
+			fmt.Sprintf("Stage: index=%d name='%s' start=%d end=%d",
+				stage.Index, stage.Name, stage.StartLine, stage.EndLine),
+			c.SuggestedUID)
+	}
+
+	result.Hit = true
+	result.Message = c.MainMessage
+	result.Matches = append(result.Matches, match)
+	return result, nil
+}
@@ -0,0 +1,90 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	check := &ExposeMismatch{
+		Info: Info{
+			ID:           "ID.20027",
+			Name:         "EXPOSE instructions don't match listened ports",
+			Description:  "The exposed ports are different from the ports the app listened on at runtime (needs runtime data)",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.20027",
+			MainMessage:  "EXPOSE instructions don't match listened ports",
+			MatchMessage: "Port %d: %s (suggestion: %s)",
+			Labels: map[string]string{
+				LabelLevel:   LevelInfo,
+				LabelScope:   ScopeRuntime,
+				LabelRuntime: "true",
+			},
+		},
+	}
+
+	Register(check)
+}
+
+type ExposeMismatch struct {
+	Info
+}
+
+func (c *ExposeMismatch) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	//no listened ports means there's no port data
+	if ctx.Runtime == nil || len(ctx.Runtime.ListenPorts) == 0 {
+		return result, nil
+	}
+
+	stage := ctx.Dockerfile.LastStage
+	if stage == nil {
+		return result, nil
+	}
+
+	listened := map[int]struct{}{}
+	for _, port := range ctx.Runtime.ListenPorts {
+		listened[port] = struct{}{}
+	}
+
+	exposed := ExposedPorts(stage)
+	for _, port := range sortedPorts(exposed) {
+		if _, ok := listened[port]; ok {
+			continue
+		}
+
+		result.Matches = append(result.Matches, &Match{
+			Instruction: exposed[port],
+			Message: fmt.Sprintf(c.MatchMessage,
+				port,
+				"exposed, but the app didn't listen on it",
+				"remove it from the EXPOSE instruction"),
+		})
+	}
+
+	for _, port := range ctx.Runtime.ListenPorts {
+		if _, ok := exposed[port]; ok {
+			continue
+		}
+
+		result.Matches = append(result.Matches, &Match{
+			Stage: stage,
+			Message: fmt.Sprintf(c.MatchMessage,
+				port,
+				"the app listened on it, but it's not exposed",
+				fmt.Sprintf("'EXPOSE %d'", port)),
+		})
+	}
+
+	if len(result.Matches) > 0 {
+		result.Hit = true
+		result.Message = c.MainMessage
+	}
+
+	return result, nil
+}
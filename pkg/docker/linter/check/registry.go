@@ -0,0 +1,63 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadCheck         = errors.New("bad check")
+	ErrDuplicateCheckID = errors.New("duplicate check ID")
+)
+
+// Registry is an ordered set of lint checks with unique check IDs
+type Registry struct {
+	checks []Runner
+	byID   map[string]Runner
+}
+
+// NewRegistry creates a new empty check registry
+func NewRegistry() *Registry {
+	return &Registry{
+		byID: map[string]Runner{},
+	}
+}
+
+// Register adds the check to the registry
+func (r *Registry) Register(c Runner) error {
+	if c == nil || c.Get() == nil || c.Get().ID == "" {
+		return ErrBadCheck
+	}
+
+	id := c.Get().ID
+	if _, ok := r.byID[id]; ok {
+		return fmt.Errorf("%w - %s", ErrDuplicateCheckID, id)
+	}
+
+	r.checks = append(r.checks, c)
+	r.byID[id] = c
+	return nil
+}
+
+// Checks returns the registered checks (in the registration order)
+func (r *Registry) Checks() []Runner {
+	return r.checks
+}
+
+// Get returns the check with the selected ID
+func (r *Registry) Get(id string) (Runner, bool) {
+	c, ok := r.byID[id]
+	return c, ok
+}
+
+// Clone creates a copy of the registry
+// (used to add the user-defined checks without changing the default registry)
+func (r *Registry) Clone() *Registry {
+	clone := NewRegistry()
+	for _, c := range r.checks {
+		clone.checks = append(clone.checks, c)
+		clone.byID[c.Get().ID] = c
+	}
+
+	return clone
+}
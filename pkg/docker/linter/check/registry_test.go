@@ -0,0 +1,22 @@
+package check
+
+import (
+	"testing"
+)
+
+func TestAllChecksDefaultRegistry(t *testing.T) {
+	checks := DefaultRegistry.Checks()
+	if len(checks) == 0 {
+		t.Fatal("no built-in checks in the default registry")
+	}
+
+	if len(AllChecks) != len(checks) {
+		t.Fatalf("AllChecks - got %d checks expected %d", len(AllChecks), len(checks))
+	}
+
+	for idx, c := range checks {
+		if AllChecks[idx] != c {
+			t.Errorf("AllChecks[%d] - got '%s' expected '%s'", idx, AllChecks[idx].Get().ID, c.Get().ID)
+		}
+	}
+}
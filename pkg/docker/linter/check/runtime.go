@@ -0,0 +1,143 @@
+package check
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+// RuntimeInfo contains the app runtime data collected by the sensor
+// and the HTTP probe (when the image was built, profiled or x-rayed)
+type RuntimeInfo struct {
+	//the effective user ID for the main app process (nil if unknown)
+	UID *uint32
+	//the TCP ports the app was listening on (not including the loopback only ports)
+	ListenPorts []int
+	//the HTTP probe endpoints with successful calls
+	HTTPEndpoints []*RuntimeHTTPEndpoint
+}
+
+// RuntimeHTTPEndpoint is a successfully probed HTTP endpoint
+type RuntimeHTTPEndpoint struct {
+	Method string
+	Path   string
+}
+
+var healthEndpointKeywords = []string{
+	"health",
+	"healthz",
+	"ready",
+	"readyz",
+	"live",
+	"livez",
+	"ping",
+	"status",
+}
+
+// HealthEndpoint returns the probed endpoint that is the best match
+// for a health check (health/ready/ping style paths first, then the root path)
+func (r *RuntimeInfo) HealthEndpoint() *RuntimeHTTPEndpoint {
+	if r == nil {
+		return nil
+	}
+
+	var root, first *RuntimeHTTPEndpoint
+	for _, ep := range r.HTTPEndpoints {
+		if ep.Method != "GET" {
+			continue
+		}
+
+		if first == nil {
+			first = ep
+		}
+
+		if ep.Path == "/" && root == nil {
+			root = ep
+		}
+
+		lpath := strings.ToLower(ep.Path)
+		for _, keyword := range healthEndpointKeywords {
+			if strings.Contains(lpath, keyword) {
+				return ep
+			}
+		}
+	}
+
+	if root != nil {
+		return root
+	}
+
+	return first
+}
+
+// ExposedPorts returns the TCP ports from the EXPOSE instructions in the stage
+// (the map values are the EXPOSE instructions)
+func ExposedPorts(stage *spec.BuildStage) map[int]*instruction.Field {
+	ports := map[int]*instruction.Field{}
+	if stage == nil {
+		return ports
+	}
+
+	for _, inst := range stage.CurrentInstructionsByType[instruction.Expose] {
+		for _, arg := range inst.Args {
+			parts := strings.SplitN(strings.ToLower(arg), "/", 2)
+			if len(parts) == 2 && parts[1] != "tcp" {
+				continue
+			}
+
+			//port ranges and variables are ignored
+			port, err := strconv.Atoi(parts[0])
+			if err != nil {
+				continue
+			}
+
+			if _, ok := ports[port]; !ok {
+				ports[port] = inst
+			}
+		}
+	}
+
+	return ports
+}
+
+func sortedPorts(ports map[int]*instruction.Field) []int {
+	var list []int
+	for port := range ports {
+		list = append(list, port)
+	}
+
+	sort.Ints(list)
+	return list
+}
+
+// appPort returns the most likely app port:
+// the first exposed port the app listened on, the first listened port or the first exposed port
+// (0 if there are no known ports)
+func appPort(stage *spec.BuildStage, runtime *RuntimeInfo) int {
+	exposed := sortedPorts(ExposedPorts(stage))
+	var listened []int
+	if runtime != nil {
+		listened = runtime.ListenPorts
+	}
+
+	for _, port := range exposed {
+		for _, lport := range listened {
+			if port == lport {
+				return port
+			}
+		}
+	}
+
+	if len(listened) > 0 {
+		return listened[0]
+	}
+
+	if len(exposed) > 0 {
+		return exposed[0]
+	}
+
+	return 0
+}
@@ -0,0 +1,232 @@
+package linter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+// Suppression sources
+const (
+	SuppressionInline   = "inline"
+	SuppressionBaseline = "baseline"
+)
+
+// Inline ignore comment format:
+// # ds-lint ignore=ID.20006,ORG.0001 reason=free form text
+// (applies to the next instruction; 'ignore-file' applies to the whole Dockerfile)
+const (
+	inlineDirective    = "ds-lint"
+	inlineIgnore       = "ignore"
+	inlineIgnoreFile   = "ignore-file"
+	inlineReason       = "reason"
+	inlineIgnoreAllIDs = "all"
+)
+
+type inlineRule struct {
+	ids    map[string]struct{}
+	reason string
+}
+
+func (r *inlineRule) has(id string) bool {
+	if r == nil {
+		return false
+	}
+
+	if _, ok := r.ids[inlineIgnoreAllIDs]; ok {
+		return true
+	}
+
+	_, ok := r.ids[id]
+	return ok
+}
+
+func (r *inlineRule) merge(other *inlineRule) *inlineRule {
+	if r == nil {
+		return other
+	}
+
+	for id := range other.ids {
+		r.ids[id] = struct{}{}
+	}
+
+	if r.reason == "" {
+		r.reason = other.reason
+	}
+
+	return r
+}
+
+// parseInlineIgnores returns the inline ignore rules
+// by instruction start line and the Dockerfile level ignore rules
+func parseInlineIgnores(lines []string) (map[int]*inlineRule, *inlineRule) {
+	byLine := map[int]*inlineRule{}
+	var fileRule *inlineRule
+
+	for idx, line := range lines {
+		lineRule, fileLevelRule := parseInlineComment(line)
+		if fileLevelRule != nil {
+			fileRule = fileRule.merge(fileLevelRule)
+		}
+
+		if lineRule == nil {
+			continue
+		}
+
+		//the target is the next line that is not empty or a comment
+		for next := idx + 1; next < len(lines); next++ {
+			data := strings.TrimSpace(lines[next])
+			if data == "" || strings.HasPrefix(data, "#") {
+				continue
+			}
+
+			//1-based line numbers (same as the instruction lines)
+			byLine[next+1] = byLine[next+1].merge(lineRule)
+			break
+		}
+	}
+
+	return byLine, fileRule
+}
+
+func parseInlineComment(line string) (lineRule *inlineRule, fileRule *inlineRule) {
+	data := strings.TrimSpace(line)
+	if !strings.HasPrefix(data, "#") {
+		return nil, nil
+	}
+
+	data = strings.TrimSpace(strings.TrimPrefix(data, "#"))
+	if !strings.HasPrefix(data, inlineDirective+" ") {
+		return nil, nil
+	}
+
+	data = strings.TrimSpace(strings.TrimPrefix(data, inlineDirective))
+
+	var reason string
+	if idx := strings.Index(data, inlineReason+"="); idx > -1 {
+		reason = strings.Trim(strings.TrimSpace(data[idx+len(inlineReason)+1:]), `"'`)
+		data = data[:idx]
+	}
+
+	for _, field := range strings.Fields(data) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		rule := &inlineRule{
+			ids:    map[string]struct{}{},
+			reason: reason,
+		}
+
+		for _, id := range strings.Split(parts[1], ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				rule.ids[id] = struct{}{}
+			}
+		}
+
+		if len(rule.ids) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case inlineIgnore:
+			lineRule = lineRule.merge(rule)
+		case inlineIgnoreFile:
+			fileRule = fileRule.merge(rule)
+		}
+	}
+
+	return lineRule, fileRule
+}
+
+func matchLine(m *check.Match) int {
+	switch {
+	case m.Instruction != nil:
+		return m.Instruction.StartLine
+	case m.Stage != nil:
+		return m.Stage.StartLine
+	}
+
+	return 0
+}
+
+// ApplyInlineIgnores suppresses the check hits ignored
+// with the inline Dockerfile comments ('# ds-lint ignore=CHECK_ID reason=...')
+func ApplyInlineIgnores(report *Report) {
+	if report == nil || report.Dockerfile == nil {
+		return
+	}
+
+	byLine, fileRule := parseInlineIgnores(report.Dockerfile.Lines)
+	if len(byLine) == 0 && fileRule == nil {
+		return
+	}
+
+	report.suppress(SuppressionInline, func(id string, m *check.Match) (bool, string) {
+		if fileRule.has(id) {
+			return true, fileRule.reason
+		}
+
+		if m == nil {
+			return false, ""
+		}
+
+		if rule := byLine[matchLine(m)]; rule.has(id) {
+			return true, rule.reason
+		}
+
+		return false, ""
+	})
+}
+
+// suppress removes the check hit matches selected by the filter
+// (the hits without any remaining matches are removed too)
+func (r *Report) suppress(source string, filter func(id string, m *check.Match) (bool, string)) {
+	var ids []string
+	for id := range r.Hits {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		result := r.Hits[id]
+		if len(result.Matches) == 0 {
+			if ok, reason := filter(id, nil); ok {
+				r.Suppressed = append(r.Suppressed, &check.Suppression{
+					CheckID: id,
+					Source:  source,
+					Reason:  reason,
+				})
+
+				delete(r.Hits, id)
+			}
+
+			continue
+		}
+
+		var matches []*check.Match
+		for _, m := range result.Matches {
+			if ok, reason := filter(id, m); ok {
+				r.Suppressed = append(r.Suppressed, &check.Suppression{
+					CheckID: id,
+					Source:  source,
+					Reason:  reason,
+					Match:   m,
+				})
+
+				continue
+			}
+
+			matches = append(matches, m)
+		}
+
+		if len(matches) == 0 {
+			delete(r.Hits, id)
+			continue
+		}
+
+		result.Matches = matches
+	}
+}
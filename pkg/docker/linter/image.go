@@ -0,0 +1,39 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/parser"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+)
+
+const reverseFromLine = "FROM scratch"
+
+// ImageDockerfile creates a Dockerfile from the reverse engineered image instructions
+// (all image stack instructions are included, so the checks see the final image state;
+// the nearest tagged base image, if it's known, is used instead of 'scratch' for the FROM instruction)
+func ImageDockerfile(info *reverse.Dockerfile, imageRef string) (*spec.Dockerfile, error) {
+	lines := append([]string{}, info.Lines...)
+	if len(lines) == 0 || lines[0] != reverseFromLine {
+		lines = append([]string{reverseFromLine}, lines...)
+	}
+
+	if baseImage := ImageBase(info); baseImage != "" {
+		lines[0] = "FROM " + baseImage
+	}
+
+	return parser.FromString(strings.Join(lines, "\n")+"\n", imageRef)
+}
+
+// ImageBase returns the name of the nearest tagged base image in the image stack
+func ImageBase(info *reverse.Dockerfile) string {
+	//the last image in the stack is the target image
+	for idx := len(info.ImageStack) - 2; idx >= 0; idx-- {
+		if name := info.ImageStack[idx].FullName; name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
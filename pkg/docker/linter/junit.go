@@ -0,0 +1,132 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+	"github.com/docker-slim/docker-slim/pkg/junit"
+)
+
+// JUnitReport creates a JUnit XML report with the lint results
+// (one test suite for each Dockerfile and one test case for each selected check:
+// the check hits are failures, the check errors are errors and the suppressed check hits are skipped)
+func JUnitReport(reports []*FileReport) *junit.TestSuites {
+	var suites []*junit.TestSuite
+	for _, fr := range reports {
+		suite := &junit.TestSuite{Name: fr.DockerfilePath}
+		suites = append(suites, suite)
+
+		if fr.Error != nil || fr.Report == nil {
+			message := "no lint report"
+			if fr.Error != nil {
+				message = fr.Error.Error()
+			}
+
+			suite.Add(&junit.TestCase{
+				Name:      "lint",
+				ClassName: fr.DockerfilePath,
+				Error:     &junit.Result{Message: message},
+			})
+
+			continue
+		}
+
+		suppressed := map[string]*check.Suppression{}
+		for _, s := range fr.Report.Suppressed {
+			suppressed[s.CheckID] = s
+		}
+
+		ids := map[string]struct{}{}
+		for id := range fr.Report.Hits {
+			ids[id] = struct{}{}
+		}
+
+		for id := range fr.Report.NoHits {
+			ids[id] = struct{}{}
+		}
+
+		for id := range fr.Report.Errors {
+			ids[id] = struct{}{}
+		}
+
+		for id := range suppressed {
+			ids[id] = struct{}{}
+		}
+
+		var sortedIDs []string
+		for id := range ids {
+			sortedIDs = append(sortedIDs, id)
+		}
+
+		sort.Strings(sortedIDs)
+		for _, id := range sortedIDs {
+			tc := &junit.TestCase{
+				Name:      junitCaseName(fr.Report, id),
+				ClassName: fr.DockerfilePath,
+			}
+
+			if err, ok := fr.Report.Errors[id]; ok {
+				tc.Error = &junit.Result{Message: err.Error()}
+			} else if result, ok := fr.Report.Hits[id]; ok {
+				tc.Failure = junitFailure(result)
+			} else if _, ok := fr.Report.NoHits[id]; !ok {
+				//all check hit matches are suppressed
+				s := suppressed[id]
+				message := fmt.Sprintf("suppressed (%s)", s.Source)
+				if s.Reason != "" {
+					message = fmt.Sprintf("%s - %s", message, s.Reason)
+				}
+
+				tc.Skipped = &junit.Skipped{Message: message}
+			}
+
+			suite.Add(tc)
+		}
+	}
+
+	return junit.NewReport("docker-slim-lint", suites)
+}
+
+func junitCaseName(report *Report, id string) string {
+	var info *check.Info
+	if result, ok := report.Hits[id]; ok && result.Source != nil {
+		info = result.Source
+	} else if result, ok := report.NoHits[id]; ok && result.Source != nil {
+		info = result.Source
+	} else if report.Registry != nil {
+		if c, ok := report.Registry.Get(id); ok {
+			info = c.Get()
+		}
+	}
+
+	if info == nil || info.Name == "" {
+		return id
+	}
+
+	return fmt.Sprintf("%s: %s", id, info.Name)
+}
+
+func junitFailure(result *check.Result) *junit.Result {
+	failure := &junit.Result{
+		Message: resultMessage(result, nil),
+	}
+
+	if result.Source != nil {
+		failure.Type = result.Source.Labels[check.LabelLevel]
+	}
+
+	var lines []string
+	for _, m := range result.Matches {
+		line := m.Message
+		if start := matchLine(m); start > 0 {
+			line = fmt.Sprintf("line %d: %s", start, line)
+		}
+
+		lines = append(lines, line)
+	}
+
+	failure.Details = strings.Join(lines, "\n")
+	return failure
+}
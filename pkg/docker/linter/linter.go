@@ -28,14 +28,18 @@ const (
 //* support linting from string
 
 type Options struct {
-	DockerfilePath   string
-	Dockerfile       *spec.Dockerfile
-	SkipBuildContext bool
-	BuildContextDir  string
-	SkipDockerignore bool //to disable .dockerignore parsing
-	Dockerignore     *dockerignore.Matcher
-	Selector         CheckSelector
-	Config           map[string]*check.Options
+	DockerfilePath    string
+	Dockerfile        *spec.Dockerfile
+	SkipBuildContext  bool
+	BuildContextDir   string
+	SkipDockerignore  bool //to disable .dockerignore parsing
+	Dockerignore      *dockerignore.Matcher
+	Selector          CheckSelector
+	Config            map[string]*check.Options
+	Registry          *check.Registry //the default check registry is used if it's nil
+	SkipInlineIgnores bool            //to disable the '# ds-lint ignore=...' comments
+	Baseline          *Baseline
+	Runtime           *check.RuntimeInfo //runtime data for the runtime-informed checks
 }
 
 type CheckContext struct {
@@ -69,6 +73,8 @@ type Report struct {
 	Hits            map[string]*check.Result
 	NoHits          map[string]*check.Result
 	Errors          map[string]error
+	Suppressed      []*check.Suppression
+	Registry        *check.Registry
 }
 
 func NewReport() *Report {
@@ -127,8 +133,15 @@ func Execute(options Options) (*Report, error) {
 	report.Dockerfile = df
 	report.Dockerignore = di
 
+	registry := options.Registry
+	if registry == nil {
+		registry = check.DefaultRegistry
+	}
+
+	report.Registry = registry
+
 	var selectedChecks []check.Runner
-	for _, check := range check.AllChecks {
+	for _, check := range registry.Checks() {
 		info := check.Get()
 
 		if len(options.Selector.IncludeCheckIDs) > 0 {
@@ -187,6 +200,7 @@ func Execute(options Options) (*Report, error) {
 		Dockerfile:      df,
 		BuildContextDir: options.BuildContextDir,
 		Dockerignore:    di,
+		Runtime:         options.Runtime,
 	}
 
 	stateCh := make(chan *CheckState, len(selectedChecks))
@@ -248,12 +262,27 @@ done:
 		}
 	}
 
+	if !options.SkipInlineIgnores {
+		ApplyInlineIgnores(report)
+	}
+
+	ApplyBaseline(report, options.Baseline, options.DockerfilePath)
 	return report, nil
 }
 
 func ListChecks() []*check.Info {
+	return ListRegistryChecks(nil)
+}
+
+// ListRegistryChecks returns the info for the checks in the registry
+// (the default check registry is used if it's nil)
+func ListRegistryChecks(registry *check.Registry) []*check.Info {
+	if registry == nil {
+		registry = check.DefaultRegistry
+	}
+
 	var list []*check.Info
-	for _, check := range check.AllChecks {
+	for _, check := range registry.Checks() {
 		info := check.Get()
 		list = append(list, info)
 	}
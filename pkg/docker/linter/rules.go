@@ -0,0 +1,64 @@
+package linter
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+// RulesConfig is the user-defined lint rules config (YAML or JSON)
+type RulesConfig struct {
+	Rules []*check.CustomRule `json:"rules"`
+}
+
+// LoadRules loads the user-defined lint rules from the config file
+func LoadRules(filePath string) ([]check.Runner, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var config RulesConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	var checks []check.Runner
+	for _, rule := range config.Rules {
+		c, err := check.NewCustomCheck(rule)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+
+		checks = append(checks, c)
+	}
+
+	return checks, nil
+}
+
+// NewRegistry creates a check registry with the built-in checks
+// and the user-defined rules from the rules config files
+func NewRegistry(ruleFiles []string) (*check.Registry, error) {
+	registry := check.DefaultRegistry.Clone()
+	for _, filePath := range ruleFiles {
+		if filePath == "" {
+			continue
+		}
+
+		checks, err := LoadRules(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range checks {
+			if err := registry.Register(c); err != nil {
+				return nil, fmt.Errorf("%s: %w", filePath, err)
+			}
+		}
+	}
+
+	return registry, nil
+}
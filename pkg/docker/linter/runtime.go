@@ -0,0 +1,150 @@
+package linter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// runtimeReport includes the report fields used to load the runtime data
+// (from the 'build' or 'profile' command reports or from the container report)
+type runtimeReport struct {
+	//command report fields
+	ArtifactLocation    string                  `json:"artifact_location"`
+	ContainerReportName string                  `json:"container_report_name"`
+	HTTPProbe           *report.HTTPProbeReport `json:"http_probe"`
+	//container report fields
+	Monitors *report.MonitorReports `json:"monitors"`
+	Network  *report.NetworkReport  `json:"network"`
+}
+
+// LoadRuntimeInfo loads the runtime data for the runtime-informed checks
+// from the 'build' or 'profile' command report file (with its container report)
+// or from the container report file (creport.json; no HTTP probe data)
+func LoadRuntimeInfo(filePath string) (*check.RuntimeInfo, error) {
+	var cmdReport runtimeReport
+	if err := loadReport(filePath, &cmdReport); err != nil {
+		return nil, err
+	}
+
+	creport := &cmdReport
+	if cmdReport.Monitors == nil {
+		if cmdReport.ContainerReportName == "" {
+			return nil, fmt.Errorf("%s: no container report info", filePath)
+		}
+
+		//the artifact directory might be moved with the command report
+		creportPath := filepath.Join(cmdReport.ArtifactLocation, cmdReport.ContainerReportName)
+		if _, err := os.Stat(creportPath); err != nil {
+			creportPath = filepath.Join(filepath.Dir(filePath), cmdReport.ContainerReportName)
+		}
+
+		creport = &runtimeReport{}
+		if err := loadReport(creportPath, creport); err != nil {
+			return nil, err
+		}
+	}
+
+	info := &check.RuntimeInfo{}
+	if creport.Monitors != nil &&
+		creport.Monitors.Fan != nil &&
+		creport.Monitors.Fan.MainProcess != nil {
+		info.UID = creport.Monitors.Fan.MainProcess.UID
+	}
+
+	if creport.Network != nil {
+		info.ListenPorts = externalListenPorts(creport.Network.ListenPorts)
+	}
+
+	if cmdReport.HTTPProbe != nil {
+		info.HTTPEndpoints = okHTTPEndpoints(cmdReport.HTTPProbe.EndpointStats)
+	}
+
+	return info, nil
+}
+
+func loadReport(filePath string, out *runtimeReport) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	return nil
+}
+
+// externalListenPorts returns the unique sorted ports
+// that are not bound to the loopback addresses only
+func externalListenPorts(list []*report.ListenPortInfo) []int {
+	unique := map[int]struct{}{}
+	for _, info := range list {
+		if ip := net.ParseIP(info.Address); ip != nil && ip.IsLoopback() {
+			continue
+		}
+
+		unique[info.Port] = struct{}{}
+	}
+
+	var ports []int
+	for port := range unique {
+		ports = append(ports, port)
+	}
+
+	sort.Ints(ports)
+	return ports
+}
+
+// okHTTPEndpoints returns the probed HTTP endpoints with 2xx responses
+// (the endpoint host is the mapped host port, so only the paths are kept)
+func okHTTPEndpoints(stats []*report.HTTPProbeEndpointStats) []*check.RuntimeHTTPEndpoint {
+	var endpoints []*check.RuntimeHTTPEndpoint
+	seen := map[string]struct{}{}
+	for _, info := range stats {
+		ok := false
+		for status, count := range info.StatusCodes {
+			if strings.HasPrefix(status, "2") && count > 0 {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			continue
+		}
+
+		epURL, err := url.Parse(info.Endpoint)
+		if err != nil || !strings.HasPrefix(epURL.Scheme, "http") {
+			continue
+		}
+
+		path := epURL.Path
+		if path == "" {
+			path = "/"
+		}
+
+		key := info.Method + " " + path
+		if _, found := seen[key]; found {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		endpoints = append(endpoints, &check.RuntimeHTTPEndpoint{
+			Method: info.Method,
+			Path:   path,
+		})
+	}
+
+	return endpoints
+}
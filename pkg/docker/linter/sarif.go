@@ -0,0 +1,317 @@
+package linter
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+// SARIF log format info
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SARIF result levels
+const (
+	sarifLevelError   = "error"
+	sarifLevelWarning = "warning"
+	sarifLevelNote    = "note"
+)
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool      `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	Version        string       `json:"version,omitempty"`
+	InformationURI string       `json:"informationUri,omitempty"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *sarifMessage          `json:"shortDescription,omitempty"`
+	FullDescription      *sarifMessage          `json:"fullDescription,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration *sarifRuleConfig       `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	RuleIndex int              `json:"ruleIndex"`
+	Level     string           `json:"level"`
+	Message   sarifMessage     `json:"message"`
+	Locations []*sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int           `json:"startLine"`
+	EndLine   int           `json:"endLine,omitempty"`
+	Snippet   *sarifMessage `json:"snippet,omitempty"`
+}
+
+// SARIFLevel maps the check level label to the SARIF result level
+func SARIFLevel(level string) string {
+	switch level {
+	case check.LevelFatal, check.LevelError:
+		return sarifLevelError
+	case check.LevelWarn:
+		return sarifLevelWarning
+	default:
+		return sarifLevelNote
+	}
+}
+
+// WriteSARIF writes the lint results in the SARIF 2.1.0 format
+// (all checks in the report registry are included as the rules; each check hit match is a separate result)
+func WriteSARIF(w io.Writer, report *Report, dockerfilePath string, toolVersion string) error {
+	return WriteSARIFFiles(w,
+		[]*FileReport{{DockerfilePath: dockerfilePath, Report: report}},
+		toolVersion)
+}
+
+// WriteSARIFFiles writes the lint results for multiple Dockerfiles in the SARIF 2.1.0 format
+// (one SARIF run with the results for all Dockerfiles)
+func WriteSARIFFiles(w io.Writer, reports []*FileReport, toolVersion string) error {
+	run := &sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "docker-slim-lint",
+				Version:        toolVersion,
+				InformationURI: "https://github.com/docker-slim/docker-slim",
+			},
+		},
+		Results: []*sarifResult{},
+	}
+
+	var registry *check.Registry
+	for _, fr := range reports {
+		if fr.Report != nil && fr.Report.Registry != nil {
+			registry = fr.Report.Registry
+			break
+		}
+	}
+
+	ruleIndexes := map[string]int{}
+	for _, info := range ListRegistryChecks(registry) {
+		ruleIndexes[info.ID] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFromCheck(info))
+	}
+
+	for _, fr := range reports {
+		if fr.Report == nil {
+			continue
+		}
+
+		var ids []string
+		for id := range fr.Report.Hits {
+			ids = append(ids, id)
+		}
+
+		sort.Strings(ids)
+
+		artifact := sarifArtifact(fr.DockerfilePath)
+		for _, id := range ids {
+			result := fr.Report.Hits[id]
+			if result == nil || result.Source == nil {
+				continue
+			}
+
+			ruleIndex, found := ruleIndexes[id]
+			if !found {
+				ruleIndex = len(run.Tool.Driver.Rules)
+				ruleIndexes[id] = ruleIndex
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFromCheck(result.Source))
+			}
+
+			level := SARIFLevel(result.Source.Labels[check.LabelLevel])
+			if len(result.Matches) == 0 {
+				run.Results = append(run.Results, &sarifResult{
+					RuleID:    id,
+					RuleIndex: ruleIndex,
+					Level:     level,
+					Message:   sarifMessage{Text: resultMessage(result, nil)},
+					Locations: []*sarifLocation{
+						{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact}},
+					},
+				})
+
+				continue
+			}
+
+			for _, m := range result.Matches {
+				location := &sarifLocation{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: artifact,
+						Region:           matchRegion(m),
+					},
+				}
+
+				run.Results = append(run.Results, &sarifResult{
+					RuleID:    id,
+					RuleIndex: ruleIndex,
+					Level:     level,
+					Message:   sarifMessage{Text: resultMessage(result, m)},
+					Locations: []*sarifLocation{location},
+				})
+			}
+		}
+	}
+
+	log := sarifLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs:    []*sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func sarifRuleFromCheck(info *check.Info) *sarifRule {
+	rule := &sarifRule{
+		ID:      info.ID,
+		Name:    ruleName(info.Name),
+		HelpURI: info.DetailsURL,
+		DefaultConfiguration: &sarifRuleConfig{
+			Level: SARIFLevel(info.Labels[check.LabelLevel]),
+		},
+	}
+
+	if info.Name != "" {
+		rule.ShortDescription = &sarifMessage{Text: info.Name}
+	}
+
+	if info.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: info.Description}
+	}
+
+	if len(info.Labels) > 0 {
+		var tags []string
+		for k, v := range info.Labels {
+			tags = append(tags, k+":"+v)
+		}
+
+		sort.Strings(tags)
+		rule.Properties = map[string]interface{}{"tags": tags}
+	}
+
+	return rule
+}
+
+// ruleName creates a PascalCase rule name from the check name
+func ruleName(name string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		sb.WriteString(strings.ToUpper(word[:1]))
+		sb.WriteString(word[1:])
+	}
+
+	return sb.String()
+}
+
+func resultMessage(result *check.Result, m *check.Match) string {
+	message := result.Message
+	if message == "" {
+		message = result.Source.Name
+	}
+
+	if m != nil && m.Message != "" {
+		message = message + ": " + m.Message
+	}
+
+	return message
+}
+
+func matchRegion(m *check.Match) *sarifRegion {
+	switch {
+	case m.Instruction != nil && m.Instruction.StartLine > 0:
+		region := &sarifRegion{
+			StartLine: m.Instruction.StartLine,
+			EndLine:   m.Instruction.EndLine,
+		}
+
+		if region.EndLine < region.StartLine {
+			region.EndLine = region.StartLine
+		}
+
+		if len(m.Instruction.RawLines) > 0 {
+			region.Snippet = &sarifMessage{Text: strings.Join(m.Instruction.RawLines, "\n")}
+		}
+
+		return region
+	case m.Stage != nil && m.Stage.StartLine > 0:
+		region := &sarifRegion{
+			StartLine: m.Stage.StartLine,
+			EndLine:   m.Stage.EndLine,
+		}
+
+		if region.EndLine < region.StartLine {
+			region.EndLine = region.StartLine
+		}
+
+		return region
+	}
+
+	return nil
+}
+
+// sarifArtifact returns the Dockerfile artifact location
+// (the relative paths are relative to the source root, which is where the scanners usually run)
+func sarifArtifact(dockerfilePath string) sarifArtifactLocation {
+	if filepath.IsAbs(dockerfilePath) {
+		return sarifArtifactLocation{
+			URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(dockerfilePath)}).String(),
+		}
+	}
+
+	return sarifArtifactLocation{
+		URI:       (&url.URL{Path: filepath.ToSlash(filepath.Clean(dockerfilePath))}).String(),
+		URIBaseID: "%SRCROOT%",
+	}
+}
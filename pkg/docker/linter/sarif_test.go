@@ -0,0 +1,329 @@
+package linter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+type testCheck struct {
+	*check.Info
+}
+
+func (c *testCheck) Run(opts *check.Options, ctx *check.Context) (*check.Result, error) {
+	return &check.Result{Source: c.Info}, nil
+}
+
+var (
+	testWarnCheck = &check.Info{
+		ID:          "ID.90001",
+		Name:        "Missing HEALTHCHECK instruction",
+		Description: "The image has no health check.",
+		DetailsURL:  "https://example.com/90001",
+		Labels: map[string]string{
+			check.LabelLevel: check.LevelWarn,
+			check.LabelScope: check.ScopeStage,
+		},
+	}
+
+	testErrorCheck = &check.Info{
+		ID:   "ID.90002",
+		Name: "apt-get upgrade/dist-upgrade",
+		Labels: map[string]string{
+			check.LabelLevel: check.LevelFatal,
+		},
+	}
+
+	testUnregisteredCheck = &check.Info{
+		ID:   "ID.90003",
+		Name: "custom check",
+	}
+)
+
+func newTestSARIFReport(t *testing.T) *Report {
+	registry := check.NewRegistry()
+	for _, info := range []*check.Info{testWarnCheck, testErrorCheck} {
+		if err := registry.Register(&testCheck{Info: info}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := NewReport()
+	report.Registry = registry
+	report.Hits[testErrorCheck.ID] = &check.Result{
+		Source:  testErrorCheck,
+		Hit:     true,
+		Message: "Do not upgrade the packages",
+		Matches: []*check.Match{
+			{
+				Instruction: &instruction.Field{
+					RawLines:  []string{"RUN apt-get update && \\", "    apt-get upgrade"},
+					StartLine: 3,
+					EndLine:   4,
+				},
+				Message: "apt-get upgrade",
+			},
+			{
+				Stage: &spec.BuildStage{StartLine: 1, EndLine: 5},
+			},
+		},
+	}
+	report.Hits[testWarnCheck.ID] = &check.Result{
+		Source: testWarnCheck,
+		Hit:    true,
+	}
+	report.Hits[testUnregisteredCheck.ID] = &check.Result{
+		Source: testUnregisteredCheck,
+		Hit:    true,
+		Matches: []*check.Match{
+			//no line info
+			{Message: "no lines"},
+		},
+	}
+
+	return report
+}
+
+func decodeSARIF(t *testing.T, data []byte) *sarifLog {
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid SARIF output - %v", err)
+	}
+
+	if log.Version != SARIFVersion || log.Schema != SARIFSchema {
+		t.Errorf("version/schema - got %s/%s", log.Version, log.Schema)
+	}
+
+	if len(log.Runs) != 1 {
+		t.Fatalf("runs - got %d expected 1", len(log.Runs))
+	}
+
+	return &log
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSARIF(&out, newTestSARIFReport(t), "app/Dockerfile", "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+
+	run := decodeSARIF(t, out.Bytes()).Runs[0]
+	if run.Tool.Driver.Name != "docker-slim-lint" || run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("driver - got %s/%s", run.Tool.Driver.Name, run.Tool.Driver.Version)
+	}
+
+	//the registry checks are the rules (in the registration order)
+	//and the hits for the unknown checks are added at the end
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 3 {
+		t.Fatalf("rules - got %d expected 3", len(rules))
+	}
+
+	expectedRule := &sarifRule{
+		ID:                   testWarnCheck.ID,
+		Name:                 "MissingHEALTHCHECKInstruction",
+		ShortDescription:     &sarifMessage{Text: testWarnCheck.Name},
+		FullDescription:      &sarifMessage{Text: testWarnCheck.Description},
+		HelpURI:              testWarnCheck.DetailsURL,
+		DefaultConfiguration: &sarifRuleConfig{Level: sarifLevelWarning},
+		Properties:           map[string]interface{}{"tags": []interface{}{"level:warn", "scope:stage"}},
+	}
+
+	if !reflect.DeepEqual(rules[0], expectedRule) {
+		t.Errorf("rule - got %+v expected %+v", rules[0], expectedRule)
+	}
+
+	for idx, id := range []string{testWarnCheck.ID, testErrorCheck.ID, testUnregisteredCheck.ID} {
+		if rules[idx].ID != id {
+			t.Errorf("rules[%d] - got %s expected %s", idx, rules[idx].ID, id)
+		}
+	}
+
+	if rules[1].DefaultConfiguration.Level != sarifLevelError ||
+		rules[2].DefaultConfiguration.Level != sarifLevelNote {
+		t.Errorf("rule levels - got %s/%s", rules[1].DefaultConfiguration.Level, rules[2].DefaultConfiguration.Level)
+	}
+
+	//one result for each match (the hits are sorted by check ID)
+	expectedResults := []*sarifResult{
+		{
+			RuleID:    testWarnCheck.ID,
+			RuleIndex: 0,
+			Level:     sarifLevelWarning,
+			Message:   sarifMessage{Text: testWarnCheck.Name},
+			Locations: []*sarifLocation{
+				{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "app/Dockerfile", URIBaseID: "%SRCROOT%"},
+				}},
+			},
+		},
+		{
+			RuleID:    testErrorCheck.ID,
+			RuleIndex: 1,
+			Level:     sarifLevelError,
+			Message:   sarifMessage{Text: "Do not upgrade the packages: apt-get upgrade"},
+			Locations: []*sarifLocation{
+				{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "app/Dockerfile", URIBaseID: "%SRCROOT%"},
+					Region: &sarifRegion{
+						StartLine: 3,
+						EndLine:   4,
+						Snippet:   &sarifMessage{Text: "RUN apt-get update && \\\n    apt-get upgrade"},
+					},
+				}},
+			},
+		},
+		{
+			RuleID:    testErrorCheck.ID,
+			RuleIndex: 1,
+			Level:     sarifLevelError,
+			Message:   sarifMessage{Text: "Do not upgrade the packages"},
+			Locations: []*sarifLocation{
+				{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "app/Dockerfile", URIBaseID: "%SRCROOT%"},
+					Region:           &sarifRegion{StartLine: 1, EndLine: 5},
+				}},
+			},
+		},
+		{
+			RuleID:    testUnregisteredCheck.ID,
+			RuleIndex: 2,
+			Level:     sarifLevelNote,
+			Message:   sarifMessage{Text: "custom check: no lines"},
+			Locations: []*sarifLocation{
+				{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "app/Dockerfile", URIBaseID: "%SRCROOT%"},
+				}},
+			},
+		},
+	}
+
+	if len(run.Results) != len(expectedResults) {
+		t.Fatalf("results - got %d expected %d", len(run.Results), len(expectedResults))
+	}
+
+	for idx, expected := range expectedResults {
+		if !reflect.DeepEqual(run.Results[idx], expected) {
+			t.Errorf("results[%d] - got %+v expected %+v", idx, run.Results[idx], expected)
+		}
+	}
+}
+
+func TestWriteSARIFFiles(t *testing.T) {
+	reports := []*FileReport{
+		{DockerfilePath: "/src/Dockerfile", Report: newTestSARIFReport(t)},
+		//the failed files have no report
+		{DockerfilePath: "bad/Dockerfile"},
+		{DockerfilePath: "./svc/../svc/Dockerfile", Report: newTestSARIFReport(t)},
+	}
+
+	var out bytes.Buffer
+	if err := WriteSARIFFiles(&out, reports, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	run := decodeSARIF(t, out.Bytes()).Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 {
+		t.Errorf("rules - got %d expected 3", len(run.Tool.Driver.Rules))
+	}
+
+	if len(run.Results) != 8 {
+		t.Fatalf("results - got %d expected 8", len(run.Results))
+	}
+
+	expectedURIs := map[int]sarifArtifactLocation{
+		0: {URI: "file:///src/Dockerfile"},
+		4: {URI: "svc/Dockerfile", URIBaseID: "%SRCROOT%"},
+	}
+
+	for idx, expected := range expectedURIs {
+		if actual := run.Results[idx].Locations[0].PhysicalLocation.ArtifactLocation; actual != expected {
+			t.Errorf("results[%d] artifact - got %+v expected %+v", idx, actual, expected)
+		}
+	}
+}
+
+func TestWriteSARIFNoHits(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSARIF(&out, NewReport(), "Dockerfile", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	//the results must be an empty array (not null) for the SARIF consumers
+	var raw struct {
+		Runs []struct {
+			Results json.RawMessage `json:"results"`
+		} `json:"runs"`
+	}
+
+	if err := json.Unmarshal(out.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(raw.Runs) != 1 || string(raw.Runs[0].Results) != "[]" {
+		t.Errorf("unexpected results - %s", out.String())
+	}
+
+	//the built-in checks are the rules when there's no report registry
+	run := decodeSARIF(t, out.Bytes()).Runs[0]
+	if len(run.Tool.Driver.Rules) != len(check.DefaultRegistry.Checks()) {
+		t.Errorf("rules - got %d expected %d", len(run.Tool.Driver.Rules), len(check.DefaultRegistry.Checks()))
+	}
+}
+
+func TestSARIFLevel(t *testing.T) {
+	tt := map[string]string{
+		check.LevelFatal: sarifLevelError,
+		check.LevelError: sarifLevelError,
+		check.LevelWarn:  sarifLevelWarning,
+		check.LevelInfo:  sarifLevelNote,
+		check.LevelStyle: sarifLevelNote,
+		"":               sarifLevelNote,
+	}
+
+	for level, expected := range tt {
+		if actual := SARIFLevel(level); actual != expected {
+			t.Errorf("SARIFLevel(%s) - got '%s' expected '%s'", level, actual, expected)
+		}
+	}
+}
+
+func TestRuleName(t *testing.T) {
+	tt := map[string]string{
+		"Missing HEALTHCHECK instruction": "MissingHEALTHCHECKInstruction",
+		"apt-get upgrade/dist-upgrade":    "AptGetUpgradeDistUpgrade",
+		"use COPY (not ADD)":              "UseCOPYNotADD",
+		"":                                "",
+	}
+
+	for name, expected := range tt {
+		if actual := ruleName(name); actual != expected {
+			t.Errorf("ruleName(%s) - got '%s' expected '%s'", name, actual, expected)
+		}
+	}
+}
+
+func TestMatchRegion(t *testing.T) {
+	//the end line is never before the start line
+	region := matchRegion(&check.Match{Instruction: &instruction.Field{StartLine: 7}})
+	if !reflect.DeepEqual(region, &sarifRegion{StartLine: 7, EndLine: 7}) {
+		t.Errorf("instruction region - got %+v", region)
+	}
+
+	region = matchRegion(&check.Match{
+		Instruction: &instruction.Field{},
+		Stage:       &spec.BuildStage{StartLine: 2},
+	})
+	if !reflect.DeepEqual(region, &sarifRegion{StartLine: 2, EndLine: 2}) {
+		t.Errorf("stage region - got %+v", region)
+	}
+
+	if region := matchRegion(&check.Match{}); region != nil {
+		t.Errorf("no lines region - got %+v", region)
+	}
+}
@@ -0,0 +1,54 @@
+package registryauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+
+	"github.com/docker-slim/docker-slim/pkg/cloudauth"
+)
+
+const (
+	//the registry username for the ACR refresh tokens
+	acrTokenUsername = "00000000-0000-0000-0000-000000000000"
+	acrScope         = "https://management.azure.com/.default"
+	acrExchangeURL   = "https://%s/oauth2/exchange"
+)
+
+// acrCredential gets the ACR registry credential:
+// the Azure AD access token is exchanged for the ACR refresh token
+func acrCredential(ctx context.Context, registry string) (*authn.AuthConfig, time.Time, error) {
+	aadToken, err := cloudauth.AzureAccessToken(ctx, acrScope)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"access_token": {aadToken.AccessToken},
+	}
+
+	if tenantID := os.Getenv("AZURE_TENANT_ID"); tenantID != "" {
+		form.Set("tenant", tenantID)
+	}
+
+	token, err := cloudauth.PostTokenForm(ctx, fmt.Sprintf(acrExchangeURL, registry), form)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if token.RefreshToken == "" {
+		return nil, time.Time{}, fmt.Errorf("%w: no ACR refresh token", ErrTokenRequest)
+	}
+
+	//the ACR refresh token is valid longer than the AAD token it's created from
+	return &authn.AuthConfig{
+		Username: acrTokenUsername,
+		Password: token.RefreshToken,
+	}, aadToken.Expires, nil
+}
@@ -0,0 +1,84 @@
+package registryauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+
+	"github.com/docker-slim/docker-slim/pkg/cloudauth"
+)
+
+const (
+	ecrService     = "ecr"
+	ecrTarget      = "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken"
+	ecrContentType = "application/x-amz-json-1.1"
+)
+
+// ecrCredential gets the ECR registry credential with the GetAuthorizationToken API call
+func ecrCredential(ctx context.Context, registry string) (*authn.AuthConfig, time.Time, error) {
+	match := ecrHostPattern.FindStringSubmatch(strings.ToLower(registry))
+	if match == nil {
+		return nil, time.Time{}, fmt.Errorf("not an ECR registry - %s", registry)
+	}
+
+	accountID, fips, region, cnSuffix := match[1], match[2], match[3], match[4]
+	creds, err := cloudauth.AWSDefaultCredentials(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	endpoint := fmt.Sprintf("https://api.ecr.%s.amazonaws.com%s/", region, cnSuffix)
+	if fips != "" {
+		endpoint = fmt.Sprintf("https://ecr-fips.%s.amazonaws.com/", region)
+	}
+
+	body := []byte(fmt.Sprintf(`{"registryIds":["%s"]}`, accountID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	req.Header.Set("Content-Type", ecrContentType)
+	req.Header.Set("X-Amz-Target", ecrTarget)
+	cloudauth.SignAWSRequest(req, creds, region, ecrService, cloudauth.SHA256Hex(body), time.Now())
+
+	var result struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+		} `json:"authorizationData"`
+	}
+
+	if err := cloudauth.GetJSON(nil, req, &result); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if len(result.AuthorizationData) == 0 {
+		return nil, time.Time{}, fmt.Errorf("%w: no ECR authorization data", ErrTokenRequest)
+	}
+
+	data := result.AuthorizationData[0]
+	token, err := base64.StdEncoding.DecodeString(data.AuthorizationToken)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	//the token is '<username>:<password>' (the username is always 'AWS')
+	parts := strings.SplitN(string(token), ":", 2)
+	if len(parts) != 2 {
+		return nil, time.Time{}, fmt.Errorf("%w: bad ECR authorization token", ErrTokenRequest)
+	}
+
+	expires := time.Unix(int64(data.ExpiresAt), 0)
+	if data.ExpiresAt == 0 {
+		expires = time.Now().Add(time.Hour)
+	}
+
+	return &authn.AuthConfig{Username: parts[0], Password: parts[1]}, expires, nil
+}
@@ -0,0 +1,30 @@
+package registryauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+
+	"github.com/docker-slim/docker-slim/pkg/cloudauth"
+)
+
+const (
+	//the registry username for the OAuth2 access tokens
+	gcrTokenUsername = "oauth2accesstoken"
+	gcrScope         = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// gcrCredential gets the GCR / Artifact Registry credential (the OAuth2 access token)
+// with the Application Default Credentials
+func gcrCredential(ctx context.Context) (*authn.AuthConfig, time.Time, error) {
+	token, err := cloudauth.GoogleAccessToken(ctx, gcrScope)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return &authn.AuthConfig{
+		Username: gcrTokenUsername,
+		Password: token.AccessToken,
+	}, token.Expires, nil
+}
@@ -0,0 +1,222 @@
+// Package registryauth resolves the container registry credentials:
+// the Docker config credentials (including the credential helpers and the credential stores)
+// and the native token flows for the cloud registries (Amazon ECR, Google Container Registry / Artifact Registry
+// and Azure Container Registry), so the registry operations work in the cloud CI environments without 'docker login'.
+package registryauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/config/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	log "github.com/sirupsen/logrus"
+)
+
+// Cloud registry providers
+const (
+	ProviderNone = ""
+	ProviderECR  = "ecr"
+	ProviderGCP  = "gcp"
+	ProviderACR  = "acr"
+)
+
+const (
+	//the cached credentials are refreshed before they expire
+	tokenExpiryMargin = 5 * time.Minute
+	dockerHubRegistry = "docker.io"
+)
+
+var ErrTokenRequest = errors.New("registry token request failed")
+
+var (
+	//<account_id>.dkr.ecr[-fips].<region>.amazonaws.com[.cn]
+	ecrHostPattern  = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+	acrHostSuffixes = []string{".azurecr.io", ".azurecr.cn", ".azurecr.de", ".azurecr.us"}
+)
+
+// Provider returns the cloud provider for the registry host (ProviderNone if it's not a cloud registry)
+func Provider(registry string) string {
+	host := strings.ToLower(registry)
+	switch {
+	case ecrHostPattern.MatchString(host):
+		return ProviderECR
+	case host == "gcr.io" || strings.HasSuffix(host, ".gcr.io") || strings.HasSuffix(host, "-docker.pkg.dev"):
+		return ProviderGCP
+	}
+
+	for _, suffix := range acrHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return ProviderACR
+		}
+	}
+
+	return ProviderNone
+}
+
+type cachedCredential struct {
+	auth    *authn.AuthConfig
+	expires time.Time
+}
+
+var credentialCache = struct {
+	sync.Mutex
+	entries map[string]*cachedCredential
+}{
+	entries: map[string]*cachedCredential{},
+}
+
+// CloudCredential returns the registry credential from the cloud provider token flow
+// (nil is returned if the registry is not a cloud registry)
+func CloudCredential(ctx context.Context, registry string) (*authn.AuthConfig, error) {
+	provider := Provider(registry)
+	if provider == ProviderNone {
+		return nil, nil
+	}
+
+	credentialCache.Lock()
+	defer credentialCache.Unlock()
+
+	if cached, found := credentialCache.entries[registry]; found &&
+		time.Now().Add(tokenExpiryMargin).Before(cached.expires) {
+		return cached.auth, nil
+	}
+
+	var (
+		auth    *authn.AuthConfig
+		expires time.Time
+		err     error
+	)
+
+	switch provider {
+	case ProviderECR:
+		auth, expires, err = ecrCredential(ctx, registry)
+	case ProviderGCP:
+		auth, expires, err = gcrCredential(ctx)
+	case ProviderACR:
+		auth, expires, err = acrCredential(ctx, registry)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", provider, err)
+	}
+
+	log.Debugf("registryauth.CloudCredential(%s): provider=%s expires=%v", registry, provider, expires)
+	credentialCache.entries[registry] = &cachedCredential{auth: auth, expires: expires}
+	return auth, nil
+}
+
+// ConfigCredential returns the registry credential from the Docker config
+// (the config file or the config directory; the credential helpers and
+// the credential stores configured in the config are used too).
+// Nil is returned if the config doesn't have a credential for the registry.
+func ConfigCredential(configPath, registry string) (*authn.AuthConfig, error) {
+	cf, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := registry
+	if key == name.DefaultRegistry || key == dockerHubRegistry {
+		key = authn.DefaultAuthKey
+	}
+
+	cfg, err := cf.GetAuthConfig(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg == (types.AuthConfig{}) {
+		return nil, nil
+	}
+
+	return &authn.AuthConfig{
+		Username:      cfg.Username,
+		Password:      cfg.Password,
+		Auth:          cfg.Auth,
+		IdentityToken: cfg.IdentityToken,
+		RegistryToken: cfg.RegistryToken,
+	}, nil
+}
+
+func loadConfig(configPath string) (*configfile.ConfigFile, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return config.Load(configPath)
+	}
+
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	cf := configfile.New(configPath)
+	if err := cf.LoadFromReader(file); err != nil {
+		return nil, err
+	}
+
+	return cf, nil
+}
+
+// Keychain returns the keychain with the Docker config credentials
+// (the default Docker config is used if the config path is empty)
+// and the cloud registry credentials (used if the Docker config doesn't have a credential for the registry)
+func Keychain(ctx context.Context, configPath string) authn.Keychain {
+	configKeychain := authn.DefaultKeychain
+	if configPath != "" {
+		configKeychain = &fileKeychain{configPath: configPath}
+	}
+
+	return authn.NewMultiKeychain(configKeychain, &cloudKeychain{ctx: ctx})
+}
+
+type fileKeychain struct {
+	configPath string
+}
+
+func (k *fileKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	auth, err := ConfigCredential(k.configPath, target.RegistryStr())
+	if err != nil {
+		return nil, err
+	}
+
+	if auth == nil {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(*auth), nil
+}
+
+type cloudKeychain struct {
+	ctx context.Context
+}
+
+func (k *cloudKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	auth, err := CloudCredential(k.ctx, target.RegistryStr())
+	if err != nil {
+		//the anonymous access still works for the public repositories
+		log.Warnf("registryauth: no cloud registry credential for '%s' - %v", target.RegistryStr(), err)
+		return authn.Anonymous, nil
+	}
+
+	if auth == nil {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(*auth), nil
+}
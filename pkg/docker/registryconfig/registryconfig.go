@@ -0,0 +1,325 @@
+// Package registryconfig has the registry network settings used for all registry interactions:
+// the registry mirrors (used for the pull operations), the custom CA bundles
+// and the HTTP(S) or SOCKS proxy (for the locked-down corporate networks).
+package registryconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	log "github.com/sirupsen/logrus"
+)
+
+// Supported proxy URL schemes
+const (
+	ProxySchemeHTTP   = "http"
+	ProxySchemeHTTPS  = "https"
+	ProxySchemeSOCKS5 = "socks5"
+)
+
+const (
+	mirrorRegistrySeparator = "="
+	insecureMirrorPrefix    = "http://"
+	secureMirrorPrefix      = "https://"
+)
+
+var (
+	ErrBadMirror = errors.New("bad registry mirror (expected '[<registry>=]<mirror>')")
+	ErrBadProxy  = errors.New("bad registry proxy (expected 'http://', 'https://' or 'socks5://' URL)")
+	ErrNoCACerts = errors.New("no CA certificates found")
+)
+
+// Mirror is a registry mirror (the mirror repositories can have a path prefix)
+type Mirror struct {
+	Host     string
+	Prefix   string
+	Insecure bool
+}
+
+func (m *Mirror) String() string {
+	value := m.Host
+	if m.Prefix != "" {
+		value = value + "/" + m.Prefix
+	}
+
+	if m.Insecure {
+		return insecureMirrorPrefix + value
+	}
+
+	return value
+}
+
+// Config is the registry network config
+type Config struct {
+	//mirrors by registry (the Docker Hub registry is 'index.docker.io')
+	Mirrors     map[string][]*Mirror
+	CACertFiles []string
+	Proxy       *url.URL
+	NoProxy     []string
+
+	rootCAs *x509.CertPool
+}
+
+// New creates the registry config from the mirror ('[<registry>=]<mirror>'; Docker Hub is the default registry),
+// CA bundle file, proxy URL and no-proxy host values
+func New(mirrors, caCertFiles []string, proxy string, noProxy []string) (*Config, error) {
+	config := &Config{
+		Mirrors:     map[string][]*Mirror{},
+		CACertFiles: caCertFiles,
+		NoProxy:     noProxy,
+	}
+
+	for _, value := range mirrors {
+		registry, mirror, err := parseMirror(value)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Mirrors[registry] = append(config.Mirrors[registry], mirror)
+	}
+
+	if len(caCertFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		for _, filePath := range caCertFiles {
+			data, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				return nil, err
+			}
+
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("%w: '%s'", ErrNoCACerts, filePath)
+			}
+		}
+
+		config.rootCAs = pool
+	}
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s' (%v)", ErrBadProxy, proxy, err)
+		}
+
+		switch proxyURL.Scheme {
+		case ProxySchemeHTTP, ProxySchemeHTTPS, ProxySchemeSOCKS5:
+		default:
+			return nil, fmt.Errorf("%w: '%s'", ErrBadProxy, proxy)
+		}
+
+		if proxyURL.Host == "" {
+			return nil, fmt.Errorf("%w: '%s'", ErrBadProxy, proxy)
+		}
+
+		config.Proxy = proxyURL
+	}
+
+	return config, nil
+}
+
+// parseMirror parses the '[<registry>=]<mirror>' value
+// (the mirror is a host or a URL with an optional repository path prefix)
+func parseMirror(value string) (string, *Mirror, error) {
+	registry := name.DefaultRegistry
+	mirrorValue := value
+	if parts := strings.SplitN(value, mirrorRegistrySeparator, 2); len(parts) == 2 {
+		registry = parts[0]
+		mirrorValue = parts[1]
+	}
+
+	var mirror Mirror
+	switch {
+	case strings.HasPrefix(mirrorValue, insecureMirrorPrefix):
+		mirror.Insecure = true
+		mirrorValue = strings.TrimPrefix(mirrorValue, insecureMirrorPrefix)
+	case strings.HasPrefix(mirrorValue, secureMirrorPrefix):
+		mirrorValue = strings.TrimPrefix(mirrorValue, secureMirrorPrefix)
+	}
+
+	mirrorValue = strings.Trim(mirrorValue, "/")
+	parts := strings.SplitN(mirrorValue, "/", 2)
+	mirror.Host = parts[0]
+	if len(parts) == 2 {
+		mirror.Prefix = parts[1]
+	}
+
+	if registry == "" || mirror.Host == "" {
+		return "", nil, fmt.Errorf("%w: '%s'", ErrBadMirror, value)
+	}
+
+	reg, err := name.NewRegistry(registry)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: '%s' (%v)", ErrBadMirror, value, err)
+	}
+
+	if _, err := name.NewRegistry(mirror.Host); err != nil {
+		return "", nil, fmt.Errorf("%w: '%s' (%v)", ErrBadMirror, value, err)
+	}
+
+	return reg.RegistryStr(), &mirror, nil
+}
+
+// Transport returns the registry transport with the CA bundles and the proxy
+// (the proxy env vars are used if the proxy is not configured)
+func (c *Config) Transport(insecure bool) *http.Transport {
+	tr := remote.DefaultTransport.Clone()
+	if c == nil {
+		if insecure {
+			tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+
+		return tr
+	}
+
+	tr.TLSClientConfig = &tls.Config{
+		RootCAs:            c.rootCAs,
+		InsecureSkipVerify: insecure,
+	}
+
+	if c.Proxy != nil {
+		tr.Proxy = c.proxy
+	}
+
+	return tr
+}
+
+func (c *Config) proxy(req *http.Request) (*url.URL, error) {
+	if c.isNoProxyHost(req.URL.Hostname()) {
+		return nil, nil
+	}
+
+	return c.Proxy, nil
+}
+
+// isNoProxyHost returns true if the host matches one of the no-proxy values
+// (the host name, the domain suffix ('.example.com' or 'example.com') or the IP network)
+func (c *Config) isNoProxyHost(host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, value := range c.NoProxy {
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch {
+		case value == "":
+			continue
+		case value == "*":
+			return true
+		case ip != nil:
+			if _, network, err := net.ParseCIDR(value); err == nil && network.Contains(ip) {
+				return true
+			}
+
+			if host == value {
+				return true
+			}
+		default:
+			domain := strings.TrimPrefix(value, ".")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// MirrorReferences returns the image references in the registry mirrors
+// (in the configured order; nil is returned if there are no mirrors for the image registry)
+func (c *Config) MirrorReferences(ref name.Reference) []name.Reference {
+	if c == nil {
+		return nil
+	}
+
+	var refs []name.Reference
+	for _, mirror := range c.Mirrors[ref.Context().RegistryStr()] {
+		repo := ref.Context().RepositoryStr()
+		if mirror.Prefix != "" {
+			repo = mirror.Prefix + "/" + repo
+		}
+
+		var opts []name.Option
+		if mirror.Insecure {
+			opts = append(opts, name.Insecure)
+		}
+
+		var (
+			mirrorRef name.Reference
+			err       error
+		)
+
+		switch r := ref.(type) {
+		case name.Digest:
+			mirrorRef, err = name.NewDigest(fmt.Sprintf("%s/%s@%s", mirror.Host, repo, r.DigestStr()), opts...)
+		default:
+			mirrorRef, err = name.NewTag(fmt.Sprintf("%s/%s:%s", mirror.Host, repo, ref.Identifier()), opts...)
+		}
+
+		if err != nil {
+			log.Debugf("registryconfig.MirrorReferences(%s): bad mirror reference (%s) - %v", ref, mirror, err)
+			continue
+		}
+
+		refs = append(refs, mirrorRef)
+	}
+
+	return refs
+}
+
+// ResolvePullReference returns the first mirror reference that has the image
+// (the original reference is returned if there are no mirrors for the registry or the mirrors don't have the image).
+// The options are used for the mirror requests, so they shouldn't have the original registry credentials.
+func (c *Config) ResolvePullReference(ref name.Reference, options ...remote.Option) name.Reference {
+	for _, mirrorRef := range c.MirrorReferences(ref) {
+		if _, err := remote.Head(mirrorRef, options...); err != nil {
+			log.Debugf("registryconfig.ResolvePullReference(%s): mirror '%s' - %v", ref, mirrorRef, err)
+			continue
+		}
+
+		log.Debugf("registryconfig.ResolvePullReference(%s): using mirror '%s'", ref, mirrorRef)
+		return mirrorRef
+	}
+
+	return ref
+}
+
+var defaultConfig = struct {
+	sync.RWMutex
+	config *Config
+}{}
+
+// SetDefault sets the registry config used by the registry operations
+func SetDefault(config *Config) {
+	defaultConfig.Lock()
+	defer defaultConfig.Unlock()
+	defaultConfig.config = config
+}
+
+// Default returns the registry config used by the registry operations
+// (nil is returned if it's not set, which is a valid config without the extra settings)
+func Default() *Config {
+	defaultConfig.RLock()
+	defer defaultConfig.RUnlock()
+	return defaultConfig.config
+}
+
+// Transport returns the registry transport with the default registry config
+func Transport(insecure bool) *http.Transport {
+	return Default().Transport(insecure)
+}
+
+// ResolvePullReference returns the pull reference with the default registry config mirrors
+func ResolvePullReference(ref name.Reference, options ...remote.Option) name.Reference {
+	return Default().ResolvePullReference(ref, options...)
+}
@@ -0,0 +1,250 @@
+package elfaudit
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	originVar    = "$ORIGIN"
+	originVarAlt = "${ORIGIN}"
+	maxDepDepth  = 32
+)
+
+// the default library directories for the glibc and musl dynamic linkers
+// (searched after the configured library directories)
+var defaultLibDirs = []string{
+	"/lib64",
+	"/usr/lib64",
+	"/lib",
+	"/usr/lib",
+	"/usr/local/lib",
+}
+
+// Report is the ELF dependency audit report
+type Report struct {
+	ExecutableCount int `json:"executable_count"`
+	LibraryCount    int `json:"library_count"`
+	StaticCount     int `json:"static_count"`
+	//executables that can't be started because of missing dependencies
+	BrokenExecutableCount int `json:"broken_executable_count"`
+	//libraries with missing direct dependencies
+	BrokenLibraryCount int           `json:"broken_library_count"`
+	LibraryDirs        []string      `json:"library_dirs,omitempty"`
+	Executables        []*ObjectDeps `json:"executables,omitempty"`
+	BrokenLibraries    []*ObjectDeps `json:"broken_libraries,omitempty"`
+}
+
+// ObjectDeps is the dependency info for a dynamic ELF object
+type ObjectDeps struct {
+	Path               string        `json:"path"`
+	Type               string        `json:"type"`
+	Interpreter        string        `json:"interpreter,omitempty"`
+	MissingInterpreter bool          `json:"missing_interpreter,omitempty"`
+	Libraries          []*Dependency `json:"libraries,omitempty"`
+	//missing libraries (for the executables they include the missing indirect dependencies)
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Dependency is a resolved library dependency
+type Dependency struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Broken returns true if the object has missing dependencies
+func (d *ObjectDeps) Broken() bool {
+	return d.MissingInterpreter || len(d.Missing) > 0
+}
+
+// Audit inventories the dynamic ELF objects in the filesystem tree
+// and resolves their library dependencies (the extra library directories are searched
+// before the configured and default library directories, like LD_LIBRARY_PATH)
+func Audit(tree *Tree, extraLibDirs []string) *Report {
+	r := &resolver{
+		tree:  tree,
+		cache: map[string]string{},
+	}
+
+	r.dirs = append(r.dirs, extraLibDirs...)
+	r.dirs = append(r.dirs, tree.libConfigDirs()...)
+	r.dirs = append(r.dirs, defaultLibDirs...)
+
+	report := &Report{
+		LibraryDirs: r.dirs,
+	}
+
+	var paths []string
+	for filePath := range tree.entries {
+		paths = append(paths, filePath)
+	}
+
+	sort.Strings(paths)
+	for _, filePath := range paths {
+		entry := tree.entries[filePath]
+		if entry.object == nil {
+			continue
+		}
+
+		if entry.object.Static {
+			report.StaticCount++
+			continue
+		}
+
+		if isExecutable(filePath, entry.object) {
+			report.ExecutableCount++
+			deps := r.executableDeps(filePath, entry.object)
+			if deps.Broken() {
+				report.BrokenExecutableCount++
+			}
+
+			report.Executables = append(report.Executables, deps)
+			continue
+		}
+
+		report.LibraryCount++
+		deps := &ObjectDeps{
+			Path: filePath,
+			Type: ObjectTypeLibrary,
+		}
+
+		for _, name := range entry.object.Needed {
+			if libPath := r.find(name, filePath, entry.object); libPath != "" {
+				deps.Libraries = append(deps.Libraries, &Dependency{Name: name, Path: libPath})
+			} else {
+				deps.Missing = append(deps.Missing, name)
+			}
+		}
+
+		if deps.Broken() {
+			report.BrokenLibraryCount++
+			report.BrokenLibraries = append(report.BrokenLibraries, deps)
+		}
+	}
+
+	return report
+}
+
+// the shared libraries can have a program interpreter too (e.g., libc.so.6)
+func isExecutable(filePath string, object *Object) bool {
+	return object.Interpreter != "" && !strings.Contains(path.Base(filePath), ".so")
+}
+
+type resolver struct {
+	tree  *Tree
+	dirs  []string
+	cache map[string]string
+}
+
+// executableDeps resolves the program interpreter and the dependency closure for the executable
+func (r *resolver) executableDeps(filePath string, object *Object) *ObjectDeps {
+	deps := &ObjectDeps{
+		Path:        filePath,
+		Type:        ObjectTypeExecutable,
+		Interpreter: object.Interpreter,
+	}
+
+	if _, entry := r.tree.resolve(object.Interpreter, 0); entry == nil || entry.isDir {
+		deps.MissingInterpreter = true
+	}
+
+	//the dependencies are resolved breadth first (the direct dependencies first)
+	type queueItem struct {
+		path   string
+		object *Object
+		depth  int
+	}
+
+	seen := map[string]struct{}{}
+	queue := []*queueItem{{path: filePath, object: object}}
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		if item.depth > maxDepDepth {
+			continue
+		}
+
+		for _, name := range item.object.Needed {
+			if _, found := seen[name]; found {
+				continue
+			}
+
+			seen[name] = struct{}{}
+			libPath := r.find(name, item.path, object)
+			if libPath == "" {
+				deps.Missing = append(deps.Missing, name)
+				continue
+			}
+
+			if item.depth == 0 {
+				deps.Libraries = append(deps.Libraries, &Dependency{Name: name, Path: libPath})
+			}
+
+			if _, entry := r.tree.resolve(libPath, 0); entry != nil && entry.object != nil {
+				queue = append(queue, &queueItem{
+					path:   libPath,
+					object: entry.object,
+					depth:  item.depth + 1,
+				})
+			}
+		}
+	}
+
+	return deps
+}
+
+// find returns the library path for the object dependency (or an empty string if it's not found)
+// (the library must be compatible with the target object)
+func (r *resolver) find(name string, objPath string, target *Object) string {
+	if strings.Contains(name, "/") {
+		if r.compatible(name, target) {
+			return name
+		}
+
+		return ""
+	}
+
+	obj := target
+	if _, entry := r.tree.resolve(objPath, 0); entry != nil && entry.object != nil {
+		obj = entry.object
+	}
+
+	origin := path.Dir(objPath)
+	searchPaths := obj.RunPath
+	if len(searchPaths) == 0 {
+		//DT_RPATH is ignored when DT_RUNPATH is set
+		searchPaths = obj.RPath
+	}
+
+	for _, dir := range searchPaths {
+		dir = strings.Replace(dir, originVarAlt, origin, -1)
+		dir = strings.Replace(dir, originVar, origin, -1)
+		if candidate := path.Join(dir, name); r.compatible(candidate, target) {
+			return candidate
+		}
+	}
+
+	//the library directory lookup results are the same for all objects with the same machine/class
+	key := strings.Join([]string{name, target.Machine.String(), target.Class.String()}, ":")
+	if libPath, found := r.cache[key]; found {
+		return libPath
+	}
+
+	var libPath string
+	for _, dir := range r.dirs {
+		if candidate := path.Join(dir, name); r.compatible(candidate, target) {
+			libPath = candidate
+			break
+		}
+	}
+
+	r.cache[key] = libPath
+	return libPath
+}
+
+// compatible returns true if the file is an ELF object for the same architecture as the target object
+func (r *resolver) compatible(filePath string, target *Object) bool {
+	_, entry := r.tree.resolve(filePath, 0)
+	return entry != nil && entry.object != nil && entry.object.compatible(target)
+}
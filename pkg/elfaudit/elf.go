@@ -0,0 +1,103 @@
+package elfaudit
+
+import (
+	"bytes"
+	"debug/elf"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ErrNotELF is returned if the file data is not an ELF object
+var ErrNotELF = errors.New("not an ELF object")
+
+var elfMagic = []byte(elf.ELFMAG)
+
+// ELF object types
+const (
+	ObjectTypeExecutable = "executable"
+	ObjectTypeLibrary    = "library"
+)
+
+// Object is the dynamic linking info for an ELF object
+type Object struct {
+	Machine     elf.Machine
+	Class       elf.Class
+	Interpreter string
+	//DT_NEEDED dependencies
+	Needed  []string
+	RPath   []string
+	RunPath []string
+	//no program interpreter and no dependencies
+	Static bool
+}
+
+// IsELFData returns true if the data starts with the ELF magic
+func IsELFData(head []byte) bool {
+	return bytes.HasPrefix(head, elfMagic)
+}
+
+// ParseELF reads the dynamic linking info from the ELF object data
+// (nil is returned for the relocatable objects and core files)
+func ParseELF(data io.ReaderAt) (*Object, error) {
+	obj, err := elf.NewFile(data)
+	if err != nil {
+		if _, ok := err.(*elf.FormatError); ok {
+			return nil, ErrNotELF
+		}
+
+		return nil, err
+	}
+
+	defer obj.Close()
+
+	if obj.Type != elf.ET_EXEC && obj.Type != elf.ET_DYN {
+		return nil, nil
+	}
+
+	info := &Object{
+		Machine: obj.Machine,
+		Class:   obj.Class,
+	}
+
+	for _, prog := range obj.Progs {
+		if prog.Type == elf.PT_INTERP {
+			data, err := ioutil.ReadAll(prog.Open())
+			if err == nil {
+				info.Interpreter = string(bytes.TrimRight(data, "\x00"))
+			}
+			break
+		}
+	}
+
+	//the static binaries have no dynamic section
+	if needed, err := obj.DynString(elf.DT_NEEDED); err == nil {
+		info.Needed = needed
+		rpath, _ := obj.DynString(elf.DT_RPATH)
+		info.RPath = splitSearchPaths(rpath)
+		runpath, _ := obj.DynString(elf.DT_RUNPATH)
+		info.RunPath = splitSearchPaths(runpath)
+	}
+
+	info.Static = info.Interpreter == "" && len(info.Needed) == 0
+	return info, nil
+}
+
+// compatible returns true if the object can be loaded by the target object
+func (o *Object) compatible(target *Object) bool {
+	return o.Machine == target.Machine && o.Class == target.Class
+}
+
+func splitSearchPaths(values []string) []string {
+	var paths []string
+	for _, val := range values {
+		for _, p := range strings.Split(val, ":") {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1,129 @@
+package elfaudit
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NewTreeFromTar creates a filesystem tree from a filesystem tarball
+func NewTreeFromTar(reader io.Reader) (*Tree, error) {
+	tree := NewTree()
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			tree.AddDir(hdr.Name)
+		case tar.TypeSymlink:
+			tree.AddSymlink(hdr.Name, hdr.Linkname)
+		case tar.TypeLink:
+			//the hard link targets are always before the links in the tarballs
+			if _, entry := tree.resolve(hdr.Linkname, 0); entry != nil {
+				tree.entries[cleanPath(hdr.Name)] = &treeEntry{object: entry.object}
+			} else {
+				tree.AddFile(hdr.Name, nil)
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := addTreeFile(tree, hdr.Name, tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+// NewTreeFromDir creates a filesystem tree from a directory with the image filesystem files
+func NewTreeFromDir(root string) (*Tree, error) {
+	tree := NewTree()
+	err := filepath.Walk(root, func(fullPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, fullPath)
+		if err != nil {
+			return err
+		}
+
+		filePath := filepath.ToSlash(rel)
+		switch {
+		case info.IsDir():
+			tree.AddDir(filePath)
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(fullPath)
+			if err != nil {
+				return err
+			}
+
+			tree.AddSymlink(filePath, target)
+		case info.Mode().IsRegular():
+			file, err := os.Open(fullPath)
+			if err != nil {
+				return err
+			}
+
+			err = addTreeFile(tree, filePath, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// addTreeFile adds a file to the tree reading the file data only if it's an ELF object or a library config file
+func addTreeFile(tree *Tree, filePath string, reader io.Reader) error {
+	if IsLibConfigFile(cleanPath(filePath)) {
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		tree.AddLibConfig(filePath, data)
+		return nil
+	}
+
+	br := bufio.NewReader(reader)
+	head, _ := br.Peek(len(elfMagic))
+	if !IsELFData(head) {
+		tree.AddFile(filePath, nil)
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return err
+	}
+
+	object, err := ParseELF(bytes.NewReader(data))
+	if err != nil {
+		log.Debugf("elfaudit.addTreeFile(%s): error parsing ELF object - %v", filePath, err)
+	}
+
+	tree.AddFile(filePath, object)
+	return nil
+}
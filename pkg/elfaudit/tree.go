@@ -0,0 +1,191 @@
+package elfaudit
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	ldConfFilePath = "/etc/ld.so.conf"
+	ldConfDirPath  = "/etc/ld.so.conf.d/"
+	muslPathPrefix = "/etc/ld-musl-"
+	muslPathSuffix = ".path"
+	maxLinkDepth   = 32
+	maxIncludes    = 5
+)
+
+// IsLibConfigFile returns true if the file is a dynamic linker library path config file
+func IsLibConfigFile(filePath string) bool {
+	switch {
+	case filePath == ldConfFilePath:
+		return true
+	case strings.HasPrefix(filePath, ldConfDirPath) && strings.HasSuffix(filePath, ".conf"):
+		return true
+	case strings.HasPrefix(filePath, muslPathPrefix) && strings.HasSuffix(filePath, muslPathSuffix):
+		return true
+	}
+
+	return false
+}
+
+type treeEntry struct {
+	isDir      bool
+	linkTarget string
+	object     *Object
+}
+
+// Tree is the image filesystem view used to resolve the ELF dependencies
+// (only the file types, the symlinks, the ELF objects and the library config files are tracked)
+type Tree struct {
+	entries map[string]*treeEntry
+	configs map[string][]byte
+}
+
+// NewTree creates a new empty filesystem tree
+func NewTree() *Tree {
+	return &Tree{
+		entries: map[string]*treeEntry{},
+		configs: map[string][]byte{},
+	}
+}
+
+// AddDir adds a directory to the tree
+func (t *Tree) AddDir(filePath string) {
+	t.entries[cleanPath(filePath)] = &treeEntry{isDir: true}
+}
+
+// AddFile adds a file to the tree (the object is nil if the file is not an ELF object)
+func (t *Tree) AddFile(filePath string, object *Object) {
+	t.entries[cleanPath(filePath)] = &treeEntry{object: object}
+}
+
+// AddSymlink adds a symlink to the tree
+func (t *Tree) AddSymlink(filePath, target string) {
+	if target == "" {
+		target = "."
+	}
+
+	t.entries[cleanPath(filePath)] = &treeEntry{linkTarget: target}
+}
+
+// AddLibConfig adds a library path config file to the tree
+func (t *Tree) AddLibConfig(filePath string, data []byte) {
+	filePath = cleanPath(filePath)
+	if _, found := t.entries[filePath]; !found {
+		t.entries[filePath] = &treeEntry{}
+	}
+
+	t.configs[filePath] = data
+}
+
+// resolve follows the symlinks in the file path and returns the real file path and its entry
+// (the missing parent directories are assumed to exist because they are often not in the image tarballs)
+func (t *Tree) resolve(filePath string, depth int) (string, *treeEntry) {
+	if depth > maxLinkDepth {
+		return "", nil
+	}
+
+	parts := strings.Split(strings.Trim(cleanPath(filePath), "/"), "/")
+	current := "/"
+	for idx, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		next := path.Join(current, part)
+		entry := t.entries[next]
+		if entry == nil {
+			if idx == len(parts)-1 {
+				return "", nil
+			}
+
+			current = next
+			continue
+		}
+
+		if entry.linkTarget != "" {
+			target := entry.linkTarget
+			if !path.IsAbs(target) {
+				target = path.Join(current, target)
+			}
+
+			rest := append([]string{target}, parts[idx+1:]...)
+			return t.resolve(path.Join(rest...), depth+1)
+		}
+
+		current = next
+	}
+
+	return current, t.entries[current]
+}
+
+// libConfigDirs returns the library directories from the ld.so.conf file (and its includes)
+// and from the musl dynamic linker path files
+func (t *Tree) libConfigDirs() []string {
+	dirs := t.ldConfDirs(ldConfFilePath, 0)
+
+	var muslFiles []string
+	for filePath := range t.configs {
+		if strings.HasPrefix(filePath, muslPathPrefix) {
+			muslFiles = append(muslFiles, filePath)
+		}
+	}
+
+	sort.Strings(muslFiles)
+	for _, filePath := range muslFiles {
+		for _, field := range strings.FieldsFunc(string(t.configs[filePath]), func(c rune) bool {
+			return c == ':' || c == '\n'
+		}) {
+			if field = strings.TrimSpace(field); field != "" {
+				dirs = append(dirs, field)
+			}
+		}
+	}
+
+	return dirs
+}
+
+func (t *Tree) ldConfDirs(filePath string, depth int) []string {
+	data, found := t.configs[filePath]
+	if !found || depth > maxIncludes {
+		return nil
+	}
+
+	var dirs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "include "):
+			pattern := strings.TrimSpace(strings.TrimPrefix(line, "include "))
+			if !path.IsAbs(pattern) {
+				pattern = path.Join(path.Dir(filePath), pattern)
+			}
+
+			var matches []string
+			for configPath := range t.configs {
+				if ok, _ := path.Match(pattern, configPath); ok {
+					matches = append(matches, configPath)
+				}
+			}
+
+			sort.Strings(matches)
+			for _, match := range matches {
+				dirs = append(dirs, t.ldConfDirs(match, depth+1)...)
+			}
+		case strings.HasPrefix(line, "/"):
+			dirs = append(dirs, line)
+		}
+	}
+
+	return dirs
+}
+
+func cleanPath(filePath string) string {
+	return path.Clean("/" + filePath)
+}
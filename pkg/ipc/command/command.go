@@ -18,9 +18,26 @@ const (
 	ResponseStatusError = "error"
 )
 
+// IPC protocol versions (the protocol version changes when the commands or the events change in an incompatible way).
+// The master and the sensor can be updated separately, so each side checks the protocol version of the other side.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// IsCompatibleProtocol returns true if the protocol version of the other side is supported
+// (zero means the other side is older than the protocol versioning and it's treated as compatible)
+func IsCompatibleProtocol(version int) bool {
+	return version == 0 || (version >= MinProtocolVersion && version <= ProtocolVersion)
+}
+
 // Response contains the command response status information
 type Response struct {
 	Status string `json:"status"`
+	//the sensor protocol and app versions (not set by the older sensors)
+	ProtocolVersion int    `json:"protocol_version,omitempty"`
+	SensorVersion   string `json:"sensor_version,omitempty"`
+	Error           string `json:"error,omitempty"`
 }
 
 // MessageName is a message ID type
@@ -40,6 +57,7 @@ type Message interface {
 
 // StartMonitor contains the start monitor command fields
 type StartMonitor struct {
+	ProtocolVersion              int                           `json:"protocol_version,omitempty"`
 	RTASourcePT                  bool                          `json:"rta_source_ptrace"`
 	AppName                      string                        `json:"app_name"`
 	AppArgs                      []string                      `json:"app_args,omitempty"`
@@ -52,6 +70,8 @@ type StartMonitor struct {
 	Includes                     map[string]*fsutil.AccessInfo `json:"includes,omitempty"`
 	IncludeBins                  []string                      `json:"include_bins,omitempty"`
 	IncludeExes                  []string                      `json:"include_exes,omitempty"`
+	IncludePkgs                  []string                      `json:"include_pkgs,omitempty"`
+	IncludePkgDeps               bool                          `json:"include_pkg_deps,omitempty"`
 	IncludeShell                 bool                          `json:"include_shell,omitempty"`
 	IncludeCertAll               bool                          `json:"include_cert_all,omitempty"`
 	IncludeCertBundles           bool                          `json:"include_cert_bundles,omitempty"`
@@ -59,6 +79,8 @@ type StartMonitor struct {
 	IncludeCertPKAll             bool                          `json:"include_cert_pk_all,omitempty"`
 	IncludeCertPKDirs            bool                          `json:"include_cert_pk_dirs,omitempty"`
 	IncludeNew                   bool                          `json:"include_new,omitempty"`
+	IncludeRuntimeEssentials     bool                          `json:"include_runtime_essentials,omitempty"`
+	RunAsUser                    *UserInfo                     `json:"run_as_user,omitempty"`
 	IncludeAppNuxtDir            bool                          `json:"include_app_nuxt_dir,omitempty"`
 	IncludeAppNuxtBuildDir       bool                          `json:"include_app_nuxt_build,omitempty"`
 	IncludeAppNuxtDistDir        bool                          `json:"include_app_nuxt_dist,omitempty"`
@@ -72,6 +94,13 @@ type StartMonitor struct {
 	IncludeNodePackages          []string                      `json:"include_node_packages,omitempty"`
 }
 
+// UserInfo contains the user to add to the optimized image
+type UserInfo struct {
+	Name string `json:"name"`
+	UID  int    `json:"uid"`
+	GID  int    `json:"gid"`
+}
+
 // GetName returns the command message ID for the start monitor command
 func (m *StartMonitor) GetName() MessageName {
 	return StartMonitorName
@@ -0,0 +1,118 @@
+// Package junit writes the command results in the JUnit XML format
+// (the de facto test report format supported by the CI systems, e.g., Jenkins and GitLab)
+package junit
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// TestSuites is the JUnit XML report root element
+type TestSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []*TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is a group of test cases (e.g., the lint results for a Dockerfile)
+type TestSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []*TestCase `xml:"testcase"`
+}
+
+// TestCase is a test result (e.g., a lint check or a verification probe call)
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Failure   *Result  `xml:"failure,omitempty"`
+	Error     *Result  `xml:"error,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+// Result contains the failure or error details
+type Result struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Details string `xml:",chardata"`
+}
+
+// Skipped marks a skipped test case
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// Add adds the test case to the suite and updates the suite counters
+func (s *TestSuite) Add(tc *TestCase) {
+	s.Cases = append(s.Cases, tc)
+	s.Tests++
+	switch {
+	case tc.Error != nil:
+		s.Errors++
+	case tc.Failure != nil:
+		s.Failures++
+	case tc.Skipped != nil:
+		s.Skipped++
+	}
+}
+
+// NewReport creates a JUnit XML report with the test suites
+// (the report counters are the totals for all suites)
+func NewReport(name string, suites []*TestSuite) *TestSuites {
+	report := &TestSuites{
+		Name:   name,
+		Suites: suites,
+	}
+
+	for _, suite := range suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+	}
+
+	return report
+}
+
+// Write writes the JUnit XML report
+func (r *TestSuites) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Save saves the JUnit XML report file
+func (r *TestSuites) Save(filePath string) error {
+	if dir := filepath.Dir(filePath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	return r.Write(file)
+}
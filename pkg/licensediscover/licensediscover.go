@@ -0,0 +1,208 @@
+package licensediscover
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+)
+
+// License file name prefixes (matched in the upper case)
+var licenseFilePrefixes = []string{
+	"LICENSE",
+	"LICENCE",
+	"COPYING",
+	"UNLICENSE",
+}
+
+// Source code and data file extensions (e.g., 'license.go' or 'license.json' are not license files)
+var nonLicenseFileExts = map[string]struct{}{
+	".c":     {},
+	".h":     {},
+	".go":    {},
+	".py":    {},
+	".pyc":   {},
+	".js":    {},
+	".ts":    {},
+	".rb":    {},
+	".php":   {},
+	".java":  {},
+	".class": {},
+	".json":  {},
+	".yaml":  {},
+	".yml":   {},
+	".xml":   {},
+	".html":  {},
+	".so":    {},
+	".mo":    {},
+	".gz":    {},
+}
+
+const (
+	dpkgDocDir        = "/usr/share/doc/"
+	dpkgCopyrightFile = "copyright"
+	//the DEP-5 machine-readable copyright file format
+	dep5FormatMarker = "copyright-format"
+)
+
+// IsLicenseFile returns true if the file path looks like a license file
+// (e.g., 'LICENSE', 'LICENSE.txt', 'COPYING' or the Debian package copyright file)
+func IsLicenseFile(name string) bool {
+	if DpkgCopyrightPackage(name) != "" {
+		return true
+	}
+
+	base := strings.ToUpper(path.Base(name))
+	if _, found := nonLicenseFileExts[strings.ToLower(path.Ext(base))]; found {
+		return false
+	}
+
+	for _, prefix := range licenseFilePrefixes {
+		if !strings.HasPrefix(base, prefix) {
+			continue
+		}
+
+		if len(base) == len(prefix) {
+			return true
+		}
+
+		switch base[len(prefix)] {
+		case '.', '-', '_':
+			return true
+		}
+	}
+
+	return false
+}
+
+// DpkgCopyrightPackage returns the package name if the file path is a Debian package copyright file
+// (/usr/share/doc/<package>/copyright)
+func DpkgCopyrightPackage(name string) string {
+	if !strings.HasPrefix(name, dpkgDocDir) {
+		return ""
+	}
+
+	parts := strings.Split(strings.TrimPrefix(name, dpkgDocDir), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != dpkgCopyrightFile {
+		return ""
+	}
+
+	return parts[0]
+}
+
+// License text signatures (the order matters: the more specific signatures go first)
+var licenseSignatures = []struct {
+	id       string
+	keywords []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"LGPL-2.0", []string{"gnu library general public license"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"EPL-2.0", []string{"eclipse public license", "2.0"}},
+	{"EPL-1.0", []string{"eclipse public license"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"Zlib", []string{"this software is provided 'as-is', without any express or implied warranty"}},
+}
+
+// Detect returns the licenses declared in the license file data
+// (the Debian machine-readable copyright files list their licenses,
+// the other license files are matched against the common license text signatures)
+func Detect(data []byte) []string {
+	if bytes.Contains(data, []byte(dep5FormatMarker)) {
+		if licenses := dep5Licenses(data); len(licenses) > 0 {
+			return licenses
+		}
+	}
+
+	text := strings.ToLower(strings.Join(strings.Fields(string(data)), " "))
+	for _, sig := range licenseSignatures {
+		matched := true
+		for _, keyword := range sig.keywords {
+			if !strings.Contains(text, keyword) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return []string{sig.id}
+		}
+	}
+
+	return nil
+}
+
+func dep5Licenses(data []byte) []string {
+	var licenses []string
+	seen := map[string]struct{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "License:") {
+			continue
+		}
+
+		value := strings.TrimSpace(strings.TrimPrefix(line, "License:"))
+		if value == "" {
+			continue
+		}
+
+		if _, found := seen[value]; !found {
+			seen[value] = struct{}{}
+			licenses = append(licenses, value)
+		}
+	}
+
+	return licenses
+}
+
+// IDs returns the license identifiers in the license expression
+// (e.g., 'GPL-2.0-or-later AND (MIT OR BSD-3-Clause)')
+func IDs(expression string) []string {
+	fields := strings.FieldsFunc(expression, func(r rune) bool {
+		switch r {
+		case ' ', '\t', '(', ')', ',', ';', '/', '|', '&':
+			return true
+		}
+
+		return false
+	})
+
+	var ids []string
+	for _, field := range fields {
+		switch strings.ToUpper(field) {
+		case "AND", "OR", "WITH":
+			continue
+		}
+
+		ids = append(ids, field)
+	}
+
+	return ids
+}
+
+// Denied returns the license identifiers in the license expression matching the denied license patterns
+// (the patterns are case insensitive and they can have wildcards, e.g., 'GPL-3.0*' or 'AGPL-*')
+func Denied(expression string, patterns []string) []string {
+	var denied []string
+	for _, id := range IDs(expression) {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(id)); matched {
+				denied = append(denied, id)
+				break
+			}
+		}
+	}
+
+	return denied
+}
@@ -115,6 +115,7 @@ type App struct {
 	StateCh         chan AppState
 	StopCh          chan struct{}
 	fsActivity      map[string]*report.FSActivityInfo
+	fsMissing       map[string]*report.FSMissingInfo
 	syscallActivity map[uint32]uint64
 	//syscallResolver system.NumberResolverFunc
 	cmd             *exec.Cmd
@@ -123,12 +124,19 @@ type App struct {
 	collectorDoneCh chan int
 	includeNew      bool
 	origPaths       map[string]interface{}
+	exitCode        int
 }
 
 func (a *App) MainPID() int {
 	return a.cmd.Process.Pid
 }
 
+// ExitCode returns the main app process exit code
+// (128 + the signal number if the process is terminated by a signal; set when the app is done)
+func (a *App) ExitCode() int {
+	return a.exitCode
+}
+
 func (a *App) PGID() int {
 	return a.pgid
 }
@@ -187,6 +195,7 @@ func newApp(
 		StateCh:         stateCh,
 		StopCh:          stopCh,
 		fsActivity:      map[string]*report.FSActivityInfo{},
+		fsMissing:       map[string]*report.FSMissingInfo{},
 		syscallActivity: map[uint32]uint64{},
 		eventCh:         make(chan syscallEvent, eventBufSize),
 		collectorDoneCh: make(chan int, 1),
@@ -195,6 +204,7 @@ func newApp(
 			ArchName:     string(archName),
 			SyscallStats: map[string]report.SyscallStatInfo{},
 			FSActivity:   map[string]*report.FSActivityInfo{},
+			FSMissing:    map[string]*report.FSMissingInfo{},
 		},
 		includeNew: includeNew,
 		origPaths:  origPaths,
@@ -231,11 +241,16 @@ func (app *App) processFileActivity(e *syscallEvent) {
 	if e.pathParam != "" {
 		p, found := syscallProcessors[int(e.callNum)]
 		if !found {
-			log.Debug("ptrace.App.processFileActivity - no syscall processor - %#v", e)
+			log.Debugf("ptrace.App.processFileActivity - no syscall processor - %#v", e)
 			//shouldn't happen
 			return
 		}
 
+		if isMissingFileRetVal(e.retVal) {
+			app.processMissingFile(e, p)
+			return
+		}
+
 		if (p.SyscallType() == CheckFileType ||
 			p.SyscallType() == OpenFileType) &&
 			!p.FailedReturnStatus(e.retVal) {
@@ -274,6 +289,40 @@ func (app *App) processFileActivity(e *syscallEvent) {
 	}
 }
 
+// isMissingFileRetVal returns true if the syscall failed because the file doesn't exist
+func isMissingFileRetVal(retVal uint64) bool {
+	return int64(retVal) == -int64(syscall.ENOENT)
+}
+
+// processMissingFile records the failed lookups for the missing files
+// (used to find the files missing in the optimized images)
+func (app *App) processMissingFile(e *syscallEvent, p SyscallProcessor) {
+	if e.pathParam == "." ||
+		e.pathParam == "/proc" ||
+		strings.HasPrefix(e.pathParam, "/proc/") ||
+		strings.HasPrefix(e.pathParam, "/sys/") ||
+		strings.HasPrefix(e.pathParam, "/dev/") {
+		return
+	}
+
+	info, ok := app.fsMissing[e.pathParam]
+	if !ok {
+		info = &report.FSMissingInfo{
+			Pids:     map[int]struct{}{},
+			Syscalls: map[int]struct{}{},
+		}
+
+		app.fsMissing[e.pathParam] = info
+	}
+
+	info.OpsAll++
+	info.Pids[e.pid] = struct{}{}
+	info.Syscalls[int(e.callNum)] = struct{}{}
+	if p.SyscallType() == ExecType {
+		info.Exec = true
+	}
+}
+
 func (app *App) process() {
 	log.Debug("ptrace.App.process")
 	state := AppDone
@@ -324,6 +373,7 @@ done:
 
 	app.Report.SyscallNum = uint32(len(app.Report.SyscallStats))
 	app.Report.FSActivity = app.FileActivity()
+	app.Report.FSMissing = app.fsMissing
 
 	app.StateCh <- state
 	app.ReportCh <- &app.Report
@@ -590,13 +640,16 @@ func (app *App) collect() {
 		handleCall := false
 		eventCode := 0
 		statusCode := 0
+		exitCode := 0
 		switch {
 		case ws.Exited():
 			terminated = true
 			statusCode = ws.ExitStatus()
+			exitCode = statusCode
 		case ws.Signaled():
 			terminated = true
 			statusCode = int(ws.Signal())
+			exitCode = 128 + statusCode
 		case ws.Stopped():
 			statusCode = int(ws.StopSignal())
 			if statusCode == int(syscall.SIGTRAP|traceSysGoodStatusBit) {
@@ -624,6 +677,7 @@ func (app *App) collect() {
 			if app.MainPID() == wpid {
 				log.Debugf("ptrace.App.collect[%d/%d]: wpid(%v) is main PID and terminated...",
 					app.cmd.Process.Pid, app.pgid, wpid)
+				app.exitCode = exitCode
 				if !mainExiting {
 					log.Debug("ptrace.App.collect: unexpected main PID termination...")
 				}
@@ -697,7 +751,8 @@ func (app *App) collect() {
 				cstate.pathParamErr = nil
 
 				_, ok := app.origPaths[evt.pathParam]
-				if app.includeNew {
+				if app.includeNew || isMissingFileRetVal(evt.retVal) {
+					//the missing files are not in the original paths
 					ok = true
 				}
 
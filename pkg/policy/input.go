@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// Input contains the report data the policies are evaluated against
+type Input struct {
+	//the command report type ('build', 'profile', 'xray', etc)
+	Type            string
+	Report          interface{}
+	ContainerReport interface{} //nil if there's no container report
+}
+
+// LoadInput loads the command report (and its container report, if it's available)
+func LoadInput(filePath string) (*Input, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	reportType, err := report.ReportSchemaName(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	input := &Input{Type: reportType}
+	if input.Report, err = decodeJSON(data); err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	fields, _ := input.Report.(map[string]interface{})
+	artifactLocation, _ := fields["artifact_location"].(string)
+	creportName, _ := fields["container_report_name"].(string)
+	if creportName == "" {
+		return input, nil
+	}
+
+	//the artifact directory might be moved with the command report
+	creportPath := filepath.Join(artifactLocation, creportName)
+	if _, err := os.Stat(creportPath); err != nil {
+		creportPath = filepath.Join(filepath.Dir(filePath), creportName)
+	}
+
+	creportData, err := ioutil.ReadFile(creportPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return input, nil
+		}
+
+		return nil, err
+	}
+
+	if input.ContainerReport, err = decodeJSON(creportData); err != nil {
+		return nil, fmt.Errorf("%s: %v", creportPath, err)
+	}
+
+	return input, nil
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,242 @@
+// Package policy implements the report policy engine
+// (user-defined Starlark policies evaluated against the command reports;
+// the Rego policies are not supported)
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+var (
+	ErrBadPolicy       = errors.New("bad policy")
+	ErrDuplicatePolicy = errors.New("duplicate policy ID")
+	//the policies are Starlark expressions (there's no OPA/Rego evaluation)
+	ErrRegoNotSupported = errors.New("Rego policies are not supported (use the Starlark 'expr' policies)")
+)
+
+// Policy levels
+const (
+	LevelError = "error"
+	LevelWarn  = "warn"
+)
+
+// Config is the policy config (YAML or JSON)
+type Config struct {
+	Policies []*Policy `json:"policies"`
+}
+
+// Policy is a user-defined report policy
+type Policy struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	//the violation message if the expression doesn't return the violation messages
+	Message string `json:"message,omitempty"`
+	//'error' (default; the violations fail the command) or 'warn'
+	Level string `json:"level,omitempty"`
+	//the report types the policy applies to (all report types if empty)
+	Reports []string `json:"reports,omitempty"`
+	//Starlark expression evaluated with the 'report', 'creport' and 'report_type' values:
+	//True (or an empty string or list) means the policy passes,
+	//False, a violation message or a list of violation messages means it fails
+	Expr string `json:"expr"`
+
+	expr *starlark.Function
+}
+
+// Result is the policy evaluation result
+type Result struct {
+	Policy     *Policy  `json:"-"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Level      string   `json:"level"`
+	Skipped    bool     `json:"skipped,omitempty"` //the policy doesn't apply to the report type
+	Passed     bool     `json:"passed"`
+	Violations []string `json:"violations,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Load loads and compiles the policies from the policy config files
+func Load(filePaths []string) ([]*Policy, error) {
+	var policies []*Policy
+	ids := map[string]struct{}{}
+	for _, filePath := range filePaths {
+		if filePath == "" {
+			continue
+		}
+
+		if strings.EqualFold(filepath.Ext(filePath), ".rego") {
+			return nil, fmt.Errorf("%s: %w", filePath, ErrRegoNotSupported)
+		}
+
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		var config Config
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("%s: %v", filePath, err)
+		}
+
+		for _, p := range config.Policies {
+			if err := p.compile(); err != nil {
+				return nil, fmt.Errorf("%s: %w", filePath, err)
+			}
+
+			if _, ok := ids[p.ID]; ok {
+				return nil, fmt.Errorf("%s: %w - %s", filePath, ErrDuplicatePolicy, p.ID)
+			}
+
+			ids[p.ID] = struct{}{}
+			policies = append(policies, p)
+		}
+	}
+
+	return policies, nil
+}
+
+func (p *Policy) compile() error {
+	if p.ID == "" {
+		return fmt.Errorf("%w - missing policy ID", ErrBadPolicy)
+	}
+
+	if p.Expr == "" {
+		return fmt.Errorf("%w - %s: missing policy expression", ErrBadPolicy, p.ID)
+	}
+
+	switch p.Level {
+	case "":
+		p.Level = LevelError
+	case LevelError, LevelWarn:
+	default:
+		return fmt.Errorf("%w - %s: unknown policy level '%s'", ErrBadPolicy, p.ID, p.Level)
+	}
+
+	if p.Name == "" {
+		p.Name = p.ID
+	}
+
+	if _, err := syntax.ParseExpr(p.ID, p.Expr, 0); err != nil {
+		return fmt.Errorf("%w - %s: %v", ErrBadPolicy, p.ID, err)
+	}
+
+	src := fmt.Sprintf("def evaluate(report, creport, report_type):\n    return (%s\n    )\n", p.Expr)
+	thread := &starlark.Thread{Name: p.ID}
+	globals, err := starlark.ExecFile(thread, p.ID, src, nil)
+	if err != nil {
+		return fmt.Errorf("%w - %s: %v", ErrBadPolicy, p.ID, err)
+	}
+
+	fn, ok := globals["evaluate"].(*starlark.Function)
+	if !ok {
+		return fmt.Errorf("%w - %s: no expression function", ErrBadPolicy, p.ID)
+	}
+
+	p.expr = fn
+	return nil
+}
+
+// AppliesTo returns true if the policy applies to the report type
+func (p *Policy) AppliesTo(reportType string) bool {
+	if len(p.Reports) == 0 {
+		return true
+	}
+
+	for _, rt := range p.Reports {
+		if rt == reportType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Evaluate evaluates the policies against the report input
+func Evaluate(policies []*Policy, input *Input) []*Result {
+	report := toStarlark(input.Report)
+	creport := toStarlark(input.ContainerReport)
+	reportType := starlark.String(input.Type)
+
+	var results []*Result
+	for _, p := range policies {
+		result := &Result{
+			Policy: p,
+			ID:     p.ID,
+			Name:   p.Name,
+			Level:  p.Level,
+		}
+
+		results = append(results, result)
+		if !p.AppliesTo(input.Type) {
+			result.Skipped = true
+			result.Passed = true
+			continue
+		}
+
+		thread := &starlark.Thread{Name: p.ID}
+		val, err := starlark.Call(thread, p.expr,
+			starlark.Tuple{report, creport, reportType}, nil)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		result.Violations, err = violations(p, val)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		result.Passed = len(result.Violations) == 0
+	}
+
+	return results
+}
+
+// violations converts the policy expression value to the violation messages
+func violations(p *Policy, val starlark.Value) ([]string, error) {
+	message := p.Message
+	if message == "" {
+		message = p.Name
+	}
+
+	switch v := val.(type) {
+	case starlark.Bool:
+		if v {
+			return nil, nil
+		}
+
+		return []string{message}, nil
+	case starlark.String:
+		if v == "" {
+			return nil, nil
+		}
+
+		return []string{string(v)}, nil
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Indexable:
+		var list []string
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			if s, ok := starlark.AsString(item); ok {
+				list = append(list, s)
+			} else {
+				list = append(list, item.String())
+			}
+		}
+
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("unexpected policy expression value type - %s", val.Type())
+}
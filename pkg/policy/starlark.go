@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// toStarlark converts the decoded JSON data to the Starlark values
+// (objects are dicts with sorted keys, arrays are lists and the numbers are ints or floats)
+func toStarlark(data interface{}) starlark.Value {
+	switch v := data.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(v)
+	case string:
+		return starlark.String(v)
+	case json.Number:
+		if n, ok := new(big.Int).SetString(string(v), 10); ok {
+			return starlark.MakeBigInt(n)
+		}
+
+		f, err := v.Float64()
+		if err != nil {
+			return starlark.String(string(v))
+		}
+
+		return starlark.Float(f)
+	case float64:
+		return starlark.Float(v)
+	case []interface{}:
+		list := make([]starlark.Value, 0, len(v))
+		for _, item := range v {
+			list = append(list, toStarlark(item))
+		}
+
+		return starlark.NewList(list)
+	case map[string]interface{}:
+		var keys []string
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(v))
+		for _, k := range keys {
+			dict.SetKey(starlark.String(k), toStarlark(v[k]))
+		}
+
+		return dict
+	}
+
+	return starlark.None
+}
@@ -7,16 +7,19 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/imageformat"
 	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+	"github.com/docker-slim/docker-slim/pkg/elfaudit"
 	"github.com/docker-slim/docker-slim/pkg/system"
-	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/version"
+	"github.com/docker-slim/docker-slim/pkg/vulnscan"
 )
 
 // DefaultFilename is the default name for the command report
@@ -28,6 +31,7 @@ const tmpPath = "/tmp"
 type Command struct {
 	reportLocation string
 	Version        string     `json:"version"`
+	SchemaVersion  string     `json:"schema_version"`
 	Engine         string     `json:"engine"`
 	Containerized  bool       `json:"containerized"`
 	HostDistro     DistroInfo `json:"host_distro"`
@@ -35,6 +39,17 @@ type Command struct {
 	Type  command.Type  `json:"type"`
 	State command.State `json:"state"`
 	Error string        `json:"error,omitempty"`
+	//the structured error info (if the command failed)
+	ErrorInfo *ErrorInfo `json:"error_info,omitempty"`
+}
+
+// ErrorInfo describes the command failure
+type ErrorInfo struct {
+	Category string `json:"category"`
+	Type     string `json:"type,omitempty"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint,omitempty"`
+	ExitCode int    `json:"exit_code"`
 }
 
 // ImageIdentity includes the container image identity fields
@@ -120,7 +135,251 @@ type BuildCommand struct {
 	ContainerReportName    string               `json:"container_report_name"`
 	SeccompProfileName     string               `json:"seccomp_profile_name"`
 	AppArmorProfileName    string               `json:"apparmor_profile_name"`
+	CapabilitiesReportName string               `json:"capabilities_report_name"`
+	SensorDataImport       string               `json:"sensor_data_import,omitempty"` //the sensor data collected by an instrumented image
 	ImageStack             []*reverse.ImageInfo `json:"image_stack"`
+	HTTPProbe              *HTTPProbeReport     `json:"http_probe,omitempty"`
+	Plan                   *BuildPlan           `json:"plan,omitempty"`
+	Verification           []*VerifyRound       `json:"verification,omitempty"`
+	Layers                 *LayerReuseInfo      `json:"layers,omitempty"`
+	Reproducible           bool                 `json:"reproducible,omitempty"`
+	OCIOutput              *OCIOutputInfo       `json:"oci_output,omitempty"`
+	SBOM                   *SBOMInfo            `json:"sbom,omitempty"`
+	Vulnerabilities        *VulnScanReport      `json:"vulnerabilities,omitempty"`
+	ELFDeps                *elfaudit.Report     `json:"elf_deps,omitempty"`
+	DebugImage             *DebugImageInfo      `json:"debug_image,omitempty"`
+	JavaRuntime            *JavaRuntimeInfo     `json:"java_runtime,omitempty"`
+	PythonPackages         *PythonPackagesInfo  `json:"python_packages,omitempty"`
+	NodePackages           *NodePackagesInfo    `json:"node_packages,omitempty"`
+	StaticBinary           *StaticBinaryInfo    `json:"static_binary,omitempty"`
+}
+
+// Output Version for 'build' with multiple targets
+const OVBatchBuildCommand = "1.0"
+
+// BatchBuildCommand is the 'build' command report data for multiple targets
+type BatchBuildCommand struct {
+	Command
+	Workers int                 `json:"workers"`
+	Failed  int                 `json:"failed"`
+	Targets []*BatchBuildTarget `json:"targets"`
+}
+
+// BatchBuildTarget contains the 'build' command results for one of the targets
+type BatchBuildTarget struct {
+	TargetReference string        `json:"target_reference"`
+	ExitCode        int           `json:"exit_code"`
+	Error           string        `json:"error,omitempty"`
+	Report          *BuildCommand `json:"report,omitempty"`
+}
+
+// DebugImageInfo contains the info about the debug variant of the optimized image
+type DebugImageInfo struct {
+	Name      string `json:"name"`
+	ID        string `json:"id"`
+	Size      int64  `json:"size"`
+	Toolbox   string `json:"toolbox"`
+	ToolsPath string `json:"tools_path"`
+}
+
+// JavaRuntimeInfo contains the info about the custom Java runtime (created with jlink) in the optimized image
+type JavaRuntimeInfo struct {
+	Status       string   `json:"status"`
+	JavaHome     string   `json:"java_home,omitempty"`
+	JLinkImage   string   `json:"jlink_image,omitempty"`
+	AppFileCount int      `json:"app_file_count,omitempty"`
+	Modules      []string `json:"modules,omitempty"`
+	OriginalSize int64    `json:"original_size,omitempty"`
+	Size         int64    `json:"size,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// PythonPackagesInfo contains the info about the Python packages pruned from the optimized image
+type PythonPackagesInfo struct {
+	Status            string               `json:"status"`
+	PackagesDirs      []string             `json:"packages_dirs,omitempty"`
+	PackageCount      int                  `json:"package_count,omitempty"`
+	RemovedPackages   []*PythonPackageInfo `json:"removed_packages,omitempty"`
+	RemovedCacheFiles int                  `json:"removed_cache_files,omitempty"`
+	RemovedTestFiles  int                  `json:"removed_test_files,omitempty"`
+	RemovedSize       int64                `json:"removed_size,omitempty"`
+	Error             string               `json:"error,omitempty"`
+}
+
+// PythonPackageInfo describes a removed Python package (distribution)
+type PythonPackageInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	FileCount int    `json:"file_count"`
+	Size      int64  `json:"size"`
+}
+
+// NodePackagesInfo contains the info about the Node.js packages pruned from the optimized image
+type NodePackagesInfo struct {
+	Status             string             `json:"status"`
+	PackageCount       int                `json:"package_count,omitempty"`
+	RemovedPackages    []*NodePackageInfo `json:"removed_packages,omitempty"`
+	RemovedDevPackages int                `json:"removed_dev_packages,omitempty"`
+	RemovedSize        int64              `json:"removed_size,omitempty"`
+	Error              string             `json:"error,omitempty"`
+}
+
+// NodePackageInfo describes a removed Node.js package
+type NodePackageInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	Path      string `json:"path"`
+	Dev       bool   `json:"dev,omitempty"`
+	FileCount int    `json:"file_count"`
+	Size      int64  `json:"size"`
+}
+
+// StaticBinaryInfo contains the info about the static app binary (and the scratch style output for it)
+type StaticBinaryInfo struct {
+	Status        string                  `json:"status"`
+	Path          string                  `json:"path,omitempty"`
+	Size          int64                   `json:"size,omitempty"`
+	KeptFiles     []*StaticBinaryFileInfo `json:"kept_files,omitempty"`
+	ExcludedFiles []*StaticBinaryFileInfo `json:"excluded_files,omitempty"`
+	ExcludedSize  int64                   `json:"excluded_size,omitempty"`
+	TmpDirs       []string                `json:"tmp_dirs,omitempty"`
+	Error         string                  `json:"error,omitempty"`
+}
+
+// StaticBinaryFileInfo describes a file kept or excluded in the static binary output (and why)
+type StaticBinaryFileInfo struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// SBOMInfo contains the info about the generated software bill of materials
+type SBOMInfo struct {
+	Format       string `json:"format"`
+	Location     string `json:"location"`
+	PackageCount int    `json:"package_count"`
+	FileCount    int    `json:"file_count"`
+}
+
+// VulnScanReport contains the vulnerability scan results for the original and the optimized images
+type VulnScanReport struct {
+	Scanner   string                    `json:"scanner"`
+	Original  *VulnScanSummary          `json:"original"`
+	Minified  *VulnScanSummary          `json:"minified"`
+	Removed   []*vulnscan.Vulnerability `json:"removed,omitempty"`
+	Added     []*vulnscan.Vulnerability `json:"added,omitempty"`
+	Reduction map[string]int            `json:"reduction,omitempty"`
+}
+
+// VulnScanSummary contains the vulnerability counts for one of the scanned images
+type VulnScanSummary struct {
+	Image      string         `json:"image"`
+	Total      int            `json:"total"`
+	BySeverity map[string]int `json:"by_severity,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// OCIOutputInfo contains the info about the optimized image saved in the OCI image layout
+type OCIOutputInfo struct {
+	Location       string   `json:"location"`
+	Compression    string   `json:"compression"`
+	ManifestDigest string   `json:"manifest_digest"`
+	Layers         []string `json:"layers"`
+}
+
+// LayerReuseInfo contains the original image layer reuse results (created in the layer-preserving mode)
+type LayerReuseInfo struct {
+	OriginalCount int      `json:"original_count"`
+	ReusedCount   int      `json:"reused_count"`
+	ReusedSize    int64    `json:"reused_size"`
+	ReusedDiffIDs []string `json:"reused_diff_ids,omitempty"`
+	NewLayerSize  int64    `json:"new_layer_size"`
+	NewFileCount  int      `json:"new_file_count"`
+	Whiteouts     int      `json:"whiteouts"`
+}
+
+// VerifyRound contains the optimized image verification results
+// (the paths added to the image before the verification round are also included)
+type VerifyRound struct {
+	Round      int      `json:"round"`
+	Passed     bool     `json:"passed"`
+	Error      string   `json:"error,omitempty"`
+	CallCount  uint64   `json:"call_count"`
+	OkCount    uint64   `json:"ok_count"`
+	ErrorCount uint64   `json:"error_count"`
+	AddedPaths []string `json:"added_paths,omitempty"`
+}
+
+// BuildPlan describes the changes the build command would make (created in the dry-run mode)
+type BuildPlan struct {
+	KeepCount       int                `json:"keep_count"`
+	KeepSize        int64              `json:"keep_size"`
+	DropCount       int                `json:"drop_count"`
+	DropSize        int64              `json:"drop_size"`
+	Keep            []*BuildPlanFile   `json:"keep,omitempty"`
+	Drop            []*BuildPlanFile   `json:"drop,omitempty"`
+	MetadataChanges []*BuildPlanChange `json:"metadata_changes,omitempty"`
+	//the reason the plan doesn't include all build changes (empty for the complete plans)
+	PartialReason string `json:"partial_reason,omitempty"`
+}
+
+// BuildPlanFile is a file in the build plan
+type BuildPlanFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// BuildPlanChange is an image metadata change in the build plan
+type BuildPlanChange struct {
+	Field    string `json:"field"`
+	Original string `json:"original,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+// HTTPProbeReport contains the HTTP probe call statistics
+type HTTPProbeReport struct {
+	CallCount     uint64                    `json:"call_count"`
+	OkCount       uint64                    `json:"ok_count"`
+	ErrorCount    uint64                    `json:"error_count"`
+	CmdCount      uint64                    `json:"command_count"`
+	CmdFailCount  uint64                    `json:"command_fail_count"`
+	EndpointStats []*HTTPProbeEndpointStats `json:"endpoint_stats,omitempty"`
+	TLSEndpoints  []*TLSEndpointInfo        `json:"tls_endpoints,omitempty"`
+}
+
+// TLSEndpointInfo contains the TLS handshake information for a probed endpoint
+type TLSEndpointInfo struct {
+	Endpoint     string         `json:"endpoint"`
+	Version      string         `json:"version"`
+	CipherSuite  string         `json:"cipher_suite"`
+	ALPN         string         `json:"alpn,omitempty"`
+	Certificates []*TLSCertInfo `json:"certificates,omitempty"`
+}
+
+// TLSCertInfo contains the TLS certificate information
+type TLSCertInfo struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serial_number"`
+	DNSNames     []string  `json:"dns_names,omitempty"`
+	IPAddresses  []string  `json:"ip_addresses,omitempty"`
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+}
+
+// HTTPProbeEndpointStats contains the HTTP probe call statistics for an endpoint
+// (the latency values are in milliseconds)
+type HTTPProbeEndpointStats struct {
+	Method      string            `json:"method"`
+	Endpoint    string            `json:"endpoint"`
+	CallCount   uint64            `json:"call_count"`
+	ErrorCount  uint64            `json:"error_count"`
+	LastError   string            `json:"last_error,omitempty"`
+	StatusCodes map[string]uint64 `json:"status_codes"`
+	LatencyMin  float64           `json:"latency_min"`
+	LatencyMax  float64           `json:"latency_max"`
+	LatencyAvg  float64           `json:"latency_avg"`
+	LatencyP50  float64           `json:"latency_p50"`
+	LatencyP95  float64           `json:"latency_p95"`
 }
 
 // Output Version for 'profile'
@@ -129,18 +388,20 @@ const OVProfileCommand = "1.0"
 // ProfileCommand is the 'profile' command report data
 type ProfileCommand struct {
 	Command
-	OriginalImage          string  `json:"original_image"`
-	OriginalImageSize      int64   `json:"original_image_size"`
-	OriginalImageSizeHuman string  `json:"original_image_size_human"`
-	MinifiedImageSize      int64   `json:"minified_image_size"`
-	MinifiedImageSizeHuman string  `json:"minified_image_size_human"`
-	MinifiedImage          string  `json:"minified_image"`
-	MinifiedImageHasData   bool    `json:"minified_image_has_data"`
-	MinifiedBy             float64 `json:"minified_by"`
-	ArtifactLocation       string  `json:"artifact_location"`
-	ContainerReportName    string  `json:"container_report_name"`
-	SeccompProfileName     string  `json:"seccomp_profile_name"`
-	AppArmorProfileName    string  `json:"apparmor_profile_name"`
+	OriginalImage          string           `json:"original_image"`
+	OriginalImageSize      int64            `json:"original_image_size"`
+	OriginalImageSizeHuman string           `json:"original_image_size_human"`
+	MinifiedImageSize      int64            `json:"minified_image_size"`
+	MinifiedImageSizeHuman string           `json:"minified_image_size_human"`
+	MinifiedImage          string           `json:"minified_image"`
+	MinifiedImageHasData   bool             `json:"minified_image_has_data"`
+	MinifiedBy             float64          `json:"minified_by"`
+	ArtifactLocation       string           `json:"artifact_location"`
+	ContainerReportName    string           `json:"container_report_name"`
+	SeccompProfileName     string           `json:"seccomp_profile_name"`
+	AppArmorProfileName    string           `json:"apparmor_profile_name"`
+	CapabilitiesReportName string           `json:"capabilities_report_name"`
+	HTTPProbe              *HTTPProbeReport `json:"http_probe,omitempty"`
 }
 
 // Output Version for 'xray'
@@ -149,15 +410,17 @@ const OVXrayCommand = "1.2"
 // XrayCommand is the 'xray' command report data
 type XrayCommand struct {
 	Command
-	TargetReference      string                      `json:"target_reference"`
-	SourceImage          ImageMetadata               `json:"source_image"`
-	ArtifactLocation     string                      `json:"artifact_location"`
-	ImageReport          *dockerimage.ImageReport    `json:"image_report,omitempty"`
-	ImageStack           []*reverse.ImageInfo        `json:"image_stack"`
-	ImageLayers          []*dockerimage.LayerReport  `json:"image_layers"`
-	ImageArchiveLocation string                      `json:"image_archive_location"`
-	RawImageManifest     *dockerimage.ManifestObject `json:"raw_image_manifest,omitempty"`
-	RawImageConfig       *dockerimage.ConfigObject   `json:"raw_image_config,omitempty"`
+	TargetReference      string                       `json:"target_reference"`
+	SourceImage          ImageMetadata                `json:"source_image"`
+	ArtifactLocation     string                       `json:"artifact_location"`
+	ImageReport          *dockerimage.ImageReport     `json:"image_report,omitempty"`
+	ImageStack           []*reverse.ImageInfo         `json:"image_stack"`
+	ImageLayers          []*dockerimage.LayerReport   `json:"image_layers"`
+	ImageArchiveLocation string                       `json:"image_archive_location"`
+	RawImageManifest     *dockerimage.ManifestObject  `json:"raw_image_manifest,omitempty"`
+	RawImageConfig       *dockerimage.ConfigObject    `json:"raw_image_config,omitempty"`
+	SBOM                 *SBOMInfo                    `json:"sbom,omitempty"`
+	LayerExport          *dockerimage.LayerExportInfo `json:"layer_export,omitempty"`
 }
 
 // Output Version for 'lint'
@@ -169,11 +432,28 @@ type LintCommand struct {
 	TargetType      string                   `json:"target_type"`
 	TargetReference string                   `json:"target_reference"`
 	BuildContextDir string                   `json:"build_context_dir,omitempty"`
+	RuntimeReport   string                   `json:"runtime_report,omitempty"` //the runtime data source for the runtime-informed checks
 	HitsCount       int                      `json:"hits_count"`
 	NoHitsCount     int                      `json:"nohits_count"`
 	ErrorsCount     int                      `json:"errors_count"`
-	Hits            map[string]*check.Result `json:"hits,omitempty"`   //map[CHECK_ID]CHECK_RESULT
-	Errors          map[string]error         `json:"errors,omitempty"` //map[CHECK_ID]ERROR_INFO
+	SuppressedCount int                      `json:"suppressed_count,omitempty"`
+	Hits            map[string]*check.Result `json:"hits,omitempty"`       //map[CHECK_ID]CHECK_RESULT
+	Errors          map[string]error         `json:"errors,omitempty"`     //map[CHECK_ID]ERROR_INFO
+	Suppressed      []*check.Suppression     `json:"suppressed,omitempty"` //inline and baseline suppressions
+	Files           []*LintFileResult        `json:"files,omitempty"`      //batch lint mode (directory targets)
+}
+
+// LintFileResult contains the lint results for one of the Dockerfiles in the batch lint mode
+type LintFileResult struct {
+	DockerfilePath  string                   `json:"dockerfile_path"`
+	Error           string                   `json:"error,omitempty"`
+	HitsCount       int                      `json:"hits_count"`
+	NoHitsCount     int                      `json:"nohits_count"`
+	ErrorsCount     int                      `json:"errors_count"`
+	SuppressedCount int                      `json:"suppressed_count,omitempty"`
+	Hits            map[string]*check.Result `json:"hits,omitempty"`
+	Errors          map[string]error         `json:"errors,omitempty"`
+	Suppressed      []*check.Suppression     `json:"suppressed,omitempty"`
 }
 
 // Output Version for 'containerize'
@@ -182,6 +462,33 @@ const OVContainerizeCommand = "1.0"
 // ContainerizeCommand is the 'containerize' command report data
 type ContainerizeCommand struct {
 	Command
+	SourceDir  string `json:"source_dir"`
+	AppType    string `json:"app_type,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+	BuildTool  string `json:"build_tool,omitempty"`
+	Dockerfile string `json:"dockerfile,omitempty"`
+	ImageTag   string `json:"image_tag,omitempty"`
+	//the build command report and exit code (when the built image is slimmed)
+	SlimReport   string `json:"slim_report,omitempty"`
+	SlimExitCode int    `json:"slim_exit_code,omitempty"`
+}
+
+// Output Version for 'instrument'
+const OVInstrumentCommand = "1.0"
+
+// InstrumentCommand is the 'instrument' command report data
+type InstrumentCommand struct {
+	Command
+	TargetReference string   `json:"target_reference"`
+	ImageTag        string   `json:"image_tag,omitempty"`
+	ImageID         string   `json:"image_id,omitempty"`
+	ImageSize       int64    `json:"image_size,omitempty"`
+	SensorVersion   string   `json:"sensor_version,omitempty"`
+	AppEntrypoint   []string `json:"app_entrypoint,omitempty"`
+	AppCmd          []string `json:"app_cmd,omitempty"`
+	AppUser         string   `json:"app_user,omitempty"`
+	//the location of the collected sensor data in the instrumented containers
+	ArtifactsPath string `json:"artifacts_path,omitempty"`
 }
 
 // Output Version for 'convert'
@@ -190,6 +497,10 @@ const OVConvertCommand = "1.0"
 // ConvertCommand is the 'convert' command report data
 type ConvertCommand struct {
 	Command
+	Source      string                   `json:"source"`
+	Destination string                   `json:"destination"`
+	MediaTypes  string                   `json:"media_types,omitempty"`
+	Convert     *imageformat.ConvertInfo `json:"convert,omitempty"`
 }
 
 // Output Version for 'edit'
@@ -214,6 +525,139 @@ const OVProbeCommand = "1.0"
 // ProbeCommand is the 'probe' command report data
 type ProbeCommand struct {
 	Command
+	TargetReference string           `json:"target_reference"`
+	TargetHost      string           `json:"target_host"`
+	TargetPorts     []string         `json:"target_ports"`
+	HTTPProbe       *HTTPProbeReport `json:"http_probe,omitempty"`
+}
+
+// Output Version for 'verify'
+const OVVerifyCommand = "1.0"
+
+// VerifyCommand is the 'verify' command report data
+type VerifyCommand struct {
+	Command
+	TargetReference string              `json:"target_reference"`
+	SlimImage       string              `json:"slim_image"`
+	Compatible      bool                `json:"compatible"`
+	CompareCount    int                 `json:"compare_count"`
+	MatchCount      int                 `json:"match_count"`
+	Differences     []*VerifyDifference `json:"differences,omitempty"`
+	OriginalProbe   *HTTPProbeReport    `json:"original_probe,omitempty"`
+	SlimProbe       *HTTPProbeReport    `json:"slim_probe,omitempty"`
+}
+
+// VerifyDifference describes a difference between the original and the optimized image responses
+type VerifyDifference struct {
+	Method   string `json:"method"`
+	Protocol string `json:"protocol"`
+	Resource string `json:"resource"`
+	//response part: 'response', 'status', 'header' or 'body'
+	Field string `json:"field"`
+	//header name (for the 'header' differences)
+	Name     string `json:"name,omitempty"`
+	Original string `json:"original"`
+	Slim     string `json:"slim"`
+}
+
+// Output Version for 'doctor'
+const OVDoctorCommand = "1.0"
+
+// DoctorCommand is the 'doctor' command report data
+type DoctorCommand struct {
+	Command
+	TargetReference string               `json:"target_reference"`
+	SlimImage       string               `json:"slim_image"`
+	Healthy         bool                 `json:"healthy"`
+	MissingPaths    []*DoctorMissingPath `json:"missing_paths,omitempty"`
+	IncludeFlags    []string             `json:"include_flags,omitempty"`
+	OriginalProbe   *HTTPProbeReport     `json:"original_probe,omitempty"`
+	SlimProbe       *HTTPProbeReport     `json:"slim_probe,omitempty"`
+}
+
+// DoctorMissingPath describes a path missing in the optimized image
+// (the lookups fail in the optimized image, but not in the original image)
+type DoctorMissingPath struct {
+	Path string `json:"path"`
+	//the path is executed
+	Exec bool `json:"exec,omitempty"`
+	//the path is used by the app in the original image
+	//(otherwise the path is looked up only in the optimized image)
+	Confirmed bool     `json:"confirmed"`
+	Syscalls  []string `json:"syscalls"`
+	//the build command flag to keep the path
+	IncludeFlag string `json:"include_flag"`
+}
+
+// Output Version for 'extract'
+const OVExtractCommand = "1.0"
+
+// ExtractCommand is the 'extract' command report data
+type ExtractCommand struct {
+	Command
+	TargetReference string                       `json:"target_reference"`
+	ImageID         string                       `json:"image_id,omitempty"`
+	OutputType      string                       `json:"output_type"`
+	Extract         *dockerimage.PathExtractInfo `json:"extract,omitempty"`
+	//the new image created from the extracted data ('new-image' output type)
+	NewImage *ExtractImageInfo `json:"new_image,omitempty"`
+}
+
+// ExtractImageInfo describes the image created from the extracted data
+type ExtractImageInfo struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+	Size int64  `json:"size"`
+}
+
+// Output Version for 'history'
+const OVHistoryCommand = "1.0"
+
+// HistoryCommand is the 'history' command report data
+type HistoryCommand struct {
+	Command
+	TargetReference string            `json:"target_reference"`
+	History         *ImageHistoryInfo `json:"history,omitempty"`
+}
+
+// ImageHistoryInfo contains the image stack info reverse engineered from the image history
+type ImageHistoryInfo struct {
+	ID               string               `json:"id"`
+	Tags             []string             `json:"tags,omitempty"`
+	Size             int64                `json:"size"`
+	SizeHuman        string               `json:"size_human"`
+	CreateTime       string               `json:"create_time"`
+	InstructionCount int                  `json:"instruction_count"`
+	ImageStack       []*reverse.ImageInfo `json:"image_stack"`
+}
+
+// Output Version for 'policy'
+const OVPolicyCommand = "1.0"
+
+// PolicyCommand is the 'policy' command report data
+type PolicyCommand struct {
+	Command
+	TargetReport string          `json:"target_report"`
+	TargetType   string          `json:"target_type"` //the target report type
+	PolicyFiles  []string        `json:"policy_files"`
+	Passed       bool            `json:"passed"`
+	PassCount    int             `json:"pass_count"`
+	FailCount    int             `json:"fail_count"`
+	WarnCount    int             `json:"warn_count"`
+	ErrorCount   int             `json:"error_count"`
+	SkipCount    int             `json:"skip_count"`
+	Results      []*PolicyResult `json:"results,omitempty"`
+}
+
+// PolicyResult is the evaluation result for a policy
+type PolicyResult struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Level      string   `json:"level"`
+	Skipped    bool     `json:"skipped,omitempty"`
+	Passed     bool     `json:"passed"`
+	Violations []string `json:"violations,omitempty"`
+	Error      string   `json:"error,omitempty"`
 }
 
 // Output Version for 'server'
@@ -240,9 +684,78 @@ const OVRegistryCommand = "1.0"
 type RegistryCommand struct {
 	Command
 	TargetReference string `json:"target_reference"`
+	//the 'registry copy' results (the image and its signature/attestation tags)
+	Copy      *RegistryCopyInfo   `json:"copy,omitempty"`
+	Artifacts []*RegistryCopyInfo `json:"artifacts,omitempty"`
+	//the 'registry tags' and 'registry inspect' results
+	Tags    []*RegistryTagInfo    `json:"tags,omitempty"`
+	Inspect *RegistryManifestInfo `json:"inspect,omitempty"`
+	//the 'registry manifest' results
+	ManifestList *RegistryManifestList `json:"manifest_list,omitempty"`
+}
+
+// RegistryTagInfo describes a repository tag
+type RegistryTagInfo struct {
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	//the compressed image size (the config and the layers; not set for the multi-platform images)
+	Size      int64    `json:"size,omitempty"`
+	Platforms []string `json:"platforms,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// RegistryManifestInfo is the image manifest (and the image config) from the registry
+type RegistryManifestInfo struct {
+	Reference string          `json:"reference"`
+	Platform  string          `json:"platform,omitempty"`
+	Digest    string          `json:"digest"`
+	MediaType string          `json:"media_type"`
+	Manifest  json.RawMessage `json:"manifest"`
+	Config    json.RawMessage `json:"config,omitempty"`
+	//the platform image manifests for the multi-platform images
+	Platforms []*RegistryManifestInfo `json:"platforms,omitempty"`
+}
+
+// RegistryCopyInfo describes the manifest copied from one registry to another
+type RegistryCopyInfo struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	Digest      string   `json:"digest"`
+	MediaType   string   `json:"media_type"`
+	Platforms   []string `json:"platforms,omitempty"`
+}
+
+// RegistryManifestList is the local manifest list (multi-platform image) created with 'registry manifest create'
+// (it's saved in the state directory until it's pushed)
+type RegistryManifestList struct {
+	Name        string                       `json:"name"`
+	MediaType   string                       `json:"media_type"`
+	Annotations map[string]string            `json:"annotations,omitempty"`
+	Manifests   []*RegistryManifestListEntry `json:"manifests"`
+	//the manifest list digest (set after the manifest list is pushed)
+	Digest string `json:"digest,omitempty"`
+}
+
+// RegistryManifestListEntry is a platform image in the manifest list
+type RegistryManifestListEntry struct {
+	//the image reference used to add the image
+	Source string `json:"source"`
+	//the image digest reference
+	Image        string            `json:"image"`
+	Digest       string            `json:"digest"`
+	MediaType    string            `json:"media_type"`
+	Size         int64             `json:"size"`
+	OS           string            `json:"os"`
+	Architecture string            `json:"architecture"`
+	Variant      string            `json:"variant,omitempty"`
+	OSVersion    string            `json:"os_version,omitempty"`
+	OSFeatures   []string          `json:"os_features,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
 }
 
 func (cmd *Command) init(containerized bool) {
+	cmd.SchemaVersion = SchemaVersion
 	cmd.Containerized = containerized
 	cmd.Engine = version.Current()
 
@@ -269,6 +782,21 @@ func NewBuildCommand(reportLocation string, containerized bool) *BuildCommand {
 	return cmd
 }
 
+// NewBatchBuildCommand creates a new 'build' command report for multiple targets
+func NewBatchBuildCommand(reportLocation string, containerized bool) *BatchBuildCommand {
+	cmd := &BatchBuildCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Version:        OVBatchBuildCommand,
+			Type:           command.Build,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
 // NewProfileCommand creates a new 'profile' command report
 func NewProfileCommand(reportLocation string, containerized bool) *ProfileCommand {
 	cmd := &ProfileCommand{
@@ -329,6 +857,21 @@ func NewContainerizeCommand(reportLocation string, containerized bool) *Containe
 	return cmd
 }
 
+// NewInstrumentCommand creates a new 'instrument' command report
+func NewInstrumentCommand(reportLocation string, containerized bool) *InstrumentCommand {
+	cmd := &InstrumentCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Version:        OVInstrumentCommand, //instrument command 'results' version (report and artifacts)
+			Type:           command.Instrument,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
 // NewConvertCommand creates a new 'convert' command report
 func NewConvertCommand(reportLocation string, containerized bool) *ConvertCommand {
 	cmd := &ConvertCommand{
@@ -389,6 +932,81 @@ func NewProbeCommand(reportLocation string, containerized bool) *ProbeCommand {
 	return cmd
 }
 
+// NewVerifyCommand creates a new 'verify' command report
+func NewVerifyCommand(reportLocation string, containerized bool) *VerifyCommand {
+	cmd := &VerifyCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Version:        OVVerifyCommand, //verify command 'results' version (report and artifacts)
+			Type:           command.Verify,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
+// NewDoctorCommand creates a new 'doctor' command report
+func NewDoctorCommand(reportLocation string, containerized bool) *DoctorCommand {
+	cmd := &DoctorCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Version:        OVDoctorCommand, //doctor command 'results' version (report and artifacts)
+			Type:           command.Doctor,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
+// NewExtractCommand creates a new 'extract' command report
+func NewExtractCommand(reportLocation string, containerized bool) *ExtractCommand {
+	cmd := &ExtractCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Version:        OVExtractCommand, //extract command 'results' version (report and artifacts)
+			Type:           command.Extract,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
+// NewHistoryCommand creates a new 'history' command report
+func NewHistoryCommand(reportLocation string, containerized bool) *HistoryCommand {
+	cmd := &HistoryCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Version:        OVHistoryCommand, //history command 'results' version (report and artifacts)
+			Type:           command.History,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
+// NewPolicyCommand creates a new 'policy' command report
+func NewPolicyCommand(reportLocation string, containerized bool) *PolicyCommand {
+	cmd := &PolicyCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Version:        OVPolicyCommand, //policy command 'results' version (report and artifacts)
+			Type:           command.Policy,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
 // NewServerCommand creates a new 'server' command report
 func NewServerCommand(reportLocation string, containerized bool) *ServerCommand {
 	cmd := &ServerCommand{
@@ -452,8 +1070,10 @@ func (p *Command) saveInfo(info interface{}) bool {
 			_, err := os.Stat(dirName)
 			if os.IsNotExist(err) {
 				os.MkdirAll(dirName, 0777)
-				_, err = os.Stat(dirName)
-				errutil.FailOn(err)
+				if _, err = os.Stat(dirName); err != nil {
+					log.Errorf("report.saveInfo - error creating report directory (%s) - %v", dirName, err)
+					return false
+				}
 			}
 		}
 
@@ -462,7 +1082,10 @@ func (p *Command) saveInfo(info interface{}) bool {
 		encoder.SetEscapeHTML(false)
 		encoder.SetIndent("", "  ")
 		err := encoder.Encode(info)
-		errutil.FailOn(err)
+		if err != nil {
+			log.Errorf("report.saveInfo - error encoding report - %v", err)
+			return false
+		}
 
 		err = ioutil.WriteFile(p.reportLocation, reportData.Bytes(), 0644)
 		if err != nil && os.IsPermission(err) {
@@ -476,7 +1099,11 @@ func (p *Command) saveInfo(info interface{}) bool {
 			}
 		}
 
-		errutil.FailOn(err)
+		if err != nil {
+			log.Errorf("report.saveInfo - error saving report (%s) - %v", p.reportLocation, err)
+			return false
+		}
+
 		return true
 	}
 
@@ -493,6 +1120,11 @@ func (p *BuildCommand) Save() bool {
 	return p.saveInfo(p)
 }
 
+// Save saves the Build command report data for multiple targets to the configured location
+func (p *BatchBuildCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
 // Save saves the Profile command report data to the configured location
 func (p *ProfileCommand) Save() bool {
 	return p.saveInfo(p)
@@ -507,3 +1139,33 @@ func (p *XrayCommand) Save() bool {
 func (p *LintCommand) Save() bool {
 	return p.saveInfo(p)
 }
+
+// Save saves the Probe command report data to the configured location
+func (p *ProbeCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the Verify command report data to the configured location
+func (p *VerifyCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the Doctor command report data to the configured location
+func (p *DoctorCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the Extract command report data to the configured location
+func (p *ExtractCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the History command report data to the configured location
+func (p *HistoryCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the Policy command report data to the configured location
+func (p *PolicyCommand) Save() bool {
+	return p.saveInfo(p)
+}
@@ -53,6 +53,11 @@ type ProcessInfo struct {
 	Cwd       string `json:"cwd"`
 	Root      string `json:"root"`
 	ParentPid int32  `json:"ppid"`
+	//the time of the first monitored event for the process (unix time in nanoseconds)
+	FirstEventTime int64 `json:"first_event_time,omitempty"`
+	//the effective user and group IDs for the process (nil if unknown)
+	UID *uint32 `json:"uid,omitempty"`
+	GID *uint32 `json:"gid,omitempty"`
 }
 
 // FileInfo contains various file object and activity metadata
@@ -63,6 +68,9 @@ type FileInfo struct {
 	ReadCount    uint32 `json:"reads,omitempty"`
 	WriteCount   uint32 `json:"writes,omitempty"`
 	ExeCount     uint32 `json:"execs,omitempty"`
+	//the first and the last event times (unix time in nanoseconds)
+	FirstEventTime int64 `json:"first_event_time,omitempty"`
+	LastEventTime  int64 `json:"last_event_time,omitempty"`
 }
 
 // FanMonitorReport is a file monitoring report
@@ -70,6 +78,8 @@ type FanMonitorReport struct {
 	MonitorPid       int                             `json:"monitor_pid"`
 	MonitorParentPid int                             `json:"monitor_ppid"`
 	EventCount       uint32                          `json:"event_count"`
+	StartTime        int64                           `json:"start_time,omitempty"`
+	StopTime         int64                           `json:"stop_time,omitempty"`
 	MainProcess      *ProcessInfo                    `json:"main_process"`
 	Processes        map[string]*ProcessInfo         `json:"processes"`
 	ProcessFiles     map[string]map[string]*FileInfo `json:"process_files"`
@@ -96,6 +106,7 @@ type PtMonitorReport struct {
 	SyscallNum   uint32                     `json:"syscall_num"`
 	SyscallStats map[string]SyscallStatInfo `json:"syscall_stats"`
 	FSActivity   map[string]*FSActivityInfo `json:"fs_activity"`
+	FSMissing    map[string]*FSMissingInfo  `json:"fs_missing,omitempty"`
 }
 
 type FSActivityInfo struct {
@@ -106,6 +117,14 @@ type FSActivityInfo struct {
 	IsSubdir     bool             `json:"is_subdir"`
 }
 
+// FSMissingInfo contains the info about the failed lookups for a missing file (ENOENT)
+type FSMissingInfo struct {
+	OpsAll   uint64           `json:"ops_all"`
+	Exec     bool             `json:"exec,omitempty"`
+	Syscalls map[int]struct{} `json:"syscalls"`
+	Pids     map[int]struct{} `json:"pids"`
+}
+
 // ArtifactProps contains various file system artifact properties
 type ArtifactProps struct {
 	FileType   ArtifactType    `json:"-"` //todo
@@ -160,7 +179,38 @@ func (p *ArtifactProps) MarshalJSON() ([]byte, error) {
 
 // ImageReport contains image report fields
 type ImageReport struct {
-	Files []*ArtifactProps `json:"files"`
+	Files       []*ArtifactProps   `json:"files"`
+	IncludeDeps []*IncludeDepsInfo `json:"include_deps,omitempty"`
+	//the runtime essentials detected (and kept) in the 'runtime essentials' mode
+	RuntimeEssentials *RuntimeEssentialsInfo `json:"runtime_essentials,omitempty"`
+	//the extended attributes (xattrs, ACLs, file capabilities) that couldn't be preserved
+	XattrIssues []*XattrIssueInfo `json:"xattr_issues,omitempty"`
+	//the extended attributes for the slim filesystem objects (keyed by path)
+	//(needed because the container file transfers only keep the file capabilities)
+	Xattrs map[string]map[string][]byte `json:"xattrs,omitempty"`
+}
+
+// XattrIssueInfo describes an extended attribute that couldn't be preserved in the slim filesystem
+type XattrIssueInfo struct {
+	Path  string `json:"path"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// RuntimeEssentialsInfo contains the detected runtime essentials usage
+type RuntimeEssentialsInfo struct {
+	TLS      bool `json:"tls"`
+	Timezone bool `json:"timezone"`
+	Users    bool `json:"users"`
+}
+
+// IncludeDepsInfo contains the dependency closure for an included binary
+type IncludeDepsInfo struct {
+	Target          string   `json:"target"`
+	Interpreter     string   `json:"interpreter,omitempty"`
+	Libraries       []string `json:"libraries,omitempty"`
+	DlopenLibraries []string `json:"dlopen_libraries,omitempty"`
+	Files           []string `json:"files"`
 }
 
 // MonitorReports contains monitoring report fields
@@ -176,11 +226,24 @@ type SystemReport struct {
 	Distro  DistroInfo `json:"distro"`
 }
 
+// ListenPortInfo describes a port the container was listening on
+type ListenPortInfo struct {
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+}
+
+// NetworkReport contains the container network activity info
+type NetworkReport struct {
+	ListenPorts []*ListenPortInfo `json:"listen_ports,omitempty"`
+}
+
 // ContainerReport contains container report fields
 type ContainerReport struct {
 	System   SystemReport   `json:"system"`
 	Monitors MonitorReports `json:"monitors"`
 	Image    ImageReport    `json:"image"`
+	Network  *NetworkReport `json:"network,omitempty"`
 }
 
 // PermSetFromFlags maps artifact flags to permissions
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// EventSchemaVersion is the version of the command event stream schema (saved in the 'schema_version' event field)
+const EventSchemaVersion = "1.0"
+
+// SchemaEvent is the command event schema name
+// (it's not a report schema, so it's not in the report schema names)
+const SchemaEvent = "event"
+
+// Event types
+const (
+	EventTypeState   = "state"
+	EventTypeInfo    = "info"
+	EventTypeError   = "error"
+	EventTypeMessage = "message"
+	EventTypePrompt  = "prompt"
+	EventTypeLog     = "log"
+)
+
+// Event is a command output event (one JSON object per line in the 'jsonl' event stream)
+type Event struct {
+	SchemaVersion string    `json:"schema_version"`
+	Seq           uint64    `json:"seq"`
+	Time          time.Time `json:"time"`
+	Command       string    `json:"cmd"`
+	Type          string    `json:"type"`
+	//the state name, info type, error type or log type
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message,omitempty"`
+	//the event values (the original value types are kept)
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventSchema returns the JSON Schema for the command events
+func EventSchema() *JSONSchema {
+	sb := &schemaBuilder{
+		definitions: map[string]*JSONSchema{},
+		names:       map[reflect.Type]string{},
+	}
+
+	root := sb.structSchema(reflect.TypeOf(Event{}))
+	root.Schema = schemaDialect
+	root.ID = fmt.Sprintf("urn:docker-slim:event:%s", EventSchemaVersion)
+	root.Title = "docker-slim command event"
+	eventType := &JSONSchema{}
+	for _, name := range []string{
+		EventTypeState,
+		EventTypeInfo,
+		EventTypeError,
+		EventTypeMessage,
+		EventTypePrompt,
+		EventTypeLog,
+	} {
+		eventType.AnyOf = append(eventType.AnyOf, &JSONSchema{Type: "string", Const: name})
+	}
+
+	root.Properties["type"] = eventType
+
+	if len(sb.definitions) > 0 {
+		root.Definitions = sb.definitions
+	}
+
+	return root
+}
+
+// EventSchemaData returns the JSON Schema document for the command events
+func EventSchemaData() ([]byte, error) {
+	return json.MarshalIndent(EventSchema(), "", "  ")
+}
@@ -0,0 +1,424 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+)
+
+// HTMLFilesMax is the max number of files listed in each file list section of the HTML reports
+const HTMLFilesMax = 100
+
+// HTMLSizeBar is a labeled size value for the HTML report size charts
+type HTMLSizeBar struct {
+	Label   string
+	Info    string
+	Size    uint64
+	Percent float64
+}
+
+type xrayHTMLView struct {
+	Report     *XrayCommand
+	Generated  string
+	Layers     []*HTMLSizeBar
+	Dockerfile []string
+	TopFiles   []*dockerimage.FileSizeInfo
+	TopDirs    []*dockerimage.DirSizeInfo
+}
+
+type buildHTMLView struct {
+	Report       *BuildCommand
+	Generated    string
+	Sizes        []*HTMLSizeBar
+	Dockerfile   []string
+	Files        *BuildPlan
+	KeptFiles    []*BuildPlanFile
+	RemovedFiles []*BuildPlanFile
+}
+
+// SaveHTML saves the xray command report as a standalone HTML page
+func (p *XrayCommand) SaveHTML(location string) error {
+	view := &xrayHTMLView{
+		Report:     p,
+		Generated:  time.Now().UTC().Format(time.RFC3339),
+		Dockerfile: dockerfileLines(p.ImageStack),
+	}
+
+	for _, layer := range p.ImageLayers {
+		bar := &HTMLSizeBar{
+			Label: fmt.Sprintf("%d", layer.Index),
+			Size:  layer.Stats.AllSize,
+		}
+
+		if layer.ChangeInstruction != nil {
+			bar.Info = layer.ChangeInstruction.Snippet
+		}
+
+		view.Layers = append(view.Layers, bar)
+	}
+
+	setBarPercents(view.Layers)
+
+	if p.ImageReport != nil && p.ImageReport.Sizes != nil && p.ImageReport.Sizes.Image != nil {
+		view.TopFiles = p.ImageReport.Sizes.Image.Files
+		view.TopDirs = p.ImageReport.Sizes.Image.Dirs
+	}
+
+	return saveHTML(location, xrayHTMLTemplate, view)
+}
+
+// SaveHTML saves the build command report as a standalone HTML page
+// (the kept and removed files are optional because they are not a part of the command report)
+func (p *BuildCommand) SaveHTML(location string, files *BuildPlan) error {
+	view := &buildHTMLView{
+		Report:     p,
+		Generated:  time.Now().UTC().Format(time.RFC3339),
+		Dockerfile: dockerfileLines(p.ImageStack),
+		Files:      files,
+	}
+
+	view.Sizes = []*HTMLSizeBar{
+		{
+			Label: "original",
+			Info:  p.TargetReference,
+			Size:  uint64(p.SourceImage.Size),
+		},
+	}
+
+	if p.MinifiedImage != "" {
+		view.Sizes = append(view.Sizes, &HTMLSizeBar{
+			Label: "optimized",
+			Info:  p.MinifiedImage,
+			Size:  uint64(p.MinifiedImageSize),
+		})
+	}
+
+	setBarPercents(view.Sizes)
+
+	if files != nil {
+		view.KeptFiles = limitFiles(files.Keep)
+		view.RemovedFiles = limitFiles(files.Drop)
+	}
+
+	return saveHTML(location, buildHTMLTemplate, view)
+}
+
+func setBarPercents(bars []*HTMLSizeBar) {
+	var max uint64
+	for _, bar := range bars {
+		if bar.Size > max {
+			max = bar.Size
+		}
+	}
+
+	if max == 0 {
+		return
+	}
+
+	for _, bar := range bars {
+		bar.Percent = float64(bar.Size) * 100 / float64(max)
+	}
+}
+
+func limitFiles(files []*BuildPlanFile) []*BuildPlanFile {
+	if len(files) > HTMLFilesMax {
+		return files[:HTMLFilesMax]
+	}
+
+	return files
+}
+
+// dockerfileLines recreates the Dockerfile from the image stack
+// (the same way the reverse engineered Dockerfile is created)
+func dockerfileLines(stack []*reverse.ImageInfo) []string {
+	if len(stack) == 0 {
+		return nil
+	}
+
+	lines := []string{"FROM scratch"}
+	for idx, info := range stack {
+		if idx > 0 {
+			lines = append(lines, "", "# new image")
+		}
+
+		for _, instInfo := range info.Instructions {
+			lines = append(lines, instInfo.CommandAll)
+		}
+
+		if !info.IsTopImage {
+			lines = append(lines, fmt.Sprintf("# end of image: %s (id: %s tags: %s)",
+				info.FullName, info.ID, strings.Join(info.RawTags, ",")))
+		}
+	}
+
+	return lines
+}
+
+var htmlFuncs = template.FuncMap{
+	"bytes": func(value interface{}) string {
+		switch v := value.(type) {
+		case uint64:
+			return humanize.Bytes(v)
+		case int64:
+			if v < 0 {
+				return ""
+			}
+
+			return humanize.Bytes(uint64(v))
+		case int:
+			return humanize.Bytes(uint64(v))
+		}
+
+		return fmt.Sprintf("%v", value)
+	},
+	"join": strings.Join,
+	"percent": func(value float64) string {
+		return fmt.Sprintf("%.1f%%", value)
+	},
+}
+
+func saveHTML(location string, pageTemplate string, view interface{}) error {
+	tmpl, err := template.New("report").Funcs(htmlFuncs).Parse(htmlHeader + pageTemplate + htmlFooter)
+	if err != nil {
+		return err
+	}
+
+	var data bytes.Buffer
+	if err := tmpl.Execute(&data, view); err != nil {
+		return err
+	}
+
+	if dirName := filepath.Dir(location); dirName != "." {
+		if err := os.MkdirAll(dirName, 0777); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(location, data.Bytes(), 0644)
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Type}} report - {{.Report.TargetReference}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #24292f; background: #f6f8fa; }
+header { background: #0b3d62; color: #fff; padding: 16px 32px; }
+header h1 { margin: 0; font-size: 22px; }
+header p { margin: 4px 0 0; font-size: 13px; opacity: 0.85; }
+main { padding: 16px 32px 32px; }
+section { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; margin: 16px 0; padding: 12px 20px; }
+h2 { font-size: 17px; margin: 4px 0 12px; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eaeef2; vertical-align: top; }
+th { background: #f6f8fa; }
+td.num { text-align: right; white-space: nowrap; }
+td.key { width: 200px; color: #57606a; }
+code, pre { font-family: SFMono-Regular, Consolas, "Liberation Mono", monospace; font-size: 12px; }
+pre { background: #f6f8fa; padding: 12px; overflow-x: auto; white-space: pre-wrap; word-break: break-all; }
+.bar { background: #eaeef2; height: 14px; min-width: 200px; }
+.bar div { background: #2f81f7; height: 14px; }
+.ok { color: #1a7f37; }
+.error { color: #cf222e; }
+.muted { color: #57606a; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Report.Type}}: {{.Report.TargetReference}}</h1>
+<p>docker-slim {{.Report.Version}} | state: {{.Report.State}}{{if .Report.Error}} | error: {{.Report.Error}}{{end}} | generated: {{.Generated}}</p>
+</header>
+<main>
+`
+
+const htmlFooter = `
+</main>
+</body>
+</html>
+`
+
+const htmlImageSection = `
+<section>
+<h2>Image</h2>
+<table>
+<tr><td class="key">ID</td><td><code>{{.Identity.ID}}</code></td></tr>
+{{if .Identity.Names}}<tr><td class="key">Names</td><td>{{join .Identity.Names ", "}}</td></tr>{{end}}
+<tr><td class="key">Size</td><td>{{.SizeHuman}}</td></tr>
+<tr><td class="key">Created</td><td>{{.CreateTime}}</td></tr>
+<tr><td class="key">OS / Architecture</td><td>{{.OS}} / {{.Architecture}}</td></tr>
+{{if .User}}<tr><td class="key">User</td><td>{{.User}}</td></tr>{{end}}
+{{if .WorkDir}}<tr><td class="key">Workdir</td><td>{{.WorkDir}}</td></tr>{{end}}
+{{if .ContainerEntry.Entrypoint}}<tr><td class="key">Entrypoint</td><td><code>{{join .ContainerEntry.Entrypoint " "}}</code></td></tr>{{end}}
+{{if .ContainerEntry.Cmd}}<tr><td class="key">Cmd</td><td><code>{{join .ContainerEntry.Cmd " "}}</code></td></tr>{{end}}
+{{if .ExposedPorts}}<tr><td class="key">Exposed ports</td><td>{{join .ExposedPorts ", "}}</td></tr>{{end}}
+{{if .Volumes}}<tr><td class="key">Volumes</td><td>{{join .Volumes ", "}}</td></tr>{{end}}
+{{if .EnvVars}}<tr><td class="key">Env vars</td><td>{{range .EnvVars}}<code>{{.}}</code><br>{{end}}</td></tr>{{end}}
+{{if .Labels}}<tr><td class="key">Labels</td><td>{{range $k, $v := .Labels}}<code>{{$k}}={{$v}}</code><br>{{end}}</td></tr>{{end}}
+</table>
+</section>
+`
+
+const htmlDockerfileSection = `
+{{if .Dockerfile}}
+<section>
+<h2>Reverse engineered Dockerfile</h2>
+<pre>{{range .Dockerfile}}{{.}}
+{{end}}</pre>
+</section>
+{{end}}
+`
+
+const xrayHTMLTemplate = `{{with .Report.SourceImage}}` + htmlImageSection + `{{end}}
+{{if .Layers}}
+<section>
+<h2>Layers</h2>
+<table>
+<tr><th>Index</th><th>Size</th><th></th><th>Added</th><th>Modified</th><th>Deleted</th><th>Instruction</th></tr>
+{{range $idx, $bar := .Layers}}{{with index $.Report.ImageLayers $idx}}
+<tr>
+<td>{{$bar.Label}}</td>
+<td class="num">{{bytes $bar.Size}}</td>
+<td><div class="bar"><div style="width: {{percent $bar.Percent}}"></div></div></td>
+<td class="num">{{.Changes.Added}}</td>
+<td class="num">{{.Changes.Modified}}</td>
+<td class="num">{{.Changes.Deleted}}</td>
+<td><code>{{$bar.Info}}</code></td>
+</tr>
+{{end}}{{end}}
+</table>
+</section>
+{{end}}
+` + htmlDockerfileSection + `
+{{with .Report.ImageReport}}
+<section>
+<h2>Analysis</h2>
+<table>
+<tr><td class="key">Duplicate files</td><td>{{.Stats.DuplicateFileCount}} ({{bytes .Stats.DuplicateFileWastedSize}} wasted)</td></tr>
+<tr><td class="key">Deleted files</td><td>{{.Stats.DeletedFileCount}} ({{bytes .Stats.DeletedFileSize}})</td></tr>
+{{with .WastedSpace}}<tr><td class="key">Wasted space</td><td>{{bytes .WastedSize}} of {{bytes .TotalSize}} (efficiency: {{printf "%.2f" .Efficiency}})</td></tr>{{end}}
+{{with .Packages}}<tr><td class="key">OS packages</td><td>{{.Count}} ({{bytes .TotalSize}})</td></tr>{{end}}
+{{with .Secrets}}<tr><td class="key">Secrets</td><td class="error">{{.Count}}</td></tr>{{end}}
+{{with .ELFDeps}}<tr><td class="key">ELF binaries</td><td>{{.ExecutableCount}} executables, {{.LibraryCount}} libraries ({{.BrokenExecutableCount}} broken executables, {{.BrokenLibraryCount}} broken libraries)</td></tr>{{end}}
+</table>
+</section>
+{{end}}
+{{if .TopFiles}}
+<section>
+<h2>Largest files</h2>
+<table>
+<tr><th>Path</th><th>Size</th><th>Layer</th></tr>
+{{range .TopFiles}}<tr><td><code>{{.Path}}</code></td><td class="num">{{bytes .Size}}</td><td class="num">{{.Layer}}</td></tr>
+{{end}}
+</table>
+</section>
+{{end}}
+{{if .TopDirs}}
+<section>
+<h2>Largest directories</h2>
+<table>
+<tr><th>Path</th><th>Size</th><th>Files</th></tr>
+{{range .TopDirs}}<tr><td><code>{{.Path}}</code></td><td class="num">{{bytes .Size}}</td><td class="num">{{.FileCount}}</td></tr>
+{{end}}
+</table>
+</section>
+{{end}}
+`
+
+const htmlFilesTable = `
+<table>
+<tr><th>Path</th><th>Size</th></tr>
+{{range .}}<tr><td><code>{{.Path}}</code></td><td class="num">{{bytes .Size}}</td></tr>
+{{end}}
+</table>
+`
+
+const buildHTMLTemplate = `
+<section>
+<h2>Size</h2>
+<table>
+{{range .Sizes}}
+<tr>
+<td class="key">{{.Label}}</td>
+<td class="num">{{bytes .Size}}</td>
+<td><div class="bar"><div style="width: {{percent .Percent}}"></div></div></td>
+<td><code>{{.Info}}</code></td>
+</tr>
+{{end}}
+</table>
+{{if .Report.MinifiedImage}}<p>The optimized image is <b>{{printf "%.2f" .Report.MinifiedBy}}X</b> smaller.</p>{{end}}
+</section>
+{{with .Report.SourceImage}}` + htmlImageSection + `{{end}}
+` + htmlDockerfileSection + `
+{{with .Report.HTTPProbe}}
+<section>
+<h2>HTTP probes</h2>
+<p>calls: {{.CallCount}} | <span class="ok">ok: {{.OkCount}}</span> | <span class="error">errors: {{.ErrorCount}}</span></p>
+{{if .EndpointStats}}
+<table>
+<tr><th>Method</th><th>Endpoint</th><th>Calls</th><th>Errors</th><th>Status codes</th><th>Latency p50 (ms)</th><th>Latency p95 (ms)</th></tr>
+{{range .EndpointStats}}
+<tr>
+<td>{{.Method}}</td>
+<td><code>{{.Endpoint}}</code>{{if .LastError}}<br><span class="error">{{.LastError}}</span>{{end}}</td>
+<td class="num">{{.CallCount}}</td>
+<td class="num">{{.ErrorCount}}</td>
+<td>{{range $code, $count := .StatusCodes}}{{$code}}: {{$count}} {{end}}</td>
+<td class="num">{{printf "%.1f" .LatencyP50}}</td>
+<td class="num">{{printf "%.1f" .LatencyP95}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</section>
+{{end}}
+{{if .Report.Verification}}
+<section>
+<h2>Verification</h2>
+<table>
+<tr><th>Round</th><th>Result</th><th>Calls</th><th>Errors</th><th>Added paths</th></tr>
+{{range .Report.Verification}}
+<tr>
+<td>{{.Round}}</td>
+<td>{{if .Passed}}<span class="ok">passed</span>{{else}}<span class="error">failed</span>{{end}}{{if .Error}} <span class="muted">{{.Error}}</span>{{end}}</td>
+<td class="num">{{.CallCount}}</td>
+<td class="num">{{.ErrorCount}}</td>
+<td>{{range .AddedPaths}}<code>{{.}}</code><br>{{end}}</td>
+</tr>
+{{end}}
+</table>
+</section>
+{{end}}
+{{with .Files}}
+<section>
+<h2>Kept files</h2>
+<p>{{.KeepCount}} files ({{bytes .KeepSize}}){{if gt .KeepCount (len $.KeptFiles)}}, the {{len $.KeptFiles}} largest files are listed{{end}}</p>
+{{with $.KeptFiles}}` + htmlFilesTable + `{{end}}
+</section>
+<section>
+<h2>Removed files</h2>
+<p>{{.DropCount}} files ({{bytes .DropSize}}){{if gt .DropCount (len $.RemovedFiles)}}, the {{len $.RemovedFiles}} largest files are listed{{end}}</p>
+{{with $.RemovedFiles}}` + htmlFilesTable + `{{end}}
+</section>
+{{end}}
+{{with .Report.Vulnerabilities}}
+<section>
+<h2>Vulnerabilities ({{.Scanner}})</h2>
+<table>
+<tr><th>Image</th><th>Total</th><th>By severity</th></tr>
+{{with .Original}}<tr><td>{{.Image}}</td><td class="num">{{.Total}}</td><td>{{range $s, $c := .BySeverity}}{{$s}}: {{$c}} {{end}}{{if .Error}}<span class="error">{{.Error}}</span>{{end}}</td></tr>{{end}}
+{{with .Minified}}<tr><td>{{.Image}}</td><td class="num">{{.Total}}</td><td>{{range $s, $c := .BySeverity}}{{$s}}: {{$c}} {{end}}{{if .Error}}<span class="error">{{.Error}}</span>{{end}}</td></tr>{{end}}
+</table>
+</section>
+{{end}}
+`
@@ -0,0 +1,351 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/command"
+)
+
+// SchemaVersion is the version of the command report schemas (saved in the 'schema_version' report field).
+// The minor version changes are backward compatible (new optional fields),
+// the major version changes are not.
+const SchemaVersion = "1.2"
+
+// JSON Schema dialect used for the report schemas
+const schemaDialect = "http://json-schema.org/draft-07/schema#"
+
+// Report schema names
+const (
+	SchemaBuild        = "build"
+	SchemaBatchBuild   = "build.batch"
+	SchemaProfile      = "profile"
+	SchemaXray         = "xray"
+	SchemaLint         = "lint"
+	SchemaContainerize = "containerize"
+	SchemaInstrument   = "instrument"
+	SchemaConvert      = "convert"
+	SchemaEdit         = "edit"
+	SchemaDebug        = "debug"
+	SchemaProbe        = "probe"
+	SchemaVerify       = "verify"
+	SchemaDoctor       = "doctor"
+	SchemaExtract      = "extract"
+	SchemaHistory      = "history"
+	SchemaPolicy       = "policy"
+	SchemaServer       = "server"
+	SchemaRun          = "run"
+	SchemaRegistry     = "registry"
+)
+
+var (
+	ErrUnknownSchema     = errors.New("unknown report schema")
+	ErrUnknownReportType = errors.New("unknown report type")
+)
+
+type schemaInfo struct {
+	cmdType    command.Type
+	reportType reflect.Type
+}
+
+var schemaTypes = map[string]schemaInfo{
+	SchemaBuild:        {command.Build, reflect.TypeOf(BuildCommand{})},
+	SchemaBatchBuild:   {command.Build, reflect.TypeOf(BatchBuildCommand{})},
+	SchemaProfile:      {command.Profile, reflect.TypeOf(ProfileCommand{})},
+	SchemaXray:         {command.Xray, reflect.TypeOf(XrayCommand{})},
+	SchemaLint:         {command.Lint, reflect.TypeOf(LintCommand{})},
+	SchemaContainerize: {command.Containerize, reflect.TypeOf(ContainerizeCommand{})},
+	SchemaInstrument:   {command.Instrument, reflect.TypeOf(InstrumentCommand{})},
+	SchemaConvert:      {command.Convert, reflect.TypeOf(ConvertCommand{})},
+	SchemaEdit:         {command.Edit, reflect.TypeOf(EditCommand{})},
+	SchemaDebug:        {command.Debug, reflect.TypeOf(DebugCommand{})},
+	SchemaProbe:        {command.Probe, reflect.TypeOf(ProbeCommand{})},
+	SchemaVerify:       {command.Verify, reflect.TypeOf(VerifyCommand{})},
+	SchemaDoctor:       {command.Doctor, reflect.TypeOf(DoctorCommand{})},
+	SchemaExtract:      {command.Extract, reflect.TypeOf(ExtractCommand{})},
+	SchemaHistory:      {command.History, reflect.TypeOf(HistoryCommand{})},
+	SchemaPolicy:       {command.Policy, reflect.TypeOf(PolicyCommand{})},
+	SchemaServer:       {command.Server, reflect.TypeOf(ServerCommand{})},
+	SchemaRun:          {command.Run, reflect.TypeOf(RunCommand{})},
+	SchemaRegistry:     {command.Registry, reflect.TypeOf(RegistryCommand{})},
+}
+
+// JSONSchema is a JSON Schema document (the subset of the draft-07 keywords used by the report schemas)
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+}
+
+// SchemaNames returns the names of the available report schemas
+func SchemaNames() []string {
+	var names []string
+	for name := range schemaTypes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// IsSchemaName returns true if the name is one of the report schema names
+func IsSchemaName(name string) bool {
+	_, ok := schemaTypes[name]
+	return ok
+}
+
+// Schema returns the JSON Schema for the named report format
+// (the schemas are created from the report data types, so they always match the saved reports)
+func Schema(name string) (*JSONSchema, error) {
+	info, ok := schemaTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w - %s", ErrUnknownSchema, name)
+	}
+
+	sb := &schemaBuilder{
+		definitions: map[string]*JSONSchema{},
+		names:       map[reflect.Type]string{},
+	}
+
+	root := sb.structSchema(info.reportType)
+	root.Schema = schemaDialect
+	root.ID = fmt.Sprintf("urn:docker-slim:report:%s:%s", name, SchemaVersion)
+	root.Title = fmt.Sprintf("docker-slim '%s' command report", info.cmdType)
+	root.Properties["type"] = &JSONSchema{Type: "string", Const: string(info.cmdType)}
+	if len(sb.definitions) > 0 {
+		root.Definitions = sb.definitions
+	}
+
+	return root, nil
+}
+
+// SchemaData returns the JSON Schema document for the named report format
+func SchemaData(name string) ([]byte, error) {
+	schema, err := Schema(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// ReportSchemaName returns the schema name for the report data
+// (based on the report 'type' field)
+func ReportSchemaName(data []byte) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+
+	var reportType string
+	if raw, ok := fields["type"]; ok {
+		if err := json.Unmarshal(raw, &reportType); err != nil {
+			return "", err
+		}
+	}
+
+	//the multi-target build reports have the same type
+	if _, ok := fields["targets"]; ok && reportType == string(command.Build) {
+		return SchemaBatchBuild, nil
+	}
+
+	if _, ok := schemaTypes[reportType]; !ok {
+		return "", fmt.Errorf("%w - '%s'", ErrUnknownReportType, reportType)
+	}
+
+	return reportType, nil
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+type schemaBuilder struct {
+	definitions map[string]*JSONSchema
+	names       map[reflect.Type]string
+}
+
+func (sb *schemaBuilder) typeSchema(t reflect.Type) *JSONSchema {
+	if t == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+		//custom JSON encoding (any value)
+		return &JSONSchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := sb.typeSchema(t.Elem())
+		return nullable(schema)
+	case reflect.Struct:
+		return &JSONSchema{Ref: "#/definitions/" + sb.definition(t)}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: []string{"string", "null"}}
+		}
+
+		return &JSONSchema{
+			Type:  []string{"array", "null"},
+			Items: sb.typeSchema(t.Elem()),
+		}
+	case reflect.Array:
+		return &JSONSchema{
+			Type:  "array",
+			Items: sb.typeSchema(t.Elem()),
+		}
+	case reflect.Map:
+		return &JSONSchema{
+			Type:                 []string{"object", "null"},
+			AdditionalProperties: sb.typeSchema(t.Elem()),
+		}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	}
+
+	//interfaces and other dynamic values
+	return &JSONSchema{}
+}
+
+// nullable makes the schema accept the null values too
+// (the struct schemas are references, so they are wrapped)
+func nullable(schema *JSONSchema) *JSONSchema {
+	switch st := schema.Type.(type) {
+	case string:
+		schema.Type = []string{st, "null"}
+		return schema
+	case []string:
+		return schema
+	}
+
+	if schema.Ref == "" {
+		//already accepts any value
+		return schema
+	}
+
+	return &JSONSchema{
+		AnyOf: []*JSONSchema{
+			schema,
+			{Type: "null"},
+		},
+	}
+}
+
+// definition adds the named struct type schema to the schema definitions
+func (sb *schemaBuilder) definition(t reflect.Type) string {
+	if name, ok := sb.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if name == "" {
+		name = "anonymous"
+	}
+
+	if pkgPath := t.PkgPath(); pkgPath != "" {
+		name = path.Base(pkgPath) + "." + name
+	}
+
+	for base, i := name, 2; sb.definitions[name] != nil; i++ {
+		name = fmt.Sprintf("%s.%d", base, i)
+	}
+
+	sb.names[t] = name
+	//placeholder for the recursive types
+	sb.definitions[name] = &JSONSchema{}
+	*sb.definitions[name] = *sb.structSchema(t)
+	return name
+}
+
+func (sb *schemaBuilder) structSchema(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{
+		Type:       "object",
+		Properties: map[string]*JSONSchema{},
+	}
+
+	sb.addFields(schema, t)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (sb *schemaBuilder) addFields(schema *JSONSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx > -1 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				sb.addFields(schema, ft)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			//unexported field
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := sb.typeSchema(field.Type)
+		if hasTagOption(opts, "string") {
+			fieldSchema = &JSONSchema{Type: "string"}
+		}
+
+		schema.Properties[name] = fieldSchema
+		if !hasTagOption(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func hasTagOption(opts, name string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,295 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError is a report data problem found during the schema validation
+type ValidationError struct {
+	//JSON pointer to the report field with the problem
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationResult contains the report schema validation results
+type ValidationResult struct {
+	Schema        string             `json:"schema"`
+	SchemaVersion string             `json:"schema_version"`
+	ReportVersion string             `json:"report_schema_version,omitempty"`
+	Errors        []*ValidationError `json:"errors,omitempty"`
+}
+
+// Valid returns true if the report data matches the schema
+func (r *ValidationResult) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// ValidateReport validates the report data using the schema for the report type
+func ValidateReport(data []byte) (*ValidationResult, error) {
+	name, err := ReportSchemaName(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return ValidateReportWithSchema(name, data)
+}
+
+// ValidateReportWithSchema validates the report data using the named report schema
+// (the reports with a different major schema version are not valid)
+func ValidateReportWithSchema(name string, data []byte) (*ValidationResult, error) {
+	schema, err := Schema(name)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	result := &ValidationResult{
+		Schema:        name,
+		SchemaVersion: SchemaVersion,
+	}
+
+	v := &validator{root: schema}
+	if fields, ok := value.(map[string]interface{}); ok {
+		if version, ok := fields["schema_version"].(string); ok {
+			result.ReportVersion = version
+			if majorVersion(version) != majorVersion(SchemaVersion) {
+				v.fail("/schema_version",
+					"incompatible schema version '%s' (expected '%s.x')", version, majorVersion(SchemaVersion))
+			}
+		}
+	}
+
+	v.validate(value, schema, "")
+	result.Errors = v.errors
+	return result, nil
+}
+
+func majorVersion(version string) string {
+	if idx := strings.Index(version, "."); idx > -1 {
+		return version[:idx]
+	}
+
+	return version
+}
+
+type validator struct {
+	root   *JSONSchema
+	errors []*ValidationError
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "/"
+	}
+
+	v.errors = append(v.errors, &ValidationError{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// matches returns true if the value matches the schema (without recording the errors)
+func (v *validator) matches(value interface{}, schema *JSONSchema) bool {
+	check := &validator{root: v.root}
+	check.validate(value, schema, "")
+	return len(check.errors) == 0
+}
+
+func (v *validator) validate(value interface{}, schema *JSONSchema, path string) {
+	if schema.Ref != "" {
+		ref, ok := v.resolve(schema.Ref)
+		if !ok {
+			v.fail(path, "unknown schema reference '%s'", schema.Ref)
+			return
+		}
+
+		v.validate(value, ref, path)
+		return
+	}
+
+	if len(schema.AnyOf) > 0 {
+		matched := false
+		for _, option := range schema.AnyOf {
+			if v.matches(value, option) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			if value != nil && len(schema.AnyOf) == 2 && schema.AnyOf[1].Type == "null" {
+				//nullable value: report the real problems
+				v.validate(value, schema.AnyOf[0], path)
+			} else {
+				v.fail(path, "value doesn't match any of the allowed schemas")
+			}
+		}
+
+		return
+	}
+
+	if schema.Type != nil && !matchesType(value, schema.Type) {
+		v.fail(path, "expected %s, got %s", typeNames(schema.Type), valueType(value))
+		return
+	}
+
+	if schema.Const != nil && fmt.Sprint(schema.Const) != fmt.Sprint(value) {
+		v.fail(path, "expected '%v', got '%v'", schema.Const, value)
+	}
+
+	if schema.Format == "date-time" {
+		if str, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				v.fail(path, "malformed date-time value '%s'", str)
+			}
+		}
+	}
+
+	switch data := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := data[name]; !ok {
+				v.fail(path, "missing required field '%s'", name)
+			}
+		}
+
+		names := make([]string, 0, len(data))
+		for name := range data {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			fieldPath := path + "/" + escapePointer(name)
+			if fieldSchema, ok := schema.Properties[name]; ok {
+				v.validate(data[name], fieldSchema, fieldPath)
+			} else if schema.AdditionalProperties != nil {
+				v.validate(data[name], schema.AdditionalProperties, fieldPath)
+			}
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for idx, item := range data {
+				v.validate(item, schema.Items, fmt.Sprintf("%s/%d", path, idx))
+			}
+		}
+	}
+}
+
+func (v *validator) resolve(ref string) (*JSONSchema, bool) {
+	const prefix = "#/definitions/"
+	if !strings.HasPrefix(ref, prefix) {
+		return nil, false
+	}
+
+	schema, ok := v.root.Definitions[strings.TrimPrefix(ref, prefix)]
+	return schema, ok
+}
+
+func matchesType(value interface{}, schemaType interface{}) bool {
+	switch st := schemaType.(type) {
+	case string:
+		return isType(value, st)
+	case []string:
+		for _, name := range st {
+			if isType(value, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isType(value interface{}, name string) bool {
+	switch name {
+	case "null":
+		return value == nil
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		num, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+
+		if _, err := num.Int64(); err == nil {
+			return true
+		}
+
+		//large unsigned values
+		if _, err := strconv.ParseUint(num.String(), 10, 64); err == nil {
+			return true
+		}
+
+		fv, err := num.Float64()
+		return err == nil && fv == math.Trunc(fv)
+	}
+
+	return false
+}
+
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	}
+
+	return "unknown"
+}
+
+func typeNames(schemaType interface{}) string {
+	switch st := schemaType.(type) {
+	case string:
+		return st
+	case []string:
+		return strings.Join(st, " or ")
+	}
+
+	return "any"
+}
+
+func escapePointer(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
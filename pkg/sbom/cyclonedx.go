@@ -0,0 +1,124 @@
+package sbom
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	cdxFormat      = "CycloneDX"
+	cdxSpecVersion = "1.4"
+)
+
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []*cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     []*cdxTool    `json:"tools"`
+	Component *cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	PURL       string         `json:"purl,omitempty"`
+	Hashes     []*cdxHash     `json:"hashes,omitempty"`
+	Properties []*cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func newCycloneDX(doc *Document) ([]byte, error) {
+	cd := &cdxDocument{
+		BOMFormat:    cdxFormat,
+		SpecVersion:  cdxSpecVersion,
+		SerialNumber: "urn:uuid:" + doc.documentUUID,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: doc.Created.UTC().Format(time.RFC3339),
+			Tools: []*cdxTool{
+				{
+					Vendor:  toolName,
+					Name:    toolName,
+					Version: doc.ToolVersion,
+				},
+			},
+			Component: &cdxComponent{
+				Type:    "container",
+				BOMRef:  doc.ImageID,
+				Name:    doc.ImageName,
+				Version: doc.ImageID,
+			},
+		},
+		Components: []*cdxComponent{},
+	}
+
+	//the component references must be unique
+	refs := map[string]struct{}{}
+	for idx, p := range doc.Packages {
+		purl := doc.purl(p)
+		ref := purl
+		if _, found := refs[ref]; found {
+			ref = fmt.Sprintf("%s#%d", purl, idx)
+		}
+
+		refs[ref] = struct{}{}
+		component := &cdxComponent{
+			Type:    "library",
+			BOMRef:  ref,
+			Name:    p.Name,
+			Version: p.Version,
+			PURL:    purl,
+			Properties: []*cdxProperty{
+				{
+					Name:  toolName + ":package:type",
+					Value: p.Type,
+				},
+			},
+		}
+
+		cd.Components = append(cd.Components, component)
+	}
+
+	for _, f := range doc.Files {
+		component := &cdxComponent{
+			Type:   "file",
+			BOMRef: "file:" + f.Path,
+			Name:   f.Path,
+		}
+
+		if f.SHA1 != "" {
+			component.Hashes = append(component.Hashes, &cdxHash{
+				Alg:     "SHA-1",
+				Content: f.SHA1,
+			})
+		}
+
+		cd.Components = append(cd.Components, component)
+	}
+
+	return marshalJSON(cd)
+}
@@ -0,0 +1,254 @@
+package sbom
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+// Supported SBOM formats
+const (
+	FormatSPDXJSON      = "spdx-json"
+	FormatCycloneDXJSON = "cyclonedx-json"
+)
+
+// Default SBOM file names
+const (
+	SPDXFileName      = "sbom.spdx.json"
+	CycloneDXFileName = "sbom.cdx.json"
+)
+
+const toolName = "docker-slim"
+
+// IsFormat returns true if the value is a supported SBOM format
+func IsFormat(value string) bool {
+	switch value {
+	case FormatSPDXJSON, FormatCycloneDXJSON:
+		return true
+	}
+
+	return false
+}
+
+// DefaultFileName returns the default SBOM file name for the format
+func DefaultFileName(format string) string {
+	if format == FormatCycloneDXJSON {
+		return CycloneDXFileName
+	}
+
+	return SPDXFileName
+}
+
+// Document is the software bill of materials data for a container image
+type Document struct {
+	ImageName    string
+	ImageID      string
+	Distro       *system.DistroInfo
+	ToolVersion  string
+	Created      time.Time
+	Packages     []*Package
+	Files        []*File
+	documentUUID string
+}
+
+// Package is an installed OS package
+type Package struct {
+	Name    string
+	Version string
+	Arch    string
+	//package database type (apk, dpkg or rpm)
+	Type string
+}
+
+// File is a file (e.g., an executable) in the container image
+type File struct {
+	Path string
+	Size int64
+	SHA1 string
+}
+
+// Generate creates the SBOM data in the selected format
+func Generate(format string, doc *Document) ([]byte, error) {
+	if doc.Created.IsZero() {
+		doc.Created = time.Now()
+	}
+
+	if doc.documentUUID == "" {
+		id, err := newUUID()
+		if err != nil {
+			return nil, err
+		}
+
+		doc.documentUUID = id
+	}
+
+	sort.Slice(doc.Packages, func(i, j int) bool {
+		if doc.Packages[i].Name != doc.Packages[j].Name {
+			return doc.Packages[i].Name < doc.Packages[j].Name
+		}
+
+		return doc.Packages[i].Arch < doc.Packages[j].Arch
+	})
+
+	sort.Slice(doc.Files, func(i, j int) bool {
+		return doc.Files[i].Path < doc.Files[j].Path
+	})
+
+	switch format {
+	case FormatSPDXJSON:
+		return newSPDX(doc)
+	case FormatCycloneDXJSON:
+		return newCycloneDX(doc)
+	}
+
+	return nil, fmt.Errorf("unsupported SBOM format - %s", format)
+}
+
+// Save creates the SBOM data in the selected format and saves it in the target file
+func Save(format string, target string, doc *Document) error {
+	data, err := Generate(format, doc)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(target); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(target, data, 0644)
+}
+
+// purl package types for the package database types
+var purlTypes = map[string]string{
+	system.OSPackageDBApk:  "apk",
+	system.OSPackageDBDpkg: "deb",
+	system.OSPackageDBRpm:  "rpm",
+}
+
+// default purl namespaces if the distro is unknown
+var purlNamespaces = map[string]string{
+	system.OSPackageDBApk:  "alpine",
+	system.OSPackageDBDpkg: "debian",
+	system.OSPackageDBRpm:  "redhat",
+}
+
+// distro name keywords to purl namespaces
+var distroNamespaces = []struct {
+	keyword   string
+	namespace string
+}{
+	{"alpine", "alpine"},
+	{"wolfi", "wolfi"},
+	{"ubuntu", "ubuntu"},
+	{"debian", "debian"},
+	{"centos", "centos"},
+	{"fedora", "fedora"},
+	{"red hat", "redhat"},
+	{"rocky", "rocky"},
+	{"alma", "almalinux"},
+	{"oracle", "oracle"},
+	{"amazon", "amazon"},
+	{"suse", "opensuse"},
+	{"photon", "photon"},
+	{"mariner", "mariner"},
+}
+
+func (doc *Document) purlNamespace(pkgType string) string {
+	if doc.Distro != nil {
+		name := strings.ToLower(doc.Distro.Name)
+		for _, info := range distroNamespaces {
+			if strings.Contains(name, info.keyword) {
+				return info.namespace
+			}
+		}
+	}
+
+	return purlNamespaces[pkgType]
+}
+
+// purl returns the package URL for the package
+func (doc *Document) purl(p *Package) string {
+	purlType, found := purlTypes[p.Type]
+	if !found {
+		purlType = "generic"
+	}
+
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(purlType)
+	b.WriteString("/")
+	if ns := doc.purlNamespace(p.Type); ns != "" && purlType != "generic" {
+		b.WriteString(ns)
+		b.WriteString("/")
+	}
+
+	b.WriteString(url.PathEscape(p.Name))
+
+	var qualifiers []string
+	version := p.Version
+	if purlType == "rpm" {
+		//the rpm package epoch is a purl qualifier
+		if parts := strings.SplitN(version, ":", 2); len(parts) == 2 {
+			qualifiers = append(qualifiers, "epoch="+url.QueryEscape(parts[0]))
+			version = parts[1]
+		}
+	}
+
+	if version != "" {
+		b.WriteString("@")
+		b.WriteString(url.PathEscape(version))
+	}
+
+	if p.Arch != "" {
+		qualifiers = append(qualifiers, "arch="+url.QueryEscape(p.Arch))
+	}
+
+	if doc.Distro != nil && doc.Distro.Version != "" && purlType != "generic" {
+		qualifiers = append(qualifiers,
+			"distro="+url.QueryEscape(fmt.Sprintf("%s-%s", doc.purlNamespace(p.Type), doc.Distro.Version)))
+	}
+
+	if len(qualifiers) > 0 {
+		b.WriteString("?")
+		b.WriteString(strings.Join(qualifiers, "&"))
+	}
+
+	return b.String()
+}
+
+// marshalJSON encodes the SBOM data without escaping the HTML characters (e.g., '&' in the purls)
+func marshalJSON(data interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func newUUID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+
+	//version 4 / variant 1 (RFC 4122)
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
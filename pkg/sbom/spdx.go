@@ -0,0 +1,184 @@
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	spdxVersion         = "SPDX-2.3"
+	spdxDataLicense     = "CC0-1.0"
+	spdxDocumentID      = "SPDXRef-DOCUMENT"
+	spdxImageID         = "SPDXRef-Image"
+	spdxDistroID        = "SPDXRef-OperatingSystem"
+	spdxNoAssertion     = "NOASSERTION"
+	spdxNamespacePrefix = "https://docker-slim.dev/spdxdocs/"
+)
+
+type spdxDocument struct {
+	SPDXVersion       string              `json:"spdxVersion"`
+	DataLicense       string              `json:"dataLicense"`
+	SPDXID            string              `json:"SPDXID"`
+	Name              string              `json:"name"`
+	DocumentNamespace string              `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo    `json:"creationInfo"`
+	Packages          []*spdxPackage      `json:"packages"`
+	Files             []*spdxFile         `json:"files,omitempty"`
+	Relationships     []*spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string             `json:"name"`
+	SPDXID                string             `json:"SPDXID"`
+	VersionInfo           string             `json:"versionInfo,omitempty"`
+	DownloadLocation      string             `json:"downloadLocation"`
+	FilesAnalyzed         bool               `json:"filesAnalyzed"`
+	LicenseConcluded      string             `json:"licenseConcluded"`
+	LicenseDeclared       string             `json:"licenseDeclared"`
+	CopyrightText         string             `json:"copyrightText"`
+	PrimaryPackagePurpose string             `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs          []*spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxFile struct {
+	FileName         string          `json:"fileName"`
+	SPDXID           string          `json:"SPDXID"`
+	FileTypes        []string        `json:"fileTypes,omitempty"`
+	Checksums        []*spdxChecksum `json:"checksums,omitempty"`
+	LicenseConcluded string          `json:"licenseConcluded"`
+	CopyrightText    string          `json:"copyrightText"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// the SPDX element IDs can only have letters, numbers, '.' and '-'
+var spdxIDChars = regexp.MustCompile(`[^a-zA-Z0-9.\-]+`)
+
+func spdxID(prefix string, idx int, name string) string {
+	return fmt.Sprintf("SPDXRef-%s-%d-%s", prefix, idx, spdxIDChars.ReplaceAllString(name, "-"))
+}
+
+func newSPDX(doc *Document) ([]byte, error) {
+	sd := &spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       spdxDataLicense,
+		SPDXID:            spdxDocumentID,
+		Name:              doc.ImageName,
+		DocumentNamespace: fmt.Sprintf("%s%s-%s", spdxNamespacePrefix, spdxIDChars.ReplaceAllString(doc.ImageName, "-"), doc.documentUUID),
+		CreationInfo: spdxCreationInfo{
+			Created:  doc.Created.UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: %s-%s", toolName, doc.ToolVersion)},
+		},
+	}
+
+	sd.Packages = append(sd.Packages, &spdxPackage{
+		Name:                  doc.ImageName,
+		SPDXID:                spdxImageID,
+		VersionInfo:           doc.ImageID,
+		DownloadLocation:      spdxNoAssertion,
+		LicenseConcluded:      spdxNoAssertion,
+		LicenseDeclared:       spdxNoAssertion,
+		CopyrightText:         spdxNoAssertion,
+		PrimaryPackagePurpose: "CONTAINER",
+	})
+
+	sd.Relationships = append(sd.Relationships, &spdxRelationship{
+		SPDXElementID:      spdxDocumentID,
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: spdxImageID,
+	})
+
+	if doc.Distro != nil && doc.Distro.Name != "" {
+		sd.Packages = append(sd.Packages, &spdxPackage{
+			Name:                  doc.Distro.Name,
+			SPDXID:                spdxDistroID,
+			VersionInfo:           doc.Distro.Version,
+			DownloadLocation:      spdxNoAssertion,
+			LicenseConcluded:      spdxNoAssertion,
+			LicenseDeclared:       spdxNoAssertion,
+			CopyrightText:         spdxNoAssertion,
+			PrimaryPackagePurpose: "OPERATING-SYSTEM",
+		})
+
+		sd.Relationships = append(sd.Relationships, &spdxRelationship{
+			SPDXElementID:      spdxImageID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: spdxDistroID,
+		})
+	}
+
+	for idx, p := range doc.Packages {
+		sp := &spdxPackage{
+			Name:             p.Name,
+			SPDXID:           spdxID("Package", idx, p.Name),
+			VersionInfo:      p.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+			ExternalRefs: []*spdxExternalRef{
+				{
+					ReferenceCategory: "PACKAGE-MANAGER",
+					ReferenceType:     "purl",
+					ReferenceLocator:  doc.purl(p),
+				},
+			},
+		}
+
+		sd.Packages = append(sd.Packages, sp)
+		sd.Relationships = append(sd.Relationships, &spdxRelationship{
+			SPDXElementID:      spdxImageID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: sp.SPDXID,
+		})
+	}
+
+	for idx, f := range doc.Files {
+		sf := &spdxFile{
+			FileName:         f.Path,
+			SPDXID:           spdxID("File", idx, f.Path),
+			FileTypes:        []string{"BINARY"},
+			LicenseConcluded: spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+		}
+
+		//the checksums are omitted if the file digest is unknown
+		//('NOASSERTION' is not a valid checksum value)
+		if f.SHA1 != "" {
+			sf.Checksums = append(sf.Checksums, &spdxChecksum{
+				Algorithm:     "SHA1",
+				ChecksumValue: f.SHA1,
+			})
+		}
+
+		sd.Files = append(sd.Files, sf)
+		sd.Relationships = append(sd.Relationships, &spdxRelationship{
+			SPDXElementID:      spdxImageID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: sf.SPDXID,
+		})
+	}
+
+	return marshalJSON(sd)
+}
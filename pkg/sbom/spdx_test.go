@@ -0,0 +1,126 @@
+package sbom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+func testDocument() *Document {
+	return &Document{
+		ImageName:   "my/app:latest",
+		ImageID:     "sha256:1234",
+		ToolVersion: "1.0",
+		Created:     time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+		Distro: &system.DistroInfo{
+			Name:    "Alpine Linux",
+			Version: "3.15.0",
+		},
+		Packages: []*Package{
+			{Name: "musl", Version: "1.2.2-r7", Arch: "x86_64", Type: system.OSPackageDBApk},
+			{Name: "busybox", Version: "1.34.1-r3", Arch: "x86_64", Type: system.OSPackageDBApk},
+		},
+		Files: []*File{
+			{Path: "/usr/bin/app", Size: 10, SHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+			{Path: "/bin/sh", Size: 20},
+		},
+	}
+}
+
+func TestGenerateSPDX(t *testing.T) {
+	data, err := Generate(FormatSPDXJSON, testDocument())
+	require.NoError(t, err)
+
+	var sd spdxDocument
+	require.NoError(t, json.Unmarshal(data, &sd))
+
+	assert.Equal(t, spdxVersion, sd.SPDXVersion)
+	assert.Equal(t, "2022-03-01T10:00:00Z", sd.CreationInfo.Created)
+	assert.Equal(t, []string{"Tool: docker-slim-1.0"}, sd.CreationInfo.Creators)
+
+	require.Len(t, sd.Packages, 4)
+	assert.Equal(t, spdxImageID, sd.Packages[0].SPDXID)
+	assert.Equal(t, "CONTAINER", sd.Packages[0].PrimaryPackagePurpose)
+
+	//only the distro package is the operating system
+	assert.Equal(t, spdxDistroID, sd.Packages[1].SPDXID)
+	assert.Equal(t, "Alpine Linux", sd.Packages[1].Name)
+	assert.Equal(t, "3.15.0", sd.Packages[1].VersionInfo)
+	assert.Equal(t, "OPERATING-SYSTEM", sd.Packages[1].PrimaryPackagePurpose)
+
+	//the OS packages are sorted by name
+	assert.Equal(t, "busybox", sd.Packages[2].Name)
+	assert.Equal(t, "SPDXRef-Package-0-busybox", sd.Packages[2].SPDXID)
+	assert.Empty(t, sd.Packages[2].PrimaryPackagePurpose)
+	require.Len(t, sd.Packages[2].ExternalRefs, 1)
+	assert.Equal(t, "pkg:apk/alpine/busybox@1.34.1-r3?arch=x86_64&distro=alpine-3.15.0", sd.Packages[2].ExternalRefs[0].ReferenceLocator)
+
+	require.Len(t, sd.Files, 2)
+	assert.Equal(t, "/bin/sh", sd.Files[0].FileName)
+	assert.Empty(t, sd.Files[0].Checksums)
+	assert.Equal(t, "/usr/bin/app", sd.Files[1].FileName)
+	assert.Equal(t, []*spdxChecksum{{Algorithm: "SHA1", ChecksumValue: "da39a3ee5e6b4b0d3255bfef95601890afd80709"}}, sd.Files[1].Checksums)
+
+	//document DESCRIBES image + image CONTAINS distro, packages and files
+	require.Len(t, sd.Relationships, 6)
+	assert.Equal(t, &spdxRelationship{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: spdxImageID}, sd.Relationships[0])
+	assert.Equal(t, &spdxRelationship{SPDXElementID: spdxImageID, RelationshipType: "CONTAINS", RelatedSPDXElement: spdxDistroID}, sd.Relationships[1])
+}
+
+func TestGenerateSPDXNoChecksums(t *testing.T) {
+	doc := testDocument()
+	doc.Distro = nil
+
+	data, err := Generate(FormatSPDXJSON, doc)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"checksumValue": "NOASSERTION"`)
+	assert.NotContains(t, string(data), "OPERATING-SYSTEM")
+
+	var raw struct {
+		Files []map[string]interface{} `json:"files"`
+	}
+
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Len(t, raw.Files, 2)
+	_, found := raw.Files[0]["checksums"]
+	assert.False(t, found)
+	_, found = raw.Files[1]["checksums"]
+	assert.True(t, found)
+}
+
+func TestPURL(t *testing.T) {
+	tt := []struct {
+		distro   *system.DistroInfo
+		pkg      *Package
+		expected string
+	}{
+		{
+			distro:   &system.DistroInfo{Name: "Ubuntu", Version: "20.04"},
+			pkg:      &Package{Name: "libc6", Version: "2.31-0ubuntu9.7", Arch: "amd64", Type: system.OSPackageDBDpkg},
+			expected: "pkg:deb/ubuntu/libc6@2.31-0ubuntu9.7?arch=amd64&distro=ubuntu-20.04",
+		},
+		{
+			distro:   &system.DistroInfo{Name: "CentOS Linux", Version: "8"},
+			pkg:      &Package{Name: "bash", Version: "1:4.4.20-1.el8_4", Arch: "x86_64", Type: system.OSPackageDBRpm},
+			expected: "pkg:rpm/centos/bash@4.4.20-1.el8_4?epoch=1&arch=x86_64&distro=centos-8",
+		},
+		{
+			pkg:      &Package{Name: "zlib", Version: "1.2.11", Type: system.OSPackageDBDpkg},
+			expected: "pkg:deb/debian/zlib@1.2.11",
+		},
+		{
+			pkg:      &Package{Name: "tool", Version: "1.0", Type: "unknown"},
+			expected: "pkg:generic/tool@1.0",
+		},
+	}
+
+	for _, test := range tt {
+		doc := &Document{Distro: test.distro}
+		assert.Equal(t, test.expected, doc.purl(test.pkg))
+	}
+}
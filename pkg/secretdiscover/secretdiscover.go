@@ -0,0 +1,378 @@
+package secretdiscover
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Secret rule names
+const (
+	RulePrivateKey         = "private-key"
+	RuleAWSAccessKeyID     = "aws-access-key-id"
+	RuleAWSSecretAccessKey = "aws-secret-access-key"
+	RuleGitHubToken        = "github-token"
+	RuleGitLabToken        = "gitlab-token"
+	RuleSlackToken         = "slack-token"
+	RuleGoogleAPIKey       = "google-api-key"
+	RuleStripeKey          = "stripe-secret-key"
+	RuleNpmToken           = "npm-token"
+	RuleJWT                = "jwt"
+	RuleURLCredentials     = "url-credentials"
+	RulePassword           = "password-assignment"
+	RuleSensitiveVar       = "sensitive-variable"
+)
+
+// MaxFileMatches is the max number of matches reported for one file
+const MaxFileMatches = 20
+
+// Match is a secret found in the scanned data
+// (the value is always redacted)
+type Match struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Line        int    `json:"line,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Value       string `json:"value"`
+}
+
+type rule struct {
+	name        string
+	description string
+	pattern     *regexp.Regexp
+	//the pattern group with the secret value (0 is the full match)
+	valueGroup int
+}
+
+var rules = []*rule{
+	{
+		name:        RulePrivateKey,
+		description: "Private key",
+		pattern:     regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	{
+		name:        RuleAWSAccessKeyID,
+		description: "AWS access key ID",
+		pattern:     regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		name:        RuleAWSSecretAccessKey,
+		description: "AWS secret access key",
+		pattern:     regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		valueGroup:  1,
+	},
+	{
+		name:        RuleGitHubToken,
+		description: "GitHub token",
+		pattern:     regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	},
+	{
+		name:        RuleGitLabToken,
+		description: "GitLab personal access token",
+		pattern:     regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}`),
+	},
+	{
+		name:        RuleSlackToken,
+		description: "Slack token",
+		pattern:     regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9\-]{10,}`),
+	},
+	{
+		name:        RuleGoogleAPIKey,
+		description: "Google API key",
+		pattern:     regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`),
+	},
+	{
+		name:        RuleStripeKey,
+		description: "Stripe secret key",
+		pattern:     regexp.MustCompile(`\b[sr]k_live_[0-9a-zA-Z]{24,}\b`),
+	},
+	{
+		name:        RuleNpmToken,
+		description: "npm access token",
+		pattern:     regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`),
+	},
+	{
+		name:        RuleJWT,
+		description: "JSON Web Token",
+		pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`),
+	},
+	{
+		name:        RuleURLCredentials,
+		description: "Password in URL",
+		pattern:     regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^/\s:@"']+:([^/\s:@"'$]{3,})@`),
+		valueGroup:  1,
+	},
+	{
+		name:        RulePassword,
+		description: "Hardcoded password or secret",
+		pattern:     regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|token|api_?key|access_?key|auth_?token)["']?\s*[:=]\s*["']([^"'\s$]{8,})["']`),
+		valueGroup:  1,
+	},
+}
+
+// sensitive variable names (env vars and build args)
+var sensitiveVarName = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?)`)
+
+// variable name suffixes for the variables that reference the secrets (and don't have them)
+var referenceVarSuffixes = []string{"_FILE", "_PATH", "_DIR", "_URL_PATH"}
+
+// variable assignments in the build history commands (e.g., build args)
+var varAssignment = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)=("[^"]*"|'[^']*'|[^\s;&|]+)`)
+
+// Redact hides most of the secret value
+func Redact(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", 8)
+	}
+
+	return value[:4] + strings.Repeat("*", 8)
+}
+
+// IsBinaryData returns true if the data looks like binary data (it has NUL bytes at the beginning)
+func IsBinaryData(data []byte) bool {
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+
+	return bytes.IndexByte(head, 0) != -1
+}
+
+// ScanData searches the (text) file data for secrets
+func ScanData(data []byte) []*Match {
+	if IsBinaryData(data) {
+		return nil
+	}
+
+	var matches []*Match
+	seen := map[string]struct{}{}
+rulesLoop:
+	for _, r := range rules {
+		for _, loc := range r.pattern.FindAllSubmatchIndex(data, -1) {
+			if len(matches) >= MaxFileMatches {
+				break rulesLoop
+			}
+
+			start, end := loc[2*r.valueGroup], loc[2*r.valueGroup+1]
+			if start < 0 {
+				continue
+			}
+
+			value := string(data[start:end])
+			key := r.name + ":" + value
+			if _, found := seen[key]; found {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			matches = append(matches, &Match{
+				Rule:        r.name,
+				Description: r.description,
+				Line:        bytes.Count(data[:start], []byte("\n")) + 1,
+				Value:       Redact(value),
+			})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Line < matches[j].Line
+	})
+
+	return matches
+}
+
+// ScanVariable checks the variable (e.g., an env var or a build arg) for secrets
+func ScanVariable(name, value string) []*Match {
+	value = strings.Trim(value, `"'`)
+	if value == "" {
+		return nil
+	}
+
+	matches := ScanData([]byte(value))
+	for _, m := range matches {
+		m.Line = 0
+		m.Name = name
+	}
+
+	if len(matches) > 0 || !IsSensitiveVarName(name) {
+		return matches
+	}
+
+	return []*Match{
+		{
+			Rule:        RuleSensitiveVar,
+			Description: "Sensitive variable value",
+			Name:        name,
+			Value:       Redact(value),
+		},
+	}
+}
+
+// ScanCommand searches the build command (e.g., from the image history) for secrets
+// including the sensitive variable assignments (e.g., build args)
+func ScanCommand(command string) []*Match {
+	matches := ScanData([]byte(command))
+	for _, m := range matches {
+		m.Line = 0
+	}
+
+	for _, loc := range varAssignment.FindAllStringSubmatch(command, -1) {
+		name := loc[1]
+		if !IsSensitiveVarName(name) {
+			continue
+		}
+
+		for _, m := range ScanVariable(name, loc[2]) {
+			if m.Rule == RuleSensitiveVar {
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	return matches
+}
+
+// RedactCommand hides the secret values in the build command (e.g., from the image history)
+// (the secrets ScanCommand finds are redacted; the private key data is redacted up to its end marker)
+func RedactCommand(command string) string {
+	var spans [][2]int
+	for _, r := range rules {
+		for _, loc := range r.pattern.FindAllStringSubmatchIndex(command, -1) {
+			start, end := loc[2*r.valueGroup], loc[2*r.valueGroup+1]
+			if start < 0 {
+				continue
+			}
+
+			if r.name == RulePrivateKey {
+				//the key data is after the key header
+				start = end
+				end = privateKeyEnd(command, start)
+			}
+
+			if start < end {
+				spans = append(spans, [2]int{start, end})
+			}
+		}
+	}
+
+	for _, loc := range varAssignment.FindAllStringSubmatchIndex(command, -1) {
+		if !IsSensitiveVarName(command[loc[2]:loc[3]]) {
+			continue
+		}
+
+		start, end := loc[4], loc[5]
+		if end-start >= 2 && (command[start] == '"' || command[start] == '\'') {
+			start++
+			end--
+		}
+
+		if start < end {
+			spans = append(spans, [2]int{start, end})
+		}
+	}
+
+	return redactSpans(command, spans)
+}
+
+// RedactCommands hides the secret values in the build command list
+func RedactCommands(commands []string) []string {
+	if commands == nil {
+		return nil
+	}
+
+	redacted := make([]string, 0, len(commands))
+	for _, command := range commands {
+		redacted = append(redacted, RedactCommand(command))
+	}
+
+	return redacted
+}
+
+// RedactVariable hides the secret values in the variable (e.g., an env var or a build arg) value
+// (the sensitive variable values are redacted completely)
+func RedactVariable(name, value string) string {
+	if strings.Trim(value, `"'`) == "" {
+		return value
+	}
+
+	if IsSensitiveVarName(name) {
+		return Redact(value)
+	}
+
+	return RedactCommand(value)
+}
+
+// RedactEnv hides the secret values in the env var list ('NAME=VALUE' records)
+func RedactEnv(envVars []string) []string {
+	if envVars == nil {
+		return nil
+	}
+
+	redacted := make([]string, 0, len(envVars))
+	for _, envVar := range envVars {
+		parts := strings.SplitN(envVar, "=", 2)
+		if len(parts) != 2 {
+			redacted = append(redacted, envVar)
+			continue
+		}
+
+		redacted = append(redacted, parts[0]+"="+RedactVariable(parts[0], parts[1]))
+	}
+
+	return redacted
+}
+
+func privateKeyEnd(data string, start int) int {
+	if idx := strings.Index(data[start:], "-----END"); idx >= 0 {
+		return start + idx
+	}
+
+	return len(data)
+}
+
+// redactSpans replaces the (possibly overlapping) data spans with their redacted values
+func redactSpans(data string, spans [][2]int) string {
+	if len(spans) == 0 {
+		return data
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i][0] < spans[j][0]
+	})
+
+	var sb strings.Builder
+	var pos int
+	for idx := 0; idx < len(spans); idx++ {
+		start, end := spans[idx][0], spans[idx][1]
+		for idx+1 < len(spans) && spans[idx+1][0] < end {
+			idx++
+			if spans[idx][1] > end {
+				end = spans[idx][1]
+			}
+		}
+
+		if start < pos {
+			start = pos
+		}
+
+		sb.WriteString(data[pos:start])
+		sb.WriteString(Redact(data[start:end]))
+		pos = end
+	}
+
+	sb.WriteString(data[pos:])
+	return sb.String()
+}
+
+// IsSensitiveVarName returns true if the variable name looks like it's used for secrets
+func IsSensitiveVarName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, suffix := range referenceVarSuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return false
+		}
+	}
+
+	return sensitiveVarName.MatchString(name)
+}
@@ -0,0 +1,314 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Package manager database types
+const (
+	OSPackageDBApk  = "apk"
+	OSPackageDBDpkg = "dpkg"
+	OSPackageDBRpm  = "rpm"
+)
+
+// Package manager database files
+const (
+	ApkInstalledDBFile      = "/lib/apk/db/installed"
+	DpkgStatusDBFile        = "/var/lib/dpkg/status"
+	DpkgStatusDBDir         = "/var/lib/dpkg/status.d/"
+	RpmPackagesDBFile       = "/var/lib/rpm/Packages"
+	RpmPackagesDBFileNew    = "/usr/lib/sysimage/rpm/Packages"
+	RpmSqliteDBFile         = "/var/lib/rpm/rpmdb.sqlite"
+	RpmSqliteDBFileNew      = "/usr/lib/sysimage/rpm/rpmdb.sqlite"
+	RpmNdbDBFile            = "/var/lib/rpm/Packages.db"
+	RpmNdbDBFileNew         = "/usr/lib/sysimage/rpm/Packages.db"
+	dpkgStatusMD5SumsSuffix = ".md5sums"
+)
+
+var (
+	ErrUnknownPackageDB     = errors.New("unknown package database")
+	ErrUnsupportedPackageDB = errors.New("unsupported package database format")
+)
+
+// OSPackage is an installed OS package
+type OSPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Arch    string `json:"arch,omitempty"`
+	Size    uint64 `json:"size,omitempty"`
+	License string `json:"license,omitempty"`
+}
+
+// OSPackageDBType returns the package database type for the file path
+// (an empty string means it's not a known package database file)
+func OSPackageDBType(name string) string {
+	switch name {
+	case ApkInstalledDBFile:
+		return OSPackageDBApk
+	case DpkgStatusDBFile:
+		return OSPackageDBDpkg
+	case RpmPackagesDBFile, RpmPackagesDBFileNew,
+		RpmSqliteDBFile, RpmSqliteDBFileNew,
+		RpmNdbDBFile, RpmNdbDBFileNew:
+		return OSPackageDBRpm
+	}
+
+	//distroless images keep one dpkg status file for each package
+	if strings.HasPrefix(name, DpkgStatusDBDir) &&
+		!strings.HasSuffix(name, dpkgStatusMD5SumsSuffix) &&
+		!strings.Contains(strings.TrimPrefix(name, DpkgStatusDBDir), "/") {
+		return OSPackageDBDpkg
+	}
+
+	return ""
+}
+
+// IsOSPackageDBFile returns true if the file path is a known package database file
+func IsOSPackageDBFile(name string) bool {
+	return OSPackageDBType(name) != ""
+}
+
+// OSPackageInfo is an installed OS package with its dependency and file info
+// (the dependency and provided names don't have the version constraints;
+// the alternative dependencies are separated with '|')
+type OSPackageInfo struct {
+	OSPackage
+	Depends  []string
+	Provides []string
+	Files    []string
+}
+
+// NewOSPackagesFromData parses the package database data
+func NewOSPackagesFromData(dbType string, data []byte) ([]*OSPackage, error) {
+	infos, err := NewOSPackageInfoFromData(dbType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*OSPackage
+	for _, info := range infos {
+		p := info.OSPackage
+		packages = append(packages, &p)
+	}
+
+	return packages, nil
+}
+
+// NewOSPackageInfoFromData parses the package database data
+// including the package dependency and file info
+// (the dpkg 'status' database doesn't have the package files)
+func NewOSPackageInfoFromData(dbType string, data []byte) ([]*OSPackageInfo, error) {
+	switch dbType {
+	case OSPackageDBApk:
+		return parseApkDB(data)
+	case OSPackageDBDpkg:
+		return parseDpkgDB(data)
+	case OSPackageDBRpm:
+		return parseRpmDB(data)
+	}
+
+	return nil, ErrUnknownPackageDB
+}
+
+// parseApkDB parses the apk 'installed' database
+// (package records are separated by empty lines and each line has a one letter field key;
+// the package files ('R') are in the directory from the last 'F' field)
+func parseApkDB(data []byte) ([]*OSPackageInfo, error) {
+	var packages []*OSPackageInfo
+	var current *OSPackageInfo
+	var dir string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			current = nil
+			continue
+		}
+
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+
+		if current == nil {
+			current = &OSPackageInfo{}
+			packages = append(packages, current)
+			dir = ""
+		}
+
+		value := line[2:]
+		switch line[0] {
+		case 'P':
+			current.Name = value
+		case 'V':
+			current.Version = value
+		case 'A':
+			current.Arch = value
+		case 'I':
+			current.Size, _ = strconv.ParseUint(value, 10, 64)
+		case 'L':
+			current.License = value
+		case 'F':
+			dir = value
+		case 'R':
+			current.Files = append(current.Files, path.Join("/", dir, value))
+		case 'D':
+			for _, dep := range strings.Fields(value) {
+				//conflicts are not dependencies
+				if strings.HasPrefix(dep, "!") {
+					continue
+				}
+
+				current.Depends = append(current.Depends, apkDepName(dep))
+			}
+		case 'p':
+			for _, provided := range strings.Fields(value) {
+				current.Provides = append(current.Provides, apkDepName(provided))
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return namedPackages(packages), nil
+}
+
+// apkDepName removes the version constraints (e.g., 'so:libc.musl-x86_64.so.1=1' or 'busybox>=1.34')
+func apkDepName(dep string) string {
+	if idx := strings.IndexAny(dep, "=<>~"); idx > 0 {
+		return dep[:idx]
+	}
+
+	return dep
+}
+
+// parseDpkgDB parses the dpkg 'status' database
+// (package records are separated by empty lines and the installed size is in KiB)
+func parseDpkgDB(data []byte) ([]*OSPackageInfo, error) {
+	records, err := dpkgRecords(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*OSPackageInfo
+	for _, fields := range records {
+		//the per package status files don't have the 'Status' field
+		if status, found := fields["Status"]; found && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+
+		current := &OSPackageInfo{
+			OSPackage: OSPackage{
+				Name:    fields["Package"],
+				Version: fields["Version"],
+				Arch:    fields["Architecture"],
+			},
+			Depends:  dpkgDepNames(fields["Pre-Depends"], fields["Depends"]),
+			Provides: dpkgDepNames(fields["Provides"]),
+		}
+
+		if size, err := strconv.ParseUint(fields["Installed-Size"], 10, 64); err == nil {
+			current.Size = size * 1024
+		}
+
+		packages = append(packages, current)
+	}
+
+	return namedPackages(packages), nil
+}
+
+// dpkgRecords returns the dpkg database record fields
+// (the continuation lines are added to the field value on separate lines)
+func dpkgRecords(data []byte) ([]map[string]string, error) {
+	var records []map[string]string
+	var current map[string]string
+	var key string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+
+		//continuation lines (e.g., the package description)
+		if line[0] == ' ' || line[0] == '\t' {
+			if current != nil && key != "" {
+				current[key] += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+
+		if current == nil {
+			current = map[string]string{}
+			records = append(records, current)
+		}
+
+		key = line[:idx]
+		current[key] = strings.TrimSpace(line[idx+1:])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// dpkgDepNames returns the package names from the dependency fields
+// (e.g., 'libc6 (>= 2.34), libssl3 | libssl1.1')
+// keeping the alternatives as a '|' separated list
+func dpkgDepNames(values ...string) []string {
+	var names []string
+	for _, val := range values {
+		for _, dep := range strings.Split(val, ",") {
+			var alternatives []string
+			for _, alt := range strings.Split(dep, "|") {
+				//the version, architecture and build profile restrictions are removed
+				if idx := strings.IndexAny(alt, "([<"); idx >= 0 {
+					alt = alt[:idx]
+				}
+
+				alt = strings.TrimSpace(alt)
+				if idx := strings.Index(alt, ":"); idx > 0 {
+					//multi-arch qualifier (e.g., 'python3:any')
+					alt = alt[:idx]
+				}
+
+				if alt != "" {
+					alternatives = append(alternatives, alt)
+				}
+			}
+
+			if len(alternatives) > 0 {
+				names = append(names, strings.Join(alternatives, "|"))
+			}
+		}
+	}
+
+	return names
+}
+
+func namedPackages(packages []*OSPackageInfo) []*OSPackageInfo {
+	var named []*OSPackageInfo
+	for _, p := range packages {
+		if p.Name != "" {
+			named = append(named, p)
+		}
+	}
+
+	return named
+}
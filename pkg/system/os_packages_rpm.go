@@ -0,0 +1,358 @@
+package system
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+//NOTE:
+//the rpm database is parsed without the rpm libraries,
+//the Berkeley DB hash ('Packages'), the SQLite ('rpmdb.sqlite')
+//and the NDB ('Packages.db') database formats are supported
+//(all of them store the same rpm header blobs)
+
+// Berkeley DB hash format constants
+const (
+	bdbHashMagic       = 0x061561
+	bdbPageHeaderSize  = 26
+	bdbPageTypeHashU   = 2 //P_HASH_UNSORTED
+	bdbPageTypeOverflw = 7 //P_OVERFLOW
+	bdbPageTypeHash    = 13
+	bdbItemKeyData     = 1 //H_KEYDATA
+	bdbItemOffPage     = 3 //H_OFFPAGE
+)
+
+// NDB format constants (the NDB data is little endian)
+const (
+	ndbHeaderMagic    = 'R' | 'p'<<8 | 'm'<<16 | 'P'<<24
+	ndbSlotMagic      = 'S' | 'l'<<8 | 'o'<<16 | 't'<<24
+	ndbBlobMagic      = 'B' | 'l'<<8 | 'b'<<16 | 'S'<<24
+	ndbVersion        = 0
+	ndbHeaderSize     = 32
+	ndbPageSize       = 4096
+	ndbSlotSize       = 16
+	ndbBlockSize      = 16
+	ndbBlobHeaderSize = 16
+)
+
+// rpm header tags and types
+const (
+	rpmTagName        = 1000
+	rpmTagVersion     = 1001
+	rpmTagRelease     = 1002
+	rpmTagEpoch       = 1003
+	rpmTagSize        = 1009
+	rpmTagLicense     = 1014
+	rpmTagArch        = 1022
+	rpmTagOldFiles    = 1027
+	rpmTagProvideName = 1047
+	rpmTagRequireName = 1049
+	rpmTagDirIndexes  = 1116
+	rpmTagBaseNames   = 1117
+	rpmTagDirNames    = 1118
+	rpmTagLongSize    = 5009
+
+	rpmTypeInt32       = 4
+	rpmTypeInt64       = 5
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+
+	rpmIndexEntrySize = 16
+	rpmPubKeyName     = "gpg-pubkey"
+	rpmLibCapPrefix   = "rpmlib("
+	rpmSqliteTable    = "Packages"
+)
+
+func parseRpmDB(data []byte) ([]*OSPackageInfo, error) {
+	var blobs [][]byte
+	var err error
+	switch {
+	case bytes.HasPrefix(data, sqliteMagic):
+		blobs, err = sqliteTableValues(data, rpmSqliteTable, 1)
+	case len(data) >= ndbHeaderSize && binary.LittleEndian.Uint32(data) == ndbHeaderMagic:
+		blobs, err = ndbValues(data)
+	default:
+		blobs, err = bdbHashValues(data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*OSPackageInfo
+	for _, blob := range blobs {
+		p, err := parseRpmHeader(blob)
+		if err != nil || p.Name == "" || p.Name == rpmPubKeyName {
+			continue
+		}
+
+		packages = append(packages, p)
+	}
+
+	return packages, nil
+}
+
+// ndbValues returns the blobs in an NDB package database file
+// (the slot pages at the beginning of the file point to the blobs;
+// the first two slots are used by the database header)
+func ndbValues(data []byte) ([][]byte, error) {
+	if binary.LittleEndian.Uint32(data[4:8]) != ndbVersion {
+		return nil, ErrUnsupportedPackageDB
+	}
+
+	slotPages := int(binary.LittleEndian.Uint32(data[12:16]))
+	if slotPages == 0 || slotPages > len(data)/ndbPageSize {
+		return nil, fmt.Errorf("bad NDB slot page count - %d", slotPages)
+	}
+
+	var values [][]byte
+	for offset := ndbHeaderSize; offset < slotPages*ndbPageSize; offset += ndbSlotSize {
+		slot := data[offset : offset+ndbSlotSize]
+		if binary.LittleEndian.Uint32(slot[0:4]) != ndbSlotMagic {
+			return nil, fmt.Errorf("bad NDB slot magic at %d", offset)
+		}
+
+		pkgIndex := binary.LittleEndian.Uint32(slot[4:8])
+		blobOffset := int(binary.LittleEndian.Uint32(slot[8:12])) * ndbBlockSize
+		if blobOffset == 0 {
+			//empty slot
+			continue
+		}
+
+		if blobOffset+ndbBlobHeaderSize > len(data) {
+			continue
+		}
+
+		blobHeader := data[blobOffset : blobOffset+ndbBlobHeaderSize]
+		if binary.LittleEndian.Uint32(blobHeader[0:4]) != ndbBlobMagic ||
+			binary.LittleEndian.Uint32(blobHeader[4:8]) != pkgIndex {
+			continue
+		}
+
+		blobStart := blobOffset + ndbBlobHeaderSize
+		blobLen := int(binary.LittleEndian.Uint32(blobHeader[12:16]))
+		if blobLen > len(data)-blobStart {
+			continue
+		}
+
+		values = append(values, data[blobStart:blobStart+blobLen])
+	}
+
+	return values, nil
+}
+
+// bdbHashValues returns the values in a Berkeley DB hash database file
+func bdbHashValues(data []byte) ([][]byte, error) {
+	if len(data) < 512 {
+		return nil, ErrUnsupportedPackageDB
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(data[12:16]) != bdbHashMagic {
+		order = binary.BigEndian
+		if order.Uint32(data[12:16]) != bdbHashMagic {
+			return nil, ErrUnsupportedPackageDB
+		}
+	}
+
+	pageSize := int(order.Uint32(data[20:24]))
+	if pageSize < 512 || len(data) < pageSize {
+		return nil, fmt.Errorf("bad Berkeley DB page size - %d", pageSize)
+	}
+
+	pageCount := len(data) / pageSize
+	page := func(pgno uint32) []byte {
+		if pgno == 0 || int(pgno) >= pageCount {
+			return nil
+		}
+
+		return data[int(pgno)*pageSize : int(pgno+1)*pageSize]
+	}
+
+	var values [][]byte
+	for pgno := 1; pgno < pageCount; pgno++ {
+		pdata := page(uint32(pgno))
+		pageType := pdata[25]
+		if pageType != bdbPageTypeHash && pageType != bdbPageTypeHashU {
+			continue
+		}
+
+		entries := int(order.Uint16(pdata[20:22]))
+		if bdbPageHeaderSize+entries*2 > pageSize {
+			continue
+		}
+
+		//the entries are key/value pairs (only the values are needed)
+		for idx := 1; idx < entries; idx += 2 {
+			offset := int(order.Uint16(pdata[bdbPageHeaderSize+idx*2:]))
+			if offset >= pageSize {
+				continue
+			}
+
+			switch pdata[offset] {
+			case bdbItemKeyData:
+				//the items are stored from the end of the page
+				end := pageSize
+				prevOffset := int(order.Uint16(pdata[bdbPageHeaderSize+(idx-1)*2:]))
+				if prevOffset > offset && prevOffset <= pageSize {
+					end = prevOffset
+				}
+
+				values = append(values, pdata[offset+1:end])
+			case bdbItemOffPage:
+				if offset+12 > pageSize {
+					continue
+				}
+
+				next := order.Uint32(pdata[offset+4:])
+				size := int(order.Uint32(pdata[offset+8:]))
+				value := make([]byte, 0, size)
+				for next != 0 && len(value) < size {
+					opage := page(next)
+					if opage == nil || opage[25] != bdbPageTypeOverflw {
+						break
+					}
+
+					//the overflow page 'hf_offset' field is the data length
+					length := int(order.Uint16(opage[22:24]))
+					if bdbPageHeaderSize+length > pageSize {
+						break
+					}
+
+					value = append(value, opage[bdbPageHeaderSize:bdbPageHeaderSize+length]...)
+					next = order.Uint32(opage[16:20])
+				}
+
+				if len(value) == size {
+					values = append(values, value)
+				}
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// parseRpmHeader parses the rpm header blob stored in the rpm database
+// (the header index and the data store use the network byte order)
+func parseRpmHeader(blob []byte) (*OSPackageInfo, error) {
+	if len(blob) < 8 {
+		return nil, ErrUnsupportedPackageDB
+	}
+
+	indexCount := int(binary.BigEndian.Uint32(blob[0:4]))
+	storeSize := int(binary.BigEndian.Uint32(blob[4:8]))
+	storeStart := 8 + indexCount*rpmIndexEntrySize
+	if indexCount < 0 || storeStart < 8 || storeStart+storeSize > len(blob) {
+		return nil, ErrUnsupportedPackageDB
+	}
+
+	store := blob[storeStart : storeStart+storeSize]
+	stringsAt := func(offset, count int) []string {
+		var values []string
+		for ; count > 0 && offset >= 0 && offset < len(store); count-- {
+			end := bytes.IndexByte(store[offset:], 0)
+			if end < 0 {
+				break
+			}
+
+			values = append(values, string(store[offset:offset+end]))
+			offset += end + 1
+		}
+
+		return values
+	}
+
+	stringAt := func(offset int) string {
+		if values := stringsAt(offset, 1); len(values) > 0 {
+			return values[0]
+		}
+
+		return ""
+	}
+
+	var p OSPackageInfo
+	var version, release string
+	var epoch uint32
+	var dirNames, baseNames []string
+	var dirIndexes []uint32
+	for idx := 0; idx < indexCount; idx++ {
+		entry := blob[8+idx*rpmIndexEntrySize:]
+		tag := binary.BigEndian.Uint32(entry[0:4])
+		dataType := binary.BigEndian.Uint32(entry[4:8])
+		offset := int(int32(binary.BigEndian.Uint32(entry[8:12])))
+		count := int(binary.BigEndian.Uint32(entry[12:16]))
+
+		switch {
+		case dataType == rpmTypeString:
+			switch tag {
+			case rpmTagName:
+				p.Name = stringAt(offset)
+			case rpmTagVersion:
+				version = stringAt(offset)
+			case rpmTagRelease:
+				release = stringAt(offset)
+			case rpmTagArch:
+				p.Arch = stringAt(offset)
+			case rpmTagLicense:
+				p.License = stringAt(offset)
+			}
+		case dataType == rpmTypeStringArray:
+			switch tag {
+			case rpmTagProvideName:
+				p.Provides = append(p.Provides, stringsAt(offset, count)...)
+			case rpmTagRequireName:
+				for _, name := range stringsAt(offset, count) {
+					//the rpmlib capabilities are provided by the rpm tool itself
+					if !strings.HasPrefix(name, rpmLibCapPrefix) {
+						p.Depends = append(p.Depends, name)
+					}
+				}
+			case rpmTagBaseNames:
+				baseNames = stringsAt(offset, count)
+			case rpmTagDirNames:
+				dirNames = stringsAt(offset, count)
+			case rpmTagOldFiles:
+				p.Files = append(p.Files, stringsAt(offset, count)...)
+			}
+		case dataType == rpmTypeInt32 && offset >= 0 && offset+4 <= len(store):
+			switch tag {
+			case rpmTagEpoch:
+				epoch = binary.BigEndian.Uint32(store[offset:])
+			case rpmTagSize:
+				if p.Size == 0 {
+					p.Size = uint64(binary.BigEndian.Uint32(store[offset:]))
+				}
+			case rpmTagDirIndexes:
+				for ; count > 0 && offset+4 <= len(store); count-- {
+					dirIndexes = append(dirIndexes, binary.BigEndian.Uint32(store[offset:]))
+					offset += 4
+				}
+			}
+		case dataType == rpmTypeInt64 && offset >= 0 && offset+8 <= len(store):
+			if tag == rpmTagLongSize {
+				p.Size = binary.BigEndian.Uint64(store[offset:])
+			}
+		}
+	}
+
+	p.Version = version
+	if release != "" {
+		p.Version = fmt.Sprintf("%s-%s", version, release)
+	}
+
+	if epoch > 0 {
+		p.Version = fmt.Sprintf("%d:%s", epoch, p.Version)
+	}
+
+	//the file paths are split into the directory names and the base names
+	for idx, name := range baseNames {
+		if idx < len(dirIndexes) && int(dirIndexes[idx]) < len(dirNames) {
+			p.Files = append(p.Files, dirNames[dirIndexes[idx]]+name)
+		}
+	}
+
+	return &p, nil
+}
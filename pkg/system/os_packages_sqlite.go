@@ -0,0 +1,346 @@
+package system
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+//NOTE:
+//this is a minimal read-only SQLite reader for the package databases,
+//it only reads the table b-tree pages in the database file
+//(the WAL file data is not used, so the database needs to be checkpointed,
+//which is done by the rpm tool when it closes the database)
+
+// SQLite file format constants
+const (
+	sqliteHeaderSize        = 100
+	sqlitePageTableInterior = 0x05
+	sqlitePageTableLeaf     = 0x0d
+	sqliteMasterRootPage    = 1
+)
+
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+var errBadSqliteRecord = errors.New("bad SQLite record")
+
+type sqliteDB struct {
+	data       []byte
+	pageSize   int
+	usableSize int
+	pageCount  int
+}
+
+// sqliteTableValues returns the column values (the blob or text values)
+// for all rows in the SQLite database table
+func sqliteTableValues(data []byte, tableName string, column int) ([][]byte, error) {
+	if len(data) < sqliteHeaderSize || !bytes.HasPrefix(data, sqliteMagic) {
+		return nil, ErrUnsupportedPackageDB
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 || len(data) < pageSize {
+		return nil, fmt.Errorf("bad SQLite page size - %d", pageSize)
+	}
+
+	db := &sqliteDB{
+		data:       data,
+		pageSize:   pageSize,
+		usableSize: pageSize - int(data[20]),
+		pageCount:  len(data) / pageSize,
+	}
+
+	//the table root pages are in the 'sqlite_master' table
+	//(the columns: type, name, tbl_name, rootpage, sql)
+	var rootPage uint32
+	err := db.tableRows(sqliteMasterRootPage, func(payload []byte) {
+		columns, err := sqliteRecord(payload)
+		if err != nil || len(columns) < 4 {
+			return
+		}
+
+		if recType, ok := columns[0].(string); !ok || recType != "table" {
+			return
+		}
+
+		if name, ok := columns[1].(string); !ok || name != tableName {
+			return
+		}
+
+		if root, ok := columns[3].(int64); ok && root > 0 {
+			rootPage = uint32(root)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rootPage == 0 {
+		return nil, fmt.Errorf("no '%s' table in the SQLite database", tableName)
+	}
+
+	var values [][]byte
+	err = db.tableRows(rootPage, func(payload []byte) {
+		columns, err := sqliteRecord(payload)
+		if err != nil || len(columns) <= column {
+			return
+		}
+
+		switch value := columns[column].(type) {
+		case []byte:
+			values = append(values, value)
+		case string:
+			values = append(values, []byte(value))
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (db *sqliteDB) page(pgno uint32) []byte {
+	if pgno == 0 || int(pgno) > db.pageCount {
+		return nil
+	}
+
+	return db.data[int(pgno-1)*db.pageSize : int(pgno)*db.pageSize]
+}
+
+// tableRows calls the handler with the record payload for each row in the table b-tree (in the rowid order)
+func (db *sqliteDB) tableRows(root uint32, handler func(payload []byte)) error {
+	visited := map[uint32]struct{}{}
+	var walk func(pgno uint32) error
+	walk = func(pgno uint32) error {
+		if _, found := visited[pgno]; found {
+			return fmt.Errorf("SQLite page loop - %d", pgno)
+		}
+
+		visited[pgno] = struct{}{}
+		page := db.page(pgno)
+		if page == nil {
+			return fmt.Errorf("bad SQLite page number - %d", pgno)
+		}
+
+		//the first page starts with the database header
+		hdr := 0
+		if pgno == 1 {
+			hdr = sqliteHeaderSize
+		}
+
+		if hdr+12 > len(page) {
+			return fmt.Errorf("bad SQLite page - %d", pgno)
+		}
+
+		pageType := page[hdr]
+		cellCount := int(binary.BigEndian.Uint16(page[hdr+3:]))
+		cellPtrs := hdr + 8
+		if pageType == sqlitePageTableInterior {
+			cellPtrs = hdr + 12
+		} else if pageType != sqlitePageTableLeaf {
+			return fmt.Errorf("unexpected SQLite page type - %d (page %d)", pageType, pgno)
+		}
+
+		if cellPtrs+cellCount*2 > len(page) {
+			return fmt.Errorf("bad SQLite cell count - %d (page %d)", cellCount, pgno)
+		}
+
+		for idx := 0; idx < cellCount; idx++ {
+			offset := int(binary.BigEndian.Uint16(page[cellPtrs+idx*2:]))
+			if offset >= len(page) || (pageType == sqlitePageTableInterior && offset+4 > len(page)) {
+				return fmt.Errorf("bad SQLite cell offset - %d (page %d)", offset, pgno)
+			}
+
+			if pageType == sqlitePageTableInterior {
+				//the interior cells have the left child page number and the rowid key
+				if err := walk(binary.BigEndian.Uint32(page[offset:])); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			payload, err := db.cellPayload(page, offset)
+			if err != nil {
+				return fmt.Errorf("%v (page %d)", err, pgno)
+			}
+
+			handler(payload)
+		}
+
+		if pageType == sqlitePageTableInterior {
+			return walk(binary.BigEndian.Uint32(page[hdr+8:]))
+		}
+
+		return nil
+	}
+
+	return walk(root)
+}
+
+// cellPayload returns the table leaf cell payload
+// (the cell has the payload size, the rowid, the local payload
+// and the first overflow page number if the payload doesn't fit on the page)
+func (db *sqliteDB) cellPayload(page []byte, offset int) ([]byte, error) {
+	size, n := sqliteVarint(page[offset:])
+	if n == 0 || size > uint64(len(db.data)) {
+		return nil, errBadSqliteRecord
+	}
+
+	offset += n
+	if _, n = sqliteVarint(page[offset:]); n == 0 {
+		return nil, errBadSqliteRecord
+	}
+
+	offset += n
+	payloadSize := int(size)
+	localSize := db.localPayloadSize(payloadSize)
+	if offset+localSize > len(page) {
+		return nil, errBadSqliteRecord
+	}
+
+	if localSize == payloadSize {
+		return page[offset : offset+localSize], nil
+	}
+
+	if offset+localSize+4 > len(page) {
+		return nil, errBadSqliteRecord
+	}
+
+	payload := make([]byte, 0, payloadSize)
+	payload = append(payload, page[offset:offset+localSize]...)
+	next := binary.BigEndian.Uint32(page[offset+localSize:])
+	//the overflow pages have the next overflow page number and the payload data
+	for count := 0; next != 0 && len(payload) < payloadSize && count < db.pageCount; count++ {
+		overflow := db.page(next)
+		if overflow == nil {
+			return nil, errBadSqliteRecord
+		}
+
+		chunk := overflow[4:db.usableSize]
+		if remaining := payloadSize - len(payload); len(chunk) > remaining {
+			chunk = chunk[:remaining]
+		}
+
+		payload = append(payload, chunk...)
+		next = binary.BigEndian.Uint32(overflow[0:4])
+	}
+
+	if len(payload) != payloadSize {
+		return nil, errBadSqliteRecord
+	}
+
+	return payload, nil
+}
+
+// localPayloadSize returns the size of the payload part stored on the table leaf page
+func (db *sqliteDB) localPayloadSize(size int) int {
+	maxLocal := db.usableSize - 35
+	if size <= maxLocal {
+		return size
+	}
+
+	minLocal := ((db.usableSize-12)*32)/255 - 23
+	local := minLocal + (size-minLocal)%(db.usableSize-4)
+	if local <= maxLocal {
+		return local
+	}
+
+	return minLocal
+}
+
+// sqliteRecord returns the record column values
+// (nil, int64, uint64 (the float64 bits), string or []byte values)
+func sqliteRecord(payload []byte) ([]interface{}, error) {
+	headerSize, n := sqliteVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) {
+		return nil, errBadSqliteRecord
+	}
+
+	var columns []interface{}
+	body := payload[headerSize:]
+	for pos := n; pos < int(headerSize); {
+		serialType, n := sqliteVarint(payload[pos:int(headerSize)])
+		if n == 0 {
+			return nil, errBadSqliteRecord
+		}
+
+		pos += n
+		var size int
+		switch {
+		case serialType == 0 || serialType == 8 || serialType == 9:
+			size = 0
+		case serialType <= 4:
+			size = int(serialType)
+		case serialType == 5:
+			size = 6
+		case serialType == 6 || serialType == 7:
+			size = 8
+		case serialType >= 12:
+			size = int((serialType - 12) / 2)
+		default:
+			return nil, errBadSqliteRecord
+		}
+
+		if size > len(body) {
+			return nil, errBadSqliteRecord
+		}
+
+		value := body[:size]
+		body = body[size:]
+		switch {
+		case serialType == 0:
+			columns = append(columns, nil)
+		case serialType == 8:
+			columns = append(columns, int64(0))
+		case serialType == 9:
+			columns = append(columns, int64(1))
+		case serialType <= 6:
+			//big endian two's complement integers
+			var iv int64
+			if value[0]&0x80 != 0 {
+				iv = -1
+			}
+
+			for _, b := range value {
+				iv = iv<<8 | int64(b)
+			}
+
+			columns = append(columns, iv)
+		case serialType == 7:
+			columns = append(columns, binary.BigEndian.Uint64(value))
+		case serialType%2 == 0:
+			columns = append(columns, value)
+		default:
+			columns = append(columns, string(value))
+		}
+	}
+
+	return columns, nil
+}
+
+// sqliteVarint returns the SQLite variable length integer and its size (0 if it's not valid)
+// (the first 8 bytes have 7 bits of data and the 9th byte has 8 bits)
+func sqliteVarint(data []byte) (uint64, int) {
+	var value uint64
+	for idx := 0; idx < len(data) && idx < 9; idx++ {
+		if idx == 8 {
+			return value<<8 | uint64(data[idx]), 9
+		}
+
+		value = value<<7 | uint64(data[idx]&0x7f)
+		if data[idx]&0x80 == 0 {
+			return value, idx + 1
+		}
+	}
+
+	return 0, 0
+}
@@ -0,0 +1,602 @@
+package system
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestOSPackageDBType(t *testing.T) {
+	tt := map[string]string{
+		ApkInstalledDBFile:                     OSPackageDBApk,
+		DpkgStatusDBFile:                       OSPackageDBDpkg,
+		"/var/lib/dpkg/status.d/base":          OSPackageDBDpkg,
+		"/var/lib/dpkg/status.d/base.md5sums":  "",
+		"/var/lib/dpkg/status.d/dir/base":      "",
+		RpmPackagesDBFile:                      OSPackageDBRpm,
+		RpmSqliteDBFileNew:                     OSPackageDBRpm,
+		"/var/lib/dpkg/status-old":             "",
+		"/usr/lib/sysimage/rpm/Packages.other": "",
+	}
+
+	for name, expected := range tt {
+		if actual := OSPackageDBType(name); actual != expected {
+			t.Errorf("OSPackageDBType(%s) - got '%s' expected '%s'", name, actual, expected)
+		}
+	}
+}
+
+func TestParseApkDB(t *testing.T) {
+	data := `C:Q1abc=
+P:musl
+V:1.2.2-r7
+A:x86_64
+I:622592
+L:MIT
+F:lib
+
+P:busybox
+V:1.34.1-r3
+A:x86_64
+I:bad
+
+V:9.9
+bad line
+`
+
+	packages, err := NewOSPackagesFromData(OSPackageDBApk, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the records without the package name are ignored
+	expected := []*OSPackage{
+		{Name: "musl", Version: "1.2.2-r7", Arch: "x86_64", Size: 622592, License: "MIT"},
+		{Name: "busybox", Version: "1.34.1-r3", Arch: "x86_64"},
+	}
+
+	if !reflect.DeepEqual(packages, expected) {
+		t.Errorf("got %+v expected %+v", packages, expected)
+	}
+}
+
+func TestParseDpkgDB(t *testing.T) {
+	data := `Package: libc6
+Status: install ok installed
+Installed-Size: 12
+Architecture: amd64
+Version: 2.31-13
+Description: GNU C Library
+ Package: not-a-package
+
+Package: removed-pkg
+Status: deinstall ok config-files
+Version: 1.0
+
+Package: tzdata
+Status: install ok installed
+Architecture: all
+Version: 2021a-1`
+
+	packages, err := NewOSPackagesFromData(OSPackageDBDpkg, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the installed size is in KiB and the removed packages are ignored
+	expected := []*OSPackage{
+		{Name: "libc6", Version: "2.31-13", Arch: "amd64", Size: 12 * 1024},
+		{Name: "tzdata", Version: "2021a-1", Arch: "all"},
+	}
+
+	if !reflect.DeepEqual(packages, expected) {
+		t.Errorf("got %+v expected %+v", packages, expected)
+	}
+
+	//the distroless per package status files don't have the 'Status' field
+	packages, err = NewOSPackagesFromData(OSPackageDBDpkg, []byte("Package: base-files\nVersion: 11.1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 1 || packages[0].Name != "base-files" {
+		t.Errorf("status.d - got %+v", packages)
+	}
+}
+
+func TestNewOSPackageInfoFromData(t *testing.T) {
+	apkData := `P:busybox
+V:1.34.1-r3
+D:so:libc.musl-x86_64.so.1 !busybox-extras
+p:/bin/sh cmd:busybox=1.34.1-r3
+F:bin
+R:busybox
+F:etc
+R:securetty
+`
+
+	packages, err := NewOSPackageInfoFromData(OSPackageDBApk, []byte(apkData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the conflicts ('!') are not dependencies
+	expected := []*OSPackageInfo{
+		{
+			OSPackage: OSPackage{Name: "busybox", Version: "1.34.1-r3"},
+			Depends:   []string{"so:libc.musl-x86_64.so.1"},
+			Provides:  []string{"/bin/sh", "cmd:busybox"},
+			Files:     []string{"/bin/busybox", "/etc/securetty"},
+		},
+	}
+
+	if !reflect.DeepEqual(packages, expected) {
+		t.Errorf("apk - got %+v expected %+v", packages, expected)
+	}
+
+	dpkgData := `Package: curl
+Status: hold ok installed
+Architecture: amd64
+Pre-Depends: libc6 (>= 2.17)
+Depends: libcurl4 (= 7.74.0-1.3), zlib1g | zlib-ng [amd64], python3:any
+Provides: curl-client
+`
+
+	packages, err = NewOSPackageInfoFromData(OSPackageDBDpkg, []byte(dpkgData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []*OSPackageInfo{
+		{
+			OSPackage: OSPackage{Name: "curl", Arch: "amd64"},
+			Depends:   []string{"libc6", "libcurl4", "zlib1g|zlib-ng", "python3"},
+			Provides:  []string{"curl-client"},
+		},
+	}
+
+	if !reflect.DeepEqual(packages, expected) {
+		t.Errorf("dpkg - got %+v expected %+v", packages, expected)
+	}
+}
+
+func TestApkDepName(t *testing.T) {
+	tt := map[string]string{
+		"busybox":                    "busybox",
+		"busybox>=1.34":              "busybox",
+		"so:libc.musl-x86_64.so.1=1": "so:libc.musl-x86_64.so.1",
+		"musl~1.2":                   "musl",
+		"pc:zlib<2":                  "pc:zlib",
+	}
+
+	for dep, expected := range tt {
+		if actual := apkDepName(dep); actual != expected {
+			t.Errorf("apkDepName(%s) - got '%s' expected '%s'", dep, actual, expected)
+		}
+	}
+}
+
+func TestDpkgRecords(t *testing.T) {
+	data := "Package: libc6\nDescription: GNU C Library\n Contains the standard libraries.\n\tMore info.\nConffiles:\n /etc/ld.so.conf.d/x86_64-linux-gnu.conf abc\n \n\nPackage: tzdata\n"
+	expected := []map[string]string{
+		{
+			"Package":     "libc6",
+			"Description": "GNU C Library\nContains the standard libraries.\nMore info.",
+			"Conffiles":   "\n/etc/ld.so.conf.d/x86_64-linux-gnu.conf abc",
+		},
+		{
+			"Package": "tzdata",
+		},
+	}
+
+	actual, err := dpkgRecords([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %#v expected %#v", actual, expected)
+	}
+}
+
+func TestDpkgDepNames(t *testing.T) {
+	tt := []struct {
+		values   []string
+		expected []string
+	}{
+		{
+			values:   []string{""},
+			expected: nil,
+		},
+		{
+			values:   []string{"libc6 (>= 2.34), libssl3 | libssl1.1"},
+			expected: []string{"libc6", "libssl3|libssl1.1"},
+		},
+		{
+			values:   []string{"dpkg (>= 1.15.6~)", "perl:any, debconf (>= 0.5) | debconf-2.0"},
+			expected: []string{"dpkg", "perl", "debconf|debconf-2.0"},
+		},
+		{
+			values:   []string{"libfoo [linux-any], , bar <!nocheck>"},
+			expected: []string{"libfoo", "bar"},
+		},
+	}
+
+	for _, test := range tt {
+		if actual := dpkgDepNames(test.values...); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("dpkgDepNames(%q) - got %q expected %q", test.values, actual, test.expected)
+		}
+	}
+}
+
+func TestNewOSPackagesFromDataUnknown(t *testing.T) {
+	if _, err := NewOSPackagesFromData("pacman", nil); err != ErrUnknownPackageDB {
+		t.Errorf("got %v expected %v", err, ErrUnknownPackageDB)
+	}
+}
+
+type testRpmTag struct {
+	tag   uint32
+	value interface{}
+}
+
+// newTestRpmHeader creates an rpm header blob
+// (index count, data store size, 16 byte index entries: tag, type, offset, count; and then the data store)
+func newTestRpmHeader(tags []testRpmTag) []byte {
+	var index, store bytes.Buffer
+	for _, t := range tags {
+		var dataType uint32
+		switch v := t.value.(type) {
+		case string:
+			dataType = rpmTypeString
+			binary.Write(&index, binary.BigEndian, []uint32{t.tag, dataType, uint32(store.Len()), 1})
+			store.WriteString(v)
+			store.WriteByte(0)
+		case []string:
+			dataType = rpmTypeStringArray
+			binary.Write(&index, binary.BigEndian, []uint32{t.tag, dataType, uint32(store.Len()), uint32(len(v))})
+			for _, str := range v {
+				store.WriteString(str)
+				store.WriteByte(0)
+			}
+		case uint32:
+			dataType = rpmTypeInt32
+			store.Write(make([]byte, (4-store.Len()%4)%4))
+			binary.Write(&index, binary.BigEndian, []uint32{t.tag, dataType, uint32(store.Len()), 1})
+			binary.Write(&store, binary.BigEndian, v)
+		case []uint32:
+			dataType = rpmTypeInt32
+			store.Write(make([]byte, (4-store.Len()%4)%4))
+			binary.Write(&index, binary.BigEndian, []uint32{t.tag, dataType, uint32(store.Len()), uint32(len(v))})
+			binary.Write(&store, binary.BigEndian, v)
+		case uint64:
+			dataType = rpmTypeInt64
+			store.Write(make([]byte, (8-store.Len()%8)%8))
+			binary.Write(&index, binary.BigEndian, []uint32{t.tag, dataType, uint32(store.Len()), 1})
+			binary.Write(&store, binary.BigEndian, v)
+		}
+	}
+
+	var blob bytes.Buffer
+	binary.Write(&blob, binary.BigEndian, []uint32{uint32(len(tags)), uint32(store.Len())})
+	blob.Write(index.Bytes())
+	blob.Write(store.Bytes())
+	return blob.Bytes()
+}
+
+const testBDBPageSize = 512
+
+// newTestBDBHash creates a little endian Berkeley DB hash database
+// (the metadata page, one hash page with the small values stored on the page
+// and the overflow pages for the large values)
+func newTestBDBHash(values [][]byte) []byte {
+	pages := [][]byte{make([]byte, testBDBPageSize), make([]byte, testBDBPageSize)}
+	binary.LittleEndian.PutUint32(pages[0][12:], bdbHashMagic)
+	binary.LittleEndian.PutUint32(pages[0][20:], testBDBPageSize)
+
+	hashPage := pages[1]
+	hashPage[25] = bdbPageTypeHash
+	binary.LittleEndian.PutUint16(hashPage[20:], uint16(len(values)*2))
+
+	//the items are added from the end of the page (key, then value)
+	itemEnd := testBDBPageSize
+	addItem := func(idx int, item []byte) {
+		itemEnd -= len(item)
+		copy(hashPage[itemEnd:], item)
+		binary.LittleEndian.PutUint16(hashPage[bdbPageHeaderSize+idx*2:], uint16(itemEnd))
+	}
+
+	for idx, value := range values {
+		addItem(idx*2, []byte{bdbItemKeyData, byte(idx), 0, 0, 0})
+		if len(value) < 128 {
+			addItem(idx*2+1, append([]byte{bdbItemKeyData}, value...))
+			continue
+		}
+
+		//the large values are split across the overflow pages
+		item := make([]byte, 12)
+		item[0] = bdbItemOffPage
+		binary.LittleEndian.PutUint32(item[4:], uint32(len(pages)))
+		binary.LittleEndian.PutUint32(item[8:], uint32(len(value)))
+		addItem(idx*2+1, item)
+
+		chunkSize := testBDBPageSize - bdbPageHeaderSize
+		for start := 0; start < len(value); start += chunkSize {
+			end := start + chunkSize
+			if end > len(value) {
+				end = len(value)
+			}
+
+			page := make([]byte, testBDBPageSize)
+			page[25] = bdbPageTypeOverflw
+			if end < len(value) {
+				binary.LittleEndian.PutUint32(page[16:], uint32(len(pages)+1))
+			}
+
+			binary.LittleEndian.PutUint16(page[22:], uint16(end-start))
+			copy(page[bdbPageHeaderSize:], value[start:end])
+			pages = append(pages, page)
+		}
+	}
+
+	return bytes.Join(pages, nil)
+}
+
+func TestParseRpmDB(t *testing.T) {
+	bash := newTestRpmHeader([]testRpmTag{
+		{tag: rpmTagName, value: "bash"},
+		{tag: rpmTagVersion, value: "4.4.20"},
+		{tag: rpmTagRelease, value: "1.el8_4"},
+		{tag: rpmTagEpoch, value: uint32(1)},
+		{tag: rpmTagArch, value: "x86_64"},
+		{tag: rpmTagSize, value: uint32(100)},
+		{tag: rpmTagLongSize, value: uint64(7000000000)},
+	})
+
+	//the large header is stored on the overflow pages
+	license := string(bytes.Repeat([]byte("GPLv2+ and "), 60)) + "MIT"
+	glibc := newTestRpmHeader([]testRpmTag{
+		{tag: rpmTagName, value: "glibc"},
+		{tag: rpmTagVersion, value: "2.28"},
+		{tag: rpmTagArch, value: "x86_64"},
+		{tag: rpmTagSize, value: uint32(2048)},
+		{tag: rpmTagLicense, value: license},
+	})
+
+	pubKey := newTestRpmHeader([]testRpmTag{
+		{tag: rpmTagName, value: rpmPubKeyName},
+		{tag: rpmTagVersion, value: "8483c65d"},
+	})
+
+	data := newTestBDBHash([][]byte{bash, glibc, pubKey, {0, 0, 0, 1}})
+	packages, err := NewOSPackagesFromData(OSPackageDBRpm, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the gpg public keys and the bad headers are ignored
+	expected := []*OSPackage{
+		{Name: "bash", Version: "1:4.4.20-1.el8_4", Arch: "x86_64", Size: 7000000000},
+		{Name: "glibc", Version: "2.28", Arch: "x86_64", Size: 2048, License: license},
+	}
+
+	if !reflect.DeepEqual(packages, expected) {
+		t.Errorf("got %+v expected %+v", packages, expected)
+	}
+}
+
+// newTestNDB creates an NDB package database
+// (one slot page with the database header and the slots, and then the blobs)
+func newTestNDB(values [][]byte) []byte {
+	slotPage := make([]byte, ndbPageSize)
+	binary.LittleEndian.PutUint32(slotPage[0:], ndbHeaderMagic)
+	binary.LittleEndian.PutUint32(slotPage[12:], 1)
+	for offset := ndbHeaderSize; offset < ndbPageSize; offset += ndbSlotSize {
+		binary.LittleEndian.PutUint32(slotPage[offset:], ndbSlotMagic)
+	}
+
+	var blobs bytes.Buffer
+	for idx, value := range values {
+		pkgIndex := uint32(idx + 1)
+		slot := slotPage[ndbHeaderSize+idx*ndbSlotSize:]
+		binary.LittleEndian.PutUint32(slot[4:], pkgIndex)
+		binary.LittleEndian.PutUint32(slot[8:], uint32((ndbPageSize+blobs.Len())/ndbBlockSize))
+
+		binary.Write(&blobs, binary.LittleEndian, []uint32{ndbBlobMagic, pkgIndex, 0, uint32(len(value))})
+		blobs.Write(value)
+		blobs.Write(make([]byte, (ndbBlockSize-blobs.Len()%ndbBlockSize)%ndbBlockSize))
+	}
+
+	return append(slotPage, blobs.Bytes()...)
+}
+
+const testSqlitePageSize = 512
+
+// newTestSqlite creates an SQLite database with the rpm 'Packages' table
+// (the 'sqlite_master' table on the first page, the 'Packages' table on the second page
+// and the overflow pages for the large values)
+func newTestSqlite(values [][]byte) []byte {
+	varint := func(v uint64) []byte {
+		var data []byte
+		for flag := byte(0); ; flag = 0x80 {
+			data = append([]byte{byte(v&0x7f) | flag}, data...)
+			if v >>= 7; v == 0 {
+				return data
+			}
+		}
+	}
+
+	record := func(columns ...interface{}) []byte {
+		var header, body []byte
+		for _, column := range columns {
+			switch v := column.(type) {
+			case nil:
+				header = append(header, 0)
+			case int:
+				header = append(header, 1)
+				body = append(body, byte(v))
+			case string:
+				header = append(header, varint(uint64(len(v)*2+13))...)
+				body = append(body, v...)
+			case []byte:
+				header = append(header, varint(uint64(len(v)*2+12))...)
+				body = append(body, v...)
+			}
+		}
+
+		return append(append(varint(uint64(len(header)+1)), header...), body...)
+	}
+
+	db := &sqliteDB{usableSize: testSqlitePageSize}
+	pages := [][]byte{make([]byte, testSqlitePageSize), make([]byte, testSqlitePageSize)}
+	copy(pages[0], sqliteMagic)
+	binary.BigEndian.PutUint16(pages[0][16:], testSqlitePageSize)
+
+	fillLeaf := func(pgno int, rows [][]byte) {
+		page := pages[pgno-1]
+		hdr := 0
+		if pgno == 1 {
+			hdr = sqliteHeaderSize
+		}
+
+		page[hdr] = sqlitePageTableLeaf
+		binary.BigEndian.PutUint16(page[hdr+3:], uint16(len(rows)))
+		cellEnd := testSqlitePageSize
+		for idx, payload := range rows {
+			local := db.localPayloadSize(len(payload))
+			cell := append(varint(uint64(len(payload))), varint(uint64(idx+1))...)
+			cell = append(cell, payload[:local]...)
+			if local < len(payload) {
+				//the overflow page chain
+				cell = append(cell, make([]byte, 4)...)
+				binary.BigEndian.PutUint32(cell[len(cell)-4:], uint32(len(pages)+1))
+				for start := local; start < len(payload); start += testSqlitePageSize - 4 {
+					overflow := make([]byte, testSqlitePageSize)
+					end := start + testSqlitePageSize - 4
+					if end < len(payload) {
+						binary.BigEndian.PutUint32(overflow, uint32(len(pages)+2))
+					} else {
+						end = len(payload)
+					}
+
+					copy(overflow[4:], payload[start:end])
+					pages = append(pages, overflow)
+				}
+			}
+
+			cellEnd -= len(cell)
+			copy(page[cellEnd:], cell)
+			binary.BigEndian.PutUint16(page[hdr+8+idx*2:], uint16(cellEnd))
+		}
+	}
+
+	fillLeaf(1, [][]byte{record("table", rpmSqliteTable, rpmSqliteTable, 2, "CREATE TABLE ...")})
+
+	var rows [][]byte
+	for _, value := range values {
+		rows = append(rows, record(nil, value))
+	}
+
+	fillLeaf(2, rows)
+	return bytes.Join(pages, nil)
+}
+
+func TestParseRpmDBFormats(t *testing.T) {
+	//the large header doesn't fit on the SQLite page
+	license := string(bytes.Repeat([]byte("GPLv2+ and "), 100)) + "MIT"
+	bash := newTestRpmHeader([]testRpmTag{
+		{tag: rpmTagName, value: "bash"},
+		{tag: rpmTagVersion, value: "5.1.8"},
+		{tag: rpmTagRelease, value: "6.el9"},
+		{tag: rpmTagArch, value: "x86_64"},
+		{tag: rpmTagLicense, value: license},
+		{tag: rpmTagProvideName, value: []string{"/bin/bash", "bash", "bash(x86-64)"}},
+		{tag: rpmTagRequireName, value: []string{"libc.so.6()(64bit)", "rpmlib(CompressedFileNames)", "filesystem"}},
+		{tag: rpmTagDirNames, value: []string{"/etc/skel/", "/usr/bin/"}},
+		{tag: rpmTagBaseNames, value: []string{".bashrc", "bash", "sh"}},
+		{tag: rpmTagDirIndexes, value: []uint32{0, 1, 1}},
+	})
+
+	pubKey := newTestRpmHeader([]testRpmTag{
+		{tag: rpmTagName, value: rpmPubKeyName},
+		{tag: rpmTagVersion, value: "8483c65d"},
+	})
+
+	//the rpmlib capabilities are provided by the rpm tool (they are not dependencies)
+	expected := []*OSPackageInfo{
+		{
+			OSPackage: OSPackage{Name: "bash", Version: "5.1.8-6.el9", Arch: "x86_64", License: license},
+			Depends:   []string{"libc.so.6()(64bit)", "filesystem"},
+			Provides:  []string{"/bin/bash", "bash", "bash(x86-64)"},
+			Files:     []string{"/etc/skel/.bashrc", "/usr/bin/bash", "/usr/bin/sh"},
+		},
+	}
+
+	tt := []struct {
+		name string
+		data []byte
+	}{
+		{name: "bdb", data: newTestBDBHash([][]byte{pubKey, bash})},
+		{name: "sqlite", data: newTestSqlite([][]byte{pubKey, bash})},
+		{name: "ndb", data: newTestNDB([][]byte{pubKey, bash})},
+	}
+
+	for _, test := range tt {
+		packages, err := NewOSPackageInfoFromData(OSPackageDBRpm, test.data)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(packages, expected) {
+			t.Errorf("%s: got %+v expected %+v", test.name, packages, expected)
+		}
+	}
+}
+
+func TestParseRpmDBBad(t *testing.T) {
+	//no rows in the 'sqlite_master' table
+	noTable := newTestSqlite(nil)
+	binary.BigEndian.PutUint16(noTable[sqliteHeaderSize+3:], 0)
+
+	badSlot := newTestNDB(nil)
+	binary.LittleEndian.PutUint32(badSlot[ndbHeaderSize:], 0)
+
+	tt := []struct {
+		name string
+		data []byte
+	}{
+		{name: "sqlite page size", data: append(append([]byte{}, sqliteMagic...), make([]byte, 1024)...)},
+		{name: "sqlite no table", data: noTable},
+		{name: "ndb slot", data: badSlot},
+	}
+
+	for _, test := range tt {
+		if _, err := NewOSPackagesFromData(OSPackageDBRpm, test.data); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestParseRpmDBUnsupported(t *testing.T) {
+	tt := []struct {
+		name string
+		data []byte
+	}{
+		{name: "short", data: make([]byte, 100)},
+		{name: "no magic", data: make([]byte, 1024)},
+	}
+
+	for _, test := range tt {
+		if _, err := NewOSPackagesFromData(OSPackageDBRpm, test.data); err != ErrUnsupportedPackageDB {
+			t.Errorf("%s: got %v expected %v", test.name, err, ErrUnsupportedPackageDB)
+		}
+	}
+
+	badPageSize := newTestBDBHash(nil)
+	binary.LittleEndian.PutUint32(badPageSize[20:], 4096)
+	if _, err := NewOSPackagesFromData(OSPackageDBRpm, badPageSize); err == nil {
+		t.Error("bad page size: expected error")
+	}
+}
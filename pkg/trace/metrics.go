@@ -0,0 +1,172 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Metric types
+const (
+	MetricGauge   = "gauge"
+	MetricCounter = "counter"
+)
+
+// Metric is a measurement recorded during the command run
+type Metric struct {
+	Type        string
+	Name        string
+	Unit        string
+	Description string
+	Value       float64
+	Attrs       map[string]interface{}
+	Time        time.Time
+}
+
+// Gauge records the current value of a measurement
+func (r *Recorder) Gauge(name, unit, description string, value float64, attrs map[string]interface{}) {
+	r.addMetric(MetricGauge, name, unit, description, value, attrs)
+}
+
+// Count records the number of times something happened during the run
+func (r *Recorder) Count(name, unit, description string, value int64, attrs map[string]interface{}) {
+	r.addMetric(MetricCounter, name, unit, description, float64(value), attrs)
+}
+
+func (r *Recorder) addMetric(mtype, name, unit, description string, value float64, attrs map[string]interface{}) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, &Metric{
+		Type:        mtype,
+		Name:        name,
+		Unit:        unit,
+		Description: description,
+		Value:       value,
+		Attrs:       attrs,
+		Time:        time.Now(),
+	})
+}
+
+// Metrics returns the recorded metrics
+func (r *Recorder) Metrics() []*Metric {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := make([]*Metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	return metrics
+}
+
+type otelDataPoint struct {
+	Attributes        []*otelKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+	AsInt             *string         `json:"asInt,omitempty"`
+}
+
+type otelGauge struct {
+	DataPoints []*otelDataPoint `json:"dataPoints"`
+}
+
+type otelSum struct {
+	DataPoints             []*otelDataPoint `json:"dataPoints"`
+	AggregationTemporality int              `json:"aggregationTemporality"`
+	IsMonotonic            bool             `json:"isMonotonic"`
+}
+
+type otelMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Gauge       *otelGauge `json:"gauge,omitempty"`
+	Sum         *otelSum   `json:"sum,omitempty"`
+}
+
+type otelScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []*otelMetric `json:"metrics"`
+}
+
+type otelResourceMetrics struct {
+	Resource struct {
+		Attributes []*otelKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []*otelScopeMetrics `json:"scopeMetrics"`
+}
+
+type otelMetrics struct {
+	ResourceMetrics []*otelResourceMetrics `json:"resourceMetrics"`
+}
+
+// OpenTelemetry aggregation temporality (delta)
+const otelTemporalityDelta = 1
+
+// WriteOTelMetrics writes the recorded metrics in the OTLP JSON format
+// (the counters are the monotonic delta sums for the command run)
+func (r *Recorder) WriteOTelMetrics(w io.Writer) error {
+	scopeMetrics := &otelScopeMetrics{}
+	scopeMetrics.Scope.Name = "docker-slim"
+
+	byName := map[string]*otelMetric{}
+	for _, m := range r.Metrics() {
+		point := &otelDataPoint{
+			Attributes:   otelAttributes(m.Attrs),
+			TimeUnixNano: unixNano(m.Time),
+		}
+
+		om, found := byName[m.Name]
+		if !found {
+			om = &otelMetric{
+				Name:        m.Name,
+				Description: m.Description,
+				Unit:        m.Unit,
+			}
+
+			if m.Type == MetricCounter {
+				om.Sum = &otelSum{
+					AggregationTemporality: otelTemporalityDelta,
+					IsMonotonic:            true,
+				}
+			} else {
+				om.Gauge = &otelGauge{}
+			}
+
+			byName[m.Name] = om
+			scopeMetrics.Metrics = append(scopeMetrics.Metrics, om)
+		}
+
+		if om.Sum != nil {
+			value := fmt.Sprintf("%d", int64(m.Value))
+			point.AsInt = &value
+			point.StartTimeUnixNano = unixNano(r.start)
+			om.Sum.DataPoints = append(om.Sum.DataPoints, point)
+		} else {
+			value := m.Value
+			point.AsDouble = &value
+			om.Gauge.DataPoints = append(om.Gauge.DataPoints, point)
+		}
+	}
+
+	resourceMetrics := &otelResourceMetrics{
+		ScopeMetrics: []*otelScopeMetrics{scopeMetrics},
+	}
+
+	resourceMetrics.Resource.Attributes = r.attributes()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(otelMetrics{ResourceMetrics: []*otelResourceMetrics{resourceMetrics}})
+}
@@ -0,0 +1,119 @@
+package trace
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OTLP/HTTP signal paths
+const (
+	otlpTracesPath  = "/v1/traces"
+	otlpMetricsPath = "/v1/metrics"
+)
+
+const otlpExportTimeout = 10 * time.Second
+
+// Exporter sends the recorded spans and metrics to an OpenTelemetry collector
+// using the OTLP/HTTP protocol with the JSON encoding
+type Exporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewExporter creates a new OTLP/HTTP exporter
+// (the endpoint is the collector base URL; the signal paths are added to it)
+func NewExporter(endpoint string, headers map[string]string) *Exporter {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	return &Exporter{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		client:   &http.Client{Timeout: otlpExportTimeout},
+	}
+}
+
+// ParseHeaders parses the OTLP headers value ('key1=value1,key2=value2'; the values can be URL encoded)
+func ParseHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		val := strings.TrimSpace(parts[1])
+		if decoded, err := url.QueryUnescape(val); err == nil {
+			val = decoded
+		}
+
+		if key != "" {
+			headers[key] = val
+		}
+	}
+
+	return headers
+}
+
+// Export sends the recorded spans and metrics
+func (e *Exporter) Export(r *Recorder) error {
+	if r == nil {
+		return nil
+	}
+
+	var spans bytes.Buffer
+	if err := r.WriteOTel(&spans); err != nil {
+		return err
+	}
+
+	if err := e.post(otlpTracesPath, &spans); err != nil {
+		return err
+	}
+
+	if len(r.Metrics()) == 0 {
+		return nil
+	}
+
+	var metrics bytes.Buffer
+	if err := r.WriteOTelMetrics(&metrics); err != nil {
+		return err
+	}
+
+	return e.post(otlpMetricsPath, &metrics)
+}
+
+func (e *Exporter) post(signalPath string, body io.Reader) error {
+	req, err := http.NewRequest(http.MethodPost, e.endpoint+signalPath, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("OTLP export error (%s): %s - %s",
+			signalPath, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
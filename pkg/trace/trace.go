@@ -0,0 +1,472 @@
+// Package trace records the command run timeline events and metrics
+// and exports them in the Chrome tracing or the OpenTelemetry (OTLP JSON) format.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trace output formats
+const (
+	FormatChrome = "chrome"
+	FormatOTel   = "otel"
+)
+
+// Common timeline tracks
+const (
+	TrackCommand   = "command"
+	TrackContainer = "container"
+	TrackSensor    = "sensor"
+	TrackProbe     = "probe"
+)
+
+var ErrUnknownFormat = errors.New("unknown trace format")
+
+// IsFormat returns true if the name is a supported trace output format
+func IsFormat(name string) bool {
+	return name == FormatChrome || name == FormatOTel
+}
+
+// Event is a timeline event (the instant events have no end time)
+type Event struct {
+	Track    string
+	Category string
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Args     map[string]interface{}
+}
+
+// IsInstant returns true for the events without duration
+func (e *Event) IsInstant() bool {
+	return e.End.IsZero()
+}
+
+// Recorder collects the timeline events
+// (all methods are safe to call on a nil recorder, so tracing can be optional)
+type Recorder struct {
+	name    string
+	start   time.Time
+	mu      sync.Mutex
+	events  []*Event
+	tracks  []string
+	metrics []*Metric
+	attrs   map[string]interface{}
+}
+
+// NewRecorder creates a new timeline recorder for the named run
+func NewRecorder(name string) *Recorder {
+	return &Recorder{
+		name:  name,
+		start: time.Now(),
+		attrs: map[string]interface{}{},
+	}
+}
+
+// SetAttribute sets a run level attribute
+// (saved as an OpenTelemetry resource attribute)
+func (r *Recorder) SetAttribute(key string, value interface{}) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attrs[key] = value
+}
+
+func (r *Recorder) attributes() []*otelKeyValue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attrs := []*otelKeyValue{otelAttribute("service.name", "docker-slim")}
+	return append(attrs, otelAttributes(r.attrs)...)
+}
+
+// Span records an event with duration
+func (r *Recorder) Span(track, category, name string, start, end time.Time, args map[string]interface{}) {
+	if r == nil {
+		return
+	}
+
+	if end.Before(start) {
+		end = start
+	}
+
+	r.add(&Event{
+		Track:    track,
+		Category: category,
+		Name:     name,
+		Start:    start,
+		End:      end,
+		Args:     args,
+	})
+}
+
+// Instant records an event without duration
+func (r *Recorder) Instant(track, category, name string, ts time.Time, args map[string]interface{}) {
+	if r == nil {
+		return
+	}
+
+	r.add(&Event{
+		Track:    track,
+		Category: category,
+		Name:     name,
+		Start:    ts,
+		Args:     args,
+	})
+}
+
+// Begin starts a span and returns the function to end it
+// (the args passed to the end function are added to the span args)
+func (r *Recorder) Begin(track, category, name string) func(args map[string]interface{}) {
+	if r == nil {
+		return func(map[string]interface{}) {}
+	}
+
+	start := time.Now()
+	return func(args map[string]interface{}) {
+		r.Span(track, category, name, start, time.Now(), args)
+	}
+}
+
+func (r *Recorder) add(event *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+	for _, track := range r.tracks {
+		if track == event.Track {
+			return
+		}
+	}
+
+	r.tracks = append(r.tracks, event.Track)
+}
+
+// Events returns the recorded events ordered by their start time
+func (r *Recorder) Events() []*Event {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]*Event, len(r.events))
+	copy(events, r.events)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	return events
+}
+
+func (r *Recorder) trackNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tracks := make([]string, len(r.tracks))
+	copy(tracks, r.tracks)
+	return tracks
+}
+
+// Save saves the timeline in the selected format
+func (r *Recorder) Save(location, format string) error {
+	if !IsFormat(format) {
+		return fmt.Errorf("%w - %s", ErrUnknownFormat, format)
+	}
+
+	if dir := filepath.Dir(location); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(location)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if format == FormatOTel {
+		return r.WriteOTel(file)
+	}
+
+	return r.WriteChrome(file)
+}
+
+// runSpan returns the time range for all recorded events
+func (r *Recorder) runSpan(events []*Event) (time.Time, time.Time) {
+	start, end := r.start, time.Now()
+	for _, e := range events {
+		if e.Start.Before(start) {
+			start = e.Start
+		}
+
+		if e.End.After(end) {
+			end = e.End
+		}
+	}
+
+	return start, end
+}
+
+type chromeEvent struct {
+	Name      string                 `json:"name"`
+	Category  string                 `json:"cat,omitempty"`
+	Phase     string                 `json:"ph"`
+	Timestamp int64                  `json:"ts"`
+	Duration  *int64                 `json:"dur,omitempty"`
+	Scope     string                 `json:"s,omitempty"`
+	Pid       int                    `json:"pid"`
+	Tid       int                    `json:"tid"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+type chromeTrace struct {
+	TraceEvents     []*chromeEvent    `json:"traceEvents"`
+	DisplayTimeUnit string            `json:"displayTimeUnit"`
+	OtherData       map[string]string `json:"otherData,omitempty"`
+}
+
+// WriteChrome writes the timeline in the Chrome trace event format
+// (for chrome://tracing, Perfetto UI or speedscope; each track is a thread)
+func (r *Recorder) WriteChrome(w io.Writer) error {
+	events := r.Events()
+	start, _ := r.runSpan(events)
+
+	trace := chromeTrace{
+		DisplayTimeUnit: "ms",
+		OtherData: map[string]string{
+			"name":  r.name,
+			"start": start.UTC().Format(time.RFC3339Nano),
+		},
+	}
+
+	trace.TraceEvents = append(trace.TraceEvents, &chromeEvent{
+		Name:  "process_name",
+		Phase: "M",
+		Pid:   1,
+		Args:  map[string]interface{}{"name": r.name},
+	})
+
+	tids := map[string]int{}
+	for idx, track := range r.trackNames() {
+		tids[track] = idx + 1
+		trace.TraceEvents = append(trace.TraceEvents,
+			&chromeEvent{
+				Name:  "thread_name",
+				Phase: "M",
+				Pid:   1,
+				Tid:   idx + 1,
+				Args:  map[string]interface{}{"name": track},
+			},
+			&chromeEvent{
+				Name:  "thread_sort_index",
+				Phase: "M",
+				Pid:   1,
+				Tid:   idx + 1,
+				Args:  map[string]interface{}{"sort_index": idx},
+			})
+	}
+
+	for _, e := range events {
+		ce := &chromeEvent{
+			Name:      e.Name,
+			Category:  e.Category,
+			Timestamp: e.Start.Sub(start).Microseconds(),
+			Pid:       1,
+			Tid:       tids[e.Track],
+			Args:      e.Args,
+		}
+
+		if e.IsInstant() {
+			ce.Phase = "i"
+			ce.Scope = "t"
+		} else {
+			ce.Phase = "X"
+			duration := e.End.Sub(e.Start).Microseconds()
+			ce.Duration = &duration
+		}
+
+		trace.TraceEvents = append(trace.TraceEvents, ce)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(trace)
+}
+
+type otelAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otelKeyValue struct {
+	Key   string       `json:"key"`
+	Value otelAnyValue `json:"value"`
+}
+
+type otelSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []*otelKeyValue `json:"attributes,omitempty"`
+}
+
+type otelScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []*otelSpan `json:"spans"`
+}
+
+type otelResourceSpans struct {
+	Resource struct {
+		Attributes []*otelKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []*otelScopeSpans `json:"scopeSpans"`
+}
+
+type otelTrace struct {
+	ResourceSpans []*otelResourceSpans `json:"resourceSpans"`
+}
+
+// OpenTelemetry span kind (internal)
+const otelSpanKindInternal = 1
+
+// WriteOTel writes the timeline as OpenTelemetry spans in the OTLP JSON format
+// (all events are the child spans of the command run span; the instant events have no duration)
+func (r *Recorder) WriteOTel(w io.Writer) error {
+	events := r.Events()
+	start, end := r.runSpan(events)
+	traceID := randomID(16)
+
+	root := &otelSpan{
+		TraceID:           traceID,
+		SpanID:            randomID(8),
+		Name:              r.name,
+		Kind:              otelSpanKindInternal,
+		StartTimeUnixNano: unixNano(start),
+		EndTimeUnixNano:   unixNano(end),
+	}
+
+	scopeSpans := &otelScopeSpans{
+		Spans: []*otelSpan{root},
+	}
+
+	scopeSpans.Scope.Name = "docker-slim"
+
+	for _, e := range events {
+		span := &otelSpan{
+			TraceID:           traceID,
+			SpanID:            randomID(8),
+			ParentSpanID:      root.SpanID,
+			Name:              e.Name,
+			Kind:              otelSpanKindInternal,
+			StartTimeUnixNano: unixNano(e.Start),
+			EndTimeUnixNano:   unixNano(e.Start),
+			Attributes: []*otelKeyValue{
+				otelAttribute("docker_slim.track", e.Track),
+				otelAttribute("docker_slim.category", e.Category),
+			},
+		}
+
+		if !e.IsInstant() {
+			span.EndTimeUnixNano = unixNano(e.End)
+		}
+
+		span.Attributes = append(span.Attributes, otelAttributes(e.Args)...)
+		scopeSpans.Spans = append(scopeSpans.Spans, span)
+	}
+
+	resourceSpans := &otelResourceSpans{
+		ScopeSpans: []*otelScopeSpans{scopeSpans},
+	}
+
+	resourceSpans.Resource.Attributes = r.attributes()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(otelTrace{ResourceSpans: []*otelResourceSpans{resourceSpans}})
+}
+
+// otelAttributes returns the attributes ordered by their keys
+func otelAttributes(values map[string]interface{}) []*otelKeyValue {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var attrs []*otelKeyValue
+	for _, key := range keys {
+		attrs = append(attrs, otelAttribute(key, values[key]))
+	}
+
+	return attrs
+}
+
+func otelAttribute(key string, value interface{}) *otelKeyValue {
+	kv := &otelKeyValue{Key: key}
+	switch v := value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case bool:
+		kv.Value.BoolValue = &v
+	case int:
+		iv := fmt.Sprintf("%d", v)
+		kv.Value.IntValue = &iv
+	case int64:
+		iv := fmt.Sprintf("%d", v)
+		kv.Value.IntValue = &iv
+	case uint32:
+		iv := fmt.Sprintf("%d", v)
+		kv.Value.IntValue = &iv
+	case uint64:
+		iv := fmt.Sprintf("%d", v)
+		kv.Value.IntValue = &iv
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		sv := fmt.Sprint(v)
+		kv.Value.StringValue = &sv
+	}
+
+	return kv
+}
+
+func unixNano(ts time.Time) string {
+	return fmt.Sprintf("%d", ts.UnixNano())
+}
+
+func randomID(size int) string {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		//not expected, but the IDs still need to be non-zero
+		data[0] = 1
+	}
+
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,77 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/docker-slim/docker-slim/pkg/cloudauth"
+)
+
+const (
+	gcsUploadURL = "https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s"
+	gcsScope     = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+type gcsUploader struct {
+	bucket string
+	prefix string
+	client *http.Client
+	token  string
+}
+
+func newGCSUploader(location *url.URL, client *http.Client) *gcsUploader {
+	return &gcsUploader{
+		bucket: location.Host,
+		prefix: location.Path,
+		client: client,
+	}
+}
+
+func (u *gcsUploader) Upload(filePath, name string) (string, error) {
+	size, err := fileSize(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if u.token == "" {
+		token, err := cloudauth.GoogleAccessToken(context.Background(), gcsScope)
+		if err != nil {
+			return "", err
+		}
+
+		u.token = token.AccessToken
+	}
+
+	key := objectKey(u.prefix, name)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	uploadURL := fmt.Sprintf(gcsUploadURL, url.PathEscape(u.bucket), url.QueryEscape(key))
+	req, err := http.NewRequest(http.MethodPost, uploadURL, file)
+	if err != nil {
+		return "", err
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType(filePath))
+	req.Header.Set("Authorization", "Bearer "+u.token)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("gs://%s/%s", u.bucket, key), nil
+}
@@ -0,0 +1,159 @@
+package upload
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+type httpUploader struct {
+	location *url.URL
+	client   *http.Client
+}
+
+func newHTTPUploader(location *url.URL, client *http.Client) *httpUploader {
+	return &httpUploader{
+		location: location,
+		client:   client,
+	}
+}
+
+// Upload uploads the file with a PUT request to the location path joined with the file name
+// (the credentials are from the location user info or from the netrc file)
+func (u *httpUploader) Upload(filePath, name string) (string, error) {
+	size, err := fileSize(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	target := *u.location
+	target.User = nil
+	target.Path = path.Join("/", u.location.Path, name)
+	target.RawPath = ""
+
+	req, err := http.NewRequest(http.MethodPut, target.String(), file)
+	if err != nil {
+		return "", err
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType(filePath))
+	if u.location.User != nil {
+		password, _ := u.location.User.Password()
+		req.SetBasicAuth(u.location.User.Username(), password)
+	} else if login, password, found := netrcCredentials(u.location.Hostname()); found {
+		req.SetBasicAuth(login, password)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	return target.String(), nil
+}
+
+// netrcCredentials returns the login and the password for the host from the netrc file
+// (the 'default' entry is used if there's no entry for the host)
+func netrcCredentials(host string) (string, string, bool) {
+	netrcPath := os.Getenv("NETRC")
+	if netrcPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", false
+		}
+
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+
+		netrcPath = filepath.Join(home, name)
+	}
+
+	file, err := os.Open(netrcPath)
+	if err != nil {
+		return "", "", false
+	}
+
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+
+	var login, password, defLogin, defPassword string
+	var inMachine, inDefault, found, foundDefault bool
+	for i := 0; i < len(tokens); i++ {
+		value := ""
+		if i+1 < len(tokens) {
+			value = tokens[i+1]
+		}
+
+		switch tokens[i] {
+		case "machine":
+			if found {
+				return login, password, true
+			}
+
+			inMachine = value == host
+			inDefault = false
+			found = inMachine
+			i++
+		case "default":
+			if found {
+				return login, password, true
+			}
+
+			inMachine = false
+			inDefault = true
+			foundDefault = true
+		case "login":
+			if inMachine {
+				login = value
+			} else if inDefault {
+				defLogin = value
+			}
+			i++
+		case "password":
+			if inMachine {
+				password = value
+			} else if inDefault {
+				defPassword = value
+			}
+			i++
+		case "account", "macdef":
+			i++
+		}
+	}
+
+	if found {
+		return login, password, true
+	}
+
+	return defLogin, defPassword, foundDefault
+}
@@ -0,0 +1,145 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/cloudauth"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	s3Service       = "s3"
+)
+
+// AWSCredentials are the AWS access keys (and the session token for the temporary credentials)
+type AWSCredentials = cloudauth.AWSCredentials
+
+type s3Uploader struct {
+	bucket   string
+	prefix   string
+	region   string
+	endpoint string
+	client   *http.Client
+	creds    *AWSCredentials
+}
+
+// newS3Uploader creates the S3 uploader
+// (the region and the S3 compatible endpoint can be set with the 'region' and 'endpoint' query params)
+func newS3Uploader(location *url.URL, client *http.Client) *s3Uploader {
+	query := location.Query()
+	region := query.Get("region")
+	if region == "" {
+		region = cloudauth.AWSRegion()
+	}
+
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	endpoint := query.Get("endpoint")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL_S3")
+	}
+
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+
+	return &s3Uploader{
+		bucket:   location.Host,
+		prefix:   location.Path,
+		region:   region,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   client,
+	}
+}
+
+func (u *s3Uploader) Upload(filePath, name string) (string, error) {
+	size, err := fileSize(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	payloadHash, err := fileSHA256(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if u.creds == nil {
+		if u.creds, err = cloudauth.AWSDefaultCredentials(context.Background()); err != nil {
+			return "", err
+		}
+	}
+
+	key := objectKey(u.prefix, name)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	req, err := http.NewRequest(http.MethodPut, u.objectURL(key), file)
+	if err != nil {
+		return "", err
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType(filePath))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	cloudauth.SignAWSRequest(req, u.creds, u.region, s3Service, payloadHash, time.Now())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("s3://%s/%s", u.bucket, key), nil
+}
+
+// objectURL returns the object URL (path-style for the custom endpoints and the bucket names with dots)
+func (u *s3Uploader) objectURL(key string) string {
+	switch {
+	case u.endpoint != "":
+		return fmt.Sprintf("%s/%s/%s", u.endpoint, u.bucket, s3EscapePath(key))
+	case strings.Contains(u.bucket, "."):
+		return fmt.Sprintf("https://s3.%s.amazonaws.com/%s/%s", u.region, u.bucket, s3EscapePath(key))
+	default:
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.bucket, u.region, s3EscapePath(key))
+	}
+}
+
+// s3EscapePath escapes the object key (the slashes are kept)
+func s3EscapePath(key string) string {
+	return cloudauth.AWSEscape(key, false)
+}
+
+func fileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
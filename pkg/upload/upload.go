@@ -0,0 +1,131 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/cloudauth"
+)
+
+// Supported upload location schemes
+const (
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+)
+
+var (
+	ErrUnsupportedScheme = errors.New("unsupported upload location scheme")
+	ErrNoBucket          = errors.New("missing bucket name in upload location")
+	//the credential errors are from the cloud credential lookups
+	ErrNoCredentials          = cloudauth.ErrNoCredentials
+	ErrUnsupportedCredentials = cloudauth.ErrUnsupportedCredentials
+)
+
+const requestTimeout = 10 * time.Minute
+
+// Uploader uploads the local files to the remote location
+type Uploader interface {
+	// Upload uploads the local file as the named object (relative to the location path)
+	Upload(filePath, name string) (string, error)
+}
+
+// New creates the uploader for the location (s3://bucket/prefix, gs://bucket/prefix or http(s)://host/path)
+func New(location string) (Uploader, error) {
+	locURL, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: requestTimeout}
+	switch locURL.Scheme {
+	case SchemeS3:
+		if locURL.Host == "" {
+			return nil, ErrNoBucket
+		}
+
+		return newS3Uploader(locURL, client), nil
+	case SchemeGCS:
+		if locURL.Host == "" {
+			return nil, ErrNoBucket
+		}
+
+		return newGCSUploader(locURL, client), nil
+	case SchemeHTTP, SchemeHTTPS:
+		return newHTTPUploader(locURL, client), nil
+	}
+
+	return nil, ErrUnsupportedScheme
+}
+
+// IsLocation returns true if the value is a supported upload location
+func IsLocation(value string) bool {
+	locURL, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	switch locURL.Scheme {
+	case SchemeS3, SchemeGCS, SchemeHTTP, SchemeHTTPS:
+		return locURL.Host != ""
+	}
+
+	return false
+}
+
+// JoinLocation returns the upload location with the extra path elements
+// (used to upload the files for different targets to their own locations)
+func JoinLocation(location string, elem ...string) string {
+	locURL, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	locURL.Path = path.Join(append([]string{"/", locURL.Path}, elem...)...)
+	return locURL.String()
+}
+
+// objectKey returns the object key for the name in the location path prefix
+func objectKey(prefix, name string) string {
+	return strings.TrimPrefix(path.Join(prefix, name), "/")
+}
+
+func contentType(filePath string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(filePath)); ct != "" {
+		return ct
+	}
+
+	return "application/octet-stream"
+}
+
+func fileSize(filePath string) (int64, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("not a regular file - %s", filePath)
+	}
+
+	return info.Size(), nil
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var msg [512]byte
+	n, _ := resp.Body.Read(msg[:])
+	return fmt.Errorf("upload failed - %s (%s)", resp.Status, strings.TrimSpace(string(msg[:n])))
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -137,6 +138,8 @@ const (
 	releasesStateKey       = "releases"
 	imageStateBaseKey      = "images"
 	imageStateArtifactsKey = "artifacts"
+	cacheStateKey          = "cache"
+	manifestStateKey       = "manifests"
 	stateArtifactsPerms    = 0777
 	releaseArtifactsPerms  = 0740
 )
@@ -365,7 +368,7 @@ func CopySymlinkFile(clone bool, src, dst string, makeDir bool) error {
 				}
 
 				if err := os.Lchown(dst, int(ssi.Uid), int(ssi.Gid)); err != nil {
-					log.Warnln("CopySymlinkFile(%v,%v)- unable to change owner", src, dst)
+					log.Warnf("CopySymlinkFile(%v,%v)- unable to change owner", src, dst)
 				}
 			}
 		} else {
@@ -383,14 +386,14 @@ type dirInfo struct {
 	sys   SysStat
 }
 
-func cloneDirPath(src, dst string) {
+func cloneDirPath(src, dst string) error {
 	src, err := filepath.Abs(src)
 	if err != nil {
-		errutil.FailOn(err)
+		return err
 	}
 	dst, err = filepath.Abs(dst)
 	if err != nil {
-		errutil.FailOn(err)
+		return err
 	}
 
 	var dirs []dirInfo
@@ -408,11 +411,11 @@ func cloneDirPath(src, dst string) {
 
 		srcInfo, err := os.Stat(src)
 		if err != nil {
-			errutil.FailOn(err)
+			return err
 		}
 
 		if !srcInfo.IsDir() {
-			errutil.Fail("not a directory")
+			return ErrSrcNotDir
 		}
 
 		if Exists(dst) {
@@ -439,7 +442,7 @@ func cloneDirPath(src, dst string) {
 		//fmt.Printf("cloning dir path = %#v\n", dir)
 		err = os.Mkdir(dir.dst, 0777)
 		if err != nil && !os.IsExist(err) {
-			errutil.FailOn(err)
+			return err
 		}
 
 		if err == nil {
@@ -453,11 +456,15 @@ func cloneDirPath(src, dst string) {
 				}
 
 				if err := os.Chown(dir.dst, int(dir.sys.Uid), int(dir.sys.Gid)); err != nil {
-					log.Warnln("cloneDirPath()- unable to change owner (%v) - %v", dir.dst, err)
+					log.Warnf("cloneDirPath()- unable to change owner (%v) - %v", dir.dst, err)
 				}
 			}
+
+			CopyXattrs(dir.src, dir.dst)
 		}
 	}
+
+	return nil
 }
 
 // CopyRegularFile copies a regular file
@@ -486,7 +493,9 @@ func CopyRegularFile(clone bool, src, dst string, makeDir bool) error {
 				srcDirPath := FileDir(src)
 
 				if clone {
-					cloneDirPath(srcDirPath, dstDirPath)
+					if err := cloneDirPath(srcDirPath, dstDirPath); err != nil {
+						return err
+					}
 				} else {
 					var dirMode os.FileMode = 0777
 					err = os.MkdirAll(dstDirPath, dirMode)
@@ -515,13 +524,24 @@ func CopyRegularFile(clone bool, src, dst string, makeDir bool) error {
 		}
 	}
 
+	if linkCopy(srcFileInfo, dst) {
+		log.Debugf("CopyRegularFile(%v,%v) - copied as hardlink", src, dst)
+		return nil
+	}
+
 	d, err := os.Create(dst)
 	if err != nil {
 		return err
 	}
 
 	if srcFileInfo.Size() > 0 {
-		written, err := io.Copy(d, s)
+		var written int64
+		if isSparse(srcFileInfo) {
+			written, err = copySparseData(d, s, srcFileInfo.Size())
+		} else {
+			written, err = CopyStream(d, s)
+		}
+
 		if err != nil {
 			d.Close()
 			return err
@@ -538,7 +558,7 @@ func CopyRegularFile(clone bool, src, dst string, makeDir bool) error {
 
 	//Need to close dst file before chmod works the right way
 	if err := d.Close(); err != nil {
-		log.Debugf("CopyRegularFile(%v,%v,%v) - d.Close error - %v", src, dst, err)
+		log.Debugf("CopyRegularFile(%v,%v,%v) - d.Close error - %v", src, dst, makeDir, err)
 		return err
 	}
 
@@ -556,7 +576,7 @@ func CopyRegularFile(clone bool, src, dst string, makeDir bool) error {
 				}
 
 				if err := os.Chown(dst, int(ssi.Uid), int(ssi.Gid)); err != nil {
-					log.Warnln("CopyRegularFile(%v,%v)- unable to change owner", src, dst)
+					log.Warnf("CopyRegularFile(%v,%v)- unable to change owner", src, dst)
 				}
 			}
 		} else {
@@ -579,6 +599,9 @@ func CopyRegularFile(clone bool, src, dst string, makeDir bool) error {
 		}
 	}
 
+	//copying the extended attributes last (changing the owner clears the file capabilities)
+	CopyXattrs(src, dst)
+	recordCopy(srcFileInfo, dst)
 	return nil
 }
 
@@ -654,7 +677,14 @@ func copyFileObjectHandler(
 			if _, err := os.Stat(targetPath); err != nil {
 				if os.IsNotExist(err) {
 					if clone {
-						cloneDirPath(path, targetPath)
+						if err := cloneDirPath(path, targetPath); err != nil {
+							if skipErrors {
+								*errs = append(*errs, err)
+								return nil
+							}
+
+							return err
+						}
 					} else {
 						err = os.MkdirAll(targetPath, 0777)
 						if err != nil {
@@ -676,6 +706,8 @@ func copyFileObjectHandler(
 									}
 								}
 							}
+
+							CopyXattrs(path, targetPath)
 						} else {
 							log.Warnf("copyFileObjectHandler() - os.Stat(%v) error - %v", path, err)
 						}
@@ -793,7 +825,9 @@ func CopyDirOnly(clone bool, src, dst string) error {
 
 	if !DirExists(dst) {
 		if clone {
-			cloneDirPath(src, dst)
+			if err := cloneDirPath(src, dst); err != nil {
+				return err
+			}
 		} else {
 			var dirMode os.FileMode = 0777
 			err = os.MkdirAll(dst, dirMode)
@@ -810,6 +844,8 @@ func CopyDirOnly(clone bool, src, dst string) error {
 					}
 				}
 			}
+
+			CopyXattrs(src, dst)
 		}
 	}
 
@@ -875,9 +911,14 @@ func CopyDir(clone bool,
 ///////////////////////////////////////////////////////////////////////////////
 
 // ExeDir returns the directory information for the application
+// (the directory of the real binary if the app is executed using a symlink, e.g., as a Docker CLI plugin)
 func ExeDir() string {
 	exePath, err := pdiscover.GetOwnProcPath()
 	errutil.FailOn(err)
+	if realPath, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = realPath
+	}
+
 	return filepath.Dir(exePath)
 }
 
@@ -947,7 +988,7 @@ func ResolveImageStateBasePath(statePrefix string) string {
 }
 
 // PrepareImageStateDirs ensures that the required application directories exist
-func PrepareImageStateDirs(statePrefix, imageID string) (string, string, string, string) {
+func PrepareImageStateDirs(statePrefix, imageID string) (string, string, string, string, error) {
 	//prepares the image processing directories
 	//creating the root state directory if it doesn't exist
 	log.Debugf("PrepareImageStateDirs(%v,%v)", statePrefix, imageID)
@@ -986,14 +1027,15 @@ func PrepareImageStateDirs(statePrefix, imageID string) (string, string, string,
 				srcSensorPath := filepath.Join(appDir, sensorFileName)
 				dstSensorPath := filepath.Join(statePrefix, sensorFileName)
 				err = CopyRegularFile(false, srcSensorPath, dstSensorPath, true)
-				errInfo := map[string]string{
-					"op":            "PrepareImageStateDirs",
-					"call":          "CopyRegularFile",
-					"srcSensorPath": srcSensorPath,
-					"dstSensorPath": dstSensorPath,
+				if err != nil {
+					log.WithFields(log.Fields{
+						"op":            "PrepareImageStateDirs",
+						"call":          "CopyRegularFile",
+						"srcSensorPath": srcSensorPath,
+						"dstSensorPath": dstSensorPath,
+					}).Errorf("error copying sensor - %v", err)
+					return "", "", "", "", err
 				}
-
-				errutil.FailOnWithInfo(err, errInfo)
 			} else {
 				log.Debugf("PrepareImageStateDirs - did not find tmp")
 			}
@@ -1010,23 +1052,45 @@ func PrepareImageStateDirs(statePrefix, imageID string) (string, string, string,
 		err = Remove(artifactLocation)
 		if err != nil {
 			log.Debugf("PrepareImageStateDirs - failed to remove existing state location: %v", artifactLocation)
-			errutil.FailOn(err)
+			return "", "", "", "", err
 		}
 	case os.IsNotExist(err):
 		log.Debugf("PrepareImageStateDirs - will create new state location: %v", artifactLocation)
 	default:
-		errutil.FailOn(err)
+		return "", "", "", "", err
 	}
 
 	err = os.MkdirAll(artifactLocation, stateArtifactsPerms)
-	errutil.FailOn(err)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
 	artifactDir, err = os.Stat(artifactLocation)
-	errutil.FailOn(err)
+	if err != nil {
+		return "", "", "", "", err
+	}
 	log.Debug("PrepareImageStateDirs - created new image state location: ", artifactLocation)
 
-	errutil.FailWhen(!artifactDir.IsDir(), "artifact location is not a directory")
+	if !artifactDir.IsDir() {
+		return "", "", "", "", fmt.Errorf("artifact location is not a directory - %s", artifactLocation)
+	}
+
+	return localVolumePath, artifactLocation, statePrefix, stateKey, nil
+}
+
+// CacheStateDir returns the location of the analysis result cache in the state directory
+func CacheStateDir(statePrefix string) string {
+	return filepath.Join(ResolveImageStateBasePath(statePrefix), rootStateKey, cacheStateKey)
+}
 
-	return localVolumePath, artifactLocation, statePrefix, stateKey
+// ManifestStateDir returns the location of the local manifest lists (created with 'registry manifest create')
+func ManifestStateDir(statePrefix string) string {
+	return filepath.Join(ResolveImageStateBasePath(statePrefix), rootStateKey, manifestStateKey)
+}
+
+// ImageStateDir returns the location of the image state directories (one per target image)
+func ImageStateDir(statePrefix string) string {
+	return filepath.Join(ResolveImageStateBasePath(statePrefix), rootStateKey, imageStateBaseKey)
 }
 
 // PrepareReleaseStateDirs ensures that the required app release directories exist
@@ -1176,8 +1240,9 @@ func ArchiveFiles(afname string,
 				return err
 			}
 
-			defer close(f)
-			if _, err := io.Copy(tw, f); err != nil {
+			_, err = CopyStream(tw, f)
+			close(f)
+			if err != nil {
 				return err
 			}
 		} else {
@@ -1212,6 +1277,8 @@ func ArchiveDir(afname string,
 	tw := tar.NewWriter(tf)
 	defer close(tw)
 
+	//the other links to the already archived files are saved as hardlinks
+	archivedLinks := map[inodeKey]string{}
 	onFSObject := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			log.Errorf("fsutil.ArchiveDir.onFSObject: path=%q err=%q", path, err)
@@ -1234,6 +1301,7 @@ func ArchiveDir(afname string,
 			}
 
 			th.Name = fmt.Sprintf("%s/", fpUpdate(path, trimPrefix, addPrefix))
+			addXattrRecords(th, path)
 			if err := tw.WriteHeader(th); err != nil {
 				return err
 			}
@@ -1244,6 +1312,18 @@ func ArchiveDir(afname string,
 			}
 
 			th.Name = fpUpdate(path, trimPrefix, addPrefix)
+			if key, ok := hardlinkKey(info); ok {
+				if linkName, found := archivedLinks[key]; found {
+					th.Typeflag = tar.TypeLink
+					th.Linkname = linkName
+					th.Size = 0
+					return tw.WriteHeader(th)
+				}
+
+				archivedLinks[key] = th.Name
+			}
+
+			addXattrRecords(th, path)
 			if err := tw.WriteHeader(th); err != nil {
 				return err
 			}
@@ -1253,8 +1333,11 @@ func ArchiveDir(afname string,
 				return err
 			}
 
-			defer close(f)
-			if _, err := io.Copy(tw, f); err != nil {
+			//close each file as soon as it's archived
+			//(keeping all of them open until the walk is done exhausts the file descriptors on large dirs)
+			_, err = CopyStream(tw, f)
+			close(f)
+			if err != nil {
 				return err
 			}
 		case info.Mode()&os.ModeSymlink != 0:
@@ -1285,6 +1368,25 @@ func ArchiveDir(afname string,
 	return nil
 }
 
+// CopyBufferSize is the size of the buffers used to stream file data
+const CopyBufferSize = 256 * 1024
+
+var copyBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, CopyBufferSize)
+		return &buf
+	},
+}
+
+// CopyStream copies the data from src to dst using a bounded (pooled) buffer,
+// so the memory used doesn't depend on the size of the data
+func CopyStream(dst io.Writer, src io.Reader) (int64, error) {
+	bufRef := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(bufRef)
+
+	return io.CopyBuffer(dst, src, *bufRef)
+}
+
 func close(ref io.Closer) {
 	if err := ref.Close(); err != nil {
 		log.Errorf("close - error closing: %v", err)
@@ -0,0 +1,100 @@
+package fsutil
+
+import (
+	"os"
+	"sync"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type inodeKey struct {
+	dev uint64
+	ino uint64
+}
+
+// hardlinks tracks the copies of the source files with multiple links,
+// so the other links to the same source file are copied as hardlinks
+// (instead of materializing duplicate copies)
+var hardlinks struct {
+	sync.Mutex
+	enabled bool
+	copies  map[inodeKey]string
+}
+
+// TrackHardlinks enables (or disables) the hardlink preservation in the copy functions
+func TrackHardlinks(enable bool) {
+	hardlinks.Lock()
+	defer hardlinks.Unlock()
+
+	hardlinks.enabled = enable
+	hardlinks.copies = map[inodeKey]string{}
+}
+
+func hardlinkKey(info os.FileInfo) (inodeKey, bool) {
+	sysStat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return inodeKey{}, false
+	}
+
+	ssi := SysStatInfo(sysStat)
+	if !ssi.Ok || ssi.Nlink < 2 {
+		return inodeKey{}, false
+	}
+
+	return inodeKey{dev: ssi.Dev, ino: ssi.Ino}, true
+}
+
+// linkCopy creates dst as a hardlink to the existing copy of the src file
+// (returns false if there's no copy or if the hardlink can't be created)
+func linkCopy(srcInfo os.FileInfo, dst string) bool {
+	key, ok := hardlinkKey(srcInfo)
+	if !ok {
+		return false
+	}
+
+	hardlinks.Lock()
+	defer hardlinks.Unlock()
+	if !hardlinks.enabled {
+		return false
+	}
+
+	copyPath, found := hardlinks.copies[key]
+	if !found || copyPath == dst {
+		return false
+	}
+
+	if err := os.Link(copyPath, dst); err != nil {
+		log.Debugf("fsutil.linkCopy: os.Link(%s,%s) error - %v", copyPath, dst, err)
+		return false
+	}
+
+	return true
+}
+
+// recordCopy saves the location of the src file copy
+func recordCopy(srcInfo os.FileInfo, dst string) {
+	key, ok := hardlinkKey(srcInfo)
+	if !ok {
+		return
+	}
+
+	hardlinks.Lock()
+	defer hardlinks.Unlock()
+	if hardlinks.enabled {
+		if _, found := hardlinks.copies[key]; !found {
+			hardlinks.copies[key] = dst
+		}
+	}
+}
+
+// isSparse returns true if the file has fewer data blocks allocated than its size needs
+func isSparse(info os.FileInfo) bool {
+	sysStat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+
+	ssi := SysStatInfo(sysStat)
+	return ssi.Ok && ssi.Blocks*512 < info.Size()
+}
@@ -0,0 +1,10 @@
+package fsutil
+
+import (
+	"os"
+)
+
+// copySparseData copies the file data (the holes are not preserved)
+func copySparseData(dst, src *os.File, size int64) (int64, error) {
+	return CopyStream(dst, src)
+}
@@ -0,0 +1,56 @@
+package fsutil
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copySparseData copies only the data regions of a sparse file
+// (the holes are recreated by extending the dst file to the src file size)
+func copySparseData(dst, src *os.File, size int64) (int64, error) {
+	fd := int(src.Fd())
+	var offset int64
+	for offset < size {
+		dataStart, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if err != nil {
+			if err == unix.ENXIO {
+				//no more data (the rest is a hole)
+				break
+			}
+
+			if offset == 0 {
+				//SEEK_DATA is not supported by the filesystem
+				return CopyStream(dst, src)
+			}
+
+			return 0, err
+		}
+
+		dataEnd, err := unix.Seek(fd, dataStart, unix.SEEK_HOLE)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := src.Seek(dataStart, io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		if _, err := dst.Seek(dataStart, io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		if _, err := CopyStream(dst, io.LimitReader(src, dataEnd-dataStart)); err != nil {
+			return 0, err
+		}
+
+		offset = dataEnd
+	}
+
+	if err := dst.Truncate(size); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
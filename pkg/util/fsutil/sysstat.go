@@ -11,6 +11,11 @@ type SysStat struct {
 	Atime syscall.Timespec
 	Mtime syscall.Timespec
 	Ctime syscall.Timespec
+	Dev   uint64
+	Ino   uint64
+	Nlink uint64
+	//number of 512B blocks allocated
+	Blocks int64
 }
 
 /*
@@ -12,5 +12,10 @@ func SysStatInfo(raw *syscall.Stat_t) SysStat {
 		Atime: raw.Atimespec,
 		Mtime: raw.Mtimespec,
 		Ctime: raw.Ctimespec,
+		//the Dev/Ino/Nlink field types are platform specific
+		Dev:    uint64(raw.Dev),
+		Ino:    uint64(raw.Ino),
+		Nlink:  uint64(raw.Nlink),
+		Blocks: int64(raw.Blocks),
 	}
 }
@@ -0,0 +1,121 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Extended attribute names with special meaning
+const (
+	XattrCapability = "security.capability"
+	XattrACLAccess  = "system.posix_acl_access"
+	XattrACLDefault = "system.posix_acl_default"
+)
+
+// XattrIssue describes an extended attribute (xattr, ACL or file capability)
+// that couldn't be preserved when copying a file object
+type XattrIssue struct {
+	Path  string `json:"path"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// the SELinux labels are host specific (they are set by the container runtime)
+var ignoredXattrs = map[string]struct{}{
+	"security.selinux": {},
+}
+
+var xattrIssues struct {
+	sync.Mutex
+	list []*XattrIssue
+}
+
+func addXattrIssue(path, name string, err error) {
+	log.Debugf("fsutil.addXattrIssue: path=%s name=%s error=%v", path, name, err)
+
+	xattrIssues.Lock()
+	defer xattrIssues.Unlock()
+	xattrIssues.list = append(xattrIssues.list, &XattrIssue{
+		Path:  path,
+		Name:  name,
+		Error: err.Error(),
+	})
+}
+
+// XattrIssues returns the extended attributes that couldn't be preserved by the copy functions
+func XattrIssues() []*XattrIssue {
+	xattrIssues.Lock()
+	defer xattrIssues.Unlock()
+
+	return append([]*XattrIssue(nil), xattrIssues.list...)
+}
+
+// Xattrs returns the extended attributes for the file object (without following symlinks)
+func Xattrs(target string) (map[string][]byte, error) {
+	names, err := listXattrs(target)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string][]byte{}
+	for _, name := range names {
+		if _, ok := ignoredXattrs[name]; ok {
+			continue
+		}
+
+		value, err := getXattr(target, name)
+		if err != nil {
+			return nil, err
+		}
+
+		attrs[name] = value
+	}
+
+	return attrs, nil
+}
+
+// CopyXattrs copies the extended attributes (including the POSIX ACLs and the file capabilities)
+// from the src file object to the dst file object.
+// The attributes that can't be copied are recorded (see XattrIssues()).
+// Note that changing the file owner clears the file capabilities,
+// so the attributes need to be copied after the owner is updated.
+func CopyXattrs(src, dst string) {
+	attrs, err := Xattrs(src)
+	if err != nil {
+		if err != errXattrsNotSupported {
+			addXattrIssue(src, "", err)
+		}
+
+		return
+	}
+
+	for name, value := range attrs {
+		if err := setXattr(dst, name, value); err != nil {
+			addXattrIssue(src, name, err)
+		}
+	}
+}
+
+const paxSchilyXattr = "SCHILY.xattr."
+
+// addXattrRecords adds the extended attributes for the file object to its tar header
+func addXattrRecords(th *tar.Header, path string) {
+	attrs, err := Xattrs(path)
+	if err != nil {
+		if err != errXattrsNotSupported {
+			addXattrIssue(path, "", err)
+		}
+
+		return
+	}
+
+	for name, value := range attrs {
+		if th.PAXRecords == nil {
+			th.PAXRecords = map[string]string{}
+		}
+
+		th.PAXRecords[paxSchilyXattr+name] = string(value)
+	}
+}
@@ -0,0 +1,19 @@
+package fsutil
+
+import (
+	"errors"
+)
+
+var errXattrsNotSupported = errors.New("extended attributes are not supported")
+
+func listXattrs(target string) ([]string, error) {
+	return nil, errXattrsNotSupported
+}
+
+func getXattr(target, name string) ([]byte, error) {
+	return nil, errXattrsNotSupported
+}
+
+func setXattr(target, name string, value []byte) error {
+	return errXattrsNotSupported
+}
@@ -0,0 +1,59 @@
+package fsutil
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+var errXattrsNotSupported = errors.New("extended attributes are not supported")
+
+func listXattrs(target string) ([]string, error) {
+	size, err := unix.Llistxattr(target, nil)
+	if err != nil {
+		if err == unix.ENOTSUP {
+			return nil, errXattrsNotSupported
+		}
+
+		return nil, err
+	}
+
+	if size == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Llistxattr(target, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+
+	return names, nil
+}
+
+func getXattr(target, name string) ([]byte, error) {
+	size, err := unix.Lgetxattr(target, name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Lgetxattr(target, name, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf[:size], nil
+}
+
+func setXattr(target, name string, value []byte) error {
+	return unix.Lsetxattr(target, name, value, 0)
+}
@@ -26,3 +26,37 @@ func Current() string {
 func Tag() string {
 	return appVersionTag
 }
+
+// Revision returns the source revision (commit hash) the app is built from
+func Revision() string {
+	return appVersionRev
+}
+
+// BuildTime returns the app build time
+func BuildTime() string {
+	return appVersionTime
+}
+
+// BuildInfo contains the app build metadata
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Name      string `json:"name"`
+	Tag       string `json:"tag"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Build returns the app build metadata
+func Build() *BuildInfo {
+	return &BuildInfo{
+		Version:   currentVersion,
+		Name:      consts.AppVersionName,
+		Tag:       appVersionTag,
+		Commit:    appVersionRev,
+		BuildTime: appVersionTime,
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	}
+}
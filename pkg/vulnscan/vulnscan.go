@@ -0,0 +1,255 @@
+package vulnscan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Supported vulnerability scanners
+const (
+	ScannerTrivy = "trivy"
+	ScannerGrype = "grype"
+)
+
+// Normalized vulnerability severity values
+const (
+	SeverityCritical = "CRITICAL"
+	SeverityHigh     = "HIGH"
+	SeverityMedium   = "MEDIUM"
+	SeverityLow      = "LOW"
+	SeverityUnknown  = "UNKNOWN"
+)
+
+var (
+	ErrUnknownScanner = errors.New("unknown vulnerability scanner")
+)
+
+// IsScanner returns true if the value is a supported vulnerability scanner
+func IsScanner(value string) bool {
+	switch value {
+	case ScannerTrivy, ScannerGrype:
+		return true
+	}
+
+	return false
+}
+
+// Vulnerability is a vulnerability match for an installed package
+type Vulnerability struct {
+	ID           string `json:"id"`
+	Package      string `json:"package"`
+	Version      string `json:"version"`
+	FixedVersion string `json:"fixed_version,omitempty"`
+	Severity     string `json:"severity"`
+}
+
+func (v *Vulnerability) key() string {
+	return fmt.Sprintf("%s/%s/%s", v.ID, v.Package, v.Version)
+}
+
+// Result contains the vulnerability scan results for an image
+type Result struct {
+	Image           string
+	Vulnerabilities []*Vulnerability
+}
+
+// CountBySeverity returns the number of vulnerabilities for each severity
+func (r *Result) CountBySeverity() map[string]int {
+	counts := map[string]int{}
+	for _, v := range r.Vulnerabilities {
+		counts[v.Severity]++
+	}
+
+	return counts
+}
+
+// Delta contains the vulnerabilities removed from (and added to) the original image
+type Delta struct {
+	Removed []*Vulnerability
+	Added   []*Vulnerability
+}
+
+// Compare returns the vulnerability differences between the original and the optimized image scan results
+func Compare(original, optimized *Result) *Delta {
+	delta := &Delta{}
+	inOriginal := map[string]struct{}{}
+	for _, v := range original.Vulnerabilities {
+		inOriginal[v.key()] = struct{}{}
+	}
+
+	inOptimized := map[string]struct{}{}
+	for _, v := range optimized.Vulnerabilities {
+		inOptimized[v.key()] = struct{}{}
+		if _, found := inOriginal[v.key()]; !found {
+			delta.Added = append(delta.Added, v)
+		}
+	}
+
+	for _, v := range original.Vulnerabilities {
+		if _, found := inOptimized[v.key()]; !found {
+			delta.Removed = append(delta.Removed, v)
+		}
+	}
+
+	return delta
+}
+
+// Scan runs the vulnerability scanner for the image
+// (exePath is optional; the scanner name is looked up in PATH if it's empty)
+func Scan(scanner string, exePath string, image string) (*Result, error) {
+	if exePath == "" {
+		exePath = scanner
+	}
+
+	var args []string
+	switch scanner {
+	case ScannerTrivy:
+		args = []string{"image", "--quiet", "--format", "json", image}
+	case ScannerGrype:
+		//the 'docker:' scheme makes grype use the local Docker engine image
+		args = []string{"--quiet", "--output", "json", "docker:" + image}
+	default:
+		return nil, ErrUnknownScanner
+	}
+
+	log.Debugf("vulnscan.Scan: %s %s", exePath, strings.Join(args, " "))
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(exePath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s error - %v (%s)", scanner, err, msg)
+		}
+
+		return nil, fmt.Errorf("%s error - %v", scanner, err)
+	}
+
+	var vulns []*Vulnerability
+	var err error
+	switch scanner {
+	case ScannerTrivy:
+		vulns, err = parseTrivy(stdout.Bytes())
+	case ScannerGrype:
+		vulns, err = parseGrype(stdout.Bytes())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Image:           image,
+		Vulnerabilities: uniqueVulnerabilities(vulns),
+	}, nil
+}
+
+type trivyReport struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+func parseTrivy(data []byte) ([]*Vulnerability, error) {
+	var report trivyReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var vulns []*Vulnerability
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			vulns = append(vulns, &Vulnerability{
+				ID:           v.VulnerabilityID,
+				Package:      v.PkgName,
+				Version:      v.InstalledVersion,
+				FixedVersion: v.FixedVersion,
+				Severity:     normalizeSeverity(v.Severity),
+			})
+		}
+	}
+
+	return vulns, nil
+}
+
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+			Fix      struct {
+				Versions []string `json:"versions"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+func parseGrype(data []byte) ([]*Vulnerability, error) {
+	var report grypeReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var vulns []*Vulnerability
+	for _, m := range report.Matches {
+		vulns = append(vulns, &Vulnerability{
+			ID:           m.Vulnerability.ID,
+			Package:      m.Artifact.Name,
+			Version:      m.Artifact.Version,
+			FixedVersion: strings.Join(m.Vulnerability.Fix.Versions, ","),
+			Severity:     normalizeSeverity(m.Vulnerability.Severity),
+		})
+	}
+
+	return vulns, nil
+}
+
+func normalizeSeverity(value string) string {
+	switch value = strings.ToUpper(value); value {
+	case SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow:
+		return value
+	case "NEGLIGIBLE":
+		return SeverityLow
+	}
+
+	return SeverityUnknown
+}
+
+// uniqueVulnerabilities removes the duplicate matches
+// (the same package can be reported for multiple scan targets)
+func uniqueVulnerabilities(vulns []*Vulnerability) []*Vulnerability {
+	var result []*Vulnerability
+	seen := map[string]struct{}{}
+	for _, v := range vulns {
+		if _, found := seen[v.key()]; found {
+			continue
+		}
+
+		seen[v.key()] = struct{}{}
+		result = append(result, v)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key() < result[j].key()
+	})
+
+	return result
+}
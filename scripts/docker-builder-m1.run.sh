@@ -7,7 +7,7 @@ while [ -h "$SOURCE" ] ; do SOURCE="$(readlink "$SOURCE")"; done
 BDIR="$( cd -P "$( dirname "$SOURCE" )/.." && pwd )"
 
 pushd $BDIR
-docker run -v $(pwd):/go/src/github.com/docker-slim/docker-slim -w /go/src/github.com/docker-slim/docker-slim -it --rm --name="docker-slim-builder" golang:1.16 make build_m1
+docker run -v $(pwd):/go/src/github.com/docker-slim/docker-slim -w /go/src/github.com/docker-slim/docker-slim -e RELEASE_PUBLIC_KEY -e RELEASE_BUILD -it --rm --name="docker-slim-builder" golang:1.16 make build_m1
 
 if [ ! -f dist_mac_m1.zip ]; then
 if hash zip 2> /dev/null; then
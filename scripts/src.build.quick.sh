@@ -14,7 +14,13 @@ if hash git 2>/dev/null && [ -e $BDIR/.git ]; then
   REVISION="$(git rev-parse HEAD)"
 fi
 
-LD_FLAGS="-s -w -X github.com/docker-slim/docker-slim/pkg/version.appVersionTag=${TAG} -X github.com/docker-slim/docker-slim/pkg/version.appVersionRev=${REVISION} -X github.com/docker-slim/docker-slim/pkg/version.appVersionTime=${BUILD_TIME}"
+# RELEASE_PUBLIC_KEY: the base64 encoded Ed25519 release signing public key
+# (the update command refuses to install the release packages it can't verify;
+# the development builds without the key need 'update --skip-verify')
+if [ -z "${RELEASE_PUBLIC_KEY}" ] && [ -n "${RELEASE_PUBLIC_KEY_FILE}" ]; then
+  RELEASE_PUBLIC_KEY="$(tr -d '[:space:]' < "${RELEASE_PUBLIC_KEY_FILE}")"
+fi
+LD_FLAGS="-s -w -X github.com/docker-slim/docker-slim/pkg/version.appVersionTag=${TAG} -X github.com/docker-slim/docker-slim/pkg/version.appVersionRev=${REVISION} -X github.com/docker-slim/docker-slim/pkg/version.appVersionTime=${BUILD_TIME} -X github.com/docker-slim/docker-slim/pkg/app/master/update.releasePublicKey=${RELEASE_PUBLIC_KEY}"
 
 BINDIR="${BDIR}/bin"
 mkdir -p "$BINDIR"
@@ -18,7 +18,17 @@ if hash git 2>/dev/null && [ -e $BDIR/.git ]; then
   REVISION="$(git rev-parse HEAD)"
 fi
 
-LD_FLAGS="-s -w -X github.com/docker-slim/docker-slim/pkg/version.appVersionTag=${TAG} -X github.com/docker-slim/docker-slim/pkg/version.appVersionRev=${REVISION} -X github.com/docker-slim/docker-slim/pkg/version.appVersionTime=${BUILD_TIME}"
+# RELEASE_PUBLIC_KEY: the base64 encoded Ed25519 release signing public key
+# (the update command refuses to install the release packages it can't verify;
+# set RELEASE_BUILD=true to fail the build if the key is not provided)
+if [ -z "${RELEASE_PUBLIC_KEY}" ] && [ -n "${RELEASE_PUBLIC_KEY_FILE}" ]; then
+  RELEASE_PUBLIC_KEY="$(tr -d '[:space:]' < "${RELEASE_PUBLIC_KEY_FILE}")"
+fi
+if [ -z "${RELEASE_PUBLIC_KEY}" ] && [ "${RELEASE_BUILD}" = "true" ]; then
+  echo "RELEASE_PUBLIC_KEY or RELEASE_PUBLIC_KEY_FILE is required for the release builds" >&2
+  exit 1
+fi
+LD_FLAGS="-s -w -X github.com/docker-slim/docker-slim/pkg/version.appVersionTag=${TAG} -X github.com/docker-slim/docker-slim/pkg/version.appVersionRev=${REVISION} -X github.com/docker-slim/docker-slim/pkg/version.appVersionTime=${BUILD_TIME} -X github.com/docker-slim/docker-slim/pkg/app/master/update.releasePublicKey=${RELEASE_PUBLIC_KEY}"
 
 pushd ${BDIR}/cmd/docker-slim
 GOOS=linux GOARCH=amd64 go build -mod=vendor -trimpath -ldflags="${LD_FLAGS}" -a -tags 'netgo osusergo' -o "${BDIR}/bin/linux/docker-slim" 